@@ -0,0 +1,130 @@
+// Package about reads account and service information from about.get
+package about
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Manager reads about.get data
+type Manager struct {
+	client *api.Client
+
+	mu      sync.Mutex
+	formats *types.FormatMatrix
+}
+
+// NewManager creates a new about manager
+func NewManager(client *api.Client) *Manager {
+	return &Manager{client: client}
+}
+
+// Formats returns the live import/export conversion matrix. The result is
+// cached for the lifetime of the manager, since it does not change within
+// a single command.
+func (m *Manager) Formats(ctx context.Context, reqCtx *types.RequestContext) (*types.FormatMatrix, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.formats != nil {
+		return m.formats, nil
+	}
+
+	call := m.client.Service().About.Get().Fields("importFormats,exportFormats")
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.About, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.formats = &types.FormatMatrix{
+		Source:        types.FormatSourceAPI,
+		ImportFormats: result.ImportFormats,
+		ExportFormats: result.ExportFormats,
+	}
+	if m.formats.ImportFormats == nil {
+		m.formats.ImportFormats = map[string][]string{}
+	}
+	if m.formats.ExportFormats == nil {
+		m.formats.ExportFormats = map[string][]string{}
+	}
+	return m.formats, nil
+}
+
+// accountFields are the about.get fields Account reads
+const accountFields = "user(displayName,emailAddress,permissionId),storageQuota,maxUploadSize,importFormats,exportFormats"
+
+// Account returns the user, storage quota, upload limit and conversion
+// formats of the authenticated account
+func (m *Manager) Account(ctx context.Context, reqCtx *types.RequestContext) (*types.AboutAccount, error) {
+	result, err := m.get(ctx, reqCtx, accountFields)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &types.AboutAccount{
+		MaxUploadSize: result.MaxUploadSize,
+		ImportFormats: result.ImportFormats,
+		ExportFormats: result.ExportFormats,
+		StorageQuota:  convertQuota(result.StorageQuota),
+	}
+	if result.User != nil {
+		account.User = types.AboutUser{
+			DisplayName:  result.User.DisplayName,
+			EmailAddress: result.User.EmailAddress,
+			PermissionID: result.User.PermissionId,
+		}
+	}
+	return account, nil
+}
+
+// Quota returns the storage quota of the authenticated account. A
+// threshold above 0 is a usage percentage to check against; an account
+// with unlimited storage never exceeds it.
+func (m *Manager) Quota(ctx context.Context, reqCtx *types.RequestContext, threshold float64) (*types.QuotaReport, error) {
+	result, err := m.get(ctx, reqCtx, "user(emailAddress),storageQuota")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.QuotaReport{
+		StorageQuota: convertQuota(result.StorageQuota),
+		Threshold:    threshold,
+	}
+	if result.User != nil {
+		report.EmailAddress = result.User.EmailAddress
+	}
+	if threshold > 0 && report.UsedPercent != nil {
+		report.Exceeded = *report.UsedPercent >= threshold
+	}
+	return report, nil
+}
+
+func (m *Manager) get(ctx context.Context, reqCtx *types.RequestContext, fields string) (*drive.About, error) {
+	call := m.client.Service().About.Get().Fields(googleapi.Field(fields))
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.About, error) {
+		return call.Context(ctx).Do()
+	})
+}
+
+func convertQuota(q *drive.AboutStorageQuota) types.StorageQuota {
+	if q == nil {
+		return types.StorageQuota{}
+	}
+	quota := types.StorageQuota{
+		Limit:             q.Limit,
+		Usage:             q.Usage,
+		UsageInDrive:      q.UsageInDrive,
+		UsageInDriveTrash: q.UsageInDriveTrash,
+	}
+	if q.Limit > 0 {
+		percent := float64(q.Usage) * 100 / float64(q.Limit)
+		quota.UsedPercent = &percent
+	}
+	return quota
+}
@@ -0,0 +1,101 @@
+package about
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestFormats_FetchesOnce(t *testing.T) {
+	var calls int32
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if got := r.URL.Query().Get("fields"); got != "importFormats,exportFormats" {
+			t.Errorf("fields = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"importFormats": {"text/csv": ["application/vnd.google-apps.spreadsheet"]},
+			"exportFormats": {"application/vnd.google-apps.document": ["application/pdf", "text/markdown"]}
+		}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	for i := 0; i < 2; i++ {
+		matrix, err := mgr.Formats(ctx, reqCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matrix.Source != types.FormatSourceAPI {
+			t.Errorf("Source = %q", matrix.Source)
+		}
+		if got := matrix.ExportTargets("application/vnd.google-apps.document"); len(got) != 2 || got[1] != "text/markdown" {
+			t.Errorf("export targets = %v", got)
+		}
+		if got := matrix.ImportTargets("text/csv"); len(got) != 1 {
+			t.Errorf("import targets = %v", got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 about.get call, got %d", calls)
+	}
+}
+
+func TestAccountAndQuota(t *testing.T) {
+	limit := `"limit":"1000",`
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"user": {"displayName": "Ada", "emailAddress": "ada@example.com", "permissionId": "123"},
+			"storageQuota": {` + limit + `"usage":"900","usageInDrive":"600","usageInDriveTrash":"50"},
+			"maxUploadSize": "5497558138880",
+			"exportFormats": {"application/vnd.google-apps.document": ["application/pdf"]}
+		}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	account, err := mgr.Account(ctx, reqCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.User.EmailAddress != "ada@example.com" || account.MaxUploadSize != 5497558138880 || len(account.ExportFormats) != 1 {
+		t.Errorf("account = %+v", account)
+	}
+	q := account.StorageQuota
+	if q.Limit != 1000 || q.UsageInDrive != 600 || q.UsageInDriveTrash != 50 || q.UsedPercent == nil || *q.UsedPercent != 90 {
+		t.Errorf("quota = %+v", q)
+	}
+
+	for _, tt := range []struct {
+		threshold float64
+		exceeded  bool
+	}{{0, false}, {95, false}, {90, true}, {85, true}} {
+		report, err := mgr.Quota(ctx, reqCtx, tt.threshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Exceeded != tt.exceeded || report.EmailAddress != "ada@example.com" {
+			t.Errorf("threshold %g: exceeded = %v, want %v", tt.threshold, report.Exceeded, tt.exceeded)
+		}
+	}
+
+	limit = ""
+	report, err := mgr.Quota(ctx, reqCtx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Exceeded || report.UsedPercent != nil || report.Limit != 0 {
+		t.Errorf("unlimited storage: %+v", report)
+	}
+}
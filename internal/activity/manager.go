@@ -8,27 +8,22 @@ import (
 
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/types"
-	"github.com/dl-alexandre/gdrv/internal/utils"
 	"google.golang.org/api/driveactivity/v2"
 )
 
 type Manager struct {
-	client *api.Client
+	client  *api.Client
+	service *driveactivity.Service
 }
 
-func NewManager(client *api.Client) *Manager {
+func NewManager(client *api.Client, service *driveactivity.Service) *Manager {
 	return &Manager{
-		client: client,
+		client:  client,
+		service: service,
 	}
 }
 
 func (m *Manager) Query(ctx context.Context, reqCtx *types.RequestContext, opts types.QueryOptions) ([]types.Activity, error) {
-	service, err := driveactivity.NewService(ctx)
-	if err != nil {
-		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
-			fmt.Sprintf("Failed to create Drive Activity service: %s", err)).Build())
-	}
-
 	req := &driveactivity.QueryDriveActivityRequest{}
 
 	if opts.FileID != "" {
@@ -76,7 +71,7 @@ func (m *Manager) Query(ctx context.Context, reqCtx *types.RequestContext, opts
 	}
 
 	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*driveactivity.QueryDriveActivityResponse, error) {
-		return service.Activity.Query(req).Do()
+		return m.service.Activity.Query(req).Do()
 	})
 	if err != nil {
 		return nil, err
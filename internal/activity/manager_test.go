@@ -11,7 +11,7 @@ import (
 
 func TestManager_Creation(t *testing.T) {
 	client := &api.Client{}
-	manager := NewManager(client)
+	manager := NewManager(client, nil)
 
 	if manager == nil {
 		t.Fatal("NewManager returned nil")
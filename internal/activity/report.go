@@ -0,0 +1,252 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/driveactivity/v2"
+)
+
+// ReportActions are the actions a report covers: the changes a compliance
+// investigation asks about
+const ReportActions = "edit,move,rename,comment,permission_change"
+
+// reportPageSize is the largest page the Activity API returns
+const reportPageSize = 100
+
+// ReportOptions selects the item and time window of a report
+type ReportOptions struct {
+	ItemID string
+	// Folder reports on everything under ItemID instead of the item itself
+	Folder bool
+	Since  time.Time
+	// Limit caps the number of entries; 0 reads every page
+	Limit     int
+	PageToken string
+}
+
+// ReportEntry is one activity on the item or, for a folder, on an item
+// under it
+type ReportEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Actors []string  `json:"actors"`
+	ItemID string    `json:"itemId,omitempty"`
+	Item   string    `json:"item,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Report lists who changed an item, newest first
+type Report struct {
+	ItemID  string         `json:"itemId"`
+	Folder  bool           `json:"folder"`
+	Since   time.Time      `json:"since"`
+	Entries []ReportEntry  `json:"entries"`
+	Counts  map[string]int `json:"counts"`
+	// NextPageToken is set when Limit stopped the report early
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// Report reads the edit, move, rename, comment and permission change
+// activity on an item since opts.Since, following pages until the activity
+// or opts.Limit runs out
+func (m *Manager) Report(ctx context.Context, reqCtx *types.RequestContext, opts ReportOptions) (*Report, error) {
+	if opts.ItemID == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, "An item ID is required").Build())
+	}
+
+	req := &driveactivity.QueryDriveActivityRequest{
+		Filter: fmt.Sprintf("(%s) AND (%s)",
+			buildTimeFilter(opts.Since.UTC().Format(time.RFC3339), ""), buildActionFilter(ReportActions)),
+		PageToken: opts.PageToken,
+	}
+	if opts.Folder {
+		req.AncestorName = "items/" + opts.ItemID
+		reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, opts.ItemID)
+	} else {
+		req.ItemName = "items/" + opts.ItemID
+		reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, opts.ItemID)
+	}
+
+	report := &Report{
+		ItemID:  opts.ItemID,
+		Folder:  opts.Folder,
+		Since:   opts.Since.UTC(),
+		Entries: []ReportEntry{},
+		Counts:  map[string]int{},
+	}
+	for {
+		// Ask for no more than the limit leaves, so the next page token
+		// resumes exactly after the last entry reported
+		req.PageSize = reportPageSize
+		if opts.Limit > 0 && opts.Limit-len(report.Entries) < reportPageSize {
+			req.PageSize = int64(opts.Limit - len(report.Entries))
+		}
+
+		resp, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*driveactivity.QueryDriveActivityResponse, error) {
+			return m.service.Activity.Query(req).Context(ctx).Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, act := range resp.Activities {
+			entry := reportEntry(act)
+			report.Entries = append(report.Entries, entry)
+			report.Counts[entry.Action]++
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		if opts.Limit > 0 && len(report.Entries) >= opts.Limit {
+			report.NextPageToken = resp.NextPageToken
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	return report, nil
+}
+
+func reportEntry(act *driveactivity.DriveActivity) ReportEntry {
+	entry := ReportEntry{Action: "unknown"}
+
+	ts := act.Timestamp
+	if ts == "" && act.TimeRange != nil {
+		ts = act.TimeRange.EndTime
+	}
+	entry.Time, _ = parseTimestamp(ts)
+
+	for _, actor := range act.Actors {
+		entry.Actors = append(entry.Actors, actorName(actor))
+	}
+	for _, target := range act.Targets {
+		if target.DriveItem != nil {
+			entry.ItemID = strings.TrimPrefix(target.DriveItem.Name, "items/")
+			entry.Item = target.DriveItem.Title
+			break
+		}
+	}
+	if act.PrimaryActionDetail != nil {
+		entry.Action = getActionType(act.PrimaryActionDetail)
+		entry.Detail = describeAction(act.PrimaryActionDetail)
+	}
+
+	return entry
+}
+
+func actorName(actor *driveactivity.Actor) string {
+	switch {
+	case actor.User != nil && actor.User.KnownUser != nil:
+		if actor.User.KnownUser.IsCurrentUser {
+			return "me"
+		}
+		return actor.User.KnownUser.PersonName
+	case actor.User != nil && actor.User.DeletedUser != nil:
+		return "deleted user"
+	case actor.User != nil:
+		return "unknown user"
+	case actor.Administrator != nil:
+		return "administrator"
+	case actor.System != nil:
+		return "system"
+	case actor.Anonymous != nil:
+		return "anonymous"
+	case actor.Impersonation != nil && actor.Impersonation.ImpersonatedUser != nil &&
+		actor.Impersonation.ImpersonatedUser.KnownUser != nil:
+		return "impersonating " + actor.Impersonation.ImpersonatedUser.KnownUser.PersonName
+	}
+	return "unknown"
+}
+
+// describeAction summarizes what changed for the actions that carry more
+// than their type
+func describeAction(detail *driveactivity.ActionDetail) string {
+	switch {
+	case detail.Rename != nil:
+		return fmt.Sprintf("%q -> %q", detail.Rename.OldTitle, detail.Rename.NewTitle)
+	case detail.Move != nil:
+		var parts []string
+		if from := targetTitles(detail.Move.RemovedParents); from != "" {
+			parts = append(parts, "from "+from)
+		}
+		if to := targetTitles(detail.Move.AddedParents); to != "" {
+			parts = append(parts, "to "+to)
+		}
+		return strings.Join(parts, " ")
+	case detail.PermissionChange != nil:
+		var parts []string
+		if added := permissionList(detail.PermissionChange.AddedPermissions); added != "" {
+			parts = append(parts, "added "+added)
+		}
+		if removed := permissionList(detail.PermissionChange.RemovedPermissions); removed != "" {
+			parts = append(parts, "removed "+removed)
+		}
+		return strings.Join(parts, "; ")
+	case detail.Comment != nil:
+		switch {
+		case detail.Comment.Post != nil:
+			return strings.ToLower(detail.Comment.Post.Subtype)
+		case detail.Comment.Assignment != nil:
+			return "assignment " + strings.ToLower(detail.Comment.Assignment.Subtype)
+		case detail.Comment.Suggestion != nil:
+			return "suggestion " + strings.ToLower(detail.Comment.Suggestion.Subtype)
+		}
+	}
+	return ""
+}
+
+func targetTitles(refs []*driveactivity.TargetReference) string {
+	var titles []string
+	for _, ref := range refs {
+		switch {
+		case ref.DriveItem != nil:
+			titles = append(titles, ref.DriveItem.Title)
+		case ref.Drive != nil:
+			titles = append(titles, ref.Drive.Title)
+		}
+	}
+	return strings.Join(titles, ", ")
+}
+
+func permissionList(perms []*driveactivity.Permission) string {
+	list := make([]string, 0, len(perms))
+	for _, p := range perms {
+		who := "unknown"
+		switch {
+		case p.User != nil && p.User.KnownUser != nil:
+			who = p.User.KnownUser.PersonName
+		case p.Group != nil:
+			who = p.Group.Email
+		case p.Domain != nil:
+			who = "domain " + p.Domain.Name
+		case p.Anyone != nil:
+			who = "anyone"
+		}
+		list = append(list, fmt.Sprintf("%s (%s)", who, strings.ToLower(p.Role)))
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
+func (r *Report) Headers() []string {
+	return []string{"Time", "Action", "Actor", "Item", "Detail"}
+}
+
+func (r *Report) Rows() [][]string {
+	rows := make([][]string, len(r.Entries))
+	for i, e := range r.Entries {
+		rows[i] = []string{e.Time.Format("2006-01-02 15:04:05"), e.Action, strings.Join(e.Actors, ", "), e.Item, e.Detail}
+	}
+	return rows
+}
+
+func (r *Report) EmptyMessage() string {
+	return fmt.Sprintf("No activity since %s", r.Since.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,115 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/option"
+)
+
+func TestReport(t *testing.T) {
+	var requests []driveactivity.QueryDriveActivityRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req driveactivity.QueryDriveActivityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		requests = append(requests, req)
+
+		switch req.PageToken {
+		case "":
+			_, _ = w.Write([]byte(`{"activities":[
+				{"timestamp":"2026-10-16T10:00:00Z",
+				 "primaryActionDetail":{"rename":{"oldTitle":"Draft","newTitle":"Final"}},
+				 "actors":[{"user":{"knownUser":{"personName":"people/1"}}}],
+				 "targets":[{"driveItem":{"name":"items/f1","title":"Final"}}]},
+				{"timestamp":"2026-10-15T10:00:00Z",
+				 "primaryActionDetail":{"permissionChange":{
+				   "addedPermissions":[{"role":"EDITOR","anyone":{}}],
+				   "removedPermissions":[{"role":"VIEWER","group":{"email":"team@example.com"}}]}},
+				 "actors":[{"user":{"knownUser":{"isCurrentUser":true}}}],
+				 "targets":[{"driveItem":{"name":"items/f1","title":"Draft"}}]}
+			],"nextPageToken":"p2"}`))
+		case "p2":
+			_, _ = w.Write([]byte(`{"activities":[
+				{"timeRange":{"startTime":"2026-10-14T09:00:00Z","endTime":"2026-10-14T10:00:00Z"},
+				 "primaryActionDetail":{"edit":{}},
+				 "actors":[{"user":{"knownUser":{"personName":"people/2"}}},{"administrator":{}}],
+				 "targets":[{"driveItem":{"name":"items/f1","title":"Draft"}}]}
+			],"nextPageToken":"p3"}`))
+		case "p3":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected page token %q", req.PageToken)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	service, err := driveactivity.NewService(ctx, option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewManager(api.NewClient(nil, 0, 100, nil), service)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+	since := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+
+	report, err := mgr.Report(ctx, reqCtx, ReportOptions{ItemID: "f1", Since: since, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("made %d requests, want 2", len(requests))
+	}
+	if requests[0].ItemName != "items/f1" || requests[0].AncestorName != "" {
+		t.Errorf("item = %q, ancestor = %q", requests[0].ItemName, requests[0].AncestorName)
+	}
+	if !strings.Contains(requests[0].Filter, "time >= '2026-10-10T00:00:00Z'") ||
+		!strings.Contains(requests[0].Filter, "PERMISSION_CHANGE") {
+		t.Errorf("filter = %q", requests[0].Filter)
+	}
+	if requests[0].PageSize != 3 || requests[1].PageSize != 1 {
+		t.Errorf("page sizes = %d, %d, want 3, 1", requests[0].PageSize, requests[1].PageSize)
+	}
+
+	if len(report.Entries) != 3 || report.NextPageToken != "p3" {
+		t.Fatalf("entries = %d, next page = %q", len(report.Entries), report.NextPageToken)
+	}
+	rename, perms, edit := report.Entries[0], report.Entries[1], report.Entries[2]
+	if rename.Action != "rename" || rename.Detail != `"Draft" -> "Final"` || rename.Actors[0] != "people/1" || rename.ItemID != "f1" {
+		t.Errorf("rename = %+v", rename)
+	}
+	if perms.Detail != "added anyone (editor); removed team@example.com (viewer)" || perms.Actors[0] != "me" {
+		t.Errorf("permission change = %+v", perms)
+	}
+	if edit.Action != "edit" || !edit.Time.Equal(time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)) ||
+		strings.Join(edit.Actors, ",") != "people/2,administrator" {
+		t.Errorf("edit = %+v", edit)
+	}
+	if report.Counts["rename"] != 1 || report.Counts["permission_change"] != 1 || report.Counts["edit"] != 1 {
+		t.Errorf("counts = %v", report.Counts)
+	}
+
+	requests = nil
+	report, err = mgr.Report(ctx, reqCtx, ReportOptions{ItemID: "d1", Folder: true, Since: since, PageToken: "p2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || requests[0].AncestorName != "items/d1" || requests[0].ItemName != "" {
+		t.Errorf("folder requests = %+v", requests)
+	}
+	if len(report.Entries) != 1 || report.NextPageToken != "" {
+		t.Errorf("resumed report = %d entries, next page %q", len(report.Entries), report.NextPageToken)
+	}
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	adminapi "google.golang.org/api/admin/directory/v1"
+)
+
+// ListUserAliases lists the alternate email addresses of a user
+func (m *Manager) ListUserAliases(ctx context.Context, reqCtx *types.RequestContext, userKey string) (*types.UserAliasesResponse, error) {
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*adminapi.Aliases, error) {
+		return m.service.Users.Aliases.List(userKey).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertAliases(userKey, result), nil
+}
+
+// AddUserAlias adds an alternate email address to a user
+func (m *Manager) AddUserAlias(ctx context.Context, reqCtx *types.RequestContext, userKey, alias string) (*types.UserAlias, error) {
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*adminapi.Alias, error) {
+		return m.service.Users.Aliases.Insert(userKey, &adminapi.Alias{Alias: alias}).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.UserAlias{Alias: result.Alias, PrimaryEmail: result.PrimaryEmail, ID: result.Id}, nil
+}
+
+// DeleteUserAlias removes an alternate email address from a user
+func (m *Manager) DeleteUserAlias(ctx context.Context, reqCtx *types.RequestContext, userKey, alias string) error {
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (struct{}, error) {
+		return struct{}{}, m.service.Users.Aliases.Delete(userKey, alias).Do()
+	})
+	return err
+}
+
+// convertAliases reads the untyped alias list the Directory API returns
+func convertAliases(userKey string, aliases *adminapi.Aliases) *types.UserAliasesResponse {
+	resp := &types.UserAliasesResponse{User: userKey, Aliases: []types.UserAlias{}}
+	if aliases == nil {
+		return resp
+	}
+	for _, raw := range aliases.Aliases {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		alias := types.UserAlias{}
+		alias.Alias, _ = fields["alias"].(string)
+		alias.PrimaryEmail, _ = fields["primaryEmail"].(string)
+		alias.ID, _ = fields["id"].(string)
+		if alias.Alias != "" {
+			resp.Aliases = append(resp.Aliases, alias)
+		}
+	}
+	return resp
+}
@@ -365,6 +365,20 @@ func (m *Manager) RemoveMember(ctx context.Context, reqCtx *types.RequestContext
 	return err
 }
 
+// ListDomains lists a customer's domains with their verified aliases
+func (m *Manager) ListDomains(ctx context.Context, reqCtx *types.RequestContext, customer string) (*types.DomainsListResponse, error) {
+	if customer == "" {
+		customer = "my_customer"
+	}
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*adminapi.Domains2, error) {
+		return m.service.Domains.List(customer).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertDomains(result), nil
+}
+
 func convertUsers(users *adminapi.Users) []types.User {
 	if users == nil || users.Users == nil {
 		return []types.User{}
@@ -456,3 +470,23 @@ func convertMember(member *adminapi.Member) types.Member {
 		Status: member.Status,
 	}
 }
+
+func convertDomains(domains *adminapi.Domains2) *types.DomainsListResponse {
+	resp := &types.DomainsListResponse{Domains: []types.Domain{}}
+	if domains == nil {
+		return resp
+	}
+	for _, d := range domains.Domains {
+		if d == nil {
+			continue
+		}
+		domain := types.Domain{Name: d.DomainName, Primary: d.IsPrimary, Verified: d.Verified}
+		for _, alias := range d.DomainAliases {
+			if alias != nil && alias.Verified {
+				domain.Aliases = append(domain.Aliases, alias.DomainAliasName)
+			}
+		}
+		resp.Domains = append(resp.Domains, domain)
+	}
+	return resp
+}
@@ -111,3 +111,39 @@ func TestConvertGroup(t *testing.T) {
 		}
 	})
 }
+
+func TestConvertDomains(t *testing.T) {
+	if got := convertDomains(nil); len(got.Domains) != 0 {
+		t.Fatalf("expected empty domains, got %d", len(got.Domains))
+	}
+
+	got := convertDomains(&adminapi.Domains2{Domains: []*adminapi.Domains{
+		{DomainName: "example.com", IsPrimary: true, Verified: true, DomainAliases: []*adminapi.DomainAlias{
+			{DomainAliasName: "example.co.uk", Verified: true},
+			{DomainAliasName: "pending.example", Verified: false},
+		}},
+		{DomainName: "unverified.com"},
+	}})
+	if len(got.Domains) != 2 || !got.Domains[0].Primary {
+		t.Fatalf("unexpected domains: %+v", got.Domains)
+	}
+	names := got.Names()
+	if len(names) != 2 || names[0] != "example.com" || names[1] != "example.co.uk" {
+		t.Fatalf("expected verified names only, got %v", names)
+	}
+}
+
+func TestConvertAliases(t *testing.T) {
+	if got := convertAliases("a@example.com", nil); got.User != "a@example.com" || len(got.Aliases) != 0 {
+		t.Fatalf("unexpected empty response: %+v", got)
+	}
+
+	got := convertAliases("a@example.com", &adminapi.Aliases{Aliases: []interface{}{
+		map[string]interface{}{"alias": "al@example.com", "primaryEmail": "a@example.com", "id": "1"},
+		map[string]interface{}{"kind": "admin#directory#alias"},
+		"unexpected",
+	}})
+	if len(got.Aliases) != 1 || got.Aliases[0].Alias != "al@example.com" || got.Aliases[0].PrimaryEmail != "a@example.com" {
+		t.Fatalf("unexpected aliases: %+v", got.Aliases)
+	}
+}
@@ -0,0 +1,236 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Ownership report sort orders
+const (
+	OwnershipSortRisk = "risk"
+	OwnershipSortSize = "size"
+)
+
+// Items owned by a user are never in a Shared Drive, so this covers exactly
+// the content a migration would have to move
+const ownedItemsQuery = "'me' in owners and trashed = false"
+
+const ownedItemsFields = "nextPageToken,files(id,mimeType,size,quotaBytesUsed,shared,permissions(type,emailAddress,domain))"
+
+// DriveForUser returns a Drive client acting as the given user
+type DriveForUser func(ctx context.Context, email string) (*api.Client, error)
+
+// OwnershipReportOptions configures an ownership sweep
+type OwnershipReportOptions struct {
+	Domain string
+	// Users limits the sweep to these addresses instead of every active
+	// user in Domain
+	Users    []string
+	MaxUsers int
+	Internal *permissions.DomainMatcher
+	Workers  int
+	SortBy   string
+}
+
+// OwnershipReport impersonates each active user in the domain and
+// aggregates the content they own outside Shared Drives, with how much of it
+// is shared externally or publicly. A user that cannot be scanned is
+// reported with an error rather than failing the sweep.
+func (m *Manager) OwnershipReport(ctx context.Context, reqCtx *types.RequestContext, opts OwnershipReportOptions, driveFor DriveForUser) (*types.OwnershipReport, error) {
+	emails := opts.Users
+	if len(emails) == 0 {
+		users, err := m.ListUsers(ctx, reqCtx, &ListUsersOptions{
+			Domain:   opts.Domain,
+			Fields:   "nextPageToken,users(primaryEmail,suspended)",
+			Paginate: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users.Users {
+			if !u.Suspended {
+				emails = append(emails, u.PrimaryEmail)
+			}
+		}
+	}
+	if opts.MaxUsers > 0 && len(emails) > opts.MaxUsers {
+		emails = emails[:opts.MaxUsers]
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]*types.UserOwnership, len(emails))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = scanUserOwnership(ctx, emails[i], opts.Internal, driveFor)
+			}
+		}()
+	}
+	for i := range emails {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &types.OwnershipReport{
+		Domain:          opts.Domain,
+		InternalDomains: opts.Internal.Patterns(),
+		SortBy:          opts.SortBy,
+		Users:           results,
+	}
+	for _, u := range results {
+		report.UsersScanned++
+		if u.Error != "" {
+			report.UsersFailed++
+		}
+		report.TotalFiles += u.Files
+		report.TotalFolders += u.Folders
+		report.TotalBytes += u.Bytes
+		report.ExternalItems += u.ExternalItems
+		report.PublicItems += u.PublicItems
+	}
+	sortOwnership(report.Users, opts.SortBy)
+	return report, nil
+}
+
+// scanUserOwnership lists everything one user owns
+func scanUserOwnership(ctx context.Context, email string, internal *permissions.DomainMatcher, driveFor DriveForUser) *types.UserOwnership {
+	stats := &types.UserOwnership{Email: email}
+	client, err := driveFor(ctx, email)
+	if err != nil {
+		stats.Error = err.Error()
+		return stats
+	}
+	reqCtx := api.NewRequestContext("", "", types.RequestTypeListOrSearch)
+
+	pageToken := ""
+	for {
+		call := client.Service().Files.List().
+			Q(ownedItemsQuery).
+			PageSize(1000).
+			Fields(googleapi.Field(ownedItemsFields))
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		result, err := api.ExecuteWithRetry(ctx, client, reqCtx, func() (*drive.FileList, error) {
+			return call.Context(ctx).Do()
+		})
+		if err != nil {
+			stats.Error = ownershipScanError(err)
+			return stats
+		}
+		for _, f := range result.Files {
+			addOwnedItem(stats, f, internal)
+		}
+		if result.NextPageToken == "" {
+			return stats
+		}
+		pageToken = result.NextPageToken
+	}
+}
+
+// ownershipScanError shortens the common impersonation failure, which means
+// the account cannot be acted as (e.g. no Drive license)
+func ownershipScanError(err error) string {
+	msg := err.Error()
+	if appErr, ok := err.(*utils.AppError); ok {
+		msg = appErr.CLIError.Message
+	}
+	if strings.Contains(msg, "unauthorized_client") {
+		return "impersonation not permitted; check domain-wide delegation for the drive.readonly scope"
+	}
+	return msg
+}
+
+func addOwnedItem(stats *types.UserOwnership, f *drive.File, internal *permissions.DomainMatcher) {
+	if f.MimeType == utils.MimeTypeFolder {
+		stats.Folders++
+	} else {
+		stats.Files++
+		// Workspace files report no size; quotaBytesUsed covers them and blobs alike
+		if f.QuotaBytesUsed > 0 {
+			stats.Bytes += f.QuotaBytesUsed
+		} else {
+			stats.Bytes += f.Size
+		}
+	}
+	if f.Shared {
+		stats.SharedItems++
+	}
+
+	public, external := false, false
+	for _, p := range f.Permissions {
+		switch p.Type {
+		case "anyone":
+			public = true
+		case "domain":
+			external = external || !internal.MatchDomain(p.Domain)
+		case "user", "group":
+			// Deleted accounts have no address and grant nothing
+			external = external || (p.EmailAddress != "" && !internal.MatchEmail(p.EmailAddress))
+		}
+	}
+	if public {
+		stats.PublicItems++
+	}
+	if external {
+		stats.ExternalItems++
+	}
+}
+
+// sortOwnership orders users by exposure (public, then external items) or
+// by volume, with failed scans last
+func sortOwnership(users []*types.UserOwnership, sortBy string) {
+	sort.SliceStable(users, func(i, j int) bool {
+		a, b := users[i], users[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		if sortBy != OwnershipSortSize {
+			if a.PublicItems != b.PublicItems {
+				return a.PublicItems > b.PublicItems
+			}
+			if a.ExternalItems != b.ExternalItems {
+				return a.ExternalItems > b.ExternalItems
+			}
+		}
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Email < b.Email
+	})
+}
+
+// ValidateOwnershipSort checks a --sort value
+func ValidateOwnershipSort(sortBy string) error {
+	if sortBy != OwnershipSortRisk && sortBy != OwnershipSortSize {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid sort %q", sortBy)).
+			WithContext("validValues", "risk, size").
+			Build())
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+// ownedItemsClient serves a fixed files.list response, checking the query
+func ownedItemsClient(t *testing.T, files []*drive.File) *api.Client {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != ownedItemsQuery {
+			t.Errorf("unexpected query %q", q)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&drive.FileList{Files: files})
+	}))
+	return client
+}
+
+func TestOwnershipReport(t *testing.T) {
+	ctx := context.Background()
+	alice := ownedItemsClient(t, []*drive.File{
+		{Id: "1", MimeType: "application/pdf", Size: 100},
+		{Id: "2", MimeType: "application/vnd.google-apps.document", QuotaBytesUsed: 50, Shared: true,
+			Permissions: []*drive.Permission{{Type: "user", EmailAddress: "bob@example.com"}}},
+		{Id: "3", MimeType: "application/vnd.google-apps.folder"},
+	})
+	carol := ownedItemsClient(t, []*drive.File{
+		{Id: "4", MimeType: "text/plain", Size: 10, Shared: true,
+			Permissions: []*drive.Permission{{Type: "user", EmailAddress: "x@partner.com"}, {Type: "anyone"}}},
+		{Id: "5", MimeType: "text/plain", Size: 5, Shared: true,
+			Permissions: []*drive.Permission{{Type: "domain", Domain: "partner.com"}, {Type: "user"}}},
+	})
+	clients := map[string]*api.Client{"alice@example.com": alice, "carol@example.com": carol}
+
+	driveFor := func(ctx context.Context, email string) (*api.Client, error) {
+		client, ok := clients[email]
+		if !ok {
+			return nil, errors.New("no such user")
+		}
+		return client, nil
+	}
+
+	m := NewManager(nil, nil)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+	report, err := m.OwnershipReport(ctx, reqCtx, OwnershipReportOptions{
+		Domain:   "example.com",
+		Users:    []string{"alice@example.com", "dave@example.com", "carol@example.com"},
+		Internal: permissions.NewDomainMatcher("example.com"),
+		Workers:  2,
+		SortBy:   OwnershipSortRisk,
+	}, driveFor)
+	if err != nil {
+		t.Fatalf("OwnershipReport: %v", err)
+	}
+
+	if report.UsersScanned != 3 || report.UsersFailed != 1 {
+		t.Fatalf("scanned %d, failed %d", report.UsersScanned, report.UsersFailed)
+	}
+	if report.TotalFiles != 4 || report.TotalFolders != 1 || report.TotalBytes != 165 {
+		t.Fatalf("totals: files %d folders %d bytes %d", report.TotalFiles, report.TotalFolders, report.TotalBytes)
+	}
+	if report.ExternalItems != 2 || report.PublicItems != 1 {
+		t.Fatalf("external %d public %d", report.ExternalItems, report.PublicItems)
+	}
+
+	var order []string
+	for _, u := range report.Users {
+		order = append(order, u.Email)
+	}
+	if got := strings.Join(order, ","); got != "carol@example.com,alice@example.com,dave@example.com" {
+		t.Fatalf("risk order = %s", got)
+	}
+	a := report.Users[1]
+	if a.Files != 2 || a.Folders != 1 || a.Bytes != 150 || a.SharedItems != 1 || a.ExternalItems != 0 {
+		t.Fatalf("alice = %+v", a)
+	}
+	if report.Users[2].Error != "no such user" {
+		t.Fatalf("dave error = %q", report.Users[2].Error)
+	}
+
+	sortOwnership(report.Users, OwnershipSortSize)
+	if report.Users[0].Email != "alice@example.com" {
+		t.Fatalf("size order starts with %s", report.Users[0].Email)
+	}
+}
+
+func TestValidateOwnershipSort(t *testing.T) {
+	for _, s := range []string{OwnershipSortRisk, OwnershipSortSize} {
+		if err := ValidateOwnershipSort(s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+	}
+	if err := ValidateOwnershipSort("name"); err == nil {
+		t.Error("expected error for unknown sort")
+	}
+}
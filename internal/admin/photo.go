@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	adminapi "google.golang.org/api/admin/directory/v1"
+)
+
+// photoTypes maps detected image types to the Directory API's photo types
+var photoTypes = map[string]string{
+	"image/jpeg": "JPEG",
+	"image/png":  "PNG",
+	"image/gif":  "GIF",
+	"image/bmp":  "BMP",
+}
+
+// GetUserPhoto returns a user's profile photo and its decoded image data
+func (m *Manager) GetUserPhoto(ctx context.Context, reqCtx *types.RequestContext, userKey string) (*types.UserPhoto, []byte, error) {
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*adminapi.UserPhoto, error) {
+		return m.service.Users.Photos.Get(userKey).Do()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := decodePhotoData(result.PhotoData)
+	if err != nil {
+		return nil, nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to decode photo of %s: %s", userKey, err)).Build())
+	}
+	return convertPhoto(result, len(data)), data, nil
+}
+
+// SetUserPhoto replaces a user's profile photo. The image must be a JPEG,
+// PNG, GIF, BMP or TIFF; the API scales it to 96x96 pixels.
+func (m *Manager) SetUserPhoto(ctx context.Context, reqCtx *types.RequestContext, userKey string, data []byte) (*types.UserPhoto, error) {
+	mimeType, err := PhotoMimeType(data)
+	if err != nil {
+		return nil, err
+	}
+	photo := &adminapi.UserPhoto{
+		MimeType:  mimeType,
+		PhotoData: base64.URLEncoding.EncodeToString(data),
+	}
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*adminapi.UserPhoto, error) {
+		return m.service.Users.Photos.Update(userKey, photo).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertPhoto(result, len(data)), nil
+}
+
+// DeleteUserPhoto removes a user's profile photo
+func (m *Manager) DeleteUserPhoto(ctx context.Context, reqCtx *types.RequestContext, userKey string) error {
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (struct{}, error) {
+		return struct{}{}, m.service.Users.Photos.Delete(userKey).Do()
+	})
+	return err
+}
+
+// PhotoMimeType returns the Directory API type of an image, or an error if
+// the image is not a format profile photos accept
+func PhotoMimeType(data []byte) (string, error) {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return "TIFF", nil
+	}
+	detected := http.DetectContentType(data)
+	if mimeType, ok := photoTypes[detected]; ok {
+		return mimeType, nil
+	}
+	return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("Unsupported photo type %s", detected)).
+		WithContext("suggestedAction", "use a JPEG, PNG, GIF, BMP or TIFF image").
+		Build())
+}
+
+// decodePhotoData decodes the API's web-safe base64, which may pad with
+// "=", "*" or "." depending on how the photo was uploaded
+func decodePhotoData(s string) ([]byte, error) {
+	s = strings.NewReplacer("*", "=", ".", "=").Replace(s)
+	return base64.URLEncoding.DecodeString(s)
+}
+
+func convertPhoto(photo *adminapi.UserPhoto, size int) *types.UserPhoto {
+	if photo == nil {
+		return &types.UserPhoto{}
+	}
+	return &types.UserPhoto{
+		PrimaryEmail: photo.PrimaryEmail,
+		MimeType:     photo.MimeType,
+		Width:        photo.Width,
+		Height:       photo.Height,
+		Size:         size,
+	}
+}
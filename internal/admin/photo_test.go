@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestPhotoMimeType(t *testing.T) {
+	cases := map[string]string{
+		"\xff\xd8\xff\xe0\x00\x10JFIF": "JPEG",
+		"\x89PNG\r\n\x1a\n":            "PNG",
+		"GIF89a":                       "GIF",
+		"BM\x00\x00":                   "BMP",
+		"II*\x00\x08\x00":              "TIFF",
+	}
+	for data, want := range cases {
+		if got, err := PhotoMimeType([]byte(data)); err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", data, got, err, want)
+		}
+	}
+	if _, err := PhotoMimeType([]byte("%PDF-1.7")); err == nil {
+		t.Error("expected an error for a PDF")
+	}
+}
+
+func TestDecodePhotoData(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0xbf, 0x01}
+	encoded := base64.URLEncoding.EncodeToString(data)
+	for _, s := range []string{encoded, "-_-_AQ**", "-_-_AQ.."} {
+		got, err := decodePhotoData(s)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%q: got %v, %v", s, got, err)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/googleapi"
+)
+
+// MaxBatchSize is the most calls Drive accepts in one batch request
+const MaxBatchSize = 100
+
+// BatchCall is one Drive API call sent as part of a batch request
+type BatchCall struct {
+	Method string
+	// Path is relative to the Drive v3 base, e.g. "files/<id>/permissions"
+	Path   string
+	Query  url.Values
+	Header http.Header
+	// Body is sent as JSON when not nil
+	Body interface{}
+}
+
+// BatchResult is the outcome of one BatchCall
+type BatchResult struct {
+	StatusCode int
+	Body       []byte
+	// Err is the call's error, classified like ExecuteWithRetry errors
+	Err error
+}
+
+// Decode unmarshals a successful call's response body into v
+func (r *BatchResult) Decode(v interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if len(r.Body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Body, v)
+}
+
+// ExecuteBatch sends calls as Drive batch requests of up to MaxBatchSize
+// calls each, so a bulk change costs one HTTP request per batch instead of
+// one per call. Each call succeeds or fails on its own: calls answered with
+// a retryable status (429, 5xx) are sent again in a later batch, with the
+// same backoff as ExecuteWithRetry, and other failures are reported in
+// their BatchResult. Results are in the order of calls. The error is set
+// only when a batch request as a whole could not be sent, in which case
+// the calls in it are failed with that error too.
+func (c *Client) ExecuteBatch(ctx context.Context, reqCtx *types.RequestContext, calls []BatchCall) ([]BatchResult, error) {
+	if c.httpClient == nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInternalError,
+			"Batch requests need an authenticated HTTP client").Build())
+	}
+	results := make([]BatchResult, len(calls))
+	for start := 0; start < len(calls); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		if err := c.executeBatchChunk(ctx, reqCtx, calls[start:end], results[start:end]); err != nil {
+			for i := end; i < len(calls); i++ {
+				results[i].Err = err
+			}
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// executeBatchChunk sends up to MaxBatchSize calls, retrying those that
+// fail with a retryable status
+func (c *Client) executeBatchChunk(ctx context.Context, reqCtx *types.RequestContext, calls []BatchCall, results []BatchResult) error {
+	logger := c.logger.WithTraceID(reqCtx.TraceID)
+	pending := make([]int, len(calls))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for attempt := 0; ; attempt++ {
+		batch := make([]BatchCall, len(pending))
+		for i, idx := range pending {
+			batch[i] = calls[idx]
+		}
+		logger.Info("Batch request starting",
+			logging.F("requestType", reqCtx.RequestType),
+			logging.F("calls", len(batch)),
+			logging.F("attempt", attempt+1),
+		)
+
+		responses, err := c.sendBatch(ctx, batch)
+		if err != nil {
+			if !isRetryable(err) || attempt >= c.maxRetries {
+				if _, ok := err.(*utils.AppError); !ok {
+					err = classifyError(err, reqCtx, c.logger)
+				}
+				failPending(results, pending, attempt+1, err)
+				return err
+			}
+			if waitErr := sleepContext(ctx, calculateBackoff(c.retryDelay, attempt, err)); waitErr != nil {
+				failPending(results, pending, attempt+1, waitErr)
+				return waitErr
+			}
+			continue
+		}
+
+		var retry []int
+		var retryErr error
+		for i, idx := range pending {
+			resp := responses[i]
+			results[idx] = BatchResult{StatusCode: resp.StatusCode, Body: resp.body}
+			if resp.err != nil && isRetryable(resp.err) && attempt < c.maxRetries {
+				retry = append(retry, idx)
+				retryErr = resp.err
+				continue
+			}
+			recordOperation(attempt+1, resp.err)
+			if resp.err != nil {
+				results[idx].Err = classifyError(resp.err, reqCtx, c.logger)
+			}
+		}
+		if len(retry) == 0 {
+			return nil
+		}
+		logger.Warn("Retrying failed batch calls",
+			logging.F("calls", len(retry)),
+			logging.F("attempt", attempt+1),
+		)
+		pending = retry
+		if err := sleepContext(ctx, calculateBackoff(c.retryDelay, attempt, retryErr)); err != nil {
+			failPending(results, pending, attempt+1, err)
+			return err
+		}
+	}
+}
+
+// failPending fails the calls still pending in a batch with err
+func failPending(results []BatchResult, pending []int, attempts int, err error) {
+	for _, idx := range pending {
+		recordOperation(attempts, err)
+		results[idx].Err = err
+	}
+}
+
+// batchResponse is one part of a batch response
+type batchResponse struct {
+	StatusCode int
+	body       []byte
+	err        error // *googleapi.Error for an error status
+}
+
+// batchURL returns the batch endpoint for the service's base path, e.g.
+// https://www.googleapis.com/batch/drive/v3, and the path calls are
+// relative to
+func (c *Client) batchURL() (endpoint, basePath string, err error) {
+	base, err := url.Parse(c.service.BasePath)
+	if err != nil {
+		return "", "", err
+	}
+	basePath = strings.TrimSuffix(base.Path, "/")
+	batch := *base
+	batch.Path = "/batch" + basePath
+	return batch.String(), basePath, nil
+}
+
+// sendBatch sends one multipart/mixed batch request and returns the
+// responses in the order of calls
+func (c *Client) sendBatch(ctx context.Context, calls []BatchCall) ([]batchResponse, error) {
+	endpoint, basePath, err := c.batchURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for i, call := range calls {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"application/http"},
+			"Content-Id":   {fmt.Sprintf("<item-%d>", i)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBatchCall(part, basePath, call); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if appErr := unwrapAppError(err); appErr != nil {
+			return nil, appErr
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	return readBatchResponse(resp, len(calls))
+}
+
+// writeBatchCall writes call as an HTTP request in a batch part
+func writeBatchCall(w io.Writer, basePath string, call BatchCall) error {
+	target := basePath + "/" + strings.TrimPrefix(call.Path, "/")
+	if len(call.Query) > 0 {
+		target += "?" + call.Query.Encode()
+	}
+	var payload []byte
+	if call.Body != nil {
+		var err error
+		if payload, err = json.Marshal(call.Body); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "%s %s HTTP/1.1\r\n", call.Method, target)
+	for key, values := range call.Header {
+		for _, v := range values {
+			fmt.Fprintf(w, "%s: %s\r\n", key, v)
+		}
+	}
+	if payload != nil {
+		fmt.Fprintf(w, "Content-Type: application/json; charset=UTF-8\r\n")
+		fmt.Fprintf(w, "Content-Length: %d\r\n", len(payload))
+	}
+	fmt.Fprint(w, "\r\n")
+	_, err := w.Write(payload)
+	return err
+}
+
+// readBatchResponse parses a multipart/mixed batch response. Parts are
+// matched to calls by their Content-ID, response-item-N, falling back to
+// their order.
+func readBatchResponse(resp *http.Response, n int) ([]batchResponse, error) {
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("unexpected batch response type %q", resp.Header.Get("Content-Type"))
+	}
+	responses := make([]batchResponse, n)
+	seen := make([]bool, n)
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for i := 0; ; i++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch response: %w", err)
+		}
+		idx := i
+		if id := strings.Trim(part.Header.Get("Content-Id"), "<>"); strings.HasPrefix(id, "response-item-") {
+			if parsed, err := strconv.Atoi(strings.TrimPrefix(id, "response-item-")); err == nil {
+				idx = parsed
+			}
+		}
+		if idx < 0 || idx >= n {
+			continue
+		}
+
+		inner, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch response part: %w", err)
+		}
+		data, err := io.ReadAll(inner.Body)
+		inner.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch response part: %w", err)
+		}
+		inner.Body = io.NopCloser(bytes.NewReader(data))
+		responses[idx] = batchResponse{StatusCode: inner.StatusCode, body: data, err: googleapi.CheckResponse(inner)}
+		seen[idx] = true
+	}
+	for i, ok := range seen {
+		if !ok {
+			responses[i] = batchResponse{err: &googleapi.Error{Code: http.StatusInternalServerError, Message: "no response to this call in the batch response"}}
+		}
+	}
+	return responses, nil
+}
+
+// unwrapAppError returns the AppError a transport, such as read-only mode,
+// failed the request with
+func unwrapAppError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		if appErr, ok := urlErr.Err.(*utils.AppError); ok {
+			return appErr
+		}
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// batchServer answers Drive batch requests, passing each inner request to
+// handle, which returns its status and JSON body
+func batchServer(t *testing.T, handle func(r *http.Request, body string) (int, string)) (*Client, *int) {
+	t.Helper()
+	var mu sync.Mutex
+	batches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/batch/drive/v3" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		batches++
+		mu.Unlock()
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Read every call before answering, as writing the response ends
+		// the request body
+		type answer struct {
+			id, body string
+			status   int
+		}
+		var answers []answer
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			inner, err := http.ReadRequest(bufio.NewReader(part))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(inner.Body)
+			status, respBody := handle(inner, string(body))
+			answers = append(answers, answer{id: strings.Trim(part.Header.Get("Content-Id"), "<>"), body: respBody, status: status})
+		}
+
+		out := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+out.Boundary())
+		for _, a := range answers {
+			pw, _ := out.CreatePart(map[string][]string{
+				"Content-Type": {"application/http"},
+				"Content-Id":   {"<response-" + a.id + ">"},
+			})
+			fmt.Fprintf(pw, "HTTP/1.1 %d %s\r\nContent-Type: application/json\r\n\r\n%s", a.status, http.StatusText(a.status), a.body)
+		}
+		out.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	service, err := drive.NewService(context.Background(), option.WithEndpoint(server.URL+"/drive/v3/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(service, 2, 1, nil)
+	client.SetHTTPClient(server.Client())
+	return client, &batches
+}
+
+func TestExecuteBatch(t *testing.T) {
+	var mu sync.Mutex
+	flaky := 0
+	client, batches := batchServer(t, func(r *http.Request, body string) (int, string) {
+		switch {
+		case r.URL.Path == "/drive/v3/files/a/permissions/p1" && r.Method == http.MethodPatch:
+			if r.URL.Query().Get("supportsAllDrives") != "true" || !strings.Contains(body, `"role":"reader"`) {
+				t.Errorf("update %s body %s", r.URL.RawQuery, body)
+			}
+			return http.StatusOK, `{"id":"p1","role":"reader"}`
+		case r.URL.Path == "/drive/v3/files/b/permissions/p2":
+			return http.StatusNotFound, `{"error":{"code":404,"message":"Permission not found","errors":[{"reason":"notFound"}]}}`
+		case r.URL.Path == "/drive/v3/files/c/permissions/p3":
+			mu.Lock()
+			defer mu.Unlock()
+			if flaky++; flaky == 1 {
+				return http.StatusServiceUnavailable, `{"error":{"code":503,"message":"Backend Error"}}`
+			}
+			return http.StatusNoContent, ``
+		}
+		t.Errorf("unexpected call %s %s", r.Method, r.URL.Path)
+		return http.StatusBadRequest, `{}`
+	})
+
+	query := url.Values{"supportsAllDrives": {"true"}}
+	calls := []BatchCall{
+		{Method: http.MethodPatch, Path: "files/a/permissions/p1", Query: query, Body: map[string]string{"role": "reader"}},
+		{Method: http.MethodDelete, Path: "files/b/permissions/p2", Query: query},
+		{Method: http.MethodDelete, Path: "files/c/permissions/p3", Query: query},
+	}
+	reqCtx := NewRequestContext("default", "", types.RequestTypeBatchOp)
+	results, err := client.ExecuteBatch(context.Background(), reqCtx, calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var perm drive.Permission
+	if err := results[0].Decode(&perm); err != nil || perm.Role != "reader" {
+		t.Errorf("result 0 = %+v, %v", perm, err)
+	}
+	appErr, ok := results[1].Err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeFileNotFound {
+		t.Errorf("result 1 err = %v, want FILE_NOT_FOUND", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].StatusCode != http.StatusNoContent {
+		t.Errorf("result 2 = %+v, want the retried call to succeed", results[2])
+	}
+	if *batches != 2 {
+		t.Errorf("sent %d batch requests, want 2 (one retry of the failed call)", *batches)
+	}
+}
+
+func TestExecuteBatch_SplitsAtMaxBatchSize(t *testing.T) {
+	client, batches := batchServer(t, func(r *http.Request, body string) (int, string) {
+		return http.StatusNoContent, ``
+	})
+	calls := make([]BatchCall, MaxBatchSize+5)
+	for i := range calls {
+		calls[i] = BatchCall{Method: http.MethodDelete, Path: fmt.Sprintf("files/f%d/permissions/p", i)}
+	}
+	results, err := client.ExecuteBatch(context.Background(), NewRequestContext("default", "", types.RequestTypeBatchOp), calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(calls) || *batches != 2 {
+		t.Errorf("%d results in %d batches", len(results), *batches)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("call %d: %v", i, r.Err)
+		}
+	}
+
+	client.SetHTTPClient(nil)
+	if _, err := client.ExecuteBatch(context.Background(), NewRequestContext("default", "", types.RequestTypeBatchOp), calls); err == nil {
+		t.Error("ExecuteBatch without an HTTP client should fail")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"math"
 	"math/rand"
+	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/dl-alexandre/gdrv/internal/errors"
+	"github.com/dl-alexandre/gdrv/internal/fieldmask"
 	"github.com/dl-alexandre/gdrv/internal/logging"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
@@ -19,10 +22,23 @@ import (
 // Client wraps the Drive API with retry logic and request shaping
 type Client struct {
 	service        *drive.Service
+	httpClient     *http.Client
 	resourceKeyMgr *ResourceKeyManager
+	driveOverrides *DriveOverrides
 	maxRetries     int
 	retryDelay     time.Duration
 	logger         logging.Logger
+	metadata       *metadataCache
+}
+
+// fieldsRecorder, when set, receives every successful API response so that
+// --fields-audit can compare returned fields with the ones a command uses
+var fieldsRecorder atomic.Pointer[fieldmask.Recorder]
+
+// SetFieldsRecorder enables (or with nil, disables) response field recording
+// for all clients
+func SetFieldsRecorder(recorder *fieldmask.Recorder) {
+	fieldsRecorder.Store(recorder)
 }
 
 // NewClient creates a new Drive API client
@@ -33,9 +49,11 @@ func NewClient(service *drive.Service, maxRetries int, retryDelayMs int, logger
 	return &Client{
 		service:        service,
 		resourceKeyMgr: NewResourceKeyManager(),
+		driveOverrides: NewDriveOverrides(),
 		maxRetries:     maxRetries,
 		retryDelay:     time.Duration(retryDelayMs) * time.Millisecond,
 		logger:         logger,
+		metadata:       newMetadataCache(),
 	}
 }
 
@@ -89,11 +107,16 @@ func ExecuteWithRetry[T any](ctx context.Context, client *Client, reqCtx *types.
 
 		result, lastErr = fn()
 		if lastErr == nil {
+			if recorder := fieldsRecorder.Load(); recorder != nil {
+				recorder.Record(result)
+			}
 			duration := time.Since(start)
 			logger.Info("API operation completed",
 				logging.F("duration_ms", duration.Milliseconds()),
 				logging.F("attempts", attempt+1),
 			)
+			recordOperation(attempt+1, nil)
+			recordLatency(reqCtx, duration, attempt+1, nil)
 			return result, nil
 		}
 
@@ -104,6 +127,8 @@ func ExecuteWithRetry[T any](ctx context.Context, client *Client, reqCtx *types.
 				logging.F("error", lastErr.Error()),
 				logging.F("attempts", attempt+1),
 			)
+			recordOperation(attempt+1, lastErr)
+			recordLatency(reqCtx, duration, attempt+1, lastErr)
 			return result, classifyError(lastErr, reqCtx, client.logger)
 		}
 
@@ -116,6 +141,8 @@ func ExecuteWithRetry[T any](ctx context.Context, client *Client, reqCtx *types.
 			)
 			select {
 			case <-ctx.Done():
+				recordOperation(attempt+1, ctx.Err())
+				recordLatency(reqCtx, time.Since(start), attempt+1, ctx.Err())
 				return result, ctx.Err()
 			case <-time.After(delay):
 			}
@@ -129,6 +156,8 @@ func ExecuteWithRetry[T any](ctx context.Context, client *Client, reqCtx *types.
 		logging.F("error", lastErr.Error()),
 	)
 
+	recordOperation(client.maxRetries+1, lastErr)
+	recordLatency(reqCtx, duration, client.maxRetries+1, lastErr)
 	return result, classifyError(lastErr, reqCtx, client.logger)
 }
 
@@ -197,3 +226,20 @@ func (c *Client) Service() *drive.Service {
 func (c *Client) ResourceKeys() *ResourceKeyManager {
 	return c.resourceKeyMgr
 }
+
+// DriveOverrides returns the per-drive request shaping overrides shared by
+// every manager using this client
+func (c *Client) DriveOverrides() *DriveOverrides {
+	return c.driveOverrides
+}
+
+// SetHTTPClient sets the authenticated HTTP client used for requests made
+// outside the generated Drive service, such as exportLinks downloads.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// HTTPClient returns the authenticated HTTP client, or nil if none was set
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
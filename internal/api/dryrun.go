@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// maxDryRunBody is the largest JSON request body decoded into a blocked
+// request's parameters
+const maxDryRunBody = 64 << 10
+
+// BlockedRequest describes a mutating request stopped by dry-run mode
+type BlockedRequest struct {
+	Method string
+	Path   string
+	// Params holds the query parameters and, for JSON requests, the body
+	Params map[string]interface{}
+}
+
+var (
+	dryRunMu   sync.RWMutex
+	dryRunHook func(BlockedRequest)
+)
+
+// SetDryRun makes every client refuse mutating requests, passing each one
+// to hook before failing it. Commands plan their changes before making any
+// request, so a request reaching the transport under --dry-run means the
+// command cannot preview it; refusing it guarantees a dry run never changes
+// anything. A nil hook turns dry-run mode off.
+func SetDryRun(hook func(BlockedRequest)) {
+	dryRunMu.Lock()
+	defer dryRunMu.Unlock()
+	dryRunHook = hook
+}
+
+func dryRunBlock(req *http.Request) (bool, error) {
+	dryRunMu.RLock()
+	hook := dryRunHook
+	dryRunMu.RUnlock()
+	if hook == nil {
+		return false, nil
+	}
+
+	blocked := BlockedRequest{Method: req.Method, Path: req.URL.Path, Params: map[string]interface{}{}}
+	for key, values := range req.URL.Query() {
+		if key == "prettyPrint" || key == "alt" {
+			continue
+		}
+		blocked.Params[key] = strings.Join(values, ",")
+	}
+	if req.Body != nil {
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+			var body interface{}
+			data, err := io.ReadAll(io.LimitReader(req.Body, maxDryRunBody))
+			if err == nil && json.Unmarshal(data, &body) == nil && body != nil {
+				blocked.Params["body"] = body
+			}
+		}
+		_ = req.Body.Close()
+	}
+	hook(blocked)
+	return true, DryRunError(req.Method + " " + req.URL.Path)
+}
+
+// DryRunError returns the error reported when dry-run mode stops a request
+// the command did not plan
+func DryRunError(operation string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeDryRun,
+		"Dry run: this command cannot preview its changes; the request was recorded in the plan and not sent").
+		WithContext("operation", operation).
+		Build())
+}
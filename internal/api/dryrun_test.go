@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var blocked []BlockedRequest
+	SetDryRun(func(req BlockedRequest) { blocked = append(blocked, req) })
+	defer SetDryRun(nil)
+	client := WrapReadOnly(server.Client())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/drive/v3/files?q=x", nil)
+	resp, err := client.Do(req)
+	if err != nil || hits != 1 {
+		t.Fatalf("GET should be sent under dry-run, err=%v", err)
+	}
+	resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodPatch, server.URL+"/drive/v3/files/abc?supportsAllDrives=true&prettyPrint=false",
+		strings.NewReader(`{"trashed":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, err = client.Do(req)
+	if err == nil || hits != 1 {
+		t.Fatal("PATCH should be refused under dry-run")
+	}
+	if !strings.Contains(err.Error(), utils.ErrCodeDryRun) && !strings.Contains(err.Error(), "Dry run") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(blocked) != 1 {
+		t.Fatalf("blocked = %d requests, want 1", len(blocked))
+	}
+	got := blocked[0]
+	if got.Method != http.MethodPatch || got.Path != "/drive/v3/files/abc" {
+		t.Errorf("blocked %s %s", got.Method, got.Path)
+	}
+	if got.Params["supportsAllDrives"] != "true" || got.Params["prettyPrint"] != nil {
+		t.Errorf("params = %v", got.Params)
+	}
+	body, ok := got.Params["body"].(map[string]interface{})
+	if !ok || body["trashed"] != true {
+		t.Errorf("body = %v", got.Params["body"])
+	}
+
+	SetDryRun(nil)
+	req, _ = http.NewRequest(http.MethodDelete, server.URL+"/drive/v3/files/abc", nil)
+	resp, err = client.Do(req)
+	if err != nil || hits != 2 {
+		t.Fatalf("DELETE should be sent after dry-run is turned off, err=%v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// latencyProfile, when set, records the latency of every API operation and
+// HTTP request, for slow-call diagnostics and --profile-api
+var latencyProfile atomic.Pointer[LatencyProfile]
+
+// SetLatencyProfile enables (or with nil, disables) latency recording for
+// all clients
+func SetLatencyProfile(profile *LatencyProfile) {
+	latencyProfile.Store(profile)
+}
+
+// CallTiming is the latency of one API operation run through
+// ExecuteWithRetry, including its retries and backoff
+type CallTiming struct {
+	TraceID     string            `json:"traceId"`
+	RequestType types.RequestType `json:"requestType"`
+	FileIDs     []string          `json:"fileIds,omitempty"`
+	DurationMs  int64             `json:"durationMs"`
+	Attempts    int               `json:"attempts"`
+	Failed      bool              `json:"failed,omitempty"`
+
+	duration time.Duration
+}
+
+// String describes the call on one line, e.g.
+// "GetById 2.4s, 3 attempts, files abc123 (trace 5f0c...)"
+func (c CallTiming) String() string {
+	s := fmt.Sprintf("%s %s", c.RequestType, c.duration.Round(time.Millisecond))
+	if c.Attempts > 1 {
+		s += fmt.Sprintf(", %d attempts", c.Attempts)
+	}
+	if c.Failed {
+		s += ", failed"
+	}
+	if len(c.FileIDs) > 0 {
+		s += ", files " + strings.Join(c.FileIDs, ",")
+	}
+	return s + " (trace " + c.TraceID + ")"
+}
+
+// LatencyBucket counts requests at or below an upper bound; the last
+// bucket has no bound
+type LatencyBucket struct {
+	Le    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+// LatencySummary describes a set of latencies
+type LatencySummary struct {
+	Count   int             `json:"count"`
+	P50Ms   int64           `json:"p50Ms"`
+	P95Ms   int64           `json:"p95Ms"`
+	MaxMs   int64           `json:"maxMs"`
+	Buckets []LatencyBucket `json:"buckets,omitempty"`
+}
+
+// EndpointLatency is the latency histogram of one API endpoint
+type EndpointLatency struct {
+	Endpoint string `json:"endpoint"` // e.g. "GET /drive/v3/files/{id}"
+	LatencySummary
+}
+
+// LatencyReport is the document written by --profile-api
+type LatencyReport struct {
+	Operations LatencySummary    `json:"operations"`
+	Endpoints  []EndpointLatency `json:"endpoints"`
+	SlowCalls  []CallTiming      `json:"slowCalls"` // Operations slower than the p95
+}
+
+// latencyBounds are the histogram bucket upper bounds
+var latencyBounds = []time.Duration{
+	50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2500 * time.Millisecond,
+	5 * time.Second, 10 * time.Second,
+}
+
+// LatencyProfile collects operation and per-endpoint request latencies
+type LatencyProfile struct {
+	mu        sync.Mutex
+	calls     []CallTiming
+	endpoints map[string][]time.Duration
+}
+
+// NewLatencyProfile returns an empty profile
+func NewLatencyProfile() *LatencyProfile {
+	return &LatencyProfile{endpoints: make(map[string][]time.Duration)}
+}
+
+func (p *LatencyProfile) recordCall(call CallTiming) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+func (p *LatencyProfile) recordRequest(endpoint string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endpoints[endpoint] = append(p.endpoints[endpoint], d)
+}
+
+// SlowCalls returns the operations slower than the p95 of all operations,
+// slowest first, and the p95 itself
+func (p *LatencyProfile) SlowCalls() ([]CallTiming, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	durations := make([]time.Duration, len(p.calls))
+	for i, call := range p.calls {
+		durations[i] = call.duration
+	}
+	p95 := percentile(sortedDurations(durations), 95)
+	var slow []CallTiming
+	for _, call := range p.calls {
+		if call.duration > p95 {
+			slow = append(slow, call)
+		}
+	}
+	sort.SliceStable(slow, func(i, j int) bool { return slow[i].duration > slow[j].duration })
+	return slow, p95
+}
+
+// Report summarizes the profile: operation latencies, a histogram per
+// endpoint (sorted by endpoint) and the slow calls
+func (p *LatencyProfile) Report() *LatencyReport {
+	slow, _ := p.SlowCalls()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	durations := make([]time.Duration, len(p.calls))
+	for i, call := range p.calls {
+		durations[i] = call.duration
+	}
+	report := &LatencyReport{
+		Operations: summarizeLatencies(durations, false),
+		Endpoints:  make([]EndpointLatency, 0, len(p.endpoints)),
+		SlowCalls:  slow,
+	}
+	if report.SlowCalls == nil {
+		report.SlowCalls = []CallTiming{}
+	}
+	for endpoint, durations := range p.endpoints {
+		report.Endpoints = append(report.Endpoints, EndpointLatency{
+			Endpoint:       endpoint,
+			LatencySummary: summarizeLatencies(durations, true),
+		})
+	}
+	sort.Slice(report.Endpoints, func(i, j int) bool {
+		return report.Endpoints[i].Endpoint < report.Endpoints[j].Endpoint
+	})
+	return report
+}
+
+func summarizeLatencies(durations []time.Duration, buckets bool) LatencySummary {
+	sorted := sortedDurations(durations)
+	summary := LatencySummary{
+		Count: len(sorted),
+		P50Ms: percentile(sorted, 50).Milliseconds(),
+		P95Ms: percentile(sorted, 95).Milliseconds(),
+	}
+	if len(sorted) > 0 {
+		summary.MaxMs = sorted[len(sorted)-1].Milliseconds()
+	}
+	if !buckets {
+		return summary
+	}
+	counts := make([]int, len(latencyBounds)+1)
+	for _, d := range sorted {
+		i := sort.Search(len(latencyBounds), func(i int) bool { return d <= latencyBounds[i] })
+		counts[i]++
+	}
+	for i, count := range counts {
+		le := "+Inf"
+		if i < len(latencyBounds) {
+			le = latencyBounds[i].String()
+		}
+		summary.Buckets = append(summary.Buckets, LatencyBucket{Le: le, Count: count})
+	}
+	return summary
+}
+
+func sortedDurations(durations []time.Duration) []time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, pct int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (pct*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// recordLatency stores the latency of a finished operation in reqCtx and,
+// when profiling, in the latency profile
+func recordLatency(reqCtx *types.RequestContext, duration time.Duration, attempts int, err error) {
+	reqCtx.Latency = duration
+	reqCtx.Attempts = attempts
+	profile := latencyProfile.Load()
+	if profile == nil {
+		return
+	}
+	profile.recordCall(CallTiming{
+		TraceID:     reqCtx.TraceID,
+		RequestType: reqCtx.RequestType,
+		FileIDs:     append([]string(nil), reqCtx.InvolvedFileIDs...),
+		DurationMs:  duration.Milliseconds(),
+		Attempts:    attempts,
+		Failed:      err != nil,
+		duration:    duration,
+	})
+}
+
+// endpointName names the API endpoint req calls, with IDs replaced by
+// {id}, e.g. "GET /drive/v3/files/{id}/permissions"
+func endpointName(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		// Custom methods like spreadsheets/{id}:batchUpdate keep the method
+		name, method, found := strings.Cut(segment, ":")
+		if found && isPathWord(method) && !isPathWord(name) {
+			segments[i] = "{id}:" + method
+		} else if !isPathWord(segment) && !isAPIVersion(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return req.Method + " /" + strings.Join(segments, "/")
+}
+
+// isPathWord reports whether segment looks like a resource or method name
+// rather than an ID: a short run of letters starting in lower case
+func isPathWord(segment string) bool {
+	if segment == "" || len(segment) > 24 || !unicode.IsLower(rune(segment[0])) {
+		return false
+	}
+	for _, r := range segment {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAPIVersion reports whether segment is an API version like v3 or v1beta
+func isAPIVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' || segment[1] < '0' || segment[1] > '9' {
+		return false
+	}
+	for _, r := range segment[1:] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
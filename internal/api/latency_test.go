@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestEndpointName(t *testing.T) {
+	tests := []struct {
+		method, url, want string
+	}{
+		{"GET", "https://www.googleapis.com/drive/v3/files?q=x", "GET /drive/v3/files"},
+		{"GET", "https://www.googleapis.com/drive/v3/files/1AbC-dEf_123456789", "GET /drive/v3/files/{id}"},
+		{"POST", "https://www.googleapis.com/drive/v3/files/1AbC/permissions", "POST /drive/v3/files/{id}/permissions"},
+		{"DELETE", "https://www.googleapis.com/drive/v3/files/1AbC/permissions/anyoneWithLink", "DELETE /drive/v3/files/{id}/permissions/anyoneWithLink"},
+		{"GET", "https://www.googleapis.com/drive/v3/changes/startPageToken", "GET /drive/v3/changes/startPageToken"},
+		{"POST", "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable", "POST /upload/drive/v3/files"},
+		{"POST", "https://sheets.googleapis.com/v4/spreadsheets/1Xyz9:batchUpdate", "POST /v4/spreadsheets/{id}:batchUpdate"},
+		{"GET", "https://admin.googleapis.com/admin/directory/v1/users/alice@example.com", "GET /admin/directory/v1/users/{id}"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, nil)
+		if got := endpointName(req); got != tt.want {
+			t.Errorf("endpointName(%s %s) = %q, want %q", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestLatencyProfile_SlowCalls(t *testing.T) {
+	profile := NewLatencyProfile()
+	SetLatencyProfile(profile)
+	defer SetLatencyProfile(nil)
+
+	for i := 1; i <= 20; i++ {
+		reqCtx := NewRequestContext("default", "", types.RequestTypeGetByID)
+		reqCtx.InvolvedFileIDs = []string{"file-" + string(rune('a'+i-1))}
+		recordLatency(reqCtx, time.Duration(i*10)*time.Millisecond, 1, nil)
+	}
+	slowCtx := NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	slowCtx.InvolvedFileIDs = []string{"big-file"}
+	recordLatency(slowCtx, 3*time.Second, 4, nil)
+	if slowCtx.Latency != 3*time.Second || slowCtx.Attempts != 4 {
+		t.Errorf("request context latency = %s, attempts = %d", slowCtx.Latency, slowCtx.Attempts)
+	}
+
+	slow, p95 := profile.SlowCalls()
+	if p95 != 200*time.Millisecond {
+		t.Errorf("p95 = %s, want 200ms", p95)
+	}
+	if len(slow) != 1 || slow[0].TraceID != slowCtx.TraceID || slow[0].FileIDs[0] != "big-file" {
+		t.Fatalf("slow calls = %+v, want only the big-file download", slow)
+	}
+	if got, want := slow[0].String(), "DownloadOrExport 3s, 4 attempts, files big-file (trace "+slowCtx.TraceID+")"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestLatencyProfile_Report(t *testing.T) {
+	profile := NewLatencyProfile()
+	for _, ms := range []int{20, 80, 90, 600, 12000} {
+		profile.recordRequest("GET /drive/v3/files/{id}", time.Duration(ms)*time.Millisecond)
+	}
+	profile.recordRequest("GET /drive/v3/about", 40*time.Millisecond)
+
+	report := profile.Report()
+	if len(report.Endpoints) != 2 || report.Endpoints[0].Endpoint != "GET /drive/v3/about" {
+		t.Fatalf("endpoints = %+v", report.Endpoints)
+	}
+	files := report.Endpoints[1]
+	if files.Count != 5 || files.P50Ms != 90 || files.P95Ms != 12000 || files.MaxMs != 12000 {
+		t.Errorf("files summary = %+v", files.LatencySummary)
+	}
+	counts := map[string]int{}
+	for _, b := range files.Buckets {
+		counts[b.Le] = b.Count
+	}
+	if counts["50ms"] != 1 || counts["100ms"] != 2 || counts["1s"] != 1 || counts["+Inf"] != 1 {
+		t.Errorf("buckets = %+v", files.Buckets)
+	}
+	if report.SlowCalls == nil {
+		t.Error("slowCalls should be an empty list, not null")
+	}
+}
+
+func TestExecuteWithRetry_RecordsLatency(t *testing.T) {
+	profile := NewLatencyProfile()
+	SetLatencyProfile(profile)
+	defer SetLatencyProfile(nil)
+
+	client := NewClient(nil, 2, 1, logging.NewNoOpLogger())
+	reqCtx := NewRequestContext("default", "", types.RequestTypeGetByID)
+	_, _ = ExecuteWithRetry(context.Background(), client, reqCtx, func() (string, error) {
+		time.Sleep(5 * time.Millisecond)
+		return "ok", nil
+	})
+	if reqCtx.Latency < 5*time.Millisecond || reqCtx.Attempts != 1 {
+		t.Errorf("latency = %s, attempts = %d", reqCtx.Latency, reqCtx.Attempts)
+	}
+	if report := profile.Report(); report.Operations.Count != 1 {
+		t.Errorf("operations = %+v, want 1 recorded", report.Operations)
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// metadataCacheDisabled turns off the per-run metadata cache for all
+// clients (--no-cache)
+var metadataCacheDisabled atomic.Bool
+
+// mutationGeneration counts requests that may have changed remote state, as
+// seen by ReadOnlyTransport. Cache entries fetched under an older generation
+// are stale.
+var mutationGeneration atomic.Uint64
+
+// maxMetadataEntries bounds the cache; it is cleared when full
+const maxMetadataEntries = 10000
+
+// SetMetadataCache enables or disables the metadata cache for all clients
+func SetMetadataCache(enabled bool) {
+	metadataCacheDisabled.Store(!enabled)
+}
+
+// noteMutation invalidates every cached entry
+func noteMutation() {
+	mutationGeneration.Add(1)
+}
+
+// metadataCache remembers files.get results for the life of a client. It
+// also learns which fields the run asks for, and fetches all of them on a
+// miss, so a later get of the same file with a different field list (e.g.
+// path resolution, then a capability check, then a confirmation prompt)
+// is served from memory.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]*metadataEntry
+	learned fieldSet
+	hits    int
+	misses  int
+}
+
+type metadataEntry struct {
+	file       *drive.File
+	fields     fieldSet
+	generation uint64
+}
+
+// MetadataCacheStats returns the client's metadata cache hits and misses
+func (c *Client) MetadataCacheStats() (hits, misses int) {
+	c.metadata.mu.Lock()
+	defer c.metadata.mu.Unlock()
+	return c.metadata.hits, c.metadata.misses
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{entries: map[string]*metadataEntry{}, learned: fieldSet{}}
+}
+
+// lookup returns the cached file if it holds every requested field
+func (c *metadataCache) lookup(fileID string, want fieldSet) (*drive.File, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[fileID]
+	if !ok || entry.generation != mutationGeneration.Load() || !entry.fields.covers(want) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return entry.file, true
+}
+
+// learn records the requested fields and returns those to fetch
+func (c *metadataCache) learn(want fieldSet) fieldSet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.learned.merge(want)
+	fetch := fieldSet{}
+	fetch.merge(c.learned)
+	return fetch
+}
+
+func (c *metadataCache) store(fileID string, file *drive.File, fields fieldSet, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxMetadataEntries {
+		c.entries = map[string]*metadataEntry{}
+	}
+	c.entries[fileID] = &metadataEntry{file: file, fields: fields, generation: generation}
+}
+
+// GetFile is files.get through the client's metadata cache. Results are
+// cached only for an explicit field list, and are returned with just the
+// requested top-level fields, as if fetched directly.
+func (c *Client) GetFile(ctx context.Context, reqCtx *types.RequestContext, fileID, fields string) (*drive.File, error) {
+	want := parseFields(fields)
+	if want == nil || metadataCacheDisabled.Load() {
+		return c.getFile(ctx, reqCtx, fileID, fields)
+	}
+
+	if file, ok := c.metadata.lookup(fileID, want); ok {
+		c.logger.Debug("Metadata cache hit", logging.F("fileId", fileID), logging.F("fields", fields))
+		return want.project(file)
+	}
+
+	fetch := c.metadata.learn(want)
+	generation := mutationGeneration.Load()
+	file, err := c.getFile(ctx, reqCtx, fileID, fetch.String())
+	if err != nil && len(fetch) > len(want) && isBadRequest(err) {
+		// A learned field may not apply here; fall back to the request as given
+		fetch = want
+		file, err = c.getFile(ctx, reqCtx, fileID, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.metadata.store(fileID, file, fetch, generation)
+	return want.project(file)
+}
+
+func (c *Client) getFile(ctx context.Context, reqCtx *types.RequestContext, fileID, fields string) (*drive.File, error) {
+	call := c.service.Files.Get(fileID)
+	call = NewRequestShaper(c).ShapeFilesGet(call, reqCtx)
+	if fields != "" {
+		call = call.Fields(googleapi.Field(fields))
+	}
+	return ExecuteWithRetry(ctx, c, reqCtx, func() (*drive.File, error) {
+		return call.Context(ctx).Do()
+	})
+}
+
+func isBadRequest(err error) bool {
+	if appErr, ok := err.(*utils.AppError); ok {
+		return appErr.CLIError.HTTPStatus == http.StatusBadRequest
+	}
+	apiErr, ok := err.(*googleapi.Error)
+	return ok && apiErr.Code == http.StatusBadRequest
+}
+
+// fieldSet is a parsed field mask keyed by top-level field name. A value of
+// "" means the whole field; otherwise it holds the field with its
+// sub-selection, e.g. "capabilities(canEdit)".
+type fieldSet map[string]string
+
+// parseFields splits a field mask at top-level commas. It returns nil for
+// masks that are not worth caching: empty or containing a wildcard.
+func parseFields(fields string) fieldSet {
+	if fields == "" || strings.Contains(fields, "*") {
+		return nil
+	}
+	set := fieldSet{}
+	depth, start := 0, 0
+	for i := 0; i <= len(fields); i++ {
+		if i < len(fields) {
+			switch fields[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		set.add(strings.TrimSpace(fields[start:i]))
+		start = i + 1
+	}
+	return set
+}
+
+func (s fieldSet) add(field string) {
+	if field == "" {
+		return
+	}
+	name, sub := field, ""
+	if i := strings.IndexByte(field, '('); i >= 0 {
+		name, sub = field[:i], field
+	}
+	existing, ok := s[name]
+	switch {
+	case !ok:
+		s[name] = sub
+	case existing != sub:
+		// Two different sub-selections: fetch the whole field
+		s[name] = ""
+	}
+}
+
+func (s fieldSet) merge(other fieldSet) {
+	for name, sub := range other {
+		if sub == "" {
+			s[name] = ""
+		} else {
+			s.add(sub)
+		}
+	}
+}
+
+// covers reports whether a response fetched with s holds everything in want
+func (s fieldSet) covers(want fieldSet) bool {
+	for name, sub := range want {
+		have, ok := s[name]
+		if !ok || (have != "" && have != sub) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s fieldSet) String() string {
+	parts := make([]string, 0, len(s))
+	for name, sub := range s {
+		if sub == "" {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, sub)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// project returns a copy of file with only the top-level fields in s, so
+// callers see the same result whether or not it came from the cache
+func (s fieldSet) project(file *drive.File) (*drive.File, error) {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for name := range all {
+		if _, ok := s[name]; !ok {
+			delete(all, name)
+		}
+	}
+	if data, err = json.Marshal(all); err != nil {
+		return nil, err
+	}
+	projected := &drive.File{}
+	if err := json.Unmarshal(data, projected); err != nil {
+		return nil, err
+	}
+	return projected, nil
+}
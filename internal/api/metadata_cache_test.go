@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// metadataServer serves files.get for one file, recording each field mask
+func metadataServer(t *testing.T, name *string, requested *[]string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("{}"))
+			return
+		}
+		*requested = append(*requested, r.URL.Query().Get("fields"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&drive.File{
+			Id:           "f1",
+			Name:         *name,
+			MimeType:     "text/plain",
+			Parents:      []string{"p1"},
+			Capabilities: &drive.FileCapabilities{CanTrash: true, CanEdit: true},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	service, err := drive.NewService(ctx,
+		option.WithEndpoint(server.URL+"/drive/v3/"),
+		option.WithHTTPClient(WrapReadOnly(server.Client())))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	return NewClient(service, 0, 10, nil)
+}
+
+func TestGetFile_Cache(t *testing.T) {
+	name := "a.txt"
+	var requested []string
+	client := metadataServer(t, &name, &requested)
+	ctx := context.Background()
+	reqCtx := NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	first, err := client.GetFile(ctx, reqCtx, "f1", "id,name,parents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != "a.txt" || first.Capabilities != nil {
+		t.Fatalf("first = %+v", first)
+	}
+
+	// A different mask is a miss, but teaches the cache to fetch both
+	if _, err := client.GetFile(ctx, reqCtx, "f1", "id,name,capabilities(canTrash)"); err != nil {
+		t.Fatal(err)
+	}
+	if got := requested[1]; got != "capabilities(canTrash),id,name,parents" {
+		t.Fatalf("prefetch mask = %q", got)
+	}
+
+	// Either mask (or a subset) is now served from memory
+	for _, fields := range []string{"id,name,parents", "capabilities(canTrash),id", "name"} {
+		file, err := client.GetFile(ctx, reqCtx, "f1", fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields == "name" && (file.Id != "" || file.Parents != nil || file.Name != "a.txt") {
+			t.Fatalf("projection for %q = %+v", fields, file)
+		}
+	}
+	if len(requested) != 2 {
+		t.Fatalf("expected 2 requests, got %d: %v", len(requested), requested)
+	}
+	if hits, misses := client.MetadataCacheStats(); hits != 3 || misses != 2 {
+		t.Fatalf("hits %d misses %d", hits, misses)
+	}
+
+	// A mutation through the transport invalidates the cache
+	name = "b.txt"
+	if _, err := client.Service().Files.Update("f1", &drive.File{Name: "b.txt"}).Do(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := client.GetFile(ctx, reqCtx, "f1", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Name != "b.txt" || len(requested) != 3 {
+		t.Fatalf("stale result %q after mutation (%d requests)", file.Name, len(requested))
+	}
+}
+
+func TestGetFile_CacheDisabled(t *testing.T) {
+	SetMetadataCache(false)
+	defer SetMetadataCache(true)
+
+	name := "a.txt"
+	var requested []string
+	client := metadataServer(t, &name, &requested)
+	ctx := context.Background()
+	reqCtx := NewRequestContext("default", "", types.RequestTypeGetByID)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetFile(ctx, reqCtx, "f1", "id,name"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(requested) != 2 || requested[1] != "id,name" {
+		t.Fatalf("requests = %v", requested)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	if parseFields("") != nil || parseFields("*") != nil {
+		t.Fatal("expected empty and wildcard masks not to be cached")
+	}
+	set := parseFields("id, name,owners(emailAddress,displayName),capabilities(canEdit)")
+	if got := set.String(); got != "capabilities(canEdit),id,name,owners(emailAddress,displayName)" {
+		t.Fatalf("String() = %q", got)
+	}
+
+	set.merge(parseFields("capabilities(canTrash)"))
+	if set["capabilities"] != "" {
+		t.Fatalf("conflicting sub-selections should widen to the whole field, got %q", set["capabilities"])
+	}
+	if !set.covers(parseFields("capabilities(canShare),id")) {
+		t.Error("whole field should cover any sub-selection")
+	}
+	if set.covers(parseFields("owners(emailAddress)")) {
+		t.Error("a different sub-selection should not be covered")
+	}
+	if set.covers(parseFields("size")) {
+		t.Error("missing field should not be covered")
+	}
+	if strings.Contains(set.String(), "(canEdit)") {
+		t.Errorf("String() = %q", set.String())
+	}
+}
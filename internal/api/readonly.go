@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// readOnly, when set, makes every client refuse requests that could modify
+// remote state
+var readOnly atomic.Bool
+
+// readOnlyPOSTSuffixes are POST endpoints that only read data. The APIs use
+// POST for these because the query does not fit in a URL.
+var readOnlyPOSTSuffixes = []string{
+	"/activity:query",              // Drive Activity
+	":getByDataFilter",             // Sheets
+	"/values:batchGetByDataFilter", // Sheets
+}
+
+// SetReadOnly enables or disables read-only mode for all clients
+func SetReadOnly(enabled bool) {
+	readOnly.Store(enabled)
+}
+
+// IsReadOnly reports whether read-only mode is enabled
+func IsReadOnly() bool {
+	return readOnly.Load()
+}
+
+// ReadOnlyTransport wraps base so that, while read-only mode is enabled,
+// mutating requests fail before they are sent. A nil base uses
+// http.DefaultTransport.
+func ReadOnlyTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, ok := base.(*readOnlyTransport); ok {
+		return base
+	}
+	return &readOnlyTransport{base: base}
+}
+
+// WrapReadOnly installs ReadOnlyTransport on client and returns it
+func WrapReadOnly(client *http.Client) *http.Client {
+	client.Transport = ReadOnlyTransport(client.Transport)
+	return client
+}
+
+type readOnlyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isMutatingRequest(req) {
+		if blocked, err := dryRunBlock(req); blocked {
+			return nil, err
+		}
+		if readOnly.Load() {
+			if req.Body != nil {
+				_ = req.Body.Close()
+			}
+			return nil, ReadOnlyError(req.Method + " " + req.URL.Path)
+		}
+		// Before and after, so no get can cache a result from mid-change
+		noteMutation()
+		defer noteMutation()
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ReadOnlyError returns the error reported when read-only mode blocks an
+// operation
+func ReadOnlyError(operation string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeReadOnly,
+		"Read-only mode is enabled; refusing to modify remote state").
+		WithContext("operation", operation).
+		WithContext("suggestedAction", "run without --read-only and unset readOnly for the profile to allow changes").
+		Build())
+}
+
+func isMutatingRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	case http.MethodPost:
+		for _, suffix := range readOnlyPOSTSuffixes {
+			if strings.HasSuffix(req.URL.Path, suffix) {
+				return false
+			}
+		}
+	}
+	return true
+}
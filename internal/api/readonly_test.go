@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+func TestReadOnlyTransport(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+	client := WrapReadOnly(server.Client())
+
+	tests := []struct {
+		method  string
+		path    string
+		allowed bool
+	}{
+		{http.MethodGet, "/drive/v3/files", true},
+		{http.MethodHead, "/drive/v3/files/abc", true},
+		{http.MethodPost, "/drive/v3/files", false},
+		{http.MethodPatch, "/drive/v3/files/abc", false},
+		{http.MethodPut, "/upload/drive/v3/files/abc", false},
+		{http.MethodDelete, "/drive/v3/files/abc/permissions/p1", false},
+		{http.MethodPost, "/v2/activity:query", true},
+		{http.MethodPost, "/v4/spreadsheets/s1/values:batchGetByDataFilter", true},
+		{http.MethodPost, "/v4/spreadsheets/s1/values:batchUpdate", false},
+	}
+	for _, tt := range tests {
+		hits = 0
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+		resp, err := client.Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if tt.allowed && (err != nil || hits != 1) {
+			t.Errorf("%s %s: expected request to be sent, err=%v", tt.method, tt.path, err)
+		}
+		if !tt.allowed && (err == nil || hits != 0) {
+			t.Errorf("%s %s: expected request to be refused", tt.method, tt.path)
+		}
+	}
+
+	SetReadOnly(false)
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/drive/v3/files/abc", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected request to be sent with read-only mode off, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestReadOnlyTransport_ExecuteWithRetryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+
+	ctx := context.Background()
+	service, err := drive.NewService(ctx,
+		option.WithEndpoint(server.URL+"/drive/v3/"),
+		option.WithHTTPClient(WrapReadOnly(server.Client())))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	client := NewClient(service, 3, 10, logging.NewNoOpLogger())
+	reqCtx := NewRequestContext("default", "", types.RequestTypeMutation)
+
+	_, err = ExecuteWithRetry(ctx, client, reqCtx, func() (*drive.File, error) {
+		return service.Files.Update("abc", &drive.File{Name: "renamed"}).Do()
+	})
+	appErr, ok := err.(*utils.AppError)
+	if !ok {
+		t.Fatalf("expected *utils.AppError, got %T: %v", err, err)
+	}
+	if appErr.CLIError.Code != utils.ErrCodeReadOnly {
+		t.Errorf("expected code %s, got %s", utils.ErrCodeReadOnly, appErr.CLIError.Code)
+	}
+}
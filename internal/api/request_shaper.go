@@ -1,11 +1,29 @@
 package api
 
 import (
+	"sync"
+
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"google.golang.org/api/drive/v3"
 )
 
-// RequestShaper applies proper parameters based on request context
+// Corpora values for files.list
+const (
+	CorporaUser      = "user"
+	CorporaDrive     = "drive"
+	CorporaDomain    = "domain"
+	CorporaAllDrives = "allDrives"
+)
+
+// RequestShaper applies proper parameters based on request context.
+//
+// The Shared Drive parameters of a request are, from lowest to highest
+// precedence: the defaults (supportsAllDrives on every call; lists search
+// the context's Shared Drive, or the user's corpus, including items from
+// all drives), the client's override for the drive the request targets,
+// and the request context's own DriveParams. Shaping never modifies the
+// request context, so contexts for several drives can be shaped
+// concurrently.
 type RequestShaper struct {
 	client *Client
 }
@@ -15,9 +33,118 @@ func NewRequestShaper(client *Client) *RequestShaper {
 	return &RequestShaper{client: client}
 }
 
+// ShapeParams are the Shared Drive parameters chosen for a request
+type ShapeParams struct {
+	Corpora                   string
+	DriveID                   string
+	SupportsAllDrives         bool
+	IncludeItemsFromAllDrives bool
+}
+
+// DriveOverrides holds request shaping overrides per Shared Drive, for runs
+// that address several drives. The empty drive ID is the user's My Drive
+// corpus. It is safe for concurrent use.
+type DriveOverrides struct {
+	mu     sync.RWMutex
+	params map[string]types.DriveParams
+}
+
+// NewDriveOverrides returns an empty set of overrides
+func NewDriveOverrides() *DriveOverrides {
+	return &DriveOverrides{params: map[string]types.DriveParams{}}
+}
+
+// Set replaces the override for driveID
+func (o *DriveOverrides) Set(driveID string, params types.DriveParams) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.params[driveID] = params
+}
+
+// Get returns the override for driveID
+func (o *DriveOverrides) Get(driveID string) (types.DriveParams, bool) {
+	if o == nil {
+		return types.DriveParams{}, false
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	params, ok := o.params[driveID]
+	return params, ok
+}
+
+// Delete removes the override for driveID
+func (o *DriveOverrides) Delete(driveID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.params, driveID)
+}
+
+// Resolve returns the Shared Drive parameters for a request. A corpora of
+// drive without a drive ID falls back to user, and the drive and allDrives
+// corpora always include items from all drives, as the API requires.
+func (s *RequestShaper) Resolve(ctx *types.RequestContext) ShapeParams {
+	driveID := ""
+	if ctx != nil {
+		driveID = ctx.DriveID
+		if ctx.DriveParams != nil && ctx.DriveParams.DriveID != "" {
+			driveID = ctx.DriveParams.DriveID
+		}
+	}
+	params := ShapeParams{
+		DriveID:                   driveID,
+		SupportsAllDrives:         true,
+		IncludeItemsFromAllDrives: true,
+	}
+	if s.client != nil {
+		if override, ok := s.client.driveOverrides.Get(driveID); ok {
+			params.apply(override)
+		}
+	}
+	if ctx != nil && ctx.DriveParams != nil {
+		params.apply(*ctx.DriveParams)
+	}
+
+	switch {
+	case params.Corpora == "":
+		params.Corpora = CorporaUser
+		if params.DriveID != "" {
+			params.Corpora = CorporaDrive
+		}
+	case params.Corpora == CorporaDrive && params.DriveID == "":
+		params.Corpora = CorporaUser
+	}
+	if params.Corpora == CorporaDrive || params.Corpora == CorporaAllDrives {
+		params.IncludeItemsFromAllDrives = true
+	}
+	return params
+}
+
+func (p *ShapeParams) apply(o types.DriveParams) {
+	if o.Corpora != "" {
+		p.Corpora = o.Corpora
+	}
+	if o.DriveID != "" {
+		p.DriveID = o.DriveID
+	}
+	if o.SupportsAllDrives != nil {
+		p.SupportsAllDrives = *o.SupportsAllDrives
+	}
+	if o.IncludeItemsFromAllDrives != nil {
+		p.IncludeItemsFromAllDrives = *o.IncludeItemsFromAllDrives
+	}
+}
+
+// involvedIDs returns the file and parent IDs of a request in a new slice,
+// leaving the context's slices untouched
+func involvedIDs(ctx *types.RequestContext) []string {
+	ids := make([]string, 0, len(ctx.InvolvedFileIDs)+len(ctx.InvolvedParentIDs))
+	ids = append(ids, ctx.InvolvedFileIDs...)
+	return append(ids, ctx.InvolvedParentIDs...)
+}
+
 // ShapeFilesGet applies parameters to files.get request
 func (s *RequestShaper) ShapeFilesGet(call *drive.FilesGetCall, ctx *types.RequestContext) *drive.FilesGetCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	// Add resource keys header if available
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
@@ -30,14 +157,24 @@ func (s *RequestShaper) ShapeFilesGet(call *drive.FilesGetCall, ctx *types.Reque
 
 // ShapeFilesList applies parameters to files.list request
 func (s *RequestShaper) ShapeFilesList(call *drive.FilesListCall, ctx *types.RequestContext) *drive.FilesListCall {
-	call = call.SupportsAllDrives(true).
-		IncludeItemsFromAllDrives(true)
+	params := s.Resolve(ctx)
+	call = call.SupportsAllDrives(params.SupportsAllDrives).
+		IncludeItemsFromAllDrives(params.IncludeItemsFromAllDrives).
+		Corpora(params.Corpora)
+	if params.Corpora == CorporaDrive {
+		call = call.DriveId(params.DriveID)
+	}
+
+	return call
+}
 
-	// Set corpora based on context
-	if ctx.DriveID != "" {
-		call = call.Corpora("drive").DriveId(ctx.DriveID)
-	} else {
-		call = call.Corpora("user")
+// ShapeFilesWatch applies parameters to files.watch request
+func (s *RequestShaper) ShapeFilesWatch(call *drive.FilesWatchCall, ctx *types.RequestContext) *drive.FilesWatchCall {
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
+
+	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
+	if header != "" {
+		call.Header().Set("X-Goog-Drive-Resource-Keys", header)
 	}
 
 	return call
@@ -45,7 +182,7 @@ func (s *RequestShaper) ShapeFilesList(call *drive.FilesListCall, ctx *types.Req
 
 // ShapeFilesCreate applies parameters to files.create request
 func (s *RequestShaper) ShapeFilesCreate(call *drive.FilesCreateCall, ctx *types.RequestContext) *drive.FilesCreateCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	// Add resource keys for parent folders
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedParentIDs)
@@ -58,11 +195,10 @@ func (s *RequestShaper) ShapeFilesCreate(call *drive.FilesCreateCall, ctx *types
 
 // ShapeFilesUpdate applies parameters to files.update request
 func (s *RequestShaper) ShapeFilesUpdate(call *drive.FilesUpdateCall, ctx *types.RequestContext) *drive.FilesUpdateCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	// Include both file and parent resource keys
-	allIDs := append(ctx.InvolvedFileIDs, ctx.InvolvedParentIDs...)
-	header := s.client.ResourceKeys().BuildHeader(allIDs)
+	header := s.client.ResourceKeys().BuildHeader(involvedIDs(ctx))
 	if header != "" {
 		call.Header().Set("X-Goog-Drive-Resource-Keys", header)
 	}
@@ -72,7 +208,7 @@ func (s *RequestShaper) ShapeFilesUpdate(call *drive.FilesUpdateCall, ctx *types
 
 // ShapeFilesDelete applies parameters to files.delete request
 func (s *RequestShaper) ShapeFilesDelete(call *drive.FilesDeleteCall, ctx *types.RequestContext) *drive.FilesDeleteCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
 	if header != "" {
@@ -84,10 +220,9 @@ func (s *RequestShaper) ShapeFilesDelete(call *drive.FilesDeleteCall, ctx *types
 
 // ShapeFilesCopy applies parameters to files.copy request
 func (s *RequestShaper) ShapeFilesCopy(call *drive.FilesCopyCall, ctx *types.RequestContext) *drive.FilesCopyCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
-	allIDs := append(ctx.InvolvedFileIDs, ctx.InvolvedParentIDs...)
-	header := s.client.ResourceKeys().BuildHeader(allIDs)
+	header := s.client.ResourceKeys().BuildHeader(involvedIDs(ctx))
 	if header != "" {
 		call.Header().Set("X-Goog-Drive-Resource-Keys", header)
 	}
@@ -97,7 +232,7 @@ func (s *RequestShaper) ShapeFilesCopy(call *drive.FilesCopyCall, ctx *types.Req
 
 // ShapePermissionsList applies parameters to permissions.list request
 func (s *RequestShaper) ShapePermissionsList(call *drive.PermissionsListCall, ctx *types.RequestContext) *drive.PermissionsListCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
 	if header != "" {
@@ -109,7 +244,7 @@ func (s *RequestShaper) ShapePermissionsList(call *drive.PermissionsListCall, ct
 
 // ShapePermissionsCreate applies parameters to permissions.create request
 func (s *RequestShaper) ShapePermissionsCreate(call *drive.PermissionsCreateCall, ctx *types.RequestContext) *drive.PermissionsCreateCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
 	if header != "" {
@@ -121,7 +256,7 @@ func (s *RequestShaper) ShapePermissionsCreate(call *drive.PermissionsCreateCall
 
 // ShapePermissionsUpdate applies parameters to permissions.update request
 func (s *RequestShaper) ShapePermissionsUpdate(call *drive.PermissionsUpdateCall, ctx *types.RequestContext) *drive.PermissionsUpdateCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
 	if header != "" {
@@ -133,7 +268,7 @@ func (s *RequestShaper) ShapePermissionsUpdate(call *drive.PermissionsUpdateCall
 
 // ShapePermissionsDelete applies parameters to permissions.delete request
 func (s *RequestShaper) ShapePermissionsDelete(call *drive.PermissionsDeleteCall, ctx *types.RequestContext) *drive.PermissionsDeleteCall {
-	call = call.SupportsAllDrives(true)
+	call = call.SupportsAllDrives(s.Resolve(ctx).SupportsAllDrives)
 
 	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
 	if header != "" {
@@ -173,6 +308,16 @@ func (s *RequestShaper) ShapeRevisionsUpdate(call *drive.RevisionsUpdateCall, ct
 	return call
 }
 
+// ShapeRevisionsDelete applies parameters to revisions.delete request
+func (s *RequestShaper) ShapeRevisionsDelete(call *drive.RevisionsDeleteCall, ctx *types.RequestContext) *drive.RevisionsDeleteCall {
+	header := s.client.ResourceKeys().BuildHeader(ctx.InvolvedFileIDs)
+	if header != "" {
+		call.Header().Set("X-Goog-Drive-Resource-Keys", header)
+	}
+
+	return call
+}
+
 // ShapeDrivesList applies parameters to drives.list request
 func (s *RequestShaper) ShapeDrivesList(call *drive.DrivesListCall, ctx *types.RequestContext) *drive.DrivesListCall {
 	// No special shaping needed for drives.list
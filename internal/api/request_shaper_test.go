@@ -2,8 +2,12 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/dl-alexandre/gdrv/internal/logging"
@@ -369,3 +373,141 @@ func TestRequestShaper_HeaderMethods(t *testing.T) {
 		})
 	}
 }
+
+var allRequestTypes = []types.RequestType{
+	types.RequestTypeGetByID,
+	types.RequestTypeListOrSearch,
+	types.RequestTypeMutation,
+	types.RequestTypeRevisionOp,
+	types.RequestTypePermissionOp,
+	types.RequestTypeDownloadOrExport,
+	types.RequestTypeBatchOp,
+}
+
+func TestRequestShaper_Resolve(t *testing.T) {
+	yes, no := true, false
+	client := NewClient(nil, 3, 1000, logging.NewNoOpLogger())
+	client.DriveOverrides().Set("legacy", types.DriveParams{SupportsAllDrives: &no})
+	client.DriveOverrides().Set("", types.DriveParams{Corpora: CorporaDomain})
+	client.DriveOverrides().Set("wide", types.DriveParams{Corpora: CorporaAllDrives, IncludeItemsFromAllDrives: &no})
+	shaper := NewRequestShaper(client)
+
+	cases := []struct {
+		name    string
+		driveID string
+		params  *types.DriveParams
+		want    ShapeParams
+	}{
+		{"my drive uses the drive override", "", nil, ShapeParams{Corpora: CorporaDomain, SupportsAllDrives: true, IncludeItemsFromAllDrives: true}},
+		{"shared drive", "d1", nil, ShapeParams{Corpora: CorporaDrive, DriveID: "d1", SupportsAllDrives: true, IncludeItemsFromAllDrives: true}},
+		{"drive override", "legacy", nil, ShapeParams{Corpora: CorporaDrive, DriveID: "legacy", IncludeItemsFromAllDrives: true}},
+		{"request override wins", "legacy", &types.DriveParams{SupportsAllDrives: &yes}, ShapeParams{Corpora: CorporaDrive, DriveID: "legacy", SupportsAllDrives: true, IncludeItemsFromAllDrives: true}},
+		{"request selects another drive", "d1", &types.DriveParams{DriveID: "legacy"}, ShapeParams{Corpora: CorporaDrive, DriveID: "legacy", IncludeItemsFromAllDrives: true}},
+		{"user corpus without items from drives", "", &types.DriveParams{Corpora: CorporaUser, IncludeItemsFromAllDrives: &no}, ShapeParams{Corpora: CorporaUser, SupportsAllDrives: true}},
+		{"drive corpus needs a drive", "", &types.DriveParams{Corpora: CorporaDrive}, ShapeParams{Corpora: CorporaUser, SupportsAllDrives: true, IncludeItemsFromAllDrives: true}},
+		{"all drives always includes items", "wide", nil, ShapeParams{Corpora: CorporaAllDrives, DriveID: "wide", SupportsAllDrives: true, IncludeItemsFromAllDrives: true}},
+	}
+	for _, rt := range allRequestTypes {
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%s/%s", rt, c.name), func(t *testing.T) {
+				reqCtx := NewRequestContext("default", c.driveID, rt)
+				reqCtx.DriveParams = c.params
+				if got := shaper.Resolve(reqCtx); got != c.want {
+					t.Errorf("Resolve = %+v, want %+v", got, c.want)
+				}
+				if reqCtx.DriveID != c.driveID {
+					t.Errorf("Resolve changed the context's drive to %q", reqCtx.DriveID)
+				}
+			})
+		}
+	}
+}
+
+func TestRequestShaper_Resolve_ZeroClient(t *testing.T) {
+	shaper := NewRequestShaper(&Client{})
+	want := ShapeParams{Corpora: CorporaUser, SupportsAllDrives: true, IncludeItemsFromAllDrives: true}
+	if got := shaper.Resolve(nil); got != want {
+		t.Errorf("Resolve(nil) = %+v, want %+v", got, want)
+	}
+}
+
+func TestRequestShaper_ShapeFilesList_Params(t *testing.T) {
+	queries := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		_, _ = w.Write([]byte(`{"files":[]}`))
+	}))
+	defer server.Close()
+	service, err := drive.NewService(context.Background(), option.WithEndpoint(server.URL+"/drive/v3/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	no := false
+	client := NewClient(service, 0, 100, logging.NewNoOpLogger())
+	client.DriveOverrides().Set("d2", types.DriveParams{SupportsAllDrives: &no})
+	shaper := NewRequestShaper(client)
+
+	cases := []struct {
+		driveID string
+		params  *types.DriveParams
+		want    map[string]string
+	}{
+		{"", nil, map[string]string{"corpora": "user", "driveId": "", "supportsAllDrives": "true", "includeItemsFromAllDrives": "true"}},
+		{"d1", nil, map[string]string{"corpora": "drive", "driveId": "d1", "supportsAllDrives": "true"}},
+		{"d2", nil, map[string]string{"corpora": "drive", "driveId": "d2", "supportsAllDrives": "false"}},
+		{"d1", &types.DriveParams{Corpora: CorporaAllDrives}, map[string]string{"corpora": "allDrives", "driveId": "", "includeItemsFromAllDrives": "true"}},
+	}
+	for _, c := range cases {
+		reqCtx := NewRequestContext("default", c.driveID, types.RequestTypeListOrSearch)
+		reqCtx.DriveParams = c.params
+		if _, err := shaper.ShapeFilesList(service.Files.List(), reqCtx).Do(); err != nil {
+			t.Fatal(err)
+		}
+		q := <-queries
+		for k, v := range c.want {
+			if q.Get(k) != v {
+				t.Errorf("drive %q %+v: %s = %q, want %q", c.driveID, c.params, k, q.Get(k), v)
+			}
+		}
+	}
+}
+
+func TestRequestShaper_ConcurrentDrives(t *testing.T) {
+	no := false
+	client := NewClient(nil, 3, 1000, logging.NewNoOpLogger())
+	client.ResourceKeys().AddKey("file1", "key1", "test")
+	client.ResourceKeys().AddKey("parent1", "pkey1", "test")
+	shaper := NewRequestShaper(client)
+	service, err := drive.NewService(context.Background(), option.WithoutAuthentication(), option.WithHTTPClient(&http.Client{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One context shared by every worker, with room to append in place
+	shared := NewRequestContext("default", "", types.RequestTypeMutation)
+	shared.InvolvedFileIDs = make([]string, 1, 8)
+	shared.InvolvedFileIDs[0] = "file1"
+	shared.InvolvedParentIDs = []string{"parent1"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			driveID := fmt.Sprintf("drive%d", i%4)
+			client.DriveOverrides().Set(driveID, types.DriveParams{SupportsAllDrives: &no})
+			reqCtx := NewRequestContext("default", driveID, types.RequestTypeListOrSearch)
+			if got := shaper.Resolve(reqCtx); got.DriveID != driveID || got.SupportsAllDrives {
+				t.Errorf("Resolve(%s) = %+v", driveID, got)
+			}
+			header := shaper.ShapeFilesUpdate(service.Files.Update("file1", &drive.File{}), shared).Header()
+			if got := header.Get("X-Goog-Drive-Resource-Keys"); got != "file1/key1,parent1/pkey1" {
+				t.Errorf("header = %q", got)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(shared.InvolvedFileIDs) != 1 || shared.InvolvedFileIDs[:2][1] != "" {
+		t.Errorf("shaping modified the shared context: %v", shared.InvolvedFileIDs[:2])
+	}
+}
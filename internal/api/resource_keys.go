@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
 // ResourceKeyManager manages resource keys for link-shared files
@@ -180,7 +182,7 @@ func (m *ResourceKeyManager) save() error {
 	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
 		return err
 	}
-	return os.WriteFile(m.path, data, 0600)
+	return utils.WriteFileAtomic(m.path, data, 0600)
 }
 
 // timeProvider is an interface for getting time
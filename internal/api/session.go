@@ -0,0 +1,47 @@
+package api
+
+import "sync/atomic"
+
+// OperationStats counts the API operations run through ExecuteWithRetry.
+// An operation is one logical call, however many attempts it took.
+type OperationStats struct {
+	Attempted int64 `json:"attempted"`
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	Retries   int64 `json:"retries"` // Attempts after the first
+}
+
+var operations struct {
+	attempted, succeeded, failed, retries atomic.Int64
+}
+
+// GetOperationStats returns the counts since the last reset
+func GetOperationStats() OperationStats {
+	return OperationStats{
+		Attempted: operations.attempted.Load(),
+		Succeeded: operations.succeeded.Load(),
+		Failed:    operations.failed.Load(),
+		Retries:   operations.retries.Load(),
+	}
+}
+
+// ResetOperationStats zeroes the operation counters
+func ResetOperationStats() {
+	for _, c := range []*atomic.Int64{&operations.attempted, &operations.succeeded,
+		&operations.failed, &operations.retries} {
+		c.Store(0)
+	}
+}
+
+// recordOperation counts a finished operation and its attempts
+func recordOperation(attempts int, err error) {
+	operations.attempted.Add(1)
+	if attempts > 1 {
+		operations.retries.Add(int64(attempts - 1))
+	}
+	if err != nil {
+		operations.failed.Add(1)
+		return
+	}
+	operations.succeeded.Add(1)
+}
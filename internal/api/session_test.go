@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/googleapi"
+)
+
+func TestOperationStats(t *testing.T) {
+	ResetOperationStats()
+	defer ResetOperationStats()
+	client := NewClient(nil, 2, 1, logging.NewNoOpLogger())
+	reqCtx := NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	calls := 0
+	_, _ = ExecuteWithRetry(context.Background(), client, reqCtx, func() (string, error) {
+		calls++
+		if calls == 1 {
+			return "", &googleapi.Error{Code: 503}
+		}
+		return "ok", nil
+	})
+	_, _ = ExecuteWithRetry(context.Background(), client, reqCtx, func() (string, error) {
+		return "", &googleapi.Error{Code: 503}
+	})
+	_, _ = ExecuteWithRetry(context.Background(), client, reqCtx, func() (string, error) {
+		return "", &googleapi.Error{Code: 404}
+	})
+
+	want := OperationStats{Attempted: 3, Succeeded: 1, Failed: 2, Retries: 3}
+	if got := GetOperationStats(); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+	ResetOperationStats()
+	if got := GetOperationStats(); got != (OperationStats{}) {
+		t.Errorf("stats after reset = %+v", got)
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// TransportOptions tunes the HTTP transport shared by all clients
+type TransportOptions struct {
+	// MaxConnsPerHost caps connections per host, including those in use;
+	// 0 means no limit
+	MaxConnsPerHost int
+	// MaxIdleConnsPerHost is how many idle connections per host are kept
+	// for reuse. Go's default of 2 forces new TLS handshakes whenever more
+	// requests than that run at once.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept
+	IdleConnTimeout time.Duration
+	// DisableHTTP2 restricts requests to HTTP/1.1
+	DisableHTTP2 bool
+	// DisableGzip stops asking Google for gzip-encoded responses
+	DisableGzip bool
+	// QuotaProject is sent as X-Goog-User-Project on API requests, so
+	// quota and billing are charged to that project instead of the OAuth
+	// client's
+	QuotaProject string
+}
+
+// DefaultTransportOptions returns the transport settings used unless
+// SetTransportOptions is called
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+var (
+	transportMu     sync.Mutex
+	sharedTransport http.RoundTripper
+	transportOpts   = DefaultTransportOptions()
+)
+
+// SetTransportOptions replaces the shared transport. Clients created before
+// the call keep the transport they were created with.
+func SetTransportOptions(opts TransportOptions) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	transportOpts = opts
+	sharedTransport = nil
+}
+
+// Transport returns the shared, tuned transport. It records TransportStats,
+// sets the quota project and, unless disabled, asks for gzip-encoded API
+// responses.
+func Transport() http.RoundTripper {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if sharedTransport == nil {
+		sharedTransport = newTransport(transportOpts)
+	}
+	return sharedTransport
+}
+
+// TransportContext returns ctx carrying an HTTP client on the shared
+// transport, which oauth2 uses as the base for authenticated clients
+func TransportContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: Transport()})
+}
+
+func newTransport(opts TransportOptions) http.RoundTripper {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		stats.connsOpened.Add(1)
+		return &countingConn{Conn: conn}, nil
+	}
+	base.MaxConnsPerHost = opts.MaxConnsPerHost
+	if opts.MaxIdleConnsPerHost > 0 {
+		base.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		if base.MaxIdleConns < opts.MaxIdleConnsPerHost {
+			base.MaxIdleConns = opts.MaxIdleConnsPerHost
+		}
+	}
+	if opts.IdleConnTimeout > 0 {
+		base.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.DisableHTTP2 {
+		base.ForceAttemptHTTP2 = false
+		base.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return &statsTransport{base: base, gzip: !opts.DisableGzip, quotaProject: opts.QuotaProject}
+}
+
+// statsTransport records TransportStats and request latencies, sets the
+// quota project and requests gzip responses
+type statsTransport struct {
+	base         http.RoundTripper
+	gzip         bool
+	quotaProject string
+}
+
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cloned := false
+	if t.quotaProject != "" && wantsQuotaProject(req) {
+		req = req.Clone(req.Context())
+		cloned = true
+		req.Header.Set(QuotaProjectHeader, t.quotaProject)
+	}
+	if t.gzip && wantsGzip(req) {
+		// Google only compresses responses for user agents containing
+		// "gzip"; the transport adds Accept-Encoding and decodes
+		if !cloned {
+			req = req.Clone(req.Context())
+		}
+		req.Header.Set("User-Agent", strings.TrimSpace(req.Header.Get("User-Agent")+" (gzip)"))
+	}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				stats.connsReused.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	stats.requests.Add(1)
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if profile := latencyProfile.Load(); profile != nil {
+		profile.recordRequest(endpointName(req), time.Since(started))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.ProtoMajor == 2 {
+		stats.http2.Add(1)
+	}
+	if resp.Uncompressed {
+		stats.gzipped.Add(1)
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body}
+	return resp, nil
+}
+
+// wantsGzip reports whether req is an API read whose response is worth
+// compressing: media downloads are left alone, since file content is
+// often already compressed and range requests must match stored bytes
+func wantsGzip(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return false
+	}
+	return req.URL.Query().Get("alt") != "media"
+}
+
+// QuotaProjectHeader names the project that API quota and billing are
+// charged to
+const QuotaProjectHeader = "X-Goog-User-Project"
+
+// wantsQuotaProject reports whether req is an API request, as opposed to
+// an OAuth token exchange, which is not charged to a project. A header the
+// caller set itself is kept.
+func wantsQuotaProject(req *http.Request) bool {
+	if req.Header.Get(QuotaProjectHeader) != "" {
+		return false
+	}
+	return req.URL.Host != "oauth2.googleapis.com" && !strings.HasSuffix(req.URL.Path, "/token")
+}
+
+// TransportStats counts the shared transport's activity
+type TransportStats struct {
+	Requests    int64 `json:"requests"`
+	HTTP2       int64 `json:"http2"`
+	Gzipped     int64 `json:"gzipped"`
+	ConnsOpened int64 `json:"connsOpened"`
+	ConnsReused int64 `json:"connsReused"`
+	WireBytes   int64 `json:"wireBytes"` // Read from the network, including TLS
+	BodyBytes   int64 `json:"bodyBytes"` // Decoded response bodies
+	SentBytes   int64 `json:"sentBytes"` // Written to the network, including TLS
+}
+
+var stats struct {
+	requests, http2, gzipped, connsOpened, connsReused, wireBytes, bodyBytes, sentBytes atomic.Int64
+}
+
+// GetTransportStats returns the counts since the last reset
+func GetTransportStats() TransportStats {
+	return TransportStats{
+		Requests:    stats.requests.Load(),
+		HTTP2:       stats.http2.Load(),
+		Gzipped:     stats.gzipped.Load(),
+		ConnsOpened: stats.connsOpened.Load(),
+		ConnsReused: stats.connsReused.Load(),
+		WireBytes:   stats.wireBytes.Load(),
+		BodyBytes:   stats.bodyBytes.Load(),
+		SentBytes:   stats.sentBytes.Load(),
+	}
+}
+
+// ResetTransportStats zeroes the transport counters
+func ResetTransportStats() {
+	for _, c := range []*atomic.Int64{&stats.requests, &stats.http2, &stats.gzipped,
+		&stats.connsOpened, &stats.connsReused, &stats.wireBytes, &stats.bodyBytes, &stats.sentBytes} {
+		c.Store(0)
+	}
+}
+
+// String summarizes the stats on one line
+func (s TransportStats) String() string {
+	return fmt.Sprintf("%d requests (%d HTTP/2, %d gzipped), %d connections opened, %d reused; %d bytes received for %d bytes of responses, %d bytes sent",
+		s.Requests, s.HTTP2, s.Gzipped, s.ConnsOpened, s.ConnsReused, s.WireBytes, s.BodyBytes, s.SentBytes)
+}
+
+type countingConn struct {
+	net.Conn
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	stats.wireBytes.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	stats.sentBytes.Add(int64(n))
+	return n, err
+}
+
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	stats.bodyBytes.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport_GzipAndStats(t *testing.T) {
+	payload := strings.Repeat(`{"id":"file","name":"report.pdf"},`, 500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.UserAgent(), "gzip") && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write([]byte(payload))
+			_ = gz.Close()
+			return
+		}
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	SetTransportOptions(DefaultTransportOptions())
+	defer SetTransportOptions(DefaultTransportOptions())
+	ResetTransportStats()
+	client := &http.Client{Transport: Transport()}
+
+	get := func(url string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("User-Agent", "gdrv-test")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if body := get(server.URL + "/drive/v3/files"); body != payload {
+		t.Fatal("list response not decoded")
+	}
+	if body := get(server.URL + "/drive/v3/files/f1?alt=media"); body != payload {
+		t.Fatal("media response changed")
+	}
+
+	stats := GetTransportStats()
+	if stats.Requests != 2 || stats.Gzipped != 1 {
+		t.Errorf("requests = %d, gzipped = %d; want 2 and 1", stats.Requests, stats.Gzipped)
+	}
+	if stats.ConnsOpened != 1 || stats.ConnsReused != 1 {
+		t.Errorf("connections opened = %d, reused = %d; want 1 and 1", stats.ConnsOpened, stats.ConnsReused)
+	}
+	if stats.BodyBytes != int64(2*len(payload)) {
+		t.Errorf("body bytes = %d, want %d", stats.BodyBytes, 2*len(payload))
+	}
+	// The gzipped list costs far less on the wire than the plain download
+	if stats.WireBytes >= int64(len(payload))*3/2 {
+		t.Errorf("wire bytes = %d, expected the list response to be compressed", stats.WireBytes)
+	}
+}
+
+func TestTransport_DisableGzip(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	defer server.Close()
+
+	opts := DefaultTransportOptions()
+	opts.DisableGzip = true
+	SetTransportOptions(opts)
+	defer SetTransportOptions(DefaultTransportOptions())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/drive/v3/files", nil)
+	req.Header.Set("User-Agent", "gdrv-test")
+	resp, err := (&http.Client{Transport: Transport()}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if userAgent != "gdrv-test" {
+		t.Errorf("User-Agent = %q, want it unchanged", userAgent)
+	}
+}
+
+func TestTransport_QuotaProject(t *testing.T) {
+	seen := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen[r.URL.Path] = r.Header.Get(QuotaProjectHeader)
+	}))
+	defer server.Close()
+
+	opts := DefaultTransportOptions()
+	opts.QuotaProject = "gdrv-quota"
+	SetTransportOptions(opts)
+	defer SetTransportOptions(DefaultTransportOptions())
+	client := &http.Client{Transport: Transport()}
+
+	send := func(method, path, project string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		if project != "" {
+			req.Header.Set(QuotaProjectHeader, project)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	send(http.MethodGet, "/drive/v3/files", "")
+	send(http.MethodPost, "/upload/drive/v3/files", "")
+	send(http.MethodGet, "/drive/v3/about", "explicit")
+	send(http.MethodPost, "/token", "")
+
+	want := map[string]string{
+		"/drive/v3/files":        "gdrv-quota",
+		"/upload/drive/v3/files": "gdrv-quota",
+		"/drive/v3/about":        "explicit",
+		"/token":                 "",
+	}
+	for path, project := range want {
+		if seen[path] != project {
+			t.Errorf("%s: %s = %q, want %q", path, QuotaProjectHeader, seen[path], project)
+		}
+	}
+}
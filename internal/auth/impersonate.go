@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"golang.org/x/oauth2"
+)
+
+// impersonation holds the --impersonate subject for the run and the
+// delegated credentials minted for it, by profile. They are never stored,
+// so impersonating a user does not create or change a profile.
+var impersonation struct {
+	mu      sync.Mutex
+	subject string
+	creds   map[string]*types.Credentials
+}
+
+// SetImpersonate makes GetValidCredentials act as subject through
+// domain-wide delegation for the rest of the run. An empty subject uses
+// the profile's own credentials.
+func SetImpersonate(subject string) {
+	impersonation.mu.Lock()
+	defer impersonation.mu.Unlock()
+	impersonation.subject = subject
+	impersonation.creds = nil
+}
+
+// Impersonating returns the subject set with SetImpersonate
+func Impersonating() string {
+	impersonation.mu.Lock()
+	defer impersonation.mu.Unlock()
+	return impersonation.subject
+}
+
+// delegatedCredentials mints credentials for subject from the key of the
+// profile's service account, with the profile's scopes. A token already
+// minted in this run is reused until it needs renewing.
+func (m *Manager) delegatedCredentials(ctx context.Context, profile, subject string) (*types.Credentials, error) {
+	impersonation.mu.Lock()
+	defer impersonation.mu.Unlock()
+	if creds := impersonation.creds[profile]; creds != nil && !m.NeedsRefresh(creds) {
+		return creds, nil
+	}
+
+	if !strings.Contains(subject, "@") {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("--impersonate must be a user's email address, got %q", subject)).Build())
+	}
+	profileCreds, err := m.LoadCredentials(profile)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+			"No credentials found. Run 'gdrv auth service-account' first.").Build())
+	}
+	if profileCreds.Type != types.AuthTypeServiceAccount && profileCreds.Type != types.AuthTypeImpersonated {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeDelegationNotConfigured,
+			fmt.Sprintf("--impersonate needs a service account profile, but profile %q uses %s credentials", profile, profileCreds.Type)).
+			WithContext("profile", profile).
+			WithContext("suggestedAction", "run 'gdrv auth service-account' for a profile with domain-wide delegation, or drop --impersonate").
+			Build())
+	}
+	if profileCreds.CredentialsSource == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeDelegationNotConfigured,
+			fmt.Sprintf("Profile %q did not keep its service account key, so tokens for other users cannot be minted", profile)).
+			WithContext("profile", profile).
+			WithContext("suggestedAction", "run 'gdrv auth service-account --credentials-source file://<key.json>' (or vault://, gcpsm://)").
+			Build())
+	}
+
+	creds, err := m.LoadServiceAccountFromSource(ctx, profileCreds.CredentialsSource, profileCreds.Scopes, subject)
+	if err != nil {
+		return nil, classifyDelegationError(err, profileCreds, subject)
+	}
+	if impersonation.creds == nil {
+		impersonation.creds = map[string]*types.Credentials{}
+	}
+	impersonation.creds[profile] = creds
+	return creds, nil
+}
+
+// classifyDelegationError explains a failed token request for subject.
+// Google refuses it with unauthorized_client when the service account's
+// client ID is not granted the scopes in the Admin console, and with
+// invalid_grant when the subject is not a user of the domain.
+func classifyDelegationError(err error, creds *types.Credentials, subject string) error {
+	var retrieveErr *oauth2.RetrieveError
+	if !stderrors.As(err, &retrieveErr) {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+			fmt.Sprintf("Failed to impersonate %s: %v", subject, err)).Build())
+	}
+	var resp struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(retrieveErr.Body, &resp)
+
+	switch resp.Error {
+	case "unauthorized_client", "access_denied":
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeDelegationNotConfigured,
+			fmt.Sprintf("Domain-wide delegation is not configured for service account %s with the requested scopes", creds.ServiceAccountEmail)).
+			WithContext("clientId", creds.ClientID).
+			WithContext("scopes", strings.Join(creds.Scopes, ",")).
+			WithContext("oauthError", resp.Error).
+			WithContext("suggestedAction", fmt.Sprintf("in the Admin console, under Security > API controls > Domain-wide delegation, authorize client ID %s for these scopes", creds.ClientID)).
+			Build())
+	case "invalid_grant":
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+			fmt.Sprintf("Cannot impersonate %s: %s", subject, resp.ErrorDescription)).
+			WithContext("oauthError", resp.Error).
+			WithContext("suggestedAction", "check that the address is an active user in the delegating domain").
+			Build())
+	}
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+		fmt.Sprintf("Failed to impersonate %s: %v", subject, err)).Build())
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func writeTestServiceAccountKey(t *testing.T, tokenURI string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(ServiceAccountKey{
+		Type:         "service_account",
+		PrivateKeyID: "k1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  "robot@project.iam.gserviceaccount.com",
+		ClientID:     "1234567890",
+		TokenURI:     tokenURI,
+	})
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGetValidCredentials_Impersonate(t *testing.T) {
+	var subjects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+			return
+		}
+		// The assertion is a JWT; its claims carry the subject
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Errorf("assertion = %q", r.PostForm.Get("assertion"))
+			return
+		}
+		var claims struct {
+			Sub string `json:"sub"`
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		_ = json.Unmarshal(payload, &claims)
+		subjects = append(subjects, claims.Sub)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch claims.Sub {
+		case "alice@example.com":
+			_, _ = w.Write([]byte(`{"access_token":"alice-token","token_type":"Bearer","expires_in":3600}`))
+		case "nobody@example.com":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid email or User ID"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized_client","error_description":"Client is unauthorized to retrieve access tokens using this method, or client not authorized for any of the scopes requested."}`))
+		}
+	}))
+	defer server.Close()
+	t.Cleanup(func() { SetImpersonate("") })
+
+	mgr := NewManagerWithOptions(t.TempDir(), ManagerOptions{ForcePlainFile: true})
+	stored := &types.Credentials{
+		AccessToken:         "robot-token",
+		ExpiryDate:          time.Now().Add(time.Hour),
+		Scopes:              []string{utils.ScopeReadonly},
+		Type:                types.AuthTypeServiceAccount,
+		ClientID:            "1234567890",
+		ServiceAccountEmail: "robot@project.iam.gserviceaccount.com",
+		CredentialsSource:   "file://" + writeTestServiceAccountKey(t, server.URL),
+	}
+	if err := mgr.SaveCredentials("robot", stored); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	SetImpersonate("alice@example.com")
+	creds, err := mgr.GetValidCredentials(ctx, "robot")
+	if err != nil {
+		t.Fatalf("GetValidCredentials: %v", err)
+	}
+	if creds.AccessToken != "alice-token" || creds.Type != types.AuthTypeImpersonated || creds.ImpersonatedUser != "alice@example.com" {
+		t.Errorf("creds = %+v", creds)
+	}
+	// The token is minted once per run and never stored in the profile
+	if _, err := mgr.GetValidCredentials(ctx, "robot"); err != nil || len(subjects) != 1 {
+		t.Errorf("second call: err = %v, token requests = %d", err, len(subjects))
+	}
+	if saved, _ := mgr.LoadCredentials("robot"); saved.AccessToken != "robot-token" || saved.ImpersonatedUser != "" {
+		t.Errorf("profile changed to %+v", saved)
+	}
+
+	SetImpersonate("bob@example.com")
+	_, err = mgr.GetValidCredentials(ctx, "robot")
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeDelegationNotConfigured || appErr.CLIError.Context["clientId"] != "1234567890" {
+		t.Errorf("unauthorized_client: err = %v", err)
+	}
+
+	SetImpersonate("nobody@example.com")
+	_, err = mgr.GetValidCredentials(ctx, "robot")
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeAuthRequired ||
+		!strings.Contains(appErr.CLIError.Message, "Invalid email or User ID") {
+		t.Errorf("invalid_grant: err = %v", err)
+	}
+
+	// OAuth profiles and service account profiles without a kept key cannot
+	// mint tokens for other users
+	if err := mgr.SaveCredentials("user", &types.Credentials{
+		AccessToken: "t", ExpiryDate: time.Now().Add(time.Hour), Type: types.AuthTypeOAuth, ClientID: "c",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	keyFileOnly := *stored
+	keyFileOnly.CredentialsSource = ""
+	if err := mgr.SaveCredentials("keyfile", &keyFileOnly); err != nil {
+		t.Fatal(err)
+	}
+	SetImpersonate("alice@example.com")
+	for _, profile := range []string{"user", "keyfile"} {
+		_, err := mgr.GetValidCredentials(ctx, profile)
+		if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeDelegationNotConfigured {
+			t.Errorf("profile %s: err = %v", profile, err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/errors"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
@@ -20,6 +21,7 @@ import (
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/driveactivity/v2"
 	"google.golang.org/api/sheets/v4"
 	"google.golang.org/api/slides/v1"
 )
@@ -39,6 +41,8 @@ type Manager struct {
 	storage        StorageBackend
 	oauthConfig    *oauth2.Config
 	storageWarning string
+	lockTimeout    time.Duration // Wait for another process's refresh (default: profileLockTimeout)
+	revokeURL      string        // Token revocation endpoint (default: GoogleRevokeURL)
 }
 
 // NewManager creates a new auth manager
@@ -139,6 +143,7 @@ func (m *Manager) LoadCredentials(profile string) (*types.Credentials, error) {
 		ClientID:            stored.ClientID,
 		ServiceAccountEmail: stored.ServiceAccountEmail,
 		ImpersonatedUser:    stored.ImpersonatedUser,
+		CredentialsSource:   stored.CredentialsSource,
 	}, nil
 }
 
@@ -160,6 +165,7 @@ func (m *Manager) SaveCredentials(profile string, creds *types.Credentials) erro
 		ClientID:            clientID,
 		ServiceAccountEmail: creds.ServiceAccountEmail,
 		ImpersonatedUser:    creds.ImpersonatedUser,
+		CredentialsSource:   creds.CredentialsSource,
 	}
 
 	data, err := json.Marshal(stored)
@@ -244,15 +250,48 @@ func (m *Manager) RefreshCredentials(ctx context.Context, creds *types.Credentia
 	}, nil
 }
 
-// GetValidCredentials returns valid credentials, refreshing if necessary
+// GetValidCredentials returns valid credentials, refreshing if necessary.
+// While a subject is set with SetImpersonate, it returns credentials
+// delegated to that subject instead (see delegatedCredentials).
 func (m *Manager) GetValidCredentials(ctx context.Context, profile string) (*types.Credentials, error) {
+	if subject := Impersonating(); subject != "" {
+		return m.delegatedCredentials(ctx, profile, subject)
+	}
+
 	creds, err := m.LoadCredentials(profile)
 	if err != nil {
 		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
 			"No credentials found. Run 'gdrv auth login' first.").Build())
 	}
+	m.useIssuingClient(profile, creds)
+
+	// Renewal happens under the profile lock. Another process may have
+	// renewed the token while this one waited, so it is loaded again.
+	if m.needsRenewal(creds) {
+		unlock, err := m.lockProfile(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		creds, err = m.LoadCredentials(profile)
+		if err != nil {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+				"No credentials found. Run 'gdrv auth login' first.").Build())
+		}
+	}
 
 	if creds.Type == types.AuthTypeServiceAccount || creds.Type == types.AuthTypeImpersonated {
+		if creds.CredentialsSource != "" && m.NeedsRefresh(creds) {
+			newCreds, err := m.LoadServiceAccountFromSource(ctx, creds.CredentialsSource, creds.Scopes, creds.ImpersonatedUser)
+			if err != nil {
+				return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthExpired,
+					fmt.Sprintf("Service account token renewal failed: %v", err)).Build())
+			}
+			if err := m.SaveCredentials(profile, newCreds); err != nil {
+				return nil, fmt.Errorf("failed to save renewed credentials: %w", err)
+			}
+			return newCreds, nil
+		}
 		if time.Now().After(creds.ExpiryDate) {
 			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthExpired,
 				"Service account token expired. Run 'gdrv auth service-account' to re-authenticate.").Build())
@@ -275,20 +314,32 @@ func (m *Manager) GetValidCredentials(ctx context.Context, profile string) (*typ
 	return creds, nil
 }
 
-// GetHTTPClient returns an authenticated HTTP client
+// needsRenewal reports whether GetValidCredentials will fetch and store a
+// new token for creds
+func (m *Manager) needsRenewal(creds *types.Credentials) bool {
+	if !m.NeedsRefresh(creds) {
+		return false
+	}
+	if creds.Type == types.AuthTypeServiceAccount || creds.Type == types.AuthTypeImpersonated {
+		return creds.CredentialsSource != ""
+	}
+	return true
+}
+
+// GetHTTPClient returns an authenticated HTTP client on the shared, tuned
+// transport (see api.Transport). Requests made through it are refused while
+// read-only mode is enabled (see api.SetReadOnly).
 func (m *Manager) GetHTTPClient(ctx context.Context, creds *types.Credentials) *http.Client {
+	ctx = api.TransportContext(ctx)
 	token := &oauth2.Token{
 		AccessToken:  creds.AccessToken,
 		RefreshToken: creds.RefreshToken,
 		Expiry:       creds.ExpiryDate,
 	}
-	if m.oauthConfig == nil {
-		return oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
-	}
-	if creds.Type != types.AuthTypeOAuth {
-		return oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	if m.oauthConfig == nil || creds.Type != types.AuthTypeOAuth {
+		return api.WrapReadOnly(oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)))
 	}
-	return m.oauthConfig.Client(ctx, token)
+	return api.WrapReadOnly(m.oauthConfig.Client(ctx, token))
 }
 
 // loadStoredCredentials loads credentials from storage
@@ -325,11 +376,14 @@ func (m *Manager) loadStoredCredentials(profile string) (*types.StoredCredential
 
 func (m *Manager) deleteStoredCredentials(profile string) error {
 	key, err := m.resolveCredentialKey(profile)
+	deleted := false
 	if err == nil {
-		_ = m.storage.Delete(key)
+		deleted = m.storage.Delete(key) == nil
 		_ = os.Remove(metadataFilePath(m.configDir, key))
+		_ = os.Remove(metadataFilePath(m.configDir, key) + utils.BackupSuffix)
 	}
-	if err := m.storage.Delete(profile); err != nil {
+	// Legacy credentials are stored under the bare profile name
+	if err := m.storage.Delete(profile); err != nil && !deleted {
 		return err
 	}
 	return nil
@@ -586,6 +640,10 @@ func (m *Manager) GetAdminService(ctx context.Context, creds *types.Credentials)
 	return m.GetServiceFactory().CreateAdminService(ctx, creds)
 }
 
+func (m *Manager) GetActivityService(ctx context.Context, creds *types.Credentials) (*driveactivity.Service, error) {
+	return m.GetServiceFactory().CreateActivityService(ctx, creds)
+}
+
 func RequiredScopesForService(svcType ServiceType) []string {
 	switch svcType {
 	case ServiceDrive:
@@ -598,6 +656,8 @@ func RequiredScopesForService(svcType ServiceType) []string {
 		return []string{utils.ScopeSlides}
 	case ServiceAdminDir:
 		return []string{utils.ScopeAdminDirectoryUser, utils.ScopeAdminDirectoryGroup}
+	case ServiceActivity:
+		return []string{utils.ScopeActivityReadonly}
 	default:
 		return nil
 	}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
 type AuthMetadata struct {
@@ -31,11 +33,11 @@ func writeMetadata(configDir, key string, metadata *AuthMetadata) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(metadataFilePath(configDir, key), data, 0600)
+	return utils.ReplaceFile(metadataFilePath(configDir, key), data, 0600, utils.ValidJSON)
 }
 
 func readMetadata(path string) (*AuthMetadata, error) {
-	data, err := os.ReadFile(path)
+	data, err := utils.ReadFileWithBackup(path, utils.ValidJSON)
 	if err != nil {
 		return nil, err
 	}
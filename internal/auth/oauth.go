@@ -27,6 +27,10 @@ type OAuthFlow struct {
 	codeVerifier string
 	codeChan     chan string
 	errChan      chan error
+
+	// includeGranted asks Google to keep previously granted scopes, so a
+	// consent for new scopes extends the existing grant
+	includeGranted bool
 }
 
 // NewOAuthFlow creates a new OAuth flow handler
@@ -66,12 +70,15 @@ func NewOAuthFlow(config *oauth2.Config, listener net.Listener, redirectURL stri
 
 // GetAuthURL returns the URL to redirect user for authentication
 func (f *OAuthFlow) GetAuthURL() string {
-	return f.config.AuthCodeURL(
-		f.state,
+	opts := []oauth2.AuthCodeOption{
 		oauth2.AccessTypeOffline,
 		oauth2.SetAuthURLParam("code_challenge", codeChallengeS256(f.codeVerifier)),
 		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
-	)
+	}
+	if f.includeGranted {
+		opts = append(opts, oauth2.SetAuthURLParam("include_granted_scopes", "true"))
+	}
+	return f.config.AuthCodeURL(f.state, opts...)
 }
 
 // StartCallbackServer starts the callback server and waits for auth code
@@ -140,11 +147,21 @@ func (f *OAuthFlow) ExchangeCode(ctx context.Context, code string) (*types.Crede
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
 		ExpiryDate:   token.Expiry,
-		Scopes:       f.config.Scopes,
+		Scopes:       grantedScopes(token, f.config.Scopes),
 		Type:         types.AuthTypeOAuth,
 	}, nil
 }
 
+// grantedScopes returns the scopes the user actually granted, which can be
+// fewer than requested with granular consent, or more with
+// include_granted_scopes
+func grantedScopes(token *oauth2.Token, requested []string) []string {
+	if granted, ok := token.Extra("scope").(string); ok && strings.TrimSpace(granted) != "" {
+		return strings.Fields(granted)
+	}
+	return requested
+}
+
 // Close cleans up resources
 func (f *OAuthFlow) Close() {
 	if f.listener != nil {
@@ -195,6 +212,8 @@ func (m *Manager) Authenticate(ctx context.Context, profile string, openBrowser
 		flow, err = newLoopbackFlow(m.oauthConfig)
 		if err != nil {
 			manualFallback = true
+		} else {
+			flow.includeGranted = opts.IncludeGrantedScopes
 		}
 	}
 
@@ -215,6 +234,7 @@ func (m *Manager) Authenticate(ctx context.Context, profile string, openBrowser
 		if err != nil {
 			return nil, err
 		}
+		flow.includeGranted = opts.IncludeGrantedScopes
 		authURL = flow.GetAuthURL()
 		fmt.Printf("Manual authentication required.\n")
 		fmt.Printf("Open this URL in a browser and approve access:\n%s\n", authURL)
@@ -266,6 +286,9 @@ func (m *Manager) Authenticate(ctx context.Context, profile string, openBrowser
 // OAuthAuthOptions controls OAuth authentication behavior.
 type OAuthAuthOptions struct {
 	NoBrowser bool
+	// IncludeGrantedScopes extends the user's existing grant instead of
+	// replacing it (incremental consent)
+	IncludeGrantedScopes bool
 }
 
 func newLoopbackFlow(config *oauth2.Config) (*OAuthFlow, error) {
@@ -309,3 +332,19 @@ func isHeadlessEnv() bool {
 	}
 	return false
 }
+
+// ExtendConsent runs the OAuth flow again to add scopes to a profile's
+// grant. Existing scopes are requested too and include_granted_scopes is
+// set, so the new token covers both.
+func (m *Manager) ExtendConsent(ctx context.Context, profile string, current *types.Credentials, add []string, openBrowser func(string) error, opts OAuthAuthOptions) (*types.Credentials, error) {
+	if m.oauthConfig == nil {
+		return nil, fmt.Errorf("OAuth config not set")
+	}
+	var existing []string
+	if current != nil {
+		existing = current.Scopes
+	}
+	m.oauthConfig.Scopes = MergeScopes(existing, add)
+	opts.IncludeGrantedScopes = true
+	return m.Authenticate(ctx, profile, openBrowser, opts)
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"os"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// oauthClient is an OAuth client ID and its secret, if it has one
+type oauthClient struct {
+	id     string
+	secret string
+}
+
+// configuredClients returns the OAuth clients a profile may use, in the
+// order logins consider them: GDRV_CLIENT_ID, the profile's config, the
+// top-level config and the bundled client
+func configuredClients(profile string) []oauthClient {
+	var clients []oauthClient
+	if id := strings.TrimSpace(os.Getenv("GDRV_CLIENT_ID")); id != "" {
+		clients = append(clients, oauthClient{id, strings.TrimSpace(os.Getenv("GDRV_CLIENT_SECRET"))})
+	}
+	if cfg, err := config.Load(); err == nil {
+		p := cfg.Profile(profile)
+		if p.OAuthClientID != "" {
+			clients = append(clients, oauthClient{p.OAuthClientID, p.OAuthClientSecret})
+		}
+		if cfg.OAuthClientID != "" {
+			clients = append(clients, oauthClient{cfg.OAuthClientID, cfg.OAuthClientSecret})
+		}
+	}
+	if id, secret, ok := GetBundledOAuthClient(); ok {
+		clients = append(clients, oauthClient{id, secret})
+	}
+	return clients
+}
+
+// useIssuingClient sets the OAuth config to the configured client that
+// issued creds, so commands other than login can refresh its tokens. A
+// refresh token only works with its own client, so nothing is set when none
+// of the configured clients issued creds.
+func (m *Manager) useIssuingClient(profile string, creds *types.Credentials) {
+	if m.oauthConfig != nil || creds.Type != types.AuthTypeOAuth || creds.ClientID == "" {
+		return
+	}
+	for _, c := range configuredClients(profile) {
+		if c.id == creds.ClientID {
+			m.SetOAuthConfig(c.id, c.secret, creds.Scopes)
+			return
+		}
+	}
+}
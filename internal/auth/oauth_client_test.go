@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestUseIssuingClient(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GDRV_CONFIG_DIR", dir)
+	t.Setenv("GDRV_CLIENT_ID", "")
+	t.Setenv("GDRV_CLIENT_SECRET", "")
+	cfg := `{"oauthClientId":"global-id","oauthClientSecret":"global-secret",
+		"profiles":{"acme":{"oauthClientId":"acme-id","oauthClientSecret":"acme-secret"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		profile    string
+		clientID   string
+		wantSecret string // empty when no client should be set
+	}{
+		{"profile client", "acme", "acme-id", "acme-secret"},
+		{"top-level client for a profile with its own", "acme", "global-id", "global-secret"},
+		{"profile client is not shared", "other", "acme-id", ""},
+		{"unknown client", "acme", "someone-else", ""},
+		{"no recorded client", "acme", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := NewManager(dir)
+			mgr.useIssuingClient(tt.profile, &types.Credentials{Type: types.AuthTypeOAuth, ClientID: tt.clientID})
+			config := mgr.GetOAuthConfig()
+			if tt.wantSecret == "" {
+				if config != nil {
+					t.Errorf("client %s set, want none", config.ClientID)
+				}
+				return
+			}
+			if config == nil || config.ClientID != tt.clientID || config.ClientSecret != tt.wantSecret {
+				t.Errorf("config = %+v", config)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Profile locks serialize token refreshes between gdrv processes. Without
+// them two processes refreshing the same profile at once (concurrent cron
+// jobs, say) can each write a token, and the later write may carry a stale
+// refresh token.
+const (
+	profileLockDir     = "locks"
+	profileLockRetry   = 100 * time.Millisecond
+	profileLockTimeout = 30 * time.Second
+)
+
+// errLockHeld is returned by tryLockFile when another process holds the lock
+var errLockHeld = errors.New("lock held by another process")
+
+// profileLockPath returns the lock file of a profile in the config directory
+func (m *Manager) profileLockPath(profile string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, profile)
+	return filepath.Join(m.configDir, profileLockDir, name+".lock")
+}
+
+// lockProfile takes the profile's lock, retrying while another process
+// holds it, and returns the function that releases it. The lock is
+// released by the OS if the process dies.
+func (m *Manager) lockProfile(ctx context.Context, profile string) (func(), error) {
+	path := m.profileLockPath(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile lock: %w", err)
+	}
+
+	timeout := m.lockTimeout
+	if timeout <= 0 {
+		timeout = profileLockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockHeld) {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock profile: %w", err)
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeTimeout,
+				fmt.Sprintf("Timed out after %s waiting for another gdrv process to finish refreshing profile '%s'", timeout, profile)).
+				WithContext("lockFile", path).
+				Build())
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, ctx.Err()
+		case <-time.After(profileLockRetry):
+		}
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
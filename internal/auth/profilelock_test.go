@@ -0,0 +1,87 @@
+//go:build linux || darwin || freebsd
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestLockProfile_Contention(t *testing.T) {
+	dir := t.TempDir()
+	holder := NewManagerWithOptions(dir, ManagerOptions{ForcePlainFile: true})
+	waiter := NewManagerWithOptions(dir, ManagerOptions{ForcePlainFile: true})
+	waiter.lockTimeout = 300 * time.Millisecond
+
+	unlock, err := holder.lockProfile(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("lockProfile: %v", err)
+	}
+
+	_, err = waiter.lockProfile(context.Background(), "work")
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeTimeout {
+		t.Fatalf("expected a timeout while the lock is held, got %v", err)
+	}
+
+	// Other profiles are not blocked
+	unlockOther, err := waiter.lockProfile(context.Background(), "personal")
+	if err != nil {
+		t.Fatalf("lockProfile(personal): %v", err)
+	}
+	unlockOther()
+
+	// The waiter gets the lock once it is released
+	waiter.lockTimeout = 5 * time.Second
+	time.AfterFunc(200*time.Millisecond, unlock)
+	unlockWaiter, err := waiter.lockProfile(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("lockProfile after release: %v", err)
+	}
+	unlockWaiter()
+}
+
+func TestGetValidCredentials_UsesTokenRefreshedWhileWaiting(t *testing.T) {
+	dir := t.TempDir()
+	other := NewManagerWithOptions(dir, ManagerOptions{ForcePlainFile: true})
+	mgr := NewManagerWithOptions(dir, ManagerOptions{ForcePlainFile: true})
+
+	expired := &types.Credentials{
+		AccessToken:  "old",
+		RefreshToken: "refresh",
+		ExpiryDate:   time.Now().Add(-time.Hour),
+		Type:         types.AuthTypeOAuth,
+		ClientID:     "client",
+	}
+	if err := other.SaveCredentials("work", expired); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process is refreshing; it stores a new token, then releases
+	unlock, err := other.lockProfile(context.Background(), "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(200*time.Millisecond, func() {
+		fresh := *expired
+		fresh.AccessToken = "new"
+		fresh.ExpiryDate = time.Now().Add(time.Hour)
+		if err := other.SaveCredentials("work", &fresh); err != nil {
+			t.Error(err)
+		}
+		unlock()
+	})
+
+	// mgr has no OAuth config, so it would fail if it refreshed itself
+	creds, err := mgr.GetValidCredentials(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("GetValidCredentials: %v", err)
+	}
+	if creds.AccessToken != "new" {
+		t.Errorf("AccessToken = %q, want the token stored by the other process", creds.AccessToken)
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// RepairFiles checks the profile list and the credential and metadata
+// files in the config dir. With restore, a corrupt or missing file whose
+// backup is good is restored from it (see utils.RepairFile). Encrypted
+// credentials are checked only when this manager uses encrypted storage,
+// since the key is needed to tell whether they are intact.
+func (m *Manager) RepairFiles(restore bool) ([]utils.FileCheck, error) {
+	var checks []utils.FileCheck
+	profilesFile := filepath.Join(m.configDir, profilesFileName)
+	if check := utils.RepairFile(profilesFile, utils.ValidJSON, restore); check.Status != utils.FileMissing {
+		checks = append(checks, check)
+	}
+
+	credDir := filepath.Join(m.configDir, "credentials")
+	entries, err := os.ReadDir(credDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checks, nil
+		}
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		// A backup without its file is checked as the missing file
+		name := strings.TrimSuffix(entry.Name(), utils.BackupSuffix)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	encrypted, _ := m.storage.(*EncryptedFileStorage)
+	for _, name := range names {
+		var valid func([]byte) error
+		switch filepath.Ext(name) {
+		case ".json":
+			valid = utils.ValidJSON
+		case ".enc":
+			if encrypted == nil {
+				continue
+			}
+			valid = encrypted.validEncrypted
+		default:
+			// Temp files and corrupt files already set aside
+			continue
+		}
+		checks = append(checks, utils.RepairFile(filepath.Join(credDir, name), valid, restore))
+	}
+	return checks, nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestRepairFiles_RestoresCorruptCredentials(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManagerWithOptions(dir, ManagerOptions{ForcePlainFile: true})
+
+	first := []byte(`{"profile":"work","access_token":"one"}`)
+	second := []byte(`{"profile":"work","access_token":"two"}`)
+	if err := mgr.storage.Save("work", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.storage.Save("work", second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a write cut short by a crash in an older release
+	credFile := filepath.Join(dir, "credentials", "work.json")
+	if err := os.WriteFile(credFile, second[:10], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := mgr.storage.Load("work")
+	if err != nil {
+		t.Fatalf("Load should fall back to the backup: %v", err)
+	}
+	if string(data) != string(first) {
+		t.Errorf("Load = %s, want the backup %s", data, first)
+	}
+
+	profiles, err := mgr.ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 1 || profiles[0] != "work" {
+		t.Errorf("ListProfiles = %v, want [work] without backups", profiles)
+	}
+
+	checks, err := mgr.RepairFiles(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].Path != credFile || checks[0].Status != utils.FileRestorable {
+		t.Fatalf("dry-run checks = %+v", checks)
+	}
+
+	checks, err = mgr.RepairFiles(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].Status != utils.FileRestored {
+		t.Fatalf("checks = %+v", checks)
+	}
+	if got, _ := os.ReadFile(credFile); string(got) != string(first) {
+		t.Errorf("restored file = %s, want %s", got, first)
+	}
+
+	if err := mgr.storage.Delete("work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(credFile + utils.BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("Delete left the backup behind: %v", err)
+	}
+}
+
+func TestRepairFiles_EncryptedStorage(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManagerWithOptions(dir, ManagerOptions{ForceEncryptedFile: true})
+	if _, ok := mgr.storage.(*EncryptedFileStorage); !ok {
+		t.Skip("encrypted storage unavailable")
+	}
+
+	if err := mgr.storage.Save("work", []byte(`{"access_token":"one"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.storage.Save("work", []byte(`{"access_token":"two"}`)); err != nil {
+		t.Fatal(err)
+	}
+	credFile := filepath.Join(dir, "credentials", "work.enc")
+	if err := os.WriteFile(credFile, []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	checks, err := mgr.RepairFiles(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].Status != utils.FileRestored {
+		t.Fatalf("checks = %+v", checks)
+	}
+	data, err := mgr.storage.Load("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"access_token":"one"}` {
+		t.Errorf("Load = %s", data)
+	}
+}
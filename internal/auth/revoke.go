@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// GoogleRevokeURL is Google's OAuth token revocation endpoint
+const GoogleRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+// RevokeResult describes what RevokeCredentials did for a profile
+type RevokeResult struct {
+	Profile string         `json:"profile"`
+	Type    types.AuthType `json:"type"`
+	// Revoked is set when Google revoked the grant; AlreadyInvalid when
+	// Google no longer knew the token (revoked or expired elsewhere)
+	Revoked        bool   `json:"revoked"`
+	AlreadyInvalid bool   `json:"alreadyInvalid,omitempty"`
+	LocalRemoved   bool   `json:"localRemoved"`
+	Note           string `json:"note,omitempty"`
+}
+
+// RevokeCredentials revokes a profile's OAuth grant with Google and removes
+// its local credentials. Revoking the refresh token ends the whole grant,
+// including access tokens issued from it.
+//
+// Service account keys cannot be revoked this way; their local token is
+// removed and the key must be disabled in the Cloud console. If Google
+// cannot be reached, local credentials are kept unless keepOnFailure is
+// false, so the revocation can be retried.
+func (m *Manager) RevokeCredentials(ctx context.Context, profile string, keepOnFailure bool) (*RevokeResult, error) {
+	creds, err := m.LoadCredentials(profile)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+			fmt.Sprintf("No credentials found for profile '%s'", profile)).Build())
+	}
+	result := &RevokeResult{Profile: profile, Type: creds.Type}
+
+	if creds.Type == types.AuthTypeOAuth {
+		token := creds.RefreshToken
+		if token == "" {
+			token = creds.AccessToken
+		}
+		invalid, err := m.revokeToken(ctx, token)
+		switch {
+		case err == nil:
+			result.Revoked = !invalid
+			result.AlreadyInvalid = invalid
+		case keepOnFailure:
+			return result, utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+				fmt.Sprintf("Failed to revoke the grant for profile '%s': %v", profile, err)).
+				WithContext("suggestedAction", "retry, or use --force to remove the local credentials anyway").
+				Build())
+		default:
+			result.Note = fmt.Sprintf("revocation failed (%v); the grant may still be active, remove it at https://myaccount.google.com/permissions", err)
+		}
+	} else {
+		result.Note = "service account keys are not revoked; disable the key in the Google Cloud console if it is no longer needed"
+	}
+
+	if err := m.DeleteCredentials(profile); err != nil {
+		return result, err
+	}
+	result.LocalRemoved = true
+	return result, nil
+}
+
+// revokeToken posts token to the revocation endpoint. invalid reports that
+// Google rejected the token as unknown, which means the grant is already
+// gone.
+func (m *Manager) revokeToken(ctx context.Context, token string) (invalid bool, err error) {
+	endpoint := m.revokeURL
+	if endpoint == "" {
+		endpoint = GoogleRevokeURL
+	}
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	var oauthErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &oauthErr)
+	if resp.StatusCode == http.StatusBadRequest && oauthErr.Error == "invalid_token" {
+		return true, nil
+	}
+	if oauthErr.Error != "" {
+		return false, fmt.Errorf("%s: %s (HTTP %d)", oauthErr.Error, oauthErr.Description, resp.StatusCode)
+	}
+	return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
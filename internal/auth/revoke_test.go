@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestRevokeCredentials(t *testing.T) {
+	tests := []struct {
+		name          string
+		credType      types.AuthType
+		status        int
+		body          string
+		keepOnFailure bool
+		wantErr       bool
+		wantRevoked   bool
+		wantInvalid   bool
+		wantRemoved   bool
+		wantNote      bool
+		wantCalls     int
+	}{
+		{name: "revoked", credType: types.AuthTypeOAuth, status: 200, wantRevoked: true, wantRemoved: true, wantCalls: 1},
+		{name: "already invalid", credType: types.AuthTypeOAuth, status: 400, body: `{"error":"invalid_token"}`,
+			wantInvalid: true, wantRemoved: true, wantCalls: 1},
+		{name: "failure keeps credentials", credType: types.AuthTypeOAuth, status: 503, keepOnFailure: true,
+			wantErr: true, wantCalls: 1},
+		{name: "failure forced", credType: types.AuthTypeOAuth, status: 503, wantRemoved: true, wantNote: true, wantCalls: 1},
+		{name: "service account", credType: types.AuthTypeServiceAccount, wantRemoved: true, wantNote: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if err := r.ParseForm(); err != nil || r.Form.Get("token") != "refresh" {
+					t.Errorf("token = %q, want the refresh token", r.Form.Get("token"))
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			mgr := NewManagerWithOptions(t.TempDir(), ManagerOptions{ForcePlainFile: true})
+			mgr.revokeURL = server.URL
+			creds := &types.Credentials{
+				AccessToken:  "access",
+				RefreshToken: "refresh",
+				ExpiryDate:   time.Now().Add(time.Hour),
+				Type:         tt.credType,
+				ClientID:     "client",
+			}
+			if err := mgr.SaveCredentials("work", creds); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := mgr.RevokeCredentials(context.Background(), "work", tt.keepOnFailure)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("revoke calls = %d, want %d", calls, tt.wantCalls)
+			}
+			_, loadErr := mgr.LoadCredentials("work")
+			if removed := loadErr != nil; removed != tt.wantRemoved {
+				t.Errorf("credentials removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if tt.wantErr {
+				if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeNetworkError {
+					t.Errorf("err = %v, want a network error", err)
+				}
+				return
+			}
+			if result.Revoked != tt.wantRevoked || result.AlreadyInvalid != tt.wantInvalid || result.LocalRemoved != tt.wantRemoved {
+				t.Errorf("result = %+v", result)
+			}
+			if (result.Note != "") != tt.wantNote {
+				t.Errorf("note = %q, wantNote %v", result.Note, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestRevokeCredentials_NoProfile(t *testing.T) {
+	mgr := NewManagerWithOptions(t.TempDir(), ManagerOptions{ForcePlainFile: true})
+	_, err := mgr.RevokeCredentials(context.Background(), "missing", true)
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeAuthRequired {
+		t.Errorf("err = %v, want AUTH_REQUIRED", err)
+	}
+}
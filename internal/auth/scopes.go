@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// CommandFamily groups commands by the access they need, so a profile can
+// be granted only the scopes for the commands it actually runs
+type CommandFamily string
+
+const (
+	FamilyNone     CommandFamily = ""         // Local commands, no API access
+	FamilyMetadata CommandFamily = "metadata" // Account and quota information
+	FamilyRead     CommandFamily = "read"     // Listings, downloads, audits
+	FamilyCreate   CommandFamily = "create"   // New files and folders
+	FamilyWrite    CommandFamily = "write"    // Changes to existing files and sharing
+	FamilyAdmin    CommandFamily = "admin"    // Admin SDK users and groups
+	FamilySheets   CommandFamily = "sheets"
+	FamilyDocs     CommandFamily = "docs"
+	FamilySlides   CommandFamily = "slides"
+	FamilyLabels   CommandFamily = "labels"
+	FamilyActivity CommandFamily = "activity"
+)
+
+// scopeRequirement is satisfied when one of its scopes is granted. The
+// first scope is the narrowest and is the one requested on consent.
+type scopeRequirement []string
+
+// familyRequirements lists the scopes each family needs; every requirement
+// must be met
+var familyRequirements = map[CommandFamily][]scopeRequirement{
+	FamilyMetadata: {{utils.ScopeMetadataReadonly, utils.ScopeReadonly, utils.ScopeFull}},
+	FamilyRead:     {{utils.ScopeReadonly, utils.ScopeFull}},
+	FamilyCreate:   {{utils.ScopeFile, utils.ScopeFull}},
+	FamilyWrite:    {{utils.ScopeFull}},
+	FamilyAdmin:    {{utils.ScopeAdminDirectoryUser}, {utils.ScopeAdminDirectoryGroup}},
+	FamilySheets:   {{utils.ScopeSheets}},
+	FamilyDocs:     {{utils.ScopeDocs}},
+	FamilySlides:   {{utils.ScopeSlides}},
+	FamilyLabels: {{utils.ScopeLabelsReadonly, utils.ScopeLabels,
+		utils.ScopeAdminLabelsReadonly, utils.ScopeAdminLabels}},
+	FamilyActivity: {{utils.ScopeActivityReadonly, utils.ScopeActivity}},
+}
+
+// commandFamilies maps command paths, without the leading "gdrv", to their
+// family. The longest matching prefix wins; commands not listed are not
+// checked up front.
+var commandFamilies = map[string]CommandFamily{
+	"auth":        FamilyNone,
+	"config":      FamilyNone,
+	"version":     FamilyNone,
+	"query":       FamilyNone,
+	"schedule":    FamilyNone,
+	"state":       FamilyNone,
+	"self-update": FamilyNone,
+	"plan":        FamilyNone,
+
+	"about":       FamilyMetadata,
+	"quota":       FamilyMetadata,
+	"activity":    FamilyActivity,
+	"admin":       FamilyAdmin,
+	"cache":       FamilyRead,
+	"changes":     FamilyRead,
+	"watch":       FamilyRead,
+	"watch list":  FamilyNone,
+	"docs":        FamilyDocs,
+	"drives":      FamilyRead,
+	"export":      FamilyRead,
+	"labels":      FamilyLabels,
+	"migrate":     FamilyWrite,
+	"sheets":      FamilySheets,
+	"slides":      FamilySlides,
+	"sync":        FamilyWrite,
+	"sync init":   FamilyRead,
+	"sync list":   FamilyNone,
+	"sync pull":   FamilyRead,
+	"sync remove": FamilyNone,
+	"sync status": FamilyRead,
+
+	"drives create":               FamilyWrite,
+	"drives create-from-template": FamilyWrite,
+	"drives delete":               FamilyWrite,
+	"drives hide":                 FamilyWrite,
+	"drives members":              FamilyWrite,
+	"drives members list":         FamilyRead,
+	"drives unhide":               FamilyWrite,
+	"drives update":               FamilyWrite,
+
+	"folders":        FamilyWrite,
+	"folders create": FamilyCreate,
+	"folders get":    FamilyRead,
+	"folders list":   FamilyRead,
+
+	"files":                   FamilyWrite,
+	"files cat":               FamilyRead,
+	"files copy":              FamilyCreate,
+	"files download":          FamilyRead,
+	"files download-query":    FamilyRead,
+	"files export-formats":    FamilyRead,
+	"files find-corrupt":      FamilyRead,
+	"files get":               FamilyRead,
+	"files list":              FamilyRead,
+	"files list-trashed":      FamilyRead,
+	"files owners-report":     FamilyRead,
+	"files properties get":    FamilyRead,
+	"files revisions":         FamilyRead,
+	"files revisions delete":  FamilyWrite,
+	"files revisions keep":    FamilyWrite,
+	"files revisions prune":   FamilyWrite,
+	"files revisions restore": FamilyWrite,
+	"files search":            FamilyRead,
+	"files shared-with-me":    FamilyRead,
+	"files shortcut create":   FamilyCreate,
+	"files upload":            FamilyCreate,
+
+	"permissions":                   FamilyWrite,
+	"permissions analyze":           FamilyRead,
+	"permissions audit":             FamilyRead,
+	"permissions check-template":    FamilyRead,
+	"permissions compare":           FamilyRead,
+	"permissions expiring":          FamilyRead,
+	"permissions explain":           FamilyRead,
+	"permissions list":              FamilyRead,
+	"permissions pending-transfers": FamilyRead,
+	"permissions policy-check":      FamilyRead,
+	"permissions remediation":       FamilyRead,
+	"permissions report":            FamilyRead,
+	"permissions search":            FamilyRead,
+	"permissions templates":         FamilyNone,
+	"permissions watch":             FamilyRead,
+}
+
+// CommandFamilies lists the families that can be named in
+// 'auth login --for'
+func CommandFamilies() []CommandFamily {
+	families := make([]CommandFamily, 0, len(familyRequirements))
+	for family := range familyRequirements {
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i] < families[j] })
+	return families
+}
+
+// ParseCommandFamily validates a family name
+func ParseCommandFamily(name string) (CommandFamily, error) {
+	family := CommandFamily(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := familyRequirements[family]; !ok {
+		names := make([]string, 0, len(familyRequirements))
+		for _, f := range CommandFamilies() {
+			names = append(names, string(f))
+		}
+		return FamilyNone, fmt.Errorf("unknown command family %q (valid: %s)", name, strings.Join(names, ", "))
+	}
+	return family, nil
+}
+
+// FamilyForCommand returns the family of a command path such as
+// "gdrv files list" or "files list"
+func FamilyForCommand(commandPath string) CommandFamily {
+	words := strings.Fields(commandPath)
+	if len(words) > 0 && words[0] == "gdrv" {
+		words = words[1:]
+	}
+	for n := len(words); n > 0; n-- {
+		if family, ok := commandFamilies[strings.Join(words[:n], " ")]; ok {
+			return family
+		}
+	}
+	return FamilyNone
+}
+
+// ScopesForFamilies returns the minimum scopes to request for families,
+// deduplicated and in a stable order
+func ScopesForFamilies(families ...CommandFamily) []string {
+	var scopes []string
+	for _, family := range families {
+		for _, req := range familyRequirements[family] {
+			scopes = append(scopes, req[0])
+		}
+	}
+	return MergeScopes(scopes)
+}
+
+// MissingFamilyScopes returns the scopes to request so that granted covers
+// family. It is empty when the family's requirements are already met.
+func MissingFamilyScopes(granted []string, family CommandFamily) []string {
+	have := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		have[s] = true
+	}
+	var missing []string
+	for _, req := range familyRequirements[family] {
+		met := false
+		for _, s := range req {
+			if have[s] {
+				met = true
+				break
+			}
+		}
+		if !met {
+			missing = append(missing, req[0])
+		}
+	}
+	return missing
+}
+
+// MergeScopes returns the union of scope lists, sorted
+func MergeScopes(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, list := range lists {
+		for _, s := range list {
+			if s != "" && !seen[s] {
+				seen[s] = true
+				merged = append(merged, s)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// MissingScopesError reports that a profile lacks the scopes for a command
+// family and how to grant them
+func MissingScopesError(profile string, family CommandFamily, missing []string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeScopeInsufficient,
+		fmt.Sprintf("Profile '%s' has not granted %s access (missing %s)", profile, family, strings.Join(missing, ", "))).
+		WithContext("family", string(family)).
+		WithContext("missingScopes", missing).
+		WithContext("suggestedAction", fmt.Sprintf("run 'gdrv auth login --add-scopes --for %s' to extend consent", family)).
+		Build())
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"golang.org/x/oauth2"
+)
+
+func TestFamilyForCommand(t *testing.T) {
+	tests := []struct {
+		path string
+		want CommandFamily
+	}{
+		{"gdrv files list", FamilyRead},
+		{"gdrv files download", FamilyRead},
+		{"gdrv files upload", FamilyCreate},
+		{"gdrv files delete", FamilyWrite},
+		{"gdrv files properties get", FamilyRead},
+		{"gdrv files properties set", FamilyWrite},
+		{"gdrv files revisions diff", FamilyRead},
+		{"gdrv files revisions prune", FamilyWrite},
+		{"gdrv files revisions restore", FamilyWrite},
+		{"gdrv folders create", FamilyCreate},
+		{"gdrv permissions audit public", FamilyRead},
+		{"gdrv permissions bulk share", FamilyWrite},
+		{"gdrv permissions policy-check", FamilyRead},
+		{"gdrv permissions expiring", FamilyRead},
+		{"gdrv files find-corrupt", FamilyRead},
+		{"gdrv admin users list", FamilyAdmin},
+		{"gdrv drives export-acls", FamilyRead},
+		{"gdrv drives create-from-template", FamilyWrite},
+		{"gdrv sync list", FamilyNone},
+		{"gdrv sync push", FamilyWrite},
+		{"gdrv auth login", FamilyNone},
+		{"permissions explain", FamilyRead},
+		{"gdrv", FamilyNone},
+		{"gdrv unknown", FamilyNone},
+	}
+	for _, tt := range tests {
+		if got := FamilyForCommand(tt.path); got != tt.want {
+			t.Errorf("FamilyForCommand(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMissingFamilyScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		family  CommandFamily
+		want    []string
+	}{
+		{"readonly covers read", []string{utils.ScopeReadonly}, FamilyRead, nil},
+		{"full covers read", []string{utils.ScopeFull}, FamilyRead, nil},
+		{"file does not cover read", []string{utils.ScopeFile}, FamilyRead, []string{utils.ScopeReadonly}},
+		{"readonly does not cover write", []string{utils.ScopeReadonly}, FamilyWrite, []string{utils.ScopeFull}},
+		{"file covers create", utils.ScopesWorkspaceBasic, FamilyCreate, nil},
+		{"admin needs users and groups", []string{utils.ScopeAdminDirectoryUser}, FamilyAdmin, []string{utils.ScopeAdminDirectoryGroup}},
+		{"no family", nil, FamilyNone, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MissingFamilyScopes(tt.granted, tt.family)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingFamilyScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopesForFamilies(t *testing.T) {
+	got := ScopesForFamilies(FamilyRead, FamilyCreate, FamilyRead)
+	want := []string{utils.ScopeFile, utils.ScopeReadonly}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScopesForFamilies() = %v, want %v", got, want)
+	}
+	for _, family := range CommandFamilies() {
+		if missing := MissingFamilyScopes(ScopesForFamilies(family), family); len(missing) > 0 {
+			t.Errorf("scopes requested for %s do not satisfy it: missing %v", family, missing)
+		}
+	}
+}
+
+func TestParseCommandFamily(t *testing.T) {
+	if family, err := ParseCommandFamily(" Read "); err != nil || family != FamilyRead {
+		t.Errorf("ParseCommandFamily(Read) = %q, %v", family, err)
+	}
+	if _, err := ParseCommandFamily("everything"); err == nil {
+		t.Error("expected an error for an unknown family")
+	}
+}
+
+func TestMissingScopesError(t *testing.T) {
+	err := MissingScopesError("work", FamilyWrite, []string{utils.ScopeFull})
+	appErr, ok := err.(*utils.AppError)
+	if !ok {
+		t.Fatalf("expected *utils.AppError, got %T", err)
+	}
+	if appErr.CLIError.Code != utils.ErrCodeScopeInsufficient {
+		t.Errorf("code = %s", appErr.CLIError.Code)
+	}
+	if appErr.CLIError.Context["suggestedAction"] != "run 'gdrv auth login --add-scopes --for write' to extend consent" {
+		t.Errorf("suggestedAction = %v", appErr.CLIError.Context["suggestedAction"])
+	}
+}
+
+func TestIncludeGrantedScopes(t *testing.T) {
+	config := &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.google.com/o/oauth2/auth"}}
+	flow, err := NewOAuthFlow(config, nil, "http://127.0.0.1:1/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _ := url.Parse(flow.GetAuthURL())
+	if parsed.Query().Has("include_granted_scopes") {
+		t.Error("include_granted_scopes set without incremental consent")
+	}
+	flow.includeGranted = true
+	parsed, _ = url.Parse(flow.GetAuthURL())
+	if got := parsed.Query().Get("include_granted_scopes"); got != "true" {
+		t.Errorf("include_granted_scopes = %q, want true", got)
+	}
+}
+
+func TestGrantedScopes(t *testing.T) {
+	requested := []string{utils.ScopeReadonly}
+	token := (&oauth2.Token{}).WithExtra(map[string]interface{}{
+		"scope": utils.ScopeFile + " " + utils.ScopeReadonly,
+	})
+	if got := grantedScopes(token, requested); !reflect.DeepEqual(got, []string{utils.ScopeFile, utils.ScopeReadonly}) {
+		t.Errorf("grantedScopes() = %v", got)
+	}
+	if got := grantedScopes(&oauth2.Token{}, requested); !reflect.DeepEqual(got, requested) {
+		t.Errorf("grantedScopes() without scope = %v", got)
+	}
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Credential source schemes. A source names where service account key
+// material is fetched from at runtime instead of a key file on disk.
+const (
+	SourceSchemeVault         = "vault://"
+	SourceSchemeSecretManager = "gcpsm://"
+	SourceSchemeFile          = "file://"
+)
+
+// defaultSecretTTL is how long fetched secrets are reused when the source
+// does not give a lease duration
+const defaultSecretTTL = 5 * time.Minute
+
+const scopeCloudPlatform = "https://www.googleapis.com/auth/cloud-platform"
+
+var (
+	secretHTTPClient      = http.DefaultClient
+	secretManagerEndpoint = "https://secretmanager.googleapis.com/v1/"
+	gcpTokenSource        = func(ctx context.Context) (oauth2.TokenSource, error) {
+		return google.DefaultTokenSource(ctx, scopeCloudPlatform)
+	}
+
+	secretCacheMu sync.Mutex
+	secretCache   = map[string]cachedSecret{}
+)
+
+type cachedSecret struct {
+	data    []byte
+	expires time.Time
+}
+
+// IsCredentialSource reports whether value names a runtime credential
+// source rather than a plain file path
+func IsCredentialSource(value string) bool {
+	return strings.HasPrefix(value, SourceSchemeVault) ||
+		strings.HasPrefix(value, SourceSchemeSecretManager) ||
+		strings.HasPrefix(value, SourceSchemeFile)
+}
+
+// FetchSecret returns the key material named by source. Supported sources:
+//
+//	vault://<api-path>[#field]   HashiCorp Vault (KV v1 or v2); uses VAULT_ADDR,
+//	                             VAULT_TOKEN or ~/.vault-token, VAULT_NAMESPACE
+//	gcpsm://projects/<p>/secrets/<s>[/versions/<v>]
+//	                             GCP Secret Manager, via application default credentials
+//	file://<path>                a local file
+//
+// Results are cached in memory for the secret's lease duration (Vault) or
+// five minutes, and are never written to disk.
+func FetchSecret(ctx context.Context, source string) ([]byte, error) {
+	secretCacheMu.Lock()
+	cached, ok := secretCache[source]
+	secretCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.data, nil
+	}
+
+	var (
+		data []byte
+		ttl  = defaultSecretTTL
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(source, SourceSchemeVault):
+		data, ttl, err = fetchVaultSecret(ctx, strings.TrimPrefix(source, SourceSchemeVault))
+	case strings.HasPrefix(source, SourceSchemeSecretManager):
+		data, err = fetchSecretManagerSecret(ctx, strings.TrimPrefix(source, SourceSchemeSecretManager))
+	case strings.HasPrefix(source, SourceSchemeFile):
+		data, err = os.ReadFile(strings.TrimPrefix(source, SourceSchemeFile))
+	default:
+		return nil, fmt.Errorf("unsupported credentials source %q (expected vault://, gcpsm:// or file://)", source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch credentials from %s: %w", source, err)
+	}
+
+	secretCacheMu.Lock()
+	secretCache[source] = cachedSecret{data: data, expires: time.Now().Add(ttl)}
+	secretCacheMu.Unlock()
+	return data, nil
+}
+
+// ClearSecretCache drops cached secrets so the next fetch goes to the source
+func ClearSecretCache() {
+	secretCacheMu.Lock()
+	secretCache = map[string]cachedSecret{}
+	secretCacheMu.Unlock()
+}
+
+func fetchVaultSecret(ctx context.Context, ref string) ([]byte, time.Duration, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, 0, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	var resp struct {
+		LeaseDuration int                    `json:"lease_duration"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := doSecretRequest(req, &resp); err != nil {
+		return nil, 0, err
+	}
+
+	// KV v2 nests the secret under data.data
+	values := resp.Data
+	if inner, ok := values["data"].(map[string]interface{}); ok {
+		if _, hasMeta := values["metadata"]; hasMeta {
+			values = inner
+		}
+	}
+
+	if field == "" {
+		if len(values) != 1 {
+			return nil, 0, fmt.Errorf("secret has %d fields; name one with #field", len(values))
+		}
+		for k := range values {
+			field = k
+		}
+	}
+	value, ok := values[field]
+	if !ok {
+		return nil, 0, fmt.Errorf("secret has no field %q", field)
+	}
+
+	ttl := defaultSecretTTL
+	if resp.LeaseDuration > 0 {
+		ttl = time.Duration(resp.LeaseDuration) * time.Second
+	}
+	// The key JSON may be stored as a string or as a nested object
+	if s, ok := value.(string); ok {
+		return []byte(s), ttl, nil
+	}
+	data, err := json.Marshal(value)
+	return data, ttl, err
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	return "", fmt.Errorf("no Vault token: set VAULT_TOKEN or log in with the vault CLI")
+}
+
+func fetchSecretManagerSecret(ctx context.Context, name string) ([]byte, error) {
+	name = strings.Trim(name, "/")
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return nil, fmt.Errorf("expected projects/<project>/secrets/<secret>[/versions/<version>]")
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	ts, err := gcpTokenSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no application default credentials: %w", err)
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretManagerEndpoint+name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(req)
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doSecretRequest(req, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Payload.Data)
+}
+
+func doSecretRequest(req *http.Request, out interface{}) error {
+	resp, err := secretHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestFetchSecret_VaultKV2(t *testing.T) {
+	ClearSecretCache()
+	defer ClearSecretCache()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "s.test" || r.URL.Path != "/v1/secret/data/gdrv" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"key":{"type":"service_account","client_email":"sa@p.iam"},"note":"x"},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "s.test")
+
+	data, err := FetchSecret(context.Background(), "vault://secret/data/gdrv#key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"client_email":"sa@p.iam","type":"service_account"}` {
+		t.Errorf("data = %s", data)
+	}
+
+	// Cached for the default TTL
+	if _, err := FetchSecret(context.Background(), "vault://secret/data/gdrv#key"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1 (cached)", requests)
+	}
+
+	if _, err := FetchSecret(context.Background(), "vault://secret/data/gdrv"); err == nil {
+		t.Error("expected an error when a multi-field secret has no #field")
+	}
+	if _, err := FetchSecret(context.Background(), "vault://secret/data/gdrv#missing"); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+}
+
+func TestFetchSecret_SecretManager(t *testing.T) {
+	ClearSecretCache()
+	defer ClearSecretCache()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer adc-token" ||
+			r.URL.Path != "/v1/projects/p/secrets/sa-key/versions/latest:access" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		payload := base64.StdEncoding.EncodeToString([]byte(`{"type":"service_account"}`))
+		_, _ = w.Write([]byte(`{"payload":{"data":"` + payload + `"}}`))
+	}))
+	defer server.Close()
+
+	origEndpoint, origTS := secretManagerEndpoint, gcpTokenSource
+	defer func() { secretManagerEndpoint, gcpTokenSource = origEndpoint, origTS }()
+	secretManagerEndpoint = server.URL + "/v1/"
+	gcpTokenSource = func(context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "adc-token"}), nil
+	}
+
+	data, err := FetchSecret(context.Background(), "gcpsm://projects/p/secrets/sa-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"service_account"}` {
+		t.Errorf("data = %s", data)
+	}
+
+	if _, err := FetchSecret(context.Background(), "gcpsm://sa-key"); err == nil {
+		t.Error("expected an error for a malformed secret name")
+	}
+}
+
+func TestFetchSecret_FileAndUnsupported(t *testing.T) {
+	ClearSecretCache()
+	defer ClearSecretCache()
+
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := FetchSecret(context.Background(), "file://"+path); err != nil || string(data) != "{}" {
+		t.Errorf("FetchSecret(file) = %q, %v", data, err)
+	}
+	if _, err := FetchSecret(context.Background(), "s3://bucket/key"); err == nil {
+		t.Error("expected an error for an unsupported scheme")
+	}
+	if IsCredentialSource("/tmp/key.json") || !IsCredentialSource("vault://x") {
+		t.Error("IsCredentialSource mismatch")
+	}
+}
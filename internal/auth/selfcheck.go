@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/errors"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"golang.org/x/oauth2"
+)
+
+// Self-check step statuses
+const (
+	CheckOK      = "ok"
+	CheckWarning = "warning"
+	CheckFailed  = "failed"
+)
+
+// SelfCheckStep is one check made by SelfCheck
+type SelfCheckStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SelfCheck proves a profile can keep working through a long operation.
+// It renews the token even if the current one is still valid, which fails
+// for a revoked refresh token or an unreachable key source, and checks that
+// the scopes the new token carries still cover family, which fails when
+// consent was withdrawn after login. The renewed credentials are stored and
+// returned with the steps taken; the error is the first failure.
+func (m *Manager) SelfCheck(ctx context.Context, profile string, family CommandFamily) (*types.Credentials, []SelfCheckStep, error) {
+	creds, err := m.LoadCredentials(profile)
+	if err != nil {
+		return nil, []SelfCheckStep{{Name: "credentials", Status: CheckFailed, Detail: "no stored credentials"}},
+			utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+				fmt.Sprintf("No credentials found for profile '%s'", profile)).
+				WithContext("suggestedAction", "run 'gdrv auth login' first").
+				Build())
+	}
+	m.useIssuingClient(profile, creds)
+
+	unlock, err := m.lockProfile(ctx, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	if current, err := m.LoadCredentials(profile); err == nil {
+		creds = current
+	}
+
+	renewed, step, err := m.renewNow(ctx, creds)
+	steps := []SelfCheckStep{step}
+	if err != nil {
+		return nil, steps, err
+	}
+	if renewed != creds {
+		if err := m.SaveCredentials(profile, renewed); err != nil {
+			return nil, steps, fmt.Errorf("failed to save renewed credentials: %w", err)
+		}
+	}
+
+	if family != FamilyNone {
+		if missing := MissingFamilyScopes(renewed.Scopes, family); len(missing) > 0 {
+			steps = append(steps, SelfCheckStep{Name: "scopes", Status: CheckFailed,
+				Detail: fmt.Sprintf("%s access needs %s", family, strings.Join(missing, ", "))})
+			return nil, steps, MissingScopesError(profile, family, missing)
+		}
+		steps = append(steps, SelfCheckStep{Name: "scopes", Status: CheckOK,
+			Detail: fmt.Sprintf("granted scopes cover %s access", family)})
+	}
+	return renewed, steps, nil
+}
+
+// renewNow fetches a new token for creds regardless of its expiry. An OAuth
+// token's scopes are taken from the token response, so a narrowed grant is
+// recorded. creds itself is returned, with a warning, for a service account
+// token that cannot be renewed but has not expired yet.
+func (m *Manager) renewNow(ctx context.Context, creds *types.Credentials) (*types.Credentials, SelfCheckStep, error) {
+	step := SelfCheckStep{Name: "refresh"}
+	fail := func(err error) (*types.Credentials, SelfCheckStep, error) {
+		step.Status = CheckFailed
+		if appErr, ok := err.(*utils.AppError); ok {
+			step.Detail = appErr.CLIError.Message
+		} else {
+			step.Detail = err.Error()
+		}
+		return nil, step, err
+	}
+
+	if creds.Type == types.AuthTypeServiceAccount || creds.Type == types.AuthTypeImpersonated {
+		if creds.CredentialsSource == "" {
+			if time.Now().After(creds.ExpiryDate) {
+				return fail(utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthExpired,
+					"Service account token expired").
+					WithContext("suggestedAction", "run 'gdrv auth service-account' to re-authenticate").
+					Build()))
+			}
+			step.Status = CheckWarning
+			step.Detail = fmt.Sprintf("token expires at %s and cannot be renewed because the key was not kept; use 'gdrv auth service-account --credentials-source' for long operations",
+				creds.ExpiryDate.Format(time.RFC3339))
+			return creds, step, nil
+		}
+		renewed, err := m.LoadServiceAccountFromSource(ctx, creds.CredentialsSource, creds.Scopes, creds.ImpersonatedUser)
+		if err != nil {
+			return fail(utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthExpired,
+				fmt.Sprintf("Service account token renewal failed: %v", err)).
+				WithContext("credentialsSource", creds.CredentialsSource).
+				WithContext("suggestedAction", "check that the key source is reachable and the key is still enabled").
+				Build()))
+		}
+		step.Status = CheckOK
+		step.Detail = fmt.Sprintf("key fetched from %s, token valid until %s", creds.CredentialsSource, renewed.ExpiryDate.Format(time.RFC3339))
+		return renewed, step, nil
+	}
+
+	if m.oauthConfig == nil {
+		return fail(utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthClientMissing,
+			"None of the configured OAuth clients issued this profile's token, so it cannot be renewed").
+			WithContext("suggestedAction", "configure the client used at login, or run 'gdrv auth login' again").
+			Build()))
+	}
+	if creds.RefreshToken == "" {
+		return fail(utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthExpired,
+			"The profile has no refresh token, so its token cannot be renewed").
+			WithContext("suggestedAction", "run 'gdrv auth login' to re-authenticate").
+			Build()))
+	}
+
+	// Without an access token the token source has to ask Google
+	token := &oauth2.Token{RefreshToken: creds.RefreshToken}
+	newToken, err := m.oauthConfig.TokenSource(api.TransportContext(ctx), token).Token()
+	if err != nil {
+		return fail(errors.ClassifyAuthRefreshError(err))
+	}
+	scopes := creds.Scopes
+	if granted, _ := newToken.Extra("scope").(string); granted != "" {
+		scopes = strings.Fields(granted)
+	}
+	renewed := &types.Credentials{
+		AccessToken:  newToken.AccessToken,
+		RefreshToken: newToken.RefreshToken,
+		ExpiryDate:   newToken.Expiry,
+		Scopes:       scopes,
+		Type:         types.AuthTypeOAuth,
+		ClientID:     m.clientIDForStorage(creds),
+	}
+	step.Status = CheckOK
+	step.Detail = fmt.Sprintf("token renewed, valid until %s", renewed.ExpiryDate.Format(time.RFC3339))
+	return renewed, step, nil
+}
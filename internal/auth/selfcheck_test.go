@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestSelfCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		family    CommandFamily
+		wantCode  string
+		wantSteps []string
+	}{
+		{name: "renewed", status: 200, family: FamilyWrite,
+			body:      `{"access_token":"new","expires_in":3600,"scope":"https://www.googleapis.com/auth/drive"}`,
+			wantSteps: []string{"refresh:ok", "scopes:ok"}},
+		{name: "revoked", status: 400, family: FamilyWrite,
+			body:     `{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`,
+			wantCode: utils.ErrCodeAuthExpired, wantSteps: []string{"refresh:failed"}},
+		{name: "consent narrowed", status: 200, family: FamilyWrite,
+			body:     `{"access_token":"new","expires_in":3600,"scope":"https://www.googleapis.com/auth/drive.readonly"}`,
+			wantCode: utils.ErrCodeScopeInsufficient, wantSteps: []string{"refresh:ok", "scopes:failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if err := r.ParseForm(); err != nil || r.Form.Get("refresh_token") != "refresh" {
+					t.Errorf("refresh_token = %q", r.Form.Get("refresh_token"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			mgr := NewManagerWithOptions(t.TempDir(), ManagerOptions{ForcePlainFile: true})
+			mgr.SetOAuthConfig("client", "secret", nil)
+			mgr.oauthConfig.Endpoint.TokenURL = server.URL
+			// Still valid for an hour, so only a forced renewal reaches the server
+			creds := &types.Credentials{
+				AccessToken:  "old",
+				RefreshToken: "refresh",
+				ExpiryDate:   time.Now().Add(time.Hour),
+				Scopes:       []string{"https://www.googleapis.com/auth/drive"},
+				Type:         types.AuthTypeOAuth,
+				ClientID:     "client",
+			}
+			if err := mgr.SaveCredentials("work", creds); err != nil {
+				t.Fatal(err)
+			}
+
+			renewed, steps, err := mgr.SelfCheck(context.Background(), "work", tt.family)
+			if calls != 1 {
+				t.Errorf("token requests = %d, want 1", calls)
+			}
+			var got []string
+			for _, s := range steps {
+				got = append(got, s.Name+":"+s.Status)
+			}
+			if len(got) != len(tt.wantSteps) {
+				t.Fatalf("steps = %v, want %v", got, tt.wantSteps)
+			}
+			for i := range got {
+				if got[i] != tt.wantSteps[i] {
+					t.Errorf("steps = %v, want %v", got, tt.wantSteps)
+				}
+			}
+			if tt.wantCode != "" {
+				if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != tt.wantCode {
+					t.Fatalf("err = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, _ := mgr.LoadCredentials("work")
+			if renewed.AccessToken != "new" || stored.AccessToken != "new" || stored.RefreshToken != "refresh" {
+				t.Errorf("renewed = %+v, stored = %+v", renewed, stored)
+			}
+		})
+	}
+}
+
+func TestSelfCheck_ServiceAccountWithoutSource(t *testing.T) {
+	mgr := NewManagerWithOptions(t.TempDir(), ManagerOptions{ForcePlainFile: true})
+	creds := &types.Credentials{
+		AccessToken: "sa",
+		ExpiryDate:  time.Now().Add(30 * time.Minute),
+		Scopes:      []string{"https://www.googleapis.com/auth/drive.readonly"},
+		Type:        types.AuthTypeServiceAccount,
+	}
+	if err := mgr.SaveCredentials("robot", creds); err != nil {
+		t.Fatal(err)
+	}
+	_, steps, err := mgr.SelfCheck(context.Background(), "robot", FamilyRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].Status != CheckWarning {
+		t.Errorf("refresh step = %+v, want a warning", steps[0])
+	}
+
+	creds.ExpiryDate = time.Now().Add(-time.Minute)
+	if err := mgr.SaveCredentials("robot", creds); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := mgr.SelfCheck(context.Background(), "robot", FamilyRead); err == nil {
+		t.Error("expired service account token passed the self-check")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
+	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/types"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
@@ -45,7 +48,32 @@ func (m *Manager) LoadServiceAccount(ctx context.Context, keyFilePath string, sc
 	if err != nil {
 		return nil, fmt.Errorf("failed to read service account key: %w", err)
 	}
+	return serviceAccountFromJSON(ctx, keyData, scopes, impersonateUser)
+}
+
+// LoadServiceAccountFromSource loads credentials from key material fetched
+// at runtime (see FetchSecret). The source is kept with the credentials so
+// expired tokens can be renewed without a key file on disk.
+func (m *Manager) LoadServiceAccountFromSource(ctx context.Context, source string, scopes []string, impersonateUser string) (*types.Credentials, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope required")
+	}
+	if impersonateUser != "" && !strings.Contains(impersonateUser, "@") {
+		return nil, fmt.Errorf("impersonate user must be an email address")
+	}
+	keyData, err := FetchSecret(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := serviceAccountFromJSON(ctx, keyData, scopes, impersonateUser)
+	if err != nil {
+		return nil, err
+	}
+	creds.CredentialsSource = source
+	return creds, nil
+}
 
+func serviceAccountFromJSON(ctx context.Context, keyData []byte, scopes []string, impersonateUser string) (*types.Credentials, error) {
 	// Parse service account key to extract email
 	var saKey ServiceAccountKey
 	if err := json.Unmarshal(keyData, &saKey); err != nil {
@@ -61,7 +89,10 @@ func (m *Manager) LoadServiceAccount(ctx context.Context, keyFilePath string, sc
 		return nil, fmt.Errorf("missing private_key in service account key")
 	}
 
-	var config *google.Credentials
+	var (
+		config *google.Credentials
+		err    error
+	)
 	if impersonateUser != "" {
 		config, err = google.CredentialsFromJSONWithParams(ctx, keyData, google.CredentialsParams{
 			Scopes:  scopes,
@@ -100,3 +131,34 @@ func (m *Manager) GetDriveService(ctx context.Context, creds *types.Credentials)
 	client := m.GetHTTPClient(ctx, creds)
 	return drive.NewService(ctx, option.WithHTTPClient(client))
 }
+
+// ServiceAccountKeyData returns the key behind service account credentials:
+// keyFile when given, otherwise the secret source the credentials were
+// loaded from
+func ServiceAccountKeyData(ctx context.Context, creds *types.Credentials, keyFile string) ([]byte, error) {
+	if keyFile != "" {
+		keyData, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account key: %w", err)
+		}
+		return keyData, nil
+	}
+	if creds.CredentialsSource == "" {
+		return nil, fmt.Errorf("the profile's service account key was not kept; pass --key-file")
+	}
+	return FetchSecret(ctx, creds.CredentialsSource)
+}
+
+// ImpersonatedHTTPClient returns a client acting as user through domain-wide
+// delegation. Tokens are renewed as needed, so it suits long sweeps.
+func ImpersonatedHTTPClient(ctx context.Context, keyData []byte, user string, scopes []string) (*http.Client, error) {
+	ctx = api.TransportContext(ctx)
+	config, err := google.CredentialsFromJSONWithParams(ctx, keyData, google.CredentialsParams{
+		Scopes:  scopes,
+		Subject: user,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	return api.WrapReadOnly(oauth2.NewClient(ctx, config.TokenSource)), nil
+}
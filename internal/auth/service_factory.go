@@ -8,6 +8,7 @@ import (
 	"google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 	"google.golang.org/api/slides/v1"
@@ -21,6 +22,7 @@ const (
 	ServiceDocs     ServiceType = "docs"
 	ServiceSlides   ServiceType = "slides"
 	ServiceAdminDir ServiceType = "admin_directory"
+	ServiceActivity ServiceType = "drive_activity"
 )
 
 type ServiceFactory struct {
@@ -43,6 +45,8 @@ func (f *ServiceFactory) CreateService(ctx context.Context, creds *types.Credent
 		return f.CreateSlidesService(ctx, creds)
 	case ServiceAdminDir:
 		return f.CreateAdminService(ctx, creds)
+	case ServiceActivity:
+		return f.CreateActivityService(ctx, creds)
 	default:
 		return nil, fmt.Errorf("unknown service type: %s", svcType)
 	}
@@ -72,3 +76,8 @@ func (f *ServiceFactory) CreateAdminService(ctx context.Context, creds *types.Cr
 	client := f.manager.GetHTTPClient(ctx, creds)
 	return admin.NewService(ctx, option.WithHTTPClient(client))
 }
+
+func (f *ServiceFactory) CreateActivityService(ctx context.Context, creds *types.Credentials) (*driveactivity.Service, error) {
+	client := f.manager.GetHTTPClient(ctx, creds)
+	return driveactivity.NewService(ctx, option.WithHTTPClient(client))
+}
@@ -11,8 +11,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
+// profilesFileName tracks the profiles stored in the system keyring
+const profilesFileName = "profiles.json"
+
 // StorageBackend defines the interface for credential storage
 type StorageBackend interface {
 	Save(profile string, data []byte) error
@@ -86,21 +91,34 @@ func (s *EncryptedFileStorage) Save(profile string, data []byte) error {
 		return err
 	}
 
-	return os.WriteFile(credFile, encrypted, 0600)
+	return utils.ReplaceFile(credFile, encrypted, 0600, s.validEncrypted)
 }
 
 func (s *EncryptedFileStorage) Load(profile string) ([]byte, error) {
 	credFile := s.getCredentialFilePath(profile)
-	encrypted, err := os.ReadFile(credFile)
+	encrypted, err := utils.ReadFileWithBackup(credFile, s.validEncrypted)
 	if err != nil {
-		return nil, fmt.Errorf("credentials not found for profile '%s'", profile)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("credentials not found for profile '%s'", profile)
+		}
+		return nil, err
 	}
 
 	return s.decrypt(encrypted)
 }
 
+// validEncrypted checks that an encrypted credential file decrypts to JSON
+func (s *EncryptedFileStorage) validEncrypted(data []byte) error {
+	plaintext, err := s.decrypt(data)
+	if err != nil {
+		return err
+	}
+	return utils.ValidJSON(plaintext)
+}
+
 func (s *EncryptedFileStorage) Delete(profile string) error {
 	credFile := s.getCredentialFilePath(profile)
+	_ = os.Remove(credFile + utils.BackupSuffix)
 	return os.Remove(credFile)
 }
 
@@ -177,20 +195,24 @@ func (s *PlainFileStorage) Save(profile string, data []byte) error {
 	if err := os.MkdirAll(filepath.Dir(credFile), 0700); err != nil {
 		return err
 	}
-	return os.WriteFile(credFile, data, 0600)
+	return utils.ReplaceFile(credFile, data, 0600, utils.ValidJSON)
 }
 
 func (s *PlainFileStorage) Load(profile string) ([]byte, error) {
 	credFile := s.getCredentialFilePath(profile)
-	data, err := os.ReadFile(credFile)
+	data, err := utils.ReadFileWithBackup(credFile, utils.ValidJSON)
 	if err != nil {
-		return nil, fmt.Errorf("credentials not found for profile '%s'", profile)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("credentials not found for profile '%s'", profile)
+		}
+		return nil, err
 	}
 	return data, nil
 }
 
 func (s *PlainFileStorage) Delete(profile string) error {
 	credFile := s.getCredentialFilePath(profile)
+	_ = os.Remove(credFile + utils.BackupSuffix)
 	return os.Remove(credFile)
 }
 
@@ -226,7 +248,7 @@ func getOrCreateEncryptionKey(baseDir string) ([]byte, error) {
 	}
 
 	encoded := base64.StdEncoding.EncodeToString(key)
-	if err := os.WriteFile(keyFile, []byte(encoded), 0600); err != nil {
+	if err := utils.WriteFileAtomic(keyFile, []byte(encoded), 0600); err != nil {
 		return nil, err
 	}
 
@@ -241,8 +263,8 @@ func (m *Manager) ListProfiles() ([]string, error) {
 	if m.useKeyring {
 		// For keyring storage, we need to track profiles separately
 		// Read from a profiles list file
-		profilesFile := filepath.Join(m.configDir, "profiles.json")
-		data, err := os.ReadFile(profilesFile)
+		profilesFile := filepath.Join(m.configDir, profilesFileName)
+		data, err := utils.ReadFileWithBackup(profilesFile, utils.ValidJSON)
 		if err != nil {
 			if os.IsNotExist(err) {
 				return []string{}, nil
@@ -313,12 +335,12 @@ func (m *Manager) addProfileToList(profile string) error {
 		return err
 	}
 
-	profilesFile := filepath.Join(m.configDir, "profiles.json")
+	profilesFile := filepath.Join(m.configDir, profilesFileName)
 	if err := os.MkdirAll(m.configDir, 0700); err != nil {
 		return err
 	}
 
-	return os.WriteFile(profilesFile, data, 0600)
+	return utils.ReplaceFile(profilesFile, data, 0600, utils.ValidJSON)
 }
 
 // removeProfileFromList removes a profile from the tracked list
@@ -344,6 +366,6 @@ func (m *Manager) removeProfileFromList(profile string) error {
 		return err
 	}
 
-	profilesFile := filepath.Join(m.configDir, "profiles.json")
-	return os.WriteFile(profilesFile, data, 0600)
+	profilesFile := filepath.Join(m.configDir, profilesFileName)
+	return utils.ReplaceFile(profilesFile, data, 0600, utils.ValidJSON)
 }
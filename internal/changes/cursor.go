@@ -0,0 +1,119 @@
+package changes
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+)
+
+// CursorFileName is the file in the config directory holding the saved
+// change feed positions
+const CursorFileName = "changes-cursors.json"
+
+// CursorStateKind versions the saved change feed positions
+var CursorStateKind = state.Register(&state.Kind{
+	Name:        "changes-cursor",
+	Description: "Change feed page tokens saved per profile",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultCursorStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: CursorFileName,
+})
+
+// Cursor is the position of a change feed: the page token to read from
+// next, and when it was saved
+type Cursor struct {
+	PageToken string    `json:"pageToken"`
+	SavedAt   time.Time `json:"savedAt"`
+}
+
+// CursorKey identifies the feed of a profile, or of one of its Shared
+// Drives
+func CursorKey(profile, driveID string) string {
+	if profile == "" {
+		profile = "default"
+	}
+	if driveID == "" {
+		return profile
+	}
+	return profile + "/" + driveID
+}
+
+// CursorStore saves change feed positions in a local file
+type CursorStore struct {
+	path string
+}
+
+type cursorFile struct {
+	state.Versioned
+	Cursors map[string]*Cursor `json:"cursors"`
+}
+
+// NewCursorStore returns a store backed by the given file
+func NewCursorStore(path string) *CursorStore {
+	return &CursorStore{path: path}
+}
+
+// DefaultCursorStore returns the store in the gdrv config directory
+func DefaultCursorStore() (*CursorStore, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewCursorStore(filepath.Join(dir, CursorFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *CursorStore) Path() string {
+	return s.path
+}
+
+func (s *CursorStore) load() (*cursorFile, error) {
+	file := &cursorFile{}
+	if _, err := state.Load(CursorStateKind, s.path, file); err != nil {
+		return nil, err
+	}
+	if file.Cursors == nil {
+		file.Cursors = map[string]*Cursor{}
+	}
+	return file, nil
+}
+
+// Get returns the saved cursor for key, or nil if there is none
+func (s *CursorStore) Get(key string) (*Cursor, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return file.Cursors[key], nil
+}
+
+// Put saves the cursor for key
+func (s *CursorStore) Put(key string, cursor *Cursor) error {
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	file.Cursors[key] = cursor
+	return state.Save(CursorStateKind, s.path, file)
+}
+
+// Delete forgets the cursor for key
+func (s *CursorStore) Delete(key string) error {
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Cursors[key]; !ok {
+		return nil
+	}
+	delete(file.Cursors, key)
+	return state.Save(CursorStateKind, s.path, file)
+}
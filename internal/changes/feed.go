@@ -0,0 +1,150 @@
+package changes
+
+import (
+	"context"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// Change feed event types
+const (
+	EventAdded    = "added"
+	EventModified = "modified"
+	EventRemoved  = "removed" // Deleted, trashed or no longer accessible
+)
+
+// feedFields requests what Feed needs to classify each change
+const feedFields = "nextPageToken,newStartPageToken," +
+	"changes(changeType,fileId,removed,time,driveId," +
+	"file(id,name,mimeType,trashed,parents,size,md5Checksum,createdTime,modifiedTime,webViewLink))"
+
+// Event is one file change in a feed
+type Event struct {
+	Type     string           `json:"type"`
+	FileID   string           `json:"fileId"`
+	Name     string           `json:"name,omitempty"`
+	MimeType string           `json:"mimeType,omitempty"`
+	DriveID  string           `json:"driveId,omitempty"`
+	Time     time.Time        `json:"time"`
+	Trashed  bool             `json:"trashed,omitempty"`
+	File     *types.DriveFile `json:"file,omitempty"`
+}
+
+// FeedOptions configures Feed
+type FeedOptions struct {
+	DriveID                   string // Read the feed of this Shared Drive
+	IncludeItemsFromAllDrives bool
+	RestrictToMyDrive         bool
+	Spaces                    string
+	PageSize                  int
+	// Since is when the page token was saved. Files created after it are
+	// reported as added; without it, files never modified since they were
+	// created are.
+	Since time.Time
+}
+
+// FeedResult is one read of a change feed
+type FeedResult struct {
+	Profile           string   `json:"profile,omitempty"`
+	DriveID           string   `json:"driveId,omitempty"`
+	PageToken         string   `json:"pageToken"`
+	NewStartPageToken string   `json:"newStartPageToken"`
+	Initialized       bool     `json:"initialized,omitempty"` // No cursor was saved; the feed starts now
+	Events            []*Event `json:"events"`
+}
+
+func (r *FeedResult) Headers() []string {
+	return []string{"Type", "Name", "File ID", "Time"}
+}
+
+func (r *FeedResult) Rows() [][]string {
+	rows := make([][]string, len(r.Events))
+	for i, e := range r.Events {
+		rows[i] = []string{e.Type, e.Name, e.FileID, types.DisplayTime(e.Time.Format(time.RFC3339))}
+	}
+	return rows
+}
+
+func (r *FeedResult) EmptyMessage() string {
+	if r.Initialized {
+		return "Change feed started; run again to see changes from now on"
+	}
+	return "No changes"
+}
+
+// Feed reads every change since pageToken, calling emit with each file
+// change in order, and returns the page token to read from next. Changes
+// to Shared Drives themselves are not reported. If emit fails, Feed stops
+// and returns its error with no new token, so the changes are read again
+// from pageToken.
+func (m *Manager) Feed(ctx context.Context, reqCtx *types.RequestContext, pageToken string, opts FeedOptions, emit func(*Event) error) (string, error) {
+	listOpts := types.ListOptions{
+		DriveID:                   opts.DriveID,
+		IncludeItemsFromAllDrives: opts.IncludeItemsFromAllDrives || opts.DriveID != "",
+		IncludeRemoved:            true,
+		RestrictToMyDrive:         opts.RestrictToMyDrive,
+		SupportsAllDrives:         true,
+		Spaces:                    opts.Spaces,
+		Limit:                     opts.PageSize,
+		Fields:                    feedFields,
+	}
+	if listOpts.Limit <= 0 {
+		listOpts.Limit = 1000
+	}
+
+	for pageToken != "" {
+		listOpts.PageToken = pageToken
+		page, err := m.List(ctx, reqCtx, listOpts)
+		if err != nil {
+			return "", err
+		}
+		for i := range page.Changes {
+			if event := classifyChange(&page.Changes[i], opts.Since); event != nil {
+				if err := emit(event); err != nil {
+					return "", err
+				}
+			}
+		}
+		if page.NewStartPageToken != "" {
+			return page.NewStartPageToken, nil
+		}
+		pageToken = page.NextPageToken
+	}
+	return "", nil
+}
+
+// classifyChange turns a file change into an event, or nil for changes to
+// Shared Drives
+func classifyChange(c *types.Change, since time.Time) *Event {
+	if c.ChangeType == "drive" || c.FileID == "" {
+		return nil
+	}
+	event := &Event{FileID: c.FileID, DriveID: c.DriveID, Time: c.Time, File: c.File}
+	if c.File != nil {
+		event.Name = c.File.Name
+		event.MimeType = c.File.MimeType
+		event.Trashed = c.File.Trashed
+	}
+
+	switch {
+	case c.Removed || c.File == nil || c.File.Trashed:
+		event.Type = EventRemoved
+	case isNewFile(c.File, since):
+		event.Type = EventAdded
+	default:
+		event.Type = EventModified
+	}
+	return event
+}
+
+func isNewFile(f *types.DriveFile, since time.Time) bool {
+	created, err := time.Parse(time.RFC3339, f.CreatedTime)
+	if err != nil {
+		return false
+	}
+	if !since.IsZero() {
+		return !created.Before(since)
+	}
+	return f.CreatedTime == f.ModifiedTime
+}
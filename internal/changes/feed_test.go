@@ -0,0 +1,137 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func newFeedManager(t *testing.T, pages map[string]*drive.ChangeList) *Manager {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/drive/v3/changes" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("includeRemoved") != "true" {
+			t.Errorf("includeRemoved not set: %s", r.URL.RawQuery)
+		}
+		page, ok := pages[r.URL.Query().Get("pageToken")]
+		if !ok {
+			http.Error(w, `{"error":{"code":400,"message":"Invalid page token"}}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	return NewManager(client)
+}
+
+func TestFeed(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mgr := newFeedManager(t, map[string]*drive.ChangeList{
+		"10": {
+			Changes: []*drive.Change{
+				{ChangeType: "file", FileId: "new", Time: "2024-05-01T12:05:00Z",
+					File: &drive.File{Id: "new", Name: "new.txt", CreatedTime: "2024-05-01T12:04:00Z", ModifiedTime: "2024-05-01T12:05:00Z"}},
+				{ChangeType: "drive", DriveId: "d1", Time: "2024-05-01T12:06:00Z"},
+			},
+			NextPageToken: "11",
+		},
+		"11": {
+			Changes: []*drive.Change{
+				{ChangeType: "file", FileId: "old", Time: "2024-05-01T12:07:00Z",
+					File: &drive.File{Id: "old", Name: "old.txt", CreatedTime: "2023-01-01T00:00:00Z", ModifiedTime: "2024-05-01T12:07:00Z"}},
+				{ChangeType: "file", FileId: "bin", Time: "2024-05-01T12:08:00Z",
+					File: &drive.File{Id: "bin", Name: "bin.txt", Trashed: true, CreatedTime: "2023-01-01T00:00:00Z"}},
+				{ChangeType: "file", FileId: "gone", Removed: true, Time: "2024-05-01T12:09:00Z"},
+			},
+			NewStartPageToken: "12",
+		},
+	})
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	var events []*Event
+	next, err := mgr.Feed(context.Background(), reqCtx, "10", FeedOptions{Since: since}, func(e *Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "12" {
+		t.Errorf("next token = %q, want 12", next)
+	}
+	want := []struct{ id, typ string }{{"new", EventAdded}, {"old", EventModified}, {"bin", EventRemoved}, {"gone", EventRemoved}}
+	if len(events) != len(want) {
+		t.Fatalf("events = %d, want %d", len(events), len(want))
+	}
+	for i, w := range want {
+		if events[i].FileID != w.id || events[i].Type != w.typ {
+			t.Errorf("event %d = %s %s, want %s %s", i, events[i].FileID, events[i].Type, w.id, w.typ)
+		}
+	}
+	if !events[2].Trashed || events[0].Name != "new.txt" || events[0].Time.IsZero() {
+		t.Errorf("event details = %+v, %+v", events[0], events[2])
+	}
+
+	// An emit failure stops the feed without a new token
+	stop := errors.New("stdout closed")
+	next, err = mgr.Feed(context.Background(), reqCtx, "10", FeedOptions{}, func(*Event) error { return stop })
+	if err != stop || next != "" {
+		t.Errorf("feed after emit failure = %q, %v", next, err)
+	}
+	if _, err := mgr.Feed(context.Background(), reqCtx, "bad", FeedOptions{}, func(*Event) error { return nil }); err == nil {
+		t.Error("expected an error for an invalid page token")
+	}
+}
+
+func TestClassifyChange_WithoutSince(t *testing.T) {
+	created := &types.Change{FileID: "a", File: &types.DriveFile{CreatedTime: "2024-05-01T12:00:00Z", ModifiedTime: "2024-05-01T12:00:00Z"}}
+	edited := &types.Change{FileID: "b", File: &types.DriveFile{CreatedTime: "2024-05-01T12:00:00Z", ModifiedTime: "2024-05-02T12:00:00Z"}}
+	if e := classifyChange(created, time.Time{}); e.Type != EventAdded {
+		t.Errorf("unmodified file = %s, want added", e.Type)
+	}
+	if e := classifyChange(edited, time.Time{}); e.Type != EventModified {
+		t.Errorf("edited file = %s, want modified", e.Type)
+	}
+}
+
+func TestCursorStore(t *testing.T) {
+	store := NewCursorStore(filepath.Join(t.TempDir(), CursorFileName))
+	key := CursorKey("work", "")
+	if c, err := store.Get(key); err != nil || c != nil {
+		t.Fatalf("empty store = %v, %v", c, err)
+	}
+	saved := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Put(key, &Cursor{PageToken: "10", SavedAt: saved}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(CursorKey("work", "d1"), &Cursor{PageToken: "99"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := store.Get(key)
+	if err != nil || c == nil || c.PageToken != "10" || !c.SavedAt.Equal(saved) {
+		t.Fatalf("cursor = %+v, %v", c, err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := store.Get(key); c != nil {
+		t.Errorf("deleted cursor = %+v", c)
+	}
+	if c, _ := store.Get("work/d1"); c == nil || c.PageToken != "99" {
+		t.Errorf("drive cursor = %+v", c)
+	}
+	if CursorKey("", "") != "default" {
+		t.Errorf("CursorKey default = %q", CursorKey("", ""))
+	}
+}
@@ -211,12 +211,16 @@ func convertChangeList(apiList *drive.ChangeList) *types.ChangeList {
 
 func convertChange(apiChange *drive.Change) types.Change {
 	change := types.Change{
-		ChangeType: apiChange.Type,
+		ChangeType: apiChange.ChangeType,
 		FileID:     apiChange.FileId,
 		Removed:    apiChange.Removed,
 		DriveID:    apiChange.DriveId,
 	}
 
+	if change.ChangeType == "" {
+		change.ChangeType = apiChange.Type
+	}
+
 	if apiChange.Time != "" {
 		if t, err := parseTime(apiChange.Time); err == nil {
 			change.Time = t
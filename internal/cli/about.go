@@ -1,9 +1,16 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"path/filepath"
 
+	"github.com/dl-alexandre/gdrv/internal/about"
+	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
 	"github.com/dl-alexandre/gdrv/pkg/version"
 	"github.com/spf13/cobra"
 )
@@ -11,28 +18,168 @@ import (
 var aboutCmd = &cobra.Command{
 	Use:   "about",
 	Short: "Display Drive account information and API capabilities",
-	Long:  "Retrieve and display information about the authenticated Drive account and supported API capabilities",
-	RunE:  runAbout,
+	Long: `Retrieve and display information about the authenticated Drive account and
+supported API capabilities.
+
+The account part comes from about.get: the user, the storage quota (limit,
+usage, usage in Drive and in Drive trash), the largest file that can be
+uploaded, and the import and export formats. The cli part lists what this
+build of gdrv supports, and is reported with a warning when the account
+cannot be read, e.g. before logging in.`,
+	RunE: runAbout,
+}
+
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Show storage quota usage",
+	Long: `Show the storage quota of the authenticated account: the limit, total
+usage, and the part of it used by Drive and by Drive trash.
+
+--watch checks usage against --threshold, a percentage of the limit. Above
+it, a QUOTA_THRESHOLD warning is reported and gdrv exits with the
+QUOTA_EXCEEDED exit code, so a cron job can alert on it. Accounts with
+unlimited storage never exceed the threshold.`,
+	Example: "  gdrv quota\n  gdrv quota --watch --threshold 85 --quiet",
+	Args:    cobra.NoArgs,
+	RunE:    runQuota,
+}
+
+var aboutFormatsCmd = &cobra.Command{
+	Use:   "formats",
+	Short: "Show supported import and export conversions",
+	Long: `Show the content-type conversion matrix reported by Drive (about.get
+importFormats and exportFormats).
+
+Import formats are the Workspace types an uploaded file can be converted
+to with "files upload --convert". Export formats are the types a Workspace
+file can be downloaded as with "files download --format" or --mime-type.
+
+Examples:
+  gdrv about formats --output table
+  gdrv about formats --source-type application/vnd.google-apps.spreadsheet`,
+	RunE: runAboutFormats,
 }
 
-var aboutFields string
+var (
+	aboutFields           string
+	aboutFormatSourceType string
+	quotaWatch            bool
+	quotaThreshold        float64
+)
 
 func init() {
 	aboutCmd.Flags().StringVar(&aboutFields, "fields", "*", "Fields to retrieve")
+	aboutFormatsCmd.Flags().StringVar(&aboutFormatSourceType, "source-type", "", "Only show conversions from this MIME type")
+	quotaCmd.Flags().BoolVar(&quotaWatch, "watch", false, "Warn and exit non-zero when usage is above --threshold")
+	quotaCmd.Flags().Float64Var(&quotaThreshold, "threshold", 90, "Usage percentage that --watch warns above")
+	aboutCmd.AddCommand(aboutFormatsCmd)
 	rootCmd.AddCommand(aboutCmd)
+	rootCmd.AddCommand(quotaCmd)
+}
+
+// AboutResult is the account from about.get with what this build of gdrv
+// supports. AboutAccount is nil when it could not be read.
+type AboutResult struct {
+	*types.AboutAccount
+	CLI map[string]interface{} `json:"cli"`
+}
+
+func (r *AboutResult) Headers() []string {
+	return []string{"Field", "Value"}
+}
+
+func (r *AboutResult) Rows() [][]string {
+	var rows [][]string
+	if r.AboutAccount != nil {
+		rows = r.AboutAccount.Rows()
+	}
+	return append(rows, []string{"gdrv version", version.Version})
+}
+
+func (r *AboutResult) EmptyMessage() string {
+	return "No account information"
 }
 
 func runAbout(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	result := &AboutResult{CLI: cliCapabilities()}
+	account, err := readAccount(ctx, flags)
+	if err != nil {
+		msg := err.Error()
+		if appErr, ok := err.(*utils.AppError); ok {
+			msg = appErr.CLIError.Message
+		}
+		out.AddWarning("ACCOUNT_UNAVAILABLE", "Account information could not be read: "+msg, "medium")
+	}
+	result.AboutAccount = account
+	return out.WriteSuccess("about", result)
+}
 
+func readAccount(ctx context.Context, flags types.GlobalFlags) (*types.AboutAccount, error) {
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return nil, err
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeGetByID)
+	return about.NewManager(client).Account(ctx, reqCtx)
+}
+
+func runQuota(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	if quotaThreshold <= 0 || quotaThreshold > 100 {
+		return out.WriteError("quota", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("--threshold must be a percentage above 0 and at most 100, got %g", quotaThreshold)).Build())
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("quota", appErr.CLIError)
+		}
+		return out.WriteError("quota", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	threshold := 0.0
+	if quotaWatch {
+		threshold = quotaThreshold
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeGetByID)
+	report, err := about.NewManager(client).Quota(ctx, reqCtx, threshold)
+	if err != nil {
+		return handleError(out, "quota", err)
+	}
+
+	if quotaWatch && report.UsedPercent == nil {
+		out.Log("Storage is unlimited; usage cannot exceed the threshold")
+	}
+	if report.Exceeded {
+		out.AddWarning("QUOTA_THRESHOLD", fmt.Sprintf("Storage usage is %.1f%% of %s, above the %g%% threshold",
+			*report.UsedPercent, utils.FormatSize(report.Limit), report.Threshold), "high")
+	}
+	if err := out.WriteSuccess("quota", report); err != nil {
+		return err
+	}
+	if report.Exceeded {
+		os.Exit(utils.GetExitCode(utils.ErrCodeQuotaExceeded))
+	}
+	return nil
+}
+
+// cliCapabilities describes what this build of gdrv supports
+func cliCapabilities() map[string]interface{} {
 	configDir := getConfigDir()
 	configPath, err := config.GetConfigPath()
 	if err != nil {
 		configPath = filepath.Join(configDir, config.ConfigFileName)
 	}
 
-	capabilities := map[string]interface{}{
+	return map[string]interface{}{
 		"version": version.Version,
 		"api": map[string]interface{}{
 			"supported_operations": []string{
@@ -85,6 +232,32 @@ func runAbout(cmd *cobra.Command, args []string) error {
 			"cache_dir":       filepath.Join(configDir, "cache"),
 		},
 	}
+}
 
-	return out.WriteSuccess("about", capabilities)
+func runAboutFormats(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("about.formats", appErr.CLIError)
+		}
+		return out.WriteError("about.formats", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeGetByID)
+	matrix, err := about.NewManager(client).Formats(ctx, reqCtx)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("about.formats", appErr.CLIError)
+		}
+		return out.WriteError("about.formats", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	if aboutFormatSourceType != "" {
+		matrix = matrix.Filter(aboutFormatSourceType)
+	}
+	return out.WriteSuccess("about.formats", matrix)
 }
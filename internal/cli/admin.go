@@ -7,6 +7,7 @@ import (
 	"github.com/dl-alexandre/gdrv/internal/admin"
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/safety"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"github.com/spf13/cobra"
@@ -323,6 +324,14 @@ func runAdminUsersCreate(cmd *cobra.Command, args []string) error {
 	email := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:         safety.OpTypeCreate,
+		ResourceName: email,
+		Description:  "Create user: " + email,
+		Predicted:    "user created",
+	}) {
+		return out.WriteSuccess("admin.users.create", nil)
+	}
 	result, err := mgr.CreateUser(ctx, reqCtx, &types.CreateUserRequest{
 		Email:      email,
 		GivenName:  adminUsersCreateGiven,
@@ -352,6 +361,14 @@ func runAdminUsersDelete(cmd *cobra.Command, args []string) error {
 	userKey := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  userKey,
+		Description: "Delete user: " + userKey,
+		Predicted:   "user deleted",
+	}) {
+		return out.WriteSuccess("admin.users.delete", nil)
+	}
 	if err := mgr.DeleteUser(ctx, reqCtx, userKey); err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("admin.users.delete", appErr.CLIError)
@@ -398,6 +415,15 @@ func runAdminUsersUpdate(cmd *cobra.Command, args []string) error {
 	userKey := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  userKey,
+		Description: "Update user: " + userKey,
+		Parameters:  map[string]interface{}{"changes": req},
+		Predicted:   "user updated",
+	}) {
+		return out.WriteSuccess("admin.users.update", nil)
+	}
 	result, err := mgr.UpdateUser(ctx, reqCtx, userKey, req)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
@@ -422,6 +448,15 @@ func runAdminUsersSuspend(cmd *cobra.Command, args []string) error {
 	userKey := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  userKey,
+		Description: "Suspend user: " + userKey,
+		Parameters:  map[string]interface{}{"suspended": true},
+		Predicted:   "user suspended",
+	}) {
+		return out.WriteSuccess("admin.users.suspend", nil)
+	}
 	result, err := mgr.SuspendUser(ctx, reqCtx, userKey)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
@@ -446,6 +481,15 @@ func runAdminUsersUnsuspend(cmd *cobra.Command, args []string) error {
 	userKey := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  userKey,
+		Description: "Unsuspend user: " + userKey,
+		Parameters:  map[string]interface{}{"suspended": false},
+		Predicted:   "user unsuspended",
+	}) {
+		return out.WriteSuccess("admin.users.unsuspend", nil)
+	}
 	result, err := mgr.UnsuspendUser(ctx, reqCtx, userKey)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
@@ -531,6 +575,15 @@ func runAdminGroupsCreate(cmd *cobra.Command, args []string) error {
 	name := args[1]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:         safety.OpTypeCreate,
+		ResourceName: email,
+		Description:  "Create group: " + email,
+		Parameters:   map[string]interface{}{"name": name},
+		Predicted:    "group created",
+	}) {
+		return out.WriteSuccess("admin.groups.create", nil)
+	}
 	result, err := mgr.CreateGroup(ctx, reqCtx, &types.CreateGroupRequest{
 		Email:       email,
 		Name:        name,
@@ -559,6 +612,14 @@ func runAdminGroupsDelete(cmd *cobra.Command, args []string) error {
 	groupKey := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  groupKey,
+		Description: "Delete group: " + groupKey,
+		Predicted:   "group deleted",
+	}) {
+		return out.WriteSuccess("admin.groups.delete", nil)
+	}
 	if err := mgr.DeleteGroup(ctx, reqCtx, groupKey); err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("admin.groups.delete", appErr.CLIError)
@@ -595,6 +656,15 @@ func runAdminGroupsUpdate(cmd *cobra.Command, args []string) error {
 	groupKey := args[0]
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  groupKey,
+		Description: "Update group: " + groupKey,
+		Parameters:  map[string]interface{}{"changes": req},
+		Predicted:   "group updated",
+	}) {
+		return out.WriteSuccess("admin.groups.update", nil)
+	}
 	result, err := mgr.UpdateGroup(ctx, reqCtx, groupKey, req)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
@@ -669,6 +739,15 @@ func runAdminMembersAdd(cmd *cobra.Command, args []string) error {
 
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreate,
+		ResourceID:  groupKey,
+		Description: "Add member " + memberEmail + " to " + groupKey,
+		Parameters:  map[string]interface{}{"member": memberEmail, "role": role},
+		Predicted:   "member added",
+	}) {
+		return out.WriteSuccess("admin.groups.members.add", nil)
+	}
 	result, err := mgr.AddMember(ctx, reqCtx, groupKey, &types.AddMemberRequest{
 		Email: memberEmail,
 		Role:  role,
@@ -698,6 +777,15 @@ func runAdminMembersRemove(cmd *cobra.Command, args []string) error {
 
 	mgr := admin.NewManager(client, svc)
 	reqCtx.RequestType = types.RequestTypeMutation
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  groupKey,
+		Description: "Remove member " + memberKey + " from " + groupKey,
+		Parameters:  map[string]interface{}{"member": memberKey},
+		Predicted:   "member removed",
+	}) {
+		return out.WriteSuccess("admin.groups.members.remove", nil)
+	}
 	if err := mgr.RemoveMember(ctx, reqCtx, groupKey, memberKey); err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("admin.groups.members.remove", appErr.CLIError)
@@ -711,6 +799,12 @@ func runAdminMembersRemove(cmd *cobra.Command, args []string) error {
 }
 
 func getAdminService(ctx context.Context, flags types.GlobalFlags) (*adminapi.Service, *api.Client, *types.RequestContext, error) {
+	return getAdminServiceWithScopes(ctx, flags, auth.RequiredScopesForService(auth.ServiceAdminDir))
+}
+
+// getAdminServiceWithScopes is getAdminService for commands that need
+// Admin SDK scopes other than the user and group defaults
+func getAdminServiceWithScopes(ctx context.Context, flags types.GlobalFlags, scopes []string) (*adminapi.Service, *api.Client, *types.RequestContext, error) {
 	configDir := getConfigDir()
 	authMgr := auth.NewManager(configDir)
 
@@ -722,7 +816,7 @@ func getAdminService(ctx context.Context, flags types.GlobalFlags) (*adminapi.Se
 		return nil, nil, nil, fmt.Errorf("admin operations require service account authentication")
 	}
 
-	if err := authMgr.ValidateServiceScopes(creds, auth.ServiceAdminDir); err != nil {
+	if err := authMgr.ValidateScopes(creds, scopes); err != nil {
 		return nil, nil, nil, err
 	}
 
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/admin"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var adminUsersAliasesCmd = &cobra.Command{
+	Use:   "aliases",
+	Short: "User email alias management",
+}
+
+var adminUsersAliasesListCmd = &cobra.Command{
+	Use:   "list <user-key>",
+	Short: "List a user's aliases",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersAliasesList,
+}
+
+var adminUsersAliasesAddCmd = &cobra.Command{
+	Use:   "add <user-key> <alias>",
+	Short: "Add an alias to a user",
+	Long:  "Add an alternate email address to a user. The alias must be in one of the customer's domains.",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAdminUsersAliasesAdd,
+}
+
+var adminUsersAliasesDeleteCmd = &cobra.Command{
+	Use:   "delete <user-key> <alias>",
+	Short: "Remove an alias from a user",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAdminUsersAliasesDelete,
+}
+
+func init() {
+	adminUsersAliasesCmd.AddCommand(adminUsersAliasesListCmd)
+	adminUsersAliasesCmd.AddCommand(adminUsersAliasesAddCmd)
+	adminUsersAliasesCmd.AddCommand(adminUsersAliasesDeleteCmd)
+	adminUsersCmd.AddCommand(adminUsersAliasesCmd)
+}
+
+func runAdminUsersAliasesList(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	svc, client, reqCtx, err := getAdminService(ctx, flags)
+	if err != nil {
+		return out.WriteError("admin.users.aliases.list", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	mgr := admin.NewManager(client, svc)
+	reqCtx.RequestType = types.RequestTypeListOrSearch
+	result, err := mgr.ListUserAliases(ctx, reqCtx, args[0])
+	if err != nil {
+		return handleError(out, "admin.users.aliases.list", err)
+	}
+
+	return out.WriteSuccess("admin.users.aliases.list", result)
+}
+
+func runAdminUsersAliasesAdd(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	userKey, alias := args[0], args[1]
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreate,
+		ResourceID:  userKey,
+		Description: fmt.Sprintf("Add alias %s to %s", alias, userKey),
+		Parameters:  map[string]interface{}{"alias": alias},
+		Predicted:   "alias added",
+	}) {
+		return out.WriteSuccess("admin.users.aliases.add", nil)
+	}
+
+	svc, client, reqCtx, err := getAdminService(ctx, flags)
+	if err != nil {
+		return out.WriteError("admin.users.aliases.add", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	mgr := admin.NewManager(client, svc)
+	reqCtx.RequestType = types.RequestTypeMutation
+	result, err := mgr.AddUserAlias(ctx, reqCtx, userKey, alias)
+	if err != nil {
+		return handleError(out, "admin.users.aliases.add", err)
+	}
+
+	return out.WriteSuccess("admin.users.aliases.add", result)
+}
+
+func runAdminUsersAliasesDelete(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	userKey, alias := args[0], args[1]
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  userKey,
+		Description: fmt.Sprintf("Remove alias %s from %s", alias, userKey),
+		Parameters:  map[string]interface{}{"alias": alias},
+		Predicted:   "alias removed",
+	}) {
+		return out.WriteSuccess("admin.users.aliases.delete", nil)
+	}
+
+	svc, client, reqCtx, err := getAdminService(ctx, flags)
+	if err != nil {
+		return out.WriteError("admin.users.aliases.delete", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	mgr := admin.NewManager(client, svc)
+	reqCtx.RequestType = types.RequestTypeMutation
+	if err := mgr.DeleteUserAlias(ctx, reqCtx, userKey, alias); err != nil {
+		return handleError(out, "admin.users.aliases.delete", err)
+	}
+
+	return out.WriteSuccess("admin.users.aliases.delete", map[string]string{
+		"message": fmt.Sprintf("Alias %s removed from %s", alias, userKey),
+	})
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dl-alexandre/gdrv/internal/admin"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var adminUsersPhotoCmd = &cobra.Command{
+	Use:   "photo",
+	Short: "User profile photo management",
+}
+
+var adminUsersPhotoGetCmd = &cobra.Command{
+	Use:   "get <user-key>",
+	Short: "Get a user's profile photo",
+	Long: `Show a user's profile photo, and with --output save the image to a file.
+The Directory API stores profile photos at 96x96 pixels.`,
+	Example: "  gdrv admin users photo get alice@example.com --output alice.jpg",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runAdminUsersPhotoGet,
+}
+
+var adminUsersPhotoSetCmd = &cobra.Command{
+	Use:   "set <user-key> <image-file>",
+	Short: "Set a user's profile photo",
+	Long: `Replace a user's profile photo with a JPEG, PNG, GIF, BMP or TIFF image.
+The Directory API scales the image to 96x96 pixels.`,
+	Example: "  gdrv admin users photo set alice@example.com ./alice.png",
+	Args:    cobra.ExactArgs(2),
+	RunE:    runAdminUsersPhotoSet,
+}
+
+var adminUsersPhotoDeleteCmd = &cobra.Command{
+	Use:   "delete <user-key>",
+	Short: "Remove a user's profile photo",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersPhotoDelete,
+}
+
+var adminUsersPhotoOutput string
+
+func init() {
+	adminUsersPhotoGetCmd.Flags().StringVar(&adminUsersPhotoOutput, "output", "", "Save the photo to this file")
+
+	adminUsersPhotoCmd.AddCommand(adminUsersPhotoGetCmd)
+	adminUsersPhotoCmd.AddCommand(adminUsersPhotoSetCmd)
+	adminUsersPhotoCmd.AddCommand(adminUsersPhotoDeleteCmd)
+	adminUsersCmd.AddCommand(adminUsersPhotoCmd)
+}
+
+func runAdminUsersPhotoGet(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	svc, client, reqCtx, err := getAdminService(ctx, flags)
+	if err != nil {
+		return out.WriteError("admin.users.photo.get", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	mgr := admin.NewManager(client, svc)
+	reqCtx.RequestType = types.RequestTypeGetByID
+	photo, data, err := mgr.GetUserPhoto(ctx, reqCtx, args[0])
+	if err != nil {
+		return handleError(out, "admin.users.photo.get", err)
+	}
+
+	if adminUsersPhotoOutput != "" {
+		if err := os.WriteFile(adminUsersPhotoOutput, data, 0644); err != nil {
+			return out.WriteError("admin.users.photo.get", utils.NewCLIError(utils.ErrCodeInvalidPath,
+				fmt.Sprintf("Failed to write photo: %s", err)).Build())
+		}
+		photo.Path = adminUsersPhotoOutput
+		out.Log("Saved photo to: %s", adminUsersPhotoOutput)
+	}
+	return out.WriteSuccess("admin.users.photo.get", photo)
+}
+
+func runAdminUsersPhotoSet(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	userKey, path := args[0], args[1]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return out.WriteError("admin.users.photo.set", utils.NewCLIError(utils.ErrCodeInvalidPath,
+			fmt.Sprintf("Failed to read photo: %s", err)).Build())
+	}
+	// Check the format before asking for credentials
+	mimeType, err := admin.PhotoMimeType(data)
+	if err != nil {
+		return handleError(out, "admin.users.photo.set", err)
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  userKey,
+		Description: fmt.Sprintf("Set the photo of %s to %s", userKey, path),
+		Parameters:  map[string]interface{}{"file": path, "mimeType": mimeType, "size": len(data)},
+		Predicted:   "photo replaced",
+	}) {
+		return out.WriteSuccess("admin.users.photo.set", nil)
+	}
+
+	svc, client, reqCtx, err := getAdminService(ctx, flags)
+	if err != nil {
+		return out.WriteError("admin.users.photo.set", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	mgr := admin.NewManager(client, svc)
+	reqCtx.RequestType = types.RequestTypeMutation
+	photo, err := mgr.SetUserPhoto(ctx, reqCtx, userKey, data)
+	if err != nil {
+		return handleError(out, "admin.users.photo.set", err)
+	}
+
+	return out.WriteSuccess("admin.users.photo.set", photo)
+}
+
+func runAdminUsersPhotoDelete(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	userKey := args[0]
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  userKey,
+		Description: fmt.Sprintf("Remove the photo of %s", userKey),
+		Predicted:   "photo removed",
+	}) {
+		return out.WriteSuccess("admin.users.photo.delete", nil)
+	}
+
+	svc, client, reqCtx, err := getAdminService(ctx, flags)
+	if err != nil {
+		return out.WriteError("admin.users.photo.delete", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	mgr := admin.NewManager(client, svc)
+	reqCtx.RequestType = types.RequestTypeMutation
+	if err := mgr.DeleteUserPhoto(ctx, reqCtx, userKey); err != nil {
+		return handleError(out, "admin.users.photo.delete", err)
+	}
+
+	return out.WriteSuccess("admin.users.photo.delete", map[string]string{
+		"message": fmt.Sprintf("Photo of %s removed", userKey),
+	})
+}
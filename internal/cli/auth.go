@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,8 +27,14 @@ var authCmd = &cobra.Command{
 var authLoginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Authenticate with Google Drive",
-	Long:  "Initiate OAuth2 authentication flow to obtain credentials",
-	RunE:  runAuthLogin,
+	Long: `Initiate OAuth2 authentication flow to obtain credentials.
+
+Use --for to request only the scopes some command families need (for
+example --for read), and --add-scopes to extend an existing grant with
+more scopes instead of replacing it.`,
+	Example: "  gdrv auth login --for read\n" +
+		"  gdrv auth login --add-scopes --for write",
+	RunE: runAuthLogin,
 }
 
 var authLogoutCmd = &cobra.Command{
@@ -40,8 +47,17 @@ var authLogoutCmd = &cobra.Command{
 var authServiceAccountCmd = &cobra.Command{
 	Use:   "service-account",
 	Short: "Authenticate with a service account",
-	Long:  "Load service account credentials from a JSON key file",
-	RunE:  runAuthServiceAccount,
+	Long: `Load service account credentials from a JSON key file, or fetch the key at
+runtime with --credentials-source so it is never stored on disk:
+
+  vault://<api-path>[#field]                 HashiCorp Vault (VAULT_ADDR, VAULT_TOKEN)
+  gcpsm://projects/<p>/secrets/<s>[/versions/<v>]   GCP Secret Manager (application default credentials)
+  file://<path>
+
+The source is remembered with the profile and the key is fetched again to
+renew expired tokens. Without either flag, the profile's credentialsSource
+config setting is used.`,
+	RunE: runAuthServiceAccount,
 }
 
 var authStatusCmd = &cobra.Command{
@@ -65,6 +81,33 @@ var authProfilesCmd = &cobra.Command{
 	RunE:  runAuthProfiles,
 }
 
+var authListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles with their type, scopes and expiry",
+	Long: `List every stored credential profile with its credential type, account,
+granted scopes, token expiry and status. The current profile is marked
+with * in table output.`,
+	Example: "  gdrv auth list --output table",
+	Args:    cobra.NoArgs,
+	RunE:    runAuthList,
+}
+
+var authRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke a profile's grant with Google and remove it",
+	Long: `Revoke the OAuth grant of the current or --profile profile with Google,
+then delete its local credentials. Unlike logout, this ends the grant
+itself: refresh tokens copied elsewhere stop working and gdrv disappears
+from the account's third-party access page once no other profile uses it.
+
+If Google cannot be reached, local credentials are kept so the revocation
+can be retried; --force removes them anyway. Service account keys cannot
+be revoked this way; only their local token is removed.`,
+	Example: "  gdrv auth revoke --profile work",
+	Args:    cobra.NoArgs,
+	RunE:    runAuthRevoke,
+}
+
 var authDiagnoseCmd = &cobra.Command{
 	Use:   "diagnose",
 	Short: "Diagnose authentication configuration",
@@ -78,10 +121,13 @@ var (
 	authWide                 bool
 	authPreset               string
 	authKeyFile              string
+	authCredentialsSource    string
 	authImpersonateUser      string
 	clientID                 string
 	clientSecret             string
 	authDiagnoseRefreshCheck bool
+	authFor                  []string
+	authAddScopes            bool
 )
 
 func init() {
@@ -89,16 +135,20 @@ func init() {
 	authLoginCmd.Flags().BoolVar(&authNoBrowser, "no-browser", false, "Do not open a browser; use manual code entry")
 	authLoginCmd.Flags().BoolVar(&authWide, "wide", false, "Request full Drive access scope")
 	authLoginCmd.Flags().StringVar(&authPreset, "preset", "", "Scope preset: workspace-basic, workspace-full, admin, workspace-with-admin, workspace-activity, workspace-labels, workspace-sync, workspace-complete")
+	authLoginCmd.Flags().StringSliceVar(&authFor, "for", nil, "Request only the scopes these command families need: "+familyNames())
+	authLoginCmd.Flags().BoolVar(&authAddScopes, "add-scopes", false, "Add the requested scopes to the profile's existing grant instead of replacing it")
 	authLoginCmd.Flags().StringVar(&clientID, "client-id", "", "OAuth client ID")
 	authLoginCmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth client secret")
 	authDeviceCmd.Flags().BoolVar(&authWide, "wide", false, "Request full Drive access scope")
+	authDeviceCmd.Flags().StringSliceVar(&authFor, "for", nil, "Request only the scopes these command families need: "+familyNames())
 	authDeviceCmd.Flags().StringVar(&authPreset, "preset", "", "Scope preset: workspace-basic, workspace-full, admin, workspace-with-admin, workspace-activity, workspace-labels, workspace-sync, workspace-complete")
-	authServiceAccountCmd.Flags().StringVar(&authKeyFile, "key-file", "", "Path to service account JSON key file (required)")
+	authServiceAccountCmd.Flags().StringVar(&authKeyFile, "key-file", "", "Path to service account JSON key file")
+	authServiceAccountCmd.Flags().StringVar(&authCredentialsSource, "credentials-source", "", "Fetch the key at runtime instead of from a file: vault://<path>[#field], gcpsm://projects/<p>/secrets/<s>, or file://<path>")
 	authServiceAccountCmd.Flags().StringVar(&authImpersonateUser, "impersonate-user", "", "User email to impersonate (required for Admin SDK scopes)")
 	authServiceAccountCmd.Flags().StringSliceVar(&authScopes, "scopes", []string{}, "OAuth scopes to request")
 	authServiceAccountCmd.Flags().BoolVar(&authWide, "wide", false, "Request full Drive access scope")
 	authServiceAccountCmd.Flags().StringVar(&authPreset, "preset", "", "Scope preset: workspace-basic, workspace-full, admin, workspace-with-admin, workspace-activity, workspace-labels, workspace-sync, workspace-complete")
-	_ = authServiceAccountCmd.MarkFlagRequired("key-file")
+	authServiceAccountCmd.MarkFlagsMutuallyExclusive("key-file", "credentials-source")
 	authDiagnoseCmd.Flags().BoolVar(&authDiagnoseRefreshCheck, "refresh-check", false, "Attempt a token refresh and report errors")
 
 	authCmd.AddCommand(authLoginCmd)
@@ -107,6 +157,8 @@ func init() {
 	authCmd.AddCommand(authLogoutCmd)
 	authCmd.AddCommand(authStatusCmd)
 	authCmd.AddCommand(authProfilesCmd)
+	authCmd.AddCommand(authListCmd)
+	authCmd.AddCommand(authRevokeCmd)
 	authCmd.AddCommand(authDiagnoseCmd)
 	rootCmd.AddCommand(authCmd)
 }
@@ -141,10 +193,19 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 	mgr.SetOAuthConfig(clientID, clientSecret, scopes)
 
 	ctx := context.Background()
+	opts := auth.OAuthAuthOptions{NoBrowser: authNoBrowser}
 	var creds *types.Credentials
-	creds, err = mgr.Authenticate(ctx, flags.Profile, openBrowser, auth.OAuthAuthOptions{
-		NoBrowser: authNoBrowser,
-	})
+	if authAddScopes {
+		// A missing or unreadable profile simply starts a new grant
+		existing, _ := mgr.LoadCredentials(flags.Profile)
+		if existing != nil && existing.Type != types.AuthTypeOAuth {
+			return out.WriteError("auth.login", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Profile '%s' uses %s credentials; --add-scopes only extends OAuth logins", flags.Profile, existing.Type)).Build())
+		}
+		creds, err = mgr.ExtendConsent(ctx, flags.Profile, existing, scopes, openBrowser, opts)
+	} else {
+		creds, err = mgr.Authenticate(ctx, flags.Profile, openBrowser, opts)
+	}
 
 	if err != nil {
 		return out.WriteError("auth.login", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
@@ -313,12 +374,104 @@ func runAuthProfiles(cmd *cobra.Command, args []string) error {
 	})
 }
 
+func runAuthList(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	mgr := auth.NewManager(getConfigDir())
+	profiles, err := mgr.ListProfiles()
+	if err != nil {
+		return out.WriteError("auth.list", utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to list profiles: %v", err)).Build())
+	}
+	sort.Strings(profiles)
+
+	list := &types.AuthProfileList{Profiles: []types.AuthProfileInfo{}, StorageBackend: mgr.GetStorageBackend()}
+	for _, profile := range profiles {
+		info := types.AuthProfileInfo{Profile: profile, Current: profile == flags.Profile}
+		creds, err := mgr.LoadCredentials(profile)
+		if err != nil {
+			info.Status = "error"
+			info.Error = err.Error()
+			list.Profiles = append(list.Profiles, info)
+			continue
+		}
+		info.Type = creds.Type
+		info.Account = creds.ServiceAccountEmail
+		if creds.ImpersonatedUser != "" {
+			info.Account = creds.ImpersonatedUser
+		}
+		info.Scopes = creds.Scopes
+		info.Expiry = creds.ExpiryDate.Format(time.RFC3339)
+		info.Status = profileStatus(mgr, creds)
+		list.Profiles = append(list.Profiles, info)
+	}
+	return out.WriteSuccess("auth.list", list)
+}
+
+// profileStatus says whether a profile's token can be used as it is, will
+// be renewed on next use, or needs a new login
+func profileStatus(mgr *auth.Manager, creds *types.Credentials) string {
+	switch {
+	case !mgr.NeedsRefresh(creds):
+		return "valid"
+	case creds.Type == types.AuthTypeOAuth && creds.RefreshToken != "",
+		creds.Type != types.AuthTypeOAuth && creds.CredentialsSource != "":
+		return "renews on use"
+	default:
+		return "expired"
+	}
+}
+
+func runAuthRevoke(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	mgr := auth.NewManager(getConfigDir())
+	if flags.DryRun {
+		creds, err := mgr.LoadCredentials(flags.Profile)
+		if err != nil {
+			return out.WriteError("auth.revoke", utils.NewCLIError(utils.ErrCodeAuthRequired,
+				fmt.Sprintf("No credentials found for profile '%s'", flags.Profile)).Build())
+		}
+		out.Log("Would revoke %s credentials for profile: %s", creds.Type, flags.Profile)
+		return out.WriteSuccess("auth.revoke", &auth.RevokeResult{Profile: flags.Profile, Type: creds.Type})
+	}
+
+	result, err := mgr.RevokeCredentials(context.Background(), flags.Profile, !flags.Force)
+	if err != nil {
+		return handleError(out, "auth.revoke", err)
+	}
+	if result.Note != "" {
+		out.AddWarning("REVOKE_INCOMPLETE", result.Note, "medium")
+	}
+	switch {
+	case result.Revoked:
+		out.Log("Revoked the grant and removed credentials for profile: %s", flags.Profile)
+	case result.AlreadyInvalid:
+		out.Log("Grant was already revoked or expired; removed credentials for profile: %s", flags.Profile)
+	default:
+		out.Log("Removed credentials for profile: %s", flags.Profile)
+	}
+	return out.WriteSuccess("auth.revoke", result)
+}
+
 func runAuthServiceAccount(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	if authKeyFile == "" {
-		return fmt.Errorf("service account key file required via --key-file")
+	source := authCredentialsSource
+	if authKeyFile == "" && source == "" {
+		if cfg, err := config.Load(); err == nil {
+			source = cfg.Profile(flags.Profile).CredentialsSource
+		}
+	}
+	if authKeyFile == "" && source == "" {
+		return fmt.Errorf("service account key required via --key-file or --credentials-source")
+	}
+	if source != "" && !auth.IsCredentialSource(source) {
+		return out.WriteError("auth.service-account", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Unsupported credentials source %q: expected vault://, gcpsm:// or file://", source)).Build())
 	}
 
 	scopes, err := resolveAuthScopes(out)
@@ -332,7 +485,12 @@ func runAuthServiceAccount(cmd *cobra.Command, args []string) error {
 	configDir := getConfigDir()
 	mgr := auth.NewManager(configDir)
 
-	creds, err := mgr.LoadServiceAccount(context.Background(), authKeyFile, scopes, authImpersonateUser)
+	var creds *types.Credentials
+	if source != "" {
+		creds, err = mgr.LoadServiceAccountFromSource(context.Background(), source, scopes, authImpersonateUser)
+	} else {
+		creds, err = mgr.LoadServiceAccount(context.Background(), authKeyFile, scopes, authImpersonateUser)
+	}
 	if err != nil {
 		return out.WriteError("auth.service-account", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
 	}
@@ -348,6 +506,7 @@ func runAuthServiceAccount(cmd *cobra.Command, args []string) error {
 		"type":           creds.Type,
 		"serviceAccount": creds.ServiceAccountEmail,
 		"impersonated":   creds.ImpersonatedUser,
+		"source":         creds.CredentialsSource,
 		"storageBackend": mgr.GetStorageBackend(),
 	})
 }
@@ -361,6 +520,18 @@ func resolveAuthScopes(out *OutputWriter) ([]string, error) {
 		out.Log("Using scope preset: %s", authPreset)
 		return scopes, nil
 	}
+	if len(authFor) > 0 {
+		families := make([]auth.CommandFamily, 0, len(authFor))
+		for _, name := range authFor {
+			family, err := auth.ParseCommandFamily(name)
+			if err != nil {
+				return nil, err
+			}
+			families = append(families, family)
+		}
+		out.Log("Requesting scopes for: %s", strings.Join(authFor, ", "))
+		return auth.MergeScopes(auth.ScopesForFamilies(families...), authScopes), nil
+	}
 	if authWide {
 		out.Log("Using full Drive scope (%s)", utils.ScopeFull)
 		return []string{utils.ScopeFull}, nil
@@ -372,6 +543,16 @@ func resolveAuthScopes(out *OutputWriter) ([]string, error) {
 	return authScopes, nil
 }
 
+// familyNames lists the command families accepted by --for
+func familyNames() string {
+	families := auth.CommandFamilies()
+	names := make([]string, len(families))
+	for i, f := range families {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
 func scopesForPreset(preset string) ([]string, error) {
 	switch preset {
 	case "workspace-basic":
@@ -401,6 +582,7 @@ func validateAdminScopesRequireImpersonation(scopes []string, impersonateUser st
 		utils.ScopeAdminDirectoryUserReadonly,
 		utils.ScopeAdminDirectoryGroup,
 		utils.ScopeAdminDirectoryGroupReadonly,
+		utils.ScopeAdminDirectoryDomainReadonly,
 	}
 
 	hasAdminScope := false
@@ -464,6 +646,8 @@ func runAuthDiagnose(cmd *cobra.Command, args []string) error {
 		"tokenLocation":    location,
 		"clientIdHash":     clientHash,
 		"clientIdLast4":    clientFingerprint,
+		"clientSource":     source,
+		"billingProject":   flags.BillingProject,
 		"scopes":           creds.Scopes,
 		"expiry":           creds.ExpiryDate.Format(time.RFC3339),
 		"refreshToken":     creds.RefreshToken != "",
@@ -520,6 +704,7 @@ type oauthClientSource string
 const (
 	oauthClientSourceFlags   oauthClientSource = "flags"
 	oauthClientSourceEnv     oauthClientSource = "env"
+	oauthClientSourceProfile oauthClientSource = "profile"
 	oauthClientSourceConfig  oauthClientSource = "config"
 	oauthClientSourceBundled oauthClientSource = "bundled"
 )
@@ -552,6 +737,13 @@ func resolveOAuthClient(cmd *cobra.Command, configDir string, allowMissing bool)
 	if cfgErr != nil {
 		return "", "", "", utils.NewCLIError(utils.ErrCodeInvalidArgument, fmt.Sprintf("Failed to load config: %v", cfgErr))
 	}
+	if p := cfg.Profile(GetGlobalFlags().Profile); p.OAuthClientID != "" || p.OAuthClientSecret != "" {
+		if p.OAuthClientID == "" || (requireSecret && p.OAuthClientSecret == "") {
+			return "", "", "", buildOAuthClientError(utils.ErrCodeAuthClientPartial, configDir,
+				"Partial OAuth client override not allowed. Set all required client fields for the profile with 'gdrv auth set-client', or clear them to use the top-level client.")
+		}
+		return p.OAuthClientID, p.OAuthClientSecret, oauthClientSourceProfile, nil
+	}
 	if cfg.OAuthClientID != "" || cfg.OAuthClientSecret != "" {
 		if cfg.OAuthClientID == "" || (requireSecret && cfg.OAuthClientSecret == "") {
 			return "", "", "", buildOAuthClientError(utils.ErrCodeAuthClientPartial, configDir,
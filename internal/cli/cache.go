@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/resolver"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Local caches",
+	Long: `Manage the caches gdrv keeps between runs.
+
+Paths resolved by one run are saved for --cache-ttl seconds, so a later run
+resolving the same path does not walk it again. --no-cache ignores them.`,
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm --folder-id <id>",
+	Short: "Cache the paths of a folder tree",
+	Long: `Resolve and cache the path of a folder and of everything up to --depth
+levels below it, so later commands given those paths, or paths deeper in
+the tree, skip walking the hierarchy one folder at a time.
+
+The folder's path is found by walking up to My Drive or its shared drive.
+Each folder in the tree is listed once. Names shared by several items in
+the same folder are not cached; they are reported as ambiguous and still
+resolved, per --strict, when used.
+
+Warmed paths are kept for --ttl. A file moved or renamed in that time is
+still found at its old path, so warm again, or pass --no-cache, after
+reorganizing a tree. Paths are cached for the resolution context of this
+run: warm with --include-shared-with-me to speed up commands that use it.`,
+	Example: "  gdrv cache warm --folder-id 1AbC... --depth 3\n" +
+		"  gdrv cache warm --folder-id /Projects/2024 --depth 5 --ttl 24h",
+	Args: cobra.NoArgs,
+	RunE: runCacheWarm,
+}
+
+var (
+	cacheWarmFolder string
+	cacheWarmDepth  int
+	cacheWarmTTL    time.Duration
+)
+
+func init() {
+	cacheWarmCmd.Flags().StringVar(&cacheWarmFolder, "folder-id", "", "Folder ID or path at the top of the tree (required)")
+	cacheWarmCmd.Flags().IntVar(&cacheWarmDepth, "depth", 3, "Levels below the folder to cache")
+	cacheWarmCmd.Flags().DurationVar(&cacheWarmTTL, "ttl", time.Hour, "How long warmed paths stay valid")
+	_ = cacheWarmCmd.MarkFlagRequired("folder-id")
+
+	cacheCmd.AddCommand(cacheWarmCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheWarm(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	if cacheWarmDepth < 0 {
+		return out.WriteError("cache.warm", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("--depth must be 0 or more, got %d", cacheWarmDepth)).Build())
+	}
+	if cacheWarmTTL <= 0 {
+		return out.WriteError("cache.warm", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--ttl must be positive").Build())
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "cache.warm", err)
+	}
+	folderID, err := ResolveFileID(ctx, client, flags, cacheWarmFolder)
+	if err != nil {
+		return handleError(out, "cache.warm", err)
+	}
+
+	store, err := resolver.DefaultPathStore()
+	if err != nil {
+		return handleError(out, "cache.warm", err)
+	}
+	pathResolver := resolver.NewPathResolver(client, cacheWarmTTL)
+	if err := pathResolver.UseStore(store); err != nil {
+		return handleError(out, "cache.warm", err)
+	}
+
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
+	result, err := pathResolver.Warm(ctx, reqCtx, folderID, resolver.WarmOptions{
+		Depth:   cacheWarmDepth,
+		TTL:     cacheWarmTTL,
+		Resolve: GetResolveOptions(flags),
+	})
+	if err != nil {
+		return handleError(out, "cache.warm", err)
+	}
+
+	if len(result.Ambiguous) > 0 {
+		out.AddWarning("AMBIGUOUS_PATHS", fmt.Sprintf("%d paths name more than one item and were not cached", len(result.Ambiguous)), "low")
+	}
+	out.Log("Cached %d paths under /%s from %d folders", result.Cached, result.Path, result.Folders)
+	return out.WriteSuccess("cache.warm", result)
+}
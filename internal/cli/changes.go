@@ -37,13 +37,22 @@ Examples:
 
 var changesListCmd = &cobra.Command{
 	Use:   "list",
-	Short: "List changes since a page token",
+	Short: "List changes since a page token or the saved feed position",
 	Long: `List changes to files and folders since a given page token.
 
 Use the page token from start-page-token or from a previous list response
 to track incremental changes.
 
+Without --page-token, the profile keeps its own feed position in the config
+directory (one per --drive-id). The first run saves the current position;
+each later run lists the files added, modified and removed since the
+previous run and advances the position. --no-save lists without advancing
+it; --reset starts over from now.
+
 Examples:
+  # Files added, modified or removed since the last run
+  gdrv changes list --json
+
   # List changes since a page token
   gdrv changes list --page-token "12345" --json
 
@@ -63,13 +72,24 @@ Examples:
 
 var changesWatchCmd = &cobra.Command{
 	Use:   "watch",
-	Short: "Watch for changes via webhook",
-	Long: `Set up a webhook to receive notifications when changes occur.
+	Short: "Stream changes, or watch for them via webhook",
+	Long: `Stream changes as JSON lines, or set up a webhook to receive notifications
+when changes occur.
+
+Without --webhook-url, the change feed is polled every --interval and each
+added, modified or removed file is written to stdout as one JSON object per
+line, for piping into other tools. The feed starts from --page-token, or from
+the profile's saved position (see 'gdrv changes list'), which is advanced
+after every poll. A poll interrupted by an error is read again, so a change
+can be written more than once.
 
-The webhook URL must be accessible from the internet and must use HTTPS.
-Google will send POST requests to this URL when changes occur.
+With --webhook-url, the URL must be accessible from the internet and must
+use HTTPS. Google will send POST requests to this URL when changes occur.
 
 Examples:
+  # Stream changes as JSON lines
+  gdrv changes watch --interval 1m | jq -c 'select(.type == "added")'
+
   # Watch for changes
   gdrv changes watch --page-token "12345" --webhook-url "https://example.com/webhook" --json
 
@@ -115,7 +135,7 @@ var (
 func init() {
 	changesStartPageTokenCmd.Flags().StringVar(&globalFlags.DriveID, "drive-id", "", "Shared Drive ID")
 
-	changesListCmd.Flags().StringVar(&changesPageToken, "page-token", "", "Page token to list changes from (required)")
+	changesListCmd.Flags().StringVar(&changesPageToken, "page-token", "", "Page token to list changes from (default: the saved feed position)")
 	changesListCmd.Flags().StringVar(&globalFlags.DriveID, "drive-id", "", "Shared Drive ID")
 	changesListCmd.Flags().BoolVar(&changesIncludeCorpusRemovals, "include-corpus-removals", false, "Include changes outside target corpus")
 	changesListCmd.Flags().BoolVar(&changesIncludeItemsFromAllDrives, "include-items-from-all-drives", false, "Include items from all drives")
@@ -127,10 +147,9 @@ func init() {
 	changesListCmd.Flags().StringVar(&changesFields, "fields", "", "Fields to return")
 	changesListCmd.Flags().StringVar(&changesSpaces, "spaces", "", "Comma-separated list of spaces (drive, appDataFolder, photos)")
 	changesListCmd.Flags().BoolVar(&changesPaginate, "paginate", false, "Auto-paginate through all changes")
-	changesListCmd.MarkFlagRequired("page-token")
 
-	changesWatchCmd.Flags().StringVar(&changesPageToken, "page-token", "", "Page token to watch from (required)")
-	changesWatchCmd.Flags().StringVar(&changesWebhookURL, "webhook-url", "", "Webhook URL for notifications (required)")
+	changesWatchCmd.Flags().StringVar(&changesPageToken, "page-token", "", "Page token to watch from (required with --webhook-url; default: the saved feed position)")
+	changesWatchCmd.Flags().StringVar(&changesWebhookURL, "webhook-url", "", "Webhook URL for notifications (default: stream JSON lines to stdout)")
 	changesWatchCmd.Flags().StringVar(&globalFlags.DriveID, "drive-id", "", "Shared Drive ID")
 	changesWatchCmd.Flags().BoolVar(&changesIncludeCorpusRemovals, "include-corpus-removals", false, "Include changes outside target corpus")
 	changesWatchCmd.Flags().BoolVar(&changesIncludeItemsFromAllDrives, "include-items-from-all-drives", false, "Include items from all drives")
@@ -141,8 +160,6 @@ func init() {
 	changesWatchCmd.Flags().StringVar(&changesSpaces, "spaces", "", "Comma-separated list of spaces (drive, appDataFolder, photos)")
 	changesWatchCmd.Flags().Int64Var(&changesExpiration, "expiration", 0, "Webhook expiration time (Unix timestamp in milliseconds)")
 	changesWatchCmd.Flags().StringVar(&changesToken, "token", "", "Arbitrary token for webhook verification")
-	changesWatchCmd.MarkFlagRequired("page-token")
-	changesWatchCmd.MarkFlagRequired("webhook-url")
 
 	changesCmd.AddCommand(changesStartPageTokenCmd)
 	changesCmd.AddCommand(changesListCmd)
@@ -214,6 +231,14 @@ func runChangesList(cmd *cobra.Command, args []string) error {
 		Spaces:                    changesSpaces,
 	}
 
+	if changesPageToken == "" {
+		return runChangesFeedList(ctx, mgr, reqCtx, out)
+	}
+	if changesNoSave || changesReset {
+		return out.WriteError("changes.list", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--no-save and --reset apply to the saved feed position, not --page-token").Build())
+	}
+
 	if changesPaginate {
 		return paginateChanges(ctx, mgr, reqCtx, out, opts)
 	}
@@ -312,6 +337,10 @@ func runChangesWatch(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if changesWebhookURL == "" {
+		return runChangesStream(ctx, mgr, reqCtx, out)
+	}
+
 	opts := types.WatchOptions{
 		PageToken:                 changesPageToken,
 		DriveID:                   globalFlags.DriveID,
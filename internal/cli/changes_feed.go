@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/changes"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+var (
+	changesNoSave   bool
+	changesReset    bool
+	changesInterval time.Duration
+)
+
+func init() {
+	changesListCmd.Flags().BoolVar(&changesNoSave, "no-save", false, "Read the saved feed without advancing it")
+	changesListCmd.Flags().BoolVar(&changesReset, "reset", false, "Discard the saved feed position and start from now")
+	changesWatchCmd.Flags().DurationVar(&changesInterval, "interval", 30*time.Second, "Time between polls when streaming")
+	changesWatchCmd.Flags().BoolVar(&changesNoSave, "no-save", false, "Stream without advancing the saved feed")
+}
+
+// feedOptions returns the change feed options given by the list and watch
+// flags
+func feedOptions(since time.Time) changes.FeedOptions {
+	return changes.FeedOptions{
+		DriveID:                   globalFlags.DriveID,
+		IncludeItemsFromAllDrives: changesIncludeItemsFromAllDrives,
+		RestrictToMyDrive:         changesRestrictToMyDrive,
+		Spaces:                    changesSpaces,
+		Since:                     since,
+	}
+}
+
+// startFeed returns the page token to read from: the saved cursor of the
+// profile, or a new start page token, which is saved. started reports
+// that no cursor was saved before.
+func startFeed(ctx context.Context, mgr *changes.Manager, reqCtx *types.RequestContext, store *changes.CursorStore, key string) (cursor *changes.Cursor, started bool, err error) {
+	cursor, err = store.Get(key)
+	if err != nil || cursor != nil {
+		return cursor, false, err
+	}
+	now := time.Now()
+	token, err := mgr.GetStartPageToken(ctx, reqCtx, globalFlags.DriveID)
+	if err != nil {
+		return nil, false, err
+	}
+	cursor = &changes.Cursor{PageToken: token, SavedAt: now}
+	return cursor, true, store.Put(key, cursor)
+}
+
+// runChangesFeedList lists the changes since the profile's saved cursor
+// and advances it
+func runChangesFeedList(ctx context.Context, mgr *changes.Manager, reqCtx *types.RequestContext, out *OutputWriter) error {
+	store, err := changes.DefaultCursorStore()
+	if err != nil {
+		return handleError(out, "changes.list", err)
+	}
+	key := changes.CursorKey(globalFlags.Profile, globalFlags.DriveID)
+	if changesReset {
+		if err := store.Delete(key); err != nil {
+			return handleError(out, "changes.list", err)
+		}
+	}
+
+	cursor, started, err := startFeed(ctx, mgr, reqCtx, store, key)
+	if err != nil {
+		return handleError(out, "changes.list", err)
+	}
+	result := &changes.FeedResult{
+		Profile:           globalFlags.Profile,
+		DriveID:           globalFlags.DriveID,
+		PageToken:         cursor.PageToken,
+		NewStartPageToken: cursor.PageToken,
+		Initialized:       started,
+		Events:            []*changes.Event{},
+	}
+	if started {
+		out.Log("Saved a new change feed position for profile %s", key)
+		return out.WriteSuccess("changes.list", result)
+	}
+
+	readAt := time.Now()
+	next, err := mgr.Feed(ctx, reqCtx, cursor.PageToken, feedOptions(cursor.SavedAt), func(e *changes.Event) error {
+		result.Events = append(result.Events, e)
+		return nil
+	})
+	if err != nil {
+		return handleError(out, "changes.list", err)
+	}
+	result.NewStartPageToken = next
+	if !changesNoSave {
+		if err := store.Put(key, &changes.Cursor{PageToken: next, SavedAt: readAt}); err != nil {
+			return handleError(out, "changes.list", err)
+		}
+	}
+	return out.WriteSuccess("changes.list", result)
+}
+
+// runChangesStream polls the change feed and writes each event to stdout
+// as a JSON line until interrupted. Without --page-token it reads from the
+// profile's saved cursor and advances it after every poll.
+func runChangesStream(ctx context.Context, mgr *changes.Manager, reqCtx *types.RequestContext, out *OutputWriter) error {
+	if changesInterval < 5*time.Second {
+		return out.WriteError("changes.watch", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--interval must be at least 5s").Build())
+	}
+	ctx, stop := interruptContext(ctx)
+	defer stop()
+
+	var store *changes.CursorStore
+	key := changes.CursorKey(globalFlags.Profile, globalFlags.DriveID)
+	cursor := &changes.Cursor{PageToken: changesPageToken}
+	if changesPageToken == "" {
+		var err error
+		if store, err = changes.DefaultCursorStore(); err != nil {
+			return handleError(out, "changes.watch", err)
+		}
+		if cursor, _, err = startFeed(ctx, mgr, reqCtx, store, key); err != nil {
+			return handleError(out, "changes.watch", err)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	out.Log("Streaming changes every %s; press Ctrl+C to stop", changesInterval)
+	for {
+		readAt := time.Now()
+		var writeErr error
+		next, err := mgr.Feed(ctx, reqCtx, cursor.PageToken, feedOptions(cursor.SavedAt), func(e *changes.Event) error {
+			writeErr = enc.Encode(e)
+			return writeErr
+		})
+		switch {
+		case writeErr != nil:
+			return writeErr
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil
+			}
+			// Keep polling through transient failures
+			out.Log("Poll failed: %v", err)
+		default:
+			cursor = &changes.Cursor{PageToken: next, SavedAt: readAt}
+			if store != nil && !changesNoSave {
+				if err := store.Put(key, cursor); err != nil {
+					return handleError(out, "changes.watch", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(changesInterval):
+		}
+	}
+}
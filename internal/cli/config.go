@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/dl-alexandre/gdrv/internal/auth"
 	"github.com/dl-alexandre/gdrv/internal/config"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
@@ -39,12 +40,30 @@ var configResetCmd = &cobra.Command{
 	RunE:  runConfigReset,
 }
 
+var configRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Restore corrupt config and credential files from their backups",
+	Long: `Check config.json, the profile list and the stored credential files.
+
+gdrv replaces these files atomically and keeps the previous version next
+to each with a .bak suffix. A file that was damaged anyway, for example by
+a full disk or a crash in an older release, is read from its backup with a
+warning until it is repaired. Repair restores each corrupt or missing file
+from a good backup and keeps the corrupt file with a .corrupt suffix.
+Files without a usable backup are reported so the profile can be logged in
+again or the config reset. --dry-run only reports.`,
+	Example: "  gdrv config repair --dry-run\n  gdrv config repair",
+	Args:    cobra.NoArgs,
+	RunE:    runConfigRepair,
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configResetCmd)
+	configCmd.AddCommand(configRepairCmd)
 }
 
 func runConfigShow(cmd *cobra.Command, args []string) error {
@@ -138,9 +157,34 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		cfg.OAuthClientID = value
 	case "oauthclientsecret":
 		cfg.OAuthClientSecret = value
+	case "readonly":
+		// Per-profile: applies to the profile selected with --profile
+		p := cfg.Profile(flags.Profile)
+		p.ReadOnly = parseBool(value)
+		cfg.SetProfile(flags.Profile, p)
+	case "billingproject":
+		// Per-profile; an empty value goes back to the OAuth client's project
+		p := cfg.Profile(flags.Profile)
+		p.BillingProject = strings.TrimSpace(value)
+		cfg.SetProfile(flags.Profile, p)
 	default:
-		return out.WriteError("config.set", utils.NewCLIError(utils.ErrCodeInvalidArgument,
-			fmt.Sprintf("Unknown configuration key: %s", key)).Build())
+		typeName, ok := strings.CutPrefix(strings.ToLower(key), "exportdefaults.")
+		if !ok {
+			return out.WriteError("config.set", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Unknown configuration key: %s", key)).Build())
+		}
+		// An empty value goes back to the built-in format
+		if value == "" {
+			delete(cfg.ExportDefaults, typeName)
+		} else {
+			if cfg.ExportDefaults == nil {
+				cfg.ExportDefaults = map[string]string{}
+			}
+			cfg.ExportDefaults[typeName] = value
+		}
+		if _, err := cfg.ExportFormats(); err != nil {
+			return out.WriteError("config.set", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
 	}
 
 	// Save the configuration
@@ -170,6 +214,63 @@ func runConfigReset(cmd *cobra.Command, args []string) error {
 	return out.WriteSuccess("config.reset", cfg)
 }
 
+func runConfigRepair(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	restore := !flags.DryRun
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return out.WriteError("config.repair", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	result := &ConfigRepairResult{}
+	if check := utils.RepairFile(configPath, utils.ValidJSON, restore); check.Status != utils.FileMissing {
+		result.Files = append(result.Files, check)
+	}
+	checks, err := auth.NewManager(getConfigDir()).RepairFiles(restore)
+	if err != nil {
+		return out.WriteError("config.repair", utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to check credential files: %v", err)).Build())
+	}
+	result.Files = append(result.Files, checks...)
+
+	for _, f := range result.Files {
+		switch {
+		case f.Status == utils.FileRestored:
+			out.Log("Restored %s from %s%s", f.Path, f.Path, utils.BackupSuffix)
+		case f.Status == utils.FileRestorable:
+			out.Log("Dry run: %s would be restored from %s%s", f.Path, f.Path, utils.BackupSuffix)
+		case f.Error != "":
+			action := "run 'gdrv auth login' for the profile again"
+			if f.Path == configPath {
+				action = "run 'gdrv config reset' and set the values again"
+			}
+			out.AddWarning("FILE_CORRUPT", fmt.Sprintf("%s cannot be repaired (%s); %s", f.Path, f.Error, action), "high")
+		}
+	}
+	return out.WriteSuccess("config.repair", result)
+}
+
+type ConfigRepairResult struct {
+	Files []utils.FileCheck `json:"files"`
+}
+
+func (r *ConfigRepairResult) Headers() []string {
+	return []string{"Status", "Path", "Error"}
+}
+
+func (r *ConfigRepairResult) Rows() [][]string {
+	rows := make([][]string, len(r.Files))
+	for i, f := range r.Files {
+		rows[i] = []string{f.Status, f.Path, f.Error}
+	}
+	return rows
+}
+
+func (r *ConfigRepairResult) EmptyMessage() string {
+	return "No config or credential files found"
+}
+
 // parseBool parses a boolean value from a string
 func parseBool(s string) bool {
 	s = strings.ToLower(strings.TrimSpace(s))
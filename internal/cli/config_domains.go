@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dl-alexandre/gdrv/internal/admin"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var configDomainsCmd = &cobra.Command{
+	Use:   "domains",
+	Short: "Manage internal domains",
+	Long: `Manage the domains a profile treats as internal when detecting external sharing.
+
+Entries are exact domains (example.com) or subdomain wildcards (*.example.com,
+which matches mail.example.com but not example.com itself). Permission audits,
+analyses and reports use these when --internal-domain is not given.`,
+}
+
+var configDomainsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List internal domains for the profile",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigDomainsList,
+}
+
+var configDomainsAddCmd = &cobra.Command{
+	Use:   "add <domain>...",
+	Short: "Add internal domains to the profile",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runConfigDomainsAdd,
+}
+
+var configDomainsRemoveCmd = &cobra.Command{
+	Use:   "remove <domain>...",
+	Short: "Remove internal domains from the profile",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runConfigDomainsRemove,
+}
+
+var configDomainsDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Discover Workspace domains and aliases",
+	Long: `Look up the Workspace customer's verified domains and domain aliases with the
+Admin SDK and add them to the profile's internal domains.
+
+Requires service account credentials with domain-wide delegation and the
+admin.directory.domain.readonly scope. Use --dry-run to list the domains
+without saving them.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigDomainsDiscover,
+}
+
+var (
+	domainsCustomer   string
+	domainsSubdomains bool
+)
+
+func init() {
+	configCmd.AddCommand(configDomainsCmd)
+	configDomainsCmd.AddCommand(configDomainsListCmd)
+	configDomainsCmd.AddCommand(configDomainsAddCmd)
+	configDomainsCmd.AddCommand(configDomainsRemoveCmd)
+	configDomainsCmd.AddCommand(configDomainsDiscoverCmd)
+
+	configDomainsDiscoverCmd.Flags().StringVar(&domainsCustomer, "customer", "my_customer", "Workspace customer ID")
+	configDomainsDiscoverCmd.Flags().BoolVar(&domainsSubdomains, "include-subdomains", false, "Also add a *.domain wildcard for each discovered domain")
+}
+
+func runConfigDomainsList(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return out.WriteError("config.domains.list", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	return out.WriteSuccess("config.domains.list", domainsResult(flags.Profile, cfg.Profile(flags.Profile).InternalDomains))
+}
+
+func runConfigDomainsAdd(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	for _, arg := range args {
+		if err := permissions.ValidateDomainPattern(arg); err != nil {
+			return handleError(out, "config.domains.add", err)
+		}
+	}
+	domains, err := updateInternalDomains(flags.Profile, func(current []string) []string {
+		return append(current, args...)
+	})
+	if err != nil {
+		return out.WriteError("config.domains.add", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	return out.WriteSuccess("config.domains.add", domainsResult(flags.Profile, domains))
+}
+
+func runConfigDomainsRemove(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	remove := permissions.NewDomainMatcher(args...).Patterns()
+	domains, err := updateInternalDomains(flags.Profile, func(current []string) []string {
+		var kept []string
+		for _, d := range current {
+			if !slices.Contains(remove, d) {
+				kept = append(kept, d)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return out.WriteError("config.domains.remove", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	return out.WriteSuccess("config.domains.remove", domainsResult(flags.Profile, domains))
+}
+
+func runConfigDomainsDiscover(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	svc, client, reqCtx, err := getAdminServiceWithScopes(ctx, flags, []string{utils.ScopeAdminDirectoryDomainReadonly})
+	if err != nil {
+		return handleError(out, "config.domains.discover", err)
+	}
+	discovered, err := admin.NewManager(client, svc).ListDomains(ctx, reqCtx, domainsCustomer)
+	if err != nil {
+		return handleError(out, "config.domains.discover", err)
+	}
+
+	names := discovered.Names()
+	if domainsSubdomains {
+		for _, name := range discovered.Names() {
+			names = append(names, "*."+name)
+		}
+	}
+
+	var domains []string
+	if flags.DryRun {
+		cfg, err := config.Load()
+		if err != nil {
+			return out.WriteError("config.domains.discover", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		}
+		domains = permissions.NewDomainMatcher(append(cfg.Profile(flags.Profile).InternalDomains, names...)...).Patterns()
+		out.Log("Dry run: internal domains for profile %s not saved", flags.Profile)
+	} else {
+		domains, err = updateInternalDomains(flags.Profile, func(current []string) []string {
+			return append(current, names...)
+		})
+		if err != nil {
+			return out.WriteError("config.domains.discover", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		}
+		out.Log("Saved %d internal domain(s) for profile %s", len(domains), flags.Profile)
+	}
+
+	result := domainsResult(flags.Profile, domains)
+	result["discovered"] = discovered.Domains
+	return out.WriteSuccess("config.domains.discover", result)
+}
+
+// updateInternalDomains applies fn to a profile's internal domains and
+// saves the normalized, de-duplicated result
+func updateInternalDomains(profile string, fn func([]string) []string) ([]string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	p := cfg.Profile(profile)
+	p.InternalDomains = permissions.NewDomainMatcher(fn(p.InternalDomains)...).Patterns()
+	cfg.SetProfile(profile, p)
+	if err := cfg.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save configuration: %w", err)
+	}
+	return p.InternalDomains, nil
+}
+
+// resolveInternalDomains returns the internal domains given on the command
+// line, falling back to those configured for the profile
+func resolveInternalDomains(profile string, fromFlags []string) ([]string, error) {
+	if len(fromFlags) > 0 {
+		for _, d := range fromFlags {
+			if err := permissions.ValidateDomainPattern(d); err != nil {
+				return nil, err
+			}
+		}
+		return fromFlags, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Profile(profile).InternalDomains, nil
+}
+
+func domainsResult(profile string, domains []string) map[string]interface{} {
+	if domains == nil {
+		domains = []string{}
+	}
+	return map[string]interface{}{
+		"profile":         profile,
+		"internalDomains": domains,
+	}
+}
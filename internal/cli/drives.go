@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/auth"
@@ -20,8 +21,14 @@ var drivesCmd = &cobra.Command{
 var drivesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all Shared Drives",
-	Long:  `List all Shared Drives accessible by the authenticated user.`,
-	RunE:  runDrivesList,
+	Long: `List all Shared Drives accessible by the authenticated user.
+
+With --use-domain-admin-access, a Workspace administrator lists every
+Shared Drive in the customer, including drives they are not a member of.
+--query filters drives with Drive's drive search syntax.`,
+	Example: "  gdrv drives list --paginate\n" +
+		"  gdrv drives list --use-domain-admin-access --query \"createdTime < '2024-01-01T00:00:00'\"",
+	RunE: runDrivesList,
 }
 
 var drivesGetCmd = &cobra.Command{
@@ -36,6 +43,8 @@ var (
 	drivesListPageSize  int
 	drivesListPageToken string
 	drivesListPaginate  bool
+	drivesListQuery     string
+	drivesAdminAccess   bool
 )
 
 func init() {
@@ -46,6 +55,9 @@ func init() {
 	drivesListCmd.Flags().IntVar(&drivesListPageSize, "page-size", 100, "Maximum number of drives to return per page")
 	drivesListCmd.Flags().StringVar(&drivesListPageToken, "page-token", "", "Page token for pagination")
 	drivesListCmd.Flags().BoolVar(&drivesListPaginate, "paginate", false, "Automatically fetch all pages")
+	drivesListCmd.Flags().StringVar(&drivesListQuery, "query", "", "Drive search query for drives (e.g. \"name contains 'Finance'\")")
+	drivesListCmd.Flags().BoolVar(&drivesAdminAccess, "use-domain-admin-access", false, "List every Shared Drive in the customer, as an administrator")
+	drivesGetCmd.Flags().BoolVar(&drivesAdminAccess, "use-domain-admin-access", false, "Read the drive as an administrator")
 }
 
 func runDrivesList(cmd *cobra.Command, args []string) error {
@@ -66,12 +78,18 @@ func runDrivesList(cmd *cobra.Command, args []string) error {
 	// Create request context
 	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeListOrSearch)
 
+	opts := drives.ListOptions{
+		PageSize:             drivesListPageSize,
+		PageToken:            drivesListPageToken,
+		Query:                drivesListQuery,
+		UseDomainAdminAccess: drivesAdminAccess,
+	}
+
 	// If --paginate flag is set, fetch all pages
 	if drivesListPaginate {
 		var allDrives []*drives.SharedDrive
-		pageToken := drivesListPageToken
 		for {
-			result, err := manager.List(ctx, reqCtx, drivesListPageSize, pageToken)
+			result, err := manager.List(ctx, reqCtx, opts)
 			if err != nil {
 				return handleError(writer, "drives list", err)
 			}
@@ -79,7 +97,7 @@ func runDrivesList(cmd *cobra.Command, args []string) error {
 			if result.NextPageToken == "" {
 				break
 			}
-			pageToken = result.NextPageToken
+			opts.PageToken = result.NextPageToken
 		}
 		return writer.WriteSuccess("drives list", map[string]interface{}{
 			"drives": allDrives,
@@ -87,7 +105,7 @@ func runDrivesList(cmd *cobra.Command, args []string) error {
 	}
 
 	// List drives
-	result, err := manager.List(ctx, reqCtx, drivesListPageSize, drivesListPageToken)
+	result, err := manager.List(ctx, reqCtx, opts)
 	if err != nil {
 		return handleError(writer, "drives list", err)
 	}
@@ -116,7 +134,7 @@ func runDrivesGet(cmd *cobra.Command, args []string) error {
 	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeGetByID)
 
 	// Get drive
-	result, err := manager.Get(ctx, reqCtx, driveID, "")
+	result, err := manager.Get(ctx, reqCtx, driveID, "", drivesAdminAccess)
 	if err != nil {
 		return handleError(writer, "drives get", err)
 	}
@@ -146,8 +164,10 @@ func getAPIClient(ctx context.Context, profile string) (*api.Client, error) {
 			"Failed to create Drive service: "+err.Error()).Build())
 	}
 
-	// Create API client
-	return api.NewClient(driveService, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger()), nil
+	// Create API client, with an HTTP client for batch requests
+	client := api.NewClient(driveService, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
+	client.SetHTTPClient(authMgr.GetHTTPClient(ctx, creds))
+	return client, nil
 }
 
 // handleError converts errors to CLI output
@@ -155,5 +175,10 @@ func handleError(writer *OutputWriter, command string, err error) error {
 	if appErr, ok := err.(*utils.AppError); ok {
 		return writer.WriteError(command, appErr.CLIError)
 	}
+	// An interrupt cancels the command's context, stopping the manager at
+	// the next item
+	if errors.Is(err, context.Canceled) {
+		return writer.WriteError(command, utils.NewCLIError(utils.ErrCodeCancelled, "Operation interrupted").Build())
+	}
 	return writer.WriteError(command, utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 }
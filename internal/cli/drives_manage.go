@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/drives"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var drivesCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a Shared Drive",
+	Long: `Create a Shared Drive, optionally with a theme or color and restrictions.
+
+Restriction flags take true or false; restrictions that are not given keep
+the Drive default.`,
+	Example: "  gdrv drives create \"Finance\" --domain-users-only --copy-requires-writer-permission\n" +
+		"  gdrv drives create \"Design\" --color \"#4285f4\"",
+	Args: cobra.ExactArgs(1),
+	RunE: runDrivesCreate,
+}
+
+var drivesUpdateCmd = &cobra.Command{
+	Use:   "update <drive-id>",
+	Short: "Rename a Shared Drive or change its theme and restrictions",
+	Long: `Change a Shared Drive's name, theme or color, and its restrictions:
+
+  --domain-users-only               only users in the drive's domain can access items
+  --drive-members-only              only drive members can access items
+  --copy-requires-writer-permission readers and commenters cannot copy, print or download
+
+Use --domain-users-only=false and so on to lift a restriction. With
+--use-domain-admin-access, an administrator can update drives they are
+not a member of.`,
+	Example: "  gdrv drives update <drive-id> --name \"Finance (archived)\"\n" +
+		"  gdrv drives update <drive-id> --drive-members-only --domain-users-only=false\n" +
+		"  gdrv drives update <drive-id> --use-domain-admin-access --copy-requires-writer-permission",
+	Args: cobra.ExactArgs(1),
+	RunE: runDrivesUpdate,
+}
+
+var drivesHideCmd = &cobra.Command{
+	Use:   "hide <drive-id>",
+	Short: "Hide a Shared Drive from your default view",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDrivesSetHidden,
+}
+
+var drivesUnhideCmd = &cobra.Command{
+	Use:   "unhide <drive-id>",
+	Short: "Show a hidden Shared Drive in your default view again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDrivesSetHidden,
+}
+
+var drivesDeleteCmd = &cobra.Command{
+	Use:   "delete <drive-id>",
+	Short: "Permanently delete a Shared Drive",
+	Long: `Permanently delete a Shared Drive. Drive refuses to delete a drive that
+still contains items, unless an administrator passes
+--use-domain-admin-access --allow-item-deletion, which deletes the items
+too. Deletion cannot be undone and asks for confirmation unless --force
+or --yes is given.`,
+	Example: "  gdrv drives delete <drive-id>\n" +
+		"  gdrv drives delete <drive-id> --use-domain-admin-access --allow-item-deletion --force",
+	Args: cobra.ExactArgs(1),
+	RunE: runDrivesDelete,
+}
+
+var (
+	drivesThemeID       string
+	drivesColor         string
+	drivesNewName       string
+	drivesDomainOnly    bool
+	drivesMembersOnly   bool
+	drivesCopyWriter    bool
+	drivesAllowItemsDel bool
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{drivesCreateCmd, drivesUpdateCmd} {
+		cmd.Flags().StringVar(&drivesThemeID, "theme-id", "", "Theme ID (see 'gdrv about'); sets the background and color")
+		cmd.Flags().StringVar(&drivesColor, "color", "", "Color as an RGB hex string, e.g. #4285f4")
+		cmd.Flags().BoolVar(&drivesDomainOnly, "domain-users-only", false, "Restrict access to users in the drive's domain")
+		cmd.Flags().BoolVar(&drivesMembersOnly, "drive-members-only", false, "Restrict access to drive members")
+		cmd.Flags().BoolVar(&drivesCopyWriter, "copy-requires-writer-permission", false, "Stop readers and commenters from copying, printing or downloading")
+		cmd.MarkFlagsMutuallyExclusive("theme-id", "color")
+	}
+	drivesUpdateCmd.Flags().StringVar(&drivesNewName, "name", "", "New drive name")
+	drivesUpdateCmd.Flags().BoolVar(&drivesAdminAccess, "use-domain-admin-access", false, "Update the drive as an administrator")
+	drivesDeleteCmd.Flags().BoolVar(&drivesAdminAccess, "use-domain-admin-access", false, "Delete the drive as an administrator")
+	drivesDeleteCmd.Flags().BoolVar(&drivesAllowItemsDel, "allow-item-deletion", false, "Delete the drive's items too (requires --use-domain-admin-access)")
+
+	drivesCmd.AddCommand(drivesCreateCmd)
+	drivesCmd.AddCommand(drivesUpdateCmd)
+	drivesCmd.AddCommand(drivesHideCmd)
+	drivesCmd.AddCommand(drivesUnhideCmd)
+	drivesCmd.AddCommand(drivesDeleteCmd)
+}
+
+// driveSettings collects the settings given on the command line;
+// restrictions are only set when their flag was given
+func driveSettings(cmd *cobra.Command, name string) drives.DriveSettings {
+	settings := drives.DriveSettings{Name: name, ThemeID: drivesThemeID, ColorRgb: drivesColor}
+	restriction := func(flag string, value bool) *bool {
+		if !cmd.Flags().Changed(flag) {
+			return nil
+		}
+		if settings.Restrictions == nil {
+			settings.Restrictions = &drives.TemplateRestrictions{}
+		}
+		return &value
+	}
+	domainOnly := restriction("domain-users-only", drivesDomainOnly)
+	membersOnly := restriction("drive-members-only", drivesMembersOnly)
+	copyWriter := restriction("copy-requires-writer-permission", drivesCopyWriter)
+	if settings.Restrictions != nil {
+		settings.Restrictions.DomainUsersOnly = domainOnly
+		settings.Restrictions.DriveMembersOnly = membersOnly
+		settings.Restrictions.CopyRequiresWriterPermission = copyWriter
+	}
+	return settings
+}
+
+// plannedSettings describes settings for a dry-run plan
+func plannedSettings(settings drives.DriveSettings) map[string]interface{} {
+	params := map[string]interface{}{}
+	if settings.Name != "" {
+		params["name"] = settings.Name
+	}
+	if settings.ThemeID != "" {
+		params["themeId"] = settings.ThemeID
+	}
+	if settings.ColorRgb != "" {
+		params["colorRgb"] = settings.ColorRgb
+	}
+	if settings.Restrictions != nil {
+		params["restrictions"] = settings.Restrictions
+	}
+	return params
+}
+
+func runDrivesCreate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	settings := driveSettings(cmd, args[0])
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreate,
+		Description: "Create Shared Drive: " + settings.Name,
+		Parameters:  plannedSettings(settings),
+		Predicted:   "shared drive created",
+	}) {
+		return writer.WriteSuccess("drives create", nil)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives create", err)
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeMutation)
+
+	result, err := drives.NewManager(client).Create(ctx, reqCtx, settings)
+	if err != nil {
+		if result == nil {
+			return handleError(writer, "drives create", err)
+		}
+		// The drive exists; only its restrictions could not be set
+		writer.AddWarning("RESTRICTIONS_NOT_SET",
+			fmt.Sprintf("Shared Drive %s was created but its restrictions were not set: %v", result.ID, err), "high")
+	}
+	writer.Log("Created Shared Drive: %s (%s)", result.Name, result.ID)
+	return writer.WriteSuccess("drives create", result)
+}
+
+func runDrivesUpdate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	settings := driveSettings(cmd, drivesNewName)
+	if settings.IsEmpty() {
+		return writer.WriteError("drives update", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Nothing to update: give --name, --theme-id, --color or a restriction flag").Build())
+	}
+	params := plannedSettings(settings)
+	params["useDomainAdminAccess"] = drivesAdminAccess
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  driveID,
+		Description: "Update Shared Drive: " + driveID,
+		Parameters:  params,
+		Predicted:   "shared drive updated",
+	}) {
+		return writer.WriteSuccess("drives update", nil)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives update", err)
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeMutation)
+
+	result, err := drives.NewManager(client).Update(ctx, reqCtx, driveID, settings, drivesAdminAccess)
+	if err != nil {
+		return handleError(writer, "drives update", err)
+	}
+	writer.Log("Updated Shared Drive: %s", result.Name)
+	return writer.WriteSuccess("drives update", result)
+}
+
+func runDrivesSetHidden(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	hidden := cmd.Name() == "hide"
+	command := "drives " + cmd.Name()
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  driveID,
+		Description: fmt.Sprintf("%s Shared Drive: %s", cmd.Name(), driveID),
+		Parameters:  map[string]interface{}{"hidden": hidden},
+		Predicted:   "shared drive " + cmd.Name() + "d",
+	}) {
+		return writer.WriteSuccess(command, nil)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, command, err)
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeMutation)
+
+	result, err := drives.NewManager(client).SetHidden(ctx, reqCtx, driveID, hidden)
+	if err != nil {
+		return handleError(writer, command, err)
+	}
+	return writer.WriteSuccess(command, result)
+}
+
+func runDrivesDelete(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	opts := drives.DeleteOptions{UseDomainAdminAccess: drivesAdminAccess, AllowItemDeletion: drivesAllowItemsDel}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  driveID,
+		Description: "Delete Shared Drive: " + driveID,
+		Parameters:  map[string]interface{}{"useDomainAdminAccess": opts.UseDomainAdminAccess, "allowItemDeletion": opts.AllowItemDeletion},
+		Predicted:   "shared drive permanently deleted",
+	}) {
+		return writer.WriteSuccess("drives delete", nil)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives delete", err)
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeMutation)
+	manager := drives.NewManager(client)
+
+	// Name the drive in the prompt; a drive that cannot be read fails here
+	sd, err := manager.Get(ctx, reqCtx, driveID, "id,name", opts.UseDomainAdminAccess)
+	if err != nil {
+		return handleError(writer, "drives delete", err)
+	}
+	operation := "permanently delete Shared Drive"
+	if opts.AllowItemDeletion {
+		operation += " and all its items"
+	}
+	confirmed, err := safety.ConfirmDestructive([]string{fmt.Sprintf("%s (%s)", sd.Name, sd.ID)}, operation,
+		dryRunSafety(flags).ForScope(safety.ScopeDelete))
+	if err != nil {
+		return handleError(writer, "drives delete", err)
+	}
+	if !confirmed {
+		return writer.WriteError("drives delete", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	if err := manager.Delete(ctx, reqCtx, driveID, opts); err != nil {
+		return handleError(writer, "drives delete", err)
+	}
+	writer.Log("Deleted Shared Drive: %s", sd.Name)
+	return writer.WriteSuccess("drives delete", map[string]string{"id": driveID, "name": sd.Name, "status": "deleted"})
+}
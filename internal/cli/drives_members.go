@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/drives"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var drivesMembersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Manage Shared Drive membership",
+	Long: `Manage who belongs to a Shared Drive. Members hold a drive-level role
+that applies to every item in the drive:
+
+  organizer      manage members, content and drive settings
+  fileOrganizer  add, edit, move and delete content
+  writer         add and edit content
+  commenter      comment on content
+  reader         view content
+
+Drive membership is distinct from item permissions managed with
+'gdrv permissions': removing a member does not remove grants they hold on
+individual items. With --use-domain-admin-access, an administrator can
+manage drives they are not a member of.`,
+}
+
+var drivesMembersListCmd = &cobra.Command{
+	Use:   "list <drive-id>",
+	Short: "List the members of a Shared Drive",
+	Example: "  gdrv drives members list <drive-id>\n" +
+		"  gdrv drives members list <drive-id> --use-domain-admin-access --paginate --json",
+	Args: cobra.ExactArgs(1),
+	RunE: runDrivesMembersList,
+}
+
+var drivesMembersAddCmd = &cobra.Command{
+	Use:   "add <drive-id>",
+	Short: "Add a user, group or domain to a Shared Drive",
+	Example: "  gdrv drives members add <drive-id> --email ann@example.com --role fileOrganizer\n" +
+		"  gdrv drives members add <drive-id> --email team@example.com --type group --role writer\n" +
+		"  gdrv drives members add <drive-id> --domain example.com --role reader",
+	Args: cobra.ExactArgs(1),
+	RunE: runDrivesMembersAdd,
+}
+
+var drivesMembersUpdateCmd = &cobra.Command{
+	Use:   "update <drive-id> [permission-id]",
+	Short: "Change a Shared Drive member's role",
+	Long: `Change a member's drive-level role. Name the member by permission ID
+(see 'gdrv drives members list') or with --email or --domain.`,
+	Example: "  gdrv drives members update <drive-id> --email ann@example.com --role organizer\n" +
+		"  gdrv drives members update <drive-id> <permission-id> --role reader",
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDrivesMembersUpdate,
+}
+
+var drivesMembersRemoveCmd = &cobra.Command{
+	Use:   "remove <drive-id> [permission-id]",
+	Short: "Remove a member from a Shared Drive",
+	Long: `Remove a member from a Shared Drive. Name the member by permission ID
+(see 'gdrv drives members list') or with --email or --domain. Asks for
+confirmation unless --force or --yes is given.`,
+	Example: "  gdrv drives members remove <drive-id> --email ann@example.com\n" +
+		"  gdrv drives members remove <drive-id> --domain example.com --use-domain-admin-access --force",
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDrivesMembersRemove,
+}
+
+var (
+	membersType      string
+	membersEmail     string
+	membersDomain    string
+	membersRole      string
+	membersNotify    bool
+	membersPageSize  int
+	membersPageToken string
+	membersPaginate  bool
+)
+
+func init() {
+	drivesMembersListCmd.Flags().IntVar(&membersPageSize, "page-size", 100, "Maximum number of members to return per page")
+	drivesMembersListCmd.Flags().StringVar(&membersPageToken, "page-token", "", "Page token for pagination")
+	drivesMembersListCmd.Flags().BoolVar(&membersPaginate, "paginate", false, "Automatically fetch all pages")
+
+	drivesMembersAddCmd.Flags().StringVar(&membersType, "type", "", "Member type: user, group or domain (default user with --email, domain with --domain)")
+	drivesMembersAddCmd.Flags().BoolVar(&membersNotify, "send-notification", false, "Email the new member")
+	for _, cmd := range []*cobra.Command{drivesMembersAddCmd, drivesMembersUpdateCmd, drivesMembersRemoveCmd} {
+		cmd.Flags().StringVar(&membersEmail, "email", "", "Member email address (user or group)")
+		cmd.Flags().StringVar(&membersDomain, "domain", "", "Member domain")
+		cmd.MarkFlagsMutuallyExclusive("email", "domain")
+	}
+	for _, cmd := range []*cobra.Command{drivesMembersAddCmd, drivesMembersUpdateCmd} {
+		cmd.Flags().StringVar(&membersRole, "role", "", "Drive role: organizer, fileOrganizer, writer, commenter or reader (required)")
+		_ = cmd.MarkFlagRequired("role")
+	}
+	drivesMembersAddCmd.MarkFlagsOneRequired("email", "domain")
+	for _, cmd := range []*cobra.Command{drivesMembersListCmd, drivesMembersAddCmd, drivesMembersUpdateCmd, drivesMembersRemoveCmd} {
+		cmd.Flags().BoolVar(&drivesAdminAccess, "use-domain-admin-access", false, "Act as an administrator")
+		drivesMembersCmd.AddCommand(cmd)
+	}
+	drivesCmd.AddCommand(drivesMembersCmd)
+}
+
+func runDrivesMembersList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives members list", err)
+	}
+	manager := drives.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeListOrSearch)
+
+	opts := drives.MemberListOptions{
+		PageSize:             membersPageSize,
+		PageToken:            membersPageToken,
+		UseDomainAdminAccess: drivesAdminAccess,
+	}
+	if !membersPaginate {
+		result, err := manager.ListMembers(ctx, reqCtx, driveID, opts)
+		if err != nil {
+			return handleError(writer, "drives members list", err)
+		}
+		return writer.WriteSuccess("drives members list", result)
+	}
+
+	all := &drives.MemberList{DriveID: driveID}
+	for {
+		result, err := manager.ListMembers(ctx, reqCtx, driveID, opts)
+		if err != nil {
+			return handleError(writer, "drives members list", err)
+		}
+		all.Members = append(all.Members, result.Members...)
+		if result.NextPageToken == "" {
+			break
+		}
+		opts.PageToken = result.NextPageToken
+	}
+	return writer.WriteSuccess("drives members list", all)
+}
+
+func runDrivesMembersAdd(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	opts := drives.MemberOptions{
+		Type:                  membersType,
+		EmailAddress:          membersEmail,
+		Domain:                membersDomain,
+		Role:                  membersRole,
+		SendNotificationEmail: membersNotify,
+		UseDomainAdminAccess:  drivesAdminAccess,
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreate,
+		ResourceID:  driveID,
+		Description: fmt.Sprintf("Add %s to Shared Drive %s as %s", memberPrincipal(), driveID, membersRole),
+		Parameters: map[string]interface{}{
+			"type":                  opts.Type,
+			"role":                  opts.Role,
+			"sendNotificationEmail": opts.SendNotificationEmail,
+			"useDomainAdminAccess":  opts.UseDomainAdminAccess,
+		},
+		Predicted: "drive member added",
+	}) {
+		return writer.WriteSuccess("drives members add", nil)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives members add", err)
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeMutation)
+
+	result, err := drives.NewManager(client).AddMember(ctx, reqCtx, driveID, opts)
+	if err != nil {
+		return handleError(writer, "drives members add", err)
+	}
+	writer.Log("Added %s to Shared Drive %s as %s", memberPrincipal(), driveID, result.Role)
+	return writer.WriteSuccess("drives members add", result)
+}
+
+func runDrivesMembersUpdate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives members update", err)
+	}
+	manager := drives.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeMutation)
+
+	memberID, err := resolveMemberArg(ctx, manager, reqCtx, args)
+	if err != nil {
+		return handleError(writer, "drives members update", err)
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  driveID,
+		Description: fmt.Sprintf("Change member %s of Shared Drive %s to %s", memberID, driveID, membersRole),
+		Parameters:  map[string]interface{}{"permissionId": memberID, "role": membersRole, "useDomainAdminAccess": drivesAdminAccess},
+		Predicted:   "drive member role changed",
+	}) {
+		return writer.WriteSuccess("drives members update", nil)
+	}
+
+	result, err := manager.UpdateMember(ctx, reqCtx, driveID, memberID, membersRole, drivesAdminAccess)
+	if err != nil {
+		return handleError(writer, "drives members update", err)
+	}
+	writer.Log("Changed member %s of Shared Drive %s to %s", memberID, driveID, result.Role)
+	return writer.WriteSuccess("drives members update", result)
+}
+
+func runDrivesMembersRemove(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	flags := GetGlobalFlags()
+	driveID := args[0]
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(writer, "drives members remove", err)
+	}
+	manager := drives.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeMutation)
+
+	memberID, err := resolveMemberArg(ctx, manager, reqCtx, args)
+	if err != nil {
+		return handleError(writer, "drives members remove", err)
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  driveID,
+		Description: fmt.Sprintf("Remove member %s from Shared Drive %s", memberID, driveID),
+		Parameters:  map[string]interface{}{"permissionId": memberID, "useDomainAdminAccess": drivesAdminAccess},
+		Predicted:   "drive member removed",
+	}) {
+		return writer.WriteSuccess("drives members remove", nil)
+	}
+
+	member := memberID
+	if principal := memberPrincipal(); principal != "" {
+		member = fmt.Sprintf("%s (%s)", principal, memberID)
+	}
+	confirmed, err := safety.ConfirmDestructive([]string{member}, "remove from Shared Drive "+driveID,
+		dryRunSafety(flags).ForScope(safety.ScopeDelete))
+	if err != nil {
+		return handleError(writer, "drives members remove", err)
+	}
+	if !confirmed {
+		return writer.WriteError("drives members remove", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	if err := manager.RemoveMember(ctx, reqCtx, driveID, memberID, drivesAdminAccess); err != nil {
+		return handleError(writer, "drives members remove", err)
+	}
+	writer.Log("Removed %s from Shared Drive %s", member, driveID)
+	return writer.WriteSuccess("drives members remove", map[string]string{"driveId": driveID, "permissionId": memberID, "status": "removed"})
+}
+
+// resolveMemberArg returns the permission ID given as the second argument,
+// or looks up the member named by --email or --domain
+func resolveMemberArg(ctx context.Context, manager *drives.Manager, reqCtx *types.RequestContext, args []string) (string, error) {
+	if len(args) == 2 {
+		if membersEmail != "" || membersDomain != "" {
+			return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"Give either a permission ID or --email/--domain, not both").Build())
+		}
+		return args[1], nil
+	}
+	if membersEmail == "" && membersDomain == "" {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Name the member with a permission ID, --email or --domain").Build())
+	}
+	member, err := manager.FindMember(ctx, reqCtx, args[0], membersEmail, membersDomain, drivesAdminAccess)
+	if err != nil {
+		return "", err
+	}
+	return member.ID, nil
+}
+
+func memberPrincipal() string {
+	if membersDomain != "" {
+		return membersDomain
+	}
+	return membersEmail
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/drives"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var drivesCreateFromTemplateCmd = &cobra.Command{
+	Use:   "create-from-template",
+	Short: "Provision a Shared Drive from a YAML spec",
+	Long: `Create a Shared Drive, apply its restrictions, add member groups with
+their roles, and scaffold a folder structure with labels, all from one spec.
+
+Example spec:
+
+  name: Finance
+  restrictions:
+    domainUsersOnly: true
+    driveMembersOnly: true
+    sharingFoldersRequiresOrganizerPermission: true
+  members:
+    - group: finance-leads@example.com
+      role: organizer
+    - group: finance@example.com
+      role: writer
+  folders:
+    - name: Contracts
+      labels:
+        - id: <label-id>
+          fields:
+            <field-id>: {selection: [<choice-id>]}
+      folders:
+        - name: Signed
+
+Member roles are organizer, fileOrganizer, writer, commenter and reader.
+The spec is validated before anything is created. If a later step fails,
+the drive is kept and the failed steps are reported; folders below a
+failed folder are skipped. --dry-run lists the steps without creating
+anything.`,
+	Example: "  gdrv drives create-from-template --spec team-drive.yaml\n" +
+		"  gdrv drives create-from-template --spec team-drive.yaml --name \"Finance EMEA\" --dry-run",
+	Args: cobra.NoArgs,
+	RunE: runDrivesCreateFromTemplate,
+}
+
+var (
+	drivesTemplateSpec   string
+	drivesTemplateName   string
+	drivesTemplateNotify bool
+)
+
+func init() {
+	drivesCreateFromTemplateCmd.Flags().StringVar(&drivesTemplateSpec, "spec", "", "Path to the YAML template spec")
+	drivesCreateFromTemplateCmd.Flags().StringVar(&drivesTemplateName, "name", "", "Drive name, overriding the spec")
+	drivesCreateFromTemplateCmd.Flags().BoolVar(&drivesTemplateNotify, "send-notification", true, "Email members added to the drive")
+	_ = drivesCreateFromTemplateCmd.MarkFlagRequired("spec")
+	drivesCmd.AddCommand(drivesCreateFromTemplateCmd)
+}
+
+func runDrivesCreateFromTemplate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	spec, err := drives.LoadTemplateSpec(drivesTemplateSpec)
+	if err != nil {
+		return handleError(out, "drives.create-from-template", err)
+	}
+	if drivesTemplateName != "" {
+		spec.Name = drivesTemplateName
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "drives.create-from-template", err)
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeMutation)
+	result, err := drives.NewManager(client).CreateFromTemplate(ctx, reqCtx, spec, drives.TemplateOptions{
+		DryRun:                flags.DryRun,
+		SendNotificationEmail: drivesTemplateNotify,
+	})
+	if err != nil {
+		return handleError(out, "drives.create-from-template", err)
+	}
+	if flags.DryRun {
+		for _, step := range result.Steps {
+			planTemplateStep(step)
+		}
+	}
+	if result.Failed > 0 {
+		out.AddWarning("TEMPLATE_INCOMPLETE",
+			fmt.Sprintf("%d provisioning step(s) failed; drive %s was created without them", result.Failed, result.DriveID), "high")
+	} else if !flags.DryRun {
+		out.Log("Provisioned Shared Drive %s (%s)", result.Name, result.DriveID)
+	}
+	return out.WriteSuccess("drives.create-from-template", result)
+}
+
+// planTemplateStep adds a provisioning step to the dry-run plan
+func planTemplateStep(step *drives.TemplateStep) {
+	op := safety.PlannedOperation{
+		ResourceName: step.Target,
+		Description:  step.Action + ": " + step.Target,
+		Parameters:   map[string]interface{}{"step": step.Action},
+	}
+	switch step.Action {
+	case "create-drive":
+		op.Type, op.Predicted = safety.OpTypeCreate, "Shared Drive created"
+	case "restrictions":
+		op.Type, op.Predicted = safety.OpTypeUpdate, "restrictions set"
+	case "add-member":
+		op.Type, op.Predicted = safety.OpTypeCreatePermission, "member added"
+	case "apply-label":
+		op.Type, op.Predicted = safety.OpTypeUpdate, "label applied"
+	default:
+		op.Type, op.Predicted = safety.OpTypeCreate, "folder created"
+	}
+	planOperation(op)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export Workspace files in bulk",
+}
+
+var exportOfficeCmd = &cobra.Command{
+	Use:   "office",
+	Short: "Convert a folder of Docs, Sheets and Slides to Office files",
+	Long: `Export every Google Doc, Sheet and Slides deck under a folder, recursively,
+as docx, xlsx and pptx, keeping the folder structure.
+
+--output is a directory, or a .zip archive that is written as files are
+converted; the archive appears only once complete. Other files are skipped
+unless --include-other copies them as they are. Duplicate names in a Drive
+folder get a " (n)" suffix locally.
+
+The result is a conversion report listing every file with its output path
+and status (exported, skipped or failed). Use --dry-run to see the report
+without exporting, and --force to write into existing output.`,
+	Example: "  gdrv export office --folder-id <folder-id> --output ./handover\n" +
+		"  gdrv export office --folder-id <folder-id> --output handover.zip --include-other",
+	Args: cobra.NoArgs,
+	RunE: runExportOffice,
+}
+
+var (
+	exportFolderID      string
+	exportOutput        string
+	exportIncludeOther  bool
+	exportWorkers       int
+	exportSkipPreflight bool
+	exportNoVerify      bool
+)
+
+func init() {
+	exportOfficeCmd.Flags().StringVar(&exportFolderID, "folder-id", "", "Folder to export (ID or path, required)")
+	exportOfficeCmd.Flags().StringVar(&exportOutput, "output", "", "Output directory or .zip archive (default: the folder name)")
+	exportOfficeCmd.Flags().BoolVar(&exportIncludeOther, "include-other", false, "Copy files that are not Docs, Sheets or Slides as they are")
+	exportOfficeCmd.Flags().IntVar(&exportWorkers, "workers", files.DefaultExportWorkers, "Concurrent exports")
+	exportOfficeCmd.Flags().BoolVar(&exportSkipPreflight, "skip-preflight", false, "Export even if the disk-space or path-length checks fail")
+	exportOfficeCmd.Flags().BoolVar(&exportNoVerify, "no-verify", false, "Skip checking files copied with --include-other against Drive's checksums")
+	_ = exportOfficeCmd.MarkFlagRequired("folder-id")
+
+	exportCmd.AddCommand(exportOfficeCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportOffice(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("export.office", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+	if exportWorkers < 1 || exportWorkers > 32 {
+		return out.WriteError("export.office", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--workers must be between 1 and 32").Build())
+	}
+
+	folderID, err := ResolveFileID(ctx, client, flags, exportFolderID)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("export.office", appErr.CLIError)
+		}
+		return out.WriteError("export.office", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	reqCtx.RequestType = types.RequestTypeDownloadOrExport
+	report, err := mgr.ExportOffice(ctx, reqCtx, folderID, files.OfficeExportOptions{
+		Output:        exportOutput,
+		IncludeOther:  exportIncludeOther,
+		Workers:       exportWorkers,
+		Overwrite:     flags.Force,
+		SkipPreflight: exportSkipPreflight,
+		NoVerify:      exportNoVerify,
+		DryRun:        flags.DryRun,
+	})
+	if err != nil {
+		return handleError(out, "export.office", err)
+	}
+
+	for _, item := range report.Items {
+		if item.Status == files.TreeItemFailed {
+			out.AddWarning("EXPORT_FAILED", fmt.Sprintf("%s: %s", item.Name, item.Error), "medium")
+		}
+	}
+	if report.DryRun {
+		out.Log("Would convert %d files into %s", len(report.Items)-report.Skipped, report.Output)
+	} else {
+		out.Log("Converted %d, copied %d, skipped %d, failed %d into %s",
+			report.Converted, report.Copied, report.Skipped, report.Failed, report.Output)
+	}
+	return out.WriteSuccess("export.office", report)
+}
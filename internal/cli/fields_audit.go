@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/fieldmask"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// fieldsAuditRecorder is set when --fields-audit is given
+var fieldsAuditRecorder *fieldmask.Recorder
+
+func startFieldsAudit() {
+	fieldsAuditRecorder = fieldmask.NewRecorder()
+	api.SetFieldsRecorder(fieldsAuditRecorder)
+}
+
+// recordFieldsAudit compares the fields returned to this command with its
+// output, saves the result, and reports the suggested mask as a warning
+func (w *OutputWriter) recordFieldsAudit(command string, data interface{}) {
+	if fieldsAuditRecorder == nil {
+		return
+	}
+	entry := fieldsAuditRecorder.Audit(command, data)
+	if entry.Calls == 0 {
+		return
+	}
+
+	saved := ""
+	if store, err := fieldmask.DefaultStore(); err == nil {
+		if merged, err := store.Merge(entry); err == nil {
+			entry = merged
+			saved = " (saved to " + store.Path() + ")"
+		}
+	}
+	message := fmt.Sprintf("%s used %d of %d returned fields; suggested field mask: %s%s",
+		command, len(entry.Used), len(entry.Returned), entry.SuggestedMask, saved)
+	w.AddWarning("FIELDS_AUDIT", message, "low")
+	if w.format != types.OutputFormatJSON {
+		w.Log("%s", message)
+	}
+}
+
+// learnedFileFields returns fields, or with --learned-fields the per-file
+// mask learned by previous --fields-audit runs. The learned mask only holds
+// fields that were non-empty in those runs, so it is never applied unasked.
+// Learned masks are ignored while auditing, so the audit sees the default
+// response.
+func learnedFileFields(command, fields string, learned bool) string {
+	if !learned || fields != "" || fieldsAuditRecorder != nil {
+		return fields
+	}
+	store, err := fieldmask.DefaultStore()
+	if err != nil {
+		return ""
+	}
+	return store.LearnedMask(command, "files")
+}
@@ -2,14 +2,23 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/drives"
+	"github.com/dl-alexandre/gdrv/internal/encryption"
 	"github.com/dl-alexandre/gdrv/internal/export"
 	"github.com/dl-alexandre/gdrv/internal/files"
 	"github.com/dl-alexandre/gdrv/internal/revisions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/spool"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -34,16 +43,72 @@ var filesGetCmd = &cobra.Command{
 
 var filesUploadCmd = &cobra.Command{
 	Use:   "upload <local-path>",
-	Short: "Upload a file",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runFilesUpload,
+	Short: "Upload a file or directory",
+	Long: `Upload a file or directory.
+
+With --encrypt the contents are encrypted client-side with AES-256-GCM
+before upload, so Drive only stores ciphertext. The file is stored as
+<name>.enc and its original name, type and size are kept in appProperties.
+The key file holds a 32-byte key, raw or as hex or base64; create one with
+"openssl rand -base64 32 > drive.key" and keep it safe, since files cannot
+be recovered without it.
+
+With --split, files larger than the given size are uploaded as parts into
+a "<name>.split" folder, with a manifest of part checksums. Re-running an
+interrupted split upload reuses the parts already in Drive. Downloading the
+folder or its manifest reassembles and verifies the original file.
+
+With --recursive, a directory is uploaded into a folder of the same name
+(or --name), creating subfolders to mirror its structure. Folders that
+already exist under the same parent are reused. --include uploads only
+files matching one of its globs; --exclude skips matching files and
+directories, on top of the defaults (.git/, .env, *.key and similar).
+Globs match the path relative to the directory or the file name.
+
+--convert-to document, spreadsheet or presentation imports the file as a
+Google Doc, Sheet or Slides deck. The file's type (from --mime-type, its
+extension or its content) must be one Drive can convert to that editor,
+as listed by 'gdrv about formats'; otherwise the upload fails with
+UNSUPPORTED_CONVERSION before anything is sent.
+
+Files larger than 5 MB are sent in chunks (--chunk-size) over a resumable
+upload session, with progress, rate and time remaining on stderr. The
+session is saved to a file until the upload completes; if the upload is
+interrupted, --resume <session-file> sends only the remaining bytes.
+Drive expires sessions after about a week.`,
+	Example: "  gdrv files upload backup.tar --parent <folder-id> --encrypt --key-file drive.key\n" +
+		"  gdrv files upload dataset.bin --parent <folder-id> --split 100G\n" +
+		"  gdrv files upload report.docx --convert-to document\n" +
+		"  gdrv files upload ./mydir --recursive --parent <folder-id> --exclude '*.tmp'\n" +
+		"  gdrv files upload --resume ~/.config/gdrv/uploads/disk.img-1a2b3c4d.json",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if filesResume != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runFilesUpload,
 }
 
 var filesDownloadCmd = &cobra.Command{
 	Use:   "download <file-id>",
 	Short: "Download a file",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runFilesDownload,
+	Long: `Download a file.
+
+Files uploaded with --encrypt are decrypted transparently when --key-file
+is given, and saved under their original name.
+
+--output - writes the content to stdout instead, as 'gdrv files cat' does.
+
+With --recursive, --changes-token makes a folder download incremental, for
+nightly backups of large trees: the first run downloads everything and saves
+a change token in the output directory, and later runs ask the Changes API
+what changed since then instead of listing the whole tree. Renamed and moved
+files are moved locally; files deleted in Drive are reported and kept.`,
+	Example: "  gdrv files download <file-id> --key-file drive.key\n" +
+		"  gdrv files download <folder-id> --recursive --output ./backup --changes-token",
+	Args: cobra.ExactArgs(1),
+	RunE: runFilesDownload,
 }
 
 var filesDeleteCmd = &cobra.Command{
@@ -81,6 +146,20 @@ var filesRestoreCmd = &cobra.Command{
 	RunE:  runFilesRestore,
 }
 
+var filesStarCmd = &cobra.Command{
+	Use:   "star <file-id>",
+	Short: "Star a file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runFilesStar,
+}
+
+var filesUnstarCmd = &cobra.Command{
+	Use:   "unstar <file-id>",
+	Short: "Remove the star from a file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runFilesUnstar,
+}
+
 var filesRevisionsCmd = &cobra.Command{
 	Use:   "revisions <file-id>",
 	Short: "List file revisions",
@@ -89,14 +168,14 @@ var filesRevisionsCmd = &cobra.Command{
 }
 
 var filesRevisionsDownloadCmd = &cobra.Command{
-	Use:   "revisions download <file-id> <revision-id>",
+	Use:   "download <file-id> <revision-id>",
 	Short: "Download a specific revision",
 	Args:  cobra.ExactArgs(2),
 	RunE:  runFilesRevisionsDownload,
 }
 
 var filesRevisionsRestoreCmd = &cobra.Command{
-	Use:   "revisions restore <file-id> <revision-id>",
+	Use:   "restore <file-id> <revision-id>",
 	Short: "Restore file to a specific revision",
 	Args:  cobra.ExactArgs(2),
 	RunE:  runFilesRevisionsRestore,
@@ -108,6 +187,44 @@ var filesListTrashedCmd = &cobra.Command{
 	RunE:  runFilesListTrashed,
 }
 
+var filesSharedWithMeCmd = &cobra.Command{
+	Use:   "shared-with-me",
+	Short: "Triage shared-with-me items",
+	Long: `List items shared with you, with owner, share time, and last view.
+
+Use --stale and --from-domains to narrow the list, and --remove to remove the
+matching items from your "Shared with me" view. Owners keep their files.`,
+	Example: "  gdrv files shared-with-me --stale 180d --from-domains ext.com\n  gdrv files shared-with-me --stale 365d --remove --dry-run",
+	RunE:    runFilesSharedWithMe,
+}
+
+var filesOwnersReportCmd = &cobra.Command{
+	Use:   "owners-report",
+	Short: "Break down folder contents by owner",
+	Long:  "Aggregate file counts and bytes per owner within a folder, e.g. to find whose consent or ownership transfer is needed before a Shared Drive migration.",
+	RunE:  runFilesOwnersReport,
+}
+
+var filesFindCorruptCmd = &cobra.Command{
+	Use:   "find-corrupt",
+	Short: "Find files left broken by failed uploads",
+	Long: `Find files left broken by failed uploads or sync runs: binary files
+with no content, and Google Docs, Sheets and Slides that export no text
+(Sheets are checked by their first sheet). With --manifest, files whose MD5
+differs from the manifest, or that are missing from Drive, are reported too.
+The manifest is md5sum output with paths relative to the folder, e.g. from
+"cd ./photos && find . -type f -exec md5sum {} + > ../photos.md5".
+
+--reupload-from replaces the reported files with the copies at the same
+relative paths in a local directory. Replaced files keep their IDs, sharing
+and revision history; missing files are uploaded into their folder. A local
+copy is only used if it matches the manifest, or, without a manifest, if it
+is not empty.`,
+	Example: "  gdrv files find-corrupt --folder-id <folder-id> --recursive\n" +
+		"  gdrv files find-corrupt --folder-id <folder-id> --recursive --manifest photos.md5 --reupload-from ./photos --dry-run",
+	RunE: runFilesFindCorrupt,
+}
+
 var filesExportFormatsCmd = &cobra.Command{
 	Use:   "export-formats <file-id>",
 	Short: "Show available export formats for a file",
@@ -115,24 +232,109 @@ var filesExportFormatsCmd = &cobra.Command{
 	RunE:  runFilesExportFormats,
 }
 
+var filesUpdateCmd = &cobra.Command{
+	Use:     "update <file-id>",
+	Short:   "Update file metadata",
+	Long:    "Rename a file or set its description. Pass --description \"\" to clear the description.",
+	Example: "  gdrv files update <file-id> --description \"Q3 board deck, final\"",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runFilesUpdate,
+}
+
+var filesSearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search files by name, description, and properties",
+	Long: `Find files by annotation. All given criteria must match.
+
+Drive cannot query descriptions directly, so --description-contains runs a
+full-text search and then matches descriptions locally.
+
+--everywhere searches My Drive (files you own), every Shared Drive you can
+access and Shared with me in parallel, and merges the results. Each file is
+listed once with the corpus it was found in; "alsoIn" names any other
+corpus that matched it. A corpus that cannot be searched is reported and
+does not stop the others.`,
+	Example: "  gdrv files search --description-contains invoice\n  gdrv files search --property project=apollo --property status=final\n  gdrv files search --name-contains \"Q3 budget\" --everywhere",
+	RunE:    runFilesSearch,
+}
+
+var filesPropertiesCmd = &cobra.Command{
+	Use:   "properties",
+	Short: "Manage file properties",
+	Long:  "Read and write key/value annotations stored in a file's appProperties, which are private to gdrv's OAuth client.",
+}
+
+var filesPropertiesGetCmd = &cobra.Command{
+	Use:   "get <file-id> [key]",
+	Short: "Show a file's properties",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runFilesPropertiesGet,
+}
+
+var filesPropertiesSetCmd = &cobra.Command{
+	Use:     "set <file-id> <key=value>...",
+	Short:   "Set file properties",
+	Example: "  gdrv files properties set <file-id> project=apollo status=final",
+	Args:    cobra.MinimumNArgs(2),
+	RunE:    runFilesPropertiesSet,
+}
+
+var filesPropertiesDeleteCmd = &cobra.Command{
+	Use:   "delete <file-id> <key>...",
+	Short: "Delete file properties",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runFilesPropertiesDelete,
+}
+
 // Command flags
 var (
 	filesParentID       string
 	filesQuery          string
 	filesLimit          int
+	filesEverywhere     bool
 	filesPageToken      string
 	filesOrderBy        string
 	filesIncludeTrashed bool
+	filesStarred        bool
+	filesNoVerify       bool
 	filesFields         string
+	filesLearnedFields  bool
 	filesGetFields      string
 	filesName           string
 	filesMimeType       string
+	filesChunkSize      string
+	filesManifest       string
+	filesSkipStubs      bool
+	filesReuploadFrom   string
+	filesResume         string
+	filesSessionFile    string
 	filesOutput         string
 	filesPermanent      bool
 	filesForce          bool
 	filesDownloadDoc    bool
 	filesRevisionOutput string
 	filesPaginate       bool
+	filesRecursive      bool
+	filesExportWorkers  int
+	filesSkipPreflight  bool
+	filesChangesToken   string
+	filesConvert        bool
+	filesConvertTo      string
+	filesFormat         string
+	filesStale          string
+	filesFromDomains    []string
+	filesRemove         bool
+	filesFolderID       string
+	filesDescription    string
+	filesNameContains   string
+	filesDescContains   string
+	filesProperties     []string
+	filesEncrypt        bool
+	filesKeyFile        string
+	filesSplit          string
+	filesInclude        []string
+	filesExclude        []string
+	filesUploadWorkers  int
 )
 
 func init() {
@@ -143,7 +345,10 @@ func init() {
 	filesListCmd.Flags().StringVar(&filesPageToken, "page-token", "", "Page token for pagination")
 	filesListCmd.Flags().StringVar(&filesOrderBy, "order-by", "", "Sort order")
 	filesListCmd.Flags().BoolVar(&filesIncludeTrashed, "include-trashed", false, "Include trashed files")
+	filesListCmd.Flags().BoolVar(&filesStarred, "starred", false, "Only list starred files")
 	filesListCmd.Flags().StringVar(&filesFields, "fields", "", "Fields to return")
+	filesListCmd.Flags().BoolVar(&filesLearnedFields, "learned-fields", false, "Request only the fields a previous --fields-audit saw this command use")
+	filesListCmd.MarkFlagsMutuallyExclusive("fields", "learned-fields")
 	filesListCmd.Flags().BoolVar(&filesPaginate, "paginate", false, "Automatically fetch all pages")
 
 	// Get flags
@@ -152,13 +357,49 @@ func init() {
 	// Upload flags
 	filesUploadCmd.Flags().StringVar(&filesParentID, "parent", "", "Parent folder ID")
 	filesUploadCmd.Flags().StringVar(&filesName, "name", "", "File name")
-	filesUploadCmd.Flags().StringVar(&filesMimeType, "mime-type", "", "MIME type")
+	filesUploadCmd.Flags().StringVar(&filesMimeType, "mime-type", "", "MIME type (with --convert, the Workspace type to convert to)")
+	filesUploadCmd.Flags().BoolVar(&filesConvert, "convert", false, "Convert to a Google Workspace format (see 'about formats')")
+	filesUploadCmd.Flags().StringVar(&filesConvertTo, "convert-to", "", "Convert to a Google editor: document, spreadsheet or presentation")
+	filesUploadCmd.Flags().StringVar(&filesDescription, "description", "", "File description")
+	filesUploadCmd.Flags().StringVar(&filesChunkSize, "chunk-size", "", "Resumable upload chunk size, rounded to 256K (e.g. 32M; default 8M)")
+	filesUploadCmd.Flags().StringVar(&filesResume, "resume", "", "Resume an interrupted upload from its session file")
+	filesUploadCmd.Flags().StringVar(&filesSessionFile, "session-file", "", "Where to save the resumable upload session (default: in the config directory)")
+	filesUploadCmd.Flags().BoolVar(&filesEncrypt, "encrypt", false, "Encrypt the file client-side before upload (requires --key-file)")
+	filesUploadCmd.Flags().StringVar(&filesKeyFile, "key-file", "", "Encryption key file (32 bytes, raw, hex or base64)")
+	filesUploadCmd.Flags().BoolVar(&filesNoVerify, "no-verify", false, "Skip checking Drive's checksum against the local file")
+	filesUploadCmd.MarkFlagsRequiredTogether("encrypt", "key-file")
+	filesUploadCmd.Flags().StringVar(&filesSplit, "split", "", "Upload files larger than this size as parts plus a manifest (e.g. 100G)")
+	filesUploadCmd.MarkFlagsMutuallyExclusive("encrypt", "convert")
+	filesUploadCmd.MarkFlagsMutuallyExclusive("split", "convert")
+	filesUploadCmd.MarkFlagsMutuallyExclusive("encrypt", "convert-to")
+	filesUploadCmd.MarkFlagsMutuallyExclusive("split", "convert-to")
+	filesUploadCmd.MarkFlagsMutuallyExclusive("convert", "convert-to")
+	filesUploadCmd.MarkFlagsMutuallyExclusive("split", "encrypt")
+	filesUploadCmd.Flags().BoolVar(&filesRecursive, "recursive", false, "Upload a directory and all of its contents")
+	filesUploadCmd.Flags().StringArrayVar(&filesInclude, "include", nil, "With --recursive, only upload files matching this glob (repeatable)")
+	filesUploadCmd.Flags().StringArrayVar(&filesExclude, "exclude", nil, "With --recursive, skip files and directories matching this glob (repeatable)")
+	filesUploadCmd.Flags().IntVar(&filesUploadWorkers, "workers", files.DefaultUploadWorkers, "Concurrent uploads for recursive uploads")
+	for _, flag := range []string{"encrypt", "split", "convert", "convert-to", "mime-type", "description"} {
+		filesUploadCmd.MarkFlagsMutuallyExclusive("recursive", flag)
+	}
+	// A resumed upload takes everything from its session file
+	for _, flag := range []string{"parent", "name", "mime-type", "convert", "convert-to", "description", "chunk-size", "encrypt", "split", "recursive", "session-file"} {
+		filesUploadCmd.MarkFlagsMutuallyExclusive("resume", flag)
+	}
 
 	// Download flags
-	filesDownloadCmd.Flags().StringVar(&filesOutput, "output", "", "Output path")
-	filesDownloadCmd.Flags().StringVar(&filesMimeType, "mime-type", "", "Export MIME type")
+	filesDownloadCmd.Flags().StringVar(&filesOutput, "output", "", "Output path, or - to write the content to stdout")
+	filesDownloadCmd.Flags().StringVar(&filesMimeType, "mime-type", "", "Export MIME type (default: the exportDefaults config, else the built-in format)")
+	filesDownloadCmd.Flags().StringVar(&filesFormat, "format", "", "Export format shorthand or MIME type (e.g. pdf, docx, xlsx)")
 	filesDownloadCmd.Flags().BoolVar(&filesDownloadDoc, "doc", false, "Export Google Docs as plain text")
 	filesDownloadCmd.Flags().BoolVar(&filesDownloadDoc, "doc-text", false, "Export Google Docs as plain text")
+	filesDownloadCmd.Flags().BoolVar(&filesRecursive, "recursive", false, "Download a folder and all of its contents")
+	filesDownloadCmd.Flags().IntVar(&filesExportWorkers, "export-workers", files.DefaultExportWorkers, "Concurrent Workspace exports for recursive downloads")
+	filesDownloadCmd.Flags().BoolVar(&filesSkipPreflight, "skip-preflight", false, "Download a folder even if the disk-space or path-length check fails")
+	filesDownloadCmd.Flags().StringVar(&filesChangesToken, "changes-token", "", "With --recursive, only download what changed since this Changes API token (no value: since the last run)")
+	filesDownloadCmd.Flags().Lookup("changes-token").NoOptDefVal = files.ChangesTokenAuto
+	filesDownloadCmd.Flags().StringVar(&filesKeyFile, "key-file", "", "Key for files uploaded with --encrypt")
+	filesDownloadCmd.Flags().BoolVar(&filesNoVerify, "no-verify", false, "Skip checking the download against Drive's checksum")
 
 	// Delete flags
 	filesDeleteCmd.Flags().BoolVar(&filesPermanent, "permanent", false, "Permanently delete")
@@ -176,6 +417,43 @@ func init() {
 	filesRevisionsDownloadCmd.Flags().StringVar(&filesRevisionOutput, "output", "", "Output path for revision download")
 	_ = filesRevisionsDownloadCmd.MarkFlagRequired("output")
 
+	// Shared-with-me flags
+	filesSharedWithMeCmd.Flags().StringVar(&filesStale, "stale", "", "Only items not viewed within this age (e.g. 180d, 12w)")
+	filesSharedWithMeCmd.Flags().StringSliceVar(&filesFromDomains, "from-domains", nil, "Only items owned by users in these domains")
+	filesSharedWithMeCmd.Flags().BoolVar(&filesRemove, "remove", false, "Remove matching items from your Shared with me list")
+	// Removing items from the list needs write access, which listing does not
+	_ = filesSharedWithMeCmd.Flags().SetAnnotation("remove", familyAnnotation, []string{string(auth.FamilyWrite)})
+
+	// Owners report flags
+	filesOwnersReportCmd.Flags().StringVar(&filesFolderID, "folder-id", "", "Folder to report on (required)")
+	filesOwnersReportCmd.Flags().BoolVar(&filesRecursive, "recursive", false, "Include subfolders")
+	_ = filesOwnersReportCmd.MarkFlagRequired("folder-id")
+
+	filesFindCorruptCmd.Flags().StringVar(&filesFolderID, "folder-id", "", "Folder to check (required)")
+	filesFindCorruptCmd.Flags().BoolVar(&filesRecursive, "recursive", false, "Include subfolders")
+	filesFindCorruptCmd.Flags().StringVar(&filesManifest, "manifest", "", "md5sum-format manifest of expected checksums")
+	filesFindCorruptCmd.Flags().BoolVar(&filesSkipStubs, "skip-stubs", false, "Do not export Workspace files to look for empty stubs")
+	filesFindCorruptCmd.Flags().StringVar(&filesReuploadFrom, "reupload-from", "", "Replace corrupt files with the copies in this local directory")
+	// Finding corrupt files only reads; replacing them needs write access
+	_ = filesFindCorruptCmd.Flags().SetAnnotation("reupload-from", familyAnnotation, []string{string(auth.FamilyWrite)})
+	_ = filesFindCorruptCmd.MarkFlagRequired("folder-id")
+
+	// Update flags
+	filesUpdateCmd.Flags().StringVar(&filesName, "name", "", "New file name")
+	filesUpdateCmd.Flags().StringVar(&filesDescription, "description", "", "File description")
+
+	// Search flags
+	filesSearchCmd.Flags().StringVar(&filesParentID, "parent", "", "Limit search to a folder")
+	filesSearchCmd.Flags().StringVar(&filesNameContains, "name-contains", "", "Name contains text")
+	filesSearchCmd.Flags().StringVar(&filesDescContains, "description-contains", "", "Description contains text (case-insensitive)")
+	filesSearchCmd.Flags().StringArrayVar(&filesProperties, "property", nil, "Property key=value that must be set (repeatable)")
+	filesSearchCmd.Flags().IntVar(&filesLimit, "limit", 0, "Maximum files to return (0 = all)")
+	filesSearchCmd.Flags().BoolVar(&filesEverywhere, "everywhere", false, "Search My Drive, all Shared Drives and Shared with me")
+
+	filesPropertiesCmd.AddCommand(filesPropertiesGetCmd)
+	filesPropertiesCmd.AddCommand(filesPropertiesSetCmd)
+	filesPropertiesCmd.AddCommand(filesPropertiesDeleteCmd)
+
 	filesRevisionsCmd.AddCommand(filesRevisionsDownloadCmd)
 	filesRevisionsCmd.AddCommand(filesRevisionsRestoreCmd)
 
@@ -185,6 +463,8 @@ func init() {
 	filesListTrashedCmd.Flags().StringVar(&filesPageToken, "page-token", "", "Page token for pagination")
 	filesListTrashedCmd.Flags().StringVar(&filesOrderBy, "order-by", "", "Sort order")
 	filesListTrashedCmd.Flags().StringVar(&filesFields, "fields", "", "Fields to return")
+	filesListTrashedCmd.Flags().BoolVar(&filesLearnedFields, "learned-fields", false, "Request only the fields a previous --fields-audit saw this command use")
+	filesListTrashedCmd.MarkFlagsMutuallyExclusive("fields", "learned-fields")
 	filesListTrashedCmd.Flags().BoolVar(&filesPaginate, "paginate", false, "Automatically fetch all pages")
 
 	filesCmd.AddCommand(filesListCmd)
@@ -196,9 +476,17 @@ func init() {
 	filesCmd.AddCommand(filesMoveCmd)
 	filesCmd.AddCommand(filesTrashCmd)
 	filesCmd.AddCommand(filesRestoreCmd)
+	filesCmd.AddCommand(filesStarCmd)
+	filesCmd.AddCommand(filesUnstarCmd)
 	filesCmd.AddCommand(filesRevisionsCmd)
 	filesCmd.AddCommand(filesListTrashedCmd)
 	filesCmd.AddCommand(filesExportFormatsCmd)
+	filesCmd.AddCommand(filesSharedWithMeCmd)
+	filesCmd.AddCommand(filesOwnersReportCmd)
+	filesCmd.AddCommand(filesFindCorruptCmd)
+	filesCmd.AddCommand(filesUpdateCmd)
+	filesCmd.AddCommand(filesSearchCmd)
+	filesCmd.AddCommand(filesPropertiesCmd)
 	rootCmd.AddCommand(filesCmd)
 }
 
@@ -218,12 +506,26 @@ func getFileManager(ctx context.Context, flags types.GlobalFlags) (*files.Manage
 	}
 
 	client := api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
+	client.SetHTTPClient(authMgr.GetHTTPClient(ctx, creds))
 	mgr := files.NewManager(client)
+	mgr.SetExportDefaults(configExportDefaults())
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
 
 	return mgr, client, reqCtx, out, nil
 }
 
+// configExportDefaults returns the exportDefaults config as export formats
+// by Workspace MIME type, or nil when there is no usable config
+func configExportDefaults() map[string]string {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+	// Load has already validated them
+	formats, _ := cfg.ExportFormats()
+	return formats
+}
+
 func runFilesList(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	ctx := context.Background()
@@ -236,7 +538,7 @@ func runFilesList(cmd *cobra.Command, args []string) error {
 	// Resolve parent path if provided
 	parentID := filesParentID
 	if parentID != "" {
-		resolvedID, err := ResolveFileID(ctx, client, flags, parentID)
+		resolvedID, driveID, err := ResolveLocation(ctx, client, flags, parentID)
 		if err != nil {
 			if appErr, ok := err.(*utils.AppError); ok {
 				return out.WriteError("files.list", appErr.CLIError)
@@ -244,6 +546,7 @@ func runFilesList(cmd *cobra.Command, args []string) error {
 			return out.WriteError("files.list", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
 		}
 		parentID = resolvedID
+		reqCtx.DriveID = driveID
 	}
 
 	opts := files.ListOptions{
@@ -253,18 +556,20 @@ func runFilesList(cmd *cobra.Command, args []string) error {
 		PageToken:      filesPageToken,
 		OrderBy:        filesOrderBy,
 		IncludeTrashed: filesIncludeTrashed,
-		Fields:         filesFields,
+		Starred:        filesStarred,
+		Fields:         learnedFileFields("files.list", filesFields, filesLearnedFields),
 	}
 
 	// If --paginate flag is set, fetch all pages
 	if filesPaginate {
-		allFiles, err := mgr.ListAll(ctx, reqCtx, opts)
+		allFiles, err := listAllSpooled(ctx, mgr, reqCtx, opts, flags, out)
 		if err != nil {
 			if appErr, ok := err.(*utils.AppError); ok {
 				return out.WriteError("files.list", appErr.CLIError)
 			}
 			return out.WriteError("files.list", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 		}
+		defer allFiles.Close()
 		// Return result without nextPageToken (all pages fetched)
 		return out.WriteSuccess("files.list", map[string]interface{}{
 			"files": allFiles,
@@ -282,6 +587,22 @@ func runFilesList(cmd *cobra.Command, args []string) error {
 	return out.WriteSuccess("files.list", result)
 }
 
+// listAllSpooled fetches every page of a listing, keeping at most
+// --max-memory-results files in memory. The caller must Close the result.
+func listAllSpooled(ctx context.Context, mgr *files.Manager, reqCtx *types.RequestContext, opts files.ListOptions, flags types.GlobalFlags, out *OutputWriter) (*spool.List[*types.DriveFile], error) {
+	allFiles := spool.New[*types.DriveFile](flags.MaxMemoryResults)
+	if err := mgr.ListEach(ctx, reqCtx, opts, allFiles.Add); err != nil {
+		_ = allFiles.Close()
+		return nil, err
+	}
+	if allFiles.Spilled() {
+		out.AddWarning("RESULTS_SPOOLED", fmt.Sprintf(
+			"%d files exceeded --max-memory-results %d; the rest were spooled to a temp file and streamed",
+			allFiles.Len(), flags.MaxMemoryResults), "info")
+	}
+	return allFiles, nil
+}
+
 func runFilesGet(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	ctx := context.Background()
@@ -302,6 +623,12 @@ func runFilesGet(cmd *cobra.Command, args []string) error {
 
 	reqCtx.RequestType = types.RequestTypeGetByID
 	file, err := mgr.Get(ctx, reqCtx, fileID, filesGetFields)
+	if err == nil && !flags.NoFollowShortcuts && file.MimeType == utils.MimeTypeShortcut {
+		// A shortcut given by ID; paths are followed when resolved
+		if file, err = mgr.FollowShortcut(ctx, reqCtx, file, filesGetFields); err == nil {
+			out.Log("Followed shortcut %s to %s", fileID, file.ID)
+		}
+	}
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("files.get", appErr.CLIError)
@@ -314,13 +641,18 @@ func runFilesGet(cmd *cobra.Command, args []string) error {
 
 func runFilesUpload(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
-	ctx := context.Background()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
 
 	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
 	if err != nil {
 		return out.WriteError("files.upload", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
 	}
 
+	if filesResume != "" {
+		return runFilesUploadResume(ctx, mgr, reqCtx, out, flags)
+	}
+
 	// Resolve parent path if provided
 	parentID := filesParentID
 	if parentID != "" {
@@ -334,13 +666,101 @@ func runFilesUpload(cmd *cobra.Command, args []string) error {
 		parentID = resolvedID
 	}
 
+	var chunkSize int64
+	if filesChunkSize != "" {
+		chunkSize, err = utils.ParseSize(filesChunkSize)
+		if err != nil {
+			return out.WriteError("files.upload", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+	}
+
+	convertTo := ""
+	if filesConvertTo != "" {
+		if convertTo, err = export.ParseConversionTarget(filesConvertTo); err != nil {
+			return handleError(out, "files.upload", err)
+		}
+	}
+
+	if filesRecursive {
+		return runFilesUploadTree(ctx, mgr, reqCtx, out, args[0], parentID, chunkSize, flags.DryRun)
+	}
+	if stat, err := os.Stat(args[0]); err == nil && stat.IsDir() {
+		return out.WriteError("files.upload", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("'%s' is a directory; use --recursive to upload it", args[0])).Build())
+	}
+
+	if planOperation(safety.PlannedOperation{
+		Type:         safety.OpTypeUpload,
+		ResourceName: args[0],
+		Description:  "Upload: " + args[0],
+		Parameters:   map[string]interface{}{"parentId": parentID, "name": filesName, "split": filesSplit, "convertTo": convertTo},
+		Predicted:    "file created",
+	}) {
+		return out.WriteSuccess("files.upload", nil)
+	}
+
+	if filesSplit != "" {
+		splitSize, err := utils.ParseSize(filesSplit)
+		if err != nil {
+			return out.WriteError("files.upload", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		stat, err := os.Stat(args[0])
+		if err != nil {
+			return out.WriteError("files.upload", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		if stat.Size() > splitSize {
+			reqCtx.RequestType = types.RequestTypeMutation
+			result, err := mgr.UploadSplit(ctx, reqCtx, args[0], splitSize, files.UploadOptions{
+				ParentID:  parentID,
+				Name:      filesName,
+				ChunkSize: chunkSize,
+			})
+			if err != nil {
+				return handleError(out, "files.upload", err)
+			}
+			out.Log("Uploaded %s as %d part(s) (%d reused) in %s", result.Name, result.Parts, result.Reused, result.FolderName)
+			return out.WriteSuccess("files.upload", result)
+		}
+		out.Log("%s is not larger than %s; uploading it whole", args[0], filesSplit)
+	}
+
+	var key *encryption.Key
+	if filesEncrypt {
+		key, err = encryption.LoadKey(filesKeyFile)
+		if err != nil {
+			return out.WriteError("files.upload", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+	}
+
+	sessionFile := ""
+	if stat, err := os.Stat(args[0]); err == nil && stat.Size() > int64(utils.UploadSimpleMaxBytes) && key == nil {
+		sessionFile = filesSessionFile
+		if sessionFile == "" {
+			sessionFile = defaultUploadSessionFile(args[0])
+		}
+		out.Log("Upload session: %s (if interrupted, resume with --resume)", sessionFile)
+	}
+
 	reqCtx.RequestType = types.RequestTypeMutation
 	file, err := mgr.Upload(ctx, reqCtx, args[0], files.UploadOptions{
-		ParentID: parentID,
-		Name:     filesName,
-		MimeType: filesMimeType,
+		ParentID:    parentID,
+		Name:        filesName,
+		MimeType:    filesMimeType,
+		Convert:     filesConvert,
+		ConvertTo:   convertTo,
+		Description: filesDescription,
+		ChunkSize:   chunkSize,
+		Encryption:  key,
+		SessionFile: sessionFile,
+		Progress:    newUploadProgress(flags.Quiet),
+		NoVerify:    filesNoVerify,
 	})
 	if err != nil {
+		if sessionFile != "" {
+			if _, statErr := os.Stat(sessionFile); statErr == nil {
+				out.Log("Upload interrupted; resume with: gdrv files upload --resume %s", sessionFile)
+			}
+		}
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("files.upload", appErr.CLIError)
 		}
@@ -351,11 +771,114 @@ func runFilesUpload(cmd *cobra.Command, args []string) error {
 	return out.WriteSuccess("files.upload", file)
 }
 
+func runFilesUploadResume(ctx context.Context, mgr *files.Manager, reqCtx *types.RequestContext, out *OutputWriter, flags types.GlobalFlags) error {
+	session, err := files.LoadUploadSession(filesResume)
+	if err != nil {
+		return handleError(out, "files.upload", err)
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:         safety.OpTypeUpload,
+		ResourceName: session.LocalPath,
+		Description:  "Resume upload: " + session.LocalPath,
+		Parameters:   map[string]interface{}{"parentId": session.ParentID, "name": session.Name, "sessionFile": filesResume},
+		Predicted:    "file created",
+	}) {
+		return out.WriteSuccess("files.upload", nil)
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	file, err := mgr.ResumeUpload(ctx, reqCtx, filesResume, files.ResumeOptions{
+		Progress: newUploadProgress(flags.Quiet),
+		NoVerify: filesNoVerify,
+	})
+	if err != nil {
+		return handleError(out, "files.upload", err)
+	}
+	out.Log("Uploaded: %s", file.Name)
+	return out.WriteSuccess("files.upload", file)
+}
+
+// defaultUploadSessionFile names a new session file for uploading
+// localPath in the config directory
+func defaultUploadSessionFile(localPath string) string {
+	return filepath.Join(getConfigDir(), "uploads", filepath.Base(localPath)+"-"+uuid.New().String()[:8]+".json")
+}
+
+func runFilesUploadTree(ctx context.Context, mgr *files.Manager, reqCtx *types.RequestContext, out *OutputWriter, localDir, parentID string, chunkSize int64, dryRun bool) error {
+	opts := files.UploadTreeOptions{
+		ParentID:  parentID,
+		Name:      filesName,
+		Include:   filesInclude,
+		Exclude:   filesExclude,
+		Workers:   filesUploadWorkers,
+		NoVerify:  filesNoVerify,
+		ChunkSize: chunkSize,
+	}
+	plan, err := files.PlanUploadTree(localDir, opts)
+	if err != nil {
+		return handleError(out, "files.upload", err)
+	}
+
+	if dryRun {
+		rootName := opts.Name
+		if rootName == "" {
+			rootName = filepath.Base(filepath.Clean(localDir))
+		}
+		folders := []string{rootName}
+		for _, dir := range plan.Dirs {
+			folders = append(folders, rootName+"/"+dir)
+		}
+		for _, dir := range folders {
+			planOperation(safety.PlannedOperation{
+				Type:         safety.OpTypeCreate,
+				ResourceName: dir,
+				Description:  "Create folder: " + dir,
+				Parameters:   map[string]interface{}{"parentId": parentID},
+				Predicted:    "folder created unless it exists",
+			})
+		}
+		for _, item := range plan.Files {
+			planOperation(safety.PlannedOperation{
+				Type:         safety.OpTypeUpload,
+				ResourceName: item.Path,
+				Description:  "Upload: " + item.LocalPath,
+				Parameters:   map[string]interface{}{"size": item.Size},
+				Predicted:    "file created",
+			})
+		}
+		out.Log("Would upload %d files (%s) into %d folders, skipping %d",
+			len(plan.Files), formatSize(plan.Bytes), len(plan.Dirs)+1, len(plan.Skipped))
+		return out.WriteSuccess("files.upload", plan)
+	}
+
+	opts.OnItem = func(item *files.UploadTreeItem, done, total int) {
+		out.Log("[%d/%d] %s %s", done, total, item.Status, item.Path)
+	}
+	reqCtx.RequestType = types.RequestTypeMutation
+	result, err := mgr.UploadTree(ctx, reqCtx, plan, opts)
+	if err != nil {
+		return handleError(out, "files.upload", err)
+	}
+
+	for _, item := range result.Items {
+		if item.Status == files.TreeItemFailed {
+			out.AddWarning("UPLOAD_FAILED", fmt.Sprintf("%s: %s", item.Path, item.Error), "medium")
+		}
+	}
+	out.Log("Uploaded %d (%s), skipped %d, failed %d into %s; created %d folders",
+		result.Uploaded, formatSize(result.Bytes), result.Skipped, result.Failed, result.FolderName, result.FoldersCreated)
+	return out.WriteSuccess("files.upload", result)
+}
+
 func runFilesDownload(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
-	ctx := context.Background()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
 
 	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if filesOutput == "-" {
+		out.ContentOnStdout()
+	}
 	if err != nil {
 		return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
 	}
@@ -369,15 +892,55 @@ func runFilesDownload(cmd *cobra.Command, args []string) error {
 		return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
 	}
 
-	reqCtx.RequestType = types.RequestTypeDownloadOrExport
 	mimeType := filesMimeType
+	if filesFormat != "" {
+		mimeType, err = export.GetConvenienceFormat(filesFormat)
+		if err != nil {
+			if appErr, ok := err.(*utils.AppError); ok {
+				return out.WriteError("files.download", appErr.CLIError)
+			}
+			return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+	}
+
+	reqCtx.RequestType = types.RequestTypeDownloadOrExport
+	if filesChangesToken != "" && !filesRecursive {
+		return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--changes-token requires --recursive").Build())
+	}
+	if filesRecursive {
+		if filesOutput == "-" {
+			return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"--output - is not supported with --recursive").Build())
+		}
+		if filesKeyFile != "" {
+			return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"--key-file is not supported with --recursive").Build())
+		}
+		return runFilesDownloadTree(ctx, mgr, reqCtx, out, fileID, mimeType, flags.DryRun)
+	}
+
 	if filesDownloadDoc && mimeType == "" {
 		mimeType = "text/plain"
 	}
+	if filesOutput == "-" {
+		return streamFile(ctx, mgr, reqCtx, out, "files.download", fileID, mimeType)
+	}
 
-	err = mgr.Download(ctx, reqCtx, fileID, files.DownloadOptions{
-		OutputPath: filesOutput,
-		MimeType:   mimeType,
+	var key *encryption.Key
+	if filesKeyFile != "" {
+		key, err = encryption.LoadKey(filesKeyFile)
+		if err != nil {
+			return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+	}
+
+	file, err := mgr.Download(ctx, reqCtx, fileID, files.DownloadOptions{
+		OutputPath:        filesOutput,
+		MimeType:          mimeType,
+		Decryption:        key,
+		NoFollowShortcuts: flags.NoFollowShortcuts,
+		NoVerify:          filesNoVerify,
 	})
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
@@ -387,7 +950,95 @@ func runFilesDownload(cmd *cobra.Command, args []string) error {
 	}
 
 	out.Log("Downloaded to: %s", filesOutput)
-	return out.WriteSuccess("files.download", map[string]string{"path": filesOutput})
+	if v := file.Verification; v.Status == types.VerificationVerified {
+		out.Log("Verified %s: %s", v.Algorithm, v.Checksum)
+	}
+	return out.WriteSuccess("files.download", map[string]interface{}{"path": filesOutput, "verification": file.Verification})
+}
+
+// exportFormatsFor applies an explicit export format to every Workspace
+// type that the live matrix says can be exported as it; other types keep
+// their default
+func exportFormatsFor(ctx context.Context, mgr *files.Manager, reqCtx *types.RequestContext, mimeType string) (map[string]string, error) {
+	matrix := mgr.Formats(ctx, reqCtx)
+	formats := make(map[string]string)
+	for workspaceType := range files.DefaultExportFormats {
+		if export.ValidateExportFormatIn(matrix, workspaceType, mimeType) == nil {
+			formats[workspaceType] = mimeType
+		}
+	}
+	if len(formats) == 0 {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("No Workspace type can be exported as '%s'", mimeType)).
+			WithContext("suggestedAction", "run 'gdrv about formats' to list supported conversions").
+			Build())
+	}
+	return formats, nil
+}
+
+func runFilesDownloadTree(ctx context.Context, mgr *files.Manager, reqCtx *types.RequestContext, out *OutputWriter, folderID, mimeType string, dryRun bool) error {
+	opts := files.DownloadTreeOptions{
+		OutputDir:     filesOutput,
+		ExportWorkers: filesExportWorkers,
+		SkipPreflight: filesSkipPreflight,
+		ChangesToken:  filesChangesToken,
+		NoVerify:      filesNoVerify,
+	}
+	if mimeType != "" {
+		formats, err := exportFormatsFor(ctx, mgr, reqCtx, mimeType)
+		if err != nil {
+			return out.WriteError("files.download", err.(*utils.AppError).CLIError)
+		}
+		opts.ExportFormats = formats
+	}
+
+	if dryRun {
+		if opts.ChangesToken != "" {
+			return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"--dry-run is not supported with --changes-token").Build())
+		}
+		estimate, err := mgr.EstimateTree(ctx, reqCtx, folderID, opts)
+		if err != nil {
+			if appErr, ok := err.(*utils.AppError); ok {
+				return out.WriteError("files.download", appErr.CLIError)
+			}
+			return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		}
+		for _, problem := range estimate.Problems {
+			out.AddWarning("PREFLIGHT_FAILED", problem, "high")
+		}
+		out.Log("Would download %d files (%s) and export %d Workspace files",
+			estimate.Files, formatSize(estimate.TotalBytes), estimate.Exports)
+		return out.WriteSuccess("files.download", estimate)
+	}
+
+	done := startProgress(GetGlobalFlags().Quiet, mgr.SetProgress)
+	result, err := mgr.DownloadTree(ctx, reqCtx, folderID, opts)
+	done()
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.download", appErr.CLIError)
+		}
+		return out.WriteError("files.download", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	for _, item := range result.Items {
+		if item.Status == files.TreeItemFailed {
+			out.AddWarning("DOWNLOAD_FAILED", fmt.Sprintf("%s: %s", item.Name, item.Error), "medium")
+		}
+	}
+
+	if result.FullResync != "" && opts.ChangesToken != "" {
+		out.Log("Full download: %s", result.FullResync)
+	}
+	if result.Incremental {
+		out.Log("Downloaded %d, exported %d, moved %d, removed %d, skipped %d, failed %d in %s",
+			result.Downloaded, result.Exported, result.Moved, result.Removed, result.Skipped, result.Failed, result.OutputDir)
+	} else {
+		out.Log("Downloaded %d, exported %d, skipped %d, failed %d into %s",
+			result.Downloaded, result.Exported, result.Skipped, result.Failed, result.OutputDir)
+	}
+	return out.WriteSuccess("files.download", result)
 }
 
 func runFilesDelete(cmd *cobra.Command, args []string) error {
@@ -409,13 +1060,16 @@ func runFilesDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx.RequestType = types.RequestTypeMutation
-	err = mgr.Delete(ctx, reqCtx, fileID, filesPermanent)
+	err = mgr.DeleteWithSafety(ctx, reqCtx, fileID, filesPermanent, dryRunSafety(flags), planRecorder())
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("files.delete", appErr.CLIError)
 		}
 		return out.WriteError("files.delete", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
+	if flags.DryRun {
+		return out.WriteSuccess("files.delete", nil)
+	}
 
 	action := "trashed"
 	if filesPermanent {
@@ -456,6 +1110,16 @@ func runFilesCopy(cmd *cobra.Command, args []string) error {
 		parentID = resolvedID
 	}
 
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCopy,
+		ResourceID:  fileID,
+		Description: "Copy: " + fileID,
+		Parameters:  map[string]interface{}{"parentId": parentID, "name": filesName},
+		Predicted:   "copy created",
+	}) {
+		return out.WriteSuccess("files.copy", nil)
+	}
+
 	reqCtx.RequestType = types.RequestTypeMutation
 	file, err := mgr.Copy(ctx, reqCtx, fileID, filesName, parentID)
 	if err != nil {
@@ -497,13 +1161,16 @@ func runFilesMove(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx.RequestType = types.RequestTypeMutation
-	file, err := mgr.Move(ctx, reqCtx, fileID, parentID)
+	file, err := mgr.MoveWithSafety(ctx, reqCtx, fileID, parentID, dryRunSafety(flags), planRecorder())
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("files.move", appErr.CLIError)
 		}
 		return out.WriteError("files.move", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
+	if flags.DryRun {
+		return out.WriteSuccess("files.move", nil)
+	}
 
 	out.Log("Moved: %s", file.Name)
 	return out.WriteSuccess("files.move", file)
@@ -527,6 +1194,15 @@ func runFilesTrash(cmd *cobra.Command, args []string) error {
 		return out.WriteError("files.trash", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
 	}
 
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeTrash,
+		ResourceID:  fileID,
+		Description: "Trash: " + fileID,
+		Predicted:   "moved to trash",
+	}) {
+		return out.WriteSuccess("files.trash", nil)
+	}
+
 	reqCtx.RequestType = types.RequestTypeMutation
 	file, err := mgr.Trash(ctx, reqCtx, fileID)
 	if err != nil {
@@ -558,6 +1234,15 @@ func runFilesRestore(cmd *cobra.Command, args []string) error {
 		return out.WriteError("files.restore", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
 	}
 
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeRestore,
+		ResourceID:  fileID,
+		Description: "Restore: " + fileID,
+		Predicted:   "restored from trash",
+	}) {
+		return out.WriteSuccess("files.restore", nil)
+	}
+
 	reqCtx.RequestType = types.RequestTypeMutation
 	file, err := mgr.Restore(ctx, reqCtx, fileID)
 	if err != nil {
@@ -571,6 +1256,62 @@ func runFilesRestore(cmd *cobra.Command, args []string) error {
 	return out.WriteSuccess("files.restore", file)
 }
 
+func runFilesStar(cmd *cobra.Command, args []string) error {
+	return setFilesStarred(args[0], true)
+}
+
+func runFilesUnstar(cmd *cobra.Command, args []string) error {
+	return setFilesStarred(args[0], false)
+}
+
+func setFilesStarred(arg string, starred bool) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+	command := "files.unstar"
+	if starred {
+		command = "files.star"
+	}
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, arg)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError(command, appErr.CLIError)
+		}
+		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  fileID,
+		Description: "Set starred: " + fileID,
+		Parameters:  map[string]interface{}{"starred": starred},
+		Predicted:   "starred updated",
+	}) {
+		return out.WriteSuccess(command, nil)
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	file, err := mgr.SetStarred(ctx, reqCtx, fileID, starred)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError(command, appErr.CLIError)
+		}
+		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	if starred {
+		out.Log("Starred: %s", file.Name)
+	} else {
+		out.Log("Unstarred: %s", file.Name)
+	}
+	return out.WriteSuccess(command, file)
+}
+
 func runFilesRevisions(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	ctx := context.Background()
@@ -692,7 +1433,7 @@ func runFilesListTrashed(cmd *cobra.Command, args []string) error {
 		PageSize:  filesLimit,
 		PageToken: filesPageToken,
 		OrderBy:   filesOrderBy,
-		Fields:    filesFields,
+		Fields:    learnedFileFields("files.list-trashed", filesFields, filesLearnedFields),
 	}
 
 	// If --paginate flag is set, fetch all pages
@@ -704,13 +1445,14 @@ func runFilesListTrashed(cmd *cobra.Command, args []string) error {
 		} else {
 			opts.Query = "trashed = true"
 		}
-		allFiles, err := mgr.ListAll(ctx, reqCtx, opts)
+		allFiles, err := listAllSpooled(ctx, mgr, reqCtx, opts, flags, out)
 		if err != nil {
 			if appErr, ok := err.(*utils.AppError); ok {
 				return out.WriteError("files.list-trashed", appErr.CLIError)
 			}
 			return out.WriteError("files.list-trashed", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 		}
+		defer allFiles.Close()
 		return out.WriteSuccess("files.list-trashed", map[string]interface{}{
 			"files": allFiles,
 		})
@@ -754,9 +1496,15 @@ func runFilesExportFormats(cmd *cobra.Command, args []string) error {
 		return out.WriteError("files.export-formats", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
-	formats, err := export.GetAvailableFormats(file.MimeType)
-	if err != nil {
-		return out.WriteError("files.export-formats", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	if !utils.IsWorkspaceMimeType(file.MimeType) {
+		return out.WriteError("files.export-formats", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Export is only supported for Google Workspace files").
+			WithContext("sourceMimeType", file.MimeType).
+			Build())
+	}
+	formats := mgr.Formats(ctx, reqCtx).ExportTargets(file.MimeType)
+	if formats == nil {
+		formats = []string{}
 	}
 
 	result := map[string]interface{}{
@@ -770,3 +1518,469 @@ func runFilesExportFormats(cmd *cobra.Command, args []string) error {
 
 	return out.WriteSuccess("files.export-formats", result)
 }
+
+func runFilesSharedWithMe(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	opts := files.SharedWithMeOptions{FromDomains: filesFromDomains}
+	if filesStale != "" {
+		staleAfter, err := utils.ParseAge(filesStale)
+		if err != nil {
+			out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+			return out.WriteError("files.shared-with-me", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid --stale value: %s", err)).Build())
+		}
+		opts.StaleAfter = staleAfter
+	}
+
+	mgr, _, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.shared-with-me", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	result, err := mgr.ListSharedWithMe(ctx, reqCtx, opts)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.shared-with-me", appErr.CLIError)
+		}
+		return out.WriteError("files.shared-with-me", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	if !filesRemove || len(result.Items) == 0 {
+		return out.WriteSuccess("files.shared-with-me", result)
+	}
+
+	if flags.DryRun {
+		result.DryRun = true
+		out.Log("Dry run: would remove %d item(s) from Shared with me", len(result.Items))
+		return out.WriteSuccess("files.shared-with-me", result)
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.ConfirmBulkOperation(len(result.Items), "remove from Shared with me", safetyOpts.ForScope(safety.ScopeSharedWithMe))
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.shared-with-me", appErr.CLIError)
+		}
+		return out.WriteError("files.shared-with-me", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	if !confirmed {
+		return out.WriteError("files.shared-with-me", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	for _, item := range result.Items {
+		if err := mgr.RemoveSharedWithMe(ctx, reqCtx, item.ID); err != nil {
+			item.Error = err.Error()
+			result.Failed++
+			continue
+		}
+		item.Removed = true
+		result.Removed++
+	}
+
+	out.Log("Removed %d item(s), %d failed", result.Removed, result.Failed)
+	return out.WriteSuccess("files.shared-with-me", result)
+}
+
+func runFilesOwnersReport(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.owners-report", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	folderID, driveID, err := ResolveLocation(ctx, client, flags, filesFolderID)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.owners-report", appErr.CLIError)
+		}
+		return out.WriteError("files.owners-report", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+	reqCtx.DriveID = driveID
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	report, err := mgr.OwnersReport(ctx, reqCtx, folderID, filesRecursive)
+	done()
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.owners-report", appErr.CLIError)
+		}
+		return out.WriteError("files.owners-report", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	return out.WriteSuccess("files.owners-report", report)
+}
+
+func runFilesFindCorrupt(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.find-corrupt", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	opts := files.FindCorruptOptions{Recursive: filesRecursive, SkipStubs: filesSkipStubs}
+	if filesManifest != "" {
+		f, err := os.Open(filesManifest)
+		if err != nil {
+			return out.WriteError("files.find-corrupt", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		opts.Manifest, err = files.ParseChecksumManifest(f)
+		f.Close()
+		if err != nil {
+			return out.WriteError("files.find-corrupt", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid manifest %s: %s", filesManifest, err)).Build())
+		}
+	}
+
+	folderID, driveID, err := ResolveLocation(ctx, client, flags, filesFolderID)
+	if err != nil {
+		return handleError(out, "files.find-corrupt", err)
+	}
+	reqCtx.DriveID = driveID
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	report, err := mgr.FindCorrupt(ctx, reqCtx, folderID, opts)
+	done()
+	if err != nil {
+		return handleError(out, "files.find-corrupt", err)
+	}
+	out.Log("%d suspect files among %d scanned", len(report.Corrupt), report.FilesScanned)
+	if filesReuploadFrom == "" || len(report.Corrupt) == 0 {
+		return out.WriteSuccess("files.find-corrupt", report)
+	}
+
+	// Which files can be re-uploaded is only known after checking the local
+	// copies, so a run that needs approval checks them first
+	if flags.DryRun || approvalNeeded(flags) {
+		done = startProgress(flags.Quiet, mgr.SetProgress)
+		err = mgr.ReuploadCorrupt(ctx, reqCtx, report, filesReuploadFrom, true)
+		done()
+		if err != nil {
+			return handleError(out, "files.find-corrupt", err)
+		}
+		planned, err := planOrApprove(flags, "files.find-corrupt", reuploadOperations(report))
+		if err != nil {
+			return handleError(out, "files.find-corrupt", err)
+		}
+		if planned {
+			return out.WriteSuccess("files.find-corrupt", report)
+		}
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.ConfirmBulkOperation(len(report.Corrupt), "re-upload corrupt files", safetyOpts.ForScope(safety.ScopeAll))
+	if err != nil {
+		return handleError(out, "files.find-corrupt", err)
+	}
+	if !confirmed {
+		return out.WriteError("files.find-corrupt", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	done = startProgress(flags.Quiet, mgr.SetProgress)
+	err = mgr.ReuploadCorrupt(ctx, reqCtx, report, filesReuploadFrom, false)
+	done()
+	if err != nil {
+		return handleError(out, "files.find-corrupt", err)
+	}
+	if report.Failed > 0 {
+		out.AddWarning("REUPLOADS_FAILED", fmt.Sprintf("%d of %d re-uploads failed; see file errors", report.Failed, len(report.Corrupt)), "high")
+	}
+	out.Log("Re-uploaded %d files, %d failed", report.Reuploaded, report.Failed)
+	return out.WriteSuccess("files.find-corrupt", report)
+}
+
+// reuploadOperations describes the re-uploads a dry run of find-corrupt
+// planned
+func reuploadOperations(report *files.CorruptReport) []safety.PlannedOperation {
+	var ops []safety.PlannedOperation
+	for _, c := range report.Corrupt {
+		if c.Reupload != files.ReuploadPlanned {
+			continue
+		}
+		op := safety.PlannedOperation{
+			Type:         safety.OpTypeUpload,
+			ResourceID:   c.FileID,
+			ResourceName: c.Path,
+			Description:  "Re-upload " + c.Path + " (" + c.Reason + ")",
+			Parameters:   map[string]interface{}{"localPath": filepath.Join(filesReuploadFrom, filepath.FromSlash(c.Path))},
+			Predicted:    "content replaced",
+		}
+		if c.Reason == files.CorruptMissing {
+			op.Predicted = "file created"
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+func runFilesUpdate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.update", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.update", appErr.CLIError)
+		}
+		return out.WriteError("files.update", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	var update files.MetadataUpdate
+	if cmd.Flags().Changed("name") {
+		update.Name = &filesName
+	}
+	if cmd.Flags().Changed("description") {
+		update.Description = &filesDescription
+	}
+
+	params := map[string]interface{}{}
+	if update.Name != nil {
+		params["name"] = *update.Name
+	}
+	if update.Description != nil {
+		params["description"] = *update.Description
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  fileID,
+		Description: "Update: " + fileID,
+		Parameters:  params,
+		Predicted:   "metadata updated",
+	}) {
+		return out.WriteSuccess("files.update", nil)
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	file, err := mgr.UpdateMetadata(ctx, reqCtx, fileID, update)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.update", appErr.CLIError)
+		}
+		return out.WriteError("files.update", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	out.Log("Updated: %s", file.Name)
+	return out.WriteSuccess("files.update", file)
+}
+
+func runFilesSearch(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.search", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	props, err := files.ParseProperties(filesProperties)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.search", appErr.CLIError)
+		}
+		return out.WriteError("files.search", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	searchOpts := files.AnnotationSearchOptions{
+		NameContains:        filesNameContains,
+		DescriptionContains: filesDescContains,
+		Properties:          props,
+		Limit:               filesLimit,
+	}
+	if filesEverywhere {
+		return runFilesSearchEverywhere(ctx, out, mgr, client, reqCtx, searchOpts)
+	}
+
+	parentID := filesParentID
+	if parentID != "" {
+		parentID, reqCtx.DriveID, err = ResolveLocation(ctx, client, flags, parentID)
+		if err != nil {
+			if appErr, ok := err.(*utils.AppError); ok {
+				return out.WriteError("files.search", appErr.CLIError)
+			}
+			return out.WriteError("files.search", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+		}
+	}
+
+	searchOpts.ParentID = parentID
+	result, err := mgr.SearchAnnotations(ctx, reqCtx, searchOpts)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.search", appErr.CLIError)
+		}
+		return out.WriteError("files.search", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	return out.WriteSuccess("files.search", result)
+}
+
+func runFilesSearchEverywhere(ctx context.Context, out *OutputWriter, mgr *files.Manager, client *api.Client, reqCtx *types.RequestContext, opts files.AnnotationSearchOptions) error {
+	if filesParentID != "" {
+		return out.WriteError("files.search", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--parent cannot be combined with --everywhere").Build())
+	}
+
+	list, err := drives.NewManager(client).ListAll(ctx, reqCtx, false)
+	if err != nil {
+		return handleError(out, "files.search", err)
+	}
+	searchDrives := make([]files.SearchDrive, len(list))
+	for i, d := range list {
+		searchDrives[i] = files.SearchDrive{ID: d.Id, Name: d.Name}
+	}
+
+	result, err := mgr.SearchEverywhere(ctx, reqCtx, opts, searchDrives)
+	if err != nil {
+		return handleError(out, "files.search", err)
+	}
+	for _, corpus := range result.Corpora {
+		if corpus.Error != "" {
+			out.AddWarning("CORPUS_SEARCH_FAILED", fmt.Sprintf("%s could not be searched: %s", corpus.Label(), corpus.Error), "medium")
+		}
+	}
+	out.Log("Found %d files in %d corpora", len(result.Files), len(result.Corpora))
+	return out.WriteSuccess("files.search", result)
+}
+
+func runFilesPropertiesGet(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.properties.get", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.get", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.get", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	reqCtx.RequestType = types.RequestTypeGetByID
+	props, err := mgr.GetProperties(ctx, reqCtx, fileID)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.get", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.get", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	if len(args) == 2 {
+		value, ok := props.Properties[args[1]]
+		if !ok {
+			return out.WriteError("files.properties.get", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Property %q is not set on %s", args[1], fileID)).Build())
+		}
+		props.Properties = map[string]string{args[1]: value}
+	}
+
+	return out.WriteSuccess("files.properties.get", props)
+}
+
+func runFilesPropertiesSet(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.properties.set", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	values, err := files.ParseProperties(args[1:])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.set", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.set", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.set", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.set", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	params := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		params[key] = value
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  fileID,
+		Description: "Set properties: " + fileID,
+		Parameters:  params,
+		Predicted:   "properties set",
+	}) {
+		return out.WriteSuccess("files.properties.set", nil)
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	props, err := mgr.SetProperties(ctx, reqCtx, fileID, values)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.set", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.set", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	return out.WriteSuccess("files.properties.set", props)
+}
+
+func runFilesPropertiesDelete(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.properties.delete", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.delete", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.delete", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  fileID,
+		Description: "Delete properties: " + fileID,
+		Parameters:  map[string]interface{}{"delete": args[1:]},
+		Predicted:   "properties removed",
+	}) {
+		return out.WriteSuccess("files.properties.delete", nil)
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	props, err := mgr.DeleteProperties(ctx, reqCtx, fileID, args[1:])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.properties.delete", appErr.CLIError)
+		}
+		return out.WriteError("files.properties.delete", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	return out.WriteSuccess("files.properties.delete", props)
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var filesArchiveOldCmd = &cobra.Command{
+	Use:   "archive-old",
+	Short: "Archive or trash files not modified within a retention period",
+	Long: `Apply a retention rule to a folder: every file under it, at any depth,
+last modified longer ago than --older-than is moved into --dest, keeping its
+path below the destination, or trashed with --trash-instead.
+
+Exceptions keep files in place: --except takes a file or folder ID, or a
+glob matched against paths relative to the folder and against file names,
+and --exceptions-file reads one per line (# starts a comment). Excepting a
+folder keeps everything in it. --manifest writes every archived, failed and
+excepted file as CSV for the retention record, in dry runs too.`,
+	Example: "  gdrv files archive-old --folder-id <folder-id> --older-than 2y --dest <archive-folder-id> --dry-run\n" +
+		"  gdrv files archive-old --folder-id <folder-id> --older-than 730d --trash-instead \\\n" +
+		"    --exceptions-file legal-hold.txt --manifest archived-2024.csv",
+	Args: cobra.NoArgs,
+	RunE: runFilesArchiveOld,
+}
+
+var (
+	archiveFolderID       string
+	archiveOlderThan      string
+	archiveDest           string
+	archiveTrashInstead   bool
+	archiveExcept         []string
+	archiveExceptionsFile string
+	archiveManifest       string
+)
+
+func init() {
+	filesArchiveOldCmd.Flags().StringVar(&archiveFolderID, "folder-id", "", "Folder to apply the retention rule to (required)")
+	filesArchiveOldCmd.Flags().StringVar(&archiveOlderThan, "older-than", "", "Archive files not modified within this age, e.g. 2y, 180d (required)")
+	filesArchiveOldCmd.Flags().StringVar(&archiveDest, "dest", "", "Folder to move stale files into")
+	filesArchiveOldCmd.Flags().BoolVar(&archiveTrashInstead, "trash-instead", false, "Trash stale files instead of moving them")
+	filesArchiveOldCmd.Flags().StringArrayVar(&archiveExcept, "except", nil, "File or folder ID, or path glob, to leave in place (repeatable)")
+	filesArchiveOldCmd.Flags().StringVar(&archiveExceptionsFile, "exceptions-file", "", "File listing exceptions, one per line")
+	filesArchiveOldCmd.Flags().StringVar(&archiveManifest, "manifest", "", "Write a CSV manifest of the run to this file")
+	_ = filesArchiveOldCmd.MarkFlagRequired("folder-id")
+	_ = filesArchiveOldCmd.MarkFlagRequired("older-than")
+	filesArchiveOldCmd.MarkFlagsMutuallyExclusive("dest", "trash-instead")
+	filesArchiveOldCmd.MarkFlagsOneRequired("dest", "trash-instead")
+	filesCmd.AddCommand(filesArchiveOldCmd)
+}
+
+func runFilesArchiveOld(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	age, err := utils.ParseAge(archiveOlderThan)
+	if err != nil {
+		return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid --older-than value: %s", err)).Build())
+	}
+	opts := files.ArchiveOptions{
+		Cutoff:     time.Now().Add(-age),
+		Trash:      archiveTrashInstead,
+		Exceptions: files.ArchiveExceptions(archiveExcept),
+	}
+	if archiveExceptionsFile != "" {
+		f, err := os.Open(archiveExceptionsFile)
+		if err != nil {
+			return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		listed, err := files.ReadArchiveExceptions(f)
+		f.Close()
+		if err != nil {
+			return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid exceptions file %s: %s", archiveExceptionsFile, err)).Build())
+		}
+		opts.Exceptions = append(opts.Exceptions, listed...)
+	}
+
+	folderID, driveID, err := ResolveLocation(ctx, client, flags, archiveFolderID)
+	if err != nil {
+		return handleError(out, "files.archive-old", err)
+	}
+	reqCtx.DriveID = driveID
+	if archiveDest != "" {
+		opts.DestinationID, err = ResolveFileID(ctx, client, flags, archiveDest)
+		if err != nil {
+			return handleError(out, "files.archive-old", err)
+		}
+		if opts.DestinationID == folderID {
+			return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"--dest must be a different folder from --folder-id").Build())
+		}
+	}
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	report, err := mgr.FindArchivable(ctx, reqCtx, folderID, opts)
+	done()
+	if err != nil {
+		return handleError(out, "files.archive-old", err)
+	}
+	out.Log("%d of %d files last modified before %s (%d excepted)", report.Planned, report.FilesScanned, report.Cutoff, report.Excepted)
+
+	planned, err := planOrApprove(flags, "files.archive-old", archiveOperations(report))
+	if err != nil {
+		return handleError(out, "files.archive-old", err)
+	}
+	if report.Planned > 0 && !planned {
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		operation := "move stale files to the archive folder"
+		scope := safety.ScopeAll
+		if opts.Trash {
+			operation = "trash stale files"
+			scope = safety.ScopeTrash
+		}
+		confirmed, err := safety.ConfirmBulkOperation(report.Planned, operation, safetyOpts.ForScope(scope))
+		if err != nil {
+			return handleError(out, "files.archive-old", err)
+		}
+		if !confirmed {
+			return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	done = startProgress(flags.Quiet, mgr.SetProgress)
+	err = mgr.Archive(ctx, reqCtx, report, planned)
+	done()
+	if err != nil {
+		return handleError(out, "files.archive-old", err)
+	}
+
+	if archiveManifest != "" {
+		var buf bytes.Buffer
+		if err := report.WriteManifest(&buf); err != nil {
+			return handleError(out, "files.archive-old", err)
+		}
+		if err := utils.WriteFileAtomic(archiveManifest, buf.Bytes(), 0644); err != nil {
+			return out.WriteError("files.archive-old", utils.NewCLIError(utils.ErrCodeUnknown,
+				fmt.Sprintf("Failed to write manifest: %s", err)).Build())
+		}
+		out.Log("Wrote manifest: %s", archiveManifest)
+	}
+	if report.Failed > 0 {
+		out.AddWarning("ARCHIVE_FAILED", fmt.Sprintf("%d of %d files could not be archived; see item errors", report.Failed, report.Planned), "high")
+	}
+	if !flags.DryRun {
+		out.Log("Archived %d files, %d failed", report.Archived, report.Failed)
+	}
+	return out.WriteSuccess("files.archive-old", report)
+}
+
+// archiveOperations describes the files an archive run would move or trash
+func archiveOperations(report *files.ArchiveReport) []safety.PlannedOperation {
+	var ops []safety.PlannedOperation
+	for _, item := range report.Items {
+		if item.Status != files.ArchivePlanned {
+			continue
+		}
+		op := safety.PlannedOperation{
+			Type:         safety.OpTypeMove,
+			ResourceID:   item.FileID,
+			ResourceName: item.Path,
+			Description:  fmt.Sprintf("Archive %s (modified %s)", item.Path, item.ModifiedTime),
+			Parameters:   map[string]interface{}{"destinationId": report.DestinationID},
+			Predicted:    "moved to the archive folder",
+		}
+		if report.Action == files.ArchiveTrash {
+			op.Type = safety.OpTypeTrash
+			op.Parameters = nil
+			op.Predicted = "moved to trash"
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/export"
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var filesDownloadQueryCmd = &cobra.Command{
+	Use:     "download-query",
+	Aliases: []string{"download-many"},
+	Short:   "Download every file matching a query",
+	Long: `Download every file matching a Drive query into one directory.
+
+The query is checked before anything is listed (see 'gdrv query explain'),
+then all matches are listed page by page and downloaded by --workers
+concurrent workers. Workspace files are exported as with a recursive
+download; folders never match.
+
+Local names come from --naming: the Drive name, the name with the file ID
+before the extension (name-id), or the file ID alone. Matches with the same
+name are always numbered; --on-conflict decides what happens to files that
+already exist in the output directory: rename the new file, skip it,
+overwrite the local one, or (checksum) skip it only when the local file has
+the same MD5 as the Drive file and overwrite it otherwise.
+
+A file that fails with a network, rate-limit or server error is retried up
+to --retries more times. The JSON result lists every file with its status
+and the number of attempts it needed.
+
+The command is also available as download-many, where --output-dir and
+--concurrency are accepted for --output and --workers.
+
+With --dry-run, the matches are listed and estimated without downloading.`,
+	Example: "  gdrv files download-query --query \"mimeType='application/pdf' and modifiedTime > '2024-01-01'\" --output ./pdfs\n" +
+		"  gdrv files download-query --query \"'me' in owners and starred = true\" --output ./starred --naming name-id --on-conflict skip\n" +
+		"  gdrv files download-many --query \"name contains 'invoice'\" --output-dir ./out --concurrency 8 --on-conflict checksum",
+	Args: cobra.NoArgs,
+	RunE: runFilesDownloadQuery,
+}
+
+var (
+	dlQuery          string
+	dlQueryOutput    string
+	dlQueryWorkers   int
+	dlQueryNaming    string
+	dlQueryConflict  string
+	dlQueryFormat    string
+	dlQueryLimit     int
+	dlQueryTrashed   bool
+	dlQueryPreflight bool
+	dlQueryRetries   int
+	dlQueryNoVerify  bool
+)
+
+// downloadQueryFlagAliases maps the download-many spellings of flags to
+// their download-query names
+var downloadQueryFlagAliases = map[string]string{
+	"output-dir":  "output",
+	"concurrency": "workers",
+}
+
+func init() {
+	filesDownloadQueryCmd.Flags().StringVar(&dlQuery, "query", "", "Drive search query selecting the files to download (required)")
+	filesDownloadQueryCmd.Flags().StringVar(&dlQueryOutput, "output", "", "Directory to download into (required)")
+	filesDownloadQueryCmd.Flags().IntVar(&dlQueryWorkers, "workers", files.DefaultDownloadWorkers, "Concurrent downloads and exports")
+	filesDownloadQueryCmd.Flags().StringVar(&dlQueryNaming, "naming", files.NamingName, "Local file names: name, name-id or id")
+	filesDownloadQueryCmd.Flags().StringVar(&dlQueryConflict, "on-conflict", files.ConflictRename, "When a local file exists: rename, skip, overwrite or checksum")
+	filesDownloadQueryCmd.Flags().StringVar(&dlQueryFormat, "format", "", "Export format shorthand or MIME type for Workspace files (e.g. pdf, docx)")
+	filesDownloadQueryCmd.Flags().IntVar(&dlQueryLimit, "limit", 0, "Maximum files to download (0 = all)")
+	filesDownloadQueryCmd.Flags().BoolVar(&dlQueryTrashed, "include-trashed", false, "Include trashed files")
+	filesDownloadQueryCmd.Flags().IntVar(&dlQueryRetries, "retries", 2, "Extra attempts for a file that fails with a transient error")
+	filesDownloadQueryCmd.Flags().BoolVar(&dlQueryPreflight, "skip-preflight", false, "Download even if the disk-space or path-length check fails")
+	filesDownloadQueryCmd.Flags().BoolVar(&dlQueryNoVerify, "no-verify", false, "Skip checking downloads against Drive's checksums")
+	_ = filesDownloadQueryCmd.MarkFlagRequired("query")
+	_ = filesDownloadQueryCmd.MarkFlagRequired("output")
+	filesDownloadQueryCmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if alias, ok := downloadQueryFlagAliases[name]; ok {
+			name = alias
+		}
+		return pflag.NormalizedName(name)
+	})
+
+	filesCmd.AddCommand(filesDownloadQueryCmd)
+}
+
+func runFilesDownloadQuery(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, _, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.download-query", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	if explanation := query.Explain(dlQuery); !explanation.Valid {
+		return writeQueryExplanation(out, "files.download-query", explanation)
+	}
+
+	opts := files.DownloadQueryOptions{
+		OutputDir:      dlQueryOutput,
+		Workers:        dlQueryWorkers,
+		Naming:         dlQueryNaming,
+		OnConflict:     dlQueryConflict,
+		MaxFiles:       dlQueryLimit,
+		IncludeTrashed: dlQueryTrashed,
+		SkipPreflight:  dlQueryPreflight,
+		Retries:        dlQueryRetries,
+		NoVerify:       dlQueryNoVerify,
+	}
+	if dlQueryFormat != "" {
+		mimeType, err := export.GetConvenienceFormat(dlQueryFormat)
+		if err != nil {
+			return handleError(out, "files.download-query", err)
+		}
+		reqCtx.RequestType = types.RequestTypeDownloadOrExport
+		if opts.ExportFormats, err = exportFormatsFor(ctx, mgr, reqCtx, mimeType); err != nil {
+			return handleError(out, "files.download-query", err)
+		}
+	}
+
+	if flags.DryRun {
+		reqCtx.RequestType = types.RequestTypeListOrSearch
+		estimate, err := mgr.EstimateQuery(ctx, reqCtx, dlQuery, opts)
+		if err != nil {
+			return handleError(out, "files.download-query", err)
+		}
+		for _, problem := range estimate.Problems {
+			out.AddWarning("PREFLIGHT_FAILED", problem, "high")
+		}
+		out.Log("Would download %d files (%s) and export %d Workspace files",
+			estimate.Files, formatSize(estimate.TotalBytes), estimate.Exports)
+		return out.WriteSuccess("files.download-query", estimate)
+	}
+
+	reqCtx.RequestType = types.RequestTypeDownloadOrExport
+	result, err := mgr.DownloadQuery(ctx, reqCtx, dlQuery, opts)
+	if err != nil {
+		return handleError(out, "files.download-query", err)
+	}
+
+	for _, item := range result.Items {
+		if item.Status == files.TreeItemFailed {
+			out.AddWarning("DOWNLOAD_FAILED", fmt.Sprintf("%s: %s", item.Name, item.Error), "medium")
+		}
+	}
+	out.Log("Downloaded %d, exported %d, skipped %d, failed %d into %s",
+		result.Downloaded, result.Exported, result.Skipped, result.Failed, result.OutputDir)
+	if result.Retried > 0 {
+		out.Log("%d files needed more than one attempt", result.Retried)
+	}
+	return out.WriteSuccess("files.download-query", result)
+}
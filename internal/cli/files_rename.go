@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var filesRenameCmd = &cobra.Command{
+	Use:   "rename <file-id> <new-name> | --pattern <s/regexp/replacement/> [file-id...]",
+	Short: "Rename a file, or many files with a sed-style pattern",
+	Long: `Rename one file, or apply a sed-style substitution to the names of the
+given files and, with --folder-id, of a folder's direct children.
+
+Patterns take the form s/regexp/replacement/flags. Any character may
+follow the s as the delimiter. In the replacement, & is the whole match
+and \1 to \9 are groups. Flags: g replaces every match, i ignores case.
+
+Drive allows several files with the same name in a folder. --if-unique
+checks each folder the file is in just before renaming and refuses a name
+a sibling already uses, failing with NAME_CONFLICT and the sibling's ID.
+In a bulk rename, files that would collide with a sibling or with each
+other are reported as conflicts and left unchanged.`,
+	Example: "  gdrv files rename <file-id> \"Budget 2025.xlsx\" --if-unique\n" +
+		"  gdrv files rename --pattern 's/draft/final/' --folder-id <folder-id> --dry-run\n" +
+		"  gdrv files rename --pattern 's/ (copy)$//i' <file-id> <file-id> --if-unique",
+	RunE: runFilesRename,
+}
+
+var (
+	renameIfUnique bool
+	renamePattern  string
+	renameFolderID string
+)
+
+func init() {
+	filesRenameCmd.Flags().BoolVar(&renameIfUnique, "if-unique", false, "Refuse a name a sibling already uses")
+	filesRenameCmd.Flags().StringVar(&renamePattern, "pattern", "", "sed-style substitution applied to each name, e.g. s/draft/final/")
+	filesRenameCmd.Flags().StringVar(&renameFolderID, "folder-id", "", "Rename the folder's direct children (requires --pattern)")
+	filesCmd.AddCommand(filesRenameCmd)
+}
+
+func runFilesRename(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	if renamePattern == "" && (len(args) != 2 || renameFolderID != "") {
+		return NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose).WriteError("files.rename",
+			utils.NewCLIError(utils.ErrCodeInvalidArgument, "Give a file ID and a new name, or --pattern").Build())
+	}
+	if renamePattern != "" && len(args) == 0 && renameFolderID == "" {
+		return NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose).WriteError("files.rename",
+			utils.NewCLIError(utils.ErrCodeInvalidArgument, "Give the files to rename, or --folder-id").Build())
+	}
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.rename", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	var report *files.RenameReport
+	if renamePattern == "" {
+		fileID, err := ResolveFileID(ctx, client, flags, args[0])
+		if err != nil {
+			return handleError(out, "files.rename", err)
+		}
+		if report, err = mgr.PlanRename(ctx, reqCtx, fileID, args[1], renameIfUnique); err != nil {
+			return handleError(out, "files.rename", err)
+		}
+	} else {
+		pattern, err := files.ParseRenamePattern(renamePattern)
+		if err != nil {
+			return handleError(out, "files.rename", err)
+		}
+		fileIDs := make([]string, len(args))
+		for i, arg := range args {
+			if fileIDs[i], err = ResolveFileID(ctx, client, flags, arg); err != nil {
+				return handleError(out, "files.rename", err)
+			}
+		}
+		folderID := ""
+		if renameFolderID != "" {
+			var driveID string
+			if folderID, driveID, err = ResolveLocation(ctx, client, flags, renameFolderID); err != nil {
+				return handleError(out, "files.rename", err)
+			}
+			reqCtx.DriveID = driveID
+		}
+		if report, err = mgr.PlanBulkRename(ctx, reqCtx, fileIDs, folderID, pattern, renameIfUnique); err != nil {
+			return handleError(out, "files.rename", err)
+		}
+	}
+
+	planned, err := planOrApprove(flags, "files.rename", renameOperations(report))
+	if err != nil {
+		return handleError(out, "files.rename", err)
+	}
+	if renamePattern != "" && report.Planned > 1 && !planned {
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		confirmed, err := safety.ConfirmBulkOperation(report.Planned, "rename files", safetyOpts.ForScope(safety.ScopeAll))
+		if err != nil {
+			return handleError(out, "files.rename", err)
+		}
+		if !confirmed {
+			return out.WriteError("files.rename", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	err = mgr.ApplyRenames(ctx, reqCtx, report, planned)
+	done()
+	if err != nil {
+		return handleError(out, "files.rename", err)
+	}
+
+	if report.Conflicts > 0 {
+		out.AddWarning("NAME_CONFLICT", fmt.Sprintf("%d files were not renamed because a sibling already uses the new name", report.Conflicts), "medium")
+	}
+	if report.Failed > 0 {
+		out.AddWarning("RENAME_FAILED", fmt.Sprintf("%d files could not be renamed; see item errors", report.Failed), "high")
+	}
+	if !flags.DryRun {
+		out.Log("Renamed %d files, %d unchanged, %d conflicts, %d failed", report.Renamed, report.Unchanged, report.Conflicts, report.Failed)
+	}
+	return out.WriteSuccess("files.rename", report)
+}
+
+// renameOperations describes the renames a report plans
+func renameOperations(report *files.RenameReport) []safety.PlannedOperation {
+	var ops []safety.PlannedOperation
+	for _, item := range report.Items {
+		if item.Status != files.RenamePlanned {
+			continue
+		}
+		ops = append(ops, safety.PlannedOperation{
+			Type:         safety.OpTypeUpdate,
+			ResourceID:   item.FileID,
+			ResourceName: item.Name,
+			Description:  fmt.Sprintf("Rename %s to %s", item.Name, item.NewName),
+			Parameters:   map[string]interface{}{"name": item.NewName},
+			Predicted:    "renamed",
+		})
+	}
+	return ops
+}
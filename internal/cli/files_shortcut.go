@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var filesShortcutCmd = &cobra.Command{
+	Use:   "shortcut",
+	Short: "Manage shortcuts",
+	Long: `Create Drive shortcuts.
+
+Other commands follow shortcuts to the files they point to: get, download
+and path resolution act on the target. Pass --no-follow-shortcuts to act on
+the shortcut itself.`,
+}
+
+var filesShortcutCreateCmd = &cobra.Command{
+	Use:   "create <target-id>",
+	Short: "Create a shortcut to a file or folder",
+	Long: `Create a shortcut to a file or folder in --parent. The shortcut takes
+the target's name unless --name is given.`,
+	Example: "  gdrv files shortcut create <file-id> --parent <folder-id>\n  gdrv files shortcut create \"/Reports/Q3.pdf\" --parent \"/Team\" --name \"Q3 report\"",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runFilesShortcutCreate,
+}
+
+var (
+	shortcutParent string
+	shortcutName   string
+)
+
+func init() {
+	filesShortcutCreateCmd.Flags().StringVar(&shortcutParent, "parent", "", "Folder ID or path to create the shortcut in (required)")
+	filesShortcutCreateCmd.Flags().StringVar(&shortcutName, "name", "", "Shortcut name (default: the target's name)")
+	_ = filesShortcutCreateCmd.MarkFlagRequired("parent")
+
+	filesShortcutCmd.AddCommand(filesShortcutCreateCmd)
+	filesCmd.AddCommand(filesShortcutCmd)
+}
+
+func runFilesShortcutCreate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	mgr, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.shortcut.create", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	// A path to a shortcut resolves to its target, which is what a new
+	// shortcut should point to as well
+	targetID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.shortcut.create", appErr.CLIError)
+		}
+		return out.WriteError("files.shortcut.create", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	parentID, err := ResolveFileID(ctx, client, flags, shortcutParent)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.shortcut.create", appErr.CLIError)
+		}
+		return out.WriteError("files.shortcut.create", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreate,
+		ResourceID:  targetID,
+		Description: "Create shortcut to: " + targetID,
+		Parameters:  map[string]interface{}{"parentId": parentID, "name": shortcutName},
+		Predicted:   "shortcut created",
+	}) {
+		return out.WriteSuccess("files.shortcut.create", nil)
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	file, err := mgr.CreateShortcut(ctx, reqCtx, targetID, parentID, shortcutName)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.shortcut.create", appErr.CLIError)
+		}
+		return out.WriteError("files.shortcut.create", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	out.Log("Created shortcut: %s -> %s", file.Name, targetID)
+	return out.WriteSuccess("files.shortcut.create", file)
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var filesEmptyTrashCmd = &cobra.Command{
+	Use:   "empty-trash",
+	Short: "Permanently delete everything in the trash",
+	Long: `Permanently delete every trashed file you own, or with --drive-id every
+trashed file in that Shared Drive. This cannot be undone.
+
+The trashed files are counted first and the deletion is confirmed unless
+--force or --yes (covering the delete scope) is given. Use --dry-run to list
+what would be deleted.`,
+	Example: "  gdrv files empty-trash --dry-run\n" +
+		"  gdrv files empty-trash --drive-id <drive-id> --yes=delete",
+	Args: cobra.NoArgs,
+	RunE: runFilesEmptyTrash,
+}
+
+var filesRestoreAllCmd = &cobra.Command{
+	Use:   "restore-all",
+	Short: "Restore trashed files matching a query",
+	Long: `Restore every trashed file you own that matches --query, or with --drive-id
+the matching trashed files in that Shared Drive. --query takes the same
+Drive query syntax as 'files list'; without it the whole trash is restored.
+
+Files trashed along with their folder are skipped: restore the folder to
+bring them back. A file that fails is reported and the rest are still
+restored. The result lists restored, skipped and failed files.`,
+	Example: "  gdrv files restore-all --query \"name contains 'invoice'\" --dry-run\n" +
+		"  gdrv files restore-all --query \"modifiedTime > '2024-06-01T00:00:00'\" --max-files 500",
+	Args: cobra.NoArgs,
+	RunE: runFilesRestoreAll,
+}
+
+var (
+	restoreAllQuery    string
+	restoreAllMaxFiles int
+)
+
+func init() {
+	filesRestoreAllCmd.Flags().StringVar(&restoreAllQuery, "query", "", "Drive query the trashed files must match")
+	filesRestoreAllCmd.Flags().IntVar(&restoreAllMaxFiles, "max-files", 0, "Restore at most this many files (0 = no limit)")
+	filesCmd.AddCommand(filesEmptyTrashCmd)
+	filesCmd.AddCommand(filesRestoreAllCmd)
+}
+
+// emptyTrashResult is the output of files empty-trash
+type emptyTrashResult struct {
+	DriveID   string `json:"driveId,omitempty"`
+	ItemCount int    `json:"itemCount"` // Trashed items found before emptying
+	Emptied   bool   `json:"emptied"`
+	DryRun    bool   `json:"dryRun,omitempty"`
+}
+
+func runFilesEmptyTrash(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, _, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.empty-trash", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	trashed, err := mgr.FindTrashed(ctx, reqCtx, "", 0)
+	done()
+	if err != nil {
+		return handleError(out, "files.empty-trash", err)
+	}
+	result := &emptyTrashResult{DriveID: flags.DriveID, ItemCount: len(trashed), DryRun: flags.DryRun}
+	if len(trashed) == 0 {
+		out.Log("Trash is empty")
+		return out.WriteSuccess("files.empty-trash", result)
+	}
+
+	ops := make([]safety.PlannedOperation, len(trashed))
+	for i, f := range trashed {
+		ops[i] = safety.PlannedOperation{
+			Type:         safety.OpTypeDelete,
+			ResourceID:   f.Id,
+			ResourceName: f.Name,
+			Description:  "Empty trash: " + f.Name,
+			Parameters:   map[string]interface{}{"permanent": true},
+			Predicted:    "permanently deleted",
+		}
+	}
+	planned, err := planOrApprove(flags, "files.empty-trash", ops)
+	if err != nil {
+		return handleError(out, "files.empty-trash", err)
+	}
+	if planned {
+		return out.WriteSuccess("files.empty-trash", result)
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.ConfirmBulkOperation(len(trashed), "permanently delete trashed files", safetyOpts.ForScope(safety.ScopeDelete))
+	if err != nil {
+		return handleError(out, "files.empty-trash", err)
+	}
+	if !confirmed {
+		return out.WriteError("files.empty-trash", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	reqCtx.RequestType = types.RequestTypeMutation
+	if err := mgr.EmptyTrash(ctx, reqCtx); err != nil {
+		return handleError(out, "files.empty-trash", err)
+	}
+	result.Emptied = true
+	out.Log("Emptied trash: %d files permanently deleted", len(trashed))
+	return out.WriteSuccess("files.empty-trash", result)
+}
+
+func runFilesRestoreAll(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, _, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.restore-all", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+	if restoreAllMaxFiles < 0 {
+		return out.WriteError("files.restore-all", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("--max-files must not be negative, got %d", restoreAllMaxFiles)).Build())
+	}
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	trashed, err := mgr.FindTrashed(ctx, reqCtx, restoreAllQuery, restoreAllMaxFiles)
+	done()
+	if err != nil {
+		return handleError(out, "files.restore-all", err)
+	}
+
+	// The dry run reads nothing more from Drive, so it also gives the
+	// changes to check for approval
+	done = startProgress(flags.Quiet, mgr.SetProgress)
+	preview, err := mgr.RestoreAll(ctx, reqCtx, trashed, true)
+	done()
+	if err != nil {
+		return handleError(out, "files.restore-all", err)
+	}
+	planned, err := planOrApprove(flags, "files.restore-all", bulkOperations(preview, safety.OpTypeRestore, nil, "restored from trash"))
+	if err != nil {
+		return handleError(out, "files.restore-all", err)
+	}
+	if planned {
+		return out.WriteSuccess("files.restore-all", preview)
+	}
+
+	if len(trashed) > 0 {
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		confirmed, err := safety.ConfirmBulkOperation(len(trashed), "restore trashed files", safetyOpts.ForScope(safety.ScopeTrash))
+		if err != nil {
+			return handleError(out, "files.restore-all", err)
+		}
+		if !confirmed {
+			return out.WriteError("files.restore-all", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	done = startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.RestoreAll(ctx, reqCtx, trashed, false)
+	done()
+	if err != nil {
+		return handleError(out, "files.restore-all", err)
+	}
+
+	if result.FailureCount > 0 {
+		out.AddWarning("RESTORE_FAILED", fmt.Sprintf("%d of %d files could not be restored; see failedFiles", result.FailureCount, result.TotalFiles), "high")
+	}
+	out.Log("Restored %d files, %d skipped, %d failed", result.SuccessCount, result.SkippedCount, result.FailureCount)
+	return out.WriteSuccess("files.restore-all", result)
+}
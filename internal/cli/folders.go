@@ -7,6 +7,7 @@ import (
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/auth"
 	"github.com/dl-alexandre/gdrv/internal/folders"
+	"github.com/dl-alexandre/gdrv/internal/safety"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"github.com/spf13/cobra"
@@ -92,31 +93,39 @@ func init() {
 	folderGetCmd.Flags().StringVar(&folderFields, "fields", "", "Fields to retrieve (comma-separated)")
 }
 
-func getFolderManager() (*folders.Manager, error) {
+func getFolderManager() (*folders.Manager, *api.Client, error) {
 	flags := GetGlobalFlags()
 
 	configDir := getConfigDir()
 	authMgr := auth.NewManager(configDir)
 	creds, err := authMgr.LoadCredentials(flags.Profile)
 	if err != nil {
-		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+		return nil, nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
 			"Authentication required. Run 'gdrv auth login' first.").Build())
 	}
 
 	service, err := authMgr.GetDriveService(context.Background(), creds)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	client := api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
-	return folders.NewManager(client), nil
+	return folders.NewManager(client), client, nil
+}
+
+// folderArgError writes the error from resolving a folder argument
+func folderArgError(writer *OutputWriter, command string, err error) error {
+	if appErr, ok := err.(*utils.AppError); ok {
+		return writer.WriteError(command, appErr.CLIError)
+	}
+	return writer.WriteError(command, utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
 }
 
 func runFolderCreate(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	mgr, err := getFolderManager()
+	mgr, client, err := getFolderManager()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return writer.WriteError("folder.create", appErr.CLIError)
@@ -127,7 +136,25 @@ func runFolderCreate(cmd *cobra.Command, args []string) error {
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeMutation)
 	name := args[0]
 
-	result, err := mgr.Create(context.Background(), reqCtx, name, folderParentID)
+	parentID := folderParentID
+	if parentID != "" {
+		parentID, reqCtx.DriveID, err = ResolveLocation(context.Background(), client, flags, parentID)
+		if err != nil {
+			return folderArgError(writer, "folder.create", err)
+		}
+	}
+
+	if planOperation(safety.PlannedOperation{
+		Type:         safety.OpTypeCreate,
+		ResourceName: name,
+		Description:  "Create folder: " + name,
+		Parameters:   map[string]interface{}{"parentId": parentID},
+		Predicted:    "folder created",
+	}) {
+		return writer.WriteSuccess("folder.create", nil)
+	}
+
+	result, err := mgr.Create(context.Background(), reqCtx, name, parentID)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
@@ -143,7 +170,7 @@ func runFolderList(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	mgr, err := getFolderManager()
+	mgr, client, err := getFolderManager()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return writer.WriteError("folder.list", appErr.CLIError)
@@ -152,7 +179,11 @@ func runFolderList(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
-	folderID := args[0]
+	folderID, driveID, err := ResolveLocation(context.Background(), client, flags, args[0])
+	if err != nil {
+		return folderArgError(writer, "folder.list", err)
+	}
+	reqCtx.DriveID = driveID
 
 	// If --paginate flag is set, fetch all pages
 	if folderPaginate {
@@ -194,7 +225,7 @@ func runFolderDelete(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	mgr, err := getFolderManager()
+	mgr, client, err := getFolderManager()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return writer.WriteError("folder.delete", appErr.CLIError)
@@ -203,15 +234,25 @@ func runFolderDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeMutation)
-	folderID := args[0]
+	folderID, err := ResolveFileID(context.Background(), client, flags, args[0])
+	if err != nil {
+		return folderArgError(writer, "folder.delete", err)
+	}
 
-	err = mgr.Delete(context.Background(), reqCtx, folderID, folderRecursive)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	err = mgr.DeleteWithSafety(ctx, reqCtx, folderID, folderRecursive, dryRunSafety(flags), planRecorder())
+	done()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("folder.delete", appErr.CLIError)
 		}
-		return writer.WriteError("folder.delete", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "folder.delete", err)
+	}
+	if flags.DryRun {
+		return writer.WriteSuccess("folder.delete", nil)
 	}
 
 	return writer.WriteSuccess("folder.delete", map[string]interface{}{
@@ -225,7 +266,7 @@ func runFolderMove(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	mgr, err := getFolderManager()
+	mgr, client, err := getFolderManager()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return writer.WriteError("folder.move", appErr.CLIError)
@@ -234,8 +275,24 @@ func runFolderMove(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeMutation)
-	folderID := args[0]
-	newParentID := args[1]
+	folderID, err := ResolveFileID(context.Background(), client, flags, args[0])
+	if err != nil {
+		return folderArgError(writer, "folder.move", err)
+	}
+	newParentID, err := ResolveFileID(context.Background(), client, flags, args[1])
+	if err != nil {
+		return folderArgError(writer, "folder.move", err)
+	}
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeMove,
+		ResourceID:  folderID,
+		Description: "Move folder: " + folderID,
+		Parameters:  map[string]interface{}{"targetParentID": newParentID},
+		Predicted:   "parent set to " + newParentID,
+	}) {
+		return writer.WriteSuccess("folder.move", nil)
+	}
 
 	result, err := mgr.Move(context.Background(), reqCtx, folderID, newParentID)
 	if err != nil {
@@ -253,7 +310,7 @@ func runFolderGet(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	mgr, err := getFolderManager()
+	mgr, client, err := getFolderManager()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return writer.WriteError("folder.get", appErr.CLIError)
@@ -262,7 +319,10 @@ func runFolderGet(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeGetByID)
-	folderID := args[0]
+	folderID, err := ResolveFileID(context.Background(), client, flags, args[0])
+	if err != nil {
+		return folderArgError(writer, "folder.get", err)
+	}
 
 	result, err := mgr.Get(context.Background(), reqCtx, folderID, folderFields)
 	if err != nil {
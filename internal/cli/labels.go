@@ -243,6 +243,8 @@ func getLabelsManager(ctx context.Context, flags types.GlobalFlags) (*labels.Man
 	}
 
 	client := api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
+	// The Drive Labels service is built on the same authenticated client
+	client.SetHTTPClient(authMgr.GetHTTPClient(ctx, creds))
 	mgr := labels.NewManager(client)
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
 
@@ -427,6 +429,19 @@ func runLabelsFileList(cmd *cobra.Command, args []string) error {
 		return out.WriteError("labels.file.list", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
+	// Titles are optional; the labels on the file are listed through the
+	// Drive API either way
+	if err := mgr.DescribeFileLabels(ctx, reqCtx, fileLabels); err != nil {
+		message := err.Error()
+		if appErr, ok := err.(*utils.AppError); ok {
+			message = appErr.CLIError.Message
+			if action, ok := appErr.CLIError.Context["suggestedAction"].(string); ok {
+				message += "; " + action
+			}
+		}
+		out.AddWarning("LABEL_TITLES_UNAVAILABLE", "label titles omitted: "+message, "low")
+	}
+
 	result := &FileLabelsListResult{FileLabels: fileLabels}
 	return out.WriteSuccess("labels.file.list", result)
 }
@@ -573,14 +588,14 @@ type FileLabelsListResult struct {
 }
 
 func (r *FileLabelsListResult) Headers() []string {
-	return []string{"Label ID", "Revision ID", "Fields"}
+	return []string{"Label ID", "Title", "Revision ID", "Fields"}
 }
 
 func (r *FileLabelsListResult) Rows() [][]string {
 	rows := make([][]string, len(r.FileLabels))
 	for i, fileLabel := range r.FileLabels {
 		fieldCount := fmt.Sprintf("%d", len(fileLabel.Fields))
-		rows[i] = []string{fileLabel.ID, fileLabel.RevisionID, fieldCount}
+		rows[i] = []string{fileLabel.ID, fileLabel.Title, fileLabel.RevisionID, fieldCount}
 	}
 	return rows
 }
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/migrate"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migration tools",
+	Long:  "Commands for migrating content between My Drive and Shared Drives",
+}
+
+var migrateToSharedDriveCmd = &cobra.Command{
+	Use:   "to-shared-drive",
+	Short: "Migrate a My Drive folder into a Shared Drive",
+	Long: `Migrate a My Drive folder tree into a Shared Drive, preserving its structure.
+
+Items you own are moved; items owned by others are copied where allowed; anything
+else (shortcuts, items that cannot be copied) is reported for manual action.
+Use --dry-run to review the plan without changing anything.`,
+	Example: "  gdrv migrate to-shared-drive --src <folder-id> --dst <drive-id> --dry-run",
+	RunE:    runMigrateToSharedDrive,
+}
+
+var (
+	migrateSrc       string
+	migrateDst       string
+	migrateDstFolder string
+)
+
+func init() {
+	migrateToSharedDriveCmd.Flags().StringVar(&migrateSrc, "src", "", "Source My Drive folder ID or path (required)")
+	migrateToSharedDriveCmd.Flags().StringVar(&migrateDst, "dst", "", "Destination Shared Drive ID (required)")
+	migrateToSharedDriveCmd.Flags().StringVar(&migrateDstFolder, "dst-folder", "", "Folder in the Shared Drive to migrate into (default: drive root)")
+	_ = migrateToSharedDriveCmd.MarkFlagRequired("src")
+	_ = migrateToSharedDriveCmd.MarkFlagRequired("dst")
+
+	migrateCmd.AddCommand(migrateToSharedDriveCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func getMigrateManager(ctx context.Context, flags types.GlobalFlags) (*migrate.Manager, *api.Client, *types.RequestContext, *OutputWriter, error) {
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	configDir := getConfigDir()
+	authMgr := auth.NewManager(configDir)
+
+	creds, err := authMgr.GetValidCredentials(ctx, flags.Profile)
+	if err != nil {
+		return nil, nil, nil, out, err
+	}
+
+	service, err := authMgr.GetDriveService(ctx, creds)
+	if err != nil {
+		return nil, nil, nil, out, err
+	}
+
+	client := api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
+	mgr := migrate.NewManager(client)
+	// The source tree lives in My Drive, so listing is not scoped to a drive
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeListOrSearch)
+
+	return mgr, client, reqCtx, out, nil
+}
+
+func runMigrateToSharedDrive(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, client, reqCtx, out, err := getMigrateManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("migrate.to-shared-drive", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	srcID, err := ResolveFileID(ctx, client, flags, migrateSrc)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("migrate.to-shared-drive", appErr.CLIError)
+		}
+		return out.WriteError("migrate.to-shared-drive", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	plan, err := mgr.Plan(ctx, reqCtx, srcID, migrateDst, migrateDstFolder)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("migrate.to-shared-drive", appErr.CLIError)
+		}
+		return out.WriteError("migrate.to-shared-drive", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	out.Log("Plan: %d folder(s), %d move(s), %d copies, %d manual action(s)",
+		plan.Folders, plan.Moves, plan.Copies, plan.ManualActions)
+	planned, err := planOrApprove(flags, "migrate.to-shared-drive", migrationOperations(plan))
+	if err != nil {
+		return handleError(out, "migrate.to-shared-drive", err)
+	}
+	if planned {
+		return out.WriteSuccess("migrate.to-shared-drive", plan)
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.ConfirmBulkOperation(len(plan.Items), "migrate", safetyOpts.ForScope(safety.ScopeMigrate))
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("migrate.to-shared-drive", appErr.CLIError)
+		}
+		return out.WriteError("migrate.to-shared-drive", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	if !confirmed && len(plan.Items) > 0 {
+		return out.WriteError("migrate.to-shared-drive", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	result, err := mgr.Execute(ctx, reqCtx, plan)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("migrate.to-shared-drive", appErr.CLIError)
+		}
+		return out.WriteError("migrate.to-shared-drive", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	if result.Failed > 0 {
+		out.AddWarning("MIGRATION_PARTIAL_FAILURE", "Some items failed to migrate; see item errors", "high")
+	}
+	if result.ManualActions > 0 {
+		out.AddWarning("MIGRATION_MANUAL_ACTION", "Some items require manual action; see item reasons", "medium")
+	}
+	return out.WriteSuccess("migrate.to-shared-drive", result)
+}
+
+// migrationOperations describes the steps of a migration plan. Items left
+// for manual action change nothing and are reported in the result only.
+func migrationOperations(plan *types.MigrationPlan) []safety.PlannedOperation {
+	var ops []safety.PlannedOperation
+	for _, item := range plan.Items {
+		op := safety.PlannedOperation{
+			ResourceID:   item.SourceID,
+			ResourceName: item.Path,
+			Description:  item.Action + ": " + item.Path,
+			Parameters:   map[string]interface{}{"destinationDriveId": plan.DestinationDrive},
+		}
+		switch item.Action {
+		case types.MigrationActionCreateFolder:
+			op.Type, op.Predicted = safety.OpTypeCreate, "folder created in the Shared Drive"
+		case types.MigrationActionMove:
+			op.Type, op.Predicted = safety.OpTypeMove, "moved into the Shared Drive"
+		case types.MigrationActionCopy:
+			op.Type, op.Predicted = safety.OpTypeCopy, "copied into the Shared Drive"
+		default:
+			continue
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/extract"
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/spool"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"github.com/google/uuid"
@@ -17,6 +23,8 @@ type OutputWriter struct {
 	quiet    bool
 	verbose  bool
 	warnings []types.CLIWarning
+	// contentOnStdout reserves stdout for file content; see ContentOnStdout
+	contentOnStdout bool
 }
 
 // NewOutputWriter creates a new output writer
@@ -29,6 +37,13 @@ func NewOutputWriter(format types.OutputFormat, quiet, verbose bool) *OutputWrit
 	}
 }
 
+// ContentOnStdout reserves stdout for file content the command streams:
+// the success result is not written, and errors are written to stderr as
+// JSON so they cannot corrupt a pipe
+func (w *OutputWriter) ContentOnStdout() {
+	w.contentOnStdout = true
+}
+
 // AddWarning adds a warning to the output
 func (w *OutputWriter) AddWarning(code, message, severity string) {
 	w.warnings = append(w.warnings, types.CLIWarning{
@@ -40,6 +55,9 @@ func (w *OutputWriter) AddWarning(code, message, severity string) {
 
 // WriteSuccess writes a successful result
 func (w *OutputWriter) WriteSuccess(command string, data interface{}) error {
+	w.recordFieldsAudit(command, data)
+	w.recordRecoveries()
+	w.recordTransportStats()
 	output := types.CLIOutput{
 		SchemaVersion: utils.SchemaVersion,
 		TraceID:       uuid.New().String(),
@@ -47,16 +65,90 @@ func (w *OutputWriter) WriteSuccess(command string, data interface{}) error {
 		Data:          data,
 		Warnings:      w.warnings,
 		Errors:        []types.CLIError{},
+		Plan:          currentPlan(command),
+	}
+	if err := writePlanFile(output.Plan); err != nil {
+		return err
 	}
 
+	if w.contentOnStdout {
+		return nil
+	}
+	if globalFlags.Extract != "" {
+		return w.writeExtract(globalFlags.Extract, data)
+	}
 	if w.format == types.OutputFormatJSON {
 		return w.writeJSON(output)
 	}
+	if w.format == types.OutputFormatSARIF {
+		return w.writeSARIF(command, data)
+	}
+	if output.Plan != nil {
+		return w.writePlanTable(output.Plan, data)
+	}
 	return w.writeTable(data)
 }
 
+// writePlanTable renders a dry run's result followed by its plan, or just
+// the plan when the command has no other result
+func (w *OutputWriter) writePlanTable(plan *types.DryRunPlan, data interface{}) error {
+	if data != nil {
+		if err := w.writeTable(data); err != nil {
+			return err
+		}
+		if len(plan.Operations) == 0 {
+			return nil
+		}
+	}
+	return w.renderTable(plan)
+}
+
+// writeExtract prints only the value at path in the result data, for
+// --extract. Warnings go to stderr since there is no envelope to carry them.
+func (w *OutputWriter) writeExtract(path string, data interface{}) error {
+	p, err := extract.Parse(path)
+	if err != nil {
+		return err
+	}
+	marshalled, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	// Expand spilled result lists before decoding
+	var buf bytes.Buffer
+	if err := spool.WriteJSON(&buf, marshalled); err != nil {
+		return err
+	}
+	doc, err := extract.Decode(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range w.warnings {
+		w.Log("warning: %s: %s", warning.Code, warning.Message)
+	}
+	value, ok := p.Get(doc)
+	if !ok {
+		// An empty value prints nothing, so a missing one must fail
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("--extract: nothing at %s", path)).Build())
+	}
+	text, err := extract.Format(value)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return nil
+	}
+	_, err = fmt.Fprintln(os.Stdout, text)
+	return err
+}
+
 // WriteError writes an error result
 func (w *OutputWriter) WriteError(command string, cliErr types.CLIError) error {
+	w.recordRecoveries()
+	w.recordTransportStats()
+	recordRunError(cliErr)
 	output := types.CLIOutput{
 		SchemaVersion: utils.SchemaVersion,
 		TraceID:       uuid.New().String(),
@@ -64,15 +156,52 @@ func (w *OutputWriter) WriteError(command string, cliErr types.CLIError) error {
 		Data:          nil,
 		Warnings:      w.warnings,
 		Errors:        []types.CLIError{cliErr},
+		Plan:          currentPlan(command),
+	}
+	if err := writePlanFile(output.Plan); err != nil {
+		return err
 	}
 
+	if w.contentOnStdout {
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(os.Stderr, string(data))
+		return err
+	}
 	return w.writeJSON(output)
 }
 
+// writeSARIF prints the result as a SARIF log for --output sarif, with
+// warnings on stderr since the log has no place for them. Commands whose
+// results are not findings report an error instead.
+func (w *OutputWriter) writeSARIF(command string, data interface{}) error {
+	renderer, ok := data.(types.SARIFRenderer)
+	if !ok {
+		return w.WriteError(command, utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s does not support --output sarif", command)).
+			WithContext("suggestedAction", "use --output json or table").
+			Build())
+	}
+	for _, warning := range w.warnings {
+		w.Log("warning: %s: %s", warning.Code, warning.Message)
+	}
+	out, err := json.MarshalIndent(renderer.SARIF(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(out, '\n'))
+	return err
+}
+
 func (w *OutputWriter) writeJSON(output types.CLIOutput) error {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(output)
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Spilled result lists are streamed from disk rather than marshalled
+	return spool.WriteJSON(os.Stdout, append(data, '\n'))
 }
 
 func (w *OutputWriter) writeTable(data interface{}) error {
@@ -83,12 +212,16 @@ func (w *OutputWriter) writeTable(data interface{}) error {
 		return w.renderTable(renderer)
 	}
 	switch v := data.(type) {
+	case *types.FileListResult:
+		return w.writeFileTable(v.Files)
 	case []*types.DriveFile:
 		return w.writeFileTable(v)
 	case *types.DriveFile:
 		return w.writeFileTable([]*types.DriveFile{v})
 	case []*types.Permission:
 		return w.writePermissionTable(v)
+	case *types.Permission:
+		return w.writePermissionTable([]*types.Permission{v})
 	default:
 		// Fallback to JSON for unknown types
 		return w.writeJSON(types.CLIOutput{
@@ -144,7 +277,7 @@ func (w *OutputWriter) writeFileTable(files []*types.DriveFile) error {
 			truncate(f.Name, 40),
 			truncate(f.MimeType, 30),
 			size,
-			f.ModifiedTime,
+			types.DisplayTime(f.ModifiedTime),
 		})
 	}
 
@@ -153,8 +286,21 @@ func (w *OutputWriter) writeFileTable(files []*types.DriveFile) error {
 }
 
 func (w *OutputWriter) writePermissionTable(perms []*types.Permission) error {
+	// Expiration is shown only when some grant is temporary
+	expiring := false
+	for _, p := range perms {
+		if p.ExpirationTime != "" {
+			expiring = true
+			break
+		}
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Type", "Role", "Email/Domain"})
+	header := []string{"ID", "Type", "Role", "Email/Domain"}
+	if expiring {
+		header = append(header, "Expires")
+	}
+	table.SetHeader(header)
 	table.SetBorder(false)
 
 	for _, p := range perms {
@@ -165,7 +311,15 @@ func (w *OutputWriter) writePermissionTable(perms []*types.Permission) error {
 		if identity == "" {
 			identity = "-"
 		}
-		table.Append([]string{p.ID, p.Type, p.Role, identity})
+		row := []string{p.ID, p.Type, p.Role, identity}
+		if expiring {
+			expires := "-"
+			if p.ExpirationTime != "" {
+				expires = types.DisplayTime(p.ExpirationTime)
+			}
+			row = append(row, expires)
+		}
+		table.Append(row)
 	}
 
 	table.Render()
@@ -193,15 +347,65 @@ func truncate(s string, max int) string {
 	return s[:max-3] + "..."
 }
 
+// formatSize renders a byte count for tables and log lines, in the
+// locale's format under --human-readable
 func formatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+	if types.IsHumanDisplay() {
+		return types.DisplaySize(bytes)
+	}
+	return utils.FormatSize(bytes)
+}
+
+// applyDisplay selects how table and text output render sizes, dates and
+// counts. --human-readable humanizes them using the configured locale, or
+// the environment's; JSON output is never affected.
+func applyDisplay() {
+	if !globalFlags.HumanReadable {
+		types.SetDisplay(nil)
+		return
+	}
+	types.SetDisplay(types.NewHumanDisplay(displayLocale()))
+}
+
+func displayLocale() string {
+	if cfg, err := config.Load(); err == nil && cfg.Locale != "" {
+		return cfg.Locale
+	}
+	for _, key := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// recordRecoveries reports the corrupt files the run read from backups as
+// warnings
+func (w *OutputWriter) recordRecoveries() {
+	for _, r := range utils.TakeRecoveries() {
+		message := fmt.Sprintf("%s is corrupt (%s); using its backup. Run 'gdrv config repair' to restore it.", r.Path, r.Reason)
+		w.AddWarning("FILE_RECOVERED_FROM_BACKUP", message, "high")
+		// --extract logs every warning itself
+		if w.format != types.OutputFormatJSON && globalFlags.Extract == "" {
+			w.Log("Warning: %s", message)
+		}
+	}
+}
+
+// recordTransportStats reports the run's HTTP transport activity as a
+// warning when --transport-stats is given
+func (w *OutputWriter) recordTransportStats() {
+	if !globalFlags.TransportStats {
+		return
+	}
+	stats := api.GetTransportStats()
+	message := "transport: " + stats.String()
+	if uploads := files.GetResumableStats(); uploads.ChunkRetries > 0 {
+		message += "; " + resumableStatsString(uploads)
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	w.AddWarning("TRANSPORT_STATS", message, "low")
+	// --extract logs every warning itself
+	if w.format != types.OutputFormatJSON && globalFlags.Extract == "" {
+		w.Log("%s", message)
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
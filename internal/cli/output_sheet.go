@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sheetsmgr "github.com/dl-alexandre/gdrv/internal/sheets"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// outputSheetNew as the --output-sheet value creates a new spreadsheet
+const outputSheetNew = "new"
+
+// outputSheetTitle names spreadsheets created with --output-sheet new
+const outputSheetTitle = "gdrv permission findings"
+
+// exportToSheet writes data as a new tab in the spreadsheet named by target
+// (a spreadsheet ID, or "new"), one row per finding under a header row.
+// Tabs are named after the command and the time of the run so repeated runs
+// accumulate side by side.
+func exportToSheet(writer *OutputWriter, flags types.GlobalFlags, target, command string, data types.TableRenderer) error {
+	ctx := context.Background()
+	svc, client, reqCtx, err := getSheetsService(ctx, flags)
+	if err != nil {
+		return err
+	}
+	reqCtx.RequestType = types.RequestTypeMutation
+	mgr := sheetsmgr.NewManager(client, svc)
+
+	tab := sheetTabTitle(command, time.Now())
+	spreadsheetID := target
+	var sheetID int64
+	if target == outputSheetNew {
+		created, err := mgr.CreateSpreadsheet(ctx, reqCtx, outputSheetTitle, tab)
+		if err != nil {
+			return err
+		}
+		spreadsheetID = created.ID
+		if len(created.Sheets) > 0 {
+			sheetID = created.Sheets[0].ID
+		}
+	} else {
+		added, err := mgr.AddSheet(ctx, reqCtx, spreadsheetID, tab)
+		if err != nil {
+			return err
+		}
+		sheetID = added.ID
+	}
+
+	rows := data.Rows()
+	values := make([][]interface{}, 0, len(rows)+1)
+	values = append(values, stringsToCells(data.Headers()))
+	for _, row := range rows {
+		values = append(values, stringsToCells(row))
+	}
+	// RAW keeps file names such as "=HYPERLINK(...)" from being evaluated
+	if _, err := mgr.UpdateValues(ctx, reqCtx, spreadsheetID, "'"+tab+"'!A1", values, "RAW"); err != nil {
+		return err
+	}
+
+	writer.Log("Wrote %d row(s) to https://docs.google.com/spreadsheets/d/%s/edit#gid=%d", len(rows), spreadsheetID, sheetID)
+	return nil
+}
+
+// sheetTabTitle returns the tab name for a run, e.g.
+// "audit public 2026-01-02 150405"
+func sheetTabTitle(command string, at time.Time) string {
+	name := strings.TrimPrefix(command, "permissions.")
+	name = strings.ReplaceAll(name, ".", " ")
+	return fmt.Sprintf("%s %s", name, at.Format("2006-01-02 150405"))
+}
+
+func stringsToCells(row []string) []interface{} {
+	cells := make([]interface{}, len(row))
+	for i, v := range row {
+		cells[i] = v
+	}
+	return cells
+}
@@ -3,10 +3,12 @@ package cli
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
 func captureStderr(t *testing.T, fn func()) string {
@@ -87,3 +89,78 @@ func TestOutputWriterVerbose(t *testing.T) {
 		t.Fatalf("expected verbose output, got %q", out)
 	}
 }
+
+func TestWriteRunSummary(t *testing.T) {
+	origFlags, origErr := globalFlags, runError
+	defer func() { globalFlags, runError = origFlags, origErr }()
+	path := t.TempDir() + "/summary.jsonl"
+	globalFlags.Summary = true
+	globalFlags.SummaryFile = path
+
+	recordRunError(types.CLIError{Code: "FILE_NOT_FOUND"})
+	summary := &RunSummary{Command: "files get", Status: "failed", ErrorCode: runError.Code, ElapsedMs: 1500, APICalls: 4, BytesIn: 2048}
+	summary.Operations.Attempted, summary.Operations.Failed, summary.Operations.Retries = 2, 1, 2
+	for i := 0; i < 2; i++ {
+		stderr := captureStderr(t, func() {
+			if err := writeRunSummary(summary); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if !strings.Contains(stderr, "files get failed (FILE_NOT_FOUND) in 1.5s: 2 operations (0 succeeded, 1 failed, 2 retries), 4 API calls") {
+			t.Errorf("summary line = %q", stderr)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"errorCode":"FILE_NOT_FOUND"`) || !strings.Contains(lines[1], `"bytesReceived":2048`) {
+		t.Errorf("summary file = %s", data)
+	}
+}
+
+func TestWriteExtract_MissingPath(t *testing.T) {
+	orig := globalFlags.Extract
+	defer func() { globalFlags.Extract = orig }()
+	w := NewOutputWriter(types.OutputFormatJSON, true, false)
+	data := map[string]interface{}{"files": []map[string]string{{"id": "f1", "description": ""}}}
+
+	globalFlags.Extract = "files.0.description"
+	if err := w.WriteSuccess("files.list", data); err != nil {
+		t.Errorf("empty field: %v", err)
+	}
+
+	globalFlags.Extract = "files.0.owner"
+	err := w.WriteSuccess("files.list", data)
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+		t.Fatalf("missing field: got %v, want an invalid argument error", err)
+	}
+	if !strings.Contains(appErr.CLIError.Message, "files.0.owner") {
+		t.Errorf("message %q does not name the path", appErr.CLIError.Message)
+	}
+}
+
+func TestOutputWriter_RecoveryWarning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"trunc`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+utils.BackupSuffix, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ReadFileWithBackup(path, utils.ValidJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewOutputWriter(types.OutputFormatJSON, false, false)
+	stderr := captureStderr(t, func() { w.recordRecoveries() })
+	if stderr != "" {
+		t.Errorf("JSON output wrote %q to stderr", stderr)
+	}
+	if len(w.warnings) != 1 || w.warnings[0].Code != "FILE_RECOVERED_FROM_BACKUP" || !strings.Contains(w.warnings[0].Message, path) {
+		t.Errorf("warnings = %+v, want the recovery of %s", w.warnings, path)
+	}
+}
@@ -2,11 +2,14 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/auth"
 	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"github.com/spf13/cobra"
@@ -22,39 +25,61 @@ var permissionsCmd = &cobra.Command{
 var permListCmd = &cobra.Command{
 	Use:   "list <file-id>",
 	Short: "List permissions",
-	Long:  "List all permissions for a file or folder",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runPermList,
+	Long: `List all permissions for a file or folder.
+
+By default the grantee, role and expiration time are returned. --full adds
+pending ownership transfers, deleted accounts, photo links, the view a
+permission belongs to, and Shared Drive inheritance details.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPermList,
 }
 
 var permCreateCmd = &cobra.Command{
 	Use:   "create <file-id>",
 	Short: "Create a permission",
-	Long:  "Create a new permission to a file or folder",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runPermCreate,
+	Long: `Create a new permission to a file or folder.
+
+Use --message-template to render the notification message from a file
+using Go template syntax. Available variables: {{.FileName}}, {{.FileID}},
+{{.Link}}, {{.Granter}}, {{.GranterEmail}}, {{.Recipient}}, {{.Role}} and
+{{.Date}}.
+
+--expires makes a user or group grant with a reader, commenter or writer
+role temporary. It takes an RFC 3339 time or a period from now such as 30d,
+up to a year ahead; Drive removes the grant when it expires.
+
+Item-level grants inside a Shared Drive are flagged with a warning that
+suggests drive membership instead; --strict-policy refuses them.`,
+	Example: "  gdrv permissions create <file-id> --type user --email contractor@example.com --role writer --expires 30d",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPermCreate,
 }
 
 var permUpdateCmd = &cobra.Command{
-	Use:   "update <file-id> <permission-id>",
+	Use:   "update <file-id> [permission-id]",
 	Short: "Update a permission",
-	Long:  "Update an existing permission's role",
-	Args:  cobra.ExactArgs(2),
-	RunE:  runPermUpdate,
+	Long: `Update an existing permission's role, expiration time, or both.
+
+The permission can be given by ID, or selected by grantee with --email,
+--domain or --anyone. --expires takes an RFC 3339 time or a period from now
+such as 30d, up to a year ahead.`,
+	Example: "  gdrv permissions update <file-id> --email contractor@example.com --expires 2w",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    runPermUpdate,
 }
 
 var permRemoveCmd = &cobra.Command{
-	Use:   "remove <file-id> <permission-id>",
+	Use:   "remove <file-id> [permission-id]",
 	Short: "Remove a permission",
-	Long:  "Remove a permission from a file or folder",
-	Args:  cobra.ExactArgs(2),
+	Long:  "Remove a permission from a file or folder.\n\nThe permission can be given by ID, or selected by grantee with --email, --domain or --anyone.",
+	Args:  cobra.RangeArgs(1, 2),
 	RunE:  runPermRemove,
 }
 
 var permCreateLinkCmd = &cobra.Command{
 	Use:   "create-link <file-id>",
 	Short: "Create a public link",
-	Long:  "Create a public sharing link for a file or folder",
+	Long:  "Create a public sharing link for a file or folder.\n\nSharing capabilities and Shared Drive restrictions are checked before the link is created.",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runPermCreateLink,
 }
@@ -67,8 +92,12 @@ var (
 	permDomain             string
 	permSendNotification   bool
 	permEmailMessage       string
+	permMessageTemplate    string
 	permTransferOwnership  bool
 	permAllowFileDiscovery bool
+	permAnyone             bool
+	permExpires            string
+	permStrictPolicy       bool
 )
 
 var permAuditCmd = &cobra.Command{
@@ -109,9 +138,15 @@ var permAuditUserCmd = &cobra.Command{
 var permAnalyzeCmd = &cobra.Command{
 	Use:   "analyze <folder-id>",
 	Short: "Analyze folder permissions",
-	Long:  "Analyze permissions for a folder and its contents",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runPermAnalyze,
+	Long: `Analyze permissions for a folder and its contents.
+
+Inside a Shared Drive, every item inherits the drive's members. The analysis
+counts those drive memberships separately from grants on the items
+themselves; use --ignore-drive-membership to leave them out of the
+distributions and risk levels so unusual per-item sharing stands out.`,
+	Example: "  gdrv permissions analyze <folder-id> --recursive --ignore-drive-membership --include-details",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPermAnalyze,
 }
 
 var permReportCmd = &cobra.Command{
@@ -125,7 +160,12 @@ var permReportCmd = &cobra.Command{
 var permBulkCmd = &cobra.Command{
 	Use:   "bulk",
 	Short: "Bulk permission operations",
-	Long:  "Perform bulk permission operations on multiple files",
+	Long: `Perform bulk permission operations on multiple files.
+
+Permission changes are sent as Drive batch requests of up to 100 changes
+(see --batch-size), and each change succeeds or fails on its own. Without
+--continue-on-error a run stops after the batch holding the first failure,
+so the other changes in that batch have already been made.`,
 }
 
 var permBulkRemovePublicCmd = &cobra.Command{
@@ -135,11 +175,33 @@ var permBulkRemovePublicCmd = &cobra.Command{
 	RunE:  runPermBulkRemovePublic,
 }
 
+var permBulkShareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Bulk share files",
+	Long: `Grant a permission on every file in a folder.
+
+With --message-template the notification message is rendered for each file,
+so recipients get the file's own name and link. Use --dry-run to review the
+rendered messages without sharing anything.
+
+As with 'permissions create', sharing inside a Shared Drive is flagged in
+favour of drive membership, and refused with --strict-policy.`,
+	Example: "  gdrv permissions bulk share --folder-id <folder-id> --type user --role reader \\\n    --email alice@example.com --message-template welcome.tmpl --dry-run",
+	RunE:    runPermBulkShare,
+}
+
 var permBulkUpdateRoleCmd = &cobra.Command{
 	Use:   "update-role",
 	Short: "Bulk update roles",
-	Long:  "Update permission roles from one role to another in a folder",
-	RunE:  runPermBulkUpdateRole,
+	Long: `Update permission roles from one role to another in a folder.
+
+Role changes that grant more access (e.g. reader to writer) are refused
+unless --upgrade-only is given, so swapped --from-role and --to-role values
+can't widen access across a tree. Use --downgrade-only to make the intent
+explicit in scripts. Before making changes, the direction and scope of the
+update are shown for confirmation.`,
+	Example: "  gdrv permissions bulk update-role --folder-id <folder-id> --recursive \\\n    --from-role writer --to-role reader --downgrade-only",
+	RunE:    runPermBulkUpdateRole,
 }
 
 var permSearchCmd = &cobra.Command{
@@ -149,16 +211,47 @@ var permSearchCmd = &cobra.Command{
 	RunE:  runPermSearch,
 }
 
+var permCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare permissions across two profiles",
+	Long: `Resolve the same path under two profiles (e.g. production and staging
+tenants) and diff the permissions on it by grantee.
+
+Use --domain-map when the tenants use different domains, so that
+alice@prod.example.com and alice@staging.example.com are treated as the
+same grantee.`,
+	Example: "  gdrv permissions compare --profile-a prod --profile-b staging --path \"Shared/Policies\" \\\n    --domain-map prod.example.com=staging.example.com",
+	RunE:    runPermCompare,
+}
+
+var (
+	permListFull      bool
+	permListPublished bool
+)
+
+var (
+	compareProfileA  string
+	compareProfileB  string
+	comparePath      string
+	comparePathB     string
+	compareDomainMap []string
+)
+
 var (
 	auditFolderID       string
 	auditRecursive      bool
-	auditInternalDomain string
+	auditInternalDomain []string
 	auditIncludePerms   bool
+	auditFollowShortcut bool
+
+	permOutputSheet string
 
 	analyzeRecursive      bool
 	analyzeMaxDepth       int
 	analyzeIncludeDetails bool
-	analyzeInternalDomain string
+	analyzeInternalDomain []string
+	analyzeFollowShortcut bool
+	analyzeIgnoreMembers  bool
 
 	bulkFolderID        string
 	bulkRecursive       bool
@@ -166,6 +259,10 @@ var (
 	bulkToRole          string
 	bulkMaxFiles        int
 	bulkContinueOnError bool
+	bulkMaxErrorRate    string
+	bulkBatchSize       int
+	bulkDowngradeOnly   bool
+	bulkUpgradeOnly     bool
 
 	searchEmail     string
 	searchRole      string
@@ -186,6 +283,7 @@ func init() {
 	permissionsCmd.AddCommand(permReportCmd)
 	permissionsCmd.AddCommand(permBulkCmd)
 	permissionsCmd.AddCommand(permSearchCmd)
+	permissionsCmd.AddCommand(permCompareCmd)
 
 	permAuditCmd.AddCommand(permAuditPublicCmd)
 	permAuditCmd.AddCommand(permAuditExternalCmd)
@@ -194,6 +292,11 @@ func init() {
 
 	permBulkCmd.AddCommand(permBulkRemovePublicCmd)
 	permBulkCmd.AddCommand(permBulkUpdateRoleCmd)
+	permBulkCmd.AddCommand(permBulkShareCmd)
+
+	// List flags
+	permListCmd.Flags().BoolVar(&permListFull, "full", false, "Include inheritance, pending-owner and view metadata")
+	permListCmd.Flags().BoolVar(&permListPublished, "include-published", false, "Include permissions of the published view (view=published)")
 
 	// Create flags
 	permCreateCmd.Flags().StringVar(&permType, "type", "", "Permission type (user, group, domain, anyone)")
@@ -202,14 +305,22 @@ func init() {
 	permCreateCmd.Flags().StringVar(&permDomain, "domain", "", "Domain (for domain type)")
 	permCreateCmd.Flags().BoolVar(&permSendNotification, "send-notification", true, "Send email notification")
 	permCreateCmd.Flags().StringVar(&permEmailMessage, "message", "", "Custom email message")
+	permCreateCmd.Flags().StringVar(&permMessageTemplate, "message-template", "", "File with a Go template for the email message")
+	permCreateCmd.MarkFlagsMutuallyExclusive("message", "message-template")
 	permCreateCmd.Flags().BoolVar(&permTransferOwnership, "transfer-ownership", false, "Transfer ownership (requires owner role)")
 	permCreateCmd.Flags().BoolVar(&permAllowFileDiscovery, "allow-discovery", false, "Allow file discovery (for anyone type)")
+	permCreateCmd.Flags().StringVar(&permExpires, "expires", "", "Expire the grant at an RFC 3339 time or after a period such as 30d (user and group only)")
+	permCreateCmd.Flags().BoolVar(&permStrictPolicy, "strict-policy", false, "Refuse item-level grants inside a Shared Drive instead of warning")
 	_ = permCreateCmd.MarkFlagRequired("type")
 	_ = permCreateCmd.MarkFlagRequired("role")
 
 	// Update flags
 	permUpdateCmd.Flags().StringVar(&permRole, "role", "", "New permission role")
-	_ = permUpdateCmd.MarkFlagRequired("role")
+	permUpdateCmd.Flags().StringVar(&permExpires, "expires", "", "New expiration: an RFC 3339 time or a period such as 30d")
+	addPermSelectorFlags(permUpdateCmd)
+
+	// Remove flags
+	addPermSelectorFlags(permRemoveCmd)
 
 	// Create link flags
 	permCreateLinkCmd.Flags().StringVar(&permRole, "role", "reader", "Permission role (reader, commenter, writer)")
@@ -219,35 +330,51 @@ func init() {
 	permAuditPublicCmd.Flags().StringVar(&auditFolderID, "folder-id", "", "Limit audit to specific folder")
 	permAuditPublicCmd.Flags().BoolVar(&auditRecursive, "recursive", false, "Include subfolders")
 	permAuditPublicCmd.Flags().BoolVar(&auditIncludePerms, "include-permissions", false, "Include full permission details")
+	permAuditPublicCmd.Flags().BoolVar(&auditFollowShortcut, "follow-shortcuts", false, "Audit shortcut targets' permissions instead of the shortcuts'")
 
 	permAuditExternalCmd.Flags().StringVar(&auditFolderID, "folder-id", "", "Limit audit to specific folder")
 	permAuditExternalCmd.Flags().BoolVar(&auditRecursive, "recursive", false, "Include subfolders")
-	permAuditExternalCmd.Flags().StringVar(&auditInternalDomain, "internal-domain", "", "Internal domain (required)")
+	permAuditExternalCmd.Flags().StringSliceVar(&auditInternalDomain, "internal-domain", nil, "Internal domains, exact or *.example.com (default: the profile's configured domains)")
 	permAuditExternalCmd.Flags().BoolVar(&auditIncludePerms, "include-permissions", false, "Include full permission details")
-	_ = permAuditExternalCmd.MarkFlagRequired("internal-domain")
+	permAuditExternalCmd.Flags().BoolVar(&auditFollowShortcut, "follow-shortcuts", false, "Audit shortcut targets' permissions instead of the shortcuts'")
 
 	permAuditAnyoneWithLinkCmd.Flags().StringVar(&auditFolderID, "folder-id", "", "Limit audit to specific folder")
 	permAuditAnyoneWithLinkCmd.Flags().BoolVar(&auditRecursive, "recursive", false, "Include subfolders")
 	permAuditAnyoneWithLinkCmd.Flags().BoolVar(&auditIncludePerms, "include-permissions", false, "Include full permission details")
+	permAuditAnyoneWithLinkCmd.Flags().BoolVar(&auditFollowShortcut, "follow-shortcuts", false, "Audit shortcut targets' permissions instead of the shortcuts'")
 
 	permAuditUserCmd.Flags().StringVar(&auditFolderID, "folder-id", "", "Limit audit to specific folder")
 	permAuditUserCmd.Flags().BoolVar(&auditRecursive, "recursive", false, "Include subfolders")
 	permAuditUserCmd.Flags().BoolVar(&auditIncludePerms, "include-permissions", false, "Include full permission details")
+	permAuditUserCmd.Flags().BoolVar(&auditFollowShortcut, "follow-shortcuts", false, "Audit shortcut targets' permissions instead of the shortcuts'")
 
 	// Analyze flags
 	permAnalyzeCmd.Flags().BoolVar(&analyzeRecursive, "recursive", false, "Analyze subfolders recursively")
 	permAnalyzeCmd.Flags().IntVar(&analyzeMaxDepth, "max-depth", 0, "Maximum recursion depth (0 = unlimited)")
 	permAnalyzeCmd.Flags().BoolVar(&analyzeIncludeDetails, "include-details", false, "Include detailed file lists")
-	permAnalyzeCmd.Flags().StringVar(&analyzeInternalDomain, "internal-domain", "", "Internal domain for external detection")
+	permAnalyzeCmd.Flags().StringSliceVar(&analyzeInternalDomain, "internal-domain", nil, "Internal domains for external detection (default: the profile's configured domains)")
+	permAnalyzeCmd.Flags().BoolVar(&analyzeFollowShortcut, "follow-shortcuts", false, "Analyze shortcut targets' permissions instead of the shortcuts'")
+	permAnalyzeCmd.Flags().BoolVar(&analyzeIgnoreMembers, "ignore-drive-membership", false, "Leave out permissions inherited from Shared Drive membership")
 
 	// Report flags
-	permReportCmd.Flags().StringVar(&analyzeInternalDomain, "internal-domain", "", "Internal domain for external detection")
+	permReportCmd.Flags().StringSliceVar(&analyzeInternalDomain, "internal-domain", nil, "Internal domains for external detection (default: the profile's configured domains)")
+
+	// Sheet export flags
+	permAuditCmd.PersistentFlags().StringVar(&permOutputSheet, "output-sheet", "", "Also write findings to a new tab in this spreadsheet ID, or 'new' to create one")
+	permAnalyzeCmd.Flags().StringVar(&permOutputSheet, "output-sheet", "", "Also write findings to a new tab in this spreadsheet ID, or 'new' to create one (implies --include-details)")
+	permReportCmd.Flags().StringVar(&permOutputSheet, "output-sheet", "", "Also write the report's permissions to a new tab in this spreadsheet ID, or 'new' to create one")
+	// Writing the tab needs the Sheets scope, which the read commands do not
+	_ = permAuditCmd.PersistentFlags().SetAnnotation("output-sheet", familyAnnotation, []string{string(auth.FamilySheets)})
+	_ = permAnalyzeCmd.Flags().SetAnnotation("output-sheet", familyAnnotation, []string{string(auth.FamilySheets)})
+	_ = permReportCmd.Flags().SetAnnotation("output-sheet", familyAnnotation, []string{string(auth.FamilySheets)})
 
 	// Bulk remove public flags
 	permBulkRemovePublicCmd.Flags().StringVar(&bulkFolderID, "folder-id", "", "Folder to operate on (required)")
 	permBulkRemovePublicCmd.Flags().BoolVar(&bulkRecursive, "recursive", false, "Include subfolders")
 	permBulkRemovePublicCmd.Flags().IntVar(&bulkMaxFiles, "max-files", 0, "Maximum files to process (0 = unlimited)")
 	permBulkRemovePublicCmd.Flags().BoolVar(&bulkContinueOnError, "continue-on-error", false, "Continue if individual operations fail")
+	permBulkRemovePublicCmd.Flags().StringVar(&bulkMaxErrorRate, "max-error-rate", "", "Continue past failures until the rolling failure rate exceeds this threshold (e.g. 5%)")
+	permBulkRemovePublicCmd.Flags().IntVar(&bulkBatchSize, "batch-size", api.MaxBatchSize, "Permission changes sent per batch request (1 sends each on its own)")
 	_ = permBulkRemovePublicCmd.MarkFlagRequired("folder-id")
 
 	// Bulk update role flags
@@ -257,15 +384,50 @@ func init() {
 	permBulkUpdateRoleCmd.Flags().StringVar(&bulkToRole, "to-role", "", "Target role (required)")
 	permBulkUpdateRoleCmd.Flags().IntVar(&bulkMaxFiles, "max-files", 0, "Maximum files to process (0 = unlimited)")
 	permBulkUpdateRoleCmd.Flags().BoolVar(&bulkContinueOnError, "continue-on-error", false, "Continue if individual operations fail")
+	permBulkUpdateRoleCmd.Flags().StringVar(&bulkMaxErrorRate, "max-error-rate", "", "Continue past failures until the rolling failure rate exceeds this threshold (e.g. 5%)")
+	permBulkUpdateRoleCmd.Flags().IntVar(&bulkBatchSize, "batch-size", api.MaxBatchSize, "Permission changes sent per batch request (1 sends each on its own)")
 	_ = permBulkUpdateRoleCmd.MarkFlagRequired("folder-id")
 	_ = permBulkUpdateRoleCmd.MarkFlagRequired("from-role")
+	permBulkUpdateRoleCmd.Flags().BoolVar(&bulkDowngradeOnly, "downgrade-only", false, "Refuse role changes that grant more access")
+	permBulkUpdateRoleCmd.Flags().BoolVar(&bulkUpgradeOnly, "upgrade-only", false, "Refuse role changes that grant less access; required for upgrades")
 	_ = permBulkUpdateRoleCmd.MarkFlagRequired("to-role")
+	permBulkUpdateRoleCmd.MarkFlagsMutuallyExclusive("downgrade-only", "upgrade-only")
+
+	// Bulk share flags
+	permBulkShareCmd.Flags().StringVar(&bulkFolderID, "folder-id", "", "Folder to operate on (required)")
+	permBulkShareCmd.Flags().BoolVar(&bulkRecursive, "recursive", false, "Include subfolders")
+	permBulkShareCmd.Flags().StringVar(&permType, "type", "", "Permission type (user, group, domain, anyone)")
+	permBulkShareCmd.Flags().StringVar(&permRole, "role", "", "Permission role (reader, commenter, writer, organizer)")
+	permBulkShareCmd.Flags().StringVar(&permEmail, "email", "", "Email address (for user/group type)")
+	permBulkShareCmd.Flags().StringVar(&permDomain, "domain", "", "Domain (for domain type)")
+	permBulkShareCmd.Flags().BoolVar(&permSendNotification, "send-notification", true, "Send email notification")
+	permBulkShareCmd.Flags().StringVar(&permEmailMessage, "message", "", "Custom email message")
+	permBulkShareCmd.Flags().StringVar(&permMessageTemplate, "message-template", "", "File with a Go template for the email message, rendered per file")
+	permBulkShareCmd.Flags().IntVar(&bulkMaxFiles, "max-files", 0, "Maximum files to process (0 = unlimited)")
+	permBulkShareCmd.Flags().BoolVar(&bulkContinueOnError, "continue-on-error", false, "Continue if individual operations fail")
+	permBulkShareCmd.Flags().StringVar(&bulkMaxErrorRate, "max-error-rate", "", "Continue past failures until the rolling failure rate exceeds this threshold (e.g. 5%)")
+	permBulkShareCmd.Flags().IntVar(&bulkBatchSize, "batch-size", api.MaxBatchSize, "Permission changes sent per batch request (1 sends each on its own)")
+	permBulkShareCmd.Flags().BoolVar(&permStrictPolicy, "strict-policy", false, "Refuse item-level grants inside a Shared Drive instead of warning")
+	permBulkShareCmd.MarkFlagsMutuallyExclusive("message", "message-template")
+	_ = permBulkShareCmd.MarkFlagRequired("folder-id")
+	_ = permBulkShareCmd.MarkFlagRequired("type")
+	_ = permBulkShareCmd.MarkFlagRequired("role")
 
 	// Search flags
 	permSearchCmd.Flags().StringVar(&searchEmail, "email", "", "Search by email address")
 	permSearchCmd.Flags().StringVar(&searchRole, "role", "", "Search by role")
 	permSearchCmd.Flags().StringVar(&searchFolderID, "folder-id", "", "Limit search to specific folder")
 	permSearchCmd.Flags().BoolVar(&searchRecursive, "recursive", false, "Include subfolders")
+
+	// Compare flags
+	permCompareCmd.Flags().StringVar(&compareProfileA, "profile-a", "", "First profile (required)")
+	permCompareCmd.Flags().StringVar(&compareProfileB, "profile-b", "", "Second profile (required)")
+	permCompareCmd.Flags().StringVar(&comparePath, "path", "", "Path or file ID to compare (required)")
+	permCompareCmd.Flags().StringVar(&comparePathB, "path-b", "", "Path or file ID in the second profile, if different")
+	permCompareCmd.Flags().StringArrayVar(&compareDomainMap, "domain-map", nil, "Treat a domain in profile A as another in profile B (from=to, repeatable)")
+	_ = permCompareCmd.MarkFlagRequired("profile-a")
+	_ = permCompareCmd.MarkFlagRequired("profile-b")
+	_ = permCompareCmd.MarkFlagRequired("path")
 }
 
 func getPermissionManager() (*permissions.Manager, error) {
@@ -279,15 +441,41 @@ func getPermissionManager() (*permissions.Manager, error) {
 			"Authentication required. Run 'gdrv auth login' first.").Build())
 	}
 
-	service, err := authMgr.GetDriveService(context.Background(), creds)
+	ctx := context.Background()
+	service, err := authMgr.GetDriveService(ctx, creds)
 	if err != nil {
 		return nil, err
 	}
 
 	client := api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
+	client.SetHTTPClient(authMgr.GetHTTPClient(ctx, creds))
 	return permissions.NewManager(client), nil
 }
 
+func addPermSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&permEmail, "email", "", "Select the user or group permission with this email address")
+	cmd.Flags().StringVar(&permDomain, "domain", "", "Select the domain permission for this domain")
+	cmd.Flags().BoolVar(&permAnyone, "anyone", false, "Select the public (anyone) permission")
+}
+
+// resolvePermissionArg returns the permission ID given on the command line,
+// or resolves it from the --email/--domain/--anyone selector.
+func resolvePermissionArg(ctx context.Context, mgr *permissions.Manager, reqCtx *types.RequestContext, args []string) (string, error) {
+	sel := permissions.Selector{Email: permEmail, Domain: permDomain, Anyone: permAnyone}
+	if len(args) == 2 {
+		if !sel.IsZero() {
+			return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"Specify either a permission ID or --email/--domain/--anyone, not both").Build())
+		}
+		return args[1], nil
+	}
+	if sel.IsZero() {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"A permission ID or one of --email, --domain or --anyone is required").Build())
+	}
+	return mgr.ResolvePermissionID(ctx, reqCtx, args[0], sel)
+}
+
 func runPermList(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
@@ -303,7 +491,10 @@ func runPermList(cmd *cobra.Command, args []string) error {
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	fileID := args[0]
 
-	result, err := mgr.List(context.Background(), reqCtx, fileID, permissions.ListOptions{})
+	result, err := mgr.List(context.Background(), reqCtx, fileID, permissions.ListOptions{
+		Full:             permListFull,
+		IncludePublished: permListPublished,
+	})
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
@@ -319,43 +510,83 @@ func runPermCreate(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
 
-	// Validate type
-	validTypes := map[string]bool{"user": true, "group": true, "domain": true, "anyone": true}
-	if !validTypes[permType] {
-		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeInvalidArgument,
-			"Invalid permission type. Must be one of: user, group, domain, anyone").Build())
+	opts, err := createOptionsFromFlags()
+	if err != nil {
+		return handleError(writer, "permissions.create", err)
 	}
 
-	// Validate role
-	validRoles := map[string]bool{"reader": true, "commenter": true, "writer": true, "organizer": true, "owner": true}
-	if !validRoles[permRole] {
-		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeInvalidArgument,
-			"Invalid permission role. Must be one of: reader, commenter, writer, organizer, owner").Build())
+	mgr, err := getPermissionManager()
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return writer.WriteError("permissions.create", appErr.CLIError)
+		}
+		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
-	// Validate email for user/group type
-	if (permType == "user" || permType == "group") && permEmail == "" {
-		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeInvalidArgument,
-			"Email address is required for user or group permission type").Build())
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+	fileID := args[0]
+
+	if err := checkDriveScope(context.Background(), mgr, reqCtx, writer, fileID, opts); err != nil {
+		return handleError(writer, "permissions.create", err)
 	}
 
-	// Validate domain for domain type
-	if permType == "domain" && permDomain == "" {
-		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeInvalidArgument,
-			"Domain is required for domain permission type").Build())
+	params := map[string]interface{}{"type": opts.Type, "role": opts.Role, "grantee": permissionGrantee(opts)}
+	if !opts.ExpirationTime.IsZero() {
+		params["expirationTime"] = opts.ExpirationTime.UTC().Format(time.RFC3339)
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreatePermission,
+		ResourceID:  fileID,
+		Description: "Grant " + opts.Role + " to " + permissionGrantee(opts),
+		Parameters:  params,
+		Predicted:   opts.Role + " granted",
+	}) {
+		return writer.WriteSuccess("permissions.create", nil)
 	}
 
-	mgr, err := getPermissionManager()
+	if err := mgr.RequireShare(context.Background(), reqCtx, fileID); err != nil {
+		return handleError(writer, "permissions.create", err)
+	}
+	result, err := mgr.Create(context.Background(), reqCtx, fileID, opts)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
+			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.create", appErr.CLIError)
 		}
 		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
-	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
-	fileID := args[0]
+	return writer.WriteSuccess("permissions.create", result)
+}
+
+// checkDriveScope warns about an item-level grant inside a Shared Drive,
+// or refuses it under --strict-policy
+func checkDriveScope(ctx context.Context, mgr *permissions.Manager, reqCtx *types.RequestContext, writer *OutputWriter, fileID string, share permissions.CreateOptions) error {
+	finding, err := mgr.CheckDriveScope(ctx, reqCtx, fileID, share)
+	if err != nil || finding == nil {
+		return err
+	}
+	if permStrictPolicy {
+		return finding.Err()
+	}
+	writer.AddWarning("DRIVE_SCOPE", finding.Message()+"; instead: "+finding.SuggestedAction(), "medium")
+	return nil
+}
 
+// permissionGrantee names who a grant is for
+func permissionGrantee(opts permissions.CreateOptions) string {
+	switch opts.Type {
+	case "domain":
+		return opts.Domain
+	case "anyone":
+		return "anyone"
+	}
+	return opts.EmailAddress
+}
+
+// createOptionsFromFlags validates the grant flags shared by create and
+// bulk share and loads --message-template
+func createOptionsFromFlags() (permissions.CreateOptions, error) {
 	opts := permissions.CreateOptions{
 		Type:                  permType,
 		Role:                  permRole,
@@ -367,16 +598,48 @@ func runPermCreate(cmd *cobra.Command, args []string) error {
 		AllowFileDiscovery:    permAllowFileDiscovery,
 	}
 
-	result, err := mgr.Create(context.Background(), reqCtx, fileID, opts)
-	if err != nil {
-		if appErr, ok := err.(*utils.AppError); ok {
-			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
-			return writer.WriteError("permissions.create", appErr.CLIError)
+	validTypes := map[string]bool{"user": true, "group": true, "domain": true, "anyone": true}
+	if !validTypes[permType] {
+		return opts, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Invalid permission type. Must be one of: user, group, domain, anyone").Build())
+	}
+
+	validRoles := map[string]bool{"reader": true, "commenter": true, "writer": true, "organizer": true, "owner": true}
+	if !validRoles[permRole] {
+		return opts, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Invalid permission role. Must be one of: reader, commenter, writer, organizer, owner").Build())
+	}
+
+	if (permType == "user" || permType == "group") && permEmail == "" {
+		return opts, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Email address is required for user or group permission type").Build())
+	}
+
+	if permType == "domain" && permDomain == "" {
+		return opts, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Domain is required for domain permission type").Build())
+	}
+
+	if permExpires != "" {
+		now := time.Now()
+		expires, err := permissions.ParseExpiration(permExpires, now)
+		if err != nil {
+			return opts, err
 		}
-		return writer.WriteError("permissions.create", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		if err := permissions.ValidateExpiration(permType, permRole, expires, now); err != nil {
+			return opts, err
+		}
+		opts.ExpirationTime = expires
 	}
 
-	return writer.WriteSuccess("permissions.create", result)
+	if permMessageTemplate != "" {
+		tmpl, err := permissions.LoadMessageTemplate(permMessageTemplate)
+		if err != nil {
+			return opts, err
+		}
+		opts.MessageTemplate = tmpl
+	}
+	return opts, nil
 }
 
 func runPermUpdate(cmd *cobra.Command, args []string) error {
@@ -385,10 +648,26 @@ func runPermUpdate(cmd *cobra.Command, args []string) error {
 
 	// Validate role
 	validRoles := map[string]bool{"reader": true, "commenter": true, "writer": true, "organizer": true, "owner": true}
-	if !validRoles[permRole] {
+	if permRole == "" && permExpires == "" {
+		return writer.WriteError("permission.update", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Nothing to update: give --role, --expires or both").Build())
+	}
+	if permRole != "" && !validRoles[permRole] {
 		return writer.WriteError("permission.update", utils.NewCLIError(utils.ErrCodeInvalidArgument,
 			"Invalid permission role. Must be one of: reader, commenter, writer, organizer, owner").Build())
 	}
+	updateOpts := permissions.UpdateOptions{Role: permRole}
+	if permExpires != "" {
+		now := time.Now()
+		expires, err := permissions.ParseExpiration(permExpires, now)
+		if err == nil {
+			err = permissions.ValidateExpiration("", permRole, expires, now)
+		}
+		if err != nil {
+			return handleError(writer, "permission.update", err)
+		}
+		updateOpts.ExpirationTime = expires
+	}
 
 	mgr, err := getPermissionManager()
 	if err != nil {
@@ -400,9 +679,21 @@ func runPermUpdate(cmd *cobra.Command, args []string) error {
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	fileID := args[0]
-	permissionID := args[1]
+	permissionID, err := resolvePermissionArg(context.Background(), mgr, reqCtx, args)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return writer.WriteError("permission.update", appErr.CLIError)
+		}
+		return writer.WriteError("permission.update", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
 
-	result, err := mgr.Update(context.Background(), reqCtx, fileID, permissionID, permissions.UpdateOptions{Role: permRole})
+	if !flags.DryRun {
+		if err := mgr.RequireShare(context.Background(), reqCtx, fileID); err != nil {
+			return handleError(writer, "permission.update", err)
+		}
+	}
+	result, err := mgr.UpdateWithSafety(context.Background(), reqCtx, fileID, permissionID,
+		updateOpts, dryRunSafety(flags), planRecorder())
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
@@ -410,6 +701,9 @@ func runPermUpdate(cmd *cobra.Command, args []string) error {
 		}
 		return writer.WriteError("permission.update", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
+	if flags.DryRun {
+		return writer.WriteSuccess("permission.update", nil)
+	}
 
 	return writer.WriteSuccess("permission.update", result)
 }
@@ -428,9 +722,21 @@ func runPermRemove(cmd *cobra.Command, args []string) error {
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	fileID := args[0]
-	permissionID := args[1]
+	permissionID, err := resolvePermissionArg(context.Background(), mgr, reqCtx, args)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return writer.WriteError("permission.remove", appErr.CLIError)
+		}
+		return writer.WriteError("permission.remove", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
 
-	err = mgr.Delete(context.Background(), reqCtx, fileID, permissionID, permissions.DeleteOptions{})
+	if !flags.DryRun {
+		if err := mgr.RequireShare(context.Background(), reqCtx, fileID); err != nil {
+			return handleError(writer, "permission.remove", err)
+		}
+	}
+	err = mgr.DeleteWithSafety(context.Background(), reqCtx, fileID, permissionID,
+		permissions.DeleteOptions{}, dryRunSafety(flags), planRecorder())
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
@@ -438,6 +744,9 @@ func runPermRemove(cmd *cobra.Command, args []string) error {
 		}
 		return writer.WriteError("permission.remove", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
+	if flags.DryRun {
+		return writer.WriteSuccess("permission.remove", nil)
+	}
 
 	return writer.WriteSuccess("permission.remove", map[string]interface{}{
 		"deleted":      true,
@@ -467,6 +776,16 @@ func runPermCreateLink(cmd *cobra.Command, args []string) error {
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	fileID := args[0]
 
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeCreatePermission,
+		ResourceID:  fileID,
+		Description: "Create public link: " + fileID,
+		Parameters:  map[string]interface{}{"type": "anyone", "role": permRole, "allowFileDiscovery": permAllowFileDiscovery},
+		Predicted:   "anyone with the link gets " + permRole,
+	}) {
+		return writer.WriteSuccess("permission.create-link", nil)
+	}
+
 	result, err := mgr.CreatePublicLink(context.Background(), reqCtx, fileID, permRole, permAllowFileDiscovery)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
@@ -496,18 +815,33 @@ func runPermAuditPublic(cmd *cobra.Command, args []string) error {
 		FolderID:           auditFolderID,
 		Recursive:          auditRecursive,
 		IncludePermissions: auditIncludePerms,
+		FollowShortcuts:    auditFollowShortcut,
 	}
 
-	result, err := mgr.AuditPublic(context.Background(), reqCtx, opts)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.AuditPublic(ctx, reqCtx, opts)
+	done()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.audit.public", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.audit.public", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.audit.public", err)
+	}
+
+	if permOutputSheet != "" {
+		if err := exportToSheet(writer, flags, permOutputSheet, "permissions.audit.public", result); err != nil {
+			return handleError(writer, "permissions.audit.public", err)
+		}
+	}
+	if err := notifyOwners(writer, flags, mgr, "permissions.audit.public", result); err != nil {
+		return handleError(writer, "permissions.audit.public", err)
 	}
 
-	return writer.WriteSuccess("permissions.audit.public", result)
+	recordAuditTrend(writer, "public", opts, result)
+	return writer.WriteSuccess("permissions.audit.public", auditOutput("public", result))
 }
 
 func runPermAuditExternal(cmd *cobra.Command, args []string) error {
@@ -522,24 +856,50 @@ func runPermAuditExternal(cmd *cobra.Command, args []string) error {
 		return writer.WriteError("permissions.audit.external", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
+	internalDomains, err := resolveInternalDomains(flags.Profile, auditInternalDomain)
+	if err != nil {
+		return handleError(writer, "permissions.audit.external", err)
+	}
+	if len(internalDomains) == 0 {
+		return writer.WriteError("permissions.audit.external", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"No internal domains given").
+			WithContext("suggestedAction", "pass --internal-domain or run 'gdrv config domains add' or 'gdrv config domains discover'").
+			Build())
+	}
+
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	opts := types.AuditOptions{
 		FolderID:           auditFolderID,
 		Recursive:          auditRecursive,
-		InternalDomain:     auditInternalDomain,
+		InternalDomains:    internalDomains,
 		IncludePermissions: auditIncludePerms,
+		FollowShortcuts:    auditFollowShortcut,
 	}
 
-	result, err := mgr.AuditExternal(context.Background(), reqCtx, opts)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.AuditExternal(ctx, reqCtx, opts)
+	done()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.audit.external", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.audit.external", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.audit.external", err)
+	}
+
+	if permOutputSheet != "" {
+		if err := exportToSheet(writer, flags, permOutputSheet, "permissions.audit.external", result); err != nil {
+			return handleError(writer, "permissions.audit.external", err)
+		}
+	}
+	if err := notifyOwners(writer, flags, mgr, "permissions.audit.external", result); err != nil {
+		return handleError(writer, "permissions.audit.external", err)
 	}
 
-	return writer.WriteSuccess("permissions.audit.external", result)
+	recordAuditTrend(writer, "external", opts, result)
+	return writer.WriteSuccess("permissions.audit.external", auditOutput("external", result))
 }
 
 func runPermAuditAnyoneWithLink(cmd *cobra.Command, args []string) error {
@@ -559,18 +919,33 @@ func runPermAuditAnyoneWithLink(cmd *cobra.Command, args []string) error {
 		FolderID:           auditFolderID,
 		Recursive:          auditRecursive,
 		IncludePermissions: auditIncludePerms,
+		FollowShortcuts:    auditFollowShortcut,
 	}
 
-	result, err := mgr.AuditAnyoneWithLink(context.Background(), reqCtx, opts)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.AuditAnyoneWithLink(ctx, reqCtx, opts)
+	done()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.audit.anyone-with-link", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.audit.anyone-with-link", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.audit.anyone-with-link", err)
+	}
+
+	if permOutputSheet != "" {
+		if err := exportToSheet(writer, flags, permOutputSheet, "permissions.audit.anyone-with-link", result); err != nil {
+			return handleError(writer, "permissions.audit.anyone-with-link", err)
+		}
+	}
+	if err := notifyOwners(writer, flags, mgr, "permissions.audit.anyone-with-link", result); err != nil {
+		return handleError(writer, "permissions.audit.anyone-with-link", err)
 	}
 
-	return writer.WriteSuccess("permissions.audit.anyone-with-link", result)
+	recordAuditTrend(writer, "anyone-with-link", opts, result)
+	return writer.WriteSuccess("permissions.audit.anyone-with-link", auditOutput("anyone-with-link", result))
 }
 
 func runPermAuditUser(cmd *cobra.Command, args []string) error {
@@ -591,17 +966,32 @@ func runPermAuditUser(cmd *cobra.Command, args []string) error {
 		FolderID:           auditFolderID,
 		Recursive:          auditRecursive,
 		IncludePermissions: auditIncludePerms,
+		FollowShortcuts:    auditFollowShortcut,
 	}
 
-	result, err := mgr.AuditUser(context.Background(), reqCtx, email, opts)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.AuditUser(ctx, reqCtx, email, opts)
+	done()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.audit.user", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.audit.user", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.audit.user", err)
+	}
+
+	if permOutputSheet != "" {
+		if err := exportToSheet(writer, flags, permOutputSheet, "permissions.audit.user", result); err != nil {
+			return handleError(writer, "permissions.audit.user", err)
+		}
+	}
+	if err := notifyOwners(writer, flags, mgr, "permissions.audit.user", result); err != nil {
+		return handleError(writer, "permissions.audit.user", err)
 	}
 
+	recordAuditTrend(writer, "user:"+email, opts, result)
 	return writer.WriteSuccess("permissions.audit.user", result)
 }
 
@@ -617,22 +1007,39 @@ func runPermAnalyze(cmd *cobra.Command, args []string) error {
 		return writer.WriteError("permissions.analyze", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
+	internalDomains, err := resolveInternalDomains(flags.Profile, analyzeInternalDomain)
+	if err != nil {
+		return handleError(writer, "permissions.analyze", err)
+	}
+
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	folderID := args[0]
 	opts := types.AnalyzeOptions{
-		Recursive:      analyzeRecursive,
-		MaxDepth:       analyzeMaxDepth,
-		IncludeDetails: analyzeIncludeDetails,
-		InternalDomain: analyzeInternalDomain,
+		Recursive:             analyzeRecursive,
+		MaxDepth:              analyzeMaxDepth,
+		IncludeDetails:        analyzeIncludeDetails || permOutputSheet != "",
+		InternalDomains:       internalDomains,
+		FollowShortcuts:       analyzeFollowShortcut,
+		IgnoreDriveMembership: analyzeIgnoreMembers,
 	}
 
-	result, err := mgr.AnalyzeFolder(context.Background(), reqCtx, folderID, opts)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.AnalyzeFolder(ctx, reqCtx, folderID, opts)
+	done()
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.analyze", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.analyze", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.analyze", err)
+	}
+
+	if permOutputSheet != "" {
+		if err := exportToSheet(writer, flags, permOutputSheet, "permissions.analyze", result); err != nil {
+			return handleError(writer, "permissions.analyze", err)
+		}
 	}
 
 	return writer.WriteSuccess("permissions.analyze", result)
@@ -650,10 +1057,15 @@ func runPermReport(cmd *cobra.Command, args []string) error {
 		return writer.WriteError("permissions.report", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
+	internalDomains, err := resolveInternalDomains(flags.Profile, analyzeInternalDomain)
+	if err != nil {
+		return handleError(writer, "permissions.report", err)
+	}
+
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
 	fileID := args[0]
 
-	result, err := mgr.GenerateReport(context.Background(), reqCtx, fileID, analyzeInternalDomain)
+	result, err := mgr.GenerateReport(context.Background(), reqCtx, fileID, internalDomains)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
@@ -662,9 +1074,41 @@ func runPermReport(cmd *cobra.Command, args []string) error {
 		return writer.WriteError("permissions.report", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
 	}
 
+	if permOutputSheet != "" {
+		if err := exportToSheet(writer, flags, permOutputSheet, "permissions.report", result); err != nil {
+			return handleError(writer, "permissions.report", err)
+		}
+	}
+
 	return writer.WriteSuccess("permissions.report", result)
 }
 
+// bulkOptionsFromFlags builds the options shared by the bulk commands.
+// --max-error-rate lets the run go on past failures until the rate is
+// exceeded; 0 is refused because leaving the flag out already stops at the
+// first failure.
+func bulkOptionsFromFlags(flags types.GlobalFlags) (types.BulkOptions, error) {
+	opts := types.BulkOptions{
+		FolderID:        bulkFolderID,
+		Recursive:       bulkRecursive,
+		DryRun:          flags.DryRun,
+		MaxFiles:        bulkMaxFiles,
+		BatchSize:       bulkBatchSize,
+		ContinueOnError: bulkContinueOnError,
+	}
+	if bulkMaxErrorRate != "" {
+		rate, err := safety.ParseErrorRate(bulkMaxErrorRate)
+		if err != nil {
+			return opts, err
+		}
+		if rate == 0 {
+			return opts, fmt.Errorf("--max-error-rate must be above 0%%; leave it out to stop at the first failure")
+		}
+		opts.MaxErrorRate = rate
+	}
+	return opts, nil
+}
+
 func runPermBulkRemovePublic(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
@@ -678,26 +1122,92 @@ func runPermBulkRemovePublic(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
-	opts := types.BulkOptions{
-		FolderID:        bulkFolderID,
-		Recursive:       bulkRecursive,
-		DryRun:          flags.DryRun,
-		MaxFiles:        bulkMaxFiles,
-		ContinueOnError: bulkContinueOnError,
+	opts, err := bulkOptionsFromFlags(flags)
+	if err != nil {
+		return writer.WriteError("permissions.bulk.remove-public", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
 	}
 
-	result, err := mgr.BulkRemovePublic(context.Background(), reqCtx, opts)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	params := map[string]interface{}{"type": "anyone"}
+	err = approveBulk(flags, "permissions.bulk.remove-public", func() (*types.BulkOperationResult, error) {
+		preview := opts
+		preview.DryRun = true
+		defer startProgress(flags.Quiet, mgr.SetProgress)()
+		return mgr.BulkRemovePublic(ctx, reqCtx, preview)
+	}, safety.OpTypeDeletePermission, params, "public access removed")
 	if err != nil {
+		return handleError(writer, "permissions.bulk.remove-public", err)
+	}
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.BulkRemovePublic(ctx, reqCtx, opts)
+	done()
+	if err != nil {
+		if result != nil && result.Aborted {
+			if appErr, ok := err.(*utils.AppError); ok {
+				return writer.WriteError("permissions.bulk.remove-public", appErr.CLIError)
+			}
+		}
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.bulk.remove-public", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.bulk.remove-public", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.bulk.remove-public", err)
 	}
 
+	planBulkItems(result, safety.OpTypeDeletePermission, params, "public access removed")
 	return writer.WriteSuccess("permissions.bulk.remove-public", result)
 }
 
+func runPermBulkShare(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	share, err := createOptionsFromFlags()
+	if err != nil {
+		return handleError(writer, "permissions.bulk.share", err)
+	}
+
+	mgr, err := getPermissionManager()
+	if err != nil {
+		return handleError(writer, "permissions.bulk.share", err)
+	}
+
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+	opts, err := bulkOptionsFromFlags(flags)
+	if err != nil {
+		return writer.WriteError("permissions.bulk.share", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	if err := checkDriveScope(context.Background(), mgr, reqCtx, writer, bulkFolderID, share); err != nil {
+		return handleError(writer, "permissions.bulk.share", err)
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	params := map[string]interface{}{"type": share.Type, "role": share.Role, "grantee": permissionGrantee(share)}
+	err = approveBulk(flags, "permissions.bulk.share", func() (*types.BulkOperationResult, error) {
+		preview := opts
+		preview.DryRun = true
+		defer startProgress(flags.Quiet, mgr.SetProgress)()
+		return mgr.BulkShare(ctx, reqCtx, share, preview)
+	}, safety.OpTypeCreatePermission, params, share.Role+" granted")
+	if err != nil {
+		return handleError(writer, "permissions.bulk.share", err)
+	}
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.BulkShare(ctx, reqCtx, share, opts)
+	done()
+	if err != nil {
+		return handleError(writer, "permissions.bulk.share", err)
+	}
+
+	planBulkItems(result, safety.OpTypeCreatePermission, params, share.Role+" granted")
+	return writer.WriteSuccess("permissions.bulk.share", result)
+}
+
 func runPermBulkUpdateRole(cmd *cobra.Command, args []string) error {
 	flags := GetGlobalFlags()
 	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
@@ -711,23 +1221,86 @@ func runPermBulkUpdateRole(cmd *cobra.Command, args []string) error {
 	}
 
 	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
-	opts := types.BulkOptions{
-		FolderID:        bulkFolderID,
-		Recursive:       bulkRecursive,
-		DryRun:          flags.DryRun,
-		MaxFiles:        bulkMaxFiles,
-		ContinueOnError: bulkContinueOnError,
+	opts, err := bulkOptionsFromFlags(flags)
+	if err != nil {
+		return writer.WriteError("permissions.bulk.update-role", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	switch {
+	case bulkDowngradeOnly:
+		opts.RoleDirection = permissions.RoleChangeDowngrade
+	case bulkUpgradeOnly:
+		opts.RoleDirection = permissions.RoleChangeUpgrade
+	}
+	direction, err := permissions.CheckRoleChange(bulkFromRole, bulkToRole, opts.RoleDirection)
+	if err != nil {
+		return handleError(writer, "permissions.bulk.update-role", err)
+	}
+	if direction == permissions.RoleChangeUpgrade && !bulkUpgradeOnly {
+		msg := fmt.Sprintf("%s → %s is a role upgrade", bulkFromRole, bulkToRole)
+		if !flags.DryRun {
+			return writer.WriteError("permissions.bulk.update-role", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				msg+"; pass --upgrade-only to confirm the upgrade is intended").
+				WithContext("suggestedAction", "check the order of --from-role and --to-role").
+				Build())
+		}
+		writer.AddWarning("ROLE_UPGRADE", msg+"; --upgrade-only will be required to apply it", "high")
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	params := map[string]interface{}{"fromRole": bulkFromRole, "newRole": bulkToRole}
+	err = approveBulk(flags, "permissions.bulk.update-role", func() (*types.BulkOperationResult, error) {
+		preview := opts
+		preview.DryRun = true
+		defer startProgress(flags.Quiet, mgr.SetProgress)()
+		return mgr.BulkUpdateRole(ctx, reqCtx, bulkFromRole, bulkToRole, preview)
+	}, safety.OpTypeUpdatePermission, params, "role set to "+bulkToRole)
+	if err != nil {
+		return handleError(writer, "permissions.bulk.update-role", err)
+	}
+
+	if !flags.DryRun {
+		scope := fmt.Sprintf("files in folder %s", bulkFolderID)
+		if bulkRecursive {
+			scope = fmt.Sprintf("folder %s and all its subfolders", bulkFolderID)
+		}
+		if bulkMaxFiles > 0 {
+			scope += fmt.Sprintf(" (at most %d files)", bulkMaxFiles)
+		}
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		confirmed, err := safety.Confirm(safety.Msg(safety.MsgRoleChangeConfirm, map[string]interface{}{
+			"Direction": direction,
+			"From":      bulkFromRole,
+			"To":        bulkToRole,
+			"Scope":     scope,
+		}), safetyOpts.ForScope(safety.ScopePermissions))
+		if err != nil {
+			return handleError(writer, "permissions.bulk.update-role", err)
+		}
+		if !confirmed {
+			return writer.WriteError("permissions.bulk.update-role", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
 	}
 
-	result, err := mgr.BulkUpdateRole(context.Background(), reqCtx, bulkFromRole, bulkToRole, opts)
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.BulkUpdateRole(ctx, reqCtx, bulkFromRole, bulkToRole, opts)
+	done()
 	if err != nil {
+		if result != nil && result.Aborted {
+			if appErr, ok := err.(*utils.AppError); ok {
+				return writer.WriteError("permissions.bulk.update-role", appErr.CLIError)
+			}
+		}
 		if appErr, ok := err.(*utils.AppError); ok {
 			os.Exit(utils.GetExitCode(appErr.CLIError.Code))
 			return writer.WriteError("permissions.bulk.update-role", appErr.CLIError)
 		}
-		return writer.WriteError("permissions.bulk.update-role", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return handleError(writer, "permissions.bulk.update-role", err)
 	}
 
+	planBulkItems(result, safety.OpTypeUpdatePermission, params, "role set to "+bulkToRole)
 	return writer.WriteSuccess("permissions.bulk.update-role", result)
 }
 
@@ -773,3 +1346,63 @@ func runPermSearch(cmd *cobra.Command, args []string) error {
 
 	return writer.WriteSuccess("permissions.search", result)
 }
+
+func runPermCompare(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	domainMap, err := permissions.ParseDomainMap(compareDomainMap)
+	if err != nil {
+		return handleError(writer, "permissions.compare", err)
+	}
+
+	pathB := comparePathB
+	if pathB == "" {
+		pathB = comparePath
+	}
+
+	fileIDA, permsA, err := listPermissionsForProfile(ctx, flags, compareProfileA, comparePath)
+	if err != nil {
+		return handleError(writer, "permissions.compare", err)
+	}
+	fileIDB, permsB, err := listPermissionsForProfile(ctx, flags, compareProfileB, pathB)
+	if err != nil {
+		return handleError(writer, "permissions.compare", err)
+	}
+
+	result := permissions.ComparePermissions(permsA, permsB, domainMap)
+	result.ProfileA, result.ProfileB = compareProfileA, compareProfileB
+	result.PathA, result.PathB = comparePath, pathB
+	result.FileIDA, result.FileIDB = fileIDA, fileIDB
+
+	if !result.InSync {
+		writer.AddWarning("PERMISSION_DRIFT", fmt.Sprintf("%d grantee(s) differ between %s and %s",
+			len(result.Drift), compareProfileA, compareProfileB), "medium")
+	}
+	return writer.WriteSuccess("permissions.compare", result)
+}
+
+// listPermissionsForProfile resolves path under profile and lists its
+// permissions. The global drive ID is not applied, since it can only be
+// valid in one tenant.
+func listPermissionsForProfile(ctx context.Context, flags types.GlobalFlags, profile, path string) (string, []*types.Permission, error) {
+	client, err := getAPIClient(ctx, profile)
+	if err != nil {
+		return "", nil, err
+	}
+
+	flags.Profile = profile
+	flags.DriveID = ""
+	fileID, err := ResolveFileID(ctx, client, flags, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	reqCtx := api.NewRequestContext(profile, "", types.RequestTypePermissionOp)
+	perms, err := permissions.NewManager(client).List(ctx, reqCtx, fileID, permissions.ListOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+	return fileID, perms, nil
+}
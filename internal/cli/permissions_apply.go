@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permApplyCmd = &cobra.Command{
+	Use:   "apply --manifest <file>",
+	Short: "Create or update grants from a CSV or JSON manifest",
+	Long: `Apply a sharing policy across many files in one run. Each manifest row names
+a file (by ID or path), a grant type, a role and the grantee; the grant is
+created, or its role updated when the grantee already has access. Rows whose
+grantee already has the role are left alone, so a manifest can be re-applied.
+
+CSV manifests have a header row naming the columns:
+
+  file_id,path,type,role,email,domain
+  1AbC...,,user,writer,alice@example.com,
+  ,/Finance/Q3.xlsx,domain,reader,,example.com
+
+JSON manifests are an array of objects with fileId, path, type, role, email
+and domain. Every row is attempted and reported; a failed row does not stop
+the run. Use --dry-run to see each row's action first.`,
+	Example: "  gdrv permissions apply --manifest sharing.csv --dry-run\n  gdrv permissions apply --manifest sharing.json --send-notification",
+	Args:    cobra.NoArgs,
+	RunE:    runPermApply,
+}
+
+var (
+	permApplyManifest    string
+	permApplyNotify      bool
+	permApplyDomainAdmin bool
+)
+
+func init() {
+	permApplyCmd.Flags().StringVar(&permApplyManifest, "manifest", "", "CSV or JSON manifest of grants (required)")
+	permApplyCmd.Flags().BoolVar(&permApplyNotify, "send-notification", false, "Email users and groups given new grants")
+	permApplyCmd.Flags().BoolVar(&permApplyDomainAdmin, "use-domain-admin-access", false, "Act as a Workspace admin")
+	_ = permApplyCmd.MarkFlagRequired("manifest")
+	permissionsCmd.AddCommand(permApplyCmd)
+}
+
+func runPermApply(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	rows, err := permissions.LoadManifest(permApplyManifest)
+	if err != nil {
+		return handleError(out, "permissions.apply", err)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "permissions.apply", err)
+	}
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+
+	opts := permissions.ManifestOptions{
+		SendNotificationEmail: permApplyNotify,
+		UseDomainAdminAccess:  permApplyDomainAdmin,
+		DryRun:                flags.DryRun,
+		Resolve: func(ctx context.Context, path string) (string, error) {
+			return ResolveFileID(ctx, client, flags, path)
+		},
+	}
+
+	// Which rows change is only known after reading each file's grants,
+	// so a run that needs approval plans first
+	if approvalNeeded(flags) {
+		preview := opts
+		preview.DryRun = true
+		planned, err := mgr.ApplyManifest(ctx, reqCtx, rows, preview)
+		if err != nil {
+			return handleError(out, "permissions.apply", err)
+		}
+		if err := requireApproval(flags, "permissions.apply", manifestOperations(planned)); err != nil {
+			return handleError(out, "permissions.apply", err)
+		}
+	}
+
+	if !flags.DryRun {
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		confirmed, err := safety.ConfirmBulkOperation(len(rows), "apply permission manifest", safetyOpts.ForScope(safety.ScopePermissions))
+		if err != nil {
+			return handleError(out, "permissions.apply", err)
+		}
+		if !confirmed && len(rows) > 0 {
+			return out.WriteError("permissions.apply", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	result, err := mgr.ApplyManifest(ctx, reqCtx, rows, opts)
+	done()
+	if err != nil {
+		return handleError(out, "permissions.apply", err)
+	}
+
+	if flags.DryRun {
+		for _, op := range manifestOperations(result) {
+			planOperation(op)
+		}
+	}
+	if result.Failed > 0 {
+		out.AddWarning("MANIFEST_ROWS_FAILED", fmt.Sprintf("%d of %d manifest rows failed; see row errors", result.Failed, result.Total), "high")
+	}
+	if flags.DryRun {
+		out.Log("Would create %d and update %d grants; %d unchanged, %d invalid", result.Created, result.Updated, result.Unchanged, result.Failed)
+	} else {
+		out.Log("Created %d, updated %d, unchanged %d, failed %d", result.Created, result.Updated, result.Unchanged, result.Failed)
+	}
+	return out.WriteSuccess("permissions.apply", result)
+}
+
+// manifestOperations describes the rows a dry run of a manifest planned
+func manifestOperations(result *permissions.ManifestResult) []safety.PlannedOperation {
+	var ops []safety.PlannedOperation
+	for _, item := range result.Items {
+		if item.Status == permissions.ManifestPlanned {
+			ops = append(ops, manifestRowOperation(item))
+		}
+	}
+	return ops
+}
+
+// manifestRowOperation describes a planned manifest row for the dry-run plan
+func manifestRowOperation(item *permissions.ManifestRowResult) safety.PlannedOperation {
+	op := safety.PlannedOperation{
+		ResourceID:   item.FileID,
+		ResourceName: item.File,
+		Description:  fmt.Sprintf("Row %d: %s %s %s", item.Row, item.Action, item.Type, item.Principal),
+		Parameters:   map[string]interface{}{"type": item.Type, "principal": item.Principal, "role": item.Role},
+	}
+	if item.Action == permissions.ManifestUpdate {
+		op.Type = safety.OpTypeUpdatePermission
+		op.Parameters["permissionID"] = item.PermissionID
+		op.Predicted = "role set to " + item.Role
+	} else {
+		op.Type = safety.OpTypeCreatePermission
+		op.Predicted = item.Role + " granted"
+	}
+	return op
+}
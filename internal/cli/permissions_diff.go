@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permDiffCmd = &cobra.Command{
+	Use:   "diff <source-id> <target-id>",
+	Short: "Show the changes that make a target's grants match a source's",
+	Long: `Compare the grants on two files or folders and list the additions, role
+changes and removals that would make the target's grants match the
+source's. Grantees are matched by email, domain or "anyone", not by
+permission ID.
+
+Owners are not compared. Grants the target inherits from its Shared Drive
+or a parent folder cannot be changed on the target and are listed as
+skipped. --keep-extra leaves target grants that the source lacks in place.
+
+With --apply the changes are made after confirmation; every change is
+attempted and reported as applied or failed. Use --dry-run to record them
+in the plan instead.`,
+	Example: "  gdrv permissions diff <template-folder-id> <new-folder-id>\n" +
+		"  gdrv permissions diff /Templates/Project /Projects/Apollo --apply --keep-extra",
+	Args: cobra.ExactArgs(2),
+	RunE: runPermDiff,
+}
+
+var (
+	permDiffApply       bool
+	permDiffKeepExtra   bool
+	permDiffNotify      bool
+	permDiffDomainAdmin bool
+)
+
+func init() {
+	permDiffCmd.Flags().BoolVar(&permDiffApply, "apply", false, "Apply the changes to the target")
+	permDiffCmd.Flags().BoolVar(&permDiffKeepExtra, "keep-extra", false, "Keep target grants the source does not have")
+	permDiffCmd.Flags().BoolVar(&permDiffNotify, "send-notification", false, "Email users and groups given new grants")
+	permDiffCmd.Flags().BoolVar(&permDiffDomainAdmin, "use-domain-admin-access", false, "Act as a Workspace admin")
+	permissionsCmd.AddCommand(permDiffCmd)
+}
+
+func runPermDiff(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+	sourceID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+	targetID, err := ResolveFileID(ctx, client, flags, args[1])
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+	diff, err := mgr.Diff(ctx, reqCtx, sourceID, targetID, permissions.DiffOptions{
+		UseDomainAdminAccess: permDiffDomainAdmin,
+		KeepExtra:            permDiffKeepExtra,
+	})
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+	if len(diff.Skipped) > 0 {
+		out.AddWarning("INHERITED_GRANTS_SKIPPED",
+			"some target grants are inherited and cannot be changed on the target; see skipped", "medium")
+	}
+	if !permDiffApply || diff.InSync {
+		return out.WriteSuccess("permissions.diff", diff)
+	}
+
+	ops := make([]safety.PlannedOperation, len(diff.Changes))
+	for i, change := range diff.Changes {
+		ops[i] = editChangeOperation(targetID, change)
+	}
+	planned, err := planOrApprove(flags, "permissions.diff", ops)
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+	if planned {
+		out.Log("Dry run: %d changes not applied", len(diff.Changes))
+		return out.WriteSuccess("permissions.diff", diff)
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.ConfirmBulkOperation(len(diff.Changes), "change permissions on "+targetID, safetyOpts.ForScope(safety.ScopePermissions))
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+	if !confirmed {
+		return out.WriteError("permissions.diff", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	err = mgr.ApplyEdits(ctx, reqCtx, targetID, diff.Changes, permissions.EditApplyOptions{
+		SendNotificationEmail: permDiffNotify,
+		UseDomainAdminAccess:  permDiffDomainAdmin,
+	})
+	if err != nil {
+		return handleError(out, "permissions.diff", err)
+	}
+	diff.Applied = true
+	out.Log("Applied %d changes", len(diff.Changes))
+	return out.WriteSuccess("permissions.diff", diff)
+}
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var permEditCmd = &cobra.Command{
+	Use:   "edit <file-id>",
+	Short: "Edit a file's grants interactively",
+	Long: `Open an inline editor listing the file's current grants. Stage role
+changes, removals and new grants with short commands, preview the diff,
+then commit to apply every change at once. Nothing is changed until
+commit is confirmed; quit discards staged changes.
+
+Commands read from standard input, so an edit can also be scripted:
+
+  echo -e "rm 3\ncommit\ny" | gdrv permissions edit <file-id>
+
+With --dry-run, commit shows the diff without applying it.`,
+	Example: "  gdrv permissions edit <file-id>",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPermEdit,
+}
+
+var (
+	permEditNotify      bool
+	permEditDomainAdmin bool
+)
+
+func init() {
+	permEditCmd.Flags().BoolVar(&permEditNotify, "send-notification", false, "Email users and groups given new grants")
+	permEditCmd.Flags().BoolVar(&permEditDomainAdmin, "use-domain-admin-access", false, "Act as a Workspace admin")
+	permissionsCmd.AddCommand(permEditCmd)
+}
+
+const permEditHelp = `Commands:
+  <n> <role>               change grant n to role (reader, commenter, writer, fileOrganizer, organizer)
+  rm <n>                   remove grant n
+  add [type] <who> <role>  add a grant; type is user (default), group, domain or anyone
+  reset <n>                undo the staged change to grant n
+  list                     show the grants again
+  diff                     preview the staged changes
+  commit                   preview, confirm and apply the staged changes
+  quit                     discard the staged changes and exit
+`
+
+func runPermEdit(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	mgr, err := getPermissionManager()
+	if err != nil {
+		return handleError(out, "permissions.edit", err)
+	}
+	fileID := args[0]
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+	perms, err := mgr.List(ctx, reqCtx, fileID, permissions.ListOptions{UseDomainAdminAccess: permEditDomainAdmin})
+	if err != nil {
+		return handleError(out, "permissions.edit", err)
+	}
+
+	session := permissions.NewEditSession(fileID, perms)
+	// The editor talks on stderr so stdout carries only the result
+	commit, err := runPermissionEditor(os.Stdin, os.Stderr, session)
+	if err != nil {
+		return handleError(out, "permissions.edit", err)
+	}
+	changes := session.Changes()
+	result := map[string]interface{}{
+		"fileId":  fileID,
+		"changes": changes,
+		"applied": false,
+	}
+	if !commit || len(changes) == 0 {
+		out.Log("No changes applied")
+		return out.WriteSuccess("permissions.edit", result)
+	}
+	ops := make([]safety.PlannedOperation, len(changes))
+	for i, change := range changes {
+		ops[i] = editChangeOperation(fileID, change)
+	}
+	planned, err := planOrApprove(flags, "permissions.edit", ops)
+	if err != nil {
+		return handleError(out, "permissions.edit", err)
+	}
+	if planned {
+		out.Log("Dry run: %d changes not applied", len(changes))
+		result["dryRun"] = true
+		return out.WriteSuccess("permissions.edit", result)
+	}
+
+	err = mgr.ApplyEdits(ctx, reqCtx, fileID, changes, permissions.EditApplyOptions{
+		SendNotificationEmail: permEditNotify,
+		UseDomainAdminAccess:  permEditDomainAdmin,
+	})
+	if err != nil {
+		return handleError(out, "permissions.edit", err)
+	}
+	out.Log("Applied %d changes", len(changes))
+	result["applied"] = true
+	return out.WriteSuccess("permissions.edit", result)
+}
+
+// editChangeOperation describes a staged edit as a plan operation
+func editChangeOperation(fileID string, change *permissions.EditChange) safety.PlannedOperation {
+	op := safety.PlannedOperation{
+		ResourceID:  fileID,
+		Description: change.String(),
+		Parameters: map[string]interface{}{
+			"type":      change.Type,
+			"principal": change.Principal,
+		},
+	}
+	switch change.Action {
+	case permissions.EditAdd:
+		op.Type = safety.OpTypeCreatePermission
+		op.Parameters["role"] = change.ToRole
+		op.Predicted = change.ToRole + " granted"
+	case permissions.EditRemove:
+		op.Type = safety.OpTypeDeletePermission
+		op.Parameters["permissionID"] = change.PermissionID
+		op.Predicted = "permission removed"
+	default:
+		op.Type = safety.OpTypeUpdatePermission
+		op.Parameters["permissionID"] = change.PermissionID
+		op.Parameters["newRole"] = change.ToRole
+		op.Predicted = "role set to " + change.ToRole
+	}
+	return op
+}
+
+// runPermissionEditor runs the edit loop until the user commits (true) or
+// quits (false). End of input quits.
+func runPermissionEditor(in io.Reader, w io.Writer, session *permissions.EditSession) (bool, error) {
+	scanner := bufio.NewScanner(in)
+	renderEditRows(w, session)
+	fmt.Fprint(w, "Type help for commands.\n")
+	for {
+		fmt.Fprint(w, "edit> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return false, scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		staged := false
+		switch cmd := strings.ToLower(fields[0]); cmd {
+		case "help", "?":
+			fmt.Fprint(w, permEditHelp)
+		case "list", "ls":
+			renderEditRows(w, session)
+		case "diff":
+			renderEditDiff(w, session.Changes())
+		case "rm", "remove", "reset":
+			var n int
+			if n, err = editRowNumber(fields, 2); err == nil {
+				if cmd == "reset" {
+					err = session.Reset(n)
+				} else {
+					err = session.Remove(n)
+				}
+			}
+			staged = true
+		case "add":
+			err = editAdd(session, fields[1:])
+			staged = true
+		case "commit":
+			changes := session.Changes()
+			if len(changes) == 0 {
+				fmt.Fprintln(w, "Nothing to commit.")
+				continue
+			}
+			renderEditDiff(w, changes)
+			fmt.Fprintf(w, "Apply %d changes? [y/N]: ", len(changes))
+			if !scanner.Scan() {
+				return false, scanner.Err()
+			}
+			if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer == "y" || answer == "yes" {
+				return true, nil
+			}
+			fmt.Fprintln(w, "Not applied; keep editing or quit.")
+		case "quit", "q", "exit":
+			return false, nil
+		default:
+			// "<n> <role>"
+			var n int
+			if n, err = strconv.Atoi(fields[0]); err != nil || len(fields) != 2 {
+				err = fmt.Errorf("unknown command %q; type help", scanner.Text())
+			} else {
+				err = session.SetRole(n, fields[1])
+			}
+			staged = true
+		}
+		if err != nil {
+			fmt.Fprintf(w, "error: %s\n", err)
+		} else if staged {
+			renderEditRows(w, session)
+		}
+	}
+}
+
+func editRowNumber(fields []string, want int) (int, error) {
+	if len(fields) != want {
+		return 0, fmt.Errorf("usage: %s <n>", fields[0])
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a grant number", fields[1])
+	}
+	return n, nil
+}
+
+func editAdd(session *permissions.EditSession, args []string) error {
+	switch len(args) {
+	case 2:
+		if args[0] == types.PermissionTypeAnyone {
+			return session.Add(types.PermissionTypeAnyone, "", args[1])
+		}
+		return session.Add(types.PermissionTypeUser, args[0], args[1])
+	case 3:
+		return session.Add(args[0], args[1], args[2])
+	default:
+		return fmt.Errorf("usage: add [type] <who> <role>")
+	}
+}
+
+func renderEditRows(w io.Writer, session *permissions.EditSession) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTYPE\tWHO\tROLE\t")
+	for i, r := range session.Rows() {
+		role := r.Role
+		switch {
+		case r.Added():
+			role += "  (new)"
+		case r.Removed:
+			role += "  → removed"
+		case r.NewRole != "":
+			role += "  → " + r.NewRole
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t\n", i+1, r.Type, r.Principal, role)
+	}
+	_ = tw.Flush()
+}
+
+func renderEditDiff(w io.Writer, changes []*permissions.EditChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No staged changes.")
+		return
+	}
+	for _, c := range changes {
+		fmt.Fprintln(w, c.String())
+	}
+}
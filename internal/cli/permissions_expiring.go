@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permExpiringCmd = &cobra.Command{
+	Use:   "expiring --folder-id <id>",
+	Short: "List grants that expire soon, and renew them",
+	Long: `List time-limited grants on a folder and the files in it that expire
+within --within, soonest first. Grants without an expiration are not
+listed, nor are grants that already expired, since Drive removes them.
+
+--renew sets every listed grant to expire that long from now; narrow the
+list first with --email or --domain to renew one grantee's access. A grant
+that already lasts longer than the renewal is left alone. Drive allows
+expirations up to a year ahead, on user and group grants with a reader,
+commenter or writer role.`,
+	Example: "  gdrv permissions expiring --folder-id <folder-id> --within 14d --recursive\n" +
+		"  gdrv permissions expiring --folder-id <folder-id> --email contractor@example.com --renew 90d --dry-run",
+	Args: cobra.NoArgs,
+	RunE: runPermExpiring,
+}
+
+var permAuditExpiringCmd = &cobra.Command{
+	Use:   "expiring",
+	Short: "Audit grants that expire soon",
+	Long: `List time-limited grants that expire within --within, soonest first.
+
+Without --folder-id everything in My Drive, or in the --drive-id drive, is
+checked; with it, the folder and, with --recursive, its subfolders. Use
+'permissions expiring --renew' to extend the grants found.`,
+	Example: "  gdrv permissions audit expiring --within 7d\n" +
+		"  gdrv permissions audit expiring --folder-id <folder-id> --recursive --within 30d --output-sheet new",
+	Args: cobra.NoArgs,
+	RunE: runPermAuditExpiring,
+}
+
+var (
+	expiringFolderID    string
+	expiringRecursive   bool
+	expiringWithin      string
+	expiringRenew       string
+	expiringEmail       string
+	expiringDomain      string
+	expiringDomainAdmin bool
+
+	auditExpiringWithin string
+)
+
+func init() {
+	permExpiringCmd.Flags().StringVar(&expiringFolderID, "folder-id", "", "Folder to check (required)")
+	permExpiringCmd.Flags().BoolVar(&expiringRecursive, "recursive", false, "Include subfolders")
+	permExpiringCmd.Flags().StringVar(&expiringWithin, "within", "14d", "List grants expiring within this period (e.g. 14d, 2w, 48h)")
+	permExpiringCmd.Flags().StringVar(&expiringRenew, "renew", "", "Set the listed grants to expire this long from now (e.g. 90d)")
+	// Listing expiring grants only reads; renewing them needs write access
+	_ = permExpiringCmd.Flags().SetAnnotation("renew", familyAnnotation, []string{string(auth.FamilyWrite)})
+	permExpiringCmd.Flags().StringVar(&expiringEmail, "email", "", "Only grants to this user or group")
+	permExpiringCmd.Flags().StringVar(&expiringDomain, "domain", "", "Only grants to this domain")
+	permExpiringCmd.Flags().BoolVar(&expiringDomainAdmin, "use-domain-admin-access", false, "Act as a Workspace admin")
+	_ = permExpiringCmd.MarkFlagRequired("folder-id")
+	permissionsCmd.AddCommand(permExpiringCmd)
+
+	permAuditExpiringCmd.Flags().StringVar(&auditFolderID, "folder-id", "", "Limit audit to specific folder")
+	permAuditExpiringCmd.Flags().BoolVar(&auditRecursive, "recursive", false, "Include subfolders of --folder-id")
+	permAuditExpiringCmd.Flags().StringVar(&auditExpiringWithin, "within", "7d", "List grants expiring within this period (e.g. 7d, 2w, 48h)")
+	permAuditExpiringCmd.Flags().BoolVar(&expiringDomainAdmin, "use-domain-admin-access", false, "Act as a Workspace admin")
+	permAuditCmd.AddCommand(permAuditExpiringCmd)
+}
+
+func runPermExpiring(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	within, err := utils.ParseAge(expiringWithin)
+	if err != nil {
+		return out.WriteError("permissions.expiring", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid --within: %s", err)).Build())
+	}
+	var renew time.Duration
+	if expiringRenew != "" {
+		if renew, err = utils.ParseAge(expiringRenew); err != nil || renew == 0 {
+			return out.WriteError("permissions.expiring", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid --renew %q: expected a positive period such as 90d", expiringRenew)).Build())
+		}
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "permissions.expiring", err)
+	}
+	folderID, err := ResolveFileID(ctx, client, flags, expiringFolderID)
+	if err != nil {
+		return handleError(out, "permissions.expiring", err)
+	}
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	report, err := mgr.FindExpiring(ctx, reqCtx, permissions.ExpiringOptions{
+		FolderID:             folderID,
+		Recursive:            expiringRecursive,
+		Within:               within,
+		Grantee:              permissions.Selector{Email: expiringEmail, Domain: expiringDomain},
+		UseDomainAdminAccess: expiringDomainAdmin,
+	})
+	done()
+	if err != nil {
+		return handleError(out, "permissions.expiring", err)
+	}
+	if renew == 0 {
+		out.Log("%d grants expire before %s", len(report.Grants), report.Cutoff)
+		return out.WriteSuccess("permissions.expiring", report)
+	}
+
+	planned, err := planOrApprove(flags, "permissions.expiring", renewOperations(report, renew))
+	if err != nil {
+		return handleError(out, "permissions.expiring", err)
+	}
+	if !planned {
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		confirmed, err := safety.ConfirmBulkOperation(len(report.Grants), "renew expiring grants", safetyOpts.ForScope(safety.ScopePermissions))
+		if err != nil {
+			return handleError(out, "permissions.expiring", err)
+		}
+		if !confirmed && len(report.Grants) > 0 {
+			return out.WriteError("permissions.expiring", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	reqCtx.RequestType = types.RequestTypePermissionOp
+	done = startProgress(flags.Quiet, mgr.SetProgress)
+	err = mgr.RenewExpiring(ctx, reqCtx, report, renew, expiringDomainAdmin, planned)
+	done()
+	if err != nil {
+		return handleError(out, "permissions.expiring", err)
+	}
+	if report.Failed > 0 {
+		out.AddWarning("RENEWALS_FAILED", fmt.Sprintf("%d of %d grants were not renewed; see grant errors", report.Failed, len(report.Grants)), "high")
+	}
+	out.Log("Renewed %d grants, %d failed", report.Renewed, report.Failed)
+	return out.WriteSuccess("permissions.expiring", report)
+}
+
+// renewOperations describes the grants a renewal would extend. The new
+// expiration depends on when the command runs, so the plan records the
+// renewal period instead, and an approved plan still matches a later run.
+func renewOperations(report *permissions.ExpiringReport, renew time.Duration) []safety.PlannedOperation {
+	var ops []safety.PlannedOperation
+	for _, g := range report.Grants {
+		if !g.Renews(renew) {
+			continue
+		}
+		ops = append(ops, safety.PlannedOperation{
+			Type:         safety.OpTypeUpdatePermission,
+			ResourceID:   g.FileID,
+			ResourceName: g.FileName,
+			Description:  fmt.Sprintf("Renew %s %s for %s", g.Type, g.Principal, expiringRenew),
+			Parameters:   map[string]interface{}{"permissionID": g.PermissionID, "renew": expiringRenew},
+			Predicted:    "expires " + expiringRenew + " from now",
+		})
+	}
+	return ops
+}
+
+func runPermAuditExpiring(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	if permNotifyOwners {
+		return out.WriteError("permissions.audit.expiring", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--notify-owners is not supported for expiring grants").Build())
+	}
+	within, err := utils.ParseAge(auditExpiringWithin)
+	if err != nil {
+		return out.WriteError("permissions.audit.expiring", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid --within: %s", err)).Build())
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "permissions.audit.expiring", err)
+	}
+	// Without a folder the whole of My Drive or the Shared Drive is audited
+	folderID, recursive := "root", true
+	if flags.DriveID != "" {
+		folderID = flags.DriveID
+	}
+	if auditFolderID != "" {
+		if folderID, err = ResolveFileID(ctx, client, flags, auditFolderID); err != nil {
+			return handleError(out, "permissions.audit.expiring", err)
+		}
+		recursive = auditRecursive
+	}
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
+
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	report, err := mgr.FindExpiring(ctx, reqCtx, permissions.ExpiringOptions{
+		FolderID:             folderID,
+		Recursive:            recursive,
+		Within:               within,
+		UseDomainAdminAccess: expiringDomainAdmin,
+	})
+	done()
+	if err != nil {
+		return handleError(out, "permissions.audit.expiring", err)
+	}
+	if permOutputSheet != "" {
+		if err := exportToSheet(out, flags, permOutputSheet, "permissions.audit.expiring", report); err != nil {
+			return handleError(out, "permissions.audit.expiring", err)
+		}
+	}
+	out.Log("%d grants expire before %s", len(report.Grants), report.Cutoff)
+	return out.WriteSuccess("permissions.audit.expiring", report)
+}
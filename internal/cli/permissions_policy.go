@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/policy"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permPolicyCheckCmd = &cobra.Command{
+	Use:   "policy-check",
+	Short: "Check sharing against a policy file, e.g. in CI",
+	Long: `Run the permission audits named by a policy config file and report their
+findings, without prompts, for scheduled jobs and CI.
+
+The config (YAML or JSON) lists rules, each running an audit (public,
+external or anyone-with-link) and reporting what it finds at a SARIF level
+(error, warning or note). A rule's minRisk skips files below that risk
+level. folderId, recursive and driveId limit where the audits look, and
+internalDomains defaults to the profile's domains:
+
+  folderId: 0AbCdEf
+  recursive: true
+  internalDomains: [example.com]
+  failOn: error
+  rules:
+    - audit: public
+    - audit: external
+      level: warning
+      minRisk: high
+
+With --output sarif the findings are written as a SARIF 2.1.0 log for code
+scanning platforms. The command exits with code 50 (POLICY_VIOLATION) when
+a finding is at or above failOn (default error; none never fails).`,
+	Example: "  gdrv permissions policy-check --config drive-policy.yaml\n" +
+		"  gdrv permissions policy-check --config drive-policy.yaml --output sarif > drive.sarif",
+	Args: cobra.NoArgs,
+	RunE: runPermPolicyCheck,
+}
+
+var permPolicyConfig string
+
+func init() {
+	permPolicyCheckCmd.Flags().StringVar(&permPolicyConfig, "config", "", "Policy config file (required)")
+	_ = permPolicyCheckCmd.MarkFlagRequired("config")
+	permissionsCmd.AddCommand(permPolicyCheckCmd)
+}
+
+func runPermPolicyCheck(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	cfg, err := policy.LoadConfig(permPolicyConfig)
+	if err != nil {
+		return handleError(out, "permissions.policy-check", err)
+	}
+	if len(cfg.InternalDomains) == 0 {
+		cfg.InternalDomains, err = resolveInternalDomains(flags.Profile, nil)
+		if err != nil {
+			return handleError(out, "permissions.policy-check", err)
+		}
+	}
+
+	mgr, err := getPermissionManager()
+	if err != nil {
+		return handleError(out, "permissions.policy-check", err)
+	}
+	driveID := flags.DriveID
+	if cfg.DriveID != "" {
+		driveID = cfg.DriveID
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypePermissionOp)
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	done := startProgress(flags.Quiet, mgr.SetProgress)
+	report, err := policy.Check(ctx, mgr, reqCtx, cfg)
+	done()
+	if err != nil {
+		return handleError(out, "permissions.policy-check", err)
+	}
+
+	if report.Failed {
+		out.AddWarning("POLICY_VIOLATION", fmt.Sprintf("%d findings; the policy fails on %s or above", len(report.Findings), report.FailOn), "high")
+	}
+	if err := out.WriteSuccess("permissions.policy-check", report); err != nil {
+		return err
+	}
+	if report.Failed {
+		os.Exit(utils.GetExitCode(utils.ErrCodePolicyViolation))
+	}
+	return nil
+}
+
+// auditOutput returns what an audit command writes: its result, or with
+// --output sarif the result as findings of a rule named after the audit
+func auditOutput(audit string, result *types.AuditResult) interface{} {
+	if globalFlags.OutputFormat == types.OutputFormatSARIF {
+		return policy.AuditReport(audit, result)
+	}
+	return result
+}
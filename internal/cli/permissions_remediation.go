@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permRemediationCmd = &cobra.Command{
+	Use:   "remediation",
+	Short: "Follow up on remediation comments posted by audits",
+	Long: `Audits run with --notify-owners post a comment on each high-risk file,
+mentioning its owner with what to fix and a deadline. The comments are
+recorded in the config directory so they can be followed up here.`,
+}
+
+var permRemediationStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check which owners have acknowledged remediation comments",
+	Long: `Check the comment of every pending remediation request.
+
+A request is acknowledged once its comment is resolved, or someone other
+than you replies to it. Requests past their deadline without that are
+reported as overdue; deleted comments are reported as dismissed.`,
+	Example: "  gdrv permissions audit public --notify-owners --deadline 14d\n" +
+		"  gdrv permissions remediation status",
+	Args: cobra.NoArgs,
+	RunE: runPermRemediationStatus,
+}
+
+var (
+	permNotifyOwners        bool
+	permRemediationDeadline string
+	permRemediationMinRisk  string
+)
+
+func init() {
+	permAuditCmd.PersistentFlags().BoolVar(&permNotifyOwners, "notify-owners", false, "Comment on each finding at or above --min-risk, mentioning the owner, and track acknowledgement")
+	permAuditCmd.PersistentFlags().StringVar(&permRemediationDeadline, "deadline", "14d", "Remediation deadline for --notify-owners, as a date (2006-01-02) or a time from now (14d, 2w)")
+	permAuditCmd.PersistentFlags().StringVar(&permRemediationMinRisk, "min-risk", types.RiskLevelHigh, "Lowest risk level commented on by --notify-owners: low, medium, high or critical")
+	// Posting comments needs write access, which the audit itself does not
+	_ = permAuditCmd.PersistentFlags().SetAnnotation("notify-owners", familyAnnotation, []string{string(auth.FamilyWrite)})
+
+	permRemediationCmd.AddCommand(permRemediationStatusCmd)
+	permissionsCmd.AddCommand(permRemediationCmd)
+}
+
+// parseDeadline accepts a date or a duration from now
+func parseDeadline(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		// The deadline is the end of that day
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	age, err := utils.ParseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline %q: use a date (2006-01-02) or a duration (14d)", value)
+	}
+	return now.Add(age), nil
+}
+
+// notifyOwners posts remediation comments for an audit's findings when
+// --notify-owners is set, reporting the outcome as warnings
+func notifyOwners(writer *OutputWriter, flags types.GlobalFlags, mgr *permissions.Manager, command string, result *types.AuditResult) error {
+	if !permNotifyOwners {
+		return nil
+	}
+	if !permissions.ValidRiskLevel(permRemediationMinRisk) {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("invalid --min-risk %q: use low, medium, high or critical", permRemediationMinRisk)).Build())
+	}
+	deadline, err := parseDeadline(permRemediationDeadline, time.Now())
+	if err != nil {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+	store, err := permissions.DefaultRemediationStore()
+	if err != nil {
+		return err
+	}
+
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeMutation)
+	remediation, err := mgr.NotifyOwners(context.Background(), reqCtx, result.Files, store, permissions.RemediationOptions{
+		MinRisk:  permRemediationMinRisk,
+		Deadline: deadline,
+		Source:   command,
+		DryRun:   flags.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	planned, skipped := 0, 0
+	for _, item := range remediation.Items {
+		switch item.Status {
+		case permissions.RemediationPlanned:
+			planned++
+			planOperation(safety.PlannedOperation{
+				Type:         safety.OpTypeCreate,
+				ResourceID:   item.FileID,
+				ResourceName: item.FileName,
+				Description:  "Remediation comment: " + item.FileName,
+				Parameters:   map[string]interface{}{"comment": "remediation", "riskLevel": item.RiskLevel},
+				Predicted:    "remediation comment posted",
+			})
+		case permissions.RemediationSkipped:
+			skipped++
+		}
+	}
+	switch {
+	case flags.DryRun:
+		writer.AddWarning("REMEDIATION_PLANNED", fmt.Sprintf("would comment on %d file(s); %d already have a pending request", planned, skipped), "low")
+	case remediation.Posted > 0 || skipped > 0:
+		writer.AddWarning("REMEDIATION_POSTED", fmt.Sprintf("commented on %d file(s), due %s; %d already had a pending request; follow up with 'gdrv permissions remediation status'",
+			remediation.Posted, deadline.Format("2006-01-02"), skipped), "low")
+	}
+	if remediation.Failed > 0 {
+		writer.AddWarning("REMEDIATION_FAILED", fmt.Sprintf("could not comment on %d file(s)", remediation.Failed), "high")
+	}
+	return nil
+}
+
+func runPermRemediationStatus(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	mgr, err := getPermissionManager()
+	if err != nil {
+		return handleError(writer, "permissions.remediation.status", err)
+	}
+	store, err := permissions.DefaultRemediationStore()
+	if err != nil {
+		return handleError(writer, "permissions.remediation.status", err)
+	}
+
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeGetByID)
+	result, err := mgr.RefreshRemediation(context.Background(), reqCtx, store, time.Now())
+	if err != nil {
+		return handleError(writer, "permissions.remediation.status", err)
+	}
+
+	overdue := 0
+	for _, item := range result.Items {
+		if item.Status == permissions.RemediationOverdue {
+			overdue++
+		}
+	}
+	if overdue > 0 {
+		writer.AddWarning("REMEDIATION_OVERDUE", fmt.Sprintf("%d remediation request(s) are past their deadline without acknowledgement", overdue), "high")
+	}
+	if result.Failed > 0 {
+		writer.AddWarning("REMEDIATION_CHECK_FAILED", fmt.Sprintf("could not check %d comment(s)", result.Failed), "medium")
+	}
+	return writer.WriteSuccess("permissions.remediation.status", result)
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const permTemplateHelp = `Templates are YAML or JSON files in the permission-templates directory of
+the config dir, named <template>.yaml, .yml or .json:
+
+  description: Standard sharing for project folders
+  variables:
+    team_group: eng@example.com
+  grants:
+    - type: group
+      email: ${team_group}
+      role: writer
+    - type: group
+      email: ${project}-leads@example.com
+      role: organizer
+    - type: domain
+      domain: example.com
+      role: reader
+
+Email, domain and role values may reference ${variables}. Defaults come
+from the template's variables and are overridden with --var name=value;
+a variable with neither is an error.
+
+Grantees are matched by email, domain or "anyone". Grants that are not in
+the template are left alone unless --exact is given. Owners are never
+changed, and grants a file inherits from its Shared Drive or a parent
+folder cannot be changed on the file, so they are listed as skipped.`
+
+var permApplyTemplateCmd = &cobra.Command{
+	Use:   "apply-template <file-id>",
+	Short: "Apply a named permission template to a file or folder",
+	Long: `Bring the grants on a file or folder, and with --recursive everything
+below it, in line with a permission template. The changes are listed and
+confirmed first; --dry-run records them in the plan instead.
+
+` + permTemplateHelp,
+	Example: "  gdrv permissions apply-template <folder-id> --template project-default --var project=apollo\n" +
+		"  gdrv permissions apply-template /Projects/Apollo --template project-default --recursive --exact --dry-run",
+	Args: cobra.ExactArgs(1),
+	RunE: runPermApplyTemplate,
+}
+
+var permCheckTemplateCmd = &cobra.Command{
+	Use:   "check-template <file-id>",
+	Short: "Report drift of a file or folder from a permission template",
+	Long: `Compare the grants on a file or folder, and with --recursive everything
+below it, with a permission template and list the changes that would bring
+each drifted file back in line. Nothing is changed; a PERMISSION_DRIFT
+warning is added when any file has drifted, so --strict fails the run.
+
+` + permTemplateHelp,
+	Example: "  gdrv permissions check-template <folder-id> --template project-default --var project=apollo --recursive",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPermCheckTemplate,
+}
+
+var permTemplatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List the permission templates in the config dir",
+	Args:  cobra.NoArgs,
+	RunE:  runPermTemplates,
+}
+
+var (
+	permTemplateName        string
+	permTemplateVars        []string
+	permTemplateRecursive   bool
+	permTemplateExact       bool
+	permTemplateNotify      bool
+	permTemplateDomainAdmin bool
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{permApplyTemplateCmd, permCheckTemplateCmd} {
+		cmd.Flags().StringVar(&permTemplateName, "template", "", "Name of the permission template")
+		cmd.Flags().StringArrayVar(&permTemplateVars, "var", nil, "Template variable as name=value (repeatable)")
+		cmd.Flags().BoolVar(&permTemplateRecursive, "recursive", false, "Include everything below a folder")
+		cmd.Flags().BoolVar(&permTemplateExact, "exact", false, "Treat grants that are not in the template as drift and remove them")
+		cmd.Flags().BoolVar(&permTemplateDomainAdmin, "use-domain-admin-access", false, "Act as a Workspace admin")
+		_ = cmd.MarkFlagRequired("template")
+		permissionsCmd.AddCommand(cmd)
+	}
+	permApplyTemplateCmd.Flags().BoolVar(&permTemplateNotify, "send-notification", false, "Email users and groups given new grants")
+	permissionsCmd.AddCommand(permTemplatesCmd)
+}
+
+func runPermTemplates(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	dir := permissions.TemplateDir(getConfigDir())
+	templates, err := permissions.ListTemplates(dir)
+	if err != nil {
+		return handleError(out, "permissions.templates", err)
+	}
+	return out.WriteSuccess("permissions.templates", map[string]interface{}{
+		"directory": dir,
+		"templates": templates,
+	})
+}
+
+func runPermCheckTemplate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, reqCtx, grants, fileID, err := prepareTemplateRun(ctx, flags, args[0])
+	if err != nil {
+		return handleError(out, "permissions.check-template", err)
+	}
+	report, err := mgr.CheckTemplate(ctx, reqCtx, permTemplateName, grants, fileID, templateOptions())
+	if err != nil {
+		return handleError(out, "permissions.check-template", err)
+	}
+	addTemplateWarnings(out, report)
+	if report.Drifted > 0 {
+		out.AddWarning("PERMISSION_DRIFT",
+			fmt.Sprintf("%d of %d files have drifted from template %s", report.Drifted, report.Checked, report.Template), "medium")
+	}
+	return out.WriteSuccess("permissions.check-template", report)
+}
+
+func runPermApplyTemplate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	mgr, reqCtx, grants, fileID, err := prepareTemplateRun(ctx, flags, args[0])
+	if err != nil {
+		return handleError(out, "permissions.apply-template", err)
+	}
+	opts := templateOptions()
+	report, err := mgr.CheckTemplate(ctx, reqCtx, permTemplateName, grants, fileID, opts)
+	if err != nil {
+		return handleError(out, "permissions.apply-template", err)
+	}
+	addTemplateWarnings(out, report)
+	if report.Drifted == 0 {
+		return out.WriteSuccess("permissions.apply-template", report)
+	}
+
+	var ops []safety.PlannedOperation
+	for _, f := range report.Files {
+		for _, change := range f.Changes {
+			ops = append(ops, editChangeOperation(f.FileID, change))
+		}
+	}
+	planned, err := planOrApprove(flags, "permissions.apply-template", ops)
+	if err != nil {
+		return handleError(out, "permissions.apply-template", err)
+	}
+	if planned {
+		out.Log("Dry run: %d changes on %d files not applied", report.Changes(), report.Drifted)
+		return out.WriteSuccess("permissions.apply-template", report)
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.ConfirmBulkOperation(report.Changes(),
+		fmt.Sprintf("apply template %s to %d files", report.Template, report.Drifted), safetyOpts.ForScope(safety.ScopePermissions))
+	if err != nil {
+		return handleError(out, "permissions.apply-template", err)
+	}
+	if !confirmed {
+		return out.WriteError("permissions.apply-template", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	opts.SendNotificationEmail = permTemplateNotify
+	if err := mgr.ApplyTemplate(ctx, reqCtx, report, grants, opts); err != nil {
+		return handleError(out, "permissions.apply-template", err)
+	}
+	out.Log("Applied template %s to %d files", report.Template, report.Drifted)
+	return out.WriteSuccess("permissions.apply-template", report)
+}
+
+// prepareTemplateRun loads and expands the template named by --template and
+// resolves the file argument
+func prepareTemplateRun(ctx context.Context, flags types.GlobalFlags, arg string) (*permissions.Manager, *types.RequestContext, []*types.Permission, string, error) {
+	template, err := permissions.LoadTemplate(permissions.TemplateDir(getConfigDir()), permTemplateName)
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+	vars, err := parseTemplateVars(permTemplateVars)
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+	grants, err := template.Expand(vars)
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+	fileID, err := ResolveFileID(ctx, client, flags, arg)
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+	return permissions.NewManager(client), reqCtx, grants, fileID, nil
+}
+
+// parseTemplateVars parses --var name=value flags
+func parseTemplateVars(values []string) (map[string]string, error) {
+	vars := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid --var %q: use name=value", v)).Build())
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+func templateOptions() permissions.TemplateOptions {
+	return permissions.TemplateOptions{
+		Recursive:            permTemplateRecursive,
+		Exact:                permTemplateExact,
+		UseDomainAdminAccess: permTemplateDomainAdmin,
+	}
+}
+
+func addTemplateWarnings(out *OutputWriter, report *permissions.TemplateReport) {
+	if report.Failed > 0 {
+		out.AddWarning("FILES_NOT_CHECKED",
+			fmt.Sprintf("permissions of %d files could not be listed; see files", report.Failed), "high")
+	}
+	for _, f := range report.Files {
+		if len(f.Skipped) > 0 {
+			out.AddWarning("INHERITED_GRANTS_SKIPPED",
+				"some grants are inherited and cannot be changed on the file itself; see skipped", "medium")
+			break
+		}
+	}
+}
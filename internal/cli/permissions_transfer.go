@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permTransferOwnershipCmd = &cobra.Command{
+	Use:   "transfer-ownership <file-id> <email>",
+	Short: "Transfer ownership of a file",
+	Long: `Make another user the owner of a file you own.
+
+Workspace accounts in the same organization transfer ownership at once.
+Personal (gmail.com) accounts cannot: the recipient is made a pending owner
+and ownership moves only when they accept in Drive. gdrv picks the direct
+transfer unless you have a personal account, and falls back to a pending
+owner when Drive answers that the recipient must consent; --mode forces
+one or the other. A recipient without access is first given writer access,
+as Drive requires for a pending owner.
+
+Pending transfers are recorded; follow them with 'permissions
+pending-transfers'. Files in Shared Drives have no owner and cannot be
+transferred.`,
+	Example: "  gdrv permissions transfer-ownership <file-id> alex@example.com --move-to-new-owners-root\n" +
+		"  gdrv permissions transfer-ownership <file-id> alex@gmail.com --dry-run",
+	Args: cobra.ExactArgs(2),
+	RunE: runPermTransferOwnership,
+}
+
+var permPendingTransfersCmd = &cobra.Command{
+	Use:   "pending-transfers",
+	Short: "Track ownership transfers awaiting acceptance",
+	Long: `Check the ownership transfers started with 'permissions
+transfer-ownership' that are still waiting for the recipient to accept.
+
+Each transfer is reported as pending, accepted, or cancelled when the
+recipient declined or the pending owner was removed. Accepted and cancelled
+transfers are reported once and then no longer tracked. --folder-id also
+scans a folder (with --recursive, its subfolders) for pending owners set
+elsewhere, such as in the Drive web UI, and tracks them from then on.`,
+	Example: "  gdrv permissions pending-transfers\n" +
+		"  gdrv permissions pending-transfers --folder-id <folder-id> --recursive",
+	Args: cobra.NoArgs,
+	RunE: runPermPendingTransfers,
+}
+
+var (
+	transferMode         string
+	transferMoveToRoot   bool
+	transferNotify       bool
+	transferEmailMessage string
+
+	pendingTransfersFolderID  string
+	pendingTransfersRecursive bool
+)
+
+func init() {
+	permTransferOwnershipCmd.Flags().StringVar(&transferMode, "mode", "auto", "Transfer mode: auto, direct, or pending")
+	permTransferOwnershipCmd.Flags().BoolVar(&transferMoveToRoot, "move-to-new-owners-root", false, "Move the file to the new owner's My Drive root on a direct transfer")
+	permTransferOwnershipCmd.Flags().BoolVar(&transferNotify, "send-notification", true, "Notify a recipient who is given writer access for a pending transfer")
+	permTransferOwnershipCmd.Flags().StringVar(&transferEmailMessage, "message", "", "Message to include in the notification email")
+	permissionsCmd.AddCommand(permTransferOwnershipCmd)
+
+	permPendingTransfersCmd.Flags().StringVar(&pendingTransfersFolderID, "folder-id", "", "Also scan this folder for pending owners")
+	permPendingTransfersCmd.Flags().BoolVar(&pendingTransfersRecursive, "recursive", false, "Include subfolders of --folder-id")
+	permissionsCmd.AddCommand(permPendingTransfersCmd)
+}
+
+func runPermTransferOwnership(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	mode := transferMode
+	switch mode {
+	case "auto":
+		mode = ""
+	case permissions.TransferDirect, permissions.TransferPending:
+	default:
+		return out.WriteError("permissions.transfer-ownership", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid --mode %q: use auto, direct or pending", transferMode)).Build())
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "permissions.transfer-ownership", err)
+	}
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		return handleError(out, "permissions.transfer-ownership", err)
+	}
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypePermissionOp)
+	opts := permissions.TransferOptions{
+		Mode:                  mode,
+		MoveToNewOwnersRoot:   transferMoveToRoot,
+		SendNotificationEmail: transferNotify,
+		EmailMessage:          transferEmailMessage,
+	}
+
+	// The checks run first, so a refused transfer is reported before the prompt
+	opts.DryRun = true
+	planned, err := mgr.TransferOwnership(ctx, reqCtx, fileID, args[1], opts)
+	if err != nil {
+		return handleError(out, "permissions.transfer-ownership", err)
+	}
+	if planned.Mode == permissions.TransferPending && transferMoveToRoot {
+		out.AddWarning("MOVE_NOT_APPLIED", "--move-to-new-owners-root only applies to direct transfers; the recipient chooses where the file goes when they accept", "low")
+	}
+	if planOperation(safety.PlannedOperation{
+		Type:         safety.OpTypeCreatePermission,
+		ResourceID:   planned.FileID,
+		ResourceName: planned.FileName,
+		Description:  fmt.Sprintf("Transfer ownership of %s to %s (%s)", planned.FileName, planned.To, planned.Mode),
+		Parameters:   map[string]interface{}{"newOwner": planned.To, "mode": planned.Mode, "moveToNewOwnersRoot": transferMoveToRoot},
+		Predicted:    "owner " + planned.To,
+	}) {
+		return out.WriteSuccess("permissions.transfer-ownership", planned)
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.Confirm(safety.Msg(safety.MsgOwnershipTransfer, map[string]interface{}{
+		"File": planned.FileName,
+		"To":   planned.To,
+		"Mode": planned.Mode,
+	}), safetyOpts.ForScope(safety.ScopePermissions))
+	if err != nil {
+		return handleError(out, "permissions.transfer-ownership", err)
+	}
+	if !confirmed {
+		return out.WriteError("permissions.transfer-ownership", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	opts.DryRun = false
+	transfer, err := mgr.TransferOwnership(ctx, reqCtx, fileID, args[1], opts)
+	if err != nil {
+		return handleError(out, "permissions.transfer-ownership", err)
+	}
+	if transfer.Status != permissions.TransferAwaiting {
+		out.Log("%s now owns %s", transfer.To, transfer.FileName)
+		return out.WriteSuccess("permissions.transfer-ownership", transfer)
+	}
+
+	out.Log("%s is now pending owner of %s; ownership moves when they accept", transfer.To, transfer.FileName)
+	if err := recordTransfer(transfer); err != nil {
+		out.AddWarning("TRANSFER_NOT_RECORDED", fmt.Sprintf("The transfer was started but could not be recorded for 'permissions pending-transfers': %v", err), "medium")
+	}
+	return out.WriteSuccess("permissions.transfer-ownership", transfer)
+}
+
+// recordTransfer adds a pending transfer to the ledger followed by
+// 'permissions pending-transfers'
+func recordTransfer(transfer *permissions.OwnershipTransfer) error {
+	store, err := permissions.DefaultTransferStore()
+	if err != nil {
+		return err
+	}
+	recorded, err := store.List()
+	if err != nil {
+		return err
+	}
+	// A repeated transfer of the same file replaces the earlier one
+	kept := recorded[:0]
+	for _, t := range recorded {
+		if t.FileID != transfer.FileID {
+			kept = append(kept, t)
+		}
+	}
+	return store.Save(append(kept, transfer))
+}
+
+func runPermPendingTransfers(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	store, err := permissions.DefaultTransferStore()
+	if err != nil {
+		return handleError(out, "permissions.pending-transfers", err)
+	}
+	recorded, err := store.List()
+	if err != nil {
+		return handleError(out, "permissions.pending-transfers", err)
+	}
+
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return handleError(out, "permissions.pending-transfers", err)
+	}
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeGetByID)
+	mgr.RefreshTransfers(ctx, reqCtx, recorded)
+
+	report := &permissions.TransferReport{Transfers: recorded, Store: store.Path()}
+	if pendingTransfersFolderID != "" {
+		folderID, err := ResolveFileID(ctx, client, flags, pendingTransfersFolderID)
+		if err != nil {
+			return handleError(out, "permissions.pending-transfers", err)
+		}
+		reqCtx.RequestType = types.RequestTypeListOrSearch
+		done := startProgress(flags.Quiet, mgr.SetProgress)
+		found, scanned, err := mgr.FindPendingTransfers(ctx, reqCtx, folderID, pendingTransfersRecursive)
+		done()
+		if err != nil {
+			return handleError(out, "permissions.pending-transfers", err)
+		}
+		report.Transfers = permissions.MergeTransfers(report.Transfers, found)
+		report.FilesScanned = scanned
+	}
+
+	var pending []*permissions.OwnershipTransfer
+	for _, t := range report.Transfers {
+		if t.Status == permissions.TransferAwaiting {
+			pending = append(pending, t)
+		}
+	}
+	report.Pending = len(pending)
+	if err := store.Save(pending); err != nil {
+		out.AddWarning("TRANSFERS_NOT_SAVED", fmt.Sprintf("Could not update %s: %v", store.Path(), err), "medium")
+	}
+	out.Log("%d ownership transfers awaiting acceptance", report.Pending)
+	return out.WriteSuccess("permissions.pending-transfers", report)
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permAuditTrendCmd = &cobra.Command{
+	Use:   "trend",
+	Short: "Show how audit findings changed over time",
+	Long: `Show the findings of past audit runs, per risk level, and whether they
+improved or degraded.
+
+Every audit run records a summary (files per risk level and per external
+domain) in the config directory. Runs of the same audit over the same
+folder form a series; the last run of each series is compared with the
+first one shown. The direction weighs findings by risk, so fewer high-risk
+files is an improvement even if more low-risk ones appeared.
+
+Schedule an audit with 'gdrv schedule add' to build the history.`,
+	Example: "  gdrv schedule add weekly-external --cron \"0 6 * * 1\" -- permissions audit external --folder-id <id> --recursive\n" +
+		"  gdrv permissions audit trend --last 12\n" +
+		"  gdrv permissions audit trend --audit external --folder-id <id> --json",
+	Args: cobra.NoArgs,
+	RunE: runPermAuditTrend,
+}
+
+var (
+	auditTrendLast     int
+	auditTrendAudit    string
+	auditTrendFolderID string
+)
+
+func init() {
+	permAuditTrendCmd.Flags().IntVar(&auditTrendLast, "last", 12, "Runs to show per audit (0 = all)")
+	permAuditTrendCmd.Flags().StringVar(&auditTrendAudit, "audit", "", "Only this audit: public, external, anyone-with-link or user:<email>")
+	permAuditTrendCmd.Flags().StringVar(&auditTrendFolderID, "folder-id", "", "Only audits of this folder")
+	permAuditCmd.AddCommand(permAuditTrendCmd)
+}
+
+func runPermAuditTrend(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	writer := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	if auditTrendLast < 0 {
+		return writer.WriteError("permissions.audit.trend", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--last cannot be negative").Build())
+	}
+	store, err := permissions.DefaultAuditTrendStore()
+	if err != nil {
+		return handleError(writer, "permissions.audit.trend", err)
+	}
+	snapshots, err := store.List()
+	if err != nil {
+		return handleError(writer, "permissions.audit.trend", err)
+	}
+
+	trend := permissions.BuildAuditTrend(snapshots, permissions.AuditTrendFilter{
+		Audit:    auditTrendAudit,
+		FolderID: auditTrendFolderID,
+		Last:     auditTrendLast,
+	})
+	for _, s := range trend.Series {
+		if s.Change != nil {
+			writer.Log("%s: %s since %s (%s files)", s.Audit, s.Change.Direction,
+				s.Change.Since.Local().Format("2006-01-02"), signedCount(s.Change.Files))
+		}
+	}
+	return writer.WriteSuccess("permissions.audit.trend", trend)
+}
+
+// recordAuditTrend adds an audit run to the trend history. Failing to
+// record it is reported as a warning; the audit itself succeeded.
+func recordAuditTrend(writer *OutputWriter, audit string, opts types.AuditOptions, result *types.AuditResult) {
+	store, err := permissions.DefaultAuditTrendStore()
+	if err == nil {
+		err = store.Record(permissions.NewAuditSnapshot(audit, opts, result, time.Now()))
+	}
+	if err != nil {
+		writer.AddWarning("AUDIT_TREND_NOT_RECORDED", fmt.Sprintf("failed to record the audit in the trend history: %s", err), "low")
+	}
+}
+
+func signedCount(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/schedule"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var permWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch a folder for permission drift",
+	Long: `Periodically re-audit a folder and alert on new exposure compared with a
+baseline snapshot: public links on files that had none, and external grantees
+(outside the internal domains) that a file did not have. Removed or narrowed
+access is not reported.
+
+If the baseline file does not exist, the current permissions are saved to it
+and become the baseline. Each alert is reported once per process; use
+--update-baseline to accept changes into the baseline after alerting.
+
+Alerts are logged, written to stdout, and posted to each --notify target
+(slack://... or an https:// webhook). Run with --once from cron, or as a
+long-running service.`,
+	Example: "  gdrv permissions watch --folder-id <folder-id> --baseline finance.json --interval 1h \\\n    --recursive --notify slack://T000/B000/XXXX",
+	Args:    cobra.NoArgs,
+	RunE:    runPermWatch,
+}
+
+var (
+	watchFolderID       string
+	watchBaseline       string
+	watchInterval       time.Duration
+	watchRecursive      bool
+	watchInternalDomain []string
+	watchNotify         []string
+	watchOnce           bool
+	watchUpdateBaseline bool
+)
+
+func init() {
+	permissionsCmd.AddCommand(permWatchCmd)
+
+	permWatchCmd.Flags().StringVar(&watchFolderID, "folder-id", "", "Folder to watch (required)")
+	permWatchCmd.Flags().StringVar(&watchBaseline, "baseline", "", "Baseline snapshot file, created on first run (required)")
+	permWatchCmd.Flags().DurationVar(&watchInterval, "interval", time.Hour, "Time between checks")
+	permWatchCmd.Flags().BoolVar(&watchRecursive, "recursive", false, "Include subfolders")
+	permWatchCmd.Flags().StringSliceVar(&watchInternalDomain, "internal-domain", nil, "Internal domains, exact or *.example.com (default: the profile's configured domains)")
+	permWatchCmd.Flags().StringArrayVar(&watchNotify, "notify", nil, "Post alerts to slack://... or an https:// webhook (repeatable)")
+	permWatchCmd.Flags().BoolVar(&watchOnce, "once", false, "Check once and exit")
+	permWatchCmd.Flags().BoolVar(&watchUpdateBaseline, "update-baseline", false, "Save each check as the new baseline after alerting")
+	_ = permWatchCmd.MarkFlagRequired("folder-id")
+	_ = permWatchCmd.MarkFlagRequired("baseline")
+}
+
+// permissionWatcher holds the state of a watch across checks
+type permissionWatcher struct {
+	mgr      *permissions.Manager
+	flags    types.GlobalFlags
+	out      *OutputWriter
+	internal *permissions.DomainMatcher
+	targets  []*schedule.NotifyTarget
+	http     *http.Client
+	baseline *types.PermissionSnapshot
+	alerted  map[string]bool
+}
+
+func runPermWatch(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	if watchInterval < time.Minute && !watchOnce {
+		return out.WriteError("permissions.watch", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--interval must be at least 1m").Build())
+	}
+
+	var targets []*schedule.NotifyTarget
+	for _, n := range watchNotify {
+		target, err := schedule.ParseNotifyTarget(n)
+		if err != nil {
+			return out.WriteError("permissions.watch", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		targets = append(targets, target)
+	}
+
+	internalDomains, err := resolveInternalDomains(flags.Profile, watchInternalDomain)
+	if err != nil {
+		return handleError(out, "permissions.watch", err)
+	}
+	if len(internalDomains) == 0 {
+		out.Log("No internal domains configured; only new public links will be reported")
+	}
+
+	mgr, err := getPermissionManager()
+	if err != nil {
+		return handleError(out, "permissions.watch", err)
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	w := &permissionWatcher{
+		mgr:      mgr,
+		flags:    flags,
+		out:      out,
+		internal: permissions.NewDomainMatcher(internalDomains...),
+		targets:  targets,
+		http:     &http.Client{Timeout: 30 * time.Second},
+		alerted:  map[string]bool{},
+	}
+
+	baseline, err := permissions.LoadSnapshot(watchBaseline)
+	switch {
+	case err == nil:
+		if baseline.FolderID != watchFolderID {
+			return out.WriteError("permissions.watch", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Baseline %s is for folder %s, not %s", watchBaseline, baseline.FolderID, watchFolderID)).
+				WithContext("suggestedAction", "use a separate --baseline file for each folder").
+				Build())
+		}
+		w.baseline = baseline
+	case os.IsNotExist(err):
+		snapshot, err := w.snapshot(ctx)
+		if err != nil {
+			return handleError(out, "permissions.watch", err)
+		}
+		if err := permissions.SaveSnapshot(watchBaseline, snapshot); err != nil {
+			return handleError(out, "permissions.watch", err)
+		}
+		out.Log("Saved baseline of %d file(s) to %s", len(snapshot.Files), watchBaseline)
+		w.baseline = snapshot
+		if watchOnce {
+			return out.WriteSuccess("permissions.watch", w.result(snapshot, []*types.PermissionAlert{}))
+		}
+	default:
+		return out.WriteError("permissions.watch", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	if watchOnce {
+		result, err := w.check(ctx)
+		if err != nil {
+			return handleError(out, "permissions.watch", err)
+		}
+		if len(result.Alerts) > 0 {
+			out.AddWarning("PERMISSION_DRIFT", fmt.Sprintf("%d new exposure(s) since the baseline", len(result.Alerts)), "high")
+		}
+		return out.WriteSuccess("permissions.watch", result)
+	}
+
+	out.Log("Watching folder %s every %s (baseline %s)", watchFolderID, watchInterval, watchBaseline)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	checkNow := baseline != nil
+	for {
+		if checkNow {
+			if err := w.checkAndReport(ctx); err != nil {
+				return err
+			}
+		}
+		checkNow = true
+
+		select {
+		case <-ctx.Done():
+			out.Log("Watch stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAndReport runs one check in service mode, writing a result only when
+// there are new alerts. Check failures are logged so the watch survives
+// transient errors.
+func (w *permissionWatcher) checkAndReport(ctx context.Context) error {
+	result, err := w.check(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.out.Log("Check failed: %v", err)
+		}
+		return nil
+	}
+	if len(result.Alerts) == 0 {
+		return nil
+	}
+	// A fresh writer per check so warnings don't accumulate
+	out := NewOutputWriter(w.flags.OutputFormat, w.flags.Quiet, w.flags.Verbose)
+	out.AddWarning("PERMISSION_DRIFT", fmt.Sprintf("%d new exposure(s) since the baseline", len(result.Alerts)), "high")
+	return out.WriteSuccess("permissions.watch", result)
+}
+
+func (w *permissionWatcher) snapshot(ctx context.Context) (*types.PermissionSnapshot, error) {
+	reqCtx := api.NewRequestContext(w.flags.Profile, w.flags.DriveID, types.RequestTypePermissionOp)
+	return w.mgr.Snapshot(ctx, reqCtx, watchFolderID, watchRecursive)
+}
+
+// check takes a snapshot and reports alerts not already reported
+func (w *permissionWatcher) check(ctx context.Context) (*types.PermissionWatchResult, error) {
+	current, err := w.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []*types.PermissionAlert{}
+	for _, alert := range permissions.DetectDrift(w.baseline, current, w.internal) {
+		if w.alerted[alert.Key()] {
+			continue
+		}
+		w.alerted[alert.Key()] = true
+		alerts = append(alerts, alert)
+	}
+
+	result := w.result(current, alerts)
+	if len(alerts) > 0 {
+		for _, alert := range alerts {
+			w.out.Log("ALERT %s: %s (%s %s) on %q", alert.Kind, alert.Grantee, alert.Type, alert.Role, alert.FileName)
+		}
+		text := watchAlertText(result)
+		for _, target := range w.targets {
+			if err := schedule.NotifyEvent(ctx, w.http, target, text, result); err != nil {
+				w.out.Log("Notification to %s failed: %v", target.Kind, err)
+			}
+		}
+	}
+
+	if watchUpdateBaseline {
+		if err := permissions.SaveSnapshot(watchBaseline, current); err != nil {
+			return nil, err
+		}
+		w.baseline = current
+		w.alerted = map[string]bool{}
+	}
+	return result, nil
+}
+
+func (w *permissionWatcher) result(snapshot *types.PermissionSnapshot, alerts []*types.PermissionAlert) *types.PermissionWatchResult {
+	return &types.PermissionWatchResult{
+		FolderID:     watchFolderID,
+		Baseline:     watchBaseline,
+		CheckedAt:    snapshot.TakenAt,
+		FilesChecked: len(snapshot.Files),
+		Alerts:       alerts,
+	}
+}
+
+// watchAlertText summarizes alerts for chat notifications
+func watchAlertText(result *types.PermissionWatchResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "gdrv permissions watch: %d new exposure(s) in folder %s", len(result.Alerts), result.FolderID)
+	for _, a := range result.Alerts {
+		switch a.Kind {
+		case types.AlertNewPublicLink:
+			fmt.Fprintf(&b, "\n• new public link (%s) on %q", a.Role, a.FileName)
+		default:
+			fmt.Fprintf(&b, "\n• new external %s %s (%s) on %q", a.Type, a.Grantee, a.Role, a.FileName)
+		}
+		if a.WebViewLink != "" {
+			fmt.Fprintf(&b, " %s", a.WebViewLink)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/about"
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// dryRunRecorder collects the run's planned operations under --dry-run
+var dryRunRecorder *safety.DefaultDryRunRecorder
+
+// applyDryRunPlan starts recording planned operations under --dry-run, and
+// stops any mutating request a command makes without planning it first
+func applyDryRunPlan(enabled bool) {
+	if !enabled {
+		dryRunRecorder = nil
+		api.SetDryRun(nil)
+		return
+	}
+	recorder := safety.NewDryRunRecorder()
+	dryRunRecorder = recorder
+	api.SetDryRun(func(req api.BlockedRequest) {
+		safety.RecordRequest(recorder, req.Method, req.Path, req.Params)
+	})
+}
+
+// dryRunSafety returns the safety options for a command that plans through
+// the *WithSafety manager methods
+func dryRunSafety(flags types.GlobalFlags) safety.SafetyOptions {
+	opts := safety.Default()
+	opts.DryRun = flags.DryRun
+	opts.Force = flags.Force
+	return opts
+}
+
+// planOperation adds op to the plan under --dry-run and reports whether it
+// did, in which case the caller must not make the change:
+//
+//	if planOperation(safety.PlannedOperation{...}) {
+//		return out.WriteSuccess(command, nil)
+//	}
+func planOperation(op safety.PlannedOperation) bool {
+	if dryRunRecorder == nil {
+		return false
+	}
+	dryRunRecorder.RecordOperation(op)
+	return true
+}
+
+// currentPlan returns the plan document for command, or nil outside a dry run
+func currentPlan(command string) *types.DryRunPlan {
+	if dryRunRecorder == nil {
+		return nil
+	}
+	return safety.NewPlan(command, dryRunRecorder.GetOperations())
+}
+
+// writePlanFile saves the plan document alone to --plan-file, so CI can
+// diff it against the plan from an earlier run
+func writePlanFile(plan *types.DryRunPlan) error {
+	if plan == nil || globalFlags.PlanFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(globalFlags.PlanFile, append(data, '\n'), 0644)
+}
+
+// planOrApprove records ops under --dry-run and reports true, in which
+// case the caller must not make the changes. Otherwise it checks them
+// against the approval policy with requireApproval.
+func planOrApprove(flags types.GlobalFlags, command string, ops []safety.PlannedOperation) (bool, error) {
+	if flags.DryRun {
+		for _, op := range ops {
+			planOperation(op)
+		}
+		return true, nil
+	}
+	return false, requireApproval(flags, command, ops)
+}
+
+// requireApproval checks the changes a bulk command is about to make.
+// Above the configured blast radius, or whenever --approval is given, the
+// run must carry an unexpired approval for exactly these changes, the
+// plan the same command reports under --dry-run, signed with a trusted key
+// that the account running it does not hold.
+func requireApproval(flags types.GlobalFlags, command string, ops []safety.PlannedOperation) error {
+	if !approvalNeeded(flags) {
+		return nil
+	}
+	policy, err := approvalPolicy()
+	if err != nil {
+		return err
+	}
+	plan := safety.NewPlan(command, ops)
+
+	if flags.ApprovalFile == "" {
+		reason := policy.Exceeded(plan)
+		if reason == "" {
+			return nil
+		}
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeApprovalRequired,
+			fmt.Sprintf("This change needs a second person's approval: %s. Write the plan with --dry-run --plan-file, have it signed with 'gdrv plan approve', then run again with --approval", reason)).
+			WithContext("planFingerprint", plan.Fingerprint).
+			WithContext("summary", plan.Summary).Build())
+	}
+	approval, err := safety.LoadApproval(flags.ApprovalFile)
+	if err != nil {
+		return err
+	}
+	operator, err := approvalOperator(flags)
+	if err != nil {
+		return err
+	}
+	return approval.Verify(plan, policy, operator, time.Now())
+}
+
+// approvalOperator returns the account the run makes its changes as,
+// which must not be the approver's
+func approvalOperator(flags types.GlobalFlags) (string, error) {
+	ctx := context.Background()
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return "", err
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, "", types.RequestTypeGetByID)
+	account, err := about.NewManager(client).Account(ctx, reqCtx)
+	if err != nil {
+		return "", err
+	}
+	return account.User.EmailAddress, nil
+}
+
+// approvalNeeded reports whether a real run must build its plan before
+// making changes, so a command whose plan costs extra requests can skip it
+func approvalNeeded(flags types.GlobalFlags) bool {
+	if flags.DryRun {
+		return false
+	}
+	if flags.ApprovalFile != "" {
+		return true
+	}
+	policy, err := approvalPolicy()
+	// An unreadable config fails the run in requireApproval
+	return err != nil || policy.MaxFiles > 0 || policy.MaxPermissionDeletions > 0
+}
+
+// approvalPolicy returns the configured approval policy. A config that
+// cannot be read is an error rather than no policy, so a damaged config
+// does not lift the limits.
+func approvalPolicy() (*safety.ApprovalPolicy, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeApprovalRequired,
+			fmt.Sprintf("Cannot check the approval policy: %v", err)).Build())
+	}
+	if cfg.Approval == nil {
+		return &safety.ApprovalPolicy{}, nil
+	}
+	return cfg.Approval, nil
+}
+
+// planRecorder returns the recorder to pass to *WithSafety manager methods,
+// or nil outside a dry run
+func planRecorder() safety.DryRunRecorder {
+	if dryRunRecorder == nil {
+		return nil
+	}
+	return dryRunRecorder
+}
+
+// planBulkItems adds the files a bulk command reported it
+// would change
+func planBulkItems(result *types.BulkOperationResult, opType safety.OperationType, params map[string]interface{}, predicted string) {
+	if dryRunRecorder == nil {
+		return
+	}
+	for _, op := range bulkOperations(result, opType, params, predicted) {
+		planOperation(op)
+	}
+}
+
+// bulkOperations describes the files a bulk command reported it would
+// change, as planBulkItems records them
+func bulkOperations(result *types.BulkOperationResult, opType safety.OperationType, params map[string]interface{}, predicted string) []safety.PlannedOperation {
+	if result == nil {
+		return nil
+	}
+	ops := make([]safety.PlannedOperation, 0, len(result.SuccessfulFiles))
+	for _, item := range result.SuccessfulFiles {
+		ops = append(ops, safety.PlannedOperation{
+			Type:         opType,
+			ResourceID:   item.FileID,
+			ResourceName: item.FileName,
+			Description:  item.Operation + ": " + item.FileName,
+			Parameters:   params,
+			Predicted:    predicted,
+		})
+	}
+	return ops
+}
+
+// approveBulk checks a bulk command's changes against the approval policy
+// before a real run. Which files change is only known after reading them,
+// so when approval is needed preview runs the command as a dry run first.
+func approveBulk(flags types.GlobalFlags, command string, preview func() (*types.BulkOperationResult, error), opType safety.OperationType, params map[string]interface{}, predicted string) error {
+	if !approvalNeeded(flags) {
+		return nil
+	}
+	result, err := preview()
+	if err != nil {
+		return err
+	}
+	return requireApproval(flags, command, bulkOperations(result, opType, params, predicted))
+}
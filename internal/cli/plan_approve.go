@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Review and approve dry-run plans",
+	Long: `Approve the plan of a bulk change so it can run above the blast radius.
+
+When the config sets an approval policy, a bulk change that touches more
+files or removes more permission grants than it allows is refused unless
+the run is given --approval with an approval file for exactly the changes
+it is about to make. The approval is made by a second person from the plan
+the same command wrote under --dry-run --plan-file, and is signed with a key
+listed in approval.trustedKeys. approval.keyOwners names the account that
+holds each key, so the person running the change cannot approve it:
+
+  "approval": {
+    "maxFiles": 200,
+    "maxPermissionDeletions": 20,
+    "trustedKeys": ["ed25519:..."],
+    "keyOwners": {"ed25519:...": "alice@example.com"}
+  }`,
+}
+
+var planApproveCmd = &cobra.Command{
+	Use:   "approve <plan-file>",
+	Short: "Sign a plan written with --dry-run --plan-file",
+	Long: `Sign a plan document with an approver key and write the approval file.
+
+The plan's fingerprint is recomputed from its operations, so a plan edited
+after it was written is refused. The approval is valid only for a run that
+would make exactly these changes: if the files or grants have changed
+since the plan was written, the run is refused and needs a new plan. It
+expires after --valid-for, and is refused for a run made by the account
+approval.keyOwners lists for the signing key.`,
+	Example: "  gdrv permissions apply --manifest grants.csv --dry-run --plan-file plan.json\n" +
+		"  gdrv plan approve plan.json --key ~/.gdrv-approver.pem --out approval.json\n" +
+		"  gdrv permissions apply --manifest grants.csv --approval approval.json",
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanApprove,
+}
+
+var planKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Create an approver signing key",
+	Long: `Create an Ed25519 approver key as a PKCS #8 PEM file, readable only by
+you, and print its public key for approval.trustedKeys. A key made with
+'openssl genpkey -algorithm ed25519' works as well.`,
+	Example: "  gdrv plan keygen --out ~/.gdrv-approver.pem",
+	Args:    cobra.NoArgs,
+	RunE:    runPlanKeygen,
+}
+
+var (
+	planApproveKey      string
+	planApproveApprover string
+	planApproveOut      string
+	planApproveValidFor string
+	planKeygenOut       string
+)
+
+func init() {
+	planApproveCmd.Flags().StringVar(&planApproveKey, "key", "", "Approver private key (PEM) (required)")
+	planApproveCmd.Flags().StringVar(&planApproveApprover, "approver", "", "Name or email recorded in the approval")
+	planApproveCmd.Flags().StringVar(&planApproveOut, "out", "approval.json", "Approval file to write")
+	planApproveCmd.Flags().StringVar(&planApproveValidFor, "valid-for", "24h", "How long the approval can be used (e.g. 2h, 3d)")
+	_ = planApproveCmd.MarkFlagRequired("key")
+	planKeygenCmd.Flags().StringVar(&planKeygenOut, "out", "", "Private key file to create (required)")
+	_ = planKeygenCmd.MarkFlagRequired("out")
+
+	planCmd.AddCommand(planApproveCmd)
+	planCmd.AddCommand(planKeygenCmd)
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlanApprove(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return out.WriteError("plan.approve", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to read plan file: %v", err)).Build())
+	}
+	var plan types.DryRunPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return out.WriteError("plan.approve", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s is not a plan document: %v", args[0], err)).Build())
+	}
+	validFor, err := utils.ParseAge(planApproveValidFor)
+	if err != nil || validFor == 0 {
+		return out.WriteError("plan.approve", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid --valid-for %q: expected a positive period such as 24h", planApproveValidFor)).Build())
+	}
+	key, err := safety.LoadPrivateKey(planApproveKey)
+	if err != nil {
+		return out.WriteError("plan.approve", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to load approver key: %v", err)).Build())
+	}
+
+	approval, err := safety.ApprovePlan(&plan, key, planApproveApprover, time.Now(), validFor)
+	if err != nil {
+		return handleError(out, "plan.approve", err)
+	}
+	encoded, err := json.MarshalIndent(approval, "", "  ")
+	if err != nil {
+		return handleError(out, "plan.approve", err)
+	}
+	if err := utils.WriteFileAtomic(planApproveOut, append(encoded, '\n'), 0644); err != nil {
+		return out.WriteError("plan.approve", utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to write approval file: %v", err)).Build())
+	}
+
+	out.Log("Approved %s plan %s (%d operations) until %s; wrote %s", plan.Command, approval.PlanFingerprint, len(plan.Operations), approval.ExpiresAt.Format(time.RFC3339), planApproveOut)
+	return out.WriteSuccess("plan.approve", approval)
+}
+
+func runPlanKeygen(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return handleError(out, "plan.keygen", err)
+	}
+	encoded, err := safety.EncodePrivateKey(key)
+	if err != nil {
+		return handleError(out, "plan.keygen", err)
+	}
+	// O_EXCL: never replace an existing key, which would orphan its
+	// entry in approval.trustedKeys
+	f, err := os.OpenFile(planKeygenOut, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return out.WriteError("plan.keygen", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to create key file: %v", err)).Build())
+	}
+	_, err = f.Write(encoded)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return out.WriteError("plan.keygen", utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to write key file: %v", err)).Build())
+	}
+
+	publicKey := safety.EncodePublicKey(pub)
+	out.Log("Wrote %s; add its public key to approval.trustedKeys and approval.keyOwners: %s", planKeygenOut, publicKey)
+	return out.WriteSuccess("plan.keygen", map[string]string{"keyFile": planKeygenOut, "publicKey": publicKey})
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestApproveBulk_MaxPermissionDeletionsBlocksRemovePublic(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodGet:
+			t.Errorf("previewing made a change: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/permissions"):
+			_, _ = w.Write([]byte(`{"permissions":[{"id":"anyoneWithLink","type":"anyone","role":"reader"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"files":[{"id":"f1","name":"a.pdf"},{"id":"f2","name":"b.pdf"}]}`))
+		}
+	}))
+	mgr := permissions.NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		limit   int
+		blocked bool
+	}{{1, true}, {2, false}} {
+		dir := t.TempDir()
+		t.Setenv("GDRV_CONFIG_DIR", dir)
+		cfg, _ := json.Marshal(map[string]interface{}{"approval": &safety.ApprovalPolicy{
+			MaxPermissionDeletions: tt.limit,
+			TrustedKeys:            []string{safety.EncodePublicKey(pub)},
+			KeyOwners:              map[string]string{safety.EncodePublicKey(pub): "approver@example.com"},
+		}})
+		if err := os.WriteFile(filepath.Join(dir, config.ConfigFileName), cfg, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		err = approveBulk(types.GlobalFlags{}, "permissions.bulk.remove-public", func() (*types.BulkOperationResult, error) {
+			return mgr.BulkRemovePublic(context.Background(), reqCtx, types.BulkOptions{FolderID: "folder", DryRun: true})
+		}, safety.OpTypeDeletePermission, map[string]interface{}{"type": "anyone"}, "public access removed")
+		if !tt.blocked {
+			if err != nil {
+				t.Errorf("limit %d: %v", tt.limit, err)
+			}
+			continue
+		}
+		appErr, ok := err.(*utils.AppError)
+		if !ok || appErr.CLIError.Code != utils.ErrCodeApprovalRequired {
+			t.Fatalf("limit %d: got %v, want an approval required error", tt.limit, err)
+		}
+		if !strings.Contains(appErr.CLIError.Message, "2 permission deletions (limit 1)") {
+			t.Errorf("message %q does not give the deletions", appErr.CLIError.Message)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+)
+
+// apiProfile is set when --verbose or --profile-api is given
+var apiProfile *api.LatencyProfile
+
+// maxSlowCalls limits how many slow calls --verbose lists
+const maxSlowCalls = 20
+
+func startAPIProfile() {
+	apiProfile = api.NewLatencyProfile()
+	api.SetLatencyProfile(apiProfile)
+}
+
+// reportAPIProfile lists the API calls slower than the run's p95 on stderr
+// with --verbose, and writes the latency report to --profile-api
+func reportAPIProfile() error {
+	if apiProfile == nil {
+		return nil
+	}
+	if globalFlags.Verbose {
+		slow, p95 := apiProfile.SlowCalls()
+		writeSlowCalls(os.Stderr, slow, p95)
+	}
+	if globalFlags.ProfileAPI == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(apiProfile.Report(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(globalFlags.ProfileAPI, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write API profile: %w", err)
+	}
+	return nil
+}
+
+// writeSlowCalls lists the slowest calls with their trace IDs, which match
+// the traceId of the calls in the log
+func writeSlowCalls(w io.Writer, slow []api.CallTiming, p95 time.Duration) {
+	if len(slow) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Slow API calls (over p95 of %s):\n", p95.Round(time.Millisecond))
+	for i, call := range slow {
+		if i == maxSlowCalls {
+			fmt.Fprintf(w, "  ... and %d more\n", len(slow)-maxSlowCalls)
+			break
+		}
+		fmt.Fprintf(w, "  %s\n", call)
+	}
+}
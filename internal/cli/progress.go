@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// itemProgress renders the progress of a long-running manager operation
+// on a single stderr line, e.g. "permissions audit 120/800 report.pdf"
+type itemProgress struct {
+	w     io.Writer
+	now   func() time.Time
+	mu    sync.Mutex
+	last  time.Time
+	drawn bool
+}
+
+// maxProgressItem limits how much of an item's name is shown
+const maxProgressItem = 48
+
+// interruptContext returns a context that Ctrl+C or SIGTERM cancels, so a
+// long-running command stops at the next item and still reports what it
+// did. stop restores the default signal handling.
+func interruptContext(parent context.Context) (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// startProgress sets a progress reporter drawing to stderr with set, unless
+// output is quiet or stderr is not a terminal. The returned func clears the
+// progress line and should be called before the command writes its result.
+func startProgress(quiet bool, set func(types.ProgressReporter)) func() {
+	if quiet || !stderrIsTerminal() {
+		return func() {}
+	}
+	p := &itemProgress{w: os.Stderr, now: time.Now}
+	set(p)
+	return p.finish
+}
+
+// Progress implements types.ProgressReporter
+func (p *itemProgress) Progress(event types.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if now.Sub(p.last) < progressInterval && (event.Total == 0 || event.Done < event.Total) {
+		return
+	}
+	p.last = now
+	p.drawn = true
+	fmt.Fprintf(p.w, "\r\033[K%s", progressLine(event))
+}
+
+func (p *itemProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.drawn {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// progressLine describes event: the operation, the count and the item
+func progressLine(event types.ProgressEvent) string {
+	line := fmt.Sprintf("%s %d", event.Operation, event.Done)
+	if event.Total > 0 {
+		line += fmt.Sprintf("/%d", event.Total)
+	}
+	if item := []rune(event.Item); len(item) > maxProgressItem {
+		line += " …" + string(item[len(item)-maxProgressItem+1:])
+	} else if len(item) > 0 {
+		line += " " + event.Item
+	}
+	return line
+}
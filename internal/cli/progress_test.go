@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestProgressLine(t *testing.T) {
+	long := strings.Repeat("a", maxProgressItem) + "/report.pdf"
+	tests := []struct {
+		event types.ProgressEvent
+		want  string
+	}{
+		{types.ProgressEvent{Operation: "permissions audit", Done: 3}, "permissions audit 3"},
+		{types.ProgressEvent{Operation: "permissions audit", Done: 3, Total: 10, Item: "Plan"}, "permissions audit 3/10 Plan"},
+		{types.ProgressEvent{Operation: "files restore", Done: 1, Total: 2, Item: long}, "files restore 1/2 …" + long[len(long)-maxProgressItem+1:]},
+	}
+	for _, tt := range tests {
+		if got := progressLine(tt.event); got != tt.want {
+			t.Errorf("progressLine(%+v) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestItemProgress_ThrottlesAndClears(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(0, 0)
+	p := &itemProgress{w: &out, now: func() time.Time { return now }}
+
+	p.Progress(types.ProgressEvent{Operation: "op", Done: 1, Total: 3})
+	p.Progress(types.ProgressEvent{Operation: "op", Done: 2, Total: 3})
+	if strings.Contains(out.String(), "op 2/3") {
+		t.Errorf("redrew within progressInterval: %q", out.String())
+	}
+	p.Progress(types.ProgressEvent{Operation: "op", Done: 3, Total: 3})
+	if !strings.HasSuffix(out.String(), "op 3/3") {
+		t.Errorf("the last item should always be drawn: %q", out.String())
+	}
+
+	out.Reset()
+	p.finish()
+	if out.String() != "\r\033[K" {
+		t.Errorf("finish wrote %q, want the line cleared", out.String())
+	}
+	out.Reset()
+	p.finish()
+	if out.Len() != 0 {
+		t.Errorf("second finish wrote %q", out.String())
+	}
+}
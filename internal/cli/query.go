@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Drive query helpers",
+	Long:  "Parse, validate, and build Drive search queries without calling the API",
+}
+
+var queryExplainCmd = &cobra.Command{
+	Use:   "explain <query>",
+	Short: "Validate a Drive query and show its normalized form",
+	Long: `Parse and validate a Drive search query (the --query / q parameter).
+
+Syntax errors and invalid terms are reported with their column position.
+Valid queries are shown in the normalized form gdrv sends to the API.
+
+Examples:
+  gdrv query explain "name contains 'report' and modifiedTime > '2024-01-01'"
+  gdrv query explain "'root' in parents and trashed = false" --output table`,
+	Args: cobra.ExactArgs(1),
+	RunE: runQueryExplain,
+}
+
+var queryBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a Drive query from flags or interactively",
+	Long: `Build a Drive search query with correct quoting and escaping.
+
+Terms given as flags are joined with "and". Use --interactive to be
+prompted for each term instead.
+
+Examples:
+  gdrv query build --name-contains "Q3 report" --mime-type application/pdf
+  gdrv query build --parent root --modified-after 2024-01-01 --trashed=false
+  gdrv query build --interactive`,
+	RunE: runQueryBuild,
+}
+
+var (
+	queryBuildName           string
+	queryBuildNameContains   string
+	queryBuildFullText       string
+	queryBuildMimeType       string
+	queryBuildParent         string
+	queryBuildOwner          string
+	queryBuildModifiedAfter  string
+	queryBuildModifiedBefore string
+	queryBuildStarred        bool
+	queryBuildTrashed        bool
+	queryBuildSharedWithMe   bool
+	queryBuildProperties     []string
+	queryBuildRaw            string
+	queryBuildInteractive    bool
+)
+
+func init() {
+	queryBuildCmd.Flags().StringVar(&queryBuildName, "name", "", "Exact file name")
+	queryBuildCmd.Flags().StringVar(&queryBuildNameContains, "name-contains", "", "File name contains")
+	queryBuildCmd.Flags().StringVar(&queryBuildFullText, "full-text", "", "Full-text search")
+	queryBuildCmd.Flags().StringVar(&queryBuildMimeType, "mime-type", "", "MIME type")
+	queryBuildCmd.Flags().StringVar(&queryBuildParent, "parent", "", "Parent folder ID")
+	queryBuildCmd.Flags().StringVar(&queryBuildOwner, "owner", "", "Owner email address")
+	queryBuildCmd.Flags().StringVar(&queryBuildModifiedAfter, "modified-after", "", "Modified after (RFC 3339 or YYYY-MM-DD)")
+	queryBuildCmd.Flags().StringVar(&queryBuildModifiedBefore, "modified-before", "", "Modified before (RFC 3339 or YYYY-MM-DD)")
+	queryBuildCmd.Flags().BoolVar(&queryBuildStarred, "starred", false, "Starred files")
+	queryBuildCmd.Flags().BoolVar(&queryBuildTrashed, "trashed", false, "Trashed files")
+	queryBuildCmd.Flags().BoolVar(&queryBuildSharedWithMe, "shared-with-me", false, "Files shared with me")
+	queryBuildCmd.Flags().StringArrayVar(&queryBuildProperties, "property", nil, "Custom property key=value (repeatable)")
+	queryBuildCmd.Flags().StringVar(&queryBuildRaw, "raw", "", "Additional query fragment to include")
+	queryBuildCmd.Flags().BoolVarP(&queryBuildInteractive, "interactive", "i", false, "Prompt for each term")
+
+	queryCmd.AddCommand(queryExplainCmd)
+	queryCmd.AddCommand(queryBuildCmd)
+	rootCmd.AddCommand(queryCmd)
+}
+
+func runQueryExplain(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	return writeQueryExplanation(out, "query.explain", query.Explain(args[0]))
+}
+
+func runQueryBuild(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	if queryBuildInteractive {
+		q, err := query.BuildInteractive(os.Stdin, os.Stderr)
+		if err != nil {
+			return out.WriteError("query.build", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		if q == "" {
+			return out.WriteError("query.build", utils.NewCLIError(utils.ErrCodeInvalidArgument, "No query terms entered").Build())
+		}
+		return writeQueryExplanation(out, "query.build", query.Explain(q))
+	}
+
+	b := query.NewBuilder()
+	terms := []struct {
+		field, operator, value string
+	}{
+		{"name", "=", queryBuildName},
+		{"name", "contains", queryBuildNameContains},
+		{"fullText", "contains", queryBuildFullText},
+		{"mimeType", "=", queryBuildMimeType},
+		{"parents", "in", queryBuildParent},
+		{"owners", "in", queryBuildOwner},
+		{"modifiedTime", ">", queryBuildModifiedAfter},
+		{"modifiedTime", "<", queryBuildModifiedBefore},
+	}
+	for _, t := range terms {
+		if t.value == "" {
+			continue
+		}
+		if err := b.AddTerm(t.field, t.operator, t.value); err != nil {
+			return out.WriteError("query.build", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+	}
+	for _, p := range queryBuildProperties {
+		if err := b.AddTerm("properties", "has", p); err != nil {
+			return out.WriteError("query.build", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+	}
+	if cmd.Flags().Changed("starred") {
+		b.WhereBool("starred", queryBuildStarred)
+	}
+	if cmd.Flags().Changed("trashed") {
+		b.WhereBool("trashed", queryBuildTrashed)
+	}
+	if cmd.Flags().Changed("shared-with-me") {
+		b.WhereBool("sharedWithMe", queryBuildSharedWithMe)
+	}
+	b.Raw(queryBuildRaw)
+
+	if b.Len() == 0 {
+		return out.WriteError("query.build", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"No query terms given; pass term flags or use --interactive").Build())
+	}
+
+	return writeQueryExplanation(out, "query.build", query.Explain(b.String()))
+}
+
+func writeQueryExplanation(out *OutputWriter, command string, explanation *types.QueryExplanation) error {
+	for _, w := range explanation.Warnings {
+		out.AddWarning("QUERY_NORMALIZED", fmt.Sprintf("%s (column %d)", w.Message, w.Position), "low")
+	}
+
+	if !explanation.Valid {
+		first := explanation.Errors[0]
+		messages := make([]string, len(explanation.Errors))
+		for i, e := range explanation.Errors {
+			messages[i] = fmt.Sprintf("column %d: %s", e.Position, e.Message)
+		}
+		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid query: %s at column %d", first.Message, first.Position)).
+			WithContext("query", explanation.Query).
+			WithContext("position", first.Position).
+			WithContext("pointer", pointerLine(explanation.Query, first.Position)).
+			WithContext("errors", strings.Join(messages, "; ")).
+			Build())
+	}
+
+	return out.WriteSuccess(command, explanation)
+}
+
+// pointerLine renders the query with a caret under the given column
+func pointerLine(q string, column int) string {
+	if column < 1 {
+		column = 1
+	}
+	return q + "\n" + strings.Repeat(" ", column-1) + "^"
+}
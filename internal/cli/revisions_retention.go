@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/revisions"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var filesRevisionsKeepCmd = &cobra.Command{
+	Use:   "keep <file-id> <revision-id>",
+	Short: "Keep a revision forever, or let Drive purge it",
+	Long: `Mark a revision keepForever so Drive does not purge it after 30 days or
+100 newer revisions, or with --forever=false let Drive purge it again.
+
+Only revisions of files with binary content can be kept, and at most 200
+per file. A revision must be kept before it can be downloaded.`,
+	Example: "  gdrv files revisions keep <file-id> <revision-id>\n" +
+		"  gdrv files revisions keep <file-id> <revision-id> --forever=false",
+	Args: cobra.ExactArgs(2),
+	RunE: runFilesRevisionsKeep,
+}
+
+var filesRevisionsDeleteCmd = &cobra.Command{
+	Use:   "delete <file-id> <revision-id>",
+	Short: "Permanently delete a revision",
+	Long: `Permanently delete a revision of a file with binary content. The head
+revision and revisions of Google Docs, Sheets and Slides cannot be deleted.
+
+The deletion is confirmed unless --force or --yes (covering the delete
+scope) is given.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runFilesRevisionsDelete,
+}
+
+var filesRevisionsPruneCmd = &cobra.Command{
+	Use:   "prune <file-id>",
+	Short: "Delete all but the newest revisions",
+	Long: `Permanently delete every revision of a file older than the newest
+--keep-last revisions. Revisions marked keepForever are kept; unmark them
+with 'gdrv files revisions keep <file-id> <revision-id> --forever=false'
+to prune them.
+
+The deletion is confirmed unless --force or --yes (covering the delete
+scope) is given. Use --dry-run to list what would be deleted. A revision
+that fails is reported and the rest are still deleted.`,
+	Example: "  gdrv files revisions prune <file-id> --keep-last 10 --dry-run\n" +
+		"  gdrv files revisions prune <file-id> --keep-last 10 --yes=delete",
+	Args: cobra.ExactArgs(1),
+	RunE: runFilesRevisionsPrune,
+}
+
+var (
+	revisionsKeepForever bool
+	revisionsKeepLast    int
+)
+
+func init() {
+	filesRevisionsKeepCmd.Flags().BoolVar(&revisionsKeepForever, "forever", true, "Keep the revision forever (false lets Drive purge it)")
+	filesRevisionsPruneCmd.Flags().IntVar(&revisionsKeepLast, "keep-last", 0, "Number of newest revisions to keep (required)")
+	_ = filesRevisionsPruneCmd.MarkFlagRequired("keep-last")
+	filesRevisionsCmd.AddCommand(filesRevisionsKeepCmd)
+	filesRevisionsCmd.AddCommand(filesRevisionsDeleteCmd)
+	filesRevisionsCmd.AddCommand(filesRevisionsPruneCmd)
+}
+
+func runFilesRevisionsKeep(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	_, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.revisions.keep", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.revisions.keep", appErr.CLIError)
+		}
+		return out.WriteError("files.revisions.keep", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+	revisionID := args[1]
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdate,
+		ResourceID:  fileID,
+		Description: fmt.Sprintf("Set keepForever=%t on revision %s", revisionsKeepForever, revisionID),
+		Parameters:  map[string]interface{}{"revisionId": revisionID, "keepForever": revisionsKeepForever},
+	}) {
+		return out.WriteSuccess("files.revisions.keep", nil)
+	}
+
+	revMgr := revisions.NewManager(client)
+	reqCtx.RequestType = types.RequestTypeMutation
+
+	revision, err := revMgr.Update(ctx, reqCtx, fileID, revisionID, revisions.UpdateOptions{KeepForever: revisionsKeepForever})
+	if err != nil {
+		return handleError(out, "files.revisions.keep", err)
+	}
+
+	if revision.KeepForever {
+		out.Log("Revision %s will be kept forever", revisionID)
+	} else {
+		out.Log("Revision %s can be purged by Drive", revisionID)
+	}
+	return out.WriteSuccess("files.revisions.keep", revision)
+}
+
+func runFilesRevisionsDelete(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+
+	_, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.revisions.delete", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.revisions.delete", appErr.CLIError)
+		}
+		return out.WriteError("files.revisions.delete", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+	revisionID := args[1]
+
+	if planOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeDelete,
+		ResourceID:  fileID,
+		Description: "Delete revision " + revisionID,
+		Parameters:  map[string]interface{}{"revisionId": revisionID, "permanent": true},
+		Predicted:   "permanently deleted",
+	}) {
+		return out.WriteSuccess("files.revisions.delete", nil)
+	}
+
+	opts := dryRunSafety(flags).ForScope(safety.ScopeDelete)
+	if opts.ShouldConfirm() {
+		confirmed, err := safety.Confirm(fmt.Sprintf("About to permanently delete revision %s. Continue?", revisionID), opts)
+		if err != nil {
+			return handleError(out, "files.revisions.delete", err)
+		}
+		if !confirmed {
+			return out.WriteError("files.revisions.delete", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	revMgr := revisions.NewManager(client)
+	reqCtx.RequestType = types.RequestTypeMutation
+
+	if err := revMgr.Delete(ctx, reqCtx, fileID, revisionID); err != nil {
+		return handleError(out, "files.revisions.delete", err)
+	}
+
+	out.Log("Deleted revision: %s", revisionID)
+	return out.WriteSuccess("files.revisions.delete", map[string]string{"fileId": fileID, "revisionId": revisionID})
+}
+
+func runFilesRevisionsPrune(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	_, client, reqCtx, out, err := getFileManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("files.revisions.prune", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+
+	fileID, err := ResolveFileID(ctx, client, flags, args[0])
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return out.WriteError("files.revisions.prune", appErr.CLIError)
+		}
+		return out.WriteError("files.revisions.prune", utils.NewCLIError(utils.ErrCodeInvalidPath, err.Error()).Build())
+	}
+
+	revMgr := revisions.NewManager(client)
+	reqCtx.RequestType = types.RequestTypeListOrSearch
+
+	plan, err := revMgr.PlanPrune(ctx, reqCtx, fileID, revisionsKeepLast)
+	if err != nil {
+		return handleError(out, "files.revisions.prune", err)
+	}
+
+	ops := make([]safety.PlannedOperation, len(plan.Delete))
+	for i, r := range plan.Delete {
+		ops[i] = safety.PlannedOperation{
+			Type:        safety.OpTypeDelete,
+			ResourceID:  fileID,
+			Description: "Prune revision " + r.ID,
+			Parameters:  map[string]interface{}{"revisionId": r.ID, "permanent": true},
+			Predicted:   "permanently deleted",
+		}
+	}
+	planned, err := planOrApprove(flags, "files.revisions.prune", ops)
+	if err != nil {
+		return handleError(out, "files.revisions.prune", err)
+	}
+
+	if len(plan.Delete) > 0 && !planned {
+		safetyOpts := safety.Default()
+		safetyOpts.Force = flags.Force
+		confirmed, err := safety.ConfirmBulkOperation(len(plan.Delete), "permanently delete revisions", safetyOpts.ForScope(safety.ScopeDelete))
+		if err != nil {
+			return handleError(out, "files.revisions.prune", err)
+		}
+		if !confirmed {
+			return out.WriteError("files.revisions.prune", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+		}
+	}
+
+	done := startProgress(flags.Quiet, revMgr.SetProgress)
+	result, err := revMgr.Prune(ctx, reqCtx, plan, planned)
+	done()
+	if err != nil {
+		return handleError(out, "files.revisions.prune", err)
+	}
+
+	if len(result.Failed) > 0 {
+		out.AddWarning("PRUNE_FAILED", fmt.Sprintf("%d of %d revisions could not be deleted; see failed", len(result.Failed), len(plan.Delete)), "high")
+	}
+	if !planned {
+		out.Log("Deleted %d revisions, kept %d pinned, %d failed", len(result.Deleted), len(result.Pinned), len(result.Failed))
+	}
+	return out.WriteSuccess("files.revisions.prune", result)
+}
@@ -3,12 +3,19 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/extract"
 	"github.com/dl-alexandre/gdrv/internal/logging"
 	"github.com/dl-alexandre/gdrv/internal/resolver"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/spool"
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/pkg/version"
 	"github.com/spf13/cobra"
@@ -31,6 +38,24 @@ All commands support JSON output for automation and scripting.`,
 		if err := validateGlobalFlags(); err != nil {
 			return err
 		}
+		if err := loadMessageCatalog(); err != nil {
+			return err
+		}
+		safety.SetDefaultYesScopes(yesScopes(globalFlags.YesScopes))
+		applyReadOnly()
+		applyBillingProject()
+		auth.SetImpersonate(globalFlags.Impersonate)
+		api.SetTransportOptions(transportOptions())
+		api.SetMetadataCache(!globalFlags.NoCache)
+		tempdir.SetKeep(globalFlags.KeepTemp)
+		applyDisplay()
+		if globalFlags.FieldsAudit {
+			startFieldsAudit()
+		}
+		if globalFlags.Verbose || globalFlags.ProfileAPI != "" {
+			startAPIProfile()
+		}
+		applyDryRunPlan(globalFlags.DryRun)
 
 		// Initialize logging
 		logConfig := logging.LogConfig{
@@ -55,10 +80,92 @@ All commands support JSON output for automation and scripting.`,
 			return fmt.Errorf("failed to initialize logger: %w", err)
 		}
 
-		return nil
+		if err := ensureCommandScopes(cmd); err != nil {
+			return err
+		}
+		return selfCheckBeforeLongRun(cmd)
 	},
 }
 
+// yesFlag implements --yes, which takes an optional comma-separated list of
+// operation scopes to auto-confirm. Bare --yes confirms everything.
+type yesFlag struct {
+	flags *types.GlobalFlags
+}
+
+func (f *yesFlag) String() string {
+	if f.flags == nil || !f.flags.Yes {
+		return "false"
+	}
+	if len(f.flags.YesScopes) == 0 {
+		return "true"
+	}
+	return strings.Join(f.flags.YesScopes, ",")
+}
+
+func (f *yesFlag) Set(value string) error {
+	if strings.EqualFold(strings.TrimSpace(value), "false") {
+		f.flags.Yes = false
+		f.flags.YesScopes = nil
+		return nil
+	}
+	scopes, err := safety.ParseConfirmScopes(value)
+	if err != nil {
+		return err
+	}
+	f.flags.Yes = true
+	f.flags.YesScopes = nil
+	for _, s := range scopes {
+		f.flags.YesScopes = append(f.flags.YesScopes, string(s))
+	}
+	return nil
+}
+
+func (f *yesFlag) Type() string {
+	return "scopes"
+}
+
+func yesScopes(names []string) []safety.ConfirmScope {
+	if len(names) == 0 {
+		return nil
+	}
+	scopes := make([]safety.ConfirmScope, len(names))
+	for i, name := range names {
+		scopes[i] = safety.ConfirmScope(name)
+	}
+	return scopes
+}
+
+// loadMessageCatalog installs the configured message catalog for safety
+// prompts and summaries. An unreadable config is left for the commands that
+// need it to report.
+func loadMessageCatalog() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+
+	var catalog *safety.Catalog
+	switch {
+	case cfg.MessageCatalog != "":
+		catalog, err = safety.LoadCatalogFile(cfg.MessageCatalog)
+	case cfg.Locale != "":
+		dir, dirErr := config.GetLocalesDir()
+		if dirErr != nil {
+			return nil
+		}
+		catalog, err = safety.LoadLocale(dir, cfg.Locale)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load message catalog: %w", err)
+	}
+
+	safety.SetCatalog(catalog)
+	return nil
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number",
@@ -70,41 +177,130 @@ var versionCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&globalFlags.Profile, "profile", "default", "Authentication profile to use")
-	rootCmd.PersistentFlags().StringVar(&globalFlags.DriveID, "drive-id", "", "Shared Drive ID to operate in")
-	rootCmd.PersistentFlags().StringVar((*string)(&globalFlags.OutputFormat), "output", "json", "Output format (json, table)")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.DriveID, "drive-id", "", "Shared Drive ID to operate in; a single argument can use sd:<drive-id>:<path> instead")
+	rootCmd.PersistentFlags().StringVar((*string)(&globalFlags.OutputFormat), "output", "json", "Output format (json, table, or sarif for audit findings)")
 	rootCmd.PersistentFlags().BoolVarP(&globalFlags.Quiet, "quiet", "q", false, "Suppress non-essential output")
 	rootCmd.PersistentFlags().BoolVarP(&globalFlags.Verbose, "verbose", "v", false, "Enable verbose logging")
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.Debug, "debug", false, "Enable debug output")
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.Strict, "strict", false, "Convert warnings to errors")
-	rootCmd.PersistentFlags().BoolVar(&globalFlags.NoCache, "no-cache", false, "Bypass path resolution cache")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.NoCache, "no-cache", false, "Bypass path resolution and file metadata caches")
 	rootCmd.PersistentFlags().IntVar(&globalFlags.CacheTTL, "cache-ttl", 300, "Path cache TTL in seconds")
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.IncludeSharedWithMe, "include-shared-with-me", false, "Include shared-with-me items")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.NoFollowShortcuts, "no-follow-shortcuts", false, "Operate on shortcuts themselves instead of the files they point to")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.Config, "config", "", "Path to configuration file")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.LogFile, "log-file", "", "Path to log file")
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.DryRun, "dry-run", false, "Show what would be done without making changes")
 	rootCmd.PersistentFlags().BoolVarP(&globalFlags.Force, "force", "f", false, "Force operation without confirmation")
-	rootCmd.PersistentFlags().BoolVarP(&globalFlags.Yes, "yes", "y", false, "Answer yes to all prompts")
+	yes := rootCmd.PersistentFlags().VarPF(&yesFlag{flags: &globalFlags}, "yes", "y",
+		"Answer yes to prompts; limit with --yes=trash,permissions (scopes: "+strings.Join(safety.KnownScopes(), ", ")+")")
+	yes.NoOptDefVal = "true"
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.JSON, "json", false, "Output in JSON format (alias for --output json)")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.FieldsAudit, "fields-audit", false, "Record which API response fields the command uses and suggest a tighter field mask")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.ReadOnly, "read-only", false, "Refuse every API request that would modify Drive or Workspace data")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.KeepTemp, "keep-temp", false, "Keep the run's temp directory (partial downloads, spools) for debugging")
+	rootCmd.PersistentFlags().IntVar(&globalFlags.MaxConns, "max-conns", 0, "Maximum concurrent connections per API host (0 = no limit)")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.NoGzip, "no-gzip", false, "Do not request gzip-compressed API responses")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.HTTP1, "http1", false, "Use HTTP/1.1 instead of HTTP/2")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.BillingProject, "billing-project", "", "Google Cloud project to charge API quota and billing to, instead of the OAuth client's project")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.Impersonate, "impersonate", "", "Act as this user through the service account profile's domain-wide delegation, for this run only")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.SkipSelfCheck, "skip-self-check", false, "Start long operations without first checking the token, scopes and Drive access")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.TransportStats, "transport-stats", false, "Report requests, connection reuse and bytes transferred")
+	rootCmd.PersistentFlags().IntVar(&globalFlags.MaxMemoryResults, "max-memory-results", spool.DefaultLimit, "Results held in memory before the rest spill to a temp file and are streamed out (0 = no limit)")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.Extract, "extract", "", "Print only the value at a GJSON-style path in the result, e.g. 'files.#.id'")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.PlanFile, "plan-file", "", "With --dry-run, also write the plan document to this file")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.ApprovalFile, "approval", "", "Signed approval file from 'gdrv plan approve' for a bulk change above the configured blast radius")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.Summary, "summary", false, "Print a summary of operations, API calls, retries, bytes transferred and elapsed time to stderr at exit")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.SummaryFile, "summary-file", "", "Append the run summary to this file as a JSON line")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.ProfileAPI, "profile-api", "", "Write a per-endpoint API latency histogram and the slowest calls to this file as JSON")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.HumanReadable, "human-readable", false, "Show sizes, dates and counts in human-friendly form (e.g. 1.4 GiB, 3 days ago) in table and text output")
 
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
 }
 
+// applyReadOnly enables read-only mode for the run when --read-only is
+// given or the profile has readOnly set
+func applyReadOnly() {
+	if !globalFlags.ReadOnly {
+		if cfg, err := config.Load(); err == nil {
+			globalFlags.ReadOnly = cfg.Profile(globalFlags.Profile).ReadOnly
+		}
+	}
+	api.SetReadOnly(globalFlags.ReadOnly)
+}
+
+// applyBillingProject falls back to the profile's billingProject when
+// --billing-project is not given
+func applyBillingProject() {
+	if globalFlags.BillingProject == "" {
+		if cfg, err := config.Load(); err == nil {
+			globalFlags.BillingProject = cfg.Profile(globalFlags.Profile).BillingProject
+		}
+	}
+}
+
+// transportOptions tunes the shared HTTP transport from the global flags.
+// The idle pool grows with --max-conns so the extra connections are reused.
+func transportOptions() api.TransportOptions {
+	opts := api.DefaultTransportOptions()
+	opts.MaxConnsPerHost = globalFlags.MaxConns
+	if globalFlags.MaxConns > opts.MaxIdleConnsPerHost {
+		opts.MaxIdleConnsPerHost = globalFlags.MaxConns
+	}
+	opts.DisableGzip = globalFlags.NoGzip
+	opts.DisableHTTP2 = globalFlags.HTTP1
+	opts.QuotaProject = globalFlags.BillingProject
+	return opts
+}
+
 func validateGlobalFlags() error {
 	// Handle --json flag as alias for --output json
 	if globalFlags.JSON {
 		globalFlags.OutputFormat = types.OutputFormatJSON
 	}
 
-	if globalFlags.OutputFormat != types.OutputFormatJSON && globalFlags.OutputFormat != types.OutputFormatTable {
+	switch globalFlags.OutputFormat {
+	case types.OutputFormatJSON, types.OutputFormatTable, types.OutputFormatSARIF:
+	default:
 		return fmt.Errorf("invalid output format: %s", globalFlags.OutputFormat)
 	}
+	if globalFlags.MaxConns < 0 {
+		return fmt.Errorf("--max-conns must not be negative, got %d", globalFlags.MaxConns)
+	}
+	if globalFlags.PlanFile != "" && !globalFlags.DryRun {
+		return fmt.Errorf("--plan-file requires --dry-run")
+	}
+	if globalFlags.ApprovalFile != "" && globalFlags.DryRun {
+		return fmt.Errorf("--approval cannot be used with --dry-run")
+	}
+	if globalFlags.Impersonate != "" && !strings.Contains(globalFlags.Impersonate, "@") {
+		return fmt.Errorf("--impersonate must be a user's email address, got %q", globalFlags.Impersonate)
+	}
+	if globalFlags.Extract != "" {
+		if _, err := extract.Parse(globalFlags.Extract); err != nil {
+			return fmt.Errorf("--extract: %w", err)
+		}
+	}
 	return nil
 }
 
-// Execute runs the root command
+// Execute runs the root command, then removes the run's temp directory
+// and reports slow API calls and the run summary when asked to
 func Execute() error {
-	return rootCmd.Execute()
+	started := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	if dir, _ := tempdir.Cleanup(); dir != "" && !globalFlags.Quiet {
+		fmt.Fprintf(os.Stderr, "Temporary files kept in %s\n", dir)
+	}
+	if profileErr := reportAPIProfile(); profileErr != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", profileErr)
+	}
+	if globalFlags.Summary || globalFlags.SummaryFile != "" {
+		if summaryErr := writeRunSummary(newRunSummary(cmd, started, err)); summaryErr != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", summaryErr)
+		}
+	}
+	return err
 }
 
 // GetGlobalFlags returns the global flags
@@ -120,35 +316,55 @@ func GetLogger() logging.Logger {
 // ResolveFileID resolves a file ID from either a direct ID or a path
 // If the input starts with "/" or contains "/", it's treated as a path
 // Otherwise, it's treated as a direct file ID
+// An sd:<drive-id>:<path> argument is resolved in that Shared Drive
 func ResolveFileID(ctx context.Context, client *api.Client, flags types.GlobalFlags, fileIDOrPath string) (string, error) {
+	fileID, _, err := ResolveLocation(ctx, client, flags, fileIDOrPath)
+	return fileID, err
+}
+
+// ResolveLocation resolves an argument like ResolveFileID and also returns
+// the Shared Drive it was resolved in: the drive named by an sd: prefix, or
+// else --drive-id. Listing a folder should use that drive, since a folder
+// given as sd:<drive-id>:<path> may not be in the --drive-id drive.
+func ResolveLocation(ctx context.Context, client *api.Client, flags types.GlobalFlags, arg string) (string, string, error) {
+	loc, err := resolver.ParseLocation(arg)
+	if err != nil {
+		return "", "", err
+	}
+	driveID := flags.DriveID
+	if loc.DriveID != "" {
+		driveID = loc.DriveID
+	}
+	// A Shared Drive's root folder has the drive's ID
+	if loc.IsRoot() {
+		return loc.DriveID, driveID, nil
+	}
+
 	// Check if this looks like a path (contains "/" or starts with a path-like name)
-	if !isPath(fileIDOrPath) {
+	if !isPath(loc.Target) {
 		// Treat as direct file ID
-		return fileIDOrPath, nil
+		return loc.Target, driveID, nil
 	}
 
 	// Create path resolver
-	cacheTTL := time.Duration(flags.CacheTTL) * time.Second
-	if flags.NoCache {
-		cacheTTL = 0
-	}
-	pathResolver := resolver.NewPathResolver(client, cacheTTL)
+	pathResolver := GetPathResolver(client, flags)
 
 	// Create request context
-	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeListOrSearch)
+	reqCtx := api.NewRequestContext(flags.Profile, driveID, types.RequestTypeListOrSearch)
 
 	// Resolve path
-	result, err := pathResolver.Resolve(ctx, reqCtx, fileIDOrPath, resolver.ResolveOptions{
-		DriveID:             flags.DriveID,
+	result, err := pathResolver.Resolve(ctx, reqCtx, loc.Target, resolver.ResolveOptions{
+		DriveID:             driveID,
 		IncludeSharedWithMe: flags.IncludeSharedWithMe,
 		UseCache:            !flags.NoCache,
 		StrictMode:          flags.Strict,
+		NoFollowShortcuts:   flags.NoFollowShortcuts,
 	})
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return result.FileID, nil
+	return result.FileID, driveID, nil
 }
 
 // isPath determines if the input looks like a path rather than a file ID
@@ -172,7 +388,15 @@ func GetPathResolver(client *api.Client, flags types.GlobalFlags) *resolver.Path
 	if flags.NoCache {
 		cacheTTL = 0
 	}
-	return resolver.NewPathResolver(client, cacheTTL)
+	pathResolver := resolver.NewPathResolver(client, cacheTTL)
+	// Paths resolved by earlier runs, or by 'cache warm', are reused unless
+	// --no-cache is given; an unreadable cache is ignored
+	if !flags.NoCache {
+		if store, err := resolver.DefaultPathStore(); err == nil {
+			_ = pathResolver.UseStore(store)
+		}
+	}
+	return pathResolver
 }
 
 // GetResolveOptions creates resolve options from global flags
@@ -182,5 +406,6 @@ func GetResolveOptions(flags types.GlobalFlags) resolver.ResolveOptions {
 		IncludeSharedWithMe: flags.IncludeSharedWithMe,
 		UseCache:            !flags.NoCache,
 		StrictMode:          flags.Strict,
+		NoFollowShortcuts:   flags.NoFollowShortcuts,
 	}
 }
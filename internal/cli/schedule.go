@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/schedule"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Recurring tasks",
+	Long: `Run gdrv commands on a cron schedule without external cron plumbing.
+
+Tasks are stored in the config directory and executed by "gdrv schedule run",
+which can be kept running with a systemd or launchd service
+(see "gdrv schedule unit").`,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <name> --cron <expr> -- <command> [args...]",
+	Short: "Add a scheduled task",
+	Long: `Add a gdrv command to run on a cron schedule.
+
+The cron expression has five fields: minute hour day-of-month month
+day-of-week. @hourly, @daily, @weekly, @monthly and @yearly are also
+accepted. Everything after "--" is the gdrv command to run.
+
+Notification targets:
+  slack://hooks.slack.com/services/T000/B000/XXXX   Slack incoming webhook
+  https://example.com/hook                          JSON POST of the run result
+
+Examples:
+  gdrv schedule add audit-public --cron "0 6 * * 1" --notify slack://hooks.slack.com/services/T0/B0/X -- permissions audit public --folder-id X
+  gdrv schedule add nightly-backup --cron @daily --notify-on failure -- sync push backup`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runScheduleAdd,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled tasks",
+	RunE:  runScheduleList,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleRemove,
+}
+
+var scheduleTriggerCmd = &cobra.Command{
+	Use:   "trigger <name>",
+	Short: "Run a scheduled task now",
+	Long:  "Run a scheduled task immediately, record the result, and send its notification",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleTrigger,
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the scheduler in the foreground",
+	Long: `Run scheduled tasks as they become due until interrupted.
+
+Tasks run one at a time as child gdrv processes. Tasks added or removed
+while the scheduler is running are picked up within a minute.`,
+	RunE: runScheduleRun,
+}
+
+var scheduleUnitCmd = &cobra.Command{
+	Use:   "unit",
+	Short: "Print a systemd or launchd service for the scheduler",
+	Long: `Print a service definition that keeps "gdrv schedule run" running.
+
+Examples:
+  gdrv schedule unit --format systemd > ~/.config/systemd/user/gdrv-scheduler.service
+  systemctl --user enable --now gdrv-scheduler
+
+  gdrv schedule unit --format launchd > ~/Library/LaunchAgents/com.github.dl-alexandre.gdrv.scheduler.plist
+  launchctl load ~/Library/LaunchAgents/com.github.dl-alexandre.gdrv.scheduler.plist`,
+	RunE: runScheduleUnit,
+}
+
+var (
+	scheduleCron       string
+	scheduleNotify     string
+	scheduleNotifyOn   string
+	scheduleDisabled   bool
+	scheduleUnitFormat string
+)
+
+func init() {
+	scheduleAddCmd.Flags().StringVar(&scheduleCron, "cron", "", "Cron expression (required)")
+	scheduleAddCmd.Flags().StringVar(&scheduleNotify, "notify", "", "Notification target (slack:// or https://)")
+	scheduleAddCmd.Flags().StringVar(&scheduleNotifyOn, "notify-on", types.NotifyAlways, "When to notify: always or failure")
+	scheduleAddCmd.Flags().BoolVar(&scheduleDisabled, "disabled", false, "Add the task without enabling it")
+	_ = scheduleAddCmd.MarkFlagRequired("cron")
+
+	scheduleUnitCmd.Flags().StringVar(&scheduleUnitFormat, "format", schedule.UnitSystemd, "Service format: systemd or launchd")
+
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	scheduleCmd.AddCommand(scheduleTriggerCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	scheduleCmd.AddCommand(scheduleUnitCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	dash := cmd.ArgsLenAtDash()
+	if dash != 1 {
+		return out.WriteError("schedule.add", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Expected: schedule add <name> --cron <expr> -- <command> [args...]").Build())
+	}
+	name, command := args[0], args[1:]
+
+	if target, _, err := rootCmd.Find(command); err != nil || target == rootCmd {
+		return out.WriteError("schedule.add", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Unknown gdrv command: %s", command[0])).Build())
+	}
+
+	store, err := schedule.DefaultStore()
+	if err != nil {
+		return out.WriteError("schedule.add", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	task := &types.ScheduledTask{
+		Name:      name,
+		Cron:      scheduleCron,
+		Args:      command,
+		Notify:    scheduleNotify,
+		NotifyOn:  scheduleNotifyOn,
+		Disabled:  scheduleDisabled,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.Add(task); err != nil {
+		return out.WriteError("schedule.add", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+	task.NextRun, _ = schedule.NextRun(task, time.Now())
+
+	out.Log("Scheduled %s (%s): gdrv %s", task.Name, task.Cron, task.Command())
+	return out.WriteSuccess("schedule.add", task)
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	store, err := schedule.DefaultStore()
+	if err != nil {
+		return out.WriteError("schedule.list", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return out.WriteError("schedule.list", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		if !task.Disabled {
+			task.NextRun, _ = schedule.NextRun(task, now)
+		}
+	}
+	return out.WriteSuccess("schedule.list", &types.ScheduleList{Tasks: tasks})
+}
+
+func runScheduleRemove(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	store, err := schedule.DefaultStore()
+	if err != nil {
+		return out.WriteError("schedule.remove", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	if err := store.Remove(args[0]); err != nil {
+		return out.WriteError("schedule.remove", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	out.Log("Removed scheduled task: %s", args[0])
+	return out.WriteSuccess("schedule.remove", map[string]string{"name": args[0], "status": "removed"})
+}
+
+func runScheduleTrigger(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	runner, store, err := newScheduleRunner(out)
+	if err != nil {
+		return out.WriteError("schedule.trigger", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	task, err := store.Get(args[0])
+	if err != nil {
+		return out.WriteError("schedule.trigger", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
+	run := runner.RunTask(context.Background(), task)
+	if run.ExitCode != 0 || run.Error != "" {
+		msg := run.Error
+		if msg == "" {
+			msg = fmt.Sprintf("Task %s exited with code %d", task.Name, run.ExitCode)
+		}
+		return out.WriteError("schedule.trigger", utils.NewCLIError(utils.ErrCodeUnknown, msg).
+			WithContext("exitCode", run.ExitCode).
+			WithContext("output", run.Output).
+			Build())
+	}
+	return out.WriteSuccess("schedule.trigger", run)
+}
+
+func runScheduleRun(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	runner, store, err := newScheduleRunner(out)
+	if err != nil {
+		return out.WriteError("schedule.run", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	out.Log("Scheduler started (tasks: %s)", store.Path())
+	if err := runner.Run(ctx); err != nil && ctx.Err() == nil {
+		return out.WriteError("schedule.run", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	out.Log("Scheduler stopped")
+	return nil
+}
+
+func runScheduleUnit(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return out.WriteError("schedule.unit", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+
+	// Pin the config directory so the service sees the same tasks and
+	// credentials as the user who generated it.
+	env := map[string]string{}
+	if dir, err := config.GetConfigDir(); err == nil {
+		env[config.EnvPrefix+"CONFIG_DIR"] = dir
+	}
+
+	unit, err := schedule.GenerateUnit(scheduleUnitFormat, executable, env)
+	if err != nil {
+		return out.WriteError("schedule.unit", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+	// The unit is written verbatim so it can be redirected into place
+	fmt.Print(unit)
+	return nil
+}
+
+func newScheduleRunner(out *OutputWriter) (*schedule.Runner, *schedule.Store, error) {
+	store, err := schedule.DefaultStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule.NewRunner(store, executable, out.Log), store, nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// familyAnnotation on a flag names the command family the command needs
+// when the flag is set, for flags that make a read command write
+const familyAnnotation = "gdrv_command_family"
+
+// ensureCommandScopes checks that the profile has granted the scopes of the
+// command's family before it runs. An interactive OAuth user is offered to
+// extend consent on the spot; otherwise the command fails with the scopes
+// to add. Profiles without stored credentials, or whose scopes are not
+// recorded, are left to the command itself.
+func ensureCommandScopes(cmd *cobra.Command) error {
+	family := commandFamily(cmd)
+	if family == auth.FamilyNone {
+		return nil
+	}
+
+	configDir := getConfigDir()
+	mgr := auth.NewManager(configDir)
+	creds, err := mgr.LoadCredentials(globalFlags.Profile)
+	if err != nil || len(creds.Scopes) == 0 {
+		return nil
+	}
+	missing := auth.MissingFamilyScopes(creds.Scopes, family)
+	if len(missing) == 0 {
+		return nil
+	}
+	// A scope problem is not a usage error
+	cmd.SilenceUsage = true
+
+	if creds.Type != types.AuthTypeOAuth || globalFlags.Quiet || !stdinIsTerminal() {
+		return auth.MissingScopesError(globalFlags.Profile, family, missing)
+	}
+	fmt.Fprintf(os.Stderr, "'%s' needs %s access, which profile '%s' has not granted:\n",
+		cmd.CommandPath(), family, globalFlags.Profile)
+	for _, scope := range missing {
+		fmt.Fprintf(os.Stderr, "  %s\n", scope)
+	}
+	fmt.Fprint(os.Stderr, "Grant it now? [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return auth.MissingScopesError(globalFlags.Profile, family, missing)
+	}
+
+	id, secret, _, cliErr := resolveOAuthClient(cmd, configDir, false)
+	if cliErr != nil {
+		return utils.NewAppError(cliErr.Build())
+	}
+	mgr.SetOAuthConfig(id, secret, nil)
+	granted, err := mgr.ExtendConsent(context.Background(), globalFlags.Profile, creds, missing, openBrowser, auth.OAuthAuthOptions{})
+	if err != nil {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+	if missing := auth.MissingFamilyScopes(granted.Scopes, family); len(missing) > 0 {
+		return auth.MissingScopesError(globalFlags.Profile, family, missing)
+	}
+	return nil
+}
+
+// commandFamily returns the family a command needs, taking flags that make
+// a read command write into account
+func commandFamily(cmd *cobra.Command) auth.CommandFamily {
+	family := auth.FamilyForCommand(cmd.CommandPath())
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if names := f.Annotations[familyAnnotation]; len(names) > 0 {
+			family = auth.CommandFamily(names[0])
+		}
+	})
+	return family
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
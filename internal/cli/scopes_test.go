@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+// writeCommands are the commands that need write access without any flag
+// set. A new command that falls through to a write prefix fails the test
+// until it is listed here or given a family of its own.
+var writeCommands = map[string]bool{
+	"gdrv drives create":                  true,
+	"gdrv drives create-from-template":    true,
+	"gdrv drives delete":                  true,
+	"gdrv drives hide":                    true,
+	"gdrv drives members add":             true,
+	"gdrv drives members remove":          true,
+	"gdrv drives members update":          true,
+	"gdrv drives unhide":                  true,
+	"gdrv drives update":                  true,
+	"gdrv files archive-old":              true,
+	"gdrv files delete":                   true,
+	"gdrv files empty-trash":              true,
+	"gdrv files move":                     true,
+	"gdrv files properties delete":        true,
+	"gdrv files properties set":           true,
+	"gdrv files rename":                   true,
+	"gdrv files restore":                  true,
+	"gdrv files restore-all":              true,
+	"gdrv files revisions delete":         true,
+	"gdrv files revisions keep":           true,
+	"gdrv files revisions prune":          true,
+	"gdrv files revisions restore":        true,
+	"gdrv files star":                     true,
+	"gdrv files trash":                    true,
+	"gdrv files unstar":                   true,
+	"gdrv files update":                   true,
+	"gdrv folders delete":                 true,
+	"gdrv folders move":                   true,
+	"gdrv migrate to-shared-drive":        true,
+	"gdrv permissions apply":              true,
+	"gdrv permissions apply-template":     true,
+	"gdrv permissions bulk remove-public": true,
+	"gdrv permissions bulk share":         true,
+	"gdrv permissions bulk update-role":   true,
+	"gdrv permissions create":             true,
+	"gdrv permissions create-link":        true,
+	"gdrv permissions diff":               true,
+	"gdrv permissions edit":               true,
+	"gdrv permissions remove":             true,
+	"gdrv permissions transfer-ownership": true,
+	"gdrv permissions update":             true,
+	"gdrv sync":                           true,
+	"gdrv sync push":                      true,
+}
+
+func TestCommandFamily_EveryCommand(t *testing.T) {
+	seen := map[string]bool{}
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if cmd.Runnable() {
+			path := cmd.CommandPath()
+			seen[path] = true
+			family := commandFamily(cmd)
+			if family == auth.FamilyWrite && !writeCommands[path] {
+				t.Errorf("%s resolves to write access; give it its own family or list it in writeCommands", path)
+			}
+			if writeCommands[path] && family != auth.FamilyWrite {
+				t.Errorf("%s is listed in writeCommands but resolves to %q", path, family)
+			}
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+	for path := range writeCommands {
+		if !seen[path] {
+			t.Errorf("%s is listed in writeCommands but is not a command", path)
+		}
+	}
+
+	if got := commandFamily(permPolicyCheckCmd); got != auth.FamilyRead {
+		t.Errorf("permissions policy-check resolves to %q, want read", got)
+	}
+}
+
+func TestCommandFamily_WriteFlags(t *testing.T) {
+	tests := []struct {
+		cmd   *cobra.Command
+		flag  string
+		value string
+	}{
+		{permExpiringCmd, "renew", "90d"},
+		{filesFindCorruptCmd, "reupload-from", "backup"},
+		{filesSharedWithMeCmd, "remove", "true"},
+	}
+	for _, tt := range tests {
+		if got := commandFamily(tt.cmd); got != auth.FamilyRead {
+			t.Errorf("%s without --%s resolves to %q, want read", tt.cmd.CommandPath(), tt.flag, got)
+		}
+		if got := commandFamily(withFlagSet(t, tt.cmd, tt.flag, tt.value)); got != auth.FamilyWrite {
+			t.Errorf("%s --%s resolves to %q, want write", tt.cmd.CommandPath(), tt.flag, got)
+		}
+	}
+}
+
+// withFlagSet returns a command at cmd's path with one of its flags set,
+// leaving cmd itself unparsed
+func withFlagSet(t *testing.T, cmd *cobra.Command, name, value string) *cobra.Command {
+	t.Helper()
+	words := strings.Fields(cmd.CommandPath())
+	leaf := &cobra.Command{Use: words[0]}
+	for _, word := range words[1:] {
+		child := &cobra.Command{Use: word}
+		leaf.AddCommand(child)
+		leaf = child
+	}
+	flag := *cmd.Flags().Lookup(name)
+	t.Cleanup(func() { _ = flag.Value.Set(flag.DefValue) })
+	leaf.Flags().AddFlag(&flag)
+	if err := leaf.Flags().Set(name, value); err != nil {
+		t.Fatal(err)
+	}
+	return leaf
+}
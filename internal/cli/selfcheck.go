@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// longRunningCommands check the profile before starting, so a revoked
+// token or lost access fails in seconds instead of hours into the job
+var longRunningCommands = map[string]bool{
+	"sync push":                      true,
+	"sync pull":                      true,
+	"migrate to-shared-drive":        true,
+	"files download-query":           true,
+	"files find-corrupt":             true,
+	"export office":                  true,
+	"drives export-acls":             true,
+	"permissions apply":              true,
+	"permissions apply-template":     true,
+	"permissions bulk remove-public": true,
+	"permissions bulk share":         true,
+	"permissions bulk update-role":   true,
+	"permissions watch":              true,
+}
+
+var authCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that the profile can run a long operation",
+	Long: `Renew the profile's token, verify the scopes it carries and fetch a known
+file, failing with guidance if any step does not work. The same check runs
+automatically before long operations such as 'sync push' and
+'permissions bulk share'; --skip-self-check turns that off.`,
+	Example: "  gdrv auth check --for write\n  gdrv auth check --file-id <folder-id>",
+	RunE:    runAuthCheck,
+}
+
+var (
+	authCheckFor    string
+	authCheckFileID string
+)
+
+func init() {
+	authCheckCmd.Flags().StringVar(&authCheckFor, "for", string(auth.FamilyRead), "Command family whose scopes to verify: "+familyNames())
+	authCheckCmd.Flags().StringVar(&authCheckFileID, "file-id", "", "File or folder to fetch as the access check (default: the --drive-id drive, or My Drive)")
+	authCmd.AddCommand(authCheckCmd)
+}
+
+func runAuthCheck(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	family, err := auth.ParseCommandFamily(authCheckFor)
+	if err != nil {
+		return out.WriteError("auth.check", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+	steps, err := selfCheck(context.Background(), flags, family, authCheckFileID)
+	if err != nil {
+		return out.WriteError("auth.check", selfCheckError(err, steps, "").CLIError)
+	}
+	for _, s := range steps {
+		if s.Status == auth.CheckWarning {
+			out.AddWarning("SELF_CHECK_WARNING", s.Detail, "warning")
+		}
+	}
+	return out.WriteSuccess("auth.check", map[string]interface{}{
+		"profile": flags.Profile,
+		"family":  family,
+		"checks":  steps,
+	})
+}
+
+// selfCheckBeforeLongRun runs the self-check for long-running commands
+// unless --skip-self-check is given
+func selfCheckBeforeLongRun(cmd *cobra.Command) error {
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if globalFlags.SkipSelfCheck || !longRunningCommands[name] {
+		return nil
+	}
+	steps, err := selfCheck(context.Background(), globalFlags, commandFamily(cmd), "")
+	if err != nil {
+		cmd.SilenceUsage = true
+		appErr := selfCheckError(err, steps, name)
+		recordRunError(appErr.CLIError)
+		return appErr
+	}
+	for _, s := range steps {
+		if s.Status == auth.CheckWarning && !globalFlags.Quiet {
+			fmt.Fprintf(os.Stderr, "Warning: self-check: %s\n", s.Detail)
+		}
+	}
+	return nil
+}
+
+// selfCheck renews the profile's token and verifies its scopes (see
+// auth.Manager.SelfCheck), then fetches fileID, or the --drive-id drive's
+// root or My Drive, to show the token is accepted for Drive access
+func selfCheck(ctx context.Context, flags types.GlobalFlags, family auth.CommandFamily, fileID string) ([]auth.SelfCheckStep, error) {
+	authMgr := auth.NewManager(getConfigDir())
+	creds, steps, err := authMgr.SelfCheck(ctx, flags.Profile, family)
+	if err != nil {
+		return steps, err
+	}
+
+	step := auth.SelfCheckStep{Name: "access", Status: auth.CheckFailed}
+	service, err := authMgr.GetDriveService(ctx, creds)
+	if err != nil {
+		step.Detail = err.Error()
+		return append(steps, step), utils.NewAppError(utils.NewCLIError(utils.ErrCodeAuthRequired,
+			"Failed to create Drive service: "+err.Error()).Build())
+	}
+	if fileID == "" {
+		fileID = "root"
+		if flags.DriveID != "" {
+			fileID = flags.DriveID
+		}
+	}
+	client := api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, GetLogger())
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeGetByID)
+	file, err := files.NewManager(client).Get(ctx, reqCtx, fileID, "id,name")
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			step.Detail = appErr.CLIError.Message
+		} else {
+			step.Detail = err.Error()
+		}
+		return append(steps, step), err
+	}
+	step.Status = auth.CheckOK
+	step.Detail = fmt.Sprintf("fetched %s (%s)", file.Name, file.ID)
+	return append(steps, step), nil
+}
+
+// selfCheckError adds the steps taken to a self-check failure and, for
+// failures before a command, says which command was stopped. The suggested
+// action goes into the message since pre-run errors are printed as text.
+func selfCheckError(err error, steps []auth.SelfCheckStep, command string) *utils.AppError {
+	cliErr := utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build()
+	if appErr, ok := err.(*utils.AppError); ok {
+		cliErr = appErr.CLIError
+	}
+	errContext := make(map[string]interface{}, len(cliErr.Context)+2)
+	for k, v := range cliErr.Context {
+		errContext[k] = v
+	}
+	errContext["checks"] = steps
+	cliErr.Context = errContext
+
+	if command != "" {
+		failed := "self-check"
+		if len(steps) > 0 {
+			failed = "self-check (" + steps[len(steps)-1].Name + ")"
+		}
+		cliErr.Message = fmt.Sprintf("'%s' not started: %s failed: %s", command, failed, cliErr.Message)
+		if action, ok := errContext["suggestedAction"].(string); ok && action != "" {
+			cliErr.Message += "; " + action
+		}
+		errContext["skipWith"] = "--skip-self-check"
+	}
+	return utils.NewAppError(cliErr)
+}
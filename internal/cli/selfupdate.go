@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/selfupdate"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/dl-alexandre/gdrv/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update gdrv to the latest release",
+	Long: `Download the latest gdrv release from GitHub and replace this binary.
+
+The download is checked against the release's checksums.txt, and against
+its signature when this build carries a signing key, and is run once to
+confirm it starts before it is installed. The previous binary is kept next
+to it with a .old suffix; --rollback swaps it back.
+
+--channel beta includes pre-releases. --check only reports whether an
+update is available, as does --dry-run. Set GITHUB_TOKEN when many machines
+update from one address, to stay within the GitHub API rate limit. Binaries
+installed by Homebrew or a system package are left to that package manager
+unless --force is given.`,
+	Example: "  gdrv self-update --check\n" +
+		"  gdrv self-update --yes\n" +
+		"  gdrv self-update --channel beta\n" +
+		"  gdrv self-update --version v1.4.0\n" +
+		"  gdrv self-update --rollback",
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+var (
+	selfUpdateChannel  string
+	selfUpdateCheck    bool
+	selfUpdateVersion  string
+	selfUpdateRollback bool
+)
+
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateChannel, "channel", selfupdate.ChannelStable, "Release channel: stable or beta")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "Only report whether an update is available")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "Install this release tag instead of the latest, e.g. v1.4.0 (may downgrade)")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateRollback, "rollback", false, "Restore the binary replaced by the last update")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+// SelfUpdateResult reports a self-update check or install
+type SelfUpdateResult struct {
+	CurrentVersion    string `json:"currentVersion"`
+	LatestVersion     string `json:"latestVersion,omitempty"`
+	Channel           string `json:"channel,omitempty"`
+	UpdateAvailable   bool   `json:"updateAvailable"`
+	Updated           bool   `json:"updated"`
+	RolledBack        bool   `json:"rolledBack,omitempty"`
+	Executable        string `json:"executable"`
+	Backup            string `json:"backup,omitempty"`
+	SHA256            string `json:"sha256,omitempty"`
+	SignatureVerified bool   `json:"signatureVerified"`
+	ReleaseURL        string `json:"releaseUrl,omitempty"`
+}
+
+func (r *SelfUpdateResult) Headers() []string {
+	return []string{"Current", "Latest", "Channel", "Status"}
+}
+
+func (r *SelfUpdateResult) Rows() [][]string {
+	status := "up to date"
+	switch {
+	case r.RolledBack:
+		status = "rolled back to the previous binary"
+	case r.Updated:
+		status = "updated"
+	case r.UpdateAvailable:
+		status = "update available"
+	}
+	return [][]string{{r.CurrentVersion, r.LatestVersion, r.Channel, status}}
+}
+
+func (r *SelfUpdateResult) EmptyMessage() string {
+	return ""
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	ctx := context.Background()
+
+	exe, err := selfupdate.Executable()
+	if err != nil {
+		return out.WriteError("self-update", utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Cannot locate the gdrv binary: %v", err)).Build())
+	}
+	result := &SelfUpdateResult{CurrentVersion: version.Version, Executable: exe}
+
+	if selfUpdateRollback {
+		if flags.DryRun {
+			out.Log("Dry run: %s would be swapped with %s%s", exe, exe, selfupdate.BackupSuffix)
+			return out.WriteSuccess("self-update", result)
+		}
+		if err := selfupdate.Rollback(exe); err != nil {
+			return handleError(out, "self-update", err)
+		}
+		result.RolledBack = true
+		result.Backup = exe + selfupdate.BackupSuffix
+		out.Log("Restored the previous binary; run 'gdrv self-update --rollback' again to undo")
+		return out.WriteSuccess("self-update", result)
+	}
+
+	updater, err := selfupdate.New()
+	if err != nil {
+		return handleError(out, "self-update", err)
+	}
+	var rel *selfupdate.Release
+	if selfUpdateVersion != "" {
+		rel, err = updater.Release(ctx, selfUpdateVersion)
+	} else {
+		result.Channel = selfUpdateChannel
+		rel, err = updater.Latest(ctx, selfUpdateChannel)
+	}
+	if err != nil {
+		return handleError(out, "self-update", err)
+	}
+	result.LatestVersion = rel.Tag
+	result.ReleaseURL = rel.URL
+	if selfUpdateVersion != "" {
+		result.UpdateAvailable = rel.Tag != version.Version
+	} else {
+		result.UpdateAvailable = selfupdate.CompareVersions(rel.Tag, version.Version) > 0
+	}
+	if !selfupdate.IsVersion(version.Version) {
+		out.AddWarning("DEVELOPMENT_BUILD", fmt.Sprintf("This is a development build (%s); updating replaces it with release %s", version.Version, rel.Tag), "low")
+	}
+
+	if !result.UpdateAvailable || selfUpdateCheck || flags.DryRun {
+		if result.UpdateAvailable {
+			out.Log("gdrv %s is available (installed: %s)", rel.Tag, version.Version)
+		} else {
+			out.Log("gdrv %s is up to date", version.Version)
+		}
+		return out.WriteSuccess("self-update", result)
+	}
+
+	if manager := selfupdate.ManagedBy(exe); manager != "" && !flags.Force {
+		return out.WriteError("self-update", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s was installed by %s; update it there, or use --force to replace it anyway", exe, manager)).Build())
+	}
+
+	safetyOpts := safety.Default()
+	safetyOpts.Force = flags.Force
+	confirmed, err := safety.Confirm(fmt.Sprintf("Replace gdrv %s at %s with %s?", version.Version, exe, rel.Tag), safetyOpts.ForScope(safety.ScopeAll))
+	if err != nil {
+		return handleError(out, "self-update", err)
+	}
+	if !confirmed {
+		return out.WriteError("self-update", utils.NewCLIError(utils.ErrCodeCancelled, "Operation cancelled by user").Build())
+	}
+
+	out.Log("Downloading gdrv %s...", rel.Tag)
+	downloaded, sum, err := updater.Download(ctx, rel, filepath.Dir(exe))
+	if err != nil {
+		return handleError(out, "self-update", err)
+	}
+	result.SHA256 = sum
+	result.SignatureVerified = updater.PublicKey != nil
+	if err := selfupdate.CheckBinary(ctx, downloaded, rel.Tag); err != nil {
+		os.Remove(downloaded)
+		return out.WriteError("self-update", utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Not installing %s: %v", rel.Tag, err)).Build())
+	}
+	backup, err := selfupdate.Install(exe, downloaded)
+	if err != nil {
+		os.Remove(downloaded)
+		return out.WriteError("self-update", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+	}
+	result.Updated = true
+	result.Backup = backup
+	out.Log("Updated gdrv %s -> %s; 'gdrv self-update --rollback' restores %s", version.Version, rel.Tag, version.Version)
+	return out.WriteSuccess("self-update", result)
+}
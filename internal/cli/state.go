@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Local state files",
+	Long: `Inspect the files gdrv keeps between runs: learned field masks,
+scheduled tasks, the sync index, download checkpoints and permission
+snapshots.
+
+Each file records the schema version it was written with. Older files are
+migrated automatically when read; files written by a newer gdrv are left
+untouched and refused until gdrv is upgraded.`,
+}
+
+var stateVerifyCmd = &cobra.Command{
+	Use:   "verify [path...]",
+	Short: "Check the schema version of local state files",
+	Long: `Check that state files can be read by this release.
+
+Without arguments, every state file in the config directory is checked.
+Paths name other state files, such as a download's .gdrv-changes.json
+checkpoint or a permissions watch snapshot; the kind is taken from the
+file name unless --kind is given.
+
+--migrate rewrites older JSON files in the current format, keeping the
+original next to it with a .bak suffix. The sync index is migrated the
+next time a sync command opens it.`,
+	Example: "  gdrv state verify\n" +
+		"  gdrv state verify --migrate\n" +
+		"  gdrv state verify --kind permission-snapshot ./baseline.json",
+	RunE: runStateVerify,
+}
+
+var (
+	stateVerifyKind    string
+	stateVerifyMigrate bool
+)
+
+func init() {
+	stateVerifyCmd.Flags().StringVar(&stateVerifyKind, "kind", "", "Kind of the given files: "+strings.Join(stateKindNames(), ", "))
+	stateVerifyCmd.Flags().BoolVar(&stateVerifyMigrate, "migrate", false, "Rewrite older files in the current format, keeping a .bak copy")
+	stateCmd.AddCommand(stateVerifyCmd)
+	rootCmd.AddCommand(stateCmd)
+}
+
+func stateKindNames() []string {
+	var names []string
+	for _, k := range state.Kinds() {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+func runStateVerify(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	rewrite := stateVerifyMigrate && !flags.DryRun
+
+	var kind *state.Kind
+	if stateVerifyKind != "" {
+		k, ok := state.Lookup(stateVerifyKind)
+		if !ok {
+			return out.WriteError("state.verify", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("unknown state kind %q (valid: %s)", stateVerifyKind, strings.Join(stateKindNames(), ", "))).Build())
+		}
+		kind = k
+	}
+
+	result := &StateVerifyResult{}
+	if len(args) == 0 {
+		for _, k := range state.Kinds() {
+			if k.DefaultPath == nil || (kind != nil && k != kind) {
+				continue
+			}
+			path, err := k.DefaultPath()
+			if err != nil {
+				return out.WriteError("state.verify", utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+			}
+			result.Reports = append(result.Reports, state.Verify(k, path, rewrite))
+		}
+	}
+	for _, path := range args {
+		k := kind
+		if k == nil {
+			k = stateKindForPath(path)
+		}
+		if k == nil {
+			return out.WriteError("state.verify", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("cannot tell the kind of %s; pass --kind", path)).Build())
+		}
+		result.Reports = append(result.Reports, state.Verify(k, path, rewrite))
+	}
+
+	for _, r := range result.Reports {
+		switch r.Status {
+		case state.StatusTooNew:
+			out.AddWarning(utils.ErrCodeStateVersion,
+				fmt.Sprintf("%s is version %d, newer than this gdrv supports (%d); upgrade gdrv", r.Path, r.Version, r.Current), "high")
+		case state.StatusCorrupt:
+			out.AddWarning("STATE_CORRUPT", fmt.Sprintf("%s cannot be read: %s", r.Path, r.Error), "high")
+		case state.StatusMigrate:
+			if r.Error != "" {
+				out.AddWarning("STATE_MIGRATION_FAILED", fmt.Sprintf("%s was not migrated: %s", r.Path, r.Error), "high")
+			}
+		case state.StatusMigrated:
+			out.Log("Migrated %s to version %d (original kept as %s.bak)", r.Path, r.Current, r.Path)
+		}
+	}
+	return out.WriteSuccess("state.verify", result)
+}
+
+// stateKindForPath matches a path to a kind by file name
+func stateKindForPath(path string) *state.Kind {
+	base := filepath.Base(path)
+	for _, k := range state.Kinds() {
+		if k.FileName != "" && k.FileName == base {
+			return k
+		}
+	}
+	return nil
+}
+
+type StateVerifyResult struct {
+	Reports []*state.Report `json:"reports"`
+}
+
+func (r *StateVerifyResult) Headers() []string {
+	return []string{"Kind", "Status", "Version", "Current", "Path"}
+}
+
+func (r *StateVerifyResult) Rows() [][]string {
+	rows := make([][]string, len(r.Reports))
+	for i, rep := range r.Reports {
+		version := "-"
+		if rep.Version > 0 {
+			version = fmt.Sprintf("%d", rep.Version)
+		}
+		rows[i] = []string{rep.Kind, rep.Status, version, fmt.Sprintf("%d", rep.Current), rep.Path}
+	}
+	return rows
+}
+
+func (r *StateVerifyResult) EmptyMessage() string {
+	return "No state files to check"
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// RunSummary describes one invocation, for --summary and --summary-file
+type RunSummary struct {
+	Command    string                `json:"command"`
+	StartedAt  time.Time             `json:"startedAt"`
+	ElapsedMs  int64                 `json:"elapsedMs"`
+	Status     string                `json:"status"` // succeeded or failed
+	ErrorCode  string                `json:"errorCode,omitempty"`
+	Operations api.OperationStats    `json:"operations"`
+	APICalls   int64                 `json:"apiCalls"`
+	BytesIn    int64                 `json:"bytesReceived"`
+	BytesOut   int64                 `json:"bytesSent"`
+	Uploads    *files.ResumableStats `json:"resumableUploads,omitempty"` // Set when an upload chunk was retried
+}
+
+// runError is the error reported by the command's output, if any; commands
+// report most errors in the output envelope rather than returning them
+var runError *types.CLIError
+
+// recordRunError remembers the error a command reported for the summary
+func recordRunError(cliErr types.CLIError) {
+	runError = &cliErr
+}
+
+// newRunSummary builds the summary of a run from the operation and
+// transport counters
+func newRunSummary(cmd *cobra.Command, started time.Time, err error) *RunSummary {
+	transport := api.GetTransportStats()
+	summary := &RunSummary{
+		StartedAt:  started.UTC(),
+		ElapsedMs:  time.Since(started).Milliseconds(),
+		Status:     "succeeded",
+		Operations: api.GetOperationStats(),
+		APICalls:   transport.Requests,
+		BytesIn:    transport.WireBytes,
+		BytesOut:   transport.SentBytes,
+	}
+	if uploads := files.GetResumableStats(); uploads.ChunkRetries > 0 {
+		summary.Uploads = &uploads
+	}
+	if cmd != nil {
+		summary.Command = strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+	}
+	switch {
+	case runError != nil:
+		summary.Status, summary.ErrorCode = "failed", runError.Code
+	case err != nil:
+		summary.Status = "failed"
+	}
+	return summary
+}
+
+// String renders the summary on one line
+func (s *RunSummary) String() string {
+	elapsed := time.Duration(s.ElapsedMs) * time.Millisecond
+	status := s.Status
+	if s.ErrorCode != "" {
+		status += " (" + s.ErrorCode + ")"
+	}
+	line := fmt.Sprintf("%s %s in %s: %d operations (%d succeeded, %d failed, %d retries), %d API calls, %s received, %s sent",
+		s.Command, status, elapsed.Round(time.Millisecond),
+		s.Operations.Attempted, s.Operations.Succeeded, s.Operations.Failed, s.Operations.Retries,
+		s.APICalls, formatSize(s.BytesIn), formatSize(s.BytesOut))
+	if s.Uploads != nil {
+		line += "; " + resumableStatsString(*s.Uploads)
+	}
+	return line
+}
+
+// resumableStatsString describes how uploads recovered from failed chunks
+func resumableStatsString(u files.ResumableStats) string {
+	return fmt.Sprintf("%d upload chunks retried after %d offset queries, %s re-sent, %s already committed",
+		u.ChunkRetries, u.OffsetQueries, formatSize(u.BytesResent), formatSize(u.BytesSkipped))
+}
+
+// writeRunSummary prints the summary with --summary and appends it to
+// --summary-file. Printing ignores --quiet, since asking for it is explicit.
+func writeRunSummary(summary *RunSummary) error {
+	if globalFlags.Summary {
+		fmt.Fprintf(os.Stderr, "Summary: %s\n", summary)
+	}
+	if globalFlags.SummaryFile == "" {
+		return nil
+	}
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(globalFlags.SummaryFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write run summary: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write run summary: %w", err)
+	}
+	return f.Close()
+}
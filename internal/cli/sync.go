@@ -9,6 +9,7 @@ import (
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/auth"
 	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/safety"
 	"github.com/dl-alexandre/gdrv/internal/sync/conflict"
 	syncengine "github.com/dl-alexandre/gdrv/internal/sync"
 	"github.com/dl-alexandre/gdrv/internal/sync/diff"
@@ -79,12 +80,12 @@ var (
 
 func init() {
 	syncInitCmd.Flags().StringVar(&syncExclude, "exclude", "", "Comma-separated exclude patterns")
-	syncInitCmd.Flags().StringVar(&syncConflict, "conflict", "rename-both", "Conflict policy (local-wins, remote-wins, rename-both)")
+	syncInitCmd.Flags().StringVar(&syncConflict, "conflict", "rename-both", "Conflict policy (local-wins, remote-wins, rename-both, skip)")
 	syncInitCmd.Flags().StringVar(&syncDirection, "direction", "bidirectional", "Sync direction (push, pull, bidirectional)")
 	syncInitCmd.Flags().StringVar(&syncConfigID, "id", "", "Optional sync configuration ID")
 
 	syncCmd.Flags().BoolVar(&syncDelete, "delete", false, "Propagate deletions")
-	syncCmd.Flags().StringVar(&syncConflict, "conflict", "", "Override conflict policy")
+	syncCmd.Flags().StringVar(&syncConflict, "conflict", "", "Override conflict policy (local-wins, remote-wins, rename-both, skip)")
 	syncCmd.Flags().IntVar(&syncConcurrency, "concurrency", 5, "Concurrent transfers")
 	syncCmd.Flags().BoolVar(&syncUseChanges, "use-changes", true, "Use Drive Changes API when available")
 
@@ -119,6 +120,10 @@ func runSyncInit(cmd *cobra.Command, args []string) error {
 	localPath := args[0]
 	remotePath := args[1]
 
+	if _, err := conflict.ParsePolicy(syncConflict); err != nil {
+		return out.WriteError("sync.init", utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+	}
+
 	stat, err := os.Stat(localPath)
 	if err != nil || !stat.IsDir() {
 		return out.WriteError("sync.init", utils.NewCLIError(utils.ErrCodeInvalidArgument, "Local path must be a directory").Build())
@@ -134,7 +139,7 @@ func runSyncInit(cmd *cobra.Command, args []string) error {
 		return out.WriteError("sync.init", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
 	}
 
-	remoteID, err := ResolveFileID(ctx, client, flags, remotePath)
+	remoteID, remoteDriveID, err := ResolveLocation(ctx, client, flags, remotePath)
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			return out.WriteError("sync.init", appErr.CLIError)
@@ -171,6 +176,7 @@ func runSyncInit(cmd *cobra.Command, args []string) error {
 		ID:              configID,
 		LocalRoot:       absLocal,
 		RemoteRootID:    remoteID,
+		RemoteDriveID:   remoteDriveID,
 		ExcludePatterns: excludes,
 		ConflictPolicy:  syncConflict,
 		Direction:       syncDirection,
@@ -227,7 +233,11 @@ func runSyncWithMode(configID string, mode diff.Mode, command string, planOnly b
 		UseChanges:  syncUseChanges,
 	}
 	if syncConflict != "" {
-		opts.ConflictPolicy = conflict.Policy(syncConflict)
+		policy, err := conflict.ParsePolicy(syncConflict)
+		if err != nil {
+			return out.WriteError(command, utils.NewCLIError(utils.ErrCodeInvalidArgument, err.Error()).Build())
+		}
+		opts.ConflictPolicy = policy
 	}
 
 	plan, err := engine.Plan(ctx, cfg, opts, reqCtx)
@@ -236,27 +246,48 @@ func runSyncWithMode(configID string, mode diff.Mode, command string, planOnly b
 	}
 
 	if planOnly {
-		return out.WriteSuccess(command, map[string]interface{}{
-			"configId":  cfg.ID,
-			"actions":   plan.Actions,
-			"conflicts": plan.Conflicts,
-		})
+		report := syncengine.NewReport(cfg.ID, mode, plan, nil)
+		report.DryRun = true
+		return out.WriteSuccess(command, report)
 	}
 
+	// The skip policy leaves conflicts in the plan so the rest of the
+	// tree can converge; any other policy resolves them all
 	if len(plan.Conflicts) > 0 {
-		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeUnknown, "Conflicts detected").Build())
+		paths := make([]string, len(plan.Conflicts))
+		for i, c := range plan.Conflicts {
+			paths[i] = c.Path
+		}
+		policy := opts.ConflictPolicy
+		if policy == "" {
+			policy = conflict.Policy(strings.ToLower(cfg.ConflictPolicy))
+		}
+		if policy != conflict.PolicySkip {
+			return out.WriteError(command, utils.NewCLIError(utils.ErrCodeUnknown, "Conflicts detected").
+				WithContext("conflicts", paths).Build())
+		}
+		for _, p := range paths {
+			out.AddWarning("SYNC_CONFLICT", "Skipped conflicting path "+p, "medium")
+		}
+	}
+
+	if flags.DryRun {
+		for _, action := range plan.Actions {
+			planSyncAction(action)
+		}
 	}
 
 	applyCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeMutation)
 	result, err := engine.Apply(ctx, cfg, plan, opts, applyCtx)
 	if err != nil {
-		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).Build())
+		return out.WriteError(command, utils.NewCLIError(utils.ErrCodeUnknown, err.Error()).
+			WithContext("summary", result.Summary).
+			WithContext("actions", result.Actions).Build())
 	}
 
-	return out.WriteSuccess(command, map[string]interface{}{
-		"configId": cfg.ID,
-		"summary":  result.Summary,
-	})
+	report := syncengine.NewReport(cfg.ID, mode, plan, &result)
+	report.DryRun = opts.DryRun
+	return out.WriteSuccess(command, report)
 }
 
 func runSyncList(cmd *cobra.Command, args []string) error {
@@ -341,6 +372,31 @@ func loadSyncEngine(ctx context.Context, flags types.GlobalFlags, configID strin
 		return nil, nil, index.SyncConfig{}, err
 	}
 
+	// The remote root may be in a different Shared Drive than --drive-id,
+	// for example when it was given as sd:<drive-id>:<path>
+	if cfg.RemoteDriveID != "" {
+		reqCtx.DriveID = cfg.RemoteDriveID
+	}
+
 	engine := syncengine.NewEngine(client, db)
+	engine.SetExportDefaults(configExportDefaults())
 	return engine, reqCtx, *cfg, nil
 }
+
+// planSyncAction adds a sync action to the dry-run plan, keeping the sync
+// action name (upload, delete_local, mkdir_remote, ...) as its type
+func planSyncAction(action diff.Action) {
+	op := safety.PlannedOperation{
+		Type:         safety.OperationType(action.Type),
+		ResourceName: action.Path,
+		Description:  string(action.Type) + ": " + action.Path,
+		Predicted:    strings.ReplaceAll(string(action.Type), "_", " "),
+	}
+	if action.Remote != nil {
+		op.ResourceID = action.Remote.ID
+	}
+	if action.FromPath != "" || action.ToPath != "" {
+		op.Parameters = map[string]interface{}{"from": action.FromPath, "to": action.ToPath}
+	}
+	planOperation(op)
+}
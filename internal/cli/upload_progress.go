@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// uploadProgress renders the progress of one upload on a single stderr
+// line, with the transfer rate and the time remaining
+type uploadProgress struct {
+	w     io.Writer
+	now   func() time.Time
+	start time.Time
+	// base is the offset the upload started from, so a resumed upload's
+	// rate counts only the bytes sent by this run
+	base     int64
+	last     time.Time
+	finished bool
+}
+
+// progressInterval limits how often the progress line is redrawn
+const progressInterval = 250 * time.Millisecond
+
+// newUploadProgress returns a progress callback drawing to stderr, or nil
+// when output is quiet or stderr is not a terminal
+func newUploadProgress(quiet bool) files.ProgressFunc {
+	if quiet || !stderrIsTerminal() {
+		return nil
+	}
+	p := &uploadProgress{w: os.Stderr, now: time.Now, base: -1}
+	return p.update
+}
+
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *uploadProgress) update(sent, total int64) {
+	now := p.now()
+	if p.base < 0 {
+		p.base, p.start = sent, now
+	}
+	done := sent >= total
+	if p.finished || (!done && now.Sub(p.last) < progressInterval) {
+		return
+	}
+	p.last = now
+	fmt.Fprintf(p.w, "\r\033[K%s", p.line(sent, total, now.Sub(p.start)))
+	if done {
+		p.finished = true
+		fmt.Fprintln(p.w)
+	}
+}
+
+// line describes sent of total bytes after elapsed time in this run
+func (p *uploadProgress) line(sent, total int64, elapsed time.Duration) string {
+	percent := 100.0
+	if total > 0 {
+		percent = float64(sent) * 100 / float64(total)
+	}
+	line := fmt.Sprintf("%s / %s (%.0f%%)", utils.FormatSize(sent), utils.FormatSize(total), percent)
+
+	moved := sent - p.base
+	if elapsed <= 0 || moved <= 0 {
+		return line
+	}
+	rate := float64(moved) / elapsed.Seconds()
+	line += fmt.Sprintf("  %s/s", utils.FormatSize(int64(rate)))
+	if sent < total {
+		eta := time.Duration(float64(total-sent) / rate * float64(time.Second))
+		line += "  ETA " + eta.Round(time.Second).String()
+	}
+	return line
+}
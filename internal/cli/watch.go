@@ -0,0 +1,397 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/dl-alexandre/gdrv/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Manage push notification channels",
+	Long: `Open, list, renew and stop Drive push notification channels.
+
+A channel makes Drive send a POST request to an HTTPS address when a file
+changes (--file-id) or when the change feed has new changes (--changes).
+Channels are recorded in the config directory with their expiration. Drive
+closes a channel when it expires, so keep them open with 'gdrv watch renew',
+for example from 'gdrv schedule'.`,
+}
+
+var watchCreateCmd = &cobra.Command{
+	Use:   "create (--file-id <id> | --changes) --address <https-url>",
+	Short: "Open a notification channel",
+	Example: "  gdrv watch create --file-id <file-id> --address https://example.com/drive-hook --ttl 24h\n" +
+		"  gdrv watch create --changes --drive-id <drive-id> --address https://example.com/drive-hook --token s3cret --ttl 168h",
+	Args: cobra.NoArgs,
+	RunE: runWatchCreate,
+}
+
+var watchListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded notification channels",
+	Args:  cobra.NoArgs,
+	RunE:  runWatchList,
+}
+
+var watchStopCmd = &cobra.Command{
+	Use:   "stop [channel-id...]",
+	Short: "Stop notification channels",
+	Long: `Stop recorded notification channels and forget them.
+
+--expired forgets every expired channel. A channel opened elsewhere can be
+stopped with its channel ID and --resource-id.`,
+	Example: "  gdrv watch stop gdrv-3f2c...\n" +
+		"  gdrv watch stop --expired",
+	RunE: runWatchStop,
+}
+
+var watchRenewCmd = &cobra.Command{
+	Use:   "renew [channel-id...]",
+	Short: "Renew notification channels before they expire",
+	Long: `Replace channels expiring within --within with new ones watching the same
+file or feed, with the same address, token and lifetime, then stop the old
+ones. Expired channels are reopened. Without channel IDs, every recorded
+channel of the profile is checked.
+
+Run it more often than --within, for example hourly with --within 2h.`,
+	Example: "  gdrv watch renew --within 2h\n" +
+		"  gdrv schedule add renew-watches --cron @hourly -- watch renew --within 2h",
+	RunE: runWatchRenew,
+}
+
+var (
+	watchCreateFileID  string
+	watchCreateChanges bool
+	watchCreateAddress string
+	watchCreateToken   string
+	watchCreateTTL     time.Duration
+	watchStopResource  string
+	watchStopExpired   bool
+	watchRenewWithin   time.Duration
+)
+
+func init() {
+	watchCreateCmd.Flags().StringVar(&watchCreateFileID, "file-id", "", "File to watch (ID or path)")
+	watchCreateCmd.Flags().BoolVar(&watchCreateChanges, "changes", false, "Watch the change feed (of --drive-id, or the user's)")
+	watchCreateCmd.Flags().StringVar(&watchCreateAddress, "address", "", "HTTPS URL receiving notifications (required)")
+	watchCreateCmd.Flags().StringVar(&watchCreateToken, "token", "", "Token sent with every notification, to verify its origin")
+	watchCreateCmd.Flags().DurationVar(&watchCreateTTL, "ttl", 0, "Channel lifetime, up to 24h for files and 168h for changes (default: Drive's, 1h)")
+	_ = watchCreateCmd.MarkFlagRequired("address")
+	watchCreateCmd.MarkFlagsMutuallyExclusive("file-id", "changes")
+	watchCreateCmd.MarkFlagsOneRequired("file-id", "changes")
+
+	watchStopCmd.Flags().StringVar(&watchStopResource, "resource-id", "", "Resource ID of a channel not recorded by gdrv")
+	watchStopCmd.Flags().BoolVar(&watchStopExpired, "expired", false, "Forget every expired channel")
+
+	watchRenewCmd.Flags().DurationVar(&watchRenewWithin, "within", time.Hour, "Renew channels expiring within this time")
+
+	watchCmd.AddCommand(watchCreateCmd)
+	watchCmd.AddCommand(watchListCmd)
+	watchCmd.AddCommand(watchStopCmd)
+	watchCmd.AddCommand(watchRenewCmd)
+	rootCmd.AddCommand(watchCmd)
+}
+
+// WatchRenewal is the outcome of renewing one channel
+type WatchRenewal struct {
+	OldID      string    `json:"oldId"`
+	NewID      string    `json:"newId,omitempty"`
+	Kind       string    `json:"kind"`
+	FileID     string    `json:"fileId,omitempty"`
+	Expiration time.Time `json:"expiration,omitempty"`
+	Status     string    `json:"status"` // renewed, planned or failed
+	Error      string    `json:"error,omitempty"`
+}
+
+// WatchRenewResult lists the channels renewed by one run
+type WatchRenewResult struct {
+	Renewals []*WatchRenewal `json:"renewals"`
+	Renewed  int             `json:"renewed"`
+	Failed   int             `json:"failed"`
+}
+
+func (r *WatchRenewResult) Headers() []string {
+	return []string{"Channel ID", "Kind", "New Channel ID", "Expires", "Status"}
+}
+
+func (r *WatchRenewResult) Rows() [][]string {
+	rows := make([][]string, len(r.Renewals))
+	for i, rn := range r.Renewals {
+		expires := ""
+		if !rn.Expiration.IsZero() {
+			expires = types.DisplayTime(rn.Expiration.Format(time.RFC3339))
+		}
+		status := rn.Status
+		if rn.Error != "" {
+			status += ": " + rn.Error
+		}
+		rows[i] = []string{rn.OldID, rn.Kind, rn.NewID, expires, status}
+	}
+	return rows
+}
+
+func (r *WatchRenewResult) EmptyMessage() string {
+	return "No channels due for renewal"
+}
+
+func getWatchManager(ctx context.Context, flags types.GlobalFlags) (*watch.Manager, *api.Client, *types.RequestContext, *OutputWriter, error) {
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	client, err := getAPIClient(ctx, flags.Profile)
+	if err != nil {
+		return nil, nil, nil, out, err
+	}
+	reqCtx := api.NewRequestContext(flags.Profile, flags.DriveID, types.RequestTypeGetByID)
+	return watch.NewManager(client), client, reqCtx, out, nil
+}
+
+// profileChannels returns the recorded channels opened by the profile
+func profileChannels(store *watch.Store, profile string) ([]*watch.Channel, error) {
+	channels, err := store.List(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var mine []*watch.Channel
+	for _, c := range channels {
+		if c.Profile == profile {
+			mine = append(mine, c)
+		}
+	}
+	return mine, nil
+}
+
+// selectChannels returns the channels with the given IDs, failing on an
+// unknown one, or all channels when no IDs are given
+func selectChannels(channels []*watch.Channel, ids []string) ([]*watch.Channel, error) {
+	if len(ids) == 0 {
+		return channels, nil
+	}
+	byID := make(map[string]*watch.Channel, len(channels))
+	for _, c := range channels {
+		byID[c.ID] = c
+	}
+	selected := make([]*watch.Channel, 0, len(ids))
+	for _, id := range ids {
+		c, ok := byID[id]
+		if !ok {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("No recorded channel %s", id)).
+				WithContext("suggestedAction", "list channels with 'gdrv watch list'").Build())
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+func runWatchCreate(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+	mgr, client, reqCtx, out, err := getWatchManager(ctx, flags)
+	if err != nil {
+		return out.WriteError("watch.create", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+	}
+	store, err := watch.DefaultStore()
+	if err != nil {
+		return handleError(out, "watch.create", err)
+	}
+
+	opts := watch.Options{Address: watchCreateAddress, Token: watchCreateToken, TTL: watchCreateTTL}
+	var channel *watch.Channel
+	if watchCreateChanges {
+		opts.DriveID = flags.DriveID
+		if planOperation(safety.PlannedOperation{
+			Type:        safety.OpTypeCreate,
+			Description: "Watch changes: " + watchCreateAddress,
+			Parameters:  map[string]interface{}{"address": watchCreateAddress, "driveId": flags.DriveID, "ttl": watchCreateTTL.String()},
+			Predicted:   "notification channel opened",
+		}) {
+			return out.WriteSuccess("watch.create", nil)
+		}
+		channel, err = mgr.WatchChanges(ctx, reqCtx, opts)
+	} else {
+		fileID, _, resolveErr := ResolveLocation(ctx, client, flags, watchCreateFileID)
+		if resolveErr != nil {
+			return handleError(out, "watch.create", resolveErr)
+		}
+		if planOperation(safety.PlannedOperation{
+			Type:        safety.OpTypeCreate,
+			ResourceID:  fileID,
+			Description: "Watch file: " + fileID,
+			Parameters:  map[string]interface{}{"address": watchCreateAddress, "ttl": watchCreateTTL.String()},
+			Predicted:   "notification channel opened",
+		}) {
+			return out.WriteSuccess("watch.create", nil)
+		}
+		channel, err = mgr.WatchFile(ctx, reqCtx, fileID, opts)
+	}
+	if err != nil {
+		return handleError(out, "watch.create", err)
+	}
+
+	if err := store.Put(channel); err != nil {
+		out.AddWarning("CHANNEL_NOT_RECORDED", fmt.Sprintf("channel %s is open but could not be recorded: %s; stop it with --resource-id %s", channel.ID, err, channel.ResourceID), "high")
+	}
+	if !channel.Expiration.IsZero() {
+		out.Log("Channel %s expires %s; keep it open with 'gdrv watch renew'", channel.ID, channel.Expiration.Local().Format(time.RFC3339))
+	}
+	return out.WriteSuccess("watch.create", channel)
+}
+
+func runWatchList(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+	store, err := watch.DefaultStore()
+	if err != nil {
+		return handleError(out, "watch.list", err)
+	}
+	channels, err := profileChannels(store, flags.Profile)
+	if err != nil {
+		return handleError(out, "watch.list", err)
+	}
+	if channels == nil {
+		channels = []*watch.Channel{}
+	}
+	return out.WriteSuccess("watch.list", &watch.ChannelList{Channels: channels})
+}
+
+func runWatchStop(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	switch {
+	case watchStopResource != "" && len(args) != 1:
+		return out.WriteError("watch.stop", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--resource-id needs exactly one channel ID").Build())
+	case watchStopExpired && len(args) > 0:
+		return out.WriteError("watch.stop", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"--expired does not take channel IDs").Build())
+	case !watchStopExpired && len(args) == 0:
+		return out.WriteError("watch.stop", utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Give the channel IDs to stop, or --expired").Build())
+	}
+
+	store, err := watch.DefaultStore()
+	if err != nil {
+		return handleError(out, "watch.stop", err)
+	}
+	var targets []*watch.Channel
+	if watchStopResource != "" {
+		targets = []*watch.Channel{{ID: args[0], ResourceID: watchStopResource}}
+	} else {
+		channels, err := profileChannels(store, flags.Profile)
+		if err != nil {
+			return handleError(out, "watch.stop", err)
+		}
+		if watchStopExpired {
+			for _, c := range channels {
+				if c.Status == watch.StatusExpired {
+					targets = append(targets, c)
+				}
+			}
+		} else if targets, err = selectChannels(channels, args); err != nil {
+			return handleError(out, "watch.stop", err)
+		}
+	}
+
+	var mgr *watch.Manager
+	var reqCtx *types.RequestContext
+	stopped := []*watch.Channel{}
+	for _, c := range targets {
+		if planOperation(safety.PlannedOperation{
+			Type:        safety.OpTypeDelete,
+			ResourceID:  c.ID,
+			Description: "Stop channel: " + c.ID,
+			Predicted:   "notification channel stopped",
+		}) {
+			continue
+		}
+		// Expired channels are already closed; only forget them
+		if c.Status != watch.StatusExpired {
+			if mgr == nil {
+				if mgr, _, reqCtx, _, err = getWatchManager(ctx, flags); err != nil {
+					return out.WriteError("watch.stop", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+				}
+			}
+			if err := mgr.Stop(ctx, reqCtx, c); err != nil {
+				return handleError(out, "watch.stop", err)
+			}
+		}
+		if err := store.Remove(c.ID); err != nil {
+			return handleError(out, "watch.stop", err)
+		}
+		stopped = append(stopped, c)
+	}
+	return out.WriteSuccess("watch.stop", &watch.ChannelList{Channels: stopped})
+}
+
+func runWatchRenew(cmd *cobra.Command, args []string) error {
+	flags := GetGlobalFlags()
+	ctx := context.Background()
+	out := NewOutputWriter(flags.OutputFormat, flags.Quiet, flags.Verbose)
+
+	store, err := watch.DefaultStore()
+	if err != nil {
+		return handleError(out, "watch.renew", err)
+	}
+	channels, err := profileChannels(store, flags.Profile)
+	if err != nil {
+		return handleError(out, "watch.renew", err)
+	}
+	if channels, err = selectChannels(channels, args); err != nil {
+		return handleError(out, "watch.renew", err)
+	}
+
+	result := &WatchRenewResult{Renewals: []*WatchRenewal{}}
+	due := watch.DueForRenewal(channels, watchRenewWithin, time.Now())
+	var mgr *watch.Manager
+	var reqCtx *types.RequestContext
+	for _, old := range due {
+		renewal := &WatchRenewal{OldID: old.ID, Kind: old.Kind, FileID: old.FileID}
+		result.Renewals = append(result.Renewals, renewal)
+		if planOperation(safety.PlannedOperation{
+			Type:        safety.OpTypeUpdate,
+			ResourceID:  old.ID,
+			Description: "Renew channel: " + old.ID,
+			Predicted:   "notification channel replaced",
+		}) {
+			renewal.Status = "planned"
+			continue
+		}
+		if mgr == nil {
+			if mgr, _, reqCtx, _, err = getWatchManager(ctx, flags); err != nil {
+				return out.WriteError("watch.renew", utils.NewCLIError(utils.ErrCodeAuthRequired, err.Error()).Build())
+			}
+		}
+
+		renewed, err := mgr.Renew(ctx, reqCtx, old)
+		if renewed == nil {
+			renewal.Status, renewal.Error = "failed", err.Error()
+			result.Failed++
+			continue
+		}
+		renewal.Status, renewal.NewID, renewal.Expiration = "renewed", renewed.ID, renewed.Expiration
+		result.Renewed++
+		if err != nil {
+			out.AddWarning("CHANNEL_NOT_STOPPED", fmt.Sprintf("renewed %s but could not stop it: %s; it stops delivering when it expires", old.ID, err), "low")
+		}
+		if err := store.Put(renewed); err != nil {
+			out.AddWarning("CHANNEL_NOT_RECORDED", fmt.Sprintf("channel %s is open but could not be recorded: %s; stop it with --resource-id %s", renewed.ID, err, renewed.ResourceID), "high")
+			continue
+		}
+		if err := store.Remove(old.ID); err != nil {
+			return handleError(out, "watch.renew", err)
+		}
+	}
+	if result.Failed > 0 {
+		out.AddWarning("RENEWALS_FAILED", fmt.Sprintf("%d channel(s) could not be renewed", result.Failed), "high")
+	}
+	return out.WriteSuccess("watch.renew", result)
+}
@@ -0,0 +1,86 @@
+package comments
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+// commentFields is requested for every comment; the Comments API requires
+// an explicit field mask
+const commentFields = "id,content,author(displayName,emailAddress,me),createdTime,modifiedTime,resolved,deleted," +
+	"replies(id,content,author(displayName,emailAddress,me),createdTime,action,deleted)"
+
+// Manager handles Drive comments
+type Manager struct {
+	client *api.Client
+}
+
+// NewManager creates a new comments manager
+func NewManager(client *api.Client) *Manager {
+	return &Manager{client: client}
+}
+
+// Create posts a comment on a file. Mentioning "+user@example.com" in the
+// content notifies that user.
+func (m *Manager) Create(ctx context.Context, reqCtx *types.RequestContext, fileID, content string) (*types.Comment, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+	call := m.client.Service().Comments.Create(fileID, &drive.Comment{Content: content}).Fields(commentFields)
+	comment, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Comment, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertComment(comment), nil
+}
+
+// Get returns a comment with its replies. Deleted comments are returned
+// with Deleted set rather than as an error.
+func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, fileID, commentID string) (*types.Comment, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+	call := m.client.Service().Comments.Get(fileID, commentID).IncludeDeleted(true).Fields(commentFields)
+	comment, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Comment, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertComment(comment), nil
+}
+
+func convertComment(c *drive.Comment) *types.Comment {
+	comment := &types.Comment{
+		ID:           c.Id,
+		Content:      c.Content,
+		CreatedTime:  c.CreatedTime,
+		ModifiedTime: c.ModifiedTime,
+		Resolved:     c.Resolved,
+		Deleted:      c.Deleted,
+	}
+	comment.Author, comment.AuthorIsMe = authorName(c.Author)
+	for _, r := range c.Replies {
+		reply := &types.CommentReply{
+			ID:          r.Id,
+			Content:     r.Content,
+			CreatedTime: r.CreatedTime,
+			Action:      r.Action,
+			Deleted:     r.Deleted,
+		}
+		reply.Author, reply.AuthorIsMe = authorName(r.Author)
+		comment.Replies = append(comment.Replies, reply)
+	}
+	return comment
+}
+
+func authorName(u *drive.User) (string, bool) {
+	if u == nil {
+		return "", false
+	}
+	if u.EmailAddress != "" {
+		return u.EmailAddress, u.Me
+	}
+	return u.DisplayName, u.Me
+}
@@ -9,7 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dl-alexandre/gdrv/internal/export"
+	"github.com/dl-alexandre/gdrv/internal/safety"
 	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
 const (
@@ -58,6 +61,55 @@ type Config struct {
 
 	// OAuthClientSecret is the OAuth client secret (optional for public clients)
 	OAuthClientSecret string `json:"oauthClientSecret,omitempty"`
+
+	// Locale selects a message catalog from the locales directory in the
+	// config dir (e.g. "de" loads locales/de.json)
+	Locale string `json:"locale,omitempty"`
+
+	// MessageCatalog is the path to a custom message catalog for prompts and
+	// warnings; it takes precedence over Locale
+	MessageCatalog string `json:"messageCatalog,omitempty"`
+
+	// ExportDefaults maps a Workspace type (document, spreadsheet,
+	// presentation, drawing or script) to the format it is exported as when
+	// no --mime-type or --format is given, e.g. {"document": "docx"}.
+	// Formats are shorthands or MIME types.
+	ExportDefaults map[string]string `json:"exportDefaults,omitempty"`
+
+	// Approval requires a signed approval file (--approval, written by
+	// 'gdrv plan approve') for bulk changes above a blast radius
+	Approval *safety.ApprovalPolicy `json:"approval,omitempty"`
+
+	// Profiles holds settings that apply to a single auth profile
+	Profiles map[string]*ProfileConfig `json:"profiles,omitempty"`
+}
+
+// ProfileConfig holds per-profile settings
+type ProfileConfig struct {
+	// InternalDomains are the domains treated as internal when detecting
+	// external sharing. Entries are exact domains ("example.com") or
+	// subdomain wildcards ("*.example.com").
+	InternalDomains []string `json:"internalDomains,omitempty"`
+
+	// CredentialsSource is where service account key material is fetched
+	// from at runtime (vault://, gcpsm:// or file://) when
+	// 'auth service-account' is run without --key-file
+	CredentialsSource string `json:"credentialsSource,omitempty"`
+
+	// ReadOnly refuses every API request that could modify remote state,
+	// as if --read-only were always given for this profile
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// OAuthClientID and OAuthClientSecret are the OAuth client used for
+	// this profile's user logins instead of the top-level client, e.g. a
+	// Workspace customer's own client with its consent screen and quota
+	OAuthClientID     string `json:"oauthClientId,omitempty"`
+	OAuthClientSecret string `json:"oauthClientSecret,omitempty"`
+
+	// BillingProject is the Google Cloud project API quota and billing are
+	// charged to (the X-Goog-User-Project header), as if --billing-project
+	// were always given for this profile
+	BillingProject string `json:"billingProject,omitempty"`
 }
 
 // FieldMaskPreset defines field mask presets
@@ -119,7 +171,7 @@ func (c *Config) loadFromFile() error {
 		return err
 	}
 
-	data, err := os.ReadFile(configPath)
+	data, err := utils.ReadFileWithBackup(configPath, utils.ValidJSON)
 	if err != nil {
 		return err
 	}
@@ -173,6 +225,12 @@ func (c *Config) loadFromEnv() {
 	if v := os.Getenv(EnvPrefix + "CLIENT_SECRET"); v != "" {
 		c.OAuthClientSecret = v
 	}
+	if v := os.Getenv(EnvPrefix + "LOCALE"); v != "" {
+		c.Locale = v
+	}
+	if v := os.Getenv(EnvPrefix + "MESSAGE_CATALOG"); v != "" {
+		c.MessageCatalog = v
+	}
 }
 
 // Save saves the configuration to the config file
@@ -199,8 +257,8 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Write to file with restricted permissions
-	if err := os.WriteFile(configPath, data, 0600); err != nil {
+	// Replace the file atomically, keeping the previous one as a backup
+	if err := utils.ReplaceFile(configPath, data, 0600, utils.ValidJSON); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -255,9 +313,60 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log level: %s (must be one of: %s)", c.LogLevel, strings.Join(validLogLevels, ", "))
 	}
 
+	// Validate export defaults
+	if _, err := c.ExportFormats(); err != nil {
+		return err
+	}
+
+	// Validate approval policy
+	if c.Approval != nil {
+		if err := c.Approval.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// ExportFormats resolves ExportDefaults to a map from Workspace MIME type
+// to export MIME type, checking each format against the reference export
+// table
+func (c *Config) ExportFormats() (map[string]string, error) {
+	formats := make(map[string]string, len(c.ExportDefaults))
+	for typeName, format := range c.ExportDefaults {
+		source, err := export.GetMimeTypeForWorkspaceType(typeName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exportDefaults key: %s (must be document, spreadsheet, presentation, drawing or script)", typeName)
+		}
+		target, err := export.GetConvenienceFormat(format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exportDefaults.%s: unknown format %s", typeName, format)
+		}
+		if err := export.ValidateExportFormat(source, target); err != nil {
+			return nil, fmt.Errorf("invalid exportDefaults.%s: %s cannot be exported as %s", typeName, typeName, format)
+		}
+		formats[source] = target
+	}
+	return formats, nil
+}
+
+// Profile returns the settings for a profile, or empty settings if the
+// profile has none
+func (c *Config) Profile(name string) *ProfileConfig {
+	if p := c.Profiles[name]; p != nil {
+		return p
+	}
+	return &ProfileConfig{}
+}
+
+// SetProfile stores the settings for a profile
+func (c *Config) SetProfile(name string, p *ProfileConfig) {
+	if c.Profiles == nil {
+		c.Profiles = map[string]*ProfileConfig{}
+	}
+	c.Profiles[name] = p
+}
+
 // GetCacheTTL returns the cache TTL as a duration
 func (c *Config) GetCacheTTL() time.Duration {
 	return time.Duration(c.CacheTTL) * time.Second
@@ -305,6 +414,15 @@ func GetConfigDir() (string, error) {
 	return newDir, nil
 }
 
+// GetLocalesDir returns the directory holding locale message catalogs
+func GetLocalesDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "locales"), nil
+}
+
 func dirExists(path string) bool {
 	info, err := os.Stat(path)
 	if err != nil {
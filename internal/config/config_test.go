@@ -428,3 +428,92 @@ func containsInner(s, substr string) bool {
 	}
 	return false
 }
+
+func TestProfileConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.Profile("work").InternalDomains; len(got) != 0 {
+		t.Fatalf("expected no internal domains, got %v", got)
+	}
+
+	cfg.SetProfile("work", &ProfileConfig{InternalDomains: []string{"example.com", "*.example.com"}})
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := DefaultConfig()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Profile("work").InternalDomains; len(got) != 2 || got[1] != "*.example.com" {
+		t.Errorf("InternalDomains = %v", got)
+	}
+	if got := loaded.Profile("default").InternalDomains; len(got) != 0 {
+		t.Errorf("default profile InternalDomains = %v", got)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ExportDefaults = map[string]string{"document": "docx", "Sheet": "text/csv", "presentation": "pdf"}
+	formats, err := cfg.ExportFormats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"application/vnd.google-apps.document":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.google-apps.spreadsheet":  "text/csv",
+		"application/vnd.google-apps.presentation": "application/pdf",
+	}
+	if len(formats) != len(want) {
+		t.Fatalf("formats = %v, want %v", formats, want)
+	}
+	for source, target := range want {
+		if formats[source] != target {
+			t.Errorf("%s exports as %q, want %q", source, formats[source], target)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	for name, defaults := range map[string]map[string]string{
+		"unknown type":       {"folder": "pdf"},
+		"unknown format":     {"document": "wordperfect"},
+		"unsupported format": {"spreadsheet": "docx"},
+	} {
+		cfg.ExportDefaults = defaults
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestConfigSave_RecoversFromCorruptFile(t *testing.T) {
+	t.Setenv("GDRV_CONFIG_DIR", t.TempDir())
+
+	cfg := DefaultConfig()
+	cfg.DefaultProfile = "first"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	cfg.DefaultProfile = "second"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"defaultProfile": "sec`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load should fall back to the backup: %v", err)
+	}
+	if loaded.DefaultProfile != "first" {
+		t.Errorf("Expected profile from backup 'first', got '%s'", loaded.DefaultProfile)
+	}
+}
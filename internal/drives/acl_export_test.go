@@ -0,0 +1,93 @@
+package drives
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestExportACLs(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("useDomainAdminAccess") != "true" {
+			t.Errorf("%s: useDomainAdminAccess not set", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/drive/v3/drives":
+			if r.URL.Query().Get("pageToken") == "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"nextPageToken": "p2",
+					"drives": []map[string]interface{}{
+						{"id": "d1", "name": "Finance", "restrictions": map[string]bool{"domainUsersOnly": true, "driveMembersOnly": true}},
+					},
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"drives": []map[string]interface{}{{"id": "d2", "name": "Legal"}, {"id": "d3", "name": "Empty"}},
+			})
+		case "/drive/v3/files/d1/permissions":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"permissions": []map[string]interface{}{
+					{"id": "p1", "type": "user", "role": "organizer", "emailAddress": "alice@example.com"},
+					{"id": "p2", "type": "group", "role": "reader", "emailAddress": "finance@example.com"},
+				},
+			})
+		case "/drive/v3/files/d2/permissions":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"denied","errors":[{"reason":"insufficientFilePermissions"}]}}`))
+		case "/drive/v3/files/d3/permissions":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"permissions": []interface{}{}})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	report, err := mgr.ExportACLs(context.Background(), reqCtx, ACLExportOptions{UseDomainAdminAccess: true})
+	if err != nil {
+		t.Fatalf("ExportACLs: %v", err)
+	}
+	if report.Drives != 3 || report.Members != 2 || report.Errors != 1 || len(report.Entries) != 4 {
+		t.Fatalf("report = drives %d, members %d, errors %d, entries %d",
+			report.Drives, report.Members, report.Errors, len(report.Entries))
+	}
+	if e := report.Entries[0]; e.MemberEmail != "alice@example.com" || e.Role != "organizer" || !e.DomainUsersOnly || !e.DriveMembersOnly {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if e := report.Entries[2]; e.DriveName != "Legal" || e.Error == "" {
+		t.Errorf("entry 2 should carry the member listing error: %+v", e)
+	}
+	if e := report.Entries[3]; e.DriveName != "Empty" || e.MemberEmail != "" || e.Error != "" {
+		t.Errorf("entry 3 should be the drive without members: %+v", e)
+	}
+	if rows := report.Rows(); rows[0][4] != "domain-only,members-only" {
+		t.Errorf("table restrictions = %q", rows[0][4])
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || strings.Join(records[0][:2], ",") != "drive_id,drive_name" {
+		t.Fatalf("csv = %v", records)
+	}
+	if records[1][11] != "alice@example.com" || records[1][7] != "true" {
+		t.Errorf("csv row 1 = %v", records[1])
+	}
+}
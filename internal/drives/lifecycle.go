@@ -0,0 +1,128 @@
+package drives
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+)
+
+// driveFields are the fields returned for drives created or changed here
+const driveFields = "id,name,kind,colorRgb,themeId,createdTime,hidden,orgUnitId,capabilities,restrictions"
+
+// DriveSettings are the settings applied when creating or updating a
+// Shared Drive; empty fields are left unchanged
+type DriveSettings struct {
+	Name         string
+	ThemeID      string
+	ColorRgb     string
+	Restrictions *TemplateRestrictions
+}
+
+// IsEmpty reports whether the settings change nothing
+func (s DriveSettings) IsEmpty() bool {
+	return s.Name == "" && s.ThemeID == "" && s.ColorRgb == "" &&
+		(s.Restrictions == nil || restrictionTarget(s.Restrictions) == "")
+}
+
+// Create creates a Shared Drive. Drive ignores restrictions on creation, so
+// they are applied by a second request; if that fails, the created drive is
+// returned along with the error.
+func (m *Manager) Create(ctx context.Context, reqCtx *types.RequestContext, settings DriveSettings) (*SharedDrive, error) {
+	if settings.Name == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, "A Shared Drive name is required").Build())
+	}
+	d := &drive.Drive{Name: settings.Name, ThemeId: settings.ThemeID, ColorRgb: settings.ColorRgb}
+	// The request ID makes retries of the create idempotent
+	call := m.client.Service().Drives.Create(uuid.New().String(), d).Fields(driveFields)
+	created, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if settings.Restrictions == nil || restrictionTarget(settings.Restrictions) == "" {
+		return mapDriveToSharedDrive(created), nil
+	}
+
+	updated, err := m.Update(ctx, reqCtx, created.Id, DriveSettings{Restrictions: settings.Restrictions}, false)
+	if err != nil {
+		return mapDriveToSharedDrive(created), err
+	}
+	return updated, nil
+}
+
+// Update changes a Shared Drive's name, theme, color or restrictions. With
+// useDomainAdminAccess, an administrator can update drives they are not a
+// member of.
+func (m *Manager) Update(ctx context.Context, reqCtx *types.RequestContext, driveID string, settings DriveSettings, useDomainAdminAccess bool) (*SharedDrive, error) {
+	if settings.IsEmpty() {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, "Nothing to update").
+			WithContext("driveId", driveID).Build())
+	}
+	if settings.ThemeID != "" && settings.ColorRgb != "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"A theme sets the drive's color; set either a theme or a color").Build())
+	}
+	d := &drive.Drive{Name: settings.Name, ThemeId: settings.ThemeID, ColorRgb: settings.ColorRgb}
+	if settings.Restrictions != nil {
+		d.Restrictions = settings.Restrictions.toAPI()
+	}
+	call := m.client.Service().Drives.Update(driveID, d).Fields(driveFields)
+	if useDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapDriveToSharedDrive(result), nil
+}
+
+// SetHidden hides a Shared Drive from the user's default view, or shows it
+// again. Hiding only affects the current user.
+func (m *Manager) SetHidden(ctx context.Context, reqCtx *types.RequestContext, driveID string, hidden bool) (*SharedDrive, error) {
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+		if hidden {
+			return m.client.Service().Drives.Hide(driveID).Fields(driveFields).Do()
+		}
+		return m.client.Service().Drives.Unhide(driveID).Fields(driveFields).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapDriveToSharedDrive(result), nil
+}
+
+// DeleteOptions configures Delete
+type DeleteOptions struct {
+	UseDomainAdminAccess bool // Delete as an administrator
+	AllowItemDeletion    bool // Delete the drive's items too; requires UseDomainAdminAccess
+}
+
+// Delete permanently deletes a Shared Drive. Drive refuses to delete a
+// drive that still has items unless an administrator allows item deletion.
+func (m *Manager) Delete(ctx context.Context, reqCtx *types.RequestContext, driveID string, opts DeleteOptions) error {
+	if opts.AllowItemDeletion && !opts.UseDomainAdminAccess {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Deleting a drive's items requires domain administrator access").
+			WithContext("suggestedAction", "add --use-domain-admin-access, or empty the drive first").
+			Build())
+	}
+	call := m.client.Service().Drives.Delete(driveID)
+	if opts.UseDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+	if opts.AllowItemDeletion {
+		call = call.AllowItemDeletion(true)
+	}
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (struct{}, error) {
+		return struct{}{}, call.Do()
+	})
+	return err
+}
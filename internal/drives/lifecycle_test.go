@@ -0,0 +1,130 @@
+package drives
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// driveRequest is a request made to the test server
+type driveRequest struct {
+	method string
+	path   string
+	query  url.Values
+	body   map[string]interface{}
+}
+
+func newLifecycleManager(t *testing.T, requests *[]driveRequest) *Manager {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := driveRequest{method: r.Method, path: strings.TrimPrefix(r.URL.Path, "/drive/v3"), query: r.URL.Query()}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			_ = json.Unmarshal(data, &req.body)
+		}
+		*requests = append(*requests, req)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		resp := map[string]interface{}{"id": "d1", "name": "Finance"}
+		if req.body != nil {
+			if restrictions, ok := req.body["restrictions"]; ok {
+				resp["restrictions"] = restrictions
+			}
+		}
+		if strings.HasSuffix(req.path, "/hide") {
+			resp["hidden"] = true
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+
+	return NewManager(client)
+}
+
+func TestCreate_AppliesRestrictions(t *testing.T) {
+	var requests []driveRequest
+	mgr := newLifecycleManager(t, &requests)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	yes, no := true, false
+
+	sd, err := mgr.Create(context.Background(), reqCtx, DriveSettings{
+		Name:         "Finance",
+		Restrictions: &TemplateRestrictions{DomainUsersOnly: &yes, DriveMembersOnly: &no},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || requests[0].method != http.MethodPost || requests[0].query.Get("requestId") == "" {
+		t.Fatalf("requests = %+v", requests)
+	}
+	update := requests[1]
+	restrictions, _ := update.body["restrictions"].(map[string]interface{})
+	// An explicit false must be sent, or Drive keeps its current value
+	if update.method != http.MethodPatch || update.path != "/drives/d1" ||
+		restrictions["domainUsersOnly"] != true || restrictions["driveMembersOnly"] != false {
+		t.Errorf("update = %+v", update)
+	}
+	if sd.Restrictions == nil || !sd.Restrictions.DomainUsersOnly {
+		t.Errorf("created drive = %+v", sd)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	var requests []driveRequest
+	mgr := newLifecycleManager(t, &requests)
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "d1", types.RequestTypeMutation)
+
+	if _, err := mgr.Update(ctx, reqCtx, "d1", DriveSettings{Name: "Finance (archived)"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests[0]; got.body["name"] != "Finance (archived)" || got.query.Get("useDomainAdminAccess") != "true" {
+		t.Errorf("update = %+v", got)
+	}
+
+	for _, settings := range []DriveSettings{{}, {Restrictions: &TemplateRestrictions{}}, {ThemeID: "t", ColorRgb: "#000000"}} {
+		_, err := mgr.Update(ctx, reqCtx, "d1", settings, false)
+		if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+			t.Errorf("Update(%+v) error = %v", settings, err)
+		}
+	}
+	if len(requests) != 1 {
+		t.Errorf("invalid updates made %d requests", len(requests)-1)
+	}
+}
+
+func TestSetHiddenAndDelete(t *testing.T) {
+	var requests []driveRequest
+	mgr := newLifecycleManager(t, &requests)
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "d1", types.RequestTypeMutation)
+
+	sd, err := mgr.SetHidden(ctx, reqCtx, "d1", true)
+	if err != nil || !sd.Hidden || requests[0].path != "/drives/d1/hide" {
+		t.Errorf("hide = %+v, %v, requests %+v", sd, err, requests)
+	}
+	if _, err := mgr.SetHidden(ctx, reqCtx, "d1", false); err != nil || requests[1].path != "/drives/d1/unhide" {
+		t.Errorf("unhide = %v, requests %+v", err, requests)
+	}
+
+	err = mgr.Delete(ctx, reqCtx, "d1", DeleteOptions{AllowItemDeletion: true})
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+		t.Errorf("item deletion without admin access: %v", err)
+	}
+	if err := mgr.Delete(ctx, reqCtx, "d1", DeleteOptions{UseDomainAdminAccess: true, AllowItemDeletion: true}); err != nil {
+		t.Fatal(err)
+	}
+	del := requests[len(requests)-1]
+	if del.method != http.MethodDelete || del.query.Get("allowItemDeletion") != "true" || del.query.Get("useDomainAdminAccess") != "true" {
+		t.Errorf("delete = %+v", del)
+	}
+}
@@ -11,8 +11,9 @@ import (
 
 // Manager handles Shared Drive operations
 type Manager struct {
-	client *api.Client
-	shaper *api.RequestShaper
+	client   *api.Client
+	shaper   *api.RequestShaper
+	progress types.ProgressReporter
 }
 
 // NewManager creates a new drives manager
@@ -23,6 +24,12 @@ func NewManager(client *api.Client) *Manager {
 	}
 }
 
+// SetProgress makes long-running operations report each item to r; nil
+// turns reporting off
+func (m *Manager) SetProgress(r types.ProgressReporter) {
+	m.progress = r
+}
+
 // SharedDrive represents a Shared Drive with metadata
 type SharedDrive struct {
 	ID                    string            `json:"id"`
@@ -74,16 +81,30 @@ type ListResult struct {
 	NextPageToken string         `json:"nextPageToken,omitempty"`
 }
 
-// List lists all Shared Drives
-func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, pageSize int, pageToken string) (*ListResult, error) {
+// ListOptions configures List
+type ListOptions struct {
+	PageSize             int
+	PageToken            string
+	Query                string // Drive search query for drives, e.g. "hidden = true"
+	UseDomainAdminAccess bool   // List every drive in the customer, as an administrator
+}
+
+// List lists one page of Shared Drives
+func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, opts ListOptions) (*ListResult, error) {
 	call := m.client.Service().Drives.List()
 	call = m.shaper.ShapeDrivesList(call, reqCtx)
 
-	if pageSize > 0 {
-		call = call.PageSize(int64(pageSize))
+	if opts.PageSize > 0 {
+		call = call.PageSize(int64(opts.PageSize))
 	}
-	if pageToken != "" {
-		call = call.PageToken(pageToken)
+	if opts.PageToken != "" {
+		call = call.PageToken(opts.PageToken)
+	}
+	if opts.Query != "" {
+		call = call.Q(opts.Query)
+	}
+	if opts.UseDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
 	}
 
 	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.DriveList, error) {
@@ -104,9 +125,13 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, pageSi
 	}, nil
 }
 
-// Get retrieves a Shared Drive by ID with full metadata
-func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, driveID string, fields string) (*SharedDrive, error) {
+// Get retrieves a Shared Drive by ID with full metadata. With
+// useDomainAdminAccess, any drive in the customer can be read.
+func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, driveID string, fields string, useDomainAdminAccess bool) (*SharedDrive, error) {
 	call := m.client.Service().Drives.Get(driveID)
+	if useDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
 	
 	// Apply fields mask if specified
 	if fields != "" {
@@ -0,0 +1,247 @@
+package drives
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const memberFields = "id,type,role,emailAddress,domain,displayName,deleted"
+
+// Member is a drive-level permission of a Shared Drive. Members have
+// access to every item in the drive, unlike file permissions, which grant
+// access to one item and its children.
+type Member struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Role         string `json:"role"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	DisplayName  string `json:"displayName,omitempty"`
+	Deleted      bool   `json:"deleted,omitempty"`
+}
+
+// MemberList is one page of a Shared Drive's members
+type MemberList struct {
+	DriveID       string    `json:"driveId"`
+	Members       []*Member `json:"members"`
+	NextPageToken string    `json:"nextPageToken,omitempty"`
+}
+
+func (l *MemberList) Headers() []string {
+	return []string{"ID", "Type", "Member", "Name", "Role"}
+}
+
+func (l *MemberList) Rows() [][]string {
+	rows := make([][]string, len(l.Members))
+	for i, mb := range l.Members {
+		name := mb.DisplayName
+		if mb.Deleted {
+			name += " (deleted)"
+		}
+		rows[i] = []string{mb.ID, mb.Type, mb.principal(), name, mb.Role}
+	}
+	return rows
+}
+
+func (l *MemberList) EmptyMessage() string {
+	return "No members"
+}
+
+func (mb *Member) principal() string {
+	if mb.Type == types.PermissionTypeDomain {
+		return mb.Domain
+	}
+	return mb.EmailAddress
+}
+
+// MemberListOptions configures ListMembers
+type MemberListOptions struct {
+	PageSize             int
+	PageToken            string
+	UseDomainAdminAccess bool // List the members of any drive in the customer, as an administrator
+}
+
+// MemberOptions configures AddMember. Type defaults to user with
+// EmailAddress and to domain with Domain.
+type MemberOptions struct {
+	Type                  string
+	EmailAddress          string
+	Domain                string
+	Role                  string
+	SendNotificationEmail bool
+	UseDomainAdminAccess  bool
+}
+
+// ListMembers lists one page of a Shared Drive's members
+func (m *Manager) ListMembers(ctx context.Context, reqCtx *types.RequestContext, driveID string, opts MemberListOptions) (*MemberList, error) {
+	call := m.client.Service().Permissions.List(driveID)
+	call = m.shaper.ShapePermissionsList(call, reqCtx)
+	call = call.Fields(googleapi.Field("nextPageToken,permissions(" + memberFields + ")"))
+	if opts.PageSize > 0 {
+		call = call.PageSize(int64(opts.PageSize))
+	}
+	if opts.PageToken != "" {
+		call = call.PageToken(opts.PageToken)
+	}
+	if opts.UseDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.PermissionList, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := &MemberList{DriveID: driveID, Members: make([]*Member, len(result.Permissions)), NextPageToken: result.NextPageToken}
+	for i, p := range result.Permissions {
+		list.Members[i] = convertMember(p)
+	}
+	return list, nil
+}
+
+// AddMember adds a user, group or domain to a Shared Drive
+func (m *Manager) AddMember(ctx context.Context, reqCtx *types.RequestContext, driveID string, opts MemberOptions) (*Member, error) {
+	perm, err := opts.permission()
+	if err != nil {
+		return nil, err
+	}
+
+	call := m.client.Service().Permissions.Create(driveID, perm)
+	call = m.shaper.ShapePermissionsCreate(call, reqCtx)
+	call = call.SendNotificationEmail(opts.SendNotificationEmail).Fields(memberFields)
+	if opts.UseDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertMember(result), nil
+}
+
+// UpdateMember changes a member's role
+func (m *Manager) UpdateMember(ctx context.Context, reqCtx *types.RequestContext, driveID, memberID, role string, useDomainAdminAccess bool) (*Member, error) {
+	if err := validateMemberRole(role); err != nil {
+		return nil, err
+	}
+
+	call := m.client.Service().Permissions.Update(driveID, memberID, &drive.Permission{Role: role})
+	call = m.shaper.ShapePermissionsUpdate(call, reqCtx)
+	call = call.Fields(memberFields)
+	if useDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertMember(result), nil
+}
+
+// RemoveMember removes a member from a Shared Drive. Permissions the
+// member holds on individual items are not affected.
+func (m *Manager) RemoveMember(ctx context.Context, reqCtx *types.RequestContext, driveID, memberID string, useDomainAdminAccess bool) error {
+	call := m.client.Service().Permissions.Delete(driveID, memberID)
+	call = m.shaper.ShapePermissionsDelete(call, reqCtx)
+	if useDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (interface{}, error) {
+		return nil, call.Do()
+	})
+	return err
+}
+
+// FindMember returns the member with the given email address (users and
+// groups) or domain. Comparisons are case-insensitive.
+func (m *Manager) FindMember(ctx context.Context, reqCtx *types.RequestContext, driveID, email, domain string, useDomainAdminAccess bool) (*Member, error) {
+	if (email == "") == (domain == "") {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Specify exactly one of --email or --domain").Build())
+	}
+	perms, err := m.listMembers(ctx, reqCtx, driveID, useDomainAdminAccess)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range perms {
+		member := convertMember(p)
+		if email != "" && member.Type != types.PermissionTypeDomain && strings.EqualFold(member.EmailAddress, email) ||
+			domain != "" && member.Type == types.PermissionTypeDomain && strings.EqualFold(member.Domain, domain) {
+			return member, nil
+		}
+	}
+	principal := email
+	if principal == "" {
+		principal = domain
+	}
+	return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+		fmt.Sprintf("%s is not a member of Shared Drive %s", principal, driveID)).
+		WithContext("driveId", driveID).
+		Build())
+}
+
+func (opts MemberOptions) permission() (*drive.Permission, error) {
+	if err := validateMemberRole(opts.Role); err != nil {
+		return nil, err
+	}
+	memberType := opts.Type
+	if memberType == "" {
+		memberType = types.PermissionTypeUser
+		if opts.Domain != "" {
+			memberType = types.PermissionTypeDomain
+		}
+	}
+	switch memberType {
+	case types.PermissionTypeUser, types.PermissionTypeGroup:
+		if opts.EmailAddress == "" {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"An email address is required for user and group members").Build())
+		}
+		return &drive.Permission{Type: memberType, EmailAddress: opts.EmailAddress, Role: opts.Role}, nil
+	case types.PermissionTypeDomain:
+		if opts.Domain == "" {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"A domain is required for domain members").Build())
+		}
+		return &drive.Permission{Type: memberType, Domain: opts.Domain, Role: opts.Role}, nil
+	}
+	return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("Invalid member type %q: must be user, group or domain", memberType)).Build())
+}
+
+func validateMemberRole(role string) error {
+	if !isMemberRole(role) {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid member role %q", role)).
+			WithContext("validRoles", strings.Join(memberRoles, ", ")).
+			Build())
+	}
+	return nil
+}
+
+func convertMember(p *drive.Permission) *Member {
+	return &Member{
+		ID:           p.Id,
+		Type:         p.Type,
+		Role:         p.Role,
+		EmailAddress: p.EmailAddress,
+		Domain:       p.Domain,
+		DisplayName:  p.DisplayName,
+		Deleted:      p.Deleted,
+	}
+}
@@ -0,0 +1,86 @@
+package drives
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestMembers(t *testing.T) {
+	var created, updated drive.Permission
+	var deleted string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("useDomainAdminAccess") != "true" {
+			t.Errorf("%s %s: useDomainAdminAccess not set", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/d1/permissions":
+			if r.URL.Query().Get("pageToken") == "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"nextPageToken": "p2",
+					"permissions":   []map[string]interface{}{{"id": "p1", "type": "user", "role": "organizer", "emailAddress": "Ann@example.com"}},
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"permissions": []map[string]interface{}{{"id": "p2", "type": "domain", "role": "reader", "domain": "example.com"}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/d1/permissions":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "p3", "type": created.Type, "role": created.Role, "emailAddress": created.EmailAddress})
+		case r.Method == http.MethodPatch && r.URL.Path == "/drive/v3/files/d1/permissions/p2":
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "p2", "type": "domain", "role": updated.Role, "domain": "example.com"})
+		case r.Method == http.MethodDelete:
+			deleted = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "d1", types.RequestTypeMutation)
+
+	page, err := mgr.ListMembers(ctx, reqCtx, "d1", MemberListOptions{UseDomainAdminAccess: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Members) != 1 || page.NextPageToken != "p2" {
+		t.Fatalf("first page = %+v", page)
+	}
+
+	member, err := mgr.FindMember(ctx, reqCtx, "d1", "", "EXAMPLE.com", true)
+	if err != nil || member.ID != "p2" {
+		t.Fatalf("FindMember by domain = %+v, %v", member, err)
+	}
+	if _, err := mgr.FindMember(ctx, reqCtx, "d1", "bob@example.com", "", true); err == nil {
+		t.Error("a non-member should not be found")
+	}
+
+	added, err := mgr.AddMember(ctx, reqCtx, "d1", MemberOptions{EmailAddress: "bob@example.com", Role: "fileOrganizer", UseDomainAdminAccess: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Type != "user" || added.ID != "p3" || added.Role != "fileOrganizer" {
+		t.Errorf("added %+v from request %+v", added, created)
+	}
+	if _, err := mgr.AddMember(ctx, reqCtx, "d1", MemberOptions{EmailAddress: "bob@example.com", Role: "owner"}); err == nil {
+		t.Error("owner is not a drive role and should be refused")
+	}
+
+	if _, err := mgr.UpdateMember(ctx, reqCtx, "d1", "p2", "commenter", true); err != nil || updated.Role != "commenter" {
+		t.Fatalf("update sent %+v, err %v", updated, err)
+	}
+	if err := mgr.RemoveMember(ctx, reqCtx, "d1", "p1", true); err != nil || deleted != "/drive/v3/files/d1/permissions/p1" {
+		t.Fatalf("delete hit %q, err %v", deleted, err)
+	}
+}
@@ -0,0 +1,415 @@
+package drives
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateSpec describes a Shared Drive to provision: the drive, its
+// restrictions, its members and a folder structure with labels
+type TemplateSpec struct {
+	Name         string                `yaml:"name" json:"name"`
+	ThemeID      string                `yaml:"themeId,omitempty" json:"themeId,omitempty"`
+	ColorRgb     string                `yaml:"colorRgb,omitempty" json:"colorRgb,omitempty"`
+	Restrictions *TemplateRestrictions `yaml:"restrictions,omitempty" json:"restrictions,omitempty"`
+	Members      []TemplateMember      `yaml:"members,omitempty" json:"members,omitempty"`
+	Folders      []TemplateFolder      `yaml:"folders,omitempty" json:"folders,omitempty"`
+}
+
+// TemplateRestrictions sets Shared Drive restrictions; unset fields keep
+// the Drive default
+type TemplateRestrictions struct {
+	AdminManagedRestrictions                  *bool `yaml:"adminManagedRestrictions,omitempty" json:"adminManagedRestrictions,omitempty"`
+	CopyRequiresWriterPermission              *bool `yaml:"copyRequiresWriterPermission,omitempty" json:"copyRequiresWriterPermission,omitempty"`
+	DomainUsersOnly                           *bool `yaml:"domainUsersOnly,omitempty" json:"domainUsersOnly,omitempty"`
+	DriveMembersOnly                          *bool `yaml:"driveMembersOnly,omitempty" json:"driveMembersOnly,omitempty"`
+	SharingFoldersRequiresOrganizerPermission *bool `yaml:"sharingFoldersRequiresOrganizerPermission,omitempty" json:"sharingFoldersRequiresOrganizerPermission,omitempty"`
+}
+
+// TemplateMember is a drive member; exactly one of User, Group or Domain
+// is set
+type TemplateMember struct {
+	User   string `yaml:"user,omitempty" json:"user,omitempty"`
+	Group  string `yaml:"group,omitempty" json:"group,omitempty"`
+	Domain string `yaml:"domain,omitempty" json:"domain,omitempty"`
+	Role   string `yaml:"role" json:"role"`
+}
+
+// TemplateFolder is a folder to create, with labels and subfolders
+type TemplateFolder struct {
+	Name    string           `yaml:"name" json:"name"`
+	Labels  []TemplateLabel  `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Folders []TemplateFolder `yaml:"folders,omitempty" json:"folders,omitempty"`
+}
+
+// TemplateLabel applies a Drive label to a folder. Fields are keyed by
+// field ID.
+type TemplateLabel struct {
+	ID     string                        `yaml:"id" json:"id"`
+	Fields map[string]TemplateLabelField `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// TemplateLabelField holds the values of one label field; set the list
+// matching the field's type
+type TemplateLabelField struct {
+	Text      []string `yaml:"text,omitempty" json:"text,omitempty"`
+	Integer   []int64  `yaml:"integer,omitempty" json:"integer,omitempty"`
+	Date      []string `yaml:"date,omitempty" json:"date,omitempty"` // YYYY-MM-DD
+	Selection []string `yaml:"selection,omitempty" json:"selection,omitempty"`
+	User      []string `yaml:"user,omitempty" json:"user,omitempty"`
+}
+
+// memberRoles are the roles a Shared Drive member can hold
+var memberRoles = []string{
+	types.PermissionRoleOrganizer,
+	"fileOrganizer",
+	types.PermissionRoleWriter,
+	types.PermissionRoleCommenter,
+	types.PermissionRoleReader,
+}
+
+// LoadTemplateSpec reads and validates a YAML (or JSON) template spec
+func LoadTemplateSpec(path string) (*TemplateSpec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+			fmt.Sprintf("Cannot read template spec: %s", err)).Build())
+	}
+	defer f.Close()
+	return ParseTemplateSpec(f)
+}
+
+// ParseTemplateSpec decodes and validates a template spec. Unknown keys
+// are rejected so a misspelled restriction is not silently ignored.
+func ParseTemplateSpec(r io.Reader) (*TemplateSpec, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	var spec TemplateSpec
+	if err := dec.Decode(&spec); err != nil && err != io.EOF {
+		return nil, templateError(fmt.Sprintf("Invalid template spec: %s", err))
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks the spec before anything is created
+func (s *TemplateSpec) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return templateError("Template spec needs a drive name")
+	}
+	for i, m := range s.Members {
+		set := 0
+		for _, v := range []string{m.User, m.Group, m.Domain} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return templateError(fmt.Sprintf("members[%d]: set exactly one of user, group or domain", i))
+		}
+		if !isMemberRole(m.Role) {
+			return templateError(fmt.Sprintf("members[%d]: role must be one of %s", i, strings.Join(memberRoles, ", ")))
+		}
+	}
+	return validateTemplateFolders(s.Folders, "")
+}
+
+func validateTemplateFolders(folders []TemplateFolder, parent string) error {
+	seen := map[string]bool{}
+	for _, f := range folders {
+		path := parent + "/" + f.Name
+		if strings.TrimSpace(f.Name) == "" || strings.Contains(f.Name, "/") {
+			return templateError(fmt.Sprintf("Invalid folder name %q under %q", f.Name, parent+"/"))
+		}
+		if seen[f.Name] {
+			return templateError(fmt.Sprintf("Folder %s is listed twice", path))
+		}
+		seen[f.Name] = true
+		for _, l := range f.Labels {
+			if l.ID == "" {
+				return templateError(fmt.Sprintf("Folder %s: label needs an id", path))
+			}
+		}
+		if err := validateTemplateFolders(f.Folders, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isMemberRole(role string) bool {
+	for _, r := range memberRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func templateError(msg string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, msg).Build())
+}
+
+// TemplateStep is one provisioning step and its outcome
+type TemplateStep struct {
+	Action string `json:"action"` // create-drive, restrictions, add-member, create-folder, apply-label
+	Target string `json:"target"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"` // done, failed, skipped, planned
+	Error  string `json:"error,omitempty"`
+}
+
+// TemplateResult reports a provisioning run
+type TemplateResult struct {
+	DriveID string          `json:"driveId,omitempty"`
+	Name    string          `json:"name"`
+	DryRun  bool            `json:"dryRun,omitempty"`
+	Failed  int             `json:"failed"`
+	Steps   []*TemplateStep `json:"steps"`
+}
+
+// TemplateOptions configures CreateFromTemplate
+type TemplateOptions struct {
+	DryRun                bool // Report the steps without calling the API
+	SendNotificationEmail bool // Email members added to the drive
+}
+
+// CreateFromTemplate provisions a Shared Drive from spec. Only a failure to
+// create the drive is returned as an error; later steps that fail are
+// recorded in the result, and the folders below a failed folder are
+// skipped. Once ctx is cancelled no further steps are taken and the result
+// so far is returned with ctx's error.
+func (m *Manager) CreateFromTemplate(ctx context.Context, reqCtx *types.RequestContext, spec *TemplateSpec, opts TemplateOptions) (*TemplateResult, error) {
+	result := &TemplateResult{Name: spec.Name, DryRun: opts.DryRun, Steps: []*TemplateStep{}}
+	step := func(action, target string) *TemplateStep {
+		s := &TemplateStep{Action: action, Target: target, Status: "planned"}
+		result.Steps = append(result.Steps, s)
+		return s
+	}
+	finish := func(s *TemplateStep, id string, err error) {
+		if err != nil {
+			s.Status = "failed"
+			s.Error = err.Error()
+			result.Failed++
+			return
+		}
+		s.ID = id
+		s.Status = "done"
+	}
+
+	create := step("create-drive", spec.Name)
+	if !opts.DryRun {
+		d := &drive.Drive{Name: spec.Name, ThemeId: spec.ThemeID, ColorRgb: spec.ColorRgb}
+		call := m.client.Service().Drives.Create(uuid.New().String(), d).Fields("id,name")
+		created, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+			return call.Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.DriveID = created.Id
+		finish(create, created.Id, nil)
+	}
+
+	if r := spec.Restrictions; r != nil {
+		s := step("restrictions", restrictionTarget(r))
+		if !opts.DryRun {
+			call := m.client.Service().Drives.Update(result.DriveID, &drive.Drive{Restrictions: r.toAPI()}).Fields("id")
+			_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+				return call.Do()
+			})
+			finish(s, result.DriveID, err)
+		}
+	}
+
+	for _, member := range spec.Members {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		p := member.toAPI()
+		s := step("add-member", fmt.Sprintf("%s %s: %s", p.Type, member.principal(), member.Role))
+		if opts.DryRun {
+			continue
+		}
+		call := m.client.Service().Permissions.Create(result.DriveID, p).
+			SupportsAllDrives(true).
+			SendNotificationEmail(opts.SendNotificationEmail).
+			Fields("id")
+		created, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+			return call.Do()
+		})
+		if err != nil {
+			finish(s, "", err)
+			continue
+		}
+		finish(s, created.Id, nil)
+	}
+
+	m.createTemplateFolders(ctx, reqCtx, result.DriveID, "", spec.Folders, opts.DryRun, step, finish)
+	return result, ctx.Err()
+}
+
+func (m *Manager) createTemplateFolders(ctx context.Context, reqCtx *types.RequestContext, parentID, parentPath string, folders []TemplateFolder,
+	dryRun bool, step func(string, string) *TemplateStep, finish func(*TemplateStep, string, error)) {
+	for _, f := range folders {
+		if ctx.Err() != nil {
+			return
+		}
+		path := parentPath + "/" + f.Name
+		s := step("create-folder", path)
+		folderID := ""
+		if !dryRun {
+			call := m.client.Service().Files.Create(&drive.File{
+				Name:     f.Name,
+				MimeType: utils.MimeTypeFolder,
+				Parents:  []string{parentID},
+			}).SupportsAllDrives(true).Fields("id")
+			created, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+				return call.Do()
+			})
+			if err != nil {
+				finish(s, "", err)
+				skipTemplateFolders(f, path, step)
+				continue
+			}
+			folderID = created.Id
+			finish(s, folderID, nil)
+		}
+
+		for _, l := range f.Labels {
+			ls := step("apply-label", path+" "+l.ID)
+			if dryRun {
+				continue
+			}
+			req := &drive.ModifyLabelsRequest{LabelModifications: []*drive.LabelModification{l.toAPI()}}
+			_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.ModifyLabelsResponse, error) {
+				return m.client.Service().Files.ModifyLabels(folderID, req).Do()
+			})
+			finish(ls, l.ID, err)
+		}
+		m.createTemplateFolders(ctx, reqCtx, folderID, path, f.Folders, dryRun, step, finish)
+	}
+}
+
+// skipTemplateFolders records the labels and subfolders of a folder that
+// could not be created as skipped
+func skipTemplateFolders(f TemplateFolder, path string, step func(string, string) *TemplateStep) {
+	for _, l := range f.Labels {
+		step("apply-label", path+" "+l.ID).Status = "skipped"
+	}
+	for _, sub := range f.Folders {
+		subPath := path + "/" + sub.Name
+		step("create-folder", subPath).Status = "skipped"
+		skipTemplateFolders(sub, subPath, step)
+	}
+}
+
+func (r *TemplateRestrictions) toAPI() *drive.DriveRestrictions {
+	out := &drive.DriveRestrictions{}
+	set := func(field string, v *bool, dst *bool) {
+		if v == nil {
+			return
+		}
+		*dst = *v
+		// Send explicit false values, which are otherwise omitted
+		out.ForceSendFields = append(out.ForceSendFields, field)
+	}
+	set("AdminManagedRestrictions", r.AdminManagedRestrictions, &out.AdminManagedRestrictions)
+	set("CopyRequiresWriterPermission", r.CopyRequiresWriterPermission, &out.CopyRequiresWriterPermission)
+	set("DomainUsersOnly", r.DomainUsersOnly, &out.DomainUsersOnly)
+	set("DriveMembersOnly", r.DriveMembersOnly, &out.DriveMembersOnly)
+	set("SharingFoldersRequiresOrganizerPermission", r.SharingFoldersRequiresOrganizerPermission, &out.SharingFoldersRequiresOrganizerPermission)
+	return out
+}
+
+// restrictionTarget summarizes the restrictions a spec sets
+func restrictionTarget(r *TemplateRestrictions) string {
+	var parts []string
+	add := func(name string, v *bool) {
+		if v != nil {
+			parts = append(parts, fmt.Sprintf("%s=%t", name, *v))
+		}
+	}
+	add("adminManagedRestrictions", r.AdminManagedRestrictions)
+	add("copyRequiresWriterPermission", r.CopyRequiresWriterPermission)
+	add("domainUsersOnly", r.DomainUsersOnly)
+	add("driveMembersOnly", r.DriveMembersOnly)
+	add("sharingFoldersRequiresOrganizerPermission", r.SharingFoldersRequiresOrganizerPermission)
+	return strings.Join(parts, ",")
+}
+
+func (m TemplateMember) principal() string {
+	switch {
+	case m.User != "":
+		return m.User
+	case m.Group != "":
+		return m.Group
+	default:
+		return m.Domain
+	}
+}
+
+func (m TemplateMember) toAPI() *drive.Permission {
+	switch {
+	case m.User != "":
+		return &drive.Permission{Type: types.PermissionTypeUser, EmailAddress: m.User, Role: m.Role}
+	case m.Group != "":
+		return &drive.Permission{Type: types.PermissionTypeGroup, EmailAddress: m.Group, Role: m.Role}
+	default:
+		return &drive.Permission{Type: types.PermissionTypeDomain, Domain: m.Domain, Role: m.Role}
+	}
+}
+
+func (l TemplateLabel) toAPI() *drive.LabelModification {
+	mod := &drive.LabelModification{LabelId: l.ID}
+	ids := make([]string, 0, len(l.Fields))
+	for id := range l.Fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		f := l.Fields[id]
+		mod.FieldModifications = append(mod.FieldModifications, &drive.LabelFieldModification{
+			FieldId:            id,
+			SetTextValues:      f.Text,
+			SetIntegerValues:   f.Integer,
+			SetDateValues:      f.Date,
+			SetSelectionValues: f.Selection,
+			SetUserValues:      f.User,
+		})
+	}
+	return mod
+}
+
+func (r *TemplateResult) Headers() []string {
+	return []string{"Action", "Target", "ID", "Status"}
+}
+
+func (r *TemplateResult) Rows() [][]string {
+	rows := make([][]string, len(r.Steps))
+	for i, s := range r.Steps {
+		status := s.Status
+		if s.Error != "" {
+			status += ": " + s.Error
+		}
+		rows[i] = []string{s.Action, s.Target, s.ID, status}
+	}
+	return rows
+}
+
+func (r *TemplateResult) EmptyMessage() string {
+	return "Nothing to provision"
+}
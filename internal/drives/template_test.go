@@ -0,0 +1,162 @@
+package drives
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+const testTemplateSpec = `
+name: Finance
+restrictions:
+  domainUsersOnly: true
+  copyRequiresWriterPermission: false
+members:
+  - group: finance-leads@example.com
+    role: organizer
+  - domain: example.com
+    role: reader
+folders:
+  - name: Contracts
+    labels:
+      - id: label1
+        fields:
+          status: {selection: [active]}
+    folders:
+      - name: Signed
+  - name: Broken
+    folders:
+      - name: Never
+`
+
+func TestParseTemplateSpec(t *testing.T) {
+	spec, err := ParseTemplateSpec(strings.NewReader(testTemplateSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "Finance" || len(spec.Members) != 2 || len(spec.Folders) != 2 {
+		t.Fatalf("spec = %+v", spec)
+	}
+	if r := spec.Restrictions; r.DomainUsersOnly == nil || !*r.DomainUsersOnly || r.CopyRequiresWriterPermission == nil || *r.CopyRequiresWriterPermission {
+		t.Errorf("restrictions = %+v", r)
+	}
+	if got := spec.Folders[0].Labels[0].Fields["status"].Selection; len(got) != 1 || got[0] != "active" {
+		t.Errorf("label selection = %v", got)
+	}
+
+	invalid := map[string]string{
+		"no name":          "members: []",
+		"unknown key":      "name: X\nrestrictions:\n  domainOnly: true",
+		"two principals":   "name: X\nmembers:\n  - user: a@example.com\n    group: b@example.com\n    role: reader",
+		"owner role":       "name: X\nmembers:\n  - user: a@example.com\n    role: owner",
+		"slash in folder":  "name: X\nfolders:\n  - name: a/b",
+		"duplicate":        "name: X\nfolders:\n  - name: A\n  - name: A",
+		"label without id": "name: X\nfolders:\n  - name: A\n    labels:\n      - fields: {}",
+	}
+	for name, spec := range invalid {
+		if _, err := ParseTemplateSpec(strings.NewReader(spec)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCreateFromTemplate(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	bodies := map[string]string{}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		bodies[r.Method+" "+r.URL.Path] += string(body)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/drive/v3/drives" && r.Method == http.MethodPost:
+			if r.URL.Query().Get("requestId") == "" {
+				t.Error("drive created without a requestId")
+			}
+			_, _ = w.Write([]byte(`{"id":"d1","name":"Finance"}`))
+		case r.URL.Path == "/drive/v3/drives/d1":
+			_, _ = w.Write([]byte(`{"id":"d1"}`))
+		case r.URL.Path == "/drive/v3/files/d1/permissions":
+			_, _ = w.Write([]byte(`{"id":"perm"}`))
+		case r.URL.Path == "/drive/v3/files" && strings.Contains(string(body), `"Broken"`):
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"denied","errors":[{"reason":"insufficientFilePermissions"}]}}`))
+		case r.URL.Path == "/drive/v3/files":
+			var f drive.File
+			_ = json.Unmarshal(body, &f)
+			_, _ = w.Write([]byte(`{"id":"` + strings.ToLower(f.Name) + `"}`))
+		case r.URL.Path == "/drive/v3/files/contracts/modifyLabels":
+			_, _ = w.Write([]byte(`{"modifiedLabels":[{"id":"label1"}]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	spec, err := ParseTemplateSpec(strings.NewReader(testTemplateSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := mgr.CreateFromTemplate(ctx, reqCtx, spec, TemplateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DriveID != "d1" || result.Failed != 1 {
+		t.Errorf("result = %+v", result)
+	}
+
+	var got []string
+	for _, s := range result.Steps {
+		got = append(got, s.Action+" "+s.Target+" "+s.Status)
+	}
+	want := []string{
+		"create-drive Finance done",
+		"restrictions copyRequiresWriterPermission=false,domainUsersOnly=true done",
+		"add-member group finance-leads@example.com: organizer done",
+		"add-member domain example.com: reader done",
+		"create-folder /Contracts done",
+		"apply-label /Contracts label1 done",
+		"create-folder /Contracts/Signed done",
+		"create-folder /Broken failed",
+		"create-folder /Broken/Never skipped",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("steps:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if b := bodies["PATCH /drive/v3/drives/d1"]; !strings.Contains(b, `"copyRequiresWriterPermission":false`) {
+		t.Errorf("explicit false restriction not sent: %s", b)
+	}
+	if b := bodies["POST /drive/v3/files"]; !strings.Contains(b, `"parents":["contracts"]`) {
+		t.Errorf("subfolder not created under its parent: %s", b)
+	}
+
+	// A dry run plans the same steps without calling the API
+	calls = nil
+	result, err = mgr.CreateFromTemplate(ctx, reqCtx, spec, TemplateOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("dry run made calls: %v", calls)
+	}
+	if len(result.Steps) != len(want) || result.Steps[len(want)-1].Status != "planned" {
+		t.Errorf("dry run steps = %d, last %+v", len(result.Steps), result.Steps[len(result.Steps)-1])
+	}
+}
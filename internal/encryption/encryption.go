@@ -0,0 +1,187 @@
+// Package encryption implements client-side encryption of file contents
+// with AES-256-GCM, so Drive only ever stores ciphertext.
+//
+// The format is a header followed by fixed-size sealed chunks. Each chunk's
+// nonce is a random per-file prefix, the chunk counter and a final-chunk
+// flag, so chunks cannot be reordered, dropped or truncated without failing
+// authentication. The header is authenticated as additional data.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Algorithm names the format in file metadata
+const Algorithm = "aes-256-gcm-v1"
+
+// KeySize is the key length in bytes
+const KeySize = 32
+
+// ChunkSize is the plaintext size of every chunk but the last
+const ChunkSize = 64 * 1024
+
+const (
+	magic       = "GDRVENC1"
+	prefixSize  = 7
+	headerSize  = len(magic) + prefixSize
+	overhead    = 16 // GCM tag
+	sealedChunk = ChunkSize + overhead
+)
+
+// ErrAuthentication is returned when ciphertext was modified, truncated or
+// encrypted with a different key
+var ErrAuthentication = errors.New("decryption failed: wrong key or corrupted data")
+
+// Key is an AES-256 key
+type Key struct {
+	aead cipher.AEAD
+	id   string
+}
+
+// NewKey creates a key from 32 raw bytes
+func NewKey(raw []byte) (*Key, error) {
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &Key{aead: aead, id: hex.EncodeToString(sum[:8])}, nil
+}
+
+// LoadKey reads a key file holding 32 raw bytes, or the key encoded as
+// base64 or hex (e.g. from "openssl rand -base64 32")
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if len(data) == KeySize {
+		return NewKey(data)
+	}
+	text := strings.TrimSpace(string(data))
+	if raw, err := hex.DecodeString(text); err == nil && len(raw) == KeySize {
+		return NewKey(raw)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(text); err == nil && len(raw) == KeySize {
+		return NewKey(raw)
+	}
+	return nil, fmt.Errorf("key file %s must hold %d bytes, raw or as hex or base64", path, KeySize)
+}
+
+// ID identifies the key without revealing it, so a download can tell a
+// wrong key from corrupted data
+func (k *Key) ID() string {
+	return k.id
+}
+
+// EncryptedSize returns the ciphertext size for plaintext of the given size
+func EncryptedSize(size int64) int64 {
+	chunks := (size + ChunkSize - 1) / ChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	return int64(headerSize) + size + chunks*overhead
+}
+
+func nonce(prefix []byte, counter uint32, last bool) []byte {
+	n := make([]byte, 12)
+	copy(n, prefix)
+	binary.BigEndian.PutUint32(n[prefixSize:], counter)
+	if last {
+		n[11] = 1
+	}
+	return n
+}
+
+// Encrypt reads plaintext from r and writes ciphertext to w
+func Encrypt(w io.Writer, r io.Reader, key *Key) error {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	if _, err := rand.Read(header[len(magic):]); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	prefix := header[len(magic):]
+
+	// Read one byte past each chunk to know whether it is the last
+	buf := make([]byte, ChunkSize+1)
+	n, err := io.ReadFull(r, buf)
+	for counter := uint32(0); ; counter++ {
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		last := n <= ChunkSize
+		chunk := buf[:min(n, ChunkSize)]
+		sealed := key.aead.Seal(nil, nonce(prefix, counter, last), chunk, header)
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errors.New("file too large to encrypt")
+		}
+		buf[0] = buf[ChunkSize]
+		n, err = io.ReadFull(r, buf[1:])
+		n++
+	}
+}
+
+// Decrypt reads ciphertext written by Encrypt from r and writes plaintext
+// to w. Data is only written once its chunk has been authenticated, but a
+// failure part way leaves w holding a prefix of the plaintext.
+func Decrypt(w io.Writer, r io.Reader, key *Key) error {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ErrAuthentication
+	}
+	if !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return fmt.Errorf("not an encrypted gdrv file")
+	}
+	prefix := header[len(magic):]
+
+	buf := make([]byte, sealedChunk+1)
+	n, err := io.ReadFull(r, buf)
+	for counter := uint32(0); ; counter++ {
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		last := n <= sealedChunk
+		sealed := buf[:min(n, sealedChunk)]
+		plain, openErr := key.aead.Open(nil, nonce(prefix, counter, last), sealed, header)
+		if openErr != nil {
+			return ErrAuthentication
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		buf[0] = buf[sealedChunk]
+		n, err = io.ReadFull(r, buf[1:])
+		n++
+	}
+}
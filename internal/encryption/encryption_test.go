@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(t *testing.T, b byte) *Key {
+	t.Helper()
+	key, err := NewKey(bytes.Repeat([]byte{b}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	key := testKey(t, 1)
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 17} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		var sealed bytes.Buffer
+		if err := Encrypt(&sealed, bytes.NewReader(plain), key); err != nil {
+			t.Fatalf("size %d: Encrypt: %v", size, err)
+		}
+		if got := int64(sealed.Len()); got != EncryptedSize(int64(size)) {
+			t.Errorf("size %d: ciphertext is %d bytes, EncryptedSize = %d", size, got, EncryptedSize(int64(size)))
+		}
+
+		var out bytes.Buffer
+		if err := Decrypt(&out, bytes.NewReader(sealed.Bytes()), key); err != nil {
+			t.Fatalf("size %d: Decrypt: %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), plain) {
+			t.Errorf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestDecrypt_RejectsTamperingAndWrongKey(t *testing.T) {
+	key := testKey(t, 1)
+	plain := bytes.Repeat([]byte("secret "), ChunkSize/3)
+	var sealed bytes.Buffer
+	if err := Encrypt(&sealed, bytes.NewReader(plain), key); err != nil {
+		t.Fatal(err)
+	}
+	data := sealed.Bytes()
+
+	cases := map[string][]byte{
+		"flipped bit":    append([]byte{}, data...),
+		"truncated":      data[:len(data)-overhead-10],
+		"dropped chunk":  data[:headerSize+sealedChunk],
+		"header changed": append([]byte{}, data...),
+	}
+	cases["flipped bit"][headerSize+5] ^= 1
+	cases["header changed"][len(magic)] ^= 1
+
+	for name, c := range cases {
+		if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(c), key); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: err = %v, want ErrAuthentication", name, err)
+		}
+	}
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(data), testKey(t, 2)); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("wrong key: err = %v, want ErrAuthentication", err)
+	}
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader([]byte("plain text, not encrypted")), key); err == nil {
+		t.Error("plain input should fail")
+	}
+}
+
+func TestLoadKey_Encodings(t *testing.T) {
+	dir := t.TempDir()
+	raw := bytes.Repeat([]byte{7}, KeySize)
+	want := testKey(t, 7).ID()
+
+	files := map[string][]byte{
+		"raw":    raw,
+		"hex":    []byte(hex.EncodeToString(raw) + "\n"),
+		"base64": []byte("BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		key, err := LoadKey(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if key.ID() != want {
+			t.Errorf("%s: key ID = %s, want %s", name, key.ID(), want)
+		}
+	}
+
+	short := filepath.Join(dir, "short")
+	_ = os.WriteFile(short, []byte("too short"), 0600)
+	if _, err := LoadKey(short); err == nil {
+		t.Error("short key should be rejected")
+	}
+}
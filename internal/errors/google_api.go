@@ -1,6 +1,8 @@
 package errors
 
 import (
+	"errors"
+
 	"github.com/dl-alexandre/gdrv/internal/logging"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
@@ -8,6 +10,13 @@ import (
 )
 
 func ClassifyGoogleAPIError(service string, err error, reqCtx *types.RequestContext, logger logging.Logger) error {
+	// Errors raised before the request was sent, such as read-only mode
+	// refusals, arrive wrapped in a *url.Error
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
 	apiErr, ok := err.(*googleapi.Error)
 	if !ok {
 		logger.Error("Non-API error",
@@ -48,8 +57,11 @@ func ClassifyGoogleAPIError(service string, err error, reqCtx *types.RequestCont
 				retryable = true
 			case "dailyLimitExceeded":
 				code = utils.ErrCodeRateLimited
-			case "domainPolicy":
+			case "domainPolicy", "publishOutNotPermitted":
 				code = utils.ErrCodePolicyViolation
+			case "teamDriveDomainUsersOnlyRestriction", "teamDriveTeamMembersOnlyRestriction",
+				"cannotShareTeamDriveWithNonGoogleAccounts":
+				code = utils.ErrCodeSharingRestricted
 			}
 		}
 	case 404:
@@ -100,6 +112,16 @@ func ClassifyGoogleAPIError(service string, err error, reqCtx *types.RequestCont
 			builder.WithContext("capability", "write_access_required")
 		case "domainPolicy":
 			builder.WithContext("suggestedAction", "contact domain administrator")
+		case "publishOutNotPermitted":
+			builder.WithContext("suggestedAction", "sharing outside the domain is disabled; ask a Workspace administrator to allow external sharing in Admin console > Apps > Google Workspace > Drive and Docs > Sharing settings")
+		case "invalidSharingRequest":
+			builder.WithContext("suggestedAction", "the requested sharing is not allowed for this file; check the domain sharing settings and whether link sharing is permitted for this role")
+		case "teamDriveDomainUsersOnlyRestriction":
+			builder.WithContext("suggestedAction", "this shared drive only allows sharing with users in its domain; a manager can change this under the shared drive's sharing settings")
+		case "teamDriveTeamMembersOnlyRestriction":
+			builder.WithContext("suggestedAction", "this shared drive only allows sharing with its members; a manager can change this under the shared drive's sharing settings")
+		case "cannotShareTeamDriveWithNonGoogleAccounts":
+			builder.WithContext("suggestedAction", "shared drive items cannot be shared with non-Google accounts")
 		}
 	}
 
@@ -145,6 +145,24 @@ func TestClassifyGoogleAPIError_403Errors(t *testing.T) {
 			wantCode:       utils.ErrCodePolicyViolation,
 			wantSuggestion: true,
 		},
+		{
+			name:           "publish out not permitted",
+			reason:         "publishOutNotPermitted",
+			wantCode:       utils.ErrCodePolicyViolation,
+			wantSuggestion: true,
+		},
+		{
+			name:           "shared drive domain users only",
+			reason:         "teamDriveDomainUsersOnlyRestriction",
+			wantCode:       utils.ErrCodeSharingRestricted,
+			wantSuggestion: true,
+		},
+		{
+			name:           "shared drive members only",
+			reason:         "teamDriveTeamMembersOnlyRestriction",
+			wantCode:       utils.ErrCodeSharingRestricted,
+			wantSuggestion: true,
+		},
 	}
 
 	for _, tt := range tests {
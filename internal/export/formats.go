@@ -4,23 +4,24 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
 // Google Workspace MIME types
 const (
-	MimeTypeGoogleDocs         = "application/vnd.google-apps.document"
-	MimeTypeGoogleSheets       = "application/vnd.google-apps.spreadsheet"
-	MimeTypeGoogleSlides       = "application/vnd.google-apps.presentation"
-	MimeTypeGoogleDrawing      = "application/vnd.google-apps.drawing"
-	MimeTypeGoogleForm         = "application/vnd.google-apps.form"
-	MimeTypeGoogleSite         = "application/vnd.google-apps.site"
-	MimeTypeGoogleScript       = "application/vnd.google-apps.script"
-	MimeTypeGoogleJamboard     = "application/vnd.google-apps.jam"
-	MimeTypeGoogleShortcut     = "application/vnd.google-apps.shortcut"
-	MimeTypeGoogleFolder       = "application/vnd.google-apps.folder"
-	MimeTypeGoogleMap          = "application/vnd.google-apps.map"
-	MimeTypeGoogleFusiontable  = "application/vnd.google-apps.fusiontable"
+	MimeTypeGoogleDocs        = "application/vnd.google-apps.document"
+	MimeTypeGoogleSheets      = "application/vnd.google-apps.spreadsheet"
+	MimeTypeGoogleSlides      = "application/vnd.google-apps.presentation"
+	MimeTypeGoogleDrawing     = "application/vnd.google-apps.drawing"
+	MimeTypeGoogleForm        = "application/vnd.google-apps.form"
+	MimeTypeGoogleSite        = "application/vnd.google-apps.site"
+	MimeTypeGoogleScript      = "application/vnd.google-apps.script"
+	MimeTypeGoogleJamboard    = "application/vnd.google-apps.jam"
+	MimeTypeGoogleShortcut    = "application/vnd.google-apps.shortcut"
+	MimeTypeGoogleFolder      = "application/vnd.google-apps.folder"
+	MimeTypeGoogleMap         = "application/vnd.google-apps.map"
+	MimeTypeGoogleFusiontable = "application/vnd.google-apps.fusiontable"
 )
 
 // Export MIME types as per Google's reference
@@ -61,27 +62,48 @@ var exportFormats = map[string][]string{
 	},
 }
 
+// Import conversions for common upload types, a subset of about.get
+// importFormats
+var importFormats = map[string][]string{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": {MimeTypeGoogleDocs},
+	"application/msword":                      {MimeTypeGoogleDocs},
+	"application/vnd.oasis.opendocument.text": {MimeTypeGoogleDocs},
+	"application/rtf":                         {MimeTypeGoogleDocs},
+	"text/html":                               {MimeTypeGoogleDocs},
+	"text/plain":                              {MimeTypeGoogleDocs},
+
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": {MimeTypeGoogleSheets},
+	"application/vnd.ms-excel":                         {MimeTypeGoogleSheets},
+	"application/x-vnd.oasis.opendocument.spreadsheet": {MimeTypeGoogleSheets},
+	"text/csv":                  {MimeTypeGoogleSheets},
+	"text/tab-separated-values": {MimeTypeGoogleSheets},
+
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": {MimeTypeGoogleSlides},
+	"application/vnd.ms-powerpoint":                                             {MimeTypeGoogleSlides},
+	"application/vnd.oasis.opendocument.presentation":                           {MimeTypeGoogleSlides},
+}
+
 // Convenience format mapping
 var convenienceFormats = map[string]string{
-	"pdf":   "application/pdf",
-	"docx":  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
-	"pptx":  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
-	"txt":   "text/plain",
-	"html":  "text/html",
-	"rtf":   "application/rtf",
-	"odt":   "application/vnd.oasis.opendocument.text",
-	"ods":   "application/x-vnd.oasis.opendocument.spreadsheet",
-	"odp":   "application/vnd.oasis.opendocument.presentation",
-	"csv":   "text/csv",
-	"tsv":   "text/tab-separated-values",
-	"zip":   "application/zip",
-	"epub":  "application/epub+zip",
-	"svg":   "image/svg+xml",
-	"png":   "image/png",
-	"jpeg":  "image/jpeg",
-	"jpg":   "image/jpeg",
-	"json":  "application/vnd.google-apps.script+json",
+	"pdf":  "application/pdf",
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"txt":  "text/plain",
+	"html": "text/html",
+	"rtf":  "application/rtf",
+	"odt":  "application/vnd.oasis.opendocument.text",
+	"ods":  "application/x-vnd.oasis.opendocument.spreadsheet",
+	"odp":  "application/vnd.oasis.opendocument.presentation",
+	"csv":  "text/csv",
+	"tsv":  "text/tab-separated-values",
+	"zip":  "application/zip",
+	"epub": "application/epub+zip",
+	"svg":  "image/svg+xml",
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"jpg":  "image/jpeg",
+	"json": "application/vnd.google-apps.script+json",
 }
 
 // IsGoogleWorkspaceFile checks if a MIME type is a Google Workspace file
@@ -92,12 +114,12 @@ func IsGoogleWorkspaceFile(mimeType string) bool {
 // GetConvenienceFormat maps a format shorthand to a MIME type
 func GetConvenienceFormat(formatShorthand string) (string, error) {
 	shorthand := strings.ToLower(formatShorthand)
-	
+
 	// Check if it's already a MIME type
 	if strings.Contains(shorthand, "/") {
 		return formatShorthand, nil
 	}
-	
+
 	// Look up convenience mapping
 	mimeType, ok := convenienceFormats[shorthand]
 	if !ok {
@@ -107,7 +129,7 @@ func GetConvenienceFormat(formatShorthand string) (string, error) {
 			WithContext("supportedFormats", getSupportedShorthands()).
 			Build())
 	}
-	
+
 	return mimeType, nil
 }
 
@@ -119,7 +141,7 @@ func GetAvailableFormats(sourceMimeType string) ([]string, error) {
 			WithContext("sourceMimeType", sourceMimeType).
 			Build())
 	}
-	
+
 	formats, ok := exportFormats[sourceMimeType]
 	if !ok {
 		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
@@ -127,34 +149,40 @@ func GetAvailableFormats(sourceMimeType string) ([]string, error) {
 			WithContext("sourceMimeType", sourceMimeType).
 			Build())
 	}
-	
+
 	return formats, nil
 }
 
 // ValidateExportFormat validates an export format against Google's reference mapping
 func ValidateExportFormat(sourceMimeType, targetMimeType string) error {
+	return ValidateExportFormatIn(BuiltinMatrix(), sourceMimeType, targetMimeType)
+}
+
+// ValidateExportFormatIn validates an export format against a conversion
+// matrix, normally the live one from about.get
+func ValidateExportFormatIn(matrix *types.FormatMatrix, sourceMimeType, targetMimeType string) error {
 	if !IsGoogleWorkspaceFile(sourceMimeType) {
 		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
 			"Export is only supported for Google Workspace files").
 			WithContext("sourceMimeType", sourceMimeType).
 			Build())
 	}
-	
-	availableFormats, ok := exportFormats[sourceMimeType]
-	if !ok {
+
+	availableFormats := matrix.ExportTargets(sourceMimeType)
+	if len(availableFormats) == 0 {
 		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
 			fmt.Sprintf("No export formats available for file type: %s", sourceMimeType)).
 			WithContext("sourceMimeType", sourceMimeType).
 			Build())
 	}
-	
+
 	// Check if target MIME type is supported
 	for _, format := range availableFormats {
 		if format == targetMimeType {
 			return nil
 		}
 	}
-	
+
 	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
 		fmt.Sprintf("Export format '%s' is not supported for source type '%s'", targetMimeType, sourceMimeType)).
 		WithContext("sourceMimeType", sourceMimeType).
@@ -163,6 +191,79 @@ func ValidateExportFormat(sourceMimeType, targetMimeType string) error {
 		Build())
 }
 
+// conversionTargets maps the names accepted by upload --convert-to to the
+// Workspace type to convert to
+var conversionTargets = map[string]string{
+	"document":     MimeTypeGoogleDocs,
+	"doc":          MimeTypeGoogleDocs,
+	"spreadsheet":  MimeTypeGoogleSheets,
+	"sheet":        MimeTypeGoogleSheets,
+	"presentation": MimeTypeGoogleSlides,
+	"slides":       MimeTypeGoogleSlides,
+}
+
+// ParseConversionTarget returns the Workspace type named by target: one of
+// document, spreadsheet or presentation (or doc, sheet, slides), or a
+// Google Workspace MIME type
+func ParseConversionTarget(target string) (string, error) {
+	if mimeType, ok := conversionTargets[strings.ToLower(target)]; ok {
+		return mimeType, nil
+	}
+	if utils.IsWorkspaceMimeType(target) {
+		return target, nil
+	}
+	return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("Unknown conversion target '%s'", target)).
+		WithContext("validTargets", []string{"document", "spreadsheet", "presentation"}).
+		Build())
+}
+
+// ResolveImportFormat returns the Workspace type an upload of
+// sourceMimeType is converted to. An empty targetMimeType selects the first
+// type Drive lists for the source; otherwise it must be one of them.
+func ResolveImportFormat(matrix *types.FormatMatrix, sourceMimeType, targetMimeType string) (string, error) {
+	targets := matrix.ImportTargets(sourceMimeType)
+	if len(targets) == 0 {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnsupportedConversion,
+			fmt.Sprintf("Files of type '%s' cannot be converted to a Google Workspace format", sourceMimeType)).
+			WithContext("sourceMimeType", sourceMimeType).
+			WithContext("suggestedAction", "run 'gdrv about formats' to list supported conversions").
+			Build())
+	}
+	if targetMimeType == "" {
+		return targets[0], nil
+	}
+	for _, target := range targets {
+		if target == targetMimeType {
+			return target, nil
+		}
+	}
+	return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnsupportedConversion,
+		fmt.Sprintf("Files of type '%s' cannot be converted to '%s'", sourceMimeType, targetMimeType)).
+		WithContext("sourceMimeType", sourceMimeType).
+		WithContext("targetMimeType", targetMimeType).
+		WithContext("availableFormats", targets).
+		WithContext("suggestedAction", "convert to one of availableFormats, or upload without conversion").
+		Build())
+}
+
+// BuiltinMatrix returns the reference conversion tables, used when the
+// live matrix from about.get cannot be fetched
+func BuiltinMatrix() *types.FormatMatrix {
+	matrix := &types.FormatMatrix{
+		Source:        types.FormatSourceBuiltin,
+		ImportFormats: make(map[string][]string, len(importFormats)),
+		ExportFormats: make(map[string][]string, len(exportFormats)),
+	}
+	for source, targets := range importFormats {
+		matrix.ImportFormats[source] = append([]string(nil), targets...)
+	}
+	for source, targets := range exportFormats {
+		matrix.ExportFormats[source] = append([]string(nil), targets...)
+	}
+	return matrix
+}
+
 // getSupportedShorthands returns a list of supported format shorthands
 func getSupportedShorthands() []string {
 	shorthands := make([]string, 0, len(convenienceFormats))
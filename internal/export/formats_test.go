@@ -3,6 +3,9 @@ package export
 import (
 	"strings"
 	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
 )
 
 func TestIsGoogleWorkspaceFile(t *testing.T) {
@@ -216,3 +219,66 @@ func TestFormatConvenienceMapping_Property(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateExportFormatIn_LiveMatrix(t *testing.T) {
+	// A live matrix may differ from the built-in table
+	live := &types.FormatMatrix{
+		Source: types.FormatSourceAPI,
+		ExportFormats: map[string][]string{
+			MimeTypeGoogleDocs: {"application/pdf", "text/markdown"},
+		},
+	}
+
+	if err := ValidateExportFormatIn(live, MimeTypeGoogleDocs, "text/markdown"); err != nil {
+		t.Errorf("text/markdown should be accepted from the live matrix: %v", err)
+	}
+	if err := ValidateExportFormatIn(live, MimeTypeGoogleDocs, "application/rtf"); err == nil {
+		t.Error("application/rtf is not in the live matrix and should be rejected")
+	}
+	if err := ValidateExportFormatIn(live, MimeTypeGoogleSheets, "text/csv"); err == nil {
+		t.Error("Sheets has no entry in the live matrix and should be rejected")
+	}
+}
+
+func TestResolveImportFormat(t *testing.T) {
+	matrix := BuiltinMatrix()
+
+	got, err := ResolveImportFormat(matrix, "text/csv", "")
+	if err != nil || got != MimeTypeGoogleSheets {
+		t.Errorf("csv default target = %q, %v", got, err)
+	}
+	_, err = ResolveImportFormat(matrix, "text/csv", MimeTypeGoogleDocs)
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeUnsupportedConversion {
+		t.Errorf("csv to a Doc = %v, want UNSUPPORTED_CONVERSION", err)
+	}
+	if _, err := ResolveImportFormat(matrix, "application/x-tar", ""); err == nil {
+		t.Error("tar has no import conversion")
+	}
+}
+
+func TestParseConversionTarget(t *testing.T) {
+	tests := map[string]string{
+		"document":         MimeTypeGoogleDocs,
+		"Spreadsheet":      MimeTypeGoogleSheets,
+		"slides":           MimeTypeGoogleSlides,
+		MimeTypeGoogleDocs: MimeTypeGoogleDocs,
+	}
+	for target, want := range tests {
+		if got, err := ParseConversionTarget(target); err != nil || got != want {
+			t.Errorf("ParseConversionTarget(%q) = %q, %v; want %q", target, got, err, want)
+		}
+	}
+	for _, target := range []string{"", "pdf", "text/plain"} {
+		if _, err := ParseConversionTarget(target); err == nil {
+			t.Errorf("ParseConversionTarget(%q) should fail", target)
+		}
+	}
+}
+
+func TestBuiltinMatrix_ReturnsCopy(t *testing.T) {
+	matrix := BuiltinMatrix()
+	matrix.ExportFormats[MimeTypeGoogleDocs][0] = "changed"
+	if BuiltinMatrix().ExportFormats[MimeTypeGoogleDocs][0] == "changed" {
+		t.Error("BuiltinMatrix should not expose the package tables")
+	}
+}
@@ -0,0 +1,439 @@
+// Package extract pulls values out of JSON documents with GJSON-style paths,
+// so scripts can select fields from command output without jq.
+//
+// Supported syntax, a subset of GJSON:
+//
+//	files.0.name           object keys and array indexes, separated by dots
+//	files.#                the length of an array
+//	files.#.id             a path applied to every element of an array
+//	files.#(size>1000).id  the first element matching a condition
+//	files.#(name%"*.pdf")# every element matching a condition
+//	na*e, n?me             wildcards in object keys (first match)
+//	a\.b                   a key containing a literal dot
+//
+// Conditions compare a path within the element with a quoted string, a
+// number, true, false or null using ==, !=, <, <=, >, >=, % (wildcard match)
+// or !% (no wildcard match).
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepKey   stepKind = iota // An object key, or an array index when numeric
+	stepCount                 // "#": array length, or map the rest over elements
+	stepQuery                 // "#(cond)" or "#(cond)#"
+)
+
+type step struct {
+	kind stepKind
+	key  string
+	cond *condition
+	all  bool // "#(cond)#" matches every element
+}
+
+type condition struct {
+	path  *Path
+	op    string
+	value interface{} // string, float64, bool or nil
+}
+
+// Path is a parsed extraction path
+type Path struct {
+	raw   string
+	steps []step
+}
+
+// String returns the path as given to Parse
+func (p *Path) String() string {
+	return p.raw
+}
+
+// Parse parses a GJSON-style path
+func Parse(raw string) (*Path, error) {
+	p := &Path{raw: raw}
+	if raw == "" {
+		return p, nil
+	}
+	parts, err := splitPath(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		s, err := parseStep(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", raw, err)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// splitPath splits a path on unescaped dots outside #(...) conditions.
+// Escapes are kept so that parseStep can tell "\*" from a wildcard.
+func splitPath(raw string) ([]string, error) {
+	var parts []string
+	var cur strings.Builder
+	depth, quoted := 0, false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(raw) {
+				return nil, fmt.Errorf("invalid path %q: trailing backslash", raw)
+			}
+			cur.WriteByte(c)
+			cur.WriteByte(raw[i+1])
+			i++
+			continue
+		case quoted:
+			if c == '"' {
+				quoted = false
+			}
+		case c == '"' && depth > 0:
+			quoted = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '.' && depth == 0:
+			parts = append(parts, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteByte(c)
+	}
+	if depth != 0 || quoted {
+		return nil, fmt.Errorf("invalid path %q: unbalanced condition", raw)
+	}
+	return append(parts, cur.String()), nil
+}
+
+func parseStep(part string) (step, error) {
+	switch {
+	case part == "":
+		return step{}, fmt.Errorf("empty path component")
+	case part == "#":
+		return step{kind: stepCount}, nil
+	case strings.HasPrefix(part, "#("):
+		s := step{kind: stepQuery}
+		body := strings.TrimPrefix(part, "#(")
+		if strings.HasSuffix(body, ")#") {
+			s.all = true
+			body = strings.TrimSuffix(body, ")#")
+		} else if strings.HasSuffix(body, ")") {
+			body = strings.TrimSuffix(body, ")")
+		} else {
+			return step{}, fmt.Errorf("condition %q is not closed", part)
+		}
+		cond, err := parseCondition(body)
+		if err != nil {
+			return step{}, err
+		}
+		s.cond = cond
+		return s, nil
+	}
+	return step{kind: stepKey, key: part}, nil
+}
+
+// operators are ordered so that two-character operators match first
+var operators = []string{"==", "!=", "<=", ">=", "!%", "<", ">", "%"}
+
+func parseCondition(body string) (*condition, error) {
+	quoted := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '"' {
+			quoted = !quoted
+		}
+		if quoted {
+			continue
+		}
+		for _, op := range operators {
+			if !strings.HasPrefix(body[i:], op) {
+				continue
+			}
+			left := strings.TrimSpace(body[:i])
+			p, err := Parse(left)
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseValue(strings.TrimSpace(body[i+len(op):]))
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := value.(string); !ok && (op == "%" || op == "!%") {
+				return nil, fmt.Errorf("%s needs a quoted pattern", op)
+			}
+			return &condition{path: p, op: op, value: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("condition %q has no operator", body)
+}
+
+func parseValue(s string) (interface{}, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return v, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q: quote strings", s)
+	}
+	return f, nil
+}
+
+// Decode parses JSON for Get, keeping numbers exact
+func Decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Get returns the value at the path in a document from Decode, and whether
+// it exists
+func (p *Path) Get(doc interface{}) (interface{}, bool) {
+	return get(doc, p.steps)
+}
+
+func get(v interface{}, steps []step) (interface{}, bool) {
+	if len(steps) == 0 {
+		return v, true
+	}
+	s, rest := steps[0], steps[1:]
+	switch s.kind {
+	case stepKey:
+		switch node := v.(type) {
+		case map[string]interface{}:
+			child, ok := lookupKey(node, s.key)
+			if !ok {
+				return nil, false
+			}
+			return get(child, rest)
+		case []interface{}:
+			i, err := strconv.Atoi(unescape(s.key))
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			return get(node[i], rest)
+		}
+		return nil, false
+
+	case stepCount:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		if len(rest) == 0 {
+			return json.Number(strconv.Itoa(len(arr))), true
+		}
+		return mapRest(arr, rest), true
+
+	case stepQuery:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		var matches []interface{}
+		for _, elem := range arr {
+			if s.cond.match(elem) {
+				if !s.all {
+					return get(elem, rest)
+				}
+				matches = append(matches, elem)
+			}
+		}
+		if !s.all {
+			return nil, false
+		}
+		return mapRest(matches, rest), true
+	}
+	return nil, false
+}
+
+// mapRest applies the remaining steps to each element, dropping elements
+// where they do not resolve
+func mapRest(arr []interface{}, rest []step) []interface{} {
+	out := []interface{}{}
+	for _, elem := range arr {
+		if child, ok := get(elem, rest); ok {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+func lookupKey(node map[string]interface{}, key string) (interface{}, bool) {
+	if !hasWildcard(key) {
+		child, ok := node[unescape(key)]
+		return child, ok
+	}
+	keys := make([]string, 0, len(node))
+	for k := range node {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ok, _ := path.Match(key, k); ok {
+			return node[k], true
+		}
+	}
+	return nil, false
+}
+
+func hasWildcard(key string) bool {
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '\\':
+			i++
+		case '*', '?':
+			return true
+		}
+	}
+	return false
+}
+
+func unescape(key string) string {
+	if !strings.Contains(key, `\`) {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\\' && i+1 < len(key) {
+			i++
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
+
+func (c *condition) match(elem interface{}) bool {
+	left, ok := c.path.Get(elem)
+	if !ok {
+		return false
+	}
+	switch want := c.value.(type) {
+	case string:
+		got, ok := left.(string)
+		if !ok {
+			return false
+		}
+		switch c.op {
+		case "%", "!%":
+			matched, _ := path.Match(want, got)
+			return matched == (c.op == "%")
+		}
+		return compare(c.op, strings.Compare(got, want))
+	case float64:
+		n, ok := left.(json.Number)
+		if !ok {
+			return false
+		}
+		got, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		switch {
+		case got < want:
+			return compare(c.op, -1)
+		case got > want:
+			return compare(c.op, 1)
+		}
+		return compare(c.op, 0)
+	default:
+		// Booleans and null only support equality
+		equal := left == c.value
+		switch c.op {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		}
+		return false
+	}
+}
+
+func compare(op string, cmp int) bool {
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// Format renders an extracted value for scripts: strings without quotes,
+// arrays of scalars one element per line, and objects or nested arrays as
+// indented JSON
+func Format(v interface{}) (string, error) {
+	if arr, ok := v.([]interface{}); ok && allScalars(arr) {
+		lines := make([]string, len(arr))
+		for i, elem := range arr {
+			lines[i] = formatScalar(elem)
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	}
+	return formatScalar(v), nil
+}
+
+func allScalars(arr []interface{}) bool {
+	for _, elem := range arr {
+		switch elem.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func formatScalar(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return s
+	}
+	return fmt.Sprint(v)
+}
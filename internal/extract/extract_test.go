@@ -0,0 +1,85 @@
+package extract
+
+import (
+	"testing"
+)
+
+const doc = `{
+  "files": [
+    {"id": "a", "name": "report.pdf", "size": 2048, "starred": true, "owners": [{"email": "x@example.com"}]},
+    {"id": "b", "name": "notes.txt", "size": 10, "starred": false},
+    {"id": "c", "name": "big.pdf", "size": 9007199254740993}
+  ],
+  "nextPageToken": "",
+  "a.b": "dotted"
+}`
+
+func TestGet(t *testing.T) {
+	parsed, err := Decode([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"files.#", "3"},
+		{"files.#.id", "a\nb\nc"},
+		{"files.1.name", "notes.txt"},
+		{"files.2.size", "9007199254740993"},
+		{"files.#(size>100).id", "a"},
+		{"files.#(size>100)#.id", "a\nc"},
+		{`files.#(name%"*.pdf")#.name`, "report.pdf\nbig.pdf"},
+		{`files.#(name!%"*.pdf").id`, "b"},
+		{"files.#(starred==true).id", "a"},
+		{`files.#(id=="b").starred`, "false"},
+		{"files.#.owners.0.email", "x@example.com"},
+		{"files.0.own*.0.email", "x@example.com"},
+		{`a\.b`, "dotted"},
+		{"nextPageToken", ""},
+		{"files.#(size<0)#", ""},
+		{"files.0.owners", "[\n  {\n    \"email\": \"x@example.com\"\n  }\n]"},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.path)
+		if err != nil {
+			t.Errorf("%s: %v", c.path, err)
+			continue
+		}
+		value, ok := p.Get(parsed)
+		if !ok {
+			t.Errorf("%s: not found", c.path)
+			continue
+		}
+		got, err := Format(value)
+		if err != nil || got != c.want {
+			t.Errorf("%s = %q, %v; want %q", c.path, got, err, c.want)
+		}
+	}
+
+	for _, path := range []string{"missing", "files.9", "files.#(id==\"z\").name", "files.0.name.x"} {
+		p, err := Parse(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := p.Get(parsed); ok {
+			t.Errorf("%s: found %v", path, v)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, path := range []string{
+		"files..id",
+		"files.#(size>1",
+		"files.#(size)",
+		"files.#(name%1)",
+		"files.#(name==abc)",
+		`files\`,
+	} {
+		if _, err := Parse(path); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package fieldmask audits which Drive API response fields a command
+// actually uses, and derives minimal field masks from the results.
+package fieldmask
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// Recorder collects the field paths present in API responses. Paths are
+// dot-separated JSON names, e.g. "files.capabilities.canEdit".
+type Recorder struct {
+	mu       sync.Mutex
+	calls    int
+	returned map[string]bool
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{returned: map[string]bool{}}
+}
+
+// Record adds the non-empty fields of an API response. Values that are not
+// API schema types (e.g. raw HTTP responses) contribute nothing.
+func (r *Recorder) Record(response interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	collectPaths(reflect.ValueOf(response), "", r.returned)
+}
+
+// Audit compares the recorded fields with a command's output. A returned
+// field counts as used when its name appears anywhere in the output.
+func (r *Recorder) Audit(command string, output interface{}) *types.FieldsAuditEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outputKeys := map[string]bool{}
+	if data, err := json.Marshal(output); err == nil {
+		var decoded interface{}
+		if json.Unmarshal(data, &decoded) == nil {
+			collectKeys(decoded, outputKeys)
+		}
+	}
+
+	entry := &types.FieldsAuditEntry{
+		Command:    command,
+		Calls:      r.calls,
+		Returned:   []string{},
+		Used:       []string{},
+		Unused:     []string{},
+		RecordedAt: time.Now().UTC(),
+	}
+	for path := range r.returned {
+		entry.Returned = append(entry.Returned, path)
+		if outputKeys[path[strings.LastIndex(path, ".")+1:]] {
+			entry.Used = append(entry.Used, path)
+		} else {
+			entry.Unused = append(entry.Unused, path)
+		}
+	}
+	sort.Strings(entry.Returned)
+	sort.Strings(entry.Used)
+	sort.Strings(entry.Unused)
+	entry.SuggestedMask = Mask(entry.Used)
+	return entry
+}
+
+// Mask renders field paths in Drive field-mask syntax, grouping nested
+// fields under their parent: "nextPageToken,files(id,capabilities(canEdit))"
+func Mask(paths []string) string {
+	children := map[string][]string{}
+	var heads []string
+	for _, path := range paths {
+		head, rest, nested := strings.Cut(path, ".")
+		if _, seen := children[head]; !seen {
+			heads = append(heads, head)
+			children[head] = nil
+		}
+		if nested {
+			children[head] = append(children[head], rest)
+		}
+	}
+	sort.Strings(heads)
+
+	parts := make([]string, len(heads))
+	for i, head := range heads {
+		parts[i] = head
+		if len(children[head]) > 0 {
+			parts[i] += "(" + Mask(children[head]) + ")"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// SubMask returns the mask for fields nested under prefix, e.g. the
+// per-file fields of a files.list response for prefix "files"
+func SubMask(paths []string, prefix string) string {
+	var nested []string
+	for _, path := range paths {
+		if rest, ok := strings.CutPrefix(path, prefix+"."); ok {
+			nested = append(nested, rest)
+		}
+	}
+	return Mask(nested)
+}
+
+// collectPaths walks an API schema value, recording the path of every
+// non-empty leaf field. Only struct fields with JSON names are followed.
+func collectPaths(v reflect.Value, prefix string, paths map[string]bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := field.Type
+		value := v.Field(i)
+		switch {
+		case value.IsZero():
+		case isStructType(fv):
+			collectPaths(value, path, paths)
+		case fv.Kind() == reflect.Slice && isStructType(fv.Elem()):
+			for j := 0; j < value.Len(); j++ {
+				collectPaths(value.Index(j), path, paths)
+			}
+		case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map:
+			if value.Len() > 0 {
+				paths[path] = true
+			}
+		default:
+			paths[path] = true
+		}
+	}
+}
+
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func collectKeys(v interface{}, keys map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			keys[k] = true
+			collectKeys(child, keys)
+		}
+	case []interface{}:
+		for _, child := range val {
+			collectKeys(child, keys)
+		}
+	}
+}
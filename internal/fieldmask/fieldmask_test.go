@@ -0,0 +1,89 @@
+package fieldmask
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestRecorderAudit(t *testing.T) {
+	r := NewRecorder()
+	r.Record(&drive.FileList{
+		Kind:          "drive#fileList",
+		NextPageToken: "tok",
+		Files: []*drive.File{
+			{Id: "1", Name: "a", MimeType: "text/plain", Capabilities: &drive.FileCapabilities{CanEdit: true}},
+			{Id: "2", Name: "b", IconLink: "https://example.com/icon", ExportLinks: map[string]string{"application/pdf": "u"}},
+		},
+	})
+	r.Record(&http.Response{StatusCode: 200})
+
+	output := map[string]interface{}{
+		"files": []*types.DriveFile{{ID: "1", Name: "a", MimeType: "text/plain",
+			Capabilities: &types.FileCapabilities{CanEdit: true}}},
+		"nextPageToken": "tok",
+	}
+	entry := r.Audit("files.list", output)
+
+	if entry.Calls != 2 {
+		t.Errorf("Calls = %d, want 2", entry.Calls)
+	}
+	wantUsed := []string{"files.capabilities.canEdit", "files.id", "files.mimeType", "files.name", "nextPageToken"}
+	if !reflect.DeepEqual(entry.Used, wantUsed) {
+		t.Errorf("Used = %v, want %v", entry.Used, wantUsed)
+	}
+	wantUnused := []string{"files.exportLinks", "files.iconLink", "kind"}
+	if !reflect.DeepEqual(entry.Unused, wantUnused) {
+		t.Errorf("Unused = %v, want %v", entry.Unused, wantUnused)
+	}
+	if want := "files(capabilities(canEdit),id,mimeType,name),nextPageToken"; entry.SuggestedMask != want {
+		t.Errorf("SuggestedMask = %q, want %q", entry.SuggestedMask, want)
+	}
+}
+
+func TestSubMask(t *testing.T) {
+	paths := []string{"files.id", "files.owners.emailAddress", "nextPageToken"}
+	if got := SubMask(paths, "files"); got != "id,owners(emailAddress)" {
+		t.Errorf("SubMask = %q", got)
+	}
+	if got := SubMask(paths, "drives"); got != "" {
+		t.Errorf("SubMask for missing prefix = %q", got)
+	}
+}
+
+func TestStoreMerge(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), StoreFileName))
+	if mask := store.LearnedMask("files.list", "files"); mask != "" {
+		t.Errorf("empty store mask = %q", mask)
+	}
+
+	first := &types.FieldsAuditEntry{Command: "files.list",
+		Returned: []string{"files.id", "files.description", "kind"},
+		Used:     []string{"files.id", "files.description"}}
+	if _, err := store.Merge(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run where no file had a description keeps the field
+	second := &types.FieldsAuditEntry{Command: "files.list",
+		Returned: []string{"files.id", "files.name", "kind"},
+		Used:     []string{"files.id", "files.name"},
+		Unused:   []string{"kind"}}
+	merged, err := store.Merge(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"files.description", "files.id", "files.name"}; !reflect.DeepEqual(merged.Used, want) {
+		t.Errorf("Used = %v, want %v", merged.Used, want)
+	}
+	if !reflect.DeepEqual(merged.Unused, []string{"kind"}) {
+		t.Errorf("Unused = %v", merged.Unused)
+	}
+	if mask := store.LearnedMask("files.list", "files"); mask != "description,id,name" {
+		t.Errorf("LearnedMask = %q", mask)
+	}
+}
@@ -0,0 +1,136 @@
+package fieldmask
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// StoreFileName is the file learned field masks are kept in, inside the
+// config directory
+const StoreFileName = "fields-audit.json"
+
+// Store persists fields-audit results per command
+type Store struct {
+	path string
+}
+
+type storeFile struct {
+	state.Versioned
+	Commands map[string]*types.FieldsAuditEntry `json:"commands"`
+}
+
+// StateKind versions the fields-audit store
+var StateKind = state.Register(&state.Kind{
+	Name:        "fields-audit",
+	Description: "Field masks learned by --fields-audit",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: StoreFileName,
+})
+
+// NewStore creates a store backed by path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store in the gdrv config directory
+func DefaultStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, StoreFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get returns the stored audit for a command, or nil if there is none
+func (s *Store) Get(command string) (*types.FieldsAuditEntry, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return file.Commands[command], nil
+}
+
+// LearnedMask returns the minimal mask for fields nested under prefix
+// learned for a command, or "" if the command has not been audited
+func (s *Store) LearnedMask(command, prefix string) string {
+	entry, err := s.Get(command)
+	if err != nil || entry == nil {
+		return ""
+	}
+	return SubMask(entry.Used, prefix)
+}
+
+// Merge saves an audit, keeping fields used in earlier audits of the same
+// command. Fields are only returned when non-empty, so a single run can
+// miss fields that later runs need.
+func (s *Store) Merge(entry *types.FieldsAuditEntry) (*types.FieldsAuditEntry, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if prev := file.Commands[entry.Command]; prev != nil {
+		entry.Used = union(entry.Used, prev.Used)
+		entry.Returned = union(entry.Returned, prev.Returned)
+		used := map[string]bool{}
+		for _, path := range entry.Used {
+			used[path] = true
+		}
+		entry.Unused = entry.Unused[:0]
+		for _, path := range entry.Returned {
+			if !used[path] {
+				entry.Unused = append(entry.Unused, path)
+			}
+		}
+		entry.SuggestedMask = Mask(entry.Used)
+	}
+	file.Commands[entry.Command] = entry
+
+	if err := state.Save(StateKind, s.path, file); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *Store) load() (*storeFile, error) {
+	file := &storeFile{Commands: map[string]*types.FieldsAuditEntry{}}
+	if _, err := state.Load(StateKind, s.path, file); err != nil {
+		return nil, err
+	}
+	if file.Commands == nil {
+		file.Commands = map[string]*types.FieldsAuditEntry{}
+	}
+	return file, nil
+}
+
+func union(a, b []string) []string {
+	set := map[string]bool{}
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		set[s] = true
+	}
+	out := make([]string, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
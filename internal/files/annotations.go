@@ -0,0 +1,223 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// maxPropertyBytes is Drive's limit on the combined size of an
+// appProperties key and value
+const maxPropertyBytes = 124
+
+const annotationFields = "id,name,mimeType,description,appProperties"
+
+// MetadataUpdate configures a metadata-only file update. Nil fields are
+// left unchanged; an empty Description clears it.
+type MetadataUpdate struct {
+	Name        *string
+	Description *string
+}
+
+// AnnotationSearchOptions selects files by name, description and
+// appProperties. All given criteria must match.
+type AnnotationSearchOptions struct {
+	ParentID            string
+	NameContains        string
+	DescriptionContains string
+	Properties          map[string]string
+	Limit               int // 0 = no limit
+}
+
+// UpdateMetadata updates a file's name and/or description
+func (m *Manager) UpdateMetadata(ctx context.Context, reqCtx *types.RequestContext, fileID string, update MetadataUpdate) (*types.DriveFile, error) {
+	metadata := &drive.File{}
+	if update.Name != nil {
+		if *update.Name == "" {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"File name cannot be empty").Build())
+		}
+		metadata.Name = *update.Name
+	}
+	if update.Description != nil {
+		metadata.Description = *update.Description
+		if metadata.Description == "" {
+			metadata.NullFields = append(metadata.NullFields, "Description")
+		}
+	}
+	if update.Name == nil && update.Description == nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Nothing to update").Build())
+	}
+
+	required := []Capability{CapabilityEdit}
+	if update.Name != nil {
+		required = append(required, CapabilityRename)
+	}
+	if _, err := m.RequireCapabilities(ctx, reqCtx, fileID, required...); err != nil {
+		return nil, err
+	}
+	return m.Update(ctx, reqCtx, fileID, metadata, annotationFields)
+}
+
+// SetStarred stars or unstars a file for the current user
+func (m *Manager) SetStarred(ctx context.Context, reqCtx *types.RequestContext, fileID string, starred bool) (*types.DriveFile, error) {
+	// Starred is omitted from the request body when false unless forced
+	metadata := &drive.File{Starred: starred, ForceSendFields: []string{"Starred"}}
+	return m.Update(ctx, reqCtx, fileID, metadata, "id,name,mimeType,starred")
+}
+
+// GetProperties returns a file's appProperties
+func (m *Manager) GetProperties(ctx context.Context, reqCtx *types.RequestContext, fileID string) (*types.FileProperties, error) {
+	file, err := m.Get(ctx, reqCtx, fileID, "id,name,appProperties")
+	if err != nil {
+		return nil, err
+	}
+	return newFileProperties(file), nil
+}
+
+// SetProperties adds or replaces appProperties on a file. Properties not
+// named are left unchanged.
+func (m *Manager) SetProperties(ctx context.Context, reqCtx *types.RequestContext, fileID string, props map[string]string) (*types.FileProperties, error) {
+	if len(props) == 0 {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"At least one property is required").Build())
+	}
+	for key, value := range props {
+		if err := validateProperty(key, value); err != nil {
+			return nil, err
+		}
+	}
+	file, err := m.Update(ctx, reqCtx, fileID, &drive.File{AppProperties: props}, "id,name,appProperties")
+	if err != nil {
+		return nil, err
+	}
+	return newFileProperties(file), nil
+}
+
+// DeleteProperties removes appProperties from a file
+func (m *Manager) DeleteProperties(ctx context.Context, reqCtx *types.RequestContext, fileID string, keys []string) (*types.FileProperties, error) {
+	if len(keys) == 0 {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"At least one property key is required").Build())
+	}
+	// Map keys in NullFields are only sent when the map itself is forced
+	metadata := &drive.File{ForceSendFields: []string{"AppProperties"}}
+	for _, key := range keys {
+		metadata.NullFields = append(metadata.NullFields, "AppProperties."+key)
+	}
+	file, err := m.Update(ctx, reqCtx, fileID, metadata, "id,name,appProperties")
+	if err != nil {
+		return nil, err
+	}
+	return newFileProperties(file), nil
+}
+
+// SearchAnnotations finds files by name, description and appProperties.
+// Drive cannot query descriptions directly, so a fullText search narrows
+// the candidates and descriptions are matched locally.
+func (m *Manager) SearchAnnotations(ctx context.Context, reqCtx *types.RequestContext, opts AnnotationSearchOptions) (*types.FileListResult, error) {
+	q := annotationQuery(opts)
+	if q == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"At least one search criterion is required").Build())
+	}
+	return m.searchAnnotations(ctx, reqCtx, q, opts)
+}
+
+// searchAnnotations pages through the files matching q, keeping those
+// whose description matches
+func (m *Manager) searchAnnotations(ctx context.Context, reqCtx *types.RequestContext, q string, opts AnnotationSearchOptions) (*types.FileListResult, error) {
+	listOpts := ListOptions{
+		ParentID: opts.ParentID,
+		Query:    q,
+		PageSize: 100,
+		Fields:   annotationFields + ",modifiedTime,parents",
+	}
+	result := &types.FileListResult{Files: []*types.DriveFile{}}
+	for {
+		page, err := m.List(ctx, reqCtx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		result.IncompleteSearch = result.IncompleteSearch || page.IncompleteSearch
+		for _, f := range page.Files {
+			if !descriptionMatches(f.Description, opts.DescriptionContains) {
+				continue
+			}
+			result.Files = append(result.Files, f)
+			if opts.Limit > 0 && len(result.Files) >= opts.Limit {
+				return result, nil
+			}
+		}
+		if page.NextPageToken == "" {
+			return result, nil
+		}
+		listOpts.PageToken = page.NextPageToken
+	}
+}
+
+// ParseProperties parses "key=value" pairs into a property map
+func ParseProperties(pairs []string) (map[string]string, error) {
+	props := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid property %q, expected key=value", pair)).Build())
+		}
+		props[key] = value
+	}
+	return props, nil
+}
+
+func annotationQuery(opts AnnotationSearchOptions) string {
+	b := query.NewBuilder()
+	if opts.NameContains != "" {
+		b.Where("name", "contains", opts.NameContains)
+	}
+	if opts.DescriptionContains != "" {
+		b.Where("fullText", "contains", opts.DescriptionContains)
+	}
+	keys := make([]string, 0, len(opts.Properties))
+	for key := range opts.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.HasProperty("appProperties", key, opts.Properties[key])
+	}
+	return b.String()
+}
+
+func descriptionMatches(description, substr string) bool {
+	return substr == "" || strings.Contains(strings.ToLower(description), strings.ToLower(substr))
+}
+
+func validateProperty(key, value string) error {
+	if key == "" {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Property key cannot be empty").Build())
+	}
+	if len(key)+len(value) > maxPropertyBytes {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Property %q is too long: key and value must total at most %d bytes", key, maxPropertyBytes)).
+			WithContext("key", key).
+			Build())
+	}
+	return nil
+}
+
+func newFileProperties(file *types.DriveFile) *types.FileProperties {
+	props := file.AppProperties
+	if props == nil {
+		props = map[string]string{}
+	}
+	return &types.FileProperties{FileID: file.ID, Name: file.Name, Properties: props}
+}
@@ -0,0 +1,129 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestParseProperties(t *testing.T) {
+	props, err := ParseProperties([]string{"project=apollo", "note=a=b", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["project"] != "apollo" || props["note"] != "a=b" || props["empty"] != "" {
+		t.Errorf("ParseProperties = %v", props)
+	}
+
+	for _, bad := range []string{"novalue", "=value"} {
+		if _, err := ParseProperties([]string{bad}); err == nil {
+			t.Errorf("ParseProperties(%q) should fail", bad)
+		}
+	}
+}
+
+func TestValidateProperty(t *testing.T) {
+	if err := validateProperty("k", strings.Repeat("v", maxPropertyBytes-1)); err != nil {
+		t.Errorf("property at the limit rejected: %v", err)
+	}
+	if err := validateProperty("k", strings.Repeat("v", maxPropertyBytes)); err == nil {
+		t.Error("property over the limit accepted")
+	}
+}
+
+func TestAnnotationQuery(t *testing.T) {
+	got := annotationQuery(AnnotationSearchOptions{
+		NameContains:        "report",
+		DescriptionContains: "it's final",
+		Properties:          map[string]string{"status": "final", "project": "apollo"},
+	})
+	want := `name contains 'report' and fullText contains 'it\'s final' and ` +
+		`appProperties has { key='project' and value='apollo' } and appProperties has { key='status' and value='final' }`
+	if got != want {
+		t.Errorf("annotationQuery =\n%s\nwant\n%s", got, want)
+	}
+	if q := annotationQuery(AnnotationSearchOptions{}); q != "" {
+		t.Errorf("empty options produced %q", q)
+	}
+}
+
+func TestDescriptionMatches(t *testing.T) {
+	if !descriptionMatches("Q3 Invoice batch", "invoice") {
+		t.Error("case-insensitive match failed")
+	}
+	if descriptionMatches("contains invoice in body only", "receipt") {
+		t.Error("unexpected match")
+	}
+	if !descriptionMatches("", "") {
+		t.Error("empty filter should match")
+	}
+}
+
+func TestDeleteProperties_SendsNulls(t *testing.T) {
+	var body map[string]interface{}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		_, _ = w.Write([]byte(`{"id":"file1","name":"doc","appProperties":{"keep":"yes"}}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	props, err := mgr.DeleteProperties(ctx, reqCtx, "file1", []string{"status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appProps, _ := body["appProperties"].(map[string]interface{})
+	if v, ok := appProps["status"]; !ok || v != nil {
+		t.Errorf("request body = %v, want appProperties.status = null", body)
+	}
+	if props.Properties["keep"] != "yes" {
+		t.Errorf("Properties = %v", props.Properties)
+	}
+}
+
+func TestSetStarred_SendsFalse(t *testing.T) {
+	var body map[string]interface{}
+	var listQuery string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			listQuery = r.URL.Query().Get("q")
+			_, _ = w.Write([]byte(`{"files":[{"id":"file1","name":"doc","starred":true}]}`))
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		_, _ = w.Write([]byte(`{"id":"file1","name":"doc","starred":false}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	if _, err := mgr.SetStarred(ctx, reqCtx, "file1", false); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := body["starred"]; !ok || v != false {
+		t.Errorf("request body = %v, want starred = false", body)
+	}
+
+	result, err := mgr.List(ctx, reqCtx, ListOptions{ParentID: "folder1", Starred: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listQuery != "'folder1' in parents and trashed = false and starred = true" {
+		t.Errorf("query = %q", listQuery)
+	}
+	if len(result.Files) != 1 || !result.Files[0].Starred {
+		t.Errorf("files = %+v, want starred state kept", result.Files)
+	}
+}
@@ -0,0 +1,299 @@
+package files
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// Archive actions
+const (
+	ArchiveMove  = "move"
+	ArchiveTrash = "trash"
+)
+
+// Archive item statuses
+const (
+	ArchivePlanned  = "planned"
+	ArchiveDone     = "archived"
+	ArchiveExcepted = "excepted"
+	ArchiveFailed   = "failed"
+)
+
+const archiveFields = "id,name,mimeType,modifiedTime,size,parents"
+
+// ArchiveExceptions lists what an archive run leaves in place. Each entry
+// is a file or folder ID, or a glob matched against paths relative to the
+// archived folder and against base names. Excepting a folder keeps
+// everything under it.
+type ArchiveExceptions []string
+
+// ReadArchiveExceptions reads exceptions one per line, skipping blank lines
+// and # comments
+func ReadArchiveExceptions(r io.Reader) (ArchiveExceptions, error) {
+	var exceptions ArchiveExceptions
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if _, err := path.Match(entry, ""); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q", line, entry)
+		}
+		exceptions = append(exceptions, entry)
+	}
+	return exceptions, scanner.Err()
+}
+
+// match returns the exception that covers the item with id at rel, or ""
+func (e ArchiveExceptions) match(id, rel string) string {
+	for _, entry := range e {
+		if entry == id || matchesGlob([]string{entry}, rel) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// ArchiveOptions configures FindArchivable
+type ArchiveOptions struct {
+	Cutoff        time.Time // Files last modified before this are archived
+	DestinationID string    // Folder archived files are moved into, keeping their paths
+	Trash         bool      // Trash files instead of moving them
+	Exceptions    ArchiveExceptions
+}
+
+// ArchiveItem is a stale file, or an excepted file or folder
+type ArchiveItem struct {
+	FileID       string `json:"fileId"`
+	Path         string `json:"path"`
+	MimeType     string `json:"mimeType"`
+	ModifiedTime string `json:"modifiedTime"`
+	Size         int64  `json:"size,omitempty"`
+	ParentID     string `json:"parentId"`
+	Status       string `json:"status"`
+	Exception    string `json:"exception,omitempty"` // The exception that kept it
+	Error        string `json:"error,omitempty"`
+}
+
+// ArchiveReport lists the files an archive run moves or trashes
+type ArchiveReport struct {
+	FolderID      string         `json:"folderId"`
+	Action        string         `json:"action"`
+	DestinationID string         `json:"destinationId,omitempty"`
+	Cutoff        string         `json:"cutoff"`
+	FilesScanned  int            `json:"filesScanned"`
+	Planned       int            `json:"planned"`
+	Excepted      int            `json:"excepted"`
+	Archived      int            `json:"archived"`
+	Failed        int            `json:"failed"`
+	DryRun        bool           `json:"dryRun,omitempty"`
+	Items         []*ArchiveItem `json:"items"`
+}
+
+func (r *ArchiveReport) Headers() []string {
+	return []string{"Path", "Modified", "Size", "Status"}
+}
+
+func (r *ArchiveReport) Rows() [][]string {
+	rows := make([][]string, len(r.Items))
+	for i, item := range r.Items {
+		status := item.Status
+		switch {
+		case item.Error != "":
+			status += ": " + item.Error
+		case item.Exception != "":
+			status += " (" + item.Exception + ")"
+		}
+		size := ""
+		if item.MimeType != utils.MimeTypeFolder {
+			size = types.DisplaySize(item.Size)
+		}
+		rows[i] = []string{item.Path, item.ModifiedTime, size, status}
+	}
+	return rows
+}
+
+func (r *ArchiveReport) EmptyMessage() string {
+	return "No files older than " + r.Cutoff + " (" + strconv.Itoa(r.FilesScanned) + " files scanned)"
+}
+
+// FindArchivable lists the files under folderID, at any depth, last
+// modified before opts.Cutoff. Items covered by an exception are listed as
+// excepted, and the destination folder is skipped when it is inside
+// folderID. Nothing is changed; see Archive.
+func (m *Manager) FindArchivable(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts ArchiveOptions) (*ArchiveReport, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, folderID)
+	report := &ArchiveReport{
+		FolderID:      folderID,
+		Action:        ArchiveMove,
+		DestinationID: opts.DestinationID,
+		Cutoff:        opts.Cutoff.UTC().Format(time.RFC3339),
+		Items:         []*ArchiveItem{},
+	}
+	if opts.Trash {
+		report.Action = ArchiveTrash
+		report.DestinationID = ""
+	}
+	if err := m.findArchivableIn(ctx, reqCtx, folderID, "", opts, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (m *Manager) findArchivableIn(ctx context.Context, reqCtx *types.RequestContext, folderID, dir string, opts ArchiveOptions, report *ArchiveReport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	children, err := m.listChildren(ctx, reqCtx, folderID, archiveFields)
+	if err != nil {
+		return err
+	}
+	for _, f := range children {
+		if f.Id == opts.DestinationID {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel := path.Join(dir, f.Name)
+		isFolder := f.MimeType == utils.MimeTypeFolder
+		if !isFolder {
+			report.FilesScanned++
+			types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files archive-old scan", Done: report.FilesScanned, Item: rel})
+		}
+		if exception := opts.Exceptions.match(f.Id, rel); exception != "" {
+			report.Excepted++
+			item := newArchiveItem(f, rel, folderID)
+			item.Status = ArchiveExcepted
+			item.Exception = exception
+			report.Items = append(report.Items, item)
+			continue
+		}
+		if isFolder {
+			if err := m.findArchivableIn(ctx, reqCtx, f.Id, rel, opts, report); err != nil {
+				return err
+			}
+			continue
+		}
+
+		modified, err := time.Parse(time.RFC3339, f.ModifiedTime)
+		if err != nil || !modified.Before(opts.Cutoff) {
+			continue
+		}
+		item := newArchiveItem(f, rel, folderID)
+		item.Status = ArchivePlanned
+		report.Planned++
+		report.Items = append(report.Items, item)
+	}
+	return nil
+}
+
+func newArchiveItem(f *drive.File, rel, parentID string) *ArchiveItem {
+	return &ArchiveItem{
+		FileID:       f.Id,
+		Path:         rel,
+		MimeType:     f.MimeType,
+		ModifiedTime: f.ModifiedTime,
+		Size:         f.Size,
+		ParentID:     parentID,
+	}
+}
+
+// Archive moves or trashes the planned items of report. Moved files keep
+// their paths below the destination, whose folders are created as needed.
+// A file that fails is marked failed and the rest are still archived. In a
+// dry run nothing is changed.
+func (m *Manager) Archive(ctx context.Context, reqCtx *types.RequestContext, report *ArchiveReport, dryRun bool) error {
+	report.DryRun = dryRun
+	if dryRun {
+		return nil
+	}
+
+	folders := map[string]string{"": report.DestinationID}
+	done := 0
+	for _, item := range report.Items {
+		if item.Status != ArchivePlanned {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		done++
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files archive-old", Done: done, Total: report.Planned, Item: item.Path})
+
+		var err error
+		if report.Action == ArchiveTrash {
+			_, err = m.Trash(ctx, reqCtx, item.FileID)
+		} else {
+			var destID string
+			if destID, err = m.archiveFolder(ctx, reqCtx, folders, path.Dir(item.Path)); err == nil {
+				_, err = m.Move(ctx, reqCtx, item.FileID, destID)
+			}
+		}
+		if err != nil {
+			item.Status = ArchiveFailed
+			item.Error = err.Error()
+			report.Failed++
+			continue
+		}
+		item.Status = ArchiveDone
+		report.Archived++
+	}
+	return nil
+}
+
+// archiveFolder returns the ID of the folder at dir below the destination,
+// creating it and its parents when missing. folders caches the IDs found.
+func (m *Manager) archiveFolder(ctx context.Context, reqCtx *types.RequestContext, folders map[string]string, dir string) (string, error) {
+	if dir == "." {
+		dir = ""
+	}
+	if id, ok := folders[dir]; ok {
+		return id, nil
+	}
+	parentID, err := m.archiveFolder(ctx, reqCtx, folders, path.Dir(dir))
+	if err != nil {
+		return "", err
+	}
+	id, _, err := m.ensureFolder(ctx, reqCtx, path.Base(dir), parentID)
+	if err != nil {
+		return "", err
+	}
+	folders[dir] = id
+	return id, nil
+}
+
+// WriteManifest writes the report's items as CSV, one row per item, for
+// the retention record
+func (r *ArchiveReport) WriteManifest(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"file_id", "path", "mime_type", "modified_time", "size", "original_parent_id", "action", "destination_id", "status", "exception", "error"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, item := range r.Items {
+		action := r.Action
+		if item.Status == ArchiveExcepted {
+			action = ""
+		}
+		row := []string{item.FileID, item.Path, item.MimeType, item.ModifiedTime, strconv.FormatInt(item.Size, 10),
+			item.ParentID, action, r.DestinationID, item.Status, item.Exception, item.Error}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,124 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestReadArchiveExceptions(t *testing.T) {
+	exceptions, err := ReadArchiveExceptions(strings.NewReader("# legal hold\n1AbCdEf\n\nContracts/*\n  *.pdf  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exceptions) != 3 {
+		t.Fatalf("exceptions = %q", exceptions)
+	}
+	if got := exceptions.match("1AbCdEf", "x/y.txt"); got != "1AbCdEf" {
+		t.Errorf("match by ID = %q", got)
+	}
+	if got := exceptions.match("id", "Contracts/2019.docx"); got != "Contracts/*" {
+		t.Errorf("match by path = %q", got)
+	}
+	if got := exceptions.match("id", "deep/scan.pdf"); got != "*.pdf" {
+		t.Errorf("match by base name = %q", got)
+	}
+	if got := exceptions.match("id", "notes.txt"); got != "" {
+		t.Errorf("unexpected match %q", got)
+	}
+	if _, err := ReadArchiveExceptions(strings.NewReader("[broken\n")); err == nil {
+		t.Error("an invalid pattern should fail")
+	}
+}
+
+func TestArchiveOld(t *testing.T) {
+	children := map[string]string{
+		"root": `{"files":[
+			{"id":"f1","name":"old.txt","mimeType":"text/plain","modifiedTime":"2020-01-01T00:00:00Z"},
+			{"id":"f2","name":"new.txt","mimeType":"text/plain","modifiedTime":"2024-06-01T00:00:00Z"},
+			{"id":"f3","name":"keep.pdf","mimeType":"application/pdf","modifiedTime":"2019-01-01T00:00:00Z"},
+			{"id":"dest","name":"Archive","mimeType":"application/vnd.google-apps.folder","modifiedTime":"2019-01-01T00:00:00Z"},
+			{"id":"sub","name":"Reports","mimeType":"application/vnd.google-apps.folder","modifiedTime":"2024-01-01T00:00:00Z"}]}`,
+		"sub": `{"files":[
+			{"id":"f4","name":"q1.txt","mimeType":"text/plain","modifiedTime":"2019-04-01T00:00:00Z"}]}`,
+	}
+	moves := map[string]string{}
+	var created []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files" && strings.Contains(q.Get("q"), "name ="):
+			_, _ = w.Write([]byte(`{"files":[]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			parent := strings.Trim(strings.SplitN(q.Get("q"), " ", 2)[0], "'")
+			_, _ = w.Write([]byte(children[parent]))
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files":
+			var folder drive.File
+			_ = json.NewDecoder(r.Body).Decode(&folder)
+			created = append(created, folder.Name+" in "+folder.Parents[0])
+			_, _ = w.Write([]byte(`{"id":"dest-reports","name":"Reports"}`))
+		case r.Method == http.MethodGet:
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+			_, _ = w.Write([]byte(`{"id":"` + id + `","name":"x","parents":["p-` + id + `"]}`))
+		case r.Method == http.MethodPatch:
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+			moves[id] = q.Get("addParents") + " from " + q.Get("removeParents")
+			_, _ = w.Write([]byte(`{"id":"` + id + `"}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	opts := ArchiveOptions{
+		Cutoff:        time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		DestinationID: "dest",
+		Exceptions:    ArchiveExceptions{"*.pdf"},
+	}
+	report, err := mgr.FindArchivable(ctx, reqCtx, "root", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FilesScanned != 4 || report.Planned != 2 || report.Excepted != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	if err := mgr.Archive(ctx, reqCtx, report, true); err != nil || len(moves) != 0 {
+		t.Fatalf("dry run moved %v, err %v", moves, err)
+	}
+	if err := mgr.Archive(ctx, reqCtx, report, false); err != nil {
+		t.Fatal(err)
+	}
+	if report.Archived != 2 || report.Failed != 0 {
+		t.Errorf("archived %d, failed %d", report.Archived, report.Failed)
+	}
+	if moves["f1"] != "dest from p-f1" || moves["f4"] != "dest-reports from p-f4" {
+		t.Errorf("moves = %v", moves)
+	}
+	if len(created) != 1 || created[0] != "Reports in dest" {
+		t.Errorf("created folders %v, want the file's folder mirrored once", created)
+	}
+
+	var manifest bytes.Buffer
+	if err := report.WriteManifest(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(manifest.String()), "\n")
+	sort.Strings(lines[1:])
+	if len(lines) != 4 || !strings.Contains(lines[1], "f1,old.txt,text/plain,2020-01-01T00:00:00Z,0,root,move,dest,archived,,") {
+		t.Errorf("manifest =\n%s", manifest.String())
+	}
+}
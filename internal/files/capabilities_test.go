@@ -0,0 +1,117 @@
+package files
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestCheckCapabilities(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        *types.DriveFile
+		required    []Capability
+		wantMissing []string
+	}{
+		{
+			name:     "nil file is not gated",
+			file:     nil,
+			required: []Capability{CapabilityEdit},
+		},
+		{
+			name:     "missing capability metadata is not gated",
+			file:     &types.DriveFile{ID: "file1", Name: "doc"},
+			required: []Capability{CapabilityTrash},
+		},
+		{
+			name: "all capabilities present",
+			file: &types.DriveFile{ID: "file1", Name: "doc", Capabilities: &types.FileCapabilities{
+				CanEdit:   true,
+				CanRename: true,
+			}},
+			required: []Capability{CapabilityEdit, CapabilityRename},
+		},
+		{
+			name: "single missing capability",
+			file: &types.DriveFile{ID: "file1", Name: "doc", Capabilities: &types.FileCapabilities{
+				CanEdit: true,
+			}},
+			required:    []Capability{CapabilityTrash},
+			wantMissing: []string{"canTrash"},
+		},
+		{
+			name: "multiple missing capabilities",
+			file: &types.DriveFile{ID: "file1", Name: "doc", Capabilities: &types.FileCapabilities{
+				CanTrash: true,
+			}},
+			required:    []Capability{CapabilityEdit, CapabilityTrash, CapabilityRename},
+			wantMissing: []string{"canEdit", "canRename"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCapabilities(tt.file, tt.required...)
+			if len(tt.wantMissing) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			appErr, ok := err.(*utils.AppError)
+			if !ok {
+				t.Fatalf("expected *utils.AppError, got %T", err)
+			}
+			if appErr.CLIError.Code != utils.ErrCodePermissionDenied {
+				t.Errorf("Code = %s, want %s", appErr.CLIError.Code, utils.ErrCodePermissionDenied)
+			}
+			if appErr.CLIError.Context["capability"] != tt.wantMissing[0] {
+				t.Errorf("capability = %v, want %s", appErr.CLIError.Context["capability"], tt.wantMissing[0])
+			}
+			missing, _ := appErr.CLIError.Context["missingCapabilities"].([]string)
+			if len(missing) != len(tt.wantMissing) {
+				t.Fatalf("missingCapabilities = %v, want %v", missing, tt.wantMissing)
+			}
+			for i := range missing {
+				if missing[i] != tt.wantMissing[i] {
+					t.Errorf("missingCapabilities[%d] = %s, want %s", i, missing[i], tt.wantMissing[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHasCapability_UnknownCapability(t *testing.T) {
+	if !hasCapability(&types.FileCapabilities{}, Capability("canSomethingNew")) {
+		t.Error("unknown capabilities should not block operations")
+	}
+}
+
+func TestMetadataMutations_RefuseWithoutEdit(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/drive/v3/files/locked" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"locked","name":"Budget","parents":["p1"],"capabilities":{"canEdit":false,"canRename":false}}`))
+	}))
+	mgr := NewManager(client)
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	name := "Budget v2"
+	_, updateErr := mgr.UpdateMetadata(ctx, reqCtx, "locked", MetadataUpdate{Name: &name})
+	_, moveErr := mgr.Move(ctx, reqCtx, "locked", "p2")
+	for op, err := range map[string]error{"update": updateErr, "move": moveErr} {
+		appErr, ok := err.(*utils.AppError)
+		if !ok || appErr.CLIError.Code != utils.ErrCodePermissionDenied || appErr.CLIError.Context["capability"] != "canEdit" {
+			t.Errorf("%s: err = %v, want PERMISSION_DENIED for canEdit", op, err)
+		}
+	}
+}
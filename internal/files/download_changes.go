@@ -0,0 +1,553 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Incremental tree download item statuses
+const (
+	TreeItemMoved   = "moved"
+	TreeItemRemoved = "removed"
+)
+
+// ChangesTokenAuto resumes from the change token saved by the last
+// incremental download into the same directory
+const ChangesTokenAuto = "auto"
+
+// ChangesStateFile records an incremental download's change token and local
+// layout. It is kept in the output directory.
+const ChangesStateFile = ".gdrv-changes.json"
+
+const (
+	changesListFields = "nextPageToken,newStartPageToken,changes(fileId,removed,file(" + changedFileFields + "))"
+	changedFileFields = "id,name,mimeType,parents,trashed,size,md5Checksum,sha256Checksum,modifiedTime,capabilities(canDownload),exportLinks,resourceKey"
+	// maxTreeDepth bounds path resolution against parent cycles
+	maxTreeDepth = 100
+)
+
+// treeState is the saved layout of an incremental tree download. Items are
+// keyed by Drive ID and hold local names, so paths are rebuilt from the
+// parent chain and a renamed folder moves everything under it.
+type treeState struct {
+	state.Versioned
+	FolderID    string                    `json:"folderId"`
+	ChangeToken string                    `json:"changeToken"`
+	Items       map[string]*treeStateItem `json:"items"`
+	// Failed files are fetched again on the next run even if unchanged
+	Failed []string `json:"failed,omitempty"`
+}
+
+// TreeStateKind versions ChangesStateFile
+var TreeStateKind = state.Register(&state.Kind{
+	Name:        "download-state",
+	Description: "Checkpoint of a files download --changes-token folder",
+	Current:     1,
+	FileName:    ChangesStateFile,
+})
+
+type treeStateItem struct {
+	Parent string `json:"parent"`
+	// Name is the local name; Title is the name it was derived from, so a
+	// " (n)" suffix survives runs where the Drive name did not change
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Folder   bool   `json:"folder,omitempty"`
+	Modified string `json:"modified,omitempty"`
+}
+
+// downloadTreeChanges brings a previous tree download up to date using the
+// Changes API, instead of listing the whole tree again. Changed files are
+// downloaded, renamed and moved files are moved locally, and files removed
+// from the tree are reported but their local copies are kept. Without a
+// usable state and token, the whole tree is downloaded and the state saved
+// for the next run.
+func (m *Manager) downloadTreeChanges(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts DownloadTreeOptions) (*DownloadTreeResult, error) {
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		folder, err := m.Get(ctx, reqCtx, folderID, "id,name")
+		if err != nil {
+			return nil, err
+		}
+		outputDir = sanitizeLocalName(folder.Name)
+		opts.OutputDir = outputDir
+	}
+
+	state, err := loadTreeState(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	token := opts.ChangesToken
+	if token == ChangesTokenAuto && state != nil {
+		token = state.ChangeToken
+	}
+	switch {
+	case state == nil:
+		return m.downloadTreeFull(ctx, reqCtx, folderID, opts, "no previous incremental download in "+outputDir)
+	case state.FolderID != folderID:
+		return m.downloadTreeFull(ctx, reqCtx, folderID, opts, "previous download was of another folder")
+	case token == "" || token == ChangesTokenAuto:
+		return m.downloadTreeFull(ctx, reqCtx, folderID, opts, "no saved change token")
+	}
+
+	changed, newToken, err := m.listTreeChanges(ctx, reqCtx, token)
+	if isInvalidToken(err) {
+		return m.downloadTreeFull(ctx, reqCtx, folderID, opts, "change token is no longer valid")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range state.Failed {
+		if _, ok := changed[id]; !ok {
+			// Removed if it can no longer be read
+			changed[id] = m.refetch(ctx, reqCtx, id)
+		}
+	}
+
+	result, err := m.applyTreeChanges(ctx, reqCtx, outputDir, state, changed, opts)
+	if err != nil {
+		// The state is not saved, so the next run starts from the same
+		// token and finishes the job
+		return result, err
+	}
+	result.Incremental = true
+	state.ChangeToken = newToken
+	state.Failed = failedIDs(result)
+	if err := saveTreeState(outputDir, state); err != nil {
+		return result, err
+	}
+	result.ChangeToken = newToken
+	return result, nil
+}
+
+// refetch reads a file's current metadata, or returns nil if it cannot be
+// read, which is treated as removed
+func (m *Manager) refetch(ctx context.Context, reqCtx *types.RequestContext, id string) *types.DriveFile {
+	file, err := m.client.GetFile(ctx, reqCtx, id, changedFileFields)
+	if err != nil || file.Trashed {
+		return nil
+	}
+	return convertDriveFile(file)
+}
+
+// downloadTreeFull downloads the whole tree and saves the state for the
+// next incremental run. The change token is taken before listing, so
+// changes made during the download are picked up next time.
+func (m *Manager) downloadTreeFull(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts DownloadTreeOptions, reason string) (*DownloadTreeResult, error) {
+	token, err := m.startPageToken(ctx, reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := m.planTree(ctx, reqCtx, folderID, opts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := m.downloadTreePlan(ctx, reqCtx, folderID, plan, opts)
+	result.FullResync = reason
+	if err != nil {
+		return result, err
+	}
+
+	// The contents of a folder that could not be listed are not in the
+	// state, so the next run must list the tree again
+	if plan.hasFailedFolders() {
+		token = ""
+	}
+	state := stateFromPlan(folderID, token, plan)
+	state.Failed = failedIDs(result)
+	if err := saveTreeState(plan.outputDir, state); err != nil {
+		return result, err
+	}
+	result.ChangeToken = token
+	return result, nil
+}
+
+func (m *Manager) startPageToken(ctx context.Context, reqCtx *types.RequestContext) (string, error) {
+	call := m.client.Service().Changes.GetStartPageToken().SupportsAllDrives(true)
+	if reqCtx.DriveID != "" {
+		call = call.DriveId(reqCtx.DriveID)
+	}
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.StartPageToken, error) {
+		return call.Context(ctx).Do()
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.StartPageToken, nil
+}
+
+// listTreeChanges pages through the changes since token. It returns the
+// latest metadata per file, nil for removed files, and the token for the
+// next run.
+func (m *Manager) listTreeChanges(ctx context.Context, reqCtx *types.RequestContext, token string) (map[string]*types.DriveFile, string, error) {
+	changed := map[string]*types.DriveFile{}
+	pageToken := token
+	for {
+		call := m.client.Service().Changes.List(pageToken).
+			SupportsAllDrives(true).
+			IncludeItemsFromAllDrives(true).
+			PageSize(1000).
+			Fields(googleapi.Field(changesListFields))
+		if reqCtx.DriveID != "" {
+			call = call.DriveId(reqCtx.DriveID)
+		}
+		list, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.ChangeList, error) {
+			return call.Context(ctx).Do()
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		for _, change := range list.Changes {
+			if change.Removed || change.File == nil || change.File.Trashed {
+				changed[change.FileId] = nil
+				continue
+			}
+			changed[change.FileId] = convertDriveFile(change.File)
+			if change.File.ResourceKey != "" {
+				m.client.ResourceKeys().UpdateFromAPIResponse(change.File.Id, change.File.ResourceKey)
+			}
+		}
+		if list.NextPageToken == "" {
+			return changed, list.NewStartPageToken, nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+// applyTreeChanges updates state with the changed files and brings the
+// local copy in line with it
+func (m *Manager) applyTreeChanges(ctx context.Context, reqCtx *types.RequestContext, outputDir string, state *treeState, changed map[string]*types.DriveFile, opts DownloadTreeOptions) (*DownloadTreeResult, error) {
+	prev := state.Items
+	next := make(map[string]*treeStateItem, len(prev))
+	for id, item := range prev {
+		copied := *item
+		next[id] = &copied
+	}
+
+	// Folders that enter the tree bring their contents without a change
+	// for each descendant, so new folders are listed
+	var skipped []*DownloadTreeItem
+	pending := changed
+	for len(pending) > 0 {
+		newFolders := m.placeChanged(state.FolderID, prev, next, pending, opts, &skipped)
+		pending = map[string]*types.DriveFile{}
+		for _, id := range newFolders {
+			children, err := m.ListAll(ctx, reqCtx, ListOptions{ParentID: id, PageSize: 1000, Fields: changedFileFields})
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				if _, seen := changed[child.ID]; !seen {
+					pending[child.ID] = child
+					changed[child.ID] = child
+				}
+			}
+		}
+	}
+
+	oldPaths := treePaths(outputDir, state.FolderID, prev)
+	newPaths := treePaths(outputDir, state.FolderID, next)
+	for id := range next {
+		if _, ok := newPaths[id]; !ok {
+			// No longer under the folder
+			delete(next, id)
+		}
+	}
+	state.Items = next
+
+	result := &DownloadTreeResult{FolderID: state.FolderID, OutputDir: outputDir}
+	plan := &treePlan{outputDir: outputDir}
+	var moved []*DownloadTreeItem
+	var movedDirs []string
+
+	ids := make([]string, 0, len(changed)+len(prev))
+	for id := range changed {
+		ids = append(ids, id)
+	}
+	for id := range prev {
+		if _, ok := changed[id]; !ok && oldPaths[id] != newPaths[id] {
+			// Unchanged items under a moved folder
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		oldPath, wasIn := oldPaths[id]
+		newPath, isIn := newPaths[id]
+		item := next[id]
+		switch {
+		case !isIn:
+			if wasIn {
+				result.Removed++
+				result.Items = append(result.Items, &DownloadTreeItem{FileID: id, Name: prev[id].Title, Path: oldPath, Status: TreeItemRemoved})
+			}
+		case item.Folder:
+			plan.dirs = append(plan.dirs, newPath)
+			if wasIn && oldPath != newPath {
+				movedDirs = append(movedDirs, oldPath)
+			}
+		default:
+			file := changed[id]
+			if wasIn && oldPath != newPath {
+				if err := moveLocal(oldPath, newPath); err == nil {
+					result.Moved++
+					moved = append(moved, &DownloadTreeItem{FileID: id, Name: item.Title, Path: newPath, Status: TreeItemMoved})
+				} else if file == nil {
+					// Nothing else would restore it; fetch it again
+					file = m.refetch(ctx, reqCtx, id)
+					item.Modified = ""
+				}
+			}
+			if file == nil || (wasIn && prev[id].Modified == item.Modified && item.Modified != "") {
+				continue
+			}
+			entry := &treeEntry{file: file, parentID: item.Parent, localPath: newPath}
+			if utils.IsWorkspaceMimeType(file.MimeType) {
+				entry.exportMime = exportFormatFor(file.MimeType, opts.ExportFormats, m.exportDefaults)
+			}
+			plan.entries = append(plan.entries, entry)
+		}
+	}
+	sort.Strings(plan.dirs)
+
+	downloaded, err := m.downloadTreePlan(ctx, reqCtx, state.FolderID, plan, opts)
+	if err != nil {
+		return downloaded, err
+	}
+	downloaded.Items = append(downloaded.Items, skipped...)
+	downloaded.Skipped += len(skipped)
+	downloaded.Items = append(downloaded.Items, moved...)
+	downloaded.Items = append(downloaded.Items, result.Items...)
+	downloaded.Moved, downloaded.Removed = result.Moved, result.Removed
+	sort.Slice(downloaded.Items, func(i, j int) bool {
+		return downloaded.Items[i].Path < downloaded.Items[j].Path
+	})
+
+	// Folders that moved are left behind empty; deepest first
+	sort.Sort(sort.Reverse(sort.StringSlice(movedDirs)))
+	for _, dir := range movedDirs {
+		_ = os.Remove(dir)
+	}
+	return downloaded, nil
+}
+
+// placeChanged records the changed files in next, giving each a unique
+// local name among its siblings. It returns folders that were not in the
+// previous state, whose contents still need listing.
+func (m *Manager) placeChanged(rootID string, prev, next map[string]*treeStateItem, changed map[string]*types.DriveFile, opts DownloadTreeOptions, skipped *[]*DownloadTreeItem) []string {
+	ids := make([]string, 0, len(changed))
+	for id := range changed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var newFolders []string
+	for _, id := range ids {
+		file := changed[id]
+		if file == nil || len(file.Parents) == 0 || id == rootID {
+			delete(next, id)
+			continue
+		}
+		title := sanitizeLocalName(file.Name)
+		isFolder := file.MimeType == utils.MimeTypeFolder
+		switch {
+		case isFolder:
+		case file.MimeType == utils.MimeTypeShortcut:
+			title = ""
+		case utils.IsWorkspaceMimeType(file.MimeType):
+			if format := exportFormatFor(file.MimeType, opts.ExportFormats, m.exportDefaults); format != "" {
+				title += exportExtension(format)
+			} else {
+				title = ""
+			}
+		}
+		if title == "" {
+			delete(next, id)
+			if _, known := prev[id]; !known && isUnder(rootID, file.Parents[0], next) {
+				*skipped = append(*skipped, &DownloadTreeItem{FileID: id, Name: file.Name, MimeType: file.MimeType,
+					Status: TreeItemSkipped, Error: "not downloadable in a tree download"})
+			}
+			continue
+		}
+
+		item := &treeStateItem{Parent: file.Parents[0], Title: title, Folder: isFolder, Modified: file.ModifiedTime}
+		if old, ok := prev[id]; ok && old.Parent == item.Parent && old.Title == title {
+			item.Name = old.Name
+		} else {
+			delete(next, id)
+			item.Name = uniqueSiblingName(next, item.Parent, title)
+		}
+		next[id] = item
+	}
+
+	// Only once all are placed is it known which new folders are in the tree
+	for _, id := range ids {
+		if item, ok := next[id]; ok && item.Folder && prev[id] == nil && isUnder(rootID, item.Parent, next) {
+			newFolders = append(newFolders, id)
+		}
+	}
+	return newFolders
+}
+
+// uniqueSiblingName returns title, or title with a " (n)" suffix if another
+// item in the same folder already has that name
+func uniqueSiblingName(items map[string]*treeStateItem, parent, title string) string {
+	used := map[string]bool{}
+	for _, item := range items {
+		if item.Parent == parent {
+			used[item.Name] = true
+		}
+	}
+	name := title
+	ext := filepath.Ext(title)
+	for n := 1; used[name]; n++ {
+		name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(title, ext), n, ext)
+	}
+	return name
+}
+
+// isUnder reports whether id is the root or a folder in items under it
+func isUnder(rootID, id string, items map[string]*treeStateItem) bool {
+	for depth := 0; depth < maxTreeDepth; depth++ {
+		if id == rootID {
+			return true
+		}
+		item, ok := items[id]
+		if !ok {
+			return false
+		}
+		id = item.Parent
+	}
+	return false
+}
+
+// treePaths resolves the local path of every item reachable from the root
+func treePaths(outputDir, rootID string, items map[string]*treeStateItem) map[string]string {
+	paths := map[string]string{}
+	var resolve func(id string, depth int) (string, bool)
+	resolve = func(id string, depth int) (string, bool) {
+		if id == rootID {
+			return outputDir, true
+		}
+		if p, ok := paths[id]; ok {
+			return p, true
+		}
+		item, ok := items[id]
+		if !ok || depth > maxTreeDepth {
+			return "", false
+		}
+		parent, ok := resolve(item.Parent, depth+1)
+		if !ok {
+			return "", false
+		}
+		p := filepath.Join(parent, item.Name)
+		paths[id] = p
+		return p, true
+	}
+	for id := range items {
+		resolve(id, 0)
+	}
+	return paths
+}
+
+func stateFromPlan(folderID, token string, plan *treePlan) *treeState {
+	state := &treeState{FolderID: folderID, ChangeToken: token, Items: map[string]*treeStateItem{}}
+	for _, f := range plan.folders {
+		if f.id == folderID {
+			continue
+		}
+		name := filepath.Base(f.dir)
+		state.Items[f.id] = &treeStateItem{Parent: f.parentID, Name: name, Title: name, Folder: true}
+	}
+	for _, entry := range plan.entries {
+		if entry.localPath == "" {
+			continue
+		}
+		title := sanitizeLocalName(entry.file.Name) + exportExtension(entry.exportMime)
+		state.Items[entry.file.ID] = &treeStateItem{
+			Parent:   entry.parentID,
+			Name:     filepath.Base(entry.localPath),
+			Title:    title,
+			Modified: entry.file.ModifiedTime,
+		}
+	}
+	return state
+}
+
+func failedIDs(result *DownloadTreeResult) []string {
+	var ids []string
+	for _, item := range result.Items {
+		if item.Status == TreeItemFailed {
+			ids = append(ids, item.FileID)
+		}
+	}
+	return ids
+}
+
+func loadTreeState(outputDir string) (*treeState, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, ChangesStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ts treeState
+	if err := state.Unmarshal(TreeStateKind, data, &ts); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return nil, appErr
+		}
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid %s: %s", ChangesStateFile, err)).
+			WithContext("suggestedAction", "delete it to download the whole folder again").
+			Build())
+	}
+	if ts.Items == nil {
+		ts.Items = map[string]*treeStateItem{}
+	}
+	return &ts, nil
+}
+
+// saveTreeState writes the state through a temp file, so an interrupted
+// run leaves the previous state intact
+func saveTreeState(outputDir string, ts *treeState) error {
+	return state.Save(TreeStateKind, filepath.Join(outputDir, ChangesStateFile), ts)
+}
+
+// moveLocal moves a previously downloaded file to its new path
+func moveLocal(oldPath, newPath string) error {
+	if _, err := os.Stat(oldPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// isInvalidToken reports whether the Changes API rejected a page token
+func isInvalidToken(err error) bool {
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) {
+		return false
+	}
+	switch appErr.CLIError.HTTPStatus {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// changesDrive serves a folder tree and a queue of changes
+type changesDrive struct {
+	mu      sync.Mutex
+	files   map[string]*drive.File
+	content map[string]string
+	changes []*drive.Change
+	lists   int
+}
+
+func (d *changesDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/drive/v3/")
+	switch {
+	case path == "changes/startPageToken":
+		_ = json.NewEncoder(w).Encode(&drive.StartPageToken{StartPageToken: "t1"})
+	case path == "changes":
+		if r.URL.Query().Get("pageToken") != "t1" {
+			http.Error(w, `{"error":{"code":400,"message":"Invalid Value"}}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&drive.ChangeList{Changes: d.changes, NewStartPageToken: "t2"})
+	case path == "files":
+		d.lists++
+		q := r.URL.Query().Get("q")
+		list := &drive.FileList{Files: []*drive.File{}}
+		for _, f := range d.files {
+			if len(f.Parents) > 0 && strings.Contains(q, "'"+f.Parents[0]+"' in parents") {
+				list.Files = append(list.Files, f)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case strings.HasSuffix(path, "/export"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "files/"), "/export")
+		_, _ = io.WriteString(w, d.content[id])
+	case r.URL.Query().Get("alt") == "media":
+		_, _ = io.WriteString(w, d.content[strings.TrimPrefix(path, "files/")])
+	default:
+		_ = json.NewEncoder(w).Encode(d.files[strings.TrimPrefix(path, "files/")])
+	}
+}
+
+func (d *changesDrive) change(f *drive.File, content string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.files[f.Id] = f
+	if content != "" {
+		d.content[f.Id] = content
+	}
+	d.changes = append(d.changes, &drive.Change{FileId: f.Id, File: f})
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDownloadTree_Changes(t *testing.T) {
+	d := &changesDrive{
+		files: map[string]*drive.File{
+			"top":  {Id: "top", Name: "Top", MimeType: utils.MimeTypeFolder},
+			"plan": {Id: "plan", Name: "Plan", MimeType: utils.MimeTypeDocument, Parents: []string{"top"}, ModifiedTime: "1"},
+			"a":    {Id: "a", Name: "a.txt", MimeType: "text/plain", Parents: []string{"top"}, ModifiedTime: "1"},
+			"old":  {Id: "old", Name: "old.txt", MimeType: "text/plain", Parents: []string{"top"}, ModifiedTime: "1"},
+			"sub":  {Id: "sub", Name: "Sub", MimeType: utils.MimeTypeFolder, Parents: []string{"top"}},
+			"b":    {Id: "b", Name: "b.txt", MimeType: "text/plain", Parents: []string{"sub"}, ModifiedTime: "1"},
+		},
+		content: map[string]string{"plan": "plan v1", "a": "A", "old": "old", "b": "B"},
+	}
+	client, _ := testhelpers.NewDriveClient(t, d)
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	out := filepath.Join(t.TempDir(), "backup")
+	opts := DownloadTreeOptions{OutputDir: out, ChangesToken: ChangesTokenAuto, SkipPreflight: true}
+
+	// First run: everything, with the state saved
+	result, err := mgr.DownloadTree(context.Background(), reqCtx, "top", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FullResync == "" || result.Incremental || result.ChangeToken != "t1" || result.Downloaded != 3 || result.Exported != 1 {
+		t.Fatalf("first run = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(out, ChangesStateFile)); err != nil {
+		t.Fatalf("state not saved: %v", err)
+	}
+
+	d.change(&drive.File{Id: "a", Name: "a2.txt", MimeType: "text/plain", Parents: []string{"top"}, ModifiedTime: "1"}, "")
+	d.change(&drive.File{Id: "sub", Name: "Sub2", MimeType: utils.MimeTypeFolder, Parents: []string{"top"}}, "")
+	d.change(&drive.File{Id: "plan", Name: "Plan", MimeType: utils.MimeTypeDocument, Parents: []string{"top"}, ModifiedTime: "2"}, "plan v2")
+	d.change(&drive.File{Id: "c", Name: "a.txt", MimeType: "text/plain", Parents: []string{"top"}, ModifiedTime: "2"}, "C")
+	d.change(&drive.File{Id: "new", Name: "New", MimeType: utils.MimeTypeFolder, Parents: []string{"top"}}, "")
+	d.mu.Lock()
+	d.files["n1"] = &drive.File{Id: "n1", Name: "inside.txt", MimeType: "text/plain", Parents: []string{"new"}, ModifiedTime: "2"}
+	d.content["n1"] = "inside"
+	d.changes = append(d.changes, &drive.Change{FileId: "old", Removed: true})
+	listsBefore := d.lists
+	d.mu.Unlock()
+
+	// Second run: only the changes
+	result, err = mgr.DownloadTree(context.Background(), reqCtx, "top", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Incremental || result.ChangeToken != "t2" {
+		t.Fatalf("second run = %+v", result)
+	}
+	if result.Moved != 2 || result.Removed != 1 || result.Downloaded != 2 || result.Exported != 1 {
+		t.Errorf("counts: moved %d removed %d downloaded %d exported %d", result.Moved, result.Removed, result.Downloaded, result.Exported)
+	}
+	if lists := d.lists - listsBefore; lists != 1 {
+		t.Errorf("expected only the new folder to be listed, got %d listings", lists)
+	}
+
+	if got := readFile(t, filepath.Join(out, "a2.txt")); got != "A" {
+		t.Errorf("renamed file = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "a.txt")); got != "C" {
+		t.Errorf("new file reusing the old name = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "Sub2", "b.txt")); got != "B" {
+		t.Errorf("file under renamed folder = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(out, "Sub")); !os.IsNotExist(err) {
+		t.Errorf("old folder should be removed once empty: %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "Plan.docx")); got != "plan v2" {
+		t.Errorf("changed doc = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "New", "inside.txt")); got != "inside" {
+		t.Errorf("file in new folder = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "old.txt")); got != "old" {
+		t.Errorf("removed file should be kept locally, got %q", got)
+	}
+
+	// An expired token falls back to a full download
+	state, err := loadTreeState(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ChangeToken != "t2" {
+		t.Fatalf("saved token = %q", state.ChangeToken)
+	}
+	result, err = mgr.DownloadTree(context.Background(), reqCtx, "top", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FullResync != "change token is no longer valid" || result.ChangeToken != "t1" {
+		t.Errorf("expired token run = %+v", result)
+	}
+}
+
+func TestUniqueSiblingName(t *testing.T) {
+	items := map[string]*treeStateItem{
+		"1": {Parent: "p", Name: "a.txt"},
+		"2": {Parent: "p", Name: "a (1).txt"},
+		"3": {Parent: "q", Name: "b.txt"},
+	}
+	if got := uniqueSiblingName(items, "p", "a.txt"); got != "a (2).txt" {
+		t.Errorf("got %q", got)
+	}
+	if got := uniqueSiblingName(items, "p", "b.txt"); got != "b.txt" {
+		t.Errorf("got %q", got)
+	}
+}
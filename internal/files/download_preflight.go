@@ -0,0 +1,126 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// maxReportedLongPaths caps the example paths listed in an estimate
+const maxReportedLongPaths = 10
+
+// freeDiskSpace is replaced in tests
+var freeDiskSpace = utils.FreeDiskSpace
+
+// DownloadTreeEstimate is the pre-flight summary of a recursive download,
+// computed from metadata before any file is written.
+type DownloadTreeEstimate struct {
+	Folders       int      `json:"folders"`
+	Files         int      `json:"files"`
+	Exports       int      `json:"exports"`
+	Skipped       int      `json:"skipped"`
+	TotalBytes    int64    `json:"totalBytes"`
+	FreeBytes     *int64   `json:"freeBytes,omitempty"`
+	LongestPath   int      `json:"longestPath"`
+	MaxPathLength int      `json:"maxPathLength"`
+	LongPathCount int      `json:"longPathCount"`
+	LongPaths     []string `json:"longPaths,omitempty"`
+	Problems      []string `json:"problems,omitempty"`
+}
+
+// EstimateTree lists a folder tree and reports the download size, free
+// space at the destination, and local paths that would be too long, without
+// downloading anything. TotalBytes covers blob files only; the size of
+// Workspace exports is not known until they are exported.
+func (m *Manager) EstimateTree(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts DownloadTreeOptions) (*DownloadTreeEstimate, error) {
+	plan, err := m.planTree(ctx, reqCtx, folderID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return estimateTree(plan), nil
+}
+
+func estimateTree(plan *treePlan) *DownloadTreeEstimate {
+	estimate := &DownloadTreeEstimate{
+		Folders:       len(plan.dirs),
+		MaxPathLength: utils.MaxPathLength,
+	}
+
+	checkPath := func(path string) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if len(abs) > estimate.LongestPath {
+			estimate.LongestPath = len(abs)
+		}
+		if len(abs) <= utils.MaxPathLength && len(filepath.Base(abs)) <= utils.MaxNameLength {
+			return
+		}
+		estimate.LongPathCount++
+		if len(estimate.LongPaths) < maxReportedLongPaths {
+			estimate.LongPaths = append(estimate.LongPaths, path)
+		}
+	}
+
+	for _, dir := range plan.dirs {
+		checkPath(dir)
+	}
+	for _, entry := range plan.entries {
+		switch {
+		case entry.skipReason != "":
+			estimate.Skipped++
+			continue
+		case entry.failReason != "":
+			continue
+		case entry.exportMime != "":
+			estimate.Exports++
+		default:
+			estimate.Files++
+			estimate.TotalBytes += entry.file.Size
+		}
+		checkPath(entry.localPath)
+	}
+
+	if free, err := freeDiskSpace(plan.outputDir); err == nil {
+		freeBytes := int64(free)
+		estimate.FreeBytes = &freeBytes
+		if estimate.TotalBytes > freeBytes {
+			estimate.Problems = append(estimate.Problems, fmt.Sprintf("download needs %s but only %s is free at %s",
+				utils.FormatSize(estimate.TotalBytes), utils.FormatSize(freeBytes), plan.outputDir))
+		}
+	}
+	if estimate.LongPathCount > 0 {
+		estimate.Problems = append(estimate.Problems, fmt.Sprintf("%d local paths exceed the %d-byte path or %d-byte name limit",
+			estimate.LongPathCount, utils.MaxPathLength, utils.MaxNameLength))
+	}
+
+	return estimate
+}
+
+// preflightError turns a failed estimate into an error carrying the summary.
+// Insufficient space is reported as a resource limit; path problems alone
+// as an invalid path.
+func preflightError(estimate *DownloadTreeEstimate) error {
+	code := utils.ErrCodeInvalidPath
+	if estimate.FreeBytes != nil && estimate.TotalBytes > *estimate.FreeBytes {
+		code = utils.ErrCodeResourceLimit
+	}
+
+	builder := utils.NewCLIError(code, "Pre-flight check failed: "+strings.Join(estimate.Problems, "; ")).
+		WithContext("files", estimate.Files).
+		WithContext("exports", estimate.Exports).
+		WithContext("totalBytes", estimate.TotalBytes).
+		WithContext("suggestedAction", "free up space or choose a shorter --output directory; use --skip-preflight to download anyway")
+	if estimate.FreeBytes != nil {
+		builder = builder.WithContext("freeBytes", *estimate.FreeBytes)
+	}
+	if len(estimate.LongPaths) > 0 {
+		builder = builder.WithContext("longPaths", estimate.LongPaths)
+	}
+	return utils.NewAppError(builder.Build())
+}
@@ -0,0 +1,84 @@
+package files
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func stubFreeDiskSpace(t *testing.T, free uint64) {
+	t.Helper()
+	orig := freeDiskSpace
+	freeDiskSpace = func(string) (uint64, error) { return free, nil }
+	t.Cleanup(func() { freeDiskSpace = orig })
+}
+
+func testPlan(dir string) *treePlan {
+	return &treePlan{
+		outputDir: dir,
+		dirs:      []string{dir, filepath.Join(dir, "sub")},
+		entries: []*treeEntry{
+			{file: &types.DriveFile{ID: "1", Name: "a.bin", Size: 600}, localPath: filepath.Join(dir, "a.bin")},
+			{file: &types.DriveFile{ID: "2", Name: "b.bin", Size: 400}, localPath: filepath.Join(dir, "sub", "b.bin")},
+			{file: &types.DriveFile{ID: "3", Name: "Doc", MimeType: utils.MimeTypeDocument}, localPath: filepath.Join(dir, "Doc.docx"), exportMime: "application/pdf"},
+			{file: &types.DriveFile{ID: "4", Name: "Link", MimeType: utils.MimeTypeShortcut}, skipReason: "shortcuts are not followed"},
+		},
+	}
+}
+
+func TestEstimateTree_Totals(t *testing.T) {
+	stubFreeDiskSpace(t, 10000)
+
+	estimate := estimateTree(testPlan(t.TempDir()))
+	if estimate.Folders != 2 || estimate.Files != 2 || estimate.Exports != 1 || estimate.Skipped != 1 {
+		t.Errorf("counts = %+v", estimate)
+	}
+	if estimate.TotalBytes != 1000 {
+		t.Errorf("TotalBytes = %d, want 1000", estimate.TotalBytes)
+	}
+	if estimate.FreeBytes == nil || *estimate.FreeBytes != 10000 {
+		t.Errorf("FreeBytes = %v, want 10000", estimate.FreeBytes)
+	}
+	if len(estimate.Problems) != 0 {
+		t.Errorf("unexpected problems: %v", estimate.Problems)
+	}
+}
+
+func TestEstimateTree_InsufficientSpace(t *testing.T) {
+	stubFreeDiskSpace(t, 999)
+
+	estimate := estimateTree(testPlan(t.TempDir()))
+	if len(estimate.Problems) != 1 || !strings.Contains(estimate.Problems[0], "only 999 B is free") {
+		t.Fatalf("problems = %v", estimate.Problems)
+	}
+
+	appErr, ok := preflightError(estimate).(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeResourceLimit {
+		t.Errorf("expected %s, got %v", utils.ErrCodeResourceLimit, preflightError(estimate))
+	}
+}
+
+func TestEstimateTree_LongPaths(t *testing.T) {
+	stubFreeDiskSpace(t, 10000)
+
+	dir := t.TempDir()
+	plan := testPlan(dir)
+	longName := strings.Repeat("x", utils.MaxNameLength+1)
+	plan.entries = append(plan.entries, &treeEntry{
+		file:      &types.DriveFile{ID: "5", Name: longName},
+		localPath: filepath.Join(dir, longName),
+	})
+
+	estimate := estimateTree(plan)
+	if estimate.LongPathCount != 1 || len(estimate.LongPaths) != 1 {
+		t.Fatalf("LongPathCount = %d, LongPaths = %v", estimate.LongPathCount, estimate.LongPaths)
+	}
+
+	appErr, ok := preflightError(estimate).(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeInvalidPath {
+		t.Errorf("expected %s, got %v", utils.ErrCodeInvalidPath, preflightError(estimate))
+	}
+}
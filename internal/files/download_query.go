@@ -0,0 +1,323 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// DefaultDownloadWorkers is the number of concurrent downloads used by
+// DownloadQuery when no worker count is given
+const DefaultDownloadWorkers = 4
+
+// downloadRetryDelay is the wait before the first retry of a failed
+// download; each further retry waits twice as long
+var downloadRetryDelay = time.Second
+
+// Local naming schemes for DownloadQuery
+const (
+	NamingName   = "name"    // The Drive name
+	NamingNameID = "name-id" // The Drive name with the file ID before the extension
+	NamingID     = "id"      // The file ID and extension
+)
+
+// Conflict policies for files that already exist locally
+const (
+	ConflictRename    = "rename"    // Add " (n)" to the new file's name
+	ConflictSkip      = "skip"      // Keep the local file and skip the download
+	ConflictOverwrite = "overwrite" // Replace the local file
+	ConflictChecksum  = "checksum"  // Skip if the local file has the same MD5, otherwise replace it
+)
+
+// DownloadQueryOptions configures DownloadQuery
+type DownloadQueryOptions struct {
+	OutputDir      string            // Local directory to download into (required)
+	Workers        int               // Concurrent downloads and exports (default: DefaultDownloadWorkers)
+	Naming         string            // NamingName (default), NamingNameID or NamingID
+	OnConflict     string            // ConflictRename (default), ConflictSkip, ConflictOverwrite or ConflictChecksum
+	Retries        int               // Extra attempts for a file whose download fails with a transient error
+	MaxFiles       int               // Stop after this many matches (0 = unlimited)
+	IncludeTrashed bool              // Include trashed files
+	ExportFormats  map[string]string // Workspace MIME type to export MIME type overrides
+	Wait           bool              // Wait for long-running exports
+	Timeout        int               // Long-running export timeout in seconds
+	PollInterval   int               // Long-running export poll interval in seconds
+	SkipPreflight  bool              // Start downloading even if the disk-space or path-length checks fail
+	NoVerify       bool              // Skip checking downloaded files against Drive's checksums
+}
+
+// DownloadQuery downloads every file matching a Drive query into one
+// directory. Matches are listed page by page, named and checked against
+// existing local files first, and then downloaded by a pool of workers.
+// Workspace files are exported as in DownloadTree; folders never match.
+// A file that fails with a network, rate-limit or server error is retried
+// up to Retries more times with exponential backoff.
+func (m *Manager) DownloadQuery(ctx context.Context, reqCtx *types.RequestContext, q string, opts DownloadQueryOptions) (*DownloadTreeResult, error) {
+	if err := validateDownloadQueryOptions(q, &opts); err != nil {
+		return nil, err
+	}
+
+	plan, err := m.planQuery(ctx, reqCtx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+	estimate := estimateTree(plan)
+	result := &DownloadTreeResult{Query: q, OutputDir: plan.outputDir, Estimate: estimate, Items: []*DownloadTreeItem{}}
+	if len(estimate.Problems) > 0 && !opts.SkipPreflight {
+		return result, preflightError(estimate)
+	}
+	if err := os.MkdirAll(plan.outputDir, 0755); err != nil {
+		return result, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to create directory: %s", err)).Build())
+	}
+
+	var mu sync.Mutex
+	record := func(item *DownloadTreeItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch item.Status {
+		case TreeItemDownloaded:
+			result.Downloaded++
+		case TreeItemExported, TreeItemExportedViaLink:
+			result.Exported++
+		case TreeItemSkipped:
+			result.Skipped++
+		case TreeItemFailed:
+			result.Failed++
+		}
+		if item.Attempts > 1 {
+			result.Retried++
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	treeOpts := DownloadTreeOptions{Wait: opts.Wait, Timeout: opts.Timeout, PollInterval: opts.PollInterval, NoVerify: opts.NoVerify}
+	entries := make(chan *treeEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				record(m.downloadQueryEntry(ctx, reqCtx, entry, treeOpts, opts.Retries))
+			}
+		}()
+	}
+
+	var runErr error
+	for _, entry := range plan.entries {
+		select {
+		case entries <- entry:
+		case <-ctx.Done():
+			runErr = ctx.Err()
+		}
+		if runErr != nil {
+			break
+		}
+	}
+	close(entries)
+	wg.Wait()
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		return result.Items[i].Path < result.Items[j].Path
+	})
+	return result, runErr
+}
+
+// EstimateQuery lists the files matching a query and reports the download
+// size, free space and local path problems without downloading anything
+func (m *Manager) EstimateQuery(ctx context.Context, reqCtx *types.RequestContext, q string, opts DownloadQueryOptions) (*DownloadTreeEstimate, error) {
+	if err := validateDownloadQueryOptions(q, &opts); err != nil {
+		return nil, err
+	}
+	plan, err := m.planQuery(ctx, reqCtx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+	return estimateTree(plan), nil
+}
+
+func validateDownloadQueryOptions(q string, opts *DownloadQueryOptions) error {
+	explanation := query.Explain(q)
+	if !explanation.Valid {
+		msg := "Invalid query"
+		if len(explanation.Errors) > 0 {
+			msg = fmt.Sprintf("Invalid query: %s at column %d", explanation.Errors[0].Message, explanation.Errors[0].Position)
+		}
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, msg).
+			WithContext("query", q).
+			WithContext("suggestedAction", "check the query with 'gdrv query explain'").
+			Build())
+	}
+	if opts.OutputDir == "" {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, "An output directory is required").Build())
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultDownloadWorkers
+	}
+	if opts.Retries < 0 {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, "Retries cannot be negative").Build())
+	}
+	switch opts.Naming {
+	case "":
+		opts.Naming = NamingName
+	case NamingName, NamingNameID, NamingID:
+	default:
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("invalid naming %q (valid: %s, %s, %s)", opts.Naming, NamingName, NamingNameID, NamingID)).Build())
+	}
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = ConflictRename
+	case ConflictRename, ConflictSkip, ConflictOverwrite, ConflictChecksum:
+	default:
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("invalid conflict policy %q (valid: %s, %s, %s, %s)", opts.OnConflict, ConflictRename, ConflictSkip, ConflictOverwrite, ConflictChecksum)).Build())
+	}
+	return nil
+}
+
+// planQuery lists the matches and assigns each its local path
+func (m *Manager) planQuery(ctx context.Context, reqCtx *types.RequestContext, q string, opts DownloadQueryOptions) (*treePlan, error) {
+	plan := &treePlan{outputDir: opts.OutputDir}
+	used := make(map[string]int)
+	errStop := fmt.Errorf("stop")
+
+	err := m.ListEach(ctx, reqCtx, ListOptions{
+		Query:          query.NewBuilder().Raw(q).Where("mimeType", "!=", utils.MimeTypeFolder).String(),
+		PageSize:       1000,
+		IncludeTrashed: opts.IncludeTrashed,
+		Fields:         "id,name,mimeType,size,md5Checksum,sha256Checksum,modifiedTime,capabilities(canDownload),exportLinks,resourceKey",
+	}, func(f *types.DriveFile) error {
+		if opts.MaxFiles > 0 && len(plan.entries) >= opts.MaxFiles {
+			return errStop
+		}
+		entry := &treeEntry{file: f}
+		ext := ""
+		switch {
+		case f.MimeType == utils.MimeTypeShortcut:
+			entry.skipReason = "shortcuts are not followed"
+		case utils.IsWorkspaceMimeType(f.MimeType):
+			entry.exportMime = exportFormatFor(f.MimeType, opts.ExportFormats, m.exportDefaults)
+			if entry.exportMime == "" {
+				entry.skipReason = "no export format for this type"
+			}
+			ext = exportExtension(entry.exportMime)
+		}
+		if entry.skipReason == "" {
+			entry.localPath, entry.skipReason = queryLocalPath(opts, used, f, ext)
+		}
+		plan.entries = append(plan.entries, entry)
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// queryLocalPath names a match in the output directory. Names already used
+// by this download are always disambiguated; names taken by existing local
+// files follow the conflict policy. ConflictChecksum compares the local file
+// with Drive's MD5; Workspace exports have none and are always replaced.
+func queryLocalPath(opts DownloadQueryOptions, used map[string]int, f *types.DriveFile, ext string) (string, string) {
+	name := sanitizeLocalName(f.Name) + ext
+	switch opts.Naming {
+	case NamingNameID:
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		name = fmt.Sprintf("%s [%s]%s", base, f.ID, filepath.Ext(name))
+	case NamingID:
+		name = sanitizeLocalName(f.ID) + filepath.Ext(name)
+	}
+
+	for {
+		path := filepath.Join(opts.OutputDir, uniqueLocalName(used, name))
+		if _, err := os.Lstat(path); err != nil {
+			return path, ""
+		}
+		switch opts.OnConflict {
+		case ConflictSkip:
+			return "", "exists locally: " + path
+		case ConflictOverwrite:
+			return path, ""
+		case ConflictChecksum:
+			if f.MD5Checksum != "" {
+				if sum, err := fileMD5(path); err == nil && strings.EqualFold(sum, f.MD5Checksum) {
+					return "", "unchanged: " + path
+				}
+			}
+			return path, ""
+		}
+		// ConflictRename: the next pass picks "name (n)"
+	}
+}
+
+// downloadQueryEntry downloads or exports one match, retrying transient
+// failures up to retries more times
+func (m *Manager) downloadQueryEntry(ctx context.Context, reqCtx *types.RequestContext, entry *treeEntry, opts DownloadTreeOptions, retries int) *DownloadTreeItem {
+	delay := downloadRetryDelay
+	for attempt := 1; ; attempt++ {
+		item, err := m.downloadQueryAttempt(ctx, reqCtx, entry, opts)
+		if attempt > 1 {
+			item.Attempts = attempt
+		}
+		if err == nil || attempt > retries || !isRetryableDownload(err) {
+			return item
+		}
+		select {
+		case <-ctx.Done():
+			return item
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (m *Manager) downloadQueryAttempt(ctx context.Context, reqCtx *types.RequestContext, entry *treeEntry, opts DownloadTreeOptions) (*DownloadTreeItem, error) {
+	f := entry.file
+	item := &DownloadTreeItem{FileID: f.ID, Name: f.Name, Path: entry.localPath, MimeType: f.MimeType}
+	switch {
+	case entry.skipReason != "":
+		item.Status, item.Error = TreeItemSkipped, entry.skipReason
+	case entry.exportMime != "":
+		return m.exportTreeItemErr(ctx, reqCtx, exportJob{file: f, localPath: entry.localPath, mimeType: entry.exportMime}, opts)
+	default:
+		err := checkCapabilities(f, CapabilityDownload)
+		if err == nil {
+			item.Verification, err = m.downloadToPath(ctx, childRequestContext(reqCtx, f.ID), f, entry.localPath, opts.NoVerify)
+		}
+		if err != nil {
+			item.Status, item.Error = TreeItemFailed, err.Error()
+			return item, err
+		}
+		item.Status = TreeItemDownloaded
+	}
+	return item, nil
+}
+
+// isRetryableDownload reports whether a failed download is worth another
+// attempt. Interrupted transfers and 429/5xx responses are; other API
+// errors, such as a missing file or denied access, and local errors are not.
+func isRetryableDownload(err error) bool {
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch appErr.CLIError.Code {
+	case utils.ErrCodeNetworkError, utils.ErrCodeTimeout, utils.ErrCodeRateLimited:
+		status := appErr.CLIError.HTTPStatus
+		return status == 0 || status == http.StatusTooManyRequests || status >= 500
+	}
+	return appErr.CLIError.Retryable
+}
@@ -0,0 +1,218 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// queryDrive serves its files over two pages of any files.list call.
+// A download of a file in failures answers 503 that many times first;
+// a file without content is not found.
+type queryDrive struct {
+	mu       sync.Mutex
+	files    []*drive.File
+	content  map[string]string
+	failures map[string]int
+	queries  []string
+}
+
+func (d *queryDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/drive/v3/")
+	switch {
+	case path == "files":
+		d.queries = append(d.queries, r.URL.Query().Get("q"))
+		half := len(d.files) / 2
+		if r.URL.Query().Get("pageToken") == "" {
+			_ = json.NewEncoder(w).Encode(&drive.FileList{Files: d.files[:half], NextPageToken: "p2"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&drive.FileList{Files: d.files[half:]})
+	case strings.HasSuffix(path, "/export"):
+		_, _ = io.WriteString(w, d.content[strings.TrimSuffix(strings.TrimPrefix(path, "files/"), "/export")])
+	case r.URL.Query().Get("alt") == "media":
+		id := strings.TrimPrefix(path, "files/")
+		content, ok := d.content[id]
+		switch {
+		case d.failures[id] > 0:
+			d.failures[id]--
+			http.Error(w, "backend error", http.StatusServiceUnavailable)
+		case !ok:
+			http.NotFound(w, r)
+		default:
+			_, _ = io.WriteString(w, content)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newQueryManager(t *testing.T, d *queryDrive) *Manager {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, d)
+	return NewManager(client)
+}
+
+func TestDownloadQuery(t *testing.T) {
+	d := &queryDrive{
+		files: []*drive.File{
+			{Id: "r1", Name: "report.pdf", MimeType: "application/pdf", Size: 2},
+			{Id: "r2", Name: "report.pdf", MimeType: "application/pdf", Size: 2},
+			{Id: "doc", Name: "Notes", MimeType: utils.MimeTypeDocument},
+			{Id: "inv", Name: "invoice.pdf", MimeType: "application/pdf", Size: 2},
+		},
+		content: map[string]string{"r1": "R1", "r2": "R2", "doc": "notes", "inv": "IN"},
+	}
+	mgr := newQueryManager(t, d)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	out := t.TempDir()
+	if err := os.WriteFile(filepath.Join(out, "invoice.pdf"), []byte("local"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	q := "mimeType = 'application/pdf' or name contains 'Notes'"
+	result, err := mgr.DownloadQuery(context.Background(), reqCtx, q, DownloadQueryOptions{OutputDir: out, Workers: 3, OnConflict: ConflictSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Query != q || result.Downloaded != 2 || result.Exported != 1 || result.Skipped != 1 || result.Failed != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if want := "(" + q + ") and mimeType != '" + utils.MimeTypeFolder + "'"; !strings.Contains(d.queries[0], want) {
+		t.Errorf("query = %q, want it to contain %q", d.queries[0], want)
+	}
+	if got := readFile(t, filepath.Join(out, "report.pdf")) + readFile(t, filepath.Join(out, "report (1).pdf")); got != "R1R2" {
+		t.Errorf("same-name downloads = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "Notes.docx")); got != "notes" {
+		t.Errorf("export = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "invoice.pdf")); got != "local" {
+		t.Errorf("existing file was replaced: %q", got)
+	}
+
+	// Renaming around existing files, with the ID in the name
+	result, err = mgr.DownloadQuery(context.Background(), reqCtx, "mimeType = 'application/pdf'", DownloadQueryOptions{OutputDir: out, Naming: NamingNameID, MaxFiles: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Path != filepath.Join(out, "report [r1].pdf") {
+		t.Errorf("name-id items = %+v", result.Items[0])
+	}
+	if _, err := os.Stat(filepath.Join(out, "report [r1].pdf")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDownloadQuery_Validation(t *testing.T) {
+	mgr := newQueryManager(t, &queryDrive{})
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	cases := []struct {
+		query string
+		opts  DownloadQueryOptions
+	}{
+		{"name = ", DownloadQueryOptions{OutputDir: "x"}},
+		{"name = 'a'", DownloadQueryOptions{}},
+		{"name = 'a'", DownloadQueryOptions{OutputDir: "x", Naming: "title"}},
+		{"name = 'a'", DownloadQueryOptions{OutputDir: "x", OnConflict: "merge"}},
+	}
+	for _, c := range cases {
+		_, err := mgr.DownloadQuery(context.Background(), reqCtx, c.query, c.opts)
+		appErr, ok := err.(*utils.AppError)
+		if !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+			t.Errorf("%q %+v: err = %v", c.query, c.opts, err)
+		}
+	}
+}
+
+func TestQueryLocalPath_Rename(t *testing.T) {
+	out := t.TempDir()
+	for _, name := range []string{"a.txt", "a (1).txt"} {
+		if err := os.WriteFile(filepath.Join(out, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := DownloadQueryOptions{OutputDir: out, Naming: NamingName, OnConflict: ConflictRename}
+	path, skip := queryLocalPath(opts, map[string]int{}, &types.DriveFile{ID: "x", Name: "a.txt"}, "")
+	if skip != "" || path != filepath.Join(out, "a (2).txt") {
+		t.Errorf("path = %q, skip = %q", path, skip)
+	}
+
+	opts.OnConflict = ConflictOverwrite
+	if path, _ := queryLocalPath(opts, map[string]int{}, &types.DriveFile{ID: "x", Name: "a.txt"}, ""); path != filepath.Join(out, "a.txt") {
+		t.Errorf("overwrite path = %q", path)
+	}
+}
+
+func TestDownloadQuery_Retries(t *testing.T) {
+	defer func(d time.Duration) { downloadRetryDelay = d }(downloadRetryDelay)
+	downloadRetryDelay = time.Millisecond
+
+	d := &queryDrive{
+		files: []*drive.File{
+			{Id: "flaky", Name: "flaky.bin", Size: 2},
+			{Id: "down", Name: "down.bin", Size: 2},
+			{Id: "gone", Name: "gone.bin", Size: 2},
+		},
+		content:  map[string]string{"flaky": "OK", "down": "NO"},
+		failures: map[string]int{"flaky": 2, "down": 5},
+	}
+	mgr := newQueryManager(t, d)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	out := t.TempDir()
+
+	result, err := mgr.DownloadQuery(context.Background(), reqCtx, "name contains '.bin'", DownloadQueryOptions{OutputDir: out, Retries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Downloaded != 1 || result.Failed != 2 || result.Retried != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	attempts := map[string]int{}
+	for _, item := range result.Items {
+		attempts[item.FileID] = item.Attempts
+	}
+	// A 404 is not retried; the others use every attempt they need
+	if attempts["flaky"] != 3 || attempts["down"] != 3 || attempts["gone"] != 0 {
+		t.Errorf("attempts = %v", attempts)
+	}
+	if got := readFile(t, filepath.Join(out, "flaky.bin")); got != "OK" {
+		t.Errorf("flaky.bin = %q", got)
+	}
+	if d.failures["down"] != 2 {
+		t.Errorf("down was requested %d times, want 3", 5-d.failures["down"])
+	}
+}
+
+func TestQueryLocalPath_Checksum(t *testing.T) {
+	out := t.TempDir()
+	if err := os.WriteFile(filepath.Join(out, "a.txt"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts := DownloadQueryOptions{OutputDir: out, Naming: NamingName, OnConflict: ConflictChecksum}
+	helloMD5 := "5d41402abc4b2a76b9719d911017c592"
+
+	if path, skip := queryLocalPath(opts, map[string]int{}, &types.DriveFile{Name: "a.txt", MD5Checksum: helloMD5}, ""); path != "" || !strings.HasPrefix(skip, "unchanged") {
+		t.Errorf("same content: path = %q, skip = %q", path, skip)
+	}
+	for _, sum := range []string{"00000000000000000000000000000000", ""} {
+		if path, skip := queryLocalPath(opts, map[string]int{}, &types.DriveFile{Name: "a.txt", MD5Checksum: sum}, ""); path != filepath.Join(out, "a.txt") || skip != "" {
+			t.Errorf("md5 %q: path = %q, skip = %q", sum, path, skip)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// DefaultExportWorkers is the number of concurrent Workspace exports used by
+// DownloadTree when no worker count is given.
+const DefaultExportWorkers = 4
+
+// DefaultExportFormats maps Workspace MIME types to the export format used
+// when downloading a folder tree. Types without an entry (e.g. Forms) cannot
+// be exported and are skipped.
+var DefaultExportFormats = map[string]string{
+	utils.MimeTypeDocument:     utils.FormatMappings["docx"],
+	utils.MimeTypeSpreadsheet:  utils.FormatMappings["xlsx"],
+	utils.MimeTypePresentation: utils.FormatMappings["pptx"],
+	utils.MimeTypeDrawing:      utils.FormatMappings["pdf"],
+	utils.MimeTypeScript:       "application/vnd.google-apps.script+json",
+}
+
+// Tree download item statuses
+const (
+	TreeItemDownloaded      = "downloaded"
+	TreeItemExported        = "exported"
+	TreeItemExportedViaLink = "exported_via_link"
+	TreeItemSkipped         = "skipped"
+	TreeItemFailed          = "failed"
+)
+
+// DownloadTreeOptions configures recursive folder download
+type DownloadTreeOptions struct {
+	OutputDir     string            // Local directory to download into (default: folder name)
+	ExportWorkers int               // Concurrent Workspace exports (default: DefaultExportWorkers)
+	ExportFormats map[string]string // Workspace MIME type to export MIME type overrides
+	Wait          bool              // Wait for long-running exports
+	Timeout       int               // Long-running export timeout in seconds
+	PollInterval  int               // Long-running export poll interval in seconds
+	SkipPreflight bool              // Start downloading even if the disk-space or path-length checks fail
+	ChangesToken  string            // Download only what changed since this Changes API token, or ChangesTokenAuto
+	NoVerify      bool              // Skip checking downloaded files against Drive's checksums
+}
+
+// DownloadTreeItem reports the outcome for a single file in a tree download
+type DownloadTreeItem struct {
+	FileID         string `json:"fileId"`
+	Name           string `json:"name"`
+	Path           string `json:"path"`
+	MimeType       string `json:"mimeType"`
+	ExportMimeType string `json:"exportMimeType,omitempty"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	Attempts       int    `json:"attempts,omitempty"`
+
+	Verification *types.ChecksumVerification `json:"verification,omitempty"`
+}
+
+// DownloadTreeResult summarizes a recursive folder download, or a download
+// of the files matching Query. Incremental
+// downloads also report moved and removed items, and the change token to
+// resume from; FullResync says why a full download was done instead.
+type DownloadTreeResult struct {
+	FolderID    string                `json:"folderId,omitempty"`
+	Query       string                `json:"query,omitempty"`
+	OutputDir   string                `json:"outputDir"`
+	Downloaded  int                   `json:"downloaded"`
+	Exported    int                   `json:"exported"`
+	Skipped     int                   `json:"skipped"`
+	Failed      int                   `json:"failed"`
+	Retried     int                   `json:"retried,omitempty"`
+	Moved       int                   `json:"moved,omitempty"`
+	Removed     int                   `json:"removed,omitempty"`
+	Incremental bool                  `json:"incremental,omitempty"`
+	FullResync  string                `json:"fullResync,omitempty"`
+	ChangeToken string                `json:"changeToken,omitempty"`
+	Estimate    *DownloadTreeEstimate `json:"estimate,omitempty"`
+	Items       []*DownloadTreeItem   `json:"items"`
+}
+
+type exportJob struct {
+	file      *types.DriveFile
+	localPath string
+	mimeType  string
+}
+
+// treeEntry is a single file in a planned tree download. Exactly one of
+// exportMime or skipReason is set for Workspace and skipped files; blob
+// files have neither. A subfolder that could not be listed is planned as
+// an entry with failReason set.
+type treeEntry struct {
+	file       *types.DriveFile
+	parentID   string
+	localPath  string
+	exportMime string
+	skipReason string
+	failReason string
+}
+
+// treePlan is the result of walking a folder tree before anything is
+// written locally. Dirs are in creation order (parents first); folders
+// holds the same directories with their Drive IDs.
+type treePlan struct {
+	outputDir string
+	dirs      []string
+	folders   []treeFolder
+	entries   []*treeEntry
+}
+
+type treeFolder struct {
+	id       string
+	parentID string
+	dir      string
+}
+
+// hasFailedFolders reports whether a subfolder could not be listed
+func (p *treePlan) hasFailedFolders() bool {
+	for _, entry := range p.entries {
+		if entry.failReason != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadTree downloads a folder and all of its contents, preserving the
+// folder structure locally. The whole tree is listed first so the download
+// can be estimated and checked against free disk space and local path
+// limits; see EstimateTree. Blob files are then downloaded in order, while
+// Workspace files are handed to a dedicated export worker pool.
+//
+// A failure on one file, or on listing one subfolder, is recorded in the
+// result and does not abort the rest of the tree. Exports that exceed the 10MB export limit fall back to
+// downloading through the file's exportLinks.
+//
+// With opts.ChangesToken set, only what changed since the last run is
+// downloaded; see downloadTreeChanges.
+func (m *Manager) DownloadTree(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts DownloadTreeOptions) (*DownloadTreeResult, error) {
+	if opts.ChangesToken != "" {
+		return m.downloadTreeChanges(ctx, reqCtx, folderID, opts)
+	}
+	plan, err := m.planTree(ctx, reqCtx, folderID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return m.downloadTreePlan(ctx, reqCtx, folderID, plan, opts)
+}
+
+// downloadTreePlan checks a planned tree download and runs it
+func (m *Manager) downloadTreePlan(ctx context.Context, reqCtx *types.RequestContext, folderID string, plan *treePlan, opts DownloadTreeOptions) (*DownloadTreeResult, error) {
+	estimate := estimateTree(plan)
+	result := &DownloadTreeResult{FolderID: folderID, OutputDir: plan.outputDir, Estimate: estimate}
+	if len(estimate.Problems) > 0 && !opts.SkipPreflight {
+		return result, preflightError(estimate)
+	}
+
+	workers := opts.ExportWorkers
+	if workers <= 0 {
+		workers = DefaultExportWorkers
+	}
+
+	var mu sync.Mutex
+	record := func(item *DownloadTreeItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch item.Status {
+		case TreeItemDownloaded:
+			result.Downloaded++
+		case TreeItemExported, TreeItemExportedViaLink:
+			result.Exported++
+		case TreeItemSkipped:
+			result.Skipped++
+		case TreeItemFailed:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files download", Done: len(result.Items), Total: len(plan.entries), Item: item.Name})
+	}
+
+	for _, dir := range plan.dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return result, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Failed to create directory: %s", err)).Build())
+		}
+	}
+
+	jobs := make(chan exportJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				record(m.exportTreeItem(ctx, reqCtx, job, opts))
+			}
+		}()
+	}
+
+	runErr := m.runTreePlan(ctx, reqCtx, plan, opts, jobs, record)
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		return result.Items[i].Path < result.Items[j].Path
+	})
+
+	return result, runErr
+}
+
+// planTree lists a folder tree and assigns every file its local path
+// without touching the local filesystem.
+func (m *Manager) planTree(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts DownloadTreeOptions) (*treePlan, error) {
+	folder, err := m.Get(ctx, reqCtx, folderID, "id,name,mimeType")
+	if err != nil {
+		return nil, err
+	}
+	if folder.MimeType != utils.MimeTypeFolder {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("'%s' is not a folder", folder.Name)).
+			WithContext("fileId", folderID).
+			WithContext("mimeType", folder.MimeType).
+			Build())
+	}
+
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = sanitizeLocalName(folder.Name)
+	}
+
+	plan := &treePlan{outputDir: outputDir}
+	if err := m.planFolder(ctx, reqCtx, folderID, "", outputDir, opts, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (m *Manager) planFolder(ctx context.Context, reqCtx *types.RequestContext, folderID, parentID, localDir string, opts DownloadTreeOptions, plan *treePlan) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	plan.dirs = append(plan.dirs, localDir)
+	plan.folders = append(plan.folders, treeFolder{id: folderID, parentID: parentID, dir: localDir})
+
+	children, err := m.ListAll(ctx, reqCtx, ListOptions{
+		ParentID: folderID,
+		PageSize: 1000,
+		Fields:   "id,name,mimeType,size,md5Checksum,sha256Checksum,modifiedTime,capabilities(canDownload),exportLinks,resourceKey",
+	})
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]int)
+	for _, child := range children {
+		if child.MimeType == utils.MimeTypeFolder {
+			dir := filepath.Join(localDir, uniqueLocalName(used, sanitizeLocalName(child.Name)))
+			if err := m.planFolder(ctx, reqCtx, child.ID, folderID, dir, opts, plan); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				plan.entries = append(plan.entries, &treeEntry{file: child, parentID: folderID, failReason: "failed to list folder: " + err.Error()})
+			}
+			continue
+		}
+
+		entry := &treeEntry{file: child, parentID: folderID}
+		switch {
+		case child.MimeType == utils.MimeTypeShortcut:
+			entry.skipReason = "shortcuts are not followed"
+		case utils.IsWorkspaceMimeType(child.MimeType):
+			entry.exportMime = exportFormatFor(child.MimeType, opts.ExportFormats, m.exportDefaults)
+			if entry.exportMime == "" {
+				entry.skipReason = "no export format for this type"
+			} else {
+				entry.localPath = filepath.Join(localDir, uniqueLocalName(used, sanitizeLocalName(child.Name)+exportExtension(entry.exportMime)))
+			}
+		default:
+			entry.localPath = filepath.Join(localDir, uniqueLocalName(used, sanitizeLocalName(child.Name)))
+		}
+		plan.entries = append(plan.entries, entry)
+	}
+
+	return nil
+}
+
+// runTreePlan downloads blob files in plan order and queues exports
+func (m *Manager) runTreePlan(ctx context.Context, reqCtx *types.RequestContext, plan *treePlan, opts DownloadTreeOptions, jobs chan<- exportJob, record func(*DownloadTreeItem)) error {
+	for _, entry := range plan.entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		child := entry.file
+		item := &DownloadTreeItem{
+			FileID:   child.ID,
+			Name:     child.Name,
+			MimeType: child.MimeType,
+		}
+
+		if entry.skipReason != "" {
+			item.Status = TreeItemSkipped
+			item.Error = entry.skipReason
+			record(item)
+			continue
+		}
+		if entry.failReason != "" {
+			item.Status = TreeItemFailed
+			item.Error = entry.failReason
+			record(item)
+			continue
+		}
+
+		if entry.exportMime != "" {
+			select {
+			case jobs <- exportJob{file: child, localPath: entry.localPath, mimeType: entry.exportMime}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+
+		item.Path = entry.localPath
+		if err := checkCapabilities(child, CapabilityDownload); err != nil {
+			item.Status = TreeItemFailed
+			item.Error = err.Error()
+			record(item)
+			continue
+		}
+		verification, err := m.downloadToPath(ctx, childRequestContext(reqCtx, child.ID), child, item.Path, opts.NoVerify)
+		item.Verification = verification
+		if err != nil {
+			item.Status = TreeItemFailed
+			item.Error = err.Error()
+		} else {
+			item.Status = TreeItemDownloaded
+		}
+		record(item)
+	}
+
+	return nil
+}
+
+func (m *Manager) exportTreeItem(ctx context.Context, reqCtx *types.RequestContext, job exportJob, opts DownloadTreeOptions) *DownloadTreeItem {
+	item, _ := m.exportTreeItemErr(ctx, reqCtx, job, opts)
+	return item
+}
+
+// exportTreeItemErr is exportTreeItem, also returning the error that
+// failed the item
+func (m *Manager) exportTreeItemErr(ctx context.Context, reqCtx *types.RequestContext, job exportJob, opts DownloadTreeOptions) (*DownloadTreeItem, error) {
+	item := &DownloadTreeItem{
+		FileID:         job.file.ID,
+		Name:           job.file.Name,
+		Path:           job.localPath,
+		MimeType:       job.file.MimeType,
+		ExportMimeType: job.mimeType,
+	}
+
+	out, err := tempdir.CreatePartial(job.localPath)
+	if err != nil {
+		item.Status = TreeItemFailed
+		item.Error = fmt.Sprintf("failed to create output file: %s", err)
+		return item, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, item.Error).Build())
+	}
+	defer out.Discard()
+
+	status, err := m.exportToFile(ctx, childRequestContext(reqCtx, job.file.ID), job.file, DownloadOptions{
+		MimeType:     job.mimeType,
+		Wait:         opts.Wait,
+		Timeout:      opts.Timeout,
+		PollInterval: opts.PollInterval,
+	}, out.File)
+	if err == nil {
+		err = out.Commit()
+	}
+	if err == nil {
+		item.Status = status
+		return item, nil
+	}
+
+	item.Status = TreeItemFailed
+	item.Error = err.Error()
+	return item, err
+}
+
+// exportToFile exports a Workspace file into f. Exports over the 10MB export
+// limit are retried through the file's exportLinks. It returns
+// TreeItemExported or TreeItemExportedViaLink.
+func (m *Manager) exportToFile(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, opts DownloadOptions, f *os.File) (string, error) {
+	err := m.exportFile(ctx, reqCtx, file.ID, file, opts, f)
+	if err == nil {
+		return TreeItemExported, nil
+	}
+
+	link := file.ExportLinks[opts.MimeType]
+	if isExportSizeLimit(err) && link != "" && m.client.HTTPClient() != nil {
+		if _, seekErr := f.Seek(0, 0); seekErr == nil && f.Truncate(0) == nil {
+			if err = api.DownloadFromURI(ctx, m.client.HTTPClient(), link, f); err == nil {
+				return TreeItemExportedViaLink, nil
+			}
+		}
+	}
+	return "", err
+}
+
+// downloadToPath downloads a blob file to path, checking it against
+// Drive's checksum unless noVerify is set. A file that fails the check is
+// never moved into place.
+func (m *Manager) downloadToPath(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, path string, noVerify bool) (*types.ChecksumVerification, error) {
+	out, err := tempdir.CreatePartial(path)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to create output file: %s", err)).Build())
+	}
+	defer out.Discard()
+
+	verification, err := m.downloadVerified(ctx, reqCtx, file, noVerify, out)
+	if err != nil {
+		return nil, err
+	}
+	return verification, out.Commit()
+}
+
+// childRequestContext derives a per-file request context that shares the
+// parent's trace ID, so concurrent workers never append to the same slices.
+func childRequestContext(parent *types.RequestContext, fileID string) *types.RequestContext {
+	return &types.RequestContext{
+		Profile:           parent.Profile,
+		DriveID:           parent.DriveID,
+		InvolvedFileIDs:   []string{fileID},
+		InvolvedParentIDs: []string{},
+		RequestType:       types.RequestTypeDownloadOrExport,
+		TraceID:           parent.TraceID,
+	}
+}
+
+// exportFormatFor returns the export format of a Workspace type in a
+// recursive download: the per-run override, else the configured default,
+// else DefaultExportFormats
+func exportFormatFor(mimeType string, overrides, defaults map[string]string) string {
+	if f, ok := overrides[mimeType]; ok {
+		return f
+	}
+	if f, ok := defaults[mimeType]; ok {
+		return f
+	}
+	return DefaultExportFormats[mimeType]
+}
+
+func exportExtension(exportMimeType string) string {
+	if exportMimeType == "application/vnd.google-apps.script+json" {
+		return ".json"
+	}
+	for ext, mime := range utils.FormatMappings {
+		if mime == exportMimeType {
+			return "." + ext
+		}
+	}
+	return ""
+}
+
+func isExportSizeLimit(err error) bool {
+	appErr, ok := err.(*utils.AppError)
+	return ok && appErr.CLIError.Code == utils.ErrCodeExportSizeLimit
+}
+
+// sanitizeLocalName makes a Drive name safe to use as a single path element
+func sanitizeLocalName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", "\x00", "_").Replace(name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// uniqueLocalName disambiguates sibling names, since Drive allows duplicate
+// names within a folder but local filesystems do not.
+func uniqueLocalName(used map[string]int, name string) string {
+	n := used[name]
+	used[name] = n + 1
+	if n == 0 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	candidate := fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+	if _, taken := used[candidate]; taken {
+		return uniqueLocalName(used, candidate)
+	}
+	used[candidate] = 1
+	return candidate
+}
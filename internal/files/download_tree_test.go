@@ -0,0 +1,128 @@
+package files
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestUniqueLocalName(t *testing.T) {
+	used := make(map[string]int)
+	got := []string{
+		uniqueLocalName(used, "report.pdf"),
+		uniqueLocalName(used, "report.pdf"),
+		uniqueLocalName(used, "report.pdf"),
+		uniqueLocalName(used, "notes"),
+		uniqueLocalName(used, "report (1).pdf"),
+	}
+	want := []string{"report.pdf", "report (1).pdf", "report (2).pdf", "notes", "report (1) (1).pdf"}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("name %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSanitizeLocalName(t *testing.T) {
+	tests := map[string]string{
+		"Budget 2024":   "Budget 2024",
+		"Q1/Q2 results": "Q1_Q2 results",
+		`a\b`:           "a_b",
+		"  ":            "_",
+		"..":            "_",
+	}
+	for in, want := range tests {
+		if got := sanitizeLocalName(in); got != want {
+			t.Errorf("sanitizeLocalName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExportFormatFor(t *testing.T) {
+	if got := exportFormatFor(utils.MimeTypeDocument, nil, nil); got != utils.FormatMappings["docx"] {
+		t.Errorf("default Document format = %q", got)
+	}
+	if got := exportFormatFor(utils.MimeTypeForm, nil, nil); got != "" {
+		t.Errorf("Form should not be exportable, got %q", got)
+	}
+
+	overrides := map[string]string{utils.MimeTypeDocument: "application/pdf"}
+	if got := exportFormatFor(utils.MimeTypeDocument, overrides, nil); got != "application/pdf" {
+		t.Errorf("override Document format = %q", got)
+	}
+	if got := exportFormatFor(utils.MimeTypeSpreadsheet, overrides, nil); got != utils.FormatMappings["xlsx"] {
+		t.Errorf("Spreadsheet should keep default, got %q", got)
+	}
+
+	defaults := map[string]string{utils.MimeTypeDocument: "application/vnd.oasis.opendocument.text", utils.MimeTypeSpreadsheet: "text/csv"}
+	if got := exportFormatFor(utils.MimeTypeDocument, overrides, defaults); got != "application/pdf" {
+		t.Errorf("override should win over the configured default, got %q", got)
+	}
+	if got := exportFormatFor(utils.MimeTypeSpreadsheet, overrides, defaults); got != "text/csv" {
+		t.Errorf("configured Spreadsheet format = %q", got)
+	}
+	if got := exportFormatFor(utils.MimeTypePresentation, overrides, defaults); got != utils.FormatMappings["pptx"] {
+		t.Errorf("Presentation should keep the built-in default, got %q", got)
+	}
+}
+
+func TestExportExtension(t *testing.T) {
+	tests := map[string]string{
+		utils.FormatMappings["docx"]:              ".docx",
+		"application/pdf":                         ".pdf",
+		"application/vnd.google-apps.script+json": ".json",
+		"application/x-unknown":                   "",
+	}
+	for mime, want := range tests {
+		if got := exportExtension(mime); got != want {
+			t.Errorf("exportExtension(%q) = %q, want %q", mime, got, want)
+		}
+	}
+}
+
+func TestDownloadTree_IsolatesFolderListingFailure(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		switch {
+		case r.URL.Query().Get("alt") == "media":
+			_, _ = w.Write([]byte("data"))
+		case strings.Contains(q, "'root1' in parents"):
+			_, _ = w.Write([]byte(`{"files":[
+				{"id":"ok","name":"ok","mimeType":"` + utils.MimeTypeFolder + `"},
+				{"id":"locked","name":"locked","mimeType":"` + utils.MimeTypeFolder + `"},
+				{"id":"a","name":"a.txt","mimeType":"text/plain","capabilities":{"canDownload":true}}]}`))
+		case strings.Contains(q, "'ok' in parents"):
+			_, _ = w.Write([]byte(`{"files":[{"id":"b","name":"b.txt","mimeType":"text/plain","capabilities":{"canDownload":true}}]}`))
+		case strings.Contains(q, "'locked' in parents"):
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"denied","errors":[{"reason":"insufficientFilePermissions"}]}}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"root1","name":"root","mimeType":"` + utils.MimeTypeFolder + `"}`))
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+
+	result, err := mgr.DownloadTree(ctx, reqCtx, "root1", DownloadTreeOptions{OutputDir: filepath.Join(t.TempDir(), "root"), SkipPreflight: true})
+	if err != nil {
+		t.Fatalf("a subfolder listing failure should not abort the download: %v", err)
+	}
+	if result.Downloaded != 2 || result.Failed != 1 {
+		t.Fatalf("downloaded %d, failed %d, want 2 and 1", result.Downloaded, result.Failed)
+	}
+	for _, item := range result.Items {
+		if item.Status == TreeItemFailed && (item.FileID != "locked" || !strings.Contains(item.Error, "failed to list folder")) {
+			t.Errorf("failed item = %+v", item)
+		}
+	}
+}
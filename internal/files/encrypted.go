@@ -0,0 +1,136 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/encryption"
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// EncryptedSuffix is appended to the Drive name of encrypted uploads
+const EncryptedSuffix = ".enc"
+
+// appProperties recording how a file was encrypted and what it was.
+// Only the algorithm and key ID are needed to decrypt.
+const (
+	propEncryption = "gdrvEncryption"
+	propKeyID      = "gdrvKeyId"
+	propName       = "gdrvName"
+	propMimeType   = "gdrvMimeType"
+	propSize       = "gdrvSize"
+)
+
+// encryptForUpload encrypts file to a temp file and returns it with the
+// appProperties describing the plaintext. The caller removes the temp file.
+func encryptForUpload(file *os.File, name, mimeType string, size int64, key *encryption.Key) (*os.File, map[string]string, error) {
+	sealed, err := tempdir.CreateTemp("encrypt-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := encryption.Encrypt(sealed, file, key); err != nil {
+		sealed.Close()
+		os.Remove(sealed.Name())
+		return nil, nil, fmt.Errorf("failed to encrypt %s: %w", name, err)
+	}
+	if _, err := sealed.Seek(0, 0); err != nil {
+		sealed.Close()
+		os.Remove(sealed.Name())
+		return nil, nil, err
+	}
+
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	props := map[string]string{
+		propEncryption: encryption.Algorithm,
+		propKeyID:      key.ID(),
+		propSize:       strconv.FormatInt(size, 10),
+	}
+	setAppProperty(props, propName, name)
+	setAppProperty(props, propMimeType, mimeType)
+	return sealed, props, nil
+}
+
+// setAppProperty sets key unless value is empty or too long to store
+func setAppProperty(props map[string]string, key, value string) {
+	if value != "" && len(key)+len(value) <= maxPropertyBytes {
+		props[key] = value
+	}
+}
+
+// IsEncrypted reports whether a file was uploaded with client-side
+// encryption
+func IsEncrypted(file *types.DriveFile) bool {
+	return file.AppProperties[propEncryption] != ""
+}
+
+// decryptedName returns the original name of an encrypted file
+func decryptedName(file *types.DriveFile) string {
+	if name := file.AppProperties[propName]; name != "" {
+		return filepath.Base(name)
+	}
+	return strings.TrimSuffix(file.Name, EncryptedSuffix)
+}
+
+// checkDecryptionKey verifies that key can decrypt file
+func checkDecryptionKey(file *types.DriveFile, key *encryption.Key) error {
+	if algorithm := file.AppProperties[propEncryption]; algorithm != encryption.Algorithm {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s uses unsupported encryption %q", file.Name, algorithm)).Build())
+	}
+	if key == nil {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s is client-side encrypted", file.Name)).
+			WithContext("keyId", file.AppProperties[propKeyID]).
+			WithContext("suggestedAction", "pass --key-file with the key it was uploaded with").
+			Build())
+	}
+	if keyID := file.AppProperties[propKeyID]; keyID != "" && keyID != key.ID() {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s was encrypted with a different key", file.Name)).
+			WithContext("keyId", keyID).
+			WithContext("givenKeyId", key.ID()).
+			Build())
+	}
+	return nil
+}
+
+// downloadDecrypted downloads an encrypted file and writes its plaintext to
+// w
+func (m *Manager) downloadDecrypted(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, key *encryption.Key, w io.Writer) error {
+	call := m.client.Service().Files.Get(file.ID)
+	call = m.shaper.ShapeFilesGet(call, reqCtx)
+
+	httpResp, err := call.Context(ctx).Download()
+	if err != nil {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+			fmt.Sprintf("Download failed: %s", err)).Build())
+	}
+	defer httpResp.Body.Close()
+
+	counter := &countingWriter{}
+	if err := encryption.Decrypt(io.MultiWriter(w, counter), httpResp.Body, key); err != nil {
+		if errors.Is(err, encryption.ErrAuthentication) {
+			return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Failed to decrypt %s: the file is corrupted or was modified", file.Name)).Build())
+		}
+		return err
+	}
+
+	if size, err := strconv.ParseInt(file.AppProperties[propSize], 10, 64); err == nil {
+		if counter.n != size {
+			return fmt.Errorf("decrypted %s is %d bytes, expected %d", file.Name, counter.n, size)
+		}
+	}
+	return nil
+}
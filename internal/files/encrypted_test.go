@@ -0,0 +1,101 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/encryption"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestUploadDownload_Encrypted(t *testing.T) {
+	var stored drive.File
+	var content []byte
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/upload/"):
+			_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			mr := multipart.NewReader(r.Body, params["boundary"])
+			part, _ := mr.NextPart()
+			_ = json.NewDecoder(part).Decode(&stored)
+			part, _ = mr.NextPart()
+			content, _ = io.ReadAll(part)
+			stored.Id = "f1"
+			stored.MimeType = "application/octet-stream"
+			_ = json.NewEncoder(w).Encode(&stored)
+		case r.URL.Query().Get("alt") == "media":
+			_, _ = w.Write(content)
+		case strings.HasSuffix(r.URL.Path, "/files/f1"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": "f1", "name": stored.Name, "mimeType": stored.MimeType,
+				"appProperties": stored.AppProperties,
+				"capabilities":  map[string]bool{"canDownload": true},
+			})
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	dir := t.TempDir()
+	plain := []byte(strings.Repeat("quarterly numbers\n", 5000))
+	src := filepath.Join(dir, "archive.tar")
+	if err := os.WriteFile(src, plain, 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := encryption.NewKey(bytes.Repeat([]byte{3}, encryption.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mgr.Upload(ctx, reqCtx, src, UploadOptions{Encryption: key}); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "archive.tar.enc" || stored.AppProperties[propName] != "archive.tar" || stored.AppProperties[propKeyID] != key.ID() {
+		t.Errorf("stored metadata = %q %v", stored.Name, stored.AppProperties)
+	}
+	if bytes.Contains(content, []byte("quarterly")) {
+		t.Fatal("plaintext was uploaded")
+	}
+
+	out := filepath.Join(dir, "restored.tar")
+	if _, err := mgr.Download(ctx, reqCtx, "f1", DownloadOptions{OutputPath: out, Decryption: key}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(out)
+	if !bytes.Equal(got, plain) {
+		t.Error("decrypted download does not match the original")
+	}
+
+	if _, err := mgr.Download(ctx, reqCtx, "f1", DownloadOptions{OutputPath: filepath.Join(dir, "nokey")}); err == nil {
+		t.Error("download without a key should fail")
+	}
+	other, _ := encryption.NewKey(bytes.Repeat([]byte{4}, encryption.KeySize))
+	if _, err := mgr.Download(ctx, reqCtx, "f1", DownloadOptions{OutputPath: filepath.Join(dir, "wrongkey"), Decryption: other}); err == nil {
+		t.Error("download with the wrong key should fail")
+	}
+
+	content[len(content)-1] ^= 1
+	corrupt := filepath.Join(dir, "corrupt")
+	if _, err := mgr.Download(ctx, reqCtx, "f1", DownloadOptions{OutputPath: corrupt, Decryption: key}); err == nil {
+		t.Error("corrupted download should fail")
+	}
+	if _, err := os.Stat(corrupt); !os.IsNotExist(err) {
+		t.Error("partial plaintext was left behind")
+	}
+}
@@ -0,0 +1,398 @@
+package files
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// Reasons a file is reported by FindCorrupt
+const (
+	CorruptZeroByte    = "zero_byte"    // A binary file with no content
+	CorruptMD5Mismatch = "md5_mismatch" // Content differs from the manifest
+	CorruptMissing     = "missing"      // Listed in the manifest but not in Drive
+	CorruptEmptyStub   = "empty_stub"   // A Workspace file that exports no text
+)
+
+// Re-upload outcomes
+const (
+	ReuploadDone    = "reuploaded"
+	ReuploadPlanned = "planned"
+	ReuploadSkipped = "skipped"
+	ReuploadFailed  = "failed"
+)
+
+// emptyMD5 is the MD5 checksum of zero bytes
+const emptyMD5 = "d41d8cd98f00b204e9800998ecf8427e"
+
+const findCorruptFields = "id,name,mimeType,size,md5Checksum"
+
+// stubExportFormats are the plain-text exports used to tell whether a
+// Workspace file has any content. Spreadsheets export their first sheet.
+var stubExportFormats = map[string]string{
+	utils.MimeTypeDocument:     "text/plain",
+	utils.MimeTypeSpreadsheet:  "text/csv",
+	utils.MimeTypePresentation: "text/plain",
+}
+
+// FindCorruptOptions configures FindCorrupt
+type FindCorruptOptions struct {
+	Recursive bool
+	// Manifest maps paths relative to the folder to their expected MD5
+	// checksums (see ParseChecksumManifest)
+	Manifest map[string]string
+	// SkipStubs skips exporting Workspace files to look for empty stubs
+	SkipStubs bool
+}
+
+// CorruptFile is a file that looks like the result of a failed upload
+type CorruptFile struct {
+	Path        string `json:"path"`
+	FileID      string `json:"fileId,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"md5Checksum,omitempty"`
+	ExpectedMD5 string `json:"expectedMd5,omitempty"`
+	Reason      string `json:"reason"`
+	Reupload    string `json:"reupload,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// CorruptReport lists the suspect files found under a folder
+type CorruptReport struct {
+	FolderID     string         `json:"folderId"`
+	Recursive    bool           `json:"recursive"`
+	FilesScanned int            `json:"filesScanned"`
+	StubsChecked int            `json:"stubsChecked"`
+	Manifest     int            `json:"manifestEntries,omitempty"`
+	Corrupt      []*CorruptFile `json:"corrupt"`
+	Reuploaded   int            `json:"reuploaded,omitempty"`
+	Failed       int            `json:"failed,omitempty"`
+	DryRun       bool           `json:"dryRun,omitempty"`
+
+	// folders maps folder paths relative to the root ("" for the root) to
+	// their IDs, for re-uploading missing files
+	folders map[string]string
+}
+
+func (r *CorruptReport) Headers() []string {
+	headers := []string{"Path", "Reason", "Size", "MD5", "Expected MD5"}
+	if r.reuploading() {
+		headers = append(headers, "Re-upload")
+	}
+	return headers
+}
+
+func (r *CorruptReport) Rows() [][]string {
+	rows := make([][]string, len(r.Corrupt))
+	for i, c := range r.Corrupt {
+		size := ""
+		if c.Reason != CorruptMissing && c.Reason != CorruptEmptyStub {
+			size = types.DisplaySize(c.Size)
+		}
+		rows[i] = []string{c.Path, c.Reason, size, c.MD5Checksum, c.ExpectedMD5}
+		if r.reuploading() {
+			status := c.Reupload
+			if c.Error != "" {
+				status += ": " + c.Error
+			}
+			rows[i] = append(rows[i], status)
+		}
+	}
+	return rows
+}
+
+func (r *CorruptReport) EmptyMessage() string {
+	return "No corrupt files found (" + strconv.Itoa(r.FilesScanned) + " files scanned)"
+}
+
+func (r *CorruptReport) reuploading() bool {
+	for _, c := range r.Corrupt {
+		if c.Reupload != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCorrupt looks under a folder for files left broken by failed uploads
+// or sync runs: binary files with no content, Workspace files that export
+// no text, and, given a manifest, files whose MD5 differs from it or that
+// are missing.
+func (m *Manager) FindCorrupt(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts FindCorruptOptions) (*CorruptReport, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, folderID)
+	report := &CorruptReport{
+		FolderID:  folderID,
+		Recursive: opts.Recursive,
+		Manifest:  len(opts.Manifest),
+		Corrupt:   []*CorruptFile{},
+		folders:   map[string]string{"": folderID},
+	}
+
+	seen := make(map[string]bool)
+	if err := m.findCorruptIn(ctx, reqCtx, folderID, "", opts, report, seen); err != nil {
+		return nil, err
+	}
+
+	for p, expected := range opts.Manifest {
+		if seen[p] || (!opts.Recursive && strings.Contains(p, "/")) {
+			continue
+		}
+		report.Corrupt = append(report.Corrupt, &CorruptFile{Path: p, ExpectedMD5: expected, Reason: CorruptMissing})
+	}
+	sort.Slice(report.Corrupt, func(i, j int) bool { return report.Corrupt[i].Path < report.Corrupt[j].Path })
+	return report, nil
+}
+
+func (m *Manager) findCorruptIn(ctx context.Context, reqCtx *types.RequestContext, folderID, dir string, opts FindCorruptOptions, report *CorruptReport, seen map[string]bool) error {
+	children, err := m.listChildren(ctx, reqCtx, folderID, findCorruptFields)
+	if err != nil {
+		return err
+	}
+	for _, f := range children {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p := path.Join(dir, f.Name)
+		switch {
+		case f.MimeType == utils.MimeTypeFolder:
+			report.folders[p] = f.Id
+			if opts.Recursive {
+				if err := m.findCorruptIn(ctx, reqCtx, f.Id, p, opts, report, seen); err != nil {
+					return err
+				}
+			}
+			continue
+		case f.MimeType == utils.MimeTypeShortcut:
+			continue
+		}
+
+		report.FilesScanned++
+		seen[p] = true
+		if c := m.checkFile(ctx, reqCtx, f, p, opts, report); c != nil {
+			report.Corrupt = append(report.Corrupt, c)
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files find-corrupt", Done: report.FilesScanned, Item: p})
+	}
+	return nil
+}
+
+// checkFile returns the problem with one file, or nil
+func (m *Manager) checkFile(ctx context.Context, reqCtx *types.RequestContext, f *drive.File, p string, opts FindCorruptOptions, report *CorruptReport) *CorruptFile {
+	c := &CorruptFile{Path: p, FileID: f.Id, MimeType: f.MimeType, Size: f.Size, MD5Checksum: f.Md5Checksum}
+	expected, listed := opts.Manifest[p]
+	c.ExpectedMD5 = expected
+
+	if utils.IsWorkspaceMimeType(f.MimeType) {
+		format, ok := stubExportFormats[f.MimeType]
+		if opts.SkipStubs || !ok {
+			return nil
+		}
+		report.StubsChecked++
+		if hasContent, err := m.exportHasText(ctx, reqCtx, f, format); err == nil && !hasContent {
+			c.Reason = CorruptEmptyStub
+			return c
+		}
+		return nil
+	}
+
+	switch {
+	case listed && !strings.EqualFold(f.Md5Checksum, expected):
+		c.Reason = CorruptMD5Mismatch
+	case f.Size == 0 && !(listed && expected == emptyMD5):
+		c.Reason = CorruptZeroByte
+	default:
+		return nil
+	}
+	return c
+}
+
+// errHasText stops an export as soon as it shows the file has content
+var errHasText = errors.New("file has content")
+
+// textProbe is a writer that fails with errHasText on the first character
+// that is not whitespace or a separator
+type textProbe struct{}
+
+func (textProbe) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch b {
+		case ' ', '\t', '\r', '\n', ',', '\xef', '\xbb', '\xbf':
+			// Whitespace, empty CSV cells and the UTF-8 byte order mark
+		default:
+			return 0, errHasText
+		}
+	}
+	return len(p), nil
+}
+
+// exportHasText reports whether a Workspace file exports any text. Files
+// over the export size limit have content by definition.
+func (m *Manager) exportHasText(ctx context.Context, reqCtx *types.RequestContext, f *drive.File, format string) (bool, error) {
+	childCtx := childRequestContext(reqCtx, f.Id)
+	err := m.exportFile(ctx, childCtx, f.Id, convertDriveFile(f), DownloadOptions{MimeType: format}, textProbe{})
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.Is(err, errHasText), isExportSizeLimit(err):
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+// ReuploadCorrupt replaces corrupt files with their copies under localDir,
+// at the same relative paths. Damaged files keep their IDs, sharing and
+// history; missing files are uploaded into their folder. A local copy is
+// only used when it matches the manifest checksum, or, without one, when
+// it is not empty. Workspace stubs are not re-uploaded.
+func (m *Manager) ReuploadCorrupt(ctx context.Context, reqCtx *types.RequestContext, report *CorruptReport, localDir string, dryRun bool) error {
+	if info, err := os.Stat(localDir); err != nil || !info.IsDir() {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("'%s' is not a directory", localDir)).Build())
+	}
+	report.DryRun = dryRun
+
+	for i, c := range report.Corrupt {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files reupload", Done: i + 1, Total: len(report.Corrupt), Item: c.Path})
+		localPath := filepath.Join(localDir, filepath.FromSlash(c.Path))
+		opCtx := childRequestContext(reqCtx, c.FileID)
+		opCtx.RequestType = types.RequestTypeMutation
+		parentID, reason := m.reuploadCheck(c, localPath, report)
+		if reason != "" {
+			c.Reupload, c.Error = ReuploadSkipped, reason
+			continue
+		}
+		if dryRun {
+			c.Reupload = ReuploadPlanned
+			continue
+		}
+
+		var err error
+		if c.Reason == CorruptMissing {
+			var uploaded *types.DriveFile
+			opCtx.InvolvedFileIDs = nil
+			uploaded, err = m.Upload(ctx, opCtx, localPath, UploadOptions{ParentID: parentID, Name: path.Base(c.Path)})
+			if err == nil {
+				c.FileID, c.Size, c.MD5Checksum = uploaded.ID, uploaded.Size, uploaded.MD5Checksum
+			}
+		} else {
+			var updated *types.DriveFile
+			updated, err = m.UpdateContent(ctx, opCtx, c.FileID, localPath, UpdateContentOptions{Fields: "id,size,md5Checksum"})
+			if err == nil {
+				c.Size, c.MD5Checksum = updated.Size, updated.MD5Checksum
+			}
+		}
+		if err != nil {
+			c.Reupload, c.Error = ReuploadFailed, err.Error()
+			report.Failed++
+			continue
+		}
+		c.Reupload = ReuploadDone
+		report.Reuploaded++
+	}
+	return nil
+}
+
+// reuploadCheck returns why a corrupt file cannot be re-uploaded from
+// localPath, and for a missing file the folder to upload it into
+func (m *Manager) reuploadCheck(c *CorruptFile, localPath string, report *CorruptReport) (parentID, reason string) {
+	if c.Reason == CorruptEmptyStub {
+		return "", "Workspace files are not re-uploaded"
+	}
+	if c.Reason == CorruptMissing {
+		dir := path.Dir(c.Path)
+		if dir == "." {
+			dir = ""
+		}
+		var ok bool
+		if parentID, ok = report.folders[dir]; !ok {
+			return "", "folder " + dir + " does not exist in Drive"
+		}
+	}
+
+	info, err := os.Stat(localPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", "no local copy"
+	}
+	if c.ExpectedMD5 == "" {
+		if info.Size() == 0 {
+			return "", "local copy is empty too"
+		}
+		return parentID, ""
+	}
+	sum, err := fileMD5(localPath)
+	if err != nil {
+		return "", err.Error()
+	}
+	if !strings.EqualFold(sum, c.ExpectedMD5) {
+		return "", "local copy does not match the manifest"
+	}
+	return parentID, ""
+}
+
+func fileMD5(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var (
+	md5sumLine = regexp.MustCompile(`^([0-9a-fA-F]{32}) [ *](.+)$`)
+	bsdMD5Line = regexp.MustCompile(`^MD5 \((.+)\) = ([0-9a-fA-F]{32})$`)
+)
+
+// ParseChecksumManifest reads MD5 checksums in the format written by
+// md5sum ("<md5>  <path>") or by BSD md5 ("MD5 (<path>) = <md5>"). Paths
+// are relative to the folder being checked; a leading "./" is ignored.
+func ParseChecksumManifest(r io.Reader) (map[string]string, error) {
+	manifest := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var p, sum string
+		if match := md5sumLine.FindStringSubmatch(text); match != nil {
+			sum, p = match[1], match[2]
+		} else if match := bsdMD5Line.FindStringSubmatch(text); match != nil {
+			p, sum = match[1], match[2]
+		} else {
+			return nil, fmt.Errorf("manifest line %d is not an MD5 checksum line", line)
+		}
+		p = path.Clean(filepath.ToSlash(strings.TrimPrefix(p, "./")))
+		manifest[p] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
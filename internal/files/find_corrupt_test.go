@@ -0,0 +1,159 @@
+package files
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseChecksumManifest(t *testing.T) {
+	manifest, err := ParseChecksumManifest(strings.NewReader(
+		md5Hex("a") + "  ./docs/a.txt\n" +
+			"# comment\n\n" +
+			strings.ToUpper(md5Hex("b")) + " *b.bin\n" +
+			"MD5 (./c d.txt) = " + md5Hex("c") + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"docs/a.txt": md5Hex("a"), "b.bin": md5Hex("b"), "c d.txt": md5Hex("c")}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("manifest = %v, want %v", manifest, want)
+	}
+
+	if _, err := ParseChecksumManifest(strings.NewReader("not a checksum\n")); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestTextProbe(t *testing.T) {
+	if _, err := (textProbe{}).Write([]byte("\xef\xbb\xbf ,,\r\n,\t")); err != nil {
+		t.Errorf("blank export reported as content: %v", err)
+	}
+	if _, err := (textProbe{}).Write([]byte("\n  x")); err != errHasText {
+		t.Errorf("err = %v, want errHasText", err)
+	}
+}
+
+func TestFindCorruptAndReupload(t *testing.T) {
+	fake := &fakeDrive{files: map[string]*drive.File{}, content: map[string][]byte{}}
+	client, _ := testhelpers.NewDriveClient(t, fake)
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	add := func(name, mimeType, parent string, data []byte) string {
+		f := &drive.File{Name: name, MimeType: mimeType}
+		if parent != "" {
+			f.Parents = []string{parent}
+		}
+		fake.add(f, data)
+		return f.Id
+	}
+	top := add("top", utils.MimeTypeFolder, "", nil)
+	sub := add("sub", utils.MimeTypeFolder, top, nil)
+	add("empty.bin", "application/octet-stream", top, []byte{})
+	add("keep.empty", "text/plain", top, []byte{})
+	add("ok.txt", "text/plain", top, []byte("hello"))
+	badID := add("bad.txt", "text/plain", top, []byte("garbage"))
+	add("stub", utils.MimeTypeDocument, top, []byte(" \n"))
+	add("notes", utils.MimeTypeDocument, top, []byte("minutes"))
+	add("deep.bin", "application/octet-stream", sub, []byte{})
+
+	manifest := map[string]string{
+		"bad.txt":         md5Hex("good"),
+		"keep.empty":      emptyMD5,
+		"sub/missing.txt": md5Hex("m"),
+	}
+
+	flat, err := mgr.FindCorrupt(ctx, reqCtx, top, FindCorruptOptions{Manifest: manifest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reasons(flat); !reflect.DeepEqual(got, map[string]string{
+		"bad.txt": CorruptMD5Mismatch, "empty.bin": CorruptZeroByte, "stub": CorruptEmptyStub,
+	}) {
+		t.Errorf("non-recursive = %v", got)
+	}
+	if flat.FilesScanned != 6 || flat.StubsChecked != 2 {
+		t.Errorf("scanned %d files and %d stubs", flat.FilesScanned, flat.StubsChecked)
+	}
+
+	report, err := mgr.FindCorrupt(ctx, reqCtx, top, FindCorruptOptions{Recursive: true, Manifest: manifest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reasons(report); len(got) != 5 || got["sub/deep.bin"] != CorruptZeroByte || got["sub/missing.txt"] != CorruptMissing {
+		t.Errorf("recursive = %v", got)
+	}
+
+	local := t.TempDir()
+	for name, content := range map[string]string{"bad.txt": "good", "empty.bin": "data", "sub/missing.txt": "m"} {
+		p := filepath.Join(local, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := mgr.ReuploadCorrupt(ctx, reqCtx, report, local, true); err != nil {
+		t.Fatal(err)
+	}
+	if report.Reuploaded != 0 || fake.uploads != 0 || reuploads(report)["bad.txt"] != ReuploadPlanned {
+		t.Errorf("dry run changed files: %v", reuploads(report))
+	}
+
+	if err := mgr.ReuploadCorrupt(ctx, reqCtx, report, local, false); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"bad.txt": ReuploadDone, "empty.bin": ReuploadDone, "sub/missing.txt": ReuploadDone,
+		"stub": ReuploadSkipped, "sub/deep.bin": ReuploadSkipped,
+	}
+	if got := reuploads(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("re-uploads = %v, want %v", got, want)
+	}
+	if string(fake.content[badID]) != "good" || report.Reuploaded != 3 || report.Failed != 0 {
+		t.Errorf("bad.txt holds %q after re-upload; report %+v", fake.content[badID], report)
+	}
+
+	after, err := mgr.FindCorrupt(ctx, reqCtx, top, FindCorruptOptions{Recursive: true, Manifest: manifest, SkipStubs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reasons(after); !reflect.DeepEqual(got, map[string]string{"sub/deep.bin": CorruptZeroByte}) {
+		t.Errorf("after re-upload = %v", got)
+	}
+}
+
+func reasons(r *CorruptReport) map[string]string {
+	m := map[string]string{}
+	for _, c := range r.Corrupt {
+		m[c.Path] = c.Reason
+	}
+	return m
+}
+
+func reuploads(r *CorruptReport) map[string]string {
+	m := map[string]string{}
+	for _, c := range r.Corrupt {
+		m[c.Path] = c.Reupload
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+package files
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/dl-alexandre/gdrv/internal/export"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/googleapi"
+)
+
+// Formats returns the live import/export conversion matrix from about.get,
+// falling back to the built-in reference tables if it cannot be fetched.
+func (m *Manager) Formats(ctx context.Context, reqCtx *types.RequestContext) *types.FormatMatrix {
+	matrix, err := m.about.Formats(ctx, reqCtx)
+	if err != nil {
+		return export.BuiltinMatrix()
+	}
+	return matrix
+}
+
+// ValidateExport checks that a Workspace type can be exported as
+// targetMimeType according to the live conversion matrix
+func (m *Manager) ValidateExport(ctx context.Context, reqCtx *types.RequestContext, sourceMimeType, targetMimeType string) error {
+	return export.ValidateExportFormatIn(m.Formats(ctx, reqCtx), sourceMimeType, targetMimeType)
+}
+
+// resolveConversion returns the content type of a local file and the
+// Workspace type it will be converted to. Without convertTo, mimeType is
+// either a Workspace type to convert to, or the content type to use
+// instead of detecting it; with convertTo it can only be the latter.
+func (m *Manager) resolveConversion(ctx context.Context, reqCtx *types.RequestContext, file *os.File, mimeType, convertTo string) (string, string, error) {
+	source, target := mimeType, convertTo
+	if target == "" && utils.IsWorkspaceMimeType(mimeType) {
+		source, target = "", mimeType
+	}
+	if source == "" {
+		detected, err := detectContentType(file)
+		if err != nil {
+			return "", "", err
+		}
+		source = detected
+	}
+
+	target, err := export.ResolveImportFormat(m.Formats(ctx, reqCtx), source, target)
+	if err != nil {
+		return "", "", err
+	}
+	return source, target, nil
+}
+
+// detectContentType guesses a file's media type from its extension, then
+// from its first bytes. The read position is restored afterwards.
+func detectContentType(file *os.File) (string, error) {
+	if byExt := mime.TypeByExtension(filepath.Ext(file.Name())); byExt != "" {
+		if mediaType, _, err := mime.ParseMediaType(byExt); err == nil {
+			return mediaType, nil
+		}
+	}
+
+	head := make([]byte, 512)
+	n, err := file.Read(head)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	return mediaType, nil
+}
+
+// mediaOptions sets the upload content type when it differs from the
+// file's Drive MIME type
+func mediaOptions(contentType string) []googleapi.MediaOption {
+	if contentType == "" {
+		return nil
+	}
+	return []googleapi.MediaOption{googleapi.ContentType(contentType)}
+}
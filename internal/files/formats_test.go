@@ -0,0 +1,86 @@
+package files
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestDetectContentType(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]struct {
+		content string
+		want    string
+	}{
+		"data.csv":  {"a,b\n1,2\n", "text/csv"},
+		"notes":     {"plain text without an extension", "text/plain"},
+		"page.html": {"<html></html>", "text/html"},
+	}
+	for name, tt := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := detectContentType(f)
+		if err != nil || got != tt.want {
+			t.Errorf("detectContentType(%s) = %q, %v; want %q", name, got, err, tt.want)
+		}
+		if pos, _ := f.Seek(0, io.SeekCurrent); pos != 0 {
+			t.Errorf("detectContentType(%s) left offset at %d", name, pos)
+		}
+		f.Close()
+	}
+}
+
+func TestUploadConvertTo(t *testing.T) {
+	const docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	var uploaded string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/drive/v3/about" {
+			_, _ = w.Write([]byte(`{"importFormats":{"` + docx + `":["` + utils.MimeTypeDocument + `"]}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		uploaded = string(body)
+		_, _ = w.Write([]byte(`{"id":"d1","name":"report.docx","mimeType":"` + utils.MimeTypeDocument + `"}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	localPath := filepath.Join(t.TempDir(), "report.docx")
+	if err := os.WriteFile(localPath, []byte("PK\x03\x04"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := mgr.Upload(ctx, reqCtx, localPath, UploadOptions{ConvertTo: utils.MimeTypeDocument})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.MimeType != utils.MimeTypeDocument || !strings.Contains(uploaded, `"mimeType":"`+utils.MimeTypeDocument+`"`) ||
+		!strings.Contains(uploaded, "Content-Type: "+docx) {
+		t.Errorf("uploaded %q as %s", uploaded, file.MimeType)
+	}
+
+	uploaded = ""
+	_, err = mgr.Upload(ctx, reqCtx, localPath, UploadOptions{ConvertTo: utils.MimeTypeSpreadsheet})
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeUnsupportedConversion {
+		t.Errorf("docx to a Sheet = %v, want UNSUPPORTED_CONVERSION", err)
+	}
+	if uploaded != "" {
+		t.Error("an unsupported conversion was uploaded")
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/dl-alexandre/gdrv/internal/about"
 	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/encryption"
 	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"google.golang.org/api/drive/v3"
@@ -19,8 +22,11 @@ import (
 
 // Manager handles file operations
 type Manager struct {
-	client *api.Client
-	shaper *api.RequestShaper
+	client         *api.Client
+	shaper         *api.RequestShaper
+	about          *about.Manager
+	progress       types.ProgressReporter
+	exportDefaults map[string]string
 }
 
 // NewManager creates a new file manager
@@ -28,22 +34,48 @@ func NewManager(client *api.Client) *Manager {
 	return &Manager{
 		client: client,
 		shaper: api.NewRequestShaper(client),
+		about:  about.NewManager(client),
 	}
 }
 
+// SetProgress makes long-running operations report each item to r; nil
+// turns reporting off
+func (m *Manager) SetProgress(r types.ProgressReporter) {
+	m.progress = r
+}
+
+// SetExportDefaults sets the export format used for each Workspace MIME
+// type when no format is given, e.g. the exportDefaults config. Types it
+// leaves out keep the built-in default.
+func (m *Manager) SetExportDefaults(formats map[string]string) {
+	m.exportDefaults = formats
+}
+
 // UploadOptions configures file upload
 type UploadOptions struct {
 	ParentID    string
 	Name        string
-	MimeType    string
-	Convert     bool
+	MimeType    string // Content type, or with Convert the Workspace type to convert to
+	Convert     bool   // Convert to a Google Workspace format using the live import matrix
+	ConvertTo   string // Workspace type to convert to; implies Convert, and MimeType is then the content type
+	Description string
 	PinRevision bool
+	ChunkSize   int64           // Resumable upload chunk size in bytes (0 = utils.UploadChunkSize)
+	Encryption  *encryption.Key // Encrypt the contents client-side before upload
+	// SessionFile is where a resumable upload saves its session, so that
+	// an interrupted upload can be continued with ResumeUpload. It is
+	// removed when the upload completes. Encrypted uploads are not saved.
+	SessionFile string
+	Progress    ProgressFunc // Called as resumable uploads advance
+	NoVerify    bool         // Skip checking Drive's checksum against the local file
 }
 
 type UpdateContentOptions struct {
-	Name     string
-	MimeType string
-	Fields   string
+	Name      string
+	MimeType  string
+	Fields    string
+	ChunkSize int64 // Resumable upload chunk size in bytes (0 = utils.UploadChunkSize)
+	NoVerify  bool  // Skip checking Drive's checksum against the local file
 }
 
 // DownloadOptions configures file download
@@ -51,8 +83,12 @@ type DownloadOptions struct {
 	OutputPath   string
 	MimeType     string
 	Wait         bool
-	Timeout      int // in seconds
-	PollInterval int // in seconds
+	Timeout      int             // in seconds
+	PollInterval int             // in seconds
+	Decryption   *encryption.Key // Key for files uploaded with Encryption
+	// NoFollowShortcuts refuses to download a shortcut's target
+	NoFollowShortcuts bool
+	NoVerify          bool // Skip checking the content against Drive's checksum
 }
 
 // ListOptions configures file listing
@@ -63,6 +99,7 @@ type ListOptions struct {
 	PageToken      string
 	OrderBy        string
 	IncludeTrashed bool
+	Starred        bool // Only starred files
 	Fields         string
 }
 
@@ -86,13 +123,42 @@ func (m *Manager) Upload(ctx context.Context, reqCtx *types.RequestContext, loca
 	}
 
 	metadata := &drive.File{
-		Name: name,
+		Name:        name,
+		Description: opts.Description,
+	}
+	convert := opts.Convert || opts.ConvertTo != ""
+	if opts.Encryption != nil {
+		if convert {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"Encrypted files cannot be converted to Google Workspace formats").Build())
+		}
+		sealed, props, err := encryptForUpload(file, name, opts.MimeType, stat.Size(), opts.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(sealed.Name())
+		defer sealed.Close()
+		if stat, err = sealed.Stat(); err != nil {
+			return nil, err
+		}
+		file = sealed
+		metadata.Name = name + EncryptedSuffix
+		metadata.AppProperties = props
+		opts.MimeType = "application/octet-stream"
 	}
 	if opts.ParentID != "" {
 		metadata.Parents = []string{opts.ParentID}
 		reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, opts.ParentID)
 	}
-	if opts.MimeType != "" {
+	contentType := ""
+	if convert {
+		source, target, err := m.resolveConversion(ctx, reqCtx, file, opts.MimeType, opts.ConvertTo)
+		if err != nil {
+			return nil, err
+		}
+		contentType = source
+		metadata.MimeType = target
+	} else if opts.MimeType != "" {
 		metadata.MimeType = opts.MimeType
 	}
 
@@ -103,11 +169,18 @@ func (m *Manager) Upload(ctx context.Context, reqCtx *types.RequestContext, loca
 
 	switch uploadType {
 	case "simple":
-		result, err = m.simpleUpload(ctx, reqCtx, file, metadata, opts)
+		result, err = m.simpleUpload(ctx, reqCtx, file, metadata, contentType)
 	case "multipart":
-		result, err = m.multipartUpload(ctx, reqCtx, file, metadata, opts)
+		result, err = m.multipartUpload(ctx, reqCtx, file, metadata, contentType)
 	case "resumable":
-		result, err = m.resumableUpload(ctx, reqCtx, file, metadata, stat.Size(), opts)
+		var onSession func(string) error
+		if opts.Encryption == nil {
+			onSession = saveSessionFunc(opts.SessionFile, localPath, stat, metadata.Name, opts.ParentID, opts.ChunkSize)
+		}
+		result, err = m.resumableUpload(ctx, reqCtx, file, metadata, stat.Size(), contentType, opts, onSession)
+		if err == nil && onSession != nil {
+			_ = os.Remove(opts.SessionFile)
+		}
 	}
 
 	if err != nil {
@@ -119,18 +192,37 @@ func (m *Manager) Upload(ctx context.Context, reqCtx *types.RequestContext, loca
 		m.client.ResourceKeys().UpdateFromAPIResponse(result.Id, result.ResourceKey)
 	}
 
-	return convertDriveFile(result), nil
+	uploaded := convertDriveFile(result)
+	if opts.NoVerify {
+		uploaded.Verification = disabledVerification()
+	} else if uploaded.Verification, err = verifyUpload(file, stat.Size(), result); err != nil {
+		return nil, err
+	}
+	return uploaded, nil
 }
 
 func (m *Manager) UpdateContent(ctx context.Context, reqCtx *types.RequestContext, fileID string, localPath string, opts UpdateContentOptions) (*types.DriveFile, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
+	required := []Capability{CapabilityEdit}
+	if opts.Name != "" {
+		required = append(required, CapabilityRename)
+	}
+	if _, err := m.RequireCapabilities(ctx, reqCtx, fileID, required...); err != nil {
+		return nil, err
+	}
+
 	file, err := os.Open(localPath)
 	if err != nil {
 		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
 			fmt.Sprintf("Failed to open file: %s", err)).Build())
 	}
 	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to stat file: %s", err)).Build())
+	}
 
 	metadata := &drive.File{}
 	if opts.Name != "" {
@@ -140,10 +232,12 @@ func (m *Manager) UpdateContent(ctx context.Context, reqCtx *types.RequestContex
 		metadata.MimeType = opts.MimeType
 	}
 
-	call := m.client.Service().Files.Update(fileID, metadata).Media(file)
+	call := m.client.Service().Files.Update(fileID, metadata).Media(file, googleapi.ChunkSize(int(NormalizeChunkSize(opts.ChunkSize))))
 	call = m.shaper.ShapeFilesUpdate(call, reqCtx)
 	if opts.Fields != "" {
-		call = call.Fields(googleapi.Field(opts.Fields))
+		call = call.Fields(googleapi.Field(withUploadFields(opts.Fields)))
+	} else {
+		call = call.Fields(uploadFields)
 	}
 
 	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
@@ -157,7 +251,13 @@ func (m *Manager) UpdateContent(ctx context.Context, reqCtx *types.RequestContex
 		m.client.ResourceKeys().UpdateFromAPIResponse(result.Id, result.ResourceKey)
 	}
 
-	return convertDriveFile(result), nil
+	updated := convertDriveFile(result)
+	if opts.NoVerify {
+		updated.Verification = disabledVerification()
+	} else if updated.Verification, err = verifyUpload(file, stat.Size(), result); err != nil {
+		return nil, err
+	}
+	return updated, nil
 }
 
 func selectUploadType(size int64, metadata *drive.File) string {
@@ -174,78 +274,226 @@ func selectUploadType(size int64, metadata *drive.File) string {
 	return "simple"
 }
 
-func (m *Manager) simpleUpload(ctx context.Context, reqCtx *types.RequestContext, reader io.Reader, metadata *drive.File, opts UploadOptions) (*drive.File, error) {
-	call := m.client.Service().Files.Create(metadata).Media(reader)
-	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+func (m *Manager) simpleUpload(ctx context.Context, reqCtx *types.RequestContext, reader io.Reader, metadata *drive.File, contentType string) (*drive.File, error) {
+	call := m.client.Service().Files.Create(metadata).Media(reader, mediaOptions(contentType)...)
+	call = m.shaper.ShapeFilesCreate(call, reqCtx).Fields(uploadFields)
 
 	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
 		return call.Do()
 	})
 }
 
-func (m *Manager) multipartUpload(ctx context.Context, reqCtx *types.RequestContext, reader io.Reader, metadata *drive.File, opts UploadOptions) (*drive.File, error) {
-	call := m.client.Service().Files.Create(metadata).Media(reader)
-	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+func (m *Manager) multipartUpload(ctx context.Context, reqCtx *types.RequestContext, reader io.Reader, metadata *drive.File, contentType string) (*drive.File, error) {
+	call := m.client.Service().Files.Create(metadata).Media(reader, mediaOptions(contentType)...)
+	call = m.shaper.ShapeFilesCreate(call, reqCtx).Fields(uploadFields)
 
 	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
 		return call.Do()
 	})
 }
 
-func (m *Manager) resumableUpload(ctx context.Context, reqCtx *types.RequestContext, reader io.Reader, metadata *drive.File, size int64, opts UploadOptions) (*drive.File, error) {
-	call := m.client.Service().Files.Create(metadata).Media(reader)
-	call = m.shaper.ShapeFilesCreate(call, reqCtx)
-	call = call.ProgressUpdater(func(current, total int64) {
-		// Progress callback - could be used to report upload progress
-		// For now, this is a placeholder for future enhancement
-	})
+func (m *Manager) resumableUpload(ctx context.Context, reqCtx *types.RequestContext, file *os.File, metadata *drive.File, size int64, contentType string, opts UploadOptions, onSession func(string) error) (*drive.File, error) {
+	chunkSize := NormalizeChunkSize(opts.ChunkSize)
+	if m.client.HTTPClient() != nil {
+		return m.uploadResumable(ctx, reqCtx, file, metadata, contentType, size, resumableOptions{
+			ChunkSize: chunkSize,
+			Progress:  opts.Progress,
+			OnSession: onSession,
+		})
+	}
+
+	// Without an authenticated HTTP client, fall back to the library's
+	// resumable uploader with the requested chunk size. Its session URI is
+	// not exposed, so the upload cannot be resumed by a later run.
+	media := append(mediaOptions(contentType), googleapi.ChunkSize(int(chunkSize)))
+	call := m.client.Service().Files.Create(metadata).Media(file, media...)
+	call = m.shaper.ShapeFilesCreate(call, reqCtx).Fields(uploadFields)
+	if opts.Progress != nil {
+		call = call.ProgressUpdater(func(current, _ int64) {
+			opts.Progress(current, size)
+		})
+	}
 
 	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
 		return call.Do()
 	})
 }
 
-// Download downloads a file from Drive
-func (m *Manager) Download(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts DownloadOptions) error {
-	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
-
-	// Get file metadata first with exportLinks included for Workspace files
-	fields := "id,name,mimeType,size,capabilities,exportLinks"
-	file, err := m.Get(ctx, reqCtx, fileID, fields)
+// Download downloads a file from Drive. Stored content is checked against
+// Drive's SHA-256 or MD5 checksum unless opts.NoVerify is set, and a
+// mismatch fails with CHECKSUM_MISMATCH without writing the output. The
+// file's metadata is returned with the verification result.
+func (m *Manager) Download(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts DownloadOptions) (*types.DriveFile, error) {
+	file, err := m.downloadable(ctx, reqCtx, fileID, opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Check download capability
-	if file.Capabilities != nil && !file.Capabilities.CanDownload {
-		return utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied,
-			"File cannot be downloaded").
-			WithContext("capability", "canDownload=false").
-			Build())
+	if IsSplit(file) {
+		manifest, err := m.downloadSplitFile(ctx, reqCtx, file, opts)
+		if err != nil {
+			return nil, err
+		}
+		// Parts and the whole are always checked against the manifest
+		file.Verification = &types.ChecksumVerification{Status: types.VerificationVerified, Algorithm: "sha256", Checksum: manifest.SHA256}
+		return file, nil
+	}
+	if err := m.checkContent(ctx, reqCtx, file, opts); err != nil {
+		return nil, err
 	}
 
 	outputPath := opts.OutputPath
 	if outputPath == "" {
-		if opts.MimeType == "text/plain" && utils.IsWorkspaceMimeType(file.MimeType) {
+		if IsEncrypted(file) {
+			outputPath = decryptedName(file)
+		} else if opts.MimeType == "text/plain" && utils.IsWorkspaceMimeType(file.MimeType) {
 			outputPath = file.Name + ".txt"
 		} else {
 			outputPath = file.Name
 		}
 	}
 
-	outFile, err := os.Create(outputPath)
+	// Written in the run's temp directory so a failed download never
+	// leaves a truncated file (or partial plaintext) at outputPath
+	outFile, err := tempdir.CreatePartial(outputPath)
 	if err != nil {
-		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
 			fmt.Sprintf("Failed to create output file: %s", err)).Build())
 	}
-	defer outFile.Close()
+	defer outFile.Discard()
+
+	if err := m.writeContent(ctx, reqCtx, file, opts, outFile); err != nil {
+		return nil, err
+	}
+	if err := outFile.Commit(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// StreamExportFormats maps Workspace MIME types to the text format Stream
+// exports them in when no format is given, so exports can be piped into
+// text tools
+var StreamExportFormats = map[string]string{
+	utils.MimeTypeDocument:     "text/plain",
+	utils.MimeTypeSpreadsheet:  "text/csv",
+	utils.MimeTypePresentation: "text/plain",
+	utils.MimeTypeDrawing:      "image/svg+xml",
+	utils.MimeTypeScript:       "application/vnd.google-apps.script+json",
+}
 
-	// Check if it's a Workspace file that needs export
-	if utils.IsWorkspaceMimeType(file.MimeType) {
-		return m.exportFile(ctx, reqCtx, fileID, file, opts, outFile)
+// Stream writes a file's content, or its export in opts.MimeType, to w
+// instead of a file; opts.OutputPath is ignored. Workspace files default to
+// StreamExportFormats. Content is written as it arrives, so a checksum
+// mismatch is only reported after w has received the data.
+func (m *Manager) Stream(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts DownloadOptions, w io.Writer) (*types.DriveFile, error) {
+	file, err := m.downloadable(ctx, reqCtx, fileID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MimeType == "" {
+		opts.MimeType = StreamExportFormats[file.MimeType]
 	}
 
-	return m.downloadBlob(ctx, reqCtx, fileID, outFile)
+	if IsSplit(file) {
+		manifest, err := m.readSplitManifest(ctx, reqCtx, file)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.downloadSplit(ctx, reqCtx, manifest, w); err != nil {
+			return nil, err
+		}
+		file.Verification = &types.ChecksumVerification{Status: types.VerificationVerified, Algorithm: "sha256", Checksum: manifest.SHA256}
+		return file, nil
+	}
+	if err := m.checkContent(ctx, reqCtx, file, opts); err != nil {
+		return nil, err
+	}
+	if err := m.writeContent(ctx, reqCtx, file, opts, w); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// downloadable reads the metadata a download needs, following a shortcut
+// to its target unless opts.NoFollowShortcuts is set
+func (m *Manager) downloadable(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts DownloadOptions) (*types.DriveFile, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	// Get file metadata first with exportLinks included for Workspace files
+	fields := "id,name,mimeType,size,md5Checksum,sha256Checksum,capabilities,exportLinks,appProperties,shortcutDetails"
+	file, err := m.Get(ctx, reqCtx, fileID, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// A shortcut has no content of its own; download what it points to
+	if targetID := file.ShortcutTarget(); targetID != "" {
+		if opts.NoFollowShortcuts {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("%s is a shortcut to %s and has no content to download", file.Name, targetID)).
+				WithContext("targetId", targetID).
+				WithContext("suggestedAction", "download the target, or drop --no-follow-shortcuts").
+				Build())
+		}
+		if file, err = m.FollowShortcut(ctx, reqCtx, file, fields); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// checkContent rejects a download that cannot succeed before anything is
+// written: a missing decryption key, no download capability or an
+// unsupported export format
+func (m *Manager) checkContent(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, opts DownloadOptions) error {
+	if IsEncrypted(file) {
+		if err := checkDecryptionKey(file, opts.Decryption); err != nil {
+			return err
+		}
+	}
+	if err := checkCapabilities(file, CapabilityDownload); err != nil {
+		return err
+	}
+	if utils.IsWorkspaceMimeType(file.MimeType) && opts.MimeType != "" {
+		return m.ValidateExport(ctx, reqCtx, file.MimeType, opts.MimeType)
+	}
+	return nil
+}
+
+// writeContent writes a file's plaintext, export or blob to w and records
+// how it was verified
+func (m *Manager) writeContent(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, opts DownloadOptions, w io.Writer) error {
+	var err error
+	switch {
+	case IsEncrypted(file):
+		// Decryption authenticates the content, which is more than
+		// Drive's checksum of the ciphertext would
+		err = m.downloadDecrypted(ctx, reqCtx, file, opts.Decryption, w)
+		file.Verification = skippedVerification("authenticated by decryption")
+	case utils.IsWorkspaceMimeType(file.MimeType):
+		err = m.exportFile(ctx, reqCtx, file.ID, file, opts, w)
+		file.Verification = skippedVerification("exported content has no Drive checksum")
+	default:
+		file.Verification, err = m.downloadVerified(ctx, reqCtx, file, opts.NoVerify, w)
+	}
+	return err
+}
+
+// downloadVerified downloads a blob, checking it against Drive's checksum
+// unless noVerify is set
+func (m *Manager) downloadVerified(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, noVerify bool, writer io.Writer) (*types.ChecksumVerification, error) {
+	if noVerify {
+		return disabledVerification(), m.downloadBlob(ctx, reqCtx, file.ID, writer)
+	}
+	h := newChecksumHasher(file.MD5Checksum, file.SHA256Checksum)
+	if h == nil {
+		return skippedVerification("Drive reports no checksum"), m.downloadBlob(ctx, reqCtx, file.ID, writer)
+	}
+	if err := m.downloadBlob(ctx, reqCtx, file.ID, io.MultiWriter(writer, h)); err != nil {
+		return nil, err
+	}
+	return h.verify(file.ID, file.Name, "retry the download; if it persists, the stored copy may be damaged")
 }
 
 func (m *Manager) downloadBlob(ctx context.Context, reqCtx *types.RequestContext, fileID string, writer io.Writer) error {
@@ -255,8 +503,11 @@ func (m *Manager) downloadBlob(ctx context.Context, reqCtx *types.RequestContext
 	// Download content
 	httpResp, err := call.Download()
 	if err != nil {
-		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
-			fmt.Sprintf("Download failed: %s", err)).Build())
+		builder := utils.NewCLIError(utils.ErrCodeNetworkError, fmt.Sprintf("Download failed: %s", err))
+		if apiErr, ok := err.(*googleapi.Error); ok {
+			builder = builder.WithHTTPStatus(apiErr.Code)
+		}
+		return utils.NewAppError(builder.Build())
 	}
 	defer httpResp.Body.Close()
 
@@ -266,6 +517,9 @@ func (m *Manager) downloadBlob(ctx context.Context, reqCtx *types.RequestContext
 
 func (m *Manager) exportFile(ctx context.Context, reqCtx *types.RequestContext, fileID string, file *types.DriveFile, opts DownloadOptions, writer io.Writer) error {
 	mimeType := opts.MimeType
+	if mimeType == "" {
+		mimeType = m.exportDefaults[file.MimeType]
+	}
 	if mimeType == "" {
 		mimeType = "application/pdf" // Default export format
 	}
@@ -417,15 +671,7 @@ func (m *Manager) pollAndDownloadOperation(ctx context.Context, reqCtx *types.Re
 func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, fileID string, fields string) (*types.DriveFile, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
-	call := m.client.Service().Files.Get(fileID)
-	call = m.shaper.ShapeFilesGet(call, reqCtx)
-	if fields != "" {
-		call = call.Fields(googleapi.Field(fields))
-	}
-
-	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
-		return call.Do()
-	})
+	result, err := m.client.GetFile(ctx, reqCtx, fileID, fields)
 	if err != nil {
 		return nil, err
 	}
@@ -461,6 +707,12 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, opts L
 		}
 		query += "trashed = false"
 	}
+	if opts.Starred {
+		if query != "" {
+			query += " and "
+		}
+		query += "starred = true"
+	}
 	if opts.Query != "" {
 		if query != "" {
 			query += " and "
@@ -509,24 +761,42 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, opts L
 // ListAll lists all files by following pagination
 func (m *Manager) ListAll(ctx context.Context, reqCtx *types.RequestContext, opts ListOptions) ([]*types.DriveFile, error) {
 	var allFiles []*types.DriveFile
-	pageToken := opts.PageToken
+	err := m.ListEach(ctx, reqCtx, opts, func(f *types.DriveFile) error {
+		allFiles = append(allFiles, f)
+		return nil
+	})
+	return allFiles, err
+}
 
+// ListEach fetches every page of a listing, calling fn for each file as its
+// page arrives so callers need not hold the whole listing in memory. It
+// stops between pages and between files once ctx is cancelled.
+func (m *Manager) ListEach(ctx context.Context, reqCtx *types.RequestContext, opts ListOptions, fn func(*types.DriveFile) error) error {
+	pageToken := opts.PageToken
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		opts.PageToken = pageToken
 		result, err := m.List(ctx, reqCtx, opts)
 		if err != nil {
-			return allFiles, err
+			return err
 		}
 
-		allFiles = append(allFiles, result.Files...)
+		for _, f := range result.Files {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(f); err != nil {
+				return err
+			}
+		}
 
 		if result.NextPageToken == "" {
-			break
+			return nil
 		}
 		pageToken = result.NextPageToken
 	}
-
-	return allFiles, nil
 }
 
 // Delete deletes or trashes a file
@@ -544,8 +814,13 @@ func (m *Manager) Delete(ctx context.Context, reqCtx *types.RequestContext, file
 func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestContext, fileID string, permanent bool, opts safety.SafetyOptions, recorder safety.DryRunRecorder) error {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
-	// Get file metadata for confirmation and dry-run display
-	file, err := m.Get(ctx, reqCtx, fileID, "id,name")
+	// Get file metadata for confirmation and dry-run display, failing fast
+	// if the caller lacks the capability for the requested operation
+	required := CapabilityTrash
+	if permanent {
+		required = CapabilityDelete
+	}
+	file, err := m.RequireCapabilities(ctx, reqCtx, fileID, required)
 	if err != nil {
 		return err
 	}
@@ -557,6 +832,11 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 	}
 
 	// Confirmation for destructive operations
+	scope := safety.ScopeTrash
+	if permanent {
+		scope = safety.ScopeDelete
+	}
+	opts = opts.ForScope(scope)
 	if opts.ShouldConfirm() {
 		operation := "trash"
 		if permanent {
@@ -636,8 +916,8 @@ func (m *Manager) MoveWithSafety(ctx context.Context, reqCtx *types.RequestConte
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 	reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, newParentID)
 
-	// Get current file info
-	file, err := m.Get(ctx, reqCtx, fileID, "parents,name")
+	// Get current file info, failing fast when the caller cannot edit it
+	file, err := m.RequireCapabilities(ctx, reqCtx, fileID, CapabilityEdit)
 	if err != nil {
 		return nil, err
 	}
@@ -709,7 +989,16 @@ func (m *Manager) Restore(ctx context.Context, reqCtx *types.RequestContext, fil
 func (m *Manager) updateTrashed(ctx context.Context, reqCtx *types.RequestContext, fileID string, trashed bool) (*types.DriveFile, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
-	call := m.client.Service().Files.Update(fileID, &drive.File{Trashed: trashed})
+	required := CapabilityTrash
+	if !trashed {
+		required = CapabilityUntrash
+	}
+	if _, err := m.RequireCapabilities(ctx, reqCtx, fileID, required); err != nil {
+		return nil, err
+	}
+
+	// Restoring sends false, the zero value, so it must be forced
+	call := m.client.Service().Files.Update(fileID, &drive.File{Trashed: trashed, ForceSendFields: []string{"Trashed"}})
 	call = m.shaper.ShapeFilesUpdate(call, reqCtx)
 
 	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
@@ -749,19 +1038,24 @@ func (m *Manager) Update(ctx context.Context, reqCtx *types.RequestContext, file
 
 func convertDriveFile(f *drive.File) *types.DriveFile {
 	file := &types.DriveFile{
-		ID:             f.Id,
-		Name:           f.Name,
-		MimeType:       f.MimeType,
-		Size:           f.Size,
-		MD5Checksum:    f.Md5Checksum,
-		CreatedTime:    f.CreatedTime,
-		ModifiedTime:   f.ModifiedTime,
-		Parents:        f.Parents,
-		ResourceKey:    f.ResourceKey,
-		ExportLinks:    f.ExportLinks,
-		WebViewLink:    f.WebViewLink,
-		WebContentLink: f.WebContentLink,
-		Trashed:        f.Trashed,
+		ID:              f.Id,
+		Name:            f.Name,
+		MimeType:        f.MimeType,
+		Description:     f.Description,
+		Size:            f.Size,
+		MD5Checksum:     f.Md5Checksum,
+		SHA256Checksum:  f.Sha256Checksum,
+		CreatedTime:     f.CreatedTime,
+		ModifiedTime:    f.ModifiedTime,
+		Parents:         f.Parents,
+		ResourceKey:     f.ResourceKey,
+		ExportLinks:     f.ExportLinks,
+		WebViewLink:     f.WebViewLink,
+		WebContentLink:  f.WebContentLink,
+		Trashed:         f.Trashed,
+		Starred:         f.Starred,
+		AppProperties:   f.AppProperties,
+		ShortcutDetails: convertShortcutDetails(f.ShortcutDetails),
 	}
 
 	if f.Capabilities != nil {
@@ -772,6 +1066,8 @@ func convertDriveFile(f *drive.File) *types.DriveFile {
 			CanDelete:        f.Capabilities.CanDelete,
 			CanTrash:         f.Capabilities.CanTrash,
 			CanReadRevisions: f.Capabilities.CanReadRevisions,
+			CanRename:        f.Capabilities.CanRename,
+			CanUntrash:       f.Capabilities.CanUntrash,
 		}
 	}
 
@@ -1,9 +1,13 @@
 package files
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"google.golang.org/api/drive/v3"
 )
@@ -569,3 +573,29 @@ func TestConvertDriveFile_MultipleParents(t *testing.T) {
 		}
 	}
 }
+
+func TestListEach_StopsWhenCancelled(t *testing.T) {
+	pages := 0
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		_, _ = w.Write([]byte(`{"nextPageToken":"more","files":[{"id":"a","name":"a"},{"id":"b","name":"b"},{"id":"c","name":"c"}]}`))
+	}))
+	mgr := NewManager(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var seen []string
+	err := mgr.ListEach(ctx, api.NewRequestContext("default", "", types.RequestTypeListOrSearch), ListOptions{}, func(f *types.DriveFile) error {
+		seen = append(seen, f.ID)
+		if len(seen) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListEach = %v, want context.Canceled", err)
+	}
+	if len(seen) != 2 || pages != 1 {
+		t.Errorf("visited %v over %d pages, want to stop after the cancelling file", seen, pages)
+	}
+}
@@ -0,0 +1,401 @@
+package files
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// OfficeFormats maps the Workspace types converted by ExportOffice to their
+// Office export format
+var OfficeFormats = map[string]string{
+	utils.MimeTypeDocument:     utils.FormatMappings["docx"],
+	utils.MimeTypeSpreadsheet:  utils.FormatMappings["xlsx"],
+	utils.MimeTypePresentation: utils.FormatMappings["pptx"],
+}
+
+// OfficeItemPlanned is the status of items in a dry-run Office export
+const OfficeItemPlanned = "planned"
+
+// OfficeExportOptions configures ExportOffice
+type OfficeExportOptions struct {
+	Output        string // Directory to write into, or a .zip archive (default: folder name)
+	IncludeOther  bool   // Copy files that are not Docs, Sheets or Slides as they are
+	Workers       int    // Concurrent exports (default: DefaultExportWorkers)
+	Overwrite     bool   // Write into an existing non-empty directory or replace an archive
+	SkipPreflight bool   // Start even if the disk-space or path-length checks fail
+	DryRun        bool   // Plan the export without writing anything
+	NoVerify      bool   // Skip checking copied files against Drive's checksums
+}
+
+// IsZipOutput reports whether an Office export to path is written as a zip
+// archive
+func IsZipOutput(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".zip")
+}
+
+// ExportOffice converts every Doc, Sheet and Slides deck under a folder to
+// docx, xlsx and pptx, preserving the folder structure. Output is either a
+// directory or, when it ends in .zip, an archive streamed as files finish;
+// the archive is moved into place only when complete.
+//
+// Drive allows duplicate names in a folder; colliding local names get a
+// " (n)" suffix and are flagged as renamed in the report. A failure on one
+// file is recorded in the report and does not stop the export.
+func (m *Manager) ExportOffice(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts OfficeExportOptions) (*types.OfficeExportReport, error) {
+	archive := IsZipOutput(opts.Output)
+	treeOpts := DownloadTreeOptions{OutputDir: opts.Output, ExportFormats: OfficeFormats}
+	if archive {
+		// Entries are rooted at the folder name inside the archive
+		treeOpts.OutputDir = ""
+	}
+	plan, err := m.planTree(ctx, reqCtx, folderID, treeOpts)
+	if err != nil {
+		return nil, err
+	}
+	restrictToOffice(plan, opts.IncludeOther)
+
+	report := &types.OfficeExportReport{FolderID: folderID, Output: plan.outputDir, Archive: archive, DryRun: opts.DryRun}
+	if archive {
+		report.Output = opts.Output
+	}
+	items := make(map[*treeEntry]*types.OfficeExportItem, len(plan.entries))
+	for _, entry := range plan.entries {
+		item := officeItem(entry, archive)
+		items[entry] = item
+		report.Items = append(report.Items, item)
+	}
+	defer tallyOffice(report)
+
+	if opts.DryRun {
+		for _, item := range report.Items {
+			if item.Status == "" {
+				item.Status = OfficeItemPlanned
+			}
+		}
+		return report, nil
+	}
+
+	if err := checkOfficeOutput(report.Output, archive, opts.Overwrite); err != nil {
+		return report, err
+	}
+	if !archive {
+		if estimate := estimateTree(plan); len(estimate.Problems) > 0 && !opts.SkipPreflight {
+			return report, preflightError(estimate)
+		}
+	}
+
+	var sink officeSink
+	if archive {
+		sink, err = newZipSink(opts.Output, plan)
+	} else {
+		sink, err = newDirSink(plan)
+	}
+	if err != nil {
+		return report, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to create output: %s", err)).Build())
+	}
+	defer sink.discard()
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultExportWorkers
+	}
+	jobs := make(chan *treeEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				item := items[entry]
+				status, err := m.exportOfficeEntry(ctx, reqCtx, entry, sink, opts.NoVerify)
+				item.Status = status
+				if err != nil {
+					item.Status = TreeItemFailed
+					item.Error = err.Error()
+				}
+			}
+		}()
+	}
+
+	var runErr error
+dispatch:
+	for _, entry := range plan.entries {
+		if items[entry].Status != "" {
+			continue
+		}
+		select {
+		case jobs <- entry:
+		case <-ctx.Done():
+			runErr = ctx.Err()
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if runErr != nil {
+		return report, runErr
+	}
+	if err := sink.finish(); err != nil {
+		return report, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to write %s: %s", report.Output, err)).Build())
+	}
+	return report, nil
+}
+
+// tallyOffice counts the report totals from its items and sorts them by path
+func tallyOffice(r *types.OfficeExportReport) {
+	r.Converted, r.Copied, r.Skipped, r.Failed, r.Renamed = 0, 0, 0, 0, 0
+	for _, item := range r.Items {
+		switch item.Status {
+		case TreeItemExported, TreeItemExportedViaLink:
+			r.Converted++
+		case TreeItemDownloaded:
+			r.Copied++
+		case TreeItemSkipped:
+			r.Skipped++
+		case TreeItemFailed:
+			r.Failed++
+		}
+		if item.Renamed {
+			r.Renamed++
+		}
+	}
+	sort.Slice(r.Items, func(i, j int) bool {
+		return r.Items[i].Path < r.Items[j].Path
+	})
+}
+
+// restrictToOffice skips everything but Docs, Sheets and Slides, and other
+// files unless they are copied as they are
+func restrictToOffice(plan *treePlan, includeOther bool) {
+	for _, entry := range plan.entries {
+		switch {
+		case entry.skipReason != "", entry.failReason != "":
+		case entry.exportMime != "":
+			if _, ok := OfficeFormats[entry.file.MimeType]; !ok {
+				entry.skipReason = "no Office format for this type"
+			}
+		case !includeOther:
+			entry.skipReason = "not a Docs, Sheets or Slides file (use --include-other to copy it)"
+		}
+		if entry.skipReason != "" {
+			entry.exportMime = ""
+		}
+	}
+}
+
+// officeItem starts the report item for a planned entry; Status is set
+// only for skipped entries
+func officeItem(entry *treeEntry, archive bool) *types.OfficeExportItem {
+	item := &types.OfficeExportItem{
+		FileID:   entry.file.ID,
+		Name:     entry.file.Name,
+		MimeType: entry.file.MimeType,
+	}
+	if entry.skipReason != "" {
+		item.Status = TreeItemSkipped
+		item.Error = entry.skipReason
+		return item
+	}
+	if entry.failReason != "" {
+		item.Status = TreeItemFailed
+		item.Error = entry.failReason
+		return item
+	}
+
+	item.Path = entry.localPath
+	if archive {
+		item.Path = filepath.ToSlash(entry.localPath)
+	}
+	ext := exportExtension(entry.exportMime)
+	item.Format = strings.TrimPrefix(ext, ".")
+	item.Renamed = filepath.Base(entry.localPath) != sanitizeLocalName(entry.file.Name)+ext
+	return item
+}
+
+// checkOfficeOutput refuses to mix an export into existing output unless
+// overwriting
+func checkOfficeOutput(path string, archive, overwrite bool) error {
+	if overwrite {
+		return nil
+	}
+	exists := false
+	if archive {
+		_, err := os.Stat(path)
+		exists = err == nil
+	} else if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+		exists = true
+	}
+	if !exists {
+		return nil
+	}
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("Output '%s' already exists", path)).
+		WithContext("suggestedAction", "choose another --output or use --force to write over it").
+		Build())
+}
+
+func (m *Manager) exportOfficeEntry(ctx context.Context, reqCtx *types.RequestContext, entry *treeEntry, sink officeSink, noVerify bool) (string, error) {
+	f, err := sink.create(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	childCtx := childRequestContext(reqCtx, entry.file.ID)
+	status := TreeItemDownloaded
+	if entry.exportMime != "" {
+		status, err = m.exportToFile(ctx, childCtx, entry.file, DownloadOptions{MimeType: entry.exportMime}, f)
+	} else if err = checkCapabilities(entry.file, CapabilityDownload); err == nil {
+		_, err = m.downloadVerified(ctx, childCtx, entry.file, noVerify, f)
+	}
+	if err != nil {
+		sink.abandon(f)
+		return "", err
+	}
+	if err := sink.add(entry, f); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// officeSink stores finished files of an Office export. create and add are
+// called from concurrent workers.
+type officeSink interface {
+	create(entry *treeEntry) (*os.File, error)
+	// add takes ownership of f once it holds the entry's complete content
+	add(entry *treeEntry, f *os.File) error
+	abandon(f *os.File)
+	finish() error
+	discard()
+}
+
+// dirSink writes files into the planned local directory tree
+type dirSink struct {
+	mu       sync.Mutex
+	partials map[*os.File]*tempdir.Partial
+}
+
+func newDirSink(plan *treePlan) (*dirSink, error) {
+	for _, dir := range plan.dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &dirSink{partials: map[*os.File]*tempdir.Partial{}}, nil
+}
+
+func (s *dirSink) create(entry *treeEntry) (*os.File, error) {
+	p, err := tempdir.CreatePartial(entry.localPath)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partials[p.File] = p
+	return p.File, nil
+}
+
+func (s *dirSink) take(f *os.File) *tempdir.Partial {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.partials[f]
+	delete(s.partials, f)
+	return p
+}
+
+func (s *dirSink) add(entry *treeEntry, f *os.File) error {
+	return s.take(f).Commit()
+}
+
+func (s *dirSink) abandon(f *os.File) {
+	s.take(f).Discard()
+}
+
+func (s *dirSink) finish() error { return nil }
+
+func (s *dirSink) discard() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for f, p := range s.partials {
+		p.Discard()
+		delete(s.partials, f)
+	}
+}
+
+// zipSink spools each file in the temp sandbox and appends it to the
+// archive as soon as it is complete, so no staging copy of the tree is kept
+type zipSink struct {
+	mu  sync.Mutex
+	out *tempdir.Partial
+	zw  *zip.Writer
+}
+
+func newZipSink(path string, plan *treePlan) (*zipSink, error) {
+	out, err := tempdir.CreatePartial(path)
+	if err != nil {
+		return nil, err
+	}
+	zw := zip.NewWriter(out)
+	// Directory entries keep empty folders in the archive
+	for _, dir := range plan.dirs {
+		if _, err := zw.Create(filepath.ToSlash(dir) + "/"); err != nil {
+			out.Discard()
+			return nil, err
+		}
+	}
+	return &zipSink{out: out, zw: zw}, nil
+}
+
+func (s *zipSink) create(entry *treeEntry) (*os.File, error) {
+	return tempdir.CreateTemp("office-*")
+}
+
+func (s *zipSink) add(entry *treeEntry, f *os.File) error {
+	defer s.abandon(f)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	header := &zip.FileHeader{Name: filepath.ToSlash(entry.localPath), Method: zip.Deflate}
+	if t, err := time.Parse(time.RFC3339, entry.file.ModifiedTime); err == nil {
+		header.Modified = t
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := s.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func (s *zipSink) abandon(f *os.File) {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+func (s *zipSink) finish() error {
+	if err := s.zw.Close(); err != nil {
+		return err
+	}
+	return s.out.Commit()
+}
+
+func (s *zipSink) discard() {
+	s.out.Discard()
+}
@@ -0,0 +1,183 @@
+package files
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+func newOfficeTestManager(t *testing.T) *Manager {
+	t.Helper()
+	files := map[string]*drive.File{
+		"top":   {Id: "top", Name: "Handover", MimeType: utils.MimeTypeFolder},
+		"doc1":  {Id: "doc1", Name: "Report", MimeType: utils.MimeTypeDocument, Parents: []string{"top"}},
+		"doc2":  {Id: "doc2", Name: "Report", MimeType: utils.MimeTypeDocument, Parents: []string{"top"}},
+		"sheet": {Id: "sheet", Name: "Budget", MimeType: utils.MimeTypeSpreadsheet, Parents: []string{"top"}},
+		"draw":  {Id: "draw", Name: "Diagram", MimeType: utils.MimeTypeDrawing, Parents: []string{"top"}},
+		"txt":   {Id: "txt", Name: "notes.txt", MimeType: "text/plain", Parents: []string{"top"}},
+		"sub":   {Id: "sub", Name: "Decks", MimeType: utils.MimeTypeFolder, Parents: []string{"top"}},
+		"deck":  {Id: "deck", Name: "Q3", MimeType: utils.MimeTypePresentation, Parents: []string{"sub"}, ModifiedTime: "2026-01-02T03:04:05Z"},
+	}
+	order := []string{"doc1", "doc2", "sheet", "draw", "txt", "sub", "deck"}
+
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/drive/v3/files")
+		switch {
+		case path == "":
+			q := r.URL.Query().Get("q")
+			list := &drive.FileList{Files: []*drive.File{}}
+			for _, id := range order {
+				if f := files[id]; strings.Contains(q, "'"+f.Parents[0]+"' in parents") {
+					list.Files = append(list.Files, f)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		case strings.HasSuffix(path, "/export"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/export")
+			if id == "sheet" {
+				http.Error(w, `{"error":{"code":500,"message":"backend error"}}`, http.StatusInternalServerError)
+				return
+			}
+			_, _ = io.WriteString(w, id+" as "+r.URL.Query().Get("mimeType"))
+		case r.URL.Query().Get("alt") == "media":
+			_, _ = io.WriteString(w, "plain notes")
+		default:
+			_ = json.NewEncoder(w).Encode(files[strings.TrimPrefix(path, "/")])
+		}
+	}))
+
+	return NewManager(client)
+}
+
+func officeStatuses(report *types.OfficeExportReport) map[string]string {
+	statuses := map[string]string{}
+	for _, item := range report.Items {
+		statuses[item.FileID] = item.Status
+	}
+	return statuses
+}
+
+func TestExportOffice_Directory(t *testing.T) {
+	mgr := newOfficeTestManager(t)
+	out := filepath.Join(t.TempDir(), "out")
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+
+	report, err := mgr.ExportOffice(context.Background(), reqCtx, "top", OfficeExportOptions{Output: out, SkipPreflight: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"doc1":  TreeItemExported,
+		"doc2":  TreeItemExported,
+		"sheet": TreeItemFailed,
+		"draw":  TreeItemSkipped,
+		"txt":   TreeItemSkipped,
+		"deck":  TreeItemExported,
+	}
+	got := officeStatuses(report)
+	for id, status := range want {
+		if got[id] != status {
+			t.Errorf("%s status = %q, want %q", id, got[id], status)
+		}
+	}
+	if report.Converted != 3 || report.Failed != 1 || report.Skipped != 2 || report.Renamed != 1 {
+		t.Errorf("totals = %+v", report)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "Report (1).docx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "doc2 as ") {
+		t.Errorf("renamed doc content = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(out, "Decks", "Q3.pptx")); err != nil {
+		t.Errorf("nested export missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "Budget.xlsx")); !os.IsNotExist(err) {
+		t.Errorf("failed export should leave no file, got %v", err)
+	}
+
+	// A second run into the same directory needs Overwrite
+	_, err = mgr.ExportOffice(context.Background(), reqCtx, "top", OfficeExportOptions{Output: out, SkipPreflight: true})
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+		t.Errorf("expected existing output error, got %v", err)
+	}
+}
+
+func TestExportOffice_Zip(t *testing.T) {
+	mgr := newOfficeTestManager(t)
+	out := filepath.Join(t.TempDir(), "handover.zip")
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+
+	report, err := mgr.ExportOffice(context.Background(), reqCtx, "top", OfficeExportOptions{Output: out, IncludeOther: true, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Archive || report.Converted != 3 || report.Copied != 1 {
+		t.Errorf("report = %+v", report)
+	}
+
+	zr, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+		if f.Name == "Handover/Decks/Q3.pptx" && f.Modified.Year() != 2026 {
+			t.Errorf("modified time not kept: %v", f.Modified)
+		}
+	}
+	sort.Strings(names)
+	wantNames := []string{"Handover/", "Handover/Decks/", "Handover/Decks/Q3.pptx", "Handover/Report (1).docx", "Handover/Report.docx", "Handover/notes.txt"}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Errorf("archive entries = %v, want %v", names, wantNames)
+	}
+	if contents["Handover/notes.txt"] != "plain notes" {
+		t.Errorf("copied file content = %q", contents["Handover/notes.txt"])
+	}
+}
+
+func TestExportOffice_DryRun(t *testing.T) {
+	mgr := newOfficeTestManager(t)
+	out := filepath.Join(t.TempDir(), "out")
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+
+	report, err := mgr.ExportOffice(context.Background(), reqCtx, "top", OfficeExportOptions{Output: out, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := officeStatuses(report); got["doc1"] != OfficeItemPlanned || got["txt"] != TreeItemSkipped {
+		t.Errorf("statuses = %v", got)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("dry run should not create output, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestOwnerAggregator(t *testing.T) {
+	alice := []*drive.User{{EmailAddress: "alice@example.com", DisplayName: "Alice"}}
+	bob := []*drive.User{{EmailAddress: "bob@ext.com", DisplayName: "Bob"}}
+
+	agg := newOwnerAggregator()
+	agg.add(&drive.File{Id: "1", MimeType: "application/pdf", Size: 100, Owners: alice})
+	agg.add(&drive.File{Id: "2", MimeType: utils.MimeTypeDocument, QuotaBytesUsed: 50, Owners: alice})
+	agg.add(&drive.File{Id: "3", MimeType: utils.MimeTypeFolder, Owners: alice})
+	agg.add(&drive.File{Id: "4", MimeType: "image/png", Size: 500, QuotaBytesUsed: 500, Owners: bob})
+	agg.add(&drive.File{Id: "5", MimeType: "text/plain", Size: 10})
+
+	report := agg.report()
+
+	if report.TotalFiles != 4 || report.TotalFolders != 1 || report.TotalBytes != 660 {
+		t.Fatalf("totals = %d files, %d folders, %d bytes", report.TotalFiles, report.TotalFolders, report.TotalBytes)
+	}
+	if len(report.Owners) != 3 {
+		t.Fatalf("expected 3 owners, got %d", len(report.Owners))
+	}
+
+	// Sorted by bytes descending
+	if report.Owners[0].Owner != "bob@ext.com" {
+		t.Errorf("first owner = %s, want bob@ext.com", report.Owners[0].Owner)
+	}
+	if a := report.Owners[1]; a.Owner != "alice@example.com" || a.Files != 2 || a.Folders != 1 || a.Bytes != 150 {
+		t.Errorf("alice stats = %+v", a)
+	}
+	if report.Owners[2].Owner != SharedDriveOwner {
+		t.Errorf("ownerless item bucket = %s, want %s", report.Owners[2].Owner, SharedDriveOwner)
+	}
+}
@@ -0,0 +1,368 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Rename item statuses
+const (
+	RenamePlanned   = "planned"
+	RenameDone      = "renamed"
+	RenameUnchanged = "unchanged"
+	RenameConflict  = "conflict"
+	RenameFailed    = "failed"
+)
+
+const renameFields = "id,name,mimeType,parents"
+
+// RenamePattern is a sed-style substitution, s/regexp/replacement/flags.
+// Any character may follow the s as the delimiter. In the replacement, &
+// is the whole match and \1 to \9 are groups. The flags are g (replace
+// every match, not just the first) and i (ignore case).
+type RenamePattern struct {
+	expr     string
+	re       *regexp.Regexp
+	template string
+	global   bool
+}
+
+// ParseRenamePattern parses a sed-style substitution such as s/draft/final/
+func ParseRenamePattern(expr string) (*RenamePattern, error) {
+	invalid := func(reason string) error {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid rename pattern %q: %s", expr, reason)).Build())
+	}
+	if len(expr) < 2 || expr[0] != 's' {
+		return nil, invalid("expected s/regexp/replacement/")
+	}
+	delim := expr[1]
+	if delim == '\\' || delim == '\n' {
+		return nil, invalid("invalid delimiter")
+	}
+	parts := splitUnescaped(expr[2:], delim)
+	if len(parts) != 3 {
+		return nil, invalid("expected s/regexp/replacement/")
+	}
+	p := &RenamePattern{expr: expr}
+	prefix := ""
+	for _, flag := range parts[2] {
+		switch flag {
+		case 'g':
+			p.global = true
+		case 'i':
+			prefix = "(?i)"
+		default:
+			return nil, invalid(fmt.Sprintf("unknown flag %q", flag))
+		}
+	}
+	if parts[0] == "" {
+		return nil, invalid("empty regexp")
+	}
+	re, err := regexp.Compile(prefix + parts[0])
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	p.re = re
+	p.template = sedTemplate(parts[1])
+	return p, nil
+}
+
+// String returns the pattern as given
+func (p *RenamePattern) String() string {
+	return p.expr
+}
+
+// Apply returns name with the substitution applied
+func (p *RenamePattern) Apply(name string) string {
+	matches := p.re.FindAllStringSubmatchIndex(name, -1)
+	if !p.global && len(matches) > 1 {
+		matches = matches[:1]
+	}
+	var b []byte
+	last := 0
+	for _, match := range matches {
+		b = append(b, name[last:match[0]]...)
+		b = p.re.ExpandString(b, p.template, name, match)
+		last = match[1]
+	}
+	return string(b) + name[last:]
+}
+
+// splitUnescaped splits s at unescaped delim, unescaping \delim
+func splitUnescaped(s string, delim byte) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			cur.WriteByte(delim)
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			cur.WriteByte(s[i])
+			cur.WriteByte(s[i+1])
+			i++
+		case s[i] == delim:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	return append(parts, cur.String())
+}
+
+// sedTemplate converts a sed replacement to a regexp.Expand template
+func sedTemplate(repl string) string {
+	var b strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		switch {
+		case c == '\\' && i+1 < len(repl) && repl[i+1] >= '0' && repl[i+1] <= '9':
+			b.WriteString("${" + string(repl[i+1]) + "}")
+			i++
+		case c == '\\' && i+1 < len(repl):
+			i++
+			if repl[i] == '$' {
+				b.WriteString("$$")
+			} else {
+				b.WriteByte(repl[i])
+			}
+		case c == '&':
+			b.WriteString("${0}")
+		case c == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// RenameItem is one file of a rename
+type RenameItem struct {
+	FileID     string   `json:"fileId"`
+	Name       string   `json:"name"`
+	NewName    string   `json:"newName"`
+	Parents    []string `json:"parents,omitempty"`
+	Status     string   `json:"status"`
+	ConflictID string   `json:"conflictId,omitempty"` // The sibling that already uses NewName
+	Error      string   `json:"error,omitempty"`
+}
+
+// RenameReport lists the files a rename changes
+type RenameReport struct {
+	Pattern   string        `json:"pattern,omitempty"`
+	IfUnique  bool          `json:"ifUnique,omitempty"`
+	Planned   int           `json:"planned"`
+	Renamed   int           `json:"renamed"`
+	Unchanged int           `json:"unchanged"`
+	Conflicts int           `json:"conflicts"`
+	Failed    int           `json:"failed"`
+	DryRun    bool          `json:"dryRun,omitempty"`
+	Items     []*RenameItem `json:"items"`
+}
+
+func (r *RenameReport) Headers() []string {
+	return []string{"ID", "Name", "New Name", "Status"}
+}
+
+func (r *RenameReport) Rows() [][]string {
+	rows := make([][]string, len(r.Items))
+	for i, item := range r.Items {
+		status := item.Status
+		switch {
+		case item.Error != "":
+			status += ": " + item.Error
+		case item.ConflictID != "":
+			status += " with " + item.ConflictID
+		}
+		rows[i] = []string{item.FileID, item.Name, item.NewName, status}
+	}
+	return rows
+}
+
+func (r *RenameReport) EmptyMessage() string {
+	return "No files to rename"
+}
+
+// PlanRename plans renaming one file. With ifUnique, a sibling in any of
+// the file's folders that already uses newName fails with NAME_CONFLICT
+// and the ID of that sibling.
+func (m *Manager) PlanRename(ctx context.Context, reqCtx *types.RequestContext, fileID, newName string, ifUnique bool) (*RenameReport, error) {
+	if newName == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"File name cannot be empty").Build())
+	}
+	file, err := m.Get(ctx, reqCtx, fileID, renameFields)
+	if err != nil {
+		return nil, err
+	}
+	report := &RenameReport{IfUnique: ifUnique}
+	if err := m.planRenames(ctx, reqCtx, report, []*types.DriveFile{file}, func(string) string { return newName }); err != nil {
+		return nil, err
+	}
+	if item := report.Items[0]; item.Status == RenameConflict {
+		return nil, nameConflictError(item)
+	}
+	return report, nil
+}
+
+// PlanBulkRename plans applying pattern to the names of fileIDs and, when
+// folderID is set, of the folder's direct children. With ifUnique, a file
+// whose new name is already used by a sibling, or by another file renamed
+// in the same folder, is marked as a conflict and left unchanged.
+func (m *Manager) PlanBulkRename(ctx context.Context, reqCtx *types.RequestContext, fileIDs []string, folderID string, pattern *RenamePattern, ifUnique bool) (*RenameReport, error) {
+	var targets []*types.DriveFile
+	for _, id := range fileIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		file, err := m.Get(ctx, reqCtx, id, renameFields)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, file)
+	}
+	if folderID != "" {
+		reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, folderID)
+		children, err := m.listChildren(ctx, reqCtx, folderID, renameFields)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range children {
+			targets = append(targets, convertDriveFile(f))
+		}
+	}
+
+	report := &RenameReport{Pattern: pattern.String(), IfUnique: ifUnique}
+	if err := m.planRenames(ctx, reqCtx, report, targets, pattern.Apply); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (m *Manager) planRenames(ctx context.Context, reqCtx *types.RequestContext, report *RenameReport, targets []*types.DriveFile, rename func(string) string) error {
+	report.Items = []*RenameItem{}
+	renamed := map[string]string{} // File ID -> new name, for files being renamed
+	claimed := map[string]string{} // Parent and new name -> file ID
+	for _, f := range targets {
+		item := &RenameItem{FileID: f.ID, Name: f.Name, NewName: rename(f.Name), Parents: f.Parents, Status: RenamePlanned}
+		report.Items = append(report.Items, item)
+		if item.NewName == item.Name {
+			item.Status = RenameUnchanged
+			continue
+		}
+		if item.NewName == "" {
+			item.Status = RenameFailed
+			item.Error = "new name is empty"
+			continue
+		}
+		renamed[item.FileID] = item.NewName
+	}
+
+	for _, item := range report.Items {
+		if item.Status != RenamePlanned || !report.IfUnique {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, parent := range item.Parents {
+			key := parent + "/" + item.NewName
+			if other, ok := claimed[key]; ok && other != item.FileID {
+				item.ConflictID = other
+				break
+			}
+			claimed[key] = item.FileID
+			siblings, err := m.findNamed(ctx, reqCtx, parent, item.NewName)
+			if err != nil {
+				return err
+			}
+			for _, sibling := range siblings {
+				// A sibling being renamed away frees the name
+				if newName, ok := renamed[sibling.ID]; sibling.ID == item.FileID || ok && newName != item.NewName {
+					continue
+				}
+				item.ConflictID = sibling.ID
+				break
+			}
+			if item.ConflictID != "" {
+				break
+			}
+		}
+		if item.ConflictID != "" {
+			item.Status = RenameConflict
+		}
+	}
+
+	for _, item := range report.Items {
+		switch item.Status {
+		case RenamePlanned:
+			report.Planned++
+		case RenameUnchanged:
+			report.Unchanged++
+		case RenameConflict:
+			report.Conflicts++
+		case RenameFailed:
+			report.Failed++
+		}
+	}
+	return nil
+}
+
+// findNamed lists the files in parentID named name
+func (m *Manager) findNamed(ctx context.Context, reqCtx *types.RequestContext, parentID, name string) ([]*types.DriveFile, error) {
+	q := query.NewBuilder().Where("name", "=", name).In("parents", parentID).String()
+	found, err := m.List(ctx, reqCtx, ListOptions{Query: q, Fields: "id,name"})
+	if err != nil {
+		return nil, err
+	}
+	return found.Files, nil
+}
+
+// ApplyRenames renames the planned items of report. A file that fails is
+// marked failed and the rest are still renamed. In a dry run nothing is
+// changed.
+func (m *Manager) ApplyRenames(ctx context.Context, reqCtx *types.RequestContext, report *RenameReport, dryRun bool) error {
+	report.DryRun = dryRun
+	if dryRun {
+		return nil
+	}
+	done := 0
+	for _, item := range report.Items {
+		if item.Status != RenamePlanned {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		done++
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files rename", Done: done, Total: report.Planned, Item: item.Name})
+		newName := item.NewName
+		if _, err := m.UpdateMetadata(ctx, reqCtx, item.FileID, MetadataUpdate{Name: &newName}); err != nil {
+			item.Status = RenameFailed
+			item.Error = err.Error()
+			report.Failed++
+			continue
+		}
+		item.Status = RenameDone
+		report.Renamed++
+	}
+	return nil
+}
+
+func nameConflictError(item *RenameItem) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeNameConflict,
+		fmt.Sprintf("A file named %q already exists in the same folder", item.NewName)).
+		WithContext("fileId", item.FileID).
+		WithContext("conflictingId", item.ConflictID).
+		WithContext("suggestedAction", "choose another name, or rename without --if-unique").
+		Build())
+}
@@ -0,0 +1,157 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestRenamePattern(t *testing.T) {
+	tests := []struct {
+		expr, name, want string
+	}{
+		{"s/draft/final/", "draft notes draft.txt", "final notes draft.txt"},
+		{"s/draft/final/g", "draft notes draft.txt", "final notes final.txt"},
+		{"s/DRAFT/final/i", "Draft.docx", "final.docx"},
+		{`s/(\d+)-(\d+)/\2-\1/`, "report 03-2024", "report 2024-03"},
+		{"s|/|-|g", "a/b/c", "a-b-c"},
+		{`s/\.txt$/.md/`, "notes.txt", "notes.md"},
+		{"s/v1/[&] $1/", "v1.pdf", "[v1] $1.pdf"},
+		{"s/nomatch/x/", "keep.txt", "keep.txt"},
+	}
+	for _, tt := range tests {
+		p, err := ParseRenamePattern(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got := p.Apply(tt.name); got != tt.want {
+			t.Errorf("%s on %q = %q, want %q", tt.expr, tt.name, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "draft/final", "s/draft/final", "s/draft/final/x", "s//x/", "s/[/x/"} {
+		if _, err := ParseRenamePattern(expr); err == nil {
+			t.Errorf("%q should be rejected", expr)
+		}
+	}
+}
+
+func TestBulkRenameIfUnique(t *testing.T) {
+	// f1 and f2 both become "final.txt"; f4 does not match
+	children := `{"files":[
+		{"id":"f1","name":"draft.txt","parents":["dir"]},
+		{"id":"f2","name":"DRAFT.txt","parents":["dir"]},
+		{"id":"f3","name":"notes-draft.txt","parents":["dir"]},
+		{"id":"f4","name":"notes.txt","parents":["dir"]}]}`
+	named := map[string]string{
+		"final.txt":       `{"files":[]}`,
+		"notes-final.txt": `{"files":[]}`,
+		"notes.txt":       `{"files":[{"id":"f4","name":"notes.txt"}]}`,
+	}
+	renamed := map[string]string{}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files" && strings.Contains(q, "name ="):
+			name := strings.SplitN(strings.SplitN(q, "name = '", 2)[1], "'", 2)[0]
+			_, _ = w.Write([]byte(named[name]))
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			_, _ = w.Write([]byte(children))
+		case r.Method == http.MethodPatch:
+			var f drive.File
+			_ = json.NewDecoder(r.Body).Decode(&f)
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+			renamed[id] = f.Name
+			_, _ = w.Write([]byte(`{"id":"` + id + `","name":"` + f.Name + `"}`))
+		case r.Method == http.MethodGet:
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+			_, _ = w.Write([]byte(`{"id":"` + id + `","name":"x","parents":["dir"]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	pattern, err := ParseRenamePattern("s/draft/final/i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := mgr.PlanBulkRename(ctx, reqCtx, nil, "dir", pattern, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Planned != 2 || report.Conflicts != 1 || report.Unchanged != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if item := report.Items[1]; item.Status != RenameConflict || item.ConflictID != "f1" {
+		t.Errorf("second file renamed to the same name: %+v", item)
+	}
+
+	if err := mgr.ApplyRenames(ctx, reqCtx, report, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 2 || renamed["f1"] != "final.txt" || renamed["f3"] != "notes-final.txt" || report.Renamed != 2 {
+		t.Errorf("renamed %v, report %+v", renamed, report)
+	}
+
+	_, err = mgr.PlanRename(ctx, reqCtx, "f3", "notes.txt", true)
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeNameConflict || appErr.CLIError.Context["conflictingId"] != "f4" {
+		t.Errorf("PlanRename onto a sibling's name = %v", err)
+	}
+}
+
+// cancelAfter cancels its context once it has seen n events
+type cancelAfter struct {
+	n      int
+	cancel context.CancelFunc
+	events []types.ProgressEvent
+}
+
+func (c *cancelAfter) Progress(event types.ProgressEvent) {
+	c.events = append(c.events, event)
+	if len(c.events) == c.n {
+		c.cancel()
+	}
+}
+
+func TestApplyRenamesStopsWhenCancelled(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+		_, _ = w.Write([]byte(`{"id":"` + id + `","name":"renamed"}`))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mgr := NewManager(client)
+	progress := &cancelAfter{n: 2, cancel: cancel}
+	mgr.SetProgress(progress)
+
+	report := &RenameReport{Planned: 3}
+	for _, id := range []string{"a", "b", "c"} {
+		report.Items = append(report.Items, &RenameItem{FileID: id, Name: id, NewName: id + "2", Status: RenamePlanned})
+	}
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	err := mgr.ApplyRenames(ctx, reqCtx, report, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ApplyRenames = %v, want context.Canceled", err)
+	}
+	if report.Items[2].Status != RenamePlanned {
+		t.Errorf("file after the cancellation was renamed: %+v", report.Items[2])
+	}
+	if len(progress.events) != 2 || progress.events[1] != (types.ProgressEvent{Operation: "files rename", Done: 2, Total: 3, Item: "b"}) {
+		t.Errorf("progress events = %+v", progress.events)
+	}
+}
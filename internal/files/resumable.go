@@ -0,0 +1,337 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/errors"
+	"github.com/dl-alexandre/gdrv/internal/logging"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ResumableChunkAlign is the granularity required by the Drive API for
+// resumable upload chunks; every chunk except the last must be a multiple.
+const ResumableChunkAlign = 256 * 1024
+
+// maxChunkRetries is the number of times a failed chunk is retried, after
+// re-querying the session for the committed offset, before giving up.
+const maxChunkRetries = 5
+
+// chunkRetryBaseDelay is the initial backoff between chunk retries
+var chunkRetryBaseDelay = time.Second
+
+// NormalizeChunkSize returns the chunk size to use for a resumable upload.
+// Zero selects the default; other values are rounded down to a multiple of
+// ResumableChunkAlign (minimum one unit).
+func NormalizeChunkSize(size int64) int64 {
+	if size <= 0 {
+		return utils.UploadChunkSize
+	}
+	if size < ResumableChunkAlign {
+		return ResumableChunkAlign
+	}
+	return size - size%ResumableChunkAlign
+}
+
+// resumableSession is an open resumable upload session
+type resumableSession struct {
+	httpClient *http.Client
+	uri        string
+	size       int64
+}
+
+// ProgressFunc is called as an upload advances, with the number of bytes
+// Drive has committed and the total size
+type ProgressFunc func(sent, total int64)
+
+// resumableOptions configures uploadResumable
+type resumableOptions struct {
+	ChunkSize int64
+	Progress  ProgressFunc
+	// OnSession is called with the session URI once the session is open,
+	// before any content is sent
+	OnSession func(uri string) error
+}
+
+// uploadResumable uploads content in fixed-size chunks using the resumable
+// upload protocol
+func (m *Manager) uploadResumable(ctx context.Context, reqCtx *types.RequestContext, content io.ReaderAt, metadata *drive.File, contentType string, size int64, opts resumableOptions) (*drive.File, error) {
+	session, err := m.startResumableSession(ctx, reqCtx, metadata, contentType, size)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OnSession != nil {
+		if err := opts.OnSession(session.uri); err != nil {
+			return nil, err
+		}
+	}
+	return session.send(ctx, reqCtx, content, 0, NormalizeChunkSize(opts.ChunkSize), opts.Progress)
+}
+
+// send uploads content from offset to the end. A chunk that fails with a
+// network error or a retryable status is retried after asking the server
+// how many bytes it committed (the 308 Range response), so only the
+// missing bytes are re-sent.
+func (s *resumableSession) send(ctx context.Context, reqCtx *types.RequestContext, content io.ReaderAt, offset, chunkSize int64, progress ProgressFunc) (*drive.File, error) {
+	report := func(sent int64) {
+		if progress != nil {
+			progress(sent, s.size)
+		}
+	}
+	report(offset)
+
+	retries := 0
+	for {
+		end := offset + chunkSize
+		if end > s.size {
+			end = s.size
+		}
+
+		file, committed, err := s.putChunk(ctx, content, offset, end)
+		if err == nil {
+			report(committed)
+			if file != nil {
+				return file, nil
+			}
+			if committed > offset {
+				offset = committed
+				retries = 0
+				continue
+			}
+			// The server accepted the chunk but committed none of it.
+			// Retried like a failed chunk, so a session that never moves
+			// on gives up after maxChunkRetries instead of looping.
+			err = utils.NewAppError(utils.NewCLIError(utils.ErrCodeInternalError,
+				fmt.Sprintf("Upload session made no progress past byte %d", offset)).
+				WithContext("chunkStart", offset).
+				WithContext("suggestedAction", "upload the file again").
+				Build())
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		resumable.chunkRetries.Add(1)
+		file, committed, err = s.recoverOffset(ctx, reqCtx, err, &retries, offset, end)
+		if err != nil {
+			return nil, err
+		}
+		report(committed)
+		if file != nil {
+			return file, nil
+		}
+		if committed > offset {
+			resumable.bytesSkipped.Add(committed - offset)
+		}
+		if committed < end {
+			resumable.bytesResent.Add(end - committed)
+		}
+		offset = committed
+	}
+}
+
+// recoverOffset backs off after chunk [start, end) failed with err and asks
+// the server how many bytes it committed, retrying the query itself on
+// network errors and retryable statuses. The offset is checked against what
+// was sent: a server claiming bytes past end has lost track of the upload,
+// and resuming from there would leave a hole in the file.
+func (s *resumableSession) recoverOffset(ctx context.Context, reqCtx *types.RequestContext, err error, retries *int, start, end int64) (*drive.File, int64, error) {
+	for {
+		if !isRetryableChunkError(err) || *retries >= maxChunkRetries {
+			return nil, 0, errors.ClassifyGoogleAPIError("drive", err, reqCtx, logging.NewNoOpLogger())
+		}
+		*retries++
+
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(chunkRetryBaseDelay * time.Duration(math.Pow(2, float64(*retries-1)))):
+		}
+
+		resumable.offsetQueries.Add(1)
+		var file *drive.File
+		var committed int64
+		file, committed, err = s.queryOffset(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
+			continue
+		}
+		if file == nil && committed > end {
+			return nil, 0, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInternalError,
+				fmt.Sprintf("Upload session reports %d bytes committed, but only %d were sent", committed, end)).
+				WithContext("chunkStart", start).
+				WithContext("suggestedAction", "upload the file again").
+				Build())
+		}
+		return file, committed, nil
+	}
+}
+
+// ResumableStats counts how resumable uploads recovered from failed chunks
+type ResumableStats struct {
+	ChunkRetries  int64 `json:"chunkRetries"`  // Chunks that failed and were resumed
+	OffsetQueries int64 `json:"offsetQueries"` // Session status queries after a failure
+	BytesResent   int64 `json:"bytesResent"`   // Bytes of failed chunks sent again
+	BytesSkipped  int64 `json:"bytesSkipped"`  // Bytes of failed chunks the server had committed anyway
+}
+
+var resumable struct {
+	chunkRetries, offsetQueries, bytesResent, bytesSkipped atomic.Int64
+}
+
+// GetResumableStats returns the counts since the last reset
+func GetResumableStats() ResumableStats {
+	return ResumableStats{
+		ChunkRetries:  resumable.chunkRetries.Load(),
+		OffsetQueries: resumable.offsetQueries.Load(),
+		BytesResent:   resumable.bytesResent.Load(),
+		BytesSkipped:  resumable.bytesSkipped.Load(),
+	}
+}
+
+// ResetResumableStats zeroes the resumable upload counters
+func ResetResumableStats() {
+	for _, c := range []*atomic.Int64{&resumable.chunkRetries, &resumable.offsetQueries,
+		&resumable.bytesResent, &resumable.bytesSkipped} {
+		c.Store(0)
+	}
+}
+
+// startResumableSession opens an upload session. contentType is the media
+// type of the uploaded bytes when it differs from metadata.MimeType, as it
+// does when converting to a Workspace format.
+func (m *Manager) startResumableSession(ctx context.Context, reqCtx *types.RequestContext, metadata *drive.File, contentType string, size int64) (*resumableSession, error) {
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadURL := googleapi.ResolveRelative(m.client.Service().BasePath, "/upload/drive/v3/files") +
+		"?uploadType=resumable&supportsAllDrives=true&alt=json&fields=" + url.QueryEscape(uploadFields)
+	httpClient := m.client.HTTPClient()
+
+	uri, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+		if contentType == "" {
+			contentType = metadata.MimeType
+		}
+		if contentType != "" {
+			req.Header.Set("X-Upload-Content-Type", contentType)
+		}
+		if header := m.client.ResourceKeys().BuildHeader(reqCtx.InvolvedParentIDs); header != "" {
+			req.Header.Set("X-Goog-Drive-Resource-Keys", header)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if err := googleapi.CheckResponse(resp); err != nil {
+			return "", err
+		}
+		location := resp.Header.Get("Location")
+		if location == "" {
+			return "", fmt.Errorf("resumable upload session response has no Location header")
+		}
+		return location, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resumableSession{httpClient: httpClient, uri: uri, size: size}, nil
+}
+
+// putChunk sends bytes [start, end) and returns either the finished file or
+// the offset the server has committed.
+func (s *resumableSession) putChunk(ctx context.Context, content io.ReaderAt, start, end int64) (*drive.File, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.uri, io.NewSectionReader(content, start, end-start))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.ContentLength = end - start
+	if end > start {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, s.size))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", s.size))
+	}
+	return s.do(req)
+}
+
+// queryOffset asks the server how much of the upload it has received
+func (s *resumableSession) queryOffset(ctx context.Context) (*drive.File, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.uri, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.ContentLength = 0
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", s.size))
+	return s.do(req)
+}
+
+func (s *resumableSession) do(req *http.Request) (*drive.File, int64, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var file drive.File
+		if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode upload response: %w", err)
+		}
+		return &file, s.size, nil
+	case http.StatusPermanentRedirect:
+		return nil, parseCommittedOffset(resp.Header.Get("Range")), nil
+	default:
+		return nil, 0, googleapi.CheckResponse(resp)
+	}
+}
+
+// parseCommittedOffset converts a 308 Range header ("bytes=0-1048575") into
+// the next offset to send. A missing header means nothing was committed.
+func parseCommittedOffset(rangeHeader string) int64 {
+	_, last, ok := strings.Cut(strings.TrimPrefix(rangeHeader, "bytes="), "-")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n + 1
+}
+
+// isRetryableChunkError reports whether a chunk can be retried on the same
+// session. Network errors and 5xx/429 responses are retried; a 404 or 410
+// means the session expired and the upload must start over.
+func isRetryableChunkError(err error) bool {
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestNormalizeChunkSize(t *testing.T) {
+	tests := []struct {
+		in, want int64
+	}{
+		{0, 8 * 1024 * 1024},
+		{1000, ResumableChunkAlign},
+		{32 * 1024 * 1024, 32 * 1024 * 1024},
+		{ResumableChunkAlign*3 + 17, ResumableChunkAlign * 3},
+	}
+	for _, tt := range tests {
+		if got := NormalizeChunkSize(tt.in); got != tt.want {
+			t.Errorf("NormalizeChunkSize(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommittedOffset(t *testing.T) {
+	if got := parseCommittedOffset("bytes=0-262143"); got != 262144 {
+		t.Errorf("got %d, want 262144", got)
+	}
+	if got := parseCommittedOffset(""); got != 0 {
+		t.Errorf("missing Range should mean offset 0, got %d", got)
+	}
+}
+
+// fakeResumableServer implements enough of the resumable upload protocol
+// to exercise chunking and recovery. The first PUT of the second chunk
+// commits only part of the data and fails with a 503. The first
+// queryFailures status queries fail with a 500, and overclaim is added to
+// the committed offset a status query reports. Once stallAfter bytes are
+// received, PUTs are answered without committing anything.
+type fakeResumableServer struct {
+	mu            sync.Mutex
+	received      bytes.Buffer
+	puts          int
+	queries       int
+	failed        bool
+	queryFailures int
+	overclaim     int64
+	stallAfter    int64
+}
+
+func (s *fakeResumableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/upload/drive/v3/files"):
+		if r.URL.Query().Get("uploadType") != "resumable" {
+			http.Error(w, "expected resumable upload", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", "http://"+r.Host+"/session")
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && r.URL.Path == "/session":
+		contentRange := r.Header.Get("Content-Range")
+		var total int64
+		if strings.HasPrefix(contentRange, "bytes */") {
+			s.queries++
+			if s.queries <= s.queryFailures {
+				http.Error(w, "backend error", http.StatusInternalServerError)
+				return
+			}
+			fmt.Sscanf(contentRange, "bytes */%d", &total)
+			if s.overclaim > 0 {
+				w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", int64(s.received.Len())+s.overclaim-1))
+				w.WriteHeader(http.StatusPermanentRedirect)
+				return
+			}
+			s.writeStatus(w, total)
+			return
+		}
+
+		s.puts++
+		var start, end int64
+		fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total)
+		if start != int64(s.received.Len()) {
+			http.Error(w, "unexpected offset", http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		if s.stallAfter > 0 && int64(s.received.Len()) >= s.stallAfter {
+			s.writeStatus(w, total)
+			return
+		}
+		if start > 0 && !s.failed {
+			s.failed = true
+			s.received.Write(data[:len(data)/2])
+			http.Error(w, "backend error", http.StatusServiceUnavailable)
+			return
+		}
+		s.received.Write(data)
+		s.writeStatus(w, total)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *fakeResumableServer) writeStatus(w http.ResponseWriter, total int64) {
+	if int64(s.received.Len()) == total {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"file123","name":"big.bin"}`)
+		return
+	}
+	if s.received.Len() > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", s.received.Len()-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+func TestUploadResumable_RecoversFromFailedChunk(t *testing.T) {
+	origDelay := chunkRetryBaseDelay
+	chunkRetryBaseDelay = time.Millisecond
+	defer func() { chunkRetryBaseDelay = origDelay }()
+	ResetResumableStats()
+	defer ResetResumableStats()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), ResumableChunkAlign*5/16+100)
+	fake := &fakeResumableServer{queryFailures: 1}
+	file, err := uploadToFake(t, fake, content)
+	if err != nil {
+		t.Fatalf("uploadResumable: %v", err)
+	}
+	if file.Id != "file123" {
+		t.Errorf("Id = %q, want file123", file.Id)
+	}
+	if !bytes.Equal(fake.received.Bytes(), content) {
+		t.Errorf("server received %d bytes, want %d identical bytes", fake.received.Len(), len(content))
+	}
+	if fake.queries != 2 {
+		t.Errorf("expected the failed offset query to be retried before resending, got %d queries", fake.queries)
+	}
+
+	// The failed chunk was two alignment units, half of which was committed
+	want := ResumableStats{ChunkRetries: 1, OffsetQueries: 2, BytesResent: ResumableChunkAlign, BytesSkipped: ResumableChunkAlign}
+	if got := GetResumableStats(); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestUploadResumable_RejectsOffsetPastSentBytes(t *testing.T) {
+	origDelay := chunkRetryBaseDelay
+	chunkRetryBaseDelay = time.Millisecond
+	defer func() { chunkRetryBaseDelay = origDelay }()
+	defer ResetResumableStats()
+
+	content := bytes.Repeat([]byte("x"), 4*ResumableChunkAlign)
+	_, err := uploadToFake(t, &fakeResumableServer{overclaim: 4 * ResumableChunkAlign}, content)
+	if err == nil || !strings.Contains(err.Error(), "committed") {
+		t.Errorf("err = %v, want the bad offset rejected", err)
+	}
+}
+
+func TestUploadResumable_GivesUpWhenSessionMakesNoProgress(t *testing.T) {
+	origDelay := chunkRetryBaseDelay
+	chunkRetryBaseDelay = time.Millisecond
+	defer func() { chunkRetryBaseDelay = origDelay }()
+	defer ResetResumableStats()
+
+	// After the first chunk every PUT answers with the same Range
+	content := bytes.Repeat([]byte("x"), 4*ResumableChunkAlign)
+	fake := &fakeResumableServer{failed: true, stallAfter: 2 * ResumableChunkAlign}
+	_, err := uploadToFake(t, fake, content)
+	if err == nil || !strings.Contains(err.Error(), "no progress") {
+		t.Errorf("err = %v, want the stalled session reported", err)
+	}
+	if fake.puts != maxChunkRetries+2 {
+		t.Errorf("sent %d chunks, want the stalled one retried %d times", fake.puts, maxChunkRetries)
+	}
+}
+
+func uploadToFake(t *testing.T, fake *fakeResumableServer, content []byte) (*drive.File, error) {
+	t.Helper()
+	client, server := testhelpers.NewDriveClient(t, fake)
+
+	ctx := context.Background()
+	client.SetHTTPClient(server.Client())
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	return mgr.uploadResumable(ctx, reqCtx, bytes.NewReader(content), &drive.File{Name: "big.bin"}, "", int64(len(content)), resumableOptions{ChunkSize: 2 * ResumableChunkAlign})
+}
@@ -0,0 +1,105 @@
+package files
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// maxCorpusSearches bounds the corpora searched at once
+const maxCorpusSearches = 4
+
+// SearchDrive is a Shared Drive to include in a federated search
+type SearchDrive struct {
+	ID   string
+	Name string
+}
+
+// SearchEverywhere runs an annotation search in My Drive, each of drives
+// and shared-with-me in parallel, and merges the matches. A file found in
+// more than one corpus is listed once, attributed to the first in that
+// order, with the others in AlsoIn. A corpus that fails is reported in
+// Corpora rather than failing the search, unless every corpus fails.
+func (m *Manager) SearchEverywhere(ctx context.Context, reqCtx *types.RequestContext, opts AnnotationSearchOptions, drives []SearchDrive) (*types.FederatedSearchResult, error) {
+	if opts.ParentID != "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"A parent folder cannot be combined with a search of every corpus").Build())
+	}
+	q := annotationQuery(opts)
+	if q == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"At least one search criterion is required").Build())
+	}
+
+	corpora := []*types.SearchCorpus{{Corpus: types.CorpusMyDrive}}
+	for _, d := range drives {
+		corpora = append(corpora, &types.SearchCorpus{Corpus: types.CorpusSharedDrive, DriveID: d.ID, DriveName: d.Name})
+	}
+	corpora = append(corpora, &types.SearchCorpus{Corpus: types.CorpusSharedWithMe})
+
+	found := make([][]*types.DriveFile, len(corpora))
+	errs := make([]error, len(corpora))
+	sem := make(chan struct{}, maxCorpusSearches)
+	var wg sync.WaitGroup
+	for i, corpus := range corpora {
+		wg.Add(1)
+		go func(i int, corpus *types.SearchCorpus) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			corpusQ := q
+			switch corpus.Corpus {
+			case types.CorpusMyDrive:
+				corpusQ += " and 'me' in owners"
+			case types.CorpusSharedWithMe:
+				corpusQ += " and sharedWithMe = true"
+			}
+			corpusCtx := api.NewRequestContext(reqCtx.Profile, corpus.DriveID, reqCtx.RequestType)
+			corpusCtx.TraceID = reqCtx.TraceID
+
+			result, err := m.searchAnnotations(ctx, corpusCtx, corpusQ, opts)
+			if err != nil {
+				errs[i] = err
+				corpus.Error = err.Error()
+				return
+			}
+			found[i] = result.Files
+			corpus.Matches = len(result.Files)
+			corpus.Incomplete = result.IncompleteSearch
+		}(i, corpus)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(corpora) {
+		return nil, errs[0]
+	}
+
+	merged := &types.FederatedSearchResult{Files: []*types.FederatedFile{}, Corpora: corpora}
+	byID := map[string]*types.FederatedFile{}
+	for i, corpus := range corpora {
+		merged.IncompleteSearch = merged.IncompleteSearch || corpus.Incomplete
+		for _, f := range found[i] {
+			if seen, ok := byID[f.ID]; ok {
+				seen.AlsoIn = append(seen.AlsoIn, corpus.Label())
+				continue
+			}
+			if opts.Limit > 0 && len(merged.Files) >= opts.Limit {
+				continue
+			}
+			file := &types.FederatedFile{DriveFile: f, Corpus: corpus.Corpus, DriveID: corpus.DriveID, DriveName: corpus.DriveName}
+			byID[f.ID] = file
+			merged.Files = append(merged.Files, file)
+		}
+	}
+	return merged, nil
+}
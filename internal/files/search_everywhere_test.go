@@ -0,0 +1,61 @@
+package files
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestSearchEverywhere(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("driveId") == "d1":
+			_, _ = w.Write([]byte(`{"files":[{"id":"shared","name":"budget.xlsx"},{"id":"team","name":"budget-team.xlsx"}]}`))
+		case q.Get("driveId") == "d2":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"Forbidden"}}`))
+		case strings.Contains(q.Get("q"), "'me' in owners"):
+			_, _ = w.Write([]byte(`{"files":[{"id":"mine","name":"budget.xlsx"}]}`))
+		case strings.Contains(q.Get("q"), "sharedWithMe = true"):
+			_, _ = w.Write([]byte(`{"files":[{"id":"shared","name":"budget.xlsx"},{"id":"other","name":"budget (1).xlsx"}],"incompleteSearch":true}`))
+		default:
+			t.Errorf("unexpected query %v", q)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	result, err := mgr.SearchEverywhere(ctx, reqCtx, AnnotationSearchOptions{NameContains: "budget"},
+		[]SearchDrive{{ID: "d1", Name: "Finance"}, {ID: "d2", Name: "Legal"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range result.Files {
+		got = append(got, f.ID+"@"+f.Corpus+strings.Join(f.AlsoIn, ","))
+	}
+	want := []string{"mine@my-drive", "shared@shared-driveshared-with-me", "team@shared-drive", "other@shared-with-me"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if result.Files[1].DriveName != "Finance" || !result.IncompleteSearch {
+		t.Errorf("result = %+v", result)
+	}
+	if legal := result.Corpora[2]; legal.DriveID != "d2" || legal.Error == "" || result.Corpora[1].Matches != 2 {
+		t.Errorf("corpora = %+v %+v", result.Corpora[1], legal)
+	}
+
+	if _, err := mgr.SearchEverywhere(ctx, reqCtx, AnnotationSearchOptions{NameContains: "x", ParentID: "p"}, nil); err == nil {
+		t.Error("expected an error for a parent folder")
+	}
+}
@@ -0,0 +1,76 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestMatchesOwnerDomain(t *testing.T) {
+	tests := []struct {
+		email   string
+		domains []string
+		want    bool
+	}{
+		{"alice@ext.com", nil, true},
+		{"alice@ext.com", []string{"ext.com"}, true},
+		{"alice@EXT.com", []string{"ext.com"}, true},
+		{"alice@eu.ext.com", []string{"ext.com"}, true},
+		{"alice@notext.com", []string{"ext.com"}, false},
+		{"alice@other.org", []string{"ext.com", "other.org"}, true},
+		{"", []string{"ext.com"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesOwnerDomain(tt.email, tt.domains); got != tt.want {
+			t.Errorf("matchesOwnerDomain(%q, %v) = %v, want %v", tt.email, tt.domains, got, tt.want)
+		}
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		item *types.SharedWithMeItem
+		want bool
+	}{
+		{"viewed before cutoff", &types.SharedWithMeItem{LastViewed: "2023-06-01T00:00:00Z"}, true},
+		{"viewed after cutoff", &types.SharedWithMeItem{LastViewed: "2024-06-01T00:00:00Z"}, false},
+		{"never viewed, shared long ago", &types.SharedWithMeItem{SharedTime: "2022-01-01T00:00:00Z"}, true},
+		{"never viewed, shared recently", &types.SharedWithMeItem{SharedTime: "2024-02-01T00:00:00Z"}, false},
+		{"no timestamps", &types.SharedWithMeItem{}, true},
+		{"unparseable timestamp", &types.SharedWithMeItem{LastViewed: "yesterday"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStale(tt.item, cutoff); got != tt.want {
+				t.Errorf("isStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertSharedWithMe(t *testing.T) {
+	item := convertSharedWithMe(&drive.File{
+		Id:               "file1",
+		Name:             "Plan",
+		Owners:           []*drive.User{{EmailAddress: "bob@ext.com", DisplayName: "Bob"}},
+		SharingUser:      &drive.User{EmailAddress: "carol@ext.com"},
+		SharedWithMeTime: "2023-01-01T00:00:00Z",
+	})
+
+	if item.Owner != "bob@ext.com" || item.OwnerName != "Bob" {
+		t.Errorf("owner = %q (%q)", item.Owner, item.OwnerName)
+	}
+	if item.SharedBy != "carol@ext.com" {
+		t.Errorf("sharedBy = %q", item.SharedBy)
+	}
+	if item.LastViewed != "" {
+		t.Errorf("lastViewed = %q, want empty", item.LastViewed)
+	}
+}
@@ -0,0 +1,90 @@
+package files
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// CreateShortcut creates a shortcut to targetID in parentID. The shortcut
+// is named after the target unless name is given.
+func (m *Manager) CreateShortcut(ctx context.Context, reqCtx *types.RequestContext, targetID, parentID, name string) (*types.DriveFile, error) {
+	if name == "" {
+		target, err := m.Get(ctx, reqCtx, targetID, "id,name")
+		if err != nil {
+			return nil, err
+		}
+		name = target.Name
+	}
+
+	metadata := &drive.File{
+		Name:            name,
+		MimeType:        utils.MimeTypeShortcut,
+		ShortcutDetails: &drive.FileShortcutDetails{TargetId: targetID},
+	}
+	if parentID != "" {
+		metadata.Parents = []string{parentID}
+		reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, parentID)
+	}
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, targetID)
+
+	call := m.client.Service().Files.Create(metadata)
+	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+	call = call.Fields("id,name,mimeType,parents,webViewLink,createdTime,shortcutDetails")
+
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertDriveFile(result), nil
+}
+
+// FollowShortcut returns the file a shortcut points to, fetched with the
+// given fields, or file itself when it is not a shortcut. A shortcut
+// fetched without its shortcutDetails has them fetched first.
+func (m *Manager) FollowShortcut(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, fields string) (*types.DriveFile, error) {
+	details := file.ShortcutDetails
+	if details == nil && file.MimeType == utils.MimeTypeShortcut {
+		shortcut, err := m.Get(ctx, reqCtx, file.ID, "id,shortcutDetails")
+		if err != nil {
+			return nil, err
+		}
+		details = shortcut.ShortcutDetails
+	}
+	if details == nil {
+		return file, nil
+	}
+	targetID := details.TargetID
+	if details.TargetResourceKey != "" {
+		m.client.ResourceKeys().UpdateFromAPIResponse(targetID, details.TargetResourceKey)
+	}
+	target, err := m.Get(ctx, reqCtx, targetID, fields)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok && appErr.CLIError.Code == utils.ErrCodeFileNotFound {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+				fmt.Sprintf("Shortcut %s points to %s, which is missing or not accessible", file.ID, targetID)).
+				WithContext("shortcutId", file.ID).
+				WithContext("targetId", targetID).
+				Build())
+		}
+		return nil, err
+	}
+	return target, nil
+}
+
+func convertShortcutDetails(d *drive.FileShortcutDetails) *types.ShortcutDetails {
+	if d == nil || d.TargetId == "" {
+		return nil
+	}
+	return &types.ShortcutDetails{
+		TargetID:          d.TargetId,
+		TargetMimeType:    d.TargetMimeType,
+		TargetResourceKey: d.TargetResourceKey,
+	}
+}
@@ -0,0 +1,93 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// newShortcutManager serves files by ID from files and records the body of
+// each create
+func newShortcutManager(t *testing.T, files map[string]*drive.File, created *drive.File) *Manager {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, created)
+			created.Id = "shortcut1"
+			_ = json.NewEncoder(w).Encode(created)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+		if f, ok := files[id]; ok {
+			_ = json.NewEncoder(w).Encode(f)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":404,"message":"File not found"}}`))
+	}))
+
+	return NewManager(client)
+}
+
+func TestCreateShortcut_NamedAfterTarget(t *testing.T) {
+	var created drive.File
+	mgr := newShortcutManager(t, map[string]*drive.File{
+		"target": {Id: "target", Name: "Budget.xlsx"},
+	}, &created)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	file, err := mgr.CreateShortcut(context.Background(), reqCtx, "target", "folder", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != "Budget.xlsx" || created.MimeType != utils.MimeTypeShortcut ||
+		created.ShortcutDetails == nil || created.ShortcutDetails.TargetId != "target" ||
+		len(created.Parents) != 1 || created.Parents[0] != "folder" {
+		t.Errorf("create body = %+v", created)
+	}
+	if file.ShortcutTarget() != "target" {
+		t.Errorf("ShortcutTarget() = %q", file.ShortcutTarget())
+	}
+}
+
+func TestFollowShortcut(t *testing.T) {
+	mgr := newShortcutManager(t, map[string]*drive.File{
+		"link": {Id: "link", MimeType: utils.MimeTypeShortcut,
+			ShortcutDetails: &drive.FileShortcutDetails{TargetId: "target"}},
+		"broken": {Id: "broken", MimeType: utils.MimeTypeShortcut,
+			ShortcutDetails: &drive.FileShortcutDetails{TargetId: "gone"}},
+		"target": {Id: "target", Name: "report.pdf", MimeType: "application/pdf"},
+	}, &drive.File{})
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	// Details missing from the first fetch are fetched before following
+	target, err := mgr.FollowShortcut(ctx, reqCtx, &types.DriveFile{ID: "link", MimeType: utils.MimeTypeShortcut}, "id,name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.ID != "target" || target.Name != "report.pdf" {
+		t.Errorf("followed to %+v", target)
+	}
+
+	plain := &types.DriveFile{ID: "target", MimeType: "application/pdf"}
+	if got, err := mgr.FollowShortcut(ctx, reqCtx, plain, "id"); err != nil || got != plain {
+		t.Errorf("non-shortcut followed to %+v, %v", got, err)
+	}
+
+	_, err = mgr.FollowShortcut(ctx, reqCtx, &types.DriveFile{ID: "broken", MimeType: utils.MimeTypeShortcut}, "id")
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeFileNotFound || appErr.CLIError.Context["targetId"] != "gone" {
+		t.Errorf("broken shortcut error = %v", err)
+	}
+}
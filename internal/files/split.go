@@ -0,0 +1,382 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// MinSplitSize is the smallest part size accepted for split uploads
+const MinSplitSize = 1 << 20
+
+// SplitManifestName is the name of the manifest in a split upload's folder
+const SplitManifestName = "manifest.json"
+
+// splitManifestVersion is the manifest format written by UploadSplit
+const splitManifestVersion = 1
+
+// appProperties marking split uploads
+const (
+	propSplit     = "gdrvSplit"
+	splitFolder   = "folder"
+	splitPart     = "part"
+	splitManifest = "manifest"
+)
+
+// SplitManifest describes a file uploaded as parts with UploadSplit
+type SplitManifest struct {
+	Version  int          `json:"version"`
+	Name     string       `json:"name"`
+	Size     int64        `json:"size"`
+	SHA256   string       `json:"sha256"`
+	PartSize int64        `json:"partSize"`
+	Parts    []*SplitPart `json:"parts"`
+}
+
+// SplitPart is one part of a split upload
+type SplitPart struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	MD5    string `json:"md5"`
+	SHA256 string `json:"sha256"`
+}
+
+// SplitUploadResult reports a split upload
+type SplitUploadResult struct {
+	FolderID   string `json:"folderId"`
+	FolderName string `json:"folderName"`
+	ManifestID string `json:"manifestId"`
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	Parts      int    `json:"parts"`
+	Uploaded   int    `json:"uploaded"`
+	Reused     int    `json:"reused"` // Parts already in Drive from an earlier, interrupted run
+}
+
+// splitFolderName returns the name of the folder holding a split upload
+func splitFolderName(name string) string {
+	return name + ".split"
+}
+
+func splitPartName(name string, index int) string {
+	return fmt.Sprintf("%s.part%04d", name, index+1)
+}
+
+// planSplit divides size bytes into parts of partSize and hashes each part
+func planSplit(file io.ReaderAt, name string, size, partSize int64) ([]*SplitPart, string, error) {
+	whole := sha256.New()
+	var parts []*SplitPart
+	for offset, index := int64(0), 0; offset < size || index == 0; offset, index = offset+partSize, index+1 {
+		partLen := min(partSize, size-offset)
+		md5Hash, shaHash := md5.New(), sha256.New()
+		section := io.NewSectionReader(file, offset, partLen)
+		if _, err := io.Copy(io.MultiWriter(md5Hash, shaHash, whole), section); err != nil {
+			return nil, "", err
+		}
+		parts = append(parts, &SplitPart{
+			Index:  index,
+			Name:   splitPartName(name, index),
+			Offset: offset,
+			Size:   partLen,
+			MD5:    hex.EncodeToString(md5Hash.Sum(nil)),
+			SHA256: hex.EncodeToString(shaHash.Sum(nil)),
+		})
+	}
+	return parts, hex.EncodeToString(whole.Sum(nil)), nil
+}
+
+// UploadSplit uploads a file as parts of partSize bytes in a folder named
+// "<name>.split", followed by a manifest listing the parts and their
+// checksums. Re-running an interrupted upload reuses parts already in
+// Drive whose checksums match.
+func (m *Manager) UploadSplit(ctx context.Context, reqCtx *types.RequestContext, localPath string, partSize int64, opts UploadOptions) (*SplitUploadResult, error) {
+	if partSize < MinSplitSize {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Split size must be at least %s", utils.FormatSize(MinSplitSize))).Build())
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to open file: %s", err)).Build())
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(localPath)
+	}
+
+	parts, sum, err := planSplit(file, name, stat.Size(), partSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", localPath, err)
+	}
+
+	folder, err := m.splitFolder(ctx, reqCtx, name, opts.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := m.ListAll(ctx, reqCtx, ListOptions{ParentID: folder.Id, Fields: "id,name,size,md5Checksum,appProperties"})
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*types.DriveFile, len(existing))
+	for _, f := range existing {
+		byName[f.Name] = f
+	}
+
+	result := &SplitUploadResult{
+		FolderID:   folder.Id,
+		FolderName: folder.Name,
+		Name:       name,
+		Size:       stat.Size(),
+		Parts:      len(parts),
+	}
+	chunkSize := NormalizeChunkSize(opts.ChunkSize)
+	for _, part := range parts {
+		if old := byName[part.Name]; old != nil {
+			if old.MD5Checksum == part.MD5 && old.Size == part.Size {
+				part.ID = old.ID
+				result.Reused++
+				continue
+			}
+			if _, err := m.Trash(ctx, reqCtx, old.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		metadata := &drive.File{
+			Name:          part.Name,
+			MimeType:      "application/octet-stream",
+			Parents:       []string{folder.Id},
+			AppProperties: map[string]string{propSplit: splitPart},
+		}
+		uploaded, err := m.uploadContent(ctx, reqCtx, io.NewSectionReader(file, part.Offset, part.Size), metadata, part.Size, chunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", part.Name, err)
+		}
+		part.ID = uploaded.Id
+		result.Uploaded++
+	}
+
+	// Drop parts left over from an earlier run with a different split size
+	planned := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		planned[part.Name] = true
+	}
+	for _, f := range existing {
+		if f.AppProperties[propSplit] == splitPart && !planned[f.Name] {
+			if _, err := m.Trash(ctx, reqCtx, f.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifest := &SplitManifest{
+		Version:  splitManifestVersion,
+		Name:     name,
+		Size:     stat.Size(),
+		SHA256:   sum,
+		PartSize: partSize,
+		Parts:    parts,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if old := byName[SplitManifestName]; old != nil {
+		if _, err := m.Trash(ctx, reqCtx, old.ID); err != nil {
+			return nil, err
+		}
+	}
+	manifestFile, err := m.uploadContent(ctx, reqCtx, bytes.NewReader(data), &drive.File{
+		Name:          SplitManifestName,
+		MimeType:      "application/json",
+		Parents:       []string{folder.Id},
+		AppProperties: map[string]string{propSplit: splitManifest},
+	}, int64(len(data)), chunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	result.ManifestID = manifestFile.Id
+	return result, nil
+}
+
+// splitFolder finds the folder of an earlier, possibly interrupted, split
+// upload of name, or creates it
+func (m *Manager) splitFolder(ctx context.Context, reqCtx *types.RequestContext, name, parentID string) (*drive.File, error) {
+	parent := parentID
+	if parent == "" {
+		parent = "root"
+	}
+	q := query.NewBuilder().
+		Where("name", "=", splitFolderName(name)).
+		Where("mimeType", "=", utils.MimeTypeFolder).
+		In("parents", parent).
+		HasProperty("appProperties", propSplit, splitFolder).
+		String()
+	found, err := m.List(ctx, reqCtx, ListOptions{Query: q, Fields: "id,name"})
+	if err != nil {
+		return nil, err
+	}
+	if len(found.Files) > 0 {
+		return &drive.File{Id: found.Files[0].ID, Name: found.Files[0].Name}, nil
+	}
+
+	metadata := &drive.File{
+		Name:          splitFolderName(name),
+		MimeType:      utils.MimeTypeFolder,
+		AppProperties: map[string]string{propSplit: splitFolder},
+	}
+	if parentID != "" {
+		metadata.Parents = []string{parentID}
+		reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, parentID)
+	}
+	call := m.client.Service().Files.Create(metadata).Fields("id,name")
+	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+}
+
+// uploadContent uploads size bytes from content, resumably when an
+// authenticated HTTP client is available
+func (m *Manager) uploadContent(ctx context.Context, reqCtx *types.RequestContext, content io.ReaderAt, metadata *drive.File, size, chunkSize int64) (*drive.File, error) {
+	if m.client.HTTPClient() != nil && size > int64(utils.UploadSimpleMaxBytes) {
+		return m.uploadResumable(ctx, reqCtx, content, metadata, "", size, resumableOptions{ChunkSize: chunkSize})
+	}
+	call := m.client.Service().Files.Create(metadata).Media(io.NewSectionReader(content, 0, size), googleapi.ChunkSize(int(chunkSize)))
+	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+}
+
+// IsSplit reports whether file is the folder or manifest of a split upload
+func IsSplit(file *types.DriveFile) bool {
+	kind := file.AppProperties[propSplit]
+	return kind == splitFolder || kind == splitManifest
+}
+
+// readSplitManifest loads the manifest of a split upload from its folder
+// or the manifest file itself
+func (m *Manager) readSplitManifest(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile) (*SplitManifest, error) {
+	manifestID := file.ID
+	if file.AppProperties[propSplit] == splitFolder {
+		q := query.NewBuilder().
+			Where("name", "=", SplitManifestName).
+			HasProperty("appProperties", propSplit, splitManifest).
+			String()
+		found, err := m.List(ctx, reqCtx, ListOptions{ParentID: file.ID, Query: q, Fields: "id"})
+		if err != nil {
+			return nil, err
+		}
+		if len(found.Files) == 0 {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+				fmt.Sprintf("%s has no manifest; the split upload did not finish", file.Name)).
+				WithContext("suggestedAction", "re-run the upload with the same --split size to resume it").
+				Build())
+		}
+		manifestID = found.Files[0].ID
+	}
+
+	var buf bytes.Buffer
+	if err := m.downloadBlob(ctx, reqCtx, manifestID, &buf); err != nil {
+		return nil, err
+	}
+	var manifest SplitManifest
+	if err := json.Unmarshal(buf.Bytes(), &manifest); err != nil {
+		return nil, fmt.Errorf("invalid split manifest: %w", err)
+	}
+	if manifest.Version != splitManifestVersion {
+		return nil, fmt.Errorf("unsupported split manifest version %d", manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// downloadSplitFile reassembles the split upload file refers to
+func (m *Manager) downloadSplitFile(ctx context.Context, reqCtx *types.RequestContext, file *types.DriveFile, opts DownloadOptions) (*SplitManifest, error) {
+	manifest, err := m.readSplitManifest(ctx, reqCtx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPath := opts.OutputPath
+	if outputPath == "" {
+		outputPath = filepath.Base(manifest.Name)
+	}
+	outFile, err := tempdir.CreatePartial(outputPath)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to create output file: %s", err)).Build())
+	}
+	defer outFile.Discard()
+
+	if err := m.downloadSplit(ctx, reqCtx, manifest, outFile); err != nil {
+		return nil, err
+	}
+	return manifest, outFile.Commit()
+}
+
+// downloadSplit reassembles a split upload into outFile, verifying each
+// part and the whole file against the manifest's checksums
+func (m *Manager) downloadSplit(ctx context.Context, reqCtx *types.RequestContext, manifest *SplitManifest, outFile io.Writer) error {
+	whole := sha256.New()
+	for _, part := range manifest.Parts {
+		partHash := sha256.New()
+		counter := &countingWriter{}
+		if err := m.downloadBlob(ctx, reqCtx, part.ID, io.MultiWriter(outFile, whole, partHash, counter)); err != nil {
+			return fmt.Errorf("failed to download %s: %w", part.Name, err)
+		}
+		if counter.n != part.Size || !hashMatches(partHash, part.SHA256) {
+			return checksumError(part.Name)
+		}
+	}
+	if !hashMatches(whole, manifest.SHA256) {
+		return checksumError(manifest.Name)
+	}
+	return nil
+}
+
+func hashMatches(h hash.Hash, want string) bool {
+	return hex.EncodeToString(h.Sum(nil)) == want
+}
+
+func checksumError(name string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+		fmt.Sprintf("Checksum mismatch for %s: the download is corrupted", name)).
+		WithContext("suggestedAction", "retry the download; if it persists, re-upload the file").
+		Build())
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
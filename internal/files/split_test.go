@@ -0,0 +1,175 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+// fakeDrive stores files in memory for upload tests. Exports return the
+// stored content as is.
+type fakeDrive struct {
+	mu      sync.Mutex
+	files   map[string]*drive.File
+	content map[string][]byte
+	uploads int
+	next    int
+}
+
+func (d *fakeDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/upload/"):
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		var f drive.File
+		part, _ := mr.NextPart()
+		_ = json.NewDecoder(part).Decode(&f)
+		part, _ = mr.NextPart()
+		data, _ := io.ReadAll(part)
+		d.uploads++
+		if r.Method == http.MethodPatch {
+			d.replace(id, data)
+			_ = json.NewEncoder(w).Encode(d.files[id])
+			return
+		}
+		d.add(&f, data)
+		_ = json.NewEncoder(w).Encode(&f)
+	case strings.HasSuffix(r.URL.Path, "/export"):
+		id = path.Base(path.Dir(r.URL.Path))
+		_, _ = w.Write(d.content[id])
+	case r.Method == http.MethodPost:
+		var f drive.File
+		_ = json.NewDecoder(r.Body).Decode(&f)
+		d.add(&f, nil)
+		_ = json.NewEncoder(w).Encode(&f)
+	case r.Method == http.MethodPatch:
+		d.files[id].Trashed = true
+		_ = json.NewEncoder(w).Encode(d.files[id])
+	case r.URL.Query().Get("alt") == "media":
+		_, _ = w.Write(d.content[id])
+	case id == "files":
+		q := r.URL.Query().Get("q")
+		list := &drive.FileList{Files: []*drive.File{}}
+		for _, f := range d.files {
+			if f.Trashed || !strings.Contains(q, "'"+f.Parents[0]+"' in parents") {
+				continue
+			}
+			if strings.Contains(q, "name = ") && !strings.Contains(q, "name = '"+f.Name+"'") {
+				continue
+			}
+			list.Files = append(list.Files, f)
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	default:
+		_ = json.NewEncoder(w).Encode(d.files[id])
+	}
+}
+
+func (d *fakeDrive) add(f *drive.File, data []byte) {
+	d.next++
+	f.Id = fmt.Sprintf("id%d", d.next)
+	if len(f.Parents) == 0 {
+		f.Parents = []string{"root"}
+	}
+	if data != nil {
+		sum := md5.Sum(data)
+		f.Md5Checksum = hex.EncodeToString(sum[:])
+		f.Size = int64(len(data))
+	}
+	f.Capabilities = &drive.FileCapabilities{CanDownload: true, CanEdit: true, CanTrash: true}
+	d.files[f.Id] = f
+	d.content[f.Id] = data
+}
+
+func (d *fakeDrive) replace(id string, data []byte) {
+	sum := md5.Sum(data)
+	d.files[id].Md5Checksum = hex.EncodeToString(sum[:])
+	d.files[id].Size = int64(len(data))
+	d.content[id] = data
+}
+
+func TestUploadSplit_ResumeAndReassemble(t *testing.T) {
+	fake := &fakeDrive{files: map[string]*drive.File{}, content: map[string][]byte{}}
+	client, _ := testhelpers.NewDriveClient(t, fake)
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	dir := t.TempDir()
+	plain := make([]byte, 2*MinSplitSize+12345)
+	for i := range plain {
+		plain[i] = byte(i * 7)
+	}
+	src := filepath.Join(dir, "dataset.bin")
+	if err := os.WriteFile(src, plain, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mgr.UploadSplit(ctx, reqCtx, src, 100, UploadOptions{}); err == nil {
+		t.Error("tiny split size should be rejected")
+	}
+
+	result, err := mgr.UploadSplit(ctx, reqCtx, src, MinSplitSize, UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Parts != 3 || result.Uploaded != 3 || result.FolderName != "dataset.bin.split" {
+		t.Errorf("result = %+v", result)
+	}
+
+	// A re-run reuses the parts already uploaded
+	fake.uploads = 0
+	again, err := mgr.UploadSplit(ctx, reqCtx, src, MinSplitSize, UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.FolderID != result.FolderID || again.Reused != 3 || fake.uploads != 1 {
+		t.Errorf("re-run = %+v with %d uploads, want 3 parts reused and only the manifest uploaded", again, fake.uploads)
+	}
+
+	out := filepath.Join(dir, "restored.bin")
+	if _, err := mgr.Download(ctx, reqCtx, again.FolderID, DownloadOptions{OutputPath: out}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(out)
+	if !bytes.Equal(got, plain) {
+		t.Fatal("reassembled file does not match the original")
+	}
+
+	// A corrupted part fails checksum verification
+	for id, f := range fake.files {
+		if f.Name == "dataset.bin.part0002" && !f.Trashed {
+			fake.content[id][10] ^= 1
+		}
+	}
+	corrupt := filepath.Join(dir, "corrupt.bin")
+	if _, err := mgr.Download(ctx, reqCtx, again.ManifestID, DownloadOptions{OutputPath: corrupt}); err == nil {
+		t.Error("corrupted part should fail verification")
+	}
+	if _, err := os.Stat(corrupt); !os.IsNotExist(err) {
+		t.Error("partial output was left behind")
+	}
+}
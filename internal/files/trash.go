@@ -0,0 +1,134 @@
+package files
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const trashFields = "id,name,mimeType,explicitlyTrashed,capabilities(canUntrash)"
+
+// FindTrashed lists the trashed files matching query, in the Shared Drive
+// of reqCtx.DriveID or else those owned by the user, which are the files
+// EmptyTrash would delete. With limit > 0 at most limit files are listed.
+func (m *Manager) FindTrashed(ctx context.Context, reqCtx *types.RequestContext, query string, limit int) ([]*drive.File, error) {
+	q := "trashed = true"
+	if reqCtx.DriveID == "" {
+		q += " and 'me' in owners"
+	}
+	if query != "" {
+		q += " and (" + query + ")"
+	}
+
+	var found []*drive.File
+	pageToken := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		call := m.client.Service().Files.List()
+		call = m.shaper.ShapeFilesList(call, reqCtx)
+		call = call.Q(q).PageSize(1000).Fields(googleapi.Field("nextPageToken,files(" + trashFields + ")"))
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.FileList, error) {
+			return call.Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, result.Files...)
+		if limit > 0 && len(found) >= limit {
+			return found[:limit], nil
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files trash scan", Done: len(found)})
+
+		pageToken = result.NextPageToken
+		if pageToken == "" {
+			return found, nil
+		}
+	}
+}
+
+// EmptyTrash permanently deletes every trashed file in the Shared Drive of
+// reqCtx.DriveID, or else every trashed file the user owns
+func (m *Manager) EmptyTrash(ctx context.Context, reqCtx *types.RequestContext) error {
+	call := m.client.Service().Files.EmptyTrash()
+	if reqCtx.DriveID != "" {
+		call = call.DriveId(reqCtx.DriveID)
+	}
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (interface{}, error) {
+		return nil, call.Do()
+	})
+	return err
+}
+
+// RestoreAll restores trashed files found by FindTrashed and reports each
+// one. A file that fails is recorded and the rest are still restored.
+// Files trashed along with their folder are skipped, since restoring the
+// folder restores them. In a dry run nothing is changed. Once ctx is
+// cancelled the files not yet restored are left in the trash and ctx's
+// error is returned with the result so far.
+func (m *Manager) RestoreAll(ctx context.Context, reqCtx *types.RequestContext, trashed []*drive.File, dryRun bool) (*types.BulkOperationResult, error) {
+	result := &types.BulkOperationResult{TotalFiles: len(trashed), DryRun: dryRun}
+	for i, f := range trashed {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files restore-all", Done: i + 1, Total: len(trashed), Item: f.Name})
+
+		item := &types.BulkOperationItem{FileID: f.Id, FileName: f.Name, Operation: "restore"}
+		switch {
+		case !f.ExplicitlyTrashed:
+			item.Status, item.Message = "skipped", "trashed with its folder; restore the folder"
+		case f.Capabilities != nil && !f.Capabilities.CanUntrash:
+			item.Status, item.Message = "skipped", "cannot be restored by the current user"
+		}
+		if item.Status == "skipped" {
+			result.SkippedCount++
+			result.SkippedFiles = append(result.SkippedFiles, item)
+			continue
+		}
+
+		if !dryRun {
+			if err := m.untrash(ctx, reqCtx, f.Id); err != nil {
+				item.Status = "failure"
+				item.ErrorMessage = err.Error()
+				if appErr, ok := err.(*utils.AppError); ok {
+					item.ErrorCode = appErr.CLIError.Code
+					item.HTTPStatus = appErr.CLIError.HTTPStatus
+				}
+				result.FailureCount++
+				result.FailedFiles = append(result.FailedFiles, item)
+				continue
+			}
+		}
+		item.Status = "success"
+		result.SuccessCount++
+		result.SuccessfulFiles = append(result.SuccessfulFiles, item)
+	}
+	return result, nil
+}
+
+// untrash restores one file, in a request context of its own so a long
+// run does not collect every file ID in reqCtx
+func (m *Manager) untrash(ctx context.Context, reqCtx *types.RequestContext, fileID string) error {
+	itemCtx := api.NewRequestContext(reqCtx.Profile, reqCtx.DriveID, types.RequestTypeMutation)
+	itemCtx.TraceID = reqCtx.TraceID
+	itemCtx.InvolvedFileIDs = append(itemCtx.InvolvedFileIDs, fileID)
+
+	// false is the zero value, so it has to be sent explicitly
+	metadata := &drive.File{Trashed: false, ForceSendFields: []string{"Trashed"}}
+	call := m.client.Service().Files.Update(fileID, metadata)
+	call = m.shaper.ShapeFilesUpdate(call, itemCtx).Fields("id")
+	_, err := api.ExecuteWithRetry(ctx, m.client, itemCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+	return err
+}
@@ -1,7 +1,15 @@
 package files
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
 )
 
 func TestListTrashed_BuildsCorrectQuery(t *testing.T) {
@@ -56,3 +64,69 @@ func TestTrashRestoration_Property(t *testing.T) {
 	// Validates that restoring from trash properly sets trashed=false
 	t.Skip("Property test requires mock Drive API client")
 }
+
+func TestRestoreAll(t *testing.T) {
+	var query string
+	restored := map[string]bool{}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			query = r.URL.Query().Get("q")
+			_, _ = w.Write([]byte(`{"files":[
+				{"id":"f1","name":"invoice-1.pdf","explicitlyTrashed":true,"capabilities":{"canUntrash":true}},
+				{"id":"f2","name":"invoice-2.pdf","explicitlyTrashed":false,"capabilities":{"canUntrash":true}},
+				{"id":"f3","name":"invoice-3.pdf","explicitlyTrashed":true,"capabilities":{"canUntrash":false}},
+				{"id":"f4","name":"invoice-4.pdf","explicitlyTrashed":true,"capabilities":{"canUntrash":true}}]}`))
+		case r.Method == http.MethodPatch:
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if trashed, ok := body["trashed"]; !ok || trashed != false {
+				t.Errorf("restore body = %v, want trashed: false", body)
+			}
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+			if id == "f4" {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"error":{"code":403,"message":"forbidden"}}`))
+				return
+			}
+			restored[id] = true
+			_, _ = w.Write([]byte(`{"id":"` + id + `"}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	trashed, err := mgr.FindTrashed(ctx, reqCtx, "name contains 'invoice'", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "trashed = true and 'me' in owners and (name contains 'invoice')"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+
+	dry, err := mgr.RestoreAll(ctx, reqCtx, trashed, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.SuccessCount != 2 || dry.SkippedCount != 2 || len(restored) != 0 {
+		t.Errorf("dry run = %+v, restored %v", dry, restored)
+	}
+
+	result, err := mgr.RestoreAll(ctx, reqCtx, trashed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFiles != 4 || result.SuccessCount != 1 || result.SkippedCount != 2 || result.FailureCount != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if !restored["f1"] || len(restored) != 1 {
+		t.Errorf("restored = %v, want only f1", restored)
+	}
+	if failed := result.FailedFiles[0]; failed.FileID != "f4" || failed.HTTPStatus != http.StatusForbidden {
+		t.Errorf("failed file = %+v", failed)
+	}
+}
@@ -0,0 +1,83 @@
+package files
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// listChildren returns the raw Drive files directly inside a folder,
+// following pagination and stopping between pages once ctx is cancelled.
+// Fields is the per-file field mask.
+func (m *Manager) listChildren(ctx context.Context, reqCtx *types.RequestContext, folderID, fields string) ([]*drive.File, error) {
+	var children []*drive.File
+	pageToken := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		call := m.client.Service().Files.List()
+		call = m.shaper.ShapeFilesList(call, reqCtx)
+		call = call.Q(fmt.Sprintf("'%s' in parents and trashed = false", folderID)).
+			PageSize(1000).
+			Fields(googleapi.Field("nextPageToken,files(" + fields + ")"))
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.FileList, error) {
+			return call.Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range result.Files {
+			if f.ResourceKey != "" {
+				m.client.ResourceKeys().UpdateFromAPIResponse(f.Id, f.ResourceKey)
+			}
+		}
+		children = append(children, result.Files...)
+
+		if result.NextPageToken == "" {
+			return children, nil
+		}
+		pageToken = result.NextPageToken
+	}
+}
+
+// walkFolder visits every item under folderID depth-first, descending into
+// subfolders when recursive is set, and stops between items once ctx is
+// cancelled. Fields must include id and mimeType.
+func (m *Manager) walkFolder(ctx context.Context, reqCtx *types.RequestContext, folderID, fields string, recursive bool, visit func(f *drive.File) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	children, err := m.listChildren(ctx, reqCtx, folderID, fields)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := visit(child); err != nil {
+			return err
+		}
+		if recursive && child.MimeType == utils.MimeTypeFolder {
+			if err := m.walkFolder(ctx, reqCtx, child.Id, fields, recursive, visit); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
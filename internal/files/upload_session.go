@@ -0,0 +1,156 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/googleapi"
+)
+
+// UploadSession records a resumable upload so it can be continued after
+// the process is interrupted. The session URI alone authorizes uploads to
+// the new file, so session files are written readable by the owner only.
+// Drive expires a session about a week after it was opened.
+type UploadSession struct {
+	URI       string    `json:"uri"`
+	LocalPath string    `json:"localPath"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+	ChunkSize int64     `json:"chunkSize"`
+	Name      string    `json:"name"`
+	ParentID  string    `json:"parentId,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// LoadUploadSession reads a session file saved by an interrupted upload
+func LoadUploadSession(path string) (*UploadSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to read upload session: %s", err)).Build())
+	}
+	var session UploadSession
+	if err := json.Unmarshal(data, &session); err != nil || session.URI == "" || session.LocalPath == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s is not an upload session file", path)).Build())
+	}
+	return &session, nil
+}
+
+// Save writes the session to path, creating its directory
+func (s *UploadSession) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0600)
+}
+
+// saveSessionFunc returns an OnSession callback that records the upload of
+// localPath in sessionFile, or nil when sessionFile is empty
+func saveSessionFunc(sessionFile, localPath string, stat os.FileInfo, name, parentID string, chunkSize int64) func(string) error {
+	if sessionFile == "" {
+		return nil
+	}
+	return func(uri string) error {
+		absPath, err := filepath.Abs(localPath)
+		if err != nil {
+			return err
+		}
+		session := &UploadSession{
+			URI:       uri,
+			LocalPath: absPath,
+			Size:      stat.Size(),
+			ModTime:   stat.ModTime(),
+			ChunkSize: NormalizeChunkSize(chunkSize),
+			Name:      name,
+			ParentID:  parentID,
+			StartedAt: time.Now(),
+		}
+		if err := session.Save(sessionFile); err != nil {
+			return fmt.Errorf("failed to save upload session: %w", err)
+		}
+		return nil
+	}
+}
+
+// ResumeOptions configures ResumeUpload
+type ResumeOptions struct {
+	Progress ProgressFunc // Called as the upload advances
+	NoVerify bool         // Skip checking Drive's checksum against the local file
+}
+
+// ResumeUpload continues an interrupted resumable upload from its session
+// file, sending only the bytes Drive has not committed. The local file
+// must not have changed since the upload started. The session file is
+// removed once the upload completes.
+func (m *Manager) ResumeUpload(ctx context.Context, reqCtx *types.RequestContext, sessionFile string, opts ResumeOptions) (*types.DriveFile, error) {
+	session, err := LoadUploadSession(sessionFile)
+	if err != nil {
+		return nil, err
+	}
+	if m.client.HTTPClient() == nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Resuming uploads requires an authenticated HTTP client").Build())
+	}
+
+	file, err := os.Open(session.LocalPath)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to open file: %s", err)).Build())
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() != session.Size || !stat.ModTime().Equal(session.ModTime) {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s has changed since the upload started; upload it again", session.LocalPath)).
+			WithContext("sessionFile", sessionFile).Build())
+	}
+
+	if session.ParentID != "" {
+		reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, session.ParentID)
+	}
+	upload := &resumableSession{httpClient: m.client.HTTPClient(), uri: session.URI, size: session.Size}
+	result, committed, err := upload.queryOffset(ctx)
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone) {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeOperationExpired,
+				"The upload session has expired; upload the file again").
+				WithContext("sessionFile", sessionFile).Build())
+		}
+		return nil, err
+	}
+	if result == nil {
+		result, err = upload.send(ctx, reqCtx, file, committed, NormalizeChunkSize(session.ChunkSize), opts.Progress)
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.Progress != nil {
+		opts.Progress(session.Size, session.Size)
+	}
+
+	_ = os.Remove(sessionFile)
+	if result.ResourceKey != "" {
+		m.client.ResourceKeys().UpdateFromAPIResponse(result.Id, result.ResourceKey)
+	}
+	uploaded := convertDriveFile(result)
+	if opts.NoVerify {
+		uploaded.Verification = disabledVerification()
+	} else if uploaded.Verification, err = verifyUpload(file, session.Size, result); err != nil {
+		return nil, err
+	}
+	return uploaded, nil
+}
@@ -0,0 +1,103 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestResumeUpload(t *testing.T) {
+	origDelay := chunkRetryBaseDelay
+	chunkRetryBaseDelay = time.Millisecond
+	defer func() { chunkRetryBaseDelay = origDelay }()
+
+	fake := &fakeResumableServer{}
+	client, server := testhelpers.NewDriveClient(t, fake)
+
+	client.SetHTTPClient(server.Client())
+	mgr := NewManager(client)
+
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "big.bin")
+	content := bytes.Repeat([]byte("0123456789abcdef"), utils.UploadSimpleMaxBytes/16+ResumableChunkAlign)
+	if err := os.WriteFile(localPath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sessionFile := filepath.Join(dir, "sessions", "big.json")
+
+	// Interrupt the upload once the first chunk is committed
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := mgr.Upload(ctx, api.NewRequestContext("default", "", types.RequestTypeMutation), localPath, UploadOptions{
+		ChunkSize:   4 * ResumableChunkAlign,
+		SessionFile: sessionFile,
+		Progress: func(sent, total int64) {
+			if sent > 0 {
+				cancel()
+			}
+		},
+	})
+	if err == nil {
+		t.Fatal("expected the cancelled upload to fail")
+	}
+	session, err := LoadUploadSession(sessionFile)
+	if err != nil {
+		t.Fatalf("session file not kept: %v", err)
+	}
+	if session.Size != int64(len(content)) || session.ChunkSize != 4*ResumableChunkAlign {
+		t.Errorf("session = %+v", session)
+	}
+	if info, _ := os.Stat(sessionFile); info.Mode().Perm() != 0600 {
+		t.Errorf("session file mode = %v, want 0600", info.Mode().Perm())
+	}
+	interrupted := fake.received.Len()
+
+	var first, last int64 = -1, 0
+	file, err := mgr.ResumeUpload(context.Background(), api.NewRequestContext("default", "", types.RequestTypeMutation), sessionFile, ResumeOptions{Progress: func(sent, total int64) {
+		if first < 0 {
+			first = sent
+		}
+		last = sent
+	}})
+	if err != nil {
+		t.Fatalf("ResumeUpload: %v", err)
+	}
+	if file.ID != "file123" || !bytes.Equal(fake.received.Bytes(), content) {
+		t.Errorf("resumed upload: id %q, %d of %d bytes", file.ID, fake.received.Len(), len(content))
+	}
+	if first != int64(interrupted) || last != int64(len(content)) {
+		t.Errorf("progress went from %d to %d, want %d to %d", first, last, interrupted, len(content))
+	}
+	if _, err := os.Stat(sessionFile); !os.IsNotExist(err) {
+		t.Error("session file should be removed once the upload completes")
+	}
+}
+
+func TestResumeUpload_FileChanged(t *testing.T) {
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "big.bin")
+	if err := os.WriteFile(localPath, []byte("changed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sessionFile := filepath.Join(dir, "big.json")
+	session := &UploadSession{URI: "http://example.invalid/session", LocalPath: localPath, Size: 100, ModTime: time.Now()}
+	if err := session.Save(sessionFile); err != nil {
+		t.Fatal(err)
+	}
+
+	client := api.NewClient(nil, 0, 100, nil)
+	client.SetHTTPClient(&http.Client{})
+	_, err := NewManager(client).ResumeUpload(context.Background(), api.NewRequestContext("default", "", types.RequestTypeMutation), sessionFile, ResumeOptions{})
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+		t.Errorf("err = %v, want an invalid argument error", err)
+	}
+}
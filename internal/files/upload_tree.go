@@ -0,0 +1,315 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/query"
+	"github.com/dl-alexandre/gdrv/internal/sync/exclude"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// DefaultUploadWorkers is the number of concurrent uploads used by
+// UploadTree when no worker count is given
+const DefaultUploadWorkers = 4
+
+// TreeItemUploaded is the status of a file uploaded by UploadTree
+const TreeItemUploaded = "uploaded"
+
+// UploadTreeOptions configures recursive directory upload
+type UploadTreeOptions struct {
+	ParentID  string   // Drive folder to upload into (default: My Drive)
+	Name      string   // Name of the Drive folder mirroring the directory (default: the directory's name)
+	Include   []string // Upload only files matching one of these globs (default: all)
+	Exclude   []string // Skip files and directories matching these globs, besides exclude.DefaultPatterns
+	Workers   int      // Concurrent uploads (default: DefaultUploadWorkers)
+	ChunkSize int64    // Resumable upload chunk size in bytes (0 = utils.UploadChunkSize)
+	NoVerify  bool     // Skip checking Drive's checksums against the local files
+	// OnItem is called as each file finishes, with the number finished so
+	// far and the number planned. Calls are serialized.
+	OnItem func(item *UploadTreeItem, done, total int)
+}
+
+// UploadTreeItem reports the outcome for a single file in a tree upload
+type UploadTreeItem struct {
+	LocalPath string `json:"localPath"`
+	Path      string `json:"path"` // Slash-separated, relative to the uploaded directory
+	FileID    string `json:"fileId,omitempty"`
+	Size      int64  `json:"size"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// UploadTreeResult summarizes a recursive directory upload
+type UploadTreeResult struct {
+	LocalDir       string            `json:"localDir"`
+	FolderID       string            `json:"folderId"`
+	FolderName     string            `json:"folderName"`
+	FoldersCreated int               `json:"foldersCreated"`
+	FoldersReused  int               `json:"foldersReused"`
+	Uploaded       int               `json:"uploaded"`
+	Skipped        int               `json:"skipped"`
+	Failed         int               `json:"failed"`
+	Bytes          int64             `json:"bytes"`
+	Items          []*UploadTreeItem `json:"items"`
+}
+
+// UploadTreePlan is a directory walked for upload, before anything is sent
+type UploadTreePlan struct {
+	LocalDir string            `json:"localDir"`
+	Dirs     []string          `json:"dirs"` // Relative directories to create, parents first
+	Files    []*UploadTreeItem `json:"files"`
+	Skipped  []*UploadTreeItem `json:"skipped,omitempty"`
+	Bytes    int64             `json:"bytes"`
+}
+
+// PlanUploadTree walks localDir and lists the directories and files an
+// upload would create. Excluded directories are not descended into;
+// symlinks and other non-regular files are skipped. With include patterns,
+// only directories holding a matching file are created.
+func PlanUploadTree(localDir string, opts UploadTreeOptions) (*UploadTreePlan, error) {
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to read directory: %s", err)).Build())
+	}
+	if !info.IsDir() {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("'%s' is not a directory", localDir)).Build())
+	}
+
+	excluded := exclude.New(opts.Exclude)
+	plan := &UploadTreePlan{LocalDir: localDir}
+	dirs := map[string]bool{}
+	err = filepath.WalkDir(localDir, func(current string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(localDir, current)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if excluded.IsExcluded(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if len(opts.Include) == 0 {
+				dirs[rel] = true
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			plan.Skipped = append(plan.Skipped, &UploadTreeItem{LocalPath: current, Path: rel, Status: TreeItemSkipped, Error: "not a regular file"})
+			return nil
+		}
+		if len(opts.Include) > 0 && !matchesGlob(opts.Include, rel) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		plan.Files = append(plan.Files, &UploadTreeItem{LocalPath: current, Path: rel, Size: fi.Size()})
+		plan.Bytes += fi.Size()
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to read directory: %s", err)).Build())
+	}
+
+	for dir := range dirs {
+		plan.Dirs = append(plan.Dirs, dir)
+	}
+	// Sorting puts every directory after its parent
+	sort.Strings(plan.Dirs)
+	return plan, nil
+}
+
+// UploadTree uploads a planned local directory into a Drive folder named
+// after it, mirroring its structure. Folders that already exist with the
+// same name under the same parent are reused, so an interrupted upload can
+// be run again; files are always uploaded as new files. Folders are
+// created first, then files are uploaded by a pool of workers. A failure
+// on one file is recorded in the result and does not stop the others.
+func (m *Manager) UploadTree(ctx context.Context, reqCtx *types.RequestContext, plan *UploadTreePlan, opts UploadTreeOptions) (*UploadTreeResult, error) {
+	rootName := opts.Name
+	if rootName == "" {
+		rootName = filepath.Base(filepath.Clean(plan.LocalDir))
+	}
+	result := &UploadTreeResult{LocalDir: plan.LocalDir, FolderName: rootName, Items: []*UploadTreeItem{}}
+	result.Items = append(result.Items, plan.Skipped...)
+	result.Skipped = len(plan.Skipped)
+
+	folderIDs := map[string]string{}
+	root, created, err := m.ensureFolder(ctx, reqCtx, rootName, opts.ParentID)
+	if err != nil {
+		return result, err
+	}
+	result.FolderID = root
+	folderIDs["."] = root
+	result.countFolder(created)
+	for _, dir := range plan.Dirs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		id, created, err := m.ensureFolder(ctx, reqCtx, path.Base(dir), folderIDs[path.Dir(dir)])
+		if err != nil {
+			return result, err
+		}
+		folderIDs[dir] = id
+		result.countFolder(created)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultUploadWorkers
+	}
+	var mu sync.Mutex
+	done := 0
+	record := func(item *UploadTreeItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch item.Status {
+		case TreeItemUploaded:
+			result.Uploaded++
+			result.Bytes += item.Size
+		case TreeItemFailed:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+		done++
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "files upload", Done: done, Total: len(plan.Files), Item: item.Path})
+		if opts.OnItem != nil {
+			opts.OnItem(item, done, len(plan.Files))
+		}
+	}
+
+	items := make(chan *UploadTreeItem)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				record(m.uploadTreeItem(ctx, reqCtx, item, folderIDs[path.Dir(item.Path)], opts))
+			}
+		}()
+	}
+
+	var runErr error
+	for _, item := range plan.Files {
+		select {
+		case items <- item:
+		case <-ctx.Done():
+			runErr = ctx.Err()
+		}
+		if runErr != nil {
+			break
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		return result.Items[i].Path < result.Items[j].Path
+	})
+	return result, runErr
+}
+
+func (r *UploadTreeResult) countFolder(created bool) {
+	if created {
+		r.FoldersCreated++
+	} else {
+		r.FoldersReused++
+	}
+}
+
+func (m *Manager) uploadTreeItem(ctx context.Context, reqCtx *types.RequestContext, planned *UploadTreeItem, parentID string, opts UploadTreeOptions) *UploadTreeItem {
+	item := *planned
+	// Upload records involved parents on the request context, so each
+	// worker needs its own
+	itemCtx := api.NewRequestContext(reqCtx.Profile, reqCtx.DriveID, reqCtx.RequestType)
+	itemCtx.TraceID = reqCtx.TraceID
+
+	file, err := m.Upload(ctx, itemCtx, item.LocalPath, UploadOptions{ParentID: parentID, ChunkSize: opts.ChunkSize, NoVerify: opts.NoVerify})
+	if err != nil {
+		item.Status = TreeItemFailed
+		item.Error = err.Error()
+		return &item
+	}
+	item.FileID = file.ID
+	item.Status = TreeItemUploaded
+	return &item
+}
+
+// ensureFolder returns the ID of the folder named name in parentID,
+// creating it when there is none, and whether it was created
+func (m *Manager) ensureFolder(ctx context.Context, reqCtx *types.RequestContext, name, parentID string) (string, bool, error) {
+	parent := parentID
+	if parent == "" {
+		parent = "root"
+	}
+	q := query.NewBuilder().
+		Where("name", "=", name).
+		Where("mimeType", "=", utils.MimeTypeFolder).
+		In("parents", parent).
+		String()
+	found, err := m.List(ctx, reqCtx, ListOptions{Query: q, Fields: "id,name"})
+	if err != nil {
+		return "", false, err
+	}
+	if len(found.Files) > 0 {
+		return found.Files[0].ID, false, nil
+	}
+
+	metadata := &drive.File{Name: name, MimeType: utils.MimeTypeFolder}
+	if parentID != "" {
+		metadata.Parents = []string{parentID}
+	}
+	call := m.client.Service().Files.Create(metadata).Fields("id,name")
+	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+	folder, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return folder.Id, true, nil
+}
+
+// matchesGlob reports whether a slash-separated relative path, or its base
+// name, matches one of patterns
+func matchesGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "mydir")
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPlanUploadTree(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.txt":            "a",
+		"docs/b.md":        "bb",
+		"docs/old/c.tmp":   "ccc",
+		"build/out.bin":    "dddd",
+		".git/config":      "x",
+		"secrets/prod.key": "k",
+	})
+
+	plan, err := PlanUploadTree(dir, UploadTreeOptions{Exclude: []string{"*.tmp", "build/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range plan.Files {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"a.txt", "docs/b.md"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("files = %v, want %v", paths, want)
+	}
+	if want := []string{"docs", "docs/old", "secrets"}; !reflect.DeepEqual(plan.Dirs, want) {
+		t.Errorf("dirs = %v, want %v", plan.Dirs, want)
+	}
+	if plan.Bytes != 3 {
+		t.Errorf("bytes = %d, want 3", plan.Bytes)
+	}
+
+	// With include patterns only the folders holding a match are created
+	plan, err = PlanUploadTree(dir, UploadTreeOptions{Include: []string{"*.md"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Files) != 1 || plan.Files[0].Path != "docs/b.md" || !reflect.DeepEqual(plan.Dirs, []string{"docs"}) {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	if _, err := PlanUploadTree(filepath.Join(dir, "a.txt"), UploadTreeOptions{}); err == nil {
+		t.Error("expected an error for a file")
+	}
+}
+
+func TestUploadTree(t *testing.T) {
+	fake := &fakeDrive{files: map[string]*drive.File{}, content: map[string][]byte{}}
+	client, _ := testhelpers.NewDriveClient(t, fake)
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+
+	dir := writeTree(t, map[string]string{
+		"a.txt":         "a",
+		"docs/b.md":     "bb",
+		"docs/sub/c.md": "ccc",
+	})
+	plan, err := PlanUploadTree(dir, UploadTreeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	result, err := mgr.UploadTree(ctx, reqCtx, plan, UploadTreeOptions{Workers: 2, OnItem: func(item *UploadTreeItem, done, total int) {
+		calls++
+		if total != 3 || done != calls {
+			t.Errorf("progress %d/%d after %d calls", done, total, calls)
+		}
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != 3 || result.Failed != 0 || result.FoldersCreated != 3 || result.Bytes != 6 || result.FolderName != "mydir" {
+		t.Errorf("result = %+v", result)
+	}
+
+	parents := map[string]string{}
+	for _, f := range fake.files {
+		if parent, ok := fake.files[f.Parents[0]]; ok {
+			parents[f.Name] = parent.Name
+		}
+	}
+	if parents["c.md"] != "sub" || parents["sub"] != "docs" || parents["docs"] != "mydir" || parents["a.txt"] != "mydir" {
+		t.Errorf("parents = %v", parents)
+	}
+
+	// A re-run reuses the folders
+	again, err := mgr.UploadTree(ctx, reqCtx, plan, UploadTreeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.FolderID != result.FolderID || again.FoldersCreated != 0 || again.FoldersReused != 3 {
+		t.Errorf("re-run = %+v", again)
+	}
+}
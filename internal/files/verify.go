@@ -0,0 +1,95 @@
+package files
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// uploadFields are requested from uploads so the stored content can be
+// checked against the local file
+const uploadFields = "id,name,mimeType,size,parents,resourceKey,md5Checksum,sha256Checksum"
+
+// withUploadFields adds the fields verifyUpload needs to a caller's
+// field mask
+func withUploadFields(fields string) string {
+	have := make(map[string]bool)
+	for _, f := range strings.Split(fields, ",") {
+		have[strings.TrimSpace(f)] = true
+	}
+	for _, f := range strings.Split(uploadFields, ",") {
+		if !have[f] {
+			fields += "," + f
+		}
+	}
+	return fields
+}
+
+// checksumHasher hashes transferred content with the strongest checksum
+// Drive reports for a file: SHA-256 when present, otherwise MD5
+type checksumHasher struct {
+	hash.Hash
+	algorithm string
+	expected  string
+}
+
+// newChecksumHasher returns nil when Drive reports no checksum, as for
+// Google Workspace files
+func newChecksumHasher(md5sum, sha256sum string) *checksumHasher {
+	switch {
+	case sha256sum != "":
+		return &checksumHasher{Hash: sha256.New(), algorithm: "sha256", expected: sha256sum}
+	case md5sum != "":
+		return &checksumHasher{Hash: md5.New(), algorithm: "md5", expected: md5sum}
+	}
+	return nil
+}
+
+// verify compares the content hashed so far with Drive's checksum.
+// suggestedAction tells the user how to recover from a mismatch.
+func (h *checksumHasher) verify(fileID, name, suggestedAction string) (*types.ChecksumVerification, error) {
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, h.expected) {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+			fmt.Sprintf("%s checksum mismatch for %s", strings.ToUpper(h.algorithm), name)).
+			WithContext("fileId", fileID).
+			WithContext("algorithm", h.algorithm).
+			WithContext("expected", h.expected).
+			WithContext("actual", actual).
+			WithContext("suggestedAction", suggestedAction).
+			Build())
+	}
+	return &types.ChecksumVerification{Status: types.VerificationVerified, Algorithm: h.algorithm, Checksum: actual}, nil
+}
+
+func skippedVerification(reason string) *types.ChecksumVerification {
+	return &types.ChecksumVerification{Status: types.VerificationSkipped, Reason: reason}
+}
+
+func disabledVerification() *types.ChecksumVerification {
+	return &types.ChecksumVerification{Status: types.VerificationDisabled}
+}
+
+// verifyUpload checks the checksum Drive returned for an upload against
+// the size bytes of content that were sent
+func verifyUpload(content io.ReaderAt, size int64, result *drive.File) (*types.ChecksumVerification, error) {
+	h := newChecksumHasher(result.Md5Checksum, result.Sha256Checksum)
+	if h == nil {
+		if utils.IsWorkspaceMimeType(result.MimeType) {
+			return skippedVerification("converted to a Google Workspace format"), nil
+		}
+		return skippedVerification("Drive returned no checksum"), nil
+	}
+	if _, err := io.Copy(h, io.NewSectionReader(content, 0, size)); err != nil {
+		return nil, err
+	}
+	return h.verify(result.Id, result.Name, "the stored copy differs from the local file; upload it again")
+}
@@ -0,0 +1,236 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestDownload_VerifiesChecksum(t *testing.T) {
+	content := "quarterly numbers\n"
+	sum := sha256.Sum256([]byte(content))
+	checksums := map[string]string{
+		"good": `"sha256Checksum":"` + hex.EncodeToString(sum[:]) + `","md5Checksum":"ignored"`,
+		"bad":  `"md5Checksum":"00000000000000000000000000000000"`,
+		"none": `"size":"18"`,
+	}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := filepath.Base(r.URL.Path)
+		if r.URL.Query().Get("alt") == "media" {
+			_, _ = w.Write([]byte(content))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"` + id + `","name":"numbers.txt","mimeType":"text/plain",` + checksums[id] + `,"capabilities":{"canDownload":true}}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	dir := t.TempDir()
+
+	file, err := mgr.Download(ctx, reqCtx, "good", DownloadOptions{OutputPath: filepath.Join(dir, "good")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := file.Verification; v.Status != types.VerificationVerified || v.Algorithm != "sha256" {
+		t.Errorf("verification = %+v, want sha256 verified", v)
+	}
+
+	_, err = mgr.Download(ctx, reqCtx, "bad", DownloadOptions{OutputPath: filepath.Join(dir, "bad")})
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeChecksumMismatch {
+		t.Fatalf("err = %v, want CHECKSUM_MISMATCH", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad")); !os.IsNotExist(err) {
+		t.Error("a mismatched download should not be written")
+	}
+
+	file, err = mgr.Download(ctx, reqCtx, "bad", DownloadOptions{OutputPath: filepath.Join(dir, "unchecked"), NoVerify: true})
+	if err != nil || file.Verification.Status != types.VerificationDisabled {
+		t.Errorf("--no-verify: file = %+v, err = %v", file, err)
+	}
+
+	file, err = mgr.Download(ctx, reqCtx, "none", DownloadOptions{OutputPath: filepath.Join(dir, "none")})
+	if err != nil || file.Verification.Status != types.VerificationSkipped {
+		t.Errorf("no checksum: file = %+v, err = %v", file, err)
+	}
+}
+
+func TestUpload_VerifiesChecksum(t *testing.T) {
+	content := []byte("local draft\n")
+	sum := md5.Sum(content)
+	returned := hex.EncodeToString(sum[:])
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fields") != uploadFields {
+			t.Errorf("fields = %q, want the checksums requested", r.URL.Query().Get("fields"))
+		}
+		_, _ = w.Write([]byte(`{"id":"up1","name":"draft.txt","mimeType":"text/plain","md5Checksum":"` + returned + `"}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	localPath := filepath.Join(t.TempDir(), "draft.txt")
+	if err := os.WriteFile(localPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := mgr.Upload(ctx, reqCtx, localPath, UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := file.Verification; v.Status != types.VerificationVerified || v.Checksum != returned {
+		t.Errorf("verification = %+v", v)
+	}
+
+	returned = "ffffffffffffffffffffffffffffffff"
+	_, err = mgr.Upload(ctx, reqCtx, localPath, UploadOptions{})
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeChecksumMismatch || appErr.CLIError.Context["fileId"] != "up1" {
+		t.Errorf("err = %v, want CHECKSUM_MISMATCH naming the uploaded file", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/drive/v3/about":
+			_, _ = w.Write([]byte(`{"exportFormats":{"application/vnd.google-apps.document":["text/plain","application/pdf"]}}`))
+		case filepath.Base(r.URL.Path) == "export":
+			if r.URL.Query().Get("mimeType") != "text/plain" {
+				t.Errorf("export mimeType = %q", r.URL.Query().Get("mimeType"))
+			}
+			_, _ = w.Write([]byte("Plan\n"))
+		case r.URL.Query().Get("alt") == "media":
+			_, _ = w.Write([]byte("a,b\n"))
+		case filepath.Base(r.URL.Path) == "doc":
+			_, _ = w.Write([]byte(`{"id":"doc","name":"Plan","mimeType":"application/vnd.google-apps.document","capabilities":{"canDownload":true}}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"csv","name":"data.csv","mimeType":"text/csv","md5Checksum":"f0e8e8b3cc0e4fca8a0ac4bb5d4c7ee4","capabilities":{"canDownload":true}}`))
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+
+	var out bytes.Buffer
+	if _, err := mgr.Stream(ctx, reqCtx, "doc", DownloadOptions{}, &out); err != nil || out.String() != "Plan\n" {
+		t.Fatalf("export streamed %q, err %v", out.String(), err)
+	}
+
+	out.Reset()
+	_, err := mgr.Stream(ctx, reqCtx, "csv", DownloadOptions{}, &out)
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeChecksumMismatch {
+		t.Fatalf("err = %v, want CHECKSUM_MISMATCH", err)
+	}
+	out.Reset()
+	if _, err := mgr.Stream(ctx, reqCtx, "csv", DownloadOptions{NoVerify: true}, &out); err != nil || out.String() != "a,b\n" {
+		t.Errorf("blob streamed %q, err %v", out.String(), err)
+	}
+}
+
+func TestUpdateContent_VerifiesChecksum(t *testing.T) {
+	content := []byte("second draft\n")
+	sum := md5.Sum(content)
+	returned := hex.EncodeToString(sum[:])
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"id":"up1","name":"draft.txt","capabilities":{"canEdit":true}}`))
+			return
+		}
+		if fields := r.URL.Query().Get("fields"); fields != "id,size,md5Checksum,name,mimeType,parents,resourceKey,sha256Checksum" {
+			t.Errorf("fields = %q, want the checksums added to the caller's", fields)
+		}
+		_, _ = w.Write([]byte(`{"id":"up1","name":"draft.txt","mimeType":"text/plain","md5Checksum":"` + returned + `"}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	localPath := filepath.Join(t.TempDir(), "draft.txt")
+	if err := os.WriteFile(localPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := UpdateContentOptions{Fields: "id,size,md5Checksum"}
+
+	file, err := mgr.UpdateContent(ctx, reqCtx, "up1", localPath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := file.Verification; v.Status != types.VerificationVerified || v.Checksum != returned {
+		t.Errorf("verification = %+v", v)
+	}
+
+	returned = "ffffffffffffffffffffffffffffffff"
+	_, err = mgr.UpdateContent(ctx, reqCtx, "up1", localPath, opts)
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodeChecksumMismatch {
+		t.Errorf("err = %v, want CHECKSUM_MISMATCH", err)
+	}
+
+	opts.NoVerify = true
+	if file, err := mgr.UpdateContent(ctx, reqCtx, "up1", localPath, opts); err != nil || file.Verification.Status != types.VerificationDisabled {
+		t.Errorf("NoVerify: file = %+v, err = %v", file, err)
+	}
+}
+
+func TestDownloadTree_VerifiesChecksums(t *testing.T) {
+	content := "ledger\n"
+	sum := md5.Sum([]byte(content))
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("alt") == "media":
+			_, _ = w.Write([]byte(content))
+		case r.URL.Path == "/drive/v3/files":
+			_, _ = w.Write([]byte(`{"files":[
+				{"id":"good","name":"good.txt","mimeType":"text/plain","md5Checksum":"` + hex.EncodeToString(sum[:]) + `","capabilities":{"canDownload":true}},
+				{"id":"bad","name":"bad.txt","mimeType":"text/plain","md5Checksum":"00000000000000000000000000000000","capabilities":{"canDownload":true}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"dir1","name":"books","mimeType":"` + utils.MimeTypeFolder + `"}`))
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeDownloadOrExport)
+	dir := filepath.Join(t.TempDir(), "books")
+
+	result, err := mgr.DownloadTree(ctx, reqCtx, "dir1", DownloadTreeOptions{OutputDir: dir, SkipPreflight: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Downloaded != 1 || result.Failed != 1 {
+		t.Fatalf("downloaded %d, failed %d, want 1 and 1", result.Downloaded, result.Failed)
+	}
+	for _, item := range result.Items {
+		switch item.FileID {
+		case "good":
+			if item.Verification == nil || item.Verification.Status != types.VerificationVerified {
+				t.Errorf("good verification = %+v", item.Verification)
+			}
+		case "bad":
+			if !strings.Contains(item.Error, "checksum mismatch") {
+				t.Errorf("bad error = %q", item.Error)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad.txt")); !os.IsNotExist(err) {
+		t.Error("a mismatched file should not be written")
+	}
+
+	result, err = mgr.DownloadTree(ctx, reqCtx, "dir1", DownloadTreeOptions{OutputDir: dir, SkipPreflight: true, NoVerify: true})
+	if err != nil || result.Downloaded != 2 {
+		t.Errorf("NoVerify: result = %+v, err = %v", result, err)
+	}
+}
@@ -9,13 +9,13 @@ import (
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"google.golang.org/api/drive/v3"
-	"google.golang.org/api/googleapi"
 )
 
 // Manager handles folder operations
 type Manager struct {
-	client *api.Client
-	shaper *api.RequestShaper
+	client   *api.Client
+	shaper   *api.RequestShaper
+	progress types.ProgressReporter
 }
 
 // NewManager creates a new folder manager
@@ -26,6 +26,12 @@ func NewManager(client *api.Client) *Manager {
 	}
 }
 
+// SetProgress makes long-running operations report each item to r; nil
+// turns reporting off
+func (m *Manager) SetProgress(r types.ProgressReporter) {
+	m.progress = r
+}
+
 // Create creates a new folder
 func (m *Manager) Create(ctx context.Context, reqCtx *types.RequestContext, name string, parentID string) (*types.DriveFile, error) {
 	if parentID != "" {
@@ -125,7 +131,7 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 		safety.RecordDelete(recorder, folderID, folder.Name, true)
 		if recursive {
 			// In dry-run, we would recursively record all contents
-			if err := m.deleteContentsWithSafety(ctx, reqCtx, folderID, opts, recorder); err != nil {
+			if err := m.deleteContentsWithSafety(ctx, reqCtx, folderID, opts, recorder, &types.ProgressEvent{Operation: "folders delete", Total: contentCount}); err != nil {
 				return err
 			}
 		}
@@ -133,11 +139,11 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 	}
 
 	// Confirmation for destructive operations
-	if opts.ShouldConfirm() {
+	if confirmOpts := opts.ForScope(safety.ScopeDelete); confirmOpts.ShouldConfirm() {
 		if recursive && contentCount > 0 {
 			confirmed, err := safety.Confirm(
 				fmt.Sprintf("About to recursively delete folder '%s' containing %d items. Continue?", folder.Name, contentCount),
-				opts,
+				confirmOpts,
 			)
 			if err != nil {
 				return err
@@ -148,7 +154,7 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 		} else {
 			confirmed, err := safety.Confirm(
 				fmt.Sprintf("About to delete folder '%s'. Continue?", folder.Name),
-				opts,
+				confirmOpts,
 			)
 			if err != nil {
 				return err
@@ -161,7 +167,7 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 
 	if recursive {
 		// List and delete all contents first
-		if err := m.deleteContentsWithSafety(ctx, reqCtx, folderID, opts, recorder); err != nil {
+		if err := m.deleteContentsWithSafety(ctx, reqCtx, folderID, opts, recorder, &types.ProgressEvent{Operation: "folders delete", Total: contentCount}); err != nil {
 			return err
 		}
 	}
@@ -175,7 +181,9 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 	return err
 }
 
-func (m *Manager) deleteContentsWithSafety(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts safety.SafetyOptions, recorder safety.DryRunRecorder) error {
+// deleteContentsWithSafety deletes everything in a folder, depth first.
+// progress counts the items deleted across the walk.
+func (m *Manager) deleteContentsWithSafety(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts safety.SafetyOptions, recorder safety.DryRunRecorder, progress *types.ProgressEvent) error {
 	pageToken := ""
 	for {
 		result, err := m.List(ctx, reqCtx, folderID, 100, pageToken)
@@ -184,11 +192,17 @@ func (m *Manager) deleteContentsWithSafety(ctx context.Context, reqCtx *types.Re
 		}
 
 		for _, file := range result.Files {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			if file.MimeType == utils.MimeTypeFolder {
-				if err := m.deleteContentsWithSafety(ctx, reqCtx, file.ID, opts, recorder); err != nil {
+				if err := m.deleteContentsWithSafety(ctx, reqCtx, file.ID, opts, recorder, progress); err != nil {
 					return err
 				}
 			}
+			progress.Done++
+			progress.Item = file.Name
+			types.ReportProgress(m.progress, *progress)
 
 			// Dry-run mode: record operation
 			if opts.DryRun && recorder != nil {
@@ -234,6 +248,9 @@ func (m *Manager) countContents(ctx context.Context, reqCtx *types.RequestContex
 		}
 
 		for _, file := range result.Files {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
 			count++
 			if file.MimeType == utils.MimeTypeFolder {
 				subCount, err := m.countContents(ctx, reqCtx, file.ID)
@@ -258,12 +275,7 @@ func (m *Manager) Move(ctx context.Context, reqCtx *types.RequestContext, folder
 	reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, newParentID)
 
 	// Get current parents
-	getCall := m.client.Service().Files.Get(folderID).Fields("parents")
-	getCall = m.shaper.ShapeFilesGet(getCall, reqCtx)
-
-	current, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
-		return getCall.Do()
-	})
+	current, err := m.client.GetFile(ctx, reqCtx, folderID, "parents")
 	if err != nil {
 		return nil, err
 	}
@@ -298,17 +310,10 @@ func (m *Manager) Move(ctx context.Context, reqCtx *types.RequestContext, folder
 func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, folderID string, fields string) (*types.DriveFile, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, folderID)
 
-	call := m.client.Service().Files.Get(folderID)
-	call = m.shaper.ShapeFilesGet(call, reqCtx)
-	if fields != "" {
-		call = call.Fields(googleapi.Field(fields))
-	} else {
-		call = call.Fields("id,name,mimeType,size,createdTime,modifiedTime,parents,resourceKey,trashed,capabilities")
+	if fields == "" {
+		fields = "id,name,mimeType,size,createdTime,modifiedTime,parents,resourceKey,trashed,capabilities"
 	}
-
-	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
-		return call.Do()
-	})
+	result, err := m.client.GetFile(ctx, reqCtx, folderID, fields)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package labels
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
 	"github.com/dl-alexandre/gdrv/internal/types"
@@ -10,23 +11,31 @@ import (
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/drivelabels/v2"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
 type Manager struct {
 	client *api.Client
+
+	mu         sync.Mutex
+	service    *drivelabels.Service // Drive Labels service, once checked
+	serviceErr error                // Why the Drive Labels API is unavailable
+	titles     map[string]string    // Label titles by ID
+	// serviceOptions are extra options for the Drive Labels service
+	serviceOptions []option.ClientOption
 }
 
 func NewManager(client *api.Client) *Manager {
 	return &Manager{
 		client: client,
+		titles: make(map[string]string),
 	}
 }
 
 func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, opts types.LabelListOptions) ([]*types.Label, string, error) {
-	service, err := drivelabels.NewService(ctx)
+	service, err := m.labelsService(ctx, reqCtx)
 	if err != nil {
-		return nil, "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
-			fmt.Sprintf("Failed to create Drive Labels service: %s", err)).Build())
+		return nil, "", err
 	}
 
 	call := service.Labels.List()
@@ -75,10 +84,9 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, opts t
 }
 
 func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, labelID string, opts types.LabelGetOptions) (*types.Label, error) {
-	service, err := drivelabels.NewService(ctx)
+	service, err := m.labelsService(ctx, reqCtx)
 	if err != nil {
-		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
-			fmt.Sprintf("Failed to create Drive Labels service: %s", err)).Build())
+		return nil, err
 	}
 
 	call := service.Labels.Get(labelID)
@@ -210,10 +218,9 @@ func (m *Manager) RemoveLabel(ctx context.Context, reqCtx *types.RequestContext,
 }
 
 func (m *Manager) CreateLabel(ctx context.Context, reqCtx *types.RequestContext, label *types.Label, opts types.LabelCreateOptions) (*types.Label, error) {
-	service, err := drivelabels.NewService(ctx)
+	service, err := m.labelsService(ctx, reqCtx)
 	if err != nil {
-		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
-			fmt.Sprintf("Failed to create Drive Labels service: %s", err)).Build())
+		return nil, err
 	}
 
 	apiLabel := convertToAPILabel(label)
@@ -239,10 +246,9 @@ func (m *Manager) CreateLabel(ctx context.Context, reqCtx *types.RequestContext,
 }
 
 func (m *Manager) PublishLabel(ctx context.Context, reqCtx *types.RequestContext, labelID string, opts types.LabelPublishOptions) (*types.Label, error) {
-	service, err := drivelabels.NewService(ctx)
+	service, err := m.labelsService(ctx, reqCtx)
 	if err != nil {
-		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
-			fmt.Sprintf("Failed to create Drive Labels service: %s", err)).Build())
+		return nil, err
 	}
 
 	publishRequest := &drivelabels.GoogleAppsDriveLabelsV2PublishLabelRequest{
@@ -266,10 +272,9 @@ func (m *Manager) PublishLabel(ctx context.Context, reqCtx *types.RequestContext
 }
 
 func (m *Manager) DisableLabel(ctx context.Context, reqCtx *types.RequestContext, labelID string, opts types.LabelDisableOptions) (*types.Label, error) {
-	service, err := drivelabels.NewService(ctx)
+	service, err := m.labelsService(ctx, reqCtx)
 	if err != nil {
-		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
-			fmt.Sprintf("Failed to create Drive Labels service: %s", err)).Build())
+		return nil, err
 	}
 
 	disableRequest := &drivelabels.GoogleAppsDriveLabelsV2DisableLabelRequest{
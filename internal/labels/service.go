@@ -0,0 +1,127 @@
+package labels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drivelabels/v2"
+	"google.golang.org/api/option"
+)
+
+// labelsService returns the Drive Labels service, created and checked once
+// per Manager. The check is a one-label list, so a disabled API or a missing
+// scope fails with a specific error instead of the first real call's.
+func (m *Manager) labelsService(ctx context.Context, reqCtx *types.RequestContext) (*drivelabels.Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.service != nil || m.serviceErr != nil {
+		return m.service, m.serviceErr
+	}
+
+	opts := m.serviceOptions
+	if httpClient := m.client.HTTPClient(); httpClient != nil {
+		opts = append([]option.ClientOption{option.WithHTTPClient(httpClient)}, opts...)
+	}
+	service, err := drivelabels.NewService(ctx, opts...)
+	if err != nil {
+		// Not cached: creating the service fails before any request is made
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeUnknown,
+			fmt.Sprintf("Failed to create Drive Labels service: %s", err)).Build())
+	}
+
+	_, err = api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drivelabels.GoogleAppsDriveLabelsV2ListLabelsResponse, error) {
+		return service.Labels.List().PageSize(1).Fields("labels/id").Do()
+	})
+	if err != nil {
+		if unavailable := labelsUnavailable(err); unavailable != nil {
+			m.serviceErr = unavailable
+			return nil, unavailable
+		}
+		return nil, err
+	}
+	m.service = service
+	return service, nil
+}
+
+// Available reports whether the Drive Labels API can be used, returning
+// the API_DISABLED or SCOPE_INSUFFICIENT error explaining why not
+func (m *Manager) Available(ctx context.Context, reqCtx *types.RequestContext) error {
+	_, err := m.labelsService(ctx, reqCtx)
+	return err
+}
+
+// labelsUnavailable translates the errors Google returns when the Drive
+// Labels API is disabled in the OAuth client's project or the token lacks a
+// labels scope; other errors give nil
+func labelsUnavailable(err error) error {
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.HTTPStatus != 403 {
+		return nil
+	}
+	message := strings.ToLower(appErr.CLIError.Message)
+	switch {
+	case strings.Contains(message, "has not been used") || strings.Contains(message, "is disabled") ||
+		strings.Contains(message, "service_disabled") || strings.Contains(message, "accessnotconfigured"):
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeAPIDisabled,
+			"The Drive Labels API is not enabled for this OAuth client's Google Cloud project").
+			WithHTTPStatus(403).
+			WithContext("api", "drivelabels.googleapis.com").
+			WithContext("detail", appErr.CLIError.Message).
+			WithContext("suggestedAction", "enable the Drive Labels API (drivelabels.googleapis.com) in the Cloud console under APIs & Services > Library, then retry").
+			Build())
+	case strings.Contains(message, "insufficient authentication scopes") || strings.Contains(message, "access_token_scope_insufficient"):
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeScopeInsufficient,
+			"The token has no Drive Labels scope").
+			WithHTTPStatus(403).
+			WithContext("family", "labels").
+			WithContext("missingScopes", []string{utils.ScopeLabelsReadonly}).
+			WithContext("suggestedAction", "run 'gdrv auth login --add-scopes --for labels' to extend consent").
+			Build())
+	}
+	return nil
+}
+
+// DescribeFileLabels fills in the titles of labels applied to a file. The
+// titles come from the Drive Labels API, so when it is unavailable they are
+// left empty and the reason is returned; labels the user cannot read are
+// skipped.
+func (m *Manager) DescribeFileLabels(ctx context.Context, reqCtx *types.RequestContext, fileLabels []*types.FileLabel) error {
+	if len(fileLabels) == 0 {
+		return nil
+	}
+	service, err := m.labelsService(ctx, reqCtx)
+	if err != nil {
+		return err
+	}
+	for _, fl := range fileLabels {
+		m.mu.Lock()
+		title, ok := m.titles[fl.ID]
+		m.mu.Unlock()
+		if !ok {
+			label, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drivelabels.GoogleAppsDriveLabelsV2Label, error) {
+				return service.Labels.Get(labelResourceName(fl.ID)).Fields("properties/title").Do()
+			})
+			if err == nil && label.Properties != nil {
+				title = label.Properties.Title
+			}
+			m.mu.Lock()
+			m.titles[fl.ID] = title
+			m.mu.Unlock()
+		}
+		fl.Title = title
+	}
+	return nil
+}
+
+// labelResourceName returns the "labels/<id>" name the Labels API expects
+// for a label ID as Drive reports it
+func labelResourceName(id string) string {
+	if strings.HasPrefix(id, "labels/") {
+		return id
+	}
+	return "labels/" + id
+}
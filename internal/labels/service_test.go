@@ -0,0 +1,133 @@
+package labels
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/option"
+)
+
+// newServiceManager returns a manager whose Drive Labels requests go to the
+// handler, counting the one-label list used as the pre-flight check
+func newServiceManager(t *testing.T, handler http.HandlerFunc) (*Manager, *int32) {
+	t.Helper()
+	var probes int32
+	client, server := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/labels" && r.URL.Query().Get("pageSize") == "1" {
+			atomic.AddInt32(&probes, 1)
+		}
+		handler(w, r)
+	}))
+	mgr := NewManager(client)
+	mgr.serviceOptions = []option.ClientOption{option.WithEndpoint(server.URL + "/"), option.WithHTTPClient(server.Client())}
+	return mgr, &probes
+}
+
+func TestAvailable_Unavailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		code    string
+	}{
+		{"api disabled", "Drive Labels API has not been used in project 123 before or it is disabled. Enable it by visiting https://console.developers.google.com/apis/api/drivelabels.googleapis.com/overview?project=123 then retry.", utils.ErrCodeAPIDisabled},
+		{"scope missing", "Request had insufficient authentication scopes.", utils.ErrCodeScopeInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, probes := newServiceManager(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"error":{"code":403,"message":"` + tt.message + `","status":"PERMISSION_DENIED"}}`))
+			})
+			reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+			err := mgr.Available(context.Background(), reqCtx)
+			appErr, ok := err.(*utils.AppError)
+			if !ok || appErr.CLIError.Code != tt.code {
+				t.Fatalf("Available = %v, want %s", err, tt.code)
+			}
+			if _, ok := appErr.CLIError.Context["suggestedAction"]; !ok {
+				t.Error("missing suggestedAction")
+			}
+
+			// The result is cached, and operations fail the same way
+			if _, _, err := mgr.List(context.Background(), reqCtx, types.LabelListOptions{}); err != appErr {
+				t.Errorf("List = %v, want the cached error", err)
+			}
+			fileLabels := []*types.FileLabel{{ID: "abc"}}
+			if err := mgr.DescribeFileLabels(context.Background(), reqCtx, fileLabels); err != appErr || fileLabels[0].Title != "" {
+				t.Errorf("DescribeFileLabels = %v, title %q", err, fileLabels[0].Title)
+			}
+			if n := atomic.LoadInt32(probes); n != 1 {
+				t.Errorf("pre-flight checks = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestAvailable_OtherErrorsNotCached(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	mgr, probes := newServiceManager(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if fail.Load() {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"The caller does not have permission"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"labels":[]}`))
+	})
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	err := mgr.Available(context.Background(), reqCtx)
+	if appErr, ok := err.(*utils.AppError); !ok || appErr.CLIError.Code != utils.ErrCodePermissionDenied {
+		t.Fatalf("Available = %v, want PERMISSION_DENIED", err)
+	}
+	fail.Store(false)
+	if err := mgr.Available(context.Background(), reqCtx); err != nil {
+		t.Fatalf("Available after recovery = %v", err)
+	}
+	if err := mgr.Available(context.Background(), reqCtx); err != nil || atomic.LoadInt32(probes) != 2 {
+		t.Errorf("Available = %v after %d checks, want the service cached", err, atomic.LoadInt32(probes))
+	}
+}
+
+func TestDescribeFileLabels(t *testing.T) {
+	var gets int32
+	mgr, _ := newServiceManager(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/labels":
+			_, _ = w.Write([]byte(`{"labels":[{"id":"abc"}]}`))
+		case "/v2/labels/abc":
+			atomic.AddInt32(&gets, 1)
+			_, _ = w.Write([]byte(`{"properties":{"title":"Confidential"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Label not found"}}`))
+		}
+	})
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	for i := 0; i < 2; i++ {
+		fileLabels := []*types.FileLabel{{ID: "abc"}, {ID: "hidden"}}
+		if err := mgr.DescribeFileLabels(context.Background(), reqCtx, fileLabels); err != nil {
+			t.Fatal(err)
+		}
+		if fileLabels[0].Title != "Confidential" || fileLabels[1].Title != "" {
+			t.Errorf("titles = %q, %q", fileLabels[0].Title, fileLabels[1].Title)
+		}
+	}
+	if n := atomic.LoadInt32(&gets); n != 1 {
+		t.Errorf("label gets = %d, want titles cached", n)
+	}
+	if labelResourceName("labels/abc") != "labels/abc" || labelResourceName("abc") != "labels/abc" {
+		t.Error("labelResourceName")
+	}
+}
@@ -0,0 +1,320 @@
+// Package migrate plans and executes moving My Drive folder trees into
+// Shared Drives, preserving folder structure and reporting items that need
+// manual action.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+const itemFields = "id,name,mimeType,parents,owners(emailAddress,me),capabilities(canMoveItemOutOfDrive,canCopy)"
+
+// MimeTypeThirdPartyShortcut identifies third-party app shortcuts, which
+// cannot be moved into a Shared Drive
+const MimeTypeThirdPartyShortcut = "application/vnd.google-apps.drive-sdk"
+
+// Manager handles Shared Drive migrations
+type Manager struct {
+	client *api.Client
+	shaper *api.RequestShaper
+}
+
+// NewManager creates a new migration manager
+func NewManager(client *api.Client) *Manager {
+	return &Manager{
+		client: client,
+		shaper: api.NewRequestShaper(client),
+	}
+}
+
+// Plan walks the source folder and decides, for each item, whether it can be
+// moved, must be copied, or needs manual action. Nothing is modified.
+//
+// The destination is a Shared Drive ID; destParentID optionally names a
+// folder inside it. A folder with the source folder's name is created there
+// on execution and the tree is rebuilt beneath it.
+func (m *Manager) Plan(ctx context.Context, reqCtx *types.RequestContext, srcFolderID, destDriveID, destParentID string) (*types.MigrationPlan, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, srcFolderID)
+
+	srcCall := m.client.Service().Files.Get(srcFolderID)
+	srcCall = m.shaper.ShapeFilesGet(srcCall, reqCtx)
+	srcCall = srcCall.Fields("id,name,mimeType,driveId")
+	src, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return srcCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if src.MimeType != utils.MimeTypeFolder {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("'%s' is not a folder", src.Name)).Build())
+	}
+	if src.DriveId != "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Source folder is already in a Shared Drive").
+			WithContext("driveId", src.DriveId).
+			Build())
+	}
+
+	driveCall := m.client.Service().Drives.Get(destDriveID).Fields("id,name,capabilities(canAddChildren)")
+	dest, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+		return driveCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dest.Capabilities != nil && !dest.Capabilities.CanAddChildren {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied,
+			fmt.Sprintf("You cannot add items to Shared Drive '%s'", dest.Name)).
+			WithContext("driveId", destDriveID).
+			WithContext("capability", "canAddChildren").
+			WithContext("suggestedAction", "ask a drive manager for Contributor access or higher").
+			Build())
+	}
+
+	myDomain := ""
+	aboutCall := m.client.Service().About.Get().Fields("user(emailAddress)")
+	about, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.About, error) {
+		return aboutCall.Do()
+	})
+	if err == nil && about.User != nil {
+		myDomain = emailDomain(about.User.EmailAddress)
+	}
+
+	if destParentID == "" {
+		destParentID = destDriveID
+	}
+	plan := &types.MigrationPlan{
+		SourceFolderID:    src.Id,
+		SourceFolderName:  src.Name,
+		DestinationDrive:  destDriveID,
+		DestinationParent: destParentID,
+		Items:             []*types.MigrationItem{},
+	}
+
+	if err := m.planFolder(ctx, reqCtx, src.Id, src.Name, myDomain, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (m *Manager) planFolder(ctx context.Context, reqCtx *types.RequestContext, folderID, folderPath, myDomain string, plan *types.MigrationPlan) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pageToken := ""
+	for {
+		call := m.client.Service().Files.List()
+		call = m.shaper.ShapeFilesList(call, reqCtx)
+		call = call.Q(fmt.Sprintf("'%s' in parents and trashed = false", folderID)).
+			PageSize(1000).
+			Fields("nextPageToken,files(" + itemFields + ")")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		list, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.FileList, error) {
+			return call.Do()
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, f := range list.Files {
+			action, reason := classifyItem(f, myDomain)
+			item := &types.MigrationItem{
+				SourceID:       f.Id,
+				SourceParentID: folderID,
+				Name:           f.Name,
+				Path:           path.Join(folderPath, f.Name),
+				MimeType:       f.MimeType,
+				Action:         action,
+				Reason:         reason,
+				Status:         types.MigrationStatusPlanned,
+			}
+			if len(f.Owners) > 0 {
+				item.Owner = f.Owners[0].EmailAddress
+			}
+			plan.Items = append(plan.Items, item)
+
+			switch action {
+			case types.MigrationActionCreateFolder:
+				plan.Folders++
+				if err := m.planFolder(ctx, reqCtx, f.Id, item.Path, myDomain, plan); err != nil {
+					return err
+				}
+			case types.MigrationActionMove:
+				plan.Moves++
+			case types.MigrationActionCopy:
+				plan.Copies++
+			case types.MigrationActionManual:
+				plan.ManualActions++
+			}
+		}
+
+		if list.NextPageToken == "" {
+			return nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+// classifyItem decides how a My Drive item reaches the Shared Drive.
+// Folders are recreated so that structure is preserved regardless of the
+// ownership of their contents.
+func classifyItem(f *drive.File, myDomain string) (action, reason string) {
+	switch f.MimeType {
+	case utils.MimeTypeFolder:
+		return types.MigrationActionCreateFolder, ""
+	case utils.MimeTypeShortcut:
+		return types.MigrationActionManual, "shortcuts must be recreated in the Shared Drive"
+	case MimeTypeThirdPartyShortcut:
+		return types.MigrationActionManual, "third-party shortcuts are not supported in Shared Drives"
+	}
+
+	ownedByMe := len(f.Owners) > 0 && f.Owners[0].Me
+	canMove := f.Capabilities != nil && f.Capabilities.CanMoveItemOutOfDrive
+	canCopy := f.Capabilities != nil && f.Capabilities.CanCopy
+
+	if ownedByMe && canMove {
+		return types.MigrationActionMove, ""
+	}
+
+	owner := "another user"
+	if len(f.Owners) > 0 && f.Owners[0].EmailAddress != "" {
+		owner = f.Owners[0].EmailAddress
+	}
+	external := myDomain != "" && len(f.Owners) > 0 && emailDomain(f.Owners[0].EmailAddress) != myDomain
+
+	switch {
+	case ownedByMe && canCopy:
+		return types.MigrationActionCopy, "cannot be moved out of My Drive; a copy is placed in the Shared Drive"
+	case !ownedByMe && external && canCopy:
+		return types.MigrationActionCopy, fmt.Sprintf("owned by external user %s; a copy is placed in the Shared Drive and the original stays with its owner", owner)
+	case !ownedByMe && canCopy:
+		return types.MigrationActionCopy, fmt.Sprintf("owned by %s; a copy is placed in the Shared Drive unless the owner moves it", owner)
+	case ownedByMe:
+		return types.MigrationActionManual, "cannot be moved or copied; check for copy restrictions on the file"
+	default:
+		return types.MigrationActionManual, fmt.Sprintf("owned by %s and cannot be copied; ask the owner to move it or transfer ownership", owner)
+	}
+}
+
+// Execute applies a migration plan. The destination root folder is created
+// first and the tree is rebuilt beneath it. Failures are recorded on the
+// item and do not stop the migration; children of a folder that could not
+// be created are skipped.
+func (m *Manager) Execute(ctx context.Context, reqCtx *types.RequestContext, plan *types.MigrationPlan) (*types.MigrationPlan, error) {
+	destCtx := api.NewRequestContext(reqCtx.Profile, plan.DestinationDrive, types.RequestTypeMutation)
+	destCtx.TraceID = reqCtx.TraceID
+
+	root, err := m.createFolder(ctx, destCtx, plan.SourceFolderName, plan.DestinationParent)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := map[string]string{plan.SourceFolderID: root.Id}
+	for _, item := range plan.Items {
+		if err := ctx.Err(); err != nil {
+			return plan, err
+		}
+
+		if item.Action == types.MigrationActionManual {
+			item.Status = types.MigrationStatusSkipped
+			continue
+		}
+
+		parent, ok := targets[item.SourceParentID]
+		if !ok {
+			item.Status = types.MigrationStatusSkipped
+			item.Error = "parent folder was not created"
+			continue
+		}
+
+		var result *drive.File
+		switch item.Action {
+		case types.MigrationActionCreateFolder:
+			result, err = m.createFolder(ctx, destCtx, item.Name, parent)
+		case types.MigrationActionMove:
+			result, err = m.moveItem(ctx, destCtx, item.SourceID, item.SourceParentID, parent)
+		case types.MigrationActionCopy:
+			result, err = m.copyItem(ctx, destCtx, item.SourceID, item.Name, parent)
+		}
+		if err != nil {
+			item.Status = types.MigrationStatusFailed
+			item.Error = err.Error()
+			plan.Failed++
+			continue
+		}
+
+		item.Status = types.MigrationStatusDone
+		item.TargetID = result.Id
+		if item.Action == types.MigrationActionCreateFolder {
+			targets[item.SourceID] = result.Id
+		}
+	}
+
+	plan.Executed = true
+	return plan, nil
+}
+
+func (m *Manager) createFolder(ctx context.Context, reqCtx *types.RequestContext, name, parentID string) (*drive.File, error) {
+	reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, parentID)
+
+	call := m.client.Service().Files.Create(&drive.File{
+		Name:     name,
+		MimeType: utils.MimeTypeFolder,
+		Parents:  []string{parentID},
+	})
+	call = m.shaper.ShapeFilesCreate(call, reqCtx)
+	call = call.Fields("id")
+
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+}
+
+func (m *Manager) moveItem(ctx context.Context, reqCtx *types.RequestContext, fileID, oldParentID, newParentID string) (*drive.File, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	call := m.client.Service().Files.Update(fileID, &drive.File{})
+	call = m.shaper.ShapeFilesUpdate(call, reqCtx)
+	call = call.AddParents(newParentID).RemoveParents(oldParentID).Fields("id")
+
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+}
+
+func (m *Manager) copyItem(ctx context.Context, reqCtx *types.RequestContext, fileID, name, parentID string) (*drive.File, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	call := m.client.Service().Files.Copy(fileID, &drive.File{
+		Name:    name,
+		Parents: []string{parentID},
+	})
+	call = m.shaper.ShapeFilesCopy(call, reqCtx)
+	call = call.Fields("id")
+
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestClassifyItem(t *testing.T) {
+	me := []*drive.User{{EmailAddress: "me@example.com", Me: true}}
+	colleague := []*drive.User{{EmailAddress: "bob@example.com"}}
+	external := []*drive.User{{EmailAddress: "eve@ext.com"}}
+
+	tests := []struct {
+		name       string
+		file       *drive.File
+		wantAction string
+	}{
+		{
+			name:       "folder is recreated",
+			file:       &drive.File{MimeType: utils.MimeTypeFolder, Owners: me},
+			wantAction: types.MigrationActionCreateFolder,
+		},
+		{
+			name:       "shortcut needs manual action",
+			file:       &drive.File{MimeType: utils.MimeTypeShortcut, Owners: me},
+			wantAction: types.MigrationActionManual,
+		},
+		{
+			name:       "third-party shortcut needs manual action",
+			file:       &drive.File{MimeType: MimeTypeThirdPartyShortcut, Owners: me},
+			wantAction: types.MigrationActionManual,
+		},
+		{
+			name: "owned and movable is moved",
+			file: &drive.File{MimeType: "application/pdf", Owners: me,
+				Capabilities: &drive.FileCapabilities{CanMoveItemOutOfDrive: true, CanCopy: true}},
+			wantAction: types.MigrationActionMove,
+		},
+		{
+			name: "owned but not movable is copied",
+			file: &drive.File{MimeType: "application/pdf", Owners: me,
+				Capabilities: &drive.FileCapabilities{CanCopy: true}},
+			wantAction: types.MigrationActionCopy,
+		},
+		{
+			name: "colleague owned is copied",
+			file: &drive.File{MimeType: "application/pdf", Owners: colleague,
+				Capabilities: &drive.FileCapabilities{CanCopy: true}},
+			wantAction: types.MigrationActionCopy,
+		},
+		{
+			name: "external owned without copy is manual",
+			file: &drive.File{MimeType: "application/pdf", Owners: external,
+				Capabilities: &drive.FileCapabilities{}},
+			wantAction: types.MigrationActionManual,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, reason := classifyItem(tt.file, "example.com")
+			if action != tt.wantAction {
+				t.Errorf("action = %s, want %s", action, tt.wantAction)
+			}
+			if action == types.MigrationActionManual && reason == "" {
+				t.Error("manual actions must explain why")
+			}
+		})
+	}
+}
+
+func TestClassifyItem_ExternalOwnerReason(t *testing.T) {
+	f := &drive.File{
+		MimeType:     "application/pdf",
+		Owners:       []*drive.User{{EmailAddress: "eve@ext.com"}},
+		Capabilities: &drive.FileCapabilities{CanCopy: true},
+	}
+	_, reason := classifyItem(f, "example.com")
+	if reason == "" || !strings.Contains(reason, "external") {
+		t.Errorf("reason should flag external owner, got %q", reason)
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	if got := emailDomain("Alice@Example.COM"); got != "example.com" {
+		t.Errorf("emailDomain = %q", got)
+	}
+	if got := emailDomain("no-at-sign"); got != "" {
+		t.Errorf("emailDomain = %q, want empty", got)
+	}
+}
+
+func TestExecute_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var moved []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"id":"newroot"}`))
+			return
+		}
+		// Interrupted while the first item is being moved
+		moved = append(moved, r.URL.Path)
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	plan := &types.MigrationPlan{
+		SourceFolderID:   "src",
+		SourceFolderName: "Projects",
+		DestinationDrive: "drive1",
+		Items: []*types.MigrationItem{
+			{SourceID: "a", SourceParentID: "src", Path: "Projects/a.pdf", Action: types.MigrationActionMove},
+			{SourceID: "b", SourceParentID: "src", Path: "Projects/b.pdf", Action: types.MigrationActionMove},
+		},
+	}
+
+	result, err := NewManager(client).Execute(ctx, api.NewRequestContext("default", "", types.RequestTypeMutation), plan)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(moved) != 1 {
+		t.Errorf("moved %v, want only the first item", moved)
+	}
+	if result.Executed || result.Items[1].Status != "" {
+		t.Errorf("migration went on after the interrupt: executed=%v, second item %q", result.Executed, result.Items[1].Status)
+	}
+}
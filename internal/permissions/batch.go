@@ -0,0 +1,226 @@
+package permissions
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// bulkChange is one permission change made by a bulk operation
+type bulkChange struct {
+	item *types.BulkOperationItem
+	// call is the change as part of a batch request
+	call api.BatchCall
+	// do makes the change on its own, when batching is not available
+	do func() error
+}
+
+// bulkRunner makes the permission changes of a bulk operation and records
+// their outcomes. Changes are queued and sent as Drive batch requests when
+// the client has an HTTP client to send them with; otherwise each change
+// is made as it is added. Without ContinueOnError or MaxErrorRate the run
+// stops after the batch holding the first failure, so the other changes in
+// that batch have already been made.
+type bulkRunner struct {
+	m       *Manager
+	reqCtx  *types.RequestContext
+	opts    types.BulkOptions
+	result  *types.BulkOperationResult
+	budget  *safety.ErrorBudget
+	size    int // 0 when changes are made one at a time
+	pending []bulkChange
+	done    int // Files started, for progress
+}
+
+func (m *Manager) newBulkRunner(reqCtx *types.RequestContext, opts types.BulkOptions, result *types.BulkOperationResult) *bulkRunner {
+	size := opts.BatchSize
+	if size <= 0 || size > api.MaxBatchSize {
+		size = api.MaxBatchSize
+	}
+	if size == 1 || m.client.HTTPClient() == nil {
+		size = 0
+	}
+	return &bulkRunner{
+		m:      m,
+		reqCtx: reqCtx,
+		opts:   opts,
+		result: result,
+		budget: newErrorBudget(opts),
+		size:   size,
+	}
+}
+
+// next starts the next file of the run. Once ctx is cancelled it returns
+// ctx's error; queued changes that were not sent are not made.
+func (r *bulkRunner) next(ctx context.Context, operation, fileName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.done++
+	types.ReportProgress(r.m.progress, types.ProgressEvent{Operation: operation, Done: r.done, Total: r.result.TotalFiles, Item: fileName})
+	return nil
+}
+
+// add makes change, or queues it and sends the queue once it fills a
+// batch. In a dry run the change is recorded as made.
+func (r *bulkRunner) add(ctx context.Context, change bulkChange) error {
+	if r.opts.DryRun {
+		return r.record(change.item, nil)
+	}
+	if r.size == 0 {
+		return r.record(change.item, change.do())
+	}
+	r.pending = append(r.pending, change)
+	if len(r.pending) >= r.size {
+		return r.flush(ctx)
+	}
+	return nil
+}
+
+// fail records a failure that is not a queued change, such as a file whose
+// permissions could not be listed. The queued changes are sent first, so
+// outcomes are recorded in the order they would be without batching.
+func (r *bulkRunner) fail(ctx context.Context, item *types.BulkOperationItem, err error) error {
+	stop := r.flush(ctx)
+	if recordErr := r.record(item, err); stop == nil {
+		stop = recordErr
+	}
+	return stop
+}
+
+// flush sends the queued changes as one batch request and records each
+// change's outcome
+func (r *bulkRunner) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	changes := r.pending
+	r.pending = nil
+
+	calls := make([]api.BatchCall, len(changes))
+	for i, change := range changes {
+		calls[i] = change.call
+	}
+	results, err := r.m.client.ExecuteBatch(ctx, r.reqCtx, calls)
+	r.result.BatchRequests++
+
+	var stop error
+	for i, change := range changes {
+		callErr := err
+		if i < len(results) {
+			callErr = results[i].Err
+		}
+		if recordErr := r.record(change.item, callErr); recordErr != nil && stop == nil {
+			stop = recordErr
+		}
+	}
+	return stop
+}
+
+// record adds the outcome of item to the result. It returns the error that
+// should stop the run: the abort error once the error budget is exceeded,
+// or err itself when neither ContinueOnError nor an error budget lets the
+// run go on past failures.
+func (r *bulkRunner) record(item *types.BulkOperationItem, err error) error {
+	if err != nil {
+		item.Status = "failure"
+		item.ErrorMessage = err.Error()
+		if appErr, ok := err.(*utils.AppError); ok {
+			item.ErrorCode = appErr.CLIError.Code
+			item.HTTPStatus = appErr.CLIError.HTTPStatus
+		}
+		r.result.FailureCount++
+		r.result.FailedFiles = append(r.result.FailedFiles, item)
+		if abortErr := recordBulkOutcome(r.budget, r.result, true); abortErr != nil {
+			return abortErr
+		}
+		if !r.opts.ContinueOnError && r.budget == nil {
+			return err
+		}
+		return nil
+	}
+
+	if !r.opts.DryRun {
+		_ = recordBulkOutcome(r.budget, r.result, false)
+	}
+	item.Status = "success"
+	r.result.SuccessCount++
+	r.result.SuccessfulFiles = append(r.result.SuccessfulFiles, item)
+	return nil
+}
+
+// permissionsPath returns the batch call path for a file's permissions, or
+// for one permission when permissionID is set
+func permissionsPath(fileID, permissionID string) string {
+	path := "files/" + url.PathEscape(fileID) + "/permissions"
+	if permissionID != "" {
+		path += "/" + url.PathEscape(permissionID)
+	}
+	return path
+}
+
+// batchHeader returns the headers for a batch call on fileID
+func (m *Manager) batchHeader(fileID string) http.Header {
+	header := http.Header{}
+	if keys := m.client.ResourceKeys().BuildHeader([]string{fileID}); keys != "" {
+		header.Set("X-Goog-Drive-Resource-Keys", keys)
+	}
+	return header
+}
+
+// deleteCall returns the batch call that removes permissionID from fileID
+func (m *Manager) deleteCall(fileID, permissionID string) api.BatchCall {
+	return api.BatchCall{
+		Method: http.MethodDelete,
+		Path:   permissionsPath(fileID, permissionID),
+		Query:  url.Values{"supportsAllDrives": {"true"}},
+		Header: m.batchHeader(fileID),
+	}
+}
+
+// updateRoleCall returns the batch call that changes the role of
+// permissionID on fileID
+func (m *Manager) updateRoleCall(fileID, permissionID, role string) api.BatchCall {
+	return api.BatchCall{
+		Method: http.MethodPatch,
+		Path:   permissionsPath(fileID, permissionID),
+		Query: url.Values{
+			"supportsAllDrives": {"true"},
+			"fields":            {permissionListFields},
+		},
+		Header: m.batchHeader(fileID),
+		Body:   map[string]string{"role": role},
+	}
+}
+
+// createCall returns the batch call that grants the permission described
+// by opts on fileID. opts.EmailMessage must already be rendered.
+func (m *Manager) createCall(reqCtx *types.RequestContext, fileID string, opts CreateOptions) api.BatchCall {
+	query := url.Values{
+		"supportsAllDrives":     {strconv.FormatBool(m.shaper.Resolve(reqCtx).SupportsAllDrives)},
+		"sendNotificationEmail": {strconv.FormatBool(opts.SendNotificationEmail)},
+		"fields":                {permissionListFields},
+	}
+	if opts.EmailMessage != "" {
+		query.Set("emailMessage", opts.EmailMessage)
+	}
+	if opts.TransferOwnership {
+		query.Set("transferOwnership", "true")
+	}
+	if opts.UseDomainAdminAccess {
+		query.Set("useDomainAdminAccess", "true")
+	}
+	return api.BatchCall{
+		Method: http.MethodPost,
+		Path:   permissionsPath(fileID, ""),
+		Query:  query,
+		Header: m.batchHeader(fileID),
+		Body:   newDrivePermission(opts),
+	}
+}
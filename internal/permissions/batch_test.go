@@ -0,0 +1,141 @@
+package permissions
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestBulkUpdateRole_Batched(t *testing.T) {
+	var batches, direct int
+	var patched []string
+	update := func(r *http.Request) (int, string) {
+		patched = append(patched, r.URL.Path)
+		if strings.Contains(r.URL.Path, "/f2/") {
+			return http.StatusForbidden, `{"error":{"code":403,"message":"Insufficient permissions","errors":[{"reason":"insufficientFilePermissions"}]}}`
+		}
+		return http.StatusOK, `{"id":"p","role":"reader"}`
+	}
+	client, server := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/batch/drive/v3":
+			batches++
+			_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			var parts []string
+			reader := multipart.NewReader(r.Body, params["boundary"])
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				inner, err := http.ReadRequest(bufio.NewReader(part))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if inner.Method != http.MethodPatch {
+					t.Errorf("batched %s %s", inner.Method, inner.URL.Path)
+				}
+				status, body := update(inner)
+				parts = append(parts, fmt.Sprintf("%s|%d|%s", strings.Trim(part.Header.Get("Content-Id"), "<>"), status, body))
+			}
+			out := multipart.NewWriter(w)
+			w.Header().Set("Content-Type", "multipart/mixed; boundary="+out.Boundary())
+			for _, p := range parts {
+				fields := strings.SplitN(p, "|", 3)
+				pw, _ := out.CreatePart(map[string][]string{"Content-Id": {"<response-" + fields[0] + ">"}})
+				fmt.Fprintf(pw, "HTTP/1.1 %s Status\r\nContent-Type: application/json\r\n\r\n%s", fields[1], fields[2])
+			}
+			out.Close()
+		case r.URL.Path == "/drive/v3/files":
+			_, _ = w.Write([]byte(`{"files":[{"id":"f1","name":"One"},{"id":"f2","name":"Two"},{"id":"f3","name":"Three"}]}`))
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/permissions"):
+			if strings.Contains(r.URL.Path, "/f3/") {
+				_, _ = w.Write([]byte(`{"permissions":[{"id":"p0","type":"user","role":"reader"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"permissions":[{"id":"p1","type":"user","role":"writer"},{"id":"p2","type":"group","role":"writer"}]}`))
+		case r.Method == http.MethodPatch:
+			direct++
+			status, body := update(r)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	client.SetHTTPClient(server.Client())
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	opts := types.BulkOptions{FolderID: "folder", ContinueOnError: true}
+	result, err := mgr.BulkUpdateRole(ctx, reqCtx, "writer", "reader", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batches != 1 || direct != 0 || result.BatchRequests != 1 || len(patched) != 4 {
+		t.Fatalf("%d batches, %d direct calls, %d updates", batches, direct, len(patched))
+	}
+	if result.SuccessCount != 2 || result.FailureCount != 2 || result.SkippedCount != 1 {
+		t.Errorf("result = %d ok, %d failed, %d skipped", result.SuccessCount, result.FailureCount, result.SkippedCount)
+	}
+	failed := result.FailedFiles[0]
+	if failed.FileID != "f2" || failed.PermissionID != "p1" || failed.HTTPStatus != http.StatusForbidden || failed.ErrorCode == "" {
+		t.Errorf("failed item = %+v", failed)
+	}
+
+	// Without --continue-on-error the run stops after the failing batch
+	opts.ContinueOnError = false
+	opts.BatchSize = 2
+	batches, patched = 0, nil
+	result, err = mgr.BulkUpdateRole(ctx, reqCtx, "writer", "reader", opts)
+	if err == nil || batches != 2 || result.FailureCount != 2 {
+		t.Errorf("err = %v after %d batches with %d failures", err, batches, result.FailureCount)
+	}
+
+	// A batch size of 1 makes each change on its own
+	opts.ContinueOnError = true
+	opts.BatchSize = 1
+	batches, patched = 0, nil
+	result, err = mgr.BulkUpdateRole(ctx, reqCtx, "writer", "reader", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batches != 0 || direct != 4 || result.BatchRequests != 0 || result.SuccessCount != 2 {
+		t.Errorf("%d batches, %d direct calls, result %+v", batches, direct, result)
+	}
+}
+
+func TestBulkRunnerNext_StopsWhenCancelled(t *testing.T) {
+	mgr := NewManager(nil)
+	progress := &cancelAfter{n: 1, cancel: func() {}}
+	mgr.SetProgress(progress)
+	r := &bulkRunner{m: mgr, result: &types.BulkOperationResult{TotalFiles: 2}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := r.next(ctx, "permissions bulk", "a"); err != nil {
+		t.Fatalf("next = %v", err)
+	}
+	cancel()
+	if err := r.next(ctx, "permissions bulk", "b"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("next after cancel = %v, want context.Canceled", err)
+	}
+	if r.done != 1 || progress.events != 1 {
+		t.Errorf("done = %d, progress events = %d; a cancelled run should not start another file", r.done, progress.events)
+	}
+}
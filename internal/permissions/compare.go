@@ -0,0 +1,106 @@
+package permissions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// ComparePermissions diffs two permission sets by grantee rather than by
+// permission ID, since IDs differ between tenants. domainMap rewrites the
+// domains of A-side grantees (e.g. prod.example.com=staging.example.com) so
+// equivalent users, groups and domains in both tenants line up.
+func ComparePermissions(a, b []*types.Permission, domainMap map[string]string) *types.PermissionComparison {
+	rolesA := granteeRoles(a, domainMap)
+	rolesB := granteeRoles(b, nil)
+
+	result := &types.PermissionComparison{Drift: []*types.PermissionDrift{}}
+	for key, roleA := range rolesA {
+		roleB, ok := rolesB[key]
+		switch {
+		case !ok:
+			result.Drift = append(result.Drift, newDrift(key, roleA, "", types.DriftOnlyA))
+		case roleA != roleB:
+			result.Drift = append(result.Drift, newDrift(key, roleA, roleB, types.DriftRoleChanged))
+		default:
+			result.Matching++
+		}
+	}
+	for key, roleB := range rolesB {
+		if _, ok := rolesA[key]; !ok {
+			result.Drift = append(result.Drift, newDrift(key, "", roleB, types.DriftOnlyB))
+		}
+	}
+
+	sort.Slice(result.Drift, func(i, j int) bool {
+		if result.Drift[i].Grantee != result.Drift[j].Grantee {
+			return result.Drift[i].Grantee < result.Drift[j].Grantee
+		}
+		return result.Drift[i].Type < result.Drift[j].Type
+	})
+	result.InSync = len(result.Drift) == 0
+	return result
+}
+
+// ParseDomainMap parses "from=to" domain pairs
+func ParseDomainMap(pairs []string) (map[string]string, error) {
+	domainMap := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || from == "" || to == "" {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Invalid domain mapping %q, expected from=to", pair)).Build())
+		}
+		domainMap[strings.ToLower(from)] = strings.ToLower(to)
+	}
+	return domainMap, nil
+}
+
+// granteeKey identifies a permission's grantee independently of its ID
+type granteeKey struct {
+	typ       string
+	principal string
+}
+
+func granteeRoles(perms []*types.Permission, domainMap map[string]string) map[granteeKey]string {
+	roles := make(map[granteeKey]string, len(perms))
+	for _, p := range perms {
+		roles[granteeOf(p, domainMap)] = p.Role
+	}
+	return roles
+}
+
+// granteeOf returns the key of a permission's grantee, with its domain
+// rewritten by domainMap
+func granteeOf(p *types.Permission, domainMap map[string]string) granteeKey {
+	key := granteeKey{typ: p.Type}
+	switch p.Type {
+	case types.PermissionTypeUser, types.PermissionTypeGroup:
+		email := strings.ToLower(p.EmailAddress)
+		if local, domain, ok := strings.Cut(email, "@"); ok {
+			email = local + "@" + mapDomain(domain, domainMap)
+		}
+		key.principal = email
+	case types.PermissionTypeDomain:
+		key.principal = mapDomain(strings.ToLower(p.Domain), domainMap)
+	case types.PermissionTypeAnyone:
+		key.principal = "anyone"
+	default:
+		key.principal = p.ID
+	}
+	return key
+}
+
+func mapDomain(domain string, domainMap map[string]string) string {
+	if mapped, ok := domainMap[domain]; ok {
+		return mapped
+	}
+	return domain
+}
+
+func newDrift(key granteeKey, roleA, roleB, status string) *types.PermissionDrift {
+	return &types.PermissionDrift{Grantee: key.principal, Type: key.typ, RoleA: roleA, RoleB: roleB, Status: status}
+}
@@ -0,0 +1,61 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestComparePermissions(t *testing.T) {
+	prod := []*types.Permission{
+		{ID: "1", Type: "user", Role: "owner", EmailAddress: "admin@prod.example.com"},
+		{ID: "2", Type: "user", Role: "writer", EmailAddress: "Alice@prod.example.com"},
+		{ID: "3", Type: "group", Role: "reader", EmailAddress: "legal@prod.example.com"},
+		{ID: "4", Type: "domain", Role: "reader", Domain: "prod.example.com"},
+	}
+	staging := []*types.Permission{
+		{ID: "a", Type: "user", Role: "owner", EmailAddress: "admin@staging.example.com"},
+		{ID: "b", Type: "user", Role: "reader", EmailAddress: "alice@staging.example.com"},
+		{ID: "c", Type: "domain", Role: "reader", Domain: "staging.example.com"},
+		{ID: "d", Type: "anyone", Role: "reader"},
+	}
+	domainMap := map[string]string{"prod.example.com": "staging.example.com"}
+
+	result := ComparePermissions(prod, staging, domainMap)
+	if result.InSync || result.Matching != 2 {
+		t.Fatalf("Matching = %d, InSync = %v; want 2, false", result.Matching, result.InSync)
+	}
+
+	want := map[string]string{
+		"alice@staging.example.com": types.DriftRoleChanged,
+		"legal@staging.example.com": types.DriftOnlyA,
+		"anyone":                    types.DriftOnlyB,
+	}
+	if len(result.Drift) != len(want) {
+		t.Fatalf("Drift = %d entries, want %d", len(result.Drift), len(want))
+	}
+	for _, d := range result.Drift {
+		if want[d.Grantee] != d.Status {
+			t.Errorf("%s: status %q, want %q", d.Grantee, d.Status, want[d.Grantee])
+		}
+	}
+
+	if unmapped := ComparePermissions(prod, staging, nil); unmapped.Matching != 0 {
+		t.Errorf("without a domain map, Matching = %d, want 0", unmapped.Matching)
+	}
+	if same := ComparePermissions(prod, prod, nil); !same.InSync || same.Matching != len(prod) {
+		t.Errorf("identical sets: %+v", same)
+	}
+}
+
+func TestParseDomainMap(t *testing.T) {
+	m, err := ParseDomainMap([]string{"Prod.example.com=staging.example.com"})
+	if err != nil || m["prod.example.com"] != "staging.example.com" {
+		t.Errorf("ParseDomainMap = %v, %v", m, err)
+	}
+	for _, bad := range []string{"prod.example.com", "=staging", "prod="} {
+		if _, err := ParseDomainMap([]string{bad}); err == nil {
+			t.Errorf("ParseDomainMap(%q) should fail", bad)
+		}
+	}
+}
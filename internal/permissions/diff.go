@@ -0,0 +1,151 @@
+package permissions
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// DiffOptions configures Diff
+type DiffOptions struct {
+	UseDomainAdminAccess bool
+	KeepExtra            bool // Keep target grants the source does not have
+}
+
+// PermissionDiff lists the changes that make a target's grants match a
+// source's, in the order ApplyEdits applies them
+type PermissionDiff struct {
+	SourceID string        `json:"sourceId"`
+	TargetID string        `json:"targetId"`
+	Matching int           `json:"matching"` // Grantees with the same role on both
+	Changes  []*EditChange `json:"changes"`
+	Skipped  []*EditChange `json:"skipped,omitempty"` // Changes that cannot be made on the target
+	InSync   bool          `json:"inSync"`
+	Applied  bool          `json:"applied"`
+}
+
+func (d *PermissionDiff) Headers() []string {
+	return []string{"Action", "Type", "Principal", "From", "To", "Status"}
+}
+
+func (d *PermissionDiff) Rows() [][]string {
+	rows := make([][]string, 0, len(d.Changes)+len(d.Skipped))
+	for _, changes := range [][]*EditChange{d.Changes, d.Skipped} {
+		for _, c := range changes {
+			status := c.Status
+			if c.Error != "" {
+				status += ": " + c.Error
+			}
+			rows = append(rows, []string{c.Action, c.Type, c.Principal, c.FromRole, c.ToRole, status})
+		}
+	}
+	return rows
+}
+
+func (d *PermissionDiff) EmptyMessage() string {
+	return "Target permissions already match the source"
+}
+
+// Diff compares the grants on two files by grantee and returns the
+// additions, role changes and removals that would make the target's grants
+// match the source's. Owners are left out, since ownership is not copied.
+// Grants the target inherits from a Shared Drive or folder cannot be changed
+// on the target itself and are reported as skipped.
+func (m *Manager) Diff(ctx context.Context, reqCtx *types.RequestContext, sourceID, targetID string, opts DiffOptions) (*PermissionDiff, error) {
+	listOpts := ListOptions{UseDomainAdminAccess: opts.UseDomainAdminAccess, AccessDetails: true}
+	source, err := m.List(ctx, reqCtx, sourceID, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	target, err := m.List(ctx, reqCtx, targetID, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	return diffPermissions(sourceID, targetID, source, target, opts), nil
+}
+
+func diffPermissions(sourceID, targetID string, source, target []*types.Permission, opts DiffOptions) *PermissionDiff {
+	diff := &PermissionDiff{SourceID: sourceID, TargetID: targetID, Changes: []*EditChange{}}
+
+	wanted := make(map[granteeKey]*types.Permission, len(source))
+	for _, p := range source {
+		if p.Role != types.PermissionRoleOwner {
+			wanted[granteeOf(p, nil)] = p
+		}
+	}
+	existing := make(map[granteeKey]*types.Permission, len(target))
+	for _, p := range target {
+		if p.Role != types.PermissionRoleOwner {
+			existing[granteeOf(p, nil)] = p
+		}
+	}
+
+	var changes, removals []*EditChange
+	for key, want := range wanted {
+		have, ok := existing[key]
+		switch {
+		case !ok:
+			changes = append(changes, &EditChange{Action: EditAdd, Type: key.typ, Principal: key.principal, ToRole: want.Role})
+		case have.Role == want.Role:
+			diff.Matching++
+		default:
+			change := &EditChange{Action: EditChangeRole, PermissionID: have.ID,
+				Type: key.typ, Principal: key.principal, FromRole: have.Role, ToRole: want.Role}
+			if inheritedOnly(have) {
+				skip(diff, change)
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+	if !opts.KeepExtra {
+		for key, have := range existing {
+			if _, ok := wanted[key]; ok {
+				continue
+			}
+			change := &EditChange{Action: EditRemove, PermissionID: have.ID,
+				Type: key.typ, Principal: key.principal, FromRole: have.Role}
+			if inheritedOnly(have) {
+				skip(diff, change)
+				continue
+			}
+			removals = append(removals, change)
+		}
+	}
+
+	sortChanges(changes)
+	sortChanges(removals)
+	sortChanges(diff.Skipped)
+	diff.Changes = append(changes, removals...)
+	diff.InSync = len(diff.Changes) == 0
+	return diff
+}
+
+// inheritedOnly reports whether a grant exists only through inheritance
+func inheritedOnly(p *types.Permission) bool {
+	if len(p.PermissionDetails) == 0 {
+		return false
+	}
+	for _, d := range p.PermissionDetails {
+		if !d.Inherited {
+			return false
+		}
+	}
+	return true
+}
+
+func skip(diff *PermissionDiff, change *EditChange) {
+	change.Status = "skipped"
+	change.Error = "inherited on the target; change it where it is granted"
+	diff.Skipped = append(diff.Skipped, change)
+}
+
+func sortChanges(changes []*EditChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Principal != changes[j].Principal {
+			return changes[i].Principal < changes[j].Principal
+		}
+		return changes[i].Type < changes[j].Type
+	})
+}
@@ -0,0 +1,63 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestDiffPermissions(t *testing.T) {
+	inherited := []*types.PermissionAccessDetail{{PermissionType: "member", Role: "organizer", Inherited: true, InheritedFrom: "drive1"}}
+	source := []*types.Permission{
+		{ID: "1", Type: "user", Role: "owner", EmailAddress: "alice@example.com"},
+		{ID: "2", Type: "user", Role: "writer", EmailAddress: "Bob@example.com"},
+		{ID: "3", Type: "group", Role: "reader", EmailAddress: "legal@example.com"},
+		{ID: "4", Type: "domain", Role: "reader", Domain: "example.com"},
+		{ID: "5", Type: "user", Role: "writer", EmailAddress: "dana@example.com"},
+	}
+	target := []*types.Permission{
+		{ID: "a", Type: "user", Role: "owner", EmailAddress: "carol@example.com"},
+		{ID: "b", Type: "user", Role: "reader", EmailAddress: "bob@example.com"},
+		{ID: "c", Type: "domain", Role: "reader", Domain: "example.com"},
+		{ID: "d", Type: "anyone", Role: "reader"},
+		{ID: "e", Type: "user", Role: "organizer", EmailAddress: "dana@example.com", PermissionDetails: inherited},
+		{ID: "f", Type: "group", Role: "organizer", EmailAddress: "admins@example.com", PermissionDetails: inherited},
+	}
+
+	diff := diffPermissions("src", "dst", source, target, DiffOptions{})
+	if diff.InSync || diff.Matching != 1 {
+		t.Fatalf("Matching = %d, InSync = %v", diff.Matching, diff.InSync)
+	}
+	// Additions and role changes come before removals; owners are ignored
+	want := []string{
+		"~ user bob@example.com: reader → writer",
+		"+ group legal@example.com: reader",
+		"- anyone anyone: reader",
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("Changes = %v, want %v", diff.Changes, want)
+	}
+	for i, c := range diff.Changes {
+		if c.String() != want[i] {
+			t.Errorf("change %d = %q, want %q", i, c.String(), want[i])
+		}
+	}
+	if diff.Changes[0].PermissionID != "b" || diff.Changes[2].PermissionID != "d" {
+		t.Errorf("permission IDs = %s, %s", diff.Changes[0].PermissionID, diff.Changes[2].PermissionID)
+	}
+	if len(diff.Skipped) != 2 || diff.Skipped[0].Principal != "admins@example.com" || diff.Skipped[1].Action != EditChangeRole {
+		t.Errorf("Skipped = %+v", diff.Skipped)
+	}
+
+	kept := diffPermissions("src", "dst", source, target, DiffOptions{KeepExtra: true})
+	for _, c := range append(kept.Changes, kept.Skipped...) {
+		if c.Action == EditRemove {
+			t.Errorf("--keep-extra removed %s", c)
+		}
+	}
+
+	same := diffPermissions("src", "dst", source[:2], []*types.Permission{{ID: "x", Type: "user", Role: "writer", EmailAddress: "bob@example.com"}}, DiffOptions{})
+	if !same.InSync || len(same.Changes) != 0 {
+		t.Errorf("identical grants: %+v", same)
+	}
+}
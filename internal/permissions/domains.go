@@ -0,0 +1,92 @@
+package permissions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// DomainMatcher classifies domains and email addresses as internal.
+// Patterns are exact domains ("example.com") or subdomain wildcards
+// ("*.example.com", which matches mail.example.com but not example.com).
+// Matching is case-insensitive.
+type DomainMatcher struct {
+	patterns []string
+}
+
+// NewDomainMatcher creates a matcher from domain patterns. Empty and
+// duplicate patterns are ignored.
+func NewDomainMatcher(patterns ...string) *DomainMatcher {
+	d := &DomainMatcher{}
+	seen := map[string]bool{}
+	for _, p := range patterns {
+		p = normalizeDomain(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		d.patterns = append(d.patterns, p)
+	}
+	return d
+}
+
+// IsZero reports whether the matcher has no patterns
+func (d *DomainMatcher) IsZero() bool {
+	return d == nil || len(d.patterns) == 0
+}
+
+// Patterns returns the normalized patterns
+func (d *DomainMatcher) Patterns() []string {
+	if d == nil {
+		return nil
+	}
+	return append([]string(nil), d.patterns...)
+}
+
+// String returns the patterns joined by commas
+func (d *DomainMatcher) String() string {
+	return strings.Join(d.Patterns(), ",")
+}
+
+// MatchDomain reports whether domain matches any pattern
+func (d *DomainMatcher) MatchDomain(domain string) bool {
+	domain = normalizeDomain(domain)
+	if domain == "" || d == nil {
+		return false
+	}
+	for _, p := range d.patterns {
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if strings.HasSuffix(domain, "."+suffix) {
+				return true
+			}
+		} else if domain == p {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchEmail reports whether an email address belongs to a matching domain
+func (d *DomainMatcher) MatchEmail(email string) bool {
+	return d.MatchDomain(extractDomain(email))
+}
+
+// ValidateDomainPattern checks that a pattern is an exact domain or a
+// subdomain wildcard
+func ValidateDomainPattern(pattern string) error {
+	p := normalizeDomain(pattern)
+	host := strings.TrimPrefix(p, "*.")
+	if host == "" || strings.ContainsAny(host, "*@/ ") || !strings.Contains(host, ".") ||
+		strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid domain pattern %q, expected example.com or *.example.com", pattern)).
+			WithContext("pattern", pattern).
+			Build())
+	}
+	return nil
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+}
@@ -0,0 +1,54 @@
+package permissions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDomainMatcher(t *testing.T) {
+	m := NewDomainMatcher("Example.com", " *.example.com", "example.co.uk", "@example.com", "")
+	if want := []string{"example.com", "*.example.com", "example.co.uk"}; !reflect.DeepEqual(m.Patterns(), want) {
+		t.Fatalf("Patterns() = %v, want %v", m.Patterns(), want)
+	}
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"user@example.com", true},
+		{"USER@EXAMPLE.COM", true},
+		{"user@mail.example.com", true},
+		{"user@a.b.example.com", true},
+		{"user@example.co.uk", true},
+		{"user@mail.example.co.uk", false},
+		{"user@notexample.com", false},
+		{"user@example.com.evil.org", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := m.MatchEmail(tt.email); got != tt.want {
+			t.Errorf("MatchEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+
+	wildcardOnly := NewDomainMatcher("*.example.com")
+	if wildcardOnly.MatchDomain("example.com") {
+		t.Error("*.example.com should not match example.com itself")
+	}
+	if !NewDomainMatcher().IsZero() || m.IsZero() {
+		t.Error("IsZero mismatch")
+	}
+}
+
+func TestValidateDomainPattern(t *testing.T) {
+	for _, p := range []string{"example.com", "*.example.com", "EXAMPLE.co.uk"} {
+		if err := ValidateDomainPattern(p); err != nil {
+			t.Errorf("ValidateDomainPattern(%q) = %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "*", "*.com.", "example", "a*.example.com", "user@example.com", ".example.com"} {
+		if err := ValidateDomainPattern(p); err == nil {
+			t.Errorf("ValidateDomainPattern(%q) should fail", p)
+		}
+	}
+}
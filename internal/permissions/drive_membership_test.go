@@ -0,0 +1,71 @@
+package permissions
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestAnalyzeFolder_IgnoreDriveMembership(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/permissions"):
+			if !strings.Contains(r.URL.Query().Get("fields"), "permissionDetails(") {
+				t.Errorf("fields = %q, want permissionDetails", r.URL.Query().Get("fields"))
+			}
+			// An external drive member on every item, plus a per-item grant
+			// to an external user on f1
+			perms := `{"id":"m1","type":"user","role":"writer","emailAddress":"contractor@partner.com",
+				"permissionDetails":[{"permissionType":"member","role":"writer","inherited":true,"inheritedFrom":"drive1"}]}`
+			if strings.Contains(r.URL.Path, "/files/f1/") {
+				perms += `,{"id":"p1","type":"user","role":"reader","emailAddress":"guest@other.com",
+					"permissionDetails":[{"permissionType":"file","role":"reader","inherited":false}]}`
+			}
+			_, _ = w.Write([]byte(`{"permissions":[` + perms + `]}`))
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			_, _ = w.Write([]byte(`{"files":[
+				{"id":"f1","name":"Shared oddly","mimeType":"text/plain"},
+				{"id":"f2","name":"Normal","mimeType":"text/plain"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/files/folder"):
+			_, _ = w.Write([]byte(`{"id":"folder","name":"Team","mimeType":"application/vnd.google-apps.folder"}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "drive1", types.RequestTypePermissionOp)
+	opts := types.AnalyzeOptions{InternalDomains: []string{"example.com"}, IncludeDetails: true}
+
+	all, err := mgr.AnalyzeFolder(ctx, reqCtx, "folder", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.DriveMemberships != 2 || all.ItemGrants != 1 {
+		t.Errorf("DriveMemberships = %d, ItemGrants = %d, want 2 and 1", all.DriveMemberships, all.ItemGrants)
+	}
+	if len(all.ExternalShares) != 2 || all.ExternalShares[1].DriveMemberCount != 1 {
+		t.Errorf("ExternalShares = %+v, want both files with one drive member each", all.ExternalShares)
+	}
+
+	opts.IgnoreDriveMembership = true
+	items, err := mgr.AnalyzeFolder(ctx, reqCtx, "folder", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !items.DriveMembershipIgnored || items.DriveMemberships != 2 {
+		t.Errorf("DriveMembershipIgnored = %v, DriveMemberships = %d", items.DriveMembershipIgnored, items.DriveMemberships)
+	}
+	if len(items.ExternalShares) != 1 || items.ExternalShares[0].FileID != "f1" {
+		t.Errorf("ExternalShares = %+v, want only f1", items.ExternalShares)
+	}
+	if items.RoleDistribution["writer"] != 0 || items.RoleDistribution["reader"] != 1 {
+		t.Errorf("RoleDistribution = %v, want only the item grant", items.RoleDistribution)
+	}
+}
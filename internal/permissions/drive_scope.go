@@ -0,0 +1,103 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// DriveScopeFinding flags an item-level grant on a Shared Drive file.
+// Access in a Shared Drive is best managed through drive membership;
+// grants on individual items are easy to lose track of.
+type DriveScopeFinding struct {
+	FileID     string `json:"fileId"`
+	DriveID    string `json:"driveId"`
+	Grantee    string `json:"grantee"`
+	Role       string `json:"role"`
+	MemberRole string `json:"memberRole,omitempty"` // The grantee's drive role, when a member
+	Redundant  bool   `json:"redundant,omitempty"`  // The drive role already grants Role or more
+}
+
+// Message describes the finding
+func (f *DriveScopeFinding) Message() string {
+	switch {
+	case f.Redundant:
+		return fmt.Sprintf("%s is already a %s of shared drive %s; an item-level %s grant adds nothing", f.Grantee, f.MemberRole, f.DriveID, f.Role)
+	case f.MemberRole != "":
+		return fmt.Sprintf("%s is a %s of shared drive %s; an item-level %s grant raises their access to one item only", f.Grantee, f.MemberRole, f.DriveID, f.Role)
+	}
+	return fmt.Sprintf("Item-level %s grant to %s inside shared drive %s; drive membership is preferred", f.Role, f.Grantee, f.DriveID)
+}
+
+// SuggestedAction names the drive-level command to use instead
+func (f *DriveScopeFinding) SuggestedAction() string {
+	switch {
+	case f.Redundant:
+		return "no grant is needed"
+	case f.MemberRole != "":
+		return fmt.Sprintf("gdrv drives members update %s --email %s --role %s", f.DriveID, f.Grantee, f.Role)
+	}
+	flag := "--email"
+	if !strings.Contains(f.Grantee, "@") {
+		flag = "--domain"
+	}
+	return fmt.Sprintf("gdrv drives members add %s %s %s --role %s", f.DriveID, flag, f.Grantee, f.Role)
+}
+
+// Err returns the finding as a POLICY_VIOLATION error, for --strict-policy
+func (f *DriveScopeFinding) Err() error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodePolicyViolation, f.Message()).
+		WithContext("fileId", f.FileID).
+		WithContext("driveId", f.DriveID).
+		WithContext("policy", "driveScope").
+		WithContext("suggestedAction", f.SuggestedAction()).
+		Build())
+}
+
+// CheckDriveScope reports whether granting share on fileID would create an
+// item-level permission inside a Shared Drive. It returns nil for files
+// outside Shared Drives and for grants drive membership cannot replace
+// (anyone links). When the drive's members cannot be listed the finding
+// is still returned, without MemberRole.
+func (m *Manager) CheckDriveScope(ctx context.Context, reqCtx *types.RequestContext, fileID string, share CreateOptions) (*DriveScopeFinding, error) {
+	if share.Type == "anyone" {
+		return nil, nil
+	}
+	file, err := m.client.GetFile(ctx, reqCtx, fileID, "id,driveId")
+	if err != nil {
+		return nil, err
+	}
+	if file.DriveId == "" || file.Id == file.DriveId {
+		return nil, nil
+	}
+
+	finding := &DriveScopeFinding{
+		FileID:  file.Id,
+		DriveID: file.DriveId,
+		Grantee: share.EmailAddress,
+		Role:    share.Role,
+	}
+	if share.Type == "domain" {
+		finding.Grantee = share.Domain
+	}
+	members, err := m.List(ctx, reqCtx, file.DriveId, ListOptions{})
+	if err != nil {
+		return finding, nil
+	}
+	for _, member := range members {
+		grantee := member.EmailAddress
+		if member.Type == "domain" {
+			grantee = member.Domain
+		}
+		if member.Type != share.Type || !strings.EqualFold(grantee, finding.Grantee) {
+			continue
+		}
+		finding.MemberRole = member.Role
+		finding.Redundant = roleRank[member.Role] >= roleRank[share.Role]
+		break
+	}
+	return finding, nil
+}
@@ -0,0 +1,98 @@
+package permissions
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestCheckDriveScope(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/drive/v3/files/inDrive":
+			_, _ = w.Write([]byte(`{"id":"inDrive","driveId":"drive1"}`))
+		case "/drive/v3/files/myDrive":
+			_, _ = w.Write([]byte(`{"id":"myDrive"}`))
+		case "/drive/v3/files/drive1/permissions":
+			_, _ = w.Write([]byte(`{"permissions":[
+				{"id":"m1","type":"user","role":"writer","emailAddress":"Alice@example.com"},
+				{"id":"m2","type":"domain","role":"reader","domain":"example.com"}]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	tests := []struct {
+		name       string
+		fileID     string
+		share      CreateOptions
+		wantNil    bool
+		memberRole string
+		redundant  bool
+		suggest    string
+	}{
+		{"outside shared drives", "myDrive", CreateOptions{Type: "user", Role: "reader", EmailAddress: "bob@example.com"}, true, "", false, ""},
+		{"anyone link", "inDrive", CreateOptions{Type: "anyone", Role: "reader"}, true, "", false, ""},
+		{"member already has it", "inDrive", CreateOptions{Type: "user", Role: "commenter", EmailAddress: "alice@example.com"}, false, "writer", true, "no grant"},
+		{"member raised on one item", "inDrive", CreateOptions{Type: "domain", Role: "writer", Domain: "example.com"}, false, "reader", false, "drives members update drive1"},
+		{"not a member", "inDrive", CreateOptions{Type: "user", Role: "reader", EmailAddress: "bob@example.com"}, false, "", false, "drives members add drive1 --email bob@example.com --role reader"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finding, err := mgr.CheckDriveScope(ctx, reqCtx, tt.fileID, tt.share)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if finding != nil {
+					t.Errorf("finding = %+v, want none", finding)
+				}
+				return
+			}
+			if finding == nil || finding.MemberRole != tt.memberRole || finding.Redundant != tt.redundant {
+				t.Fatalf("finding = %+v", finding)
+			}
+			if !strings.Contains(finding.SuggestedAction(), tt.suggest) {
+				t.Errorf("SuggestedAction() = %q, want %q", finding.SuggestedAction(), tt.suggest)
+			}
+			appErr, ok := finding.Err().(*utils.AppError)
+			if !ok || appErr.CLIError.Code != utils.ErrCodePolicyViolation {
+				t.Errorf("Err() = %v, want POLICY_VIOLATION", finding.Err())
+			}
+		})
+	}
+}
+
+func TestRequireShare(t *testing.T) {
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/drive/v3/files/shared":
+			_, _ = w.Write([]byte(`{"id":"shared","name":"Plan","capabilities":{"canShare":true}}`))
+		case "/drive/v3/files/locked":
+			_, _ = w.Write([]byte(`{"id":"locked","name":"Budget","capabilities":{"canShare":false}}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	if err := mgr.RequireShare(context.Background(), reqCtx, "shared"); err != nil {
+		t.Errorf("shareable file refused: %v", err)
+	}
+	err := mgr.RequireShare(context.Background(), reqCtx, "locked")
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodePermissionDenied || appErr.CLIError.Context["capability"] != "canShare" {
+		t.Errorf("err = %v, want PERMISSION_DENIED for canShare", err)
+	}
+}
@@ -0,0 +1,269 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Edit actions staged in an EditSession
+const (
+	EditAdd        = "add"
+	EditChangeRole = "change-role"
+	EditRemove     = "remove"
+)
+
+// editableRoles are the roles an edit session can grant; ownership moves
+// through 'permissions create --transfer-ownership'
+var editableRoles = []string{
+	types.PermissionRoleReader,
+	types.PermissionRoleCommenter,
+	types.PermissionRoleWriter,
+	"fileOrganizer",
+	types.PermissionRoleOrganizer,
+}
+
+// EditRow is one line of an edit session: an existing grant, possibly with
+// a staged change, or a staged new grant
+type EditRow struct {
+	Permission *types.Permission // Nil for a staged new grant
+	Type       string
+	Principal  string // Email, domain, or "anyone"
+	Role       string // Current role
+	NewRole    string // Staged role; empty when unchanged
+	Removed    bool
+}
+
+// Added reports whether the row is a staged new grant
+func (r *EditRow) Added() bool { return r.Permission == nil }
+
+// EditChange is one change in the diff of an edit session
+type EditChange struct {
+	Action       string `json:"action"`
+	PermissionID string `json:"permissionId,omitempty"`
+	Type         string `json:"type"`
+	Principal    string `json:"principal"`
+	FromRole     string `json:"fromRole,omitempty"`
+	ToRole       string `json:"toRole,omitempty"`
+	Status       string `json:"status,omitempty"` // applied, failed
+	Error        string `json:"error,omitempty"`
+}
+
+// String renders the change as a diff line
+func (c *EditChange) String() string {
+	switch c.Action {
+	case EditAdd:
+		return fmt.Sprintf("+ %s %s: %s", c.Type, c.Principal, c.ToRole)
+	case EditRemove:
+		return fmt.Sprintf("- %s %s: %s", c.Type, c.Principal, c.FromRole)
+	default:
+		return fmt.Sprintf("~ %s %s: %s → %s", c.Type, c.Principal, c.FromRole, c.ToRole)
+	}
+}
+
+// EditSession stages changes to a file's grants so they can be previewed
+// and applied together. It holds no API state; see ApplyEdits.
+type EditSession struct {
+	FileID string
+	rows   []*EditRow
+}
+
+// NewEditSession starts an edit session from a file's current permissions
+func NewEditSession(fileID string, perms []*types.Permission) *EditSession {
+	s := &EditSession{FileID: fileID}
+	for _, p := range perms {
+		s.rows = append(s.rows, &EditRow{
+			Permission: p,
+			Type:       p.Type,
+			Principal:  principalOf(p),
+			Role:       p.Role,
+		})
+	}
+	return s
+}
+
+// Rows returns the session's rows; row numbers used by the other methods
+// are 1-based indexes into this list
+func (s *EditSession) Rows() []*EditRow {
+	return s.rows
+}
+
+func (s *EditSession) row(n int) (*EditRow, error) {
+	if n < 1 || n > len(s.rows) {
+		return nil, fmt.Errorf("no grant #%d", n)
+	}
+	return s.rows[n-1], nil
+}
+
+// SetRole stages a role change for row n. Setting a staged new grant's role
+// changes the grant; setting an existing grant back to its role unstages it.
+func (s *EditSession) SetRole(n int, role string) error {
+	r, err := s.row(n)
+	if err != nil {
+		return err
+	}
+	if err := checkEditableRole(role); err != nil {
+		return err
+	}
+	if r.Added() {
+		r.Role = role
+		return nil
+	}
+	if r.Role == types.PermissionRoleOwner {
+		return fmt.Errorf("the owner's role cannot be changed here")
+	}
+	r.Removed = false
+	r.NewRole = role
+	if role == r.Role {
+		r.NewRole = ""
+	}
+	return nil
+}
+
+// Remove stages the removal of row n; a staged new grant is dropped
+func (s *EditSession) Remove(n int) error {
+	r, err := s.row(n)
+	if err != nil {
+		return err
+	}
+	if r.Added() {
+		s.rows = append(s.rows[:n-1], s.rows[n:]...)
+		return nil
+	}
+	if r.Role == types.PermissionRoleOwner {
+		return fmt.Errorf("the owner cannot be removed")
+	}
+	r.Removed = true
+	r.NewRole = ""
+	return nil
+}
+
+// Reset unstages any change to row n
+func (s *EditSession) Reset(n int) error {
+	r, err := s.row(n)
+	if err != nil {
+		return err
+	}
+	if r.Added() {
+		return s.Remove(n)
+	}
+	r.Removed = false
+	r.NewRole = ""
+	return nil
+}
+
+// Add stages a new grant. principal is an email for users and groups, a
+// domain for domain grants, and ignored for anyone.
+func (s *EditSession) Add(permType, principal, role string) error {
+	switch permType {
+	case types.PermissionTypeUser, types.PermissionTypeGroup:
+		if !strings.Contains(principal, "@") {
+			return fmt.Errorf("%s grants need an email address", permType)
+		}
+	case types.PermissionTypeDomain:
+		if principal == "" || strings.Contains(principal, "@") {
+			return fmt.Errorf("domain grants need a domain name")
+		}
+	case types.PermissionTypeAnyone:
+		principal = types.PermissionTypeAnyone
+	default:
+		return fmt.Errorf("unknown grant type %q (user, group, domain, anyone)", permType)
+	}
+	if err := checkEditableRole(role); err != nil {
+		return err
+	}
+	for _, r := range s.rows {
+		if r.Type == permType && strings.EqualFold(r.Principal, principal) && !r.Removed {
+			return fmt.Errorf("%s already has a grant; change its role instead", principal)
+		}
+	}
+	s.rows = append(s.rows, &EditRow{Type: permType, Principal: principal, Role: role})
+	return nil
+}
+
+// Changes returns the staged changes: new grants and role changes first,
+// then removals, which is the order ApplyEdits applies them in
+func (s *EditSession) Changes() []*EditChange {
+	var changes, removals []*EditChange
+	for _, r := range s.rows {
+		switch {
+		case r.Added():
+			changes = append(changes, &EditChange{Action: EditAdd, Type: r.Type, Principal: r.Principal, ToRole: r.Role})
+		case r.Removed:
+			removals = append(removals, &EditChange{Action: EditRemove, PermissionID: r.Permission.ID,
+				Type: r.Type, Principal: r.Principal, FromRole: r.Role})
+		case r.NewRole != "":
+			changes = append(changes, &EditChange{Action: EditChangeRole, PermissionID: r.Permission.ID,
+				Type: r.Type, Principal: r.Principal, FromRole: r.Role, ToRole: r.NewRole})
+		}
+	}
+	return append(changes, removals...)
+}
+
+// EditApplyOptions configures ApplyEdits
+type EditApplyOptions struct {
+	SendNotificationEmail bool // Notify users and groups given new grants
+	UseDomainAdminAccess  bool
+}
+
+// ApplyEdits applies the changes of an edit session. Every change is
+// attempted; each is marked applied or failed, and an error is returned
+// if any failed.
+func (m *Manager) ApplyEdits(ctx context.Context, reqCtx *types.RequestContext, fileID string, changes []*EditChange, opts EditApplyOptions) error {
+	failed := 0
+	for _, c := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		switch c.Action {
+		case EditAdd:
+			create := CreateOptions{
+				Type:                  c.Type,
+				Role:                  c.ToRole,
+				SendNotificationEmail: opts.SendNotificationEmail,
+				UseDomainAdminAccess:  opts.UseDomainAdminAccess,
+			}
+			switch c.Type {
+			case types.PermissionTypeDomain:
+				create.Domain = c.Principal
+			case types.PermissionTypeUser, types.PermissionTypeGroup:
+				create.EmailAddress = c.Principal
+			}
+			_, err = m.Create(ctx, reqCtx, fileID, create)
+		case EditChangeRole:
+			_, err = m.Update(ctx, reqCtx, fileID, c.PermissionID, UpdateOptions{
+				Role:                 c.ToRole,
+				UseDomainAdminAccess: opts.UseDomainAdminAccess,
+			})
+		case EditRemove:
+			err = m.Delete(ctx, reqCtx, fileID, c.PermissionID, DeleteOptions{UseDomainAdminAccess: opts.UseDomainAdminAccess})
+		}
+		if err != nil {
+			c.Status = "failed"
+			c.Error = err.Error()
+			failed++
+			continue
+		}
+		c.Status = "applied"
+	}
+	if failed > 0 {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeBatchPartialFailure,
+			fmt.Sprintf("%d of %d permission changes failed", failed, len(changes))).
+			WithContext("changes", changes).
+			Build())
+	}
+	return nil
+}
+
+func checkEditableRole(role string) error {
+	for _, r := range editableRoles {
+		if r == role {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown role %q (%s)", role, strings.Join(editableRoles, ", "))
+}
@@ -0,0 +1,146 @@
+package permissions
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func editTestPermissions() []*types.Permission {
+	return []*types.Permission{
+		{ID: "p0", Type: "user", Role: "owner", EmailAddress: "owner@example.com"},
+		{ID: "p1", Type: "user", Role: "writer", EmailAddress: "alice@example.com"},
+		{ID: "p2", Type: "anyone", Role: "reader"},
+	}
+}
+
+func TestEditSession_StagesChanges(t *testing.T) {
+	s := NewEditSession("file1", editTestPermissions())
+
+	if err := s.Remove(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetRole(2, "reader"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("group", "team@example.com", "commenter"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range s.Changes() {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"~ user alice@example.com: writer → reader",
+		"+ group team@example.com: commenter",
+		"- anyone anyone: reader",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %q, want %q", got, want)
+	}
+
+	// Setting the original role back, and resetting, unstage the change
+	if err := s.SetRole(2, "writer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reset(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reset(4); err != nil {
+		t.Fatal(err)
+	}
+	if changes := s.Changes(); len(changes) != 0 {
+		t.Errorf("expected no changes after reset, got %v", changes)
+	}
+	if len(s.Rows()) != 3 {
+		t.Errorf("reset of a new grant should drop it, have %d rows", len(s.Rows()))
+	}
+}
+
+func TestEditSession_RejectsInvalidEdits(t *testing.T) {
+	s := NewEditSession("file1", editTestPermissions())
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"owner role", s.SetRole(1, "reader")},
+		{"owner removal", s.Remove(1)},
+		{"unknown row", s.Remove(9)},
+		{"unknown role", s.SetRole(2, "editor")},
+		{"owner role grant", s.Add("user", "bob@example.com", "owner")},
+		{"user without email", s.Add("user", "bob", "reader")},
+		{"domain with email", s.Add("domain", "bob@example.com", "reader")},
+		{"unknown type", s.Add("robot", "bob@example.com", "reader")},
+		{"duplicate grant", s.Add("user", "ALICE@example.com", "reader")},
+	}
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if changes := s.Changes(); len(changes) != 0 {
+		t.Errorf("rejected edits were staged: %v", changes)
+	}
+}
+
+func TestApplyEdits(t *testing.T) {
+	var calls []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+		}
+		if r.Method == http.MethodDelete && r.URL.Path == "/drive/v3/files/file1/permissions/p2" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"forbidden","errors":[{"reason":"insufficientFilePermissions"}]}}`))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"new","type":"user","role":"reader"}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	s := NewEditSession("file1", editTestPermissions())
+	_ = s.SetRole(2, "reader")
+	_ = s.Remove(3)
+	_ = s.Add("user", "bob@example.com", "reader")
+	changes := s.Changes()
+
+	err := mgr.ApplyEdits(ctx, reqCtx, "file1", changes, EditApplyOptions{})
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeBatchPartialFailure {
+		t.Fatalf("expected a partial failure, got %v", err)
+	}
+
+	sort.Strings(calls)
+	wantCalls := []string{
+		"DELETE /drive/v3/files/file1/permissions/p2",
+		"PATCH /drive/v3/files/file1/permissions/p1",
+		"POST /drive/v3/files/file1/permissions",
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+	statuses := map[string]string{}
+	for _, c := range changes {
+		statuses[c.Action] = c.Status
+	}
+	want := map[string]string{EditChangeRole: "applied", EditAdd: "applied", EditRemove: "failed"}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+}
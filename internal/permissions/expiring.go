@@ -0,0 +1,300 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/safety"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// Expiring grant renewal outcomes
+const (
+	RenewRenewed = "renewed"
+	RenewPlanned = "planned"
+	RenewFailed  = "failed"
+)
+
+// MaxExpiration is how far ahead Drive lets a grant expire
+const MaxExpiration = 365 * 24 * time.Hour
+
+// ParseExpiration parses an expiration given as an RFC 3339 time or as a
+// period from now such as 30d, 2w or 36h
+func ParseExpiration(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := utils.ParseAge(s)
+	if err != nil || d == 0 {
+		return time.Time{}, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid expiration %q: use an RFC 3339 time or a period such as 30d", s)).Build())
+	}
+	return now.Add(d).UTC().Truncate(time.Second), nil
+}
+
+// ValidateExpiration checks an expiration against Drive's rules: it must
+// be in the future and at most a year ahead, and only user and group grants
+// with a reader, commenter or writer role can expire. An empty permType or
+// role is not checked, for updates that keep them.
+func ValidateExpiration(permType, role string, expires, now time.Time) error {
+	invalid := func(msg string) error {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, msg).
+			WithContext("expirationTime", expires.UTC().Format(time.RFC3339)).Build())
+	}
+	switch {
+	case !expires.After(now):
+		return invalid("Expiration time must be in the future")
+	case expires.After(now.Add(MaxExpiration)):
+		return invalid("Expiration time can be at most a year ahead")
+	case permType != "" && permType != "user" && permType != "group":
+		return invalid(fmt.Sprintf("Only user and group grants can expire, not %s grants", permType))
+	case role != "" && role != "reader" && role != "commenter" && role != "writer":
+		return invalid(fmt.Sprintf("Only reader, commenter and writer grants can expire, not %s", role))
+	}
+	return nil
+}
+
+// recordExpirationUpdate plans a permission update that sets an expiration
+func recordExpirationUpdate(recorder safety.DryRunRecorder, fileID, permissionID string, perm *drive.Permission) {
+	description := "Set permission to expire at " + perm.ExpirationTime
+	params := map[string]interface{}{"permissionID": permissionID, "expirationTime": perm.ExpirationTime}
+	if perm.Role != "" {
+		description = fmt.Sprintf("Update permission to role '%s', expiring at %s", perm.Role, perm.ExpirationTime)
+		params["newRole"] = perm.Role
+	}
+	recorder.RecordOperation(safety.PlannedOperation{
+		Type:        safety.OpTypeUpdatePermission,
+		ResourceID:  fileID,
+		Description: description,
+		Parameters:  params,
+		Predicted:   "expires " + perm.ExpirationTime,
+	})
+}
+
+// ExpiringOptions selects grants whose expiration is approaching
+type ExpiringOptions struct {
+	FolderID  string
+	Recursive bool
+	// Within is how far ahead to look; grants expiring after now+Within
+	// are not reported
+	Within time.Duration
+	// Grantee limits the report to one grantee when set
+	Grantee              Selector
+	UseDomainAdminAccess bool
+	// Now is the reference time; zero means time.Now()
+	Now time.Time
+}
+
+// ExpiringGrant is a time-limited grant and, after a renewal, its outcome
+type ExpiringGrant struct {
+	FileID         string `json:"fileId"`
+	FileName       string `json:"fileName"`
+	PermissionID   string `json:"permissionId"`
+	Type           string `json:"type"`
+	Role           string `json:"role"`
+	Principal      string `json:"principal"`
+	ExpirationTime string `json:"expirationTime"`
+	NewExpiration  string `json:"newExpirationTime,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Error          string `json:"error,omitempty"`
+
+	expires time.Time
+}
+
+// Renews reports whether renewing the grant to expire renew from now
+// would extend it; RenewExpiring leaves the others alone
+func (g *ExpiringGrant) Renews(renew time.Duration) bool {
+	return time.Now().Add(renew).UTC().Truncate(time.Second).After(g.expires)
+}
+
+// ExpiringReport lists grants expiring within a window under a folder
+type ExpiringReport struct {
+	FolderID     string           `json:"folderId"`
+	FolderName   string           `json:"folderName,omitempty"`
+	Recursive    bool             `json:"recursive"`
+	Cutoff       string           `json:"cutoff"`
+	FilesScanned int              `json:"filesScanned"`
+	Grants       []*ExpiringGrant `json:"grants"`
+	Renewed      int              `json:"renewed,omitempty"`
+	Failed       int              `json:"failed,omitempty"`
+	DryRun       bool             `json:"dryRun,omitempty"`
+}
+
+func (r *ExpiringReport) Headers() []string {
+	headers := []string{"File", "Grantee", "Type", "Role", "Expires"}
+	if r.renewing() {
+		headers = append(headers, "New Expiry", "Status")
+	}
+	return headers
+}
+
+func (r *ExpiringReport) Rows() [][]string {
+	rows := make([][]string, len(r.Grants))
+	for i, g := range r.Grants {
+		rows[i] = []string{g.FileName, g.Principal, g.Type, g.Role, types.DisplayTime(g.ExpirationTime)}
+		if r.renewing() {
+			status := g.Status
+			if g.Error != "" {
+				status += ": " + g.Error
+			}
+			rows[i] = append(rows[i], types.DisplayTime(g.NewExpiration), status)
+		}
+	}
+	return rows
+}
+
+func (r *ExpiringReport) EmptyMessage() string {
+	return "No grants expire before " + r.Cutoff + " (" + strconv.Itoa(r.FilesScanned) + " files scanned)"
+}
+
+func (r *ExpiringReport) renewing() bool {
+	for _, g := range r.Grants {
+		if g.Status != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FindExpiring lists the grants on a folder and the files in it (and in
+// its subfolders when recursive) that expire within opts.Within, soonest
+// first. Grants that already expired are gone from Drive and not listed.
+func (m *Manager) FindExpiring(ctx context.Context, reqCtx *types.RequestContext, opts ExpiringOptions) (*ExpiringReport, error) {
+	if !opts.Grantee.IsZero() {
+		if err := opts.Grantee.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	cutoff := now.Add(opts.Within)
+
+	getCall := m.client.Service().Files.Get(opts.FolderID).Fields("id,name")
+	getCall = m.shaper.ShapeFilesGet(getCall, reqCtx)
+	folder, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return getCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	files, err := m.findFilesInFolder(ctx, reqCtx, types.BulkOptions{FolderID: opts.FolderID, Recursive: opts.Recursive})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ExpiringReport{
+		FolderID:   folder.Id,
+		FolderName: folder.Name,
+		Recursive:  opts.Recursive,
+		Cutoff:     cutoff.UTC().Format(time.RFC3339),
+		Grants:     []*ExpiringGrant{},
+	}
+	scan := append([]*drive.File{folder}, files...)
+	for i, file := range scan {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions expiring", Done: i + 1, Total: len(scan), Item: file.Name})
+		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{Full: true, UseDomainAdminAccess: opts.UseDomainAdminAccess})
+		if err != nil {
+			return nil, err
+		}
+		report.FilesScanned++
+		for _, p := range perms {
+			if p.ExpirationTime == "" || p.Deleted {
+				continue
+			}
+			if !opts.Grantee.IsZero() && !opts.Grantee.Matches(p) {
+				continue
+			}
+			expires, err := time.Parse(time.RFC3339, p.ExpirationTime)
+			if err != nil || expires.Before(now) || expires.After(cutoff) {
+				continue
+			}
+			report.Grants = append(report.Grants, &ExpiringGrant{
+				FileID:         file.Id,
+				FileName:       file.Name,
+				PermissionID:   p.ID,
+				Type:           p.Type,
+				Role:           p.Role,
+				Principal:      principalOf(p),
+				ExpirationTime: p.ExpirationTime,
+				expires:        expires,
+			})
+		}
+	}
+	sort.SliceStable(report.Grants, func(i, j int) bool {
+		return report.Grants[i].expires.Before(report.Grants[j].expires)
+	})
+	return report, nil
+}
+
+// RenewExpiring sets every grant in report to expire renew from now. A
+// grant that would not be extended is left alone. Under dryRun nothing is
+// changed and each grant is reported as planned; a failed grant does not
+// stop the others.
+func (m *Manager) RenewExpiring(ctx context.Context, reqCtx *types.RequestContext, report *ExpiringReport, renew time.Duration, useDomainAdminAccess, dryRun bool) error {
+	if renew <= 0 {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Renewal period must be positive").Build())
+	}
+	newExpiry := time.Now().Add(renew).UTC().Truncate(time.Second)
+	report.DryRun = dryRun
+
+	for i, g := range report.Grants {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions renew", Done: i + 1, Total: len(report.Grants), Item: g.FileName})
+		g.NewExpiration = newExpiry.Format(time.RFC3339)
+		if !newExpiry.After(g.expires) {
+			g.Status = RenewFailed
+			g.Error = "renewal would not extend the grant"
+			report.Failed++
+			continue
+		}
+		if dryRun {
+			g.Status = RenewPlanned
+			continue
+		}
+		if err := m.setExpiration(ctx, reqCtx, g.FileID, g.PermissionID, newExpiry, useDomainAdminAccess); err != nil {
+			g.Status = RenewFailed
+			g.Error = err.Error()
+			report.Failed++
+			continue
+		}
+		g.Status = RenewRenewed
+		g.ExpirationTime = g.NewExpiration
+		report.Renewed++
+	}
+	return nil
+}
+
+// setExpiration changes when a permission expires, leaving its role alone
+func (m *Manager) setExpiration(ctx context.Context, reqCtx *types.RequestContext, fileID, permissionID string, expires time.Time, useDomainAdminAccess bool) error {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	call := m.client.Service().Permissions.Update(fileID, permissionID, &drive.Permission{
+		ExpirationTime: expires.Format(time.RFC3339),
+	})
+	call = call.SupportsAllDrives(true).Fields("id,expirationTime")
+	if useDomainAdminAccess {
+		call = call.UseDomainAdminAccess(true)
+	}
+	if header := m.client.ResourceKeys().BuildHeader(reqCtx.InvolvedFileIDs); header != "" {
+		call.Header().Set("X-Goog-Drive-Resource-Keys", header)
+	}
+
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+		return call.Do()
+	})
+	return err
+}
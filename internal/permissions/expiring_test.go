@@ -0,0 +1,177 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestFindAndRenewExpiring(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	var renewed []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			var p drive.Permission
+			_ = json.Unmarshal(body, &p)
+			if p.Role != "" || p.ExpirationTime == "" {
+				t.Errorf("update body = %s", body)
+			}
+			renewed = append(renewed, strings.TrimPrefix(r.URL.Path, "/drive/v3/files/"))
+			_, _ = w.Write([]byte(`{"id":"x"}`))
+		case r.URL.Path == "/drive/v3/files/folder":
+			_, _ = w.Write([]byte(`{"id":"folder","name":"Team"}`))
+		case r.URL.Path == "/drive/v3/files":
+			_, _ = w.Write([]byte(`{"files":[{"id":"doc","name":"Plan"}]}`))
+		case r.URL.Path == "/drive/v3/files/folder/permissions":
+			_ = json.NewEncoder(w).Encode(drive.PermissionList{Permissions: []*drive.Permission{
+				{Id: "p1", Type: "user", Role: "writer", EmailAddress: "a@example.com", ExpirationTime: at(10 * 24 * time.Hour)},
+				{Id: "p2", Type: "user", Role: "reader", EmailAddress: "b@example.com", ExpirationTime: at(40 * 24 * time.Hour)},
+				{Id: "p3", Type: "user", Role: "reader", EmailAddress: "c@example.com"},
+			}})
+		case r.URL.Path == "/drive/v3/files/doc/permissions":
+			_ = json.NewEncoder(w).Encode(drive.PermissionList{Permissions: []*drive.Permission{
+				{Id: "p4", Type: "group", Role: "reader", EmailAddress: "g@example.com", ExpirationTime: at(2 * 24 * time.Hour)},
+			}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	report, err := mgr.FindExpiring(ctx, reqCtx, ExpiringOptions{FolderID: "folder", Within: 14 * 24 * time.Hour, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FilesScanned != 2 || len(report.Grants) != 2 || report.Grants[0].PermissionID != "p4" || report.Grants[1].PermissionID != "p1" {
+		t.Fatalf("report = %+v", report)
+	}
+
+	if err := mgr.RenewExpiring(ctx, reqCtx, report, 90*24*time.Hour, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(renewed) != 0 || report.Grants[0].Status != RenewPlanned {
+		t.Errorf("dry run renewed %v, status %s", renewed, report.Grants[0].Status)
+	}
+
+	// A renewal shorter than a grant's remaining time does not shorten it
+	if err := mgr.RenewExpiring(ctx, reqCtx, report, 5*24*time.Hour, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if report.Renewed != 1 || report.Failed != 1 || len(renewed) != 1 || renewed[0] != "doc/permissions/p4" {
+		t.Errorf("renewed %v, report %+v", renewed, report)
+	}
+
+	filtered, err := mgr.FindExpiring(ctx, reqCtx, ExpiringOptions{FolderID: "folder", Within: 60 * 24 * time.Hour, Now: now, Grantee: Selector{Email: "B@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.Grants) != 1 || filtered.Grants[0].Principal != "b@example.com" {
+		t.Errorf("filtered = %+v", filtered.Grants)
+	}
+}
+
+func TestParseAndValidateExpiration(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseExpiration("30d", now)
+	if err != nil || !got.Equal(now.Add(30*24*time.Hour)) {
+		t.Errorf("ParseExpiration(30d) = %v, %v", got, err)
+	}
+	got, err = ParseExpiration("2026-04-01T09:00:00+02:00", now)
+	if err != nil || !got.Equal(time.Date(2026, 4, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseExpiration(RFC 3339) = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "0d", "soon", "2026-04-01"} {
+		if _, err := ParseExpiration(bad, now); err == nil {
+			t.Errorf("ParseExpiration(%q) should fail", bad)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		permType string
+		role     string
+		expires  time.Time
+		wantErr  bool
+	}{
+		{name: "user reader", permType: "user", role: "reader", expires: now.Add(time.Hour)},
+		{name: "group writer", permType: "group", role: "writer", expires: now.Add(300 * 24 * time.Hour)},
+		{name: "update keeps type and role", expires: now.Add(time.Hour)},
+		{name: "past", permType: "user", role: "reader", expires: now.Add(-time.Hour), wantErr: true},
+		{name: "over a year", permType: "user", role: "reader", expires: now.Add(366 * 24 * time.Hour), wantErr: true},
+		{name: "domain", permType: "domain", role: "reader", expires: now.Add(time.Hour), wantErr: true},
+		{name: "anyone", permType: "anyone", role: "reader", expires: now.Add(time.Hour), wantErr: true},
+		{name: "organizer", permType: "user", role: "organizer", expires: now.Add(time.Hour), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExpiration(tt.permType, tt.role, tt.expires, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExpiration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateAndUpdateWithExpiration(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	var bodies []drive.Permission
+	var fields []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p drive.Permission
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &p)
+		bodies = append(bodies, p)
+		fields = append(fields, r.URL.Query().Get("fields"))
+		p.Id = "p1"
+		p.Type = "user"
+		if p.Role == "" {
+			p.Role = "reader"
+		}
+		_ = json.NewEncoder(w).Encode(p)
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	created, err := mgr.Create(ctx, reqCtx, "file1", CreateOptions{
+		Type: "user", Role: "reader", EmailAddress: "a@example.com", ExpirationTime: expires,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bodies[0].ExpirationTime != "2030-01-02T03:04:05Z" || created.ExpirationTime != bodies[0].ExpirationTime {
+		t.Errorf("create sent %q, returned %q", bodies[0].ExpirationTime, created.ExpirationTime)
+	}
+
+	// An expiration-only update leaves the role alone
+	updated, err := mgr.Update(ctx, reqCtx, "file1", "p1", UpdateOptions{ExpirationTime: expires.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bodies[1].Role != "" || bodies[1].ExpirationTime != "2030-01-02T04:04:05Z" || updated.ExpirationTime == "" {
+		t.Errorf("update sent %+v, returned %+v", bodies[1], updated)
+	}
+	for _, f := range fields {
+		if !strings.Contains(f, "expirationTime") {
+			t.Errorf("fields = %q, want expirationTime", f)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// GroupExpander lists the direct members of a group
+type GroupExpander func(ctx context.Context, group string) ([]types.Member, error)
+
+// ExplainOptions configures Explain
+type ExplainOptions struct {
+	// ExpandGroups lists group members; nil leaves groups unexpanded
+	ExpandGroups GroupExpander
+	// GroupsUnavailable says why groups are not expanded, for the notes
+	GroupsUnavailable string
+	// MaxGroupDepth limits nested group expansion (0 for the default)
+	MaxGroupDepth int
+}
+
+const (
+	defaultMaxGroupDepth = 5
+	// maxAncestorDepth bounds the folder walk used to find where a My Drive
+	// permission was inherited from
+	maxAncestorDepth  = 20
+	explainFileFields = "id,name,mimeType,driveId,parents"
+	ancestorFields    = "id,name,parents"
+)
+
+// Explain lists every principal with access to a file, where each grant
+// comes from (a direct share, a parent folder, Shared Drive membership or a
+// link), and each principal's effective role. Group grants are expanded into
+// their members when opts.ExpandGroups is set.
+func (m *Manager) Explain(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts ExplainOptions) (*types.AccessExplanation, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	file, err := m.client.GetFile(ctx, reqCtx, fileID, explainFileFields)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := m.List(ctx, reqCtx, fileID, ListOptions{AccessDetails: true})
+	if err != nil {
+		return nil, err
+	}
+
+	exp := &types.AccessExplanation{
+		FileID:         file.Id,
+		FileName:       file.Name,
+		MimeType:       file.MimeType,
+		DriveID:        file.DriveId,
+		GroupsExpanded: opts.ExpandGroups != nil,
+		Grants:         []*types.AccessGrant{},
+	}
+
+	// Shared Drive items report inheritance; for My Drive it is worked out
+	// from the parent folders
+	var origins map[string]*ancestor
+	if file.DriveId == "" && len(file.Parents) > 0 {
+		var note string
+		origins, note = m.inheritedOrigins(ctx, reqCtx, file.Parents[0], perms)
+		if note != "" {
+			exp.Notes = append(exp.Notes, note)
+		}
+	}
+
+	folderNames := map[string]string{}
+	for _, p := range perms {
+		g := &types.AccessGrant{
+			PermissionID: p.ID,
+			Type:         p.Type,
+			Principal:    principalOf(p),
+			DisplayName:  p.DisplayName,
+			Role:         p.Role,
+		}
+		if len(p.PermissionDetails) > 0 {
+			g.Source, g.InheritedFrom = sourceFromDetails(p)
+		} else if origin, ok := origins[grantKey(p)]; ok {
+			g.Source, g.InheritedFrom, g.InheritedFromName = types.AccessSourceInherited, origin.id, origin.name
+		} else {
+			g.Source = types.AccessSourceDirect
+		}
+		if p.Type == "anyone" {
+			g.Source = types.AccessSourceLink
+		}
+		if g.InheritedFrom != "" && g.InheritedFromName == "" {
+			g.InheritedFromName = m.folderName(ctx, reqCtx, g.InheritedFrom, folderNames)
+		}
+		exp.Grants = append(exp.Grants, g)
+	}
+
+	hasGroups := false
+	maxDepth := opts.MaxGroupDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxGroupDepth
+	}
+	members := map[string][]types.Member{}
+	for _, g := range exp.Grants {
+		if g.Type != "group" {
+			continue
+		}
+		hasGroups = true
+		if opts.ExpandGroups != nil {
+			expandGroup(ctx, g, opts.ExpandGroups, members, map[string]bool{}, maxDepth)
+		}
+	}
+	if hasGroups && opts.ExpandGroups == nil {
+		note := "Groups were not expanded"
+		if opts.GroupsUnavailable != "" {
+			note += ": " + opts.GroupsUnavailable
+		}
+		exp.Notes = append(exp.Notes, note)
+	}
+
+	sortGrants(exp.Grants)
+	exp.Effective = effectiveAccess(exp.Grants)
+	return exp, nil
+}
+
+func principalOf(p *types.Permission) string {
+	switch p.Type {
+	case "anyone":
+		return "anyone"
+	case "domain":
+		return p.Domain
+	default:
+		return p.EmailAddress
+	}
+}
+
+// grantKey identifies a grant of the same access on different items
+func grantKey(p *types.Permission) string {
+	return p.Type + ":" + strings.ToLower(principalOf(p)) + ":" + p.Role
+}
+
+// sourceFromDetails picks the access detail behind a Shared Drive
+// permission's role, preferring a direct grant over an inherited one
+func sourceFromDetails(p *types.Permission) (source, inheritedFrom string) {
+	var best *types.PermissionAccessDetail
+	for _, d := range p.PermissionDetails {
+		if d.Role != p.Role {
+			continue
+		}
+		if best == nil || (best.Inherited && !d.Inherited) {
+			best = d
+		}
+	}
+	switch {
+	case best == nil:
+		return types.AccessSourceDirect, ""
+	case best.PermissionType == "member":
+		return types.AccessSourceDriveMembership, ""
+	case best.Inherited:
+		return types.AccessSourceInherited, best.InheritedFrom
+	default:
+		return types.AccessSourceDirect, ""
+	}
+}
+
+type ancestor struct {
+	id   string
+	name string
+}
+
+// inheritedOrigins walks up from parentID and, for each of the file's
+// grants, finds the topmost folder in the unbroken chain of ancestors that
+// has the same grant. That folder is where the access was granted.
+// Ownership does not propagate, so owner grants are never inherited.
+func (m *Manager) inheritedOrigins(ctx context.Context, reqCtx *types.RequestContext, parentID string, perms []*types.Permission) (map[string]*ancestor, string) {
+	pending := map[string]bool{}
+	for _, p := range perms {
+		if p.Role != types.PermissionRoleOwner {
+			pending[grantKey(p)] = true
+		}
+	}
+	origins := map[string]*ancestor{}
+
+	id := parentID
+	for depth := 0; id != "" && len(pending) > 0 && depth < maxAncestorDepth; depth++ {
+		folder, err := m.client.GetFile(ctx, reqCtx, id, ancestorFields)
+		if err != nil {
+			return origins, fmt.Sprintf("Inheritance is incomplete: cannot read folder %s", id)
+		}
+		folderPerms, err := m.List(ctx, reqCtx, id, ListOptions{})
+		if err != nil {
+			return origins, fmt.Sprintf("Inheritance is incomplete: cannot read the permissions of %q", folder.Name)
+		}
+		has := map[string]bool{}
+		for _, p := range folderPerms {
+			has[grantKey(p)] = true
+		}
+		for key := range pending {
+			if has[key] {
+				origins[key] = &ancestor{id: folder.Id, name: folder.Name}
+			} else {
+				// Not on this folder, so not inherited from further up
+				delete(pending, key)
+			}
+		}
+		id = ""
+		if len(folder.Parents) > 0 {
+			id = folder.Parents[0]
+		}
+	}
+	return origins, ""
+}
+
+// folderName looks up a folder's name for display, or "" if it cannot be read
+func (m *Manager) folderName(ctx context.Context, reqCtx *types.RequestContext, id string, names map[string]string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	name := ""
+	if folder, err := m.client.GetFile(ctx, reqCtx, id, ancestorFields); err == nil {
+		name = folder.Name
+	}
+	names[id] = name
+	return name
+}
+
+// expandGroup fills in g's members, recursing into nested groups. seen
+// holds the groups on the current path so membership cycles terminate.
+func expandGroup(ctx context.Context, g *types.AccessGrant, expand GroupExpander, cache map[string][]types.Member, seen map[string]bool, depth int) {
+	key := strings.ToLower(g.Principal)
+	if seen[key] {
+		g.NotExpanded = "membership cycle"
+		return
+	}
+	if depth == 0 {
+		g.NotExpanded = "nested too deeply"
+		return
+	}
+
+	members, ok := cache[key]
+	if !ok {
+		var err error
+		members, err = expand(ctx, g.Principal)
+		if err != nil {
+			g.NotExpanded = err.Error()
+			return
+		}
+		cache[key] = members
+	}
+
+	seen[key] = true
+	defer delete(seen, key)
+	for _, member := range members {
+		child := &types.AccessGrant{
+			Type:      strings.ToLower(member.Type),
+			Principal: member.Email,
+			Role:      g.Role,
+			Source:    types.AccessSourceGroup,
+		}
+		if member.Type == "CUSTOMER" {
+			child.Type, child.Principal = "domain", "(everyone in the organization)"
+		}
+		if child.Type == "group" {
+			expandGroup(ctx, child, expand, cache, seen, depth-1)
+		}
+		g.Members = append(g.Members, child)
+	}
+	sortGrants(g.Members)
+}
+
+// sortGrants orders grants by role, highest first, then principal
+func sortGrants(grants []*types.AccessGrant) {
+	sort.SliceStable(grants, func(i, j int) bool {
+		if ri, rj := roleRank[grants[i].Role], roleRank[grants[j].Role]; ri != rj {
+			return ri > rj
+		}
+		return grants[i].Principal < grants[j].Principal
+	})
+}
+
+// describeGrant says how a top-level grant applies
+func describeGrant(g *types.AccessGrant) string {
+	switch {
+	case g.Source == types.AccessSourceInherited && g.InheritedFromName != "":
+		return fmt.Sprintf("inherited from %q", g.InheritedFromName)
+	case g.Source == types.AccessSourceInherited && g.InheritedFrom != "":
+		return "inherited from " + g.InheritedFrom
+	case g.Source == types.AccessSourceDriveMembership:
+		return "Shared Drive membership"
+	default:
+		return g.Source
+	}
+}
+
+// effectiveAccess gives each principal reached by the grants its highest
+// role, with every path that reaches it
+func effectiveAccess(grants []*types.AccessGrant) []*types.EffectiveAccess {
+	byPrincipal := map[string]*types.EffectiveAccess{}
+	var order []string
+	var visit func(grants []*types.AccessGrant, via func(*types.AccessGrant) string)
+	visit = func(grants []*types.AccessGrant, via func(*types.AccessGrant) string) {
+		for _, g := range grants {
+			path := via(g)
+			key := g.Type + ":" + strings.ToLower(g.Principal)
+			e, ok := byPrincipal[key]
+			if !ok {
+				e = &types.EffectiveAccess{Principal: g.Principal, Type: g.Type, Role: g.Role}
+				byPrincipal[key] = e
+				order = append(order, key)
+			} else if roleRank[g.Role] > roleRank[e.Role] {
+				e.Role = g.Role
+			}
+			e.Via = append(e.Via, path)
+
+			group := g.Principal
+			visit(g.Members, func(*types.AccessGrant) string {
+				return fmt.Sprintf("group %s (%s)", group, path)
+			})
+		}
+	}
+	visit(grants, describeGrant)
+
+	result := make([]*types.EffectiveAccess, 0, len(order))
+	for _, key := range order {
+		result = append(result, byPrincipal[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if ri, rj := roleRank[result[i].Role], roleRank[result[j].Role]; ri != rj {
+			return ri > rj
+		}
+		return result[i].Principal < result[j].Principal
+	})
+	return result
+}
@@ -0,0 +1,143 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestExplain_MyDrive(t *testing.T) {
+	files := map[string]*drive.File{
+		"f1":   {Id: "f1", Name: "Budget", Parents: []string{"a"}},
+		"a":    {Id: "a", Name: "Finance", Parents: []string{"root"}},
+		"root": {Id: "root", Name: "My Drive"},
+	}
+	owner := &drive.Permission{Id: "o", Type: "user", Role: "owner", EmailAddress: "owner@example.com"}
+	team := &drive.Permission{Id: "g", Type: "group", Role: "reader", EmailAddress: "team@example.com"}
+	perms := map[string][]*drive.Permission{
+		"f1": {owner, team,
+			{Id: "b", Type: "user", Role: "writer", EmailAddress: "bob@example.com"},
+			{Id: "l", Type: "anyone", Role: "reader"}},
+		"a":    {owner, team},
+		"root": {owner, team},
+	}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/")
+		w.Header().Set("Content-Type", "application/json")
+		if id, ok := strings.CutSuffix(path, "/permissions"); ok {
+			_ = json.NewEncoder(w).Encode(&drive.PermissionList{Permissions: perms[id]})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(files[path])
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	groups := map[string][]types.Member{
+		"team@example.com":   {{Email: "alice@example.com", Type: "USER"}, {Email: "nested@example.com", Type: "GROUP"}},
+		"nested@example.com": {{Email: "bob@example.com", Type: "USER"}, {Email: "team@example.com", Type: "GROUP"}},
+	}
+	expand := func(ctx context.Context, group string) ([]types.Member, error) {
+		members, ok := groups[group]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return members, nil
+	}
+
+	exp, err := mgr.Explain(ctx, reqCtx, "f1", ExplainOptions{ExpandGroups: expand})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grants := map[string]*types.AccessGrant{}
+	for _, g := range exp.Grants {
+		grants[g.Principal] = g
+	}
+	if g := grants["team@example.com"]; g.Source != types.AccessSourceInherited || g.InheritedFrom != "root" || g.InheritedFromName != "My Drive" {
+		t.Errorf("team grant = %+v", g)
+	}
+	if g := grants["bob@example.com"]; g.Source != types.AccessSourceDirect {
+		t.Errorf("bob grant = %+v", g)
+	}
+	if g := grants["owner@example.com"]; g.Source != types.AccessSourceDirect {
+		t.Errorf("owner grant = %+v", g)
+	}
+	if g := grants["anyone"]; g.Source != types.AccessSourceLink {
+		t.Errorf("link grant = %+v", g)
+	}
+
+	teamGrant := grants["team@example.com"]
+	if len(teamGrant.Members) != 2 {
+		t.Fatalf("team members = %+v", teamGrant.Members)
+	}
+	nested := teamGrant.Members[1]
+	if nested.Principal != "nested@example.com" || len(nested.Members) != 2 || nested.Members[1].NotExpanded != "membership cycle" {
+		t.Errorf("nested group = %+v", nested)
+	}
+
+	effective := map[string]*types.EffectiveAccess{}
+	for _, e := range exp.Effective {
+		effective[e.Principal] = e
+	}
+	if e := effective["bob@example.com"]; e.Role != "writer" || len(e.Via) != 2 {
+		t.Errorf("bob effective = %+v", e)
+	}
+	if e := effective["alice@example.com"]; e.Role != "reader" || !strings.Contains(e.Via[0], "group team@example.com") {
+		t.Errorf("alice effective = %+v", e)
+	}
+	if exp.Effective[0].Principal != "owner@example.com" {
+		t.Errorf("effective should start with the owner, got %s", exp.Effective[0].Principal)
+	}
+
+	rows := exp.Rows()
+	if len(rows) != 8 || rows[3][0] != "└─ team@example.com" || rows[7][0] != "      └─ team@example.com (members not expanded)" {
+		t.Errorf("rows = %v", rows)
+	}
+
+	// Without an expander groups are noted, not expanded
+	exp, err = mgr.Explain(ctx, reqCtx, "f1", ExplainOptions{GroupsUnavailable: "no admin access"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exp.Notes) != 1 || !strings.Contains(exp.Notes[0], "no admin access") {
+		t.Errorf("notes = %v", exp.Notes)
+	}
+}
+
+func TestSourceFromDetails(t *testing.T) {
+	tests := []struct {
+		name      string
+		details   []*types.PermissionAccessDetail
+		source    string
+		inherited string
+	}{
+		{"membership", []*types.PermissionAccessDetail{{PermissionType: "member", Role: "writer", Inherited: true, InheritedFrom: "d1"}}, types.AccessSourceDriveMembership, ""},
+		{"folder", []*types.PermissionAccessDetail{{PermissionType: "file", Role: "writer", Inherited: true, InheritedFrom: "folder1"}}, types.AccessSourceInherited, "folder1"},
+		{"direct beats inherited", []*types.PermissionAccessDetail{
+			{PermissionType: "file", Role: "writer", Inherited: true, InheritedFrom: "folder1"},
+			{PermissionType: "file", Role: "writer"},
+		}, types.AccessSourceDirect, ""},
+		{"lower role ignored", []*types.PermissionAccessDetail{
+			{PermissionType: "member", Role: "reader", Inherited: true},
+			{PermissionType: "file", Role: "writer", Inherited: true, InheritedFrom: "folder2"},
+		}, types.AccessSourceInherited, "folder2"},
+	}
+	for _, tt := range tests {
+		p := &types.Permission{Type: "user", Role: "writer", PermissionDetails: tt.details}
+		source, inherited := sourceFromDetails(p)
+		if source != tt.source || inherited != tt.inherited {
+			t.Errorf("%s: got %s/%s, want %s/%s", tt.name, source, inherited, tt.source, tt.inherited)
+		}
+	}
+}
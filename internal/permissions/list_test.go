@@ -0,0 +1,58 @@
+package permissions
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestList_FullFieldsAndPublishedView(t *testing.T) {
+	var query map[string][]string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"permissions":[{"id":"p1","type":"user","role":"writer",
+			"emailAddress":"a@example.com","pendingOwner":true,"expirationTime":"2030-01-01T00:00:00Z","view":"published",
+			"permissionDetails":[{"permissionType":"member","role":"writer","inherited":true,"inheritedFrom":"drive1"}]}]}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	perms, err := mgr.List(ctx, reqCtx, "file1", ListOptions{Full: true, IncludePublished: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := query["includePermissionsForView"]; len(got) != 1 || got[0] != "published" {
+		t.Errorf("includePermissionsForView = %v", got)
+	}
+	if fields := strings.Join(query["fields"], ","); !strings.Contains(fields, "permissionDetails(") || !strings.Contains(fields, "pendingOwner") {
+		t.Errorf("fields = %q, want full permission fields", fields)
+	}
+
+	if len(perms) != 1 {
+		t.Fatalf("got %d permissions", len(perms))
+	}
+	p := perms[0]
+	if !p.PendingOwner || p.ExpirationTime == "" || p.View != "published" {
+		t.Errorf("permission = %+v", p)
+	}
+	if len(p.PermissionDetails) != 1 || !p.PermissionDetails[0].Inherited || p.PermissionDetails[0].InheritedFrom != "drive1" {
+		t.Errorf("PermissionDetails = %+v", p.PermissionDetails)
+	}
+
+	if _, err := mgr.List(ctx, reqCtx, "file1", ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := query["includePermissionsForView"]; ok {
+		t.Error("includePermissionsForView should not be sent by default")
+	}
+	if fields := strings.Join(query["fields"], ","); strings.Contains(fields, "permissionDetails") {
+		t.Errorf("default fields = %q, want the short mask", fields)
+	}
+}
@@ -7,12 +7,15 @@ package permissions
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/files"
 	"github.com/dl-alexandre/gdrv/internal/safety"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 )
 
 // Manager handles permission operations for Google Drive files and folders.
@@ -27,8 +30,9 @@ import (
 //   - Domain admin access for Workspace environments
 //   - Resource key handling for link-shared files
 type Manager struct {
-	client *api.Client
-	shaper *api.RequestShaper
+	client   *api.Client
+	shaper   *api.RequestShaper
+	progress types.ProgressReporter
 }
 
 // NewManager creates a new permission manager
@@ -39,6 +43,12 @@ func NewManager(client *api.Client) *Manager {
 	}
 }
 
+// SetProgress makes long-running operations report each item to r; nil
+// turns reporting off
+func (m *Manager) SetProgress(r types.ProgressReporter) {
+	m.progress = r
+}
+
 // CreateOptions configures permission creation.
 //
 // Type specifies the permission type:
@@ -62,15 +72,17 @@ func NewManager(client *api.Client) *Manager {
 //   - Requirement 4.5: Support allowFileDiscovery for public permissions
 //   - Requirement 4.6: Support useDomainAdminAccess for Workspace environments
 type CreateOptions struct {
-	Type                  string // user, group, domain, anyone
-	Role                  string // reader, commenter, writer, organizer, owner
-	EmailAddress          string // Required for user and group types
-	Domain                string // Required for domain type
-	SendNotificationEmail bool   // Send email notification to recipients
-	EmailMessage          string // Custom message to include in notification email
-	TransferOwnership     bool   // Transfer ownership (only valid when Role="owner")
-	AllowFileDiscovery    bool   // Allow file to be discovered via search (anyone type only)
-	UseDomainAdminAccess  bool   // Use domain administrator access for Workspace environments
+	Type                  string           // user, group, domain, anyone
+	Role                  string           // reader, commenter, writer, organizer, owner
+	EmailAddress          string           // Required for user and group types
+	Domain                string           // Required for domain type
+	SendNotificationEmail bool             // Send email notification to recipients
+	EmailMessage          string           // Custom message to include in notification email
+	MessageTemplate       *MessageTemplate // Rendered per grant in place of EmailMessage
+	TransferOwnership     bool             // Transfer ownership (only valid when Role="owner")
+	AllowFileDiscovery    bool             // Allow file to be discovered via search (anyone type only)
+	UseDomainAdminAccess  bool             // Use domain administrator access for Workspace environments
+	ExpirationTime        time.Time        // When the grant expires (user and group only); zero for never
 }
 
 // UpdateOptions configures permission updates.
@@ -79,8 +91,9 @@ type CreateOptions struct {
 //   - Requirement 4.10: Modify existing permission levels
 //   - Requirement 4.6: Support useDomainAdminAccess for Workspace environments
 type UpdateOptions struct {
-	Role                 string // New role: reader, commenter, writer, organizer
-	UseDomainAdminAccess bool   // Use domain administrator access
+	Role                 string    // New role: reader, commenter, writer, organizer; empty keeps the role
+	UseDomainAdminAccess bool      // Use domain administrator access
+	ExpirationTime       time.Time // New expiration time; zero keeps the current one
 }
 
 // DeleteOptions configures permission deletion.
@@ -101,8 +114,19 @@ type DeleteOptions struct {
 type ListOptions struct {
 	UseDomainAdminAccess bool // Use domain administrator access
 	PageSize             int  // Number of permissions per page (0 = API default)
+	Full                 bool // Request inheritance, expiration, ownership-transfer and view fields
+	IncludePublished     bool // Include permissions of the published view
+	AccessDetails        bool // Request permissionDetails, e.g. to tell Shared Drive membership from item grants
 }
 
+const (
+	permissionListFields     = "id,type,role,emailAddress,domain,displayName,expirationTime"
+	permissionDetailsFields  = "permissionDetails(permissionType,role,inherited,inheritedFrom)"
+	permissionListFullFields = permissionListFields +
+		",allowFileDiscovery,deleted,pendingOwner,photoLink,view," +
+		permissionDetailsFields
+)
+
 // List lists all permissions for a file or folder.
 // It automatically handles pagination to retrieve all permissions.
 //
@@ -124,8 +148,18 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, fileID
 
 	call := m.client.Service().Permissions.List(fileID)
 	call = m.shaper.ShapePermissionsList(call, reqCtx)
-	call = call.Fields("permissions(id,type,role,emailAddress,domain,displayName),nextPageToken")
+	fields := permissionListFields
+	switch {
+	case opts.Full:
+		fields = permissionListFullFields
+	case opts.AccessDetails:
+		fields += "," + permissionDetailsFields
+	}
+	call = call.Fields(googleapi.Field("permissions(" + fields + "),nextPageToken"))
 
+	if opts.IncludePublished {
+		call = call.IncludePermissionsForView("published")
+	}
 	if opts.UseDomainAdminAccess {
 		call = call.UseDomainAdminAccess(true)
 	}
@@ -137,6 +171,9 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, fileID
 	pageToken := ""
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if pageToken != "" {
 			call = call.PageToken(pageToken)
 		}
@@ -194,25 +231,18 @@ func (m *Manager) List(ctx context.Context, reqCtx *types.RequestContext, fileID
 func (m *Manager) Create(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts CreateOptions) (*types.Permission, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
-	perm := &drive.Permission{
-		Type: opts.Type,
-		Role: opts.Role,
-	}
-	if opts.EmailAddress != "" {
-		perm.EmailAddress = opts.EmailAddress
-	}
-	if opts.Domain != "" {
-		perm.Domain = opts.Domain
-	}
-	if opts.Type == "anyone" {
-		perm.AllowFileDiscovery = opts.AllowFileDiscovery
-	}
-
-	call := m.client.Service().Permissions.Create(fileID, perm)
+	call := m.client.Service().Permissions.Create(fileID, newDrivePermission(opts))
 	call = m.shaper.ShapePermissionsCreate(call, reqCtx)
 	call = call.SendNotificationEmail(opts.SendNotificationEmail)
-	call = call.Fields("id,type,role,emailAddress,domain,displayName")
+	call = call.Fields(permissionListFields)
 
+	if opts.MessageTemplate != nil && opts.SendNotificationEmail && opts.EmailMessage == "" {
+		message, err := m.renderMessage(ctx, reqCtx, fileID, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.EmailMessage = message
+	}
 	if opts.EmailMessage != "" {
 		call = call.EmailMessage(opts.EmailMessage)
 	}
@@ -233,14 +263,35 @@ func (m *Manager) Create(ctx context.Context, reqCtx *types.RequestContext, file
 	return convertPermission(result), nil
 }
 
-// Update updates an existing permission's role.
+// newDrivePermission returns the permission resource that opts describe
+func newDrivePermission(opts CreateOptions) *drive.Permission {
+	perm := &drive.Permission{
+		Type: opts.Type,
+		Role: opts.Role,
+	}
+	if opts.EmailAddress != "" {
+		perm.EmailAddress = opts.EmailAddress
+	}
+	if opts.Domain != "" {
+		perm.Domain = opts.Domain
+	}
+	if opts.Type == "anyone" {
+		perm.AllowFileDiscovery = opts.AllowFileDiscovery
+	}
+	if !opts.ExpirationTime.IsZero() {
+		perm.ExpirationTime = opts.ExpirationTime.UTC().Format(time.RFC3339)
+	}
+	return perm
+}
+
+// Update updates an existing permission's role or expiration time.
 //
 // Parameters:
 //   - ctx: Context for request cancellation
 //   - reqCtx: Request context with profile, drive context, and trace ID
 //   - fileID: The ID of the file or folder
 //   - permissionID: The ID of the permission to update
-//   - opts: Update options (new role, domain admin access)
+//   - opts: Update options (new role, expiration time, domain admin access)
 //
 // Returns the updated permission or an error.
 //
@@ -251,7 +302,8 @@ func (m *Manager) Update(ctx context.Context, reqCtx *types.RequestContext, file
 	return m.UpdateWithSafety(ctx, reqCtx, fileID, permissionID, opts, safety.Default(), nil)
 }
 
-// UpdateWithSafety updates an existing permission's role with safety controls.
+// UpdateWithSafety updates an existing permission's role or expiration time
+// with safety controls.
 // Supports dry-run mode and confirmation.
 //
 // Requirements:
@@ -259,23 +311,31 @@ func (m *Manager) Update(ctx context.Context, reqCtx *types.RequestContext, file
 func (m *Manager) UpdateWithSafety(ctx context.Context, reqCtx *types.RequestContext, fileID, permissionID string, opts UpdateOptions, safetyOpts safety.SafetyOptions, recorder safety.DryRunRecorder) (*types.Permission, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
+	perm := &drive.Permission{
+		Role: opts.Role,
+	}
+	if !opts.ExpirationTime.IsZero() {
+		perm.ExpirationTime = opts.ExpirationTime.UTC().Format(time.RFC3339)
+	}
+
 	// Get current permission for dry-run display
 	if safetyOpts.DryRun && recorder != nil {
-		safety.RecordPermissionUpdate(recorder, fileID, fileID, permissionID, opts.Role)
+		if perm.ExpirationTime == "" {
+			safety.RecordPermissionUpdate(recorder, fileID, fileID, permissionID, opts.Role)
+		} else {
+			recordExpirationUpdate(recorder, fileID, permissionID, perm)
+		}
 		// Return a placeholder permission
 		return &types.Permission{
-			ID:   permissionID,
-			Role: opts.Role,
+			ID:             permissionID,
+			Role:           opts.Role,
+			ExpirationTime: perm.ExpirationTime,
 		}, nil
 	}
 
-	perm := &drive.Permission{
-		Role: opts.Role,
-	}
-
 	call := m.client.Service().Permissions.Update(fileID, permissionID, perm)
 	call = call.SupportsAllDrives(true)
-	call = call.Fields("id,type,role,emailAddress,domain,displayName")
+	call = call.Fields(permissionListFields)
 
 	if opts.UseDomainAdminAccess {
 		call = call.UseDomainAdminAccess(true)
@@ -336,6 +396,7 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 	}
 
 	// Confirmation for destructive operations
+	safetyOpts = safetyOpts.ForScope(safety.ScopePermissions)
 	if safetyOpts.ShouldConfirm() {
 		displayName := permissionID
 		if perm != nil && perm.EmailAddress != "" {
@@ -374,6 +435,14 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 	return err
 }
 
+// RequireShare fails fast with a missing capability error when the caller
+// cannot change the file's sharing, instead of a generic 403 from the
+// permission call. Bulk operations skip it to avoid a fetch per file.
+func (m *Manager) RequireShare(ctx context.Context, reqCtx *types.RequestContext, fileID string) error {
+	_, err := files.NewManager(m.client).RequireCapabilities(ctx, reqCtx, fileID, files.CapabilityShare)
+	return err
+}
+
 // CreatePublicLink creates a public "anyone with link" permission.
 //
 // This is a convenience method for creating public sharing links.
@@ -386,12 +455,24 @@ func (m *Manager) DeleteWithSafety(ctx context.Context, reqCtx *types.RequestCon
 //   - role: Permission role (reader, commenter, writer)
 //   - allowDiscovery: If true, file can be discovered via search
 //
+// Before creating the permission, the caller's canShare capability and any
+// Shared Drive sharing restrictions are checked so that policy conflicts
+// fail early with guidance rather than with an opaque API error.
+//
 // Returns the created permission or an error.
 //
 // Requirements:
 //   - Requirement 4.11: Support "anyone with link" sharing
 //   - Requirement 4.5: Support allowFileDiscovery for discoverability control
 func (m *Manager) CreatePublicLink(ctx context.Context, reqCtx *types.RequestContext, fileID string, role string, allowDiscovery bool) (*types.Permission, error) {
+	policy, err := m.GetLinkSharingPolicy(ctx, reqCtx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPublicLinkPolicy(policy, role); err != nil {
+		return nil, err
+	}
+
 	return m.Create(ctx, reqCtx, fileID, CreateOptions{
 		Type:               "anyone",
 		Role:               role,
@@ -399,6 +480,96 @@ func (m *Manager) CreatePublicLink(ctx context.Context, reqCtx *types.RequestCon
 	})
 }
 
+// LinkSharingPolicy describes the sharing state that decides whether a
+// public link may be created on a file. It combines the caller's
+// capabilities on the file with the restrictions of the containing
+// Shared Drive, if any.
+type LinkSharingPolicy struct {
+	FileID           string `json:"fileId"`
+	DriveID          string `json:"driveId,omitempty"`
+	CanShare         bool   `json:"canShare"`
+	DomainUsersOnly  bool   `json:"domainUsersOnly,omitempty"`
+	DriveMembersOnly bool   `json:"driveMembersOnly,omitempty"`
+}
+
+// GetLinkSharingPolicy fetches the sharing capabilities for a file and,
+// for Shared Drive files, the drive's sharing restrictions.
+//
+// Domain-wide sharing settings are not exposed by the Drive API; violations
+// of those are reported by the API on create and classified as
+// POLICY_VIOLATION with guidance.
+func (m *Manager) GetLinkSharingPolicy(ctx context.Context, reqCtx *types.RequestContext, fileID string) (*LinkSharingPolicy, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	file, err := m.client.GetFile(ctx, reqCtx, fileID, "id,driveId,capabilities(canShare)")
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &LinkSharingPolicy{
+		FileID:  file.Id,
+		DriveID: file.DriveId,
+	}
+	if file.Capabilities != nil {
+		policy.CanShare = file.Capabilities.CanShare
+	}
+
+	if file.DriveId != "" {
+		driveCall := m.client.Service().Drives.Get(file.DriveId).Fields("id,restrictions(domainUsersOnly,driveMembersOnly)")
+		sharedDrive, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Drive, error) {
+			return driveCall.Do()
+		})
+		// Non-members may be able to see a file without being able to read
+		// its drive; the API still enforces the restrictions on create.
+		if err == nil && sharedDrive.Restrictions != nil {
+			policy.DomainUsersOnly = sharedDrive.Restrictions.DomainUsersOnly
+			policy.DriveMembersOnly = sharedDrive.Restrictions.DriveMembersOnly
+		}
+	}
+
+	return policy, nil
+}
+
+// checkPublicLinkPolicy rejects public link creation that the file's
+// capabilities or Shared Drive restrictions are known to forbid, so the
+// caller gets actionable guidance instead of an opaque API error.
+func checkPublicLinkPolicy(policy *LinkSharingPolicy, role string) error {
+	if policy == nil {
+		return nil
+	}
+
+	if !policy.CanShare {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied,
+			"You do not have permission to change sharing on this file").
+			WithContext("fileId", policy.FileID).
+			WithContext("capability", "canShare").
+			WithContext("suggestedAction", "ask the owner to share the file or to allow editors to change permissions").
+			Build())
+	}
+
+	if policy.DriveMembersOnly {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeSharingRestricted,
+			fmt.Sprintf("Shared drive only allows sharing with its members; cannot create a public %s link", role)).
+			WithContext("fileId", policy.FileID).
+			WithContext("driveId", policy.DriveID).
+			WithContext("restriction", "driveMembersOnly").
+			WithContext("suggestedAction", "a shared drive manager can allow sharing with non-members in the drive's sharing settings").
+			Build())
+	}
+
+	if policy.DomainUsersOnly {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeSharingRestricted,
+			fmt.Sprintf("Shared drive only allows sharing with users in its domain; cannot create a public %s link", role)).
+			WithContext("fileId", policy.FileID).
+			WithContext("driveId", policy.DriveID).
+			WithContext("restriction", "domainUsersOnly").
+			WithContext("suggestedAction", "a shared drive manager can allow sharing outside the domain in the drive's sharing settings, if the Workspace policy permits it").
+			Build())
+	}
+
+	return nil
+}
+
 // Get retrieves a specific permission by ID.
 //
 // Parameters:
@@ -416,7 +587,7 @@ func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, fileID,
 
 	call := m.client.Service().Permissions.Get(fileID, permissionID)
 	call = call.SupportsAllDrives(true)
-	call = call.Fields("id,type,role,emailAddress,domain,displayName")
+	call = call.Fields(permissionListFields)
 
 	header := m.client.ResourceKeys().BuildHeader(reqCtx.InvolvedFileIDs)
 	if header != "" {
@@ -434,14 +605,32 @@ func (m *Manager) Get(ctx context.Context, reqCtx *types.RequestContext, fileID,
 }
 
 func convertPermission(p *drive.Permission) *types.Permission {
-	return &types.Permission{
-		ID:           p.Id,
-		Type:         p.Type,
-		Role:         p.Role,
-		EmailAddress: p.EmailAddress,
-		Domain:       p.Domain,
-		DisplayName:  p.DisplayName,
+	perm := &types.Permission{
+		ID:                 p.Id,
+		Type:               p.Type,
+		Role:               p.Role,
+		EmailAddress:       p.EmailAddress,
+		Domain:             p.Domain,
+		DisplayName:        p.DisplayName,
+		AllowFileDiscovery: p.AllowFileDiscovery,
+		Deleted:            p.Deleted,
+		PendingOwner:       p.PendingOwner,
+		ExpirationTime:     p.ExpirationTime,
+		PhotoLink:          p.PhotoLink,
+		View:               p.View,
+	}
+	for _, d := range p.PermissionDetails {
+		if d == nil {
+			continue
+		}
+		perm.PermissionDetails = append(perm.PermissionDetails, &types.PermissionAccessDetail{
+			PermissionType: d.PermissionType,
+			Role:           d.Role,
+			Inherited:      d.Inherited,
+			InheritedFrom:  d.InheritedFrom,
+		})
 	}
+	return perm
 }
 
 // AuditPublic finds all files with public access (type="anyone")
@@ -459,20 +648,21 @@ func (m *Manager) AuditPublic(ctx context.Context, reqCtx *types.RequestContext,
 
 // AuditExternal finds all files shared with external domains
 func (m *Manager) AuditExternal(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error) {
-	if opts.InternalDomain == "" {
+	internal := NewDomainMatcher(opts.InternalDomains...)
+	if internal.IsZero() {
 		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
-			"InternalDomain is required for external audit").Build())
+			"InternalDomains is required for external audit").Build())
 	}
 
 	return m.auditByQuery(ctx, reqCtx, "", opts, func(perms []*types.Permission) bool {
 		for _, p := range perms {
 			switch p.Type {
 			case "user", "group":
-				if p.EmailAddress != "" && !isInternalEmail(p.EmailAddress, opts.InternalDomain) {
+				if p.EmailAddress != "" && !internal.MatchEmail(p.EmailAddress) {
 					return true
 				}
 			case "domain":
-				if p.Domain != "" && p.Domain != opts.InternalDomain {
+				if p.Domain != "" && !internal.MatchDomain(p.Domain) {
 					return true
 				}
 			}
@@ -513,6 +703,12 @@ func (m *Manager) AuditUser(ctx context.Context, reqCtx *types.RequestContext, e
 
 // AnalyzeFolder analyzes permissions for a folder and optionally its descendants
 func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts types.AnalyzeOptions) (*types.PermissionAnalysis, error) {
+	return m.analyzeFolder(ctx, reqCtx, folderID, opts, new(int))
+}
+
+// analyzeFolder analyzes one folder; analyzed counts the items analyzed
+// across the walk, for progress
+func (m *Manager) analyzeFolder(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts types.AnalyzeOptions, analyzed *int) (*types.PermissionAnalysis, error) {
 	reqCtx.InvolvedParentIDs = append(reqCtx.InvolvedParentIDs, folderID)
 
 	filesManager := m.client.Service().Files
@@ -532,12 +728,13 @@ func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContex
 	}
 
 	analysis := &types.PermissionAnalysis{
-		FolderID:         folderID,
-		FolderName:       folder.Name,
-		Recursive:        opts.Recursive,
-		RiskDistribution: make(map[string]int),
-		PermissionTypes:  make(map[string]int),
-		RoleDistribution: make(map[string]int),
+		FolderID:               folderID,
+		FolderName:             folder.Name,
+		Recursive:              opts.Recursive,
+		DriveMembershipIgnored: opts.IgnoreDriveMembership,
+		RiskDistribution:       make(map[string]int),
+		PermissionTypes:        make(map[string]int),
+		RoleDistribution:       make(map[string]int),
 	}
 
 	query := fmt.Sprintf("'%s' in parents", folderID)
@@ -545,7 +742,7 @@ func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContex
 		query += " and trashed = false"
 	}
 
-	listCall := filesManager.List().Q(query).Fields("files(id,name,mimeType,webViewLink,createdTime,modifiedTime)")
+	listCall := filesManager.List().Q(query).Fields("files(" + auditFileFields + ")")
 	listCall = m.shaper.ShapeFilesList(listCall, reqCtx)
 
 	fileList, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.FileList, error) {
@@ -555,19 +752,40 @@ func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContex
 		return nil, err
 	}
 
+	internal := NewDomainMatcher(opts.InternalDomains...)
+	shortcuts := newShortcutTracker(opts.FollowShortcuts, fileList.Files)
 	for _, file := range fileList.Files {
-		if file.MimeType == "application/vnd.google-apps.folder" {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		*analyzed++
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions analyze", Done: *analyzed, Item: file.Name})
+		switch {
+		case file.MimeType == "application/vnd.google-apps.folder":
 			analysis.TotalFolders++
-		} else {
+		case isShortcut(file):
+			analysis.TotalShortcuts++
+		default:
 			analysis.TotalFiles++
 		}
 
-		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{})
-		if err != nil {
+		perms, skip, err := m.listAuditPermissions(ctx, reqCtx, shortcuts, file, ListOptions{AccessDetails: true})
+		if err != nil || skip {
 			continue
 		}
 
-		fileInfo := analyzeFilePermissions(file, perms, opts.InternalDomain)
+		items, members := splitDriveMembership(perms)
+		analysis.ItemGrants += len(items)
+		analysis.DriveMemberships += len(members)
+		if opts.IgnoreDriveMembership {
+			perms = items
+		}
+
+		fileInfo := analyzeFilePermissions(file, perms, internal)
+		shortcuts.annotate(fileInfo, file)
+		if !opts.IgnoreDriveMembership {
+			fileInfo.DriveMemberCount = len(members)
+		}
 
 		for _, p := range perms {
 			analysis.PermissionTypes[p.Type]++
@@ -599,7 +817,7 @@ func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContex
 			}
 		}
 
-		if opts.MaxFiles > 0 && (analysis.TotalFiles+analysis.TotalFolders) >= opts.MaxFiles {
+		if opts.MaxFiles > 0 && (analysis.TotalFiles+analysis.TotalFolders+analysis.TotalShortcuts) >= opts.MaxFiles {
 			break
 		}
 	}
@@ -613,7 +831,10 @@ func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContex
 						break
 					}
 				}
-				subAnalysis, err := m.AnalyzeFolder(ctx, reqCtx, file.Id, opts)
+				subAnalysis, err := m.analyzeFolder(ctx, reqCtx, file.Id, opts, analyzed)
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return nil, ctxErr
+				}
 				if err == nil {
 					analysis.Subfolders = append(analysis.Subfolders, subAnalysis)
 				}
@@ -625,15 +846,11 @@ func (m *Manager) AnalyzeFolder(ctx context.Context, reqCtx *types.RequestContex
 }
 
 // GenerateReport generates a detailed permission report for a file or folder
-func (m *Manager) GenerateReport(ctx context.Context, reqCtx *types.RequestContext, fileID string, internalDomain string) (*types.PermissionReport, error) {
+func (m *Manager) GenerateReport(ctx context.Context, reqCtx *types.RequestContext, fileID string, internalDomains []string) (*types.PermissionReport, error) {
+	internal := NewDomainMatcher(internalDomains...)
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
-	fileCall := m.client.Service().Files.Get(fileID).Fields("id,name,mimeType,webViewLink,createdTime,modifiedTime,owners")
-	fileCall = m.shaper.ShapeFilesGet(fileCall, reqCtx)
-
-	file, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
-		return fileCall.Do()
-	})
+	file, err := m.client.GetFile(ctx, reqCtx, fileID, "id,name,mimeType,webViewLink,createdTime,modifiedTime,owners")
 	if err != nil {
 		return nil, err
 	}
@@ -651,7 +868,7 @@ func (m *Manager) GenerateReport(ctx context.Context, reqCtx *types.RequestConte
 		WebViewLink:     file.WebViewLink,
 		CreatedTime:     file.CreatedTime,
 		ModifiedTime:    file.ModifiedTime,
-		InternalDomain:  internalDomain,
+		InternalDomains: internal.Patterns(),
 		PermissionCount: len(perms),
 		Permissions:     make([]*types.PermissionDetail, 0, len(perms)),
 	}
@@ -683,14 +900,14 @@ func (m *Manager) GenerateReport(ctx context.Context, reqCtx *types.RequestConte
 			report.HasAnyoneWithLink = true
 			detail.RiskLevel = types.RiskLevelCritical
 			riskScore += 40
-		} else if p.Type == "domain" && p.Domain != internalDomain {
+		} else if p.Type == "domain" && !internal.MatchDomain(p.Domain) {
 			detail.IsExternal = true
 			report.HasExternalAccess = true
 			externalDomains[p.Domain] = true
 			detail.RiskLevel = types.RiskLevelHigh
 			riskScore += 20
 		} else if (p.Type == "user" || p.Type == "group") && p.EmailAddress != "" {
-			if !isInternalEmail(p.EmailAddress, internalDomain) {
+			if !isInternalEmail(p.EmailAddress, internal) {
 				detail.IsExternal = true
 				report.HasExternalAccess = true
 				domain := extractDomain(p.EmailAddress)
@@ -770,20 +987,17 @@ func (m *Manager) BulkRemovePublic(ctx context.Context, reqCtx *types.RequestCon
 	}
 
 	result.TotalFiles = len(files)
+	runner := m.newBulkRunner(reqCtx, opts, result)
 
 	for _, file := range files {
+		if err := runner.next(ctx, "permissions bulk remove-public", file.Name); err != nil {
+			return result, err
+		}
 		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{})
 		if err != nil {
-			result.FailureCount++
-			result.FailedFiles = append(result.FailedFiles, &types.BulkOperationItem{
-				FileID:       file.Id,
-				FileName:     file.Name,
-				Operation:    "remove_public",
-				Status:       "failure",
-				ErrorMessage: err.Error(),
-			})
-			if !opts.ContinueOnError {
-				return result, err
+			item := &types.BulkOperationItem{FileID: file.Id, FileName: file.Name, Operation: "remove_public"}
+			if stop := runner.fail(ctx, item, err); stop != nil {
+				return result, stop
 			}
 			continue
 		}
@@ -792,37 +1006,21 @@ func (m *Manager) BulkRemovePublic(ctx context.Context, reqCtx *types.RequestCon
 		for _, p := range perms {
 			if p.Type == "anyone" {
 				hasPublic = true
-				if opts.DryRun {
-					result.SuccessCount++
-					result.SuccessfulFiles = append(result.SuccessfulFiles, &types.BulkOperationItem{
-						FileID:    file.Id,
-						FileName:  file.Name,
-						Operation: "remove_public",
-						Status:    "success",
-					})
-				} else {
-					err := m.Delete(ctx, reqCtx, file.Id, p.ID, DeleteOptions{})
-					if err != nil {
-						result.FailureCount++
-						result.FailedFiles = append(result.FailedFiles, &types.BulkOperationItem{
-							FileID:       file.Id,
-							FileName:     file.Name,
-							Operation:    "remove_public",
-							Status:       "failure",
-							ErrorMessage: err.Error(),
-						})
-						if !opts.ContinueOnError {
-							return result, err
-						}
-					} else {
-						result.SuccessCount++
-						result.SuccessfulFiles = append(result.SuccessfulFiles, &types.BulkOperationItem{
-							FileID:    file.Id,
-							FileName:  file.Name,
-							Operation: "remove_public",
-							Status:    "success",
-						})
-					}
+				fileID, permID := file.Id, p.ID
+				change := bulkChange{
+					item: &types.BulkOperationItem{
+						FileID:       fileID,
+						FileName:     file.Name,
+						PermissionID: permID,
+						Operation:    "remove_public",
+					},
+					call: m.deleteCall(fileID, permID),
+					do: func() error {
+						return m.Delete(ctx, reqCtx, fileID, permID, DeleteOptions{})
+					},
+				}
+				if stop := runner.add(ctx, change); stop != nil {
+					return result, stop
 				}
 				break
 			}
@@ -839,9 +1037,46 @@ func (m *Manager) BulkRemovePublic(ctx context.Context, reqCtx *types.RequestCon
 		}
 	}
 
+	if stop := runner.flush(ctx); stop != nil {
+		return result, stop
+	}
 	return result, nil
 }
 
+// newErrorBudget returns the error budget for a bulk run, or nil when no
+// maximum error rate is configured.
+func newErrorBudget(opts types.BulkOptions) *safety.ErrorBudget {
+	if opts.MaxErrorRate <= 0 {
+		return nil
+	}
+	return safety.NewErrorBudget(opts.MaxErrorRate, safety.DefaultErrorBudgetWindow)
+}
+
+// recordBulkOutcome feeds an operation outcome into the error budget and
+// returns an error once the rolling failure rate exceeds it. The result is
+// marked as aborted so callers can report the partial progress.
+func recordBulkOutcome(budget *safety.ErrorBudget, result *types.BulkOperationResult, failed bool) error {
+	if budget == nil {
+		return nil
+	}
+	budget.Record(failed)
+	if !failed || !budget.Exceeded() {
+		return nil
+	}
+
+	result.Aborted = true
+	result.AbortReason = fmt.Sprintf("failure rate %.1f%% over the last %d operations exceeded the maximum of %.1f%%",
+		budget.Rate()*100, budget.Samples(), budget.MaxRate()*100)
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeBatchPartialFailure,
+		"Bulk operation aborted: "+result.AbortReason).
+		WithContext("failureRate", budget.Rate()).
+		WithContext("maxErrorRate", budget.MaxRate()).
+		WithContext("successCount", result.SuccessCount).
+		WithContext("failureCount", result.FailureCount).
+		WithContext("suggestedAction", "check authentication scopes, quota, and access to the folder before retrying").
+		Build())
+}
+
 // BulkUpdateRole updates permissions from one role to another in a folder
 func (m *Manager) BulkUpdateRole(ctx context.Context, reqCtx *types.RequestContext, fromRole, toRole string, opts types.BulkOptions) (*types.BulkOperationResult, error) {
 	if opts.FolderID == "" {
@@ -854,6 +1089,10 @@ func (m *Manager) BulkUpdateRole(ctx context.Context, reqCtx *types.RequestConte
 			"Both fromRole and toRole are required").Build())
 	}
 
+	if _, err := CheckRoleChange(fromRole, toRole, opts.RoleDirection); err != nil {
+		return nil, err
+	}
+
 	result := &types.BulkOperationResult{
 		DryRun: opts.DryRun,
 	}
@@ -868,65 +1107,47 @@ func (m *Manager) BulkUpdateRole(ctx context.Context, reqCtx *types.RequestConte
 	}
 
 	result.TotalFiles = len(files)
+	runner := m.newBulkRunner(reqCtx, opts, result)
 
 	for _, file := range files {
+		if err := runner.next(ctx, "permissions bulk update-role", file.Name); err != nil {
+			return result, err
+		}
 		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{})
 		if err != nil {
-			result.FailureCount++
-			result.FailedFiles = append(result.FailedFiles, &types.BulkOperationItem{
-				FileID:       file.Id,
-				FileName:     file.Name,
-				Operation:    "update_role",
-				Status:       "failure",
-				ErrorMessage: err.Error(),
-			})
-			if !opts.ContinueOnError {
-				return result, err
+			item := &types.BulkOperationItem{FileID: file.Id, FileName: file.Name, Operation: "update_role"}
+			if stop := runner.fail(ctx, item, err); stop != nil {
+				return result, stop
 			}
 			continue
 		}
 
-		updated := false
+		matched := false
 		for _, p := range perms {
-			if p.Role == fromRole {
-				if opts.DryRun {
-					result.SuccessCount++
-					result.SuccessfulFiles = append(result.SuccessfulFiles, &types.BulkOperationItem{
-						FileID:    file.Id,
-						FileName:  file.Name,
-						Operation: "update_role",
-						Status:    "success",
-					})
-					updated = true
-				} else {
-					_, err := m.Update(ctx, reqCtx, file.Id, p.ID, UpdateOptions{Role: toRole})
-					if err != nil {
-						result.FailureCount++
-						result.FailedFiles = append(result.FailedFiles, &types.BulkOperationItem{
-							FileID:       file.Id,
-							FileName:     file.Name,
-							Operation:    "update_role",
-							Status:       "failure",
-							ErrorMessage: err.Error(),
-						})
-						if !opts.ContinueOnError {
-							return result, err
-						}
-					} else {
-						result.SuccessCount++
-						result.SuccessfulFiles = append(result.SuccessfulFiles, &types.BulkOperationItem{
-							FileID:    file.Id,
-							FileName:  file.Name,
-							Operation: "update_role",
-							Status:    "success",
-						})
-						updated = true
-					}
-				}
+			if p.Role != fromRole {
+				continue
+			}
+			matched = true
+			fileID, permID := file.Id, p.ID
+			change := bulkChange{
+				item: &types.BulkOperationItem{
+					FileID:       fileID,
+					FileName:     file.Name,
+					PermissionID: permID,
+					Operation:    "update_role",
+				},
+				call: m.updateRoleCall(fileID, permID, toRole),
+				do: func() error {
+					_, err := m.Update(ctx, reqCtx, fileID, permID, UpdateOptions{Role: toRole})
+					return err
+				},
+			}
+			if stop := runner.add(ctx, change); stop != nil {
+				return result, stop
 			}
 		}
 
-		if !updated {
+		if !matched {
 			result.SkippedCount++
 			result.SkippedFiles = append(result.SkippedFiles, &types.BulkOperationItem{
 				FileID:    file.Id,
@@ -937,6 +1158,87 @@ func (m *Manager) BulkUpdateRole(ctx context.Context, reqCtx *types.RequestConte
 		}
 	}
 
+	if stop := runner.flush(ctx); stop != nil {
+		return result, stop
+	}
+	return result, nil
+}
+
+// BulkShare grants the permission described by share on every file in a
+// folder. When share has a MessageTemplate, the notification message is
+// rendered for each file; in a dry run the rendered message is included in
+// the result so it can be reviewed before sending.
+func (m *Manager) BulkShare(ctx context.Context, reqCtx *types.RequestContext, share CreateOptions, opts types.BulkOptions) (*types.BulkOperationResult, error) {
+	if opts.FolderID == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"FolderID is required for bulk operations").Build())
+	}
+
+	result := &types.BulkOperationResult{
+		DryRun: opts.DryRun,
+	}
+
+	files, err := m.findFilesInFolder(ctx, reqCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxFiles > 0 && len(files) > opts.MaxFiles {
+		files = files[:opts.MaxFiles]
+	}
+
+	var granter *drive.User
+	if share.MessageTemplate != nil {
+		granter, err = m.currentUser(ctx, reqCtx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.TotalFiles = len(files)
+	runner := m.newBulkRunner(reqCtx, opts, result)
+
+	for _, file := range files {
+		if err := runner.next(ctx, "permissions bulk share", file.Name); err != nil {
+			return result, err
+		}
+		grant := share
+		grant.MessageTemplate = nil
+		item := &types.BulkOperationItem{
+			FileID:    file.Id,
+			FileName:  file.Name,
+			Operation: "share",
+		}
+
+		if share.MessageTemplate != nil && share.SendNotificationEmail {
+			message, err := share.MessageTemplate.Render(messageVars(file, granter, share))
+			if err != nil {
+				if stop := runner.fail(ctx, item, err); stop != nil {
+					return result, stop
+				}
+				continue
+			}
+			grant.EmailMessage = message
+			item.Message = message
+		}
+
+		fileID := file.Id
+		change := bulkChange{
+			item: item,
+			call: m.createCall(reqCtx, fileID, grant),
+			do: func() error {
+				_, err := m.Create(ctx, reqCtx, fileID, grant)
+				return err
+			},
+		}
+		if stop := runner.add(ctx, change); stop != nil {
+			return result, stop
+		}
+	}
+
+	if stop := runner.flush(ctx); stop != nil {
+		return result, stop
+	}
 	return result, nil
 }
 
@@ -991,6 +1293,14 @@ func (m *Manager) SearchByRole(ctx context.Context, reqCtx *types.RequestContext
 
 func (m *Manager) auditByQuery(ctx context.Context, reqCtx *types.RequestContext, baseQuery string, opts types.AuditOptions, filter func([]*types.Permission) bool) (*types.AuditResult, error) {
 	query := baseQuery
+	if query != "" {
+		// Shortcuts don't carry their target's visibility, so include them
+		// when following so the filter can check the target's permissions
+		if opts.FollowShortcuts {
+			query += fmt.Sprintf(" or mimeType = '%s'", utils.MimeTypeShortcut)
+		}
+		query = "(" + query + ")"
+	}
 	if opts.FolderID != "" {
 		if query != "" {
 			query += " and "
@@ -1022,7 +1332,7 @@ func (m *Manager) auditByQuery(ctx context.Context, reqCtx *types.RequestContext
 	if query != "" {
 		listCall = listCall.Q(query)
 	}
-	listCall = listCall.Fields("files(id,name,mimeType,webViewLink,createdTime,modifiedTime)")
+	listCall = listCall.Fields("files(" + auditFileFields + ")")
 	if opts.PageSize > 0 {
 		listCall = listCall.PageSize(int64(opts.PageSize))
 	}
@@ -1042,9 +1352,15 @@ func (m *Manager) auditByQuery(ctx context.Context, reqCtx *types.RequestContext
 		Summary: make(map[string]int),
 	}
 
-	for _, file := range fileList.Files {
-		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{})
-		if err != nil {
+	internal := NewDomainMatcher(opts.InternalDomains...)
+	shortcuts := newShortcutTracker(opts.FollowShortcuts, fileList.Files)
+	for i, file := range fileList.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions audit", Done: i + 1, Total: len(fileList.Files), Item: file.Name})
+		perms, skip, err := m.listAuditPermissions(ctx, reqCtx, shortcuts, file, ListOptions{})
+		if err != nil || skip {
 			continue
 		}
 
@@ -1063,7 +1379,8 @@ func (m *Manager) auditByQuery(ctx context.Context, reqCtx *types.RequestContext
 				fileInfo.Permissions = perms
 			}
 
-			fileInfo = analyzeFilePermissions(file, perms, opts.InternalDomain)
+			fileInfo = analyzeFilePermissions(file, perms, internal)
+			shortcuts.annotate(fileInfo, file)
 			result.Files = append(result.Files, fileInfo)
 			result.Summary[fileInfo.RiskLevel]++
 		}
@@ -1102,24 +1419,41 @@ func (m *Manager) findFilesInFolder(ctx context.Context, reqCtx *types.RequestCo
 		query += " and " + opts.Query
 	}
 
-	listCall := m.client.Service().Files.List().Q(query).Fields("files(id,name,mimeType)")
-	listCall = m.shaper.ShapeFilesList(listCall, reqCtx)
+	var files []*drive.File
+	pageToken := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		listCall := m.client.Service().Files.List().Q(query).Fields("nextPageToken,files(id,name,mimeType,webViewLink)")
+		listCall = m.shaper.ShapeFilesList(listCall, reqCtx)
+		if pageToken != "" {
+			listCall = listCall.PageToken(pageToken)
+		}
 
-	fileList, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.FileList, error) {
-		return listCall.Do()
-	})
-	if err != nil {
-		return nil, err
-	}
+		fileList, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.FileList, error) {
+			return listCall.Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fileList.Files...)
 
-	files := fileList.Files
+		pageToken = fileList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
 
 	if opts.Recursive {
-		for _, file := range fileList.Files {
+		for _, file := range files {
 			if file.MimeType == "application/vnd.google-apps.folder" {
 				subOpts := opts
 				subOpts.FolderID = file.Id
 				subFiles, err := m.findFilesInFolder(ctx, reqCtx, subOpts)
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return nil, ctxErr
+				}
 				if err == nil {
 					files = append(files, subFiles...)
 				}
@@ -1130,7 +1464,20 @@ func (m *Manager) findFilesInFolder(ctx context.Context, reqCtx *types.RequestCo
 	return files, nil
 }
 
-func analyzeFilePermissions(file *drive.File, perms []*types.Permission, internalDomain string) *types.FilePermissionInfo {
+// splitDriveMembership separates grants on an item from permissions it
+// only has through Shared Drive membership
+func splitDriveMembership(perms []*types.Permission) (items, members []*types.Permission) {
+	for _, p := range perms {
+		if p.IsDriveMembership() {
+			members = append(members, p)
+		} else {
+			items = append(items, p)
+		}
+	}
+	return items, members
+}
+
+func analyzeFilePermissions(file *drive.File, perms []*types.Permission, internal *DomainMatcher) *types.FilePermissionInfo {
 	info := &types.FilePermissionInfo{
 		FileID:          file.Id,
 		FileName:        file.Name,
@@ -1153,13 +1500,13 @@ func analyzeFilePermissions(file *drive.File, perms []*types.Permission, interna
 			info.HasAnyoneWithLink = true
 			info.RiskReasons = append(info.RiskReasons, "Public access enabled")
 			riskScore += 40
-		} else if p.Type == "domain" && p.Domain != internalDomain {
+		} else if p.Type == "domain" && !internal.MatchDomain(p.Domain) {
 			info.HasExternalAccess = true
 			externalDomains[p.Domain] = true
 			info.RiskReasons = append(info.RiskReasons, fmt.Sprintf("Shared with external domain: %s", p.Domain))
 			riskScore += 20
 		} else if (p.Type == "user" || p.Type == "group") && p.EmailAddress != "" {
-			if !isInternalEmail(p.EmailAddress, internalDomain) {
+			if !isInternalEmail(p.EmailAddress, internal) {
 				info.HasExternalAccess = true
 				domain := extractDomain(p.EmailAddress)
 				if domain != "" {
@@ -1194,12 +1541,13 @@ func analyzeFilePermissions(file *drive.File, perms []*types.Permission, interna
 	return info
 }
 
-func isInternalEmail(email, internalDomain string) bool {
-	if internalDomain == "" {
+// isInternalEmail reports whether email is internal. With no internal
+// domains configured every address is treated as internal.
+func isInternalEmail(email string, internal *DomainMatcher) bool {
+	if internal.IsZero() {
 		return true
 	}
-	domain := extractDomain(email)
-	return domain == internalDomain
+	return internal.MatchEmail(email)
 }
 
 func extractDomain(email string) string {
@@ -1,10 +1,16 @@
 package permissions
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
 	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/googleapi"
 )
@@ -536,6 +542,64 @@ func TestCreatePublicLink(t *testing.T) {
 	}
 }
 
+func TestCheckPublicLinkPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *LinkSharingPolicy
+		wantCode string
+	}{
+		{
+			name:   "nil policy allowed",
+			policy: nil,
+		},
+		{
+			name:   "can share in my drive",
+			policy: &LinkSharingPolicy{FileID: "file123", CanShare: true},
+		},
+		{
+			name:     "cannot share",
+			policy:   &LinkSharingPolicy{FileID: "file123", CanShare: false},
+			wantCode: utils.ErrCodePermissionDenied,
+		},
+		{
+			name:     "shared drive domain users only",
+			policy:   &LinkSharingPolicy{FileID: "file123", DriveID: "drive1", CanShare: true, DomainUsersOnly: true},
+			wantCode: utils.ErrCodeSharingRestricted,
+		},
+		{
+			name:     "shared drive members only",
+			policy:   &LinkSharingPolicy{FileID: "file123", DriveID: "drive1", CanShare: true, DriveMembersOnly: true},
+			wantCode: utils.ErrCodeSharingRestricted,
+		},
+		{
+			name:   "shared drive without restrictions",
+			policy: &LinkSharingPolicy{FileID: "file123", DriveID: "drive1", CanShare: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPublicLinkPolicy(tt.policy, "writer")
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			appErr, ok := err.(*utils.AppError)
+			if !ok {
+				t.Fatalf("expected *utils.AppError, got %T", err)
+			}
+			if appErr.CLIError.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", appErr.CLIError.Code, tt.wantCode)
+			}
+			if appErr.CLIError.Context["suggestedAction"] == nil {
+				t.Error("Expected suggestedAction in context")
+			}
+		})
+	}
+}
+
 // Test error handling for policy violations
 func TestPolicyViolationErrors(t *testing.T) {
 	tests := []struct {
@@ -1341,7 +1405,7 @@ func TestIsInternalEmail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isInternalEmail(tt.email, tt.internalDomain)
+			result := isInternalEmail(tt.email, NewDomainMatcher(tt.internalDomain))
 			if result != tt.expected {
 				t.Errorf("isInternalEmail(%q, %q) = %v, want %v", tt.email, tt.internalDomain, result, tt.expected)
 			}
@@ -1483,7 +1547,7 @@ func TestAnalyzeFilePermissions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := analyzeFilePermissions(tt.file, tt.perms, tt.internalDomain)
+			result := analyzeFilePermissions(tt.file, tt.perms, NewDomainMatcher(tt.internalDomain))
 			if err := tt.validate(result); err != nil {
 				t.Error(err)
 			}
@@ -1502,3 +1566,114 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+func TestRecordBulkOutcome_AbortsWhenBudgetExceeded(t *testing.T) {
+	if err := recordBulkOutcome(nil, &types.BulkOperationResult{}, true); err != nil {
+		t.Fatalf("nil budget should never abort: %v", err)
+	}
+
+	budget := newErrorBudget(types.BulkOptions{MaxErrorRate: 0.05})
+	result := &types.BulkOperationResult{}
+	var err error
+	for i := 0; i < 20 && err == nil; i++ {
+		err = recordBulkOutcome(budget, result, true)
+	}
+	if err == nil {
+		t.Fatal("expected abort after consecutive failures")
+	}
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeBatchPartialFailure {
+		t.Fatalf("expected BATCH_PARTIAL_FAILURE, got %v", err)
+	}
+	if !result.Aborted || result.AbortReason == "" {
+		t.Error("result should be marked as aborted")
+	}
+}
+
+func TestNewErrorBudget_Disabled(t *testing.T) {
+	if newErrorBudget(types.BulkOptions{}) != nil {
+		t.Error("budget should be nil without MaxErrorRate")
+	}
+}
+
+func TestBulkRunnerRecord_MaxErrorRateContinuesPastFailures(t *testing.T) {
+	failure := utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied, "denied").Build())
+
+	stops := &bulkRunner{opts: types.BulkOptions{}, result: &types.BulkOperationResult{}}
+	if err := stops.record(&types.BulkOperationItem{FileID: "f1"}, failure); err == nil {
+		t.Error("without --continue-on-error or --max-error-rate the first failure should stop the run")
+	}
+
+	// --max-error-rate alone keeps going until the budget is exceeded
+	opts := types.BulkOptions{MaxErrorRate: 0.5}
+	r := &bulkRunner{opts: opts, result: &types.BulkOperationResult{}, budget: newErrorBudget(opts)}
+	var err error
+	n := 0
+	for ; n < 100 && err == nil; n++ {
+		err = r.record(&types.BulkOperationItem{FileID: "f"}, failure)
+	}
+	if err == nil || !r.result.Aborted {
+		t.Fatalf("err = %v, want the run aborted by the error budget", err)
+	}
+	if n < 2 {
+		t.Errorf("aborted after %d failures, want the first failures tolerated", n)
+	}
+}
+
+// cancelAfter cancels its context once it has seen n progress events
+type cancelAfter struct {
+	n      int
+	cancel context.CancelFunc
+	events int
+}
+
+func (c *cancelAfter) Progress(types.ProgressEvent) {
+	c.events++
+	if c.events == c.n {
+		c.cancel()
+	}
+}
+
+func TestAuditAndAnalyze_StopWhenCancelled(t *testing.T) {
+	var listed []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/drive/v3/files":
+			_, _ = w.Write([]byte(`{"files":[{"id":"a","name":"a"},{"id":"b","name":"b"},{"id":"c","name":"c"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/permissions"):
+			listed = append(listed, strings.Split(r.URL.Path, "/")[4])
+			_, _ = w.Write([]byte(`{"permissions":[{"id":"p","type":"anyone","role":"reader"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"root","name":"Root"}`))
+		}
+	}))
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	runs := map[string]func(ctx context.Context) error{
+		"audit": func(ctx context.Context) error {
+			_, err := mgr.AuditPublic(ctx, reqCtx, types.AuditOptions{})
+			return err
+		},
+		"analyze": func(ctx context.Context) error {
+			_, err := mgr.AnalyzeFolder(ctx, reqCtx, "root", types.AnalyzeOptions{})
+			return err
+		},
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			listed = nil
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			progress := &cancelAfter{n: 2, cancel: cancel}
+			mgr.SetProgress(progress)
+
+			if err := run(ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want context.Canceled", err)
+			}
+			if progress.events != 2 || len(listed) == 0 || len(listed) > 2 || listed[len(listed)-1] == "c" {
+				t.Errorf("%d progress events, permissions listed for %v; want the run to stop before c", progress.events, listed)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package permissions
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Manifest row outcomes
+const (
+	ManifestCreate    = "create"
+	ManifestUpdate    = "update"
+	ManifestUnchanged = "unchanged"
+
+	ManifestApplied = "applied"
+	ManifestPlanned = "planned"
+	ManifestSkipped = "skipped"
+	ManifestFailed  = "failed"
+)
+
+// ManifestRow is one grant in a permissions manifest. The file is named by
+// FileID or by Path; Email is used for user and group grants and Domain for
+// domain grants.
+type ManifestRow struct {
+	Row    int    `json:"-"` // 1-based position among the manifest's rows
+	FileID string `json:"fileId,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Type   string `json:"type"`
+	Role   string `json:"role"`
+	Email  string `json:"email,omitempty"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// manifestColumns maps normalized CSV header names to row fields
+var manifestColumns = map[string]func(*ManifestRow) *string{
+	"fileid":       func(r *ManifestRow) *string { return &r.FileID },
+	"id":           func(r *ManifestRow) *string { return &r.FileID },
+	"path":         func(r *ManifestRow) *string { return &r.Path },
+	"type":         func(r *ManifestRow) *string { return &r.Type },
+	"role":         func(r *ManifestRow) *string { return &r.Role },
+	"email":        func(r *ManifestRow) *string { return &r.Email },
+	"emailaddress": func(r *ManifestRow) *string { return &r.Email },
+	"domain":       func(r *ManifestRow) *string { return &r.Domain },
+}
+
+// LoadManifest reads a manifest file, choosing CSV or JSON by extension
+func LoadManifest(path string) ([]*ManifestRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidPath,
+			fmt.Sprintf("Failed to open manifest: %s", err)).Build())
+	}
+	defer f.Close()
+
+	var rows []*ManifestRow
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		rows, err = ParseManifestCSV(f)
+	case ".json":
+		rows, err = ParseManifestJSON(f)
+	default:
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Unknown manifest format %q: use a .csv or .json file", ext)).Build())
+	}
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid manifest %s: %s", path, err)).Build())
+	}
+	return rows, nil
+}
+
+// ParseManifestCSV parses a CSV manifest. The header names the columns:
+// file_id (or id) and/or path, type, role, email and domain, in any order
+// and case.
+func ParseManifestCSV(r io.Reader) ([]*ManifestRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("empty manifest")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]func(*ManifestRow) *string, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		key := strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		field, ok := manifestColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (file_id, path, type, role, email, domain)", name)
+		}
+		fields[i] = field
+		seen[key] = true
+	}
+	if !seen["role"] {
+		return nil, fmt.Errorf("missing role column")
+	}
+	if !seen["fileid"] && !seen["id"] && !seen["path"] {
+		return nil, fmt.Errorf("missing file_id or path column")
+	}
+
+	var rows []*ManifestRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := &ManifestRow{Row: len(rows) + 1}
+		for i, value := range record {
+			*fields[i](row) = strings.TrimSpace(value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ParseManifestJSON parses a JSON manifest: an array of objects with
+// fileId and/or path, type, role, email and domain
+func ParseManifestJSON(r io.Reader) ([]*ManifestRow, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var rows []*ManifestRow
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		if row == nil {
+			return nil, fmt.Errorf("row %d is null", i+1)
+		}
+		row.Row = i + 1
+	}
+	return rows, nil
+}
+
+// ManifestRowResult reports what applying one manifest row did
+type ManifestRowResult struct {
+	Row          int    `json:"row"`
+	File         string `json:"file"` // The file ID or path from the manifest
+	FileID       string `json:"fileId,omitempty"`
+	Type         string `json:"type"`
+	Principal    string `json:"principal"`
+	Role         string `json:"role"`
+	FromRole     string `json:"fromRole,omitempty"`
+	Action       string `json:"action,omitempty"` // create, update, unchanged
+	Status       string `json:"status"`           // applied, planned, skipped, failed
+	PermissionID string `json:"permissionId,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ManifestResult reports a manifest run row by row
+type ManifestResult struct {
+	Total     int                  `json:"total"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Failed    int                  `json:"failed"`
+	DryRun    bool                 `json:"dryRun,omitempty"`
+	Items     []*ManifestRowResult `json:"rows"`
+}
+
+func (r *ManifestResult) Headers() []string {
+	return []string{"Row", "File", "Grantee", "Role", "Action", "Status"}
+}
+
+func (r *ManifestResult) Rows() [][]string {
+	rows := make([][]string, len(r.Items))
+	for i, item := range r.Items {
+		role := item.Role
+		if item.FromRole != "" {
+			role = item.FromRole + " → " + item.Role
+		}
+		status := item.Status
+		if item.Error != "" {
+			status += ": " + item.Error
+		}
+		rows[i] = []string{fmt.Sprint(item.Row), item.File, item.Type + " " + item.Principal, role, item.Action, status}
+	}
+	return rows
+}
+
+func (r *ManifestResult) EmptyMessage() string {
+	return "Manifest has no rows"
+}
+
+// ManifestOptions configures ApplyManifest
+type ManifestOptions struct {
+	SendNotificationEmail bool // Notify users and groups given new grants
+	UseDomainAdminAccess  bool
+	DryRun                bool // Work out each row's action without applying it
+
+	// Resolve turns a manifest path into a file ID. Rows with a path fail
+	// when it is nil.
+	Resolve func(ctx context.Context, path string) (string, error)
+}
+
+// ApplyManifest creates or updates the grant each row describes. A grantee
+// that already has the row's role is left alone, and one with a different
+// role is updated in place. Every row is attempted and reported; rows that
+// fail do not stop the run.
+func (m *Manager) ApplyManifest(ctx context.Context, reqCtx *types.RequestContext, rows []*ManifestRow, opts ManifestOptions) (*ManifestResult, error) {
+	result := &ManifestResult{Total: len(rows), DryRun: opts.DryRun, Items: make([]*ManifestRowResult, 0, len(rows))}
+	// Permissions are listed once per file and kept current as rows apply,
+	// so repeated rows for a grantee see the earlier rows' changes
+	perms := map[string][]*types.Permission{}
+
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		item := m.applyManifestRow(ctx, reqCtx, row, perms, opts)
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions apply", Done: i + 1, Total: len(rows), Item: item.File})
+		switch {
+		case item.Status == ManifestFailed:
+			result.Failed++
+		case item.Action == ManifestCreate:
+			result.Created++
+		case item.Action == ManifestUpdate:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+func (m *Manager) applyManifestRow(ctx context.Context, reqCtx *types.RequestContext, row *ManifestRow, perms map[string][]*types.Permission, opts ManifestOptions) *ManifestRowResult {
+	item := &ManifestRowResult{Row: row.Row, File: row.FileID, FileID: row.FileID, Type: row.Type, Role: row.Role}
+	if item.File == "" {
+		item.File = row.Path
+	}
+	fail := func(err error) *ManifestRowResult {
+		item.Status = ManifestFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	principal, err := validateManifestRow(row)
+	if err != nil {
+		return fail(err)
+	}
+	item.Type, item.Principal = row.grantType(), principal
+
+	if item.FileID == "" {
+		if opts.Resolve == nil {
+			return fail(fmt.Errorf("cannot resolve path %q", row.Path))
+		}
+		if item.FileID, err = opts.Resolve(ctx, row.Path); err != nil {
+			return fail(err)
+		}
+	}
+
+	current, ok := perms[item.FileID]
+	if !ok {
+		current, err = m.List(ctx, reqCtx, item.FileID, ListOptions{UseDomainAdminAccess: opts.UseDomainAdminAccess})
+		if err != nil {
+			return fail(err)
+		}
+		perms[item.FileID] = current
+	}
+
+	var existing *types.Permission
+	for _, p := range current {
+		if p.Type == item.Type && strings.EqualFold(principalOf(p), principal) {
+			existing = p
+			break
+		}
+	}
+	switch {
+	case existing == nil:
+		item.Action = ManifestCreate
+	case existing.Role == row.Role:
+		item.Action, item.Status, item.PermissionID = ManifestUnchanged, ManifestSkipped, existing.ID
+		return item
+	default:
+		item.Action, item.FromRole, item.PermissionID = ManifestUpdate, existing.Role, existing.ID
+	}
+	item.Status = ManifestPlanned
+	if !opts.DryRun {
+		if existing != nil {
+			_, err = m.Update(ctx, reqCtx, item.FileID, existing.ID, UpdateOptions{
+				Role:                 row.Role,
+				UseDomainAdminAccess: opts.UseDomainAdminAccess,
+			})
+		} else {
+			var created *types.Permission
+			created, err = m.Create(ctx, reqCtx, item.FileID, CreateOptions{
+				Type:                  item.Type,
+				Role:                  row.Role,
+				EmailAddress:          row.Email,
+				Domain:                row.Domain,
+				SendNotificationEmail: opts.SendNotificationEmail,
+				UseDomainAdminAccess:  opts.UseDomainAdminAccess,
+			})
+			if created != nil {
+				item.PermissionID = created.ID
+			}
+		}
+		if err != nil {
+			return fail(err)
+		}
+		item.Status = ManifestApplied
+	}
+
+	// Record the grant as applied, or as it would be on a dry run
+	if existing != nil {
+		existing.Role = row.Role
+	} else {
+		perms[item.FileID] = append(current, &types.Permission{ID: item.PermissionID, Type: item.Type,
+			Role: row.Role, EmailAddress: row.Email, Domain: row.Domain})
+	}
+	return item
+}
+
+// grantType is the row's type, defaulting to user when only an email is
+// given and to domain when only a domain is given
+func (row *ManifestRow) grantType() string {
+	switch {
+	case row.Type != "":
+		return strings.ToLower(row.Type)
+	case row.Email != "":
+		return types.PermissionTypeUser
+	case row.Domain != "":
+		return types.PermissionTypeDomain
+	}
+	return ""
+}
+
+// validateManifestRow checks a row and returns the grantee it names
+func validateManifestRow(row *ManifestRow) (string, error) {
+	if row.FileID == "" && row.Path == "" {
+		return "", fmt.Errorf("row needs a file ID or path")
+	}
+	if err := checkEditableRole(row.Role); err != nil {
+		return "", err
+	}
+	switch permType := row.grantType(); permType {
+	case types.PermissionTypeUser, types.PermissionTypeGroup:
+		if !strings.Contains(row.Email, "@") {
+			return "", fmt.Errorf("%s grants need an email address", permType)
+		}
+		return row.Email, nil
+	case types.PermissionTypeDomain:
+		if row.Domain == "" || strings.Contains(row.Domain, "@") {
+			return "", fmt.Errorf("domain grants need a domain name")
+		}
+		return row.Domain, nil
+	case types.PermissionTypeAnyone:
+		return types.PermissionTypeAnyone, nil
+	case "":
+		return "", fmt.Errorf("row needs a type (user, group, domain, anyone)")
+	default:
+		return "", fmt.Errorf("unknown grant type %q (user, group, domain, anyone)", permType)
+	}
+}
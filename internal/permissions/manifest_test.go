@@ -0,0 +1,122 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestParseManifestCSV(t *testing.T) {
+	rows, err := ParseManifestCSV(strings.NewReader("File_ID, Path ,type,role,Email,domain\n" +
+		"f1,,user,writer,alice@example.com,\n" +
+		",/Finance/Q3.xlsx,domain,reader,,example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ManifestRow{
+		{Row: 1, FileID: "f1", Type: "user", Role: "writer", Email: "alice@example.com"},
+		{Row: 2, Path: "/Finance/Q3.xlsx", Type: "domain", Role: "reader", Domain: "example.com"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v", rows)
+	}
+
+	for _, bad := range []string{"", "file_id,type,role,owner\n", "type,role\nuser,reader\n", "file_id,type\nf1,user\n"} {
+		if _, err := ParseManifestCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestParseManifestJSON(t *testing.T) {
+	rows, err := ParseManifestJSON(strings.NewReader(`[{"fileId":"f1","role":"reader","email":"bob@example.com"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Row != 1 || rows[0].grantType() != "user" {
+		t.Errorf("rows = %+v", rows)
+	}
+	if _, err := ParseManifestJSON(strings.NewReader(`[{"fileId":"f1","rol":"reader"}]`)); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestApplyManifest(t *testing.T) {
+	var calls []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/file1/permissions":
+			_, _ = w.Write([]byte(`{"permissions":[
+				{"id":"p1","type":"user","role":"writer","emailAddress":"Alice@example.com"},
+				{"id":"p2","type":"domain","role":"reader","domain":"example.com"}]}`))
+			return
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"File not found"}}`))
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"id":"new","type":"user","role":"reader"}`))
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	rows := []*ManifestRow{
+		{Row: 1, FileID: "file1", Type: "user", Role: "reader", Email: "alice@example.com"},
+		{Row: 2, FileID: "file1", Type: "domain", Role: "reader", Domain: "example.com"},
+		{Row: 3, Path: "/Docs/plan.txt", Type: "user", Role: "commenter", Email: "bob@example.com"},
+		{Row: 4, Path: "/Docs/plan.txt", Type: "user", Role: "commenter", Email: "bob@example.com"},
+		{Row: 5, FileID: "file1", Type: "user", Role: "owner", Email: "carol@example.com"},
+		{Row: 6, FileID: "missing", Type: "anyone", Role: "reader"},
+	}
+	resolve := func(ctx context.Context, path string) (string, error) {
+		if path == "/Docs/plan.txt" {
+			return "file1", nil
+		}
+		return "", fmt.Errorf("not found: %s", path)
+	}
+
+	plan, err := mgr.ApplyManifest(ctx, reqCtx, rows, ManifestOptions{DryRun: true, Resolve: resolve})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("dry run made changes: %v", calls)
+	}
+	// Row 4 repeats row 3, so it is unchanged once row 3 is planned
+	if plan.Created != 1 || plan.Updated != 1 || plan.Unchanged != 2 || plan.Failed != 2 {
+		t.Errorf("dry run = %+v", plan)
+	}
+
+	result, err := mgr.ApplyManifest(ctx, reqCtx, rows, ManifestOptions{Resolve: resolve})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCalls := []string{
+		"PATCH /drive/v3/files/file1/permissions/p1",
+		"POST /drive/v3/files/file1/permissions",
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+	statuses := make([]string, len(result.Items))
+	for i, item := range result.Items {
+		statuses[i] = item.Action + "/" + item.Status
+	}
+	want := []string{"update/applied", "unchanged/skipped", "create/applied", "unchanged/skipped", "/failed", "/failed"}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if result.Items[0].FromRole != "writer" || result.Items[2].FileID != "file1" {
+		t.Errorf("items = %+v %+v", result.Items[0], result.Items[2])
+	}
+}
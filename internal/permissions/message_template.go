@@ -0,0 +1,135 @@
+package permissions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// MessageVars are the values available to a notification message template,
+// e.g. {{.FileName}} or {{.Link}}
+type MessageVars struct {
+	FileName     string
+	FileID       string
+	Link         string
+	Granter      string // display name of the authenticated user
+	GranterEmail string
+	Recipient    string // email address or domain being granted access
+	Role         string
+	Date         string // YYYY-MM-DD
+}
+
+// MessageTemplate renders a notification email message for each grant
+type MessageTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseMessageTemplate parses template text using Go text/template syntax.
+// References to unknown variables are rejected here rather than on the
+// first grant.
+func ParseMessageTemplate(text string) (*MessageTemplate, error) {
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, invalidTemplateError(err)
+	}
+	t := &MessageTemplate{tmpl: tmpl}
+	if _, err := t.Render(MessageVars{}); err != nil {
+		return nil, invalidTemplateError(err)
+	}
+	return t, nil
+}
+
+// LoadMessageTemplate reads and parses a template file
+func LoadMessageTemplate(path string) (*MessageTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to read message template: %v", err)).
+			WithContext("path", path).
+			Build())
+	}
+	return ParseMessageTemplate(string(data))
+}
+
+// Render returns the message for one grant
+func (t *MessageTemplate) Render(vars MessageVars) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func invalidTemplateError(err error) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("Invalid message template: %v", err)).
+		WithContext("variables", "FileName, FileID, Link, Granter, GranterEmail, Recipient, Role, Date").
+		Build())
+}
+
+// recipient returns who a grant is for, as shown in {{.Recipient}}
+func (opts CreateOptions) recipient() string {
+	switch {
+	case opts.EmailAddress != "":
+		return opts.EmailAddress
+	case opts.Domain != "":
+		return opts.Domain
+	}
+	return opts.Type
+}
+
+// messageVars builds template variables for a grant on file
+func messageVars(file *drive.File, granter *drive.User, opts CreateOptions) MessageVars {
+	vars := MessageVars{
+		FileName:  file.Name,
+		FileID:    file.Id,
+		Link:      file.WebViewLink,
+		Recipient: opts.recipient(),
+		Role:      opts.Role,
+		Date:      time.Now().Format("2006-01-02"),
+	}
+	if granter != nil {
+		vars.Granter = granter.DisplayName
+		vars.GranterEmail = granter.EmailAddress
+	}
+	return vars
+}
+
+// currentUser returns the authenticated user, the granter in message
+// templates
+func (m *Manager) currentUser(ctx context.Context, reqCtx *types.RequestContext) (*drive.User, error) {
+	call := m.client.Service().About.Get().Fields("user(displayName,emailAddress)")
+	about, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.About, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return about.User, nil
+}
+
+// renderMessage renders opts.MessageTemplate for a grant on fileID
+func (m *Manager) renderMessage(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts CreateOptions) (string, error) {
+	call := m.client.Service().Files.Get(fileID).Fields("id,name,webViewLink")
+	call = m.shaper.ShapeFilesGet(call, reqCtx)
+	file, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return "", err
+	}
+	granter, err := m.currentUser(ctx, reqCtx)
+	if err != nil {
+		return "", err
+	}
+	return opts.MessageTemplate.Render(messageVars(file, granter, opts))
+}
@@ -0,0 +1,101 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestParseMessageTemplate(t *testing.T) {
+	tmpl, err := ParseMessageTemplate("Hi {{.Recipient}}, {{.Granter}} shared \"{{.FileName}}\" ({{.Role}}): {{.Link}}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tmpl.Render(MessageVars{
+		Recipient: "bob@example.com",
+		Granter:   "Alice",
+		FileName:  "Plan",
+		Role:      "reader",
+		Link:      "https://docs.google.com/d/1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Hi bob@example.com, Alice shared "Plan" (reader): https://docs.google.com/d/1`
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	for _, text := range []string{"{{.FileNmae}}", "{{.FileName"} {
+		if _, err := ParseMessageTemplate(text); err == nil {
+			t.Errorf("ParseMessageTemplate(%q) should fail", text)
+		}
+	}
+}
+
+func TestBulkShare_RendersMessagePerFile(t *testing.T) {
+	var messages []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/about"):
+			_, _ = w.Write([]byte(`{"user":{"displayName":"Alice","emailAddress":"alice@example.com"}}`))
+		case strings.HasSuffix(r.URL.Path, "/permissions") && r.Method == http.MethodPost:
+			messages = append(messages, r.URL.Query().Get("emailMessage"))
+			var perm drive.Permission
+			_ = json.NewDecoder(r.Body).Decode(&perm)
+			perm.Id = "p1"
+			_ = json.NewEncoder(w).Encode(&perm)
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			_, _ = w.Write([]byte(`{"files":[
+				{"id":"f1","name":"One","mimeType":"text/plain","webViewLink":"https://drive/f1"},
+				{"id":"f2","name":"Two","mimeType":"text/plain","webViewLink":"https://drive/f2"}]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	tmpl, err := ParseMessageTemplate("{{.Granter}} shared {{.FileName}} with {{.Recipient}}: {{.Link}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := CreateOptions{
+		Type:                  "user",
+		Role:                  "reader",
+		EmailAddress:          "bob@example.com",
+		SendNotificationEmail: true,
+		MessageTemplate:       tmpl,
+	}
+
+	result, err := mgr.BulkShare(ctx, reqCtx, share, types.BulkOptions{FolderID: "folder", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 0 {
+		t.Fatalf("dry run created %d permissions", len(messages))
+	}
+	if result.SuccessCount != 2 || result.SuccessfulFiles[1].Message != "Alice shared Two with bob@example.com: https://drive/f2" {
+		t.Errorf("dry run result = %+v", result.SuccessfulFiles)
+	}
+
+	if _, err := mgr.BulkShare(ctx, reqCtx, share, types.BulkOptions{FolderID: "folder"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Alice shared One with bob@example.com: https://drive/f1",
+		"Alice shared Two with bob@example.com: https://drive/f2",
+	}
+	if strings.Join(messages, "|") != strings.Join(want, "|") {
+		t.Errorf("emailMessage = %q, want %q", messages, want)
+	}
+}
@@ -0,0 +1,420 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// Ownership transfer modes
+const (
+	// TransferDirect makes the recipient owner at once, as Workspace
+	// accounts in the same organization can
+	TransferDirect = "direct"
+	// TransferPending marks the recipient as pending owner; ownership moves
+	// when they accept, as consumer accounts require
+	TransferPending = "pending"
+)
+
+// Ownership transfer statuses
+const (
+	TransferPlanned     = "planned"
+	TransferTransferred = "transferred"
+	TransferAwaiting    = "pending"
+	TransferAccepted    = "accepted"
+	TransferCancelled   = "cancelled" // Declined, withdrawn, or the grant is gone
+)
+
+// consentRequiredReason is the Drive error reason for a direct transfer
+// between accounts that need the recipient's consent
+const consentRequiredReason = "consentRequiredForOwnershipTransfer"
+
+// consumerDomains host personal Google accounts, which can only transfer
+// ownership through a pending owner
+var consumerDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// TransfersFileName is the file in the config directory that tracks
+// ownership transfers awaiting acceptance
+const TransfersFileName = "ownership-transfers.json"
+
+// TransfersStateKind versions the ownership transfer ledger
+var TransfersStateKind = state.Register(&state.Kind{
+	Name:        "ownership-transfers",
+	Description: "Ownership transfers awaiting the recipient's acceptance",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultTransferStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: TransfersFileName,
+})
+
+// OwnershipTransfer is one ownership transfer and its outcome
+type OwnershipTransfer struct {
+	FileID       string    `json:"fileId"`
+	FileName     string    `json:"fileName"`
+	From         string    `json:"from,omitempty"`
+	To           string    `json:"to"`
+	PermissionID string    `json:"permissionId,omitempty"`
+	Mode         string    `json:"mode"`
+	Status       string    `json:"status"`
+	PreviousRole string    `json:"previousRole,omitempty"` // The recipient's role before a pending transfer
+	StartedAt    time.Time `json:"startedAt"`
+	CheckedAt    time.Time `json:"checkedAt,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// AsTableRenderer renders a single transfer as a one-row report
+func (t *OwnershipTransfer) AsTableRenderer() types.TableRenderer {
+	return &TransferReport{Transfers: []*OwnershipTransfer{t}}
+}
+
+// TransferOptions configures TransferOwnership
+type TransferOptions struct {
+	// Mode forces TransferDirect or TransferPending; empty picks one from
+	// the accounts involved
+	Mode string
+	// MoveToNewOwnersRoot moves a directly transferred file to the new
+	// owner's My Drive root, removing its current parents
+	MoveToNewOwnersRoot   bool
+	SendNotificationEmail bool
+	EmailMessage          string
+	DryRun                bool
+}
+
+// TransferOwnership makes email the owner of a file the caller owns. A
+// direct transfer is tried unless the caller has a consumer account or
+// Mode asks for a pending one; when Drive answers that the recipient must
+// consent, the transfer falls back to marking them pending owner. For a
+// pending transfer the recipient is given writer access first if they lack
+// it, as Drive requires. Under DryRun only the checks are made.
+func (m *Manager) TransferOwnership(ctx context.Context, reqCtx *types.RequestContext, fileID, email string, opts TransferOptions) (*OwnershipTransfer, error) {
+	if opts.Mode != "" && opts.Mode != TransferDirect && opts.Mode != TransferPending {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid transfer mode %q: use direct or pending", opts.Mode)).Build())
+	}
+	email = strings.TrimSpace(email)
+	if !strings.Contains(email, "@") {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Invalid new owner %q: expected an email address", email)).Build())
+	}
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	getCall := m.client.Service().Files.Get(fileID).Fields("id,name,driveId,ownedByMe,owners(emailAddress)")
+	getCall = m.shaper.ShapeFilesGet(getCall, reqCtx)
+	file, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return getCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file.DriveId != "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s is in a Shared Drive, which owns its files; move it out of the drive to give it an owner", file.Name)).
+			WithContext("driveId", file.DriveId).Build())
+	}
+	if !file.OwnedByMe {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied,
+			fmt.Sprintf("Only the owner of %s can transfer its ownership", file.Name)).Build())
+	}
+	for _, owner := range file.Owners {
+		if strings.EqualFold(owner.EmailAddress, email) {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("%s already owns %s", email, file.Name)).Build())
+		}
+	}
+
+	me, err := m.currentUser(ctx, reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	transfer := &OwnershipTransfer{
+		FileID:    file.Id,
+		FileName:  file.Name,
+		From:      me.EmailAddress,
+		To:        email,
+		Mode:      opts.Mode,
+		StartedAt: time.Now().UTC(),
+	}
+	if transfer.Mode == "" {
+		transfer.Mode = TransferDirect
+		if consumerDomains[strings.ToLower(extractDomain(me.EmailAddress))] {
+			transfer.Mode = TransferPending
+		}
+	}
+	if opts.DryRun {
+		transfer.Status = TransferPlanned
+		return transfer, nil
+	}
+
+	if transfer.Mode == TransferDirect {
+		perm, err := m.transferDirect(ctx, reqCtx, fileID, email, opts)
+		if err == nil {
+			transfer.PermissionID = perm.Id
+			transfer.Status = TransferTransferred
+			return transfer, nil
+		}
+		appErr, ok := err.(*utils.AppError)
+		if opts.Mode != "" || !ok || appErr.CLIError.DriveReason != consentRequiredReason {
+			return nil, err
+		}
+		transfer.Mode = TransferPending
+	}
+
+	if err := m.transferPending(ctx, reqCtx, transfer, opts); err != nil {
+		return nil, err
+	}
+	transfer.Status = TransferAwaiting
+	return transfer, nil
+}
+
+// transferDirect makes email the owner in one request
+func (m *Manager) transferDirect(ctx context.Context, reqCtx *types.RequestContext, fileID, email string, opts TransferOptions) (*drive.Permission, error) {
+	call := m.client.Service().Permissions.Create(fileID, &drive.Permission{
+		Type:         "user",
+		Role:         types.PermissionRoleOwner,
+		EmailAddress: email,
+	})
+	call = m.shaper.ShapePermissionsCreate(call, reqCtx)
+	call = call.TransferOwnership(true).SendNotificationEmail(true).Fields("id,role,emailAddress")
+	if opts.MoveToNewOwnersRoot {
+		call = call.MoveToNewOwnersRoot(true)
+	}
+	if opts.EmailMessage != "" {
+		call = call.EmailMessage(opts.EmailMessage)
+	}
+	return api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+		return call.Do()
+	})
+}
+
+// transferPending marks transfer.To as pending owner, granting writer
+// access first when they lack it
+func (m *Manager) transferPending(ctx context.Context, reqCtx *types.RequestContext, transfer *OwnershipTransfer, opts TransferOptions) error {
+	perms, err := m.List(ctx, reqCtx, transfer.FileID, ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, p := range perms {
+		if p.Type == "user" && strings.EqualFold(p.EmailAddress, transfer.To) {
+			transfer.PermissionID = p.ID
+			transfer.PreviousRole = p.Role
+			break
+		}
+	}
+	if transfer.PermissionID == "" {
+		created, err := m.Create(ctx, reqCtx, transfer.FileID, CreateOptions{
+			Type:                  "user",
+			Role:                  types.PermissionRoleWriter,
+			EmailAddress:          transfer.To,
+			SendNotificationEmail: opts.SendNotificationEmail,
+			EmailMessage:          opts.EmailMessage,
+		})
+		if err != nil {
+			return err
+		}
+		transfer.PermissionID = created.ID
+	}
+
+	call := m.client.Service().Permissions.Update(transfer.FileID, transfer.PermissionID, &drive.Permission{
+		Role:         types.PermissionRoleWriter,
+		PendingOwner: true,
+	})
+	call = call.SupportsAllDrives(true).Fields("id,role,pendingOwner")
+	if header := m.client.ResourceKeys().BuildHeader(reqCtx.InvolvedFileIDs); header != "" {
+		call.Header().Set("X-Goog-Drive-Resource-Keys", header)
+	}
+	_, err = api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+		return call.Do()
+	})
+	return err
+}
+
+// RefreshTransfers checks each pending transfer and records whether the
+// recipient accepted it, or it was declined or withdrawn. A transfer whose
+// file or grant is gone counts as cancelled; other failures are recorded
+// on the transfer and leave it pending. Once ctx is cancelled the rest are
+// left unchecked.
+func (m *Manager) RefreshTransfers(ctx context.Context, reqCtx *types.RequestContext, transfers []*OwnershipTransfer) {
+	for _, t := range transfers {
+		if t.Status != TransferAwaiting {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		call := m.client.Service().Permissions.Get(t.FileID, t.PermissionID)
+		call = call.SupportsAllDrives(true).Fields("id,role,pendingOwner")
+		perm, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Permission, error) {
+			return call.Do()
+		})
+		t.CheckedAt = time.Now().UTC()
+		t.Error = ""
+		switch {
+		case err != nil:
+			if appErr, ok := err.(*utils.AppError); ok && appErr.CLIError.Code == utils.ErrCodeFileNotFound {
+				t.Status = TransferCancelled
+				t.Error = "the file or the recipient's grant no longer exists"
+			} else {
+				t.Error = err.Error()
+			}
+		case perm.Role == types.PermissionRoleOwner:
+			t.Status = TransferAccepted
+		case !perm.PendingOwner:
+			t.Status = TransferCancelled
+		}
+	}
+}
+
+// FindPendingTransfers lists the pending owners on a folder and the files
+// in it (and in its subfolders when recursive), including transfers started
+// outside gdrv. It returns the number of files scanned.
+func (m *Manager) FindPendingTransfers(ctx context.Context, reqCtx *types.RequestContext, folderID string, recursive bool) ([]*OwnershipTransfer, int, error) {
+	getCall := m.client.Service().Files.Get(folderID).Fields("id,name")
+	getCall = m.shaper.ShapeFilesGet(getCall, reqCtx)
+	folder, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return getCall.Do()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	files, err := m.findFilesInFolder(ctx, reqCtx, types.BulkOptions{FolderID: folderID, Recursive: recursive})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var found []*OwnershipTransfer
+	scanned := 0
+	scan := append([]*drive.File{folder}, files...)
+	for _, file := range scan {
+		if err := ctx.Err(); err != nil {
+			return nil, scanned, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions pending-transfers", Done: scanned + 1, Total: len(scan), Item: file.Name})
+		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{Full: true})
+		if err != nil {
+			return nil, scanned, err
+		}
+		scanned++
+		for _, p := range perms {
+			if p.PendingOwner {
+				found = append(found, &OwnershipTransfer{
+					FileID:       file.Id,
+					FileName:     file.Name,
+					To:           p.EmailAddress,
+					PermissionID: p.ID,
+					Mode:         TransferPending,
+					Status:       TransferAwaiting,
+					CheckedAt:    time.Now().UTC(),
+				})
+			}
+		}
+	}
+	return found, scanned, nil
+}
+
+// MergeTransfers adds found transfers that are not yet in recorded, keyed
+// by file and grant, and returns the combined list; recorded is not changed
+func MergeTransfers(recorded, found []*OwnershipTransfer) []*OwnershipTransfer {
+	merged := append([]*OwnershipTransfer(nil), recorded...)
+	seen := make(map[string]bool, len(recorded))
+	for _, t := range recorded {
+		seen[t.FileID+"/"+t.PermissionID] = true
+	}
+	for _, t := range found {
+		if !seen[t.FileID+"/"+t.PermissionID] {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+// TransferReport lists recorded ownership transfers
+type TransferReport struct {
+	Transfers    []*OwnershipTransfer `json:"transfers"`
+	Pending      int                  `json:"pending"`
+	FilesScanned int                  `json:"filesScanned,omitempty"`
+	Store        string               `json:"store,omitempty"`
+}
+
+func (r *TransferReport) Headers() []string {
+	return []string{"File", "From", "To", "Mode", "Status", "Started"}
+}
+
+func (r *TransferReport) Rows() [][]string {
+	rows := make([][]string, len(r.Transfers))
+	for i, t := range r.Transfers {
+		status := t.Status
+		if t.Error != "" {
+			status += ": " + t.Error
+		}
+		started := "-"
+		if !t.StartedAt.IsZero() {
+			started = types.DisplayTime(t.StartedAt.Format(time.RFC3339))
+		}
+		rows[i] = []string{t.FileName, t.From, t.To, t.Mode, status, started}
+	}
+	return rows
+}
+
+func (r *TransferReport) EmptyMessage() string {
+	return "No ownership transfers awaiting acceptance"
+}
+
+// TransferStore persists ownership transfers as JSON
+type TransferStore struct {
+	path string
+}
+
+type transfersFile struct {
+	state.Versioned
+	Transfers []*OwnershipTransfer `json:"transfers"`
+}
+
+// NewTransferStore returns a store backed by the given file
+func NewTransferStore(path string) *TransferStore {
+	return &TransferStore{path: path}
+}
+
+// DefaultTransferStore returns the store in the gdrv config directory
+func DefaultTransferStore() (*TransferStore, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewTransferStore(filepath.Join(dir, TransfersFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *TransferStore) Path() string {
+	return s.path
+}
+
+// List returns all recorded transfers, oldest first
+func (s *TransferStore) List() ([]*OwnershipTransfer, error) {
+	var file transfersFile
+	if _, err := state.Load(TransfersStateKind, s.path, &file); err != nil {
+		return nil, err
+	}
+	return file.Transfers, nil
+}
+
+// Save replaces the recorded transfers
+func (s *TransferStore) Save(transfers []*OwnershipTransfer) error {
+	return state.Save(TransfersStateKind, s.path, &transfersFile{Transfers: transfers})
+}
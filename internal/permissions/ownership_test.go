@@ -0,0 +1,167 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func newOwnershipTestManager(t *testing.T, handler http.HandlerFunc) *Manager {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, handler)
+	return NewManager(client)
+}
+
+func TestTransferOwnership_ConsumerAccountUsesPendingOwner(t *testing.T) {
+	var requests []string
+	var pendingBody drive.Permission
+	mgr := newOwnershipTestManager(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/drive/v3/files/doc":
+			_, _ = w.Write([]byte(`{"id":"doc","name":"Plan","ownedByMe":true,"owners":[{"emailAddress":"me@gmail.com"}]}`))
+		case r.URL.Path == "/drive/v3/about":
+			_, _ = w.Write([]byte(`{"user":{"emailAddress":"me@gmail.com"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/doc/permissions":
+			_, _ = w.Write([]byte(`{"permissions":[{"id":"owner","type":"user","role":"owner","emailAddress":"me@gmail.com"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/doc/permissions":
+			if r.URL.Query().Get("transferOwnership") != "" {
+				t.Error("a consumer account should not try a direct transfer")
+			}
+			_, _ = w.Write([]byte(`{"id":"p2","type":"user","role":"writer","emailAddress":"you@gmail.com"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/drive/v3/files/doc/permissions/p2":
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &pendingBody)
+			_, _ = w.Write([]byte(`{"id":"p2","role":"writer","pendingOwner":true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	planned, err := mgr.TransferOwnership(ctx, reqCtx, "doc", "you@gmail.com", TransferOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if planned.Mode != TransferPending || planned.Status != TransferPlanned || len(requests) != 2 {
+		t.Fatalf("planned = %+v after %v", planned, requests)
+	}
+
+	transfer, err := mgr.TransferOwnership(ctx, reqCtx, "doc", "you@gmail.com", TransferOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Mode != TransferPending || transfer.Status != TransferAwaiting || transfer.PermissionID != "p2" || transfer.From != "me@gmail.com" {
+		t.Errorf("transfer = %+v", transfer)
+	}
+	if !pendingBody.PendingOwner || pendingBody.Role != types.PermissionRoleWriter {
+		t.Errorf("pending owner update = %+v", pendingBody)
+	}
+
+	if _, err := mgr.TransferOwnership(ctx, reqCtx, "doc", "me@gmail.com", TransferOptions{}); err == nil {
+		t.Error("transferring to the current owner should fail")
+	}
+}
+
+func TestTransferOwnership_FallsBackWhenConsentRequired(t *testing.T) {
+	var direct, pending int
+	mgr := newOwnershipTestManager(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/drive/v3/files/doc":
+			_, _ = w.Write([]byte(`{"id":"doc","name":"Plan","ownedByMe":true}`))
+		case r.URL.Path == "/drive/v3/about":
+			_, _ = w.Write([]byte(`{"user":{"emailAddress":"me@example.com"}}`))
+		case r.Method == http.MethodPost:
+			if r.URL.Query().Get("transferOwnership") != "true" || r.URL.Query().Get("moveToNewOwnersRoot") != "true" {
+				t.Errorf("direct transfer query = %s", r.URL.RawQuery)
+			}
+			direct++
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"Consent is required","errors":[{"reason":"consentRequiredForOwnershipTransfer"}]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/doc/permissions":
+			_, _ = w.Write([]byte(`{"permissions":[{"id":"p9","type":"user","role":"reader","emailAddress":"You@Other.org"}]}`))
+		case r.Method == http.MethodPatch:
+			pending++
+			_, _ = w.Write([]byte(`{"id":"p9","role":"writer","pendingOwner":true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+
+	transfer, err := mgr.TransferOwnership(ctx, reqCtx, "doc", "you@other.org", TransferOptions{MoveToNewOwnersRoot: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if direct != 1 || pending != 1 || transfer.Mode != TransferPending || transfer.PermissionID != "p9" || transfer.PreviousRole != "reader" {
+		t.Errorf("direct=%d pending=%d transfer=%+v", direct, pending, transfer)
+	}
+
+	// A forced direct transfer reports the refusal instead
+	if _, err := mgr.TransferOwnership(ctx, reqCtx, "doc", "you@other.org", TransferOptions{Mode: TransferDirect, MoveToNewOwnersRoot: true}); err == nil {
+		t.Error("forced direct transfer should fail")
+	}
+}
+
+func TestRefreshTransfersAndStore(t *testing.T) {
+	mgr := newOwnershipTestManager(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/drive/v3/files/a/permissions/p1":
+			_, _ = w.Write([]byte(`{"id":"p1","role":"owner"}`))
+		case "/drive/v3/files/b/permissions/p2":
+			_, _ = w.Write([]byte(`{"id":"p2","role":"writer"}`))
+		case "/drive/v3/files/c/permissions/p3":
+			_, _ = w.Write([]byte(`{"id":"p3","role":"writer","pendingOwner":true}`))
+		case "/drive/v3/files/d/permissions/p4":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Permission not found"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	transfers := []*OwnershipTransfer{
+		{FileID: "a", PermissionID: "p1", Status: TransferAwaiting},
+		{FileID: "b", PermissionID: "p2", Status: TransferAwaiting},
+		{FileID: "c", PermissionID: "p3", Status: TransferAwaiting},
+		{FileID: "d", PermissionID: "p4", Status: TransferAwaiting},
+		{FileID: "e", PermissionID: "p5", Status: TransferAccepted},
+	}
+	mgr.RefreshTransfers(context.Background(), api.NewRequestContext("default", "", types.RequestTypeGetByID), transfers)
+
+	want := []string{TransferAccepted, TransferCancelled, TransferAwaiting, TransferCancelled, TransferAccepted}
+	for i, tr := range transfers {
+		if tr.Status != want[i] {
+			t.Errorf("transfer %s: status %s, want %s", tr.FileID, tr.Status, want[i])
+		}
+	}
+
+	merged := MergeTransfers(transfers[:2], []*OwnershipTransfer{{FileID: "a", PermissionID: "p1"}, {FileID: "f", PermissionID: "p6"}})
+	if len(merged) != 3 || merged[2].FileID != "f" {
+		t.Errorf("merged = %+v", merged)
+	}
+
+	store := NewTransferStore(filepath.Join(t.TempDir(), TransfersFileName))
+	if got, err := store.List(); err != nil || len(got) != 0 {
+		t.Fatalf("empty store: %v, %v", got, err)
+	}
+	if err := store.Save(transfers); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(transfers) || got[2].Status != TransferAwaiting {
+		t.Errorf("round trip = %+v", got)
+	}
+}
@@ -0,0 +1,345 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/comments"
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// RemediationFileName is the file in the config directory that tracks
+// remediation comments
+const RemediationFileName = "remediation.json"
+
+// Remediation statuses
+const (
+	RemediationPlanned      = "planned"
+	RemediationOpen         = "open"
+	RemediationAcknowledged = "acknowledged"
+	RemediationOverdue      = "overdue"
+	RemediationDismissed    = "dismissed" // The comment was deleted
+	RemediationSkipped      = "skipped"   // An open request already exists
+	RemediationFailed       = "failed"
+)
+
+// RemediationStateKind versions the remediation ledger
+var RemediationStateKind = state.Register(&state.Kind{
+	Name:        "remediation",
+	Description: "Remediation comments posted by permission audits",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultRemediationStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: RemediationFileName,
+})
+
+// RemediationItem is one remediation request posted as a comment on a file
+type RemediationItem struct {
+	FileID         string    `json:"fileId"`
+	FileName       string    `json:"fileName"`
+	WebViewLink    string    `json:"webViewLink,omitempty"`
+	Owner          string    `json:"owner,omitempty"`
+	RiskLevel      string    `json:"riskLevel"`
+	Reasons        []string  `json:"reasons"`
+	Source         string    `json:"source,omitempty"` // The audit that found it
+	CommentID      string    `json:"commentId,omitempty"`
+	PostedAt       time.Time `json:"postedAt,omitempty"`
+	Deadline       time.Time `json:"deadline"`
+	Status         string    `json:"status"`
+	AcknowledgedBy string    `json:"acknowledgedBy,omitempty"`
+	AcknowledgedAt string    `json:"acknowledgedAt,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// Pending reports whether the owner has yet to respond
+func (i *RemediationItem) Pending() bool {
+	return i.Status == RemediationOpen || i.Status == RemediationOverdue
+}
+
+// RemediationStore persists remediation requests as JSON
+type RemediationStore struct {
+	path string
+}
+
+type remediationFile struct {
+	state.Versioned
+	Items []*RemediationItem `json:"items"`
+}
+
+// NewRemediationStore returns a store backed by the given file
+func NewRemediationStore(path string) *RemediationStore {
+	return &RemediationStore{path: path}
+}
+
+// DefaultRemediationStore returns the store in the gdrv config directory
+func DefaultRemediationStore() (*RemediationStore, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemediationStore(filepath.Join(dir, RemediationFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *RemediationStore) Path() string {
+	return s.path
+}
+
+// List returns all recorded requests, oldest first
+func (s *RemediationStore) List() ([]*RemediationItem, error) {
+	var file remediationFile
+	if _, err := state.Load(RemediationStateKind, s.path, &file); err != nil {
+		return nil, err
+	}
+	return file.Items, nil
+}
+
+// Save replaces the recorded requests
+func (s *RemediationStore) Save(items []*RemediationItem) error {
+	return state.Save(RemediationStateKind, s.path, &remediationFile{Items: items})
+}
+
+// RemediationOptions configures NotifyOwners
+type RemediationOptions struct {
+	// MinRisk is the lowest risk level that gets a comment (default high)
+	MinRisk  string
+	Deadline time.Time
+	// Source names the audit, recorded with each request
+	Source string
+	DryRun bool
+}
+
+// RemediationResult lists the requests posted or refreshed by one run
+type RemediationResult struct {
+	Items  []*RemediationItem `json:"items"`
+	Posted int                `json:"posted"`
+	Failed int                `json:"failed"`
+	Store  string             `json:"store,omitempty"`
+}
+
+func (r *RemediationResult) Headers() []string {
+	return []string{"File", "Owner", "Risk", "Deadline", "Status", "Comment"}
+}
+
+func (r *RemediationResult) Rows() [][]string {
+	rows := make([][]string, len(r.Items))
+	for i, item := range r.Items {
+		status := item.Status
+		if item.AcknowledgedBy != "" {
+			status += " by " + item.AcknowledgedBy
+		}
+		if item.Error != "" {
+			status += ": " + item.Error
+		}
+		rows[i] = []string{item.FileName, item.Owner, item.RiskLevel, item.Deadline.Format("2006-01-02"), status, item.CommentID}
+	}
+	return rows
+}
+
+func (r *RemediationResult) EmptyMessage() string {
+	return "No findings at or above the remediation risk level"
+}
+
+var riskRank = map[string]int{
+	types.RiskLevelLow:      1,
+	types.RiskLevelMedium:   2,
+	types.RiskLevelHigh:     3,
+	types.RiskLevelCritical: 4,
+}
+
+// ValidRiskLevel reports whether level is low, medium, high or critical
+func ValidRiskLevel(level string) bool {
+	return riskRank[level] > 0
+}
+
+// RiskAtLeast reports whether level is min or a higher risk level
+func RiskAtLeast(level, min string) bool {
+	return riskRank[level] >= riskRank[min]
+}
+
+// NotifyOwners posts a comment on each finding at or above opts.MinRisk,
+// mentioning the file's owner with what to fix and by when, and records
+// it in store so RefreshRemediation can follow up. Files that already have
+// a pending request are skipped rather than commented on again.
+func (m *Manager) NotifyOwners(ctx context.Context, reqCtx *types.RequestContext, findings []*types.FilePermissionInfo, store *RemediationStore, opts RemediationOptions) (*RemediationResult, error) {
+	minRisk := opts.MinRisk
+	if minRisk == "" {
+		minRisk = types.RiskLevelHigh
+	}
+	items, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	pending := map[string]*RemediationItem{}
+	for _, item := range items {
+		if item.Pending() {
+			pending[item.FileID] = item
+		}
+	}
+
+	result := &RemediationResult{Items: []*RemediationItem{}, Store: store.Path()}
+	cm := comments.NewManager(m.client)
+	var runErr error
+	for _, f := range findings {
+		if riskRank[f.RiskLevel] < riskRank[minRisk] {
+			continue
+		}
+		// Stop posting, but save the requests already posted
+		if runErr = ctx.Err(); runErr != nil {
+			break
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions notify-owners", Done: len(result.Items) + 1, Item: f.FileName})
+		item := &RemediationItem{
+			FileID:      f.FileID,
+			FileName:    f.FileName,
+			WebViewLink: f.WebViewLink,
+			Owner:       fileOwner(f.Permissions),
+			RiskLevel:   f.RiskLevel,
+			Reasons:     f.RiskReasons,
+			Source:      opts.Source,
+			Deadline:    opts.Deadline,
+		}
+		switch {
+		case pending[f.FileID] != nil:
+			existing := *pending[f.FileID]
+			existing.Status = RemediationSkipped
+			result.Items = append(result.Items, &existing)
+			continue
+		case opts.DryRun:
+			item.Status = RemediationPlanned
+			result.Items = append(result.Items, item)
+			continue
+		}
+
+		comment, err := cm.Create(ctx, reqCtx, f.FileID, remediationComment(item))
+		if err != nil {
+			item.Status, item.Error = RemediationFailed, err.Error()
+			result.Failed++
+			result.Items = append(result.Items, item)
+			continue
+		}
+		item.CommentID = comment.ID
+		item.PostedAt = time.Now().UTC()
+		item.Status = RemediationOpen
+		result.Posted++
+		result.Items = append(result.Items, item)
+		items = append(items, item)
+		pending[f.FileID] = item
+	}
+
+	if result.Posted > 0 {
+		if err := store.Save(items); err != nil {
+			return result, err
+		}
+	}
+	return result, runErr
+}
+
+// RefreshRemediation checks the comments of pending requests. A request is
+// acknowledged once its comment is resolved or someone other than the
+// poster replies, and overdue when the deadline passes without that.
+func (m *Manager) RefreshRemediation(ctx context.Context, reqCtx *types.RequestContext, store *RemediationStore, now time.Time) (*RemediationResult, error) {
+	items, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RemediationResult{Items: items, Store: store.Path()}
+	cm := comments.NewManager(m.client)
+	changed := false
+	var runErr error
+	for _, item := range items {
+		if !item.Pending() || item.CommentID == "" {
+			continue
+		}
+		if runErr = ctx.Err(); runErr != nil {
+			break
+		}
+		comment, err := cm.Get(ctx, reqCtx, item.FileID, item.CommentID)
+		if err != nil {
+			item.Error = err.Error()
+			result.Failed++
+			continue
+		}
+		item.Error = ""
+		status := item.Status
+		if by, at, ok := acknowledgement(comment); ok {
+			status, item.AcknowledgedBy, item.AcknowledgedAt = RemediationAcknowledged, by, at
+		} else if comment.Deleted {
+			status = RemediationDismissed
+		} else if !item.Deadline.IsZero() && now.After(item.Deadline) {
+			status = RemediationOverdue
+		}
+		if status != item.Status {
+			item.Status = status
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := store.Save(items); err != nil {
+			return result, err
+		}
+	}
+	return result, runErr
+}
+
+// acknowledgement returns who acknowledged a remediation comment: the
+// person who resolved it, or else the first reply not from the poster
+func acknowledgement(c *types.Comment) (string, string, bool) {
+	for i := len(c.Replies) - 1; i >= 0; i-- {
+		r := c.Replies[i]
+		if c.Resolved && r.Action == "resolve" {
+			return r.Author, r.CreatedTime, true
+		}
+	}
+	if c.Resolved {
+		return "", c.ModifiedTime, true
+	}
+	for _, r := range c.Replies {
+		if !r.AuthorIsMe && !r.Deleted {
+			return r.Author, r.CreatedTime, true
+		}
+	}
+	return "", "", false
+}
+
+func fileOwner(perms []*types.Permission) string {
+	for _, p := range perms {
+		if p.Role == "owner" && p.EmailAddress != "" {
+			return p.EmailAddress
+		}
+	}
+	return ""
+}
+
+func remediationComment(item *RemediationItem) string {
+	var b strings.Builder
+	if item.Owner != "" {
+		b.WriteString("+" + item.Owner + " ")
+	}
+	fmt.Fprintf(&b, "A permission audit rated this file's sharing as %s risk:\n", item.RiskLevel)
+	reasons := append([]string(nil), item.Reasons...)
+	sort.Strings(reasons)
+	for _, r := range reasons {
+		b.WriteString("- " + r + "\n")
+	}
+	if !item.Deadline.IsZero() {
+		fmt.Fprintf(&b, "Please remove or justify this access by %s. ", item.Deadline.Format("2006-01-02"))
+	} else {
+		b.WriteString("Please remove or justify this access. ")
+	}
+	b.WriteString("Reply to this comment or resolve it to acknowledge.")
+	return b.String()
+}
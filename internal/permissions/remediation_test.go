@@ -0,0 +1,106 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestNotifyOwnersAndRefresh(t *testing.T) {
+	var mu sync.Mutex
+	posted := map[string]string{}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/comments"):
+			var c drive.Comment
+			_ = json.NewDecoder(r.Body).Decode(&c)
+			fileID := strings.Split(r.URL.Path, "/")[4]
+			posted[fileID] = c.Content
+			_, _ = w.Write([]byte(`{"id":"c-` + fileID + `"}`))
+		case r.URL.Path == "/drive/v3/files/f1/comments/c-f1":
+			_, _ = w.Write([]byte(`{"id":"c-f1","replies":[{"id":"r1","content":"On it","author":{"displayName":"Owner"}}]}`))
+		case r.URL.Path == "/drive/v3/files/f2/comments/c-f2":
+			_, _ = w.Write([]byte(`{"id":"c-f2","replies":[{"id":"r1","content":"reminder","author":{"me":true}}]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeMutation)
+	store := NewRemediationStore(filepath.Join(t.TempDir(), RemediationFileName))
+
+	owner := []*types.Permission{{Type: "user", Role: "owner", EmailAddress: "owner@example.com"}}
+	findings := []*types.FilePermissionInfo{
+		{FileID: "f1", FileName: "budget.xlsx", RiskLevel: types.RiskLevelCritical, RiskReasons: []string{"Public access enabled"}, Permissions: owner},
+		{FileID: "f2", FileName: "plan.docx", RiskLevel: types.RiskLevelHigh, RiskReasons: []string{"Shared with external domain: other.com"}, Permissions: owner},
+		{FileID: "f3", FileName: "notes.txt", RiskLevel: types.RiskLevelMedium},
+	}
+	deadline := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	opts := RemediationOptions{Deadline: deadline, Source: "permissions.audit.public"}
+
+	result, err := mgr.NotifyOwners(ctx, reqCtx, findings, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 2 || len(posted) != 2 {
+		t.Fatalf("posted %d comments (%v), want 2", result.Posted, posted)
+	}
+	if c := posted["f1"]; !strings.HasPrefix(c, "+owner@example.com ") || !strings.Contains(c, "Public access enabled") || !strings.Contains(c, "2025-03-01") {
+		t.Errorf("comment = %q", c)
+	}
+
+	// A second audit does not comment again on files with a pending request
+	result, err = mgr.NotifyOwners(ctx, reqCtx, findings, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 0 || len(result.Items) != 2 || result.Items[0].Status != RemediationSkipped {
+		t.Errorf("second run = %+v", result)
+	}
+
+	// f1's owner replied; f2 only has a reply from the poster and is late
+	result, err = mgr.RefreshRemediation(ctx, reqCtx, store, deadline.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := map[string]string{}
+	for _, item := range result.Items {
+		status[item.FileID] = item.Status
+	}
+	if status["f1"] != RemediationAcknowledged || status["f2"] != RemediationOverdue {
+		t.Errorf("statuses = %v", status)
+	}
+	items, _ := store.List()
+	if items[0].AcknowledgedBy != "Owner" || items[1].Status != RemediationOverdue {
+		t.Errorf("stored items = %+v, %+v", items[0], items[1])
+	}
+}
+
+func TestAcknowledgement(t *testing.T) {
+	resolved := &types.Comment{Resolved: true, Replies: []*types.CommentReply{
+		{Author: "bob@example.com", Action: "resolve", CreatedTime: "2025-02-01T10:00:00Z"},
+	}}
+	if by, at, ok := acknowledgement(resolved); !ok || by != "bob@example.com" || at != "2025-02-01T10:00:00Z" {
+		t.Errorf("resolved: %q %q %v", by, at, ok)
+	}
+	mine := &types.Comment{Replies: []*types.CommentReply{{Author: "me", AuthorIsMe: true}}}
+	if _, _, ok := acknowledgement(mine); ok {
+		t.Error("a reply from the poster is not an acknowledgement")
+	}
+}
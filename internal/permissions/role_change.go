@@ -0,0 +1,66 @@
+package permissions
+
+import (
+	"fmt"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Role change directions
+const (
+	RoleChangeUpgrade   = "upgrade"
+	RoleChangeDowngrade = "downgrade"
+)
+
+// roleRank orders roles by the access they grant
+var roleRank = map[string]int{
+	types.PermissionRoleReader:    1,
+	types.PermissionRoleCommenter: 2,
+	types.PermissionRoleWriter:    3,
+	"fileOrganizer":               4,
+	types.PermissionRoleOrganizer: 5,
+	types.PermissionRoleOwner:     6,
+}
+
+// RoleChangeDirection reports whether changing fromRole to toRole grants
+// more access (upgrade) or less (downgrade)
+func RoleChangeDirection(fromRole, toRole string) (string, error) {
+	for _, role := range []string{fromRole, toRole} {
+		if _, ok := roleRank[role]; !ok {
+			return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				fmt.Sprintf("Unknown role %q", role)).
+				WithContext("validRoles", "reader, commenter, writer, fileOrganizer, organizer").
+				Build())
+		}
+	}
+	if fromRole == types.PermissionRoleOwner || toRole == types.PermissionRoleOwner {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Ownership cannot be changed with a role update").Build())
+	}
+	if fromRole == toRole {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("From and to roles are both %q", fromRole)).Build())
+	}
+	if roleRank[toRole] > roleRank[fromRole] {
+		return RoleChangeUpgrade, nil
+	}
+	return RoleChangeDowngrade, nil
+}
+
+// CheckRoleChange validates a role change and, when required is set,
+// rejects a change in the other direction, which usually means --from-role
+// and --to-role were swapped
+func CheckRoleChange(fromRole, toRole, required string) (string, error) {
+	direction, err := RoleChangeDirection(fromRole, toRole)
+	if err != nil {
+		return "", err
+	}
+	if required != "" && direction != required {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s → %s is a role %s, but only %ss are allowed", fromRole, toRole, direction, required)).
+			WithContext("suggestedAction", "check the order of --from-role and --to-role").
+			Build())
+	}
+	return direction, nil
+}
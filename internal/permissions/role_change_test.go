@@ -0,0 +1,64 @@
+package permissions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestRoleChangeDirection(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+		wantErr  bool
+	}{
+		{"writer", "reader", RoleChangeDowngrade, false},
+		{"commenter", "reader", RoleChangeDowngrade, false},
+		{"organizer", "fileOrganizer", RoleChangeDowngrade, false},
+		{"reader", "writer", RoleChangeUpgrade, false},
+		{"reader", "commenter", RoleChangeUpgrade, false},
+		{"reader", "reader", "", true},
+		{"reader", "editor", "", true},
+		{"writer", "owner", "", true},
+	}
+	for _, tt := range tests {
+		got, err := RoleChangeDirection(tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("RoleChangeDirection(%q, %q) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RoleChangeDirection(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRoleChange_RejectsWrongDirection(t *testing.T) {
+	if _, err := CheckRoleChange("writer", "reader", RoleChangeDowngrade); err != nil {
+		t.Errorf("downgrade with downgrade-only: %v", err)
+	}
+	if _, err := CheckRoleChange("reader", "writer", RoleChangeDowngrade); err == nil {
+		t.Error("upgrade with downgrade-only should fail")
+	}
+	if _, err := CheckRoleChange("writer", "reader", RoleChangeUpgrade); err == nil {
+		t.Error("downgrade with upgrade-only should fail")
+	}
+	if _, err := CheckRoleChange("reader", "writer", ""); err != nil {
+		t.Errorf("upgrade without a guard: %v", err)
+	}
+}
+
+func TestBulkUpdateRole_GuardRejectsBeforeListing(t *testing.T) {
+	// A nil service would panic if the guard let the update reach the API
+	mgr := NewManager(api.NewClient(nil, 0, 100, nil))
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+	_, err := mgr.BulkUpdateRole(context.Background(), reqCtx, "reader", "writer", types.BulkOptions{
+		FolderID:      "folder",
+		RoleDirection: RoleChangeDowngrade,
+	})
+	if err == nil {
+		t.Fatal("expected swapped roles to be rejected")
+	}
+}
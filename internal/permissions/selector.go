@@ -0,0 +1,116 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Selector identifies a permission by its grantee instead of its ID.
+// Exactly one of Email, Domain or Anyone must be set.
+type Selector struct {
+	Email  string // user or group email address
+	Domain string // domain permission
+	Anyone bool   // public (anyone) permission
+}
+
+// IsZero reports whether no grantee was given
+func (s Selector) IsZero() bool {
+	return s.Email == "" && s.Domain == "" && !s.Anyone
+}
+
+// String describes the selector for messages
+func (s Selector) String() string {
+	switch {
+	case s.Email != "":
+		return "email " + s.Email
+	case s.Domain != "":
+		return "domain " + s.Domain
+	case s.Anyone:
+		return "anyone"
+	}
+	return ""
+}
+
+// Validate checks that exactly one grantee was given
+func (s Selector) Validate() error {
+	set := 0
+	if s.Email != "" {
+		set++
+	}
+	if s.Domain != "" {
+		set++
+	}
+	if s.Anyone {
+		set++
+	}
+	if set != 1 {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Specify exactly one of --email, --domain or --anyone").Build())
+	}
+	return nil
+}
+
+// Matches reports whether a permission belongs to the selected grantee.
+// Email and domain comparisons are case-insensitive.
+func (s Selector) Matches(p *types.Permission) bool {
+	switch {
+	case s.Email != "":
+		return (p.Type == "user" || p.Type == "group") && strings.EqualFold(p.EmailAddress, s.Email)
+	case s.Domain != "":
+		return p.Type == "domain" && strings.EqualFold(p.Domain, s.Domain)
+	case s.Anyone:
+		return p.Type == "anyone"
+	}
+	return false
+}
+
+// ResolvePermissionID lists a file's permissions and returns the ID of the
+// single permission matching sel. It fails if none or more than one match.
+func (m *Manager) ResolvePermissionID(ctx context.Context, reqCtx *types.RequestContext, fileID string, sel Selector) (string, error) {
+	if err := sel.Validate(); err != nil {
+		return "", err
+	}
+	perms, err := m.List(ctx, reqCtx, fileID, ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	perm, err := selectPermission(fileID, perms, sel)
+	if err != nil {
+		return "", err
+	}
+	return perm.ID, nil
+}
+
+func selectPermission(fileID string, perms []*types.Permission, sel Selector) (*types.Permission, error) {
+	var matches []*types.Permission
+	for _, p := range perms {
+		if sel.Matches(p) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+			fmt.Sprintf("No permission for %s on file %s", sel, fileID)).
+			WithContext("fileId", fileID).
+			Build())
+	}
+
+	candidates := make([]string, len(matches))
+	for i, p := range matches {
+		candidates[i] = fmt.Sprintf("%s (%s, %s)", p.ID, p.Type, p.Role)
+	}
+	return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("%d permissions match %s on file %s", len(matches), sel, fileID)).
+		WithContext("fileId", fileID).
+		WithContext("candidates", candidates).
+		WithContext("suggestedAction", "pass the permission ID instead of a selector").
+		Build())
+}
@@ -0,0 +1,79 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestSelectorValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sel     Selector
+		wantErr bool
+	}{
+		{"email", Selector{Email: "a@example.com"}, false},
+		{"domain", Selector{Domain: "example.com"}, false},
+		{"anyone", Selector{Anyone: true}, false},
+		{"none", Selector{}, true},
+		{"email and anyone", Selector{Email: "a@example.com", Anyone: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sel.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectPermission(t *testing.T) {
+	perms := []*types.Permission{
+		{ID: "p1", Type: "user", Role: "writer", EmailAddress: "Alice@Example.com"},
+		{ID: "p2", Type: "group", Role: "reader", EmailAddress: "team@example.com"},
+		{ID: "p3", Type: "domain", Role: "reader", Domain: "example.com"},
+		{ID: "p4", Type: "anyone", Role: "reader"},
+		{ID: "p5", Type: "anyone", Role: "commenter"},
+	}
+
+	tests := []struct {
+		name     string
+		sel      Selector
+		wantID   string
+		wantCode string
+	}{
+		{"email case-insensitive", Selector{Email: "alice@example.com"}, "p1", ""},
+		{"group email", Selector{Email: "team@example.com"}, "p2", ""},
+		{"domain", Selector{Domain: "EXAMPLE.COM"}, "p3", ""},
+		{"no match", Selector{Email: "bob@example.com"}, "", utils.ErrCodeFileNotFound},
+		{"ambiguous", Selector{Anyone: true}, "", utils.ErrCodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perm, err := selectPermission("file1", perms, tt.sel)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if perm.ID != tt.wantID {
+					t.Errorf("ID = %s, want %s", perm.ID, tt.wantID)
+				}
+				return
+			}
+			appErr, ok := err.(*utils.AppError)
+			if !ok {
+				t.Fatalf("expected *utils.AppError, got %T", err)
+			}
+			if appErr.CLIError.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", appErr.CLIError.Code, tt.wantCode)
+			}
+		})
+	}
+
+	_, err := selectPermission("file1", perms, Selector{Anyone: true})
+	candidates, _ := err.(*utils.AppError).CLIError.Context["candidates"].([]string)
+	if len(candidates) != 2 {
+		t.Errorf("candidates = %v, want both anyone permissions", candidates)
+	}
+}
@@ -0,0 +1,74 @@
+package permissions
+
+import (
+	"context"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// auditFileFields are the file fields audits and analyses request, including
+// shortcut targets
+const auditFileFields = "id,name,mimeType,webViewLink,createdTime,modifiedTime,shortcutDetails(targetId,targetMimeType)"
+
+func isShortcut(file *drive.File) bool {
+	return file.MimeType == utils.MimeTypeShortcut && file.ShortcutDetails != nil && file.ShortcutDetails.TargetId != ""
+}
+
+// shortcutTracker decides which permissions to audit for each listed file
+// and keeps shortcuts from reporting a target more than once
+type shortcutTracker struct {
+	follow bool
+	seen   map[string]bool // effective file IDs already audited
+}
+
+// newShortcutTracker creates a tracker for one listing. Files listed
+// directly take precedence over shortcuts to them.
+func newShortcutTracker(follow bool, files []*drive.File) *shortcutTracker {
+	t := &shortcutTracker{follow: follow, seen: map[string]bool{}}
+	if follow {
+		for _, f := range files {
+			if !isShortcut(f) {
+				t.seen[f.Id] = true
+			}
+		}
+	}
+	return t
+}
+
+// target returns the ID whose permissions should be audited for file, or
+// "" if file is a followed shortcut whose target was already audited
+func (t *shortcutTracker) target(file *drive.File) string {
+	if !t.follow || !isShortcut(file) {
+		return file.Id
+	}
+	targetID := file.ShortcutDetails.TargetId
+	if t.seen[targetID] {
+		return ""
+	}
+	t.seen[targetID] = true
+	return targetID
+}
+
+// annotate records shortcut details on an audit finding
+func (t *shortcutTracker) annotate(info *types.FilePermissionInfo, file *drive.File) {
+	if !isShortcut(file) {
+		return
+	}
+	info.IsShortcut = true
+	info.TargetID = file.ShortcutDetails.TargetId
+	info.Dereferenced = t.follow
+}
+
+// listAuditPermissions lists the permissions to audit for file, following
+// shortcuts when the tracker is set to. skip is true when the file should
+// not be reported.
+func (m *Manager) listAuditPermissions(ctx context.Context, reqCtx *types.RequestContext, tracker *shortcutTracker, file *drive.File, opts ListOptions) (perms []*types.Permission, skip bool, err error) {
+	targetID := tracker.target(file)
+	if targetID == "" {
+		return nil, true, nil
+	}
+	perms, err = m.List(ctx, reqCtx, targetID, opts)
+	return perms, false, err
+}
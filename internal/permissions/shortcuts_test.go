@@ -0,0 +1,61 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+func shortcutTo(id, targetID string) *drive.File {
+	return &drive.File{Id: id, MimeType: utils.MimeTypeShortcut,
+		ShortcutDetails: &drive.FileShortcutDetails{TargetId: targetID}}
+}
+
+func TestShortcutTracker(t *testing.T) {
+	direct := &drive.File{Id: "doc1", MimeType: "application/pdf"}
+	toDirect := shortcutTo("sc1", "doc1")
+	toOther := shortcutTo("sc2", "doc2")
+	toOtherAgain := shortcutTo("sc3", "doc2")
+	files := []*drive.File{toDirect, toOther, direct, toOtherAgain}
+
+	t.Run("not following", func(t *testing.T) {
+		tracker := newShortcutTracker(false, files)
+		for _, f := range files {
+			if got := tracker.target(f); got != f.Id {
+				t.Errorf("target(%s) = %q, want own ID", f.Id, got)
+			}
+		}
+		info := &types.FilePermissionInfo{}
+		tracker.annotate(info, toOther)
+		if !info.IsShortcut || info.TargetID != "doc2" || info.Dereferenced {
+			t.Errorf("annotation = %+v", info)
+		}
+	})
+
+	t.Run("following", func(t *testing.T) {
+		tracker := newShortcutTracker(true, files)
+		want := map[string]string{
+			"sc1":  "",     // target listed directly
+			"sc2":  "doc2", // first shortcut to doc2
+			"doc1": "doc1",
+			"sc3":  "", // doc2 already audited
+		}
+		for _, f := range files {
+			if got := tracker.target(f); got != want[f.Id] {
+				t.Errorf("target(%s) = %q, want %q", f.Id, got, want[f.Id])
+			}
+		}
+		info := &types.FilePermissionInfo{}
+		tracker.annotate(info, toOther)
+		if !info.Dereferenced {
+			t.Error("followed shortcut should be marked dereferenced")
+		}
+		plain := &types.FilePermissionInfo{}
+		tracker.annotate(plain, direct)
+		if plain.IsShortcut {
+			t.Error("regular file annotated as shortcut")
+		}
+	})
+}
@@ -0,0 +1,403 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateDirName is the directory in the config dir holding permission
+// templates, one <name>.yaml, .yml or .json file each
+const TemplateDirName = "permission-templates"
+
+// templateExtensions are the file extensions tried for a template name, in
+// order
+var templateExtensions = []string{".yaml", ".yml", ".json"}
+
+// templateVar matches ${name} references in template grants
+var templateVar = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// PermissionTemplate is a named set of grants to apply to files, such as a
+// team's standard sharing for project folders. Email, domain and role
+// values may reference ${variables}, whose defaults are set in Variables.
+type PermissionTemplate struct {
+	Name        string            `yaml:"-" json:"name"` // From the file name
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Variables   map[string]string `yaml:"variables,omitempty" json:"variables,omitempty"`
+	Grants      []TemplateGrant   `yaml:"grants" json:"grants"`
+}
+
+// TemplateGrant is one grant in a template. Email is used for user and
+// group grants and Domain for domain grants.
+type TemplateGrant struct {
+	Type   string `yaml:"type" json:"type"`
+	Role   string `yaml:"role" json:"role"`
+	Email  string `yaml:"email,omitempty" json:"email,omitempty"`
+	Domain string `yaml:"domain,omitempty" json:"domain,omitempty"`
+}
+
+// TemplateDir returns the permission template directory in configDir
+func TemplateDir(configDir string) string {
+	return filepath.Join(configDir, TemplateDirName)
+}
+
+// LoadTemplate reads the template called name from dir
+func LoadTemplate(dir, name string) (*PermissionTemplate, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return nil, templateError(fmt.Sprintf("Invalid template name %q", name))
+	}
+	for _, ext := range templateExtensions {
+		f, err := os.Open(filepath.Join(dir, name+ext))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, templateError(fmt.Sprintf("Cannot read template %s: %s", name, err))
+		}
+		defer f.Close()
+		return ParseTemplate(f, name)
+	}
+
+	names, _ := templateNames(dir)
+	return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("No permission template named %q in %s", name, dir)).
+		WithContext("available", names).
+		Build())
+}
+
+// ListTemplates reads every template in dir, sorted by name. A missing
+// directory has no templates.
+func ListTemplates(dir string) ([]*PermissionTemplate, error) {
+	names, err := templateNames(dir)
+	if err != nil {
+		return nil, templateError(fmt.Sprintf("Cannot read template directory: %s", err))
+	}
+	templates := make([]*PermissionTemplate, 0, len(names))
+	for _, name := range names {
+		t, err := LoadTemplate(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func templateNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		name := strings.TrimSuffix(e.Name(), ext)
+		if e.IsDir() || seen[name] {
+			continue
+		}
+		for _, known := range templateExtensions {
+			if strings.EqualFold(ext, known) {
+				seen[name] = true
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ParseTemplate decodes and validates a YAML or JSON template. Unknown keys
+// are rejected so a misspelled field is not silently ignored.
+func ParseTemplate(r io.Reader, name string) (*PermissionTemplate, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	t := &PermissionTemplate{Name: name}
+	if err := dec.Decode(t); err != nil && err != io.EOF {
+		return nil, templateError(fmt.Sprintf("Invalid permission template %s: %s", name, err))
+	}
+	if len(t.Grants) == 0 {
+		return nil, templateError(fmt.Sprintf("Permission template %s has no grants", name))
+	}
+	// Grants are fully checked once variables are filled in; types and
+	// literal roles can be checked now
+	for i, g := range t.Grants {
+		var err error
+		switch g.Type {
+		case types.PermissionTypeUser, types.PermissionTypeGroup, types.PermissionTypeDomain, types.PermissionTypeAnyone:
+			if !templateVar.MatchString(g.Role) {
+				err = checkEditableRole(g.Role)
+			}
+		default:
+			err = fmt.Errorf("unknown grant type %q (user, group, domain, anyone)", g.Type)
+		}
+		if err != nil {
+			return nil, templateError(fmt.Sprintf("Permission template %s: grants[%d]: %s", name, i, err))
+		}
+	}
+	return t, nil
+}
+
+// Expand fills in the template's variables, vars overriding the template's
+// defaults, and returns its grants as permissions to compare a file's with
+func (t *PermissionTemplate) Expand(vars map[string]string) ([]*types.Permission, error) {
+	var missing []string
+	lookup := func(name string) (string, bool) {
+		if v, ok := vars[name]; ok {
+			return v, true
+		}
+		if v, ok := t.Variables[name]; ok {
+			return v, true
+		}
+		missing = append(missing, name)
+		return "", false
+	}
+
+	grants := make([]*types.Permission, 0, len(t.Grants))
+	seen := map[granteeKey]bool{}
+	for i, g := range t.Grants {
+		p, err := expandGrant(g, lookup)
+		if len(missing) > 0 {
+			continue
+		}
+		if err != nil {
+			return nil, templateError(fmt.Sprintf("Permission template %s: grants[%d]: %s", t.Name, i, err))
+		}
+		key := granteeOf(p, nil)
+		if seen[key] {
+			return nil, templateError(fmt.Sprintf("Permission template %s: %s %s is granted twice", t.Name, key.typ, key.principal))
+		}
+		seen[key] = true
+		grants = append(grants, p)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Permission template %s needs values for: %s", t.Name, strings.Join(dedupe(missing), ", "))).
+			WithContext("suggestedAction", "pass --var name=value for each, or set defaults under variables in the template").
+			Build())
+	}
+	return grants, nil
+}
+
+// expandGrant substitutes variables in a grant and validates the result
+func expandGrant(g TemplateGrant, lookup func(string) (string, bool)) (*types.Permission, error) {
+	expand := func(s string) string {
+		return templateVar.ReplaceAllStringFunc(s, func(ref string) string {
+			v, _ := lookup(templateVar.FindStringSubmatch(ref)[1])
+			return v
+		})
+	}
+	p := &types.Permission{
+		Type:         strings.TrimSpace(g.Type),
+		Role:         strings.TrimSpace(expand(g.Role)),
+		EmailAddress: strings.TrimSpace(expand(g.Email)),
+		Domain:       strings.TrimSpace(expand(g.Domain)),
+	}
+	switch p.Type {
+	case types.PermissionTypeUser, types.PermissionTypeGroup:
+		if !strings.Contains(p.EmailAddress, "@") || p.Domain != "" {
+			return nil, fmt.Errorf("%s grants need an email and no domain", p.Type)
+		}
+	case types.PermissionTypeDomain:
+		if p.Domain == "" || strings.Contains(p.Domain, "@") || p.EmailAddress != "" {
+			return nil, fmt.Errorf("domain grants need a domain name and no email")
+		}
+	case types.PermissionTypeAnyone:
+		if p.EmailAddress != "" || p.Domain != "" {
+			return nil, fmt.Errorf("anyone grants take no email or domain")
+		}
+	default:
+		return nil, fmt.Errorf("unknown grant type %q (user, group, domain, anyone)", p.Type)
+	}
+	if err := checkEditableRole(p.Role); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func dedupe(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func templateError(message string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, message).Build())
+}
+
+// TemplateOptions configures CheckTemplate and ApplyTemplate
+type TemplateOptions struct {
+	Recursive             bool // Include everything below a folder
+	Exact                 bool // Remove grants the template does not have
+	UseDomainAdminAccess  bool
+	SendNotificationEmail bool // Notify users and groups given new grants
+}
+
+// TemplateFileResult is the drift of one file from a template
+type TemplateFileResult struct {
+	FileID  string        `json:"fileId"`
+	Name    string        `json:"name,omitempty"`
+	Changes []*EditChange `json:"changes"`
+	Skipped []*EditChange `json:"skipped,omitempty"`
+	InSync  bool          `json:"inSync"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// TemplateReport lists the files checked against a template and the
+// changes that bring each one in line
+type TemplateReport struct {
+	Template string                `json:"template"`
+	FileID   string                `json:"fileId"`
+	Checked  int                   `json:"checked"`
+	Drifted  int                   `json:"drifted"`
+	Failed   int                   `json:"failed"`
+	Applied  bool                  `json:"applied"`
+	Files    []*TemplateFileResult `json:"files"`
+}
+
+// Changes returns the number of changes across all files
+func (r *TemplateReport) Changes() int {
+	n := 0
+	for _, f := range r.Files {
+		n += len(f.Changes)
+	}
+	return n
+}
+
+func (r *TemplateReport) Headers() []string {
+	return []string{"File", "Action", "Type", "Principal", "From", "To", "Status"}
+}
+
+func (r *TemplateReport) Rows() [][]string {
+	var rows [][]string
+	for _, f := range r.Files {
+		name := f.Name
+		if name == "" {
+			name = f.FileID
+		}
+		if f.Error != "" {
+			rows = append(rows, []string{name, "", "", "", "", "", "failed: " + f.Error})
+		}
+		for _, changes := range [][]*EditChange{f.Changes, f.Skipped} {
+			for _, c := range changes {
+				status := c.Status
+				if c.Error != "" {
+					status += ": " + c.Error
+				}
+				rows = append(rows, []string{name, c.Action, c.Type, c.Principal, c.FromRole, c.ToRole, status})
+			}
+		}
+	}
+	return rows
+}
+
+func (r *TemplateReport) EmptyMessage() string {
+	return fmt.Sprintf("All %d files match template %s", r.Checked, r.Template)
+}
+
+// CheckTemplate compares the grants on fileID, and with Recursive on
+// everything below it, with a template's expanded grants. Grants outside
+// the template are drift only with Exact. Files whose permissions cannot be
+// listed are reported with their error.
+func (m *Manager) CheckTemplate(ctx context.Context, reqCtx *types.RequestContext, template string, grants []*types.Permission, fileID string, opts TemplateOptions) (*TemplateReport, error) {
+	report := &TemplateReport{Template: template, FileID: fileID, Files: []*TemplateFileResult{}}
+	targets := []*TemplateFileResult{{FileID: fileID}}
+	if opts.Recursive {
+		files, err := m.findFilesInFolder(ctx, reqCtx, types.BulkOptions{FolderID: fileID, Recursive: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			targets = append(targets, &TemplateFileResult{FileID: f.Id, Name: f.Name})
+		}
+	}
+
+	for i, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions template check", Done: i + 1, Total: len(targets), Item: target.FileID})
+		m.diffTemplate(ctx, reqCtx, template, grants, target, opts)
+		report.Checked++
+		switch {
+		case target.Error != "":
+			report.Failed++
+		case !target.InSync:
+			report.Drifted++
+		}
+		if target.Error != "" || !target.InSync || len(target.Skipped) > 0 {
+			report.Files = append(report.Files, target)
+		}
+	}
+	return report, nil
+}
+
+// ApplyTemplate makes the changes CheckTemplate found, file by file. Each
+// file is compared again first, since grants added to a folder may now be
+// inherited by the files below it. Every change is attempted; an error is
+// returned if any failed.
+func (m *Manager) ApplyTemplate(ctx context.Context, reqCtx *types.RequestContext, report *TemplateReport, grants []*types.Permission, opts TemplateOptions) error {
+	failed := 0
+	for _, target := range report.Files {
+		if target.Error != "" || target.InSync {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if target.FileID != report.FileID {
+			m.diffTemplate(ctx, reqCtx, report.Template, grants, target, opts)
+			if target.Error != "" {
+				failed++
+				continue
+			}
+		}
+		err := m.ApplyEdits(ctx, reqCtx, target.FileID, target.Changes, EditApplyOptions{
+			SendNotificationEmail: opts.SendNotificationEmail,
+			UseDomainAdminAccess:  opts.UseDomainAdminAccess,
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			failed++
+		}
+	}
+	report.Applied = true
+	if failed > 0 {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeBatchPartialFailure,
+			fmt.Sprintf("Template %s could not be fully applied to %d of %d files", report.Template, failed, report.Drifted)).
+			WithContext("files", report.Files).
+			Build())
+	}
+	return nil
+}
+
+// diffTemplate fills in target's changes from its current grants
+func (m *Manager) diffTemplate(ctx context.Context, reqCtx *types.RequestContext, template string, grants []*types.Permission, target *TemplateFileResult, opts TemplateOptions) {
+	perms, err := m.List(ctx, reqCtx, target.FileID, ListOptions{UseDomainAdminAccess: opts.UseDomainAdminAccess, AccessDetails: true})
+	if err != nil {
+		target.Error = err.Error()
+		return
+	}
+	diff := diffPermissions(template, target.FileID, grants, perms, DiffOptions{KeepExtra: !opts.Exact})
+	target.Changes, target.Skipped, target.InSync = diff.Changes, diff.Skipped, diff.InSync
+}
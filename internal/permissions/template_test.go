@@ -0,0 +1,192 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+const projectTemplate = `description: Project folders
+variables:
+  team_group: eng@example.com
+grants:
+  - type: group
+    email: ${team_group}
+    role: writer
+  - type: group
+    email: ${project}-leads@example.com
+    role: ${lead_role}
+  - type: domain
+    domain: example.com
+    role: reader
+`
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"project-default.yaml": projectTemplate,
+		"public.json":          `{"grants":[{"type":"anyone","role":"reader"}]}`,
+		"notes.txt":            "not a template",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates, err := ListTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0].Name != "project-default" || templates[1].Name != "public" {
+		t.Fatalf("templates = %+v", templates)
+	}
+	if templates[0].Description != "Project folders" || len(templates[0].Grants) != 3 {
+		t.Errorf("project-default = %+v", templates[0])
+	}
+
+	_, err = LoadTemplate(dir, "missing")
+	appErr, ok := err.(*utils.AppError)
+	if !ok || len(appErr.CLIError.Context["available"].([]string)) != 2 {
+		t.Errorf("missing template err = %v", err)
+	}
+	if _, err := LoadTemplate(dir, "../project-default"); err == nil {
+		t.Error("template name with a path was accepted")
+	}
+	if templates, err := ListTemplates(filepath.Join(dir, "none")); err != nil || len(templates) != 0 {
+		t.Errorf("missing directory: %v, %v", templates, err)
+	}
+
+	invalid := map[string]string{
+		"unknown key":  "grants:\n  - type: user\n    email: a@example.com\n    role: reader\n    expires: 2026-01-01\n",
+		"owner role":   "grants:\n  - type: user\n    email: a@example.com\n    role: owner\n",
+		"unknown type": "grants:\n  - type: team\n    role: reader\n",
+		"no grants":    "description: empty\n",
+	}
+	for name, content := range invalid {
+		if _, err := ParseTemplate(strings.NewReader(content), "t"); err == nil {
+			t.Errorf("%s: template accepted", name)
+		}
+	}
+}
+
+func TestTemplateExpand(t *testing.T) {
+	tmpl, err := ParseTemplate(strings.NewReader(projectTemplate), "project-default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grants, err := tmpl.Expand(map[string]string{"project": "apollo", "lead_role": "fileOrganizer", "team_group": "apollo@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 3 || grants[0].EmailAddress != "apollo@example.com" ||
+		grants[1].EmailAddress != "apollo-leads@example.com" || grants[1].Role != "fileOrganizer" {
+		t.Errorf("grants = %+v %+v %+v", grants[0], grants[1], grants[2])
+	}
+
+	_, err = tmpl.Expand(nil)
+	if err == nil || !strings.Contains(err.Error(), "lead_role, project") {
+		t.Errorf("missing variables err = %v", err)
+	}
+	if _, err := tmpl.Expand(map[string]string{"project": "apollo", "lead_role": "owner"}); err == nil {
+		t.Error("owner role accepted from a variable")
+	}
+	if _, err := tmpl.Expand(map[string]string{"project": "eng", "lead_role": "reader", "team_group": "ENG-leads@example.com"}); err == nil {
+		t.Error("grantee listed twice accepted")
+	}
+}
+
+func TestCheckAndApplyTemplate(t *testing.T) {
+	inherited := []*drive.PermissionPermissionDetails{{PermissionType: "file", Role: "writer", Inherited: true, InheritedFrom: "folder"}}
+	perms := map[string][]*drive.Permission{
+		"folder": {
+			{Id: "o", Type: "user", Role: "owner", EmailAddress: "me@example.com"},
+			{Id: "g", Type: "group", Role: "writer", EmailAddress: "eng@example.com"},
+			{Id: "x", Type: "user", Role: "reader", EmailAddress: "old@example.com"},
+		},
+		"doc": {
+			{Id: "o", Type: "user", Role: "owner", EmailAddress: "me@example.com"},
+			{Id: "g", Type: "group", Role: "writer", EmailAddress: "eng@example.com", PermissionDetails: inherited},
+		},
+		"sheet": {
+			{Id: "g", Type: "group", Role: "writer", EmailAddress: "eng@example.com", PermissionDetails: inherited},
+			{Id: "d", Type: "domain", Role: "reader", Domain: "example.com"},
+		},
+	}
+	var requests []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/drive/v3/files")
+		switch {
+		case path == "":
+			_, _ = w.Write([]byte(`{"files":[{"id":"doc","name":"Plan"},{"id":"sheet","name":"Budget"}]}`))
+		case r.Method == http.MethodGet && strings.HasSuffix(path, "/permissions"):
+			id := strings.Trim(strings.TrimSuffix(path, "/permissions"), "/")
+			_ = json.NewEncoder(w).Encode(drive.PermissionList{Permissions: perms[id]})
+		case r.Method == http.MethodGet && path == "/folder/permissions/x":
+			_ = json.NewEncoder(w).Encode(perms["folder"][2])
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, "POST "+path+" "+string(body))
+			var p drive.Permission
+			_ = json.Unmarshal(body, &p)
+			// Grants on the folder are inherited below it
+			if path == "/folder/permissions" {
+				p.PermissionDetails = inherited
+				perms["doc"] = append(perms["doc"], &p)
+			}
+			_, _ = w.Write([]byte(`{"id":"new"}`))
+		case r.Method == http.MethodDelete:
+			requests = append(requests, "DELETE "+path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypePermissionOp)
+	grants := []*types.Permission{
+		{Type: "group", Role: "writer", EmailAddress: "eng@example.com"},
+		{Type: "domain", Role: "reader", Domain: "example.com"},
+	}
+	opts := TemplateOptions{Recursive: true, Exact: true}
+
+	report, err := mgr.CheckTemplate(ctx, reqCtx, "project", grants, "folder", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 3 || report.Drifted != 2 || len(report.Files) != 2 || report.Changes() != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+	if c := report.Files[0].Changes; c[0].Action != EditAdd || c[1].Action != EditRemove || c[1].PermissionID != "x" {
+		t.Errorf("folder changes = %v", c)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("check made changes: %v", requests)
+	}
+
+	if err := mgr.ApplyTemplate(ctx, reqCtx, report, grants, opts); err != nil {
+		t.Fatal(err)
+	}
+	// The domain grant added to the folder is inherited by doc, so doc
+	// needs nothing of its own
+	if len(requests) != 2 || !strings.HasPrefix(requests[0], "POST /folder/permissions") || requests[1] != "DELETE /folder/permissions/x" {
+		t.Errorf("requests = %v", requests)
+	}
+	if !report.Applied || !report.Files[1].InSync {
+		t.Errorf("report after apply = %+v, doc = %+v", report, report.Files[1])
+	}
+}
@@ -0,0 +1,299 @@
+package permissions
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// AuditTrendFileName is the file in the config directory holding the
+// summary of every audit run
+const AuditTrendFileName = "audit-trend.json"
+
+// MaxAuditSnapshots bounds the trend file; the oldest runs are dropped
+const MaxAuditSnapshots = 5000
+
+// Trend directions
+const (
+	TrendImproved  = "improved"
+	TrendDegraded  = "degraded"
+	TrendUnchanged = "unchanged"
+)
+
+// AuditTrendStateKind versions the audit trend history
+var AuditTrendStateKind = state.Register(&state.Kind{
+	Name:        "audit-trend",
+	Description: "Summaries of past permission audits",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultAuditTrendStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: AuditTrendFileName,
+})
+
+// AuditSnapshot summarizes one audit run: the findings per risk level and
+// per external domain. Runs with the same audit, folder and recursion form
+// one series.
+type AuditSnapshot struct {
+	Time      time.Time      `json:"time"`
+	Audit     string         `json:"audit"` // public, external, anyone-with-link or user:<email>
+	FolderID  string         `json:"folderId,omitempty"`
+	Recursive bool           `json:"recursive,omitempty"`
+	Files     int            `json:"files"`
+	Risk      map[string]int `json:"risk"`
+	Domains   map[string]int `json:"domains,omitempty"`
+}
+
+// NewAuditSnapshot summarizes an audit result
+func NewAuditSnapshot(audit string, opts types.AuditOptions, result *types.AuditResult, now time.Time) *AuditSnapshot {
+	snap := &AuditSnapshot{
+		Time:      now.UTC(),
+		Audit:     audit,
+		FolderID:  opts.FolderID,
+		Recursive: opts.Recursive,
+		Files:     len(result.Files),
+		Risk:      map[string]int{},
+		Domains:   map[string]int{},
+	}
+	for _, f := range result.Files {
+		if f.RiskLevel != "" {
+			snap.Risk[f.RiskLevel]++
+		}
+		for _, domain := range f.ExternalDomains {
+			snap.Domains[domain]++
+		}
+	}
+	return snap
+}
+
+// series identifies the snapshots comparable with this one
+func (s *AuditSnapshot) series() string {
+	return fmt.Sprintf("%s|%s|%t", s.Audit, s.FolderID, s.Recursive)
+}
+
+// score weighs the findings by risk, so one critical file counts more than
+// several low ones
+func (s *AuditSnapshot) score() int {
+	score := 0
+	for level, n := range s.Risk {
+		score += riskRank[level] * n
+	}
+	return score
+}
+
+// AuditTrendStore records audit snapshots in a local file
+type AuditTrendStore struct {
+	path string
+}
+
+type auditTrendFile struct {
+	state.Versioned
+	Snapshots []*AuditSnapshot `json:"snapshots"`
+}
+
+// NewAuditTrendStore returns a store backed by the given file
+func NewAuditTrendStore(path string) *AuditTrendStore {
+	return &AuditTrendStore{path: path}
+}
+
+// DefaultAuditTrendStore returns the store in the gdrv config directory
+func DefaultAuditTrendStore() (*AuditTrendStore, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditTrendStore(filepath.Join(dir, AuditTrendFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *AuditTrendStore) Path() string {
+	return s.path
+}
+
+// List returns all recorded snapshots, oldest first
+func (s *AuditTrendStore) List() ([]*AuditSnapshot, error) {
+	var file auditTrendFile
+	if _, err := state.Load(AuditTrendStateKind, s.path, &file); err != nil {
+		return nil, err
+	}
+	return file.Snapshots, nil
+}
+
+// Record appends a snapshot, dropping the oldest beyond MaxAuditSnapshots
+func (s *AuditTrendStore) Record(snap *AuditSnapshot) error {
+	snapshots, err := s.List()
+	if err != nil {
+		return err
+	}
+	snapshots = append(snapshots, snap)
+	if len(snapshots) > MaxAuditSnapshots {
+		snapshots = snapshots[len(snapshots)-MaxAuditSnapshots:]
+	}
+	return state.Save(AuditTrendStateKind, s.path, &auditTrendFile{Snapshots: snapshots})
+}
+
+// AuditTrendFilter selects the series shown by BuildAuditTrend
+type AuditTrendFilter struct {
+	Audit    string // Only this audit (empty = all)
+	FolderID string // Only audits of this folder (empty = all)
+	Last     int    // Runs per series (0 = all)
+}
+
+// AuditTrend shows how each audit's findings changed over its recent runs
+type AuditTrend struct {
+	Series []*AuditTrendSeries `json:"series"`
+}
+
+// AuditTrendSeries is the history of one audit of one scope. Change
+// compares the last run with the first one shown.
+type AuditTrendSeries struct {
+	Audit     string            `json:"audit"`
+	FolderID  string            `json:"folderId,omitempty"`
+	Recursive bool              `json:"recursive,omitempty"`
+	Runs      []*AuditSnapshot  `json:"runs"`
+	Change    *AuditTrendChange `json:"change,omitempty"`
+}
+
+// AuditTrendChange is the difference between two runs. Direction follows
+// the risk-weighted score, so fewer high-risk files is an improvement even
+// if more low-risk ones appeared.
+type AuditTrendChange struct {
+	Since     time.Time      `json:"since"`
+	Files     int            `json:"files"`
+	Risk      map[string]int `json:"risk"`
+	Domains   map[string]int `json:"domains,omitempty"`
+	Direction string         `json:"direction"`
+}
+
+// BuildAuditTrend groups snapshots into series, most recently run first,
+// keeping the last filter.Last runs of each
+func BuildAuditTrend(snapshots []*AuditSnapshot, filter AuditTrendFilter) *AuditTrend {
+	bySeries := map[string]*AuditTrendSeries{}
+	var order []string
+	for _, snap := range snapshots {
+		if (filter.Audit != "" && snap.Audit != filter.Audit) || (filter.FolderID != "" && snap.FolderID != filter.FolderID) {
+			continue
+		}
+		key := snap.series()
+		series, ok := bySeries[key]
+		if !ok {
+			series = &AuditTrendSeries{Audit: snap.Audit, FolderID: snap.FolderID, Recursive: snap.Recursive}
+			bySeries[key] = series
+			order = append(order, key)
+		}
+		series.Runs = append(series.Runs, snap)
+	}
+
+	trend := &AuditTrend{Series: []*AuditTrendSeries{}}
+	for _, key := range order {
+		series := bySeries[key]
+		sort.SliceStable(series.Runs, func(i, j int) bool { return series.Runs[i].Time.Before(series.Runs[j].Time) })
+		if filter.Last > 0 && len(series.Runs) > filter.Last {
+			series.Runs = series.Runs[len(series.Runs)-filter.Last:]
+		}
+		if len(series.Runs) > 1 {
+			series.Change = compareSnapshots(series.Runs[0], series.Runs[len(series.Runs)-1])
+		}
+		trend.Series = append(trend.Series, series)
+	}
+	sort.SliceStable(trend.Series, func(i, j int) bool {
+		a, b := trend.Series[i].Runs, trend.Series[j].Runs
+		return a[len(a)-1].Time.After(b[len(b)-1].Time)
+	})
+	return trend
+}
+
+func compareSnapshots(from, to *AuditSnapshot) *AuditTrendChange {
+	change := &AuditTrendChange{
+		Since:     from.Time,
+		Files:     to.Files - from.Files,
+		Risk:      diffCounts(from.Risk, to.Risk),
+		Domains:   diffCounts(from.Domains, to.Domains),
+		Direction: TrendUnchanged,
+	}
+	switch delta := to.score() - from.score(); {
+	case delta < 0:
+		change.Direction = TrendImproved
+	case delta > 0:
+		change.Direction = TrendDegraded
+	}
+	return change
+}
+
+// diffCounts returns the non-zero differences between two count maps
+func diffCounts(from, to map[string]int) map[string]int {
+	diff := map[string]int{}
+	for k, n := range to {
+		diff[k] += n
+	}
+	for k, n := range from {
+		diff[k] -= n
+	}
+	for k, n := range diff {
+		if n == 0 {
+			delete(diff, k)
+		}
+	}
+	return diff
+}
+
+func (t *AuditTrend) Headers() []string {
+	return []string{"Audit", "Folder", "Time", "Files", "Critical", "High", "Medium", "Low", "Change"}
+}
+
+// Rows lists every run of every series; each run's change is relative to
+// the run before it, and the series ends with its overall direction
+func (t *AuditTrend) Rows() [][]string {
+	var rows [][]string
+	for _, s := range t.Series {
+		folder := s.FolderID
+		if folder == "" {
+			folder = "(all)"
+		}
+		if s.Recursive {
+			folder += " (recursive)"
+		}
+		for i, run := range s.Runs {
+			change := ""
+			if i > 0 {
+				change = signed(run.Files - s.Runs[i-1].Files)
+				if i == len(s.Runs)-1 && s.Change != nil {
+					change += ", " + s.Change.Direction
+				}
+			}
+			rows = append(rows, []string{
+				s.Audit,
+				folder,
+				types.DisplayTime(run.Time.Format(time.RFC3339)),
+				types.DisplayCount(int64(run.Files)),
+				strconv.Itoa(run.Risk[types.RiskLevelCritical]),
+				strconv.Itoa(run.Risk[types.RiskLevelHigh]),
+				strconv.Itoa(run.Risk[types.RiskLevelMedium]),
+				strconv.Itoa(run.Risk[types.RiskLevelLow]),
+				change,
+			})
+		}
+	}
+	return rows
+}
+
+func (t *AuditTrend) EmptyMessage() string {
+	return "No audit runs recorded yet"
+}
+
+func signed(n int) string {
+	if n > 0 {
+		return "+" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,96 @@
+package permissions
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestNewAuditSnapshot(t *testing.T) {
+	result := &types.AuditResult{Files: []*types.FilePermissionInfo{
+		{FileID: "a", RiskLevel: types.RiskLevelHigh, ExternalDomains: []string{"partner.com"}},
+		{FileID: "b", RiskLevel: types.RiskLevelHigh, ExternalDomains: []string{"partner.com", "gmail.com"}},
+		{FileID: "c", RiskLevel: types.RiskLevelLow},
+	}}
+	snap := NewAuditSnapshot("external", types.AuditOptions{FolderID: "f", Recursive: true}, result, time.Now())
+	if snap.Files != 3 || snap.Risk[types.RiskLevelHigh] != 2 || snap.Risk[types.RiskLevelLow] != 1 {
+		t.Errorf("risk = %+v", snap)
+	}
+	if snap.Domains["partner.com"] != 2 || snap.Domains["gmail.com"] != 1 || snap.FolderID != "f" || !snap.Recursive {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+func TestAuditTrendStore(t *testing.T) {
+	store := NewAuditTrendStore(filepath.Join(t.TempDir(), AuditTrendFileName))
+	if snaps, err := store.List(); err != nil || len(snaps) != 0 {
+		t.Fatalf("empty store = %v, %v", snaps, err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := store.Record(&AuditSnapshot{Audit: "public", Files: i, Risk: map[string]int{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snaps, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 3 || snaps[0].Files != 0 || snaps[2].Files != 2 {
+		t.Errorf("snapshots = %+v", snaps)
+	}
+}
+
+func TestBuildAuditTrend(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2024, 1, n, 6, 0, 0, 0, time.UTC) }
+	snap := func(audit, folder string, n, high, low int, domains map[string]int) *AuditSnapshot {
+		return &AuditSnapshot{
+			Time: day(n), Audit: audit, FolderID: folder, Files: high + low,
+			Risk:    map[string]int{types.RiskLevelHigh: high, types.RiskLevelLow: low},
+			Domains: domains,
+		}
+	}
+	snapshots := []*AuditSnapshot{
+		snap("external", "f", 1, 5, 0, map[string]int{"partner.com": 5}),
+		snap("public", "", 2, 1, 0, nil),
+		snap("external", "f", 8, 3, 2, map[string]int{"partner.com": 3, "gmail.com": 2}),
+		snap("external", "f", 15, 2, 4, map[string]int{"partner.com": 2, "gmail.com": 4}),
+		snap("public", "", 9, 2, 0, nil),
+		snap("external", "other", 3, 1, 0, nil),
+	}
+
+	trend := BuildAuditTrend(snapshots, AuditTrendFilter{Last: 2})
+	if len(trend.Series) != 3 {
+		t.Fatalf("series = %d, want 3", len(trend.Series))
+	}
+	ext := trend.Series[0]
+	if ext.Audit != "external" || ext.FolderID != "f" || len(ext.Runs) != 2 || !ext.Runs[0].Time.Equal(day(8)) {
+		t.Fatalf("first series = %+v", ext)
+	}
+	// 3 high + 2 low (score 11) to 2 high + 4 low (score 10)
+	c := ext.Change
+	if c.Direction != TrendImproved || c.Files != 1 || c.Risk[types.RiskLevelHigh] != -1 || c.Risk[types.RiskLevelLow] != 2 {
+		t.Errorf("change = %+v", c)
+	}
+	if c.Domains["gmail.com"] != 2 || c.Domains["partner.com"] != -1 || !c.Since.Equal(day(8)) {
+		t.Errorf("domain change = %+v", c.Domains)
+	}
+	if pub := trend.Series[1]; pub.Audit != "public" || pub.Change.Direction != TrendDegraded {
+		t.Errorf("public series = %+v", pub)
+	}
+	if single := trend.Series[2]; single.Change != nil {
+		t.Errorf("single-run series has a change: %+v", single.Change)
+	}
+	if rows := trend.Rows(); len(rows) != 5 || rows[1][8] != "+1, improved" {
+		t.Errorf("rows = %v", rows)
+	}
+
+	filtered := BuildAuditTrend(snapshots, AuditTrendFilter{Audit: "external", FolderID: "f"})
+	if len(filtered.Series) != 1 || len(filtered.Series[0].Runs) != 3 || filtered.Series[0].Change.Direction != TrendImproved {
+		t.Errorf("filtered = %+v", filtered.Series)
+	}
+	if empty := BuildAuditTrend(nil, AuditTrendFilter{}); len(empty.Series) != 0 || empty.Rows() != nil {
+		t.Errorf("empty trend = %+v", empty)
+	}
+}
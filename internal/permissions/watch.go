@@ -0,0 +1,153 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// Snapshot records the permissions on a folder and the files in it (and in
+// its subfolders when recursive)
+func (m *Manager) Snapshot(ctx context.Context, reqCtx *types.RequestContext, folderID string, recursive bool) (*types.PermissionSnapshot, error) {
+	getCall := m.client.Service().Files.Get(folderID).Fields("id,name,webViewLink")
+	getCall = m.shaper.ShapeFilesGet(getCall, reqCtx)
+	folder, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return getCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := m.findFilesInFolder(ctx, reqCtx, types.BulkOptions{FolderID: folderID, Recursive: recursive})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &types.PermissionSnapshot{
+		FolderID:  folderID,
+		Recursive: recursive,
+		TakenAt:   time.Now().UTC().Format(time.RFC3339),
+		Files:     make(map[string]*types.SnapshotFile, len(files)+1),
+	}
+	scan := append([]*drive.File{folder}, files...)
+	for i, file := range scan {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "permissions snapshot", Done: i + 1, Total: len(scan), Item: file.Name})
+		perms, err := m.List(ctx, reqCtx, file.Id, ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Files[file.Id] = &types.SnapshotFile{
+			Name:        file.Name,
+			WebViewLink: file.WebViewLink,
+			Permissions: perms,
+		}
+	}
+	return snapshot, nil
+}
+
+// DetectDrift returns the exposures in current that baseline does not
+// have: public links on files that had none, and external grantees (outside
+// internal) a file did not have. Removed or narrowed access is not reported.
+func DetectDrift(baseline, current *types.PermissionSnapshot, internal *DomainMatcher) []*types.PermissionAlert {
+	alerts := []*types.PermissionAlert{}
+	for fileID, file := range current.Files {
+		var before map[granteeKey]string
+		if old := baseline.Files[fileID]; old != nil {
+			before = granteeRoles(old.Permissions, nil)
+		}
+
+		for key, role := range granteeRoles(file.Permissions, nil) {
+			if _, existed := before[key]; existed {
+				continue
+			}
+
+			var kind string
+			switch key.typ {
+			case types.PermissionTypeAnyone:
+				kind = types.AlertNewPublicLink
+			case types.PermissionTypeUser, types.PermissionTypeGroup:
+				if isInternalEmail(key.principal, internal) {
+					continue
+				}
+				kind = types.AlertNewExternalGrantee
+			case types.PermissionTypeDomain:
+				if internal.IsZero() || internal.MatchDomain(key.principal) {
+					continue
+				}
+				kind = types.AlertNewExternalGrantee
+			default:
+				continue
+			}
+
+			alerts = append(alerts, &types.PermissionAlert{
+				Kind:        kind,
+				FileID:      fileID,
+				FileName:    file.Name,
+				WebViewLink: file.WebViewLink,
+				Grantee:     key.principal,
+				Type:        key.typ,
+				Role:        role,
+			})
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].FileName != alerts[j].FileName {
+			return alerts[i].FileName < alerts[j].FileName
+		}
+		return alerts[i].Key() < alerts[j].Key()
+	})
+	return alerts
+}
+
+// SnapshotStateKind versions permission snapshot files
+var SnapshotStateKind = state.Register(&state.Kind{
+	Name:        "permission-snapshot",
+	Description: "Baseline written by permissions watch",
+	Current:     1,
+})
+
+// snapshotFile is the versioned on-disk form of a PermissionSnapshot
+type snapshotFile struct {
+	state.Versioned
+	*types.PermissionSnapshot
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot
+func LoadSnapshot(path string) (*types.PermissionSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := snapshotFile{PermissionSnapshot: &types.PermissionSnapshot{}}
+	if err := state.Unmarshal(SnapshotStateKind, data, &file); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			return nil, appErr
+		}
+		return nil, fmt.Errorf("invalid permission snapshot %s: %w", path, err)
+	}
+	snapshot := file.PermissionSnapshot
+	if snapshot.Files == nil {
+		snapshot.Files = map[string]*types.SnapshotFile{}
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot writes a snapshot as JSON
+func SaveSnapshot(path string, snapshot *types.PermissionSnapshot) error {
+	if err := state.Save(SnapshotStateKind, path, &snapshotFile{PermissionSnapshot: snapshot}); err != nil {
+		return fmt.Errorf("failed to save permission snapshot: %w", err)
+	}
+	return nil
+}
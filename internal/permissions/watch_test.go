@@ -0,0 +1,72 @@
+package permissions
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestDetectDrift(t *testing.T) {
+	baseline := &types.PermissionSnapshot{Files: map[string]*types.SnapshotFile{
+		"f1": {Name: "Budget", Permissions: []*types.Permission{
+			{Type: "user", Role: "owner", EmailAddress: "owner@example.com"},
+			{Type: "user", Role: "reader", EmailAddress: "partner@vendor.com"},
+		}},
+	}}
+	current := &types.PermissionSnapshot{Files: map[string]*types.SnapshotFile{
+		"f1": {Name: "Budget", Permissions: []*types.Permission{
+			{Type: "user", Role: "owner", EmailAddress: "owner@example.com"},
+			{Type: "user", Role: "writer", EmailAddress: "Partner@vendor.com"}, // role change only
+			{Type: "user", Role: "reader", EmailAddress: "new@example.com"},    // internal
+			{Type: "anyone", Role: "reader"},
+		}},
+		"f2": {Name: "New file", Permissions: []*types.Permission{
+			{Type: "group", Role: "reader", EmailAddress: "ext@other.org"},
+			{Type: "domain", Role: "reader", Domain: "mail.example.com"},
+			{Type: "domain", Role: "reader", Domain: "other.org"},
+		}},
+	}}
+
+	alerts := DetectDrift(baseline, current, NewDomainMatcher("example.com", "*.example.com"))
+	got := map[string]bool{}
+	for _, a := range alerts {
+		got[a.Key()] = true
+	}
+	want := []string{
+		"new-public-link|f1|anyone|anyone",
+		"new-external-grantee|f2|group|ext@other.org",
+		"new-external-grantee|f2|domain|other.org",
+	}
+	if len(alerts) != len(want) {
+		t.Errorf("got %d alerts, want %d: %v", len(alerts), len(want), got)
+	}
+	for _, key := range want {
+		if !got[key] {
+			t.Errorf("missing alert %s", key)
+		}
+	}
+
+	// Without internal domains only public links are reported
+	alerts = DetectDrift(baseline, current, NewDomainMatcher())
+	if len(alerts) != 1 || alerts[0].Kind != types.AlertNewPublicLink {
+		t.Errorf("alerts without internal domains = %+v", alerts)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	snapshot := &types.PermissionSnapshot{FolderID: "folder", Files: map[string]*types.SnapshotFile{
+		"f1": {Name: "Doc", Permissions: []*types.Permission{{ID: "p1", Type: "anyone", Role: "reader"}}},
+	}}
+	if err := SaveSnapshot(path, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FolderID != "folder" || loaded.Files["f1"].Permissions[0].Type != "anyone" {
+		t.Errorf("loaded = %+v", loaded)
+	}
+}
@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Auditor runs the permission audits; *permissions.Manager implements it
+type Auditor interface {
+	AuditPublic(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error)
+	AuditExternal(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error)
+	AuditAnyoneWithLink(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error)
+}
+
+// Finding is a file a rule reported
+type Finding struct {
+	RuleID          string   `json:"ruleId"`
+	Level           string   `json:"level"`
+	Message         string   `json:"message"`
+	FileID          string   `json:"fileId"`
+	FileName        string   `json:"fileName"`
+	WebViewLink     string   `json:"webViewLink,omitempty"`
+	RiskLevel       string   `json:"riskLevel,omitempty"`
+	RiskReasons     []string `json:"riskReasons,omitempty"`
+	ExternalDomains []string `json:"externalDomains,omitempty"`
+}
+
+// Report is the outcome of a policy check
+type Report struct {
+	Rules    []Rule         `json:"rules"`
+	Findings []*Finding     `json:"findings"`
+	Counts   map[string]int `json:"counts"` // Findings per level
+	FailOn   string         `json:"failOn"`
+	Failed   bool           `json:"failed"` // Some finding is at or above FailOn
+}
+
+// Check runs every rule of cfg with auditor and collects their findings.
+// External rules need cfg.InternalDomains.
+func Check(ctx context.Context, auditor Auditor, reqCtx *types.RequestContext, cfg *Config) (*Report, error) {
+	report := newReport(cfg.Rules, cfg.FailOn)
+	opts := types.AuditOptions{
+		FolderID:        cfg.FolderID,
+		Recursive:       cfg.Recursive,
+		InternalDomains: cfg.InternalDomains,
+		FollowShortcuts: cfg.FollowShortcuts,
+	}
+	for _, rule := range cfg.Rules {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var result *types.AuditResult
+		var err error
+		switch rule.Audit {
+		case AuditPublic:
+			result, err = auditor.AuditPublic(ctx, reqCtx, opts)
+		case AuditExternal:
+			if len(cfg.InternalDomains) == 0 {
+				return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+					fmt.Sprintf("Rule %s audits external shares but the policy has no internalDomains", rule.ID)).
+					WithContext("suggestedAction", "add internalDomains to the policy config or to the profile with 'gdrv config domains add'").
+					Build())
+			}
+			result, err = auditor.AuditExternal(ctx, reqCtx, opts)
+		case AuditAnyoneWithLink:
+			result, err = auditor.AuditAnyoneWithLink(ctx, reqCtx, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.add(rule, result)
+	}
+	return report, nil
+}
+
+// AuditReport reports the result of one audit command as findings of a
+// single error-level rule named after the audit, for --output sarif
+func AuditReport(audit string, result *types.AuditResult) *Report {
+	rule := Rule{ID: audit, Audit: audit, Level: types.SARIFLevelError}
+	report := newReport([]Rule{rule}, FailNone)
+	report.add(rule, result)
+	return report
+}
+
+func newReport(rules []Rule, failOn string) *Report {
+	return &Report{
+		Rules:    rules,
+		Findings: []*Finding{},
+		Counts:   map[string]int{},
+		FailOn:   failOn,
+	}
+}
+
+func (r *Report) add(rule Rule, result *types.AuditResult) {
+	for _, f := range result.Files {
+		if rule.MinRisk != "" && !permissions.RiskAtLeast(f.RiskLevel, rule.MinRisk) {
+			continue
+		}
+		r.Findings = append(r.Findings, &Finding{
+			RuleID:          rule.ID,
+			Level:           rule.Level,
+			Message:         findingMessage(rule, f),
+			FileID:          f.FileID,
+			FileName:        f.FileName,
+			WebViewLink:     f.WebViewLink,
+			RiskLevel:       f.RiskLevel,
+			RiskReasons:     f.RiskReasons,
+			ExternalDomains: f.ExternalDomains,
+		})
+		r.Counts[rule.Level]++
+		if r.FailOn != FailNone && levelRank[rule.Level] >= levelRank[r.FailOn] {
+			r.Failed = true
+		}
+	}
+}
+
+// findingMessage describes what a rule found on a file, e.g.
+// "budget.xlsx is shared with external domains partner.com (high risk)"
+func findingMessage(rule Rule, f *types.FilePermissionInfo) string {
+	var what string
+	switch rule.Audit {
+	case AuditPublic:
+		what = "is shared publicly"
+	case AuditExternal:
+		what = "is shared outside the organization"
+		if len(f.ExternalDomains) > 0 {
+			what = "is shared with external domains " + strings.Join(f.ExternalDomains, ", ")
+		}
+	case AuditAnyoneWithLink:
+		what = "is shared with anyone who has the link"
+	}
+	message := f.FileName + " " + what
+	if f.RiskLevel != "" {
+		message += " (" + f.RiskLevel + " risk)"
+	}
+	if rule.Description != "" {
+		message += ". " + rule.Description
+	}
+	return message
+}
+
+func (r *Report) Headers() []string {
+	return []string{"Rule", "Level", "File ID", "Name", "Risk", "Reasons"}
+}
+
+func (r *Report) Rows() [][]string {
+	rows := make([][]string, len(r.Findings))
+	for i, f := range r.Findings {
+		rows[i] = []string{f.RuleID, f.Level, f.FileID, f.FileName, f.RiskLevel, strings.Join(f.RiskReasons, "; ")}
+	}
+	return rows
+}
+
+func (r *Report) EmptyMessage() string {
+	return "No policy findings (" + strconv.Itoa(len(r.Rules)) + " rules checked)"
+}
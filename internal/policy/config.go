@@ -0,0 +1,120 @@
+// Package policy runs the permission audits as policy rules, headless from
+// a config file, and reports their findings as a table, JSON or a SARIF log
+// for code scanning platforms.
+package policy
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Audits a rule can run, matching the permissions audit commands
+const (
+	AuditPublic         = "public"
+	AuditExternal       = "external"
+	AuditAnyoneWithLink = "anyone-with-link"
+)
+
+// FailNone never fails a check, whatever it finds
+const FailNone = "none"
+
+// levelRank orders SARIF levels; a check fails on findings at or above
+// its FailOn level
+var levelRank = map[string]int{
+	types.SARIFLevelNote:    1,
+	types.SARIFLevelWarning: 2,
+	types.SARIFLevelError:   3,
+}
+
+// Config is a policy: the rules to check and where to check them
+type Config struct {
+	FolderID        string   `yaml:"folderId,omitempty" json:"folderId,omitempty"` // Limit the audits to a folder
+	Recursive       bool     `yaml:"recursive,omitempty" json:"recursive,omitempty"`
+	DriveID         string   `yaml:"driveId,omitempty" json:"driveId,omitempty"` // Shared Drive to audit
+	InternalDomains []string `yaml:"internalDomains,omitempty" json:"internalDomains,omitempty"`
+	FollowShortcuts bool     `yaml:"followShortcuts,omitempty" json:"followShortcuts,omitempty"`
+	FailOn          string   `yaml:"failOn,omitempty" json:"failOn,omitempty"` // error (default), warning, note or none
+	Rules           []Rule   `yaml:"rules" json:"rules"`
+}
+
+// Rule reports the files an audit finds at a SARIF level
+type Rule struct {
+	ID          string `yaml:"id,omitempty" json:"id"` // Defaults to the audit name
+	Audit       string `yaml:"audit" json:"audit"`
+	Level       string `yaml:"level,omitempty" json:"level"`                       // error (default), warning or note
+	MinRisk     string `yaml:"minRisk,omitempty" json:"minRisk,omitempty"`         // Skip files below this risk level
+	Description string `yaml:"description,omitempty" json:"description,omitempty"` // Shown with the rule's findings
+}
+
+// LoadConfig reads the policy config at path
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, configError(fmt.Sprintf("Cannot read policy config: %s", err))
+	}
+	defer f.Close()
+	return ParseConfig(f)
+}
+
+// ParseConfig decodes and validates a YAML or JSON policy config, filling
+// in defaults. Unknown keys are rejected so a misspelled field is not
+// silently ignored.
+func ParseConfig(r io.Reader) (*Config, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	cfg := &Config{}
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return nil, configError(fmt.Sprintf("Invalid policy config: %s", err))
+	}
+	if len(cfg.Rules) == 0 {
+		return nil, configError("Policy config has no rules")
+	}
+	if cfg.FailOn == "" {
+		cfg.FailOn = types.SARIFLevelError
+	}
+	if cfg.FailOn != FailNone && levelRank[cfg.FailOn] == 0 {
+		return nil, configError(fmt.Sprintf("Invalid failOn %q: use error, warning, note or none", cfg.FailOn))
+	}
+	for _, d := range cfg.InternalDomains {
+		if err := permissions.ValidateDomainPattern(d); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		switch rule.Audit {
+		case AuditPublic, AuditExternal, AuditAnyoneWithLink:
+		default:
+			return nil, configError(fmt.Sprintf("Rule %d: unknown audit %q: use public, external or anyone-with-link", i+1, rule.Audit))
+		}
+		if rule.ID == "" {
+			rule.ID = rule.Audit
+		}
+		if seen[rule.ID] {
+			return nil, configError(fmt.Sprintf("Rule %d: duplicate rule id %q", i+1, rule.ID))
+		}
+		seen[rule.ID] = true
+		if rule.Level == "" {
+			rule.Level = types.SARIFLevelError
+		}
+		if levelRank[rule.Level] == 0 {
+			return nil, configError(fmt.Sprintf("Rule %s: invalid level %q: use error, warning or note", rule.ID, rule.Level))
+		}
+		if rule.MinRisk != "" && !permissions.ValidRiskLevel(rule.MinRisk) {
+			return nil, configError(fmt.Sprintf("Rule %s: invalid minRisk %q: use low, medium, high or critical", rule.ID, rule.MinRisk))
+		}
+	}
+	return cfg, nil
+}
+
+func configError(message string) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, message).Build())
+}
@@ -0,0 +1,189 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`
+folderId: folder1
+internalDomains: [example.com]
+rules:
+  - audit: public
+  - id: external-high
+    audit: external
+    level: warning
+    minRisk: high
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.FailOn != types.SARIFLevelError {
+		t.Errorf("failOn = %q, want error by default", cfg.FailOn)
+	}
+	if rule := cfg.Rules[0]; rule.ID != AuditPublic || rule.Level != types.SARIFLevelError {
+		t.Errorf("first rule = %+v, want id and level defaulted", rule)
+	}
+
+	invalid := map[string]string{
+		"no rules":      `folderId: x`,
+		"unknown audit": "rules:\n  - audit: everything",
+		"bad level":     "rules:\n  - audit: public\n    level: fatal",
+		"bad risk":      "rules:\n  - audit: public\n    minRisk: severe",
+		"duplicate id":  "rules:\n  - audit: public\n  - audit: public",
+		"bad failOn":    "failOn: always\nrules:\n  - audit: public",
+		"unknown key":   "rules:\n  - audit: public\n    severity: high",
+	}
+	for name, config := range invalid {
+		_, err := ParseConfig(strings.NewReader(config))
+		appErr, ok := err.(*utils.AppError)
+		if !ok || appErr.CLIError.Code != utils.ErrCodeInvalidArgument {
+			t.Errorf("%s: err = %v, want INVALID_ARGUMENT", name, err)
+		}
+	}
+}
+
+// fakeAuditor returns canned results per audit
+type fakeAuditor struct {
+	results map[string]*types.AuditResult
+	opts    types.AuditOptions
+}
+
+func (a *fakeAuditor) AuditPublic(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error) {
+	a.opts = opts
+	return a.results[AuditPublic], nil
+}
+
+func (a *fakeAuditor) AuditExternal(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error) {
+	a.opts = opts
+	return a.results[AuditExternal], nil
+}
+
+func (a *fakeAuditor) AuditAnyoneWithLink(ctx context.Context, reqCtx *types.RequestContext, opts types.AuditOptions) (*types.AuditResult, error) {
+	a.opts = opts
+	return a.results[AuditAnyoneWithLink], nil
+}
+
+func TestCheck(t *testing.T) {
+	auditor := &fakeAuditor{results: map[string]*types.AuditResult{
+		AuditPublic: {Files: []*types.FilePermissionInfo{
+			{FileID: "f1", FileName: "budget.xlsx", RiskLevel: types.RiskLevelCritical, WebViewLink: "https://docs.google.com/f1"},
+		}},
+		AuditExternal: {Files: []*types.FilePermissionInfo{
+			{FileID: "f2", FileName: "plan.doc", RiskLevel: types.RiskLevelHigh, ExternalDomains: []string{"partner.com"}},
+			{FileID: "f3", FileName: "notes.txt", RiskLevel: types.RiskLevelLow},
+		}},
+	}}
+	cfg, err := ParseConfig(strings.NewReader(`
+folderId: folder1
+internalDomains: [example.com]
+failOn: error
+rules:
+  - audit: external
+    level: warning
+    minRisk: medium
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqCtx := &types.RequestContext{}
+
+	report, err := Check(context.Background(), auditor, reqCtx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auditor.opts.FolderID != "folder1" || auditor.opts.InternalDomains[0] != "example.com" {
+		t.Errorf("audit options = %+v", auditor.opts)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].FileID != "f2" {
+		t.Fatalf("findings = %+v, want only the high-risk external share", report.Findings)
+	}
+	if report.Failed {
+		t.Error("a warning should not fail a policy that fails on error")
+	}
+	if want := "plan.doc is shared with external domains partner.com (high risk)"; report.Findings[0].Message != want {
+		t.Errorf("message = %q, want %q", report.Findings[0].Message, want)
+	}
+
+	cfg.Rules = append(cfg.Rules, Rule{ID: AuditPublic, Audit: AuditPublic, Level: types.SARIFLevelError})
+	report, err = Check(context.Background(), auditor, reqCtx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Failed || report.Counts[types.SARIFLevelError] != 1 || report.Counts[types.SARIFLevelWarning] != 1 {
+		t.Errorf("report = %+v, want failed with one error and one warning", report)
+	}
+
+	cfg.InternalDomains = nil
+	if _, err := Check(context.Background(), auditor, reqCtx, cfg); err == nil {
+		t.Error("an external rule without internal domains should be rejected")
+	}
+}
+
+func TestReportSARIF(t *testing.T) {
+	report := AuditReport(AuditPublic, &types.AuditResult{Files: []*types.FilePermissionInfo{
+		{FileID: "f1", FileName: "budget.xlsx", RiskLevel: types.RiskLevelCritical, RiskReasons: []string{"public"}},
+	}})
+	if report.Failed {
+		t.Error("an audit report should never fail")
+	}
+
+	data, err := json.Marshal(report.SARIF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "gdrv" {
+		t.Fatalf("log = %s", data)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != AuditPublic {
+		t.Errorf("rules = %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("results = %+v", run.Results)
+	}
+	result := run.Results[0]
+	if result.RuleID != AuditPublic || result.Level != types.SARIFLevelError {
+		t.Errorf("result = %+v", result)
+	}
+	if uri := result.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "https://drive.google.com/open?id=f1" {
+		t.Errorf("uri = %q", uri)
+	}
+	if fp := result.PartialFingerprints[fingerprintKey]; fp != "public:f1" {
+		t.Errorf("fingerprint = %q", fp)
+	}
+}
@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/pkg/version"
+)
+
+// informationURI is where the SARIF log says to read about gdrv
+const informationURI = "https://github.com/dl-alexandre/Google-Drive-CLI"
+
+// fingerprintKey names the partial fingerprint that lets code scanning
+// platforms match a finding across runs: the same rule on the same file
+const fingerprintKey = "gdrvFinding/v1"
+
+// auditDescriptions describe each audit's rules in the SARIF log
+var auditDescriptions = map[string]struct{ short, help string }{
+	AuditPublic: {
+		"File is shared publicly",
+		"Anyone on the internet can find or open the file. Remove the 'anyone' permission, e.g. with 'gdrv permissions bulk remove-public'.",
+	},
+	AuditExternal: {
+		"File is shared outside the organization",
+		"Users or domains outside the policy's internal domains have access. Review the grants with 'gdrv permissions report <file-id>'.",
+	},
+	AuditAnyoneWithLink: {
+		"File is shared with anyone who has the link",
+		"Anyone with the link can open the file. Restrict the link or share with named users instead.",
+	},
+}
+
+// SARIF returns the report as a SARIF log, with one SARIF rule per policy
+// rule and one result per finding, located at the file's Drive URL
+func (r *Report) SARIF() *types.SARIFLog {
+	driver := types.SARIFDriver{
+		Name:           "gdrv",
+		Version:        version.Version,
+		InformationURI: informationURI,
+		Rules:          make([]types.SARIFRule, len(r.Rules)),
+	}
+	for i, rule := range r.Rules {
+		desc := auditDescriptions[rule.Audit]
+		sarifRule := types.SARIFRule{
+			ID:                   rule.ID,
+			Name:                 rule.Audit,
+			ShortDescription:     types.SARIFMessage{Text: desc.short},
+			Help:                 &types.SARIFMessage{Text: desc.help},
+			DefaultConfiguration: &types.SARIFRuleConfig{Level: rule.Level},
+		}
+		if rule.Description != "" {
+			sarifRule.FullDescription = &types.SARIFMessage{Text: rule.Description}
+		}
+		if rule.MinRisk != "" {
+			sarifRule.Properties = map[string]interface{}{"minRisk": rule.MinRisk}
+		}
+		driver.Rules[i] = sarifRule
+	}
+
+	results := make([]types.SARIFResult, len(r.Findings))
+	for i, f := range r.Findings {
+		uri := f.WebViewLink
+		if uri == "" {
+			uri = "https://drive.google.com/open?id=" + f.FileID
+		}
+		location := types.SARIFLocation{
+			PhysicalLocation: &types.SARIFPhysicalLocation{ArtifactLocation: types.SARIFArtifactLocation{URI: uri}},
+			LogicalLocations: []types.SARIFLogicalLocation{{
+				Name:               f.FileName,
+				FullyQualifiedName: "drive://" + f.FileID,
+				Kind:               "resource",
+			}},
+		}
+
+		properties := map[string]interface{}{"fileId": f.FileID}
+		if f.RiskLevel != "" {
+			properties["riskLevel"] = f.RiskLevel
+		}
+		if len(f.RiskReasons) > 0 {
+			properties["riskReasons"] = f.RiskReasons
+		}
+		if len(f.ExternalDomains) > 0 {
+			properties["externalDomains"] = f.ExternalDomains
+		}
+		results[i] = types.SARIFResult{
+			RuleID:              f.RuleID,
+			Level:               f.Level,
+			Message:             types.SARIFMessage{Text: f.Message},
+			Locations:           []types.SARIFLocation{location},
+			PartialFingerprints: map[string]string{fingerprintKey: f.RuleID + ":" + f.FileID},
+			Properties:          properties,
+		}
+	}
+
+	return &types.SARIFLog{
+		Version: types.SARIFVersion,
+		Schema:  types.SARIFSchema,
+		Runs:    []types.SARIFRun{{Tool: types.SARIFTool{Driver: driver}, Results: results}},
+	}
+}
@@ -0,0 +1,192 @@
+package query
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Builder assembles a Drive query from individual terms, quoting values so
+// callers never have to escape them by hand. Terms are joined with "and".
+type Builder struct {
+	terms []string
+}
+
+// NewBuilder creates an empty query builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Where adds a "field operator 'value'" term for string and date fields
+func (b *Builder) Where(field, operator, value string) *Builder {
+	b.terms = append(b.terms, field+" "+operator+" "+Quote(value))
+	return b
+}
+
+// WhereBool adds a "field = true|false" term
+func (b *Builder) WhereBool(field string, value bool) *Builder {
+	v := "false"
+	if value {
+		v = "true"
+	}
+	b.terms = append(b.terms, field+" = "+v)
+	return b
+}
+
+// In adds a "'value' in field" term, e.g. In("parents", folderID)
+func (b *Builder) In(field, value string) *Builder {
+	b.terms = append(b.terms, Quote(value)+" in "+field)
+	return b
+}
+
+// HasProperty adds a "field has { key='k' and value='v' }" term
+func (b *Builder) HasProperty(field, key, value string) *Builder {
+	b.terms = append(b.terms, field+" has { key="+Quote(key)+" and value="+Quote(value)+" }")
+	return b
+}
+
+// Raw adds a pre-built query fragment, parenthesized when it contains "or"
+func (b *Builder) Raw(fragment string) *Builder {
+	fragment = strings.TrimSpace(fragment)
+	if fragment == "" {
+		return b
+	}
+	if node, err := Parse(fragment); err == nil {
+		if bin, ok := node.(*BinaryExpr); ok && bin.Op == "or" {
+			fragment = "(" + fragment + ")"
+		}
+	}
+	b.terms = append(b.terms, fragment)
+	return b
+}
+
+// Len returns the number of terms added
+func (b *Builder) Len() int {
+	return len(b.terms)
+}
+
+// String returns the assembled query
+func (b *Builder) String() string {
+	return strings.Join(b.terms, " and ")
+}
+
+// AddTerm adds a term for any supported field, using the field's syntax:
+// "'value' in parents", "properties has { ... }" (value given as key=value),
+// unquoted booleans and numbers, and quoted strings otherwise.
+func (b *Builder) AddTerm(field, operator, value string) error {
+	spec, ok := fields[field]
+	if !ok {
+		return fmt.Errorf("unknown field '%s'", field)
+	}
+	if !containsString(spec.operators, operator) {
+		return fmt.Errorf("operator '%s' is not supported for '%s' (use %s)", operator, field, strings.Join(spec.operators, ", "))
+	}
+
+	switch spec.kind {
+	case kindCollection:
+		b.In(field, value)
+	case kindProperties:
+		key, val, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("property must be given as key=value")
+		}
+		b.HasProperty(field, key, val)
+	case kindBool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("'%s' expects true or false", field)
+		}
+		b.terms = append(b.terms, fmt.Sprintf("%s %s %t", field, operator, v))
+	case kindNumber:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("'%s' expects an integer", field)
+		}
+		b.terms = append(b.terms, field+" "+operator+" "+value)
+	case kindDate:
+		ts, err := normalizeTimestamp(value)
+		if err != nil {
+			return fmt.Errorf("'%s' is not an RFC 3339 timestamp", value)
+		}
+		b.Where(field, operator, ts)
+	default:
+		b.Where(field, operator, value)
+	}
+	return nil
+}
+
+// FieldNames returns the supported query fields in sorted order
+func FieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FieldOperators returns the operators supported by a field
+func FieldOperators(field string) ([]string, bool) {
+	spec, ok := fields[field]
+	return spec.operators, ok
+}
+
+// BuildInteractive prompts for query terms on out, reading answers from in,
+// until a blank field name is entered. Terms are joined with "and".
+func BuildInteractive(in io.Reader, out io.Writer) (string, error) {
+	reader := bufio.NewReader(in)
+	ask := func(prompt string) (string, error) {
+		fmt.Fprint(out, prompt)
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	b := NewBuilder()
+	fmt.Fprintf(out, "Fields: %s\n", strings.Join(FieldNames(), ", "))
+	for {
+		field, err := ask("Field (blank to finish): ")
+		if err == io.EOF || field == "" {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		ops, ok := FieldOperators(field)
+		if !ok {
+			fmt.Fprintf(out, "Unknown field '%s'\n", field)
+			continue
+		}
+
+		operator := ops[0]
+		if len(ops) > 1 {
+			answer, err := ask(fmt.Sprintf("Operator (%s) [%s]: ", strings.Join(ops, ", "), ops[0]))
+			if err != nil {
+				return "", err
+			}
+			if answer != "" {
+				operator = answer
+			}
+		}
+
+		valuePrompt := "Value: "
+		if fields[field].kind == kindProperties {
+			valuePrompt = "Property (key=value): "
+		}
+		value, err := ask(valuePrompt)
+		if err != nil {
+			return "", err
+		}
+		if err := b.AddTerm(field, operator, value); err != nil {
+			fmt.Fprintf(out, "%s\n", err)
+			continue
+		}
+		fmt.Fprintf(out, "Query so far: %s\n", b.String())
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,269 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindDate
+	kindBool
+	kindNumber
+	kindCollection // used with "value in field"
+	kindProperties // used with "field has { ... }"
+)
+
+type fieldSpec struct {
+	kind      fieldKind
+	operators []string
+}
+
+var (
+	stringOps  = []string{"contains", "=", "!="}
+	equalOps   = []string{"=", "!="}
+	compareOps = []string{"<", "<=", "=", "!=", ">", ">="}
+)
+
+// fields lists the query terms supported by files.list and drives.list
+var fields = map[string]fieldSpec{
+	"name":                     {kindString, stringOps},
+	"fullText":                 {kindString, []string{"contains"}},
+	"mimeType":                 {kindString, stringOps},
+	"visibility":               {kindString, equalOps},
+	"shortcutDetails.targetId": {kindString, equalOps},
+	"modifiedTime":             {kindDate, compareOps},
+	"viewedByMeTime":           {kindDate, compareOps},
+	"createdTime":              {kindDate, compareOps},
+	"sharedWithMeTime":         {kindDate, compareOps},
+	"trashed":                  {kindBool, equalOps},
+	"starred":                  {kindBool, equalOps},
+	"sharedWithMe":             {kindBool, equalOps},
+	"hidden":                   {kindBool, equalOps},
+	"memberCount":              {kindNumber, compareOps},
+	"organizerCount":           {kindNumber, compareOps},
+	"parents":                  {kindCollection, []string{"in"}},
+	"owners":                   {kindCollection, []string{"in"}},
+	"writers":                  {kindCollection, []string{"in"}},
+	"readers":                  {kindCollection, []string{"in"}},
+	"properties":               {kindProperties, []string{"has"}},
+	"appProperties":            {kindProperties, []string{"has"}},
+}
+
+var visibilityValues = []string{"anyoneCanFind", "anyoneWithLink", "domainCanFind", "domainWithLink", "limited"}
+
+// Explain parses and validates a Drive query, returning the normalized form
+// that will be sent to the API along with any errors and their positions.
+// A syntax error stops parsing; validation errors are reported for every
+// term.
+func Explain(q string) *types.QueryExplanation {
+	result := &types.QueryExplanation{Query: q}
+
+	node, err := Parse(q)
+	if err != nil {
+		if se, ok := err.(*SyntaxError); ok {
+			result.Errors = append(result.Errors, &types.QueryIssue{Position: se.Position, Message: se.Message})
+		} else {
+			result.Errors = append(result.Errors, &types.QueryIssue{Message: err.Error()})
+		}
+		return result
+	}
+
+	v := &validator{result: result}
+	v.walk(node)
+	result.Terms = Terms(node)
+	result.Valid = len(result.Errors) == 0
+	if result.Valid {
+		result.Normalized = node.String()
+	}
+	return result
+}
+
+type validator struct {
+	result *types.QueryExplanation
+}
+
+func (v *validator) errorf(pos int, format string, args ...interface{}) {
+	v.result.Errors = append(v.result.Errors, &types.QueryIssue{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(pos int, format string, args ...interface{}) {
+	v.result.Warnings = append(v.result.Warnings, &types.QueryIssue{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) walk(node Node) {
+	switch n := node.(type) {
+	case *BinaryExpr:
+		v.walk(n.Left)
+		v.walk(n.Right)
+	case *NotExpr:
+		v.walk(n.Expr)
+	case *ParenExpr:
+		v.walk(n.Expr)
+	case *Comparison:
+		v.comparison(n)
+	case *Membership:
+		v.membership(n)
+	case *HasExpr:
+		v.has(n)
+	}
+}
+
+func (v *validator) lookup(field string, pos int) (fieldSpec, bool) {
+	spec, ok := fields[field]
+	if ok {
+		return spec, true
+	}
+	for name := range fields {
+		if strings.EqualFold(name, field) {
+			v.errorf(pos, "unknown field '%s' (field names are case-sensitive; did you mean '%s'?)", field, name)
+			return fieldSpec{}, false
+		}
+	}
+	v.errorf(pos, "unknown field '%s'", field)
+	return fieldSpec{}, false
+}
+
+func (v *validator) comparison(c *Comparison) {
+	spec, ok := v.lookup(c.Field, c.Pos)
+	if !ok {
+		return
+	}
+	v.checkQuotes(c.Value)
+
+	switch spec.kind {
+	case kindCollection:
+		v.errorf(c.Pos, "'%s' is matched with \"'value' in %s\", not '%s'", c.Field, c.Field, c.Operator)
+		return
+	case kindProperties:
+		v.errorf(c.Pos, "'%s' is matched with \"%s has { key='k' and value='v' }\"", c.Field, c.Field)
+		return
+	}
+	if !containsString(spec.operators, c.Operator) {
+		v.errorf(c.Pos, "operator '%s' is not supported for '%s' (use %s)", c.Operator, c.Field, strings.Join(spec.operators, ", "))
+		return
+	}
+
+	switch spec.kind {
+	case kindString:
+		if c.Value.Kind != ValueString {
+			v.errorf(c.Value.Pos, "'%s' expects a quoted string, found %s", c.Field, c.Value.Text)
+			return
+		}
+		if c.Field == "visibility" && !containsString(visibilityValues, c.Value.Text) {
+			v.errorf(c.Value.Pos, "invalid visibility '%s' (use %s)", c.Value.Text, strings.Join(visibilityValues, ", "))
+		}
+	case kindDate:
+		if c.Value.Kind != ValueString {
+			v.errorf(c.Value.Pos, "'%s' expects a quoted RFC 3339 timestamp, found %s", c.Field, c.Value.Text)
+			return
+		}
+		normalized, err := normalizeTimestamp(c.Value.Text)
+		if err != nil {
+			v.errorf(c.Value.Pos, "'%s' is not an RFC 3339 timestamp (e.g. '2024-01-31T00:00:00')", c.Value.Text)
+			return
+		}
+		if normalized != c.Value.Text {
+			v.warnf(c.Value.Pos, "date '%s' has no time component; sending '%s'", c.Value.Text, normalized)
+			c.Value.Text = normalized
+		}
+	case kindBool:
+		if c.Value.Kind == ValueString && (strings.EqualFold(c.Value.Text, "true") || strings.EqualFold(c.Value.Text, "false")) {
+			v.warnf(c.Value.Pos, "'%s' expects an unquoted boolean; sending %s", c.Field, strings.ToLower(c.Value.Text))
+			c.Value = Value{Kind: ValueBool, Text: strings.ToLower(c.Value.Text), Pos: c.Value.Pos}
+		} else if c.Value.Kind != ValueBool {
+			v.errorf(c.Value.Pos, "'%s' expects true or false", c.Field)
+		}
+	case kindNumber:
+		if _, err := strconv.Atoi(c.Value.Text); err != nil || c.Value.Kind != ValueNumber {
+			v.errorf(c.Value.Pos, "'%s' expects an integer", c.Field)
+		}
+	}
+}
+
+func (v *validator) membership(m *Membership) {
+	spec, ok := v.lookup(m.Field, m.Pos)
+	if !ok {
+		return
+	}
+	v.checkQuotes(m.Value)
+	if spec.kind != kindCollection {
+		v.errorf(m.Pos, "'in' is only supported for parents, owners, writers, and readers, not '%s'", m.Field)
+		return
+	}
+	if m.Value.Kind != ValueString {
+		v.errorf(m.Value.Pos, "value before 'in %s' must be a quoted string", m.Field)
+	}
+}
+
+func (v *validator) has(h *HasExpr) {
+	spec, ok := v.lookup(h.Field, h.Pos)
+	if !ok {
+		return
+	}
+	v.checkQuotes(h.Key)
+	v.checkQuotes(h.Value)
+	if spec.kind != kindProperties {
+		v.errorf(h.Pos, "'has' is only supported for properties and appProperties, not '%s'", h.Field)
+	}
+}
+
+func (v *validator) checkQuotes(val Value) {
+	if val.DoubleQuoted {
+		v.warnf(val.Pos, "double-quoted string converted to single quotes")
+	}
+}
+
+// normalizeTimestamp accepts RFC 3339 timestamps, with or without a zone,
+// and bare dates, which are expanded to midnight.
+func normalizeTimestamp(s string) (string, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04:05.999999999"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return s, nil
+		}
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return s + "T00:00:00", nil
+	}
+	return "", fmt.Errorf("invalid timestamp %q", s)
+}
+
+// Terms returns the search terms of a parsed query in order
+func Terms(node Node) []*types.QueryTerm {
+	var terms []*types.QueryTerm
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *BinaryExpr:
+			walk(n.Left)
+			walk(n.Right)
+		case *NotExpr:
+			walk(n.Expr)
+		case *ParenExpr:
+			walk(n.Expr)
+		case *Comparison:
+			terms = append(terms, &types.QueryTerm{Field: n.Field, Operator: n.Operator, Value: n.Value.String()})
+		case *Membership:
+			terms = append(terms, &types.QueryTerm{Field: n.Field, Operator: "in", Value: n.Value.String()})
+		case *HasExpr:
+			terms = append(terms, &types.QueryTerm{Field: n.Field, Operator: "has", Value: fmt.Sprintf("%s=%s", n.Key.String(), n.Value.String())})
+		}
+	}
+	walk(node)
+	return terms
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
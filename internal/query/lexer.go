@@ -0,0 +1,172 @@
+// Package query parses, validates, and builds Drive API search queries
+// (the "q" parameter of files.list), so malformed queries can be reported
+// with positions before they are sent to the API.
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOperator // = != < <= > >=
+	tokLParen
+	tokRParen
+	tokLBrace
+	tokRBrace
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokHas
+	tokContains
+	tokTrue
+	tokFalse
+)
+
+var keywords = map[string]tokenKind{
+	"and":      tokAnd,
+	"or":       tokOr,
+	"not":      tokNot,
+	"in":       tokIn,
+	"has":      tokHas,
+	"contains": tokContains,
+	"true":     tokTrue,
+	"false":    tokFalse,
+}
+
+type token struct {
+	kind  tokenKind
+	text  string // raw text, or the unescaped value for strings
+	pos   int    // 1-based column
+	quote bool   // string used double quotes
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("string '%s'", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// SyntaxError is a query error at a specific column
+type SyntaxError struct {
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Message, e.Position)
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		start := i + 1
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: start})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: start})
+			i++
+		case c == '{':
+			tokens = append(tokens, token{kind: tokLBrace, text: "{", pos: start})
+			i++
+		case c == '}':
+			tokens = append(tokens, token{kind: tokRBrace, text: "}", pos: start})
+			i++
+		case c == '=':
+			tokens = append(tokens, token{kind: tokOperator, text: "=", pos: start})
+			i++
+		case c == '!' || c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(input) && input[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &SyntaxError{Position: start, Message: "unexpected '!' (did you mean '!='?)"}
+			}
+			tokens = append(tokens, token{kind: tokOperator, text: op, pos: start})
+			i += len(op)
+		case c == '\'' || c == '"':
+			value, n, err := lexString(input[i:], start)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: value, pos: start, quote: c == '"'})
+			i += n
+		case isDigit(c) || (c == '-' && i+1 < len(input) && isDigit(input[i+1])):
+			j := i + 1
+			for j < len(input) && (isDigit(input[j]) || input[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: input[i:j], pos: start})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(input) && isIdentPart(input[j]) {
+				j++
+			}
+			word := input[i:j]
+			kind := tokIdent
+			if k, ok := keywords[strings.ToLower(word)]; ok {
+				kind = k
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start})
+			i = j
+		default:
+			return nil, &SyntaxError{Position: start, Message: fmt.Sprintf("unexpected character '%c'", c)}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(input) + 1})
+	return tokens, nil
+}
+
+// lexString reads a quoted string starting at s[0], handling backslash
+// escapes. It returns the unescaped value and the number of bytes consumed.
+func lexString(s string, pos int) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, &SyntaxError{Position: pos + i, Message: "unfinished escape sequence"}
+			}
+			i++
+			b.WriteByte(s[i])
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, &SyntaxError{Position: pos, Message: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
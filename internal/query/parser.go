@@ -0,0 +1,270 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is a parsed query expression
+type Node interface {
+	// String returns the normalized query text for the node
+	String() string
+}
+
+// BinaryExpr joins two expressions with "and" or "or"
+type BinaryExpr struct {
+	Op    string
+	Left  Node
+	Right Node
+}
+
+// NotExpr negates an expression
+type NotExpr struct {
+	Expr Node
+}
+
+// ParenExpr is a parenthesized expression
+type ParenExpr struct {
+	Expr Node
+}
+
+// Comparison is a "field operator value" term, e.g. name contains 'report'
+type Comparison struct {
+	Field    string
+	Operator string
+	Value    Value
+	Pos      int
+}
+
+// Membership is a "value in field" term, e.g. 'root' in parents
+type Membership struct {
+	Value Value
+	Field string
+	Pos   int
+}
+
+// HasExpr is a "field has { key=... and value=... }" term used with
+// properties and appProperties
+type HasExpr struct {
+	Field string
+	Key   Value
+	Value Value
+	Pos   int
+}
+
+// ValueKind is the literal type of a query value
+type ValueKind string
+
+const (
+	ValueString ValueKind = "string"
+	ValueBool   ValueKind = "bool"
+	ValueNumber ValueKind = "number"
+)
+
+// Value is a literal in a query
+type Value struct {
+	Kind         ValueKind
+	Text         string // unescaped
+	Pos          int
+	DoubleQuoted bool
+}
+
+func (v Value) String() string {
+	if v.Kind == ValueString {
+		return Quote(v.Text)
+	}
+	return v.Text
+}
+
+func (e *BinaryExpr) String() string { return e.Left.String() + " " + e.Op + " " + e.Right.String() }
+func (e *NotExpr) String() string    { return "not " + e.Expr.String() }
+func (e *ParenExpr) String() string  { return "(" + e.Expr.String() + ")" }
+func (e *Comparison) String() string { return e.Field + " " + e.Operator + " " + e.Value.String() }
+func (e *Membership) String() string { return e.Value.String() + " in " + e.Field }
+func (e *HasExpr) String() string {
+	return fmt.Sprintf("%s has { key=%s and value=%s }", e.Field, e.Key.String(), e.Value.String())
+}
+
+// Quote returns s as a single-quoted Drive query string literal
+func Quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// Parse parses a Drive query. Only syntax is checked; use Explain to also
+// validate field names, operators, and value types.
+func Parse(q string) (Node, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &SyntaxError{Position: 1, Message: "empty query"}
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.unexpected(t, "'and' or 'or'")
+	}
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	return &SyntaxError{Position: t.pos, Message: fmt.Sprintf("expected %s, found %s", expected, t.describe())}
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	switch t := p.peek(); t.kind {
+	case tokNot:
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Expr: expr}, nil
+	case tokLParen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.unexpected(closing, fmt.Sprintf("')' to close '(' at column %d", t.pos))
+		}
+		return &ParenExpr{Expr: expr}, nil
+	case tokString, tokNumber, tokTrue, tokFalse:
+		value := p.parseValue()
+		if in := p.next(); in.kind != tokIn {
+			return nil, p.unexpected(in, "'in' after value")
+		}
+		field := p.next()
+		if field.kind != tokIdent {
+			return nil, p.unexpected(field, "field name after 'in'")
+		}
+		return &Membership{Value: value, Field: field.text, Pos: value.Pos}, nil
+	case tokIdent:
+		return p.parseTerm()
+	default:
+		return nil, p.unexpected(t, "a search term")
+	}
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	field := p.next()
+	switch op := p.next(); op.kind {
+	case tokOperator, tokContains:
+		v := p.peek()
+		switch v.kind {
+		case tokString, tokNumber, tokTrue, tokFalse:
+		default:
+			return nil, p.unexpected(v, fmt.Sprintf("value after '%s'", op.text))
+		}
+		return &Comparison{Field: field.text, Operator: strings.ToLower(op.text), Value: p.parseValue(), Pos: field.pos}, nil
+	case tokHas:
+		key, value, err := p.parseProperty()
+		if err != nil {
+			return nil, err
+		}
+		return &HasExpr{Field: field.text, Key: key, Value: value, Pos: field.pos}, nil
+	default:
+		return nil, p.unexpected(op, fmt.Sprintf("operator after '%s'", field.text))
+	}
+}
+
+// parseProperty parses "{ key='k' and value='v' }"
+func (p *parser) parseProperty() (Value, Value, error) {
+	var key, value Value
+	if t := p.next(); t.kind != tokLBrace {
+		return key, value, p.unexpected(t, "'{' after 'has'")
+	}
+	for i, name := range []string{"key", "value"} {
+		if i > 0 {
+			if t := p.next(); t.kind != tokAnd {
+				return key, value, p.unexpected(t, "'and' between key and value")
+			}
+		}
+		if t := p.next(); t.kind != tokIdent || !strings.EqualFold(t.text, name) {
+			return key, value, p.unexpected(t, "'"+name+"'")
+		}
+		if t := p.next(); t.kind != tokOperator || t.text != "=" {
+			return key, value, p.unexpected(t, "'='")
+		}
+		if t := p.peek(); t.kind != tokString {
+			return key, value, p.unexpected(t, "quoted "+name)
+		}
+		if name == "key" {
+			key = p.parseValue()
+		} else {
+			value = p.parseValue()
+		}
+	}
+	if t := p.next(); t.kind != tokRBrace {
+		return key, value, p.unexpected(t, "'}'")
+	}
+	return key, value, nil
+}
+
+func (p *parser) parseValue() Value {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return Value{Kind: ValueString, Text: t.text, Pos: t.pos, DoubleQuoted: t.quote}
+	case tokNumber:
+		return Value{Kind: ValueNumber, Text: t.text, Pos: t.pos}
+	default:
+		return Value{Kind: ValueBool, Text: strings.ToLower(t.text), Pos: t.pos}
+	}
+}
@@ -0,0 +1,125 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain_Valid(t *testing.T) {
+	tests := []struct {
+		query      string
+		normalized string
+	}{
+		{"name contains 'report'", "name contains 'report'"},
+		{"'root' in parents and trashed = false", "'root' in parents and trashed = false"},
+		{"NOT starred = TRUE", "not starred = true"},
+		{`name = "it's"`, `name = 'it\'s'`},
+		{"(mimeType = 'a' or mimeType = 'b') and modifiedTime > '2024-01-31T10:00:00Z'",
+			"(mimeType = 'a' or mimeType = 'b') and modifiedTime > '2024-01-31T10:00:00Z'"},
+		{"properties has { key='dept' and value='eng' }", "properties has { key='dept' and value='eng' }"},
+		{"createdTime >= '2024-01-01'", "createdTime >= '2024-01-01T00:00:00'"},
+	}
+	for _, tt := range tests {
+		result := Explain(tt.query)
+		if !result.Valid {
+			t.Errorf("Explain(%q) invalid: %+v", tt.query, result.Errors[0])
+			continue
+		}
+		if result.Normalized != tt.normalized {
+			t.Errorf("Explain(%q).Normalized = %q, want %q", tt.query, result.Normalized, tt.normalized)
+		}
+	}
+}
+
+func TestExplain_Errors(t *testing.T) {
+	tests := []struct {
+		query    string
+		position int
+		contains string
+	}{
+		{"", 1, "empty query"},
+		{"name contains 'x", 15, "unterminated string"},
+		{"name 'x'", 6, "expected operator"},
+		{"name contains 'x' and", 22, "expected a search term"},
+		{"(starred = true", 16, "')'"},
+		{"name contains 'x' starred = true", 19, "'and' or 'or'"},
+		{"mimetype = 'x'", 1, "did you mean 'mimeType'"},
+		{"parents = 'root'", 1, "in parents"},
+		{"name > 'x'", 1, "operator '>' is not supported"},
+		{"modifiedTime > 'yesterday'", 16, "RFC 3339"},
+		{"trashed = 1", 11, "true or false"},
+		{"'x' in name", 1, "'in' is only supported"},
+		{"visibility = 'public'", 14, "invalid visibility"},
+		{"name ! 'x'", 6, "'!='"},
+	}
+	for _, tt := range tests {
+		result := Explain(tt.query)
+		if result.Valid {
+			t.Errorf("Explain(%q) should be invalid", tt.query)
+			continue
+		}
+		e := result.Errors[0]
+		if e.Position != tt.position || !strings.Contains(e.Message, tt.contains) {
+			t.Errorf("Explain(%q) error = %d: %q, want %d containing %q", tt.query, e.Position, e.Message, tt.position, tt.contains)
+		}
+	}
+}
+
+func TestExplain_ReportsAllValidationErrors(t *testing.T) {
+	result := Explain("nme = 'x' and trashed = 'maybe'")
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", result.Errors)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder()
+	b.Where("name", "contains", `O'Brien\notes`).
+		In("parents", "root").
+		WhereBool("trashed", false).
+		Raw("starred = true or sharedWithMe = true")
+	if err := b.AddTerm("properties", "has", "dept=eng"); err != nil {
+		t.Fatalf("AddTerm: %v", err)
+	}
+
+	want := `name contains 'O\'Brien\\notes' and 'root' in parents and trashed = false and (starred = true or sharedWithMe = true) and properties has { key='dept' and value='eng' }`
+	if got := b.String(); got != want {
+		t.Errorf("String() = %q\nwant %q", got, want)
+	}
+	if result := Explain(b.String()); !result.Valid {
+		t.Errorf("built query is invalid: %+v", result.Errors[0])
+	}
+}
+
+func TestBuilder_AddTermErrors(t *testing.T) {
+	b := NewBuilder()
+	for _, term := range [][3]string{
+		{"nope", "=", "x"},
+		{"name", ">", "x"},
+		{"trashed", "=", "maybe"},
+		{"modifiedTime", ">", "last week"},
+		{"properties", "has", "novalue"},
+	} {
+		if err := b.AddTerm(term[0], term[1], term[2]); err == nil {
+			t.Errorf("AddTerm(%v) should fail", term)
+		}
+	}
+	if b.Len() != 0 {
+		t.Errorf("failed terms should not be added, got %q", b.String())
+	}
+}
+
+func TestBuildInteractive(t *testing.T) {
+	input := "name\ncontains\nbudget\nbogus\nparents\nroot\n\n"
+	var out strings.Builder
+	q, err := BuildInteractive(strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatalf("BuildInteractive: %v", err)
+	}
+	if q != "name contains 'budget' and 'root' in parents" {
+		t.Errorf("query = %q", q)
+	}
+	if !strings.Contains(out.String(), "Unknown field 'bogus'") {
+		t.Error("expected unknown field message")
+	}
+}
@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// LocationPrefix marks an argument scoped to a Shared Drive:
+// sd:<drive-id>:<path-or-id>
+const LocationPrefix = "sd:"
+
+// Location is a file argument with an optional per-argument Shared Drive.
+// Commands that take several locations, such as a move between drives,
+// resolve each one in its own drive instead of the global --drive-id.
+type Location struct {
+	// DriveID is the Shared Drive named by the argument; empty when the
+	// argument carries no drive and the global --drive-id applies
+	DriveID string
+	// Target is a path or file ID within the drive; empty for the drive root
+	Target string
+}
+
+// ParseLocation splits an argument of the form sd:<drive-id>:<path-or-id>.
+// Arguments without the prefix are returned unchanged as the target.
+func ParseLocation(arg string) (Location, error) {
+	if !strings.HasPrefix(arg, LocationPrefix) {
+		return Location{Target: arg}, nil
+	}
+	rest := strings.TrimPrefix(arg, LocationPrefix)
+	driveID, target, _ := strings.Cut(rest, ":")
+	if driveID == "" || strings.ContainsAny(driveID, "/ ") {
+		return Location{}, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidPath,
+			"invalid Shared Drive location "+arg).
+			WithContext("suggestedAction", "use sd:<drive-id>:/path/in/drive, or sd:<drive-id> for the drive root").
+			Build())
+	}
+	if normalizePath(target) == "" {
+		target = ""
+	}
+	return Location{DriveID: driveID, Target: target}, nil
+}
+
+// IsRoot reports whether the location names the root of its Shared Drive
+func (l Location) IsRoot() bool {
+	return l.DriveID != "" && l.Target == ""
+}
+
+// String formats the location in the form ParseLocation accepts
+func (l Location) String() string {
+	if l.DriveID == "" {
+		return l.Target
+	}
+	return LocationPrefix + l.DriveID + ":" + l.Target
+}
@@ -0,0 +1,39 @@
+package resolver
+
+import "testing"
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Location
+	}{
+		{"/Reports/2024", Location{Target: "/Reports/2024"}},
+		{"1AbCdEf", Location{Target: "1AbCdEf"}},
+		{"sd:0AbC:/Reports/2024", Location{DriveID: "0AbC", Target: "/Reports/2024"}},
+		{"sd:0AbC:1AbCdEf", Location{DriveID: "0AbC", Target: "1AbCdEf"}},
+		{"sd:0AbC:Notes: draft.txt", Location{DriveID: "0AbC", Target: "Notes: draft.txt"}},
+		{"sd:0AbC", Location{DriveID: "0AbC"}},
+		{"sd:0AbC:/", Location{DriveID: "0AbC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLocation(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLocation(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{"sd:", "sd::/path", "sd:a/b:/path"} {
+		if _, err := ParseLocation(input); err == nil {
+			t.Errorf("ParseLocation(%q): expected an error", input)
+		}
+	}
+
+	if root, _ := ParseLocation("sd:0AbC"); !root.IsRoot() || root.String() != "sd:0AbC:" {
+		t.Errorf("root location = %+v (%s)", root, root)
+	}
+}
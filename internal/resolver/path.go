@@ -19,6 +19,7 @@ type PathResolver struct {
 	shaper   *api.RequestShaper
 	cache    *pathCache
 	cacheTTL time.Duration
+	store    *PathStore
 }
 
 type pathCache struct {
@@ -29,6 +30,10 @@ type pathCache struct {
 type cacheEntry struct {
 	fileID    string
 	timestamp time.Time
+	// file is the resolved file's metadata, when known
+	file *types.DriveFile
+	// expires overrides the resolver's TTL for entries loaded from the store
+	expires time.Time
 }
 
 // NewPathResolver creates a new path resolver
@@ -43,6 +48,30 @@ func NewPathResolver(client *api.Client, cacheTTL time.Duration) *PathResolver {
 	}
 }
 
+// UseStore loads the paths persisted in store into the cache, and saves
+// paths resolved from now on to it so later runs can reuse them
+func (r *PathResolver) UseStore(store *PathStore) error {
+	entries, err := store.Load()
+	if err != nil {
+		return err
+	}
+	r.store = store
+
+	r.cache.mu.Lock()
+	defer r.cache.mu.Unlock()
+	for key, entry := range entries {
+		r.cache.entries[key] = cacheEntry{
+			fileID:  entry.FileID,
+			file:    entry.driveFile(),
+			expires: entry.ExpiresAt,
+		}
+		if entry.ResourceKey != "" && r.client != nil {
+			r.client.ResourceKeys().UpdateFromAPIResponse(entry.FileID, entry.ResourceKey)
+		}
+	}
+	return nil
+}
+
 // SearchDomain represents the scope of path resolution
 type SearchDomain string
 
@@ -62,6 +91,9 @@ type ResolveOptions struct {
 	UseCache            bool
 	StrictMode          bool
 	MaxAncestorDepth    int // For shared-with-me ancestor walk validation (default: 10)
+	// NoFollowShortcuts resolves a path ending in a shortcut to the
+	// shortcut itself, and does not traverse shortcuts to folders
+	NoFollowShortcuts bool
 }
 
 // ResolveResult contains path resolution results
@@ -72,6 +104,7 @@ type ResolveResult struct {
 	Cached       bool
 	Matches      []*types.DriveFile
 	SearchDomain SearchDomain // Which domain the result came from
+	ShortcutID   string       // The shortcut followed to FileID, if any
 }
 
 // Resolve resolves a path to a file ID
@@ -92,9 +125,10 @@ func (r *PathResolver) Resolve(ctx context.Context, reqCtx *types.RequestContext
 	// Check cache first
 	if opts.UseCache {
 		cacheKey := r.makeCacheKey(path, opts)
-		if cached, ok := r.checkCacheByKey(cacheKey); ok {
+		if entry, ok := r.lookupCache(cacheKey); ok {
 			return &ResolveResult{
-				FileID:       cached,
+				FileID:       entry.fileID,
+				File:         entry.file,
 				Cached:       true,
 				SearchDomain: opts.SearchDomain,
 			}, nil
@@ -136,8 +170,22 @@ func (r *PathResolver) resolveParentBasedPath(ctx context.Context, reqCtx *types
 		reqCtx.DriveID = opts.DriveID
 	}
 
+	// Start below the deepest cached ancestor, e.g. one filled by 'cache warm'
+	start := 0
+	if opts.UseCache {
+		for i := len(segments) - 1; i > 0; i-- {
+			key := r.makeCacheKey(strings.Join(segments[:i], "/"), opts)
+			if cached, ok := r.checkCacheByKey(key); ok {
+				currentID = cached
+				start = i
+				break
+			}
+		}
+	}
+
 	// Walk path segments
-	for i, segment := range segments {
+	for i := start; i < len(segments); i++ {
+		segment := segments[i]
 		if segment == "" {
 			continue
 		}
@@ -169,22 +217,23 @@ func (r *PathResolver) resolveParentBasedPath(ctx context.Context, reqCtx *types
 			matches = r.sortMatchesWithDomainPreference(matches, opts.SearchDomain)
 		}
 
-		currentID = matches[0].ID
+		file, shortcutID := followShortcut(matches[0], opts)
+		currentID = file.ID
 
 		// If this is the last segment, return full result
 		if i == len(segments)-1 {
 			result := &ResolveResult{
 				FileID:       currentID,
-				File:         matches[0],
+				File:         file,
 				Ambiguous:    len(matches) > 1,
 				Matches:      matches,
 				SearchDomain: opts.SearchDomain,
+				ShortcutID:   shortcutID,
 			}
 
 			// Update cache
 			if opts.UseCache {
-				cacheKey := r.makeCacheKey(path, opts)
-				r.updateCacheByKey(cacheKey, currentID)
+				r.remember(r.makeCacheKey(path, opts), file)
 			}
 
 			return result, nil
@@ -247,18 +296,19 @@ func (r *PathResolver) resolveSharedWithMeSingleSegment(ctx context.Context, req
 		matches = r.sortMatchesWithDomainPreference(matches, SearchDomainSharedWithMe)
 	}
 
+	file, shortcutID := followShortcut(matches[0], opts)
 	result := &ResolveResult{
-		FileID:       matches[0].ID,
-		File:         matches[0],
+		FileID:       file.ID,
+		File:         file,
 		Ambiguous:    len(matches) > 1,
 		Matches:      matches,
 		SearchDomain: SearchDomainSharedWithMe,
+		ShortcutID:   shortcutID,
 	}
 
 	// Update cache
 	if opts.UseCache {
-		cacheKey := r.makeCacheKey(path, opts)
-		r.updateCacheByKey(cacheKey, matches[0].ID)
+		r.remember(r.makeCacheKey(path, opts), file)
 	}
 
 	return result, nil
@@ -288,7 +338,8 @@ func (r *PathResolver) resolveSharedWithMeMultiLevel(ctx context.Context, reqCtx
 	var validMatches []*types.DriveFile
 	for _, candidate := range candidates {
 		// Try to traverse from this candidate
-		currentID := candidate.ID
+		start, _ := followShortcut(candidate, opts)
+		currentID := start.ID
 		valid := true
 
 		for i := 1; i < len(segments); i++ {
@@ -321,7 +372,8 @@ func (r *PathResolver) resolveSharedWithMeMultiLevel(ctx context.Context, reqCtx
 			if len(childMatches) > 1 {
 				childMatches = r.sortMatchesWithDomainPreference(childMatches, SearchDomainSharedWithMe)
 			}
-			currentID = childMatches[0].ID
+			child, _ := followShortcut(childMatches[0], opts)
+			currentID = child.ID
 
 			// Check depth bounds
 			if i >= opts.MaxAncestorDepth {
@@ -377,8 +429,7 @@ func (r *PathResolver) resolveSharedWithMeMultiLevel(ctx context.Context, reqCtx
 
 	// Update cache
 	if opts.UseCache {
-		cacheKey := r.makeCacheKey(path, opts)
-		r.updateCacheByKey(cacheKey, validMatches[0].ID)
+		r.remember(r.makeCacheKey(path, opts), validMatches[0])
 	}
 
 	return result, nil
@@ -409,17 +460,21 @@ func (r *PathResolver) findByNameWithParent(ctx context.Context, reqCtx *types.R
 	matches := make([]*types.DriveFile, len(result.Files))
 	for i, f := range result.Files {
 		matches[i] = &types.DriveFile{
-			ID:          f.Id,
-			Name:        f.Name,
-			MimeType:    f.MimeType,
-			Parents:     f.Parents,
-			ResourceKey: f.ResourceKey,
+			ID:              f.Id,
+			Name:            f.Name,
+			MimeType:        f.MimeType,
+			Parents:         f.Parents,
+			ResourceKey:     f.ResourceKey,
+			ShortcutDetails: convertShortcutDetails(f.ShortcutDetails),
 		}
 
 		// Update resource key cache
 		if f.ResourceKey != "" {
 			r.client.ResourceKeys().UpdateFromAPIResponse(f.Id, f.ResourceKey)
 		}
+		if f.ShortcutDetails != nil && f.ShortcutDetails.TargetResourceKey != "" {
+			r.client.ResourceKeys().UpdateFromAPIResponse(f.ShortcutDetails.TargetId, f.ShortcutDetails.TargetResourceKey)
+		}
 	}
 
 	return matches, nil
@@ -457,17 +512,21 @@ func (r *PathResolver) findSharedWithMeByName(ctx context.Context, reqCtx *types
 	matches := make([]*types.DriveFile, len(result.Files))
 	for i, f := range result.Files {
 		matches[i] = &types.DriveFile{
-			ID:          f.Id,
-			Name:        f.Name,
-			MimeType:    f.MimeType,
-			Parents:     f.Parents,
-			ResourceKey: f.ResourceKey,
+			ID:              f.Id,
+			Name:            f.Name,
+			MimeType:        f.MimeType,
+			Parents:         f.Parents,
+			ResourceKey:     f.ResourceKey,
+			ShortcutDetails: convertShortcutDetails(f.ShortcutDetails),
 		}
 
 		// Update resource key cache
 		if f.ResourceKey != "" {
 			r.client.ResourceKeys().UpdateFromAPIResponse(f.Id, f.ResourceKey)
 		}
+		if f.ShortcutDetails != nil && f.ShortcutDetails.TargetResourceKey != "" {
+			r.client.ResourceKeys().UpdateFromAPIResponse(f.ShortcutDetails.TargetId, f.ShortcutDetails.TargetResourceKey)
+		}
 	}
 
 	return matches, nil
@@ -475,23 +534,18 @@ func (r *PathResolver) findSharedWithMeByName(ctx context.Context, reqCtx *types
 
 // getFileByID retrieves a file by its ID
 func (r *PathResolver) getFileByID(ctx context.Context, reqCtx *types.RequestContext, fileID string) (*types.DriveFile, error) {
-	call := r.client.Service().Files.Get(fileID)
-	call = r.shaper.ShapeFilesGet(call, reqCtx)
-	call = call.Fields("id,name,mimeType,parents,resourceKey,shortcutDetails,owners,driveId")
-
-	result, err := api.ExecuteWithRetry(ctx, r.client, reqCtx, func() (*drive.File, error) {
-		return call.Do()
-	})
+	result, err := r.client.GetFile(ctx, reqCtx, fileID, "id,name,mimeType,parents,resourceKey,shortcutDetails,owners,driveId")
 	if err != nil {
 		return nil, err
 	}
 
 	file := &types.DriveFile{
-		ID:          result.Id,
-		Name:        result.Name,
-		MimeType:    result.MimeType,
-		Parents:     result.Parents,
-		ResourceKey: result.ResourceKey,
+		ID:              result.Id,
+		Name:            result.Name,
+		MimeType:        result.MimeType,
+		Parents:         result.Parents,
+		ResourceKey:     result.ResourceKey,
+		ShortcutDetails: convertShortcutDetails(result.ShortcutDetails),
 	}
 
 	// Update resource key cache
@@ -502,6 +556,33 @@ func (r *PathResolver) getFileByID(ctx context.Context, reqCtx *types.RequestCon
 	return file, nil
 }
 
+// followShortcut returns the target of a shortcut, described from the
+// shortcut's details, and the shortcut's ID. Other files, and shortcuts
+// when opts says not to follow them, are returned as they are.
+func followShortcut(file *types.DriveFile, opts ResolveOptions) (*types.DriveFile, string) {
+	targetID := file.ShortcutTarget()
+	if targetID == "" || opts.NoFollowShortcuts {
+		return file, ""
+	}
+	return &types.DriveFile{
+		ID:          targetID,
+		Name:        file.Name,
+		MimeType:    file.ShortcutDetails.TargetMimeType,
+		ResourceKey: file.ShortcutDetails.TargetResourceKey,
+	}, file.ID
+}
+
+func convertShortcutDetails(d *drive.FileShortcutDetails) *types.ShortcutDetails {
+	if d == nil || d.TargetId == "" {
+		return nil
+	}
+	return &types.ShortcutDetails{
+		TargetID:          d.TargetId,
+		TargetMimeType:    d.TargetMimeType,
+		TargetResourceKey: d.TargetResourceKey,
+	}
+}
+
 // isPermissionError checks if an error is a permission-related error
 func isPermissionError(err error) bool {
 	if appErr, ok := err.(*utils.AppError); ok {
@@ -528,19 +609,29 @@ func (r *PathResolver) checkCache(path, driveID string) (string, bool) {
 }
 
 func (r *PathResolver) checkCacheByKey(key string) (string, bool) {
+	entry, ok := r.lookupCache(key)
+	return entry.fileID, ok
+}
+
+// lookupCache returns the live cache entry for key
+func (r *PathResolver) lookupCache(key string) (cacheEntry, bool) {
 	r.cache.mu.RLock()
 	defer r.cache.mu.RUnlock()
 
 	entry, ok := r.cache.entries[key]
 	if !ok {
-		return "", false
+		return cacheEntry{}, false
 	}
 
-	if time.Since(entry.timestamp) > r.cacheTTL {
-		return "", false
+	if !entry.expires.IsZero() {
+		if time.Now().After(entry.expires) {
+			return cacheEntry{}, false
+		}
+	} else if time.Since(entry.timestamp) > r.cacheTTL {
+		return cacheEntry{}, false
 	}
 
-	return entry.fileID, true
+	return entry, true
 }
 
 func (r *PathResolver) updateCache(path, driveID, fileID string) {
@@ -564,6 +655,24 @@ func (r *PathResolver) updateCacheByKey(key, fileID string) {
 	}
 }
 
+// remember caches a resolved path, saving it to the store when there is one
+func (r *PathResolver) remember(key string, file *types.DriveFile) {
+	now := time.Now()
+	r.cache.mu.Lock()
+	r.cache.entries[key] = cacheEntry{
+		fileID:    file.ID,
+		timestamp: now,
+		file:      file,
+	}
+	r.cache.mu.Unlock()
+
+	if r.store == nil || r.cacheTTL <= 0 {
+		return
+	}
+	// The store is only a cache; failing to save it does not fail resolution
+	_ = r.store.Put(map[string]*PathCacheEntry{key: newPathCacheEntry(file, now.Add(r.cacheTTL))})
+}
+
 // InvalidateCache removes a path from the cache
 func (r *PathResolver) InvalidateCache(path, driveID string) {
 	r.cache.mu.Lock()
@@ -584,7 +693,12 @@ func (r *PathResolver) InvalidateCacheByKey(key string) {
 // makeCacheKey creates a cache key that includes search domain
 func (r *PathResolver) makeCacheKey(path string, opts ResolveOptions) string {
 	// Include search domain in cache key to differentiate between different resolution contexts
-	return fmt.Sprintf("%s:%s:%s", opts.DriveID, string(opts.SearchDomain), path)
+	key := fmt.Sprintf("%s:%s:%s", opts.DriveID, string(opts.SearchDomain), path)
+	if opts.NoFollowShortcuts {
+		// A path through a shortcut resolves differently
+		key = "nofollow:" + key
+	}
+	return key
 }
 
 // ClearCache removes all cached entries
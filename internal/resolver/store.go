@@ -0,0 +1,128 @@
+package resolver
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// PathCacheFileName is the file resolved paths are kept in, inside the
+// cache directory
+const PathCacheFileName = "paths.json"
+
+// PathCacheEntry is a resolved path and the metadata of the file it names
+type PathCacheEntry struct {
+	FileID      string    `json:"fileId"`
+	Name        string    `json:"name"`
+	MimeType    string    `json:"mimeType,omitempty"`
+	Parents     []string  `json:"parents,omitempty"`
+	ResourceKey string    `json:"resourceKey,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type pathCacheFile struct {
+	state.Versioned
+	// Entries are keyed like the in-memory cache: driveID:domain:path
+	Entries map[string]*PathCacheEntry `json:"entries"`
+}
+
+// PathCacheStateKind versions the persistent path cache
+var PathCacheStateKind = state.Register(&state.Kind{
+	Name:        "path-cache",
+	Description: "Resolved paths kept between runs and filled by 'cache warm'",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultPathStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: PathCacheFileName,
+})
+
+// PathStore persists resolved paths between runs
+type PathStore struct {
+	path string
+}
+
+// NewPathStore creates a store backed by path
+func NewPathStore(path string) *PathStore {
+	return &PathStore{path: path}
+}
+
+// DefaultPathStore returns the store in the gdrv cache directory
+func DefaultPathStore() (*PathStore, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewPathStore(filepath.Join(dir, "cache", PathCacheFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *PathStore) Path() string {
+	return s.path
+}
+
+// Load returns the entries that have not expired
+func (s *PathStore) Load() (map[string]*PathCacheEntry, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return file.Entries, nil
+}
+
+// Put saves entries, replacing any stored under the same keys. Expired
+// entries are dropped while the file is rewritten.
+func (s *PathStore) Put(entries map[string]*PathCacheEntry) error {
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	for key, entry := range entries {
+		file.Entries[key] = entry
+	}
+	return state.Save(PathCacheStateKind, s.path, file)
+}
+
+func (s *PathStore) load() (*pathCacheFile, error) {
+	file := &pathCacheFile{}
+	if _, err := state.Load(PathCacheStateKind, s.path, file); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	live := make(map[string]*PathCacheEntry, len(file.Entries))
+	for key, entry := range file.Entries {
+		if entry != nil && entry.ExpiresAt.After(now) {
+			live[key] = entry
+		}
+	}
+	file.Entries = live
+	return file, nil
+}
+
+func newPathCacheEntry(file *types.DriveFile, expires time.Time) *PathCacheEntry {
+	return &PathCacheEntry{
+		FileID:      file.ID,
+		Name:        file.Name,
+		MimeType:    file.MimeType,
+		Parents:     file.Parents,
+		ResourceKey: file.ResourceKey,
+		ExpiresAt:   expires,
+	}
+}
+
+func (e *PathCacheEntry) driveFile() *types.DriveFile {
+	return &types.DriveFile{
+		ID:          e.FileID,
+		Name:        e.Name,
+		MimeType:    e.MimeType,
+		Parents:     e.Parents,
+		ResourceKey: e.ResourceKey,
+	}
+}
@@ -0,0 +1,257 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+)
+
+// maxWarmAncestors bounds the walk from a warmed folder up to its root
+const maxWarmAncestors = 100
+
+// WarmOptions configures cache warming
+type WarmOptions struct {
+	// Depth is how many levels below the folder to cache; 0 caches only
+	// the folder itself
+	Depth int
+	// TTL is how long warmed paths stay valid
+	TTL time.Duration
+	// Resolve is the resolution context later runs will look paths up in
+	Resolve ResolveOptions
+}
+
+// WarmResult summarizes a cache warm
+type WarmResult struct {
+	FolderID  string    `json:"folderId"`
+	Path      string    `json:"path"`
+	DriveID   string    `json:"driveId,omitempty"`
+	Depth     int       `json:"depth"`
+	Folders   int       `json:"foldersListed"`
+	Cached    int       `json:"pathsCached"`
+	Ambiguous []string  `json:"ambiguous,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CacheFile string    `json:"cacheFile,omitempty"`
+}
+
+func (r *WarmResult) Headers() []string {
+	return []string{"Folder", "Path", "Depth", "Folders Listed", "Paths Cached", "Ambiguous", "Expires"}
+}
+
+func (r *WarmResult) Rows() [][]string {
+	return [][]string{{
+		r.FolderID,
+		"/" + r.Path,
+		strconv.Itoa(r.Depth),
+		strconv.Itoa(r.Folders),
+		strconv.Itoa(r.Cached),
+		strconv.Itoa(len(r.Ambiguous)),
+		r.ExpiresAt.Format(time.RFC3339),
+	}}
+}
+
+func (r *WarmResult) EmptyMessage() string {
+	return "Nothing cached"
+}
+
+// Warm caches the path of folderID and of everything up to opts.Depth
+// levels below it, saving them to the store when there is one. Names used
+// by more than one item in a folder are not cached, since resolving them
+// depends on --strict.
+func (r *PathResolver) Warm(ctx context.Context, reqCtx *types.RequestContext, folderID string, opts WarmOptions) (*WarmResult, error) {
+	folder, err := r.getWarmFile(ctx, reqCtx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if folder.MimeType != utils.MimeTypeFolder {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("%s is not a folder", folderID)).
+			WithContext("mimeType", folder.MimeType).
+			Build())
+	}
+
+	resolveOpts := opts.Resolve
+	resolveOpts.DriveID = folder.DriveId
+	resolveOpts.SearchDomain = r.determineSearchDomain(resolveOpts)
+	reqCtx.DriveID = folder.DriveId
+
+	path, err := r.folderPath(ctx, reqCtx, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(opts.TTL)
+	result := &WarmResult{
+		FolderID:  folder.Id,
+		Path:      path,
+		DriveID:   folder.DriveId,
+		Depth:     opts.Depth,
+		ExpiresAt: expires,
+	}
+	entries := map[string]*PathCacheEntry{}
+	if path != "" {
+		entries[r.makeCacheKey(path, resolveOpts)] = newPathCacheEntry(toDriveFile(folder), expires)
+	}
+
+	type pending struct {
+		id    string
+		path  string
+		level int
+	}
+	queue := []pending{{id: folder.Id, path: path, level: 0}}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if dir.level >= opts.Depth {
+			continue
+		}
+
+		children, err := r.listChildren(ctx, reqCtx, dir.id)
+		if err != nil {
+			return nil, err
+		}
+		result.Folders++
+
+		byName := map[string][]*drive.File{}
+		for _, child := range children {
+			byName[child.Name] = append(byName[child.Name], child)
+		}
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			childPath := joinPath(dir.path, name)
+			if len(byName[name]) > 1 || strings.Contains(name, "/") {
+				result.Ambiguous = append(result.Ambiguous, "/"+childPath)
+				continue
+			}
+			child := byName[name][0]
+			entries[r.makeCacheKey(childPath, resolveOpts)] = newPathCacheEntry(toDriveFile(child), expires)
+			if child.MimeType == utils.MimeTypeFolder {
+				queue = append(queue, pending{id: child.Id, path: childPath, level: dir.level + 1})
+			}
+		}
+	}
+
+	r.cache.mu.Lock()
+	for key, entry := range entries {
+		r.cache.entries[key] = cacheEntry{fileID: entry.FileID, file: entry.driveFile(), expires: expires}
+	}
+	r.cache.mu.Unlock()
+	result.Cached = len(entries)
+
+	if r.store != nil {
+		if err := r.store.Put(entries); err != nil {
+			return nil, err
+		}
+		result.CacheFile = r.store.Path()
+	}
+	return result, nil
+}
+
+// folderPath returns the path of folder from the root of its drive, as
+// path resolution would walk it
+func (r *PathResolver) folderPath(ctx context.Context, reqCtx *types.RequestContext, folder *drive.File) (string, error) {
+	rootID := folder.DriveId
+	if rootID == "" {
+		root, err := r.client.GetFile(ctx, reqCtx, "root", "id")
+		if err != nil {
+			return "", err
+		}
+		rootID = root.Id
+	}
+
+	var names []string
+	current := folder
+	for i := 0; current.Id != rootID; i++ {
+		if len(current.Parents) == 0 || i >= maxWarmAncestors {
+			return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidPath,
+				"Folder is not reachable from My Drive or a shared drive root, so it has no path").
+				WithContext("folderId", folder.Id).
+				WithContext("suggestedAction", "add a shortcut to the folder in My Drive and warm that, or use file IDs").
+				Build())
+		}
+		names = append(names, current.Name)
+		parent, err := r.getWarmFile(ctx, reqCtx, current.Parents[0])
+		if err != nil {
+			return "", err
+		}
+		current = parent
+	}
+
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/"), nil
+}
+
+func (r *PathResolver) getWarmFile(ctx context.Context, reqCtx *types.RequestContext, fileID string) (*drive.File, error) {
+	file, err := r.client.GetFile(ctx, reqCtx, fileID, "id,name,mimeType,parents,resourceKey,driveId")
+	if err != nil {
+		return nil, err
+	}
+	if file.ResourceKey != "" {
+		r.client.ResourceKeys().UpdateFromAPIResponse(file.Id, file.ResourceKey)
+	}
+	return file, nil
+}
+
+// listChildren returns every item directly inside parentID
+func (r *PathResolver) listChildren(ctx context.Context, reqCtx *types.RequestContext, parentID string) ([]*drive.File, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false", parentID)
+	var files []*drive.File
+	pageToken := ""
+	for {
+		call := r.client.Service().Files.List().Q(query).PageSize(1000)
+		call = r.shaper.ShapeFilesList(call, reqCtx)
+		call = call.Fields("nextPageToken,files(id,name,mimeType,parents,resourceKey)")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		page, err := api.ExecuteWithRetry(ctx, r.client, reqCtx, func() (*drive.FileList, error) {
+			return call.Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range page.Files {
+			if f.ResourceKey != "" {
+				r.client.ResourceKeys().UpdateFromAPIResponse(f.Id, f.ResourceKey)
+			}
+		}
+		files = append(files, page.Files...)
+
+		if page.NextPageToken == "" {
+			return files, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func toDriveFile(f *drive.File) *types.DriveFile {
+	return &types.DriveFile{
+		ID:          f.Id,
+		Name:        f.Name,
+		MimeType:    f.MimeType,
+		Parents:     f.Parents,
+		ResourceKey: f.ResourceKey,
+	}
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
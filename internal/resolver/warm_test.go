@@ -0,0 +1,205 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+const folderMime = "application/vnd.google-apps.folder"
+
+// warmTree is My Drive > Projects > {Alpha > {spec.txt}, notes.txt, dup, dup}
+var warmTree = []*drive.File{
+	{Id: "root-id", Name: "My Drive", MimeType: folderMime},
+	{Id: "projects", Name: "Projects", MimeType: folderMime, Parents: []string{"root-id"}},
+	{Id: "alpha", Name: "Alpha", MimeType: folderMime, Parents: []string{"projects"}},
+	{Id: "spec", Name: "spec.txt", MimeType: "text/plain", Parents: []string{"alpha"}},
+	{Id: "notes", Name: "notes.txt", MimeType: "text/plain", Parents: []string{"projects"}},
+	{Id: "dup1", Name: "dup", MimeType: "text/plain", Parents: []string{"projects"}},
+	{Id: "dup2", Name: "dup", MimeType: "text/plain", Parents: []string{"projects"}},
+}
+
+func newWarmResolver(t *testing.T, requests *atomic.Int32) *PathResolver {
+	t.Helper()
+	return newTreeResolver(t, warmTree, requests)
+}
+
+// newTreeResolver serves tree as a Drive API, answering gets by ID and
+// parent/name queries
+func newTreeResolver(t *testing.T, tree []*drive.File, requests *atomic.Int32) *PathResolver {
+	t.Helper()
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files")
+		id = strings.TrimPrefix(id, "/")
+		if id == "root" {
+			id = "root-id"
+		}
+		if id != "" {
+			for _, f := range tree {
+				if f.Id == id {
+					_ = json.NewEncoder(w).Encode(f)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"File not found"}}`))
+			return
+		}
+
+		// Queries look like 'ID' in parents [and name = 'NAME'] and trashed = false
+		q := r.URL.Query().Get("q")
+		parent := strings.SplitN(strings.TrimPrefix(q, "'"), "'", 2)[0]
+		if parent == "root" {
+			parent = "root-id"
+		}
+		name := ""
+		if i := strings.Index(q, "name = '"); i >= 0 {
+			name = strings.SplitN(q[i+len("name = '"):], "'", 2)[0]
+		}
+		list := &drive.FileList{}
+		for _, f := range tree {
+			if len(f.Parents) > 0 && f.Parents[0] == parent && (name == "" || f.Name == name) {
+				list.Files = append(list.Files, f)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	}))
+
+	return NewPathResolver(client, time.Minute)
+}
+
+func TestWarm(t *testing.T) {
+	store := NewPathStore(filepath.Join(t.TempDir(), "cache", PathCacheFileName))
+	var requests atomic.Int32
+	r := newWarmResolver(t, &requests)
+	if err := r.UseStore(store); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+	result, err := r.Warm(ctx, reqCtx, "projects", WarmOptions{Depth: 3, TTL: time.Hour, Resolve: ResolveOptions{UseCache: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Path != "Projects" || result.Folders != 2 || result.Cached != 4 {
+		t.Errorf("result = %+v", result)
+	}
+	if !reflect.DeepEqual(result.Ambiguous, []string{"/Projects/dup"}) {
+		t.Errorf("ambiguous = %v", result.Ambiguous)
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	if len(keys) != 4 || entries[":my-drive:Projects/Alpha/spec.txt"].FileID != "spec" {
+		t.Errorf("stored keys = %v", keys)
+	}
+
+	// A later run resolves warmed paths from the store without any requests
+	var later atomic.Int32
+	next := newWarmResolver(t, &later)
+	if err := next.UseStore(store); err != nil {
+		t.Fatal(err)
+	}
+	got, err := next.Resolve(ctx, reqCtx, "/Projects/Alpha/spec.txt", ResolveOptions{UseCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FileID != "spec" || !got.Cached || got.File.Name != "spec.txt" || later.Load() != 0 {
+		t.Errorf("resolve = %+v after %d requests", got, later.Load())
+	}
+}
+
+func TestResolveStartsBelowCachedAncestor(t *testing.T) {
+	var requests atomic.Int32
+	r := newWarmResolver(t, &requests)
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+	opts := ResolveOptions{UseCache: true}
+
+	if _, err := r.Warm(ctx, reqCtx, "projects", WarmOptions{Depth: 1, TTL: time.Hour, Resolve: opts}); err != nil {
+		t.Fatal(err)
+	}
+	requests.Store(0)
+
+	got, err := r.Resolve(ctx, reqCtx, "Projects/Alpha/spec.txt", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FileID != "spec" || requests.Load() != 1 {
+		t.Errorf("resolve = %+v after %d requests, want 1", got, requests.Load())
+	}
+}
+
+func TestPathStoreDropsExpired(t *testing.T) {
+	store := NewPathStore(filepath.Join(t.TempDir(), PathCacheFileName))
+	err := store.Put(map[string]*PathCacheEntry{
+		"old": {FileID: "a", ExpiresAt: time.Now().Add(-time.Minute)},
+		"new": {FileID: "b", ExpiresAt: time.Now().Add(time.Minute)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries["new"].FileID != "b" {
+		t.Errorf("entries = %v", entries)
+	}
+}
+
+func TestResolveFollowsShortcuts(t *testing.T) {
+	tree := append(warmTree[:len(warmTree):len(warmTree)],
+		&drive.File{Id: "alpha-link", Name: "Alpha link", MimeType: "application/vnd.google-apps.shortcut", Parents: []string{"root-id"},
+			ShortcutDetails: &drive.FileShortcutDetails{TargetId: "alpha", TargetMimeType: folderMime}},
+		&drive.File{Id: "spec-link", Name: "spec link", MimeType: "application/vnd.google-apps.shortcut", Parents: []string{"root-id"},
+			ShortcutDetails: &drive.FileShortcutDetails{TargetId: "spec", TargetMimeType: "text/plain"}},
+	)
+	var requests atomic.Int32
+	r := newTreeResolver(t, tree, &requests)
+	ctx := context.Background()
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	got, err := r.Resolve(ctx, reqCtx, "spec link", ResolveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FileID != "spec" || got.ShortcutID != "spec-link" {
+		t.Errorf("final shortcut resolved to %+v", got)
+	}
+
+	got, err = r.Resolve(ctx, reqCtx, "Alpha link/spec.txt", ResolveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FileID != "spec" || got.ShortcutID != "" {
+		t.Errorf("path through shortcut resolved to %+v", got)
+	}
+
+	got, err = r.Resolve(ctx, reqCtx, "spec link", ResolveOptions{NoFollowShortcuts: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FileID != "spec-link" || got.ShortcutID != "" {
+		t.Errorf("unfollowed shortcut resolved to %+v", got)
+	}
+}
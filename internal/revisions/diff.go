@@ -0,0 +1,248 @@
+package revisions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Diff output formats
+const (
+	DiffFormatUnified = "unified"
+	DiffFormatHTML    = "html"
+)
+
+// DefaultDiffContext is the number of unchanged lines shown around changes
+const DefaultDiffContext = 3
+
+// MaxDiffSize is the largest revision content, in bytes, that is compared
+const MaxDiffSize = 20 << 20
+
+// diffExportTypes maps the Workspace types that can be compared to the
+// text format their revisions are exported in
+var diffExportTypes = map[string]string{
+	utils.MimeTypeDocument:     "text/plain",
+	utils.MimeTypeSpreadsheet:  "text/csv",
+	utils.MimeTypePresentation: "text/plain",
+}
+
+// DiffOptions configures a revision diff
+type DiffOptions struct {
+	Format  string // unified (default) or html
+	Context int    // Unchanged lines around each change; negative for the default
+}
+
+// DiffResult is the difference between two revisions of a file
+type DiffResult struct {
+	FileID       string          `json:"fileId"`
+	FileName     string          `json:"fileName"`
+	MimeType     string          `json:"mimeType"`
+	ComparedAs   string          `json:"comparedAs,omitempty"` // Export format of Workspace files
+	RevisionA    *types.Revision `json:"revisionA"`
+	RevisionB    *types.Revision `json:"revisionB"`
+	Format       string          `json:"format"`
+	Identical    bool            `json:"identical"`
+	Binary       bool            `json:"binary,omitempty"`
+	LinesAdded   int             `json:"linesAdded"`
+	LinesRemoved int             `json:"linesRemoved"`
+	Diff         string          `json:"diff,omitempty"`
+	OutputPath   string          `json:"outputPath,omitempty"`
+}
+
+func (r *DiffResult) Headers() []string {
+	return []string{"File", "Revision A", "Revision B", "Added", "Removed", "Status"}
+}
+
+func (r *DiffResult) Rows() [][]string {
+	status := "changed"
+	switch {
+	case r.Identical:
+		status = "identical"
+	case r.Binary:
+		status = "binary content differs"
+	}
+	return [][]string{{r.FileName, r.RevisionA.ID, r.RevisionB.ID,
+		"+" + strconv.Itoa(r.LinesAdded), "-" + strconv.Itoa(r.LinesRemoved), status}}
+}
+
+func (r *DiffResult) EmptyMessage() string {
+	return ""
+}
+
+// Diff compares the content of two revisions of a file. Docs and Slides are
+// compared as plain text and Sheets as CSV (the first sheet), exported from
+// each revision; other files are compared by their stored content, line by
+// line when it is text.
+func (m *Manager) Diff(ctx context.Context, reqCtx *types.RequestContext, fileID, revisionA, revisionB string, opts DiffOptions) (*DiffResult, error) {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	format := opts.Format
+	if format == "" {
+		format = DiffFormatUnified
+	}
+	if format != DiffFormatUnified && format != DiffFormatHTML {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Unknown diff format %q; use unified or html", format)).Build())
+	}
+	contextLines := opts.Context
+	if contextLines < 0 {
+		contextLines = DefaultDiffContext
+	}
+
+	fileCall := m.client.Service().Files.Get(fileID)
+	fileCall = m.shaper.ShapeFilesGet(fileCall, reqCtx)
+	fileCall = fileCall.Fields("id,name,mimeType,capabilities")
+	file, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.File, error) {
+		return fileCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file.Capabilities != nil && !file.Capabilities.CanReadRevisions {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied,
+			"Cannot read revisions for this file").
+			WithContext("capability", "canReadRevisions=false").
+			WithContext("fileId", fileID).
+			Build())
+	}
+
+	exportType, isWorkspace := diffExportTypes[file.MimeType]
+	if !isWorkspace && strings.HasPrefix(file.MimeType, "application/vnd.google-apps.") {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Revisions of %s files cannot be compared; only Docs, Sheets, Slides and stored files can", file.MimeType)).
+			WithContext("fileId", fileID).
+			Build())
+	}
+
+	result := &DiffResult{
+		FileID:     file.Id,
+		FileName:   file.Name,
+		MimeType:   file.MimeType,
+		ComparedAs: exportType,
+		Format:     format,
+	}
+	contentA, revA, err := m.revisionContent(ctx, reqCtx, fileID, revisionA, exportType)
+	if err != nil {
+		return nil, err
+	}
+	contentB, revB, err := m.revisionContent(ctx, reqCtx, fileID, revisionB, exportType)
+	if err != nil {
+		return nil, err
+	}
+	result.RevisionA = convertRevision(revA)
+	result.RevisionB = convertRevision(revB)
+
+	if isBinary(contentA) || isBinary(contentB) {
+		result.Binary = true
+		result.Identical = bytes.Equal(contentA, contentB)
+		return result, nil
+	}
+
+	linesA := splitLines(string(contentA))
+	linesB := splitLines(string(contentB))
+	ops := diffLines(linesA, linesB)
+	for _, op := range ops {
+		switch op.kind {
+		case lineInsert:
+			result.LinesAdded++
+		case lineDelete:
+			result.LinesRemoved++
+		}
+	}
+	result.Identical = result.LinesAdded == 0 && result.LinesRemoved == 0
+
+	nameA := fmt.Sprintf("%s (revision %s)", file.Name, revisionA)
+	nameB := fmt.Sprintf("%s (revision %s)", file.Name, revisionB)
+	if format == DiffFormatHTML {
+		result.Diff = htmlDiff(linesA, linesB, ops, nameA, nameB, file.Name, contextLines)
+	} else {
+		result.Diff = unifiedDiff(linesA, linesB, ops, nameA, nameB, contextLines)
+	}
+	return result, nil
+}
+
+// revisionContent returns the content of a revision, exported as
+// exportType when it is set
+func (m *Manager) revisionContent(ctx context.Context, reqCtx *types.RequestContext, fileID, revisionID, exportType string) ([]byte, *drive.Revision, error) {
+	call := m.client.Service().Revisions.Get(fileID, revisionID)
+	call = m.shaper.ShapeRevisionsGet(call, reqCtx)
+	call = call.Fields("id,mimeType,modifiedTime,keepForever,size,originalFilename,exportLinks")
+	revision, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Revision, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp *http.Response
+	if exportType != "" {
+		link := revision.ExportLinks[exportType]
+		if link == "" {
+			return nil, nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeRevisionNotDownloadable,
+				fmt.Sprintf("Revision %s cannot be exported as %s", revisionID, exportType)).
+				WithContext("fileId", fileID).
+				WithContext("revisionId", revisionID).
+				Build())
+		}
+		httpClient := m.client.HTTPClient()
+		if httpClient == nil {
+			return nil, nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInternalError,
+				"Exporting revisions needs an authenticated HTTP client").Build())
+		}
+		resp, err = api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*http.Response, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			if err := googleapi.CheckResponse(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		})
+	} else {
+		download := m.client.Service().Revisions.Get(fileID, revisionID)
+		download = m.shaper.ShapeRevisionsGet(download, reqCtx)
+		resp, err = api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*http.Response, error) {
+			return download.Download()
+		})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, MaxDiffSize+1))
+	if err != nil {
+		return nil, nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+			fmt.Sprintf("Failed to read revision %s: %s", revisionID, err)).Build())
+	}
+	if len(content) > MaxDiffSize {
+		return nil, nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Revision %s is larger than %d MB and cannot be compared", revisionID, MaxDiffSize>>20)).
+			WithContext("fileId", fileID).
+			WithContext("revisionId", revisionID).
+			Build())
+	}
+	return content, revision, nil
+}
+
+// isBinary reports whether content is not text
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content)
+}
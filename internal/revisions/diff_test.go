@@ -0,0 +1,119 @@
+package revisions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := splitLines("one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n")
+	b := splitLines("\ufeffone\r\ntwo\r\nTHREE\r\nfour\r\nfive\r\nsix\r\nseven\r\neight\r\nnine\r\nten\r\neleven\r\n")
+	got := unifiedDiff(a, b, diffLines(a, b), "a", "b", 1)
+	want := `--- a
++++ b
+@@ -2,3 +2,3 @@
+ two
+-three
++THREE
+ four
+@@ -10 +10,2 @@
+ ten
++eleven
+`
+	if got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := unifiedDiff(a, a, diffLines(a, a), "a", "b", 3); got != "" {
+		t.Errorf("identical texts diff = %q", got)
+	}
+
+	// Insertions into an empty text start at line 0
+	got = unifiedDiff(nil, []string{"x"}, diffLines(nil, []string{"x"}), "a", "b", 3)
+	if !strings.Contains(got, "@@ -0,0 +1 @@\n+x\n") {
+		t.Errorf("diff from empty =\n%s", got)
+	}
+}
+
+func TestDiffLines_Minimal(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+	ops := diffLines(a, b)
+	edits := 0
+	var rebuilt []string
+	for _, op := range ops {
+		switch op.kind {
+		case lineEqual:
+			rebuilt = append(rebuilt, a[op.a])
+		case lineInsert:
+			rebuilt = append(rebuilt, b[op.b])
+			edits++
+		case lineDelete:
+			edits++
+		}
+	}
+	if strings.Join(rebuilt, " ") != strings.Join(b, " ") {
+		t.Errorf("ops rebuild %q, want %q", rebuilt, b)
+	}
+	if edits != 5 {
+		t.Errorf("%d edits, want the shortest edit script of 5", edits)
+	}
+}
+
+func TestDiff_ExportsWorkspaceRevisions(t *testing.T) {
+	content := map[string]string{
+		"1": "Title\nFirst draft\n",
+		"2": "Title\nSecond draft\nWith an extra line\n",
+	}
+	var serverURL string
+	client, server := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/drive/v3/files/doc1":
+			_, _ = w.Write([]byte(`{"id":"doc1","name":"Plan","mimeType":"application/vnd.google-apps.document","capabilities":{"canReadRevisions":true}}`))
+		case strings.HasPrefix(r.URL.Path, "/drive/v3/files/doc1/revisions/"):
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/doc1/revisions/")
+			fmt.Fprintf(w, `{"id":%q,"exportLinks":{"text/plain":"%s/export/%s"}}`, id, serverURL, id)
+		case strings.HasPrefix(r.URL.Path, "/export/"):
+			_, _ = w.Write([]byte(content[strings.TrimPrefix(r.URL.Path, "/export/")]))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	serverURL = server.URL
+
+	ctx := context.Background()
+	client.SetHTTPClient(server.Client())
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeGetByID)
+
+	result, err := mgr.Diff(ctx, reqCtx, "doc1", "1", "2", DiffOptions{Context: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ComparedAs != "text/plain" || result.Identical || result.LinesAdded != 2 || result.LinesRemoved != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if !strings.Contains(result.Diff, "-First draft\n+Second draft\n+With an extra line\n") {
+		t.Errorf("diff =\n%s", result.Diff)
+	}
+
+	html, err := mgr.Diff(ctx, reqCtx, "doc1", "1", "2", DiffOptions{Format: DiffFormatHTML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.Diff, `<tr class="ins"><td class="num"></td><td class="num">3</td><td class="line">+With an extra line</td></tr>`) {
+		t.Errorf("html diff =\n%s", html.Diff)
+	}
+
+	if _, err := mgr.Diff(ctx, reqCtx, "doc1", "1", "2", DiffOptions{Format: "side-by-side"}); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
@@ -0,0 +1,299 @@
+package revisions
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// maxDiffEdits bounds the work done to find the shortest line diff. Past
+// it, the differing middle of the two texts is shown as replaced wholesale.
+const maxDiffEdits = 2000
+
+type lineOpKind int
+
+const (
+	lineEqual lineOpKind = iota
+	lineDelete
+	lineInsert
+)
+
+// lineOp is one step of a line diff. a and b are the positions in the old
+// and new lines; for an insert a is where the line goes in the old text,
+// and for a delete b is where it was in the new text.
+type lineOp struct {
+	kind lineOpKind
+	a, b int
+}
+
+// splitLines splits text into lines, dropping the trailing newline, a
+// byte order mark, and carriage returns from CRLF line endings
+func splitLines(text string) []string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns the steps that turn a into b, using Myers' algorithm
+// on the lines between the common prefix and suffix
+func diffLines(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]lineOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, lineOp{kind: lineEqual, a: i, b: i})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, lineOp{kind: lineEqual, a: len(a) - suffix + i, b: len(b) - suffix + i})
+	}
+	return ops
+}
+
+// myers diffs a and b, offsetting positions by aOff and bOff
+func myers(a, b []string, aOff, bOff int) []lineOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m, aOff, bOff)
+	}
+	max := n + m
+	if max > maxDiffEdits {
+		max = maxDiffEdits
+	}
+
+	// v holds the furthest x reached on each diagonal k, at v[k+offset];
+	// trace[d] keeps diagonals -d..d after d edits, at trace[d][k+d]
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		row := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			row[k+d] = x
+			if x >= n && y >= m {
+				trace = append(trace, row)
+				return backtrack(trace, n, m, aOff, bOff)
+			}
+		}
+		trace = append(trace, row)
+	}
+	return replaceAll(n, m, aOff, bOff)
+}
+
+// backtrack walks trace back from (n, m) to recover the edit steps
+func backtrack(trace [][]int, n, m, aOff, bOff int) []lineOp {
+	var reversed []lineOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, lineOp{kind: lineEqual, a: aOff + x, b: bOff + y})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, lineOp{kind: lineInsert, a: aOff + x, b: bOff + y})
+		} else {
+			x--
+			reversed = append(reversed, lineOp{kind: lineDelete, a: aOff + x, b: bOff + y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, lineOp{kind: lineEqual, a: aOff + x, b: bOff + y})
+	}
+
+	ops := make([]lineOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+// replaceAll returns the steps that delete all n lines of a and insert all
+// m lines of b
+func replaceAll(n, m, aOff, bOff int) []lineOp {
+	ops := make([]lineOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, lineOp{kind: lineDelete, a: aOff + i, b: bOff})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, lineOp{kind: lineInsert, a: aOff + n, b: bOff + j})
+	}
+	return ops
+}
+
+// diffHunk is a run of changes with the unchanged lines around them
+type diffHunk struct {
+	ops []lineOp
+}
+
+// hunks groups the changes in ops with up to context unchanged lines on
+// either side, merging changes that are closer than that
+func hunks(ops []lineOp, context int) []diffHunk {
+	var result []diffHunk
+	start := -1
+	lastChange := -1
+	for i, op := range ops {
+		if op.kind == lineEqual {
+			continue
+		}
+		if start >= 0 && i-lastChange-1 > 2*context {
+			result = append(result, diffHunk{ops: ops[start : lastChange+1+context]})
+			start = -1
+		}
+		if start < 0 {
+			start = i - context
+			if start < 0 {
+				start = 0
+			}
+		}
+		lastChange = i
+	}
+	if start >= 0 {
+		end := lastChange + 1 + context
+		if end > len(ops) {
+			end = len(ops)
+		}
+		result = append(result, diffHunk{ops: ops[start:end]})
+	}
+	return result
+}
+
+// ranges returns the unified diff line ranges of h: the 1-based start and
+// length in the old and new text, where an empty range starts at the line
+// before it
+func (h diffHunk) ranges() (aStart, aLen, bStart, bLen int) {
+	first := h.ops[0]
+	for _, op := range h.ops {
+		if op.kind != lineInsert {
+			aLen++
+		}
+		if op.kind != lineDelete {
+			bLen++
+		}
+	}
+	aStart, bStart = first.a, first.b
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+	return aStart, aLen, bStart, bLen
+}
+
+func formatRange(start, length int) string {
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
+
+// unifiedDiff renders the diff of a and b in unified format
+func unifiedDiff(a, b []string, ops []lineOp, nameA, nameB string, context int) string {
+	groups := hunks(ops, context)
+	if len(groups) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+	for _, h := range groups {
+		aStart, aLen, bStart, bLen := h.ranges()
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", formatRange(aStart, aLen), formatRange(bStart, bLen))
+		for _, op := range h.ops {
+			switch op.kind {
+			case lineEqual:
+				sb.WriteString(" " + a[op.a] + "\n")
+			case lineDelete:
+				sb.WriteString("-" + a[op.a] + "\n")
+			case lineInsert:
+				sb.WriteString("+" + b[op.b] + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// htmlDiff renders the diff of a and b as a standalone HTML page
+func htmlDiff(a, b []string, ops []lineOp, nameA, nameB, title string, context int) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(title))
+	sb.WriteString(`<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; font-family: monospace; width: 100%; }
+td { padding: 0 6px; white-space: pre-wrap; vertical-align: top; }
+td.num { color: #888; text-align: right; width: 1%; user-select: none; }
+tr.del td.line { background: #ffebe9; }
+tr.ins td.line { background: #e6ffec; }
+tr.hunk td { background: #ddf4ff; color: #555; }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n<p>%s &rarr; %s</p>\n", html.EscapeString(title), html.EscapeString(nameA), html.EscapeString(nameB))
+
+	groups := hunks(ops, context)
+	if len(groups) == 0 {
+		sb.WriteString("<p>No differences.</p>\n")
+	} else {
+		sb.WriteString("<table>\n")
+		for _, h := range groups {
+			aStart, aLen, bStart, bLen := h.ranges()
+			fmt.Fprintf(&sb, "<tr class=\"hunk\"><td class=\"num\"></td><td class=\"num\"></td><td>@@ -%s +%s @@</td></tr>\n",
+				formatRange(aStart, aLen), formatRange(bStart, bLen))
+			for _, op := range h.ops {
+				switch op.kind {
+				case lineEqual:
+					fmt.Fprintf(&sb, "<tr><td class=\"num\">%d</td><td class=\"num\">%d</td><td class=\"line\">%s</td></tr>\n",
+						op.a+1, op.b+1, html.EscapeString(a[op.a]))
+				case lineDelete:
+					fmt.Fprintf(&sb, "<tr class=\"del\"><td class=\"num\">%d</td><td class=\"num\"></td><td class=\"line\">-%s</td></tr>\n",
+						op.a+1, html.EscapeString(a[op.a]))
+				case lineInsert:
+					fmt.Fprintf(&sb, "<tr class=\"ins\"><td class=\"num\"></td><td class=\"num\">%d</td><td class=\"line\">+%s</td></tr>\n",
+						op.b+1, html.EscapeString(b[op.b]))
+				}
+			}
+		}
+		sb.WriteString("</table>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
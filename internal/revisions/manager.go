@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
 	"github.com/dl-alexandre/gdrv/internal/types"
 	"github.com/dl-alexandre/gdrv/internal/utils"
 	"google.golang.org/api/drive/v3"
@@ -15,8 +16,9 @@ import (
 
 // Manager handles file revision operations
 type Manager struct {
-	client *api.Client
-	shaper *api.RequestShaper
+	client   *api.Client
+	shaper   *api.RequestShaper
+	progress types.ProgressReporter
 }
 
 // NewManager creates a new revision manager
@@ -27,6 +29,11 @@ func NewManager(client *api.Client) *Manager {
 	}
 }
 
+// SetProgress sets the reporter told about each revision of a prune
+func (m *Manager) SetProgress(r types.ProgressReporter) {
+	m.progress = r
+}
+
 // ListOptions configures revision listing
 type ListOptions struct {
 	PageSize  int
@@ -137,7 +144,7 @@ func (m *Manager) Download(ctx context.Context, reqCtx *types.RequestContext, fi
 			"Revision must be marked keepForever=true before downloading").
 			WithContext("revisionId", revisionID).
 			WithContext("fileId", fileID).
-			WithContext("suggestedAction", "use update command to set keepForever=true first").
+			WithContext("suggestedAction", "mark it with 'gdrv files revisions keep <file-id> <revision-id>' first").
 			Build())
 	}
 
@@ -150,12 +157,12 @@ func (m *Manager) Download(ctx context.Context, reqCtx *types.RequestContext, fi
 		}
 	}
 
-	outFile, err := os.Create(outputPath)
+	outFile, err := tempdir.CreatePartial(outputPath)
 	if err != nil {
 		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
 			fmt.Sprintf("Failed to create output file: %s", err)).Build())
 	}
-	defer outFile.Close()
+	defer outFile.Discard()
 
 	// Download revision content
 	call := m.client.Service().Revisions.Get(fileID, revisionID)
@@ -177,8 +184,10 @@ func (m *Manager) Download(ctx context.Context, reqCtx *types.RequestContext, fi
 	}
 	defer resp.Body.Close()
 
-	_, err = io.Copy(outFile, resp.Body)
-	return err
+	if _, err := io.Copy(outFile, resp.Body); err != nil {
+		return err
+	}
+	return outFile.Commit()
 }
 
 // UpdateOptions configures revision update
@@ -190,8 +199,10 @@ type UpdateOptions struct {
 func (m *Manager) Update(ctx context.Context, reqCtx *types.RequestContext, fileID string, revisionID string, opts UpdateOptions) (*types.Revision, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
 
+	// false is the zero value, so it has to be sent explicitly
 	metadata := &drive.Revision{
-		KeepForever: opts.KeepForever,
+		KeepForever:     opts.KeepForever,
+		ForceSendFields: []string{"KeepForever"},
 	}
 
 	call := m.client.Service().Revisions.Update(fileID, revisionID, metadata)
@@ -224,6 +235,20 @@ func (m *Manager) Update(ctx context.Context, reqCtx *types.RequestContext, file
 	return convertRevision(result), nil
 }
 
+// Delete permanently deletes a revision. Drive only deletes revisions of
+// files with binary content, and never the head revision.
+func (m *Manager) Delete(ctx context.Context, reqCtx *types.RequestContext, fileID string, revisionID string) error {
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+
+	call := m.client.Service().Revisions.Delete(fileID, revisionID)
+	call = m.shaper.ShapeRevisionsDelete(call, reqCtx)
+
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (interface{}, error) {
+		return nil, call.Do()
+	})
+	return err
+}
+
 // Restore restores a file to a specific revision
 func (m *Manager) Restore(ctx context.Context, reqCtx *types.RequestContext, fileID string, revisionID string) (*types.DriveFile, error) {
 	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
@@ -245,7 +270,7 @@ func (m *Manager) Restore(ctx context.Context, reqCtx *types.RequestContext, fil
 	}
 
 	// Create temporary file for download
-	tmpFile, err := os.CreateTemp("", "gdrv-restore-*")
+	tmpFile, err := tempdir.CreateTemp("restore-*")
 	if err != nil {
 		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInternalError,
 			fmt.Sprintf("Failed to create temporary file: %s", err)).Build())
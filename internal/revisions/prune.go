@@ -0,0 +1,159 @@
+package revisions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// pruneFields are the revision fields a prune needs
+const pruneFields = "id,modifiedTime,keepForever,size,mimeType,originalFilename"
+
+// PrunePlan lists the revisions a prune would delete
+type PrunePlan struct {
+	FileID         string
+	KeepLast       int
+	TotalRevisions int
+	Delete         []*types.Revision // Oldest first
+	Pinned         []*types.Revision // Older than KeepLast but marked keepForever
+}
+
+// PruneFailure is a revision that could not be deleted
+type PruneFailure struct {
+	RevisionID   string `json:"revisionId"`
+	ErrorMessage string `json:"errorMessage"`
+	ErrorCode    string `json:"errorCode,omitempty"`
+	HTTPStatus   int    `json:"httpStatus,omitempty"`
+}
+
+// PruneResult is the outcome of a prune
+type PruneResult struct {
+	FileID         string            `json:"fileId"`
+	KeepLast       int               `json:"keepLast"`
+	TotalRevisions int               `json:"totalRevisions"`
+	Deleted        []*types.Revision `json:"deleted"`
+	Pinned         []*types.Revision `json:"pinned,omitempty"`
+	Failed         []*PruneFailure   `json:"failed,omitempty"`
+	DryRun         bool              `json:"dryRun,omitempty"`
+}
+
+// PlanPrune lists every revision of a file and picks the ones older than
+// the newest keepLast. Revisions marked keepForever are never picked; unmark
+// them with Update to prune them.
+func (m *Manager) PlanPrune(ctx context.Context, reqCtx *types.RequestContext, fileID string, keepLast int) (*PrunePlan, error) {
+	if keepLast < 1 {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Must keep at least the newest revision, got keep-last %d", keepLast)).
+			WithContext("reason", "the head revision cannot be deleted").
+			Build())
+	}
+
+	var all []*types.Revision
+	opts := ListOptions{PageSize: 1000, Fields: pruneFields}
+	for {
+		page, err := m.List(ctx, reqCtx, fileID, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Revisions...)
+		if page.NextPageToken == "" {
+			break
+		}
+		opts.PageToken = page.NextPageToken
+	}
+
+	for _, r := range all {
+		if strings.HasPrefix(r.MimeType, "application/vnd.google-apps.") {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+				"Revisions of Google Workspace files cannot be deleted").
+				WithContext("fileId", fileID).
+				WithContext("mimeType", r.MimeType).
+				Build())
+		}
+	}
+
+	// Drive lists revisions oldest first; sort anyway so keepLast always
+	// keeps the newest
+	sort.SliceStable(all, func(i, j int) bool { return all[i].ModifiedTime < all[j].ModifiedTime })
+
+	plan := &PrunePlan{FileID: fileID, KeepLast: keepLast, TotalRevisions: len(all), Delete: []*types.Revision{}}
+	if len(all) <= keepLast {
+		return plan, nil
+	}
+	for _, r := range all[:len(all)-keepLast] {
+		if r.KeepForever {
+			plan.Pinned = append(plan.Pinned, r)
+			continue
+		}
+		plan.Delete = append(plan.Delete, r)
+	}
+	return plan, nil
+}
+
+// Prune deletes the revisions picked by plan. A revision that fails is
+// recorded and the rest are still deleted; with dryRun nothing is deleted.
+func (m *Manager) Prune(ctx context.Context, reqCtx *types.RequestContext, plan *PrunePlan, dryRun bool) (*PruneResult, error) {
+	result := &PruneResult{
+		FileID:         plan.FileID,
+		KeepLast:       plan.KeepLast,
+		TotalRevisions: plan.TotalRevisions,
+		Deleted:        []*types.Revision{},
+		Pinned:         plan.Pinned,
+		DryRun:         dryRun,
+	}
+	for i, r := range plan.Delete {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		types.ReportProgress(m.progress, types.ProgressEvent{Operation: "revisions prune", Done: i + 1, Total: len(plan.Delete), Item: r.ID})
+
+		if !dryRun {
+			// Each delete gets a request context of its own so a long run
+			// does not collect the file ID once per revision
+			itemCtx := api.NewRequestContext(reqCtx.Profile, reqCtx.DriveID, types.RequestTypeMutation)
+			itemCtx.TraceID = reqCtx.TraceID
+			if err := m.Delete(ctx, itemCtx, plan.FileID, r.ID); err != nil {
+				failure := &PruneFailure{RevisionID: r.ID, ErrorMessage: err.Error()}
+				if appErr, ok := err.(*utils.AppError); ok {
+					failure.ErrorCode = appErr.CLIError.Code
+					failure.HTTPStatus = appErr.CLIError.HTTPStatus
+				}
+				result.Failed = append(result.Failed, failure)
+				continue
+			}
+		}
+		result.Deleted = append(result.Deleted, r)
+	}
+	return result, nil
+}
+
+func (r *PruneResult) Headers() []string {
+	return []string{"Revision ID", "Modified", "Size", "Status"}
+}
+
+func (r *PruneResult) Rows() [][]string {
+	deleted := "deleted"
+	if r.DryRun {
+		deleted = "would delete"
+	}
+	var rows [][]string
+	for _, rev := range r.Deleted {
+		rows = append(rows, []string{rev.ID, rev.ModifiedTime, fmt.Sprintf("%d", rev.Size), deleted})
+	}
+	for _, rev := range r.Pinned {
+		rows = append(rows, []string{rev.ID, rev.ModifiedTime, fmt.Sprintf("%d", rev.Size), "kept (keepForever)"})
+	}
+	for _, f := range r.Failed {
+		rows = append(rows, []string{f.RevisionID, "", "", "failed: " + f.ErrorMessage})
+	}
+	return rows
+}
+
+func (r *PruneResult) EmptyMessage() string {
+	return fmt.Sprintf("Nothing to prune: %d revisions, keeping the last %d", r.TotalRevisions, r.KeepLast)
+}
@@ -0,0 +1,99 @@
+package revisions
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestPrune(t *testing.T) {
+	var deleted []string
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/drive/v3/files/f1":
+			_, _ = w.Write([]byte(`{"id":"f1","capabilities":{"canReadRevisions":true}}`))
+		case r.URL.Path == "/drive/v3/files/f1/revisions" && r.Method == http.MethodGet:
+			if !strings.Contains(r.URL.Query().Get("fields"), "keepForever") {
+				t.Errorf("fields = %q, want keepForever", r.URL.Query().Get("fields"))
+			}
+			_, _ = w.Write([]byte(`{"revisions":[
+				{"id":"r1","modifiedTime":"2024-01-01T00:00:00Z"},
+				{"id":"r2","modifiedTime":"2024-01-02T00:00:00Z","keepForever":true},
+				{"id":"r5","modifiedTime":"2024-01-05T00:00:00Z"},
+				{"id":"r3","modifiedTime":"2024-01-03T00:00:00Z"},
+				{"id":"r4","modifiedTime":"2024-01-04T00:00:00Z"}
+			]}`))
+		case strings.HasPrefix(r.URL.Path, "/drive/v3/files/f1/revisions/") && r.Method == http.MethodDelete:
+			id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/f1/revisions/")
+			if id == "r3" {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"error":{"code":403,"message":"forbidden","errors":[{"reason":"insufficientFilePermissions"}]}}`))
+				return
+			}
+			deleted = append(deleted, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+
+	ctx := context.Background()
+	mgr := NewManager(client)
+	reqCtx := api.NewRequestContext("default", "", types.RequestTypeListOrSearch)
+
+	if _, err := mgr.PlanPrune(ctx, reqCtx, "f1", 0); err == nil {
+		t.Error("keep-last 0 should be rejected")
+	}
+
+	plan, err := mgr.PlanPrune(ctx, reqCtx, "f1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := revisionIDs(plan.Delete); ids != "r1,r3" {
+		t.Errorf("plan deletes %s, want r1,r3 (the newest two and the pinned r2 kept)", ids)
+	}
+	if ids := revisionIDs(plan.Pinned); ids != "r2" {
+		t.Errorf("pinned = %s, want r2", ids)
+	}
+
+	dry, err := mgr.Prune(ctx, reqCtx, plan, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 0 || revisionIDs(dry.Deleted) != "r1,r3" {
+		t.Errorf("dry run deleted %v and reported %s", deleted, revisionIDs(dry.Deleted))
+	}
+
+	result, err := mgr.Prune(ctx, reqCtx, plan, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(deleted, ",") != "r1" || revisionIDs(result.Deleted) != "r1" {
+		t.Errorf("deleted %v, reported %s, want r1", deleted, revisionIDs(result.Deleted))
+	}
+	if len(result.Failed) != 1 || result.Failed[0].RevisionID != "r3" {
+		t.Errorf("failed = %+v, want r3", result.Failed)
+	}
+
+	plan, err = mgr.PlanPrune(ctx, reqCtx, "f1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Delete) != 0 || len(plan.Pinned) != 0 {
+		t.Errorf("plan = %+v, want nothing to prune", plan)
+	}
+}
+
+func revisionIDs(revs []*types.Revision) string {
+	ids := make([]string, len(revs))
+	for i, r := range revs {
+		ids[i] = r.ID
+	}
+	return strings.Join(ids, ",")
+}
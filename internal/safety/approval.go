@@ -0,0 +1,254 @@
+package safety
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// ApprovalSchemaVersion is the version of the approval file format
+const ApprovalSchemaVersion = 1
+
+// publicKeyPrefix marks an encoded approver public key
+const publicKeyPrefix = "ed25519:"
+
+// ApprovalPolicy sets the blast radius above which a bulk change needs a
+// signed approval, and whose signatures count. A zero limit is not checked.
+type ApprovalPolicy struct {
+	// MaxFiles is the number of distinct files a plan may touch
+	MaxFiles int `json:"maxFiles,omitempty"`
+
+	// MaxPermissionDeletions is the number of grants a plan may remove
+	MaxPermissionDeletions int `json:"maxPermissionDeletions,omitempty"`
+
+	// TrustedKeys are the approvers' public keys, as printed by
+	// 'gdrv plan keygen' ("ed25519:<base64>")
+	TrustedKeys []string `json:"trustedKeys,omitempty"`
+
+	// KeyOwners maps each trusted key to the Google account of the person
+	// who holds it, so no one can approve their own change
+	KeyOwners map[string]string `json:"keyOwners,omitempty"`
+}
+
+// Validate checks the limits and that every trusted key parses
+func (p *ApprovalPolicy) Validate() error {
+	if p.MaxFiles < 0 || p.MaxPermissionDeletions < 0 {
+		return fmt.Errorf("approval limits must not be negative")
+	}
+	if (p.MaxFiles > 0 || p.MaxPermissionDeletions > 0) && len(p.TrustedKeys) == 0 {
+		return fmt.Errorf("approval limits are set but approval.trustedKeys is empty, so no plan above them could be approved")
+	}
+	for _, key := range p.TrustedKeys {
+		if _, err := ParsePublicKey(key); err != nil {
+			return fmt.Errorf("invalid approval.trustedKeys entry: %w", err)
+		}
+		if p.KeyOwners[key] == "" {
+			return fmt.Errorf("approval.keyOwners does not name the account that holds %s", key)
+		}
+	}
+	return nil
+}
+
+// Exceeded describes how plan goes beyond the blast radius, or returns ""
+// when it stays within it
+func (p *ApprovalPolicy) Exceeded(plan *types.DryRunPlan) string {
+	files := map[string]bool{}
+	deletions := 0
+	for _, op := range plan.Operations {
+		target := op.Target.ID
+		if target == "" {
+			target = op.Target.Name
+		}
+		files[target] = true
+		if op.Type == string(OpTypeDeletePermission) {
+			deletions++
+		}
+	}
+
+	var reasons []string
+	if p.MaxFiles > 0 && len(files) > p.MaxFiles {
+		reasons = append(reasons, fmt.Sprintf("%d files affected (limit %d)", len(files), p.MaxFiles))
+	}
+	if p.MaxPermissionDeletions > 0 && deletions > p.MaxPermissionDeletions {
+		reasons = append(reasons, fmt.Sprintf("%d permission deletions (limit %d)", deletions, p.MaxPermissionDeletions))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Approval records that the holder of a key reviewed one exact plan.
+// The signature covers every other field.
+type Approval struct {
+	SchemaVersion   int            `json:"schemaVersion"`
+	Command         string         `json:"command"`
+	PlanFingerprint string         `json:"planFingerprint"`
+	Summary         map[string]int `json:"summary"`
+	Approver        string         `json:"approver,omitempty"`
+	ApprovedAt      time.Time      `json:"approvedAt"`
+	ExpiresAt       time.Time      `json:"expiresAt"`
+	PublicKey       string         `json:"publicKey"`
+	Signature       string         `json:"signature"`
+}
+
+// signedBytes is the canonical encoding the signature is made over
+func (a *Approval) signedBytes() []byte {
+	unsigned := *a
+	unsigned.Signature = ""
+	data, _ := json.Marshal(unsigned)
+	return data
+}
+
+// ApprovePlan signs plan with key, valid until validFor after now. The
+// plan's fingerprint is recomputed from its operations, so a plan edited
+// after it was written is refused.
+func ApprovePlan(plan *types.DryRunPlan, key ed25519.PrivateKey, approver string, now time.Time, validFor time.Duration) (*Approval, error) {
+	if validFor <= 0 {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"An approval must be valid for a positive period").Build())
+	}
+	if plan.SchemaVersion != types.PlanSchemaVersion {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Unsupported plan schema version %d", plan.SchemaVersion)).Build())
+	}
+	fingerprint := PlanFingerprint(plan)
+	if plan.Fingerprint != "" && plan.Fingerprint != fingerprint {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeApprovalInvalid,
+			"The plan's operations do not match its fingerprint; it was edited after it was written").
+			WithContext("fingerprint", plan.Fingerprint).
+			WithContext("computed", fingerprint).Build())
+	}
+
+	approval := &Approval{
+		SchemaVersion:   ApprovalSchemaVersion,
+		Command:         plan.Command,
+		PlanFingerprint: fingerprint,
+		Summary:         plan.Summary,
+		Approver:        approver,
+		ApprovedAt:      now.UTC(),
+		ExpiresAt:       now.Add(validFor).UTC(),
+		PublicKey:       EncodePublicKey(key.Public().(ed25519.PublicKey)),
+	}
+	approval.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, approval.signedBytes()))
+	return approval, nil
+}
+
+// Verify checks that the approval is signed by a key policy trusts, has
+// not expired at now and was given for exactly plan. A key held by
+// operator, the account making the change, is refused.
+func (a *Approval) Verify(plan *types.DryRunPlan, policy *ApprovalPolicy, operator string, now time.Time) error {
+	invalid := func(msg string) error {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeApprovalInvalid, msg).
+			WithContext("planFingerprint", plan.Fingerprint).Build())
+	}
+
+	if a.SchemaVersion != ApprovalSchemaVersion {
+		return invalid(fmt.Sprintf("Unsupported approval schema version %d", a.SchemaVersion))
+	}
+	trusted := false
+	for _, key := range policy.TrustedKeys {
+		if key == a.PublicKey {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return invalid("The approval is signed with a key that is not in approval.trustedKeys")
+	}
+	pub, err := ParsePublicKey(a.PublicKey)
+	if err != nil {
+		return invalid(err.Error())
+	}
+	sig, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil || !ed25519.Verify(pub, a.signedBytes(), sig) {
+		return invalid("The approval signature is not valid; the file was changed after it was signed")
+	}
+	if !now.Before(a.ExpiresAt) {
+		return invalid(fmt.Sprintf("The approval expired at %s; have the plan approved again", a.ExpiresAt.Format(time.RFC3339)))
+	}
+	owner := policy.KeyOwners[a.PublicKey]
+	switch {
+	case owner == "":
+		return invalid("approval.keyOwners does not name the account that holds the approval's key")
+	case operator == "":
+		return invalid("Cannot tell which account is making this change, so cannot check it is not the approver's")
+	case strings.EqualFold(owner, operator):
+		return invalid(fmt.Sprintf("The approval is signed with a key held by %s, who is making this change; it needs a second person's approval", operator))
+	}
+	if a.Command != plan.Command || a.PlanFingerprint != plan.Fingerprint {
+		return invalid(fmt.Sprintf("The approval is for %s plan %s, but this run would execute %s plan %s",
+			a.Command, a.PlanFingerprint, plan.Command, plan.Fingerprint))
+	}
+	return nil
+}
+
+// LoadApproval reads an approval file
+func LoadApproval(path string) (*Approval, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Failed to read approval file: %v", err)).Build())
+	}
+	var approval Approval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeApprovalInvalid,
+			fmt.Sprintf("Approval file %s is not valid JSON: %v", path, err)).Build())
+	}
+	return &approval, nil
+}
+
+// EncodePublicKey formats an approver public key for approval.trustedKeys
+func EncodePublicKey(pub ed25519.PublicKey) string {
+	return publicKeyPrefix + base64.StdEncoding.EncodeToString(pub)
+}
+
+// ParsePublicKey parses a key formatted by EncodePublicKey
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	encoded, ok := strings.CutPrefix(s, publicKeyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("public key %q must start with %s", s, publicKeyPrefix)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key %q is not a base64 Ed25519 key", s)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// EncodePrivateKey returns key as a PKCS #8 PEM block, the format
+// 'openssl genpkey -algorithm ed25519' writes
+func EncodePrivateKey(key ed25519.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// LoadPrivateKey reads a PKCS #8 PEM Ed25519 private key
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 key", path)
+	}
+	return edKey, nil
+}
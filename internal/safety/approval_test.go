@@ -0,0 +1,171 @@
+package safety
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func approvalTestOps() []PlannedOperation {
+	return []PlannedOperation{
+		{Type: OpTypeDeletePermission, ResourceID: "f1", Parameters: map[string]interface{}{"permissionID": "p1", "limit": 3}, Predicted: "permission removed"},
+		{Type: OpTypeDeletePermission, ResourceID: "f1", Parameters: map[string]interface{}{"permissionID": "p2"}, Predicted: "permission removed"},
+		{Type: OpTypeCreatePermission, ResourceID: "f2", Parameters: map[string]interface{}{"role": "reader"}, Predicted: "reader granted"},
+	}
+}
+
+func TestApprovalRoundTrip(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &ApprovalPolicy{
+		TrustedKeys: []string{EncodePublicKey(pub)},
+		KeyOwners:   map[string]string{EncodePublicKey(pub): "reviewer@example.com"},
+	}
+	now := time.Now()
+
+	// The approver signs the plan as read back from --plan-file
+	written, _ := json.Marshal(NewPlan("permissions.diff", approvalTestOps()))
+	var reviewed types.DryRunPlan
+	if err := json.Unmarshal(written, &reviewed); err != nil {
+		t.Fatal(err)
+	}
+	approval, err := ApprovePlan(&reviewed, key, "reviewer@example.com", now, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The approval file is read back by the run that makes the changes
+	data, _ := json.Marshal(approval)
+	path := filepath.Join(t.TempDir(), "approval.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadApproval(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Verify(NewPlan("permissions.diff", approvalTestOps()), policy, "operator@example.com", now); err != nil {
+		t.Fatalf("approval for the same plan rejected: %v", err)
+	}
+
+	changed := approvalTestOps()
+	changed[2].Parameters["role"] = "writer"
+	assertApprovalInvalid(t, loaded.Verify(NewPlan("permissions.diff", changed), policy, "operator@example.com", now), "would execute")
+	assertApprovalInvalid(t, loaded.Verify(NewPlan("permissions.apply", approvalTestOps()), policy, "operator@example.com", now), "would execute")
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	untrusted := &ApprovalPolicy{TrustedKeys: []string{EncodePublicKey(other)}, KeyOwners: map[string]string{EncodePublicKey(other): "other@example.com"}}
+	assertApprovalInvalid(t, loaded.Verify(NewPlan("permissions.diff", approvalTestOps()), untrusted, "operator@example.com", now), "not in approval.trustedKeys")
+
+	forged := *loaded
+	forged.Summary = map[string]int{"delete_permission": 1}
+	assertApprovalInvalid(t, forged.Verify(NewPlan("permissions.diff", approvalTestOps()), policy, "operator@example.com", now), "signature")
+
+	reviewed.Operations = reviewed.Operations[1:]
+	if _, err := ApprovePlan(&reviewed, key, "", now, time.Hour); err == nil {
+		t.Error("a plan edited after it was written should not be approved")
+	}
+}
+
+func TestApprovalVerify_ExpiryAndSelfApproval(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &ApprovalPolicy{
+		TrustedKeys: []string{EncodePublicKey(pub)},
+		KeyOwners:   map[string]string{EncodePublicKey(pub): "Alice@example.com"},
+	}
+	plan := NewPlan("permissions.diff", approvalTestOps())
+	approvedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	approval, err := ApprovePlan(plan, key, "alice", approvedAt, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approval.ExpiresAt.Equal(approvedAt.Add(2 * time.Hour)) {
+		t.Errorf("expiresAt = %v, want two hours after approval", approval.ExpiresAt)
+	}
+
+	if err := approval.Verify(plan, policy, "bob@example.com", approvedAt.Add(time.Hour)); err != nil {
+		t.Errorf("unexpired approval rejected: %v", err)
+	}
+	assertApprovalInvalid(t, approval.Verify(plan, policy, "bob@example.com", approvedAt.Add(2*time.Hour)), "expired")
+
+	extended := *approval
+	extended.ExpiresAt = extended.ExpiresAt.Add(24 * time.Hour)
+	assertApprovalInvalid(t, extended.Verify(plan, policy, "bob@example.com", approvedAt.Add(3*time.Hour)), "signature")
+
+	assertApprovalInvalid(t, approval.Verify(plan, policy, "alice@example.com", approvedAt), "who is making this change")
+	assertApprovalInvalid(t, approval.Verify(plan, policy, "", approvedAt), "Cannot tell which account")
+	unowned := &ApprovalPolicy{TrustedKeys: policy.TrustedKeys}
+	assertApprovalInvalid(t, approval.Verify(plan, unowned, "bob@example.com", approvedAt), "keyOwners")
+
+	if _, err := ApprovePlan(plan, key, "alice", approvedAt, 0); err == nil {
+		t.Error("an approval valid for no time should not be made")
+	}
+}
+
+func assertApprovalInvalid(t *testing.T, err error, want string) {
+	t.Helper()
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeApprovalInvalid || !strings.Contains(appErr.CLIError.Message, want) {
+		t.Errorf("err = %v, want %s mentioning %q", err, utils.ErrCodeApprovalInvalid, want)
+	}
+}
+
+func TestApprovalPolicyExceeded(t *testing.T) {
+	plan := NewPlan("permissions.diff", approvalTestOps())
+
+	if got := (&ApprovalPolicy{}).Exceeded(plan); got != "" {
+		t.Errorf("no limits exceeded %q", got)
+	}
+	if got := (&ApprovalPolicy{MaxFiles: 2, MaxPermissionDeletions: 2}).Exceeded(plan); got != "" {
+		t.Errorf("plan at the limits exceeded %q", got)
+	}
+	got := (&ApprovalPolicy{MaxFiles: 1, MaxPermissionDeletions: 1}).Exceeded(plan)
+	if got != "2 files affected (limit 1), 2 permission deletions (limit 1)" {
+		t.Errorf("exceeded = %q", got)
+	}
+}
+
+func TestApprovalPolicyValidate(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	owners := map[string]string{EncodePublicKey(pub): "alice@example.com"}
+	if err := (&ApprovalPolicy{MaxFiles: 10, TrustedKeys: []string{EncodePublicKey(pub)}, KeyOwners: owners}).Validate(); err != nil {
+		t.Errorf("valid policy rejected: %v", err)
+	}
+	for _, p := range []*ApprovalPolicy{
+		{MaxFiles: -1},
+		{MaxFiles: 10},
+		{TrustedKeys: []string{"ed25519:not-base64"}},
+		{TrustedKeys: []string{strings.TrimPrefix(EncodePublicKey(pub), "ed25519:")}},
+		{MaxFiles: 10, TrustedKeys: []string{EncodePublicKey(pub)}},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("policy %+v should be rejected", p)
+		}
+	}
+
+	encoded, err := EncodePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "approver.pem")
+	if err := os.WriteFile(path, encoded, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPrivateKey(path)
+	if err != nil || !loaded.Equal(key) {
+		t.Errorf("key did not round-trip: %v", err)
+	}
+}
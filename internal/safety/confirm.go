@@ -24,21 +24,18 @@ func Confirm(message string, opts SafetyOptions) (bool, error) {
 	// Auto-confirm if flags are set
 	if opts.AutoConfirm() {
 		if !opts.Quiet && !opts.DryRun {
-			fmt.Printf("%s [auto-confirmed]\n", message)
+			fmt.Println(Msg(MsgAutoConfirmed, map[string]interface{}{"Message": message}))
 		}
 		return true, nil
 	}
 
 	// Non-interactive mode without auto-confirm flag
 	if !opts.Interactive {
-		return false, utils.NewAppError(utils.NewCLIError(
-			utils.ErrCodeInvalidArgument,
-			"Confirmation required but running in non-interactive mode. Use --yes or --force to proceed.",
-		).Build())
+		return false, nonInteractiveError(opts)
 	}
 
 	// Interactive prompt
-	fmt.Printf("%s [y/N]: ", message)
+	fmt.Print(Msg(MsgPromptSuffix, map[string]interface{}{"Message": message}))
 	reader := bufio.NewReader(os.Stdin)
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -64,11 +61,12 @@ func ConfirmBulkOperation(itemCount int, operation string, opts SafetyOptions) (
 		return false, nil
 	}
 
+	vars := map[string]interface{}{"Operation": operation, "Count": itemCount}
 	if itemCount == 1 {
-		return Confirm(fmt.Sprintf("About to %s 1 item. Continue?", operation), opts)
+		return Confirm(Msg(MsgBulkConfirmOne, vars), opts)
 	}
 
-	return Confirm(fmt.Sprintf("About to %s %d items. Continue?", operation, itemCount), opts)
+	return Confirm(Msg(MsgBulkConfirmMany, vars), opts)
 }
 
 // ConfirmDestructive prompts for confirmation of a destructive operation with item details.
@@ -92,21 +90,18 @@ func ConfirmDestructive(items []string, operation string, opts SafetyOptions) (b
 	// Auto-confirm if flags are set
 	if opts.AutoConfirm() {
 		if !opts.Quiet && !opts.DryRun {
-			fmt.Printf("About to %s %d item(s) [auto-confirmed]\n", operation, len(items))
+			fmt.Println(Msg(MsgDestructiveAutoConfirmed, map[string]interface{}{"Operation": operation, "Count": len(items)}))
 		}
 		return true, nil
 	}
 
 	// Non-interactive mode without auto-confirm flag
 	if !opts.Interactive {
-		return false, utils.NewAppError(utils.NewCLIError(
-			utils.ErrCodeInvalidArgument,
-			"Confirmation required but running in non-interactive mode. Use --yes or --force to proceed.",
-		).Build())
+		return false, nonInteractiveError(opts)
 	}
 
 	// Display items to be affected
-	fmt.Printf("\n⚠️  WARNING: About to %s the following items:\n\n", operation)
+	fmt.Println(Msg(MsgDestructiveHeader, map[string]interface{}{"Operation": operation}))
 
 	displayCount := len(items)
 	if displayCount > 10 {
@@ -114,17 +109,17 @@ func ConfirmDestructive(items []string, operation string, opts SafetyOptions) (b
 	}
 
 	for i := 0; i < displayCount; i++ {
-		fmt.Printf("  - %s\n", items[i])
+		fmt.Println(Msg(MsgDestructiveItem, map[string]interface{}{"Item": items[i]}))
 	}
 
 	if len(items) > displayCount {
-		fmt.Printf("  ... and %d more items\n", len(items)-displayCount)
+		fmt.Println(Msg(MsgDestructiveMore, map[string]interface{}{"Count": len(items) - displayCount}))
 	}
 
-	fmt.Printf("\nTotal: %d item(s)\n\n", len(items))
+	fmt.Println(Msg(MsgDestructiveTotal, map[string]interface{}{"Count": len(items)}))
 
 	// Interactive prompt
-	fmt.Printf("This operation cannot be undone. Continue? [y/N]: ")
+	fmt.Print(Msg(MsgDestructivePrompt, nil))
 	reader := bufio.NewReader(os.Stdin)
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -146,26 +141,23 @@ func ConfirmWithDefault(message string, defaultValue bool, opts SafetyOptions) (
 	// Auto-confirm if flags are set
 	if opts.AutoConfirm() {
 		if !opts.Quiet && !opts.DryRun {
-			fmt.Printf("%s [auto-confirmed]\n", message)
+			fmt.Println(Msg(MsgAutoConfirmed, map[string]interface{}{"Message": message}))
 		}
 		return true, nil
 	}
 
 	// Non-interactive mode without auto-confirm flag
 	if !opts.Interactive {
-		return false, utils.NewAppError(utils.NewCLIError(
-			utils.ErrCodeInvalidArgument,
-			"Confirmation required but running in non-interactive mode. Use --yes or --force to proceed.",
-		).Build())
+		return false, nonInteractiveError(opts)
 	}
 
 	// Interactive prompt with default indicator
-	defaultIndicator := "[y/N]"
+	promptID := MsgPromptSuffix
 	if defaultValue {
-		defaultIndicator = "[Y/n]"
+		promptID = MsgPromptSuffixDefaultYes
 	}
 
-	fmt.Printf("%s %s: ", message, defaultIndicator)
+	fmt.Print(Msg(promptID, map[string]interface{}{"Message": message}))
 	reader := bufio.NewReader(os.Stdin)
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -181,3 +173,24 @@ func ConfirmWithDefault(message string, defaultValue bool, opts SafetyOptions) (
 
 	return response == "y" || response == "yes", nil
 }
+
+// nonInteractiveError explains why a confirmation could not be obtained
+func nonInteractiveError(opts SafetyOptions) error {
+	if opts.deniedScope != "" {
+		scopes := make([]string, len(opts.YesScopes))
+		for i, s := range opts.YesScopes {
+			scopes[i] = string(s)
+		}
+		return utils.NewAppError(utils.NewCLIError(
+			utils.ErrCodeInvalidArgument,
+			Msg(MsgScopeNotConfirmed, map[string]interface{}{
+				"Scope":  string(opts.deniedScope),
+				"Scopes": strings.Join(scopes, ","),
+			}),
+		).WithContext("scope", string(opts.deniedScope)).Build())
+	}
+	return utils.NewAppError(utils.NewCLIError(
+		utils.ErrCodeInvalidArgument,
+		Msg(MsgNonInteractive, nil),
+	).Build())
+}
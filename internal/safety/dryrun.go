@@ -49,6 +49,22 @@ const (
 
 	// OpTypeCopy represents a copy operation
 	OpTypeCopy OperationType = "copy"
+
+	// OpTypeCreate represents creating a file, folder or other resource
+	OpTypeCreate OperationType = "create"
+
+	// OpTypeUpload represents an upload of local content
+	OpTypeUpload OperationType = "upload"
+
+	// OpTypeRestore represents restoring a file from trash or a revision
+	OpTypeRestore OperationType = "restore"
+
+	// OpTypeCreatePermission represents a permission grant
+	OpTypeCreatePermission OperationType = "create_permission"
+
+	// OpTypeRequest represents a mutating API request from a command that
+	// does not describe its changes itself; see RecordRequest
+	OpTypeRequest OperationType = "request"
 )
 
 // PlannedOperation represents a planned operation in dry-run mode.
@@ -71,6 +87,9 @@ type PlannedOperation struct {
 	// Parameters contains operation-specific parameters
 	Parameters map[string]interface{}
 
+	// Predicted is the expected outcome, e.g. "trashed" or "role set to reader"
+	Predicted string
+
 	// Timestamp is when the operation was recorded
 	Timestamp time.Time
 }
@@ -162,9 +181,11 @@ func (r *DefaultDryRunRecorder) Count() int {
 func RecordDelete(recorder DryRunRecorder, resourceID, resourceName string, permanent bool) {
 	opType := OpTypeTrash
 	desc := fmt.Sprintf("Trash: %s", resourceName)
+	predicted := "moved to trash"
 	if permanent {
 		opType = OpTypeDelete
 		desc = fmt.Sprintf("Permanently delete: %s", resourceName)
+		predicted = "permanently deleted"
 	}
 
 	recorder.RecordOperation(PlannedOperation{
@@ -175,6 +196,7 @@ func RecordDelete(recorder DryRunRecorder, resourceID, resourceName string, perm
 		Parameters: map[string]interface{}{
 			"permanent": permanent,
 		},
+		Predicted: predicted,
 	})
 }
 
@@ -189,6 +211,7 @@ func RecordMove(recorder DryRunRecorder, resourceID, resourceName, targetParentI
 			"targetParentID":   targetParentID,
 			"targetParentName": targetParentName,
 		},
+		Predicted: "parent set to " + targetParentID,
 	})
 }
 
@@ -203,6 +226,7 @@ func RecordPermissionUpdate(recorder DryRunRecorder, fileID, fileName, permissio
 			"permissionID": permissionID,
 			"newRole":      newRole,
 		},
+		Predicted: "role set to " + newRole,
 	})
 }
 
@@ -216,6 +240,7 @@ func RecordPermissionDelete(recorder DryRunRecorder, fileID, fileName, permissio
 		Parameters: map[string]interface{}{
 			"permissionID": permissionID,
 		},
+		Predicted: "permission removed",
 	})
 }
 
@@ -227,38 +252,43 @@ func RecordUpdate(recorder DryRunRecorder, resourceID, resourceName string, fiel
 		ResourceName: resourceName,
 		Description:  fmt.Sprintf("Update '%s'", resourceName),
 		Parameters:   fields,
+		Predicted:    "updated",
 	})
 }
 
 // PrintDryRunResult prints a formatted dry-run result to stdout
 func PrintDryRunResult(result *DryRunResult) {
-	fmt.Println("\n=== DRY RUN RESULTS ===")
-	fmt.Printf("Total operations planned: %d\n\n", result.TotalCount)
+	fmt.Println(Msg(MsgDryRunHeader, nil))
+	fmt.Println(Msg(MsgDryRunTotal, map[string]interface{}{"Count": result.TotalCount}))
 
 	if len(result.Summary) > 0 {
-		fmt.Println("Operations by type:")
+		fmt.Println(Msg(MsgDryRunByType, nil))
 		for opType, count := range result.Summary {
-			fmt.Printf("  - %s: %d\n", opType, count)
+			fmt.Println(Msg(MsgDryRunTypeCount, map[string]interface{}{"Type": opType, "Count": count}))
 		}
 		fmt.Println()
 	}
 
 	if len(result.Operations) > 0 {
-		fmt.Println("Planned operations:")
+		fmt.Println(Msg(MsgDryRunPlanned, nil))
 		for i, op := range result.Operations {
-			fmt.Printf("  %d. [%s] %s (ID: %s)\n", i+1, op.Type, op.Description, op.ResourceID)
+			fmt.Println(Msg(MsgDryRunOperation, map[string]interface{}{
+				"Index":       i + 1,
+				"Type":        op.Type,
+				"Description": op.Description,
+				"ResourceID":  op.ResourceID,
+			}))
 		}
 		fmt.Println()
 	}
 
 	if len(result.Warnings) > 0 {
-		fmt.Println("⚠️  Warnings:")
+		fmt.Println(Msg(MsgDryRunWarnings, nil))
 		for _, warning := range result.Warnings {
-			fmt.Printf("  - %s\n", warning)
+			fmt.Println(Msg(MsgDryRunWarning, map[string]interface{}{"Warning": warning}))
 		}
 		fmt.Println()
 	}
 
-	fmt.Println("NOTE: This was a dry run. No actual changes were made.")
-	fmt.Println("Remove --dry-run flag to execute these operations.")
+	fmt.Println(Msg(MsgDryRunFooter, nil))
 }
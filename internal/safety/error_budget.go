@@ -0,0 +1,100 @@
+package safety
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultErrorBudgetWindow is the number of most recent operations over
+// which the failure rate is measured.
+const DefaultErrorBudgetWindow = 100
+
+// ErrorBudget tracks the failure rate over a rolling window of recent
+// operations. Bulk operations use it to abort when failures indicate a
+// systemic problem (e.g. revoked scopes) rather than isolated bad items,
+// instead of grinding through thousands of calls that are bound to fail.
+type ErrorBudget struct {
+	maxRate    float64
+	window     []bool
+	next       int
+	count      int
+	failures   int
+	minSamples int
+}
+
+// NewErrorBudget creates an error budget that is exceeded once the failure
+// rate over the last windowSize operations goes above maxRate (0.0-1.0).
+// The rate is not evaluated until enough operations have been seen to make
+// it meaningful.
+func NewErrorBudget(maxRate float64, windowSize int) *ErrorBudget {
+	if windowSize <= 0 {
+		windowSize = DefaultErrorBudgetWindow
+	}
+	minSamples := 20
+	if windowSize < minSamples {
+		minSamples = windowSize
+	}
+	return &ErrorBudget{
+		maxRate:    maxRate,
+		window:     make([]bool, windowSize),
+		minSamples: minSamples,
+	}
+}
+
+// Record adds the outcome of one operation
+func (b *ErrorBudget) Record(failed bool) {
+	if b.count == len(b.window) {
+		if b.window[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.window[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.window)
+}
+
+// Rate returns the failure rate over the current window
+func (b *ErrorBudget) Rate() float64 {
+	if b.count == 0 {
+		return 0
+	}
+	return float64(b.failures) / float64(b.count)
+}
+
+// Samples returns the number of operations in the current window
+func (b *ErrorBudget) Samples() int {
+	return b.count
+}
+
+// MaxRate returns the configured threshold
+func (b *ErrorBudget) MaxRate() float64 {
+	return b.maxRate
+}
+
+// Exceeded reports whether the failure rate is above the threshold
+func (b *ErrorBudget) Exceeded() bool {
+	return b.count >= b.minSamples && b.Rate() > b.maxRate
+}
+
+// ParseErrorRate parses a rate given as a percentage ("5%") or a fraction
+// ("0.05").
+func ParseErrorRate(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	percent := strings.HasSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid error rate %q", s)
+	}
+	if percent {
+		v /= 100
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("error rate %q must be between 0%% and 100%%", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,80 @@
+package safety
+
+import "testing"
+
+func TestErrorBudget_NotEvaluatedBeforeMinSamples(t *testing.T) {
+	b := NewErrorBudget(0.05, 100)
+	for i := 0; i < 19; i++ {
+		b.Record(true)
+	}
+	if b.Exceeded() {
+		t.Error("budget should not be evaluated before 20 samples")
+	}
+	b.Record(true)
+	if !b.Exceeded() {
+		t.Error("budget should be exceeded after 20 consecutive failures")
+	}
+}
+
+func TestErrorBudget_RollingWindow(t *testing.T) {
+	b := NewErrorBudget(0.10, 20)
+
+	// 3 failures in 20 = 15%
+	for i := 0; i < 20; i++ {
+		b.Record(i < 3)
+	}
+	if !b.Exceeded() {
+		t.Fatalf("rate %.2f should exceed 0.10", b.Rate())
+	}
+
+	// Push the early failures out of the window
+	for i := 0; i < 3; i++ {
+		b.Record(false)
+	}
+	if b.Exceeded() {
+		t.Errorf("rate %.2f should be back under budget", b.Rate())
+	}
+	if b.Samples() != 20 {
+		t.Errorf("samples = %d, want 20", b.Samples())
+	}
+}
+
+func TestErrorBudget_SmallWindow(t *testing.T) {
+	b := NewErrorBudget(0.5, 4)
+	b.Record(true)
+	b.Record(true)
+	b.Record(true)
+	if b.Exceeded() {
+		t.Error("should wait for a full small window")
+	}
+	b.Record(false)
+	if !b.Exceeded() {
+		t.Errorf("rate %.2f should exceed 0.5", b.Rate())
+	}
+}
+
+func TestParseErrorRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"5%", 0.05, false},
+		{"0.05", 0.05, false},
+		{"100%", 1, false},
+		{"0", 0, false},
+		{"150%", 0, true},
+		{"-1%", 0, true},
+		{"five", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseErrorRate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseErrorRate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (got-tt.want > 1e-9 || tt.want-got > 1e-9) {
+			t.Errorf("ParseErrorRate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
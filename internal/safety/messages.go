@@ -0,0 +1,222 @@
+package safety
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// MessageID identifies a user-visible string in the message catalog
+type MessageID string
+
+// Message IDs for confirmation prompts and dry-run summaries. The template
+// variables each message receives are those used by its default text.
+const (
+	MsgAutoConfirmed            MessageID = "confirm.autoConfirmed"
+	MsgNonInteractive           MessageID = "confirm.nonInteractive"
+	MsgScopeNotConfirmed        MessageID = "confirm.scopeNotConfirmed"
+	MsgPromptSuffix             MessageID = "confirm.promptSuffix"
+	MsgPromptSuffixDefaultYes   MessageID = "confirm.promptSuffixDefaultYes"
+	MsgBulkConfirmOne           MessageID = "bulk.confirmOne"
+	MsgBulkConfirmMany          MessageID = "bulk.confirmMany"
+	MsgRoleChangeConfirm        MessageID = "bulk.roleChangeConfirm"
+	MsgOwnershipTransfer        MessageID = "ownership.transferConfirm"
+	MsgDestructiveAutoConfirmed MessageID = "destructive.autoConfirmed"
+	MsgDestructiveHeader        MessageID = "destructive.header"
+	MsgDestructiveItem          MessageID = "destructive.item"
+	MsgDestructiveMore          MessageID = "destructive.more"
+	MsgDestructiveTotal         MessageID = "destructive.total"
+	MsgDestructivePrompt        MessageID = "destructive.prompt"
+	MsgDryRunHeader             MessageID = "dryrun.header"
+	MsgDryRunTotal              MessageID = "dryrun.total"
+	MsgDryRunByType             MessageID = "dryrun.byType"
+	MsgDryRunTypeCount          MessageID = "dryrun.typeCount"
+	MsgDryRunPlanned            MessageID = "dryrun.planned"
+	MsgDryRunOperation          MessageID = "dryrun.operation"
+	MsgDryRunWarnings           MessageID = "dryrun.warnings"
+	MsgDryRunWarning            MessageID = "dryrun.warning"
+	MsgDryRunFooter             MessageID = "dryrun.footer"
+)
+
+// defaultMessages is the built-in English catalog
+var defaultMessages = map[MessageID]string{
+	MsgAutoConfirmed:            "{{.Message}} [auto-confirmed]",
+	MsgNonInteractive:           "Confirmation required but running in non-interactive mode. Use --yes or --force to proceed.",
+	MsgScopeNotConfirmed:        "Confirmation required: '{{.Scope}}' operations are not covered by --yes={{.Scopes}}. Add '{{.Scope}}' to --yes or use --force to proceed.",
+	MsgPromptSuffix:             "{{.Message}} [y/N]: ",
+	MsgPromptSuffixDefaultYes:   "{{.Message}} [Y/n]: ",
+	MsgBulkConfirmOne:           "About to {{.Operation}} 1 item. Continue?",
+	MsgBulkConfirmMany:          "About to {{.Operation}} {{.Count}} items. Continue?",
+	MsgRoleChangeConfirm:        "About to {{.Direction}} every {{.From}} permission to {{.To}} on {{.Scope}}. Continue?",
+	MsgOwnershipTransfer:        "About to transfer ownership of {{.File}} to {{.To}} ({{.Mode}}). You keep editor access but can no longer delete it or change its owner. Continue?",
+	MsgDestructiveAutoConfirmed: "About to {{.Operation}} {{.Count}} item(s) [auto-confirmed]",
+	MsgDestructiveHeader:        "\n⚠️  WARNING: About to {{.Operation}} the following items:\n",
+	MsgDestructiveItem:          "  - {{.Item}}",
+	MsgDestructiveMore:          "  ... and {{.Count}} more items",
+	MsgDestructiveTotal:         "\nTotal: {{.Count}} item(s)\n",
+	MsgDestructivePrompt:        "This operation cannot be undone. Continue? [y/N]: ",
+	MsgDryRunHeader:             "\n=== DRY RUN RESULTS ===",
+	MsgDryRunTotal:              "Total operations planned: {{.Count}}\n",
+	MsgDryRunByType:             "Operations by type:",
+	MsgDryRunTypeCount:          "  - {{.Type}}: {{.Count}}",
+	MsgDryRunPlanned:            "Planned operations:",
+	MsgDryRunOperation:          "  {{.Index}}. [{{.Type}}] {{.Description}} (ID: {{.ResourceID}})",
+	MsgDryRunWarnings:           "⚠️  Warnings:",
+	MsgDryRunWarning:            "  - {{.Warning}}",
+	MsgDryRunFooter:             "NOTE: This was a dry run. No actual changes were made.\nRemove --dry-run flag to execute these operations.",
+}
+
+// Catalog holds message templates for one locale. Messages missing from a
+// custom catalog fall back to the built-in English text.
+type Catalog struct {
+	locale    string
+	templates map[MessageID]*template.Template
+}
+
+// catalogFile is the on-disk format of a message catalog
+type catalogFile struct {
+	Locale   string               `json:"locale"`
+	Messages map[MessageID]string `json:"messages"`
+}
+
+var (
+	activeCatalog   = DefaultCatalog()
+	activeCatalogMu sync.RWMutex
+)
+
+// DefaultCatalog returns the built-in English catalog
+func DefaultCatalog() *Catalog {
+	c := &Catalog{locale: "en", templates: make(map[MessageID]*template.Template, len(defaultMessages))}
+	for id, text := range defaultMessages {
+		c.templates[id] = template.Must(template.New(string(id)).Parse(text))
+	}
+	return c
+}
+
+// Locale returns the catalog's locale
+func (c *Catalog) Locale() string {
+	return c.locale
+}
+
+// Merge overrides messages in the catalog. Unknown message IDs and
+// templates that fail to parse are rejected so typos surface at load time
+// rather than in the middle of a prompt.
+func (c *Catalog) Merge(messages map[MessageID]string) error {
+	for id, text := range messages {
+		if _, ok := defaultMessages[id]; !ok {
+			return fmt.Errorf("unknown message id %q", id)
+		}
+		tmpl, err := template.New(string(id)).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template for %q: %w", id, err)
+		}
+		c.templates[id] = tmpl
+	}
+	return nil
+}
+
+// Format renders a message with the given template variables. A custom
+// template that fails to render falls back to the built-in text.
+func (c *Catalog) Format(id MessageID, vars map[string]interface{}) string {
+	if tmpl, ok := c.templates[id]; ok {
+		if s, err := render(tmpl, vars); err == nil {
+			return s
+		}
+	}
+	if text, ok := defaultMessages[id]; ok {
+		if s, err := render(template.Must(template.New(string(id)).Parse(text)), vars); err == nil {
+			return s
+		}
+		return text
+	}
+	return string(id)
+}
+
+func render(tmpl *template.Template, vars map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// LoadCatalogFile loads a JSON message catalog and overlays it on the
+// built-in messages. The file has the form:
+//
+//	{"locale": "de", "messages": {"bulk.confirmMany": "{{.Count}} Elemente {{.Operation}}?"}}
+func LoadCatalogFile(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse message catalog %s: %w", path, err)
+	}
+
+	c := DefaultCatalog()
+	if file.Locale != "" {
+		c.locale = file.Locale
+	}
+	if err := c.Merge(file.Messages); err != nil {
+		return nil, fmt.Errorf("message catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// LoadLocale loads <dir>/<locale>.json, falling back to the base language
+// (e.g. "de" for "de_DE.UTF-8"). The built-in catalog is returned when no
+// locale file exists.
+func LoadLocale(dir, locale string) (*Catalog, error) {
+	for _, name := range localeCandidates(locale) {
+		c, err := LoadCatalogFile(filepath.Join(dir, name+".json"))
+		if err == nil {
+			if c.locale == "en" {
+				c.locale = name
+			}
+			return c, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return DefaultCatalog(), nil
+}
+
+func localeCandidates(locale string) []string {
+	locale = strings.SplitN(locale, ".", 2)[0]
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if locale == "" {
+		return nil
+	}
+	candidates := []string{locale}
+	if i := strings.Index(locale, "-"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	return candidates
+}
+
+// SetCatalog sets the catalog used by prompts and summaries
+func SetCatalog(c *Catalog) {
+	activeCatalogMu.Lock()
+	defer activeCatalogMu.Unlock()
+	activeCatalog = c
+}
+
+// ActiveCatalog returns the catalog used by prompts and summaries
+func ActiveCatalog() *Catalog {
+	activeCatalogMu.RLock()
+	defer activeCatalogMu.RUnlock()
+	return activeCatalog
+}
+
+// Msg renders a message from the active catalog
+func Msg(id MessageID, vars map[string]interface{}) string {
+	return ActiveCatalog().Format(id, vars)
+}
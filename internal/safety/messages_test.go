@@ -0,0 +1,98 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultCatalog_CoversAllMessages(t *testing.T) {
+	c := DefaultCatalog()
+	for id := range defaultMessages {
+		if got := c.Format(id, map[string]interface{}{}); got == "" {
+			t.Errorf("message %s rendered empty", id)
+		}
+	}
+}
+
+func TestCatalogFormat(t *testing.T) {
+	c := DefaultCatalog()
+	got := c.Format(MsgBulkConfirmMany, map[string]interface{}{"Operation": "trash", "Count": 3})
+	if got != "About to trash 3 items. Continue?" {
+		t.Errorf("Format = %q", got)
+	}
+
+	if got := c.Format(MessageID("no.such.message"), nil); got != "no.such.message" {
+		t.Errorf("unknown id should render as itself, got %q", got)
+	}
+}
+
+func TestCatalogMerge(t *testing.T) {
+	c := DefaultCatalog()
+	if err := c.Merge(map[MessageID]string{MsgBulkConfirmOne: "Really {{.Operation}} it?"}); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if got := c.Format(MsgBulkConfirmOne, map[string]interface{}{"Operation": "delete"}); got != "Really delete it?" {
+		t.Errorf("Format = %q", got)
+	}
+
+	if err := c.Merge(map[MessageID]string{"bulk.typo": "x"}); err == nil {
+		t.Error("expected error for unknown message id")
+	}
+	if err := c.Merge(map[MessageID]string{MsgBulkConfirmOne: "{{.Operation"}); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestCatalogFormat_FallsBackOnRenderError(t *testing.T) {
+	c := DefaultCatalog()
+	if err := c.Merge(map[MessageID]string{MsgDestructiveItem: "{{.Item.Missing}}"}); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	got := c.Format(MsgDestructiveItem, map[string]interface{}{"Item": "report.pdf"})
+	if got != "  - report.pdf" {
+		t.Errorf("Format = %q, want built-in fallback", got)
+	}
+}
+
+func TestLoadLocale(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"messages": {"bulk.confirmMany": "{{.Count}} Elemente: {{.Operation}}?"}}`
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadLocale(dir, "de_DE.UTF-8")
+	if err != nil {
+		t.Fatalf("LoadLocale: %v", err)
+	}
+	if c.Locale() != "de" {
+		t.Errorf("Locale = %q, want de", c.Locale())
+	}
+	got := c.Format(MsgBulkConfirmMany, map[string]interface{}{"Operation": "löschen", "Count": 2})
+	if got != "2 Elemente: löschen?" {
+		t.Errorf("Format = %q", got)
+	}
+	// Untranslated messages keep the built-in text
+	if got := c.Format(MsgDestructivePrompt, nil); got != defaultMessages[MsgDestructivePrompt] {
+		t.Errorf("untranslated message = %q", got)
+	}
+
+	c, err = LoadLocale(dir, "fr")
+	if err != nil {
+		t.Fatalf("LoadLocale missing locale: %v", err)
+	}
+	if c.Locale() != "en" {
+		t.Errorf("missing locale should fall back to en, got %q", c.Locale())
+	}
+}
+
+func TestLoadCatalogFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte(`{"messages": {"bogus": "x"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCatalogFile(path); err == nil {
+		t.Error("expected error for unknown message id")
+	}
+}
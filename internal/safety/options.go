@@ -32,6 +32,13 @@ type SafetyOptions struct {
 	// Interactive enables interactive mode with detailed prompts.
 	// When false, operations fail if confirmation is required but not provided.
 	Interactive bool
+
+	// YesScopes limits Yes to the listed operation scopes (--yes=trash,...).
+	// Empty means Yes applies to every operation. See ForScope.
+	YesScopes []ConfirmScope
+
+	// deniedScope is set by ForScope when Yes does not cover the operation
+	deniedScope ConfirmScope
 }
 
 // Default returns a SafetyOptions with defaults for CLI use.
@@ -44,6 +51,7 @@ func Default() SafetyOptions {
 		Yes:         true, // Auto-confirm by default for agent-friendly CLI
 		Quiet:       false,
 		Interactive: false,
+		YesScopes:   getDefaultYesScopes(),
 	}
 }
 
@@ -86,8 +94,9 @@ func DryRunMode() SafetyOptions {
 
 // ShouldConfirm returns true if the operation should request user confirmation.
 // Returns false if Force or Yes flags are set, or if in DryRun mode.
+// Operations outside the --yes scopes always require confirmation.
 func (o SafetyOptions) ShouldConfirm() bool {
-	return !o.Force && !o.Yes && !o.DryRun && o.Interactive
+	return !o.Force && !o.Yes && !o.DryRun && (o.Interactive || o.deniedScope != "")
 }
 
 // AutoConfirm returns true if operations should be automatically confirmed.
@@ -0,0 +1,74 @@
+package safety
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// NewPlan builds the versioned plan document for a command's recorded
+// operations. Operations are sorted by type, target and parameters so the
+// document does not depend on the order concurrent work was recorded in.
+func NewPlan(command string, operations []PlannedOperation) *types.DryRunPlan {
+	plan := &types.DryRunPlan{
+		SchemaVersion: types.PlanSchemaVersion,
+		Command:       command,
+		Summary:       map[string]int{},
+		Operations:    make([]*types.PlanOperation, 0, len(operations)),
+	}
+	keys := make(map[*types.PlanOperation]string, len(operations))
+	for _, op := range operations {
+		planned := &types.PlanOperation{
+			Type:      string(op.Type),
+			Target:    types.PlanTarget{ID: op.ResourceID, Name: op.ResourceName},
+			Params:    op.Parameters,
+			Predicted: op.Predicted,
+		}
+		if planned.Predicted == "" {
+			planned.Predicted = op.Description
+		}
+		key, _ := json.Marshal(planned)
+		keys[planned] = string(key)
+		plan.Operations = append(plan.Operations, planned)
+		plan.Summary[planned.Type]++
+	}
+	sort.SliceStable(plan.Operations, func(i, j int) bool {
+		return keys[plan.Operations[i]] < keys[plan.Operations[j]]
+	})
+
+	plan.Fingerprint = PlanFingerprint(plan)
+	return plan
+}
+
+// PlanFingerprint hashes a plan's schema version, command and operations.
+// It is recomputed from the operations rather than trusted from the
+// Fingerprint field, so an edited plan file does not keep its old hash.
+func PlanFingerprint(plan *types.DryRunPlan) string {
+	// encoding/json sorts map keys, so this encoding is canonical
+	canonical, _ := json.Marshal(struct {
+		SchemaVersion int                    `json:"schemaVersion"`
+		Command       string                 `json:"command"`
+		Operations    []*types.PlanOperation `json:"operations"`
+	}{plan.SchemaVersion, plan.Command, plan.Operations})
+	sum := sha256.Sum256(canonical)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// RecordRequest records a mutating API request that was stopped under
+// dry-run because the command does not plan its changes itself
+func RecordRequest(recorder DryRunRecorder, method, path string, params map[string]interface{}) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	params["method"] = method
+	recorder.RecordOperation(PlannedOperation{
+		Type:        OpTypeRequest,
+		ResourceID:  path,
+		Description: method + " " + path,
+		Parameters:  params,
+		Predicted:   "not sent: this command cannot preview its changes",
+	})
+}
@@ -0,0 +1,59 @@
+package safety
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewPlanIsDeterministic(t *testing.T) {
+	ops := []PlannedOperation{
+		{Type: OpTypeTrash, ResourceID: "b", ResourceName: "b.txt", Parameters: map[string]interface{}{"permanent": false}, Predicted: "moved to trash"},
+		{Type: OpTypeMove, ResourceID: "a", ResourceName: "a.txt", Parameters: map[string]interface{}{"targetParentID": "p"}, Predicted: "parent set to p"},
+		{Type: OpTypeTrash, ResourceID: "a", ResourceName: "a.txt", Parameters: map[string]interface{}{"permanent": false}, Predicted: "moved to trash"},
+	}
+	reversed := []PlannedOperation{ops[2], ops[1], ops[0]}
+
+	first := NewPlan("files.delete", ops)
+	second := NewPlan("files.delete", reversed)
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if string(a) != string(b) {
+		t.Fatalf("plans differ with recording order:\n%s\n%s", a, b)
+	}
+	if first.Operations[0].Type != "move" || first.Operations[1].Target.ID != "a" {
+		t.Errorf("operations not sorted: %s", a)
+	}
+	if first.Summary["trash"] != 2 || first.Summary["move"] != 1 {
+		t.Errorf("summary = %v", first.Summary)
+	}
+	if first.SchemaVersion != 1 || len(first.Fingerprint) != len("sha256:")+64 {
+		t.Errorf("schemaVersion = %d, fingerprint = %q", first.SchemaVersion, first.Fingerprint)
+	}
+
+	if NewPlan("files.move", ops).Fingerprint == first.Fingerprint {
+		t.Error("fingerprint should cover the command")
+	}
+	ops[0].Parameters = map[string]interface{}{"permanent": true}
+	if NewPlan("files.delete", ops).Fingerprint == first.Fingerprint {
+		t.Error("fingerprint should change with the operations")
+	}
+}
+
+func TestNewPlanFallsBackToDescription(t *testing.T) {
+	plan := NewPlan("x", []PlannedOperation{{Type: OpTypeUpdate, Description: "Update: a"}})
+	if plan.Operations[0].Predicted != "Update: a" {
+		t.Errorf("predicted = %q", plan.Operations[0].Predicted)
+	}
+	if empty := NewPlan("x", nil); len(empty.Operations) != 0 || empty.Operations == nil {
+		t.Errorf("empty plan operations = %#v", empty.Operations)
+	}
+}
+
+func TestRecordRequest(t *testing.T) {
+	recorder := NewDryRunRecorder()
+	RecordRequest(recorder, "PATCH", "/drive/v3/files/abc", map[string]interface{}{"fields": "id"})
+	ops := recorder.GetOperations()
+	if len(ops) != 1 || ops[0].Type != OpTypeRequest || ops[0].Parameters["method"] != "PATCH" {
+		t.Fatalf("ops = %+v", ops)
+	}
+}
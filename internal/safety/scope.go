@@ -0,0 +1,105 @@
+package safety
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConfirmScope names a class of confirmable operation that --yes can be
+// limited to, e.g. --yes=trash,permissions
+type ConfirmScope string
+
+const (
+	ScopeAll          ConfirmScope = "all"
+	ScopeTrash        ConfirmScope = "trash"
+	ScopeDelete       ConfirmScope = "delete" // permanent deletion
+	ScopePermissions  ConfirmScope = "permissions"
+	ScopeSync         ConfirmScope = "sync"
+	ScopeMigrate      ConfirmScope = "migrate"
+	ScopeSharedWithMe ConfirmScope = "shared-with-me"
+)
+
+var knownScopes = map[ConfirmScope]bool{
+	ScopeAll:          true,
+	ScopeTrash:        true,
+	ScopeDelete:       true,
+	ScopePermissions:  true,
+	ScopeSync:         true,
+	ScopeMigrate:      true,
+	ScopeSharedWithMe: true,
+}
+
+var (
+	defaultYesScopes   []ConfirmScope
+	defaultYesScopesMu sync.RWMutex
+)
+
+// ParseConfirmScopes parses a comma-separated --yes value. An empty value,
+// "true", or "all" means every operation is auto-confirmed and returns nil.
+func ParseConfirmScopes(s string) ([]ConfirmScope, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" || s == "true" || s == string(ScopeAll) {
+		return nil, nil
+	}
+
+	var scopes []ConfirmScope
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		scope := ConfirmScope(part)
+		if !knownScopes[scope] {
+			return nil, fmt.Errorf("unknown --yes scope %q (valid: %s)", part, strings.Join(KnownScopes(), ", "))
+		}
+		if scope == ScopeAll {
+			return nil, nil
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// KnownScopes returns the valid --yes scope names in sorted order
+func KnownScopes() []string {
+	names := make([]string, 0, len(knownScopes))
+	for scope := range knownScopes {
+		names = append(names, string(scope))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDefaultYesScopes restricts the auto-confirmation of options returned by
+// Default() to the given scopes. A nil slice removes the restriction.
+func SetDefaultYesScopes(scopes []ConfirmScope) {
+	defaultYesScopesMu.Lock()
+	defer defaultYesScopesMu.Unlock()
+	defaultYesScopes = scopes
+}
+
+func getDefaultYesScopes() []ConfirmScope {
+	defaultYesScopesMu.RLock()
+	defer defaultYesScopesMu.RUnlock()
+	return defaultYesScopes
+}
+
+// ForScope returns the options to use for an operation in the given scope.
+// When Yes is restricted to other scopes, auto-confirmation is withdrawn:
+// the operation prompts in interactive mode and is refused otherwise. Force
+// and DryRun are unaffected.
+func (o SafetyOptions) ForScope(scope ConfirmScope) SafetyOptions {
+	if len(o.YesScopes) == 0 || !o.Yes || o.Force || o.DryRun {
+		return o
+	}
+	for _, s := range o.YesScopes {
+		if s == scope || s == ScopeAll {
+			return o
+		}
+	}
+	o.Yes = false
+	o.deniedScope = scope
+	return o
+}
@@ -0,0 +1,88 @@
+package safety
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfirmScopes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []ConfirmScope
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"true", nil, false},
+		{"all", nil, false},
+		{"trash,permissions", []ConfirmScope{ScopeTrash, ScopePermissions}, false},
+		{" Trash , ", []ConfirmScope{ScopeTrash}, false},
+		{"trash,all", nil, false},
+		{"trash,bogus", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseConfirmScopes(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConfirmScopes(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseConfirmScopes(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseConfirmScopes(%q)[%d] = %s, want %s", tt.in, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestForScope(t *testing.T) {
+	scoped := SafetyOptions{Yes: true, YesScopes: []ConfirmScope{ScopeTrash, ScopePermissions}}
+
+	if opts := scoped.ForScope(ScopeTrash); !opts.AutoConfirm() || opts.ShouldConfirm() {
+		t.Error("trash should be auto-confirmed")
+	}
+
+	opts := scoped.ForScope(ScopeDelete)
+	if opts.AutoConfirm() {
+		t.Error("delete should not be auto-confirmed")
+	}
+	if !opts.ShouldConfirm() {
+		t.Error("delete outside --yes scopes should require confirmation")
+	}
+	_, err := Confirm("Delete?", opts)
+	if err == nil || !strings.Contains(err.Error(), "delete") {
+		t.Errorf("expected scope error naming delete, got %v", err)
+	}
+
+	forced := scoped
+	forced.Force = true
+	if !forced.ForScope(ScopeDelete).AutoConfirm() {
+		t.Error("--force should override --yes scopes")
+	}
+
+	dryRun := scoped
+	dryRun.DryRun = true
+	if dryRun.ForScope(ScopeDelete).ShouldConfirm() {
+		t.Error("dry-run should never require confirmation")
+	}
+
+	unscoped := SafetyOptions{Yes: true}
+	if !unscoped.ForScope(ScopeDelete).AutoConfirm() {
+		t.Error("unscoped --yes should confirm everything")
+	}
+}
+
+func TestDefaultYesScopes(t *testing.T) {
+	SetDefaultYesScopes([]ConfirmScope{ScopeTrash})
+	defer SetDefaultYesScopes(nil)
+
+	opts := Default()
+	if _, err := ConfirmBulkOperation(3, "migrate", opts.ForScope(ScopeMigrate)); err == nil {
+		t.Error("migrate should be blocked when --yes=trash")
+	}
+	if ok, err := ConfirmBulkOperation(3, "trash", opts.ForScope(ScopeTrash)); err != nil || !ok {
+		t.Errorf("trash should be confirmed, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// maxNotifyOutput caps the command output included in a notification
+const maxNotifyOutput = 3000
+
+// Notification target kinds
+const (
+	NotifySlack   = "slack"
+	NotifyWebhook = "webhook"
+)
+
+// NotifyTarget is a parsed --notify destination
+type NotifyTarget struct {
+	Kind string
+	URL  string
+}
+
+// ParseNotifyTarget parses a notification destination:
+//
+//	slack://hooks.slack.com/services/T000/B000/XXXX  Slack incoming webhook
+//	slack://T000/B000/XXXX                           same, token form
+//	https://example.com/hook                         JSON POST of the run
+func ParseNotifyTarget(target string) (*NotifyTarget, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid notify target %q", target)
+	}
+
+	switch u.Scheme {
+	case "slack":
+		path := strings.Trim(u.Path, "/")
+		if u.Host != "hooks.slack.com" {
+			path = "services/" + u.Host + "/" + path
+		}
+		if strings.Count(path, "/") != 3 || !strings.HasPrefix(path, "services/") {
+			return nil, fmt.Errorf("invalid Slack target %q: expected slack://hooks.slack.com/services/T.../B.../...", target)
+		}
+		return &NotifyTarget{Kind: NotifySlack, URL: "https://hooks.slack.com/" + path}, nil
+	case "https", "http":
+		return &NotifyTarget{Kind: NotifyWebhook, URL: target}, nil
+	default:
+		return nil, fmt.Errorf("unsupported notify target %q: use slack:// or https://", target)
+	}
+}
+
+// Notify posts the outcome of a run to the target
+func Notify(ctx context.Context, client *http.Client, target *NotifyTarget, run *types.ScheduledTaskRun) error {
+	return NotifyEvent(ctx, client, target, slackMessage(run), run)
+}
+
+// NotifyEvent posts an event to the target: text for Slack, the JSON
+// encoding of event for webhooks
+func NotifyEvent(ctx context.Context, client *http.Client, target *NotifyTarget, text string, event interface{}) error {
+	payload := event
+	if target.Kind == NotifySlack {
+		payload = map[string]string{"text": text}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func slackMessage(run *types.ScheduledTaskRun) string {
+	status := "succeeded"
+	if run.ExitCode != 0 || run.Error != "" {
+		status = fmt.Sprintf("failed (exit %d)", run.ExitCode)
+	}
+	duration := (time.Duration(run.DurationMs) * time.Millisecond).Round(time.Second)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "gdrv task *%s* %s in %s", run.Name, status, duration)
+	if run.Error != "" {
+		fmt.Fprintf(&b, "\n%s", run.Error)
+	}
+	if output := strings.TrimSpace(run.Output); output != "" {
+		if len(output) > maxNotifyOutput {
+			output = output[:maxNotifyOutput] + "\n…"
+		}
+		fmt.Fprintf(&b, "\n```\n%s\n```", output)
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestParseNotifyTarget(t *testing.T) {
+	tests := []struct {
+		in, kind, url string
+	}{
+		{"slack://hooks.slack.com/services/T0/B0/XYZ", NotifySlack, "https://hooks.slack.com/services/T0/B0/XYZ"},
+		{"slack://T0/B0/XYZ", NotifySlack, "https://hooks.slack.com/services/T0/B0/XYZ"},
+		{"https://example.com/hook", NotifyWebhook, "https://example.com/hook"},
+	}
+	for _, tt := range tests {
+		target, err := ParseNotifyTarget(tt.in)
+		if err != nil {
+			t.Fatalf("ParseNotifyTarget(%q): %v", tt.in, err)
+		}
+		if target.Kind != tt.kind || target.URL != tt.url {
+			t.Errorf("ParseNotifyTarget(%q) = %+v", tt.in, target)
+		}
+	}
+
+	for _, bad := range []string{"slack://T0", "mailto:ops@example.com", "not a url"} {
+		if _, err := ParseNotifyTarget(bad); err == nil {
+			t.Errorf("ParseNotifyTarget(%q) should fail", bad)
+		}
+	}
+}
+
+func TestNotify_Slack(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	target := &NotifyTarget{Kind: NotifySlack, URL: server.URL}
+	run := &types.ScheduledTaskRun{Name: "audit-public", ExitCode: 1, Output: "3 public files"}
+	if err := Notify(context.Background(), server.Client(), target, run); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got["text"], "*audit-public* failed (exit 1)") || !strings.Contains(got["text"], "3 public files") {
+		t.Errorf("unexpected Slack text: %q", got["text"])
+	}
+}
+
+func TestNotify_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	target := &NotifyTarget{Kind: NotifyWebhook, URL: server.URL}
+	err := Notify(context.Background(), server.Client(), target, &types.ScheduledTaskRun{Name: "x"})
+	if err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("expected error with response body, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// DefaultPollInterval is how often the scheduler re-reads the store for
+// added, removed, or changed tasks while idle.
+const DefaultPollInterval = time.Minute
+
+// maxCapturedOutput caps the command output kept for a run
+const maxCapturedOutput = 64 * 1024
+
+// ExecFunc runs a gdrv command and returns its combined output and exit code
+type ExecFunc func(ctx context.Context, args []string) (output string, exitCode int, err error)
+
+// Runner executes scheduled tasks when they are due
+type Runner struct {
+	store        *Store
+	exec         ExecFunc
+	httpClient   *http.Client
+	logf         func(format string, args ...interface{})
+	now          func() time.Time
+	PollInterval time.Duration
+}
+
+// NewRunner creates a runner that executes tasks by invoking the gdrv
+// binary at executable. logf receives progress messages.
+func NewRunner(store *Store, executable string, logf func(format string, args ...interface{})) *Runner {
+	return &Runner{
+		store:        store,
+		exec:         commandExec(executable),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		logf:         logf,
+		now:          time.Now,
+		PollInterval: DefaultPollInterval,
+	}
+}
+
+// NextRun returns the next time a task is due after t
+func NextRun(task *types.ScheduledTask, t time.Time) (time.Time, error) {
+	cron, err := ParseCron(task.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cron.Next(t), nil
+}
+
+// Run executes tasks as they become due until ctx is cancelled. Tasks run
+// one at a time; a task that is still running when its next slot passes
+// runs once afterwards rather than once per missed slot.
+func (r *Runner) Run(ctx context.Context) error {
+	next := make(map[string]time.Time)
+
+	for {
+		tasks, err := r.store.List()
+		if err != nil {
+			r.logf("Failed to load schedules: %s", err)
+		}
+
+		now := r.now()
+		wake := now.Add(r.PollInterval)
+		seen := make(map[string]bool)
+		for _, task := range tasks {
+			if task.Disabled {
+				continue
+			}
+			key := task.Name + "\x00" + task.Cron
+			seen[key] = true
+
+			due, ok := next[key]
+			if !ok {
+				if due, err = NextRun(task, now); err != nil {
+					r.logf("Skipping %s: %s", task.Name, err)
+					continue
+				}
+				next[key] = due
+			}
+			if !due.After(now) {
+				r.RunTask(ctx, task)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				due, _ = NextRun(task, r.now())
+				next[key] = due
+			}
+			if !due.IsZero() && due.Before(wake) {
+				wake = due
+			}
+		}
+		for key := range next {
+			if !seen[key] {
+				delete(next, key)
+			}
+		}
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RunTask executes a task once, records the outcome in the store, and
+// sends a notification if the task has a target.
+func (r *Runner) RunTask(ctx context.Context, task *types.ScheduledTask) *types.ScheduledTaskRun {
+	run := &types.ScheduledTaskRun{Name: task.Name, StartedAt: r.now().UTC()}
+	r.logf("Running %s: gdrv %s", task.Name, task.Command())
+
+	output, exitCode, err := r.exec(ctx, task.Args)
+	run.DurationMs = r.now().UTC().Sub(run.StartedAt).Milliseconds()
+	run.ExitCode = exitCode
+	run.Output = output
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if run.ExitCode != 0 || run.Error != "" {
+		r.logf("%s failed (exit %d) after %dms", task.Name, run.ExitCode, run.DurationMs)
+	} else {
+		r.logf("%s completed in %dms", task.Name, run.DurationMs)
+	}
+
+	if err := r.store.RecordRun(run); err != nil {
+		r.logf("Failed to record run of %s: %s", task.Name, err)
+	}
+
+	failed := run.ExitCode != 0 || run.Error != ""
+	if task.Notify != "" && (task.NotifyOn != types.NotifyFailure || failed) {
+		target, err := ParseNotifyTarget(task.Notify)
+		if err == nil {
+			err = Notify(ctx, r.httpClient, target, run)
+		}
+		if err != nil {
+			r.logf("Failed to notify for %s: %s", task.Name, err)
+		} else {
+			run.Notified = true
+		}
+	}
+
+	return run
+}
+
+// commandExec runs tasks as child processes of the gdrv binary, so a crash
+// or hang in one task cannot take down the scheduler.
+func commandExec(executable string) ExecFunc {
+	return func(ctx context.Context, args []string) (string, int, error) {
+		var output bytes.Buffer
+		cmd := exec.CommandContext(ctx, executable, args...)
+		cmd.Stdout = &limitedBuffer{buf: &output, limit: maxCapturedOutput}
+		cmd.Stderr = cmd.Stdout
+
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return output.String(), exitErr.ExitCode(), nil
+		}
+		if err != nil {
+			return output.String(), -1, err
+		}
+		return output.String(), 0, nil
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func newTestRunner(t *testing.T, exec ExecFunc) (*Runner, *Store) {
+	t.Helper()
+	store := NewStore(filepath.Join(t.TempDir(), StoreFileName))
+	r := NewRunner(store, "gdrv", t.Logf)
+	r.exec = exec
+	return r, store
+}
+
+func TestRunTask_RecordsAndNotifiesOnFailure(t *testing.T) {
+	var notified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&notified, 1)
+	}))
+	defer server.Close()
+
+	exitCode := 0
+	r, store := newTestRunner(t, func(ctx context.Context, args []string) (string, int, error) {
+		return strings.Join(args, " "), exitCode, nil
+	})
+	r.httpClient = server.Client()
+
+	task := &types.ScheduledTask{Name: "audit", Cron: "@daily", Args: []string{"permissions", "audit", "public"},
+		Notify: server.URL, NotifyOn: types.NotifyFailure}
+	if err := store.Add(task); err != nil {
+		t.Fatal(err)
+	}
+
+	run := r.RunTask(context.Background(), task)
+	if run.Output != "permissions audit public" || run.Notified {
+		t.Errorf("successful run: %+v", run)
+	}
+
+	exitCode = 2
+	run = r.RunTask(context.Background(), task)
+	if !run.Notified || atomic.LoadInt32(&notified) != 1 {
+		t.Errorf("failed run should notify once: %+v, notified=%d", run, notified)
+	}
+
+	stored, _ := store.Get("audit")
+	if stored.LastExitCode != 2 || stored.LastRun.IsZero() {
+		t.Errorf("run not recorded: %+v", stored)
+	}
+}
+
+func TestRun_ExecutesDueTasks(t *testing.T) {
+	ran := make(chan string, 10)
+	r, store := newTestRunner(t, func(ctx context.Context, args []string) (string, int, error) {
+		select {
+		case ran <- args[0]:
+		default:
+		}
+		return "", 0, nil
+	})
+	r.PollInterval = 10 * time.Millisecond
+
+	// The clock starts just before a minute boundary, so "* * * * *" is due
+	// on the first poll after the clock advances.
+	var tick int64
+	start := time.Date(2024, 1, 1, 0, 0, 59, 0, time.UTC)
+	r.now = func() time.Time {
+		return start.Add(time.Duration(atomic.AddInt64(&tick, 1)) * time.Second)
+	}
+
+	if err := store.Add(&types.ScheduledTask{Name: "every-minute", Cron: "* * * * *", Args: []string{"about"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(&types.ScheduledTask{Name: "off", Cron: "* * * * *", Args: []string{"drives"}, Disabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	select {
+	case cmd := <-ran:
+		if cmd != "about" {
+			t.Errorf("ran %q, want about", cmd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task never ran")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+	for len(ran) > 0 {
+		if cmd := <-ran; cmd == "drives" {
+			t.Error("disabled task ran")
+		}
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	lb := &limitedBuffer{buf: new(bytes.Buffer), limit: 4}
+	n, err := lb.Write([]byte("0123456789"))
+	if n != 10 || err != nil || lb.buf.String() != "0123" {
+		t.Errorf("Write = %d, %v; kept %q", n, err, lb.buf.String())
+	}
+}
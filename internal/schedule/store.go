@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// StoreFileName is the file in the config directory that holds scheduled
+// tasks
+const StoreFileName = "schedules.json"
+
+var taskNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Store persists scheduled tasks as JSON. Every operation re-reads the
+// file, so a running scheduler picks up tasks added or removed by other
+// gdrv invocations.
+type Store struct {
+	path string
+}
+
+type storeFile struct {
+	state.Versioned
+	Tasks []*types.ScheduledTask `json:"tasks"`
+}
+
+// StateKind versions the schedule store
+var StateKind = state.Register(&state.Kind{
+	Name:        "schedules",
+	Description: "Scheduled tasks and their last runs",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: StoreFileName,
+})
+
+// NewStore returns a store backed by the given file
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store in the gdrv config directory
+func DefaultStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, StoreFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns all tasks sorted by name
+func (s *Store) List() ([]*types.ScheduledTask, error) {
+	var file storeFile
+	found, err := state.Load(StateKind, s.path, &file)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return []*types.ScheduledTask{}, nil
+	}
+	sort.Slice(file.Tasks, func(i, j int) bool { return file.Tasks[i].Name < file.Tasks[j].Name })
+	return file.Tasks, nil
+}
+
+// Get returns the named task
+func (s *Store) Get(name string) (*types.ScheduledTask, error) {
+	tasks, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("no scheduled task named %q", name)
+}
+
+// Add validates and stores a new task. Names must be unique.
+func (s *Store) Add(task *types.ScheduledTask) error {
+	if !taskNamePattern.MatchString(task.Name) {
+		return fmt.Errorf("invalid task name %q: use letters, digits, '.', '_' and '-'", task.Name)
+	}
+	if _, err := ParseCron(task.Cron); err != nil {
+		return err
+	}
+	if len(task.Args) == 0 {
+		return fmt.Errorf("task %q has no command", task.Name)
+	}
+	if task.Notify != "" {
+		if _, err := ParseNotifyTarget(task.Notify); err != nil {
+			return err
+		}
+	}
+	switch task.NotifyOn {
+	case "":
+		task.NotifyOn = types.NotifyAlways
+	case types.NotifyAlways, types.NotifyFailure:
+	default:
+		return fmt.Errorf("invalid notify-on %q: use %s or %s", task.NotifyOn, types.NotifyAlways, types.NotifyFailure)
+	}
+
+	return s.update(func(tasks []*types.ScheduledTask) ([]*types.ScheduledTask, error) {
+		for _, t := range tasks {
+			if t.Name == task.Name {
+				return nil, fmt.Errorf("a scheduled task named %q already exists", task.Name)
+			}
+		}
+		return append(tasks, task), nil
+	})
+}
+
+// Remove deletes the named task
+func (s *Store) Remove(name string) error {
+	return s.update(func(tasks []*types.ScheduledTask) ([]*types.ScheduledTask, error) {
+		for i, t := range tasks {
+			if t.Name == name {
+				return append(tasks[:i], tasks[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("no scheduled task named %q", name)
+	})
+}
+
+// RecordRun stores the outcome of a run on the named task. A task removed
+// while it was running is ignored.
+func (s *Store) RecordRun(run *types.ScheduledTaskRun) error {
+	return s.update(func(tasks []*types.ScheduledTask) ([]*types.ScheduledTask, error) {
+		for _, t := range tasks {
+			if t.Name == run.Name {
+				t.LastRun = run.StartedAt
+				t.LastExitCode = run.ExitCode
+				t.LastError = run.Error
+			}
+		}
+		return tasks, nil
+	})
+}
+
+func (s *Store) update(fn func([]*types.ScheduledTask) ([]*types.ScheduledTask, error)) error {
+	tasks, err := s.List()
+	if err != nil {
+		return err
+	}
+	tasks, err = fn(tasks)
+	if err != nil {
+		return err
+	}
+
+	return state.Save(StateKind, s.path, &storeFile{Tasks: tasks})
+}
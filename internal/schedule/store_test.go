@@ -0,0 +1,65 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestStore_AddListRemove(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), StoreFileName))
+
+	tasks, err := store.List()
+	if err != nil || len(tasks) != 0 {
+		t.Fatalf("empty store: %v, %v", tasks, err)
+	}
+
+	for _, name := range []string{"weekly-audit", "backup"} {
+		if err := store.Add(&types.ScheduledTask{Name: name, Cron: "@daily", Args: []string{"about"}}); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+	}
+	if err := store.Add(&types.ScheduledTask{Name: "backup", Cron: "@daily", Args: []string{"about"}}); err == nil {
+		t.Error("duplicate name should fail")
+	}
+
+	tasks, _ = store.List()
+	if len(tasks) != 2 || tasks[0].Name != "backup" || tasks[0].NotifyOn != types.NotifyAlways {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+
+	if err := store.RecordRun(&types.ScheduledTaskRun{Name: "backup", ExitCode: 3}); err != nil {
+		t.Fatal(err)
+	}
+	task, err := store.Get("backup")
+	if err != nil || task.LastExitCode != 3 {
+		t.Errorf("RecordRun not persisted: %+v, %v", task, err)
+	}
+
+	if err := store.Remove("backup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove("backup"); err == nil {
+		t.Error("removing a missing task should fail")
+	}
+	if tasks, _ = store.List(); len(tasks) != 1 {
+		t.Errorf("expected 1 task after remove, got %d", len(tasks))
+	}
+}
+
+func TestStore_AddValidates(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), StoreFileName))
+	invalid := []*types.ScheduledTask{
+		{Name: "bad name", Cron: "@daily", Args: []string{"about"}},
+		{Name: "bad-cron", Cron: "every day", Args: []string{"about"}},
+		{Name: "no-command", Cron: "@daily"},
+		{Name: "bad-notify", Cron: "@daily", Args: []string{"about"}, Notify: "smtp://mail"},
+		{Name: "bad-notify-on", Cron: "@daily", Args: []string{"about"}, NotifyOn: "sometimes"},
+	}
+	for _, task := range invalid {
+		if err := store.Add(task); err == nil {
+			t.Errorf("Add(%s) should fail", task.Name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Service unit formats
+const (
+	UnitSystemd = "systemd"
+	UnitLaunchd = "launchd"
+)
+
+// LaunchdLabel is the launchd job label for the scheduler
+const LaunchdLabel = "com.github.dl-alexandre.gdrv.scheduler"
+
+// GenerateUnit returns a service definition that keeps "gdrv schedule run"
+// running under systemd or launchd. env is added to the service
+// environment, e.g. to pin GDRV_CONFIG_DIR.
+func GenerateUnit(format, executable string, env map[string]string) (string, error) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	switch format {
+	case UnitSystemd:
+		b.WriteString("[Unit]\n")
+		b.WriteString("Description=gdrv task scheduler\n")
+		b.WriteString("After=network-online.target\n")
+		b.WriteString("Wants=network-online.target\n\n")
+		b.WriteString("[Service]\n")
+		fmt.Fprintf(&b, "ExecStart=%s schedule run\n", systemdQuote(executable))
+		for _, k := range keys {
+			fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(k+"="+env[k]))
+		}
+		b.WriteString("Restart=on-failure\n")
+		b.WriteString("RestartSec=30\n\n")
+		b.WriteString("[Install]\n")
+		b.WriteString("WantedBy=default.target\n")
+	case UnitLaunchd:
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+		b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+		b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+		fmt.Fprintf(&b, "  <key>Label</key>\n  <string>%s</string>\n", LaunchdLabel)
+		b.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+		for _, arg := range []string{executable, "schedule", "run"} {
+			fmt.Fprintf(&b, "    <string>%s</string>\n", html.EscapeString(arg))
+		}
+		b.WriteString("  </array>\n")
+		if len(keys) > 0 {
+			b.WriteString("  <key>EnvironmentVariables</key>\n  <dict>\n")
+			for _, k := range keys {
+				fmt.Fprintf(&b, "    <key>%s</key>\n    <string>%s</string>\n", html.EscapeString(k), html.EscapeString(env[k]))
+			}
+			b.WriteString("  </dict>\n")
+		}
+		b.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+		b.WriteString("  <key>KeepAlive</key>\n  <true/>\n")
+		b.WriteString("</dict>\n</plist>\n")
+	default:
+		return "", fmt.Errorf("unsupported unit format %q: use %s or %s", format, UnitSystemd, UnitLaunchd)
+	}
+	return b.String(), nil
+}
+
+// systemdQuote quotes a value for an ExecStart or Environment line when it
+// contains characters systemd would split on or interpret
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"\\'$%") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, `%`, `%%`)
+	s = strings.ReplaceAll(s, `$`, `$$`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateUnit(t *testing.T) {
+	env := map[string]string{"GDRV_CONFIG_DIR": "/home/ops/.config/gdrv"}
+
+	systemd, err := GenerateUnit(UnitSystemd, "/usr/local/bin/gdrv", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ExecStart=/usr/local/bin/gdrv schedule run", "Environment=GDRV_CONFIG_DIR=/home/ops/.config/gdrv", "Restart=on-failure"} {
+		if !strings.Contains(systemd, want) {
+			t.Errorf("systemd unit missing %q:\n%s", want, systemd)
+		}
+	}
+
+	launchd, err := GenerateUnit(UnitLaunchd, "/Applications/My Tools/gdrv", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<string>" + LaunchdLabel + "</string>", "<string>/Applications/My Tools/gdrv</string>", "<key>GDRV_CONFIG_DIR</key>"} {
+		if !strings.Contains(launchd, want) {
+			t.Errorf("launchd plist missing %q:\n%s", want, launchd)
+		}
+	}
+
+	if _, err := GenerateUnit("cron", "gdrv", nil); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	if got := systemdQuote("/opt/My Tools/gdrv"); got != `"/opt/My Tools/gdrv"` {
+		t.Errorf("got %s", got)
+	}
+	if got := systemdQuote("/usr/bin/gdrv"); got != "/usr/bin/gdrv" {
+		t.Errorf("got %s", got)
+	}
+}
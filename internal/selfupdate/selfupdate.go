@@ -0,0 +1,523 @@
+// Package selfupdate replaces the running gdrv binary with a newer GitHub
+// release, checking the download against the release's checksums.
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// Release channels
+const (
+	ChannelStable = "stable" // The latest release not marked as a pre-release
+	ChannelBeta   = "beta"   // The newest release, pre-releases included
+)
+
+const (
+	// DefaultRepo is the GitHub repository gdrv releases are published to
+	DefaultRepo = "dl-alexandre/Google-Drive-CLI"
+	// DefaultAPIURL is the GitHub REST API endpoint
+	DefaultAPIURL = "https://api.github.com"
+
+	// ChecksumsAsset lists the SHA-256 of every release binary
+	ChecksumsAsset = "checksums.txt"
+	// SignatureAsset is an ed25519 signature of ChecksumsAsset
+	SignatureAsset = "checksums.txt.sig"
+
+	// BackupSuffix names the previous binary kept for Rollback
+	BackupSuffix = ".old"
+)
+
+// SigningPublicKey is the base64 ed25519 key release checksums are signed
+// with, set at build time with -ldflags from the release workflow's
+// RELEASE_SIGNING_PUBLIC_KEY. Builds without it check the checksums only.
+var SigningPublicKey = ""
+
+// Release is a GitHub release
+type Release struct {
+	Tag        string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	URL        string    `json:"html_url"`
+	Draft      bool      `json:"draft"`
+	Prerelease bool      `json:"prerelease"`
+	Published  time.Time `json:"published_at"`
+	Assets     []Asset   `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Asset returns the release's asset named name
+func (r *Release) Asset(name string) (*Asset, bool) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], true
+		}
+	}
+	return nil, false
+}
+
+// Updater finds and downloads releases
+type Updater struct {
+	APIURL     string
+	Repo       string
+	HTTPClient *http.Client
+	// Token authenticates GitHub API requests, raising the rate limit for
+	// fleets of machines behind one address
+	Token string
+	// PublicKey verifies SignatureAsset; nil skips the signature check
+	PublicKey ed25519.PublicKey
+}
+
+// New returns an Updater for the gdrv releases, using GITHUB_TOKEN when set
+// and the build's SigningPublicKey
+func New() (*Updater, error) {
+	u := &Updater{
+		APIURL:     DefaultAPIURL,
+		Repo:       DefaultRepo,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+		Token:      os.Getenv("GITHUB_TOKEN"),
+	}
+	if SigningPublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(SigningPublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid release signing key built into this binary")
+		}
+		u.PublicKey = key
+	}
+	return u, nil
+}
+
+// Latest returns the newest release on channel
+func (u *Updater) Latest(ctx context.Context, channel string) (*Release, error) {
+	switch channel {
+	case ChannelStable:
+		var rel Release
+		if err := u.getJSON(ctx, fmt.Sprintf("%s/repos/%s/releases/latest", u.APIURL, u.Repo), &rel); err != nil {
+			return nil, err
+		}
+		return &rel, nil
+	case ChannelBeta:
+		var releases []Release
+		if err := u.getJSON(ctx, fmt.Sprintf("%s/repos/%s/releases?per_page=30", u.APIURL, u.Repo), &releases); err != nil {
+			return nil, err
+		}
+		var newest *Release
+		for i := range releases {
+			r := &releases[i]
+			if r.Draft {
+				continue
+			}
+			if newest == nil || CompareVersions(r.Tag, newest.Tag) > 0 {
+				newest = r
+			}
+		}
+		if newest == nil {
+			return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+				fmt.Sprintf("No releases found in %s", u.Repo)).Build())
+		}
+		return newest, nil
+	}
+	return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+		fmt.Sprintf("Invalid channel %q: use stable or beta", channel)).Build())
+}
+
+// Release returns the release tagged tag. The tag is escaped, so one
+// containing "/" or "?" cannot reach another API path.
+func (u *Updater) Release(ctx context.Context, tag string) (*Release, error) {
+	var rel Release
+	if err := u.getJSON(ctx, fmt.Sprintf("%s/repos/%s/releases/tags/%s", u.APIURL, u.Repo, url.PathEscape(tag)), &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+// AssetName returns the release binary name for a platform, as built by
+// the release workflow
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("gdrv-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Download fetches the release binary for this platform into a temp file
+// in dir and checks it against the release checksums, and their signature
+// when u.PublicKey is set. It returns the file's path and SHA-256; the
+// caller removes the file if it is not installed.
+func (u *Updater) Download(ctx context.Context, rel *Release, dir string) (path, sum string, err error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	asset, ok := rel.Asset(name)
+	if !ok {
+		return "", "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+			fmt.Sprintf("Release %s has no binary for %s/%s", rel.Tag, runtime.GOOS, runtime.GOARCH)).
+			WithContext("asset", name).Build())
+	}
+	want, err := u.checksum(ctx, rel, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	body, err := u.get(ctx, asset.URL)
+	if err != nil {
+		return "", "", err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(dir, ".gdrv-update-*")
+	if err != nil {
+		return "", "", utils.NewAppError(utils.NewCLIError(utils.ErrCodePermissionDenied,
+			fmt.Sprintf("Cannot write to %s: %v", dir, err)).
+			WithContext("suggestedAction", "run self-update as a user who can write the gdrv binary").Build())
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+			fmt.Sprintf("Failed to download %s: %v", name, err)).Build())
+	}
+
+	sum = hex.EncodeToString(hash.Sum(nil))
+	if sum != want {
+		os.Remove(tmp.Name())
+		return "", "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+			fmt.Sprintf("Downloaded %s does not match %s", name, ChecksumsAsset)).
+			WithContext("expected", want).
+			WithContext("actual", sum).Build())
+	}
+	return tmp.Name(), sum, nil
+}
+
+// checksum returns the SHA-256 the release lists for name, after checking
+// the list's signature when a key is set
+func (u *Updater) checksum(ctx context.Context, rel *Release, name string) (string, error) {
+	asset, ok := rel.Asset(ChecksumsAsset)
+	if !ok {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+			fmt.Sprintf("Release %s has no %s to verify the download against", rel.Tag, ChecksumsAsset)).Build())
+	}
+	list, err := u.fetch(ctx, asset.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if u.PublicKey != nil {
+		sigAsset, ok := rel.Asset(SignatureAsset)
+		if !ok {
+			return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+				fmt.Sprintf("Release %s is not signed (%s is missing)", rel.Tag, SignatureAsset)).Build())
+		}
+		sig, err := u.fetch(ctx, sigAsset.URL)
+		if err != nil {
+			return "", err
+		}
+		if !VerifySignature(u.PublicKey, list, sig) {
+			return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+				fmt.Sprintf("The signature of %s in release %s is not valid", ChecksumsAsset, rel.Tag)).Build())
+		}
+	}
+
+	sum, ok := ParseChecksums(list)[name]
+	if !ok {
+		return "", utils.NewAppError(utils.NewCLIError(utils.ErrCodeChecksumMismatch,
+			fmt.Sprintf("%s in release %s does not list %s", ChecksumsAsset, rel.Tag, name)).Build())
+	}
+	return sum, nil
+}
+
+// ParseChecksums reads sha256sum/shasum output into a map from file name
+// to lower-case hex digest
+func ParseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// shasum marks binary-mode entries with a leading '*'
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// VerifySignature reports whether sig, raw or base64-encoded, is key's
+// ed25519 signature of data
+func VerifySignature(key ed25519.PublicKey, data, sig []byte) bool {
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return false
+		}
+		sig = decoded
+	}
+	return len(sig) == ed25519.SignatureSize && ed25519.Verify(key, data, sig)
+}
+
+// CheckBinary runs path's version command and confirms it reports tag, so
+// a binary that does not start on this machine is never installed
+func CheckBinary(ctx context.Context, path, tag string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "version").Output()
+	if err != nil {
+		return fmt.Errorf("the downloaded binary failed to run: %w", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != tag {
+		return fmt.Errorf("the downloaded binary reports version %q, want %q", got, tag)
+	}
+	return nil
+}
+
+// Install replaces exe with newBinary, keeping exe as exe + BackupSuffix.
+// If newBinary cannot be moved into place, exe is put back. Renaming the
+// running binary works on every platform, including Windows.
+func Install(exe, newBinary string) (backup string, err error) {
+	backup = exe + BackupSuffix
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove the previous backup %s: %w", backup, err)
+	}
+	if err := os.Rename(exe, backup); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", exe, err)
+	}
+	if err := os.Rename(newBinary, exe); err != nil {
+		if restoreErr := os.Rename(backup, exe); restoreErr != nil {
+			return "", fmt.Errorf("failed to install the update (%v) and to restore %s from %s: %w", err, exe, backup, restoreErr)
+		}
+		return "", fmt.Errorf("failed to install the update, %s was left unchanged: %w", exe, err)
+	}
+	return backup, nil
+}
+
+// Rollback swaps exe with the backup kept by Install, so a second rollback
+// undoes the first
+func Rollback(exe string) error {
+	backup := exe + BackupSuffix
+	if _, err := os.Stat(backup); err != nil {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeFileNotFound,
+			fmt.Sprintf("No previous version to roll back to (%s not found)", backup)).Build())
+	}
+	swap := exe + ".rollback"
+	if err := os.Rename(exe, swap); err != nil {
+		return err
+	}
+	if err := os.Rename(backup, exe); err != nil {
+		_ = os.Rename(swap, exe)
+		return err
+	}
+	return os.Rename(swap, backup)
+}
+
+// Executable returns the path of the running binary with symlinks resolved,
+// so the file itself is replaced rather than a link to it
+func Executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// ManagedBy names the package manager that installed exe, or "" when gdrv
+// was installed by hand
+func ManagedBy(exe string) string {
+	slashed := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(slashed, "/Cellar/"):
+		return "Homebrew"
+	case strings.HasPrefix(slashed, "/usr/bin/"):
+		return "the system package manager"
+	}
+	return ""
+}
+
+// CompareVersions compares two release tags such as v1.2.3 and
+// v1.3.0-beta.1 by semantic version precedence, returning -1, 0 or 1. A
+// tag that is not a version, such as a "dev" build, sorts before every
+// version.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			return compareInts(va.core[i], vb.core[i])
+		}
+	}
+	// A pre-release sorts before its release
+	switch {
+	case len(va.pre) == 0 && len(vb.pre) == 0:
+		return 0
+	case len(va.pre) == 0:
+		return 1
+	case len(vb.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(va.pre) && i < len(vb.pre); i++ {
+		if c := comparePrerelease(va.pre[i], vb.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(va.pre), len(vb.pre))
+}
+
+// IsVersion reports whether tag is a release version
+func IsVersion(tag string) bool {
+	_, ok := parseVersion(tag)
+	return ok
+}
+
+type semver struct {
+	core [3]int
+	pre  []string
+}
+
+func parseVersion(s string) (semver, bool) {
+	var v semver
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// comparePrerelease compares identifiers numerically when both are numbers
+// and lexically otherwise, numbers sorting first
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (u *Updater) getJSON(ctx context.Context, url string, v interface{}) error {
+	body, err := u.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+			fmt.Sprintf("Invalid response from %s: %v", url, err)).Build())
+	}
+	return nil
+}
+
+func (u *Updater) fetch(ctx context.Context, url string) ([]byte, error) {
+	body, err := u.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+			fmt.Sprintf("Failed to read %s: %v", url, err)).Build())
+	}
+	return data, nil
+}
+
+func (u *Updater) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(url, u.APIURL) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if u.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+u.Token)
+		}
+	}
+	resp, err := u.HTTPClient.Do(req)
+	if err != nil {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeNetworkError,
+			fmt.Sprintf("Failed to reach %s: %v", url, err)).WithRetryable(true).Build())
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		code := utils.ErrCodeNetworkError
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			code = utils.ErrCodeFileNotFound
+		case http.StatusForbidden, http.StatusTooManyRequests:
+			// GitHub answers 403 when the unauthenticated rate limit is used up
+			code = utils.ErrCodeRateLimited
+		}
+		builder := utils.NewCLIError(code, fmt.Sprintf("GET %s: %s", url, resp.Status)).WithHTTPStatus(resp.StatusCode)
+		if code == utils.ErrCodeRateLimited && u.Token == "" {
+			builder.WithContext("suggestedAction", "set GITHUB_TOKEN to raise the GitHub API rate limit")
+		}
+		return nil, utils.NewAppError(builder.Build())
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,219 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.10.0", -1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.3.0-beta.1", "v1.3.0", -1},
+		{"v1.3.0-beta.2", "v1.3.0-beta.10", -1},
+		{"v1.3.0-alpha", "v1.3.0-beta", -1},
+		{"v1.3.0-beta", "v1.3.0-beta.1", -1},
+		{"v1.3.0-rc.1", "v1.2.9", 1},
+		{"dev", "v0.0.1", -1},
+		{"v1.0.0", "v1.0.0-dirty-3-gabc", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	sums := ParseChecksums([]byte("ABC123  gdrv-linux-amd64\ndef456 *gdrv-windows-amd64.exe\n\nbroken line here\n"))
+	if sums["gdrv-linux-amd64"] != "abc123" || sums["gdrv-windows-amd64.exe"] != "def456" || len(sums) != 2 {
+		t.Errorf("sums = %v", sums)
+	}
+}
+
+// releaseServer serves a release of binary, with checksums signed by key
+// when key is not nil
+func releaseServer(t *testing.T, binary []byte, listedSum string, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	checksums := []byte(fmt.Sprintf("%s  %s\n0000  gdrv-other-arch\n", listedSum, name))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/releases/latest":
+			assets := []Asset{
+				{Name: name, URL: server.URL + "/dl/" + name},
+				{Name: ChecksumsAsset, URL: server.URL + "/dl/" + ChecksumsAsset},
+			}
+			if key != nil {
+				assets = append(assets, Asset{Name: SignatureAsset, URL: server.URL + "/dl/" + SignatureAsset})
+			}
+			_ = json.NewEncoder(w).Encode(Release{Tag: "v1.4.0", Assets: assets})
+		case "/repos/o/r/releases":
+			_ = json.NewEncoder(w).Encode([]Release{
+				{Tag: "v1.4.0"},
+				{Tag: "v1.6.0-beta.1", Draft: true},
+				{Tag: "v1.5.0-beta.2", Prerelease: true},
+				{Tag: "v1.5.0-beta.1", Prerelease: true},
+			})
+		case "/dl/" + name:
+			_, _ = w.Write(binary)
+		case "/dl/" + ChecksumsAsset:
+			_, _ = w.Write(checksums)
+		case "/dl/" + SignatureAsset:
+			_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, checksums))))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLatestAndDownload(t *testing.T) {
+	binary := []byte("new gdrv binary")
+	digest := sha256.Sum256(binary)
+	sum := hex.EncodeToString(digest[:])
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := releaseServer(t, binary, sum, priv)
+	u := &Updater{APIURL: server.URL, Repo: "o/r", HTTPClient: server.Client(), PublicKey: pub}
+	ctx := context.Background()
+
+	beta, err := u.Latest(ctx, ChannelBeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beta.Tag != "v1.5.0-beta.2" {
+		t.Errorf("beta = %s, want the newest non-draft release", beta.Tag)
+	}
+	rel, err := u.Latest(ctx, ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path, got, err := u.Download(ctx, rel, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != sum {
+		t.Errorf("sum = %s, want %s", got, sum)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(binary) {
+		t.Errorf("downloaded %q", data)
+	}
+
+	// A signature from another key is refused
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	u.PublicKey = other
+	if _, _, err := u.Download(ctx, rel, dir); !isCode(err, utils.ErrCodeChecksumMismatch) {
+		t.Errorf("wrong key: err = %v", err)
+	}
+}
+
+func TestRelease_EscapesTag(t *testing.T) {
+	const tag = "v1/../../latest?x=#y"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/releases/tags/"+tag || r.URL.RawQuery != "" {
+			t.Errorf("requested path %q query %q, want the tag as one segment", r.URL.Path, r.URL.RawQuery)
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"tag_name": tag})
+	}))
+	defer server.Close()
+	u := &Updater{APIURL: server.URL, Repo: "o/r", HTTPClient: server.Client()}
+
+	rel, err := u.Release(context.Background(), tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Tag != tag {
+		t.Errorf("tag = %q, want %q", rel.Tag, tag)
+	}
+}
+
+func TestDownloadRejectsChecksumMismatch(t *testing.T) {
+	server := releaseServer(t, []byte("tampered"), "0123abcd", nil)
+	u := &Updater{APIURL: server.URL, Repo: "o/r", HTTPClient: server.Client()}
+	ctx := context.Background()
+	rel, err := u.Latest(ctx, ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if _, _, err := u.Download(ctx, rel, dir); !isCode(err, utils.ErrCodeChecksumMismatch) {
+		t.Fatalf("err = %v, want a checksum mismatch", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("rejected download left %d files behind", len(entries))
+	}
+}
+
+func TestInstallAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "gdrv")
+	update := filepath.Join(dir, ".gdrv-update-1")
+	if err := os.WriteFile(exe, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(update, []byte("v2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := Install(exe, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "v2" {
+		t.Errorf("installed %q", got)
+	}
+	if got, _ := os.ReadFile(backup); string(got) != "v1" {
+		t.Errorf("backup %q", got)
+	}
+
+	if err := Rollback(exe); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "v1" {
+		t.Errorf("after rollback %q", got)
+	}
+	if got, _ := os.ReadFile(backup); string(got) != "v2" {
+		t.Errorf("after rollback the backup holds %q, want the undone update", got)
+	}
+
+	// A missing update leaves the installed binary in place
+	if _, err := Install(exe, filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("Install of a missing file should fail")
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "v1" {
+		t.Errorf("failed install left %q", got)
+	}
+}
+
+func isCode(err error, code string) bool {
+	appErr, ok := err.(*utils.AppError)
+	return ok && appErr.CLIError.Code == code
+}
@@ -129,6 +129,57 @@ func (m *Manager) BatchUpdate(ctx context.Context, reqCtx *types.RequestContext,
 	}, nil
 }
 
+// CreateSpreadsheet creates a spreadsheet whose only sheet is named
+// sheetTitle, with the first row frozen for headers
+func (m *Manager) CreateSpreadsheet(ctx context.Context, reqCtx *types.RequestContext, title, sheetTitle string) (*types.Spreadsheet, error) {
+	call := m.service.Spreadsheets.Create(&sheets.Spreadsheet{
+		Properties: &sheets.SpreadsheetProperties{Title: title},
+		Sheets:     []*sheets.Sheet{{Properties: headerSheetProperties(sheetTitle)}},
+	})
+
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*sheets.Spreadsheet, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return convertSpreadsheet(result), nil
+}
+
+// AddSheet adds a sheet (tab) named title, with the first row frozen for
+// headers
+func (m *Manager) AddSheet(ctx context.Context, reqCtx *types.RequestContext, spreadsheetID, title string) (*types.Sheet, error) {
+	call := m.service.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			AddSheet: &sheets.AddSheetRequest{Properties: headerSheetProperties(title)},
+		}},
+	})
+
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &types.Sheet{Title: title}
+	if len(result.Replies) > 0 && result.Replies[0].AddSheet != nil && result.Replies[0].AddSheet.Properties != nil {
+		props := result.Replies[0].AddSheet.Properties
+		sheet.ID = props.SheetId
+		sheet.Index = props.Index
+		sheet.Type = props.SheetType
+	}
+	return sheet, nil
+}
+
+func headerSheetProperties(title string) *sheets.SheetProperties {
+	return &sheets.SheetProperties{
+		Title:          title,
+		GridProperties: &sheets.GridProperties{FrozenRowCount: 1},
+	}
+}
+
 func (m *Manager) GetSpreadsheet(ctx context.Context, reqCtx *types.RequestContext, spreadsheetID string) (*types.Spreadsheet, error) {
 	call := m.service.Spreadsheets.Get(spreadsheetID)
 
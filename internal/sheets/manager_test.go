@@ -1,8 +1,15 @@
 package sheets
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/option"
 	sheetsapi "google.golang.org/api/sheets/v4"
 )
 
@@ -51,3 +58,34 @@ func TestConvertSpreadsheet(t *testing.T) {
 		}
 	})
 }
+
+func TestAddSheet(t *testing.T) {
+	var req sheetsapi.BatchUpdateSpreadsheetRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v4/spreadsheets/s1:batchUpdate" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte(`{"spreadsheetId":"s1","replies":[{"addSheet":{"properties":{"sheetId":42,"title":"audit","index":3}}}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	svc, err := sheetsapi.NewService(ctx, option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewManager(api.NewClient(nil, 0, 100, nil), svc)
+
+	sheet, err := mgr.AddSheet(ctx, api.NewRequestContext("default", "", types.RequestTypeMutation), "s1", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sheet.ID != 42 || sheet.Index != 3 || sheet.Title != "audit" {
+		t.Errorf("sheet = %+v", sheet)
+	}
+	props := req.Requests[0].AddSheet.Properties
+	if props.Title != "audit" || props.GridProperties.FrozenRowCount != 1 {
+		t.Errorf("AddSheet properties = %+v", props)
+	}
+}
@@ -0,0 +1,245 @@
+// Package spool holds result lists too large to keep in memory. Items past
+// a threshold are spilled to a temp file as JSON lines and streamed back out
+// as a JSON array when the result is written.
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/tempdir"
+)
+
+// DefaultLimit is the number of items kept in memory before spilling
+const DefaultLimit = 50000
+
+// placeholderPrefix marks where a spilled list belongs in marshalled output.
+// The NUL byte cannot occur unescaped in JSON produced from real data.
+const placeholderPrefix = "\x00gdrv-spool:"
+
+var (
+	registryMu sync.Mutex
+	registry   = map[int]streamer{}
+	nextID     int
+)
+
+type streamer interface {
+	streamJSON(w io.Writer) error
+}
+
+// List is an append-only list that keeps at most limit items in memory.
+// The zero limit keeps everything in memory.
+type List[T any] struct {
+	limit int
+	mem   []T
+	file  *os.File
+	buf   *bufio.Writer
+	count int
+	id    int
+}
+
+// New creates a list that spills to disk after limit items
+func New[T any](limit int) *List[T] {
+	return &List[T]{limit: limit}
+}
+
+// Add appends an item, spilling it to the temp file once the in-memory
+// limit has been reached
+func (l *List[T]) Add(item T) error {
+	l.count++
+	if l.file == nil && (l.limit <= 0 || len(l.mem) < l.limit) {
+		l.mem = append(l.mem, item)
+		return nil
+	}
+	if l.file == nil {
+		if err := l.spill(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode spooled item: %w", err)
+	}
+	if _, err := l.buf.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of items added
+func (l *List[T]) Len() int {
+	return l.count
+}
+
+// Spilled reports whether items have been written to disk
+func (l *List[T]) Spilled() bool {
+	return l.file != nil
+}
+
+// Each calls fn for every item in order. Spilled items are decoded from
+// disk one at a time.
+func (l *List[T]) Each(fn func(T) error) error {
+	for _, item := range l.mem {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return l.eachSpilled(func(line []byte) error {
+		var item T
+		if err := json.Unmarshal(line, &item); err != nil {
+			return fmt.Errorf("failed to decode spooled item: %w", err)
+		}
+		return fn(item)
+	})
+}
+
+// MarshalJSON encodes the list as an array. Once spilled, it encodes a
+// placeholder that WriteJSON expands by streaming from disk instead.
+func (l *List[T]) MarshalJSON() ([]byte, error) {
+	if l.file == nil {
+		if l.mem == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(l.mem)
+	}
+	return json.Marshal(placeholderPrefix + strconv.Itoa(l.id))
+}
+
+// Close removes the temp file. The list must not be used afterwards.
+func (l *List[T]) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	registryMu.Lock()
+	delete(registry, l.id)
+	registryMu.Unlock()
+
+	name := l.file.Name()
+	_ = l.file.Close()
+	l.file = nil
+	return os.Remove(name)
+}
+
+func (l *List[T]) spill() error {
+	f, err := tempdir.CreateTemp("spool-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	l.file = f
+	l.buf = bufio.NewWriter(f)
+
+	registryMu.Lock()
+	nextID++
+	l.id = nextID
+	registry[l.id] = l
+	registryMu.Unlock()
+	return nil
+}
+
+func (l *List[T]) eachSpilled(fn func(line []byte) error) error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read spool file: %w", err)
+	}
+	// Later Adds append at the end
+	defer func() { _, _ = l.file.Seek(0, io.SeekEnd) }()
+
+	scanner := bufio.NewScanner(l.file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// streamJSON writes the list as a JSON array with one item per line
+func (l *List[T]) streamJSON(w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	writeItem := func(data []byte) error {
+		sep := ",\n"
+		if first {
+			sep = "\n"
+			first = false
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		_, err := w.Write(data)
+		return err
+	}
+	for _, item := range l.mem {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if err := writeItem(data); err != nil {
+			return err
+		}
+	}
+	if err := l.eachSpilled(writeItem); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n]")
+	return err
+}
+
+// WriteJSON writes marshalled output to w, streaming the contents of any
+// spilled lists in place of their placeholders
+func WriteJSON(w io.Writer, data []byte) error {
+	bw := bufio.NewWriter(w)
+	marker := []byte(`"` + jsonEscapedPrefix())
+	for {
+		i := bytes.Index(data, marker)
+		if i < 0 {
+			break
+		}
+		end := bytes.IndexByte(data[i+len(marker):], '"')
+		if end < 0 {
+			break
+		}
+		id, err := strconv.Atoi(string(data[i+len(marker) : i+len(marker)+end]))
+		if err != nil {
+			break
+		}
+		registryMu.Lock()
+		s := registry[id]
+		registryMu.Unlock()
+		if s == nil {
+			return fmt.Errorf("spooled result %d is no longer available", id)
+		}
+
+		if _, err := bw.Write(data[:i]); err != nil {
+			return err
+		}
+		if err := s.streamJSON(bw); err != nil {
+			return err
+		}
+		data = data[i+len(marker)+end+1:]
+	}
+	if _, err := bw.Write(data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// jsonEscapedPrefix returns placeholderPrefix as encoding/json escapes it
+func jsonEscapedPrefix() string {
+	quoted, _ := json.Marshal(placeholderPrefix)
+	return string(quoted[1 : len(quoted)-1])
+}
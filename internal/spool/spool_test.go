@@ -0,0 +1,107 @@
+package spool
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type item struct {
+	ID   string `json:"id"`
+	Size int    `json:"size"`
+}
+
+func fill(t *testing.T, l *List[item], n int) []item {
+	t.Helper()
+	var want []item
+	for i := 0; i < n; i++ {
+		it := item{ID: string(rune('a' + i)), Size: i}
+		want = append(want, it)
+		if err := l.Add(it); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return want
+}
+
+func TestListInMemory(t *testing.T) {
+	l := New[item](10)
+	defer l.Close()
+	want := fill(t, l, 3)
+	if l.Spilled() {
+		t.Fatal("list under the limit should not spill")
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"files": l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct{ Files []item }
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Files, want) {
+		t.Errorf("Files = %v, want %v", got.Files, want)
+	}
+}
+
+func TestListSpillsAndStreams(t *testing.T) {
+	l := New[item](2)
+	want := fill(t, l, 5)
+	if !l.Spilled() || l.Len() != 5 {
+		t.Fatalf("Spilled = %v, Len = %d", l.Spilled(), l.Len())
+	}
+
+	var each []item
+	if err := l.Each(func(it item) error { each = append(each, it); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(each, want) {
+		t.Errorf("Each = %v, want %v", each, want)
+	}
+
+	// Adding after iterating appends rather than overwriting
+	if err := l.Add(item{ID: "z", Size: 99}); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, item{ID: "z", Size: 99})
+
+	envelope, err := json.MarshalIndent(map[string]interface{}{"data": map[string]interface{}{"files": l}, "ok": true}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WriteJSON(&out, envelope); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Data struct{ Files []item }
+		OK   bool
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("streamed output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if !got.OK || !reflect.DeepEqual(got.Data.Files, want) {
+		t.Errorf("streamed = %+v, want %v", got, want)
+	}
+
+	name := l.file.Name()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spool file %s not removed", name)
+	}
+}
+
+func TestWriteJSONWithoutSpools(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJSON(&out, []byte(`{"a":"\u0000not-a-spool"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != `{"a":"\u0000not-a-spool"}` {
+		t.Errorf("output = %q", out.String())
+	}
+}
@@ -0,0 +1,275 @@
+// Package state versions the files gdrv keeps between runs (caches,
+// schedules, download checkpoints, permission snapshots, the sync index),
+// so a release can change a format and migrate older files instead of
+// misreading them.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+// LegacyVersion is the version of JSON state written before files carried
+// a schemaVersion
+const LegacyVersion = 1
+
+// Migration upgrades a JSON document by one version, in place
+type Migration func(doc map[string]json.RawMessage) error
+
+// Kind describes one kind of state file
+type Kind struct {
+	Name        string
+	Description string
+	// Current is the version this release reads and writes
+	Current int
+	// Migrations[n] upgrades a version n document to n+1
+	Migrations map[int]Migration
+	// DefaultPath locates the file in the config directory; nil for state
+	// kept elsewhere, such as next to a download
+	DefaultPath func() (string, error)
+	// FileName identifies files of this kind given by path
+	FileName string
+	// Inspect returns the version of a state file that is not JSON
+	Inspect func(path string) (int, error)
+}
+
+// Versioned is embedded in the top-level struct of every JSON state file
+type Versioned struct {
+	SchemaVersion int `json:"schemaVersion"`
+}
+
+func (v *Versioned) setSchemaVersion(n int) { v.SchemaVersion = n }
+
+type versioned interface {
+	setSchemaVersion(int)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Kind{}
+)
+
+// Register adds a kind to the set checked by 'gdrv state verify' and
+// returns it
+func Register(kind *Kind) *Kind {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[kind.Name]; ok {
+		panic("state: kind registered twice: " + kind.Name)
+	}
+	registry[kind.Name] = kind
+	return kind
+}
+
+// Kinds returns the registered kinds sorted by name
+func Kinds() []*Kind {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	kinds := make([]*Kind, 0, len(registry))
+	for _, k := range registry {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Name < kinds[j].Name })
+	return kinds
+}
+
+// Lookup returns the named kind
+func Lookup(name string) (*Kind, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	k, ok := registry[name]
+	return k, ok
+}
+
+// Marshal encodes v as indented JSON stamped with the kind's current
+// version
+func Marshal(kind *Kind, v versioned) ([]byte, error) {
+	v.setSchemaVersion(kind.Current)
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// Unmarshal decodes a document of any version up to kind.Current into v,
+// migrating older documents first. Documents from a newer release are
+// refused, so this release never rewrites data it cannot represent.
+func Unmarshal(kind *Kind, data []byte, v interface{}) error {
+	migrated, _, err := migrate(kind, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(migrated, v)
+}
+
+// migrate returns data upgraded to kind.Current and the version it was
+// written with
+func migrate(kind *Kind, data []byte) ([]byte, int, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	version := LegacyVersion
+	if raw, ok := doc["schemaVersion"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, 0, fmt.Errorf("invalid schemaVersion: %w", err)
+		}
+	}
+	if version > kind.Current {
+		return nil, version, TooNewError(kind, version)
+	}
+	if version == kind.Current {
+		return data, version, nil
+	}
+	for n := version; n < kind.Current; n++ {
+		if m := kind.Migrations[n]; m != nil {
+			if err := m(doc); err != nil {
+				return nil, version, fmt.Errorf("migrating %s from version %d: %w", kind.Name, n, err)
+			}
+		}
+	}
+	current, _ := json.Marshal(kind.Current)
+	doc["schemaVersion"] = current
+	out, err := json.Marshal(doc)
+	return out, version, err
+}
+
+// Load reads the state file at path into v, migrating older versions. It
+// reports false, with no error, when the file does not exist.
+func Load(kind *Kind, path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := Unmarshal(kind, data, v); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			appErr.CLIError.Context["path"] = path
+			return false, appErr
+		}
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Save writes v to path, stamped with the kind's current version. The file
+// is replaced atomically, so an interrupted write keeps the previous state.
+func Save(kind *Kind, path string, v versioned) error {
+	data, err := Marshal(kind, v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", kind.Name, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := utils.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// TooNewError reports a state file written by a newer release
+func TooNewError(kind *Kind, version int) error {
+	return utils.NewAppError(utils.NewCLIError(utils.ErrCodeStateVersion,
+		fmt.Sprintf("%s state is version %d, but this gdrv supports up to %d", kind.Name, version, kind.Current)).
+		WithContext("suggestedAction", "upgrade gdrv; the file was left unchanged").
+		Build())
+}
+
+// Status values reported by Verify
+const (
+	StatusOK       = "ok"
+	StatusMigrate  = "needs-migration"
+	StatusMigrated = "migrated"
+	StatusTooNew   = "too-new"
+	StatusCorrupt  = "corrupt"
+	StatusMissing  = "missing"
+)
+
+// Report is the verification result for one state file
+type Report struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Version int    `json:"version,omitempty"`
+	Current int    `json:"current"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Verify checks the file at path against kind. With rewrite, an older
+// JSON file is migrated and written back atomically, keeping the original
+// as path + ".bak".
+func Verify(kind *Kind, path string, rewrite bool) *Report {
+	r := &Report{Kind: kind.Name, Path: path, Current: kind.Current}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		r.Status = StatusMissing
+		return r
+	}
+
+	if kind.Inspect != nil {
+		version, err := kind.Inspect(path)
+		r.Version = version
+		switch {
+		case err != nil:
+			r.Status, r.Error = StatusCorrupt, err.Error()
+		case version > kind.Current:
+			r.Status = StatusTooNew
+		case version < kind.Current:
+			// Non-JSON state is migrated by its owner when opened
+			r.Status = StatusMigrate
+		default:
+			r.Status = StatusOK
+		}
+		return r
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.Status, r.Error = StatusCorrupt, err.Error()
+		return r
+	}
+	migrated, version, err := migrate(kind, data)
+	r.Version = version
+	switch {
+	case version > kind.Current:
+		r.Status = StatusTooNew
+		return r
+	case err != nil:
+		r.Status, r.Error = StatusCorrupt, err.Error()
+		return r
+	case version == kind.Current:
+		r.Status = StatusOK
+		return r
+	case !rewrite:
+		r.Status = StatusMigrate
+		return r
+	}
+
+	if err := rewriteFile(path, data, migrated); err != nil {
+		r.Status, r.Error = StatusMigrate, err.Error()
+		return r
+	}
+	r.Status = StatusMigrated
+	return r
+}
+
+func rewriteFile(path string, original, migrated []byte) error {
+	var indented map[string]json.RawMessage
+	if err := json.Unmarshal(migrated, &indented); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(indented, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".bak", original, 0600); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, out, 0600)
+}
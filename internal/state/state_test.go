@@ -0,0 +1,115 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/utils"
+)
+
+type testDoc struct {
+	Versioned
+	Items []string `json:"items"`
+}
+
+// testKind is at version 2: version 1 stored a single "item"
+func testKind() *Kind {
+	return &Kind{
+		Name:    "test",
+		Current: 2,
+		Migrations: map[int]Migration{
+			1: func(doc map[string]json.RawMessage) error {
+				if item, ok := doc["item"]; ok {
+					doc["items"] = json.RawMessage("[" + string(item) + "]")
+					delete(doc, "item")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func TestLoadAndSave(t *testing.T) {
+	kind := testKind()
+	path := filepath.Join(t.TempDir(), "nested", "test.json")
+
+	var doc testDoc
+	if found, err := Load(kind, path, &doc); found || err != nil {
+		t.Fatalf("missing file: found = %v, err = %v", found, err)
+	}
+
+	if err := Save(kind, path, &testDoc{Items: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"schemaVersion": 2`) {
+		t.Errorf("saved file not stamped: %s", data)
+	}
+
+	// A legacy file without schemaVersion is migrated when read
+	if err := os.WriteFile(path, []byte(`{"item":"old"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc = testDoc{}
+	if found, err := Load(kind, path, &doc); !found || err != nil {
+		t.Fatalf("legacy file: found = %v, err = %v", found, err)
+	}
+	if doc.SchemaVersion != 2 || len(doc.Items) != 1 || doc.Items[0] != "old" {
+		t.Errorf("migrated doc = %+v", doc)
+	}
+
+	// A file from a newer release is refused
+	if err := os.WriteFile(path, []byte(`{"schemaVersion":3,"items":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(kind, path, &doc)
+	appErr, ok := err.(*utils.AppError)
+	if !ok || appErr.CLIError.Code != utils.ErrCodeStateVersion || appErr.CLIError.Context["path"] != path {
+		t.Errorf("newer file: err = %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	kind := testKind()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cases := []struct {
+		path   string
+		status string
+	}{
+		{write("current.json", `{"schemaVersion":2,"items":[]}`), StatusOK},
+		{write("legacy.json", `{"item":"x"}`), StatusMigrate},
+		{write("newer.json", `{"schemaVersion":9}`), StatusTooNew},
+		{write("corrupt.json", `{"items":`), StatusCorrupt},
+		{filepath.Join(dir, "missing.json"), StatusMissing},
+	}
+	for _, c := range cases {
+		if r := Verify(kind, c.path, false); r.Status != c.status {
+			t.Errorf("%s: status = %s, want %s", filepath.Base(c.path), r.Status, c.status)
+		}
+	}
+
+	legacy := cases[1].path
+	if r := Verify(kind, legacy, true); r.Status != StatusMigrated || r.Version != 1 {
+		t.Fatalf("migrate: %+v", r)
+	}
+	if backup, _ := os.ReadFile(legacy + ".bak"); string(backup) != `{"item":"x"}` {
+		t.Errorf("backup = %q", backup)
+	}
+	if r := Verify(kind, legacy, false); r.Status != StatusOK {
+		t.Errorf("after migrate: status = %s", r.Status)
+	}
+	if newer, _ := os.ReadFile(cases[2].path); string(newer) != `{"schemaVersion":9}` {
+		t.Errorf("newer file was modified: %s", newer)
+	}
+}
@@ -1,6 +1,7 @@
 package conflict
 
 import (
+	"fmt"
 	"path"
 	"strings"
 
@@ -13,8 +14,20 @@ const (
 	PolicyLocalWins  Policy = "local-wins"
 	PolicyRemoteWins Policy = "remote-wins"
 	PolicyRenameBoth Policy = "rename-both"
+	// PolicySkip leaves conflicting paths untouched and reports them, so
+	// the rest of the tree can still converge
+	PolicySkip Policy = "skip"
 )
 
+// ParsePolicy validates a conflict policy name
+func ParsePolicy(value string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case PolicyLocalWins, PolicyRemoteWins, PolicyRenameBoth, PolicySkip:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (expected local-wins, remote-wins, rename-both or skip)", value)
+}
+
 func Resolve(conflicts []diff.Conflict, policy Policy) ([]diff.Action, []diff.Conflict) {
 	var actions []diff.Action
 	var remaining []diff.Conflict
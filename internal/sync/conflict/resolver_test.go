@@ -0,0 +1,47 @@
+package conflict
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/sync/diff"
+	"github.com/dl-alexandre/gdrv/internal/sync/scanner"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for _, name := range []string{"local-wins", "remote-wins", "rename-both", "Skip"} {
+		if _, err := ParsePolicy(name); err != nil {
+			t.Errorf("ParsePolicy(%q): %v", name, err)
+		}
+	}
+	if _, err := ParsePolicy("newest-wins"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	conflicts := []diff.Conflict{{
+		Path:   "docs/a.txt",
+		Kind:   diff.ConflictBothModified,
+		Local:  &scanner.LocalEntry{RelativePath: "docs/a.txt"},
+		Remote: &scanner.RemoteEntry{RelativePath: "docs/a.txt", ID: "f1"},
+	}}
+
+	actions, remaining := Resolve(conflicts, PolicySkip)
+	if len(actions) != 0 || len(remaining) != 1 {
+		t.Errorf("skip: %d actions, %d remaining", len(actions), len(remaining))
+	}
+
+	actions, _ = Resolve(conflicts, PolicyLocalWins)
+	if len(actions) != 1 || actions[0].Type != diff.ActionUpdate {
+		t.Errorf("local-wins: %+v", actions)
+	}
+	actions, _ = Resolve(conflicts, PolicyRemoteWins)
+	if len(actions) != 1 || actions[0].Type != diff.ActionDownload {
+		t.Errorf("remote-wins: %+v", actions)
+	}
+
+	actions, remaining = Resolve(conflicts, PolicyRenameBoth)
+	if len(remaining) != 0 || len(actions) != 4 || actions[0].ToPath != "docs/a.local.txt" || actions[1].ToPath != "docs/a.remote.txt" {
+		t.Errorf("rename-both: %+v", actions)
+	}
+}
@@ -68,6 +68,11 @@ func Compute(snapshot Snapshot, mode Mode, includeDeletes bool) Result {
 		switch {
 		case localOK && remoteOK:
 			if localChanged && remoteChanged {
+				// Both sides changed to the same content; recording the
+				// new state is enough
+				if !localEntry.IsDir && localEntry.Hash != "" && localEntry.Hash == remoteEntry.MD5Checksum {
+					continue
+				}
 				conflicts = append(conflicts, Conflict{
 					Path:   path,
 					Kind:   ConflictBothModified,
@@ -0,0 +1,72 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/sync/index"
+	"github.com/dl-alexandre/gdrv/internal/sync/scanner"
+)
+
+func TestComputeBothModified(t *testing.T) {
+	prev := map[string]index.SyncEntry{
+		"a.txt": {RelativePath: "a.txt", DriveFileID: "f1", LocalSize: 1, LocalMTime: 100, ContentHash: "old", RemoteMD5: "old"},
+		"b.txt": {RelativePath: "b.txt", DriveFileID: "f2", LocalSize: 1, LocalMTime: 100, ContentHash: "old", RemoteMD5: "old"},
+	}
+	snapshot := Snapshot{
+		Local: map[string]scanner.LocalEntry{
+			"a.txt": {RelativePath: "a.txt", Size: 2, ModTime: 200, Hash: "local"},
+			"b.txt": {RelativePath: "b.txt", Size: 2, ModTime: 200, Hash: "same"},
+		},
+		Remote: map[string]scanner.RemoteEntry{
+			"a.txt": {RelativePath: "a.txt", ID: "f1", MD5Checksum: "remote"},
+			"b.txt": {RelativePath: "b.txt", ID: "f2", MD5Checksum: "same"},
+		},
+		Prev: prev,
+	}
+
+	result := Compute(snapshot, ModeBidirectional, false)
+	if len(result.Actions) != 0 {
+		t.Errorf("actions = %+v, want none", result.Actions)
+	}
+	// b.txt changed to the same content on both sides and has converged
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "a.txt" || result.Conflicts[0].Kind != ConflictBothModified {
+		t.Errorf("conflicts = %+v, want a.txt both modified", result.Conflicts)
+	}
+}
+
+func TestComputeOneSideModified(t *testing.T) {
+	prev := map[string]index.SyncEntry{
+		"up.txt":   {RelativePath: "up.txt", DriveFileID: "f1", LocalSize: 1, LocalMTime: 100, ContentHash: "old", RemoteMD5: "old"},
+		"down.txt": {RelativePath: "down.txt", DriveFileID: "f2", LocalSize: 1, LocalMTime: 100, ContentHash: "old", RemoteMD5: "old"},
+	}
+	snapshot := Snapshot{
+		Local: map[string]scanner.LocalEntry{
+			"up.txt":   {RelativePath: "up.txt", Size: 2, ModTime: 200, Hash: "new"},
+			"down.txt": {RelativePath: "down.txt", Size: 1, ModTime: 100, Hash: "old"},
+			"new.txt":  {RelativePath: "new.txt", Size: 3, ModTime: 300, Hash: "n"},
+		},
+		Remote: map[string]scanner.RemoteEntry{
+			"up.txt":   {RelativePath: "up.txt", ID: "f1", MD5Checksum: "old"},
+			"down.txt": {RelativePath: "down.txt", ID: "f2", MD5Checksum: "new"},
+		},
+		Prev: prev,
+	}
+
+	got := map[string]ActionType{}
+	for _, action := range Compute(snapshot, ModeBidirectional, false).Actions {
+		got[action.Path] = action.Type
+	}
+	want := map[string]ActionType{"up.txt": ActionUpdate, "down.txt": ActionDownload, "new.txt": ActionUpload}
+	for p, typ := range want {
+		if got[p] != typ {
+			t.Errorf("%s: action %q, want %q", p, got[p], typ)
+		}
+	}
+
+	push := Compute(snapshot, ModePush, false).Actions
+	for _, action := range push {
+		if action.Type == ActionDownload {
+			t.Errorf("push planned a download of %s", action.Path)
+		}
+	}
+}
@@ -50,6 +50,8 @@ type Plan struct {
 type Result struct {
 	Plan    Plan
 	Summary executor.Summary
+	// Actions holds the outcome of every action attempted
+	Actions []executor.ActionResult
 }
 
 func NewEngine(client *api.Client, db *index.DB) *Engine {
@@ -62,6 +64,12 @@ func NewEngine(client *api.Client, db *index.DB) *Engine {
 	}
 }
 
+// SetExportDefaults sets the format Workspace files are exported as when
+// downloaded, by Workspace MIME type
+func (e *Engine) SetExportDefaults(formats map[string]string) {
+	e.filesMgr.SetExportDefaults(formats)
+}
+
 func (e *Engine) Close() error {
 	if e == nil || e.indexDB == nil {
 		return nil
@@ -167,34 +175,62 @@ func (e *Engine) Apply(ctx context.Context, cfg index.SyncConfig, plan Plan, opt
 		LocalEntries: plan.Local,
 		RemoteEntries: plan.Remote,
 	}
-	state, summary, err := exec.Apply(ctx, reqCtx, plan.Actions, state, executor.Options{
+	state, summary, actions, err := exec.Apply(ctx, reqCtx, plan.Actions, state, executor.Options{
 		Concurrency: opts.Concurrency,
 		DryRun:      opts.DryRun,
 		Force:       opts.Force,
 		Yes:         opts.Yes,
 	})
+	result := Result{
+		Plan:    plan,
+		Summary: summary,
+		Actions: actions,
+	}
 	if err != nil {
-		return Result{}, err
+		return result, err
 	}
 
 	if !opts.DryRun {
 		newEntries := buildIndexEntries(cfg.ID, state.LocalEntries, state.RemoteEntries)
+		newEntries = keepConflictEntries(newEntries, plan.Conflicts, plan.Prev)
 		if err := e.indexDB.ReplaceEntries(ctx, cfg.ID, newEntries); err != nil {
-			return Result{}, err
+			return result, err
 		}
 		cfg.LastSyncTime = time.Now().Unix()
 		if plan.ChangeToken != "" {
 			cfg.LastChangeToken = plan.ChangeToken
 		}
 		if err := e.indexDB.UpsertConfig(ctx, cfg); err != nil {
-			return Result{}, err
+			return result, err
 		}
 	}
 
-	return Result{
-		Plan:    plan,
-		Summary: summary,
-	}, nil
+	return result, nil
+}
+
+// keepConflictEntries restores the previous index entries of unresolved
+// conflicts. Recording their current state would make both sides look
+// unchanged, and the conflict would silently disappear on the next run.
+func keepConflictEntries(entries []index.SyncEntry, conflicts []diff.Conflict, prev map[string]index.SyncEntry) []index.SyncEntry {
+	if len(conflicts) == 0 {
+		return entries
+	}
+	conflicted := make(map[string]struct{}, len(conflicts))
+	for _, c := range conflicts {
+		conflicted[c.Path] = struct{}{}
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if _, ok := conflicted[entry.RelativePath]; !ok {
+			kept = append(kept, entry)
+		}
+	}
+	for _, c := range conflicts {
+		if entry, ok := prev[c.Path]; ok {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
 }
 
 func buildIndexEntries(configID string, local map[string]scanner.LocalEntry, remote map[string]scanner.RemoteEntry) []index.SyncEntry {
@@ -258,6 +294,8 @@ func parsePolicy(value string) conflict.Policy {
 		return conflict.PolicyLocalWins
 	case "remote-wins":
 		return conflict.PolicyRemoteWins
+	case "skip":
+		return conflict.PolicySkip
 	default:
 		return conflict.PolicyRenameBoth
 	}
@@ -40,12 +40,62 @@ type State struct {
 }
 
 type Summary struct {
-	Uploads   int
-	Updates   int
-	Downloads int
-	Deletes   int
-	Moves     int
-	Mkdirs    int
+	Uploads   int `json:"uploads"`
+	Updates   int `json:"updates"`
+	Downloads int `json:"downloads"`
+	Deletes   int `json:"deletes"`
+	Moves     int `json:"moves"`
+	Mkdirs    int `json:"mkdirs"`
+	Failed    int `json:"failed"`
+}
+
+// Action outcomes
+const (
+	StatusDone    = "done"
+	StatusPlanned = "planned"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+)
+
+// ActionResult records what happened to one planned action
+type ActionResult struct {
+	Action diff.ActionType `json:"action"`
+	Path   string          `json:"path"`
+	From   string          `json:"from,omitempty"`
+	To     string          `json:"to,omitempty"`
+	FileID string          `json:"fileId,omitempty"`
+	Status string          `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// recorder collects action results from concurrent transfers
+type recorder struct {
+	mu      sync.Mutex
+	results []ActionResult
+}
+
+func (r *recorder) add(action diff.Action, fileID string, err error) {
+	result := ActionResult{
+		Action: action.Type,
+		Path:   action.Path,
+		From:   action.FromPath,
+		To:     action.ToPath,
+		FileID: fileID,
+		Status: StatusDone,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+func (r *recorder) skip(action diff.Action, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, ActionResult{Action: action.Type, Path: action.Path, Status: StatusSkipped, Error: reason})
 }
 
 func New(filesMgr *files.Manager, foldersMgr *folders.Manager) *Executor {
@@ -55,13 +105,34 @@ func New(filesMgr *files.Manager, foldersMgr *folders.Manager) *Executor {
 	}
 }
 
-func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, actions []diff.Action, state State, opts Options) (State, Summary, error) {
+// Apply carries out actions and returns the updated state, a summary and
+// the outcome of every action attempted. Under DryRun nothing is changed
+// and every action is reported as planned. Apply stops at the first
+// failure, which is reported with its error.
+func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, actions []diff.Action, state State, opts Options) (State, Summary, []ActionResult, error) {
+	state, summary, rec, err := e.apply(ctx, reqCtx, actions, state, opts)
+	if err != nil {
+		summary.Failed++
+	}
+	return state, summary, rec.results, err
+}
+
+func (e *Executor) apply(ctx context.Context, reqCtx *types.RequestContext, actions []diff.Action, state State, opts Options) (State, Summary, *recorder, error) {
 	summary := Summary{}
+	rec := &recorder{}
 	if opts.DryRun {
 		for _, action := range actions {
 			summary = addSummary(summary, action.Type)
+			rec.results = append(rec.results, ActionResult{
+				Action: action.Type,
+				Path:   action.Path,
+				From:   action.FromPath,
+				To:     action.ToPath,
+				FileID: remoteID(action),
+				Status: StatusPlanned,
+			})
 		}
-		return state, summary, nil
+		return state, summary, rec, nil
 	}
 
 	remoteFolders := make(map[string]string)
@@ -98,16 +169,20 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 
 	sortByDepth(mkdirRemote, true)
 	for _, action := range mkdirRemote {
-		if _, err := e.ensureRemoteFolder(ctx, reqCtx, remoteFolders, action.Path); err != nil {
-			return state, summary, err
+		id, err := e.ensureRemoteFolder(ctx, reqCtx, remoteFolders, action.Path)
+		rec.add(action, id, err)
+		if err != nil {
+			return state, summary, rec, err
 		}
 		summary = addSummary(summary, action.Type)
 	}
 
 	sortByDepth(mkdirLocal, true)
 	for _, action := range mkdirLocal {
-		if err := e.ensureLocalDir(state.LocalRoot, action.Path); err != nil {
-			return state, summary, err
+		err := e.ensureLocalDir(state.LocalRoot, action.Path)
+		rec.add(action, "", err)
+		if err != nil {
+			return state, summary, rec, err
 		}
 		if entry, ok := state.RemoteEntries[action.Path]; ok {
 			state.LocalEntries[action.Path] = scanner.LocalEntry{
@@ -121,15 +196,19 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 	}
 
 	for _, action := range moveRemote {
-		if err := e.applyMoveRemote(ctx, reqCtx, remoteFolders, state, action, opts); err != nil {
-			return state, summary, err
+		err := e.applyMoveRemote(ctx, reqCtx, remoteFolders, state, action, opts)
+		rec.add(action, remoteID(action), err)
+		if err != nil {
+			return state, summary, rec, err
 		}
 		summary = addSummary(summary, action.Type)
 	}
 
 	for _, action := range moveLocal {
-		if err := e.applyMoveLocal(state.LocalRoot, state.LocalEntries, action); err != nil {
-			return state, summary, err
+		err := e.applyMoveLocal(state.LocalRoot, state.LocalEntries, action)
+		rec.add(action, "", err)
+		if err != nil {
+			return state, summary, rec, err
 		}
 		summary = addSummary(summary, action.Type)
 	}
@@ -140,7 +219,7 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 			parentPath = ""
 		}
 		if _, err := e.ensureRemoteFolder(ctx, reqCtx, remoteFolders, parentPath); err != nil {
-			return state, summary, err
+			return state, summary, rec, err
 		}
 	}
 
@@ -150,7 +229,7 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 			parentPath = ""
 		}
 		if _, err := e.ensureRemoteFolder(ctx, reqCtx, remoteFolders, parentPath); err != nil {
-			return state, summary, err
+			return state, summary, rec, err
 		}
 	}
 
@@ -159,6 +238,7 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 	if err := runConcurrent(ctx, uploads, opts.Concurrency, func(action diff.Action) error {
 		localEntry := resolveLocalEntry(state.LocalEntries, action.Path, action.Local)
 		if localEntry == nil {
+			rec.skip(action, "local file no longer exists")
 			return nil
 		}
 		parentPath := path.Dir(action.Path)
@@ -171,8 +251,10 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 			Name:     action.Name,
 		})
 		if err != nil {
+			rec.add(action, "", err)
 			return err
 		}
+		rec.add(action, result.ID, nil)
 		transferMutex.Lock()
 		state.RemoteEntries[action.Path] = scanner.RemoteEntry{
 			RelativePath: action.Path,
@@ -187,7 +269,7 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 		transferMutex.Unlock()
 		return nil
 	}); err != nil {
-		return state, summary, err
+		return state, summary, rec, err
 	}
 	for range uploads {
 		summary = addSummary(summary, diff.ActionUpload)
@@ -197,9 +279,11 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 		localEntry := resolveLocalEntry(state.LocalEntries, action.Path, action.Local)
 		remoteEntry := resolveRemoteEntry(state.RemoteEntries, action.Path, action.Remote)
 		if localEntry == nil || remoteEntry == nil {
+			rec.skip(action, "file no longer exists")
 			return nil
 		}
 		result, err := e.files.UpdateContent(ctx, reqCtx, remoteEntry.ID, localEntry.AbsPath, files.UpdateContentOptions{})
+		rec.add(action, remoteEntry.ID, err)
 		if err != nil {
 			return err
 		}
@@ -217,7 +301,7 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 		transferMutex.Unlock()
 		return nil
 	}); err != nil {
-		return state, summary, err
+		return state, summary, rec, err
 	}
 	for range updates {
 		summary = addSummary(summary, diff.ActionUpdate)
@@ -225,19 +309,21 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 
 	for _, action := range downloads {
 		if err := e.ensureLocalDir(state.LocalRoot, path.Dir(action.Path)); err != nil {
-			return state, summary, err
+			return state, summary, rec, err
 		}
 	}
 
 	if err := runConcurrent(ctx, downloads, opts.Concurrency, func(action diff.Action) error {
 		remoteEntry := resolveRemoteEntry(state.RemoteEntries, action.Path, action.Remote)
 		if remoteEntry == nil {
+			rec.skip(action, "remote file no longer exists")
 			return nil
 		}
 		absPath := filepath.Join(state.LocalRoot, action.Path)
-		err := e.files.Download(ctx, reqCtx, remoteEntry.ID, files.DownloadOptions{
+		_, err := e.files.Download(ctx, reqCtx, remoteEntry.ID, files.DownloadOptions{
 			OutputPath: absPath,
 		})
+		rec.add(action, remoteEntry.ID, err)
 		if err != nil {
 			return err
 		}
@@ -262,7 +348,7 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 		transferMutex.Unlock()
 		return nil
 	}); err != nil {
-		return state, summary, err
+		return state, summary, rec, err
 	}
 	for range downloads {
 		summary = addSummary(summary, diff.ActionDownload)
@@ -279,30 +365,35 @@ func (e *Executor) Apply(ctx context.Context, reqCtx *types.RequestContext, acti
 		safetyOpts.Force = opts.Force
 		safetyOpts.Yes = opts.Yes
 		safetyOpts.Interactive = !opts.Force && !opts.Yes
-		confirmed, err := safety.ConfirmDestructive(items, "delete local files", safetyOpts)
+		confirmed, err := safety.ConfirmDestructive(items, "delete local files", safetyOpts.ForScope(safety.ScopeSync))
 		if err != nil {
-			return state, summary, err
+			return state, summary, rec, err
 		}
 		if !confirmed {
-			return state, summary, errors.New("operation cancelled by user")
+			return state, summary, rec, errors.New("operation cancelled by user")
 		}
 	}
 	for _, action := range deleteLocal {
-		if err := e.deleteLocal(state.LocalRoot, state.LocalEntries, action); err != nil {
-			return state, summary, err
+		err := e.deleteLocal(state.LocalRoot, state.LocalEntries, action)
+		rec.add(action, "", err)
+		if err != nil {
+			return state, summary, rec, err
 		}
 		summary = addSummary(summary, action.Type)
 	}
 
 	sortByDepth(deleteRemote, false)
 	for _, action := range deleteRemote {
-		if err := e.deleteRemote(ctx, reqCtx, state.RemoteEntries, action, opts); err != nil {
-			return state, summary, err
+		id := remoteID(action)
+		err := e.deleteRemote(ctx, reqCtx, state.RemoteEntries, action, opts)
+		rec.add(action, id, err)
+		if err != nil {
+			return state, summary, rec, err
 		}
 		summary = addSummary(summary, action.Type)
 	}
 
-	return state, summary, nil
+	return state, summary, rec, nil
 }
 
 func runConcurrent(ctx context.Context, actions []diff.Action, concurrency int, handler func(diff.Action) error) error {
@@ -592,3 +683,14 @@ func (e *Executor) deleteRemote(ctx context.Context, reqCtx *types.RequestContex
 	delete(remoteEntries, action.Path)
 	return nil
 }
+
+// remoteID returns the Drive file an action refers to, if known
+func remoteID(action diff.Action) string {
+	if action.Remote != nil {
+		return action.Remote.ID
+	}
+	if action.Prev != nil {
+		return action.Prev.DriveFileID
+	}
+	return ""
+}
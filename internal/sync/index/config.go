@@ -14,8 +14,8 @@ func (d *DB) UpsertConfig(ctx context.Context, cfg SyncConfig) error {
 
 	_, err = d.db.ExecContext(ctx, `
 		INSERT INTO sync_configs (
-			id, local_root, remote_root_id, exclude_patterns, conflict_policy, direction, last_sync_time, last_change_token
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			id, local_root, remote_root_id, exclude_patterns, conflict_policy, direction, last_sync_time, last_change_token, remote_drive_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			local_root=excluded.local_root,
 			remote_root_id=excluded.remote_root_id,
@@ -23,20 +23,21 @@ func (d *DB) UpsertConfig(ctx context.Context, cfg SyncConfig) error {
 			conflict_policy=excluded.conflict_policy,
 			direction=excluded.direction,
 			last_sync_time=excluded.last_sync_time,
-			last_change_token=excluded.last_change_token
-	`, cfg.ID, cfg.LocalRoot, cfg.RemoteRootID, string(patterns), cfg.ConflictPolicy, cfg.Direction, cfg.LastSyncTime, cfg.LastChangeToken)
+			last_change_token=excluded.last_change_token,
+			remote_drive_id=excluded.remote_drive_id
+	`, cfg.ID, cfg.LocalRoot, cfg.RemoteRootID, string(patterns), cfg.ConflictPolicy, cfg.Direction, cfg.LastSyncTime, cfg.LastChangeToken, cfg.RemoteDriveID)
 	return err
 }
 
 func (d *DB) GetConfig(ctx context.Context, id string) (*SyncConfig, error) {
 	row := d.db.QueryRowContext(ctx, `
-		SELECT id, local_root, remote_root_id, exclude_patterns, conflict_policy, direction, last_sync_time, last_change_token
+		SELECT id, local_root, remote_root_id, exclude_patterns, conflict_policy, direction, last_sync_time, last_change_token, remote_drive_id
 		FROM sync_configs WHERE id = ?
 	`, id)
 
 	var cfg SyncConfig
 	var patterns string
-	err := row.Scan(&cfg.ID, &cfg.LocalRoot, &cfg.RemoteRootID, &patterns, &cfg.ConflictPolicy, &cfg.Direction, &cfg.LastSyncTime, &cfg.LastChangeToken)
+	err := row.Scan(&cfg.ID, &cfg.LocalRoot, &cfg.RemoteRootID, &patterns, &cfg.ConflictPolicy, &cfg.Direction, &cfg.LastSyncTime, &cfg.LastChangeToken, &cfg.RemoteDriveID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +49,7 @@ func (d *DB) GetConfig(ctx context.Context, id string) (*SyncConfig, error) {
 
 func (d *DB) ListConfigs(ctx context.Context) ([]SyncConfig, error) {
 	rows, err := d.db.QueryContext(ctx, `
-		SELECT id, local_root, remote_root_id, exclude_patterns, conflict_policy, direction, last_sync_time, last_change_token
+		SELECT id, local_root, remote_root_id, exclude_patterns, conflict_policy, direction, last_sync_time, last_change_token, remote_drive_id
 		FROM sync_configs ORDER BY id
 	`)
 	if err != nil {
@@ -60,7 +61,7 @@ func (d *DB) ListConfigs(ctx context.Context) ([]SyncConfig, error) {
 	for rows.Next() {
 		var cfg SyncConfig
 		var patterns string
-		if err := rows.Scan(&cfg.ID, &cfg.LocalRoot, &cfg.RemoteRootID, &patterns, &cfg.ConflictPolicy, &cfg.Direction, &cfg.LastSyncTime, &cfg.LastChangeToken); err != nil {
+		if err := rows.Scan(&cfg.ID, &cfg.LocalRoot, &cfg.RemoteRootID, &patterns, &cfg.ConflictPolicy, &cfg.Direction, &cfg.LastSyncTime, &cfg.LastChangeToken, &cfg.RemoteDriveID); err != nil {
 			return nil, err
 		}
 		if patterns != "" {
@@ -3,12 +3,41 @@ package index
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
 	_ "modernc.org/sqlite"
 )
 
+// SchemaVersion is the sync index schema this release reads and writes,
+// kept in the database's user_version
+const SchemaVersion = 2
+
+// migrations[n] upgrades a version n index to n+1
+var migrations = map[int]string{
+	// Version 2 records the Shared Drive of the remote root
+	1: `ALTER TABLE sync_configs ADD COLUMN remote_drive_id TEXT NOT NULL DEFAULT ''`,
+}
+
+// StateKind versions the sync index
+var StateKind = state.Register(&state.Kind{
+	Name:        "sync-index",
+	Description: "Sync configurations and the last synced state of each file",
+	Current:     SchemaVersion,
+	DefaultPath: func() (string, error) {
+		dir, err := config.GetConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "sync", "index.db"), nil
+	},
+	FileName: "index.db",
+	Inspect:  inspectVersion,
+})
+
 type DB struct {
 	db *sql.DB
 }
@@ -41,9 +70,73 @@ func (d *DB) Close() error {
 	return d.db.Close()
 }
 
+// Migrate creates the schema and upgrades an older index. An index
+// written by a newer release is refused rather than modified.
 func (d *DB) Migrate(ctx context.Context) error {
-	_, err := d.db.ExecContext(ctx, schemaSQL)
-	return err
+	var version int
+	if err := d.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return state.TooNewError(StateKind, version)
+	}
+	if version == 0 {
+		// Unversioned: either a new database or one created before the
+		// index was versioned
+		var tables int
+		if err := d.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE name = 'sync_configs'").Scan(&tables); err != nil {
+			return err
+		}
+		if tables == 0 {
+			version = SchemaVersion
+		} else {
+			version = state.LegacyVersion
+		}
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for n := version; n < SchemaVersion; n++ {
+		if _, err := tx.ExecContext(ctx, migrations[n]); err != nil {
+			return fmt.Errorf("migrating sync index from version %d: %w", n, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, schemaSQL); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// inspectVersion reads the schema version of the index at path without
+// migrating it. Indexes created before versioning report the legacy
+// version.
+func inspectVersion(path string) (int, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	if version == 0 {
+		var tables int
+		if err := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+			return 0, err
+		}
+		if tables == 0 {
+			return 0, fmt.Errorf("not a sync index")
+		}
+		version = state.LegacyVersion
+	}
+	return version, nil
 }
 
 const schemaSQL = `
@@ -55,7 +148,8 @@ CREATE TABLE IF NOT EXISTS sync_configs (
 	conflict_policy TEXT NOT NULL,
 	direction TEXT NOT NULL,
 	last_sync_time INTEGER,
-	last_change_token TEXT
+	last_change_token TEXT,
+	remote_drive_id TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS sync_entries (
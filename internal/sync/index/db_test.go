@@ -0,0 +1,74 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/state"
+)
+
+func TestMigrateLegacyIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+
+	// An index created before versioning: no user_version, no drive column
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = legacy.Exec(`
+		CREATE TABLE sync_configs (
+			id TEXT PRIMARY KEY, local_root TEXT NOT NULL, remote_root_id TEXT NOT NULL,
+			exclude_patterns TEXT, conflict_policy TEXT NOT NULL, direction TEXT NOT NULL,
+			last_sync_time INTEGER, last_change_token TEXT
+		);
+		INSERT INTO sync_configs VALUES ('docs', '/tmp/docs', 'root1', '[]', 'rename-both', 'bidirectional', 0, '');
+	`)
+	_ = legacy.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := state.Verify(StateKind, path, false); r.Status != state.StatusMigrate || r.Version != 1 {
+		t.Errorf("before open: %+v", r)
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := db.GetConfig(context.Background(), "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RemoteRootID != "root1" || cfg.RemoteDriveID != "" {
+		t.Errorf("migrated config = %+v", cfg)
+	}
+	cfg.RemoteDriveID = "0AbC"
+	if err := db.UpsertConfig(context.Background(), *cfg); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	if r := state.Verify(StateKind, path, false); r.Status != state.StatusOK || r.Version != SchemaVersion {
+		t.Errorf("after open: %+v", r)
+	}
+}
+
+func TestOpenRejectsNewerIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	newer, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newer.Exec("CREATE TABLE sync_configs (id TEXT); PRAGMA user_version = 99")
+	_ = newer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Fatal("expected a newer index to be refused")
+	}
+}
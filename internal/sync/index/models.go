@@ -9,6 +9,9 @@ type SyncConfig struct {
 	Direction       string
 	LastSyncTime    int64
 	LastChangeToken string
+	// RemoteDriveID is the Shared Drive holding RemoteRootID; empty for
+	// My Drive
+	RemoteDriveID string
 }
 
 type SyncEntry struct {
@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"github.com/dl-alexandre/gdrv/internal/sync/diff"
+	"github.com/dl-alexandre/gdrv/internal/sync/executor"
+)
+
+// Report is the machine-readable record of a sync run: every action taken
+// (or, for a dry run, planned) and the conflicts left unresolved
+type Report struct {
+	ConfigID  string                  `json:"configId"`
+	Mode      diff.Mode               `json:"mode"`
+	DryRun    bool                    `json:"dryRun"`
+	Summary   executor.Summary        `json:"summary"`
+	Actions   []executor.ActionResult `json:"actions"`
+	Conflicts []ConflictInfo          `json:"conflicts"`
+}
+
+// ConflictInfo describes a path changed on both sides since the last sync
+type ConflictInfo struct {
+	Path               string            `json:"path"`
+	Kind               diff.ConflictKind `json:"kind"`
+	FileID             string            `json:"fileId,omitempty"`
+	LocalSize          int64             `json:"localSize,omitempty"`
+	LocalModifiedTime  int64             `json:"localModifiedTime,omitempty"`
+	LocalMD5           string            `json:"localMd5,omitempty"`
+	RemoteSize         int64             `json:"remoteSize,omitempty"`
+	RemoteModifiedTime string            `json:"remoteModifiedTime,omitempty"`
+	RemoteMD5          string            `json:"remoteMd5,omitempty"`
+}
+
+// NewReport builds the report of a plan and, once applied, its result.
+// A nil result reports every action in the plan as planned.
+func NewReport(configID string, mode diff.Mode, plan Plan, result *Result) *Report {
+	report := &Report{
+		ConfigID:  configID,
+		Mode:      mode,
+		Actions:   []executor.ActionResult{},
+		Conflicts: make([]ConflictInfo, 0, len(plan.Conflicts)),
+	}
+	if result != nil {
+		report.Summary = result.Summary
+		report.Actions = append(report.Actions, result.Actions...)
+	} else {
+		for _, action := range plan.Actions {
+			report.Actions = append(report.Actions, executor.ActionResult{
+				Action: action.Type,
+				Path:   action.Path,
+				From:   action.FromPath,
+				To:     action.ToPath,
+				Status: executor.StatusPlanned,
+			})
+			if action.Remote != nil {
+				report.Actions[len(report.Actions)-1].FileID = action.Remote.ID
+			}
+		}
+	}
+	for _, c := range plan.Conflicts {
+		info := ConflictInfo{Path: c.Path, Kind: c.Kind}
+		if c.Local != nil {
+			info.LocalSize = c.Local.Size
+			info.LocalModifiedTime = c.Local.ModTime
+			info.LocalMD5 = c.Local.Hash
+		}
+		if c.Remote != nil {
+			info.FileID = c.Remote.ID
+			info.RemoteSize = c.Remote.Size
+			info.RemoteModifiedTime = c.Remote.ModifiedTime
+			info.RemoteMD5 = c.Remote.MD5Checksum
+		}
+		report.Conflicts = append(report.Conflicts, info)
+	}
+	return report
+}
+
+func (r *Report) Headers() []string {
+	return []string{"Action", "Path", "Status"}
+}
+
+func (r *Report) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Actions)+len(r.Conflicts))
+	for _, a := range r.Actions {
+		p := a.Path
+		if a.From != "" && a.To != "" {
+			p = a.From + " -> " + a.To
+		}
+		status := a.Status
+		if a.Error != "" {
+			status += ": " + a.Error
+		}
+		rows = append(rows, []string{string(a.Action), p, status})
+	}
+	for _, c := range r.Conflicts {
+		rows = append(rows, []string{"conflict", c.Path, string(c.Kind)})
+	}
+	return rows
+}
+
+func (r *Report) EmptyMessage() string {
+	return "Already in sync"
+}
@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/sync/diff"
+	"github.com/dl-alexandre/gdrv/internal/sync/executor"
+	"github.com/dl-alexandre/gdrv/internal/sync/index"
+	"github.com/dl-alexandre/gdrv/internal/sync/scanner"
+)
+
+func TestNewReport(t *testing.T) {
+	plan := Plan{
+		Actions: []diff.Action{
+			{Type: diff.ActionUpload, Path: "new.txt"},
+			{Type: diff.ActionDeleteRemote, Path: "old.txt", Remote: &scanner.RemoteEntry{ID: "f9"}},
+		},
+		Conflicts: []diff.Conflict{{
+			Path:   "a.txt",
+			Kind:   diff.ConflictBothModified,
+			Local:  &scanner.LocalEntry{Size: 3, ModTime: 100, Hash: "l"},
+			Remote: &scanner.RemoteEntry{ID: "f1", Size: 4, ModifiedTime: "2026-01-02T03:04:05Z", MD5Checksum: "r"},
+		}},
+	}
+
+	planned := NewReport("cfg", diff.ModeBidirectional, plan, nil)
+	if len(planned.Actions) != 2 || planned.Actions[1].Status != executor.StatusPlanned || planned.Actions[1].FileID != "f9" {
+		t.Errorf("planned actions = %+v", planned.Actions)
+	}
+	want := ConflictInfo{
+		Path: "a.txt", Kind: diff.ConflictBothModified, FileID: "f1",
+		LocalSize: 3, LocalModifiedTime: 100, LocalMD5: "l",
+		RemoteSize: 4, RemoteModifiedTime: "2026-01-02T03:04:05Z", RemoteMD5: "r",
+	}
+	if len(planned.Conflicts) != 1 || planned.Conflicts[0] != want {
+		t.Errorf("conflicts = %+v", planned.Conflicts)
+	}
+	if rows := planned.Rows(); len(rows) != 3 || rows[2][0] != "conflict" {
+		t.Errorf("rows = %v", rows)
+	}
+
+	result := &Result{
+		Summary: executor.Summary{Uploads: 1, Failed: 1},
+		Actions: []executor.ActionResult{
+			{Action: diff.ActionUpload, Path: "new.txt", Status: executor.StatusDone},
+			{Action: diff.ActionDeleteRemote, Path: "old.txt", Status: executor.StatusFailed, Error: "forbidden"},
+		},
+	}
+	applied := NewReport("cfg", diff.ModeBidirectional, plan, result)
+	if !reflect.DeepEqual(applied.Actions, result.Actions) || applied.Summary != result.Summary {
+		t.Errorf("applied report = %+v", applied)
+	}
+	if rows := applied.Rows(); rows[1][2] != "failed: forbidden" {
+		t.Errorf("rows = %v", rows)
+	}
+
+	if empty := NewReport("cfg", diff.ModePush, Plan{}, nil); len(empty.Rows()) != 0 || empty.EmptyMessage() != "Already in sync" {
+		t.Errorf("empty report = %+v", empty)
+	}
+}
+
+func TestKeepConflictEntries(t *testing.T) {
+	entries := []index.SyncEntry{
+		{RelativePath: "a.txt", ContentHash: "current"},
+		{RelativePath: "b.txt", ContentHash: "current"},
+		{RelativePath: "c.txt", ContentHash: "current"},
+	}
+	conflicts := []diff.Conflict{{Path: "a.txt"}, {Path: "c.txt"}}
+	prev := map[string]index.SyncEntry{"a.txt": {RelativePath: "a.txt", ContentHash: "previous"}}
+
+	got := keepConflictEntries(entries, conflicts, prev)
+	hashes := map[string]string{}
+	for _, e := range got {
+		hashes[e.RelativePath] = e.ContentHash
+	}
+	// a.txt keeps its previous entry; c.txt had none, so it is dropped
+	want := map[string]string{"a.txt": "previous", "b.txt": "current"}
+	if !reflect.DeepEqual(hashes, want) {
+		t.Errorf("entries = %v, want %v", hashes, want)
+	}
+}
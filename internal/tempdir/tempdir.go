@@ -0,0 +1,172 @@
+// Package tempdir gives each run a single sandbox directory for temporary
+// artifacts such as partial downloads, encryption and restore spools, and
+// spilled results. The directory is created on first use and removed by
+// Cleanup when the run ends, unless it is kept for debugging.
+//
+// Downloads are written to a Partial in the sandbox and moved to their
+// destination only once complete, so a failed run never leaves truncated
+// files in the working directory.
+package tempdir
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prefix names sandbox directories under the system temp directory
+const prefix = "gdrv-run-"
+
+// staleAge is how old another run's sandbox must be before it is treated as
+// abandoned (e.g. the process was killed) and removed
+const staleAge = 24 * time.Hour
+
+var (
+	mu   sync.Mutex
+	dir  string
+	keep bool
+)
+
+// SetKeep controls whether Cleanup leaves the sandbox in place
+func SetKeep(k bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	keep = k
+}
+
+// Dir returns the sandbox directory, creating it on first use
+func Dir() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if dir != "" {
+		return dir, nil
+	}
+	root := os.TempDir()
+	sweepStale(root)
+	d, err := os.MkdirTemp(root, prefix+"*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	dir = d
+	return dir, nil
+}
+
+// CreateTemp creates a temp file in the sandbox, as os.CreateTemp
+func CreateTemp(pattern string) (*os.File, error) {
+	d, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(d, pattern)
+}
+
+// Cleanup removes the sandbox. When keeping temp files it leaves the
+// directory and returns its path instead; otherwise it returns "".
+func Cleanup() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if dir == "" {
+		return "", nil
+	}
+	if keep {
+		return dir, nil
+	}
+	err := os.RemoveAll(dir)
+	dir = ""
+	return "", err
+}
+
+// sweepStale removes sandboxes left behind by runs that did not clean up
+func sweepStale(root string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-staleAge)
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			_ = os.RemoveAll(filepath.Join(root, e.Name()))
+		}
+	}
+}
+
+// Partial is a file written in the sandbox and moved to its destination by
+// Commit. Until then the destination is untouched.
+type Partial struct {
+	*os.File
+	dest string
+	done bool
+}
+
+// CreatePartial starts writing a file that will end up at dest
+func CreatePartial(dest string) (*Partial, error) {
+	if info, err := os.Stat(filepath.Dir(dest)); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", filepath.Dir(dest))
+	}
+	f, err := CreateTemp("partial-*-" + filepath.Base(dest))
+	if err != nil {
+		return nil, err
+	}
+	return &Partial{File: f, dest: dest}, nil
+}
+
+// Commit closes the file and moves it to its destination, replacing any
+// existing file
+func (p *Partial) Commit() error {
+	if p.done {
+		return nil
+	}
+	p.done = true
+	tmp := p.Name()
+	defer os.Remove(tmp)
+	if err := p.File.Close(); err != nil {
+		return err
+	}
+	// Temp files are private; the output should look like any other file
+	if err := os.Chmod(tmp, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p.dest); err == nil {
+		return nil
+	}
+	// The sandbox may be on another filesystem
+	return copyFile(tmp, p.dest)
+}
+
+// Discard closes and removes the file. It does nothing after Commit, so it
+// can be deferred.
+func (p *Partial) Discard() {
+	if p.done {
+		return
+	}
+	p.done = true
+	_ = p.File.Close()
+	_ = os.Remove(p.Name())
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,146 @@
+package tempdir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useRoot points the sandbox at a fresh temp root for one test
+func useRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("TMPDIR", root)
+	t.Cleanup(func() {
+		SetKeep(false)
+		_, _ = Cleanup()
+	})
+	return root
+}
+
+func TestDirCreatedOnceAndCleanedUp(t *testing.T) {
+	root := useRoot(t)
+
+	d1, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, _ := Dir()
+	if d1 != d2 || filepath.Dir(d1) != root || !strings.HasPrefix(filepath.Base(d1), prefix) {
+		t.Fatalf("unexpected sandbox %s, %s", d1, d2)
+	}
+	f, err := CreateTemp("spool-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if kept, err := Cleanup(); err != nil || kept != "" {
+		t.Fatalf("Cleanup = %q, %v", kept, err)
+	}
+	if _, err := os.Stat(d1); !os.IsNotExist(err) {
+		t.Fatalf("sandbox not removed: %v", err)
+	}
+}
+
+func TestKeep(t *testing.T) {
+	useRoot(t)
+	SetKeep(true)
+	d, _ := Dir()
+	kept, err := Cleanup()
+	if err != nil || kept != d {
+		t.Fatalf("Cleanup = %q, %v; want %q", kept, err, d)
+	}
+	if _, err := os.Stat(d); err != nil {
+		t.Fatalf("kept sandbox missing: %v", err)
+	}
+}
+
+func TestSweepStale(t *testing.T) {
+	root := useRoot(t)
+	stale := filepath.Join(root, prefix+"old")
+	recent := filepath.Join(root, prefix+"recent")
+	other := filepath.Join(root, "unrelated")
+	for _, d := range []string{stale, recent, other} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * staleAge)
+	_ = os.Chtimes(stale, old, old)
+	_ = os.Chtimes(other, old, old)
+
+	if _, err := Dir(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale sandbox not removed")
+	}
+	for _, d := range []string{recent, other} {
+		if _, err := os.Stat(d); err != nil {
+			t.Errorf("%s removed: %v", d, err)
+		}
+	}
+}
+
+func TestPartial(t *testing.T) {
+	useRoot(t)
+	out := t.TempDir()
+
+	dest := filepath.Join(out, "report.pdf")
+	p, err := CreatePartial(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.WriteString("content"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatal("destination written before commit")
+	}
+	if err := p.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	p.Discard()
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "content" {
+		t.Fatalf("dest = %q, %v", data, err)
+	}
+	if _, err := os.Stat(p.Name()); !os.IsNotExist(err) {
+		t.Error("partial left in sandbox")
+	}
+
+	failed := filepath.Join(out, "failed.bin")
+	p, err = CreatePartial(failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = p.WriteString("trunc")
+	p.Discard()
+	if _, err := os.Stat(failed); !os.IsNotExist(err) {
+		t.Error("discarded partial reached its destination")
+	}
+	if _, err := os.Stat(p.Name()); !os.IsNotExist(err) {
+		t.Error("discarded partial left in sandbox")
+	}
+
+	if _, err := CreatePartial(filepath.Join(out, "missing", "x")); err == nil {
+		t.Error("expected error for missing destination directory")
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	if err := os.WriteFile(src, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyFile(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "data" {
+		t.Fatalf("dest = %q", data)
+	}
+}
@@ -0,0 +1,288 @@
+// Package testenv provisions a disposable sandbox in a real Google Drive for
+// end-to-end tests. A sandbox authenticates with a service account, creates
+// a uniquely named folder (and, when enabled, a Shared Drive) for one test,
+// and removes everything it created when the test finishes. Tests using it
+// are skipped unless GDRV_TEST_SERVICE_ACCOUNT is set.
+package testenv
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/auth"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Environment variables read by FromEnv
+const (
+	EnvServiceAccount = "GDRV_TEST_SERVICE_ACCOUNT" // Path to a service account key file (required)
+	EnvSubject        = "GDRV_TEST_SUBJECT"         // User to impersonate through domain-wide delegation
+	EnvParentID       = "GDRV_TEST_PARENT_ID"       // Folder to create sandboxes in (default: the account's My Drive)
+	EnvSharedDrives   = "GDRV_TEST_SHARED_DRIVES"   // Set to 1 to also create a disposable Shared Drive
+	EnvGrantee        = "GDRV_TEST_GRANTEE"         // User or group email to share with in permission tests
+	EnvLabelID        = "GDRV_TEST_LABEL_ID"        // Published label to apply in label tests
+)
+
+// NamePrefix starts the name of every folder and Shared Drive a sandbox
+// creates, so leftovers from interrupted runs can be found and swept
+const NamePrefix = "gdrv-it-"
+
+// StaleAfter is how old a leftover sandbox must be before Sweep removes it,
+// so concurrent runs do not remove each other's sandboxes
+const StaleAfter = 6 * time.Hour
+
+// Config describes the account and resources end-to-end tests run against
+type Config struct {
+	ServiceAccountFile string
+	Subject            string
+	ParentID           string
+	SharedDrives       bool
+	Grantee            string
+	LabelID            string
+}
+
+// FromEnv reads the sandbox configuration from the environment. ok is false
+// when no service account is configured.
+func FromEnv() (cfg Config, ok bool) {
+	cfg = Config{
+		ServiceAccountFile: os.Getenv(EnvServiceAccount),
+		Subject:            os.Getenv(EnvSubject),
+		ParentID:           os.Getenv(EnvParentID),
+		Grantee:            os.Getenv(EnvGrantee),
+		LabelID:            os.Getenv(EnvLabelID),
+	}
+	cfg.SharedDrives, _ = strconv.ParseBool(os.Getenv(EnvSharedDrives))
+	return cfg, cfg.ServiceAccountFile != ""
+}
+
+// Scopes are the OAuth scopes a sandbox requests
+var Scopes = []string{utils.ScopeFull, utils.ScopeLabels}
+
+// Sandbox is a disposable folder, and optionally a Shared Drive, in Drive
+type Sandbox struct {
+	Config
+	Client *api.Client
+	// Prefix is unique to this sandbox; names built with Name start with it
+	Prefix   string
+	FolderID string
+	// DriveID is the disposable Shared Drive, empty unless SharedDrives is set
+	DriveID string
+}
+
+var sweepOnce sync.Once
+
+// New provisions a sandbox for t and registers its teardown with
+// t.Cleanup. It skips t when no service account is configured and fails it
+// when the sandbox cannot be created. The first sandbox of a run also
+// sweeps sandboxes left behind by earlier runs.
+func New(t testing.TB) *Sandbox {
+	t.Helper()
+	cfg, ok := FromEnv()
+	if !ok {
+		t.Skipf("%s not set; skipping end-to-end test", EnvServiceAccount)
+	}
+	ctx := context.Background()
+
+	client, err := newClient(ctx, t.TempDir(), cfg)
+	if err != nil {
+		t.Fatalf("testenv: %v", err)
+	}
+	s := &Sandbox{Config: cfg, Client: client, Prefix: newPrefix(time.Now())}
+
+	sweepOnce.Do(func() {
+		if n, err := s.Sweep(ctx, time.Now().Add(-StaleAfter)); err != nil {
+			t.Logf("testenv: sweeping old sandboxes: %v", err)
+		} else if n > 0 {
+			t.Logf("testenv: removed %d sandboxes left by earlier runs", n)
+		}
+	})
+
+	t.Cleanup(func() {
+		if err := s.Teardown(context.Background()); err != nil {
+			t.Errorf("testenv: teardown of %s: %v", s.Prefix, err)
+		}
+	})
+
+	folder := &drive.File{Name: s.Prefix, MimeType: utils.MimeTypeFolder}
+	if cfg.ParentID != "" {
+		folder.Parents = []string{cfg.ParentID}
+	}
+	created, err := client.Service().Files.Create(folder).SupportsAllDrives(true).Fields("id").Context(ctx).Do()
+	if err != nil {
+		t.Fatalf("testenv: creating sandbox folder: %v", err)
+	}
+	s.FolderID = created.Id
+
+	if cfg.SharedDrives {
+		sharedDrive, err := client.Service().Drives.Create(s.Prefix, &drive.Drive{Name: s.Prefix}).Fields("id").Context(ctx).Do()
+		if err != nil {
+			t.Fatalf("testenv: creating sandbox Shared Drive: %v", err)
+		}
+		s.DriveID = sharedDrive.Id
+	}
+	return s
+}
+
+func newClient(ctx context.Context, configDir string, cfg Config) (*api.Client, error) {
+	// Plain file storage keeps the run away from the system keyring; the
+	// credentials are never saved
+	authMgr := auth.NewManagerWithOptions(configDir, auth.ManagerOptions{ForcePlainFile: true})
+	creds, err := authMgr.LoadServiceAccount(ctx, cfg.ServiceAccountFile, Scopes, cfg.Subject)
+	if err != nil {
+		return nil, err
+	}
+	service, err := authMgr.GetDriveService(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewClient(service, utils.DefaultMaxRetries, utils.DefaultRetryDelayMs, nil), nil
+}
+
+func newPrefix(now time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return NamePrefix + now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// Name returns a name unique to this sandbox
+func (s *Sandbox) Name(name string) string {
+	return s.Prefix + "-" + name
+}
+
+// RequestContext returns a request context for calls made in the sandbox
+func (s *Sandbox) RequestContext(requestType types.RequestType) *types.RequestContext {
+	return api.NewRequestContext("testenv", "", requestType)
+}
+
+// RequireSharedDrive skips t unless the sandbox has a Shared Drive
+func (s *Sandbox) RequireSharedDrive(t testing.TB) {
+	t.Helper()
+	if s.DriveID == "" {
+		t.Skipf("%s not set; skipping Shared Drive test", EnvSharedDrives)
+	}
+}
+
+// RequireGrantee skips t unless a grantee is configured, and returns it
+func (s *Sandbox) RequireGrantee(t testing.TB) string {
+	t.Helper()
+	if s.Grantee == "" {
+		t.Skipf("%s not set; skipping permission test", EnvGrantee)
+	}
+	return s.Grantee
+}
+
+// RequireLabel skips t unless a label is configured, and returns it
+func (s *Sandbox) RequireLabel(t testing.TB) string {
+	t.Helper()
+	if s.LabelID == "" {
+		t.Skipf("%s not set; skipping label test", EnvLabelID)
+	}
+	return s.LabelID
+}
+
+// Teardown permanently deletes the sandbox folder and Shared Drive with
+// everything in them. It is registered by New and safe to call again.
+func (s *Sandbox) Teardown(ctx context.Context) error {
+	var errs []error
+	if s.FolderID != "" {
+		if err := s.deleteFile(ctx, s.FolderID); err != nil {
+			errs = append(errs, err)
+		} else {
+			s.FolderID = ""
+		}
+	}
+	if s.DriveID != "" {
+		if err := s.deleteDrive(ctx, s.DriveID); err != nil {
+			errs = append(errs, err)
+		} else {
+			s.DriveID = ""
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sweep removes sandbox folders and Shared Drives created before cutoff,
+// left behind by runs that were killed before their teardown, and returns
+// how many it removed
+func (s *Sandbox) Sweep(ctx context.Context, cutoff time.Time) (int, error) {
+	svc := s.Client.Service()
+	created := cutoff.UTC().Format(time.RFC3339)
+	removed := 0
+
+	q := fmt.Sprintf("name contains '%s' and mimeType = '%s' and createdTime < '%s' and trashed = false",
+		NamePrefix, utils.MimeTypeFolder, created)
+	err := svc.Files.List().Q(q).Fields("nextPageToken,files(id,name)").
+		SupportsAllDrives(true).IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(list *drive.FileList) error {
+			for _, f := range list.Files {
+				if err := s.deleteFile(ctx, f.Id); err != nil {
+					return err
+				}
+				removed++
+			}
+			return nil
+		})
+	if err != nil {
+		return removed, err
+	}
+
+	err = svc.Drives.List().Q(fmt.Sprintf("name contains '%s'", NamePrefix)).Fields("nextPageToken,drives(id,name,createdTime)").
+		Pages(ctx, func(list *drive.DriveList) error {
+			for _, d := range list.Drives {
+				createdAt, err := time.Parse(time.RFC3339, d.CreatedTime)
+				if err != nil || !createdAt.Before(cutoff) {
+					continue
+				}
+				if err := s.deleteDrive(ctx, d.Id); err != nil {
+					return err
+				}
+				removed++
+			}
+			return nil
+		})
+	return removed, err
+}
+
+// deleteFile permanently deletes a file or folder. A file that is already
+// gone, such as one in a folder deleted earlier, is not an error.
+func (s *Sandbox) deleteFile(ctx context.Context, fileID string) error {
+	err := s.Client.Service().Files.Delete(fileID).SupportsAllDrives(true).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// deleteDrive empties a Shared Drive and deletes it; Drive refuses to
+// delete a Shared Drive that still holds items
+func (s *Sandbox) deleteDrive(ctx context.Context, driveID string) error {
+	svc := s.Client.Service()
+	err := svc.Files.List().Q(fmt.Sprintf("'%s' in parents", driveID)).Fields("nextPageToken,files(id)").
+		Corpora("drive").DriveId(driveID).SupportsAllDrives(true).IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(list *drive.FileList) error {
+			for _, f := range list.Files {
+				if err := s.deleteFile(ctx, f.Id); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	return svc.Drives.Delete(driveID).Context(ctx).Do()
+}
@@ -0,0 +1,34 @@
+package testenv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvServiceAccount, "")
+	if _, ok := FromEnv(); ok {
+		t.Error("FromEnv without a service account should not be ok")
+	}
+
+	t.Setenv(EnvServiceAccount, "/keys/sa.json")
+	t.Setenv(EnvSharedDrives, "1")
+	t.Setenv(EnvGrantee, "qa@example.com")
+	cfg, ok := FromEnv()
+	if !ok || cfg.ServiceAccountFile != "/keys/sa.json" || !cfg.SharedDrives || cfg.Grantee != "qa@example.com" {
+		t.Errorf("FromEnv() = %+v, %v", cfg, ok)
+	}
+}
+
+func TestNewPrefix(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	a, b := newPrefix(now), newPrefix(now)
+	if !strings.HasPrefix(a, NamePrefix+"20260304T050607-") || a == b {
+		t.Errorf("prefixes %q and %q should share the timestamp and differ", a, b)
+	}
+	s := &Sandbox{Prefix: a}
+	if name := s.Name("report.txt"); name != a+"-report.txt" {
+		t.Errorf("Name() = %q", name)
+	}
+}
@@ -0,0 +1,28 @@
+package testing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// NewDriveClient serves handler from a test server and returns an API
+// client whose Drive service sends its requests there, with retries off.
+// Handlers see Drive API paths such as /drive/v3/files/{id}. The server is
+// closed when the test ends.
+func NewDriveClient(t *testing.T, handler http.Handler) (*api.Client, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	service, err := drive.NewService(context.Background(),
+		option.WithEndpoint(server.URL+"/drive/v3/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api.NewClient(service, 0, 100, nil), server
+}
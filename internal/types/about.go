@@ -0,0 +1,174 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format matrix sources
+const (
+	FormatSourceAPI     = "api"
+	FormatSourceBuiltin = "builtin"
+)
+
+// FormatMatrix holds the import and export conversions Drive supports, as
+// reported by about.get. ImportFormats maps an uploaded MIME type to the
+// Workspace types it can be converted to; ExportFormats maps a Workspace
+// type to the MIME types it can be exported as.
+type FormatMatrix struct {
+	Source        string              `json:"source"`
+	ImportFormats map[string][]string `json:"importFormats"`
+	ExportFormats map[string][]string `json:"exportFormats"`
+}
+
+// ExportTargets returns the export MIME types for a Workspace type
+func (m *FormatMatrix) ExportTargets(sourceMimeType string) []string {
+	return m.ExportFormats[sourceMimeType]
+}
+
+// ImportTargets returns the Workspace types an uploaded MIME type can be
+// converted to
+func (m *FormatMatrix) ImportTargets(sourceMimeType string) []string {
+	return m.ImportFormats[sourceMimeType]
+}
+
+// Filter returns a matrix containing only the given source MIME type
+func (m *FormatMatrix) Filter(sourceMimeType string) *FormatMatrix {
+	filtered := &FormatMatrix{
+		Source:        m.Source,
+		ImportFormats: map[string][]string{},
+		ExportFormats: map[string][]string{},
+	}
+	if targets, ok := m.ImportFormats[sourceMimeType]; ok {
+		filtered.ImportFormats[sourceMimeType] = targets
+	}
+	if targets, ok := m.ExportFormats[sourceMimeType]; ok {
+		filtered.ExportFormats[sourceMimeType] = targets
+	}
+	return filtered
+}
+
+func (m *FormatMatrix) Headers() []string {
+	return []string{"Direction", "Source Type", "Target Types"}
+}
+
+func (m *FormatMatrix) Rows() [][]string {
+	var rows [][]string
+	for _, section := range []struct {
+		direction string
+		formats   map[string][]string
+	}{
+		{"import", m.ImportFormats},
+		{"export", m.ExportFormats},
+	} {
+		sources := make([]string, 0, len(section.formats))
+		for source := range section.formats {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			rows = append(rows, []string{section.direction, source, strings.Join(section.formats[source], ", ")})
+		}
+	}
+	return rows
+}
+
+func (m *FormatMatrix) EmptyMessage() string {
+	return "No conversions found"
+}
+
+// AboutAccount is the authenticated account as reported by about.get
+type AboutAccount struct {
+	User          AboutUser           `json:"user"`
+	StorageQuota  StorageQuota        `json:"storageQuota"`
+	MaxUploadSize int64               `json:"maxUploadSize"`
+	ImportFormats map[string][]string `json:"importFormats,omitempty"`
+	ExportFormats map[string][]string `json:"exportFormats,omitempty"`
+}
+
+// AboutUser identifies the authenticated user
+type AboutUser struct {
+	DisplayName  string `json:"displayName,omitempty"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	PermissionID string `json:"permissionId,omitempty"`
+}
+
+// StorageQuota is the account's storage use in bytes. Usage covers Drive,
+// Gmail and Photos; UsageInDrive and UsageInDriveTrash are the Drive part
+// of it. Limit is 0 when storage is unlimited, and UsedPercent is then
+// omitted.
+type StorageQuota struct {
+	Limit             int64    `json:"limit"`
+	Usage             int64    `json:"usage"`
+	UsageInDrive      int64    `json:"usageInDrive"`
+	UsageInDriveTrash int64    `json:"usageInDriveTrash"`
+	UsedPercent       *float64 `json:"usedPercent,omitempty"`
+}
+
+// quotaRows renders the quota as field/value rows
+func (q StorageQuota) quotaRows() [][]string {
+	limit := "unlimited"
+	if q.Limit > 0 {
+		limit = DisplaySize(q.Limit)
+	}
+	usage := DisplaySize(q.Usage)
+	if q.UsedPercent != nil {
+		usage += fmt.Sprintf(" (%.1f%%)", *q.UsedPercent)
+	}
+	return [][]string{
+		{"Storage limit", limit},
+		{"Usage", usage},
+		{"Usage in Drive", DisplaySize(q.UsageInDrive)},
+		{"Usage in Drive trash", DisplaySize(q.UsageInDriveTrash)},
+		{"Usage outside Drive", DisplaySize(q.Usage - q.UsageInDrive)},
+	}
+}
+
+func (a *AboutAccount) Headers() []string {
+	return []string{"Field", "Value"}
+}
+
+func (a *AboutAccount) Rows() [][]string {
+	rows := [][]string{
+		{"User", a.User.DisplayName},
+		{"Email", a.User.EmailAddress},
+	}
+	rows = append(rows, a.StorageQuota.quotaRows()...)
+	return append(rows,
+		[]string{"Max upload size", DisplaySize(a.MaxUploadSize)},
+		[]string{"Import formats", DisplayCount(int64(len(a.ImportFormats))) + " source types"},
+		[]string{"Export formats", DisplayCount(int64(len(a.ExportFormats))) + " source types"},
+	)
+}
+
+func (a *AboutAccount) EmptyMessage() string {
+	return "No account information"
+}
+
+// QuotaReport is the storage quota with the result of a threshold check
+type QuotaReport struct {
+	EmailAddress string `json:"emailAddress,omitempty"`
+	StorageQuota
+	// Threshold is the usage percentage checked against, 0 when not checked
+	Threshold float64 `json:"threshold,omitempty"`
+	Exceeded  bool    `json:"exceeded"`
+}
+
+func (r *QuotaReport) Headers() []string {
+	return []string{"Field", "Value"}
+}
+
+func (r *QuotaReport) Rows() [][]string {
+	rows := append([][]string{{"Email", r.EmailAddress}}, r.StorageQuota.quotaRows()...)
+	if r.Threshold > 0 {
+		rows = append(rows, []string{"Threshold", fmt.Sprintf("%.1f%%", r.Threshold)},
+			[]string{"Exceeded", strconv.FormatBool(r.Exceeded)})
+	}
+	return rows
+}
+
+func (r *QuotaReport) EmptyMessage() string {
+	return "No quota information"
+}
@@ -1,6 +1,10 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type User struct {
 	ID               string   `json:"id"`
@@ -67,6 +71,64 @@ func (r *UsersListResponse) EmptyMessage() string {
 	return "No users found"
 }
 
+// UserAlias is an alternate email address of a user
+type UserAlias struct {
+	Alias        string `json:"alias"`
+	PrimaryEmail string `json:"primaryEmail"`
+	ID           string `json:"id,omitempty"`
+}
+
+type UserAliasesResponse struct {
+	User    string      `json:"user"`
+	Aliases []UserAlias `json:"aliases"`
+}
+
+func (r *UserAliasesResponse) Headers() []string {
+	return []string{"Alias", "Primary Email"}
+}
+
+func (r *UserAliasesResponse) Rows() [][]string {
+	rows := make([][]string, len(r.Aliases))
+	for i, alias := range r.Aliases {
+		rows[i] = []string{alias.Alias, alias.PrimaryEmail}
+	}
+	return rows
+}
+
+func (r *UserAliasesResponse) EmptyMessage() string {
+	return "No aliases found"
+}
+
+// UserPhoto is a user's profile photo. The Directory API stores photos at
+// 96x96 pixels; Path is where the image was written locally, if anywhere.
+type UserPhoto struct {
+	PrimaryEmail string `json:"primaryEmail"`
+	MimeType     string `json:"mimeType"`
+	Width        int64  `json:"width"`
+	Height       int64  `json:"height"`
+	Size         int    `json:"size"`
+	Path         string `json:"path,omitempty"`
+}
+
+func (p *UserPhoto) Headers() []string {
+	return []string{"User", "Type", "Width", "Height", "Bytes", "Path"}
+}
+
+func (p *UserPhoto) Rows() [][]string {
+	return [][]string{{
+		p.PrimaryEmail,
+		p.MimeType,
+		strconv.FormatInt(p.Width, 10),
+		strconv.FormatInt(p.Height, 10),
+		strconv.Itoa(p.Size),
+		p.Path,
+	}}
+}
+
+func (p *UserPhoto) EmptyMessage() string {
+	return "No photo set"
+}
+
 type Group struct {
 	ID                 string `json:"id"`
 	Email              string `json:"email"`
@@ -161,3 +223,101 @@ type AddMemberRequest struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
 }
+
+// Domain is a Workspace domain with its aliases
+type Domain struct {
+	Name     string   `json:"name"`
+	Primary  bool     `json:"primary,omitempty"`
+	Verified bool     `json:"verified"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+type DomainsListResponse struct {
+	Domains []Domain `json:"domains"`
+}
+
+// Names returns every verified domain and alias name
+func (r *DomainsListResponse) Names() []string {
+	var names []string
+	for _, d := range r.Domains {
+		if d.Verified {
+			names = append(names, d.Name)
+		}
+		names = append(names, d.Aliases...)
+	}
+	return names
+}
+
+func (r *DomainsListResponse) Headers() []string {
+	return []string{"Domain", "Primary", "Verified", "Aliases"}
+}
+
+func (r *DomainsListResponse) Rows() [][]string {
+	rows := make([][]string, len(r.Domains))
+	for i, d := range r.Domains {
+		rows[i] = []string{
+			d.Name,
+			fmt.Sprintf("%t", d.Primary),
+			fmt.Sprintf("%t", d.Verified),
+			strings.Join(d.Aliases, ", "),
+		}
+	}
+	return rows
+}
+
+func (r *DomainsListResponse) EmptyMessage() string {
+	return "No domains found"
+}
+
+// UserOwnership aggregates the My Drive content one user owns
+type UserOwnership struct {
+	Email         string `json:"email"`
+	Files         int    `json:"files"`
+	Folders       int    `json:"folders"`
+	Bytes         int64  `json:"bytes"`
+	SharedItems   int    `json:"sharedItems"`
+	ExternalItems int    `json:"externalItems"`
+	PublicItems   int    `json:"publicItems"`
+	Error         string `json:"error,omitempty"`
+}
+
+// OwnershipReport is a domain-wide sweep of content owned outside Shared
+// Drives, ordered to prioritize migration
+type OwnershipReport struct {
+	Domain          string           `json:"domain"`
+	InternalDomains []string         `json:"internalDomains"`
+	SortBy          string           `json:"sortBy"`
+	UsersScanned    int              `json:"usersScanned"`
+	UsersFailed     int              `json:"usersFailed"`
+	TotalFiles      int              `json:"totalFiles"`
+	TotalFolders    int              `json:"totalFolders"`
+	TotalBytes      int64            `json:"totalBytes"`
+	ExternalItems   int              `json:"externalItems"`
+	PublicItems     int              `json:"publicItems"`
+	Users           []*UserOwnership `json:"users"`
+}
+
+func (r *OwnershipReport) Headers() []string {
+	return []string{"User", "Files", "Folders", "Bytes", "Shared", "External", "Public", "Error"}
+}
+
+func (r *OwnershipReport) Rows() [][]string {
+	rows := make([][]string, len(r.Users))
+	for i, u := range r.Users {
+		rows[i] = []string{
+			u.Email,
+			DisplayCount(int64(u.Files)),
+			DisplayCount(int64(u.Folders)),
+			DisplaySize(u.Bytes),
+			DisplayCount(int64(u.SharedItems)),
+			DisplayCount(int64(u.ExternalItems)),
+			DisplayCount(int64(u.PublicItems)),
+			u.Error,
+		}
+	}
+	return rows
+}
+
+func (r *OwnershipReport) EmptyMessage() string {
+	return "No users found"
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // RequestType classifies API requests for proper parameter injection
 type RequestType string
 
@@ -21,4 +23,32 @@ type RequestContext struct {
 	InvolvedParentIDs []string
 	RequestType       RequestType
 	TraceID           string
+	// DriveParams overrides the Shared Drive parameters the request shaper
+	// would choose, for this request only
+	DriveParams *DriveParams
+	// Latency and Attempts describe the last operation run with this
+	// context, including retries; set by api.ExecuteWithRetry
+	Latency  time.Duration
+	Attempts int
+}
+
+// DriveParams overrides how requests address Shared Drives. Empty fields
+// keep the value chosen by the request shaper.
+type DriveParams struct {
+	Corpora                   string // user, drive, domain or allDrives (lists only)
+	DriveID                   string // The Shared Drive to list with corpora drive
+	SupportsAllDrives         *bool
+	IncludeItemsFromAllDrives *bool // Lists only
+}
+
+// FieldsAuditEntry compares the API response fields a command received
+// with the ones it used, as recorded by --fields-audit
+type FieldsAuditEntry struct {
+	Command       string    `json:"command"`
+	Calls         int       `json:"calls"`
+	Returned      []string  `json:"returned"`
+	Used          []string  `json:"used"`
+	Unused        []string  `json:"unused"`
+	SuggestedMask string    `json:"suggestedMask"`
+	RecordedAt    time.Time `json:"recordedAt"`
 }
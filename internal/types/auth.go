@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Credentials represents OAuth2 or service account credentials
 type Credentials struct {
@@ -12,6 +15,7 @@ type Credentials struct {
 	ClientID            string    `json:"client_id,omitempty"`
 	ServiceAccountEmail string    `json:"service_account_email,omitempty"`
 	ImpersonatedUser    string    `json:"impersonated_user,omitempty"`
+	CredentialsSource   string    `json:"credentials_source,omitempty"` // e.g. vault://secret/data/gdrv#key
 }
 
 type AuthType string
@@ -33,4 +37,52 @@ type StoredCredentials struct {
 	ClientID            string   `json:"client_id,omitempty"`
 	ServiceAccountEmail string   `json:"service_account_email,omitempty"`
 	ImpersonatedUser    string   `json:"impersonated_user,omitempty"`
+	CredentialsSource   string   `json:"credentials_source,omitempty"`
+}
+
+// AuthProfileInfo summarizes one stored credential profile
+type AuthProfileInfo struct {
+	Profile string   `json:"profile"`
+	Current bool     `json:"current,omitempty"`
+	Type    AuthType `json:"type,omitempty"`
+	// Account is the service account or impersonated user, when known
+	Account string   `json:"account,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+	Expiry  string   `json:"expiry,omitempty"`
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// AuthProfileList is the output of 'auth list'
+type AuthProfileList struct {
+	Profiles       []AuthProfileInfo `json:"profiles"`
+	StorageBackend string            `json:"storageBackend"`
+}
+
+func (l *AuthProfileList) Headers() []string {
+	return []string{"Profile", "Type", "Account", "Scopes", "Expiry", "Status"}
+}
+
+func (l *AuthProfileList) Rows() [][]string {
+	rows := make([][]string, len(l.Profiles))
+	for i, p := range l.Profiles {
+		name := p.Profile
+		if p.Current {
+			name += " *"
+		}
+		scopes := make([]string, len(p.Scopes))
+		for j, s := range p.Scopes {
+			scopes[j] = strings.TrimPrefix(s, "https://www.googleapis.com/auth/")
+		}
+		status := p.Status
+		if p.Error != "" {
+			status += ": " + p.Error
+		}
+		rows[i] = []string{name, string(p.Type), p.Account, strings.Join(scopes, ","), p.Expiry, status}
+	}
+	return rows
+}
+
+func (l *AuthProfileList) EmptyMessage() string {
+	return "No stored profiles"
 }
@@ -348,3 +348,24 @@ func TestCredentials_ExpiryDateHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthProfileList_Rows(t *testing.T) {
+	list := &AuthProfileList{Profiles: []AuthProfileInfo{
+		{Profile: "default", Current: true, Type: AuthTypeOAuth, Scopes: []string{
+			"https://www.googleapis.com/auth/drive.readonly",
+			"https://www.googleapis.com/auth/drive.file",
+		}, Expiry: "2026-01-01T00:00:00Z", Status: "valid"},
+		{Profile: "broken", Status: "error", Error: "failed to parse credentials"},
+	}}
+
+	rows := list.Rows()
+	if len(rows) != 2 || len(rows[0]) != len(list.Headers()) {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if rows[0][0] != "default *" || rows[0][3] != "drive.readonly,drive.file" {
+		t.Errorf("row 0 = %v", rows[0])
+	}
+	if rows[1][5] != "error: failed to parse credentials" {
+		t.Errorf("row 1 status = %q", rows[1][5])
+	}
+}
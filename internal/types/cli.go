@@ -8,6 +8,7 @@ type CLIOutput struct {
 	Data          interface{}  `json:"data"`
 	Warnings      []CLIWarning `json:"warnings"`
 	Errors        []CLIError   `json:"errors"`
+	Plan          *DryRunPlan  `json:"plan,omitempty"`
 }
 
 // CLIWarning represents a non-fatal warning
@@ -33,6 +34,7 @@ type OutputFormat string
 const (
 	OutputFormatJSON  OutputFormat = "json"
 	OutputFormatTable OutputFormat = "table"
+	OutputFormatSARIF OutputFormat = "sarif" // Findings as a SARIF log, for commands that report them
 )
 
 // GlobalFlags represents CLI global options
@@ -52,5 +54,25 @@ type GlobalFlags struct {
 	DryRun              bool
 	Force               bool
 	Yes                 bool
+	YesScopes           []string
 	JSON                bool
+	FieldsAudit         bool
+	MaxMemoryResults    int
+	ReadOnly            bool
+	KeepTemp            bool
+	MaxConns            int
+	NoGzip              bool
+	HTTP1               bool
+	TransportStats      bool
+	Extract             string
+	PlanFile            string
+	ApprovalFile        string
+	HumanReadable       bool
+	Summary             bool
+	SummaryFile         string
+	NoFollowShortcuts   bool
+	BillingProject      string
+	Impersonate         string
+	SkipSelfCheck       bool
+	ProfileAPI          string
 }
@@ -0,0 +1,26 @@
+package types
+
+// Comment is a Drive comment on a file
+type Comment struct {
+	ID           string          `json:"id"`
+	Content      string          `json:"content"`
+	Author       string          `json:"author,omitempty"`
+	AuthorIsMe   bool            `json:"authorIsMe,omitempty"`
+	CreatedTime  string          `json:"createdTime,omitempty"`
+	ModifiedTime string          `json:"modifiedTime,omitempty"`
+	Resolved     bool            `json:"resolved"`
+	Deleted      bool            `json:"deleted,omitempty"`
+	Replies      []*CommentReply `json:"replies,omitempty"`
+}
+
+// CommentReply is a reply to a Drive comment. Action is "resolve" or
+// "reopen" when the reply changed the comment's state.
+type CommentReply struct {
+	ID          string `json:"id"`
+	Content     string `json:"content,omitempty"`
+	Author      string `json:"author,omitempty"`
+	AuthorIsMe  bool   `json:"authorIsMe,omitempty"`
+	CreatedTime string `json:"createdTime,omitempty"`
+	Action      string `json:"action,omitempty"`
+	Deleted     bool   `json:"deleted,omitempty"`
+}
@@ -0,0 +1,211 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Display renders sizes, timestamps and counts in table and text output.
+// JSON output always carries the raw values, so the display in use never
+// changes it. Table renderers format through DisplaySize, DisplayTime and
+// DisplayCount rather than calling a Display directly.
+type Display interface {
+	Size(bytes int64) string
+	Time(timestamp string) string
+	Count(n int64) string
+}
+
+var (
+	displayMu      sync.RWMutex
+	currentDisplay Display = RawDisplay{}
+)
+
+// SetDisplay replaces the display used by table and text output; nil
+// restores RawDisplay
+func SetDisplay(d Display) {
+	if d == nil {
+		d = RawDisplay{}
+	}
+	displayMu.Lock()
+	defer displayMu.Unlock()
+	currentDisplay = d
+}
+
+// CurrentDisplay returns the display used by table and text output
+func CurrentDisplay() Display {
+	displayMu.RLock()
+	defer displayMu.RUnlock()
+	return currentDisplay
+}
+
+// IsHumanDisplay reports whether humanized output is in use
+func IsHumanDisplay() bool {
+	_, ok := CurrentDisplay().(*HumanDisplay)
+	return ok
+}
+
+// DisplaySize formats a byte count with the current display
+func DisplaySize(bytes int64) string {
+	return CurrentDisplay().Size(bytes)
+}
+
+// DisplayTime formats an RFC 3339 timestamp with the current display.
+// Empty and unparseable timestamps are returned unchanged.
+func DisplayTime(timestamp string) string {
+	return CurrentDisplay().Time(timestamp)
+}
+
+// DisplayCount formats a count with the current display
+func DisplayCount(n int64) string {
+	return CurrentDisplay().Count(n)
+}
+
+// RawDisplay renders values as the API returns them: sizes as byte counts
+// and timestamps in RFC 3339
+type RawDisplay struct{}
+
+func (RawDisplay) Size(bytes int64) string      { return strconv.FormatInt(bytes, 10) }
+func (RawDisplay) Time(timestamp string) string { return timestamp }
+func (RawDisplay) Count(n int64) string         { return strconv.FormatInt(n, 10) }
+
+// NumberLocale holds the separators a locale uses for numbers
+type NumberLocale struct {
+	Decimal string
+	Group   string
+}
+
+// Number locales by language
+var (
+	LocaleEnglish = NumberLocale{Decimal: ".", Group: ","}
+	// Most of continental Europe and Latin America
+	LocaleDecimalComma = NumberLocale{Decimal: ",", Group: "."}
+	// French, Nordic and Slavic languages group with a no-break space
+	LocaleDecimalCommaSpace = NumberLocale{Decimal: ",", Group: "\u00a0"}
+)
+
+var localesByLanguage = map[string]NumberLocale{
+	"de": LocaleDecimalComma, "es": LocaleDecimalComma, "it": LocaleDecimalComma,
+	"nl": LocaleDecimalComma, "pt": LocaleDecimalComma, "da": LocaleDecimalComma,
+	"id": LocaleDecimalComma, "tr": LocaleDecimalComma, "el": LocaleDecimalComma,
+	"ro": LocaleDecimalComma,
+	"fr": LocaleDecimalCommaSpace, "ru": LocaleDecimalCommaSpace, "pl": LocaleDecimalCommaSpace,
+	"cs": LocaleDecimalCommaSpace, "sk": LocaleDecimalCommaSpace, "sv": LocaleDecimalCommaSpace,
+	"fi": LocaleDecimalCommaSpace, "nb": LocaleDecimalCommaSpace, "no": LocaleDecimalCommaSpace,
+	"uk": LocaleDecimalCommaSpace, "hu": LocaleDecimalCommaSpace,
+}
+
+// LookupNumberLocale returns the number locale for a locale name such as
+// "de", "fr-CA" or "pt_BR.UTF-8". Unknown names, "C" and "POSIX" use
+// LocaleEnglish.
+func LookupNumberLocale(name string) NumberLocale {
+	lang := strings.ToLower(name)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if locale, ok := localesByLanguage[lang]; ok {
+		return locale
+	}
+	return LocaleEnglish
+}
+
+// HumanDisplay renders sizes with binary units ("1.4 GiB"), timestamps
+// relative to now ("3 days ago") and counts with digit grouping, using the
+// separators of its locale
+type HumanDisplay struct {
+	Locale NumberLocale
+	// Now is the reference time for relative timestamps; nil means time.Now
+	Now func() time.Time
+}
+
+// NewHumanDisplay returns a humanized display for a locale name (see
+// LookupNumberLocale)
+func NewHumanDisplay(locale string) *HumanDisplay {
+	return &HumanDisplay{Locale: LookupNumberLocale(locale)}
+}
+
+func (d *HumanDisplay) Size(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return d.Count(bytes) + " B"
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	value := strconv.FormatFloat(float64(bytes)/float64(div), 'f', 1, 64)
+	return strings.Replace(value, ".", d.Locale.Decimal, 1) + " " + string("KMGTPE"[exp]) + "iB"
+}
+
+func (d *HumanDisplay) Count(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if i > 0 {
+			b.WriteString(d.Locale.Group)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+func (d *HumanDisplay) Time(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	now := time.Now()
+	if d.Now != nil {
+		now = d.Now()
+	}
+	return relativeTime(now.Sub(t))
+}
+
+// relativeTime describes how long ago (or, for negative durations, how far
+// ahead) something happened
+func relativeTime(ago time.Duration) string {
+	future := ago < 0
+	if future {
+		ago = -ago
+	}
+	if ago < time.Minute {
+		return "just now"
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case ago < time.Hour:
+		n, unit = int64(ago/time.Minute), "minute"
+	case ago < 24*time.Hour:
+		n, unit = int64(ago/time.Hour), "hour"
+	case ago < 30*24*time.Hour:
+		n, unit = int64(ago/(24*time.Hour)), "day"
+	case ago < 365*24*time.Hour:
+		n, unit = int64(ago/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int64(ago/(365*24*time.Hour)), "year"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanDisplay(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	en := &HumanDisplay{Locale: LookupNumberLocale("en_US.UTF-8"), Now: func() time.Time { return now }}
+	de := &HumanDisplay{Locale: LookupNumberLocale("de-DE")}
+	fr := &HumanDisplay{Locale: LookupNumberLocale("fr")}
+
+	tests := []struct {
+		got, want string
+	}{
+		{en.Size(512), "512 B"},
+		{en.Size(1536), "1.5 KiB"},
+		{en.Size(1503238554), "1.4 GiB"},
+		{de.Size(1503238554), "1,4 GiB"},
+		{en.Count(1234567), "1,234,567"},
+		{en.Count(-1234), "-1,234"},
+		{en.Count(999), "999"},
+		{de.Count(1234567), "1.234.567"},
+		{fr.Count(12345), "12\u00a0345"},
+		{en.Time("2026-05-10T11:59:30Z"), "just now"},
+		{en.Time("2026-05-10T11:00:00Z"), "1 hour ago"},
+		{en.Time("2026-05-07T09:00:00Z"), "3 days ago"},
+		{en.Time("2025-11-10T12:00:00Z"), "6 months ago"},
+		{en.Time("2026-08-08T12:00:00Z"), "in 3 months"},
+		{en.Time("not a time"), "not a time"},
+		{en.Time(""), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestSetDisplay(t *testing.T) {
+	defer SetDisplay(nil)
+
+	if DisplaySize(2048) != "2048" || DisplayTime("2026-01-01T00:00:00Z") != "2026-01-01T00:00:00Z" || IsHumanDisplay() {
+		t.Error("the default display should render raw values")
+	}
+	SetDisplay(NewHumanDisplay("C"))
+	if DisplaySize(2048) != "2.0 KiB" || !IsHumanDisplay() {
+		t.Errorf("DisplaySize(2048) = %q under a human display", DisplaySize(2048))
+	}
+	SetDisplay(nil)
+	if DisplayCount(1000) != "1000" {
+		t.Error("SetDisplay(nil) should restore raw values")
+	}
+}
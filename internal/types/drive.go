@@ -1,12 +1,19 @@
 package types
 
+import (
+	"sort"
+	"strconv"
+)
+
 // DriveFile represents a Google Drive file
 type DriveFile struct {
 	ID             string            `json:"id"`
 	Name           string            `json:"name"`
 	MimeType       string            `json:"mimeType"`
+	Description    string            `json:"description,omitempty"`
 	Size           int64             `json:"size,omitempty"`
 	MD5Checksum    string            `json:"md5Checksum,omitempty"`
+	SHA256Checksum string            `json:"sha256Checksum,omitempty"`
 	CreatedTime    string            `json:"createdTime,omitempty"`
 	ModifiedTime   string            `json:"modifiedTime,omitempty"`
 	Parents        []string          `json:"parents,omitempty"`
@@ -16,6 +23,44 @@ type DriveFile struct {
 	WebViewLink    string            `json:"webViewLink,omitempty"`
 	WebContentLink string            `json:"webContentLink,omitempty"`
 	Trashed        bool              `json:"trashed,omitempty"`
+	Starred        bool              `json:"starred,omitempty"`
+	AppProperties  map[string]string `json:"appProperties,omitempty"`
+	// ShortcutDetails is set on shortcuts, for the file they point to
+	ShortcutDetails *ShortcutDetails `json:"shortcutDetails,omitempty"`
+	// Verification is set after an upload or download
+	Verification *ChecksumVerification `json:"verification,omitempty"`
+}
+
+// Checksum verification statuses
+const (
+	VerificationVerified = "verified"
+	VerificationSkipped  = "skipped"
+	VerificationDisabled = "disabled"
+)
+
+// ChecksumVerification reports whether transferred content matched the
+// checksum Drive holds for it
+type ChecksumVerification struct {
+	Status    string `json:"status"`
+	Algorithm string `json:"algorithm,omitempty"` // md5 or sha256
+	Checksum  string `json:"checksum,omitempty"`
+	Reason    string `json:"reason,omitempty"` // Why verification was skipped
+}
+
+// ShortcutDetails describes the target of a shortcut
+type ShortcutDetails struct {
+	TargetID          string `json:"targetId"`
+	TargetMimeType    string `json:"targetMimeType,omitempty"`
+	TargetResourceKey string `json:"targetResourceKey,omitempty"`
+}
+
+// ShortcutTarget returns the ID of the file a shortcut points to, or ""
+// when f is not a shortcut
+func (f *DriveFile) ShortcutTarget() string {
+	if f == nil || f.ShortcutDetails == nil {
+		return ""
+	}
+	return f.ShortcutDetails.TargetID
 }
 
 // FileCapabilities represents what actions can be performed on a file
@@ -26,6 +71,8 @@ type FileCapabilities struct {
 	CanDelete        bool `json:"canDelete"`
 	CanTrash         bool `json:"canTrash"`
 	CanReadRevisions bool `json:"canReadRevisions"`
+	CanRename        bool `json:"canRename,omitempty"`
+	CanUntrash       bool `json:"canUntrash,omitempty"`
 }
 
 // FileListResult represents paginated file list response
@@ -35,6 +82,102 @@ type FileListResult struct {
 	IncompleteSearch bool         `json:"incompleteSearch,omitempty"`
 }
 
+// Search corpora, in the order federated search results are attributed
+const (
+	CorpusMyDrive      = "my-drive"
+	CorpusSharedDrive  = "shared-drive"
+	CorpusSharedWithMe = "shared-with-me"
+)
+
+// SearchCorpus is one corpus searched by a federated search and how it went
+type SearchCorpus struct {
+	Corpus    string `json:"corpus"`
+	DriveID   string `json:"driveId,omitempty"`
+	DriveName string `json:"driveName,omitempty"`
+	Matches   int    `json:"matches"`
+	// Incomplete is Drive's incompleteSearch for this corpus
+	Incomplete bool   `json:"incomplete,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Label names the corpus for display, e.g. "shared-drive:Finance"
+func (c *SearchCorpus) Label() string {
+	if c.Corpus == CorpusSharedDrive {
+		name := c.DriveName
+		if name == "" {
+			name = c.DriveID
+		}
+		return c.Corpus + ":" + name
+	}
+	return c.Corpus
+}
+
+// FederatedFile is a search match attributed to the first corpus it was
+// found in
+type FederatedFile struct {
+	*DriveFile
+	Corpus    string   `json:"corpus"`
+	DriveID   string   `json:"driveId,omitempty"`
+	DriveName string   `json:"driveName,omitempty"`
+	AlsoIn    []string `json:"alsoIn,omitempty"`
+}
+
+// FederatedSearchResult merges one search run in several corpora, with
+// each file listed once
+type FederatedSearchResult struct {
+	Files            []*FederatedFile `json:"files"`
+	Corpora          []*SearchCorpus  `json:"corpora"`
+	IncompleteSearch bool             `json:"incompleteSearch,omitempty"`
+}
+
+func (r *FederatedSearchResult) Headers() []string {
+	return []string{"ID", "Name", "Type", "Modified", "Found In"}
+}
+
+func (r *FederatedSearchResult) Rows() [][]string {
+	rows := make([][]string, len(r.Files))
+	for i, f := range r.Files {
+		found := (&SearchCorpus{Corpus: f.Corpus, DriveID: f.DriveID, DriveName: f.DriveName}).Label()
+		if len(f.AlsoIn) > 0 {
+			found += " (+" + strconv.Itoa(len(f.AlsoIn)) + ")"
+		}
+		rows[i] = []string{f.ID, f.Name, f.MimeType, DisplayTime(f.ModifiedTime), found}
+	}
+	return rows
+}
+
+func (r *FederatedSearchResult) EmptyMessage() string {
+	return "No files found"
+}
+
+// FileProperties holds a file's appProperties
+type FileProperties struct {
+	FileID     string            `json:"fileId"`
+	Name       string            `json:"name,omitempty"`
+	Properties map[string]string `json:"properties"`
+}
+
+func (p *FileProperties) Headers() []string {
+	return []string{"Key", "Value"}
+}
+
+func (p *FileProperties) Rows() [][]string {
+	keys := make([]string, 0, len(p.Properties))
+	for key := range p.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	rows := make([][]string, len(keys))
+	for i, key := range keys {
+		rows[i] = []string{key, p.Properties[key]}
+	}
+	return rows
+}
+
+func (p *FileProperties) EmptyMessage() string {
+	return "No properties set"
+}
+
 // Permission represents a Drive permission
 type Permission struct {
 	ID           string `json:"id"`
@@ -43,6 +186,39 @@ type Permission struct {
 	EmailAddress string `json:"emailAddress,omitempty"`
 	Domain       string `json:"domain,omitempty"`
 	DisplayName  string `json:"displayName,omitempty"`
+
+	// Populated by full listings
+	AllowFileDiscovery bool                      `json:"allowFileDiscovery,omitempty"`
+	Deleted            bool                      `json:"deleted,omitempty"`
+	PendingOwner       bool                      `json:"pendingOwner,omitempty"`
+	ExpirationTime     string                    `json:"expirationTime,omitempty"`
+	PhotoLink          string                    `json:"photoLink,omitempty"`
+	View               string                    `json:"view,omitempty"` // "published" for published-view permissions
+	PermissionDetails  []*PermissionAccessDetail `json:"permissionDetails,omitempty"`
+}
+
+// PermissionAccessDetail describes one way a Shared Drive permission grants
+// access, and whether it is inherited
+type PermissionAccessDetail struct {
+	PermissionType string `json:"permissionType"` // file, member
+	Role           string `json:"role"`
+	Inherited      bool   `json:"inherited"`
+	InheritedFrom  string `json:"inheritedFrom,omitempty"`
+}
+
+// IsDriveMembership reports whether a permission only exists because of
+// Shared Drive membership, which is inherited by every item in the drive.
+// It requires permissionDetails to have been requested.
+func (p *Permission) IsDriveMembership() bool {
+	if len(p.PermissionDetails) == 0 {
+		return false
+	}
+	for _, d := range p.PermissionDetails {
+		if d.PermissionType != "member" {
+			return false
+		}
+	}
+	return true
 }
 
 // Revision represents a file revision
@@ -54,3 +230,81 @@ type Revision struct {
 	MimeType         string `json:"mimeType,omitempty"`
 	OriginalFilename string `json:"originalFilename,omitempty"`
 }
+
+// SharedWithMeItem is a shared-with-me file with the context needed to
+// decide whether to keep it
+type SharedWithMeItem struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	MimeType   string `json:"mimeType"`
+	Owner      string `json:"owner,omitempty"`
+	OwnerName  string `json:"ownerName,omitempty"`
+	SharedBy   string `json:"sharedBy,omitempty"`
+	SharedTime string `json:"sharedTime,omitempty"`
+	LastViewed string `json:"lastViewed,omitempty"`
+	Removed    bool   `json:"removed,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SharedWithMeResult lists shared-with-me items matching triage filters
+type SharedWithMeResult struct {
+	Items   []*SharedWithMeItem `json:"items"`
+	Removed int                 `json:"removed,omitempty"`
+	Failed  int                 `json:"failed,omitempty"`
+	DryRun  bool                `json:"dryRun,omitempty"`
+}
+
+func (r *SharedWithMeResult) Headers() []string {
+	return []string{"ID", "Name", "Owner", "Shared", "Last Viewed"}
+}
+
+func (r *SharedWithMeResult) Rows() [][]string {
+	rows := make([][]string, len(r.Items))
+	for i, item := range r.Items {
+		lastViewed := DisplayTime(item.LastViewed)
+		if lastViewed == "" {
+			lastViewed = "never"
+		}
+		rows[i] = []string{item.ID, item.Name, item.Owner, DisplayTime(item.SharedTime), lastViewed}
+	}
+	return rows
+}
+
+func (r *SharedWithMeResult) EmptyMessage() string {
+	return "No shared-with-me items found"
+}
+
+// OwnerStats aggregates item counts and bytes for one owner
+type OwnerStats struct {
+	Owner       string `json:"owner"`
+	DisplayName string `json:"displayName,omitempty"`
+	Files       int    `json:"files"`
+	Folders     int    `json:"folders"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// OwnersReport breaks down a folder's contents by owner
+type OwnersReport struct {
+	FolderID     string        `json:"folderId"`
+	Recursive    bool          `json:"recursive"`
+	TotalFiles   int           `json:"totalFiles"`
+	TotalFolders int           `json:"totalFolders"`
+	TotalBytes   int64         `json:"totalBytes"`
+	Owners       []*OwnerStats `json:"owners"`
+}
+
+func (r *OwnersReport) Headers() []string {
+	return []string{"Owner", "Name", "Files", "Folders", "Bytes"}
+}
+
+func (r *OwnersReport) Rows() [][]string {
+	rows := make([][]string, len(r.Owners))
+	for i, o := range r.Owners {
+		rows[i] = []string{o.Owner, o.DisplayName, DisplayCount(int64(o.Files)), DisplayCount(int64(o.Folders)), DisplaySize(o.Bytes)}
+	}
+	return rows
+}
+
+func (r *OwnersReport) EmptyMessage() string {
+	return "No items found"
+}
@@ -0,0 +1,48 @@
+package types
+
+// OfficeExportItem is the outcome for one file in an Office export
+type OfficeExportItem struct {
+	FileID   string `json:"fileId"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Path     string `json:"path,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Status   string `json:"status"`
+	Renamed  bool   `json:"renamed,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// OfficeExportReport is the conversion report of an Office export. Paths
+// are relative to the archive when Archive is set.
+type OfficeExportReport struct {
+	FolderID  string              `json:"folderId"`
+	Output    string              `json:"output"`
+	Archive   bool                `json:"archive,omitempty"`
+	DryRun    bool                `json:"dryRun,omitempty"`
+	Converted int                 `json:"converted"`
+	Copied    int                 `json:"copied"`
+	Skipped   int                 `json:"skipped"`
+	Failed    int                 `json:"failed"`
+	Renamed   int                 `json:"renamed"`
+	Items     []*OfficeExportItem `json:"items"`
+}
+
+func (r *OfficeExportReport) Headers() []string {
+	return []string{"Name", "Path", "Format", "Status", "Note"}
+}
+
+func (r *OfficeExportReport) Rows() [][]string {
+	rows := make([][]string, len(r.Items))
+	for i, item := range r.Items {
+		note := item.Error
+		if note == "" && item.Renamed {
+			note = "renamed (duplicate or invalid name)"
+		}
+		rows[i] = []string{item.Name, item.Path, item.Format, item.Status, note}
+	}
+	return rows
+}
+
+func (r *OfficeExportReport) EmptyMessage() string {
+	return "No files to export"
+}
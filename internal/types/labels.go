@@ -446,6 +446,9 @@ type FileLabel struct {
 	// RevisionID is the revision ID of the label
 	RevisionID string `json:"revisionId,omitempty"`
 
+	// Title is the label's title, when the Drive Labels API is available
+	Title string `json:"title,omitempty"`
+
 	// Fields are the field values
 	Fields map[string]*LabelFieldValue `json:"fields,omitempty"`
 }
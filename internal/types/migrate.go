@@ -0,0 +1,68 @@
+package types
+
+// Migration actions
+const (
+	MigrationActionCreateFolder = "create_folder"
+	MigrationActionMove         = "move"
+	MigrationActionCopy         = "copy"
+	MigrationActionManual       = "manual"
+)
+
+// Migration item statuses
+const (
+	MigrationStatusPlanned = "planned"
+	MigrationStatusDone    = "done"
+	MigrationStatusFailed  = "failed"
+	MigrationStatusSkipped = "skipped"
+)
+
+// MigrationItem is a single source item in a Shared Drive migration plan
+type MigrationItem struct {
+	SourceID       string `json:"sourceId"`
+	SourceParentID string `json:"sourceParentId"`
+	Name           string `json:"name"`
+	Path           string `json:"path"`
+	MimeType       string `json:"mimeType"`
+	Owner          string `json:"owner,omitempty"`
+	Action         string `json:"action"`
+	Reason         string `json:"reason,omitempty"`
+	Status         string `json:"status"`
+	TargetID       string `json:"targetId,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// MigrationPlan describes how a My Drive folder will be moved into a
+// Shared Drive
+type MigrationPlan struct {
+	SourceFolderID    string           `json:"sourceFolderId"`
+	SourceFolderName  string           `json:"sourceFolderName"`
+	DestinationDrive  string           `json:"destinationDriveId"`
+	DestinationParent string           `json:"destinationParentId"`
+	Items             []*MigrationItem `json:"items"`
+	Moves             int              `json:"moves"`
+	Copies            int              `json:"copies"`
+	Folders           int              `json:"folders"`
+	ManualActions     int              `json:"manualActions"`
+	Executed          bool             `json:"executed"`
+	Failed            int              `json:"failed,omitempty"`
+}
+
+func (p *MigrationPlan) Headers() []string {
+	return []string{"Path", "Action", "Status", "Reason"}
+}
+
+func (p *MigrationPlan) Rows() [][]string {
+	rows := make([][]string, len(p.Items))
+	for i, item := range p.Items {
+		reason := item.Reason
+		if item.Error != "" {
+			reason = item.Error
+		}
+		rows[i] = []string{item.Path, item.Action, item.Status, reason}
+	}
+	return rows
+}
+
+func (p *MigrationPlan) EmptyMessage() string {
+	return "Nothing to migrate"
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"strconv"
+	"strings"
+)
+
 // AuditResult represents the result of a permission audit operation.
 // It contains files that match specific permission criteria (public, external, etc.)
 type AuditResult struct {
@@ -41,7 +46,14 @@ type FilePermissionInfo struct {
 	HasExternalAccess bool     `json:"hasExternalAccess"`
 	HasAnyoneWithLink bool     `json:"hasAnyoneWithLink"`
 	ExternalDomains   []string `json:"externalDomains,omitempty"`
+	DriveMemberCount  int      `json:"driveMemberCount,omitempty"` // Permissions inherited from Shared Drive membership
 	PermissionCount   int      `json:"permissionCount"`
+
+	// Shortcut details. When Dereferenced is set, the permissions above are
+	// the target's rather than the shortcut's own.
+	IsShortcut   bool   `json:"isShortcut,omitempty"`
+	TargetID     string `json:"targetId,omitempty"`
+	Dereferenced bool   `json:"dereferenced,omitempty"`
 }
 
 // PermissionAnalysis represents a hierarchical analysis of folder permissions
@@ -54,12 +66,19 @@ type PermissionAnalysis struct {
 	// Analysis results
 	TotalFiles       int            `json:"totalFiles"`
 	TotalFolders     int            `json:"totalFolders"`
+	TotalShortcuts   int            `json:"totalShortcuts,omitempty"`
 	FilesWithRisks   int            `json:"filesWithRisks"`
 	FoldersWithRisks int            `json:"foldersWithRisks"`
 	RiskDistribution map[string]int `json:"riskDistribution"` // low, medium, high, critical counts
 	PermissionTypes  map[string]int `json:"permissionTypes"`  // user, group, domain, anyone counts
 	RoleDistribution map[string]int `json:"roleDistribution"` // reader, writer, etc. counts
 
+	// Shared Drive membership, inherited by every item, versus grants on
+	// the items themselves
+	ItemGrants             int  `json:"itemGrants"`
+	DriveMemberships       int  `json:"driveMemberships,omitempty"`
+	DriveMembershipIgnored bool `json:"driveMembershipIgnored,omitempty"` // Memberships left out of the distributions and risk
+
 	// Detailed findings
 	PublicFiles    []*FilePermissionInfo `json:"publicFiles,omitempty"`
 	ExternalShares []*FilePermissionInfo `json:"externalShares,omitempty"`
@@ -92,7 +111,7 @@ type PermissionReport struct {
 	HasExternalAccess bool     `json:"hasExternalAccess"`
 	HasAnyoneWithLink bool     `json:"hasAnyoneWithLink"`
 	ExternalDomains   []string `json:"externalDomains,omitempty"`
-	InternalDomain    string   `json:"internalDomain,omitempty"`
+	InternalDomains   []string `json:"internalDomains,omitempty"`
 
 	// Risk assessment
 	RiskLevel   string   `json:"riskLevel"`
@@ -103,6 +122,105 @@ type PermissionReport struct {
 	Recommendations []string `json:"recommendations,omitempty"`
 }
 
+func (r *AuditResult) Headers() []string {
+	return []string{"File ID", "Name", "Risk", "Public", "External", "Anyone With Link", "External Domains", "Permissions", "Link", "Reasons"}
+}
+
+func (r *AuditResult) Rows() [][]string {
+	rows := make([][]string, len(r.Files))
+	for i, f := range r.Files {
+		rows[i] = []string{
+			f.FileID,
+			f.FileName,
+			f.RiskLevel,
+			strconv.FormatBool(f.HasPublicAccess),
+			strconv.FormatBool(f.HasExternalAccess),
+			strconv.FormatBool(f.HasAnyoneWithLink),
+			strings.Join(f.ExternalDomains, ", "),
+			DisplayCount(int64(f.PermissionCount)),
+			f.WebViewLink,
+			strings.Join(f.RiskReasons, "; "),
+		}
+	}
+	return rows
+}
+
+func (r *AuditResult) EmptyMessage() string {
+	return "No files matched the audit"
+}
+
+// Headers, Rows and EmptyMessage list the detailed findings of the folder
+// and its subfolders, one row per file and category
+func (a *PermissionAnalysis) Headers() []string {
+	return []string{"Folder", "Category", "File ID", "Name", "Risk", "External Domains", "Link"}
+}
+
+func (a *PermissionAnalysis) Rows() [][]string {
+	var rows [][]string
+	categories := []struct {
+		name  string
+		files []*FilePermissionInfo
+	}{
+		{"public", a.PublicFiles},
+		{"anyone-with-link", a.AnyoneWithLink},
+		{"external", a.ExternalShares},
+		{"high-risk", a.HighRiskFiles},
+	}
+	for _, c := range categories {
+		for _, f := range c.files {
+			rows = append(rows, []string{
+				a.FolderName,
+				c.name,
+				f.FileID,
+				f.FileName,
+				f.RiskLevel,
+				strings.Join(f.ExternalDomains, ", "),
+				f.WebViewLink,
+			})
+		}
+	}
+	for _, sub := range a.Subfolders {
+		rows = append(rows, sub.Rows()...)
+	}
+	return rows
+}
+
+func (a *PermissionAnalysis) EmptyMessage() string {
+	return "No risky files found"
+}
+
+func (r *PermissionReport) Headers() []string {
+	return []string{"Permission ID", "Type", "Role", "Grantee", "Display Name", "External", "Public", "Risk"}
+}
+
+func (r *PermissionReport) Rows() [][]string {
+	rows := make([][]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		grantee := p.EmailAddress
+		if grantee == "" {
+			grantee = p.Domain
+		}
+		if grantee == "" {
+			grantee = p.Type
+		}
+		rows[i] = []string{
+			p.ID,
+			p.Type,
+			p.Role,
+			grantee,
+			p.DisplayName,
+			strconv.FormatBool(p.IsExternal),
+			strconv.FormatBool(p.IsPublic),
+			p.RiskLevel,
+		}
+	}
+	return rows
+}
+
+func (r *PermissionReport) EmptyMessage() string {
+	return "No permissions"
+}
+
 // PermissionDetail represents detailed information about a single permission
 type PermissionDetail struct {
 	// Permission fields
@@ -131,7 +249,7 @@ type AuditOptions struct {
 	Query          string // Additional Drive API query
 
 	// Domain options
-	InternalDomain string // Domain to consider as internal (for external detection)
+	InternalDomains []string // Domains to consider as internal (exact or "*.example.com")
 
 	// Pagination
 	PageSize  int    // Number of results per page
@@ -140,6 +258,9 @@ type AuditOptions struct {
 	// Output options
 	IncludePermissions  bool // Include full permission details in results
 	IncludeRiskAnalysis bool // Include risk assessment
+
+	// Shortcut options
+	FollowShortcuts bool // Audit shortcut targets' permissions instead of the shortcuts'
 }
 
 // AnalyzeOptions configures folder permission analysis
@@ -152,12 +273,18 @@ type AnalyzeOptions struct {
 	IncludeTrashed bool // Include trashed items
 
 	// Analysis options
-	IncludeDetails bool   // Include detailed file lists
-	InternalDomain string // Domain to consider as internal
-	RiskThreshold  string // Minimum risk level to include (low, medium, high, critical)
+	IncludeDetails  bool     // Include detailed file lists
+	InternalDomains []string // Domains to consider as internal (exact or "*.example.com")
+	RiskThreshold   string   // Minimum risk level to include (low, medium, high, critical)
 
 	// Performance
 	MaxFiles int // Maximum files to analyze (0 = unlimited)
+
+	// Shortcuts
+	FollowShortcuts bool // Analyze shortcut targets' permissions instead of the shortcuts'
+
+	// Shared Drives
+	IgnoreDriveMembership bool // Leave out permissions inherited from Shared Drive membership
 }
 
 // BulkOptions configures bulk permission operations
@@ -172,10 +299,12 @@ type BulkOptions struct {
 	IncludeTrashed bool   // Include trashed files
 
 	// Safety
-	DryRun          bool // Preview operations without executing
-	MaxFiles        int  // Maximum files to process (safety limit)
-	BatchSize       int  // Number of operations per batch
-	ContinueOnError bool // Continue processing if individual operations fail
+	DryRun          bool    // Preview operations without executing
+	MaxFiles        int     // Maximum files to process (safety limit)
+	BatchSize       int     // Number of operations per batch
+	ContinueOnError bool    // Continue processing if individual operations fail
+	MaxErrorRate    float64 // Continue past failures until the rolling failure rate exceeds this fraction (0 = stop at the first failure unless ContinueOnError)
+	RoleDirection   string  // Only allow role updates in this direction, "upgrade" or "downgrade" (empty = either)
 
 	// Progress
 	ShowProgress bool // Show progress during bulk operations
@@ -205,7 +334,8 @@ type SearchOptions struct {
 	IncludePermissions bool // Include full permission details
 }
 
-// BulkOperationResult represents the result of a bulk permission operation
+// BulkOperationResult represents the result of a bulk permission or file
+// operation
 type BulkOperationResult struct {
 	// Summary
 	TotalFiles   int `json:"totalFiles"`
@@ -223,15 +353,26 @@ type BulkOperationResult struct {
 
 	// Dry run
 	DryRun bool `json:"dryRun"`
+
+	// Abort
+	Aborted     bool   `json:"aborted,omitempty"`
+	AbortReason string `json:"abortReason,omitempty"`
+
+	// Batch requests sent, when changes were batched
+	BatchRequests int `json:"batchRequests,omitempty"`
 }
 
 // BulkOperationItem represents a single item in a bulk operation
 type BulkOperationItem struct {
 	FileID       string `json:"fileId"`
 	FileName     string `json:"fileName"`
+	PermissionID string `json:"permissionId,omitempty"`
 	Operation    string `json:"operation"` // remove, update, etc.
 	Status       string `json:"status"`    // success, failure, skipped
 	ErrorMessage string `json:"errorMessage,omitempty"`
+	ErrorCode    string `json:"errorCode,omitempty"`
+	HTTPStatus   int    `json:"httpStatus,omitempty"` // Status of the failed call
+	Message      string `json:"message,omitempty"`    // Rendered notification message
 }
 
 // RiskLevel constants
@@ -258,3 +399,194 @@ const (
 	PermissionRoleOrganizer = "organizer"
 	PermissionRoleOwner     = "owner"
 )
+
+// Permission drift statuses
+const (
+	DriftOnlyA       = "only-a"
+	DriftOnlyB       = "only-b"
+	DriftRoleChanged = "role-changed"
+)
+
+// PermissionDrift is a grantee whose access differs between two files
+type PermissionDrift struct {
+	Grantee string `json:"grantee"`
+	Type    string `json:"type"`
+	RoleA   string `json:"roleA,omitempty"`
+	RoleB   string `json:"roleB,omitempty"`
+	Status  string `json:"status"` // only-a, only-b, role-changed
+}
+
+// PermissionComparison compares the permissions on the same path in two
+// profiles, e.g. production and staging tenants
+type PermissionComparison struct {
+	ProfileA string `json:"profileA"`
+	ProfileB string `json:"profileB"`
+	PathA    string `json:"pathA"`
+	PathB    string `json:"pathB"`
+	FileIDA  string `json:"fileIdA"`
+	FileIDB  string `json:"fileIdB"`
+
+	// Matching is the number of grantees with the same role on both sides
+	Matching int                `json:"matching"`
+	Drift    []*PermissionDrift `json:"drift"`
+	InSync   bool               `json:"inSync"`
+}
+
+func (c *PermissionComparison) Headers() []string {
+	return []string{"Grantee", "Type", "Role (" + c.ProfileA + ")", "Role (" + c.ProfileB + ")", "Status"}
+}
+
+func (c *PermissionComparison) Rows() [][]string {
+	rows := make([][]string, len(c.Drift))
+	for i, d := range c.Drift {
+		rows[i] = []string{d.Grantee, d.Type, d.RoleA, d.RoleB, d.Status}
+	}
+	return rows
+}
+
+func (c *PermissionComparison) EmptyMessage() string {
+	return "Permissions are in sync"
+}
+
+// PermissionSnapshot records the permissions on a folder and the files
+// under it, as the baseline for 'permissions watch'
+type PermissionSnapshot struct {
+	FolderID  string                   `json:"folderId"`
+	Recursive bool                     `json:"recursive"`
+	TakenAt   string                   `json:"takenAt"`
+	Files     map[string]*SnapshotFile `json:"files"`
+}
+
+// SnapshotFile is one file's permissions in a PermissionSnapshot
+type SnapshotFile struct {
+	Name        string        `json:"name"`
+	WebViewLink string        `json:"webViewLink,omitempty"`
+	Permissions []*Permission `json:"permissions"`
+}
+
+// Permission watch alert kinds
+const (
+	AlertNewPublicLink      = "new-public-link"
+	AlertNewExternalGrantee = "new-external-grantee"
+)
+
+// PermissionAlert is an exposure present now but not in the baseline
+type PermissionAlert struct {
+	Kind        string `json:"kind"` // new-public-link, new-external-grantee
+	FileID      string `json:"fileId"`
+	FileName    string `json:"fileName"`
+	WebViewLink string `json:"webViewLink,omitempty"`
+	Grantee     string `json:"grantee"`
+	Type        string `json:"type"`
+	Role        string `json:"role"`
+}
+
+// Key identifies the alert across checks so it is reported once
+func (a *PermissionAlert) Key() string {
+	return a.Kind + "|" + a.FileID + "|" + a.Type + "|" + a.Grantee
+}
+
+// PermissionWatchResult is the outcome of one 'permissions watch' check
+type PermissionWatchResult struct {
+	FolderID     string             `json:"folderId"`
+	Baseline     string             `json:"baseline"`
+	CheckedAt    string             `json:"checkedAt"`
+	FilesChecked int                `json:"filesChecked"`
+	Alerts       []*PermissionAlert `json:"alerts"`
+}
+
+func (r *PermissionWatchResult) Headers() []string {
+	return []string{"Kind", "File", "Grantee", "Type", "Role", "Link"}
+}
+
+func (r *PermissionWatchResult) Rows() [][]string {
+	rows := make([][]string, len(r.Alerts))
+	for i, a := range r.Alerts {
+		rows[i] = []string{a.Kind, a.FileName, a.Grantee, a.Type, a.Role, a.WebViewLink}
+	}
+	return rows
+}
+
+func (r *PermissionWatchResult) EmptyMessage() string {
+	return "No new exposure since the baseline"
+}
+
+// Sources of access in a permission explanation
+const (
+	AccessSourceDirect          = "direct"
+	AccessSourceInherited       = "inherited"
+	AccessSourceDriveMembership = "drive-membership"
+	AccessSourceLink            = "link"
+	AccessSourceGroup           = "group"
+)
+
+// AccessGrant is one way a principal has access to a file. Group grants
+// list the group's members when they could be expanded.
+type AccessGrant struct {
+	PermissionID      string         `json:"permissionId,omitempty"`
+	Type              string         `json:"type"`
+	Principal         string         `json:"principal"`
+	DisplayName       string         `json:"displayName,omitempty"`
+	Role              string         `json:"role"`
+	Source            string         `json:"source"`
+	InheritedFrom     string         `json:"inheritedFrom,omitempty"`
+	InheritedFromName string         `json:"inheritedFromName,omitempty"`
+	Members           []*AccessGrant `json:"members,omitempty"`
+	NotExpanded       string         `json:"notExpanded,omitempty"`
+}
+
+// EffectiveAccess is a principal's highest role on a file across every
+// grant that reaches it
+type EffectiveAccess struct {
+	Principal string   `json:"principal"`
+	Type      string   `json:"type"`
+	Role      string   `json:"role"`
+	Via       []string `json:"via"`
+}
+
+// AccessExplanation lists everyone with access to a file and why
+type AccessExplanation struct {
+	FileID         string             `json:"fileId"`
+	FileName       string             `json:"fileName"`
+	MimeType       string             `json:"mimeType,omitempty"`
+	DriveID        string             `json:"driveId,omitempty"`
+	GroupsExpanded bool               `json:"groupsExpanded"`
+	Grants         []*AccessGrant     `json:"grants"`
+	Effective      []*EffectiveAccess `json:"effective"`
+	Notes          []string           `json:"notes,omitempty"`
+}
+
+// Headers and Rows render the grants as a tree, with group members
+// indented under their group
+func (e *AccessExplanation) Headers() []string {
+	return []string{"Principal", "Type", "Role", "Source", "Inherited From"}
+}
+
+func (e *AccessExplanation) Rows() [][]string {
+	var rows [][]string
+	var walk func(grants []*AccessGrant, indent string)
+	walk = func(grants []*AccessGrant, indent string) {
+		for i, g := range grants {
+			branch, next := "├─ ", "│  "
+			if i == len(grants)-1 {
+				branch, next = "└─ ", "   "
+			}
+			inherited := g.InheritedFromName
+			if inherited == "" {
+				inherited = g.InheritedFrom
+			}
+			principal := g.Principal
+			if g.NotExpanded != "" {
+				principal += " (members not expanded)"
+			}
+			rows = append(rows, []string{indent + branch + principal, g.Type, g.Role, g.Source, inherited})
+			walk(g.Members, indent+next)
+		}
+	}
+	walk(e.Grants, "")
+	return rows
+}
+
+func (e *AccessExplanation) EmptyMessage() string {
+	return "No one has access"
+}
@@ -0,0 +1,28 @@
+package types
+
+import "testing"
+
+func TestPermissionAnalysisRows(t *testing.T) {
+	analysis := &PermissionAnalysis{
+		FolderName:  "Root",
+		PublicFiles: []*FilePermissionInfo{{FileID: "f1", FileName: "Open", RiskLevel: RiskLevelHigh}},
+		Subfolders: []*PermissionAnalysis{{
+			FolderName:     "Child",
+			ExternalShares: []*FilePermissionInfo{{FileID: "f2", FileName: "Shared", ExternalDomains: []string{"a.com", "b.com"}}},
+		}},
+	}
+
+	rows := analysis.Rows()
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0][0] != "Root" || rows[0][1] != "public" || rows[0][2] != "f1" {
+		t.Errorf("row 0 = %v", rows[0])
+	}
+	if rows[1][0] != "Child" || rows[1][1] != "external" || rows[1][5] != "a.com, b.com" {
+		t.Errorf("row 1 = %v", rows[1])
+	}
+	if len(rows[0]) != len(analysis.Headers()) {
+		t.Errorf("row has %d cells, headers have %d", len(rows[0]), len(analysis.Headers()))
+	}
+}
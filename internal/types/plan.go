@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlanSchemaVersion is the version of the dry-run plan document. It changes
+// only when the meaning of existing fields changes.
+const PlanSchemaVersion = 1
+
+// DryRunPlan is the machine-readable plan every command reports under
+// --dry-run. It is deterministic: it carries no timestamps or trace IDs,
+// operations are sorted, and Fingerprint hashes the command and operations,
+// so two runs planning the same changes produce identical documents.
+type DryRunPlan struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Command       string           `json:"command"`
+	Fingerprint   string           `json:"fingerprint"`
+	Summary       map[string]int   `json:"summary"`
+	Operations    []*PlanOperation `json:"operations"`
+}
+
+// PlanOperation is one change a command would make
+type PlanOperation struct {
+	Type      string                 `json:"type"`
+	Target    PlanTarget             `json:"target"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Predicted string                 `json:"predictedResult"`
+}
+
+// PlanTarget identifies what an operation acts on
+type PlanTarget struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+func (p *DryRunPlan) Headers() []string {
+	return []string{"Type", "Target", "Params", "Predicted Result"}
+}
+
+func (p *DryRunPlan) Rows() [][]string {
+	rows := make([][]string, len(p.Operations))
+	for i, op := range p.Operations {
+		target := op.Target.Name
+		if op.Target.ID != "" {
+			if target != "" {
+				target += " "
+			}
+			target += "(" + op.Target.ID + ")"
+		}
+		rows[i] = []string{op.Type, target, formatPlanParams(op.Params), op.Predicted}
+	}
+	return rows
+}
+
+func (p *DryRunPlan) EmptyMessage() string {
+	return "Dry run: no changes planned"
+}
+
+// formatPlanParams renders params as sorted key=value pairs
+func formatPlanParams(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		var value string
+		switch v := params[k].(type) {
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		default:
+			data, _ := json.Marshal(v)
+			value = string(data)
+		}
+		parts[i] = k + "=" + value
+	}
+	return strings.Join(parts, " ")
+}
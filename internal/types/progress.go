@@ -0,0 +1,25 @@
+package types
+
+// ProgressEvent reports that a long-running operation processed an item
+type ProgressEvent struct {
+	Operation string // e.g. "permissions audit", "files archive"
+	Done      int    // Items processed so far, including this one
+	Total     int    // Items expected, or 0 when unknown (listings, walks)
+	Item      string // Name or ID of the item, when there is one
+}
+
+// ProgressReporter observes long-running manager operations. Managers call
+// it at item boundaries, from the goroutine running the operation or, for
+// concurrent operations, from worker goroutines; implementations must be
+// safe for concurrent use.
+type ProgressReporter interface {
+	Progress(event ProgressEvent)
+}
+
+// ReportProgress sends event to r; a nil reporter is ignored, so managers
+// can report unconditionally
+func ReportProgress(r ProgressReporter, event ProgressEvent) {
+	if r != nil {
+		r.Progress(event)
+	}
+}
@@ -0,0 +1,51 @@
+package types
+
+import "strconv"
+
+// QueryIssue is a syntax or validation problem in a Drive query
+type QueryIssue struct {
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+// QueryTerm is a single search term in a parsed Drive query
+type QueryTerm struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// QueryExplanation describes a Drive query after parsing and validation
+type QueryExplanation struct {
+	Query      string        `json:"query"`
+	Valid      bool          `json:"valid"`
+	Normalized string        `json:"normalized,omitempty"`
+	Terms      []*QueryTerm  `json:"terms,omitempty"`
+	Errors     []*QueryIssue `json:"errors,omitempty"`
+	Warnings   []*QueryIssue `json:"warnings,omitempty"`
+}
+
+func (e *QueryExplanation) Headers() []string {
+	return []string{"Kind", "Column", "Detail"}
+}
+
+func (e *QueryExplanation) Rows() [][]string {
+	var rows [][]string
+	if e.Normalized != "" {
+		rows = append(rows, []string{"normalized", "", e.Normalized})
+	}
+	for _, t := range e.Terms {
+		rows = append(rows, []string{"term", "", t.Field + " " + t.Operator + " " + t.Value})
+	}
+	for _, issue := range e.Errors {
+		rows = append(rows, []string{"error", strconv.Itoa(issue.Position), issue.Message})
+	}
+	for _, issue := range e.Warnings {
+		rows = append(rows, []string{"warning", strconv.Itoa(issue.Position), issue.Message})
+	}
+	return rows
+}
+
+func (e *QueryExplanation) EmptyMessage() string {
+	return "Empty query"
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// Schedule notification policies
+const (
+	NotifyAlways  = "always"
+	NotifyFailure = "failure"
+)
+
+// ScheduledTask is a gdrv command run on a cron schedule by "schedule run"
+type ScheduledTask struct {
+	Name         string    `json:"name"`
+	Cron         string    `json:"cron"`
+	Args         []string  `json:"args"`
+	Notify       string    `json:"notify,omitempty"`
+	NotifyOn     string    `json:"notifyOn,omitempty"`
+	Disabled     bool      `json:"disabled,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastRun      time.Time `json:"lastRun,omitzero"`
+	LastExitCode int       `json:"lastExitCode,omitempty"`
+	LastError    string    `json:"lastError,omitempty"`
+	NextRun      time.Time `json:"nextRun,omitzero"`
+}
+
+// Command returns the task's gdrv arguments as a single display string
+func (t *ScheduledTask) Command() string {
+	return strings.Join(t.Args, " ")
+}
+
+// ScheduledTaskRun is the outcome of one execution of a scheduled task
+type ScheduledTaskRun struct {
+	Name       string    `json:"name"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	Notified   bool      `json:"notified,omitempty"`
+}
+
+// ScheduleList is the result of "schedule list"
+type ScheduleList struct {
+	Tasks []*ScheduledTask `json:"tasks"`
+}
+
+func (l *ScheduleList) Headers() []string {
+	return []string{"Name", "Cron", "Command", "Next Run", "Last Run", "Status"}
+}
+
+func (l *ScheduleList) Rows() [][]string {
+	rows := make([][]string, len(l.Tasks))
+	for i, t := range l.Tasks {
+		next, last, status := "", "never", "ok"
+		if !t.NextRun.IsZero() {
+			next = t.NextRun.Local().Format("2006-01-02 15:04")
+		}
+		if !t.LastRun.IsZero() {
+			last = t.LastRun.Local().Format("2006-01-02 15:04")
+		}
+		switch {
+		case t.Disabled:
+			status = "disabled"
+			next = ""
+		case t.LastRun.IsZero():
+			status = "pending"
+		case t.LastExitCode != 0 || t.LastError != "":
+			status = "failed"
+		}
+		rows[i] = []string{t.Name, t.Cron, t.Command(), next, last, status}
+	}
+	return rows
+}
+
+func (l *ScheduleList) EmptyMessage() string {
+	return "No scheduled tasks"
+}
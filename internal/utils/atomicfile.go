@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// BackupSuffix names the copy of a file's last good contents kept by
+// ReplaceFile
+const BackupSuffix = ".bak"
+
+// CorruptSuffix names a corrupt file set aside by RepairFile
+const CorruptSuffix = ".corrupt"
+
+// File statuses reported by RepairFile
+const (
+	FileOK         = "ok"
+	FileMissing    = "missing"
+	FileRestorable = "restorable"
+	FileRestored   = "restored"
+	FileCorrupt    = "corrupt"
+)
+
+// FileCheck is the result of checking one file with RepairFile
+type FileCheck struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Recovery records a corrupt file that ReadFileWithBackup read from its
+// backup instead
+type Recovery struct {
+	Path   string
+	Reason string
+}
+
+var (
+	recoveredMu sync.Mutex
+	recovered   = map[string]bool{}
+	recoveries  []Recovery
+)
+
+// ValidJSON reports why data is not a JSON document, such as a file cut
+// short by a crash
+func ValidJSON(data []byte) error {
+	var v json.RawMessage
+	return json.Unmarshal(data, &v)
+}
+
+// WriteFileAtomic replaces path with data through a temp file in the same
+// directory, synced before it is renamed over path, so a crash leaves
+// either the old or the new contents and never a mix.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fail(err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// ReplaceFile writes data to path like WriteFileAtomic, first keeping the
+// current contents as path + BackupSuffix. The current contents are kept
+// only if valid accepts them, so a corrupt file never replaces a good
+// backup.
+func ReplaceFile(path string, data []byte, perm os.FileMode, valid func([]byte) error) error {
+	if current, err := os.ReadFile(path); err == nil && valid(current) == nil {
+		if err := WriteFileAtomic(path+BackupSuffix, current, perm); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+	return WriteFileAtomic(path, data, perm)
+}
+
+// ReadFileWithBackup reads path and checks it with valid. When path is
+// corrupt and its backup is not, the backup is returned instead and the
+// fallback is recorded for TakeRecoveries, since the caller carries on as
+// if path were intact; the files are left for 'gdrv config repair'. Each
+// path is recorded once, however often it is read. A missing path is
+// returned as the os.ErrNotExist error from reading it.
+func ReadFileWithBackup(path string, valid func([]byte) error) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	invalid := valid(data)
+	if invalid == nil {
+		return data, nil
+	}
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil || valid(backup) != nil {
+		return nil, fmt.Errorf("%s is corrupt and has no usable backup: %w", path, invalid)
+	}
+	recoveredMu.Lock()
+	if !recovered[path] {
+		recovered[path] = true
+		recoveries = append(recoveries, Recovery{Path: path, Reason: invalid.Error()})
+	}
+	recoveredMu.Unlock()
+	return backup, nil
+}
+
+// TakeRecoveries returns the files ReadFileWithBackup fell back to backups
+// for since the last call, for the caller to report
+func TakeRecoveries() []Recovery {
+	recoveredMu.Lock()
+	defer recoveredMu.Unlock()
+	taken := recoveries
+	recoveries = nil
+	return taken
+}
+
+// RepairFile checks path with valid and, when it is corrupt or missing but
+// its backup is good, restores the backup with the file's own mode. A
+// corrupt file is set aside as path + CorruptSuffix + "." + a UTC
+// timestamp. With restore false nothing is changed and a recoverable file
+// is reported as FileRestorable.
+func RepairFile(path string, valid func([]byte) error, restore bool) FileCheck {
+	check := FileCheck{Path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := valid(data); err != nil {
+			check.Status, check.Error = FileCorrupt, err.Error()
+		} else {
+			check.Status = FileOK
+			return check
+		}
+	case errors.Is(err, os.ErrNotExist):
+		check.Status = FileMissing
+	default:
+		check.Status, check.Error = FileCorrupt, err.Error()
+	}
+
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil || valid(backup) != nil {
+		return check
+	}
+	if !restore {
+		check.Status = FileRestorable
+		return check
+	}
+	// A missing file takes the backup's mode, which ReplaceFile wrote
+	// with the same mode as the file
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if info, err := os.Stat(path + BackupSuffix); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if check.Status == FileCorrupt {
+		aside := fmt.Sprintf("%s%s.%s", path, CorruptSuffix, time.Now().UTC().Format("20060102T150405Z"))
+		if err := os.Rename(path, aside); err != nil {
+			check.Error = fmt.Sprintf("failed to set aside corrupt file: %v", err)
+			return check
+		}
+	}
+	if err := WriteFileAtomic(path, backup, mode); err != nil {
+		check.Error = fmt.Sprintf("failed to restore backup: %v", err)
+		return check
+	}
+	check.Status, check.Error = FileRestored, ""
+	return check
+}
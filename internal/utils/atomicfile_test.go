@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceFile_KeepsLastGoodBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	if err := ReplaceFile(path, []byte(`{"v":1}`), 0600, ValidJSON); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if _, err := os.Stat(path + BackupSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup after the first write, got %v", err)
+	}
+	if err := ReplaceFile(path, []byte(`{"v":2}`), 0600, ValidJSON); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+	if got, _ := os.ReadFile(path + BackupSuffix); string(got) != `{"v":1}` {
+		t.Fatalf("backup = %q, want the previous contents", got)
+	}
+
+	// A corrupt file is not backed up over the good backup
+	if err := os.WriteFile(path, []byte(`{"v":`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceFile(path, []byte(`{"v":3}`), 0600, ValidJSON); err != nil {
+		t.Fatalf("third write: %v", err)
+	}
+	if got, _ := os.ReadFile(path + BackupSuffix); string(got) != `{"v":1}` {
+		t.Fatalf("backup = %q, want it kept", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestReadFileWithBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "creds.json")
+
+	if _, err := ReadFileWithBackup(path, ValidJSON); !os.IsNotExist(err) {
+		t.Fatalf("missing file: err = %v, want not-exist", err)
+	}
+
+	if err := os.WriteFile(path, []byte(``), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFileWithBackup(path, ValidJSON); err == nil || !strings.Contains(err.Error(), "no usable backup") {
+		t.Fatalf("corrupt file without backup: err = %v", err)
+	}
+
+	if err := os.WriteFile(path+BackupSuffix, []byte(`{"ok":true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadFileWithBackup(path, ValidJSON)
+	if err != nil {
+		t.Fatalf("corrupt file with backup: %v", err)
+	}
+	if string(data) != `{"ok":true}` {
+		t.Errorf("data = %q, want the backup", data)
+	}
+	if got, _ := os.ReadFile(path); len(got) != 0 {
+		t.Errorf("reading changed the corrupt file to %q", got)
+	}
+	if got := TakeRecoveries(); len(got) != 1 || got[0].Path != path || got[0].Reason == "" {
+		t.Errorf("recoveries = %+v, want one for %s", got, path)
+	}
+	if _, err := ReadFileWithBackup(path, ValidJSON); err != nil {
+		t.Fatal(err)
+	}
+	if got := TakeRecoveries(); len(got) != 0 {
+		t.Errorf("rereading recorded %+v again", got)
+	}
+}
+
+func TestRepairFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if got := RepairFile(path, ValidJSON, true); got.Status != FileMissing {
+		t.Fatalf("missing: status = %s", got.Status)
+	}
+	if err := os.WriteFile(path, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepairFile(path, ValidJSON, true); got.Status != FileOK {
+		t.Fatalf("good file: status = %s", got.Status)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"trunc`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepairFile(path, ValidJSON, true); got.Status != FileCorrupt || got.Error == "" {
+		t.Fatalf("corrupt without backup: %+v", got)
+	}
+
+	if err := os.WriteFile(path+BackupSuffix, []byte(`{"good":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepairFile(path, ValidJSON, false); got.Status != FileRestorable {
+		t.Fatalf("dry run: status = %s", got.Status)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"trunc` {
+		t.Fatalf("dry run changed the file to %q", got)
+	}
+
+	if got := RepairFile(path, ValidJSON, true); got.Status != FileRestored || got.Error != "" {
+		t.Fatalf("restore: %+v", got)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"good":1}` {
+		t.Errorf("restored contents = %q", got)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0644 {
+		t.Errorf("restored mode = %v, want the file's 0644", info.Mode().Perm())
+	}
+	aside, _ := filepath.Glob(path + CorruptSuffix + ".*")
+	if len(aside) != 1 {
+		t.Fatalf("corrupt file set aside as %v, want one file", aside)
+	}
+	if got, _ := os.ReadFile(aside[0]); string(got) != `{"trunc` {
+		t.Errorf("set-aside contents = %q", got)
+	}
+}
@@ -12,27 +12,28 @@ const RevisionKeepForeverLimit = 200
 
 // OAuth scopes
 const (
-	ScopeFull                        = "https://www.googleapis.com/auth/drive"
-	ScopeFile                        = "https://www.googleapis.com/auth/drive.file"
-	ScopeReadonly                    = "https://www.googleapis.com/auth/drive.readonly"
-	ScopeMetadataReadonly            = "https://www.googleapis.com/auth/drive.metadata.readonly"
-	ScopeAppdata                     = "https://www.googleapis.com/auth/drive.appdata"
-	ScopeSheets                      = "https://www.googleapis.com/auth/spreadsheets"
-	ScopeSheetsReadonly              = "https://www.googleapis.com/auth/spreadsheets.readonly"
-	ScopeDocs                        = "https://www.googleapis.com/auth/documents"
-	ScopeDocsReadonly                = "https://www.googleapis.com/auth/documents.readonly"
-	ScopeSlides                      = "https://www.googleapis.com/auth/presentations"
-	ScopeSlidesReadonly              = "https://www.googleapis.com/auth/presentations.readonly"
-	ScopeAdminDirectoryUser          = "https://www.googleapis.com/auth/admin.directory.user"
-	ScopeAdminDirectoryUserReadonly  = "https://www.googleapis.com/auth/admin.directory.user.readonly"
-	ScopeAdminDirectoryGroup         = "https://www.googleapis.com/auth/admin.directory.group"
-	ScopeAdminDirectoryGroupReadonly = "https://www.googleapis.com/auth/admin.directory.group.readonly"
-	ScopeLabels                      = "https://www.googleapis.com/auth/drive.labels"
-	ScopeLabelsReadonly              = "https://www.googleapis.com/auth/drive.labels.readonly"
-	ScopeAdminLabels                 = "https://www.googleapis.com/auth/drive.admin.labels"
-	ScopeAdminLabelsReadonly         = "https://www.googleapis.com/auth/drive.admin.labels.readonly"
-	ScopeActivity                    = "https://www.googleapis.com/auth/drive.activity"
-	ScopeActivityReadonly            = "https://www.googleapis.com/auth/drive.activity.readonly"
+	ScopeFull                         = "https://www.googleapis.com/auth/drive"
+	ScopeFile                         = "https://www.googleapis.com/auth/drive.file"
+	ScopeReadonly                     = "https://www.googleapis.com/auth/drive.readonly"
+	ScopeMetadataReadonly             = "https://www.googleapis.com/auth/drive.metadata.readonly"
+	ScopeAppdata                      = "https://www.googleapis.com/auth/drive.appdata"
+	ScopeSheets                       = "https://www.googleapis.com/auth/spreadsheets"
+	ScopeSheetsReadonly               = "https://www.googleapis.com/auth/spreadsheets.readonly"
+	ScopeDocs                         = "https://www.googleapis.com/auth/documents"
+	ScopeDocsReadonly                 = "https://www.googleapis.com/auth/documents.readonly"
+	ScopeSlides                       = "https://www.googleapis.com/auth/presentations"
+	ScopeSlidesReadonly               = "https://www.googleapis.com/auth/presentations.readonly"
+	ScopeAdminDirectoryUser           = "https://www.googleapis.com/auth/admin.directory.user"
+	ScopeAdminDirectoryUserReadonly   = "https://www.googleapis.com/auth/admin.directory.user.readonly"
+	ScopeAdminDirectoryGroup          = "https://www.googleapis.com/auth/admin.directory.group"
+	ScopeAdminDirectoryGroupReadonly  = "https://www.googleapis.com/auth/admin.directory.group.readonly"
+	ScopeAdminDirectoryDomainReadonly = "https://www.googleapis.com/auth/admin.directory.domain.readonly"
+	ScopeLabels                       = "https://www.googleapis.com/auth/drive.labels"
+	ScopeLabelsReadonly               = "https://www.googleapis.com/auth/drive.labels.readonly"
+	ScopeAdminLabels                  = "https://www.googleapis.com/auth/drive.admin.labels"
+	ScopeAdminLabelsReadonly          = "https://www.googleapis.com/auth/drive.admin.labels.readonly"
+	ScopeActivity                     = "https://www.googleapis.com/auth/drive.activity"
+	ScopeActivityReadonly             = "https://www.googleapis.com/auth/drive.activity.readonly"
 )
 
 var (
@@ -55,6 +56,7 @@ var (
 	ScopesAdmin = []string{
 		ScopeAdminDirectoryUser,
 		ScopeAdminDirectoryGroup,
+		ScopeAdminDirectoryDomainReadonly,
 		ScopeAdminLabels,
 	}
 	ScopesWorkspaceWithAdmin = []string{
@@ -64,6 +66,7 @@ var (
 		ScopeSlides,
 		ScopeAdminDirectoryUser,
 		ScopeAdminDirectoryGroup,
+		ScopeAdminDirectoryDomainReadonly,
 		ScopeLabels,
 		ScopeAdminLabels,
 	}
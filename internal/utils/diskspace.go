@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FreeDiskSpace returns the bytes available to the current user on the
+// filesystem that holds path. If path does not exist yet, the nearest
+// existing parent directory is used.
+func FreeDiskSpace(path string) (uint64, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return freeDiskSpace(dir)
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package utils
+
+import "errors"
+
+// MaxPathLength and MaxNameLength are conservative local limits for
+// platforms without a specific implementation.
+const (
+	MaxPathLength = 1024
+	MaxNameLength = 255
+)
+
+var errDiskSpaceUnsupported = errors.New("free disk space is not available on this platform")
+
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import "syscall"
+
+// MaxPathLength and MaxNameLength are the local limits, in bytes, for a
+// full path and a single path element. Linux allows 4096-byte paths, but
+// macOS and FreeBSD stop at 1024, so the smaller limit is used everywhere.
+const (
+	MaxPathLength = 1024
+	MaxNameLength = 255
+)
+
+func freeDiskSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "golang.org/x/sys/windows"
+
+// MaxPathLength and MaxNameLength are the local limits for a full path and a
+// single path element. Windows applies MAX_PATH unless long paths are
+// enabled system-wide, so the conservative limit is used.
+const (
+	MaxPathLength = 260
+	MaxNameLength = 255
+)
+
+func freeDiskSpace(dir string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -21,16 +21,19 @@ const (
 	ExitExportSizeLimit          = 23
 	ExitRevisionNotDownloadable  = 24
 	ExitRevisionKeepForeverLimit = 25
+	ExitChecksumMismatch         = 26
+	ExitNameConflict             = 27
 	// Network errors (30-39)
 	ExitNetworkError     = 30
 	ExitTimeout          = 31
 	ExitRateLimited      = 32
 	ExitOperationExpired = 33
 	// Validation errors (40-49)
-	ExitInvalidArgument = 40
-	ExitInvalidPath     = 41
-	ExitAmbiguousPath   = 42
-	ExitInvalidMimeType = 43
+	ExitInvalidArgument       = 40
+	ExitInvalidPath           = 41
+	ExitAmbiguousPath         = 42
+	ExitInvalidMimeType       = 43
+	ExitUnsupportedConversion = 44
 	// Policy errors (50-59)
 	ExitPolicyViolation   = 50
 	ExitSharingRestricted = 51
@@ -47,13 +50,17 @@ const (
 	ErrCodeAuthClientMissing        = "AUTH_CLIENT_MISSING"
 	ErrCodeAuthClientInvalid        = "AUTH_CLIENT_INVALID"
 	ErrCodeAuthClientPartial        = "AUTH_CLIENT_PARTIAL"
+	ErrCodeDelegationNotConfigured  = "DELEGATION_NOT_CONFIGURED"
 	ErrCodeScopeInsufficient        = "SCOPE_INSUFFICIENT"
+	ErrCodeAPIDisabled              = "API_DISABLED"
 	ErrCodeFileNotFound             = "FILE_NOT_FOUND"
 	ErrCodePermissionDenied         = "PERMISSION_DENIED"
 	ErrCodeQuotaExceeded            = "QUOTA_EXCEEDED"
 	ErrCodeExportSizeLimit          = "EXPORT_SIZE_LIMIT"
 	ErrCodeRevisionNotDownloadable  = "REVISION_NOT_DOWNLOADABLE"
 	ErrCodeRevisionKeepForeverLimit = "REVISION_KEEP_FOREVER_LIMIT"
+	ErrCodeChecksumMismatch         = "CHECKSUM_MISMATCH"
+	ErrCodeNameConflict             = "NAME_CONFLICT"
 	ErrCodeNetworkError             = "NETWORK_ERROR"
 	ErrCodeTimeout                  = "TIMEOUT"
 	ErrCodeRateLimited              = "RATE_LIMITED"
@@ -62,11 +69,17 @@ const (
 	ErrCodeInvalidPath              = "INVALID_PATH"
 	ErrCodeAmbiguousPath            = "AMBIGUOUS_PATH"
 	ErrCodeInvalidMimeType          = "INVALID_MIME_TYPE"
+	ErrCodeUnsupportedConversion    = "UNSUPPORTED_CONVERSION"
 	ErrCodePolicyViolation          = "POLICY_VIOLATION"
 	ErrCodeSharingRestricted        = "SHARING_RESTRICTED"
 	ErrCodeBatchPartialFailure      = "BATCH_PARTIAL_FAILURE"
+	ErrCodeReadOnly                 = "READ_ONLY_MODE"
 	ErrCodeCancelled                = "CANCELLED"
 	ErrCodeResourceLimit            = "RESOURCE_LIMIT"
+	ErrCodeStateVersion             = "STATE_VERSION_UNSUPPORTED"
+	ErrCodeDryRun                   = "DRY_RUN_UNSUPPORTED"
+	ErrCodeApprovalRequired         = "APPROVAL_REQUIRED"
+	ErrCodeApprovalInvalid          = "APPROVAL_INVALID"
 	ErrCodeInternalError            = "INTERNAL_ERROR"
 	ErrCodeUnknown                  = "UNKNOWN"
 )
@@ -121,13 +134,17 @@ func GetExitCode(errorCode string) int {
 		ErrCodeAuthClientMissing:        ExitAuthRequired,
 		ErrCodeAuthClientInvalid:        ExitAuthRequired,
 		ErrCodeAuthClientPartial:        ExitAuthRequired,
+		ErrCodeDelegationNotConfigured:  ExitAuthRequired,
 		ErrCodeScopeInsufficient:        ExitScopeInsufficient,
+		ErrCodeAPIDisabled:              ExitPermissionDenied,
 		ErrCodeFileNotFound:             ExitFileNotFound,
 		ErrCodePermissionDenied:         ExitPermissionDenied,
 		ErrCodeQuotaExceeded:            ExitQuotaExceeded,
 		ErrCodeExportSizeLimit:          ExitExportSizeLimit,
 		ErrCodeRevisionNotDownloadable:  ExitRevisionNotDownloadable,
 		ErrCodeRevisionKeepForeverLimit: ExitRevisionKeepForeverLimit,
+		ErrCodeChecksumMismatch:         ExitChecksumMismatch,
+		ErrCodeNameConflict:             ExitNameConflict,
 		ErrCodeNetworkError:             ExitNetworkError,
 		ErrCodeTimeout:                  ExitTimeout,
 		ErrCodeRateLimited:              ExitRateLimited,
@@ -136,9 +153,15 @@ func GetExitCode(errorCode string) int {
 		ErrCodeInvalidPath:              ExitInvalidPath,
 		ErrCodeAmbiguousPath:            ExitAmbiguousPath,
 		ErrCodeInvalidMimeType:          ExitInvalidMimeType,
+		ErrCodeUnsupportedConversion:    ExitUnsupportedConversion,
 		ErrCodePolicyViolation:          ExitPolicyViolation,
 		ErrCodeSharingRestricted:        ExitSharingRestricted,
 		ErrCodeBatchPartialFailure:      ExitBatchPartialFailure,
+		ErrCodeReadOnly:                 ExitPolicyViolation,
+		ErrCodeStateVersion:             ExitInvalidArgument,
+		ErrCodeDryRun:                   ExitPolicyViolation,
+		ErrCodeApprovalRequired:         ExitPolicyViolation,
+		ErrCodeApprovalInvalid:          ExitPolicyViolation,
 	}
 	if code, ok := mapping[errorCode]; ok {
 		return code
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1048576", 1048576, false},
+		{"256K", 256 * 1024, false},
+		{"256KiB", 256 * 1024, false},
+		{"32M", 32 * 1024 * 1024, false},
+		{"32mb", 32 * 1024 * 1024, false},
+		{"1G", 1024 * 1024 * 1024, false},
+		{"", 0, true},
+		{"M", 0, true},
+		{"-1M", 0, true},
+		{"12X", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:              "512 B",
+		1536:             "1.5 KB",
+		32 * 1024 * 1024: "32.0 MB",
+	}
+	for in, want := range tests {
+		if got := FormatSize(in); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFreeDiskSpace_MissingPath(t *testing.T) {
+	free, err := FreeDiskSpace(filepath.Join(t.TempDir(), "not", "created", "yet"))
+	if err != nil {
+		t.Skipf("free disk space unavailable: %v", err)
+	}
+	if free == 0 {
+		t.Error("expected free space for the nearest existing parent")
+	}
+}
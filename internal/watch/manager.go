@@ -0,0 +1,208 @@
+// Package watch opens, renews and stops Drive push notification channels,
+// which deliver a webhook request when a file or the change feed changes.
+// Drive gives channels a fixed lifetime; they are renewed by opening a new
+// channel and stopping the old one.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"github.com/dl-alexandre/gdrv/internal/utils"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+)
+
+// Longest lifetimes Drive accepts for a channel
+const (
+	MaxFileTTL    = 24 * time.Hour
+	MaxChangesTTL = 7 * 24 * time.Hour
+)
+
+// Options configures a new channel
+type Options struct {
+	Address string        // HTTPS URL receiving notifications (required)
+	Token   string        // Sent with every notification, to verify its origin
+	TTL     time.Duration // Requested lifetime (0 = Drive's default of an hour)
+	DriveID string        // For change channels, watch this Shared Drive's feed
+}
+
+type Manager struct {
+	client *api.Client
+	shaper *api.RequestShaper
+	now    func() time.Time
+}
+
+func NewManager(client *api.Client) *Manager {
+	return &Manager{
+		client: client,
+		shaper: api.NewRequestShaper(client),
+		now:    time.Now,
+	}
+}
+
+// WatchFile opens a channel notified when the file changes
+func (m *Manager) WatchFile(ctx context.Context, reqCtx *types.RequestContext, fileID string, opts Options) (*Channel, error) {
+	if fileID == "" {
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument, "A file ID is required").Build())
+	}
+	if err := validateOptions(opts, MaxFileTTL); err != nil {
+		return nil, err
+	}
+
+	reqCtx.InvolvedFileIDs = append(reqCtx.InvolvedFileIDs, fileID)
+	channel := m.newChannel(opts)
+	call := m.shaper.ShapeFilesWatch(m.client.Service().Files.Watch(fileID, channel), reqCtx)
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Channel, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	record := m.record(result, KindFile, reqCtx.Profile, opts)
+	record.FileID = fileID
+	return record, nil
+}
+
+// WatchChanges opens a channel notified when the change feed of the user,
+// or of opts.DriveID, has new changes
+func (m *Manager) WatchChanges(ctx context.Context, reqCtx *types.RequestContext, opts Options) (*Channel, error) {
+	if err := validateOptions(opts, MaxChangesTTL); err != nil {
+		return nil, err
+	}
+
+	tokenCall := m.client.Service().Changes.GetStartPageToken().SupportsAllDrives(true)
+	if opts.DriveID != "" {
+		tokenCall = tokenCall.DriveId(opts.DriveID)
+	}
+	start, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.StartPageToken, error) {
+		return tokenCall.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	call := m.client.Service().Changes.Watch(start.StartPageToken, m.newChannel(opts)).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	if opts.DriveID != "" {
+		call = call.DriveId(opts.DriveID)
+	}
+	result, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*drive.Channel, error) {
+		return call.Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.record(result, KindChanges, reqCtx.Profile, opts), nil
+}
+
+// Stop stops a channel. A channel Drive no longer knows, because it
+// expired or was already stopped, counts as stopped.
+func (m *Manager) Stop(ctx context.Context, reqCtx *types.RequestContext, channel *Channel) error {
+	if channel.ID == "" || channel.ResourceID == "" {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"Stopping a channel needs its channel ID and resource ID").Build())
+	}
+	_, err := api.ExecuteWithRetry(ctx, m.client, reqCtx, func() (*struct{}, error) {
+		return &struct{}{}, m.client.Service().Channels.Stop(&drive.Channel{Id: channel.ID, ResourceId: channel.ResourceID}).Do()
+	})
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Renew replaces a channel with a new one watching the same file or feed,
+// with the same address, token and lifetime, and then stops the old one.
+// If stopping the old channel fails, the new channel is returned with the
+// error; both channels then deliver notifications until the old expires.
+func (m *Manager) Renew(ctx context.Context, reqCtx *types.RequestContext, old *Channel) (*Channel, error) {
+	opts := Options{Address: old.Address, Token: old.Token, TTL: old.TTL, DriveID: old.DriveID}
+	var renewed *Channel
+	var err error
+	switch old.Kind {
+	case KindFile:
+		renewed, err = m.WatchFile(ctx, reqCtx, old.FileID, opts)
+	case KindChanges:
+		renewed, err = m.WatchChanges(ctx, reqCtx, opts)
+	default:
+		return nil, utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("Channel %s has unknown kind %q", old.ID, old.Kind)).Build())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if old.Expired(m.now()) {
+		return renewed, nil
+	}
+	return renewed, m.Stop(ctx, reqCtx, old)
+}
+
+// DueForRenewal returns the channels expiring within the given time of
+// now, including those already expired
+func DueForRenewal(channels []*Channel, within time.Duration, now time.Time) []*Channel {
+	var due []*Channel
+	for _, c := range channels {
+		if !c.Expiration.IsZero() && c.Expiration.Before(now.Add(within)) {
+			due = append(due, c)
+		}
+	}
+	return due
+}
+
+func (m *Manager) newChannel(opts Options) *drive.Channel {
+	channel := &drive.Channel{
+		Id:      "gdrv-" + uuid.New().String(),
+		Type:    "web_hook",
+		Address: opts.Address,
+		Token:   opts.Token,
+	}
+	if opts.TTL > 0 {
+		channel.Expiration = m.now().Add(opts.TTL).UnixMilli()
+	}
+	return channel
+}
+
+func (m *Manager) record(result *drive.Channel, kind, profile string, opts Options) *Channel {
+	channel := &Channel{
+		ID:         result.Id,
+		ResourceID: result.ResourceId,
+		Kind:       kind,
+		DriveID:    opts.DriveID,
+		Address:    opts.Address,
+		Token:      opts.Token,
+		Profile:    profile,
+		CreatedAt:  m.now().UTC(),
+		TTL:        opts.TTL,
+		Status:     StatusActive,
+	}
+	if result.Expiration > 0 {
+		channel.Expiration = time.UnixMilli(result.Expiration).UTC()
+	}
+	return channel
+}
+
+func validateOptions(opts Options, maxTTL time.Duration) error {
+	u, err := url.Parse(opts.Address)
+	if opts.Address == "" || err != nil || u.Scheme != "https" || u.Host == "" {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			"The notification address must be an https:// URL").
+			WithContext("address", opts.Address).Build())
+	}
+	if opts.TTL < 0 || opts.TTL > maxTTL {
+		return utils.NewAppError(utils.NewCLIError(utils.ErrCodeInvalidArgument,
+			fmt.Sprintf("The channel lifetime must be between 0 and %s", maxTTL)).Build())
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	appErr, ok := err.(*utils.AppError)
+	return ok && (appErr.CLIError.Code == utils.ErrCodeFileNotFound || appErr.CLIError.HTTPStatus == http.StatusNotFound)
+}
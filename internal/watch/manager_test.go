@@ -0,0 +1,194 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	testhelpers "github.com/dl-alexandre/gdrv/internal/testing"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+// channelServer serves the channel endpoints of the Drive API and records
+// the channels opened and stopped
+type channelServer struct {
+	mu      sync.Mutex
+	opened  []*drive.Channel
+	stopped []string
+	known   map[string]bool
+}
+
+func newTestManager(t *testing.T, now time.Time) (*Manager, *channelServer) {
+	t.Helper()
+	cs := &channelServer{known: map[string]bool{}}
+	client, _ := testhelpers.NewDriveClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+		switch {
+		case r.URL.Path == "/drive/v3/changes/startPageToken":
+			_ = json.NewEncoder(w).Encode(&drive.StartPageToken{StartPageToken: "42"})
+		case r.URL.Path == "/drive/v3/changes/watch" && r.URL.Query().Get("pageToken") != "42":
+			http.Error(w, `{"error":{"code":400,"message":"Invalid page token"}}`, http.StatusBadRequest)
+		case r.URL.Path == "/drive/v3/changes/watch" || strings.HasSuffix(r.URL.Path, "/watch"):
+			var ch drive.Channel
+			_ = json.NewDecoder(r.Body).Decode(&ch)
+			cs.opened = append(cs.opened, &ch)
+			cs.known[ch.Id] = true
+			ch.ResourceId = "res-" + ch.Id
+			if ch.Expiration == 0 {
+				ch.Expiration = now.Add(time.Hour).UnixMilli()
+			}
+			_ = json.NewEncoder(w).Encode(&ch)
+		case r.URL.Path == "/drive/v3/channels/stop":
+			var ch drive.Channel
+			_ = json.NewDecoder(r.Body).Decode(&ch)
+			if !cs.known[ch.Id] {
+				http.Error(w, `{"error":{"code":404,"message":"Channel not found"}}`, http.StatusNotFound)
+				return
+			}
+			delete(cs.known, ch.Id)
+			cs.stopped = append(cs.stopped, ch.Id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	mgr := NewManager(client)
+	mgr.now = func() time.Time { return now }
+	return mgr, cs
+}
+
+func TestWatchAndRenew(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mgr, cs := newTestManager(t, now)
+	reqCtx := api.NewRequestContext("work", "", types.RequestTypeGetByID)
+	opts := Options{Address: "https://example.com/hook", Token: "s3cret", TTL: 6 * time.Hour}
+
+	file, err := mgr.WatchFile(context.Background(), reqCtx, "f1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Kind != KindFile || file.FileID != "f1" || file.Profile != "work" || file.ResourceID != "res-"+file.ID {
+		t.Errorf("file channel = %+v", file)
+	}
+	if !file.Expiration.Equal(now.Add(6 * time.Hour)) {
+		t.Errorf("expiration = %s", file.Expiration)
+	}
+	if got := cs.opened[0]; got.Type != "web_hook" || got.Token != "s3cret" || !strings.HasPrefix(got.Id, "gdrv-") {
+		t.Errorf("requested channel = %+v", got)
+	}
+
+	feed, err := mgr.WatchChanges(context.Background(), reqCtx, Options{Address: opts.Address, DriveID: "d1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Kind != KindChanges || feed.DriveID != "d1" || !feed.Expiration.Equal(now.Add(time.Hour)) {
+		t.Errorf("changes channel = %+v", feed)
+	}
+
+	renewed, err := mgr.Renew(context.Background(), reqCtx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.ID == file.ID || renewed.FileID != "f1" || renewed.TTL != opts.TTL || renewed.Token != "s3cret" {
+		t.Errorf("renewed channel = %+v", renewed)
+	}
+	if len(cs.stopped) != 1 || cs.stopped[0] != file.ID {
+		t.Errorf("stopped = %v, want the old channel", cs.stopped)
+	}
+
+	// Drive no longer knowing a channel counts as stopped
+	if err := mgr.Stop(context.Background(), reqCtx, file); err != nil {
+		t.Errorf("stopping an unknown channel: %v", err)
+	}
+
+	// Expired channels are reopened without being stopped
+	expired := *feed
+	expired.Expiration = now.Add(-time.Minute)
+	if _, err := mgr.Renew(context.Background(), reqCtx, &expired); err != nil {
+		t.Fatal(err)
+	}
+	if len(cs.stopped) != 1 {
+		t.Errorf("stopped = %v, expired channel should not be stopped", cs.stopped)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		max  time.Duration
+		ok   bool
+	}{
+		{"valid", Options{Address: "https://example.com/hook", TTL: time.Hour}, MaxFileTTL, true},
+		{"default ttl", Options{Address: "https://example.com/hook"}, MaxFileTTL, true},
+		{"missing address", Options{}, MaxFileTTL, false},
+		{"plain http", Options{Address: "http://example.com/hook"}, MaxFileTTL, false},
+		{"no host", Options{Address: "https:///hook"}, MaxFileTTL, false},
+		{"file ttl too long", Options{Address: "https://example.com", TTL: 48 * time.Hour}, MaxFileTTL, false},
+		{"changes ttl", Options{Address: "https://example.com", TTL: 48 * time.Hour}, MaxChangesTTL, true},
+		{"negative ttl", Options{Address: "https://example.com", TTL: -time.Hour}, MaxChangesTTL, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateOptions(tt.opts, tt.max); (err == nil) != tt.ok {
+				t.Errorf("validateOptions = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStoreAndDueForRenewal(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(filepath.Join(t.TempDir(), ChannelsFileName))
+	if channels, err := store.List(now); err != nil || len(channels) != 0 {
+		t.Fatalf("empty store = %v, %v", channels, err)
+	}
+	for _, c := range []*Channel{
+		{ID: "later", Kind: KindFile, FileID: "f1", Expiration: now.Add(5 * time.Hour)},
+		{ID: "soon", Kind: KindChanges, Expiration: now.Add(30 * time.Minute)},
+		{ID: "gone", Kind: KindFile, FileID: "f2", Expiration: now.Add(-time.Hour)},
+		{ID: "forever", Kind: KindFile, FileID: "f3"},
+	} {
+		if err := store.Put(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Put(&Channel{ID: "later", Kind: KindFile, FileID: "f1", Expiration: now.Add(4 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	channels, err := store.List(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, c := range channels {
+		ids = append(ids, c.ID+":"+c.Status)
+	}
+	if got := strings.Join(ids, ","); got != "forever:active,gone:expired,soon:active,later:active" {
+		t.Errorf("channels = %s", got)
+	}
+
+	due := DueForRenewal(channels, time.Hour, now)
+	if len(due) != 2 || due[0].ID != "gone" || due[1].ID != "soon" {
+		t.Errorf("due = %v", due)
+	}
+
+	if err := store.Remove("gone"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := store.Get("gone"); c != nil {
+		t.Errorf("removed channel = %+v", c)
+	}
+	if c, _ := store.Get("later"); c == nil || !c.Expiration.Equal(now.Add(4*time.Hour)) {
+		t.Errorf("replaced channel = %+v", c)
+	}
+}
@@ -0,0 +1,199 @@
+package watch
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dl-alexandre/gdrv/internal/config"
+	"github.com/dl-alexandre/gdrv/internal/state"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+// ChannelsFileName is the file in the config directory recording the
+// notification channels opened by gdrv
+const ChannelsFileName = "watch-channels.json"
+
+// ChannelsStateKind versions the notification channel records
+var ChannelsStateKind = state.Register(&state.Kind{
+	Name:        "watch-channels",
+	Description: "Push notification channels opened with 'gdrv watch create'",
+	Current:     1,
+	DefaultPath: func() (string, error) {
+		s, err := DefaultStore()
+		if err != nil {
+			return "", err
+		}
+		return s.Path(), nil
+	},
+	FileName: ChannelsFileName,
+})
+
+// Channel kinds
+const (
+	KindFile    = "file"    // Notifications for one file
+	KindChanges = "changes" // Notifications for the change feed
+)
+
+// Channel statuses reported by List
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+)
+
+// Channel records a notification channel so it can be listed, stopped and
+// renewed later. ResourceID is needed to stop the channel.
+type Channel struct {
+	ID         string    `json:"id"`
+	ResourceID string    `json:"resourceId"`
+	Kind       string    `json:"kind"`
+	FileID     string    `json:"fileId,omitempty"`
+	DriveID    string    `json:"driveId,omitempty"`
+	Address    string    `json:"address"`
+	Token      string    `json:"token,omitempty"`
+	Profile    string    `json:"profile,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Expiration time.Time `json:"expiration"`
+	// TTL is the lifetime asked for when the channel was opened; renewals
+	// ask for the same (0 = Drive's default)
+	TTL    time.Duration `json:"ttl,omitempty"`
+	Status string        `json:"status,omitempty"`
+}
+
+// Expired reports whether the channel has expired at now
+func (c *Channel) Expired(now time.Time) bool {
+	return !c.Expiration.IsZero() && !now.Before(c.Expiration)
+}
+
+// Store records notification channels in a local file
+type Store struct {
+	path string
+}
+
+type channelsFile struct {
+	state.Versioned
+	Channels []*Channel `json:"channels"`
+}
+
+// NewStore returns a store backed by the given file
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store in the gdrv config directory
+func DefaultStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, ChannelsFileName)), nil
+}
+
+// Path returns the backing file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns the recorded channels, soonest expiry first, with their
+// status at now
+func (s *Store) List(now time.Time) ([]*Channel, error) {
+	var file channelsFile
+	if _, err := state.Load(ChannelsStateKind, s.path, &file); err != nil {
+		return nil, err
+	}
+	for _, c := range file.Channels {
+		c.Status = StatusActive
+		if c.Expired(now) {
+			c.Status = StatusExpired
+		}
+	}
+	sort.SliceStable(file.Channels, func(i, j int) bool {
+		return file.Channels[i].Expiration.Before(file.Channels[j].Expiration)
+	})
+	return file.Channels, nil
+}
+
+// Get returns the channel with the given ID, or nil
+func (s *Store) Get(id string) (*Channel, error) {
+	channels, err := s.List(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range channels {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+// Put records a channel, replacing one with the same ID
+func (s *Store) Put(channel *Channel) error {
+	return s.update(func(channels []*Channel) []*Channel {
+		for i, c := range channels {
+			if c.ID == channel.ID {
+				channels[i] = channel
+				return channels
+			}
+		}
+		return append(channels, channel)
+	})
+}
+
+// Remove forgets the channel with the given ID
+func (s *Store) Remove(id string) error {
+	return s.update(func(channels []*Channel) []*Channel {
+		kept := channels[:0]
+		for _, c := range channels {
+			if c.ID != id {
+				kept = append(kept, c)
+			}
+		}
+		return kept
+	})
+}
+
+func (s *Store) update(change func([]*Channel) []*Channel) error {
+	var file channelsFile
+	if _, err := state.Load(ChannelsStateKind, s.path, &file); err != nil {
+		return err
+	}
+	channels := change(file.Channels)
+	for _, c := range channels {
+		// The status is derived when listing
+		c.Status = ""
+	}
+	return state.Save(ChannelsStateKind, s.path, &channelsFile{Channels: channels})
+}
+
+// ChannelList is a table of recorded channels
+type ChannelList struct {
+	Channels []*Channel `json:"channels"`
+}
+
+func (l *ChannelList) Headers() []string {
+	return []string{"Channel ID", "Kind", "Target", "Address", "Expires", "Status"}
+}
+
+func (l *ChannelList) Rows() [][]string {
+	rows := make([][]string, len(l.Channels))
+	for i, c := range l.Channels {
+		target := c.FileID
+		if c.Kind == KindChanges {
+			target = "changes"
+			if c.DriveID != "" {
+				target += " (" + c.DriveID + ")"
+			}
+		}
+		expires := ""
+		if !c.Expiration.IsZero() {
+			expires = types.DisplayTime(c.Expiration.Format(time.RFC3339))
+		}
+		rows[i] = []string{c.ID, c.Kind, target, c.Address, expires, c.Status}
+	}
+	return rows
+}
+
+func (l *ChannelList) EmptyMessage() string {
+	return "No notification channels"
+}
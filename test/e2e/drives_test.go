@@ -0,0 +1,44 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/api"
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/testenv"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestSharedDrive_UploadAndList(t *testing.T) {
+	sb := testenv.New(t)
+	sb.RequireSharedDrive(t)
+	ctx := context.Background()
+	mgr := files.NewManager(sb.Client)
+
+	local := filepath.Join(t.TempDir(), "team.txt")
+	if err := os.WriteFile(local, []byte("team"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reqCtx := api.NewRequestContext("testenv", sb.DriveID, types.RequestTypeMutation)
+	file, err := mgr.Upload(ctx, reqCtx, local, files.UploadOptions{ParentID: sb.DriveID, Name: sb.Name("team.txt")})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	listCtx := api.NewRequestContext("testenv", sb.DriveID, types.RequestTypeListOrSearch)
+	result, err := mgr.List(ctx, listCtx, files.ListOptions{ParentID: sb.DriveID})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, f := range result.Files {
+		if f.ID == file.ID {
+			return
+		}
+	}
+	t.Errorf("uploaded file %s not listed in the Shared Drive", file.ID)
+}
@@ -0,0 +1,140 @@
+//go:build integration
+
+// Package e2e runs end-to-end tests against the real Drive API in
+// disposable sandboxes (see internal/testenv). Run with "make integration".
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/testenv"
+	"github.com/dl-alexandre/gdrv/internal/types"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestFiles_UploadDownloadRoundTrip(t *testing.T) {
+	sb := testenv.New(t)
+	ctx := context.Background()
+	mgr := files.NewManager(sb.Client)
+
+	content := []byte("end-to-end " + sb.Prefix)
+	local := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(local, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	uploaded, err := mgr.Upload(ctx, sb.RequestContext(types.RequestTypeMutation), local, files.UploadOptions{
+		ParentID: sb.FolderID,
+		Name:     sb.Name("hello.txt"),
+	})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if uploaded.Size != int64(len(content)) {
+		t.Errorf("uploaded size = %d, want %d", uploaded.Size, len(content))
+	}
+
+	out := filepath.Join(t.TempDir(), "downloaded.txt")
+	if err := mgr.Download(ctx, sb.RequestContext(types.RequestTypeDownloadOrExport), uploaded.ID, files.DownloadOptions{OutputPath: out}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %q, want %q", got, content)
+	}
+}
+
+func TestFiles_UpdateCopyTrashRestore(t *testing.T) {
+	sb := testenv.New(t)
+	ctx := context.Background()
+	mgr := files.NewManager(sb.Client)
+
+	local := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(local, []byte("notes"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := mgr.Upload(ctx, sb.RequestContext(types.RequestTypeMutation), local, files.UploadOptions{ParentID: sb.FolderID})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	renamed := sb.Name("renamed.txt")
+	updated, err := mgr.Update(ctx, sb.RequestContext(types.RequestTypeMutation), file.ID,
+		&drive.File{Name: renamed, Description: "e2e"}, "id,name,description")
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Name != renamed {
+		t.Errorf("updated name = %q, want %q", updated.Name, renamed)
+	}
+
+	copied, err := mgr.Copy(ctx, sb.RequestContext(types.RequestTypeMutation), file.ID, sb.Name("copy.txt"), sb.FolderID)
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if copied.ID == file.ID {
+		t.Error("copy returned the original file")
+	}
+
+	trashed, err := mgr.Trash(ctx, sb.RequestContext(types.RequestTypeMutation), copied.ID)
+	if err != nil {
+		t.Fatalf("trash: %v", err)
+	}
+	if !trashed.Trashed {
+		t.Error("trashed file is not marked trashed")
+	}
+	restored, err := mgr.Restore(ctx, sb.RequestContext(types.RequestTypeMutation), copied.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.Trashed {
+		t.Error("restored file is still trashed")
+	}
+}
+
+func TestFiles_UploadTree(t *testing.T) {
+	sb := testenv.New(t)
+	ctx := context.Background()
+	mgr := files.NewManager(sb.Client)
+
+	dir := filepath.Join(t.TempDir(), "tree")
+	for name, content := range map[string]string{"a.txt": "a", "sub/b.txt": "bb", "sub/skip.tmp": "x"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := files.UploadTreeOptions{ParentID: sb.FolderID, Exclude: []string{"*.tmp"}}
+	plan, err := files.PlanUploadTree(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := mgr.UploadTree(ctx, sb.RequestContext(types.RequestTypeMutation), plan, opts)
+	if err != nil {
+		t.Fatalf("upload tree: %v", err)
+	}
+	if result.Uploaded != 2 || result.Failed != 0 || result.FoldersCreated != 2 {
+		t.Errorf("result = %+v", result)
+	}
+
+	// A second run reuses the folders it created
+	again, err := mgr.UploadTree(ctx, sb.RequestContext(types.RequestTypeMutation), plan, opts)
+	if err != nil {
+		t.Fatalf("second upload tree: %v", err)
+	}
+	if again.FolderID != result.FolderID || again.FoldersReused != 2 {
+		t.Errorf("second run = %+v", again)
+	}
+}
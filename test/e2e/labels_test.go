@@ -0,0 +1,58 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/files"
+	"github.com/dl-alexandre/gdrv/internal/labels"
+	"github.com/dl-alexandre/gdrv/internal/testenv"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestLabels_ApplyAndRemove(t *testing.T) {
+	sb := testenv.New(t)
+	labelID := sb.RequireLabel(t)
+	ctx := context.Background()
+
+	local := filepath.Join(t.TempDir(), "labeled.txt")
+	if err := os.WriteFile(local, []byte("labeled"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := files.NewManager(sb.Client).Upload(ctx, sb.RequestContext(types.RequestTypeMutation), local, files.UploadOptions{ParentID: sb.FolderID})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	mgr := labels.NewManager(sb.Client)
+	if _, err := mgr.ApplyLabel(ctx, sb.RequestContext(types.RequestTypeMutation), file.ID, labelID, types.FileLabelApplyOptions{}); err != nil {
+		t.Fatalf("apply label: %v", err)
+	}
+	hasLabel := func() bool {
+		t.Helper()
+		applied, err := mgr.ListFileLabels(ctx, sb.RequestContext(types.RequestTypeListOrSearch), file.ID, types.FileLabelListOptions{})
+		if err != nil {
+			t.Fatalf("list file labels: %v", err)
+		}
+		for _, l := range applied {
+			if l.ID == labelID {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasLabel() {
+		t.Fatalf("label %s not applied", labelID)
+	}
+
+	if err := mgr.RemoveLabel(ctx, sb.RequestContext(types.RequestTypeMutation), file.ID, labelID); err != nil {
+		t.Fatalf("remove label: %v", err)
+	}
+	if hasLabel() {
+		t.Errorf("label %s still applied after removal", labelID)
+	}
+}
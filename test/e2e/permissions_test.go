@@ -0,0 +1,66 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dl-alexandre/gdrv/internal/folders"
+	"github.com/dl-alexandre/gdrv/internal/permissions"
+	"github.com/dl-alexandre/gdrv/internal/testenv"
+	"github.com/dl-alexandre/gdrv/internal/types"
+)
+
+func TestPermissions_GrantUpdateRevoke(t *testing.T) {
+	sb := testenv.New(t)
+	grantee := sb.RequireGrantee(t)
+	ctx := context.Background()
+
+	folder, err := folders.NewManager(sb.Client).Create(ctx, sb.RequestContext(types.RequestTypeMutation), sb.Name("shared"), sb.FolderID)
+	if err != nil {
+		t.Fatalf("create folder: %v", err)
+	}
+
+	mgr := permissions.NewManager(sb.Client)
+	perm, err := mgr.Create(ctx, sb.RequestContext(types.RequestTypePermissionOp), folder.ID, permissions.CreateOptions{
+		Type:         "user",
+		Role:         "reader",
+		EmailAddress: grantee,
+	})
+	if err != nil {
+		t.Fatalf("create permission: %v", err)
+	}
+
+	find := func() *types.Permission {
+		t.Helper()
+		perms, err := mgr.List(ctx, sb.RequestContext(types.RequestTypeListOrSearch), folder.ID, permissions.ListOptions{})
+		if err != nil {
+			t.Fatalf("list permissions: %v", err)
+		}
+		for _, p := range perms {
+			if p.ID == perm.ID {
+				return p
+			}
+		}
+		return nil
+	}
+	if p := find(); p == nil || !strings.EqualFold(p.EmailAddress, grantee) || p.Role != "reader" {
+		t.Fatalf("granted permission = %+v", p)
+	}
+
+	if _, err := mgr.Update(ctx, sb.RequestContext(types.RequestTypePermissionOp), folder.ID, perm.ID, permissions.UpdateOptions{Role: "writer"}); err != nil {
+		t.Fatalf("update permission: %v", err)
+	}
+	if p := find(); p == nil || p.Role != "writer" {
+		t.Errorf("updated permission = %+v", p)
+	}
+
+	if err := mgr.Delete(ctx, sb.RequestContext(types.RequestTypePermissionOp), folder.ID, perm.ID, permissions.DeleteOptions{}); err != nil {
+		t.Fatalf("delete permission: %v", err)
+	}
+	if p := find(); p != nil {
+		t.Errorf("permission still present after delete: %+v", p)
+	}
+}